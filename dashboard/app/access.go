@@ -76,6 +76,8 @@ func checkTextAccess(c context.Context, r *http.Request, tag string, id int64) (
 		return nil, checkJobTextAccess(c, r, "Log", id)
 	case textError:
 		return nil, checkJobTextAccess(c, r, "Error", id)
+	case textBisectResult:
+		return nil, checkJobTextAccess(c, r, "BisectResult", id)
 	case textKernelConfig:
 		// This is checked based on text namespace.
 		return nil, nil
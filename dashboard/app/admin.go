@@ -41,6 +41,7 @@ func dropNamespace(c context.Context, w http.ResponseWriter, r *http.Request) er
 		{"Job", ""},
 		{textLog, ""},
 		{textError, ""},
+		{textBisectResult, ""},
 		{textCrashLog, ""},
 		{textCrashReport, ""},
 		{"Build", ""},
@@ -49,6 +49,8 @@ var apiNamespaceHandlers = map[string]APINamespaceHandler{
 	"manager_stats":       apiManagerStats,
 	"commit_poll":         apiCommitPoll,
 	"upload_commits":      apiUploadCommits,
+	"asset_upload":        apiAssetUpload,
+	"asset_done":          apiAssetDone,
 }
 
 type JSONHandler func(c context.Context, r *http.Request) (interface{}, error)
@@ -481,6 +483,7 @@ func uploadBuild(c context.Context, now time.Time, ns string, req *dashapi.Build
 		KernelCommitTitle:   req.KernelCommitTitle,
 		KernelCommitDate:    req.KernelCommitDate,
 		KernelConfig:        configID,
+		Assets:              makeAssetRefs(req.Assets),
 	}
 	if _, err := db.Put(c, buildKey(c, ns, req.ID), build); err != nil {
 		return nil, false, err
@@ -699,6 +702,7 @@ func apiReportCrash(c context.Context, ns string, r *http.Request, payload []byt
 func reportCrash(c context.Context, build *Build, req *dashapi.Crash) (*Bug, error) {
 	req.Title = limitLength(req.Title, maxTextLen)
 	req.Maintainers = email.MergeEmailLists(req.Maintainers)
+	req.SubsystemCC = email.MergeEmailLists(req.SubsystemCC)
 	if req.Corrupted {
 		// The report is corrupted and the title is most likely invalid.
 		// Such reports are usually unactionable and are discarded.
@@ -762,6 +766,9 @@ func reportCrash(c context.Context, build *Build, req *dashapi.Crash) (*Bug, err
 		if !stringInList(bug.HappenedOn, build.Manager) {
 			bug.HappenedOn = append(bug.HappenedOn, build.Manager)
 		}
+		if len(req.Subsystems) != 0 {
+			bug.Subsystems = req.Subsystems
+		}
 		if _, err = db.Put(c, bugKey, bug); err != nil {
 			return fmt.Errorf("failed to put bug: %v", err)
 		}
@@ -792,6 +799,9 @@ func saveCrash(c context.Context, ns string, req *dashapi.Crash, bugKey *db.Key,
 		BuildID:     req.BuildID,
 		Time:        timeNow(c),
 		Maintainers: req.Maintainers,
+		Subsystems:  req.Subsystems,
+		SubsystemCC: req.SubsystemCC,
+		Assets:      makeAssetRefs(req.Assets),
 		ReproOpts:   req.ReproOpts,
 		ReportLen:   prio,
 	}
@@ -33,6 +33,7 @@ var testConfig = &GlobalConfig{
 		"\"Bar\" <BlackListed@Domain.com>",
 	},
 	DefaultNamespace: "test1",
+	AssetBucket:      "syzkaller-assets",
 	Namespaces: map[string]*Config{
 		"test1": {
 			AccessLevel: AccessAdmin,
@@ -40,6 +41,9 @@ var testConfig = &GlobalConfig{
 			Clients: map[string]string{
 				client1: key1,
 			},
+			APIClients: map[string]APIClient{
+				clientAPI: {Key: keyAPI},
+			},
 			Repos: []KernelRepo{
 				{
 					URL:    "git://syzkaller.org",
@@ -53,6 +57,13 @@ var testConfig = &GlobalConfig{
 					Alias:  "repo10alias",
 					CC:     []string{"maintainers@repo10.org", "bugs@repo10.org"},
 				},
+				{
+					URL:              "git://syzkaller.org/subsystems.git",
+					Branch:           "master",
+					Alias:            "subsystems",
+					CC:               []string{"bugs@subsystems.org"},
+					SubsystemRouting: true,
+				},
 			},
 			Reporting: []Reporting{
 				{
@@ -213,6 +224,8 @@ const (
 	keyUser      = "clientuserkeyclientuserkey"
 	clientPublic = "client-public"
 	keyPublic    = "clientpublickeyclientpublickey"
+	clientAPI    = "client-api"
+	keyAPI       = "clientapikeyclientapikey"
 )
 
 func skipWithRepro(bug *Bug) FilterResult {
@@ -0,0 +1,241 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dash
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	db "google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+// This file contains storage for large crash assets (see dashapi.UploadAsset for the protocol).
+// Asset contents are stored in GlobalConfig.AssetBucket GCS bucket as <namespace>/<sha256>,
+// the dashboard only keeps asset metadata and hands out signed GCS URLs for upload and download.
+
+// Asset holds metadata of an uploaded asset, keyed by namespace and hash (see assetKey).
+type Asset struct {
+	Namespace string
+	Hash      string
+	Type      dashapi.AssetType
+	Size      int64
+	Created   time.Time
+	Uploaded  time.Time // zero until the client confirms the upload
+}
+
+// AssetRef is a reference to an asset from a crash, build or job.
+type AssetRef struct {
+	Type dashapi.AssetType
+	Name string
+	Hash string
+	Size int64
+}
+
+const (
+	maxAssetSize           = 64 << 30
+	maxAssetRefs           = 10
+	assetUploadExpiration  = 6 * time.Hour
+	assetUIExpiration      = 10 * time.Minute
+	defaultAssetExpiration = 30 * 24 * time.Hour
+)
+
+var assetHashRe = regexp.MustCompile("^[0-9a-f]{64}$")
+
+var assetTitles = map[dashapi.AssetType]string{
+	dashapi.AssetDiskImage:   "disk image",
+	dashapi.AssetKernel:      "kernel image",
+	dashapi.AssetMemoryDump:  "memory dump",
+	dashapi.AssetBisectLog:   "bisection log",
+	dashapi.AssetDiagnostics: "memory diagnostics",
+}
+
+func assetKey(c context.Context, ns, hash string) *db.Key {
+	return db.NewKey(c, "Asset", ns+"-"+hash, 0, nil)
+}
+
+func apiAssetUpload(c context.Context, ns string, r *http.Request, payload []byte) (interface{}, error) {
+	req := new(dashapi.AssetUploadReq)
+	if err := json.Unmarshal(payload, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %v", err)
+	}
+	if config.AssetBucket == "" {
+		return nil, fmt.Errorf("asset storage is not configured")
+	}
+	if !assetHashRe.MatchString(req.Hash) {
+		return nil, fmt.Errorf("bad asset hash %q", req.Hash)
+	}
+	if assetTitles[req.Type] == "" {
+		return nil, fmt.Errorf("unknown asset type %q", req.Type)
+	}
+	if req.Size <= 0 || req.Size > maxAssetSize {
+		return nil, fmt.Errorf("bad asset size %v", req.Size)
+	}
+	asset := new(Asset)
+	key := assetKey(c, ns, req.Hash)
+	if err := db.Get(c, key, asset); err != nil && err != db.ErrNoSuchEntity {
+		return nil, fmt.Errorf("failed to get asset: %v", err)
+	}
+	if !asset.Uploaded.IsZero() {
+		return &dashapi.AssetUploadResp{Exists: true}, nil
+	}
+	if asset.Hash == "" {
+		asset = &Asset{
+			Namespace: ns,
+			Hash:      req.Hash,
+			Type:      req.Type,
+			Size:      req.Size,
+			Created:   timeNow(c),
+		}
+		if _, err := db.Put(c, key, asset); err != nil {
+			return nil, fmt.Errorf("failed to put asset: %v", err)
+		}
+	}
+	link, err := signAssetURL(c, http.MethodPut, assetObject(ns, req.Hash), timeNow(c).Add(assetUploadExpiration))
+	if err != nil {
+		return nil, err
+	}
+	return &dashapi.AssetUploadResp{UploadURL: link}, nil
+}
+
+func apiAssetDone(c context.Context, ns string, r *http.Request, payload []byte) (interface{}, error) {
+	req := new(dashapi.AssetDoneReq)
+	if err := json.Unmarshal(payload, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %v", err)
+	}
+	key := assetKey(c, ns, req.Hash)
+	tx := func(c context.Context) error {
+		asset := new(Asset)
+		if err := db.Get(c, key, asset); err != nil {
+			return fmt.Errorf("failed to get asset %v: %v", req.Hash, err)
+		}
+		if !asset.Uploaded.IsZero() {
+			return nil
+		}
+		asset.Uploaded = timeNow(c)
+		if _, err := db.Put(c, key, asset); err != nil {
+			return fmt.Errorf("failed to put asset: %v", err)
+		}
+		return nil
+	}
+	return nil, db.RunInTransaction(c, tx, nil)
+}
+
+// makeAssetRefs converts client-provided asset references for storing in entities.
+// References are checked only when links are created (see assetLinks),
+// so that the check does not need to be part of transactions.
+func makeAssetRefs(assets []dashapi.Asset) []AssetRef {
+	var res []AssetRef
+	for _, asset := range assets {
+		if len(res) == maxAssetRefs {
+			break
+		}
+		res = append(res, AssetRef{
+			Type: asset.Type,
+			Name: limitLength(asset.Name, maxTextLen),
+			Hash: asset.Hash,
+			Size: asset.Size,
+		})
+	}
+	return res
+}
+
+// assetLinks returns signed download links to the assets that were actually uploaded.
+func assetLinks(c context.Context, ns string, refs []AssetRef, expires time.Time) []dashapi.AssetLink {
+	if len(refs) == 0 || config.AssetBucket == "" {
+		return nil
+	}
+	keys := make([]*db.Key, len(refs))
+	for i, ref := range refs {
+		keys[i] = assetKey(c, ns, ref.Hash)
+	}
+	assets := make([]*Asset, len(refs))
+	if err := db.GetMulti(c, keys, assets); err != nil {
+		if _, ok := err.(appengine.MultiError); !ok {
+			log.Errorf(c, "failed to get assets: %v", err)
+			return nil
+		}
+	}
+	var res []dashapi.AssetLink
+	for i, ref := range refs {
+		if assets[i] == nil || assets[i].Uploaded.IsZero() {
+			continue
+		}
+		link, err := signAssetURL(c, http.MethodGet, assetObject(ns, ref.Hash), expires)
+		if err != nil {
+			log.Errorf(c, "%v", err)
+			continue
+		}
+		res = append(res, dashapi.AssetLink{
+			Type:    ref.Type,
+			Title:   assetTitles[ref.Type],
+			Name:    ref.Name,
+			Size:    assets[i].Size,
+			Link:    link,
+			Expires: expires,
+		})
+	}
+	return res
+}
+
+// reportAssetLinks returns links to the assets for bug reports and notifications.
+func reportAssetLinks(c context.Context, ns string, refs ...[]AssetRef) []dashapi.AssetLink {
+	var all []AssetRef
+	for _, r := range refs {
+		all = append(all, r...)
+	}
+	return assetLinks(c, ns, all, timeNow(c).Add(config.AssetLinkExpiration))
+}
+
+// handleAsset redirects to a short-lived download link to the asset (used in web UI).
+func handleAsset(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	ns := r.FormValue("ns")
+	cfg := config.Namespaces[ns]
+	if cfg == nil {
+		return ErrDontLog(fmt.Errorf("unknown namespace %q", ns))
+	}
+	if err := checkAccessLevel(c, r, cfg.AccessLevel); err != nil {
+		return err
+	}
+	ref := AssetRef{Hash: r.FormValue("h")}
+	links := assetLinks(c, ns, []AssetRef{ref}, timeNow(c).Add(assetUIExpiration))
+	if len(links) == 0 {
+		return ErrDontLog(fmt.Errorf("unknown asset %q", ref.Hash))
+	}
+	return ErrRedirect(fmt.Errorf("%v", links[0].Link))
+}
+
+func assetUILink(ns string, ref AssetRef) string {
+	return fmt.Sprintf("/asset?ns=%v&h=%v", url.QueryEscape(ns), ref.Hash)
+}
+
+func assetObject(ns, hash string) string {
+	return ns + "/" + hash
+}
+
+// signAssetURL returns GCS signed URL (V2 signing) for the object in the asset bucket.
+// Overridable for testing.
+var signAssetURL = func(c context.Context, method, object string, expires time.Time) (string, error) {
+	account, err := appengine.ServiceAccount(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to get service account: %v", err)
+	}
+	path := "/" + config.AssetBucket + "/" + object
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	_, sig, err := appengine.SignBytes(c, []byte(method+"\n\n\n"+exp+"\n"+path))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign asset URL: %v", err)
+	}
+	return fmt.Sprintf("https://storage.googleapis.com%v?GoogleAccessId=%v&Expires=%v&Signature=%v",
+		path, url.QueryEscape(account), exp, url.QueryEscape(base64.StdEncoding.EncodeToString(sig))), nil
+}
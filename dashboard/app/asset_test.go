@@ -0,0 +1,87 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build aetest
+
+package dash
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+)
+
+func TestAssets(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+
+	dump := dashapi.Asset{
+		Type: dashapi.AssetMemoryDump,
+		Name: "vmcore",
+		Hash: strings.Repeat("ab", 32),
+		Size: 1 << 30,
+	}
+	req := &dashapi.AssetUploadReq{
+		Type: dump.Type,
+		Hash: dump.Hash,
+		Size: dump.Size,
+	}
+	resp := new(dashapi.AssetUploadResp)
+	c.expectOK(c.client.Query("asset_upload", req, resp))
+	c.expectEQ(resp.Exists, false)
+	c.expectEQ(resp.UploadURL, fmt.Sprintf("https://storage.googleapis.com/syzkaller-assets/test1/%v"+
+		"?method=PUT&expires=%v", dump.Hash, c.mockedTime.Add(assetUploadExpiration).Unix()))
+
+	// Not uploaded assets are not linked in reports.
+	crash1 := testCrash(build, 1)
+	crash1.Assets = []dashapi.Asset{dump}
+	c.client.ReportCrash(crash1)
+	rep := c.client.pollBug()
+	c.expectEQ(len(rep.Assets), 0)
+	c.client.updateBug(rep.ID, dashapi.BugStatusUpstream, "")
+
+	c.expectOK(c.client.Query("asset_done", &dashapi.AssetDoneReq{Hash: dump.Hash}, nil))
+	c.expectOK(c.client.Query("asset_upload", req, resp))
+	c.expectEQ(resp.Exists, true)
+	c.expectEQ(resp.UploadURL, "")
+
+	// The asset is namespace-local.
+	resp2 := new(dashapi.AssetUploadResp)
+	c.expectOK(c.client2.Query("asset_upload", req, resp2))
+	c.expectEQ(resp2.Exists, false)
+
+	rep = c.client.pollBug()
+	expires := c.mockedTime.Add(defaultAssetExpiration)
+	c.expectEQ(rep.Assets, []dashapi.AssetLink{{
+		Type:  dashapi.AssetMemoryDump,
+		Title: "memory dump",
+		Name:  "vmcore",
+		Size:  1 << 30,
+		Link: fmt.Sprintf("https://storage.googleapis.com/syzkaller-assets/test1/%v"+
+			"?method=GET&expires=%v", dump.Hash, expires.Unix()),
+		Expires: expires,
+	}})
+
+	// Web UI redirects to a short-lived link.
+	_, err := c.AuthGET(AccessAdmin, fmt.Sprintf("/asset?ns=test1&h=%v", dump.Hash))
+	httpErr, ok := err.(HttpError)
+	c.expectTrue(ok)
+	c.expectEQ(httpErr.Code, http.StatusFound)
+	c.expectEQ(httpErr.Headers.Get("Location"), fmt.Sprintf("https://storage.googleapis.com/syzkaller-assets/"+
+		"test1/%v?method=GET&expires=%v", dump.Hash, c.mockedTime.Add(assetUIExpiration).Unix()))
+	_, err = c.AuthGET(AccessUser, fmt.Sprintf("/asset?ns=test1&h=%v", dump.Hash))
+	c.expectForbidden(err)
+
+	// Bad requests.
+	req.Hash = "foo"
+	c.expectFail("bad asset hash", c.client.Query("asset_upload", req, resp))
+	req.Hash = dump.Hash
+	req.Type = "foo"
+	c.expectFail("unknown asset type", c.client.Query("asset_upload", req, resp))
+}
@@ -29,6 +29,11 @@ type GlobalConfig struct {
 	// Dashboard will append manager_name.html to that prefix.
 	// syz-ci can upload these reports to GCS.
 	CoverPath string
+	// GCS bucket for large crash assets (disk images, memory dumps, etc), see dashapi.UploadAsset.
+	// Asset uploads are rejected if not set. The app service account needs write access to the bucket.
+	AssetBucket string
+	// How long asset download links in bug reports stay valid (30 days by default).
+	AssetLinkExpiration time.Duration
 	// Global API clients that work across namespaces (e.g. external reporting).
 	Clients map[string]string
 	// List of emails blacklisted from issuing test requests.
@@ -54,6 +59,8 @@ type Config struct {
 	SimilarityDomain string
 	// Per-namespace clients that act only on a particular namespace.
 	Clients map[string]string
+	// Clients of the read-only JSON API (e.g. external bug trackers), see dashapi.PublicBug.
+	APIClients map[string]APIClient
 	// A unique key for hashing, can be anything.
 	Key string
 	// Mail bugs without reports (e.g. "no output").
@@ -78,6 +85,14 @@ type Config struct {
 	Repos []KernelRepo
 }
 
+// APIClient is a client of the read-only JSON API.
+type APIClient struct {
+	Key string
+	// Bugs and texts are returned only if they are visible on this access level
+	// (defaults to the namespace access level).
+	AccessLevel AccessLevel
+}
+
 // ConfigManager describes a single syz-manager instance.
 // Dashboard does not generally need to know about all of them,
 // but in some special cases it needs to know some additional information.
@@ -132,6 +147,11 @@ type KernelRepo struct {
 	ReportingPriority int
 	// Additional CC list to add to all bugs reported on this repo.
 	CC []string
+	// SubsystemRouting says to mail bugs to the lists/maintainers of the subsystems the crash
+	// is attributed to based on the MAINTAINERS file of the tree (see pkg/subsystem),
+	// instead of get_maintainer.pl output for the guilty file.
+	// Crashes that can't be classified still use get_maintainer.pl output.
+	SubsystemRouting bool
 }
 
 var (
@@ -181,6 +201,7 @@ func installConfig(cfg *GlobalConfig) {
 	initEmailReporting()
 	initHTTPHandlers()
 	initAPIHandlers()
+	initPublicAPIHandlers()
 }
 
 func checkConfig(cfg *GlobalConfig) {
@@ -194,6 +215,9 @@ func checkConfig(cfg *GlobalConfig) {
 	clientNames := make(map[string]bool)
 	checkClients(clientNames, cfg.Clients)
 	checkConfigAccessLevel(&cfg.AccessLevel, AccessPublic, "global")
+	if cfg.AssetLinkExpiration == 0 {
+		cfg.AssetLinkExpiration = defaultAssetExpiration
+	}
 	if cfg.Namespaces[cfg.DefaultNamespace] == nil {
 		panic(fmt.Sprintf("default namespace %q is not found", cfg.DefaultNamespace))
 	}
@@ -238,6 +262,11 @@ func checkNamespace(ns string, cfg *Config, namespaces, clientNames map[string]b
 	}
 	checkKernelRepos(ns, cfg)
 	checkNamespaceReporting(ns, cfg)
+	for name, client := range cfg.APIClients {
+		checkClients(clientNames, map[string]string{name: client.Key})
+		checkConfigAccessLevel(&client.AccessLevel, cfg.AccessLevel, fmt.Sprintf("api client %v", name))
+		cfg.APIClients[name] = client
+	}
 }
 
 func checkKernelRepos(ns string, cfg *Config) {
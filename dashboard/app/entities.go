@@ -61,9 +61,10 @@ type Build struct {
 	KernelRepo          string
 	KernelBranch        string
 	KernelCommit        string
-	KernelCommitTitle   string    `datastore:",noindex"`
-	KernelCommitDate    time.Time `datastore:",noindex"`
-	KernelConfig        int64     // reference to KernelConfig text entity
+	KernelCommitTitle   string     `datastore:",noindex"`
+	KernelCommitDate    time.Time  `datastore:",noindex"`
+	KernelConfig        int64      // reference to KernelConfig text entity
+	Assets              []AssetRef `datastore:",noindex"`
 }
 
 type Bug struct {
@@ -92,6 +93,7 @@ type Bug struct {
 	HappenedOn     []string `datastore:",noindex"` // list of managers
 	PatchedOn      []string `datastore:",noindex"` // list of managers
 	UNCC           []string // don't CC these emails on this bug
+	Subsystems     []string // MAINTAINERS entries the bug is attributed to (see pkg/subsystem)
 }
 
 type Commit struct {
@@ -121,13 +123,16 @@ type Crash struct {
 	Manager     string
 	BuildID     string
 	Time        time.Time
-	Reported    time.Time // set if this crash was ever reported
-	Maintainers []string  `datastore:",noindex"`
-	Log         int64     // reference to CrashLog text entity
-	Report      int64     // reference to CrashReport text entity
-	ReproOpts   []byte    `datastore:",noindex"`
-	ReproSyz    int64     // reference to ReproSyz text entity
-	ReproC      int64     // reference to ReproC text entity
+	Reported    time.Time  // set if this crash was ever reported
+	Maintainers []string   `datastore:",noindex"`
+	Subsystems  []string   `datastore:",noindex"`
+	SubsystemCC []string   `datastore:",noindex"` // recipients of Subsystems
+	Assets      []AssetRef `datastore:",noindex"`
+	Log         int64      // reference to CrashLog text entity
+	Report      int64      // reference to CrashReport text entity
+	ReproOpts   []byte     `datastore:",noindex"`
+	ReproSyz    int64      // reference to ReproSyz text entity
+	ReproC      int64      // reference to ReproC text entity
 	// Custom crash priority for reporting (greater values are higher priority).
 	// For example, a crash in mainline kernel has higher priority than a crash in a side branch.
 	// For historical reasons this is called ReportLen.
@@ -183,6 +188,9 @@ type Job struct {
 	BuildID     string
 	Log         int64 // reference to Log text entity
 	Error       int64 // reference to Error text entity, if set job failed
+	// Reference to BisectResult text entity (machine-readable bisection log).
+	BisectResult int64
+	Assets       []AssetRef `datastore:",noindex"`
 
 	Reported bool // have we reported result back to user?
 }
@@ -210,6 +218,7 @@ const (
 	textPatch        = "Patch"
 	textLog          = "Log"
 	textError        = "Error"
+	textBisectResult = "BisectResult"
 )
 
 const (
@@ -74,6 +74,12 @@ func TestFixBasic(t *testing.T) {
 	builderPollResp, _ = c.client.BuilderPoll(build1.Manager)
 	c.expectEQ(len(builderPollResp.PendingCommits), 0)
 
+	// External reportings learn that the bug is closed as fixed.
+	closed := new(dashapi.PollClosedResponse)
+	c.expectOK(c.client.Query("reporting_poll_closed", &dashapi.PollClosedRequest{IDs: []string{rep.ID}}, closed))
+	c.expectEQ(closed.IDs, []string{rep.ID})
+	c.expectEQ(closed.Fixed, []string{rep.ID})
+
 	// Ensure that a new crash creates a new bug (the old one must be marked as fixed).
 	c.client.ReportCrash(crash1)
 	rep2 := c.client.pollBug()
@@ -420,6 +420,10 @@ func createJobResp(c context.Context, job *Job, jobKey *db.Key) (*dashapi.JobPol
 }
 
 // doneJob is called by syz-ci to mark completion of a job.
+// Jobs that fail due to infrastructure problems (see JobDoneReq.InfraFailure)
+// are retried up to this number of attempts.
+const maxJobInfraAttempts = 3
+
 func doneJob(c context.Context, req *dashapi.JobDoneReq) error {
 	jobID := req.ID
 	jobKey, err := jobID2Key(c, req.ID)
@@ -435,6 +439,16 @@ func doneJob(c context.Context, req *dashapi.JobDoneReq) error {
 		if !job.Finished.IsZero() {
 			return fmt.Errorf("job %v: already finished", jobID)
 		}
+		if req.InfraFailure && job.Attempts < maxJobInfraAttempts {
+			// Leave the job pending, it will be retried later
+			// (jobs with fewer attempts are polled first).
+			log.Infof(c, "job %v: infrastructure failure on attempt %v: %s", jobID, job.Attempts, req.Error)
+			job.Started = time.Time{}
+			if _, err := db.Put(c, jobKey, job); err != nil {
+				return fmt.Errorf("failed to put job: %v", err)
+			}
+			return nil
+		}
 		ns := job.Namespace
 		if req.Build.ID != "" {
 			if _, isNewBuild, err := uploadBuild(c, now, ns, &req.Build, BuildJob); err != nil {
@@ -449,12 +463,16 @@ func doneJob(c context.Context, req *dashapi.JobDoneReq) error {
 		if job.Error, err = putText(c, ns, textError, req.Error, false); err != nil {
 			return err
 		}
+		if job.BisectResult, err = putText(c, ns, textBisectResult, req.BisectResult, false); err != nil {
+			return err
+		}
 		if job.CrashLog, err = putText(c, ns, textCrashLog, req.CrashLog, false); err != nil {
 			return err
 		}
 		if job.CrashReport, err = putText(c, ns, textCrashReport, req.CrashReport, false); err != nil {
 			return err
 		}
+		job.Assets = makeAssetRefs(req.Assets)
 		for _, com := range req.Commits {
 			job.Commits = append(job.Commits, Commit{
 				Hash:       com.Hash,
@@ -613,10 +631,11 @@ func createBugReportForJob(c context.Context, job *Job, jobKey *db.Key, config i
 		Error:        jobError,
 		ErrorLink:    externalLink(c, textError, job.Error),
 		PatchLink:    externalLink(c, textPatch, job.Patch),
+		Assets:       reportAssetLinks(c, job.Namespace, job.Assets),
 	}
 	if job.Type == JobBisectCause || job.Type == JobBisectFix {
 		kernelRepo := kernelRepoInfo(build)
-		rep.Maintainers = append(crash.Maintainers, kernelRepo.CC...)
+		rep.Maintainers = crashMaintainers(crash, kernelRepo)
 		rep.ExtID = bugReporting.ExtID
 		if bugReporting.CC != "" {
 			rep.CC = strings.Split(bugReporting.CC, "|")
@@ -28,6 +28,7 @@ func initHTTPHandlers() {
 	http.Handle("/bug", handlerWrapper(handleBug))
 	http.Handle("/text", handlerWrapper(handleText))
 	http.Handle("/admin", handlerWrapper(handleAdmin))
+	http.Handle("/asset", handlerWrapper(handleAsset))
 	http.Handle("/x/.config", handlerWrapper(handleTextX(textKernelConfig)))
 	http.Handle("/x/log.txt", handlerWrapper(handleTextX(textCrashLog)))
 	http.Handle("/x/report.txt", handlerWrapper(handleTextX(textCrashReport)))
@@ -36,10 +37,12 @@ func initHTTPHandlers() {
 	http.Handle("/x/patch.diff", handlerWrapper(handleTextX(textPatch)))
 	http.Handle("/x/bisect.txt", handlerWrapper(handleTextX(textLog)))
 	http.Handle("/x/error.txt", handlerWrapper(handleTextX(textError)))
+	http.Handle("/x/bisect.json", handlerWrapper(handleTextX(textBisectResult)))
 	for ns := range config.Namespaces {
 		http.Handle("/"+ns, handlerWrapper(handleMain))
 		http.Handle("/"+ns+"/fixed", handlerWrapper(handleFixed))
 		http.Handle("/"+ns+"/invalid", handlerWrapper(handleInvalid))
+		http.Handle("/"+ns+"/subsystems", handlerWrapper(handleSubsystems))
 	}
 }
 
@@ -154,6 +157,11 @@ type uiBug struct {
 	NumManagers    int
 }
 
+type uiAsset struct {
+	Title string
+	Link  string
+}
+
 type uiCrash struct {
 	Manager      string
 	Time         time.Time
@@ -162,6 +170,7 @@ type uiCrash struct {
 	ReportLink   string
 	ReproSyzLink string
 	ReproCLink   string
+	Assets       []*uiAsset
 	*uiBuild
 }
 
@@ -820,6 +829,12 @@ func makeUICrash(crash *Crash, build *Build) *uiCrash {
 	}
 	if build != nil {
 		ui.uiBuild = makeUIBuild(build)
+		for _, ref := range append(append([]AssetRef{}, crash.Assets...), build.Assets...) {
+			ui.Assets = append(ui.Assets, &uiAsset{
+				Title: assetTitles[ref.Type],
+				Link:  assetUILink(build.Namespace, ref),
+			})
+		}
 	}
 	return ui
 }
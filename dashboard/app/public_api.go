@@ -0,0 +1,337 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dash
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	db "google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+// This file contains read-only JSON API for external bug trackers (see dashapi.PublicBug for details).
+
+type PublicAPIHandler func(c context.Context, ns string, access AccessLevel, r *http.Request) (interface{}, error)
+
+func initPublicAPIHandlers() {
+	for ns := range config.Namespaces {
+		prefix := "/api/v1/" + ns
+		http.Handle(prefix+"/bugs", handleJSON(handlePublicAPI(ns, apiPublicBugs)))
+		http.Handle(prefix+"/bug", handleJSON(handlePublicAPI(ns, apiPublicBug)))
+		http.Handle(prefix+"/text", handlePublicText(ns))
+	}
+}
+
+func handlePublicAPI(ns string, fn PublicAPIHandler) JSONHandler {
+	return func(c context.Context, r *http.Request) (interface{}, error) {
+		access, err := checkAPIClient(c, ns, r)
+		if err != nil {
+			return nil, err
+		}
+		return fn(c, ns, access, r)
+	}
+}
+
+// checkAPIClient authenticates API client using HTTP basic auth and returns its access level.
+func checkAPIClient(c context.Context, ns string, r *http.Request) (AccessLevel, error) {
+	name, key, ok := r.BasicAuth()
+	if !ok {
+		return 0, ErrAccess
+	}
+	cfg := config.Namespaces[ns]
+	client, ok := cfg.APIClients[name]
+	if !ok || subtle.ConstantTimeCompare([]byte(client.Key), []byte(key)) != 1 {
+		log.Errorf(c, "api client %q: bad name or key for namespace %v", name, ns)
+		return 0, ErrAccess
+	}
+	if client.AccessLevel < cfg.AccessLevel {
+		return 0, ErrAccess
+	}
+	log.Infof(c, "public api %v from %q", r.URL.Path, name)
+	return client.AccessLevel, nil
+}
+
+func apiPublicBugs(c context.Context, ns string, access AccessLevel, r *http.Request) (interface{}, error) {
+	query := db.NewQuery("Bug").Filter("Namespace=", ns)
+	if status := r.FormValue("status"); status != "" {
+		code, ok := publicBugStatuses[status]
+		if !ok {
+			return nil, fmt.Errorf("unknown bug status %q", status)
+		}
+		query = query.Filter("Status=", code)
+	}
+	var since time.Time
+	if str := r.FormValue("since"); str != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, str); err != nil {
+			return nil, fmt.Errorf("bad since value: %v", err)
+		}
+	}
+	var bugs []*Bug
+	if _, err := query.GetAll(c, &bugs); err != nil {
+		return nil, fmt.Errorf("failed to query bugs: %v", err)
+	}
+	res := new(dashapi.PublicBugList)
+	for _, bug := range bugs {
+		if access < bug.sanitizeAccess(access) || bug.LastActivity.Before(since) {
+			continue
+		}
+		res.Bugs = append(res.Bugs, makePublicBug(bug))
+	}
+	sort.Slice(res.Bugs, func(i, j int) bool {
+		return res.Bugs[i].LastActivity.After(res.Bugs[j].LastActivity)
+	})
+	return res, nil
+}
+
+func apiPublicBug(c context.Context, ns string, access AccessLevel, r *http.Request) (interface{}, error) {
+	id := r.FormValue("id")
+	if id == "" {
+		return nil, fmt.Errorf("mandatory parameter id is missing")
+	}
+	bug := new(Bug)
+	if err := db.Get(c, db.NewKey(c, "Bug", id, 0, nil), bug); err != nil {
+		return nil, fmt.Errorf("failed to get bug: %v", err)
+	}
+	if bug.Namespace != ns || access < bug.sanitizeAccess(access) {
+		return nil, ErrAccess
+	}
+	res := makePublicBug(bug)
+	crashes, _, err := queryCrashesForBug(c, bug.key(c), maxCrashes)
+	if err != nil {
+		return nil, err
+	}
+	builds := make(map[string]*Build)
+	for _, crash := range crashes {
+		build := builds[crash.BuildID]
+		if build == nil {
+			if build, err = loadBuild(c, ns, crash.BuildID); err != nil {
+				return nil, err
+			}
+			builds[crash.BuildID] = build
+		}
+		res.Crashes = append(res.Crashes, &dashapi.PublicCrash{
+			Manager:           crash.Manager,
+			Time:              crash.Time,
+			KernelRepo:        build.KernelRepo,
+			KernelBranch:      build.KernelBranch,
+			KernelCommit:      build.KernelCommit,
+			KernelCommitTitle: build.KernelCommitTitle,
+			SyzkallerCommit:   build.SyzkallerCommit,
+			CompilerID:        build.CompilerID,
+			KernelConfigLink:  apiTextLink(ns, textKernelConfig, build.KernelConfig),
+			LogLink:           apiTextLink(ns, textCrashLog, crash.Log),
+			ReportLink:        apiTextLink(ns, textCrashReport, crash.Report),
+			ReproSyzLink:      apiTextLink(ns, textReproSyz, crash.ReproSyz),
+			ReproCLink:        apiTextLink(ns, textReproC, crash.ReproC),
+		})
+	}
+	for _, bisection := range res.Bisections {
+		if bisection.Status == "pending" {
+			continue
+		}
+		typ := JobBisectCause
+		if bisection.Type == "fix" {
+			typ = JobBisectFix
+		}
+		var jobs []*Job
+		_, err := db.NewQuery("Job").
+			Ancestor(bug.key(c)).
+			Filter("Type=", typ).
+			Filter("Finished>", time.Time{}).
+			Order("-Finished").
+			Limit(1).
+			GetAll(c, &jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query jobs: %v", err)
+		}
+		if len(jobs) == 0 {
+			continue
+		}
+		job := jobs[0]
+		bisection.CrashTitle = job.CrashTitle
+		bisection.Finished = job.Finished
+		bisection.LogLink = apiTextLink(ns, textLog, job.Log)
+		bisection.ResultLink = apiTextLink(ns, textBisectResult, job.BisectResult)
+		bisection.ErrorLink = apiTextLink(ns, textError, job.Error)
+		for _, com := range job.Commits {
+			bisection.Commits = append(bisection.Commits, dashapi.Commit{
+				Hash:       com.Hash,
+				Title:      com.Title,
+				Author:     com.Author,
+				AuthorName: com.AuthorName,
+				Date:       com.Date,
+			})
+		}
+	}
+	return res, nil
+}
+
+var publicBugStatuses = map[string]int{
+	"open":    BugStatusOpen,
+	"fixed":   BugStatusFixed,
+	"invalid": BugStatusInvalid,
+	"dup":     BugStatusDup,
+}
+
+func makePublicBug(bug *Bug) *dashapi.PublicBug {
+	res := &dashapi.PublicBug{
+		ID:           bug.keyHash(),
+		Title:        bug.displayTitle(),
+		DupOf:        bug.DupOf,
+		Link:         bugLink(bug.keyHash()),
+		FirstCrash:   bug.FirstTime,
+		LastCrash:    bug.LastTime,
+		LastActivity: bug.LastActivity,
+		Closed:       bug.Closed,
+		NumCrashes:   bug.NumCrashes,
+		ReproLevel:   bug.ReproLevel,
+		PatchedOn:    bug.PatchedOn,
+	}
+	for status, code := range publicBugStatuses {
+		if bug.Status == code {
+			res.Status = status
+		}
+	}
+	for i := len(bug.Reporting) - 1; i >= 0; i-- {
+		if !bug.Reporting[i].Reported.IsZero() {
+			res.ExternalLink = bug.Reporting[i].Link
+			break
+		}
+	}
+	for i, title := range bug.Commits {
+		info := bug.getCommitInfo(i)
+		res.FixCommits = append(res.FixCommits, dashapi.Commit{
+			Hash:       info.Hash,
+			Title:      title,
+			Author:     info.Author,
+			AuthorName: info.AuthorName,
+			Date:       info.Date,
+		})
+	}
+	bisections := []struct {
+		typ    string
+		status BisectStatus
+	}{
+		{"cause", bug.BisectCause},
+		{"fix", bug.BisectFix},
+	}
+	for _, bisection := range bisections {
+		status := ""
+		switch bisection.status {
+		case BisectPending:
+			status = "pending"
+		case BisectError:
+			status = "error"
+		case BisectYes:
+			status = "done"
+		default:
+			continue
+		}
+		res.Bisections = append(res.Bisections, &dashapi.PublicBisection{
+			Type:   bisection.typ,
+			Status: status,
+		})
+	}
+	return res
+}
+
+func handlePublicText(ns string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := appengine.NewContext(r)
+		data, err := publicText(c, ns, r)
+		if err != nil {
+			if err != ErrAccess {
+				log.Errorf(c, "%v", err)
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(data)
+	})
+}
+
+func publicText(c context.Context, ns string, r *http.Request) ([]byte, error) {
+	access, err := checkAPIClient(c, ns, r)
+	if err != nil {
+		return nil, err
+	}
+	tag := r.FormValue("tag")
+	xid, err := strconv.ParseUint(r.FormValue("x"), 16, 64)
+	if err != nil || xid == 0 {
+		return nil, fmt.Errorf("failed to parse text id: %v", err)
+	}
+	id := int64(xid)
+	var bugKey *db.Key
+	switch tag {
+	case textKernelConfig:
+		// Configs are not attached to bugs, checked based on text namespace below.
+	case textCrashLog, textCrashReport, textReproSyz, textReproC:
+		field := map[string]string{
+			textCrashLog:    "Log",
+			textCrashReport: "Report",
+			textReproSyz:    "ReproSyz",
+			textReproC:      "ReproC",
+		}[tag]
+		bugKey, err = textParentKey(c, "Crash", field, id)
+	case textLog, textError, textBisectResult:
+		bugKey, err = textParentKey(c, "Job", tag, id)
+	default:
+		return nil, fmt.Errorf("unknown text tag %q", tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bugKey != nil {
+		bug := new(Bug)
+		if err := db.Get(c, bugKey, bug); err != nil {
+			return nil, fmt.Errorf("failed to get bug: %v", err)
+		}
+		if bug.Namespace != ns || access < bug.sanitizeAccess(access) {
+			return nil, ErrAccess
+		}
+	}
+	data, textNs, err := getText(c, tag, id)
+	if err != nil {
+		return nil, err
+	}
+	if textNs != ns {
+		return nil, ErrAccess
+	}
+	if tag == textReproSyz {
+		data = append([]byte(syzReproPrefix), data...)
+	}
+	return data, nil
+}
+
+// textParentKey returns key of the bug that owns the crash/job that refers to the text.
+func textParentKey(c context.Context, kind, field string, id int64) (*db.Key, error) {
+	keys, err := db.NewQuery(kind).
+		Filter(field+"=", id).
+		KeysOnly().
+		GetAll(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %v: %v", kind, err)
+	}
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("found %v %v entities for %v=%v", len(keys), kind, field, id)
+	}
+	return keys[0].Parent(), nil
+}
+
+func apiTextLink(ns, tag string, id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/api/v1/%v/text?tag=%v&x=%v", ns, tag, strconv.FormatUint(uint64(id), 16))
+}
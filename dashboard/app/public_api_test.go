@@ -0,0 +1,89 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build aetest
+
+package dash
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+)
+
+func TestPublicAPI(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+	crash1 := testCrashWithRepro(build, 1)
+	c.client.ReportCrash(crash1)
+	crash2 := testCrash(build, 2)
+	c.client.ReportCrash(crash2)
+	c.client.pollBugs(2)
+
+	_, code := c.apiGET(clientAPI, "wrongkeywrongkeywrongkey", "/api/v1/test1/bugs")
+	c.expectNE(code, http.StatusOK)
+	_, code = c.apiGET(clientAPI, keyAPI, "/api/v1/test2/bugs")
+	c.expectNE(code, http.StatusOK)
+
+	list := new(dashapi.PublicBugList)
+	c.apiGETJSON("/api/v1/test1/bugs?status=open", list)
+	c.expectEQ(len(list.Bugs), 2)
+	c.apiGETJSON("/api/v1/test1/bugs?status=fixed", list)
+	c.expectEQ(len(list.Bugs), 0)
+
+	c.apiGETJSON("/api/v1/test1/bugs", list)
+	var bugID string
+	for _, bug := range list.Bugs {
+		if bug.Title == crash1.Title {
+			bugID = bug.ID
+		}
+	}
+	c.expectNE(bugID, "")
+
+	bug := new(dashapi.PublicBug)
+	c.apiGETJSON("/api/v1/test1/bug?id="+bugID, bug)
+	c.expectEQ(bug.Title, crash1.Title)
+	c.expectEQ(bug.Status, "open")
+	c.expectEQ(bug.ReproLevel, dashapi.ReproLevelC)
+	c.expectEQ(len(bug.Crashes), 1)
+	crash := bug.Crashes[0]
+	c.expectEQ(crash.KernelCommit, build.KernelCommit)
+	data, code := c.apiGET(clientAPI, keyAPI, crash.ReproCLink)
+	c.expectEQ(code, http.StatusOK)
+	c.expectEQ(string(data), string(crash1.ReproC))
+	data, code = c.apiGET(clientAPI, keyAPI, crash.ReportLink)
+	c.expectEQ(code, http.StatusOK)
+	c.expectEQ(string(data), string(crash1.Report))
+
+	// Texts of one namespace can't be requested via another namespace.
+	_, code = c.apiGET(clientAPI, keyAPI, "/api/v1/test2"+crash.ReproCLink[len("/api/v1/test1"):])
+	c.expectNE(code, http.StatusOK)
+}
+
+func (c *Ctx) apiGET(client, key, url string) ([]byte, int) {
+	r, err := c.inst.NewRequest("GET", url, nil)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	r.SetBasicAuth(client, key)
+	registerContext(r, c)
+	w := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(w, r)
+	return w.Body.Bytes(), w.Code
+}
+
+func (c *Ctx) apiGETJSON(url string, reply interface{}) {
+	data, code := c.apiGET(clientAPI, keyAPI, url)
+	if code != http.StatusOK {
+		c.t.Fatalf("\n%v: %v failed: %v: %s", caller(0), url, code, data)
+	}
+	if err := json.Unmarshal(data, reply); err != nil {
+		c.t.Fatalf("\n%v: failed to unmarshal %v reply: %v", caller(0), url, err)
+	}
+}
@@ -250,7 +250,7 @@ func createNotification(c context.Context, typ dashapi.BugNotif, public bool, te
 		Public:    public,
 	}
 	if public {
-		notif.Maintainers = append(crash.Maintainers, kernelRepo.CC...)
+		notif.Maintainers = crashMaintainers(crash, kernelRepo)
 	}
 	if (public || reporting.moderation) && bugReporting.CC != "" {
 		notif.CC = strings.Split(bugReporting.CC, "|")
@@ -365,7 +365,7 @@ func createBugReport(c context.Context, bug *Bug, crash *Crash, crashKey *db.Key
 		LogLink:      externalLink(c, textCrashLog, crash.Log),
 		Report:       report,
 		ReportLink:   externalLink(c, textCrashReport, crash.Report),
-		Maintainers:  append(crash.Maintainers, kernelRepo.CC...),
+		Maintainers:  crashMaintainers(crash, kernelRepo),
 		ReproC:       reproC,
 		ReproCLink:   externalLink(c, textReproC, crash.ReproC),
 		ReproSyz:     reproSyz,
@@ -373,6 +373,7 @@ func createBugReport(c context.Context, bug *Bug, crash *Crash, crashKey *db.Key
 		CrashID:      crashKey.IntID(),
 		NumCrashes:   bug.NumCrashes,
 		HappenedOn:   managersToRepos(c, bug.Namespace, bug.HappenedOn),
+		Assets:       reportAssetLinks(c, bug.Namespace, crash.Assets, build.Assets),
 	}
 	if bugReporting.CC != "" {
 		rep.CC = strings.Split(bugReporting.CC, "|")
@@ -447,6 +448,19 @@ func loadBisectJob(c context.Context, bug *Bug) (*Job, *Crash, *db.Key, *db.Key,
 	return job, crash, keys[0], crashKey, nil
 }
 
+// crashMaintainers returns recipients for the crash on the repo:
+// subsystem recipients if the repo uses subsystem routing and the crash was classified,
+// get_maintainer.pl output otherwise, plus the repo CC list.
+func crashMaintainers(crash *Crash, kernelRepo KernelRepo) []string {
+	var res []string
+	if kernelRepo.SubsystemRouting && len(crash.SubsystemCC) != 0 {
+		res = append(res, crash.SubsystemCC...)
+	} else {
+		res = append(res, crash.Maintainers...)
+	}
+	return append(res, kernelRepo.CC...)
+}
+
 func managersToRepos(c context.Context, ns string, managers []string) []string {
 	var repos []string
 	dedup := make(map[string]bool)
@@ -490,13 +504,13 @@ func foreachBug(c context.Context, fn func(bug *Bug) error) error {
 }
 
 // reportingPollClosed is called by backends to get list of closed bugs.
-func reportingPollClosed(c context.Context, ids []string) ([]string, error) {
+// Fixed is the subset of closed bugs that are fixed.
+func reportingPollClosed(c context.Context, ids []string) (closed, fixed []string, err error) {
 	idMap := make(map[string]bool, len(ids))
 	for _, id := range ids {
 		idMap[id] = true
 	}
-	var closed []string
-	err := foreachBug(c, func(bug *Bug) error {
+	err = foreachBug(c, func(bug *Bug) error {
 		for i := range bug.Reporting {
 			bugReporting := &bug.Reporting[i]
 			if !idMap[bugReporting.ID] {
@@ -511,11 +525,14 @@ func reportingPollClosed(c context.Context, ids []string) ([]string, error) {
 			if bug.Status >= BugStatusFixed || !bugReporting.Closed.IsZero() {
 				closed = append(closed, bugReporting.ID)
 			}
+			if bug.Status == BugStatusFixed {
+				fixed = append(fixed, bugReporting.ID)
+			}
 			break
 		}
 		return nil
 	})
-	return closed, err
+	return closed, fixed, err
 }
 
 // incomingCommand is entry point to bug status updates.
@@ -59,13 +59,14 @@ func apiReportingPollClosed(c context.Context, r *http.Request, payload []byte)
 	if err := json.Unmarshal(payload, req); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal request: %v", err)
 	}
-	ids, err := reportingPollClosed(c, req.IDs)
+	ids, fixed, err := reportingPollClosed(c, req.IDs)
 	if err != nil {
 		log.Errorf(c, "failed to poll closed bugs: %v", err)
 		return nil, err
 	}
 	resp := &dashapi.PollClosedResponse{
-		IDs: ids,
+		IDs:   ids,
+		Fixed: fixed,
 	}
 	return resp, nil
 }
@@ -421,3 +421,44 @@ func TestReportingFilter(t *testing.T) {
 	rep4 := c.client.pollBug()
 	c.expectEQ(string(rep4.Config), `{"Index":2}`)
 }
+
+func TestSubsystemRouting(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	build.KernelRepo = "git://syzkaller.org/subsystems.git"
+	build.KernelBranch = "master"
+	c.client.UploadBuild(build)
+
+	crash1 := testCrash(build, 1)
+	crash1.Maintainers = []string{"foo@bar.com"}
+	crash1.Subsystems = []string{"EXT4 FILE SYSTEM"}
+	crash1.SubsystemCC = []string{"linux-ext4@vger.kernel.org", "tytso@mit.edu"}
+	c.client.ReportCrash(crash1)
+	rep := c.client.pollBug()
+	c.expectEQ(rep.Maintainers, []string{"linux-ext4@vger.kernel.org", "tytso@mit.edu", "bugs@subsystems.org"})
+	bug, _, _ := c.loadBug(rep.ID)
+	c.expectEQ(bug.Subsystems, []string{"EXT4 FILE SYSTEM"})
+
+	// Unclassified crashes still go to get_maintainer.pl recipients.
+	crash2 := testCrash(build, 2)
+	crash2.Maintainers = []string{"foo@bar.com"}
+	c.client.ReportCrash(crash2)
+	rep = c.client.pollBug()
+	c.expectEQ(rep.Maintainers, []string{"foo@bar.com", "bugs@subsystems.org"})
+
+	// Repos without subsystem routing ignore subsystem recipients.
+	build2 := testBuild(2)
+	c.client.UploadBuild(build2)
+	crash3 := testCrash(build2, 3)
+	crash3.Maintainers = []string{"foo@bar.com"}
+	crash3.Subsystems = []string{"EXT4 FILE SYSTEM"}
+	crash3.SubsystemCC = []string{"linux-ext4@vger.kernel.org"}
+	c.client.ReportCrash(crash3)
+	rep = c.client.pollBug()
+	c.expectEQ(rep.Maintainers, []string{"foo@bar.com"})
+
+	c.expectOK(c.GET("/test1/subsystems"))
+	c.expectOK(c.GET("/test1/subsystems?name=EXT4+FILE+SYSTEM"))
+}
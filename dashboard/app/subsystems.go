@@ -0,0 +1,162 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dash
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	db "google.golang.org/appengine/datastore"
+)
+
+// This file contains per-subsystem bug views (see pkg/subsystem for how bugs are classified).
+
+type uiSubsystemsPage struct {
+	Header     *uiHeader
+	Now        time.Time
+	Subsystems []*uiSubsystem
+	// Set if a single subsystem is selected.
+	Subsystem string
+	Groups    []*uiBugGroup
+}
+
+type uiSubsystem struct {
+	Name       string
+	Link       string
+	Open       int
+	Fixed      int
+	Invalid    int
+	Repro      int
+	LastCrash  time.Time
+	NumCrashes int64
+}
+
+func handleSubsystems(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	accessLevel := accessLevel(c, r)
+	hdr, err := commonHeader(c, r, w, "")
+	if err != nil {
+		return err
+	}
+	hdr.Subpage = "/subsystems"
+	data := &uiSubsystemsPage{
+		Header:    hdr,
+		Now:       timeNow(c),
+		Subsystem: r.FormValue("name"),
+	}
+	if data.Subsystem != "" {
+		data.Groups, err = fetchSubsystemBugs(c, accessLevel, hdr.Namespace, data.Subsystem)
+	} else {
+		data.Subsystems, err = fetchSubsystemStats(c, accessLevel, hdr.Namespace)
+	}
+	if err != nil {
+		return err
+	}
+	return serveTemplate(w, "subsystems.html", data)
+}
+
+func fetchSubsystemStats(c context.Context, accessLevel AccessLevel, ns string) ([]*uiSubsystem, error) {
+	var bugs []*Bug
+	_, err := db.NewQuery("Bug").
+		Filter("Namespace=", ns).
+		Filter("Status<", BugStatusDup).
+		GetAll(c, &bugs)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*uiSubsystem)
+	for _, bug := range bugs {
+		if accessLevel < bug.sanitizeAccess(accessLevel) {
+			continue
+		}
+		for _, name := range bug.Subsystems {
+			stat := stats[name]
+			if stat == nil {
+				stat = &uiSubsystem{
+					Name: name,
+					Link: fmt.Sprintf("/%v/subsystems?name=%v", ns, url.QueryEscape(name)),
+				}
+				stats[name] = stat
+			}
+			switch bug.Status {
+			case BugStatusOpen:
+				stat.Open++
+			case BugStatusFixed:
+				stat.Fixed++
+			case BugStatusInvalid:
+				stat.Invalid++
+			}
+			if bug.ReproLevel != ReproLevelNone {
+				stat.Repro++
+			}
+			if stat.LastCrash.Before(bug.LastTime) {
+				stat.LastCrash = bug.LastTime
+			}
+			stat.NumCrashes += bug.NumCrashes
+		}
+	}
+	var res []*uiSubsystem
+	for _, stat := range stats {
+		res = append(res, stat)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Open != res[j].Open {
+			return res[i].Open > res[j].Open
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+func fetchSubsystemBugs(c context.Context, accessLevel AccessLevel, ns, name string) ([]*uiBugGroup, error) {
+	var bugs []*Bug
+	_, err := db.NewQuery("Bug").
+		Filter("Namespace=", ns).
+		Filter("Subsystems=", name).
+		GetAll(c, &bugs)
+	if err != nil {
+		return nil, err
+	}
+	state, err := loadReportingState(c)
+	if err != nil {
+		return nil, err
+	}
+	managers, err := managerList(c, ns)
+	if err != nil {
+		return nil, err
+	}
+	groups := []*uiBugGroup{
+		{Caption: "open", Fragment: "open"},
+		{Caption: "fixed", Fragment: "fixed", ShowPatch: true},
+		{Caption: "invalid", Fragment: "invalid"},
+	}
+	for _, bug := range bugs {
+		if accessLevel < bug.sanitizeAccess(accessLevel) {
+			continue
+		}
+		var group *uiBugGroup
+		switch bug.Status {
+		case BugStatusOpen:
+			group = groups[0]
+		case BugStatusFixed:
+			group = groups[1]
+		case BugStatusInvalid:
+			group = groups[2]
+		default:
+			continue
+		}
+		group.Bugs = append(group.Bugs, createUIBug(c, bug, state, managers))
+	}
+	for _, group := range groups {
+		group.Now = timeNow(c)
+		group.Namespace = ns
+		sort.Slice(group.Bugs, func(i, j int) bool {
+			return group.Bugs[i].LastTime.After(group.Bugs[j].LastTime)
+		})
+	}
+	return groups, nil
+}
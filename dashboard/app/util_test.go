@@ -449,6 +449,10 @@ func initMocks() {
 		getRequestContext(c).emailSink <- msg
 		return nil
 	}
+	signAssetURL = func(c context.Context, method, object string, expires time.Time) (string, error) {
+		return fmt.Sprintf("https://storage.googleapis.com/%v/%v?method=%v&expires=%v",
+			config.AssetBucket, object, method, expires.Unix()), nil
+	}
 }
 
 // Machinery to associate mocked time with requests.
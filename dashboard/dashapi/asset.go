@@ -0,0 +1,121 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dashapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Assets are large files related to crashes, builds and jobs (disk images, memory dumps, full bisection logs)
+// that are not stored in the dashboard itself. Assets are content-addressed:
+//   - the client sends AssetUploadReq with sha256 of the file,
+//   - if the dashboard does not have the asset yet, it replies with a signed upload URL
+//     and the client uploads the file with HTTP PUT to that URL and then sends AssetDoneReq,
+//   - the client references the asset by hash in Crash.Assets, Build.Assets or JobDoneReq.Assets.
+// The dashboard includes expiring signed download links to the assets in bug reports.
+// UploadAsset implements the whole sequence.
+
+type AssetType string
+
+const (
+	AssetDiskImage   AssetType = "disk_image"
+	AssetKernel      AssetType = "kernel_image"
+	AssetMemoryDump  AssetType = "memory_dump"
+	AssetBisectLog   AssetType = "bisect_log"
+	AssetDiagnostics AssetType = "crash_diagnostics"
+)
+
+// Asset is a reference to an uploaded asset.
+type Asset struct {
+	Type AssetType
+	Name string // original file name, for display only
+	Hash string // hex sha256 of the contents
+	Size int64
+}
+
+type AssetUploadReq struct {
+	Type AssetType
+	Hash string
+	Size int64
+}
+
+type AssetUploadResp struct {
+	Exists    bool   // the asset is already uploaded
+	UploadURL string // otherwise the contents need to be PUT to this URL
+}
+
+type AssetDoneReq struct {
+	Hash string
+}
+
+// AssetLink is an asset in bug reports.
+type AssetLink struct {
+	Type    AssetType
+	Title   string // human-readable type (e.g. "disk image")
+	Name    string
+	Size    int64
+	Link    string
+	Expires time.Time // the link won't work after this time
+}
+
+// UploadAsset uploads the file (unless the dashboard already has it) and returns a reference to it.
+func (dash *Dashboard) UploadAsset(typ AssetType, file string) (*Asset, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", file, err)
+	}
+	asset := &Asset{
+		Type: typ,
+		Name: filepath.Base(file),
+		Hash: hex.EncodeToString(hasher.Sum(nil)),
+		Size: size,
+	}
+	req := &AssetUploadReq{
+		Type: asset.Type,
+		Hash: asset.Hash,
+		Size: asset.Size,
+	}
+	resp := new(AssetUploadResp)
+	if err := dash.Query("asset_upload", req, resp); err != nil {
+		return nil, err
+	}
+	if resp.Exists {
+		return asset, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r, err := dash.ctor(http.MethodPut, resp.UploadURL, f)
+	if err != nil {
+		return nil, err
+	}
+	r.ContentLength = size
+	httpResp, err := dash.doer(r)
+	if err != nil {
+		return nil, fmt.Errorf("asset upload failed: %v", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("asset upload failed with %v: %s", httpResp.Status, data)
+	}
+	if err := dash.Query("asset_done", &AssetDoneReq{Hash: asset.Hash}, nil); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
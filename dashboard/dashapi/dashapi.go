@@ -70,6 +70,7 @@ type Build struct {
 	KernelConfig        []byte
 	Commits             []string // see BuilderPoll
 	FixCommits          []Commit
+	Assets              []Asset // e.g. disk image and kernel image, see UploadAsset
 }
 
 type Commit struct {
@@ -144,13 +145,19 @@ type JobPollResp struct {
 }
 
 type JobDoneReq struct {
-	ID          string
-	Build       Build
-	Error       []byte
-	Log         []byte // bisection log
-	CrashTitle  string
-	CrashLog    []byte
-	CrashReport []byte
+	ID    string
+	Build Build
+	Error []byte
+	Log   []byte // bisection log
+	// Machine-readable bisection log (JSON-encoded pkg/bisect.Result).
+	BisectResult []byte
+	// The job failed due to infrastructure problems (e.g. the kernel does not build/boot
+	// with bisection compilers), it makes sense to retry it later.
+	InfraFailure bool
+	CrashTitle   string
+	CrashLog     []byte
+	CrashReport  []byte
+	Assets       []Asset // e.g. full bisection log, see UploadAsset
 	// Bisection results:
 	// If there is 0 commits:
 	//  - still happens on HEAD for fix bisection
@@ -223,6 +230,14 @@ type Crash struct {
 	Maintainers []string
 	Log         []byte
 	Report      []byte
+	// Fingerprint identifies the bug across kernel versions (see report.Fingerprint), optional.
+	// Crashes with different titles but the same fingerprint are likely duplicates.
+	Fingerprint string
+	// Subsystems the crash is attributed to and their recipients (see report.Report.Subsystems), optional.
+	Subsystems  []string
+	SubsystemCC []string
+	// Large crash artifacts (e.g. memory dump) uploaded with UploadAsset, optional.
+	Assets []Asset
 	// The following is optional and is filled only after repro.
 	ReproOpts []byte
 	ReproSyz  []byte
@@ -324,6 +339,9 @@ type BugReport struct {
 	PatchLink      string
 	BisectCause    *BisectResult
 	BisectFix      *BisectResult
+
+	// Download links for crash, build and job assets (see UploadAsset).
+	Assets []AssetLink
 }
 
 type BisectResult struct {
@@ -396,7 +414,8 @@ type PollClosedRequest struct {
 }
 
 type PollClosedResponse struct {
-	IDs []string
+	IDs   []string
+	Fixed []string // subset of IDs that are closed as fixed
 }
 
 func (dash *Dashboard) ReportingPollBugs(typ string) (*PollBugsResponse, error) {
@@ -0,0 +1,71 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dashapi
+
+import (
+	"time"
+)
+
+// Types returned by the read-only dashboard JSON API that is intended for external bug trackers
+// and triage systems. Requests use HTTP basic auth with one of the namespace API clients:
+//
+//	GET /api/v1/<namespace>/bugs?status=open&since=2006-01-02T15:04:05Z	returns PublicBugList
+//	GET /api/v1/<namespace>/bug?id=<bug id>					returns PublicBug with crashes and bisections
+//	GET /api/v1/<namespace>/text?tag=<tag>&x=<id>				returns text (link from other replies)
+//
+// All filters of the bugs request are optional: status is one of open/fixed/invalid/dup,
+// since returns only bugs with any activity after the given time (for incremental sync).
+
+type PublicBugList struct {
+	Bugs []*PublicBug
+}
+
+type PublicBug struct {
+	ID           string
+	Title        string
+	Status       string // open, fixed, invalid or dup
+	DupOf        string `json:",omitempty"` // ID of the canonical bug for dups
+	Link         string // dashboard bug page
+	ExternalLink string `json:",omitempty"` // link to the last report (e.g. mailing list archive)
+	FirstCrash   time.Time
+	LastCrash    time.Time
+	LastActivity time.Time
+	Closed       time.Time
+	NumCrashes   int64
+	ReproLevel   ReproLevel
+	// Commits that fix the bug; Hash is empty for commits that have not appeared in any tree yet.
+	FixCommits []Commit           `json:",omitempty"`
+	PatchedOn  []string           `json:",omitempty"` // managers that already have the fix
+	Bisections []*PublicBisection `json:",omitempty"`
+	Crashes    []*PublicCrash     `json:",omitempty"` // only for single bug requests
+}
+
+type PublicCrash struct {
+	Manager           string
+	Time              time.Time
+	KernelRepo        string
+	KernelBranch      string
+	KernelCommit      string
+	KernelCommitTitle string
+	SyzkallerCommit   string
+	CompilerID        string
+	KernelConfigLink  string
+	LogLink           string
+	ReportLink        string
+	ReproSyzLink      string `json:",omitempty"`
+	ReproCLink        string `json:",omitempty"`
+}
+
+type PublicBisection struct {
+	Type   string // cause or fix
+	Status string // pending, error or done
+	// The rest is only for single bug requests and finished bisections.
+	// See JobDoneReq.Commits for the meaning of Commits.
+	Commits    []Commit  `json:",omitempty"`
+	CrashTitle string    `json:",omitempty"`
+	Finished   time.Time `json:",omitempty"`
+	LogLink    string    `json:",omitempty"`
+	ResultLink string    `json:",omitempty"` // machine-readable log (pkg/bisect.Result)
+	ErrorLink  string    `json:",omitempty"`
+}
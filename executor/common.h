@@ -17,6 +17,14 @@
 
 #if GOOS_freebsd || GOOS_test && HOSTGOOS_freebsd
 #include <sys/endian.h> // for htobe*.
+#elif GOOS_darwin || GOOS_test && HOSTGOOS_darwin
+#include <libkern/OSByteOrder.h> // for htobe*.
+#define htobe16(x) OSSwapHostToBigInt16(x)
+#define htobe32(x) OSSwapHostToBigInt32(x)
+#define htobe64(x) OSSwapHostToBigInt64(x)
+#define htole16(x) OSSwapHostToLittleInt16(x)
+#define htole32(x) OSSwapHostToLittleInt32(x)
+#define htole64(x) OSSwapHostToLittleInt64(x)
 #else
 #include <endian.h> // for htobe*.
 #endif
@@ -25,7 +33,7 @@
 #include <stdlib.h>
 #include <string.h>
 
-#if SYZ_TRACE
+#if SYZ_TRACE || SYZ_STRACE
 #include <errno.h>
 #endif
 
@@ -181,7 +189,7 @@ static void use_temporary_dir(void)
 #endif
 #endif
 
-#if GOOS_akaros || GOOS_netbsd || GOOS_freebsd || GOOS_openbsd || GOOS_test
+#if GOOS_akaros || GOOS_netbsd || GOOS_freebsd || GOOS_darwin || GOOS_openbsd || GOOS_test
 #if SYZ_EXECUTOR || SYZ_EXECUTOR_USES_FORK_SERVER && SYZ_REPEAT && SYZ_USE_TMP_DIR
 #include <dirent.h>
 #include <stdio.h>
@@ -266,7 +274,7 @@ static void thread_start(void* (*fn)(void*), void* arg)
 #endif
 #endif
 
-#if GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_akaros || GOOS_test
+#if GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_darwin || GOOS_akaros || GOOS_test
 #if SYZ_EXECUTOR || SYZ_THREADED
 
 #include <pthread.h>
@@ -349,7 +357,7 @@ static int event_timedwait(event_t* ev, uint64 timeout)
 			       (((type)(val) << (bf_off)) & BITMASK((bf_off), (bf_len))))
 #endif
 
-#if SYZ_EXECUTOR || SYZ_USE_CHECKSUMS
+#if SYZ_EXECUTOR || SYZ_USE_CHECKSUMS || SYZ_TUN_ENABLE && (__NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send)
 struct csum_inet {
 	uint32 acc;
 };
@@ -383,7 +391,7 @@ static uint16 csum_inet_digest(struct csum_inet* csum)
 
 #if GOOS_akaros
 #include "common_akaros.h"
-#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd
+#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_darwin
 #include "common_bsd.h"
 #elif GOOS_fuchsia
 #include "common_fuchsia.h"
@@ -620,7 +628,8 @@ static void loop(void)
 				last_executed = now;
 			}
 			// TODO: adjust timeout for progs with syz_usb_connect call.
-			if ((now - start < 5 * 1000) && (now - start < 3 * 1000 || now - last_executed < 1000))
+			uint64 scale = slowdown_scale;
+			if ((now - start < 5000 * scale) && (now - start < 3000 * scale || now - last_executed < 1000 * scale))
 				continue;
 #else
 			if (current_time_ms() - start < 5 * 1000)
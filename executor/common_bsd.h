@@ -37,6 +37,221 @@ static uintptr_t syz_open_pts(void)
 
 #endif // GOOS_openbsd
 
+#if GOOS_darwin
+
+#define __syscall syscall
+
+#include <mach/mach.h>
+#include <mach/mach_time.h>
+#include <mach/mach_vm.h>
+#include <mach/mk_timer.h>
+#include <mach/thread_switch.h>
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_allocate || __NR_syz_mach_port_deallocate ||                            \
+    __NR_syz_mach_port_destroy || __NR_syz_mach_port_mod_refs || __NR_syz_mach_port_insert_right ||            \
+    __NR_syz_mach_port_insert_member || __NR_syz_mach_port_extract_member || __NR_syz_mach_port_move_member || \
+    __NR_syz_mach_port_construct || __NR_syz_mach_port_destruct || __NR_syz_mach_port_guard ||                 \
+    __NR_syz_mach_port_unguard || __NR_syz_mach_port_type || __NR_syz_mach_msg || __NR_syz_mach_vm_allocate || \
+    __NR_syz_mach_vm_deallocate || __NR_syz_mach_vm_protect || __NR_syz_mk_timer_destroy ||                    \
+    __NR_syz_mk_timer_arm || __NR_syz_mk_timer_cancel || __NR_syz_thread_switch || __NR_syz_mach_timebase_info
+#include <errno.h>
+
+// Mach traps return kern_return_t instead of setting errno,
+// convert it to the usual -1/errno convention (see sys/darwin/mach.txt).
+static intptr_t mach_result(kern_return_t kr)
+{
+	if (kr == KERN_SUCCESS)
+		return 0;
+	errno = kr;
+	return -1;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_reply_port
+static intptr_t syz_mach_reply_port()
+{
+	return mach_reply_port();
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_allocate
+static intptr_t syz_mach_port_allocate(intptr_t right, intptr_t name)
+{
+	return mach_result(mach_port_allocate(mach_task_self(), right, (mach_port_name_t*)name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_deallocate
+static intptr_t syz_mach_port_deallocate(intptr_t name)
+{
+	return mach_result(mach_port_deallocate(mach_task_self(), name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_destroy
+static intptr_t syz_mach_port_destroy(intptr_t name)
+{
+	return mach_result(mach_port_destroy(mach_task_self(), name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_mod_refs
+static intptr_t syz_mach_port_mod_refs(intptr_t name, intptr_t right, intptr_t delta)
+{
+	return mach_result(mach_port_mod_refs(mach_task_self(), name, right, delta));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_insert_right
+static intptr_t syz_mach_port_insert_right(intptr_t name, intptr_t poly, intptr_t poly_type)
+{
+	return mach_result(mach_port_insert_right(mach_task_self(), name, poly, poly_type));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_insert_member
+static intptr_t syz_mach_port_insert_member(intptr_t name, intptr_t pset)
+{
+	return mach_result(mach_port_insert_member(mach_task_self(), name, pset));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_extract_member
+static intptr_t syz_mach_port_extract_member(intptr_t name, intptr_t pset)
+{
+	return mach_result(mach_port_extract_member(mach_task_self(), name, pset));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_move_member
+static intptr_t syz_mach_port_move_member(intptr_t member, intptr_t after)
+{
+	return mach_result(mach_port_move_member(mach_task_self(), member, after));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_construct
+static intptr_t syz_mach_port_construct(intptr_t options, intptr_t context, intptr_t name)
+{
+	return mach_result(mach_port_construct(mach_task_self(), (mach_port_options_t*)options, context,
+					       (mach_port_name_t*)name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_destruct
+static intptr_t syz_mach_port_destruct(intptr_t name, intptr_t srdelta, intptr_t guard)
+{
+	return mach_result(mach_port_destruct(mach_task_self(), name, srdelta, guard));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_guard
+static intptr_t syz_mach_port_guard(intptr_t name, intptr_t guard, intptr_t strict)
+{
+	return mach_result(mach_port_guard(mach_task_self(), name, guard, strict));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_unguard
+static intptr_t syz_mach_port_unguard(intptr_t name, intptr_t guard)
+{
+	return mach_result(mach_port_unguard(mach_task_self(), name, guard));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_type
+static intptr_t syz_mach_port_type(intptr_t name, intptr_t ptype)
+{
+	return mach_result(mach_port_type(mach_task_self(), name, (mach_port_type_t*)ptype));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_msg
+static intptr_t syz_mach_msg(intptr_t msg, intptr_t option, intptr_t send_size, intptr_t rcv_size, intptr_t rcv_name)
+{
+	const mach_msg_timeout_t timeout = 10;
+	option |= MACH_SEND_TIMEOUT | MACH_RCV_TIMEOUT;
+	if (!(option & MACH_SEND_MSG))
+		send_size = 0;
+	if (!(option & MACH_RCV_MSG))
+		rcv_size = 0;
+	return mach_result(mach_msg((mach_msg_header_t*)msg, option, send_size, rcv_size, rcv_name,
+				    timeout, MACH_PORT_NULL));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_allocate
+static intptr_t syz_mach_vm_allocate(intptr_t addr, intptr_t size, intptr_t flags)
+{
+	return mach_result(mach_vm_allocate(mach_task_self(), (mach_vm_address_t*)addr, size, flags));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_deallocate
+static intptr_t syz_mach_vm_deallocate(intptr_t addr, intptr_t size)
+{
+	return mach_result(mach_vm_deallocate(mach_task_self(), addr, size));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_protect
+static intptr_t syz_mach_vm_protect(intptr_t addr, intptr_t size, intptr_t set_max, intptr_t prot)
+{
+	return mach_result(mach_vm_protect(mach_task_self(), addr, size, set_max, prot));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_create
+static intptr_t syz_mk_timer_create()
+{
+	return mk_timer_create();
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_destroy
+static intptr_t syz_mk_timer_destroy(intptr_t name)
+{
+	return mach_result(mk_timer_destroy(name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_arm
+static intptr_t syz_mk_timer_arm(intptr_t name, intptr_t expire_time)
+{
+	return mach_result(mk_timer_arm(name, expire_time));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_cancel
+static intptr_t syz_mk_timer_cancel(intptr_t name, intptr_t result)
+{
+	return mach_result(mk_timer_cancel(name, (uint64_t*)result));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_thread_switch
+static intptr_t syz_thread_switch(intptr_t option, intptr_t time)
+{
+	return mach_result(thread_switch(MACH_PORT_NULL, option, time));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_swtch_pri
+static intptr_t syz_swtch_pri(intptr_t pri)
+{
+	return swtch_pri(pri);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_timebase_info
+static intptr_t syz_mach_timebase_info(intptr_t info)
+{
+	return mach_result(mach_timebase_info((mach_timebase_info_t)info));
+}
+#endif
+
+#endif // GOOS_darwin
+
 #if GOOS_freebsd || GOOS_openbsd
 
 #if SYZ_EXECUTOR || SYZ_TUN_ENABLE
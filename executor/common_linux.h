@@ -409,15 +409,18 @@ static void initialize_tun(void)
 
 	netlink_add_addr4(sock, TUN_IFACE, LOCAL_IPV4);
 	netlink_add_addr6(sock, TUN_IFACE, LOCAL_IPV6);
-	uint64 macaddr = REMOTE_MAC;
+	// Changing the device address flushes the neighbour table,
+	// so the remote neighbours must be added after that. Otherwise the kernel
+	// sends ARP/NDISC requests instead of replies to the remote peer.
+	uint64 macaddr = LOCAL_MAC;
+	netlink_device_change(sock, TUN_IFACE, true, 0, &macaddr, ETH_ALEN);
+	macaddr = REMOTE_MAC;
 	struct in_addr in_addr;
 	inet_pton(AF_INET, REMOTE_IPV4, &in_addr);
 	netlink_add_neigh(sock, TUN_IFACE, &in_addr, sizeof(in_addr), &macaddr, ETH_ALEN);
 	struct in6_addr in6_addr;
 	inet_pton(AF_INET6, REMOTE_IPV6, &in6_addr);
 	netlink_add_neigh(sock, TUN_IFACE, &in6_addr, sizeof(in6_addr), &macaddr, ETH_ALEN);
-	macaddr = LOCAL_MAC;
-	netlink_device_change(sock, TUN_IFACE, true, 0, &macaddr, ETH_ALEN);
 	close(sock);
 }
 #endif
@@ -444,6 +447,21 @@ static void initialize_tun(void)
 #define DEV_IPV6 "fe80::%02x"
 #define DEV_MAC 0x00aaaaaaaaaa
 
+// Groups of network devices, correspond to ipc.NetDevices.
+#define NETDEV_VIRTUAL (1 << 0)
+#define NETDEV_VETH (1 << 1)
+#define NETDEV_BRIDGE (1 << 2)
+#define NETDEV_BOND (1 << 3)
+#define NETDEV_TEAM (1 << 4)
+#define NETDEV_HSR (1 << 5)
+#define NETDEV_NETDEVSIM (1 << 6)
+
+#if SYZ_EXECUTOR
+#define NETDEV_ENABLED(group) (flag_net_devices & (group))
+#else
+#define NETDEV_ENABLED(group) 1
+#endif
+
 // We test in a separate namespace, which does not have any network devices initially (even lo).
 // Create/up as many as we can.
 static void initialize_netdevices(void)
@@ -478,25 +496,34 @@ static void initialize_netdevices(void)
 	struct {
 		const char* type;
 		const char* dev;
+		int group;
 	} devtypes[] = {
 	    // Note: ip6erspan device can't be added if ip6gretap exists in the same namespace.
-	    {"ip6gretap", "ip6gretap0"},
-	    {"bridge", "bridge0"},
-	    {"vcan", "vcan0"},
-	    {"bond", "bond0"},
-	    {"team", "team0"},
-	    {"dummy", "dummy0"},
-	    {"nlmon", "nlmon0"},
-	    {"caif", "caif0"},
-	    {"batadv", "batadv0"},
+	    {"ip6gretap", "ip6gretap0", NETDEV_VIRTUAL},
+	    {"bridge", "bridge0", NETDEV_BRIDGE},
+	    {"vcan", "vcan0", NETDEV_VIRTUAL},
+	    {"bond", "bond0", NETDEV_BOND},
+	    {"team", "team0", NETDEV_TEAM},
+	    {"dummy", "dummy0", NETDEV_VIRTUAL},
+	    {"nlmon", "nlmon0", NETDEV_VIRTUAL},
+	    {"caif", "caif0", NETDEV_VIRTUAL},
+	    {"batadv", "batadv0", NETDEV_VIRTUAL},
 	    // Note: adding device vxcan0 fails.
-	    {"vxcan", "vxcan1"},
+	    {"vxcan", "vxcan1", NETDEV_VIRTUAL},
 	    // Note: netdevsim devices can't have the same name even in different namespaces.
-	    {"netdevsim", netdevsim},
+	    // So when the namespace is recycled, it's not created until the previous namespace is destroyed.
+	    {"netdevsim", netdevsim, NETDEV_NETDEVSIM},
 	    // This adds connected veth0 and veth1 devices.
-	    {"veth", 0},
+	    {"veth", 0, NETDEV_VETH},
+	};
+	struct {
+		const char* type;
+		int group;
+	} devmasters[] = {
+	    {"bridge", NETDEV_BRIDGE},
+	    {"bond", NETDEV_BOND},
+	    {"team", NETDEV_TEAM},
 	};
-	const char* devmasters[] = {"bridge", "bond", "team"};
 	// If you extend this array, also update netdev_addr_id in vnet.txt.
 	struct {
 		const char* name;
@@ -540,21 +567,25 @@ static void initialize_netdevices(void)
 	if (sock == -1)
 		fail("socket(AF_NETLINK) failed");
 	unsigned i;
-	for (i = 0; i < sizeof(devtypes) / sizeof(devtypes[0]); i++)
-		netlink_add_device(sock, devtypes[i].type, devtypes[i].dev);
+	for (i = 0; i < sizeof(devtypes) / sizeof(devtypes[0]); i++) {
+		if (NETDEV_ENABLED(devtypes[i].group))
+			netlink_add_device(sock, devtypes[i].type, devtypes[i].dev);
+	}
 	// This creates connected bridge/bond/team_slave devices of type veth,
 	// and makes them slaves of bridge/bond/team devices, respectively.
 	// Note: slave devices don't need MAC/IP addresses, only master devices.
 	//       veth0_to_* is not slave devices, which still need ip addresses.
 	for (i = 0; i < sizeof(devmasters) / (sizeof(devmasters[0])); i++) {
+		if (!NETDEV_ENABLED(devmasters[i].group))
+			continue;
 		char master[32], slave0[32], veth0[32], slave1[32], veth1[32];
-		sprintf(slave0, "%s_slave_0", devmasters[i]);
-		sprintf(veth0, "veth0_to_%s", devmasters[i]);
+		sprintf(slave0, "%s_slave_0", devmasters[i].type);
+		sprintf(veth0, "veth0_to_%s", devmasters[i].type);
 		netlink_add_veth(sock, slave0, veth0);
-		sprintf(slave1, "%s_slave_1", devmasters[i]);
-		sprintf(veth1, "veth1_to_%s", devmasters[i]);
+		sprintf(slave1, "%s_slave_1", devmasters[i].type);
+		sprintf(veth1, "veth1_to_%s", devmasters[i].type);
 		netlink_add_veth(sock, slave1, veth1);
-		sprintf(master, "%s0", devmasters[i]);
+		sprintf(master, "%s0", devmasters[i].type);
 		netlink_device_change(sock, slave0, false, master, 0, 0);
 		netlink_device_change(sock, slave1, false, master, 0, 0);
 	}
@@ -564,11 +595,13 @@ static void initialize_netdevices(void)
 	netlink_device_change(sock, "bridge_slave_1", true, 0, 0, 0);
 
 	// Setup hsr device (slightly different from what we do for devmasters).
-	netlink_add_veth(sock, "hsr_slave_0", "veth0_to_hsr");
-	netlink_add_veth(sock, "hsr_slave_1", "veth1_to_hsr");
-	netlink_add_hsr(sock, "hsr0", "hsr_slave_0", "hsr_slave_1");
-	netlink_device_change(sock, "hsr_slave_0", true, 0, 0, 0);
-	netlink_device_change(sock, "hsr_slave_1", true, 0, 0, 0);
+	if (NETDEV_ENABLED(NETDEV_HSR)) {
+		netlink_add_veth(sock, "hsr_slave_0", "veth0_to_hsr");
+		netlink_add_veth(sock, "hsr_slave_1", "veth1_to_hsr");
+		netlink_add_hsr(sock, "hsr0", "hsr_slave_0", "hsr_slave_1");
+		netlink_device_change(sock, "hsr_slave_0", true, 0, 0, 0);
+		netlink_device_change(sock, "hsr_slave_1", true, 0, 0, 0);
+	}
 
 	for (i = 0; i < sizeof(devices) / (sizeof(devices[0])); i++) {
 		// Assign some unique address to devices. Some devices won't up without this.
@@ -629,7 +662,7 @@ static void initialize_netdevices_init(void)
 }
 #endif
 
-#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || SYZ_REPEAT)
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || SYZ_REPEAT || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv)
 #include <errno.h>
 
 static int read_tun(char* data, int size)
@@ -724,7 +757,7 @@ static void flush_tun()
 }
 #endif
 
-#if SYZ_EXECUTOR || __NR_syz_extract_tcp_res && SYZ_TUN_ENABLE
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send)
 #ifndef __ANDROID__
 // Can't include <linux/ipv6.h>, since it causes
 // conflicts due to some structs redefinition.
@@ -741,7 +774,9 @@ struct ipv6hdr {
 	struct in6_addr daddr;
 };
 #endif
+#endif
 
+#if SYZ_EXECUTOR || __NR_syz_extract_tcp_res && SYZ_TUN_ENABLE
 struct tcp_resources {
 	uint32 seq;
 	uint32 ack;
@@ -799,6 +834,21 @@ static long syz_extract_tcp_res(volatile long a0, volatile long a1, volatile lon
 }
 #endif
 
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send)
+#include <arpa/inet.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <linux/if_alg.h>
+#include <net/if.h>
+#include <poll.h>
+#include <stdbool.h>
+#include <string.h>
+#include <sys/socket.h>
+#include <unistd.h>
+
+#include "common_vnet.h"
+#endif
+
 #if SYZ_EXECUTOR || __NR_syz_usb_connect
 #include <errno.h>
 #include <fcntl.h>
@@ -814,6 +864,26 @@ static long syz_extract_tcp_res(volatile long a0, volatile long a1, volatile lon
 #include "common_usb.h"
 #endif
 
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_9p_io || __NR_syz_nfs_connect || __NR_syz_nfs_io || __NR_syz_smb_connect || __NR_syz_smb_io
+#include <arpa/inet.h>
+#include <errno.h>
+#include <netinet/in.h>
+#include <poll.h>
+#include <pthread.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdio.h>
+#include <string.h>
+#include <sys/mount.h>
+#include <sys/socket.h>
+#include <sys/stat.h>
+#include <sys/types.h>
+#include <sys/uio.h>
+#include <unistd.h>
+
+#include "common_netfs.h"
+#endif
+
 #if SYZ_EXECUTOR || __NR_syz_open_dev
 #include <fcntl.h>
 #include <string.h>
@@ -1023,8 +1093,8 @@ static long syz_read_part_table(volatile unsigned long size, volatile unsigned l
 		segs[i].offset %= IMAGE_MAX_SIZE;
 		if (segs[i].offset > IMAGE_MAX_SIZE - segs[i].size)
 			segs[i].offset = IMAGE_MAX_SIZE - segs[i].size;
-		if (size < segs[i].offset + segs[i].offset)
-			size = segs[i].offset + segs[i].offset;
+		if (size < segs[i].offset + segs[i].size)
+			size = segs[i].offset + segs[i].size;
 	}
 	if (size > IMAGE_MAX_SIZE)
 		size = IMAGE_MAX_SIZE;
@@ -1116,6 +1186,8 @@ static long syz_mount_image(volatile long fsarg, volatile long dir, volatile uns
 	// But some filesystems have large number of segments (2000+),
 	// we can't allocate that much on stack and allocating elsewhere is problematic,
 	// so we just use the memory allocated by fuzzer.
+	// Filesystems with structure-aware mutation pass the whole image as a single segment
+	// (see fs_image type), the loop device is made at least as large as the image.
 	struct fs_image_segment* segs = (struct fs_image_segment*)segments;
 
 	if (nsegs > IMAGE_MAX_SEGMENTS)
@@ -1126,8 +1198,8 @@ static long syz_mount_image(volatile long fsarg, volatile long dir, volatile uns
 		segs[i].offset %= IMAGE_MAX_SIZE;
 		if (segs[i].offset > IMAGE_MAX_SIZE - segs[i].size)
 			segs[i].offset = IMAGE_MAX_SIZE - segs[i].size;
-		if (size < segs[i].offset + segs[i].offset)
-			size = segs[i].offset + segs[i].offset;
+		if (size < segs[i].offset + segs[i].size)
+			size = segs[i].offset + segs[i].size;
 	}
 	if (size > IMAGE_MAX_SIZE)
 		size = IMAGE_MAX_SIZE;
@@ -1223,6 +1295,53 @@ static long syz_kvm_setup_cpu(volatile long a0, volatile long a1, volatile long
 #endif
 #endif
 
+#if SYZ_EXECUTOR || __NR_syz_vmware_backdoor
+#include <errno.h>
+#include <string.h>
+
+#define VMWARE_BACKDOOR_MAGIC 0x564D5868
+#define VMWARE_BACKDOOR_PORT 0x5658
+
+#if GOARCH_amd64
+static void vmware_backdoor_call(volatile uint32* regs)
+{
+	uint32 eax = regs[0], ebx = regs[1], ecx = regs[2], edx = regs[3], esi = regs[4], edi = regs[5];
+	asm volatile("inl %%dx, %%eax"
+		     : "+a"(eax), "+b"(ebx), "+c"(ecx), "+d"(edx), "+S"(esi), "+D"(edi)
+		     :
+		     : "memory");
+	regs[0] = eax;
+	regs[1] = ebx;
+	regs[2] = ecx;
+	regs[3] = edx;
+	regs[4] = esi;
+	regs[5] = edi;
+}
+#endif
+
+// syz_vmware_backdoor(cmd int32[0:100], arg intptr, regs ptr[out, array[int32, 6], opt])
+// The backdoor port is accessible from ring 3, outside of VMware (or if the backdoor
+// is disabled in the VM config) the in instruction causes #GP which we catch with NONFAILING.
+static long syz_vmware_backdoor(volatile long a0, volatile long a1, volatile long a2)
+{
+#if GOARCH_amd64
+	volatile uint32 regs[6] = {VMWARE_BACKDOOR_MAGIC, (uint32)a1, (uint32)a0, VMWARE_BACKDOOR_PORT, 0, 0};
+	volatile int done = 0;
+	NONFAILING(vmware_backdoor_call(regs); done = 1);
+	if (!done) {
+		errno = ENODEV;
+		return -1;
+	}
+	if (a2)
+		NONFAILING(memcpy((void*)a2, (void*)regs, sizeof(regs)));
+	return 0;
+#else
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_RESET_NET_NAMESPACE
 #include <errno.h>
 #include <net/if.h>
@@ -2019,6 +2138,21 @@ static int do_sandbox_none(void)
 #include <sched.h>
 #include <sys/prctl.h>
 
+#if SYZ_EXECUTOR
+// Capabilities dropped from the bounding set can't be gained via execve of setuid/file-capability
+// binaries. Dropping requires CAP_SETPCAP, so this must be done before switching the identity.
+static void drop_capability_bounding_set()
+{
+	for (int cap = 0; cap < 64; cap++) {
+		if (sandbox_identity.cap_bound & (1ull << cap))
+			continue;
+		// EINVAL means that the kernel does not know this capability.
+		if (prctl(PR_CAPBSET_DROP, cap, 0, 0, 0) && errno != EINVAL)
+			fail("prctl(PR_CAPBSET_DROP, %d) failed", cap);
+	}
+}
+#endif
+
 #define SYZ_HAVE_SANDBOX_SETUID 1
 static int do_sandbox_setuid(void)
 {
@@ -2045,17 +2179,34 @@ static int do_sandbox_setuid(void)
 #endif
 
 	const int nobody = 65534;
-	if (setgroups(0, NULL))
+	int uid = nobody;
+	int gid = nobody;
+	int ngroups = 0;
+	gid_t groups[32] = {0};
+#if SYZ_EXECUTOR
+	if (flag_sandbox_identity) {
+		uid = sandbox_identity.uid;
+		gid = sandbox_identity.gid;
+		for (; ngroups < (int)sandbox_identity.ngroups; ngroups++)
+			groups[ngroups] = sandbox_identity.groups[ngroups];
+		drop_capability_bounding_set();
+	}
+#endif
+	if (setgroups(ngroups, groups))
 		fail("failed to setgroups");
-	if (syscall(SYS_setresgid, nobody, nobody, nobody))
+	if (syscall(SYS_setresgid, gid, gid, gid))
 		fail("failed to setresgid");
-	if (syscall(SYS_setresuid, nobody, nobody, nobody))
+	if (syscall(SYS_setresuid, uid, uid, uid))
 		fail("failed to setresuid");
 
 	// This is required to open /proc/self/* files.
 	// Otherwise they are owned by root and we can't open them after setuid.
 	// See task_dump_owner function in kernel.
 	prctl(PR_SET_DUMPABLE, 1, 0, 0, 0);
+#if SYZ_EXECUTOR
+	if (flag_sandbox_identity && sandbox_identity.no_new_privs && prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0))
+		fail("prctl(PR_SET_NO_NEW_PRIVS) failed");
+#endif
 
 	loop();
 	doexit(1);
@@ -2580,6 +2731,76 @@ static void reset_loop()
 }
 #endif
 
+#if SYZ_EXECUTOR
+// Moves the test process into a fresh net namespace with new network devices,
+// so that network state left by previous programs (routes, sockets, device settings)
+// does not affect this program. It's much slower than reset_net_namespace.
+static void recycle_net_namespace()
+{
+	if (!flag_enable_net_recycle)
+		return;
+	if (unshare(CLONE_NEWNET))
+		fail("unshare(CLONE_NEWNET) failed");
+	initialize_tun();
+	initialize_netdevices();
+}
+
+#include <sys/mount.h>
+
+// Moves the test process into fresh mount and ipc namespaces and mounts a tmpfs
+// over its work dir, so that mounts and System V IPC objects do not outlive the program
+// (see ipc.ResetNamespace).
+static void reset_namespace()
+{
+	if (!flag_reset_namespace)
+		return;
+	char cwd[1024];
+	if (!getcwd(cwd, sizeof(cwd)))
+		fail("getcwd failed");
+	if (unshare(CLONE_NEWNS | CLONE_NEWIPC))
+		fail("unshare(CLONE_NEWNS|CLONE_NEWIPC) failed");
+	// Don't propagate mounts done by the program back to the parent namespace.
+	if (mount(NULL, "/", NULL, MS_REC | MS_PRIVATE, NULL))
+		fail("mount(/, MS_REC|MS_PRIVATE) failed");
+	if (mount("syz-tmpfs", cwd, "tmpfs", 0, NULL))
+		fail("mount(%s, tmpfs) failed", cwd);
+	// The cwd still refers to the old directory that is now hidden under the tmpfs,
+	// chdir again to move into the new mount.
+	if (chdir(cwd))
+		fail("chdir(%s) failed", cwd);
+}
+
+#include <linux/filter.h>
+#include <linux/seccomp.h>
+#include <sys/prctl.h>
+
+// Applies the sandbox profile received during handshake (see ipc.SandboxProfile) to the test process.
+// LSM profiles are applied first, because the seccomp filter may prohibit writes to /proc.
+static void apply_sandbox_profile()
+{
+	if (!flag_sandbox_profile)
+		return;
+	if (sandbox_profile.apparmor[0]) {
+		// Kernels with LSM stacking have a separate AppArmor attr dir.
+		if (!write_file("/proc/self/attr/apparmor/current", "changeprofile %s", sandbox_profile.apparmor) &&
+		    !write_file("/proc/self/attr/current", "changeprofile %s", sandbox_profile.apparmor))
+			fail("failed to change AppArmor profile to %s", sandbox_profile.apparmor);
+	}
+	if (sandbox_profile.selinux[0])
+		syz_setcon(sandbox_profile.selinux);
+	if (sandbox_profile.seccomp_len) {
+		struct sock_fprog prog;
+		prog.len = sandbox_profile.seccomp_len;
+		prog.filter = (struct sock_filter*)sandbox_seccomp;
+		// Unprivileged processes (sandbox=setuid/namespace) can install a filter only with no_new_privs.
+		if (prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0))
+			fail("prctl(PR_SET_NO_NEW_PRIVS) failed");
+		if (prctl(PR_SET_SECCOMP, SECCOMP_MODE_FILTER, &prog, 0, 0))
+			fail("failed to install seccomp filter");
+	}
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_REPEAT
 #include <sys/prctl.h>
 
@@ -2593,11 +2814,19 @@ static void setup_test()
 #endif
 	// It's the leaf test process we want to be always killed first.
 	write_file("/proc/self/oom_score_adj", "1000");
+#if SYZ_EXECUTOR
+	recycle_net_namespace();
+	reset_namespace();
+#endif
 #if SYZ_EXECUTOR || SYZ_TUN_ENABLE
 	// Read all remaining packets from tun to better
 	// isolate consequently executing programs.
 	flush_tun();
 #endif
+#if SYZ_EXECUTOR
+	// Must be the last, the profile may prohibit some of the above.
+	apply_sandbox_profile();
+#endif
 }
 #endif
 
@@ -0,0 +1,596 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// This file is shared between executor and csource package.
+
+// Implementation of syz_9p_*, syz_nfs_* and syz_smb_* pseudo-syscalls.
+// The in-kernel network filesystem client is connected to a server socket owned by the program.
+// syz_*_connect mounts the filesystem while a helper thread answers requests of the client
+// with the responses provided by the fuzzer (the mount needs several round-trips to complete).
+// The helper thread is not traced by KCOV, so coverage is collected for the mount itself.
+// After that syz_*_io answer client requests caused by other calls one at a time.
+
+#define NETFS_MAX_CONNS 4
+#define NETFS_MAX_RESP_LEN (64 << 10)
+#define NETFS_OPTS_LEN 512
+
+static int netfs_read_full(int fd, void* buf, uint32 len)
+{
+	uint32 pos = 0;
+	while (pos < len) {
+		int rv = read(fd, (char*)buf + pos, len - pos);
+		if (rv <= 0) {
+			if (rv == -1 && errno == EINTR)
+				continue;
+			return -1;
+		}
+		pos += rv;
+	}
+	return 0;
+}
+
+static int netfs_discard(int fd, uint32 len)
+{
+	char buf[512];
+	while (len) {
+		uint32 n = len < sizeof(buf) ? len : sizeof(buf);
+		if (netfs_read_full(fd, buf, n))
+			return -1;
+		len -= n;
+	}
+	return 0;
+}
+
+static bool netfs_write_full(int fd, struct iovec* iov, int iovcnt)
+{
+	ssize_t total = 0;
+	int i;
+	for (i = 0; i < iovcnt; i++)
+		total += iov[i].iov_len;
+	// Response data points to the fuzzer memory, so the write may fail with EFAULT.
+	// A partially written message breaks the stream framing, so report it as failure.
+	return writev(fd, iov, iovcnt) == total;
+}
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_nfs_connect || __NR_syz_smb_connect
+// netfs_server answers requests that arrive on the kernel connections during mount.
+// For TCP-based filesystems the kernel may open several connections (e.g. NFSv3 talks
+// to the MOUNT and NFS programs separately), so the server accepts up to NETFS_MAX_CONNS.
+struct netfs_server {
+	int listen_fd;
+	int fds[NETFS_MAX_CONNS];
+	int nfds;
+	void* resps;
+	bool (*serve)(int fd, void* resps);
+	int done;
+};
+
+static void* netfs_server_thread(void* arg)
+{
+	struct netfs_server* srv = (struct netfs_server*)arg;
+	while (!__atomic_load_n(&srv->done, __ATOMIC_RELAXED)) {
+		struct pollfd pfds[NETFS_MAX_CONNS + 1];
+		memset(pfds, 0, sizeof(pfds));
+		// poll ignores negative fds, this is used both for the listening socket
+		// of socketpair-based servers and for closed connections.
+		pfds[0].fd = srv->nfds < NETFS_MAX_CONNS ? srv->listen_fd : -1;
+		pfds[0].events = POLLIN;
+		int i;
+		for (i = 0; i < srv->nfds; i++) {
+			pfds[i + 1].fd = srv->fds[i];
+			pfds[i + 1].events = POLLIN;
+		}
+		if (poll(pfds, srv->nfds + 1, 10) <= 0)
+			continue;
+		for (i = 0; i < srv->nfds; i++) {
+			if (!pfds[i + 1].revents)
+				continue;
+			if (!srv->serve(srv->fds[i], srv->resps)) {
+				// Closing the connection makes the kernel fail the request quickly
+				// instead of waiting for a response that will never come.
+				close(srv->fds[i]);
+				srv->fds[i] = -1;
+			}
+		}
+		if (pfds[0].revents) {
+			int fd = accept(srv->listen_fd, NULL, NULL);
+			if (fd != -1) {
+				debug("netfs: accepted connection %d\n", fd);
+				srv->fds[srv->nfds++] = fd;
+			}
+		}
+	}
+	return NULL;
+}
+
+// netfs_copy_opts copies user-provided mount options leaving space for the options
+// that connect the client to the server.
+static void netfs_copy_opts(char* opts, size_t size, const char* optsarg)
+{
+	memset(opts, 0, size);
+	NONFAILING(strncpy(opts, optsarg, size - 1));
+}
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect || __NR_syz_smb_connect
+static int netfs_listen(int* port)
+{
+	int fd = socket(AF_INET, SOCK_STREAM, 0);
+	if (fd == -1)
+		return -1;
+	struct sockaddr_in addr;
+	memset(&addr, 0, sizeof(addr));
+	addr.sin_family = AF_INET;
+	addr.sin_addr.s_addr = htonl(INADDR_LOOPBACK);
+	socklen_t addrlen = sizeof(addr);
+	if (bind(fd, (struct sockaddr*)&addr, sizeof(addr)) || listen(fd, NETFS_MAX_CONNS) ||
+	    getsockname(fd, (struct sockaddr*)&addr, &addrlen)) {
+		close(fd);
+		return -1;
+	}
+	*port = ntohs(addr.sin_port);
+	return fd;
+}
+#endif
+
+// netfs_connect mounts the filesystem while srv answers the requests.
+// Returns the last live server connection on success.
+static long netfs_connect(struct netfs_server* srv, const char* source, const char* dir,
+			  const char* fs, long flags, const char* opts)
+{
+	pthread_t th;
+	long res = -1;
+	int rv = -1, err = 0, i;
+	srv->done = 0;
+	if (pthread_create(&th, NULL, netfs_server_thread, srv) == 0) {
+		mkdir(dir, 0777);
+		debug("netfs: mounting %s on %s, opts='%s'\n", fs, dir, opts);
+		rv = mount(source, dir, fs, flags, opts);
+		err = errno;
+		__atomic_store_n(&srv->done, 1, __ATOMIC_RELAXED);
+		pthread_join(th, NULL);
+		debug("netfs: mount returned %d (errno %d)\n", rv, rv ? err : 0);
+	} else {
+		err = errno;
+		debug("netfs: pthread_create failed: %d\n", err);
+	}
+	for (i = 0; i < srv->nfds; i++) {
+		if (rv != 0)
+			close(srv->fds[i]);
+		else if (srv->fds[i] != -1)
+			res = srv->fds[i];
+	}
+	if (srv->listen_fd != -1)
+		close(srv->listen_fd);
+	errno = err;
+	return res;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_9p_io
+struct p9_response {
+	uint8 type;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct p9_responses {
+	uint32 len;
+	struct p9_response* generic;
+	struct p9_response* resps[0];
+} __attribute__((packed));
+
+struct p9_header {
+	uint32 size;
+	uint8 type;
+	uint16 tag;
+} __attribute__((packed));
+
+static bool lookup_9p_response(struct p9_responses* resps, uint8 req_type,
+			       uint8* type, char** data, uint32* len)
+{
+	int resps_num = (resps->len - offsetof(struct p9_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
+
+	// Every 9p R-message type is the corresponding T-message type + 1.
+	for (i = 0; i < resps_num; i++) {
+		struct p9_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		if (resp->type == req_type + 1) {
+			*type = resp->type;
+			*data = &resp->data[0];
+			*len = resp->len;
+			return true;
+		}
+	}
+
+	if (resps->generic) {
+		*type = resps->generic->type;
+		*data = &resps->generic->data[0];
+		*len = resps->generic->len;
+		return true;
+	}
+
+	return false;
+}
+
+static bool netfs_serve_9p(int fd, void* arg)
+{
+	struct p9_responses* resps = (struct p9_responses*)arg;
+	struct p9_header req;
+	if (netfs_read_full(fd, &req, sizeof(req)) || req.size < sizeof(req) ||
+	    netfs_discard(fd, req.size - sizeof(req)))
+		return false;
+	debug("syz_9p: request type=%d tag=0x%x size=%u\n", req.type, req.tag, req.size);
+
+	bool response_found = false;
+	uint8 type = 0;
+	char* data = NULL;
+	uint32 len = 0;
+	NONFAILING(response_found = lookup_9p_response(resps, req.type, &type, &data, &len));
+	if (!response_found)
+		return false;
+	if (len > NETFS_MAX_RESP_LEN)
+		len = 0;
+
+	struct p9_header resp;
+	resp.size = sizeof(resp) + len;
+	resp.type = type;
+	resp.tag = req.tag;
+	struct iovec iov[2] = {{&resp, sizeof(resp)}, {data, len}};
+	debug("syz_9p: response type=%d size=%u\n", resp.type, resp.size);
+	return netfs_write_full(fd, iov, 2);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect
+// The kernel reads and writes 9p messages from/to a socketpair end passed with trans=fd.
+static volatile long syz_9p_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int sv[2];
+
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	if (socketpair(AF_UNIX, SOCK_STREAM, 0, sv))
+		return -1;
+	snprintf(opts, sizeof(opts), "trans=fd,rfdno=%d,wfdno=%d,%s", sv[1], sv[1], user_opts);
+	srv.listen_fd = -1;
+	srv.fds[0] = sv[0];
+	srv.nfds = 1;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_9p;
+	long res = netfs_connect(&srv, "syz", (char*)a0, "9p", a1, opts);
+	int err = errno;
+	// The kernel holds its own reference to the client end.
+	close(sv[1]);
+	errno = err;
+	return res;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_io
+static volatile long syz_9p_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct p9_responses* resps = (struct p9_responses*)a1;
+
+	if (!netfs_serve_9p(fd, resps))
+		return -1;
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect || __NR_syz_nfs_io
+// ONC RPC (RFC 5531) over TCP, every message is prefixed with a record marker.
+#define RPC_LAST_FRAGMENT 0x80000000
+#define RPC_REPLY 1
+#define RPC_MSG_ACCEPTED 0
+
+struct nfs_response {
+	uint32 prog;
+	uint32 vers;
+	uint32 proc;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct nfs_responses {
+	uint32 len;
+	struct nfs_response* generic;
+	struct nfs_response* resps[0];
+} __attribute__((packed));
+
+struct rpc_call_header {
+	uint32 xid;
+	uint32 msg_type;
+	uint32 rpcvers;
+	uint32 prog;
+	uint32 vers;
+	uint32 proc;
+} __attribute__((packed));
+
+struct rpc_reply_header {
+	uint32 marker;
+	uint32 xid;
+	uint32 msg_type;
+	uint32 reply_stat;
+	uint32 verf_flavor;
+	uint32 verf_len;
+} __attribute__((packed));
+
+static bool lookup_nfs_response(struct nfs_responses* resps, uint32 prog, uint32 vers, uint32 proc,
+				char** data, uint32* len)
+{
+	int resps_num = (resps->len - offsetof(struct nfs_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
+
+	for (i = 0; i < resps_num; i++) {
+		struct nfs_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		// Procedure numbers are reused across protocol versions (e.g. NFSv3 GETATTR and NFSv4 COMPOUND).
+		if (resp->prog == prog && resp->vers == vers && resp->proc == proc) {
+			*data = &resp->data[0];
+			*len = resp->len;
+			return true;
+		}
+	}
+
+	if (resps->generic) {
+		*data = &resps->generic->data[0];
+		*len = resps->generic->len;
+		return true;
+	}
+
+	return false;
+}
+
+static bool netfs_serve_nfs(int fd, void* arg)
+{
+	struct nfs_responses* resps = (struct nfs_responses*)arg;
+	uint32 marker;
+	struct rpc_call_header req;
+	if (netfs_read_full(fd, &marker, sizeof(marker)))
+		return false;
+	// The kernel always sends calls in a single fragment.
+	uint32 size = ntohl(marker) & ~RPC_LAST_FRAGMENT;
+	if (size < sizeof(req) || netfs_read_full(fd, &req, sizeof(req)) ||
+	    netfs_discard(fd, size - sizeof(req)))
+		return false;
+	uint32 prog = ntohl(req.prog);
+	uint32 vers = ntohl(req.vers);
+	uint32 proc = ntohl(req.proc);
+	debug("syz_nfs: call xid=0x%x prog=%u vers=%u proc=%u size=%u\n",
+	      ntohl(req.xid), prog, vers, proc, size);
+
+	bool response_found = false;
+	char* data = NULL;
+	uint32 len = 0;
+	NONFAILING(response_found = lookup_nfs_response(resps, prog, vers, proc, &data, &len));
+	if (!response_found)
+		return false;
+	if (len > NETFS_MAX_RESP_LEN)
+		len = 0;
+
+	// The fuzzer provides accept_stat and the procedure results,
+	// the verifier is always AUTH_NONE.
+	struct rpc_reply_header resp;
+	resp.marker = htonl(RPC_LAST_FRAGMENT | (sizeof(resp) - sizeof(resp.marker) + len));
+	resp.xid = req.xid;
+	resp.msg_type = htonl(RPC_REPLY);
+	resp.reply_stat = htonl(RPC_MSG_ACCEPTED);
+	resp.verf_flavor = 0;
+	resp.verf_len = 0;
+	struct iovec iov[2] = {{&resp, sizeof(resp)}, {data, len}};
+	debug("syz_nfs: reply len=%u\n", len);
+	return netfs_write_full(fd, iov, 2);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect
+// Both MOUNT and NFS programs are served on the same port, so that the kernel
+// doesn't need to query rpcbind. Locking would require rpc.statd, so it's disabled,
+// and short soft timeouts make the client give up when the fuzzer doesn't answer.
+static volatile long syz_nfs_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int port = 0;
+
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	srv.listen_fd = netfs_listen(&port);
+	if (srv.listen_fd == -1)
+		return -1;
+	srv.nfds = 0;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_nfs;
+	snprintf(opts, sizeof(opts),
+		 "addr=127.0.0.1,port=%d,mountaddr=127.0.0.1,mountport=%d,"
+		 "proto=tcp,mountproto=tcp,nolock,soft,timeo=10,retrans=1,%s",
+		 port, port, user_opts);
+	return netfs_connect(&srv, "127.0.0.1:/syz", (char*)a0, "nfs", a1, opts);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_io
+static volatile long syz_nfs_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct nfs_responses* resps = (struct nfs_responses*)a1;
+
+	if (!netfs_serve_nfs(fd, resps))
+		return -1;
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_smb_connect || __NR_syz_smb_io
+// SMB2 over direct TCP: every message is prefixed with a 4-byte big-endian length,
+// the kernel may send several compounded requests in one message.
+#define SMB2_HEADER_LEN 64
+#define SMB2_FLAGS_SERVER_TO_REDIR 0x1
+#define SMB2_MAX_COMPOUND 8
+#define SMB2_MAX_REQUEST_LEN 4096
+
+struct smb_response {
+	uint16 command;
+	uint32 status;
+	uint16 credits;
+	uint64 session_id;
+	uint32 tree_id;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct smb_responses {
+	uint32 len;
+	struct smb_response* generic;
+	struct smb_response* resps[0];
+} __attribute__((packed));
+
+struct smb2_header {
+	uint8 protocol[4];
+	uint16 structure_size;
+	uint16 credit_charge;
+	uint32 status;
+	uint16 command;
+	uint16 credits;
+	uint32 flags;
+	uint32 next_command;
+	uint64 message_id;
+	uint32 process_id;
+	uint32 tree_id;
+	uint64 session_id;
+	uint8 signature[16];
+} __attribute__((packed));
+
+static bool lookup_smb_response(struct smb_responses* resps, uint16 command,
+				struct smb_response* hdr, char** data)
+{
+	int resps_num = (resps->len - offsetof(struct smb_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
+
+	for (i = 0; i < resps_num; i++) {
+		struct smb_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		if (resp->command == command) {
+			memcpy(hdr, resp, sizeof(*hdr));
+			*data = &resp->data[0];
+			return true;
+		}
+	}
+
+	if (resps->generic) {
+		memcpy(hdr, resps->generic, sizeof(*hdr));
+		*data = &resps->generic->data[0];
+		return true;
+	}
+
+	return false;
+}
+
+static bool netfs_serve_smb(int fd, void* arg)
+{
+	struct smb_responses* resps = (struct smb_responses*)arg;
+	char req[SMB2_MAX_REQUEST_LEN];
+	uint32 size;
+	if (netfs_read_full(fd, &size, sizeof(size)))
+		return false;
+	size = ntohl(size) & 0xffffff;
+	uint32 req_len = size < sizeof(req) ? size : sizeof(req);
+	if (req_len < SMB2_HEADER_LEN || netfs_read_full(fd, req, req_len) ||
+	    netfs_discard(fd, size - req_len))
+		return false;
+
+	struct smb2_header hdrs[SMB2_MAX_COMPOUND];
+	struct iovec iov[1 + 3 * SMB2_MAX_COMPOUND];
+	static const char pad[8] = {0};
+	uint32 total = 0, off = 0, padding = 0;
+	int n = 0, iovcnt = 1;
+	while (n < SMB2_MAX_COMPOUND && off + SMB2_HEADER_LEN <= req_len) {
+		struct smb2_header* hdr = &hdrs[n++];
+		memcpy(hdr, req + off, sizeof(*hdr));
+		if (memcmp(hdr->protocol, "\xfeSMB", 4))
+			return false;
+		debug("syz_smb: request command=%d mid=%llu size=%u\n",
+		      hdr->command, (unsigned long long)hdr->message_id, size);
+		uint32 next = hdr->next_command;
+
+		bool response_found = false;
+		struct smb_response resp;
+		char* data = NULL;
+		NONFAILING(response_found = lookup_smb_response(resps, hdr->command, &resp, &data));
+		if (!response_found)
+			return false;
+		if (resp.len > NETFS_MAX_RESP_LEN)
+			resp.len = 0;
+		hdr->command = resp.command;
+		hdr->status = resp.status;
+		hdr->credits = resp.credits;
+		hdr->flags |= SMB2_FLAGS_SERVER_TO_REDIR;
+		if (resp.session_id)
+			hdr->session_id = resp.session_id;
+		if (resp.tree_id)
+			hdr->tree_id = resp.tree_id;
+		memset(hdr->signature, 0, sizeof(hdr->signature));
+		iov[iovcnt].iov_base = hdr;
+		iov[iovcnt++].iov_len = sizeof(*hdr);
+		iov[iovcnt].iov_base = data;
+		iov[iovcnt++].iov_len = resp.len;
+		// Compounded responses are 8-byte aligned and chained with next_command.
+		padding = (8 - (sizeof(*hdr) + resp.len) % 8) % 8;
+		iov[iovcnt].iov_base = (void*)pad;
+		iov[iovcnt++].iov_len = padding;
+		hdr->next_command = sizeof(*hdr) + resp.len + padding;
+		total += hdr->next_command;
+		if (!next || next > req_len - off)
+			break;
+		off += next;
+	}
+	// The last response in the chain is not padded.
+	hdrs[n - 1].next_command = 0;
+	iov[--iovcnt].iov_len = 0;
+	total -= padding;
+	uint32 netbios = htonl(total);
+	iov[0].iov_base = &netbios;
+	iov[0].iov_len = sizeof(netbios);
+	debug("syz_smb: response of %d commands, size=%u\n", n, total);
+	return netfs_write_full(fd, iov, iovcnt);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_smb_connect
+static volatile long syz_smb_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int port = 0;
+
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	srv.listen_fd = netfs_listen(&port);
+	if (srv.listen_fd == -1)
+		return -1;
+	srv.nfds = 0;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_smb;
+	snprintf(opts, sizeof(opts),
+		 "ip=127.0.0.1,port=%d,unc=\\\\127.0.0.1\\syz,username=syz,password=syz,%s",
+		 port, user_opts);
+	// The backslash form of the UNC name is used since csource strips everything after //.
+	return netfs_connect(&srv, "\\\\127.0.0.1\\syz", (char*)a0, "cifs", a1, opts);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_smb_io
+static volatile long syz_smb_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct smb_responses* resps = (struct smb_responses*)a1;
+
+	if (!netfs_serve_smb(fd, resps))
+		return -1;
+	return 0;
+}
+#endif
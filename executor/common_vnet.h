@@ -0,0 +1,652 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// This file is shared between executor and csource package.
+
+// Implementation of syz_vtcp_* pseudo-syscalls.
+// With syz_emit_ethernet the program has to track TCP state itself (see syz_extract_tcp_res),
+// so the fuzzer rarely gets past the handshake and any mutation of sequence numbers
+// or checksums makes the kernel drop the following segments.
+// These calls play the remote peer of TCP connections with local kernel sockets over tun.
+// syz_vtcp_connect/accept perform the handshake and remember the connection 4-tuple and
+// sequence numbers in the executor. syz_vtcp_send sends a fuzzer-provided segment
+// and optionally fixes up ports, sequence/ack numbers and checksum after mutation.
+// syz_vtcp_recv consumes segments sent by the kernel to advance the ack number.
+// syz_vtcp_tls installs kTLS keys on the local socket and remembers them for the remote peer,
+// so that syz_vtcp_tls_send can send records that the kernel is able to decrypt.
+
+#define VTCP_MAX_CONNS 8
+#define VTCP_MAX_SEGMENT 1400
+#define VTCP_MAX_OPTIONS 40
+#define VTCP_MAX_PACKETS 32
+#define VTCP_WAIT_MS 20
+
+#define VTCP_FIXUP_PORTS (1 << 0)
+#define VTCP_FIXUP_SEQ (1 << 1)
+#define VTCP_FIXUP_ACK (1 << 2)
+#define VTCP_FIXUP_CSUM (1 << 3)
+
+#define VTCP_FLAG_FIN 0x01
+#define VTCP_FLAG_SYN 0x02
+#define VTCP_FLAG_RST 0x04
+#define VTCP_FLAG_PSH 0x08
+#define VTCP_FLAG_ACK 0x10
+
+// The window we advertise, the kernel is free to send as much as it wants.
+#define VTCP_WINDOW 65535
+
+struct vtcp_conn {
+	bool ipv6;
+	// Ports are in host byte order, lport is the port of the local kernel socket.
+	uint16 lport;
+	uint16 rport;
+	uint32 snd_nxt;
+	uint32 rcv_nxt;
+	// Remote side of kTLS, tls_version is 0 until syz_vtcp_tls succeeds.
+	uint16 tls_version;
+	uint8 tls_key[16];
+	uint8 tls_salt[4];
+	uint8 tls_iv[8];
+	uint64 tls_seq;
+};
+
+static struct vtcp_conn vtcp_conns[VTCP_MAX_CONNS];
+static int vtcp_nconns;
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept
+static long vtcp_add(struct vtcp_conn* conn)
+{
+	int idx = __atomic_fetch_add(&vtcp_nconns, 1, __ATOMIC_SEQ_CST);
+	if (idx >= VTCP_MAX_CONNS) {
+		errno = EMFILE;
+		return -1;
+	}
+	vtcp_conns[idx] = *conn;
+	debug("vtcp: conn %d: ipv6=%d lport=%d rport=%d snd_nxt=%08x rcv_nxt=%08x\n",
+	      idx, conn->ipv6, conn->lport, conn->rport, conn->snd_nxt, conn->rcv_nxt);
+	return idx;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send
+static struct vtcp_conn* vtcp_lookup(long idx)
+{
+	if (idx < 0 || idx >= VTCP_MAX_CONNS || idx >= __atomic_load_n(&vtcp_nconns, __ATOMIC_SEQ_CST)) {
+		errno = EBADF;
+		return NULL;
+	}
+	return &vtcp_conns[idx];
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send
+// vtcp_emit wraps the TCP segment into ethernet and IP headers of the remote peer
+// and writes it to tun. If csum is set, the TCP checksum is recalculated.
+static int vtcp_emit(struct vtcp_conn* conn, const uint8* seg, uint32 len, bool csum)
+{
+	uint8 frame[sizeof(struct ethhdr) + sizeof(struct ipv6hdr) + VTCP_MAX_SEGMENT];
+	if (len > VTCP_MAX_SEGMENT)
+		len = VTCP_MAX_SEGMENT;
+	struct ethhdr* eth = (struct ethhdr*)frame;
+	uint64 macaddr = LOCAL_MAC;
+	memcpy(eth->h_dest, &macaddr, ETH_ALEN);
+	macaddr = REMOTE_MAC;
+	memcpy(eth->h_source, &macaddr, ETH_ALEN);
+	struct csum_inet pseudo;
+	csum_inet_init(&pseudo);
+	uint32 off = sizeof(struct ethhdr);
+	if (!conn->ipv6) {
+		eth->h_proto = htons(ETH_P_IP);
+		struct iphdr* ip = (struct iphdr*)&frame[off];
+		memset(ip, 0, sizeof(*ip));
+		ip->version = 4;
+		ip->ihl = sizeof(*ip) / 4;
+		ip->tot_len = htons(sizeof(*ip) + len);
+		ip->ttl = 64;
+		ip->protocol = IPPROTO_TCP;
+		inet_pton(AF_INET, REMOTE_IPV4, &ip->saddr);
+		inet_pton(AF_INET, LOCAL_IPV4, &ip->daddr);
+		struct csum_inet csum_ip;
+		csum_inet_init(&csum_ip);
+		csum_inet_update(&csum_ip, (const uint8*)ip, sizeof(*ip));
+		ip->check = csum_inet_digest(&csum_ip);
+		csum_inet_update(&pseudo, (const uint8*)&ip->saddr, 2 * sizeof(ip->saddr));
+		off += sizeof(*ip);
+	} else {
+		eth->h_proto = htons(ETH_P_IPV6);
+		struct ipv6hdr* ip = (struct ipv6hdr*)&frame[off];
+		memset(ip, 0, sizeof(*ip));
+		ip->version = 6;
+		ip->payload_len = htons(len);
+		ip->nexthdr = IPPROTO_TCP;
+		ip->hop_limit = 64;
+		inet_pton(AF_INET6, REMOTE_IPV6, &ip->saddr);
+		inet_pton(AF_INET6, LOCAL_IPV6, &ip->daddr);
+		csum_inet_update(&pseudo, (const uint8*)&ip->saddr, 2 * sizeof(ip->saddr));
+		off += sizeof(*ip);
+	}
+	memcpy(&frame[off], seg, len);
+	if (csum && len >= sizeof(struct tcphdr)) {
+		// The rest of the pseudo header sums up to the same value for IPv4 and IPv6.
+		uint8 rest[4] = {0, IPPROTO_TCP, (uint8)(len >> 8), (uint8)len};
+		csum_inet_update(&pseudo, rest, sizeof(rest));
+		struct tcphdr* tcp = (struct tcphdr*)&frame[off];
+		tcp->check = 0;
+		csum_inet_update(&pseudo, &frame[off], len);
+		tcp->check = csum_inet_digest(&pseudo);
+	}
+	debug_dump_data((const char*)frame, off + len);
+	if (write(tunfd, frame, off + len) != (ssize_t)(off + len))
+		return -1;
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept
+// vtcp_emit_ctl sends a segment without payload with the given flags and current sequence numbers.
+static int vtcp_emit_ctl(struct vtcp_conn* conn, uint8 flags, const uint8* opts, uint32 optlen)
+{
+	uint8 seg[sizeof(struct tcphdr) + VTCP_MAX_OPTIONS];
+	memset(seg, 0, sizeof(seg));
+	if (optlen > VTCP_MAX_OPTIONS)
+		optlen = VTCP_MAX_OPTIONS;
+	if (opts)
+		NONFAILING(memcpy(&seg[sizeof(struct tcphdr)], opts, optlen));
+	// Options are padded with zeros (TCPOPT_EOL) up to the header length.
+	optlen = (optlen + 3) & ~3;
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	tcp->source = htons(conn->rport);
+	tcp->dest = htons(conn->lport);
+	tcp->seq = htonl(conn->snd_nxt);
+	if (flags & VTCP_FLAG_ACK)
+		tcp->ack_seq = htonl(conn->rcv_nxt);
+	tcp->doff = (sizeof(struct tcphdr) + optlen) / 4;
+	seg[13] = flags;
+	tcp->window = htons(VTCP_WINDOW);
+	return vtcp_emit(conn, seg, sizeof(struct tcphdr) + optlen, true);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv
+// vtcp_parse returns the TCP header if the packet belongs to the connection
+// and stores the length of the segment payload in *payload.
+static struct tcphdr* vtcp_parse(struct vtcp_conn* conn, uint8* data, uint32 length, uint32* payload)
+{
+	if (length < sizeof(struct ethhdr))
+		return NULL;
+	struct ethhdr* eth = (struct ethhdr*)data;
+	uint32 off = sizeof(struct ethhdr);
+	uint32 total = 0;
+	if (!conn->ipv6) {
+		if (eth->h_proto != htons(ETH_P_IP) || length < off + sizeof(struct iphdr))
+			return NULL;
+		struct iphdr* ip = (struct iphdr*)&data[off];
+		struct in_addr remote;
+		inet_pton(AF_INET, REMOTE_IPV4, &remote);
+		if (ip->protocol != IPPROTO_TCP || ip->daddr != remote.s_addr ||
+		    ntohs(ip->tot_len) < ip->ihl * 4)
+			return NULL;
+		total = ntohs(ip->tot_len) - ip->ihl * 4;
+		off += ip->ihl * 4;
+	} else {
+		if (eth->h_proto != htons(ETH_P_IPV6) || length < off + sizeof(struct ipv6hdr))
+			return NULL;
+		struct ipv6hdr* ip = (struct ipv6hdr*)&data[off];
+		struct in6_addr remote;
+		inet_pton(AF_INET6, REMOTE_IPV6, &remote);
+		// The kernel does not add extension headers to TCP segments by itself.
+		if (ip->nexthdr != IPPROTO_TCP || memcmp(&ip->daddr, &remote, sizeof(remote)) != 0)
+			return NULL;
+		total = ntohs(ip->payload_len);
+		off += sizeof(struct ipv6hdr);
+	}
+	if (length < off + sizeof(struct tcphdr) || total < sizeof(struct tcphdr))
+		return NULL;
+	struct tcphdr* tcp = (struct tcphdr*)&data[off];
+	if (ntohs(tcp->source) != conn->lport || ntohs(tcp->dest) != conn->rport ||
+	    tcp->doff * 4u < sizeof(struct tcphdr) || tcp->doff * 4u > total)
+		return NULL;
+	*payload = total - tcp->doff * 4;
+	return tcp;
+}
+
+// vtcp_receive reads packets from tun until it finds a segment of the connection.
+// Other packets are dropped. If there are no packets, it waits for up to wait_ms.
+static struct tcphdr* vtcp_receive(struct vtcp_conn* conn, uint8* data, int size, uint32* payload, int wait_ms)
+{
+	int i;
+	for (i = 0; i < VTCP_MAX_PACKETS;) {
+		int rv = read_tun((char*)data, size);
+		if (rv == -1) {
+			if (wait_ms <= 0)
+				return NULL;
+			struct pollfd pfd;
+			pfd.fd = tunfd;
+			pfd.events = POLLIN;
+			pfd.revents = 0;
+			poll(&pfd, 1, 1);
+			wait_ms--;
+			continue;
+		}
+		i++;
+		debug_dump_data((const char*)data, rv);
+		struct tcphdr* tcp = vtcp_parse(conn, data, rv, payload);
+		if (tcp)
+			return tcp;
+	}
+	return NULL;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv
+static void vtcp_track(struct vtcp_conn* conn, struct tcphdr* tcp, uint32 payload)
+{
+	uint32 end = ntohl(tcp->seq) + payload + tcp->syn + tcp->fin;
+	if ((int)(end - conn->rcv_nxt) > 0)
+		conn->rcv_nxt = end;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect
+static long syz_vtcp_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3, volatile long a4, volatile long a5)
+{
+	// syz_vtcp_connect(af flags[vtcp_af], lport sock_port, rport sock_port, iss int32,
+	//	opts ptr[in, tcp_options, opt], optlen len[opts]) vtcp_conn
+	// The remote peer connects to a listening local socket.
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn conn;
+	memset(&conn, 0, sizeof(conn));
+	conn.ipv6 = a0 == AF_INET6;
+	// Ports are int16be, so the executor receives them in network byte order.
+	conn.lport = ntohs((uint16)a1);
+	conn.rport = ntohs((uint16)a2);
+	conn.snd_nxt = (uint32)a3;
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_SYN, (const uint8*)a4, (uint32)a5))
+		return -1;
+	conn.snd_nxt++;
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, VTCP_WAIT_MS);
+		if (tcp == NULL) {
+			errno = ETIMEDOUT;
+			return -1;
+		}
+		if (tcp->rst) {
+			errno = ECONNREFUSED;
+			return -1;
+		}
+		if (tcp->syn && tcp->ack && ntohl(tcp->ack_seq) == conn.snd_nxt) {
+			conn.rcv_nxt = ntohl(tcp->seq) + 1;
+			break;
+		}
+	}
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_ACK, NULL, 0))
+		return -1;
+	return vtcp_add(&conn);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_accept
+static long syz_vtcp_accept(volatile long a0, volatile long a1, volatile long a2, volatile long a3, volatile long a4, volatile long a5)
+{
+	// syz_vtcp_accept(fd sock_tcp, af const[AF_INET], rport sock_port, iss int32,
+	//	opts ptr[in, tcp_options, opt], optlen len[opts]) vtcp_conn
+	// The local socket connects to the remote peer, which accepts the connection.
+	if (tunfd < 0)
+		return -1;
+	int fd = a0;
+	struct vtcp_conn conn;
+	memset(&conn, 0, sizeof(conn));
+	conn.ipv6 = a1 == AF_INET6;
+	conn.rport = ntohs((uint16)a2);
+	struct sockaddr_storage addr;
+	memset(&addr, 0, sizeof(addr));
+	socklen_t addrlen = 0;
+	if (!conn.ipv6) {
+		struct sockaddr_in* sin = (struct sockaddr_in*)&addr;
+		sin->sin_family = AF_INET;
+		sin->sin_port = htons(conn.rport);
+		inet_pton(AF_INET, REMOTE_IPV4, &sin->sin_addr);
+		addrlen = sizeof(*sin);
+	} else {
+		struct sockaddr_in6* sin6 = (struct sockaddr_in6*)&addr;
+		sin6->sin6_family = AF_INET6;
+		sin6->sin6_port = htons(conn.rport);
+		inet_pton(AF_INET6, REMOTE_IPV6, &sin6->sin6_addr);
+		// The remote address is link-local.
+		sin6->sin6_scope_id = if_nametoindex(TUN_IFACE);
+		addrlen = sizeof(*sin6);
+	}
+	// Blocking connect would wait for the SYN-ACK that we are going to send.
+	int flags = fcntl(fd, F_GETFL);
+	if (flags == -1)
+		return -1;
+	fcntl(fd, F_SETFL, flags | O_NONBLOCK);
+	int rv = connect(fd, (struct sockaddr*)&addr, addrlen);
+	int err = errno;
+	fcntl(fd, F_SETFL, flags);
+	if (rv == -1 && err != EINPROGRESS) {
+		errno = err;
+		return -1;
+	}
+	memset(&addr, 0, sizeof(addr));
+	addrlen = sizeof(addr);
+	if (getsockname(fd, (struct sockaddr*)&addr, &addrlen))
+		return -1;
+	conn.lport = ntohs(((struct sockaddr_in*)&addr)->sin_port);
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, VTCP_WAIT_MS);
+		if (tcp == NULL) {
+			errno = ETIMEDOUT;
+			return -1;
+		}
+		if (tcp->syn && !tcp->ack) {
+			conn.rcv_nxt = ntohl(tcp->seq) + 1;
+			break;
+		}
+	}
+	conn.snd_nxt = (uint32)a3;
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_SYN | VTCP_FLAG_ACK, (const uint8*)a4, (uint32)a5))
+		return -1;
+	conn.snd_nxt++;
+	// Consume the final ACK of the handshake if it's already there, it's not required
+	// for the connection to be established on our side.
+	struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, 0);
+	if (tcp)
+		vtcp_track(&conn, tcp, payload);
+	return vtcp_add(&conn);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send
+// vtcp_send applies fixups to the segment and sends it.
+// The send sequence number is advanced if the segment continues the stream.
+static long vtcp_send(struct vtcp_conn* conn, uint32 fixups, uint8* seg, uint32 len)
+{
+	if (len < sizeof(struct tcphdr)) {
+		errno = EINVAL;
+		return -1;
+	}
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	if (fixups & VTCP_FIXUP_PORTS) {
+		tcp->source = htons(conn->rport);
+		tcp->dest = htons(conn->lport);
+	}
+	if (fixups & VTCP_FIXUP_SEQ)
+		tcp->seq = htonl(conn->snd_nxt);
+	if (fixups & VTCP_FIXUP_ACK)
+		tcp->ack_seq = htonl(conn->rcv_nxt);
+	uint32 hdrlen = tcp->doff * 4;
+	if (hdrlen < sizeof(struct tcphdr) || hdrlen > len)
+		hdrlen = len;
+	if (ntohl(tcp->seq) == conn->snd_nxt)
+		conn->snd_nxt += len - hdrlen + tcp->syn + tcp->fin;
+	return vtcp_emit(conn, seg, len, fixups & VTCP_FIXUP_CSUM);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send
+static long syz_vtcp_send(volatile long a0, volatile long a1, volatile long a2)
+{
+	// syz_vtcp_send(conn vtcp_conn, seg ptr[in, vtcp_segment], len bytesize[seg])
+	// vtcp_segment {
+	//	fixups	flags[vtcp_fixups, int32]
+	//	header	vtcp_header
+	//	payload	array[int8]
+	// }
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	uint32 len = a2;
+	if (len < sizeof(uint32)) {
+		errno = EINVAL;
+		return -1;
+	}
+	len -= sizeof(uint32);
+	if (len > VTCP_MAX_SEGMENT)
+		len = VTCP_MAX_SEGMENT;
+	uint32 fixups = 0;
+	uint8 seg[VTCP_MAX_SEGMENT];
+	memset(seg, 0, sizeof(seg));
+	NONFAILING(fixups = *(uint32*)a1);
+	NONFAILING(memcpy(seg, (char*)a1 + sizeof(uint32), len));
+	return vtcp_send(conn, fixups, seg, len);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_recv
+static long syz_vtcp_recv(volatile long a0)
+{
+	// syz_vtcp_recv(conn vtcp_conn)
+	// Consumes all segments the kernel sent on the connection and returns the total payload length.
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	long total = 0;
+	int wait_ms = VTCP_WAIT_MS;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(conn, data, sizeof(data), &payload, wait_ms);
+		if (tcp == NULL)
+			break;
+		vtcp_track(conn, tcp, payload);
+		total += payload;
+		wait_ms = 0;
+	}
+	return total;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send
+#ifndef SOL_TLS
+#define SOL_TLS 282
+#endif
+#ifndef TCP_ULP
+#define TCP_ULP 31
+#endif
+#define VTCP_TLS_TX 1
+#define VTCP_TLS_RX 2
+#define VTCP_TLS_1_3_VERSION 0x0304
+#define VTCP_TLS_MAX_DATA 1024
+#define VTCP_TLS_TAG_SIZE 16
+
+// Same as struct tls12_crypto_info_aes_gcm_128.
+struct vtcp_tls_info {
+	uint16 version;
+	uint16 cipher_type;
+	uint8 iv[8];
+	uint8 key[16];
+	uint8 salt[4];
+	uint8 rec_seq[8];
+};
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls
+static long syz_vtcp_tls(volatile long a0, volatile long a1, volatile long a2)
+{
+	// syz_vtcp_tls(conn vtcp_conn, fd sock_tcp, info ptr[in, tls12_crypto_info_aes_gcm_128])
+	// The handshake is assumed to be done, we install the same key for both directions.
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	int fd = a1;
+	struct vtcp_tls_info info;
+	memset(&info, 0, sizeof(info));
+	NONFAILING(memcpy(&info, (void*)a2, sizeof(info)));
+	// This fails if the ULP is already attached, which is fine.
+	setsockopt(fd, IPPROTO_TCP, TCP_ULP, "tls", sizeof("tls"));
+	setsockopt(fd, SOL_TLS, VTCP_TLS_TX, &info, sizeof(info));
+	if (setsockopt(fd, SOL_TLS, VTCP_TLS_RX, &info, sizeof(info)))
+		return -1;
+	conn->tls_version = info.version;
+	memcpy(conn->tls_key, info.key, sizeof(conn->tls_key));
+	memcpy(conn->tls_salt, info.salt, sizeof(conn->tls_salt));
+	memcpy(conn->tls_iv, info.iv, sizeof(conn->tls_iv));
+	conn->tls_seq = 0;
+	unsigned i;
+	for (i = 0; i < sizeof(info.rec_seq); i++)
+		conn->tls_seq = (conn->tls_seq << 8) | info.rec_seq[i];
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls_send
+#ifndef SOL_ALG
+#define SOL_ALG 279
+#endif
+
+// vtcp_aead_encrypt encrypts data with AES-GCM-128 using AF_ALG.
+// The output is the ciphertext followed by the tag.
+static int vtcp_aead_encrypt(const uint8* key, const uint8* nonce, const uint8* aad, uint32 aadlen,
+			     const uint8* data, uint32 len, uint8* out)
+{
+	int tfm = socket(AF_ALG, SOCK_SEQPACKET, 0);
+	if (tfm == -1)
+		return -1;
+	struct sockaddr_alg sa;
+	memset(&sa, 0, sizeof(sa));
+	sa.salg_family = AF_ALG;
+	strcpy((char*)sa.salg_type, "aead");
+	strcpy((char*)sa.salg_name, "gcm(aes)");
+	int op = -1;
+	if (bind(tfm, (struct sockaddr*)&sa, sizeof(sa)) == 0 &&
+	    setsockopt(tfm, SOL_ALG, ALG_SET_KEY, key, 16) == 0 &&
+	    setsockopt(tfm, SOL_ALG, ALG_SET_AEAD_AUTHSIZE, NULL, VTCP_TLS_TAG_SIZE) == 0)
+		op = accept(tfm, NULL, NULL);
+	close(tfm);
+	if (op == -1)
+		return -1;
+	char control[CMSG_SPACE(sizeof(uint32)) + CMSG_SPACE(sizeof(struct af_alg_iv) + 12) +
+		     CMSG_SPACE(sizeof(uint32))];
+	memset(control, 0, sizeof(control));
+	struct msghdr msg;
+	memset(&msg, 0, sizeof(msg));
+	msg.msg_control = control;
+	msg.msg_controllen = sizeof(control);
+	struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_OP;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(uint32));
+	*(uint32*)CMSG_DATA(cmsg) = ALG_OP_ENCRYPT;
+	cmsg = CMSG_NXTHDR(&msg, cmsg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_IV;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(struct af_alg_iv) + 12);
+	struct af_alg_iv* iv = (struct af_alg_iv*)CMSG_DATA(cmsg);
+	iv->ivlen = 12;
+	memcpy(iv->iv, nonce, 12);
+	cmsg = CMSG_NXTHDR(&msg, cmsg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_AEAD_ASSOCLEN;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(uint32));
+	*(uint32*)CMSG_DATA(cmsg) = aadlen;
+	struct iovec iov[2];
+	iov[0].iov_base = (void*)aad;
+	iov[0].iov_len = aadlen;
+	iov[1].iov_base = (void*)data;
+	iov[1].iov_len = len;
+	msg.msg_iov = iov;
+	msg.msg_iovlen = 2;
+	int res = -1;
+	// The output is prefixed with a copy of the associated data.
+	uint8 buf[16 + VTCP_TLS_MAX_DATA + 1 + VTCP_TLS_TAG_SIZE];
+	if (sendmsg(op, &msg, 0) == (ssize_t)(aadlen + len) &&
+	    read(op, buf, aadlen + len + VTCP_TLS_TAG_SIZE) == (ssize_t)(aadlen + len + VTCP_TLS_TAG_SIZE)) {
+		memcpy(out, &buf[aadlen], len + VTCP_TLS_TAG_SIZE);
+		res = 0;
+	}
+	close(op);
+	return res;
+}
+
+// vtcp_tls_seal builds an encrypted TLS record in the way the kernel TLS_RX expects it
+// and returns the record length.
+// data must have room for one more byte.
+static int vtcp_tls_seal(struct vtcp_conn* conn, uint8 type, uint8* data, uint32 len, uint8* rec)
+{
+	uint8 seq[8];
+	unsigned i;
+	for (i = 0; i < sizeof(seq); i++)
+		seq[i] = conn->tls_seq >> (8 * (sizeof(seq) - 1 - i));
+	uint8 nonce[12];
+	memcpy(nonce, conn->tls_salt, sizeof(conn->tls_salt));
+	uint8 aad[13];
+	uint32 aadlen = 0;
+	uint32 hdrlen = 5;
+	if (conn->tls_version == VTCP_TLS_1_3_VERSION) {
+		// The real content type is encrypted at the end of the data,
+		// the nonce is the IV xored with the record sequence number.
+		memcpy(&nonce[4], conn->tls_iv, sizeof(conn->tls_iv));
+		for (i = 0; i < sizeof(seq); i++)
+			nonce[4 + i] ^= seq[i];
+		data[len++] = type;
+		type = 23;
+		aadlen = hdrlen;
+	} else {
+		// TLS 1.2 records carry the explicit part of the nonce, we use the sequence number.
+		memcpy(&nonce[4], seq, sizeof(seq));
+		memcpy(&rec[hdrlen], seq, sizeof(seq));
+		hdrlen += sizeof(seq);
+		memcpy(aad, seq, sizeof(seq));
+		aadlen = sizeof(seq) + 5;
+	}
+	uint32 reclen = hdrlen - 5 + len + VTCP_TLS_TAG_SIZE;
+	rec[0] = type;
+	rec[1] = 3;
+	rec[2] = 3;
+	rec[3] = reclen >> 8;
+	rec[4] = reclen;
+	if (conn->tls_version == VTCP_TLS_1_3_VERSION) {
+		memcpy(aad, rec, 5);
+	} else {
+		memcpy(&aad[8], rec, 3);
+		aad[11] = len >> 8;
+		aad[12] = len;
+	}
+	if (vtcp_aead_encrypt(conn->tls_key, nonce, aad, aadlen, data, len, &rec[hdrlen]))
+		return -1;
+	conn->tls_seq++;
+	return 5 + reclen;
+}
+
+static long syz_vtcp_tls_send(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	// syz_vtcp_tls_send(conn vtcp_conn, type flags[tls_record_type, int8], data ptr[in, array[int8, 0:1024]], len bytesize[data])
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	if (conn->tls_version == 0) {
+		errno = ENOTCONN;
+		return -1;
+	}
+	uint32 len = a3;
+	if (len > VTCP_TLS_MAX_DATA)
+		len = VTCP_TLS_MAX_DATA;
+	// One more byte for the TLS 1.3 inner content type.
+	uint8 data[VTCP_TLS_MAX_DATA + 1];
+	memset(data, 0, sizeof(data));
+	NONFAILING(memcpy(data, (void*)a2, len));
+	uint8 seg[VTCP_MAX_SEGMENT];
+	memset(seg, 0, sizeof(seg));
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	tcp->doff = sizeof(struct tcphdr) / 4;
+	seg[13] = VTCP_FLAG_PSH | VTCP_FLAG_ACK;
+	tcp->window = htons(VTCP_WINDOW);
+	int reclen = vtcp_tls_seal(conn, (uint8)a1, data, len, &seg[sizeof(struct tcphdr)]);
+	if (reclen == -1)
+		return -1;
+	return vtcp_send(conn, VTCP_FIXUP_PORTS | VTCP_FIXUP_SEQ | VTCP_FIXUP_ACK | VTCP_FIXUP_CSUM,
+			 seg, sizeof(struct tcphdr) + reclen);
+}
+#endif
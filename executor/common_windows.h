@@ -7,6 +7,69 @@
 
 #include "common.h"
 
+// Native NT API is exported by ntdll.dll, but most of it is not declared in SDK headers
+// (and winternl.h declares only a subset with opaque types), so declare what we use.
+#pragma comment(lib, "ntdll.lib")
+
+extern "C" {
+NTSYSAPI LONG NTAPI NtCreateFile(PHANDLE FileHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				 PVOID IoStatusBlock, PLARGE_INTEGER AllocationSize, ULONG FileAttributes,
+				 ULONG ShareAccess, ULONG CreateDisposition, ULONG CreateOptions,
+				 PVOID EaBuffer, ULONG EaLength);
+NTSYSAPI LONG NTAPI NtOpenFile(PHANDLE FileHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+			       PVOID IoStatusBlock, ULONG ShareAccess, ULONG OpenOptions);
+NTSYSAPI LONG NTAPI NtReadFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+			       PVOID IoStatusBlock, PVOID Buffer, ULONG Length, PLARGE_INTEGER ByteOffset,
+			       PULONG Key);
+NTSYSAPI LONG NTAPI NtWriteFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+				PVOID IoStatusBlock, PVOID Buffer, ULONG Length, PLARGE_INTEGER ByteOffset,
+				PULONG Key);
+NTSYSAPI LONG NTAPI NtFlushBuffersFile(HANDLE FileHandle, PVOID IoStatusBlock);
+NTSYSAPI LONG NTAPI NtQueryInformationFile(HANDLE FileHandle, PVOID IoStatusBlock, PVOID FileInformation,
+					   ULONG Length, ULONG FileInformationClass);
+NTSYSAPI LONG NTAPI NtSetInformationFile(HANDLE FileHandle, PVOID IoStatusBlock, PVOID FileInformation,
+					 ULONG Length, ULONG FileInformationClass);
+NTSYSAPI LONG NTAPI NtQueryDirectoryFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+					 PVOID IoStatusBlock, PVOID FileInformation, ULONG Length,
+					 ULONG FileInformationClass, BOOLEAN ReturnSingleEntry, PVOID FileName,
+					 BOOLEAN RestartScan);
+NTSYSAPI LONG NTAPI NtDeviceIoControlFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+					  PVOID IoStatusBlock, ULONG IoControlCode, PVOID InputBuffer,
+					  ULONG InputBufferLength, PVOID OutputBuffer, ULONG OutputBufferLength);
+NTSYSAPI LONG NTAPI NtFsControlFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+				    PVOID IoStatusBlock, ULONG FsControlCode, PVOID InputBuffer,
+				    ULONG InputBufferLength, PVOID OutputBuffer, ULONG OutputBufferLength);
+NTSYSAPI LONG NTAPI NtAllocateVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, ULONG_PTR ZeroBits,
+					    PSIZE_T RegionSize, ULONG AllocationType, ULONG Protect);
+NTSYSAPI LONG NTAPI NtFreeVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, PSIZE_T RegionSize,
+					ULONG FreeType);
+NTSYSAPI LONG NTAPI NtProtectVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, PSIZE_T RegionSize,
+					   ULONG NewProtect, PULONG OldProtect);
+NTSYSAPI LONG NTAPI NtQueryVirtualMemory(HANDLE ProcessHandle, PVOID BaseAddress, ULONG MemoryInformationClass,
+					 PVOID MemoryInformation, SIZE_T MemoryInformationLength,
+					 PSIZE_T ReturnLength);
+NTSYSAPI LONG NTAPI NtCreateSection(PHANDLE SectionHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				    PLARGE_INTEGER MaximumSize, ULONG SectionPageProtection,
+				    ULONG AllocationAttributes, HANDLE FileHandle);
+NTSYSAPI LONG NTAPI NtMapViewOfSection(HANDLE SectionHandle, HANDLE ProcessHandle, PVOID* BaseAddress,
+				       ULONG_PTR ZeroBits, SIZE_T CommitSize, PLARGE_INTEGER SectionOffset,
+				       PSIZE_T ViewSize, ULONG InheritDisposition, ULONG AllocationType,
+				       ULONG Win32Protect);
+NTSYSAPI LONG NTAPI NtUnmapViewOfSection(HANDLE ProcessHandle, PVOID BaseAddress);
+NTSYSAPI LONG NTAPI NtExtendSection(HANDLE SectionHandle, PLARGE_INTEGER NewSectionSize);
+NTSYSAPI LONG NTAPI NtCreateEvent(PHANDLE EventHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				  ULONG EventType, BOOLEAN InitialState);
+NTSYSAPI LONG NTAPI NtSetEvent(HANDLE EventHandle, PLONG PreviousState);
+NTSYSAPI LONG NTAPI NtResetEvent(HANDLE EventHandle, PLONG PreviousState);
+NTSYSAPI LONG NTAPI NtWaitForSingleObject(HANDLE Handle, BOOLEAN Alertable, PLARGE_INTEGER Timeout);
+NTSYSAPI LONG NTAPI NtDuplicateObject(HANDLE SourceProcessHandle, HANDLE SourceHandle,
+				      HANDLE TargetProcessHandle, PHANDLE TargetHandle, ACCESS_MASK DesiredAccess,
+				      ULONG HandleAttributes, ULONG Options);
+NTSYSAPI LONG NTAPI NtQueryObject(HANDLE Handle, ULONG ObjectInformationClass, PVOID ObjectInformation,
+				  ULONG ObjectInformationLength, PULONG ReturnLength);
+NTSYSAPI LONG NTAPI NtClose(HANDLE Handle);
+}
+
 #if SYZ_EXECUTOR || SYZ_HANDLE_SEGV
 static void install_segv_handler()
 {
@@ -111,3 +174,50 @@ static int do_sandbox_none(void)
 	return 0;
 }
 #endif
+
+// syz_nt_* are wrappers for NT calls with more than 9 arguments (see sys/windows/nt.txt).
+// APC routines, events and extended attributes are not used.
+#if SYZ_EXECUTOR || __NR_syz_nt_create_file
+static intptr_t syz_nt_create_file(intptr_t handle, intptr_t access, intptr_t attrs, intptr_t iosb,
+				   intptr_t file_attrs, intptr_t share, intptr_t disposition, intptr_t options)
+{
+	return NtCreateFile((PHANDLE)handle, access, (PVOID)attrs, (PVOID)iosb, NULL, file_attrs,
+			    share, disposition, options, NULL, 0);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_query_directory_file
+static intptr_t syz_nt_query_directory_file(intptr_t handle, intptr_t iosb, intptr_t info, intptr_t len,
+					    intptr_t cls, intptr_t single, intptr_t name, intptr_t restart)
+{
+	return NtQueryDirectoryFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, (PVOID)info, len,
+				    cls, (BOOLEAN)single, (PVOID)name, (BOOLEAN)restart);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_device_io_control_file
+static intptr_t syz_nt_device_io_control_file(intptr_t handle, intptr_t iosb, intptr_t code,
+					      intptr_t in, intptr_t inlen, intptr_t out, intptr_t outlen)
+{
+	return NtDeviceIoControlFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, code,
+				     (PVOID)in, inlen, (PVOID)out, outlen);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_fs_control_file
+static intptr_t syz_nt_fs_control_file(intptr_t handle, intptr_t iosb, intptr_t code,
+				       intptr_t in, intptr_t inlen, intptr_t out, intptr_t outlen)
+{
+	return NtFsControlFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, code,
+			       (PVOID)in, inlen, (PVOID)out, outlen);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_map_view_of_section
+static intptr_t syz_nt_map_view_of_section(intptr_t section, intptr_t addr, intptr_t commit, intptr_t offset,
+					   intptr_t size, intptr_t inherit, intptr_t type, intptr_t protect)
+{
+	return NtMapViewOfSection((HANDLE)section, GetCurrentProcess(), (PVOID*)addr, 0, commit,
+				  (PLARGE_INTEGER)offset, (PSIZE_T)size, inherit, type, protect);
+}
+#endif
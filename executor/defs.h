@@ -15,6 +15,31 @@
 
 #endif
 
+#if GOOS_darwin
+#define GOOS "darwin"
+
+#if GOARCH_amd64
+#define GOARCH "amd64"
+#define SYZ_REVISION "92192ee2adcaf54aabcca82013cfad5dfa7a6e30"
+#define SYZ_EXECUTOR_USES_FORK_SERVER 1
+#define SYZ_EXECUTOR_USES_SHMEM 1
+#define SYZ_PAGE_SIZE 4096
+#define SYZ_NUM_PAGES 4096
+#define SYZ_DATA_OFFSET 68719476736
+#endif
+
+#if GOARCH_arm64
+#define GOARCH "arm64"
+#define SYZ_REVISION "a2384723143fe6c7c13e84049b54da4fa3dff6b7"
+#define SYZ_EXECUTOR_USES_FORK_SERVER 1
+#define SYZ_EXECUTOR_USES_SHMEM 1
+#define SYZ_PAGE_SIZE 16384
+#define SYZ_NUM_PAGES 1024
+#define SYZ_DATA_OFFSET 68719476736
+#endif
+
+#endif
+
 #if GOOS_freebsd
 #define GOOS "freebsd"
 
@@ -70,7 +95,7 @@
 
 #if GOARCH_386
 #define GOARCH "386"
-#define SYZ_REVISION "f22c45c650bebdef94ba18978dece63e493ea7a3"
+#define SYZ_REVISION "0762f0d8718d1b97792bf66585c93349d58c818a"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -80,7 +105,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "0427f7bf3e8b373ed205ede923d3e80372fa44fa"
+#define SYZ_REVISION "eab251a2550035458080f95aa70a4fb882108ce6"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -90,7 +115,7 @@
 
 #if GOARCH_arm
 #define GOARCH "arm"
-#define SYZ_REVISION "bf20f9a660d824072225dfab51d51b92723ab789"
+#define SYZ_REVISION "cf12c54a34efc6ded8104cd0cc12ff28d3ddb7c5"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -100,7 +125,7 @@
 
 #if GOARCH_arm64
 #define GOARCH "arm64"
-#define SYZ_REVISION "e517ab5d40c8d121a0587c805f53ccb3185bef9e"
+#define SYZ_REVISION "7abaf10b8f32b76448c66be4f914ef0b39b667b4"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -110,7 +135,7 @@
 
 #if GOARCH_ppc64le
 #define GOARCH "ppc64le"
-#define SYZ_REVISION "6fe830f2ded65a00aed0f1310b8774df680d9613"
+#define SYZ_REVISION "5d668937b0c9a8aa6e17c570e8094f6dcd7c4bbb"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -155,7 +180,7 @@
 
 #if GOARCH_32_fork_shmem
 #define GOARCH "32_fork_shmem"
-#define SYZ_REVISION "c1820da1fd3d10373aa40011a453b7479b078078"
+#define SYZ_REVISION "828bd5526bf4e79e53f353e610f6fab39d445fc5"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -165,7 +190,7 @@
 
 #if GOARCH_32_shmem
 #define GOARCH "32_shmem"
-#define SYZ_REVISION "5c997491edf9b3c7f42853ea0cb2078731b361f2"
+#define SYZ_REVISION "646f749dfbdd2b1436ef80db1db609db5dfe5b25"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 8192
@@ -175,7 +200,7 @@
 
 #if GOARCH_64
 #define GOARCH "64"
-#define SYZ_REVISION "74c87d08a1f72c38c85e889813b60823a1e5755c"
+#define SYZ_REVISION "8453841cc688255fc149faae5cbdb04b2e1ae2e5"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -185,7 +210,7 @@
 
 #if GOARCH_64_fork
 #define GOARCH "64_fork"
-#define SYZ_REVISION "7467ae0af7e161054ba46c3c4e2c2a2a9c82ba7f"
+#define SYZ_REVISION "e95b57f826ce059184d4a2e391083679db27fb35"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 8192
@@ -215,7 +240,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "75e6a1fa03cbbd34d40cee331e1f7e5f3d26d4ef"
+#define SYZ_REVISION "05435fe25097cce845a64b8dd2091ef6467219f6"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -224,3 +249,18 @@
 #endif
 
 #endif
+
+#if GOOS_zephyr
+#define GOOS "zephyr"
+
+#if GOARCH_arm
+#define GOARCH "arm"
+#define SYZ_REVISION "99259bf3db86ab0b53c0f332f62b054034ae790e"
+#define SYZ_EXECUTOR_USES_FORK_SERVER 1
+#define SYZ_EXECUTOR_USES_SHMEM 0
+#define SYZ_PAGE_SIZE 4096
+#define SYZ_NUM_PAGES 64
+#define SYZ_DATA_OFFSET 538968064
+#endif
+
+#endif
@@ -86,19 +86,29 @@ static void resend_execute(int fd);
 #if SYZ_EXECUTOR_USES_FORK_SERVER
 static void receive_handshake();
 static void reply_handshake();
+#if GOOS_linux
+static void receive_sandbox_profile();
+static void receive_sandbox_identity();
+static void read_pipe_full(void* data, uint64 size);
+#endif
 #endif
 
 #if SYZ_EXECUTOR_USES_SHMEM
 const int kMaxOutput = 16 << 20;
 const int kInFd = 3;
 const int kOutFd = 4;
+static void write_completed(uint32 completed);
+#else
+// Without shmem signal and coverage of a single call are collected in this buffer
+// and then sent over the control pipe after the call_reply.
+const int kMaxOutput = 2 * kCoverSize * sizeof(uint32);
+static uint32 output_buffer[kMaxOutput / sizeof(uint32)];
+#endif
 static uint32* output_data;
 static uint32* output_pos;
 static uint32* write_output(uint32 v);
-static void write_completed(uint32 completed);
 static uint32 hash(uint32 a);
 static bool dedup(uint32 sig);
-#endif
 
 enum sandbox_type {
 	sandbox_none,
@@ -116,6 +126,11 @@ static bool flag_extra_cover;
 static bool flag_enable_tun;
 static bool flag_enable_net_dev;
 static bool flag_enable_net_reset;
+static bool flag_enable_net_recycle;
+#if GOOS_linux
+// Groups of network devices to create (NETDEV_* in common_linux.h).
+static uint64 flag_net_devices = ~0ull;
+#endif
 static bool flag_enable_cgroups;
 static bool flag_enable_close_fds;
 
@@ -132,6 +147,53 @@ static bool flag_inject_fault;
 static int flag_fault_call;
 static int flag_fault_nth;
 
+// Race flag_race_call1-th and flag_race_call2-th syscalls under a forced schedule
+// (see race_prepare and ipc.RaceMode for details).
+static bool flag_race;
+static int flag_race_call1;
+static int flag_race_call2;
+static int flag_race_mode;
+static uint64 flag_race_delay;
+
+// Run the test process in fresh namespaces with a tmpfs work dir (see ipc.ResetNamespace).
+static bool flag_reset_namespace;
+
+// Check global state after every call and report side effects (see ipc.SideEffects).
+static bool flag_detect_side_effects;
+
+// Timeouts are multiplied by this factor for slow kernels (e.g. with heavy debugging configs).
+static uint64 slowdown_scale = 1;
+
+// Userspace confinement applied to test processes (see ipc.SandboxProfile).
+// Received after handshake_req if flag_sandbox_profile is set.
+static bool flag_sandbox_profile;
+#if GOOS_linux
+struct sandbox_profile_t {
+	uint64 seccomp_len; // number of struct sock_filter instructions in sandbox_seccomp
+	char selinux[256];
+	char apparmor[256];
+};
+const int kMaxSeccompLen = 4096;
+static sandbox_profile_t sandbox_profile;
+static uint64 sandbox_seccomp[kMaxSeccompLen];
+#endif
+
+// Identity the setuid sandbox switches to instead of nobody (see ipc.SandboxIdentity).
+// Received after handshake_req (and sandbox profile) if flag_sandbox_identity is set.
+static bool flag_sandbox_identity;
+#if GOOS_linux
+const int kMaxSandboxGroups = 32;
+struct sandbox_identity_t {
+	uint64 uid;
+	uint64 gid;
+	uint64 no_new_privs;
+	uint64 cap_bound; // capabilities kept in the bounding set
+	uint64 ngroups;
+	uint64 groups[kMaxSandboxGroups];
+};
+static sandbox_identity_t sandbox_identity;
+#endif
+
 #define SYZ_EXECUTOR 1
 #include "common.h"
 
@@ -194,6 +256,7 @@ struct thread_t {
 	uint64 copyout_index;
 	bool colliding;
 	bool executing;
+	int race; // 1/2 for the first/second raced call, 0 otherwise
 	int call_index;
 	int call_num;
 	int num_args;
@@ -207,6 +270,10 @@ struct thread_t {
 static thread_t threads[kMaxThreads];
 static thread_t* last_scheduled;
 
+// The first raced call waits on race_start until the second one is scheduled.
+static event_t race_start;
+static int race_armed;
+
 static cover_t extra_cov;
 
 struct res_t {
@@ -223,6 +290,7 @@ struct handshake_req {
 	uint64 magic;
 	uint64 flags; // env flags
 	uint64 pid;
+	uint64 net_devices;
 };
 
 struct handshake_reply {
@@ -236,6 +304,11 @@ struct execute_req {
 	uint64 pid;
 	uint64 fault_call;
 	uint64 fault_nth;
+	uint64 race_call1;
+	uint64 race_call2;
+	uint64 race_mode;
+	uint64 race_delay;
+	uint64 slowdown;
 	uint64 prog_size;
 };
 
@@ -250,6 +323,7 @@ const uint32 call_flag_executed = 1 << 0;
 const uint32 call_flag_finished = 1 << 1;
 const uint32 call_flag_blocked = 1 << 2;
 const uint32 call_flag_fault_injected = 1 << 3;
+const uint32 call_flag_raced = 1 << 4;
 
 struct call_reply {
 	execute_reply header;
@@ -257,6 +331,7 @@ struct call_reply {
 	uint32 call_num;
 	uint32 reserrno;
 	uint32 flags;
+	uint32 side_effects;
 	uint32 signal_size;
 	uint32 cover_size;
 	uint32 comps_size;
@@ -290,12 +365,15 @@ struct feature_t {
 	void (*setup)();
 };
 
-static thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos);
+static thread_t* schedule_call(int call_index, int call_num, bool colliding, int race, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos);
 static void handle_completion(thread_t* th);
 static void copyout_call_results(thread_t* th);
 static void write_call_output(thread_t* th, bool finished);
+static uint32 detect_side_effects();
 static void write_extra_output();
 static void execute_call(thread_t* th);
+static void race_prepare(thread_t* th);
+static void race_finish(thread_t* th);
 static void thread_create(thread_t* th, int id);
 static void* worker_thread(void* arg);
 static uint64 read_input(uint64** input_posp, bool peek = false);
@@ -316,7 +394,7 @@ static void setup_features(char** enable, int n);
 #include "executor_fuchsia.h"
 #elif GOOS_akaros
 #include "executor_akaros.h"
-#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd
+#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_darwin
 #include "executor_bsd.h"
 #elif GOOS_windows
 #include "executor_windows.h"
@@ -373,6 +451,8 @@ int main(int argc, char** argv)
 	// which will cause fuzzer to crash.
 	close(kInFd);
 	close(kOutFd);
+#else
+	output_data = output_buffer;
 #endif
 
 	use_temporary_dir();
@@ -470,6 +550,9 @@ void parse_env_flags(uint64 flags)
 	flag_enable_net_reset = flags & (1 << 8);
 	flag_enable_cgroups = flags & (1 << 9);
 	flag_enable_close_fds = flags & (1 << 10);
+	flag_sandbox_profile = flags & (1 << 11);
+	flag_enable_net_recycle = flags & (1 << 12);
+	flag_sandbox_identity = flags & (1 << 13);
 }
 
 #if SYZ_EXECUTOR_USES_FORK_SERVER
@@ -483,7 +566,59 @@ void receive_handshake()
 		fail("bad handshake magic 0x%llx", req.magic);
 	parse_env_flags(req.flags);
 	procid = req.pid;
+#if GOOS_linux
+	if (req.net_devices)
+		flag_net_devices = req.net_devices;
+#endif
+	if (flag_sandbox_profile) {
+#if GOOS_linux
+		receive_sandbox_profile();
+#else
+		fail("sandbox profiles are supported only on linux");
+#endif
+	}
+	if (flag_sandbox_identity) {
+#if GOOS_linux
+		receive_sandbox_identity();
+#else
+		fail("sandbox identity is supported only on linux");
+#endif
+	}
+}
+
+#if GOOS_linux
+void read_pipe_full(void* data, uint64 size)
+{
+	for (uint64 pos = 0; pos < size;) {
+		ssize_t rv = read(kInPipeFd, (char*)data + pos, size - pos);
+		if (rv <= 0)
+			fail("control pipe read failed");
+		pos += rv;
+	}
+}
+
+void receive_sandbox_profile()
+{
+	read_pipe_full(&sandbox_profile, sizeof(sandbox_profile));
+	if (sandbox_profile.seccomp_len > kMaxSeccompLen)
+		fail("bad seccomp filter size %llu", sandbox_profile.seccomp_len);
+	read_pipe_full(sandbox_seccomp, sandbox_profile.seccomp_len * sizeof(sandbox_seccomp[0]));
+	sandbox_profile.selinux[sizeof(sandbox_profile.selinux) - 1] = 0;
+	sandbox_profile.apparmor[sizeof(sandbox_profile.apparmor) - 1] = 0;
+	debug("sandbox profile: seccomp=%llu selinux=%s apparmor=%s\n", sandbox_profile.seccomp_len,
+	      sandbox_profile.selinux, sandbox_profile.apparmor);
+}
+
+void receive_sandbox_identity()
+{
+	read_pipe_full(&sandbox_identity, sizeof(sandbox_identity));
+	if (sandbox_identity.ngroups > kMaxSandboxGroups)
+		fail("bad number of supplementary groups %llu", sandbox_identity.ngroups);
+	debug("sandbox identity: uid=%llu gid=%llu groups=%llu no_new_privs=%llu cap_bound=0x%llx\n",
+	      sandbox_identity.uid, sandbox_identity.gid, sandbox_identity.ngroups,
+	      sandbox_identity.no_new_privs, sandbox_identity.cap_bound);
 }
+#endif
 
 void reply_handshake()
 {
@@ -515,12 +650,30 @@ void receive_execute()
 	flag_collide = req.exec_flags & (1 << 5);
 	flag_fault_call = req.fault_call;
 	flag_fault_nth = req.fault_nth;
+	flag_race = req.exec_flags & (1 << 6);
+	flag_race_call1 = req.race_call1;
+	flag_race_call2 = req.race_call2;
+	flag_race_mode = req.race_mode;
+	flag_race_delay = req.race_delay;
+	flag_reset_namespace = req.exec_flags & (1 << 7);
+	flag_detect_side_effects = req.exec_flags & (1 << 8);
+	slowdown_scale = req.slowdown ? req.slowdown : 1;
 	if (!flag_threaded)
 		flag_collide = false;
-	debug("[%llums] exec opts: procid=%llu threaded=%d collide=%d cover=%d comps=%d dedup=%d fault=%d/%d/%d prog=%llu\n",
+#if SYZ_HAVE_RACE_SCHED
+	if (!flag_threaded || flag_race_call1 >= flag_race_call2)
+		flag_race = false;
+#else
+	flag_race = false;
+#endif
+	if (flag_race)
+		flag_collide = false;
+	debug("[%llums] exec opts: procid=%llu threaded=%d collide=%d cover=%d comps=%d dedup=%d fault=%d/%d/%d"
+	      " race=%d/%d/%d/%d/%llu reset=%d prog=%llu\n",
 	      current_time_ms() - start_time_ms, procid, flag_threaded, flag_collide,
 	      flag_collect_cover, flag_collect_comps, flag_dedup_cover, flag_inject_fault,
-	      flag_fault_call, flag_fault_nth, req.prog_size);
+	      flag_fault_call, flag_fault_nth, flag_race, flag_race_call1, flag_race_call2,
+	      flag_race_mode, flag_race_delay, flag_reset_namespace, req.prog_size);
 	if (SYZ_EXECUTOR_USES_SHMEM) {
 		if (req.prog_size)
 			fail("need_prog: no program");
@@ -574,6 +727,8 @@ void execute_one()
 	write_output(0); // Number of executed syscalls (updated later).
 #endif
 	uint64 start = current_time_ms();
+	// Take the initial state.
+	detect_side_effects();
 
 retry:
 	uint64* input_pos = (uint64*)input_data;
@@ -586,6 +741,11 @@ retry:
 	}
 
 	int call_index = 0;
+	bool race_pending = false;
+	if (flag_race) {
+		event_init(&race_start);
+		race_armed = 0;
+	}
 	bool collect_extra_cover = false;
 	int prog_extra_timeout = 0;
 	for (;;) {
@@ -602,6 +762,13 @@ retry:
 		if (strcmp(syscalls[call_num].name, "syz_usb_disconnect") == 0) {
 			call_extra_timeout = 200;
 		}
+		if (strcmp(syscalls[call_num].name, "syz_9p_connect") == 0 ||
+		    strcmp(syscalls[call_num].name, "syz_nfs_connect") == 0 ||
+		    strcmp(syscalls[call_num].name, "syz_smb_connect") == 0) {
+			if (prog_extra_timeout < 1000)
+				prog_extra_timeout = 1000;
+			call_extra_timeout = 1000;
+		}
 		if (call_num == instr_copyin) {
 			char* addr = (char*)read_input(&input_pos);
 			uint64 typ = read_input(&input_pos);
@@ -701,16 +868,25 @@ retry:
 			args[i] = read_arg(&input_pos);
 		for (uint64 i = num_args; i < kMaxArgs; i++)
 			args[i] = 0;
-		thread_t* th = schedule_call(call_index++, call_num, colliding, copyout_index,
+		int race = 0;
+		if (flag_race && call_index == flag_race_call1)
+			race = 1;
+		else if (flag_race && call_index == flag_race_call2)
+			race = 2;
+		thread_t* th = schedule_call(call_index++, call_num, colliding, race, copyout_index,
 					     num_args, args, input_pos);
 
-		if (colliding && (call_index % 2) == 0) {
+		if (race == 1) {
+			// Don't wait for the first raced call,
+			// it does not start until the second raced call is scheduled.
+			race_pending = true;
+		} else if (colliding && (call_index % 2) == 0) {
 			// Don't wait for every other call.
 			// We already have results from the previous execution.
 		} else if (flag_threaded) {
 			// Wait for call completion.
 			// Note: sys knows about this 25ms timeout when it generates timespec/timeval values.
-			uint64 timeout_ms = 45 + call_extra_timeout;
+			uint64 timeout_ms = (45 + call_extra_timeout) * slowdown_scale;
 			if (flag_debug && timeout_ms < 1000)
 				timeout_ms = 1000;
 			if (event_timedwait(&th->done, timeout_ms))
@@ -730,17 +906,24 @@ retry:
 			event_set(&th->done);
 			handle_completion(th);
 		}
+		if (race == 2)
+			race_pending = false;
+	}
+
+	if (race_pending) {
+		// The program does not have the second raced call, release the first one.
+		event_set(&race_start);
 	}
 
 	if (!colliding && !collide && running > 0) {
 		// Give unfinished syscalls some additional time.
 		last_scheduled = 0;
-		uint64 wait = 100;
+		uint64 wait = 100 * slowdown_scale;
 		uint64 wait_start = current_time_ms();
 		uint64 wait_end = wait_start + wait;
-		if (wait_end < start + 800)
-			wait_end = start + 800;
-		wait_end += prog_extra_timeout;
+		if (wait_end < start + 800 * slowdown_scale)
+			wait_end = start + 800 * slowdown_scale;
+		wait_end += prog_extra_timeout * slowdown_scale;
 		while (running > 0 && current_time_ms() <= wait_end) {
 			sleep_ms(1);
 			for (int i = 0; i < kMaxThreads; i++) {
@@ -779,7 +962,7 @@ retry:
 	}
 }
 
-thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos)
+thread_t* schedule_call(int call_index, int call_num, bool colliding, int race, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos)
 {
 	// Find a spare thread to execute the call.
 	int i;
@@ -801,6 +984,7 @@ thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 cop
 		     event_isset(&th->ready), event_isset(&th->done), th->executing);
 	last_scheduled = th;
 	th->colliding = colliding;
+	th->race = race;
 	th->copyout_pos = pos;
 	th->copyout_index = copyout_index;
 	event_reset(&th->done);
@@ -815,7 +999,6 @@ thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 cop
 	return th;
 }
 
-#if SYZ_EXECUTOR_USES_SHMEM
 template <typename cover_data_t>
 void write_coverage_signal(cover_t* cov, uint32* signal_count_pos, uint32* cover_count_pos)
 {
@@ -855,7 +1038,6 @@ void write_coverage_signal(cover_t* cov, uint32* signal_count_pos, uint32* cover
 		write_output(cover_data[i]);
 	*cover_count_pos = cover_size;
 }
-#endif
 
 void handle_completion(thread_t* th)
 {
@@ -916,11 +1098,15 @@ void write_call_output(thread_t* th, bool finished)
 		call_flags |= call_flag_finished |
 			      (th->fault_injected ? call_flag_fault_injected : 0);
 	}
+	if (th->race)
+		call_flags |= call_flag_raced;
+	uint32 side_effects = detect_side_effects();
 #if SYZ_EXECUTOR_USES_SHMEM
 	write_output(th->call_index);
 	write_output(th->call_num);
 	write_output(reserrno);
 	write_output(call_flags);
+	write_output(side_effects);
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	uint32* comps_count_pos = write_output(0); // filled in later
@@ -963,13 +1149,26 @@ void write_call_output(thread_t* th, bool finished)
 	reply.call_num = th->call_num;
 	reply.reserrno = reserrno;
 	reply.flags = call_flags;
+	reply.side_effects = side_effects;
 	reply.signal_size = 0;
 	reply.cover_size = 0;
 	reply.comps_size = 0;
+	// Comparisons are not supported without shmem.
+	output_pos = output_data;
+	if (flag_cover && !flag_collect_comps) {
+		if (is_kernel_64_bit)
+			write_coverage_signal<uint64>(&th->cov, &reply.signal_size, &reply.cover_size);
+		else
+			write_coverage_signal<uint32>(&th->cov, &reply.signal_size, &reply.cover_size);
+	}
 	if (write(kOutPipeFd, &reply, sizeof(reply)) != sizeof(reply))
 		fail("control pipe call write failed");
-	debug_verbose("out: index=%u num=%u errno=%d finished=%d blocked=%d\n",
-		      th->call_index, th->call_num, reserrno, finished, blocked);
+	ssize_t output_size = (char*)output_pos - (char*)output_data;
+	if (output_size && write(kOutPipeFd, output_data, output_size) != output_size)
+		fail("control pipe coverage write failed");
+	debug_verbose("out: index=%u num=%u errno=%d finished=%d blocked=%d sig=%u cover=%u\n",
+		      th->call_index, th->call_num, reserrno, finished, blocked,
+		      reply.signal_size, reply.cover_size);
 #endif
 }
 
@@ -985,6 +1184,7 @@ void write_extra_output()
 	write_output(-1); // call num
 	write_output(999); // errno
 	write_output(0); // call flags
+	write_output(0); // side effects
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	write_output(0); // comps_count_pos
@@ -999,6 +1199,17 @@ void write_extra_output()
 #endif
 }
 
+// Returns side effects of the calls since the previous invocation.
+// Calls run concurrently in threaded mode, so the effects may be attributed to a wrong call.
+uint32 detect_side_effects()
+{
+#if SYZ_HAVE_SIDE_EFFECTS
+	if (flag_detect_side_effects)
+		return side_effects_update();
+#endif
+	return 0;
+}
+
 void thread_create(thread_t* th, int id)
 {
 	th->created = true;
@@ -1020,12 +1231,57 @@ void* worker_thread(void* arg)
 	for (;;) {
 		event_wait(&th->ready);
 		event_reset(&th->ready);
+		if (th->race)
+			race_prepare(th);
 		execute_call(th);
+		if (th->race)
+			race_finish(th);
 		event_set(&th->done);
 	}
 	return 0;
 }
 
+// race_prepare forces the schedule requested in flag_race_mode/flag_race_delay.
+// In preempt mode both raced threads are pinned to the same CPU and the second one
+// runs with a higher SCHED_FIFO priority. It releases the first call and sleeps,
+// when the timer fires it preempts the first call in the middle of the syscall.
+// In parallel mode the threads are pinned to different CPUs and the second call
+// busy-waits for the delay after the first call is started.
+void race_prepare(thread_t* th)
+{
+#if SYZ_HAVE_RACE_SCHED
+	const int race_mode_parallel = 1; // must match ipc.RaceParallel
+	bool parallel = flag_race_mode == race_mode_parallel && race_sched_cpus() > 1;
+	int cpu = procid + (parallel && th->race == 2 ? 1 : 0);
+	race_sched_enter(cpu, parallel ? 0 : th->race);
+	if (th->race == 1) {
+		event_wait(&race_start);
+		__atomic_store_n(&race_armed, 1, __ATOMIC_RELEASE);
+		return;
+	}
+	event_set(&race_start);
+	if (!parallel) {
+		if (flag_race_delay)
+			race_sched_sleep_ns(flag_race_delay);
+		return;
+	}
+	// Don't spin forever if the first call is not going to start.
+	uint64 start = race_sched_now_ns();
+	while (!__atomic_load_n(&race_armed, __ATOMIC_ACQUIRE) && race_sched_now_ns() - start < 10 * 1000 * 1000) {
+	}
+	start = race_sched_now_ns();
+	while (race_sched_now_ns() - start < flag_race_delay) {
+	}
+#endif
+}
+
+void race_finish(thread_t* th)
+{
+#if SYZ_HAVE_RACE_SCHED
+	race_sched_leave();
+#endif
+}
+
 void execute_call(thread_t* th)
 {
 	const call_t* call = &syscalls[th->call_num];
@@ -1072,7 +1328,6 @@ void execute_call(thread_t* th)
 	debug("\n");
 }
 
-#if SYZ_EXECUTOR_USES_SHMEM
 static uint32 hash(uint32 a)
 {
 	a = (a ^ 61) ^ (a >> 16);
@@ -1103,7 +1358,6 @@ static bool dedup(uint32 sig)
 	dedup_table[sig % dedup_table_size] = sig;
 	return false;
 }
-#endif
 
 template <typename T>
 void copyin_int(char* addr, uint64 val, uint64 bf, uint64 bf_off, uint64 bf_len)
@@ -1268,7 +1522,6 @@ uint64 read_input(uint64** input_posp, bool peek)
 	return *input_pos;
 }
 
-#if SYZ_EXECUTOR_USES_SHMEM
 uint32* write_output(uint32 v)
 {
 	if (output_pos < output_data || (char*)output_pos >= (char*)output_data + kMaxOutput)
@@ -1278,6 +1531,7 @@ uint32* write_output(uint32 v)
 	return output_pos++;
 }
 
+#if SYZ_EXECUTOR_USES_SHMEM
 void write_completed(uint32 completed)
 {
 	__atomic_store_n(output_data, completed, __ATOMIC_RELEASE);
@@ -2,6 +2,7 @@
 // Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
 
 #include <fcntl.h>
+#include <stddef.h>
 #include <stdlib.h>
 #include <sys/ioctl.h>
 #include <sys/mman.h>
@@ -15,6 +16,9 @@ static void os_init(int argc, char** argv, void* data, size_t data_size)
 #if GOOS_openbsd
 	// W^X not allowed by default on OpenBSD.
 	int prot = PROT_READ | PROT_WRITE;
+#elif GOOS_darwin
+	// RWX mappings require MAP_JIT and are not allowed at fixed addresses on arm64.
+	int prot = PROT_READ | PROT_WRITE;
 #elif GOOS_netbsd
 	// W^X not allowed by default on NetBSD (PaX MPROTECT).
 	int prot = PROT_READ | PROT_WRITE | PROT_MPROTECT(PROT_EXEC);
@@ -113,6 +117,89 @@ static bool cover_check(uint32 pc)
 	return true;
 }
 
+static bool cover_check(uint64 pc)
+{
+	return true;
+}
+#elif GOOS_darwin
+
+// XNU development kernels built with KSANCOV=1 expose SanitizerCoverage through /dev/ksancov.
+// The interface is declared in xnu san/ksancov.h which is not shipped with the SDK.
+// Each thread gets own device fd and own trace buffer. PCs in the buffer are 32-bit
+// offsets relative to the kernel text base (trace->offset), so we treat the kernel
+// as 32-bit and feed the offsets directly into signal/coverage.
+
+#include <sys/ioccom.h>
+
+#define KSANCOV_PATH "/dev/ksancov"
+
+struct ksancov_buf_desc {
+	uintptr_t ptr;
+	size_t sz;
+};
+
+struct ksancov_trace {
+	uint32 magic;
+	uint32 enabled;
+	uintptr_t offset;
+	uint32 maxpcs;
+	uint32 head;
+	uint32 pcs[];
+};
+
+#define KSANCOV_IOC_TRACE _IOW('K', 1, size_t)
+#define KSANCOV_IOC_MAP _IOWR('K', 8, struct ksancov_buf_desc)
+#define KSANCOV_IOC_START _IOW('K', 10, uintptr_t)
+
+static void cover_open(cover_t* cov, bool extra)
+{
+	int fd = open(KSANCOV_PATH, O_RDWR);
+	if (fd == -1)
+		fail("open of %s failed", KSANCOV_PATH);
+	if (dup2(fd, cov->fd) < 0)
+		fail("failed to dup2(%d, %d) cover fd", fd, cov->fd);
+	close(fd);
+	size_t maxpcs = kCoverSize;
+	if (ioctl(cov->fd, KSANCOV_IOC_TRACE, &maxpcs))
+		fail("ksancov trace ioctl failed");
+	struct ksancov_buf_desc mc = {};
+	if (ioctl(cov->fd, KSANCOV_IOC_MAP, &mc))
+		fail("ksancov map ioctl failed");
+	is_kernel_64_bit = false;
+	struct ksancov_trace* trace = (struct ksancov_trace*)mc.ptr;
+	// Executor expects the number of PCs to be right before the PCs.
+	cov->data = (char*)&trace->head;
+	cov->data_end = (char*)mc.ptr + mc.sz;
+}
+
+static void cover_enable(cover_t* cov, bool collect_comps, bool extra)
+{
+	if (collect_comps)
+		fail("ksancov does not support comparisons");
+	uintptr_t thread = 0;
+	if (ioctl(cov->fd, KSANCOV_IOC_START, &thread))
+		exitf("ksancov start ioctl failed");
+	struct ksancov_trace* trace = (struct ksancov_trace*)(cov->data - offsetof(struct ksancov_trace, head));
+	__atomic_store_n(&trace->enabled, 1, __ATOMIC_RELAXED);
+}
+
+static void cover_reset(cover_t* cov)
+{
+	__atomic_store_n((uint32*)cov->data, 0, __ATOMIC_RELAXED);
+}
+
+static void cover_collect(cover_t* cov)
+{
+	cov->size = __atomic_load_n((uint32*)cov->data, __ATOMIC_RELAXED);
+	if (cov->size > kCoverSize)
+		cov->size = kCoverSize;
+}
+
+static bool cover_check(uint32 pc)
+{
+	return true;
+}
+
 static bool cover_check(uint64 pc)
 {
 	return true;
@@ -2,6 +2,7 @@
 // Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
 
 #include <fcntl.h>
+#include <sched.h>
 #include <signal.h>
 #include <stdio.h>
 #include <stdlib.h>
@@ -9,6 +10,7 @@
 #include <sys/mman.h>
 #include <sys/prctl.h>
 #include <sys/syscall.h>
+#include <time.h>
 #include <unistd.h>
 
 const unsigned long KCOV_TRACE_PC = 0;
@@ -168,6 +170,205 @@ NORETURN void doexit(int status)
 	}
 }
 
+#define SYZ_HAVE_RACE_SCHED 1
+static __thread cpu_set_t race_saved_cpus;
+
+// Returns number of CPUs the executor is allowed to run on.
+static int race_sched_cpus()
+{
+	cpu_set_t cpus;
+	if (sched_getaffinity(0, sizeof(cpus), &cpus))
+		return 1;
+	return CPU_COUNT(&cpus);
+}
+
+// Pins the current thread to the n-th (modulo number of allowed CPUs) CPU
+// and, if prio is not 0, switches it to SCHED_FIFO with the given priority.
+// SCHED_FIFO requires CAP_SYS_NICE which we don't have in some sandboxes,
+// in such case we just rely on pinning and wakeup preemption.
+static void race_sched_enter(int n, int prio)
+{
+	if (sched_getaffinity(0, sizeof(race_saved_cpus), &race_saved_cpus))
+		fail("sched_getaffinity failed");
+	int count = CPU_COUNT(&race_saved_cpus);
+	n %= count;
+	for (int cpu = 0; cpu < CPU_SETSIZE; cpu++) {
+		if (!CPU_ISSET(cpu, &race_saved_cpus) || n--)
+			continue;
+		cpu_set_t cpus;
+		CPU_ZERO(&cpus);
+		CPU_SET(cpu, &cpus);
+		if (sched_setaffinity(0, sizeof(cpus), &cpus))
+			debug("sched_setaffinity(%d) failed: %d\n", cpu, errno);
+		break;
+	}
+	if (prio) {
+		struct sched_param param = {};
+		param.sched_priority = prio;
+		if (sched_setscheduler(0, SCHED_FIFO, &param))
+			debug("sched_setscheduler(SCHED_FIFO, %d) failed: %d\n", prio, errno);
+	}
+}
+
+static void race_sched_leave()
+{
+	struct sched_param param = {};
+	sched_setscheduler(0, SCHED_OTHER, &param);
+	sched_setaffinity(0, sizeof(race_saved_cpus), &race_saved_cpus);
+}
+
+static uint64 race_sched_now_ns()
+{
+	struct timespec ts;
+	if (clock_gettime(CLOCK_MONOTONIC, &ts))
+		fail("clock_gettime failed");
+	return (uint64)ts.tv_sec * 1000000000 + (uint64)ts.tv_nsec;
+}
+
+static void race_sched_sleep_ns(uint64 ns)
+{
+	struct timespec ts;
+	ts.tv_sec = ns / 1000000000;
+	ts.tv_nsec = ns % 1000000000;
+	while (clock_nanosleep(CLOCK_MONOTONIC, 0, &ts, &ts) == EINTR) {
+	}
+}
+
+#define SYZ_HAVE_SIDE_EFFECTS 1
+#include <dirent.h>
+
+// Side effects are changes of global state that outlive the test process (see ipc.SideEffects).
+// We keep hashes of the state and compare them after every call.
+// Bits must match ipc.SideEffect* values.
+enum {
+	side_effect_mount, // mounts outside of the work dir
+	side_effect_module, // loaded kernel modules
+	side_effect_sysctl, // global sysctls
+	side_effect_loop, // attached loop devices (except the ones used by executors)
+	side_effect_count,
+};
+
+static uint64 side_effects_state[side_effect_count];
+static char side_effects_cwd[1024];
+static char side_effects_buf[64 << 10];
+
+// Sysctls that affect the whole machine (rather than a namespace) and kernel bug detection.
+static const char* side_effects_sysctls[] = {
+    "/proc/sys/kernel/panic_on_oops",
+    "/proc/sys/kernel/panic_on_warn",
+    "/proc/sys/kernel/printk",
+    "/proc/sys/kernel/hung_task_timeout_secs",
+    "/proc/sys/kernel/perf_event_paranoid",
+    "/proc/sys/kernel/kptr_restrict",
+    "/proc/sys/vm/overcommit_memory",
+    "/proc/sys/vm/panic_on_oom",
+    "/proc/sys/net/core/bpf_jit_enable",
+};
+
+static uint64 side_effects_hash(uint64 hash, const char* data, int size)
+{
+	// FNV-1a.
+	for (int i = 0; i < size; i++)
+		hash = (hash ^ (uint8)data[i]) * 1099511628211ull;
+	return hash;
+}
+
+static int side_effects_read(const char* file)
+{
+	int fd = open(file, O_RDONLY);
+	if (fd == -1)
+		return 0;
+	int size = 0;
+	for (;;) {
+		int n = read(fd, side_effects_buf + size, sizeof(side_effects_buf) - 1 - size);
+		if (n <= 0)
+			break;
+		size += n;
+	}
+	close(fd);
+	side_effects_buf[size] = 0;
+	return size;
+}
+
+// Hashes the given (0-based) space-separated column of all lines of the file
+// skipping values that start with skip.
+static uint64 side_effects_hash_column(const char* file, int column, const char* skip)
+{
+	uint64 hash = 14695981039346656037ull;
+	side_effects_read(file);
+	for (char* line = side_effects_buf; *line;) {
+		char* next = strchr(line, '\n');
+		if (next)
+			*next++ = 0;
+		else
+			next = line + strlen(line);
+		char* val = line;
+		for (int i = 0; i < column && val; i++) {
+			val = strchr(val, ' ');
+			if (val)
+				val++;
+		}
+		if (val) {
+			int size = strcspn(val, " ");
+			if (!skip || strncmp(val, skip, strlen(skip)))
+				hash = side_effects_hash(hash, val, size + 1);
+		}
+		line = next;
+	}
+	return hash;
+}
+
+static uint64 side_effects_hash_sysctls()
+{
+	uint64 hash = 14695981039346656037ull;
+	for (size_t i = 0; i < sizeof(side_effects_sysctls) / sizeof(side_effects_sysctls[0]); i++) {
+		int size = side_effects_read(side_effects_sysctls[i]);
+		hash = side_effects_hash(hash, side_effects_buf, size + 1);
+	}
+	return hash;
+}
+
+static uint64 side_effects_hash_loops()
+{
+	// Executors use /dev/loopN (N=procid) for syz_mount_image and detach it after each program.
+	const int max_pids = 32; // must match prog.MaxPids
+	uint64 hash = 14695981039346656037ull;
+	DIR* dir = opendir("/sys/block");
+	if (!dir)
+		return hash;
+	while (struct dirent* ent = readdir(dir)) {
+		int id = 0;
+		if (sscanf(ent->d_name, "loop%d", &id) != 1 || id < max_pids)
+			continue;
+		// The loop dir exists only while the device is attached.
+		char file[64];
+		sprintf(file, "/sys/block/loop%d/loop", id);
+		if (access(file, F_OK) == 0)
+			hash = side_effects_hash(hash, (char*)&id, sizeof(id));
+	}
+	closedir(dir);
+	return hash;
+}
+
+// Returns mask of side effects since the previous call.
+static uint32 side_effects_update()
+{
+	if (!side_effects_cwd[0] && !getcwd(side_effects_cwd, sizeof(side_effects_cwd)))
+		fail("getcwd failed");
+	uint64 state[side_effect_count];
+	state[side_effect_mount] = side_effects_hash_column("/proc/self/mountinfo", 4, side_effects_cwd);
+	state[side_effect_module] = side_effects_hash_column("/proc/modules", 0, NULL);
+	state[side_effect_sysctl] = side_effects_hash_sysctls();
+	state[side_effect_loop] = side_effects_hash_loops();
+	uint32 res = 0;
+	for (int i = 0; i < side_effect_count; i++) {
+		if (state[i] != side_effects_state[i])
+			res |= 1 << i;
+		side_effects_state[i] = state[i];
+	}
+	return res;
+}
+
 #define SYZ_HAVE_FEATURES 1
 static feature_t features[] = {
     {"leak", setup_leak},
@@ -17,7 +17,6 @@ static void cover_collect(cover_t* cov)
 {
 }
 
-#if SYZ_EXECUTOR_USES_SHMEM
 static bool cover_check(uint32 pc)
 {
 	return true;
@@ -27,4 +26,3 @@ static bool cover_check(uint64 pc)
 {
 	return true;
 }
-#endif
@@ -222,6 +222,408 @@ const call_t syscalls[] = {
 
 #endif
 
+#if GOOS_darwin
+
+#if GOARCH_amd64
+const call_t syscalls[] = {
+    {"accept", 30},
+    {"accept$inet", 30},
+    {"accept$inet6", 30},
+    {"accept$unix", 30},
+    {"access", 33},
+    {"bind", 104},
+    {"bind$inet", 104},
+    {"bind$inet6", 104},
+    {"bind$unix", 104},
+    {"chdir", 12},
+    {"chmod", 15},
+    {"chown", 16},
+    {"chroot", 61},
+    {"close", 6},
+    {"connect", 98},
+    {"connect$inet", 98},
+    {"connect$inet6", 98},
+    {"connect$unix", 98},
+    {"dup", 41},
+    {"dup2", 90},
+    {"faccessat", 466},
+    {"fchdir", 13},
+    {"fchmod", 124},
+    {"fchmodat", 467},
+    {"fchown", 123},
+    {"fchownat", 468},
+    {"fcntl$dupfd", 92},
+    {"fcntl$getflags", 92},
+    {"fcntl$getown", 92},
+    {"fcntl$getpath", 92},
+    {"fcntl$int", 92},
+    {"fcntl$lock", 92},
+    {"fcntl$setflags", 92},
+    {"fcntl$setown", 92},
+    {"fcntl$setstatus", 92},
+    {"fdatasync", 187},
+    {"fgetxattr", 235},
+    {"flistxattr", 241},
+    {"flock", 131},
+    {"fremovexattr", 239},
+    {"fsetxattr", 237},
+    {"fstat64", 339},
+    {"fstatat64", 470},
+    {"fsync", 95},
+    {"ftruncate", 201},
+    {"futimes", 139},
+    {"getdirentries64", 344},
+    {"getegid", 43},
+    {"getentropy", 500},
+    {"geteuid", 25},
+    {"getgid", 47},
+    {"getgroups", 79},
+    {"getitimer", 86},
+    {"getpeername", 31},
+    {"getpeername$inet", 31},
+    {"getpeername$inet6", 31},
+    {"getpeername$unix", 31},
+    {"getpgid", 151},
+    {"getpgrp", 81},
+    {"getpid", 20},
+    {"getppid", 39},
+    {"getrlimit", 194},
+    {"getrusage", 117},
+    {"getsockname", 32},
+    {"getsockname$inet", 32},
+    {"getsockname$inet6", 32},
+    {"getsockname$unix", 32},
+    {"getsockopt", 118},
+    {"getsockopt$inet6_int", 118},
+    {"getsockopt$inet_int", 118},
+    {"getsockopt$inet_opts", 118},
+    {"getsockopt$inet_tcp_int", 118},
+    {"getsockopt$sock_int", 118},
+    {"getsockopt$sock_linger", 118},
+    {"getsockopt$sock_timeval", 118},
+    {"getsockopt$unix_int", 118},
+    {"getsockopt$unix_peercred", 118},
+    {"getuid", 24},
+    {"getxattr", 234},
+    {"issetugid", 327},
+    {"kevent", 363},
+    {"kqueue", 362},
+    {"lchown", 364},
+    {"link", 9},
+    {"linkat", 471},
+    {"listen", 106},
+    {"listxattr", 240},
+    {"lseek", 199},
+    {"lstat64", 340},
+    {"madvise", 75},
+    {"mincore", 78},
+    {"minherit", 250},
+    {"mkdir", 136},
+    {"mkdirat", 475},
+    {"mkfifo", 132},
+    {"mknod", 14},
+    {"mlock", 203},
+    {"mlockall", 324},
+    {"mmap", 197},
+    {"mprotect", 74},
+    {"msync", 65},
+    {"munlock", 204},
+    {"munlockall", 325},
+    {"munmap", 73},
+    {"open", 5},
+    {"open$dir", 5},
+    {"openat", 463},
+    {"poll", 230},
+    {"pread", 153},
+    {"pwrite", 154},
+    {"read", 3},
+    {"readlink", 58},
+    {"readlinkat", 473},
+    {"readv", 120},
+    {"recvfrom", 29},
+    {"recvfrom$inet", 29},
+    {"recvfrom$inet6", 29},
+    {"recvfrom$unix", 29},
+    {"recvmsg", 27},
+    {"removexattr", 238},
+    {"rename", 128},
+    {"renameat", 465},
+    {"rmdir", 137},
+    {"select", 93},
+    {"sendmsg", 28},
+    {"sendmsg$unix", 28},
+    {"sendto", 133},
+    {"sendto$inet", 133},
+    {"sendto$inet6", 133},
+    {"sendto$unix", 133},
+    {"setegid", 182},
+    {"seteuid", 183},
+    {"setgid", 181},
+    {"setgroups", 80},
+    {"setitimer", 83},
+    {"setpgid", 82},
+    {"setregid", 127},
+    {"setreuid", 126},
+    {"setrlimit", 195},
+    {"setsockopt", 105},
+    {"setsockopt$inet6_int", 105},
+    {"setsockopt$inet_int", 105},
+    {"setsockopt$inet_opts", 105},
+    {"setsockopt$inet_tcp_int", 105},
+    {"setsockopt$sock_int", 105},
+    {"setsockopt$sock_linger", 105},
+    {"setsockopt$sock_timeval", 105},
+    {"setuid", 23},
+    {"setxattr", 236},
+    {"shutdown", 134},
+    {"socket", 97},
+    {"socket$inet", 97},
+    {"socket$inet6", 97},
+    {"socket$unix", 97},
+    {"socketpair", 135},
+    {"socketpair$unix", 135},
+    {"stat64", 338},
+    {"symlink", 57},
+    {"symlinkat", 474},
+    {"sync", 36},
+    {"syz_mach_msg", 0, (syscall_t)syz_mach_msg},
+    {"syz_mach_port_allocate", 0, (syscall_t)syz_mach_port_allocate},
+    {"syz_mach_port_allocate$set", 0, (syscall_t)syz_mach_port_allocate},
+    {"syz_mach_port_construct", 0, (syscall_t)syz_mach_port_construct},
+    {"syz_mach_port_deallocate", 0, (syscall_t)syz_mach_port_deallocate},
+    {"syz_mach_port_destroy", 0, (syscall_t)syz_mach_port_destroy},
+    {"syz_mach_port_destruct", 0, (syscall_t)syz_mach_port_destruct},
+    {"syz_mach_port_extract_member", 0, (syscall_t)syz_mach_port_extract_member},
+    {"syz_mach_port_guard", 0, (syscall_t)syz_mach_port_guard},
+    {"syz_mach_port_insert_member", 0, (syscall_t)syz_mach_port_insert_member},
+    {"syz_mach_port_insert_right", 0, (syscall_t)syz_mach_port_insert_right},
+    {"syz_mach_port_mod_refs", 0, (syscall_t)syz_mach_port_mod_refs},
+    {"syz_mach_port_move_member", 0, (syscall_t)syz_mach_port_move_member},
+    {"syz_mach_port_type", 0, (syscall_t)syz_mach_port_type},
+    {"syz_mach_port_unguard", 0, (syscall_t)syz_mach_port_unguard},
+    {"syz_mach_reply_port", 0, (syscall_t)syz_mach_reply_port},
+    {"syz_mach_timebase_info", 0, (syscall_t)syz_mach_timebase_info},
+    {"syz_mach_vm_allocate", 0, (syscall_t)syz_mach_vm_allocate},
+    {"syz_mach_vm_deallocate", 0, (syscall_t)syz_mach_vm_deallocate},
+    {"syz_mach_vm_protect", 0, (syscall_t)syz_mach_vm_protect},
+    {"syz_mk_timer_arm", 0, (syscall_t)syz_mk_timer_arm},
+    {"syz_mk_timer_cancel", 0, (syscall_t)syz_mk_timer_cancel},
+    {"syz_mk_timer_create", 0, (syscall_t)syz_mk_timer_create},
+    {"syz_mk_timer_destroy", 0, (syscall_t)syz_mk_timer_destroy},
+    {"syz_swtch_pri", 0, (syscall_t)syz_swtch_pri},
+    {"syz_thread_switch", 0, (syscall_t)syz_thread_switch},
+    {"truncate", 200},
+    {"unlink", 10},
+    {"unlinkat", 472},
+    {"utimes", 138},
+    {"wait4", 7},
+    {"write", 4},
+    {"writev", 121},
+
+};
+#endif
+
+#if GOARCH_arm64
+const call_t syscalls[] = {
+    {"accept", 30},
+    {"accept$inet", 30},
+    {"accept$inet6", 30},
+    {"accept$unix", 30},
+    {"access", 33},
+    {"bind", 104},
+    {"bind$inet", 104},
+    {"bind$inet6", 104},
+    {"bind$unix", 104},
+    {"chdir", 12},
+    {"chmod", 15},
+    {"chown", 16},
+    {"chroot", 61},
+    {"close", 6},
+    {"connect", 98},
+    {"connect$inet", 98},
+    {"connect$inet6", 98},
+    {"connect$unix", 98},
+    {"dup", 41},
+    {"dup2", 90},
+    {"faccessat", 466},
+    {"fchdir", 13},
+    {"fchmod", 124},
+    {"fchmodat", 467},
+    {"fchown", 123},
+    {"fchownat", 468},
+    {"fcntl$dupfd", 92},
+    {"fcntl$getflags", 92},
+    {"fcntl$getown", 92},
+    {"fcntl$getpath", 92},
+    {"fcntl$int", 92},
+    {"fcntl$lock", 92},
+    {"fcntl$setflags", 92},
+    {"fcntl$setown", 92},
+    {"fcntl$setstatus", 92},
+    {"fdatasync", 187},
+    {"fgetxattr", 235},
+    {"flistxattr", 241},
+    {"flock", 131},
+    {"fremovexattr", 239},
+    {"fsetxattr", 237},
+    {"fstat64", 339},
+    {"fstatat64", 470},
+    {"fsync", 95},
+    {"ftruncate", 201},
+    {"futimes", 139},
+    {"getdirentries64", 344},
+    {"getegid", 43},
+    {"getentropy", 500},
+    {"geteuid", 25},
+    {"getgid", 47},
+    {"getgroups", 79},
+    {"getitimer", 86},
+    {"getpeername", 31},
+    {"getpeername$inet", 31},
+    {"getpeername$inet6", 31},
+    {"getpeername$unix", 31},
+    {"getpgid", 151},
+    {"getpgrp", 81},
+    {"getpid", 20},
+    {"getppid", 39},
+    {"getrlimit", 194},
+    {"getrusage", 117},
+    {"getsockname", 32},
+    {"getsockname$inet", 32},
+    {"getsockname$inet6", 32},
+    {"getsockname$unix", 32},
+    {"getsockopt", 118},
+    {"getsockopt$inet6_int", 118},
+    {"getsockopt$inet_int", 118},
+    {"getsockopt$inet_opts", 118},
+    {"getsockopt$inet_tcp_int", 118},
+    {"getsockopt$sock_int", 118},
+    {"getsockopt$sock_linger", 118},
+    {"getsockopt$sock_timeval", 118},
+    {"getsockopt$unix_int", 118},
+    {"getsockopt$unix_peercred", 118},
+    {"getuid", 24},
+    {"getxattr", 234},
+    {"issetugid", 327},
+    {"kevent", 363},
+    {"kqueue", 362},
+    {"lchown", 364},
+    {"link", 9},
+    {"linkat", 471},
+    {"listen", 106},
+    {"listxattr", 240},
+    {"lseek", 199},
+    {"lstat64", 340},
+    {"madvise", 75},
+    {"mincore", 78},
+    {"minherit", 250},
+    {"mkdir", 136},
+    {"mkdirat", 475},
+    {"mkfifo", 132},
+    {"mknod", 14},
+    {"mlock", 203},
+    {"mlockall", 324},
+    {"mmap", 197},
+    {"mprotect", 74},
+    {"msync", 65},
+    {"munlock", 204},
+    {"munlockall", 325},
+    {"munmap", 73},
+    {"open", 5},
+    {"open$dir", 5},
+    {"openat", 463},
+    {"poll", 230},
+    {"pread", 153},
+    {"pwrite", 154},
+    {"read", 3},
+    {"readlink", 58},
+    {"readlinkat", 473},
+    {"readv", 120},
+    {"recvfrom", 29},
+    {"recvfrom$inet", 29},
+    {"recvfrom$inet6", 29},
+    {"recvfrom$unix", 29},
+    {"recvmsg", 27},
+    {"removexattr", 238},
+    {"rename", 128},
+    {"renameat", 465},
+    {"rmdir", 137},
+    {"select", 93},
+    {"sendmsg", 28},
+    {"sendmsg$unix", 28},
+    {"sendto", 133},
+    {"sendto$inet", 133},
+    {"sendto$inet6", 133},
+    {"sendto$unix", 133},
+    {"setegid", 182},
+    {"seteuid", 183},
+    {"setgid", 181},
+    {"setgroups", 80},
+    {"setitimer", 83},
+    {"setpgid", 82},
+    {"setregid", 127},
+    {"setreuid", 126},
+    {"setrlimit", 195},
+    {"setsockopt", 105},
+    {"setsockopt$inet6_int", 105},
+    {"setsockopt$inet_int", 105},
+    {"setsockopt$inet_opts", 105},
+    {"setsockopt$inet_tcp_int", 105},
+    {"setsockopt$sock_int", 105},
+    {"setsockopt$sock_linger", 105},
+    {"setsockopt$sock_timeval", 105},
+    {"setuid", 23},
+    {"setxattr", 236},
+    {"shutdown", 134},
+    {"socket", 97},
+    {"socket$inet", 97},
+    {"socket$inet6", 97},
+    {"socket$unix", 97},
+    {"socketpair", 135},
+    {"socketpair$unix", 135},
+    {"stat64", 338},
+    {"symlink", 57},
+    {"symlinkat", 474},
+    {"sync", 36},
+    {"syz_mach_msg", 0, (syscall_t)syz_mach_msg},
+    {"syz_mach_port_allocate", 0, (syscall_t)syz_mach_port_allocate},
+    {"syz_mach_port_allocate$set", 0, (syscall_t)syz_mach_port_allocate},
+    {"syz_mach_port_construct", 0, (syscall_t)syz_mach_port_construct},
+    {"syz_mach_port_deallocate", 0, (syscall_t)syz_mach_port_deallocate},
+    {"syz_mach_port_destroy", 0, (syscall_t)syz_mach_port_destroy},
+    {"syz_mach_port_destruct", 0, (syscall_t)syz_mach_port_destruct},
+    {"syz_mach_port_extract_member", 0, (syscall_t)syz_mach_port_extract_member},
+    {"syz_mach_port_guard", 0, (syscall_t)syz_mach_port_guard},
+    {"syz_mach_port_insert_member", 0, (syscall_t)syz_mach_port_insert_member},
+    {"syz_mach_port_insert_right", 0, (syscall_t)syz_mach_port_insert_right},
+    {"syz_mach_port_mod_refs", 0, (syscall_t)syz_mach_port_mod_refs},
+    {"syz_mach_port_move_member", 0, (syscall_t)syz_mach_port_move_member},
+    {"syz_mach_port_type", 0, (syscall_t)syz_mach_port_type},
+    {"syz_mach_port_unguard", 0, (syscall_t)syz_mach_port_unguard},
+    {"syz_mach_reply_port", 0, (syscall_t)syz_mach_reply_port},
+    {"syz_mach_timebase_info", 0, (syscall_t)syz_mach_timebase_info},
+    {"syz_mach_vm_allocate", 0, (syscall_t)syz_mach_vm_allocate},
+    {"syz_mach_vm_deallocate", 0, (syscall_t)syz_mach_vm_deallocate},
+    {"syz_mach_vm_protect", 0, (syscall_t)syz_mach_vm_protect},
+    {"syz_mk_timer_arm", 0, (syscall_t)syz_mk_timer_arm},
+    {"syz_mk_timer_cancel", 0, (syscall_t)syz_mk_timer_cancel},
+    {"syz_mk_timer_create", 0, (syscall_t)syz_mk_timer_create},
+    {"syz_mk_timer_destroy", 0, (syscall_t)syz_mk_timer_destroy},
+    {"syz_swtch_pri", 0, (syscall_t)syz_swtch_pri},
+    {"syz_thread_switch", 0, (syscall_t)syz_thread_switch},
+    {"truncate", 200},
+    {"unlink", 10},
+    {"unlinkat", 472},
+    {"utimes", 138},
+    {"wait4", 7},
+    {"write", 4},
+    {"writev", 121},
+
+};
+#endif
+
+#endif
+
 #if GOOS_freebsd
 
 #if GOARCH_386
@@ -2839,6 +3241,7 @@ const call_t syscalls[] = {
     {"ioctl$IMHOLD_L1", 54},
     {"ioctl$IMSETDEVNAME", 54},
     {"ioctl$INOTIFY_IOC_SETNEXTWD", 54},
+    {"ioctl$IOCTL_PRIVCMD_HYPERCALL", 54},
     {"ioctl$IOC_PR_CLEAR", 54},
     {"ioctl$IOC_PR_PREEMPT", 54},
     {"ioctl$IOC_PR_PREEMPT_ABORT", 54},
@@ -3721,6 +4124,7 @@ const call_t syscalls[] = {
     {"openat$cgroup_subtree", 295},
     {"openat$cgroup_type", 295},
     {"openat$cuse", 295},
+    {"openat$dev_port", 295},
     {"openat$dir", 295},
     {"openat$dlm_control", 295},
     {"openat$dlm_monitor", 295},
@@ -3809,6 +4213,7 @@ const call_t syscalls[] = {
     {"openat$vimc2", 295},
     {"openat$vnet", 295},
     {"openat$vsock", 295},
+    {"openat$xen_privcmd", 295},
     {"openat$xenevtchn", 295},
     {"openat$zero", 295},
     {"openat$zygote", 295},
@@ -3879,6 +4284,8 @@ const call_t syscalls[] = {
     {"prctl$PR_TASK_PERF_EVENTS_DISABLE", 172},
     {"prctl$PR_TASK_PERF_EVENTS_ENABLE", 172},
     {"pread64", 180},
+    {"pread64$msr", 180},
+    {"pread64$port", 180},
     {"preadv", 333},
     {"prlimit64", 340},
     {"process_vm_readv", 347},
@@ -3901,6 +4308,8 @@ const call_t syscalls[] = {
     {"ptrace$setregset", 26},
     {"ptrace$setsig", 26},
     {"pwrite64", 181},
+    {"pwrite64$msr", 181},
+    {"pwrite64$port", 181},
     {"pwritev", 334},
     {"quotactl", 131},
     {"read", 3},
@@ -4475,6 +4884,8 @@ const call_t syscalls[] = {
     {"sysfs$3", 135},
     {"sysinfo", 116},
     {"syslog", 103},
+    {"syz_9p_connect", 0, (syscall_t)syz_9p_connect},
+    {"syz_9p_io", 0, (syscall_t)syz_9p_io},
     {"syz_emit_ethernet", 0, (syscall_t)syz_emit_ethernet},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
     {"syz_extract_tcp_res", 0, (syscall_t)syz_extract_tcp_res},
@@ -4520,6 +4931,8 @@ const call_t syscalls[] = {
     {"syz_mount_image$reiserfs", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$vfat", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$xfs", 0, (syscall_t)syz_mount_image},
+    {"syz_nfs_connect", 0, (syscall_t)syz_nfs_connect},
+    {"syz_nfs_io", 0, (syscall_t)syz_nfs_io},
     {"syz_open_dev$CDROM_DEV_LINK", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$I2C", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$admmidi", 0, (syscall_t)syz_open_dev},
@@ -4528,6 +4941,7 @@ const call_t syscalls[] = {
     {"syz_open_dev$audion", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$binder", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$cec", 0, (syscall_t)syz_open_dev},
+    {"syz_open_dev$cpu_msr", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dmmidi", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dri", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dricontrol", 0, (syscall_t)syz_open_dev},
@@ -4567,10 +4981,21 @@ const call_t syscalls[] = {
     {"syz_open_procfs$namespace", 0, (syscall_t)syz_open_procfs},
     {"syz_open_pts", 0, (syscall_t)syz_open_pts},
     {"syz_read_part_table", 0, (syscall_t)syz_read_part_table},
+    {"syz_smb_connect", 0, (syscall_t)syz_smb_connect},
+    {"syz_smb_io", 0, (syscall_t)syz_smb_io},
     {"syz_usb_connect", 0, (syscall_t)syz_usb_connect},
     {"syz_usb_control_io", 0, (syscall_t)syz_usb_control_io},
     {"syz_usb_disconnect", 0, (syscall_t)syz_usb_disconnect},
     {"syz_usb_ep_write", 0, (syscall_t)syz_usb_ep_write},
+    {"syz_vmware_backdoor", 0, (syscall_t)syz_vmware_backdoor},
+    {"syz_vtcp_accept$inet", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_accept$inet6", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_connect", 0, (syscall_t)syz_vtcp_connect},
+    {"syz_vtcp_recv", 0, (syscall_t)syz_vtcp_recv},
+    {"syz_vtcp_send", 0, (syscall_t)syz_vtcp_send},
+    {"syz_vtcp_tls$inet", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls$inet6", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls_send", 0, (syscall_t)syz_vtcp_tls_send},
     {"tee", 315},
     {"tgkill", 270},
     {"time", 13},
@@ -5535,6 +5960,7 @@ const call_t syscalls[] = {
     {"ioctl$IMHOLD_L1", 16},
     {"ioctl$IMSETDEVNAME", 16},
     {"ioctl$INOTIFY_IOC_SETNEXTWD", 16},
+    {"ioctl$IOCTL_PRIVCMD_HYPERCALL", 16},
     {"ioctl$IOC_PR_CLEAR", 16},
     {"ioctl$IOC_PR_PREEMPT", 16},
     {"ioctl$IOC_PR_PREEMPT_ABORT", 16},
@@ -6421,6 +6847,7 @@ const call_t syscalls[] = {
     {"openat$cgroup_subtree", 257},
     {"openat$cgroup_type", 257},
     {"openat$cuse", 257},
+    {"openat$dev_port", 257},
     {"openat$dir", 257},
     {"openat$dlm_control", 257},
     {"openat$dlm_monitor", 257},
@@ -6509,6 +6936,7 @@ const call_t syscalls[] = {
     {"openat$vimc2", 257},
     {"openat$vnet", 257},
     {"openat$vsock", 257},
+    {"openat$xen_privcmd", 257},
     {"openat$xenevtchn", 257},
     {"openat$zero", 257},
     {"openat$zygote", 257},
@@ -6579,6 +7007,8 @@ const call_t syscalls[] = {
     {"prctl$PR_TASK_PERF_EVENTS_DISABLE", 157},
     {"prctl$PR_TASK_PERF_EVENTS_ENABLE", 157},
     {"pread64", 17},
+    {"pread64$msr", 17},
+    {"pread64$port", 17},
     {"preadv", 295},
     {"prlimit64", 302},
     {"process_vm_readv", 310},
@@ -6601,6 +7031,8 @@ const call_t syscalls[] = {
     {"ptrace$setregset", 101},
     {"ptrace$setsig", 101},
     {"pwrite64", 18},
+    {"pwrite64$msr", 18},
+    {"pwrite64$port", 18},
     {"pwritev", 296},
     {"quotactl", 179},
     {"read", 0},
@@ -7176,6 +7608,8 @@ const call_t syscalls[] = {
     {"sysfs$3", 139},
     {"sysinfo", 99},
     {"syslog", 103},
+    {"syz_9p_connect", 0, (syscall_t)syz_9p_connect},
+    {"syz_9p_io", 0, (syscall_t)syz_9p_io},
     {"syz_emit_ethernet", 0, (syscall_t)syz_emit_ethernet},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
     {"syz_extract_tcp_res", 0, (syscall_t)syz_extract_tcp_res},
@@ -7221,6 +7655,8 @@ const call_t syscalls[] = {
     {"syz_mount_image$reiserfs", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$vfat", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$xfs", 0, (syscall_t)syz_mount_image},
+    {"syz_nfs_connect", 0, (syscall_t)syz_nfs_connect},
+    {"syz_nfs_io", 0, (syscall_t)syz_nfs_io},
     {"syz_open_dev$CDROM_DEV_LINK", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$I2C", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$admmidi", 0, (syscall_t)syz_open_dev},
@@ -7229,6 +7665,7 @@ const call_t syscalls[] = {
     {"syz_open_dev$audion", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$binder", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$cec", 0, (syscall_t)syz_open_dev},
+    {"syz_open_dev$cpu_msr", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dmmidi", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dri", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dricontrol", 0, (syscall_t)syz_open_dev},
@@ -7268,10 +7705,21 @@ const call_t syscalls[] = {
     {"syz_open_procfs$namespace", 0, (syscall_t)syz_open_procfs},
     {"syz_open_pts", 0, (syscall_t)syz_open_pts},
     {"syz_read_part_table", 0, (syscall_t)syz_read_part_table},
+    {"syz_smb_connect", 0, (syscall_t)syz_smb_connect},
+    {"syz_smb_io", 0, (syscall_t)syz_smb_io},
     {"syz_usb_connect", 0, (syscall_t)syz_usb_connect},
     {"syz_usb_control_io", 0, (syscall_t)syz_usb_control_io},
     {"syz_usb_disconnect", 0, (syscall_t)syz_usb_disconnect},
     {"syz_usb_ep_write", 0, (syscall_t)syz_usb_ep_write},
+    {"syz_vmware_backdoor", 0, (syscall_t)syz_vmware_backdoor},
+    {"syz_vtcp_accept$inet", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_accept$inet6", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_connect", 0, (syscall_t)syz_vtcp_connect},
+    {"syz_vtcp_recv", 0, (syscall_t)syz_vtcp_recv},
+    {"syz_vtcp_send", 0, (syscall_t)syz_vtcp_send},
+    {"syz_vtcp_tls$inet", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls$inet6", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls_send", 0, (syscall_t)syz_vtcp_tls_send},
     {"tee", 276},
     {"tgkill", 234},
     {"time", 201},
@@ -8212,6 +8660,7 @@ const call_t syscalls[] = {
     {"ioctl$IMGETVERSION", 54},
     {"ioctl$IMHOLD_L1", 54},
     {"ioctl$IMSETDEVNAME", 54},
+    {"ioctl$IOCTL_PRIVCMD_HYPERCALL", 54},
     {"ioctl$IOC_PR_CLEAR", 54},
     {"ioctl$IOC_PR_PREEMPT", 54},
     {"ioctl$IOC_PR_PREEMPT_ABORT", 54},
@@ -9063,6 +9512,7 @@ const call_t syscalls[] = {
     {"openat$cgroup_subtree", 322},
     {"openat$cgroup_type", 322},
     {"openat$cuse", 322},
+    {"openat$dev_port", 322},
     {"openat$dir", 322},
     {"openat$dlm_control", 322},
     {"openat$dlm_monitor", 322},
@@ -9151,6 +9601,7 @@ const call_t syscalls[] = {
     {"openat$vimc2", 322},
     {"openat$vnet", 322},
     {"openat$vsock", 322},
+    {"openat$xen_privcmd", 322},
     {"openat$xenevtchn", 322},
     {"openat$zero", 322},
     {"openat$zygote", 322},
@@ -9221,6 +9672,8 @@ const call_t syscalls[] = {
     {"prctl$PR_TASK_PERF_EVENTS_DISABLE", 172},
     {"prctl$PR_TASK_PERF_EVENTS_ENABLE", 172},
     {"pread64", 180},
+    {"pread64$msr", 180},
+    {"pread64$port", 180},
     {"preadv", 361},
     {"prlimit64", 369},
     {"process_vm_readv", 376},
@@ -9243,6 +9696,8 @@ const call_t syscalls[] = {
     {"ptrace$setregset", 26},
     {"ptrace$setsig", 26},
     {"pwrite64", 181},
+    {"pwrite64$msr", 181},
+    {"pwrite64$port", 181},
     {"pwritev", 362},
     {"quotactl", 131},
     {"read", 3},
@@ -9816,6 +10271,8 @@ const call_t syscalls[] = {
     {"sysfs$3", 135},
     {"sysinfo", 116},
     {"syslog", 103},
+    {"syz_9p_connect", 0, (syscall_t)syz_9p_connect},
+    {"syz_9p_io", 0, (syscall_t)syz_9p_io},
     {"syz_emit_ethernet", 0, (syscall_t)syz_emit_ethernet},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
     {"syz_extract_tcp_res", 0, (syscall_t)syz_extract_tcp_res},
@@ -9861,6 +10318,8 @@ const call_t syscalls[] = {
     {"syz_mount_image$reiserfs", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$vfat", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$xfs", 0, (syscall_t)syz_mount_image},
+    {"syz_nfs_connect", 0, (syscall_t)syz_nfs_connect},
+    {"syz_nfs_io", 0, (syscall_t)syz_nfs_io},
     {"syz_open_dev$CDROM_DEV_LINK", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$I2C", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$admmidi", 0, (syscall_t)syz_open_dev},
@@ -9869,6 +10328,7 @@ const call_t syscalls[] = {
     {"syz_open_dev$audion", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$binder", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$cec", 0, (syscall_t)syz_open_dev},
+    {"syz_open_dev$cpu_msr", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dmmidi", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dri", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dricontrol", 0, (syscall_t)syz_open_dev},
@@ -9908,10 +10368,21 @@ const call_t syscalls[] = {
     {"syz_open_procfs$namespace", 0, (syscall_t)syz_open_procfs},
     {"syz_open_pts", 0, (syscall_t)syz_open_pts},
     {"syz_read_part_table", 0, (syscall_t)syz_read_part_table},
+    {"syz_smb_connect", 0, (syscall_t)syz_smb_connect},
+    {"syz_smb_io", 0, (syscall_t)syz_smb_io},
     {"syz_usb_connect", 0, (syscall_t)syz_usb_connect},
     {"syz_usb_control_io", 0, (syscall_t)syz_usb_control_io},
     {"syz_usb_disconnect", 0, (syscall_t)syz_usb_disconnect},
     {"syz_usb_ep_write", 0, (syscall_t)syz_usb_ep_write},
+    {"syz_vmware_backdoor", 0, (syscall_t)syz_vmware_backdoor},
+    {"syz_vtcp_accept$inet", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_accept$inet6", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_connect", 0, (syscall_t)syz_vtcp_connect},
+    {"syz_vtcp_recv", 0, (syscall_t)syz_vtcp_recv},
+    {"syz_vtcp_send", 0, (syscall_t)syz_vtcp_send},
+    {"syz_vtcp_tls$inet", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls$inet6", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls_send", 0, (syscall_t)syz_vtcp_tls_send},
     {"tee", 342},
     {"tgkill", 268},
     {"timer_create", 257},
@@ -10842,6 +11313,7 @@ const call_t syscalls[] = {
     {"ioctl$IMHOLD_L1", 29},
     {"ioctl$IMSETDEVNAME", 29},
     {"ioctl$INOTIFY_IOC_SETNEXTWD", 29},
+    {"ioctl$IOCTL_PRIVCMD_HYPERCALL", 29},
     {"ioctl$IOC_PR_CLEAR", 29},
     {"ioctl$IOC_PR_PREEMPT", 29},
     {"ioctl$IOC_PR_PREEMPT_ABORT", 29},
@@ -11689,6 +12161,7 @@ const call_t syscalls[] = {
     {"openat$cgroup_subtree", 56},
     {"openat$cgroup_type", 56},
     {"openat$cuse", 56},
+    {"openat$dev_port", 56},
     {"openat$dir", 56},
     {"openat$dlm_control", 56},
     {"openat$dlm_monitor", 56},
@@ -11777,6 +12250,7 @@ const call_t syscalls[] = {
     {"openat$vimc2", 56},
     {"openat$vnet", 56},
     {"openat$vsock", 56},
+    {"openat$xen_privcmd", 56},
     {"openat$xenevtchn", 56},
     {"openat$zero", 56},
     {"openat$zygote", 56},
@@ -11844,6 +12318,8 @@ const call_t syscalls[] = {
     {"prctl$PR_TASK_PERF_EVENTS_DISABLE", 167},
     {"prctl$PR_TASK_PERF_EVENTS_ENABLE", 167},
     {"pread64", 67},
+    {"pread64$msr", 67},
+    {"pread64$port", 67},
     {"preadv", 69},
     {"prlimit64", 261},
     {"process_vm_readv", 270},
@@ -11866,6 +12342,8 @@ const call_t syscalls[] = {
     {"ptrace$setregset", 117},
     {"ptrace$setsig", 117},
     {"pwrite64", 68},
+    {"pwrite64$msr", 68},
+    {"pwrite64$port", 68},
     {"pwritev", 70},
     {"quotactl", 60},
     {"read", 63},
@@ -12430,6 +12908,8 @@ const call_t syscalls[] = {
     {"syncfs", 267},
     {"sysinfo", 179},
     {"syslog", 116},
+    {"syz_9p_connect", 0, (syscall_t)syz_9p_connect},
+    {"syz_9p_io", 0, (syscall_t)syz_9p_io},
     {"syz_emit_ethernet", 0, (syscall_t)syz_emit_ethernet},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
     {"syz_extract_tcp_res", 0, (syscall_t)syz_extract_tcp_res},
@@ -12475,6 +12955,8 @@ const call_t syscalls[] = {
     {"syz_mount_image$reiserfs", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$vfat", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$xfs", 0, (syscall_t)syz_mount_image},
+    {"syz_nfs_connect", 0, (syscall_t)syz_nfs_connect},
+    {"syz_nfs_io", 0, (syscall_t)syz_nfs_io},
     {"syz_open_dev$CDROM_DEV_LINK", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$I2C", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$admmidi", 0, (syscall_t)syz_open_dev},
@@ -12483,6 +12965,7 @@ const call_t syscalls[] = {
     {"syz_open_dev$audion", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$binder", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$cec", 0, (syscall_t)syz_open_dev},
+    {"syz_open_dev$cpu_msr", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dmmidi", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dri", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dricontrol", 0, (syscall_t)syz_open_dev},
@@ -12522,10 +13005,21 @@ const call_t syscalls[] = {
     {"syz_open_procfs$namespace", 0, (syscall_t)syz_open_procfs},
     {"syz_open_pts", 0, (syscall_t)syz_open_pts},
     {"syz_read_part_table", 0, (syscall_t)syz_read_part_table},
+    {"syz_smb_connect", 0, (syscall_t)syz_smb_connect},
+    {"syz_smb_io", 0, (syscall_t)syz_smb_io},
     {"syz_usb_connect", 0, (syscall_t)syz_usb_connect},
     {"syz_usb_control_io", 0, (syscall_t)syz_usb_control_io},
     {"syz_usb_disconnect", 0, (syscall_t)syz_usb_disconnect},
     {"syz_usb_ep_write", 0, (syscall_t)syz_usb_ep_write},
+    {"syz_vmware_backdoor", 0, (syscall_t)syz_vmware_backdoor},
+    {"syz_vtcp_accept$inet", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_accept$inet6", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_connect", 0, (syscall_t)syz_vtcp_connect},
+    {"syz_vtcp_recv", 0, (syscall_t)syz_vtcp_recv},
+    {"syz_vtcp_send", 0, (syscall_t)syz_vtcp_send},
+    {"syz_vtcp_tls$inet", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls$inet6", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls_send", 0, (syscall_t)syz_vtcp_tls_send},
     {"tee", 77},
     {"tgkill", 131},
     {"timer_create", 107},
@@ -13463,6 +13957,7 @@ const call_t syscalls[] = {
     {"ioctl$IMGETVERSION", 54},
     {"ioctl$IMHOLD_L1", 54},
     {"ioctl$IMSETDEVNAME", 54},
+    {"ioctl$IOCTL_PRIVCMD_HYPERCALL", 54},
     {"ioctl$IOC_PR_CLEAR", 54},
     {"ioctl$IOC_PR_PREEMPT", 54},
     {"ioctl$IOC_PR_PREEMPT_ABORT", 54},
@@ -14310,6 +14805,7 @@ const call_t syscalls[] = {
     {"openat$cgroup_subtree", 286},
     {"openat$cgroup_type", 286},
     {"openat$cuse", 286},
+    {"openat$dev_port", 286},
     {"openat$dir", 286},
     {"openat$dlm_control", 286},
     {"openat$dlm_monitor", 286},
@@ -14398,6 +14894,7 @@ const call_t syscalls[] = {
     {"openat$vimc2", 286},
     {"openat$vnet", 286},
     {"openat$vsock", 286},
+    {"openat$xen_privcmd", 286},
     {"openat$xenevtchn", 286},
     {"openat$zero", 286},
     {"openat$zygote", 286},
@@ -14468,6 +14965,8 @@ const call_t syscalls[] = {
     {"prctl$PR_TASK_PERF_EVENTS_DISABLE", 171},
     {"prctl$PR_TASK_PERF_EVENTS_ENABLE", 171},
     {"pread64", 179},
+    {"pread64$msr", 179},
+    {"pread64$port", 179},
     {"preadv", 320},
     {"prlimit64", 325},
     {"process_vm_readv", 351},
@@ -14490,6 +14989,8 @@ const call_t syscalls[] = {
     {"ptrace$setregset", 26},
     {"ptrace$setsig", 26},
     {"pwrite64", 180},
+    {"pwrite64$msr", 180},
+    {"pwrite64$port", 180},
     {"pwritev", 321},
     {"quotactl", 131},
     {"read", 3},
@@ -15062,6 +15563,8 @@ const call_t syscalls[] = {
     {"sysfs$3", 135},
     {"sysinfo", 116},
     {"syslog", 103},
+    {"syz_9p_connect", 0, (syscall_t)syz_9p_connect},
+    {"syz_9p_io", 0, (syscall_t)syz_9p_io},
     {"syz_emit_ethernet", 0, (syscall_t)syz_emit_ethernet},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
     {"syz_extract_tcp_res", 0, (syscall_t)syz_extract_tcp_res},
@@ -15107,6 +15610,8 @@ const call_t syscalls[] = {
     {"syz_mount_image$reiserfs", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$vfat", 0, (syscall_t)syz_mount_image},
     {"syz_mount_image$xfs", 0, (syscall_t)syz_mount_image},
+    {"syz_nfs_connect", 0, (syscall_t)syz_nfs_connect},
+    {"syz_nfs_io", 0, (syscall_t)syz_nfs_io},
     {"syz_open_dev$CDROM_DEV_LINK", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$I2C", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$admmidi", 0, (syscall_t)syz_open_dev},
@@ -15115,6 +15620,7 @@ const call_t syscalls[] = {
     {"syz_open_dev$audion", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$binder", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$cec", 0, (syscall_t)syz_open_dev},
+    {"syz_open_dev$cpu_msr", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dmmidi", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dri", 0, (syscall_t)syz_open_dev},
     {"syz_open_dev$dricontrol", 0, (syscall_t)syz_open_dev},
@@ -15154,10 +15660,21 @@ const call_t syscalls[] = {
     {"syz_open_procfs$namespace", 0, (syscall_t)syz_open_procfs},
     {"syz_open_pts", 0, (syscall_t)syz_open_pts},
     {"syz_read_part_table", 0, (syscall_t)syz_read_part_table},
+    {"syz_smb_connect", 0, (syscall_t)syz_smb_connect},
+    {"syz_smb_io", 0, (syscall_t)syz_smb_io},
     {"syz_usb_connect", 0, (syscall_t)syz_usb_connect},
     {"syz_usb_control_io", 0, (syscall_t)syz_usb_control_io},
     {"syz_usb_disconnect", 0, (syscall_t)syz_usb_disconnect},
     {"syz_usb_ep_write", 0, (syscall_t)syz_usb_ep_write},
+    {"syz_vmware_backdoor", 0, (syscall_t)syz_vmware_backdoor},
+    {"syz_vtcp_accept$inet", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_accept$inet6", 0, (syscall_t)syz_vtcp_accept},
+    {"syz_vtcp_connect", 0, (syscall_t)syz_vtcp_connect},
+    {"syz_vtcp_recv", 0, (syscall_t)syz_vtcp_recv},
+    {"syz_vtcp_send", 0, (syscall_t)syz_vtcp_send},
+    {"syz_vtcp_tls$inet", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls$inet6", 0, (syscall_t)syz_vtcp_tls},
+    {"syz_vtcp_tls_send", 0, (syscall_t)syz_vtcp_tls_send},
     {"tee", 284},
     {"tgkill", 250},
     {"time", 13},
@@ -15991,6 +16508,8 @@ const call_t syscalls[] = {
     {"test$excessive_args1", 0},
     {"test$excessive_args2", 0},
     {"test$excessive_fields1", 0},
+    {"test$fs_image_ext4", 0},
+    {"test$fs_image_vfat", 0},
     {"test$hint_data", 0},
     {"test$int", 0},
     {"test$length0", 0},
@@ -16026,6 +16545,7 @@ const call_t syscalls[] = {
     {"test$length9", 0},
     {"test$missing_resource", 0},
     {"test$missing_struct", 0},
+    {"test$nlattr", 0},
     {"test$offsetof0", 0},
     {"test$opt0", 0},
     {"test$opt1", 0},
@@ -16044,9 +16564,14 @@ const call_t syscalls[] = {
     {"test$struct", 0},
     {"test$syz_union3", 0},
     {"test$syz_union4", 0},
+    {"test$text_arm64", 0},
+    {"test$text_arm64_priv", 0},
+    {"test$text_bpf", 0},
     {"test$text_x86_16", 0},
     {"test$text_x86_32", 0},
     {"test$text_x86_64", 0},
+    {"test$text_x86_64_io", 0},
+    {"test$text_x86_64_user", 0},
     {"test$text_x86_real", 0},
     {"test$type_confusion1", 0},
     {"test$union0", 0},
@@ -17907,6 +18432,26 @@ const call_t syscalls[] = {
     {"NotifyServiceStatusChangeA", 0, (syscall_t)NotifyServiceStatusChangeA},
     {"NotifyUILanguageChange", 0, (syscall_t)NotifyUILanguageChange},
     {"NotifyWinEvent", 0, (syscall_t)NotifyWinEvent},
+    {"NtAllocateVirtualMemory", 0, (syscall_t)NtAllocateVirtualMemory},
+    {"NtClose", 0, (syscall_t)NtClose},
+    {"NtCreateEvent", 0, (syscall_t)NtCreateEvent},
+    {"NtCreateSection", 0, (syscall_t)NtCreateSection},
+    {"NtDuplicateObject", 0, (syscall_t)NtDuplicateObject},
+    {"NtExtendSection", 0, (syscall_t)NtExtendSection},
+    {"NtFlushBuffersFile", 0, (syscall_t)NtFlushBuffersFile},
+    {"NtFreeVirtualMemory", 0, (syscall_t)NtFreeVirtualMemory},
+    {"NtOpenFile", 0, (syscall_t)NtOpenFile},
+    {"NtProtectVirtualMemory", 0, (syscall_t)NtProtectVirtualMemory},
+    {"NtQueryInformationFile", 0, (syscall_t)NtQueryInformationFile},
+    {"NtQueryObject", 0, (syscall_t)NtQueryObject},
+    {"NtQueryVirtualMemory", 0, (syscall_t)NtQueryVirtualMemory},
+    {"NtReadFile", 0, (syscall_t)NtReadFile},
+    {"NtResetEvent", 0, (syscall_t)NtResetEvent},
+    {"NtSetEvent", 0, (syscall_t)NtSetEvent},
+    {"NtSetInformationFile", 0, (syscall_t)NtSetInformationFile},
+    {"NtUnmapViewOfSection", 0, (syscall_t)NtUnmapViewOfSection},
+    {"NtWaitForSingleObject", 0, (syscall_t)NtWaitForSingleObject},
+    {"NtWriteFile", 0, (syscall_t)NtWriteFile},
     {"ObjectCloseAuditAlarmA", 0, (syscall_t)ObjectCloseAuditAlarmA},
     {"ObjectDeleteAuditAlarmA", 0, (syscall_t)ObjectDeleteAuditAlarmA},
     {"ObjectOpenAuditAlarmA", 0, (syscall_t)ObjectOpenAuditAlarmA},
@@ -19008,6 +19553,11 @@ const call_t syscalls[] = {
     {"sndPlaySoundA", 0, (syscall_t)sndPlaySoundA},
     {"socket", 0, (syscall_t)socket},
     {"syz_execute_func", 0, (syscall_t)syz_execute_func},
+    {"syz_nt_create_file", 0, (syscall_t)syz_nt_create_file},
+    {"syz_nt_device_io_control_file", 0, (syscall_t)syz_nt_device_io_control_file},
+    {"syz_nt_fs_control_file", 0, (syscall_t)syz_nt_fs_control_file},
+    {"syz_nt_map_view_of_section", 0, (syscall_t)syz_nt_map_view_of_section},
+    {"syz_nt_query_directory_file", 0, (syscall_t)syz_nt_query_directory_file},
     {"timeBeginPeriod", 0, (syscall_t)timeBeginPeriod},
     {"timeEndPeriod", 0, (syscall_t)timeEndPeriod},
     {"timeGetDevCaps", 0, (syscall_t)timeGetDevCaps},
@@ -19074,3 +19624,62 @@ const call_t syscalls[] = {
 #endif
 
 #endif
+
+#if GOOS_zephyr
+
+#if GOARCH_arm
+const call_t syscalls[] = {
+    {"k_msgq_alloc_init", 0, (syscall_t)k_msgq_alloc_init},
+    {"k_msgq_get_attrs", 0, (syscall_t)k_msgq_get_attrs},
+    {"k_msgq_num_free_get", 0, (syscall_t)k_msgq_num_free_get},
+    {"k_msgq_num_used_get", 0, (syscall_t)k_msgq_num_used_get},
+    {"k_msgq_peek", 0, (syscall_t)k_msgq_peek},
+    {"k_msgq_purge", 0, (syscall_t)k_msgq_purge},
+    {"k_mutex_init", 0, (syscall_t)k_mutex_init},
+    {"k_mutex_unlock", 0, (syscall_t)k_mutex_unlock},
+    {"k_object_alloc$msgq", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$mutex", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$pipe", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$poll_signal", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$queue", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$sem", 0, (syscall_t)k_object_alloc},
+    {"k_object_alloc$stack", 0, (syscall_t)k_object_alloc},
+    {"k_object_release", 0, (syscall_t)k_object_release},
+    {"k_pipe_alloc_init", 0, (syscall_t)k_pipe_alloc_init},
+    {"k_poll_signal_check", 0, (syscall_t)k_poll_signal_check},
+    {"k_poll_signal_init", 0, (syscall_t)k_poll_signal_init},
+    {"k_poll_signal_raise", 0, (syscall_t)k_poll_signal_raise},
+    {"k_poll_signal_reset", 0, (syscall_t)k_poll_signal_reset},
+    {"k_queue_alloc_append", 0, (syscall_t)k_queue_alloc_append},
+    {"k_queue_alloc_prepend", 0, (syscall_t)k_queue_alloc_prepend},
+    {"k_queue_cancel_wait", 0, (syscall_t)k_queue_cancel_wait},
+    {"k_queue_init", 0, (syscall_t)k_queue_init},
+    {"k_queue_is_empty", 0, (syscall_t)k_queue_is_empty},
+    {"k_queue_peek_head", 0, (syscall_t)k_queue_peek_head},
+    {"k_queue_peek_tail", 0, (syscall_t)k_queue_peek_tail},
+    {"k_sem_count_get", 0, (syscall_t)k_sem_count_get},
+    {"k_sem_give", 0, (syscall_t)k_sem_give},
+    {"k_sem_init", 0, (syscall_t)k_sem_init},
+    {"k_sem_reset", 0, (syscall_t)k_sem_reset},
+    {"k_stack_alloc_init", 0, (syscall_t)k_stack_alloc_init},
+    {"k_stack_push", 0, (syscall_t)k_stack_push},
+    {"k_str_out", 0, (syscall_t)k_str_out},
+    {"k_uptime_ticks", 0, (syscall_t)k_uptime_ticks},
+    {"k_usleep", 0, (syscall_t)k_usleep},
+    {"k_yield", 0, (syscall_t)k_yield},
+    {"syz_k_msgq_get", 0, (syscall_t)syz_k_msgq_get},
+    {"syz_k_msgq_put", 0, (syscall_t)syz_k_msgq_put},
+    {"syz_k_mutex_lock", 0, (syscall_t)syz_k_mutex_lock},
+    {"syz_k_pipe_get", 0, (syscall_t)syz_k_pipe_get},
+    {"syz_k_pipe_put", 0, (syscall_t)syz_k_pipe_put},
+    {"syz_k_poll", 0, (syscall_t)syz_k_poll},
+    {"syz_k_queue_get", 0, (syscall_t)syz_k_queue_get},
+    {"syz_k_sem_take", 0, (syscall_t)syz_k_sem_take},
+    {"syz_k_sleep", 0, (syscall_t)syz_k_sleep},
+    {"syz_k_stack_pop", 0, (syscall_t)syz_k_stack_pop},
+    {"syz_mmap", 0, (syscall_t)syz_mmap},
+
+};
+#endif
+
+#endif
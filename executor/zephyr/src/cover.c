@@ -0,0 +1,36 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Coverage callback for kernel code built with -fsanitize-coverage=trace-pc.
+// PCs are collected only while the kernel runs on behalf of the worker thread
+// that executes the current call (i.e. inside its syscalls), similar to KCOV.
+
+#include <kernel.h>
+
+#include "cover.h"
+
+struct k_thread* volatile syz_cover_thread;
+uint32_t syz_cover_data[CONFIG_SYZ_COVER_SIZE];
+volatile uint32_t syz_cover_size;
+
+void __sanitizer_cov_trace_pc(void)
+{
+	if (syz_cover_thread == NULL || k_is_in_isr() || k_current_get() != syz_cover_thread)
+		return;
+	uint32_t pos = syz_cover_size;
+	if (pos >= CONFIG_SYZ_COVER_SIZE)
+		return;
+	syz_cover_data[pos] = (uint32_t)__builtin_return_address(0);
+	syz_cover_size = pos + 1;
+}
+
+void syz_cover_enable(struct k_thread* thread)
+{
+	syz_cover_size = 0;
+	syz_cover_thread = thread;
+}
+
+void syz_cover_disable(void)
+{
+	syz_cover_thread = NULL;
+}
@@ -0,0 +1,10 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+#include <kernel.h>
+
+extern uint32_t syz_cover_data[CONFIG_SYZ_COVER_SIZE];
+extern volatile uint32_t syz_cover_size;
+
+void syz_cover_enable(struct k_thread* thread);
+void syz_cover_disable(void);
@@ -0,0 +1,704 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// On-device executor for Zephyr.
+// It speaks the fork server protocol of executor.cc without shmem (see pkg/ipc),
+// but over a UART that is relayed to the fuzzer by tools/syz-serialexec.
+// Calls are executed one-by-one in user mode threads, so that a call that
+// passes bad arguments only kills its thread (K_ERR_KERNEL_OOPS),
+// while any other fatal error halts the system and is reported as a crash.
+// Threaded/collide modes and comparisons are not supported.
+
+#include <device.h>
+#include <drivers/uart.h>
+#include <errno.h>
+#include <fatal.h>
+#include <kernel.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/printk.h>
+#include <sys/ring_buffer.h>
+
+#include "cover.h"
+#include "defs.h"
+
+#ifndef GIT_REVISION
+#define GIT_REVISION "unknown"
+#endif
+
+#define ARRAY_SIZE(x) (sizeof(x) / sizeof((x)[0]))
+
+typedef unsigned long long uint64;
+typedef unsigned int uint32;
+typedef unsigned short uint16;
+typedef unsigned char uint8;
+
+typedef intptr_t (*syscall_t)(intptr_t, intptr_t, intptr_t, intptr_t, intptr_t, intptr_t, intptr_t, intptr_t, intptr_t);
+
+typedef struct {
+	const char* name;
+	int sys_nr;
+	syscall_t call;
+} call_t;
+
+#define kMaxArgs 9
+#define kMaxCommands 1000
+#define kMaxInput (64 << 10)
+#define kFailStatus 67
+#define kWorkerStackSize 2048
+
+static const uint64 kInMagic = 0xbadc0ffeebadface;
+static const uint32 kOutMagic = 0xbadf00d;
+// tools/syz-serialexec sends kSyncMagic before the handshake request,
+// so that the executor can find message boundaries after the relay restarts.
+// kVersionMagic after kSyncMagic requests the version line.
+static const uint64 kSyncMagic = 0x434e59532d5a5953; // "SYZ-SYNC"
+static const uint64 kVersionMagic = 0x535245562d5a5953; // "SYZ-VERS"
+
+static const uint64 instr_eof = -1;
+static const uint64 instr_copyin = -2;
+static const uint64 instr_copyout = -3;
+
+static const uint64 arg_const = 0;
+static const uint64 arg_result = 1;
+static const uint64 arg_data = 2;
+
+static const uint64 binary_format_native = 0;
+static const uint64 binary_format_bigendian = 1;
+
+static const uint64 no_copyout = -1;
+
+static const uint32 call_flag_executed = 1 << 0;
+static const uint32 call_flag_finished = 1 << 1;
+
+// Pseudo-syscalls, called in the worker thread in user mode.
+// Calls that accept k_timeout_t accept timeout in milliseconds.
+
+static intptr_t syz_mmap(intptr_t addr, intptr_t len)
+{
+	// The data region is always mapped (reserved in devicetree).
+	return 0;
+}
+
+static intptr_t syz_k_sem_take(intptr_t sem, intptr_t ms)
+{
+	return k_sem_take((struct k_sem*)sem, K_MSEC(ms));
+}
+
+static intptr_t syz_k_mutex_lock(intptr_t mutex, intptr_t ms)
+{
+	return k_mutex_lock((struct k_mutex*)mutex, K_MSEC(ms));
+}
+
+static intptr_t syz_k_msgq_put(intptr_t msgq, intptr_t data, intptr_t ms)
+{
+	return k_msgq_put((struct k_msgq*)msgq, (void*)data, K_MSEC(ms));
+}
+
+static intptr_t syz_k_msgq_get(intptr_t msgq, intptr_t data, intptr_t ms)
+{
+	return k_msgq_get((struct k_msgq*)msgq, (void*)data, K_MSEC(ms));
+}
+
+static intptr_t syz_k_pipe_put(intptr_t pipe, intptr_t data, intptr_t bytes, intptr_t written, intptr_t min_xfer, intptr_t ms)
+{
+	return k_pipe_put((struct k_pipe*)pipe, (void*)data, bytes, (size_t*)written, min_xfer, K_MSEC(ms));
+}
+
+static intptr_t syz_k_pipe_get(intptr_t pipe, intptr_t data, intptr_t bytes, intptr_t read, intptr_t min_xfer, intptr_t ms)
+{
+	return k_pipe_get((struct k_pipe*)pipe, (void*)data, bytes, (size_t*)read, min_xfer, K_MSEC(ms));
+}
+
+static intptr_t syz_k_stack_pop(intptr_t stack, intptr_t data, intptr_t ms)
+{
+	return k_stack_pop((struct k_stack*)stack, (stack_data_t*)data, K_MSEC(ms));
+}
+
+static intptr_t syz_k_queue_get(intptr_t queue, intptr_t ms)
+{
+	return (intptr_t)k_queue_get((struct k_queue*)queue, K_MSEC(ms));
+}
+
+static intptr_t syz_k_poll(intptr_t events, intptr_t num, intptr_t ms)
+{
+	return k_poll((struct k_poll_event*)events, num, K_MSEC(ms));
+}
+
+static intptr_t syz_k_sleep(intptr_t ms)
+{
+	return k_msleep(ms);
+}
+
+#include "syscalls.h"
+
+// The data region (see DataOffset/NumPages in sys/targets) is the only memory
+// shared with the worker threads besides the syz_partition below.
+K_MEM_PARTITION_DEFINE(data_partition, SYZ_DATA_OFFSET, SYZ_NUM_PAGES* SYZ_PAGE_SIZE, K_MEM_PARTITION_P_RW_U_RW);
+K_APPMEM_PARTITION_DEFINE(syz_partition);
+static struct k_mem_domain syz_domain;
+
+struct worker_call {
+	syscall_t fn;
+	intptr_t args[kMaxArgs];
+	intptr_t res;
+	bool done;
+};
+
+K_APP_BMEM(syz_partition)
+static struct worker_call current_call;
+
+K_THREAD_STACK_DEFINE(worker_stack, kWorkerStackSize);
+static struct k_thread worker_thread;
+static volatile bool worker_running;
+
+struct result_t {
+	bool executed;
+	uint64 val;
+};
+
+static struct result_t results[kMaxCommands];
+static uint64 input_data[kMaxInput / sizeof(uint64)];
+static uint64 procid;
+static bool flag_cover;
+static bool flag_collect_cover;
+static bool flag_dedup_cover;
+static uint64 slowdown_scale = 1;
+
+static const struct device* uart;
+RING_BUF_DECLARE(uart_rx, 4096);
+static K_SEM_DEFINE(uart_rx_sem, 0, 1);
+
+struct execute_req {
+	uint64 magic;
+	uint64 env_flags;
+	uint64 exec_flags;
+	uint64 pid;
+	uint64 fault_call;
+	uint64 fault_nth;
+	uint64 race_call1;
+	uint64 race_call2;
+	uint64 race_mode;
+	uint64 race_delay;
+	uint64 slowdown;
+	uint64 prog_size;
+};
+
+struct execute_reply {
+	uint32 magic;
+	uint32 done;
+	uint32 status;
+};
+
+struct call_reply {
+	struct execute_reply header;
+	uint32 call_index;
+	uint32 call_num;
+	uint32 reserrno;
+	uint32 flags;
+	uint32 signal_size;
+	uint32 cover_size;
+	uint32 comps_size;
+};
+
+static void uart_isr(const struct device* dev, void* user_data)
+{
+	uint8 buf[64];
+	while (uart_irq_update(dev) && uart_irq_rx_ready(dev)) {
+		int n = uart_fifo_read(dev, buf, sizeof(buf));
+		if (n <= 0)
+			break;
+		// If the ring buffer overflows, the executor will resync on the next request.
+		ring_buf_put(&uart_rx, buf, n);
+		k_sem_give(&uart_rx_sem);
+	}
+}
+
+static void serial_read(void* data, size_t size)
+{
+	uint8* pos = data;
+	while (size) {
+		uint32 n = ring_buf_get(&uart_rx, pos, size);
+		if (n == 0) {
+			k_sem_take(&uart_rx_sem, K_FOREVER);
+			continue;
+		}
+		pos += n;
+		size -= n;
+	}
+}
+
+static void serial_write(const void* data, size_t size)
+{
+	const uint8* pos = data;
+	for (size_t i = 0; i < size; i++)
+		uart_poll_out(uart, pos[i]);
+}
+
+// serial_sync skips input until kSyncMagic.
+static void serial_sync(void)
+{
+	uint64 window = 0;
+	while (window != kSyncMagic) {
+		uint8 c;
+		serial_read(&c, 1);
+		window = (window >> 8) | ((uint64)c << 56);
+	}
+}
+
+static void reply_done(uint32 status)
+{
+	struct execute_reply reply = {};
+	reply.magic = kOutMagic;
+	reply.done = 1;
+	reply.status = status;
+	serial_write(&reply, sizeof(reply));
+}
+
+// fail reports the error on console and to the fuzzer.
+// Unlike in executor.cc, it does not exit: there is nothing to restart the executor,
+// so it returns back to serve.
+static bool failed;
+
+static void fail(const char* msg, uint64 val)
+{
+	printk("SYZFAIL: %s 0x%llx\n", msg, val);
+	failed = true;
+}
+
+static uint64 read_input(uint64** input_posp)
+{
+	uint64* input_pos = *input_posp;
+	if (failed || input_pos >= input_data + ARRAY_SIZE(input_data)) {
+		if (!failed)
+			fail("input command overflows input", (uint64)(uintptr_t)input_pos);
+		return instr_eof;
+	}
+	*input_posp = input_pos + 1;
+	return *input_pos;
+}
+
+static bool in_data_region(uint64 addr, uint64 size)
+{
+	return addr >= SYZ_DATA_OFFSET && size <= SYZ_NUM_PAGES * SYZ_PAGE_SIZE &&
+	       addr - SYZ_DATA_OFFSET <= SYZ_NUM_PAGES * SYZ_PAGE_SIZE - size;
+}
+
+static uint64 swap(uint64 v, uint64 size, uint64 bf)
+{
+	if (bf == binary_format_native)
+		return v;
+	switch (size) {
+	case 2:
+		return __builtin_bswap16(v);
+	case 4:
+		return __builtin_bswap32(v);
+	case 8:
+		return __builtin_bswap64(v);
+	}
+	return v;
+}
+
+static void copyin(uint64 addr, uint64 val, uint64 size, uint64 bf, uint64 bf_off, uint64 bf_len)
+{
+	if (bf != binary_format_native && bf != binary_format_bigendian) {
+		fail("unsupported binary format", bf);
+		return;
+	}
+	if ((size != 1 && size != 2 && size != 4 && size != 8) || !in_data_region(addr, size)) {
+		fail("bad copyin", addr);
+		return;
+	}
+	uint64 x = 0;
+	memcpy(&x, (void*)(uintptr_t)addr, size);
+	x = swap(x, size, bf);
+	if (bf_off != 0 || bf_len != 0) {
+		uint64 mask = ((bf_len < 64 ? (1ull << bf_len) : 0) - 1) << bf_off;
+		val = (x & ~mask) | ((val << bf_off) & mask);
+	}
+	val = swap(val, size, bf);
+	memcpy((void*)(uintptr_t)addr, &val, size);
+}
+
+static bool copyout(uint64 addr, uint64 size, uint64* res)
+{
+	if ((size != 1 && size != 2 && size != 4 && size != 8) || !in_data_region(addr, size)) {
+		fail("bad copyout", addr);
+		return false;
+	}
+	*res = 0;
+	memcpy(res, (void*)(uintptr_t)addr, size);
+	return true;
+}
+
+static uint64 read_const_arg(uint64** input_posp, uint64* size_p, uint64* bf_p, uint64* bf_off_p, uint64* bf_len_p)
+{
+	uint64 meta = read_input(input_posp);
+	uint64 val = read_input(input_posp);
+	*size_p = meta & 0xff;
+	*bf_p = (meta >> 8) & 0xff;
+	*bf_off_p = (meta >> 16) & 0xff;
+	*bf_len_p = (meta >> 24) & 0xff;
+	uint64 pid_stride = meta >> 32;
+	return val + pid_stride * procid;
+}
+
+static uint64 read_result(uint64** input_posp)
+{
+	uint64 idx = read_input(input_posp);
+	uint64 op_div = read_input(input_posp);
+	uint64 op_add = read_input(input_posp);
+	uint64 arg = read_input(input_posp);
+	if (idx >= kMaxCommands) {
+		fail("command refers to bad result", idx);
+		return arg;
+	}
+	if (results[idx].executed) {
+		arg = results[idx].val;
+		if (op_div != 0)
+			arg = arg / op_div;
+		arg += op_add;
+	}
+	return arg;
+}
+
+static uint64 read_arg(uint64** input_posp)
+{
+	uint64 typ = read_input(input_posp);
+	if (typ == arg_const) {
+		uint64 size, bf, bf_off, bf_len;
+		uint64 val = read_const_arg(input_posp, &size, &bf, &bf_off, &bf_len);
+		return swap(val, size, bf);
+	}
+	if (typ == arg_result) {
+		read_input(input_posp); // meta
+		return read_result(input_posp);
+	}
+	fail("bad argument type", typ);
+	return 0;
+}
+
+static uint32 hash(uint32 a)
+{
+	a = (a ^ 61) ^ (a >> 16);
+	a = a + (a << 3);
+	a = a ^ (a >> 4);
+	a = a * 0x27d4eb2d;
+	a = a ^ (a >> 15);
+	return a;
+}
+
+#define dedup_table_size 1024
+static uint32 dedup_table[dedup_table_size];
+
+// Poorman's best-effort hashmap-based deduplication, same as in executor.cc.
+static bool dedup(uint32 sig)
+{
+	for (uint32 i = 0; i < 4; i++) {
+		uint32 pos = (sig + i) % dedup_table_size;
+		if (dedup_table[pos] == sig)
+			return true;
+		if (dedup_table[pos] == 0) {
+			dedup_table[pos] = sig;
+			return false;
+		}
+	}
+	dedup_table[sig % dedup_table_size] = sig;
+	return false;
+}
+
+static int compare_pcs(const void* a, const void* b)
+{
+	uint32 x = *(const uint32*)a, y = *(const uint32*)b;
+	return x < y ? -1 : x > y;
+}
+
+// Signal is written in place of the collected PCs, PCs are appended after it,
+// so the buffer is twice the max number of PCs.
+static uint32 output_data[2 * CONFIG_SYZ_COVER_SIZE];
+
+static void write_call_output(uint32 call_index, uint32 call_num, bool finished, uint32 reserrno)
+{
+	struct call_reply reply = {};
+	reply.header.magic = kOutMagic;
+	reply.call_index = call_index;
+	reply.call_num = call_num;
+	reply.reserrno = finished ? reserrno : 999;
+	reply.flags = call_flag_executed | (finished ? call_flag_finished : 0);
+	uint32 nout = 0;
+	if (flag_cover) {
+		uint32 ncover = syz_cover_size;
+		uint32 prev = 0;
+		for (uint32 i = 0; i < ncover; i++) {
+			uint32 pc = syz_cover_data[i];
+			uint32 sig = pc ^ prev;
+			prev = hash(pc);
+			if (dedup(sig))
+				continue;
+			output_data[nout++] = sig;
+		}
+		reply.signal_size = nout;
+		if (flag_collect_cover) {
+			if (flag_dedup_cover) {
+				qsort(syz_cover_data, ncover, sizeof(syz_cover_data[0]), compare_pcs);
+				uint32 n = 0;
+				for (uint32 i = 0; i < ncover; i++) {
+					if (n == 0 || syz_cover_data[n - 1] != syz_cover_data[i])
+						syz_cover_data[n++] = syz_cover_data[i];
+				}
+				ncover = n;
+			}
+			memcpy(&output_data[nout], syz_cover_data, ncover * sizeof(uint32));
+			nout += ncover;
+			reply.cover_size = ncover;
+		}
+	}
+	serial_write(&reply, sizeof(reply));
+	serial_write(output_data, nout * sizeof(uint32));
+}
+
+static void worker(void* p1, void* p2, void* p3)
+{
+	struct worker_call* c = p1;
+	c->res = c->fn(c->args[0], c->args[1], c->args[2], c->args[3], c->args[4],
+		       c->args[5], c->args[6], c->args[7], c->args[8]);
+	c->done = true;
+}
+
+// execute_call runs the call in a fresh user mode thread and waits for it.
+// Returns false if the call did not finish within the timeout.
+static bool execute_call(const call_t* call, uint64* args, intptr_t* res, uint32* reserrno)
+{
+	memset(&current_call, 0, sizeof(current_call));
+	current_call.fn = call->call;
+	for (int i = 0; i < kMaxArgs; i++)
+		current_call.args[i] = (intptr_t)args[i];
+	k_tid_t tid = k_thread_create(&worker_thread, worker_stack, K_THREAD_STACK_SIZEOF(worker_stack),
+				      worker, &current_call, NULL, NULL,
+				      K_LOWEST_APPLICATION_THREAD_PRIO, K_USER, K_FOREVER);
+	k_thread_name_set(tid, "syz-worker");
+	worker_running = true;
+	if (flag_cover)
+		syz_cover_enable(tid);
+	k_thread_start(tid);
+	// Note: sys knows about this timeout when it generates timeout values.
+	int err = k_thread_join(tid, K_MSEC(45 * slowdown_scale));
+	if (err) {
+		k_thread_abort(tid);
+		k_thread_join(tid, K_FOREVER);
+	}
+	syz_cover_disable();
+	worker_running = false;
+	if (err)
+		return false;
+	*reserrno = 0;
+	*res = current_call.res;
+	if (!current_call.done) {
+		// The thread was killed by the kernel for passing bad arguments.
+		*reserrno = EFAULT;
+		*res = -1;
+	} else if (*res < 0 && *res > -4096 && strncmp(call->name, "k_object_alloc", 14) != 0) {
+		*reserrno = -*res;
+		*res = -1;
+	}
+	if (*res != -1 && *res != 0 && strncmp(call->name, "k_object_alloc", 14) == 0) {
+		// The object belongs to the thread that allocated it,
+		// make it accessible to the subsequent calls.
+		k_object_access_all_grant((void*)*res);
+	}
+	return true;
+}
+
+static void copyout_call_results(uint64 copyout_index, intptr_t res, uint64* copyout_pos)
+{
+	if (copyout_index != no_copyout) {
+		if (copyout_index >= kMaxCommands) {
+			fail("result idx overflows kMaxCommands", copyout_index);
+			return;
+		}
+		results[copyout_index].executed = true;
+		results[copyout_index].val = res;
+	}
+	for (;;) {
+		uint64* pos = copyout_pos;
+		if (read_input(&pos) != instr_copyout)
+			break;
+		copyout_pos = pos;
+		uint64 index = read_input(&copyout_pos);
+		uint64 addr = read_input(&copyout_pos);
+		uint64 size = read_input(&copyout_pos);
+		if (index >= kMaxCommands) {
+			fail("result idx overflows kMaxCommands", index);
+			return;
+		}
+		uint64 val = 0;
+		if (copyout(addr, size, &val)) {
+			results[index].executed = true;
+			results[index].val = val;
+		}
+	}
+}
+
+static void execute_one(void)
+{
+	uint64* input_pos = input_data;
+	int call_index = 0;
+	memset(results, 0, sizeof(results));
+	for (;;) {
+		uint64 call_num = read_input(&input_pos);
+		if (call_num == instr_eof)
+			break;
+		if (call_num == instr_copyin) {
+			uint64 addr = read_input(&input_pos);
+			uint64 typ = read_input(&input_pos);
+			if (typ == arg_const) {
+				uint64 size, bf, bf_off, bf_len;
+				uint64 arg = read_const_arg(&input_pos, &size, &bf, &bf_off, &bf_len);
+				copyin(addr, arg, size, bf, bf_off, bf_len);
+			} else if (typ == arg_result) {
+				uint64 meta = read_input(&input_pos);
+				uint64 val = read_result(&input_pos);
+				copyin(addr, val, meta & 0xff, meta >> 8, 0, 0);
+			} else if (typ == arg_data) {
+				uint64 size = read_input(&input_pos);
+				size &= ~(1ull << 63); // readable flag
+				if (!in_data_region(addr, size) || size > sizeof(input_data) ||
+				    (char*)input_pos + size > (char*)input_data + sizeof(input_data)) {
+					fail("bad data copyin", addr);
+					break;
+				}
+				memcpy((void*)(uintptr_t)addr, input_pos, size);
+				input_pos += (size + 7) / 8;
+			} else {
+				// Checksums are not used in zephyr descriptions.
+				fail("bad argument type", typ);
+			}
+			continue;
+		}
+		if (call_num == instr_copyout) {
+			read_input(&input_pos); // index
+			read_input(&input_pos); // addr
+			read_input(&input_pos); // size
+			// The copyout will happen when/if the call completes.
+			continue;
+		}
+		if (call_num >= ARRAY_SIZE(syscalls)) {
+			fail("invalid command number", call_num);
+			break;
+		}
+		uint64 copyout_index = read_input(&input_pos);
+		uint64 num_args = read_input(&input_pos);
+		if (num_args > kMaxArgs) {
+			fail("command has bad number of arguments", num_args);
+			break;
+		}
+		uint64 args[kMaxArgs] = {};
+		for (uint64 i = 0; i < num_args; i++)
+			args[i] = read_arg(&input_pos);
+		if (failed)
+			break;
+		intptr_t res = -1;
+		uint32 reserrno = 0;
+		bool finished = execute_call(&syscalls[call_num], args, &res, &reserrno);
+		if (finished && res != -1)
+			copyout_call_results(copyout_index, res, input_pos);
+		write_call_output(call_index++, call_num, finished, reserrno);
+	}
+}
+
+static void handle_version(void)
+{
+	static const char version[] = GOOS " " GOARCH " " SYZ_REVISION " " GIT_REVISION "\n";
+	serial_write(version, sizeof(version) - 1);
+}
+
+static void handle_handshake(void)
+{
+	// The rest of handshake_req: env flags and pid.
+	uint64 req[2];
+	serial_read(req, sizeof(req));
+	procid = req[1];
+	uint32 magic = kOutMagic;
+	serial_write(&magic, sizeof(magic));
+}
+
+static void handle_execute(void)
+{
+	struct execute_req req;
+	req.magic = kInMagic;
+	serial_read(&req.env_flags, sizeof(req) - sizeof(req.magic));
+	if (req.prog_size > sizeof(input_data)) {
+		printk("SYZFAIL: bad execute prog size 0x%llx\n", req.prog_size);
+		// Can't skip the program reliably, resync on the next request.
+		reply_done(kFailStatus);
+		serial_sync();
+		return;
+	}
+	serial_read(input_data, req.prog_size);
+	memset((char*)input_data + req.prog_size, 0xff, sizeof(input_data) - req.prog_size);
+	procid = req.pid;
+	flag_cover = IS_ENABLED(CONFIG_SYZ_COVERAGE) && (req.env_flags & (1 << 1));
+	flag_collect_cover = req.exec_flags & (1 << 0);
+	flag_dedup_cover = req.exec_flags & (1 << 1);
+	slowdown_scale = req.slowdown ? req.slowdown : 1;
+	failed = false;
+	execute_one();
+	reply_done(failed ? kFailStatus : 0);
+}
+
+void k_sys_fatal_error_handler(unsigned int reason, const z_arch_esf_t* esf)
+{
+	if (reason == K_ERR_KERNEL_OOPS && worker_running && k_current_get() == &worker_thread) {
+		// Bad syscall arguments, the kernel aborts the worker thread when we return.
+		return;
+	}
+	printk("Halting system\n");
+	k_fatal_halt(reason);
+}
+
+void main(void)
+{
+	struct k_mem_partition* parts[] = {&data_partition, &syz_partition};
+	k_mem_domain_init(&syz_domain, ARRAY_SIZE(parts), parts);
+	k_mem_domain_add_thread(&syz_domain, k_current_get());
+
+	uart = DEVICE_DT_GET(DT_CHOSEN(syz_executor_uart));
+	if (!device_is_ready(uart)) {
+		printk("SYZFAIL: executor uart is not ready\n");
+		return;
+	}
+	uart_irq_callback_user_data_set(uart, uart_isr, NULL);
+	uart_irq_rx_enable(uart);
+	printk("syz-executor: serving on %s\n", uart->name);
+
+	serial_sync();
+	for (;;) {
+		uint64 magic;
+		serial_read(&magic, sizeof(magic));
+		if (magic == kSyncMagic)
+			continue;
+		if (magic == kVersionMagic) {
+			handle_version();
+			serial_sync();
+			continue;
+		}
+		if (magic != kInMagic) {
+			printk("SYZFAIL: bad request magic 0x%llx\n", magic);
+			serial_sync();
+			continue;
+		}
+		// The first request after sync is the handshake,
+		// all subsequent ones are execute requests.
+		handle_handshake();
+		for (;;) {
+			serial_read(&magic, sizeof(magic));
+			if (magic != kInMagic)
+				break;
+			handle_execute();
+		}
+		if (magic != kSyncMagic) {
+			printk("SYZFAIL: bad execute request magic 0x%llx\n", magic);
+			serial_sync();
+		}
+	}
+}
@@ -74,6 +74,34 @@ func (n *Define) Info() (Pos, string, string) {
 	return n.Pos, tok2str[tokDefine], n.Name.Name
 }
 
+// If, Else and EndIf are conditional compilation directives (@if COND, @else, @endif).
+// Declarations between them are compiled only for kernels that satisfy the condition
+// (see compiler.ResolveConds).
+type If struct {
+	Pos  Pos
+	Cond string
+}
+
+func (n *If) Info() (Pos, string, string) {
+	return n.Pos, tok2str[tokIf], ""
+}
+
+type Else struct {
+	Pos Pos
+}
+
+func (n *Else) Info() (Pos, string, string) {
+	return n.Pos, tok2str[tokElse], ""
+}
+
+type EndIf struct {
+	Pos Pos
+}
+
+func (n *EndIf) Info() (Pos, string, string) {
+	return n.Pos, tok2str[tokEndIf], ""
+}
+
 type Resource struct {
 	Pos    Pos
 	Name   *Ident
@@ -92,6 +120,7 @@ type Call struct {
 	NR       uint64
 	Args     []*Field
 	Ret      *Type
+	Attrs    []*Type
 }
 
 func (n *Call) Info() (Pos, string, string) {
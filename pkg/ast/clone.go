@@ -46,6 +46,25 @@ func (n *Define) Clone() Node {
 	}
 }
 
+func (n *If) Clone() Node {
+	return &If{
+		Pos:  n.Pos,
+		Cond: n.Cond,
+	}
+}
+
+func (n *Else) Clone() Node {
+	return &Else{
+		Pos: n.Pos,
+	}
+}
+
+func (n *EndIf) Clone() Node {
+	return &EndIf{
+		Pos: n.Pos,
+	}
+}
+
 func (n *Resource) Clone() Node {
 	return &Resource{
 		Pos:    n.Pos,
@@ -89,6 +108,7 @@ func (n *Call) Clone() Node {
 		NR:       n.NR,
 		Args:     cloneFields(n.Args),
 		Ret:      ret,
+		Attrs:    cloneTypes(n.Attrs),
 	}
 }
 
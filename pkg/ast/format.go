@@ -74,6 +74,18 @@ func (def *Define) serialize(w io.Writer) {
 	fmt.Fprintf(w, "define %v\t%v\n", def.Name.Name, fmtInt(def.Value))
 }
 
+func (n *If) serialize(w io.Writer) {
+	fmt.Fprintf(w, "@if %v\n", n.Cond)
+}
+
+func (n *Else) serialize(w io.Writer) {
+	fmt.Fprintf(w, "@else\n")
+}
+
+func (n *EndIf) serialize(w io.Writer) {
+	fmt.Fprintf(w, "@endif\n")
+}
+
 func (res *Resource) serialize(w io.Writer) {
 	fmt.Fprintf(w, "resource %v[%v]", res.Name.Name, fmtType(res.Base))
 	for i, v := range res.Values {
@@ -101,6 +113,13 @@ func (c *Call) serialize(w io.Writer) {
 	if c.Ret != nil {
 		fmt.Fprintf(w, " %v", fmtType(c.Ret))
 	}
+	if len(c.Attrs) != 0 {
+		fmt.Fprintf(w, " (")
+		for i, a := range c.Attrs {
+			fmt.Fprintf(w, "%v%v", comma(i, ""), fmtType(a))
+		}
+		fmt.Fprintf(w, ")")
+	}
 	fmt.Fprintf(w, "\n")
 }
 
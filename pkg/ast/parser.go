@@ -126,6 +126,18 @@ func (p *parser) parseTop() Node {
 		return p.parseIncdir()
 	case tokResource:
 		return p.parseResource()
+	case tokIf:
+		n := &If{Pos: p.pos, Cond: p.lit}
+		p.consume(tokIf)
+		return n
+	case tokElse:
+		n := &Else{Pos: p.pos}
+		p.consume(tokElse)
+		return n
+	case tokEndIf:
+		n := &EndIf{Pos: p.pos}
+		p.consume(tokEndIf)
+		return n
 	case tokIdent:
 		name := p.parseIdent()
 		if name.Name == "type" {
@@ -295,9 +307,16 @@ func (p *parser) parseCall(name *Ident) *Call {
 		p.tryConsume(tokComma)
 	}
 	p.consume(tokRParen)
-	if p.tok != tokNewLine {
+	if p.tok != tokNewLine && p.tok != tokLParen {
 		c.Ret = p.parseType()
 	}
+	if p.tryConsume(tokLParen) {
+		c.Attrs = append(c.Attrs, p.parseType())
+		for p.tryConsume(tokComma) {
+			c.Attrs = append(c.Attrs, p.parseType())
+		}
+		p.consume(tokRParen)
+	}
 	return c
 }
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type token int
@@ -22,6 +23,9 @@ const (
 	tokString
 	tokCExpr
 	tokInt
+	tokIf
+	tokElse
+	tokEndIf
 
 	tokNewLine
 	tokLParen
@@ -61,6 +65,9 @@ var tok2str = [...]string{
 	tokString:   "string",
 	tokCExpr:    "CEXPR",
 	tokInt:      "int",
+	tokIf:       "@if",
+	tokElse:     "@else",
+	tokEndIf:    "@endif",
 	tokNewLine:  "NEWLINE",
 	tokEOF:      "EOF",
 }
@@ -147,6 +154,8 @@ func (s *scanner) Scan() (tok token, lit string, pos Pos) {
 		for s.next(); s.ch != '\n'; s.next() {
 		}
 		lit = string(s.data[pos.Off+1 : s.off])
+	case s.ch == '@':
+		tok, lit = s.scanDirective(pos)
 	case s.ch == '"' || s.ch == '<':
 		tok = tokString
 		lit = s.scanStr(pos)
@@ -260,6 +269,30 @@ func (s *scanner) scanIdent(pos Pos) (tok token, lit string) {
 	return
 }
 
+// scanDirective scans conditional compilation directives,
+// condition of @if is the rest of the line (it's parsed by the compiler).
+func (s *scanner) scanDirective(pos Pos) (tok token, lit string) {
+	for s.next(); s.ch >= 'a' && s.ch <= 'z'; s.next() {
+	}
+	switch name := string(s.data[pos.Off+1 : s.off]); name {
+	case "if":
+		tok = tokIf
+		for ; s.ch != '\n'; s.next() {
+		}
+		lit = strings.TrimSpace(string(s.data[pos.Off+3 : s.off]))
+		if lit == "" {
+			s.Error(pos, "@if without condition")
+		}
+	case "else":
+		tok = tokElse
+	case "endif":
+		tok = tokEndIf
+	default:
+		s.Error(pos, "unknown directive @%v", name)
+	}
+	return
+}
+
 func (s *scanner) Error(pos Pos, msg string, args ...interface{}) {
 	s.errors++
 	s.errorHandler(pos, fmt.Sprintf(msg, args...))
@@ -25,6 +25,9 @@ func (n *Comment) Walk(cb func(Node)) {}
 func (n *Ident) Walk(cb func(Node))   {}
 func (n *String) Walk(cb func(Node))  {}
 func (n *Int) Walk(cb func(Node))     {}
+func (n *If) Walk(cb func(Node))      {}
+func (n *Else) Walk(cb func(Node))    {}
+func (n *EndIf) Walk(cb func(Node))   {}
 
 func (n *Include) Walk(cb func(Node)) {
 	cb(n.File)
@@ -68,6 +71,9 @@ func (n *Call) Walk(cb func(Node)) {
 	if n.Ret != nil {
 		cb(n.Ret)
 	}
+	for _, a := range n.Attrs {
+		cb(a)
+	}
 }
 
 func (n *Struct) Walk(cb func(Node)) {
@@ -0,0 +1,391 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package aws provides wrappers around Amazon EC2 APIs.
+// It is assumed that the program itself also runs on EC2 as APIs operate on the current region/subnet.
+// The APIs are invoked with the aws command line tool, credentials are taken from the instance role.
+//
+// See https://docs.aws.amazon.com/cli/latest/reference/ec2/index.html for details.
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+type Context struct {
+	Region         string
+	Instance       string
+	InternalIP     string
+	Subnet         string
+	SecurityGroups []string
+
+	// apiRateGate ticks regularly, preventing us from accidentally making
+	// EC2 API calls too quickly and hitting request throttling.
+	apiRateGate <-chan time.Time
+}
+
+// Instance describes a created EC2 instance.
+type Instance struct {
+	ID string
+	IP string
+}
+
+func NewContext() (*Context, error) {
+	ctx := &Context{
+		apiRateGate: time.NewTicker(time.Second).C,
+	}
+	if _, err := osutil.RunCmd(time.Minute, "", "aws", "--version"); err != nil {
+		return nil, fmt.Errorf("aws command line tool is not available: %v", err)
+	}
+	var err error
+	ctx.Region, err = getMeta("placement/region")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 region: %v", err)
+	}
+	ctx.Instance, err = getMeta("instance-id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 instance id: %v", err)
+	}
+	ctx.InternalIP, err = getMeta("local-ipv4")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 internal IP: %v", err)
+	}
+	mac, err := getMeta("mac")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 mac: %v", err)
+	}
+	ctx.Subnet, err = getMeta("network/interfaces/macs/" + mac + "/subnet-id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 subnet: %v", err)
+	}
+	groups, err := getMeta("network/interfaces/macs/" + mac + "/security-group-ids")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ec2 security groups: %v", err)
+	}
+	ctx.SecurityGroups = strings.Fields(groups)
+	return ctx, nil
+}
+
+// CreateInstance creates an instance with the given name tag and waits until it's running.
+// If spot is set, creates a spot instance, and falls back to an on-demand instance
+// if there is no spot capacity.
+func (ctx *Context) CreateInstance(name, instanceType, image string, spot bool) (*Instance, error) {
+	args := []string{
+		"run-instances",
+		"--image-id", image,
+		"--instance-type", instanceType,
+		"--count", "1",
+		"--subnet-id", ctx.Subnet,
+		"--security-group-ids",
+	}
+	args = append(args, ctx.SecurityGroups...)
+	args = append(args,
+		"--tag-specifications", fmt.Sprintf("ResourceType=instance,Tags=[{Key=Name,Value=%v}]", name),
+	)
+	var res struct {
+		Instances []struct {
+			InstanceID string `json:"InstanceId"`
+		}
+	}
+	if spot {
+		spotArgs := append(args, "--instance-market-options",
+			"MarketType=spot,SpotOptions={SpotInstanceType=one-time,InstanceInterruptionBehavior=terminate}")
+		err := ctx.ec2(&res, spotArgs...)
+		if err != nil && isCapacityError(err) {
+			log.Logf(0, "no spot capacity for %v, creating on-demand instance: %v", name, err)
+			err = ctx.ec2(&res, args...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if err := ctx.ec2(&res, args...); err != nil {
+		return nil, err
+	}
+	if len(res.Instances) != 1 {
+		return nil, fmt.Errorf("run-instances returned %v instances", len(res.Instances))
+	}
+	id := res.Instances[0].InstanceID
+	if err := ctx.ec2(nil, "wait", "instance-running", "--instance-ids", id); err != nil {
+		ctx.DeleteInstance(name, false)
+		return nil, err
+	}
+	inst, err := ctx.describeInstance(id)
+	if err != nil {
+		ctx.DeleteInstance(name, false)
+		return nil, err
+	}
+	return &Instance{ID: id, IP: inst.PrivateIPAddress}, nil
+}
+
+func isCapacityError(err error) bool {
+	for _, code := range []string{"InsufficientInstanceCapacity", "SpotMaxPriceTooLow",
+		"MaxSpotInstanceCountExceeded", "InsufficientCapacity"} {
+		if strings.Contains(err.Error(), code) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteInstance terminates all instances with the given name tag.
+func (ctx *Context) DeleteInstance(name string, wait bool) error {
+	var res struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceID string `json:"InstanceId"`
+			}
+		}
+	}
+	if err := ctx.ec2(&res, "describe-instances",
+		"--filters", "Name=tag:Name,Values="+name,
+		"Name=instance-state-name,Values=pending,running,stopping,stopped"); err != nil {
+		return err
+	}
+	var ids []string
+	for _, resv := range res.Reservations {
+		for _, inst := range resv.Instances {
+			ids = append(ids, inst.InstanceID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := ctx.ec2(nil, append([]string{"terminate-instances", "--instance-ids"}, ids...)...); err != nil {
+		return err
+	}
+	if wait {
+		return ctx.ec2(nil, append([]string{"wait", "instance-terminated", "--instance-ids"}, ids...)...)
+	}
+	return nil
+}
+
+// IsInstanceRunning returns false if the instance was terminated or stopped
+// (e.g. spot instance was reclaimed).
+func (ctx *Context) IsInstanceRunning(id string) bool {
+	inst, err := ctx.describeInstance(id)
+	if err != nil {
+		return false
+	}
+	return inst.State.Name == "running"
+}
+
+type instanceDesc struct {
+	PrivateIPAddress string `json:"PrivateIpAddress"`
+	State            struct {
+		Name string
+	}
+}
+
+func (ctx *Context) describeInstance(id string) (*instanceDesc, error) {
+	var res struct {
+		Reservations []struct {
+			Instances []*instanceDesc
+		}
+	}
+	if err := ctx.ec2(&res, "describe-instances", "--instance-ids", id); err != nil {
+		return nil, err
+	}
+	if len(res.Reservations) != 1 || len(res.Reservations[0].Instances) != 1 {
+		return nil, fmt.Errorf("instance %v not found", id)
+	}
+	return res.Reservations[0].Instances[0], nil
+}
+
+// CreateImage creates an AMI with the given name from a raw disk image uploaded to s3Path
+// (s3://bucket/key), replacing the existing AMI with the same name. Returns the AMI id.
+func (ctx *Context) CreateImage(name, s3Path, arch string) (string, error) {
+	bucket, key, err := splitS3Path(s3Path)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.DeleteImage(name); err != nil {
+		return "", err
+	}
+	var task struct {
+		ImportTaskID string `json:"ImportTaskId"`
+	}
+	if err := ctx.ec2(&task, "import-snapshot", "--description", name,
+		"--disk-container", fmt.Sprintf("Format=raw,UserBucket={S3Bucket=%v,S3Key=%v}", bucket, key)); err != nil {
+		return "", err
+	}
+	snapshot := ""
+	for start := time.Now(); snapshot == ""; {
+		if time.Since(start) > 2*time.Hour {
+			return "", fmt.Errorf("snapshot import timed out")
+		}
+		time.Sleep(30 * time.Second)
+		var res struct {
+			ImportSnapshotTasks []struct {
+				SnapshotTaskDetail struct {
+					Status        string
+					StatusMessage string
+					SnapshotID    string `json:"SnapshotId"`
+				}
+			}
+		}
+		if err := ctx.ec2(&res, "describe-import-snapshot-tasks", "--import-task-ids", task.ImportTaskID); err != nil {
+			return "", err
+		}
+		if len(res.ImportSnapshotTasks) != 1 {
+			return "", fmt.Errorf("import task %v not found", task.ImportTaskID)
+		}
+		detail := res.ImportSnapshotTasks[0].SnapshotTaskDetail
+		switch detail.Status {
+		case "completed":
+			snapshot = detail.SnapshotID
+		case "deleting", "deleted":
+			return "", fmt.Errorf("snapshot import failed: %v", detail.StatusMessage)
+		}
+	}
+	var image struct {
+		ImageID string `json:"ImageId"`
+	}
+	if err := ctx.ec2(&image, "register-image", "--name", name,
+		"--architecture", arch,
+		"--virtualization-type", "hvm",
+		"--ena-support",
+		"--root-device-name", "/dev/xvda",
+		"--block-device-mappings",
+		fmt.Sprintf("DeviceName=/dev/xvda,Ebs={SnapshotId=%v,DeleteOnTermination=true}", snapshot)); err != nil {
+		return "", err
+	}
+	return image.ImageID, nil
+}
+
+// DeleteImage deregisters the AMI with the given name (if any) and deletes its snapshots.
+func (ctx *Context) DeleteImage(name string) error {
+	var res struct {
+		Images []struct {
+			ImageID             string `json:"ImageId"`
+			BlockDeviceMappings []struct {
+				Ebs *struct {
+					SnapshotID string `json:"SnapshotId"`
+				}
+			}
+		}
+	}
+	if err := ctx.ec2(&res, "describe-images", "--owners", "self",
+		"--filters", "Name=name,Values="+name); err != nil {
+		return err
+	}
+	for _, image := range res.Images {
+		if err := ctx.ec2(nil, "deregister-image", "--image-id", image.ImageID); err != nil {
+			return err
+		}
+		for _, bdm := range image.BlockDeviceMappings {
+			if bdm.Ebs != nil && bdm.Ebs.SnapshotID != "" {
+				ctx.ec2(nil, "delete-snapshot", "--snapshot-id", bdm.Ebs.SnapshotID)
+			}
+		}
+	}
+	return nil
+}
+
+// SendSerialConsoleKey authorizes the public key to connect to the serial console
+// of the instance for the next 60 seconds.
+func (ctx *Context) SendSerialConsoleKey(id, pubkeyFile string) error {
+	_, err := ctx.run("ec2-instance-connect", "send-serial-console-ssh-public-key",
+		"--instance-id", id, "--serial-port", "0", "--ssh-public-key", "file://"+pubkeyFile)
+	return err
+}
+
+// ConsoleOutput returns the most recent console output of the instance
+// captured by EC2 (does not require serial console access).
+func (ctx *Context) ConsoleOutput(id string) ([]byte, error) {
+	var res struct {
+		Output string
+	}
+	if err := ctx.ec2(&res, "get-console-output", "--latest", "--instance-id", id); err != nil {
+		return nil, err
+	}
+	return []byte(res.Output), nil
+}
+
+// SerialConsoleAddr returns ssh address of the serial console of the instance.
+func (ctx *Context) SerialConsoleAddr(id string) string {
+	return fmt.Sprintf("%v.port0@serial-console.ec2-instance-connect.%v.aws", id, ctx.Region)
+}
+
+// UploadFile copies the local file to s3Path (s3://bucket/key).
+func (ctx *Context) UploadFile(localFile, s3Path string) error {
+	_, err := osutil.RunCmd(2*time.Hour, "", "aws", "s3", "cp", "--region", ctx.Region,
+		"--only-show-errors", localFile, s3Path)
+	return err
+}
+
+func splitS3Path(s3Path string) (string, string, error) {
+	path := strings.TrimPrefix(s3Path, "s3://")
+	pos := strings.IndexByte(path, '/')
+	if path == s3Path || pos <= 0 || pos == len(path)-1 {
+		return "", "", fmt.Errorf("bad s3 path %q, want s3://bucket/key", s3Path)
+	}
+	return path[:pos], path[pos+1:], nil
+}
+
+func (ctx *Context) ec2(res interface{}, args ...string) error {
+	output, err := ctx.run("ec2", args...)
+	if err != nil || res == nil {
+		return err
+	}
+	if err := json.Unmarshal(output, res); err != nil {
+		return fmt.Errorf("failed to parse aws ec2 %v output: %v", args[0], err)
+	}
+	return nil
+}
+
+func (ctx *Context) run(service string, args ...string) ([]byte, error) {
+	<-ctx.apiRateGate
+	args = append([]string{service}, args...)
+	args = append(args, "--region", ctx.Region, "--output", "json")
+	// Waiters poll for up to 10 minutes.
+	output, err := osutil.RunCmd(15*time.Minute, "", "aws", args...)
+	if err != nil {
+		return nil, fmt.Errorf("aws %v %v failed: %v", service, args[1], err)
+	}
+	return output, nil
+}
+
+func getMeta(path string) (string, error) {
+	// IMDSv2 requires a session token.
+	req, err := http.NewRequest("PUT", "http://169.254.169.254/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	token, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req, err = http.NewRequest("GET", "http://169.254.169.254/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("X-aws-ec2-metadata-token", string(token))
+	resp, err = client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata request failed: %v", resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
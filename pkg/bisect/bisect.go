@@ -4,6 +4,7 @@
 package bisect
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/google/syzkaller/pkg/build"
 	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/kconfig"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/report"
@@ -26,6 +28,9 @@ type Config struct {
 	Syzkaller SyzkallerConfig
 	Repro     ReproConfig
 	Manager   mgrconfig.Config
+	// Retries says how many times a commit is re-tested after failures
+	// that are likely not caused by the commit itself (zero value means no retries).
+	Retries RetryPolicy
 }
 
 type KernelConfig struct {
@@ -36,8 +41,85 @@ type KernelConfig struct {
 	Sysctl    string
 	Config    []byte
 	Userspace string
+	// BaselineConfig is a small config (e.g. defconfig with debugging options and sanitizers).
+	// If set, the config is minimized before bisection: options that are not needed
+	// to reproduce the crash are reverted to the baseline values. Smaller configs build
+	// and boot on old commits more reliably and don't attribute the crash to unrelated commits.
+	BaselineConfig []byte
 }
 
+type RetryPolicy struct {
+	Infra int // failures to build the image, start VMs or run the test
+	Boot  int // most instances failed to boot or failed basic kernel testing
+}
+
+var DefaultRetries = RetryPolicy{Infra: 2, Boot: 1}
+
+// InfraError is returned when the original commit can't be tested due to build/boot/infrastructure
+// problems (as opposed to the crash not being reproduced). Such bisections make sense to retry later.
+type InfraError struct {
+	Verdict string
+}
+
+func (err *InfraError) Error() string {
+	return fmt.Sprintf("failed to test the original commit: %v", err.Verdict)
+}
+
+// Result is the bisection result along with a machine-readable log of all tested commits.
+// Run saves it to bisect.json in DebugDir.
+type Result struct {
+	Fix bool
+	// See Run for the meaning of Commits and Report.
+	Commits    []*vcs.Commit
+	Report     *report.Report `json:"-"`
+	CrashTitle string         `json:",omitempty"`
+	// Minimized kernel config that was used for bisection, nil if the config was not minimized.
+	Config []byte `json:",omitempty"`
+	Steps  []*Step
+	// Set if bisection failed, InfraError is set if it failed due to an InfraError.
+	Error      string `json:",omitempty"`
+	InfraError bool   `json:",omitempty"`
+	TotalTime  time.Duration
+	BuildTime  time.Duration
+	TestTime   time.Duration
+}
+
+// Step describes testing of a single commit.
+type Step struct {
+	Stage    string // StageOriginal, StageConfig, StageRange or StageBisect
+	Commit   string
+	Title    string
+	Compiler string `json:",omitempty"`
+	// Verdict of the last attempt (one of Verdict* constants).
+	// Note: for fix bisection VerdictCrashed means that the commit is "good".
+	Verdict    string
+	Attempts   int
+	Crashed    int    // number of instances that crashed
+	OK         int    // number of instances that did not crash
+	BootFailed int    // number of instances that failed to boot or failed basic testing
+	Failed     int    // number of instances that failed due to other errors
+	CrashTitle string `json:",omitempty"`
+	Error      string `json:",omitempty"`
+	BuildTime  time.Duration
+	TestTime   time.Duration
+}
+
+const (
+	StageOriginal = "original" // the commit the crash was found on
+	StageConfig   = "config"   // config minimization on the original commit
+	StageRange    = "range"    // releases (cause bisection) or HEAD (fix bisection)
+	StageBisect   = "bisect"
+
+	VerdictCrashed    = "crashed"
+	VerdictOK         = "ok"
+	VerdictBuildError = "build error"
+	VerdictBootError  = "boot error"
+	VerdictInfraError = "infra error"
+)
+
+// MaxConfigSteps limits number of commit tests during config minimization.
+const MaxConfigSteps = 8
+
 type SyzkallerConfig struct {
 	Repo         string
 	Commit       string
@@ -56,6 +138,8 @@ type env struct {
 	bisecter  vcs.Bisecter
 	head      *vcs.Commit
 	inst      *instance.Env
+	config    []byte
+	result    *Result
 	numTests  int
 	buildTime time.Duration
 	testTime  time.Duration
@@ -63,32 +147,38 @@ type env struct {
 
 const NumTests = 10 // number of tests we do per commit
 
-// Run does the bisection and returns:
-//  - if bisection is conclusive, the single cause/fix commit
-//    - for cause bisection report is the crash on the cause commit
-//    - for fix bisection report is nil
-//  - if bisection is inconclusive, range of potential cause/fix commits
-//    - report is nil in such case
-//  - if the crash still happens on the oldest release/HEAD (for cause/fix bisection correspondingly),
-//    no commits and the crash report on the oldest release/HEAD
-//  - if the crash is not reproduced on the start commit, an error
-func Run(cfg *Config) ([]*vcs.Commit, *report.Report, error) {
+// Run does the bisection and returns result with commits and report:
+//   - if bisection is conclusive, the single cause/fix commit
+//   - for cause bisection report is the crash on the cause commit
+//   - for fix bisection report is nil
+//   - if bisection is inconclusive, range of potential cause/fix commits
+//   - report is nil in such case
+//   - if the crash still happens on the oldest release/HEAD (for cause/fix bisection correspondingly),
+//     no commits and the crash report on the oldest release/HEAD
+//   - if the crash is not reproduced on the start commit, an error
+//     (InfraError if the commit could not be tested)
+//
+// The result is returned along with an error as well, it contains log of the tested commits.
+func Run(cfg *Config) (*Result, error) {
+	res := &Result{Fix: cfg.Fix}
 	if err := checkConfig(cfg); err != nil {
-		return nil, nil, err
+		return res, err
 	}
 	cfg.Manager.Cover = false // it's not supported somewhere back in time
 	repo, err := vcs.NewRepo(cfg.Manager.TargetOS, cfg.Manager.Type, cfg.Manager.KernelSrc)
 	if err != nil {
-		return nil, nil, err
+		return res, err
 	}
 	bisecter, ok := repo.(vcs.Bisecter)
 	if !ok {
-		return nil, nil, fmt.Errorf("bisection is not implemented for %v", cfg.Manager.TargetOS)
+		return res, fmt.Errorf("bisection is not implemented for %v", cfg.Manager.TargetOS)
 	}
 	env := &env{
 		cfg:      cfg,
 		repo:     repo,
 		bisecter: bisecter,
+		config:   cfg.Kernel.Config,
+		result:   res,
 	}
 	if cfg.Fix {
 		env.log("bisecting fixing commit since %v", cfg.Kernel.Commit)
@@ -97,11 +187,19 @@ func Run(cfg *Config) ([]*vcs.Commit, *report.Report, error) {
 	}
 	start := time.Now()
 	commits, rep, err := env.bisect()
+	res.TotalTime, res.BuildTime, res.TestTime = time.Since(start), env.buildTime, env.testTime
 	env.log("revisions tested: %v, total time: %v (build: %v, test: %v)",
-		env.numTests, time.Since(start), env.buildTime, env.testTime)
+		env.numTests, res.TotalTime, env.buildTime, env.testTime)
+	defer env.saveResult()
 	if err != nil {
 		env.log("error: %v", err)
-		return nil, nil, err
+		res.Error = err.Error()
+		_, res.InfraError = err.(*InfraError)
+		return res, err
+	}
+	res.Commits, res.Report = commits, rep
+	if rep != nil {
+		res.CrashTitle = rep.Title
 	}
 	if len(commits) == 0 {
 		if cfg.Fix {
@@ -110,7 +208,7 @@ func Run(cfg *Config) ([]*vcs.Commit, *report.Report, error) {
 			env.log("the crash already happened on the oldest tested release")
 		}
 		env.log("crash: %v\n%s", rep.Title, rep.Report)
-		return nil, rep, nil
+		return res, nil
 	}
 	what := "bad"
 	if cfg.Fix {
@@ -121,7 +219,7 @@ func Run(cfg *Config) ([]*vcs.Commit, *report.Report, error) {
 		for _, com := range commits {
 			env.log("%v", com.Hash)
 		}
-		return commits, nil, nil
+		return res, nil
 	}
 	com := commits[0]
 	env.log("first %v commit: %v %v", what, com.Hash, com.Title)
@@ -129,7 +227,7 @@ func Run(cfg *Config) ([]*vcs.Commit, *report.Report, error) {
 	if rep != nil {
 		env.log("crash: %v\n%s", rep.Title, rep.Report)
 	}
-	return commits, rep, nil
+	return res, nil
 }
 
 func (env *env) bisect() ([]*vcs.Commit, *report.Report, error) {
@@ -152,12 +250,18 @@ func (env *env) bisect() ([]*vcs.Commit, *report.Report, error) {
 	if _, err := env.repo.CheckoutCommit(cfg.Kernel.Repo, cfg.Kernel.Commit); err != nil {
 		return nil, nil, err
 	}
-	res, _, rep0, err := env.test()
+	res, _, rep0, err := env.test(StageOriginal)
 	if err != nil {
 		return nil, nil, err
 	} else if res != vcs.BisectBad {
+		if verdict := env.lastStep().Verdict; verdict != VerdictOK {
+			return nil, nil, &InfraError{verdict}
+		}
 		return nil, nil, fmt.Errorf("the crash wasn't reproduced on the original commit")
 	}
+	if err := env.minimizeConfig(); err != nil {
+		return nil, nil, err
+	}
 	bad, good, rep1, err := env.commitRange()
 	if err != nil {
 		return nil, nil, err
@@ -168,7 +272,10 @@ func (env *env) bisect() ([]*vcs.Commit, *report.Report, error) {
 	reports := make(map[string]*report.Report)
 	reports[cfg.Kernel.Commit] = rep0
 	commits, err := env.bisecter.Bisect(bad, good, cfg.Trace, func() (vcs.BisectResult, error) {
-		res, com, rep, err := env.test()
+		res, com, rep, err := env.test(StageBisect)
+		if err != nil {
+			return 0, err
+		}
 		reports[com.Hash] = rep
 		if cfg.Fix {
 			if res == vcs.BisectBad {
@@ -198,7 +305,7 @@ func (env *env) commitRangeForFix() (string, string, *report.Report, error) {
 	if _, err := env.repo.SwitchCommit(env.head.Hash); err != nil {
 		return "", "", nil, err
 	}
-	res, _, rep, err := env.test()
+	res, _, rep, err := env.test(StageRange)
 	if err != nil {
 		return "", "", nil, err
 	}
@@ -224,7 +331,7 @@ func (env *env) commitRangeForBug() (string, string, *report.Report, error) {
 		if _, err := env.repo.SwitchCommit(tag); err != nil {
 			return "", "", nil, err
 		}
-		res, _, rep, err := env.test()
+		res, _, rep, err := env.test(StageRange)
 		if err != nil {
 			return "", "", nil, err
 		}
@@ -239,74 +346,181 @@ func (env *env) commitRangeForBug() (string, string, *report.Report, error) {
 	return "", "", lastRep, nil
 }
 
-func (env *env) test() (vcs.BisectResult, *vcs.Commit, *report.Report, error) {
+// minimizeConfig reverts config options that are not needed to reproduce the crash
+// to their baseline values. It first tries the baseline config alone and then halves
+// the set of differing options as long as one of the halves still reproduces the crash.
+func (env *env) minimizeConfig() error {
 	cfg := env.cfg
-	env.numTests++
+	if len(cfg.Kernel.BaselineConfig) == 0 {
+		return nil
+	}
+	keep := kconfig.Diff(cfg.Kernel.BaselineConfig, cfg.Kernel.Config)
+	env.log("minimizing kernel config: %v options differ from the baseline config", len(keep))
+	steps := 0
+	reproduces := func(options [][]byte) (bool, error) {
+		steps++
+		env.config = kconfig.Merge(cfg.Kernel.BaselineConfig, options...)
+		res, _, _, err := env.test(StageConfig)
+		return res == vcs.BisectBad, err
+	}
+	minimized, err := reproduces(nil)
+	if err != nil {
+		return err
+	}
+	if minimized {
+		keep = nil
+	}
+	for !minimized || len(keep) > 1 {
+		found := false
+		half := len(keep) / 2
+		for _, options := range [][][]byte{keep[:half], keep[half:]} {
+			if steps >= MaxConfigSteps || len(keep) < 2 {
+				break
+			}
+			ok, err := reproduces(options)
+			if err != nil {
+				return err
+			}
+			if ok {
+				keep, found, minimized = options, true, true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	if !minimized {
+		env.log("the crash is not reproduced with smaller configs, using the original config")
+		env.config = cfg.Kernel.Config
+		return nil
+	}
+	env.config = kconfig.Merge(cfg.Kernel.BaselineConfig, keep...)
+	env.result.Config = env.config
+	env.log("minimized kernel config: %v options differ from the baseline config", len(keep))
+	for _, opt := range keep {
+		env.log("  %s", opt)
+	}
+	return nil
+}
+
+// test tests the current commit and records the result as a new step.
+// Commits that failed due to infrastructure or boot problems are re-tested according to cfg.Retries.
+func (env *env) test(stage string) (vcs.BisectResult, *vcs.Commit, *report.Report, error) {
 	current, err := env.repo.HeadCommit()
 	if err != nil {
 		return 0, nil, nil, err
 	}
-	bisectEnv, err := env.bisecter.EnvForCommit(current.Hash, cfg.Kernel.Config)
+	step := &Step{
+		Stage:  stage,
+		Commit: current.Hash,
+		Title:  current.Title,
+	}
+	env.result.Steps = append(env.result.Steps, step)
+	for {
+		step.Attempts++
+		res, rep, err := env.testOnce(current, step)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		retries := 0
+		switch step.Verdict {
+		case VerdictInfraError:
+			retries = env.cfg.Retries.Infra
+		case VerdictBootError:
+			retries = env.cfg.Retries.Boot
+		}
+		if step.Attempts > retries {
+			return res, current, rep, nil
+		}
+		env.log("%v, retrying (attempt %v of %v)", step.Verdict, step.Attempts+1, retries+1)
+	}
+}
+
+func (env *env) testOnce(current *vcs.Commit, step *Step) (vcs.BisectResult, *report.Report, error) {
+	cfg := env.cfg
+	env.numTests++
+	step.Crashed, step.OK, step.BootFailed, step.Failed = 0, 0, 0, 0
+	step.CrashTitle, step.Error = "", ""
+	bisectEnv, err := env.bisecter.EnvForCommit(current.Hash, env.config)
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, err
 	}
 	compiler := filepath.Join(cfg.BinDir, bisectEnv.Compiler, "bin", "gcc")
 	compilerID, err := build.CompilerIdentity(compiler)
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, err
 	}
+	step.Compiler = compilerID
 	env.log("testing commit %v with %v", current.Hash, compilerID)
 	buildStart := time.Now()
 	if err := build.Clean(cfg.Manager.TargetOS, cfg.Manager.TargetVMArch,
 		cfg.Manager.Type, cfg.Manager.KernelSrc); err != nil {
-		return 0, nil, nil, fmt.Errorf("kernel clean failed: %v", err)
+		return 0, nil, fmt.Errorf("kernel clean failed: %v", err)
 	}
 	_, err = env.inst.BuildKernel(compiler, cfg.Kernel.Userspace,
 		cfg.Kernel.Cmdline, cfg.Kernel.Sysctl, bisectEnv.KernelConfig)
-	env.buildTime += time.Since(buildStart)
+	buildTime := time.Since(buildStart)
+	env.buildTime += buildTime
+	step.BuildTime += buildTime
 	if err != nil {
+		// Kernel build errors are deterministic, while image creation and other failures may be not.
+		step.Verdict = VerdictInfraError
 		if verr, ok := err.(*osutil.VerboseError); ok {
 			env.log("%v", verr.Title)
 			env.saveDebugFile(current.Hash, 0, verr.Output)
+			step.Error = verr.Title
 		} else if verr, ok := err.(build.KernelBuildError); ok {
 			env.log("%v", verr.Title)
 			env.saveDebugFile(current.Hash, 0, verr.Output)
+			step.Verdict, step.Error = VerdictBuildError, verr.Title
 		} else {
 			env.log("%v", err)
+			step.Error = err.Error()
 		}
-		return vcs.BisectSkip, current, nil, nil
+		return vcs.BisectSkip, nil, nil
 	}
 	testStart := time.Now()
 	results, err := env.inst.Test(NumTests, cfg.Repro.Syz, cfg.Repro.Opts, cfg.Repro.C)
-	env.testTime += time.Since(testStart)
+	testTime := time.Since(testStart)
+	env.testTime += testTime
+	step.TestTime += testTime
 	if err != nil {
 		env.log("failed: %v", err)
-		return vcs.BisectSkip, current, nil, nil
+		step.Verdict, step.Error = VerdictInfraError, err.Error()
+		return vcs.BisectSkip, nil, nil
 	}
-	bad, good, rep := env.processResults(current, results)
+	rep := env.processResults(current, results, step)
 	res := vcs.BisectSkip
-	if bad != 0 {
+	if step.Crashed != 0 {
 		res = vcs.BisectBad
-	} else if NumTests-good-bad > NumTests/3*2 {
+		step.Verdict, step.CrashTitle = VerdictCrashed, rep.Title
+	} else if step.BootFailed+step.Failed > NumTests/3*2 {
 		// More than 2/3 of instances failed with infrastructure error,
 		// can't reliably tell that the commit is good.
 		res = vcs.BisectSkip
-	} else if good != 0 {
+		step.Verdict = VerdictInfraError
+		if step.BootFailed >= step.Failed {
+			step.Verdict = VerdictBootError
+		}
+	} else if step.OK != 0 {
 		res = vcs.BisectGood
+		step.Verdict = VerdictOK
 	}
-	return res, current, rep, nil
+	return res, rep, nil
 }
 
-func (env *env) processResults(current *vcs.Commit, results []error) (bad, good int, rep *report.Report) {
+func (env *env) processResults(current *vcs.Commit, results []error, step *Step) (rep *report.Report) {
 	var verdicts []string
 	for i, res := range results {
 		if res == nil {
-			good++
+			step.OK++
 			verdicts = append(verdicts, "OK")
 			continue
 		}
 		switch err := res.(type) {
 		case *instance.TestError:
+			step.BootFailed++
 			if err.Boot {
 				verdicts = append(verdicts, fmt.Sprintf("boot failed: %v", err))
 			} else {
@@ -318,7 +532,7 @@ func (env *env) processResults(current *vcs.Commit, results []error) (bad, good
 			}
 			env.saveDebugFile(current.Hash, i, output)
 		case *instance.CrashError:
-			bad++
+			step.Crashed++
 			rep = err.Report
 			verdicts = append(verdicts, fmt.Sprintf("crashed: %v", err))
 			output := err.Report.Report
@@ -327,6 +541,7 @@ func (env *env) processResults(current *vcs.Commit, results []error) (bad, good
 			}
 			env.saveDebugFile(current.Hash, i, output)
 		default:
+			step.Failed++
 			verdicts = append(verdicts, fmt.Sprintf("failed: %v", err))
 		}
 	}
@@ -344,6 +559,23 @@ func (env *env) processResults(current *vcs.Commit, results []error) (bad, good
 	return
 }
 
+func (env *env) lastStep() *Step {
+	return env.result.Steps[len(env.result.Steps)-1]
+}
+
+func (env *env) saveResult() {
+	if env.cfg.DebugDir == "" {
+		return
+	}
+	data, err := json.MarshalIndent(env.result, "", "\t")
+	if err != nil {
+		env.log("failed to marshal bisection result: %v", err)
+		return
+	}
+	osutil.MkdirAll(env.cfg.DebugDir)
+	osutil.WriteFile(filepath.Join(env.cfg.DebugDir, "bisect.json"), data)
+}
+
 func (env *env) saveDebugFile(hash string, idx int, data []byte) {
 	if env.cfg.DebugDir == "" || len(data) == 0 {
 		return
@@ -239,6 +239,7 @@ func (comp *compiler) checkTypes() {
 			if n.Ret != nil {
 				comp.checkType(checkCtx{}, n.Ret, checkIsArg|checkIsRet)
 			}
+			comp.checkCallAttrs(n)
 		}
 	}
 }
@@ -279,11 +280,33 @@ func (comp *compiler) checkAttributeValues() {
 							", expect [1, 1<<20]", sz)
 					}
 				}
+				if attr.Ident == "nlattr" {
+					comp.checkNlAttr(n)
+				}
 			}
 		}
 	}
 }
 
+// checkNlAttr checks that the struct has the layout of struct nlattr:
+// nla_len that covers the whole attribute, nla_type and then the payload.
+func (comp *compiler) checkNlAttr(n *ast.Struct) {
+	if len(n.Fields) < 2 {
+		comp.error(n.Pos, "nlattr struct %v must have length and type fields", n.Name.Name)
+		return
+	}
+	lenType := n.Fields[0].Type
+	if lenType.Ident != "len" && lenType.Ident != "bytesize" ||
+		len(lenType.Args) != 2 || lenType.Args[0].Ident != "parent" || len(lenType.Args[0].Colon) != 0 ||
+		lenType.Args[1].Ident != "int16" || len(lenType.Args[1].Colon) != 0 {
+		comp.error(n.Fields[0].Pos, "first field of nlattr struct %v must be len[parent, int16]",
+			n.Name.Name)
+	}
+	if comp.isVarlen(n.Fields[1].Type) {
+		comp.error(n.Fields[1].Pos, "type field of nlattr struct %v has variable size", n.Name.Name)
+	}
+}
+
 func (comp *compiler) checkLenTargets() {
 	warned := make(map[string]bool)
 	for _, decl := range comp.desc.Nodes {
@@ -432,6 +455,9 @@ func (comp *compiler) checkLenTargetRec(t0, t *ast.Type, targets []*ast.Type,
 }
 
 func CollectUnused(desc *ast.Description, target *targets.Target, eh ast.ErrorHandler) ([]ast.Node, error) {
+	if desc = ResolveConds(desc, nil, eh); desc == nil {
+		return nil, errors.New("bad @if directives")
+	}
 	comp := createCompiler(desc, target, eh)
 	comp.typecheck()
 	if comp.errors > 0 {
@@ -731,6 +757,20 @@ func (comp *compiler) checkStruct(ctx checkCtx, n *ast.Struct) {
 	}
 }
 
+func (comp *compiler) checkCallAttrs(n *ast.Call) {
+	for _, attr := range n.Attrs {
+		if unexpected, _, ok := checkTypeKind(attr, kindIdent); !ok {
+			comp.error(attr.Pos, "unexpected %v, expect attribute", unexpected)
+			return
+		}
+		if len(attr.Colon) != 0 {
+			comp.error(attr.Colon[0].Pos, "unexpected ':'")
+			return
+		}
+	}
+	comp.parseCallAttrs(n)
+}
+
 type checkFlags int
 
 const (
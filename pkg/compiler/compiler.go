@@ -7,6 +7,7 @@ package compiler
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,6 +20,8 @@ import (
 // Overview of compilation process:
 // 1. ast.Parse on text file does tokenization and builds AST.
 //    This step catches basic syntax errors. AST contains full debug info.
+// 1.1. ResolveConds (optional) drops declarations under @if directives that don't hold
+//      for the target kernel. Compile resolves the rest for the latest kernel.
 // 2. ExtractConsts as AST returns set of constant identifiers.
 //    This step also does verification of include/incdir/define AST nodes.
 // 3. User translates constants to values.
@@ -76,6 +79,10 @@ func createCompiler(desc *ast.Description, target *targets.Target, eh ast.ErrorH
 
 // Compile compiles sys description.
 func Compile(desc *ast.Description, consts map[string]uint64, target *targets.Target, eh ast.ErrorHandler) *Prog {
+	// Descriptions are compiled for the latest kernel unless @if directives were already resolved.
+	if desc = ResolveConds(desc, nil, eh); desc == nil {
+		return nil
+	}
 	comp := createCompiler(desc.Clone(), target, eh)
 	comp.typecheck()
 	// The subsequent, more complex, checks expect basic validity of the tree,
@@ -195,6 +202,7 @@ func (comp *compiler) parseUnionAttrs(n *ast.Struct) (varlen bool, size uint64)
 
 func (comp *compiler) parseStructAttrs(n *ast.Struct) (packed bool, size, align uint64) {
 	size = sizeUnassigned
+	nlattr := false
 	for _, attr := range n.Attrs {
 		switch {
 		case attr.Ident == "packed":
@@ -224,14 +232,115 @@ func (comp *compiler) parseStructAttrs(n *ast.Struct) (packed bool, size, align
 			align = a
 		case attr.Ident == "size":
 			size = comp.parseSizeAttr(attr)
+		case attr.Ident == "kernel":
+			comp.parseKernelAttr(attr)
+		case attr.Ident == "nlattr":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+			}
+			nlattr = true
 		default:
 			comp.error(attr.Pos, "unknown struct %v attribute %v",
 				n.Name.Name, attr.Ident)
 		}
 	}
+	if nlattr {
+		// Netlink attributes are packed and aligned to NLA_ALIGNTO.
+		packed = true
+		if align == 0 {
+			align = 4
+		}
+	}
 	return
 }
 
+// parseCallAttrs returns kernel requirements of the call specified with the requires attribute
+// (requires["CONFIG_FOO", "/dev/foo"]), or nil if there is no such attribute.
+func (comp *compiler) parseCallAttrs(n *ast.Call) *prog.KernelRequirements {
+	var req *prog.KernelRequirements
+	for _, attr := range n.Attrs {
+		switch attr.Ident {
+		case "requires":
+			if len(attr.Args) == 0 {
+				comp.error(attr.Pos, "%v attribute is expected to have arguments", attr.Ident)
+				continue
+			}
+			if req == nil {
+				req = new(prog.KernelRequirements)
+			}
+			for _, arg := range attr.Args {
+				if unexpected, _, ok := checkTypeKind(arg, kindString); !ok {
+					comp.error(arg.Pos, "unexpected %v, expect config or file name", unexpected)
+					continue
+				}
+				switch {
+				case strings.HasPrefix(arg.String, "/"):
+					req.Files = append(req.Files, arg.String)
+				case kernelConfigRe.MatchString(arg.String):
+					req.Configs = append(req.Configs, arg.String)
+				default:
+					comp.error(arg.Pos, "bad requirement %q, expect CONFIG_* or absolute file name",
+						arg.String)
+				}
+			}
+		default:
+			comp.error(attr.Pos, "unknown syscall %v attribute %v",
+				n.Name.Name, attr.Ident)
+		}
+	}
+	return req
+}
+
+var kernelConfigRe = regexp.MustCompile("^CONFIG_[A-Z0-9_]+$")
+
+func isNlAttrStruct(n *ast.Struct) bool {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "nlattr" {
+			return true
+		}
+	}
+	return false
+}
+
+// structKernelName returns name of the kernel struct specified with the kernel attribute
+// (kernel[name], or just kernel if it's the base name of the struct), or "" if there is no such attribute.
+func (comp *compiler) structKernelName(n *ast.Struct) string {
+	for _, attr := range n.Attrs {
+		if attr.Ident != "kernel" {
+			continue
+		}
+		if name := comp.parseKernelAttr(attr); name != "" {
+			return name
+		}
+		name := n.Name.Name
+		if pos := strings.IndexByte(name, '$'); pos != -1 {
+			name = name[:pos]
+		}
+		return name
+	}
+	return ""
+}
+
+func (comp *compiler) parseKernelAttr(attr *ast.Type) string {
+	if len(attr.Args) == 0 {
+		return ""
+	}
+	if len(attr.Args) != 1 {
+		comp.error(attr.Pos, "%v attribute is expected to have at most 1 argument", attr.Ident)
+		return ""
+	}
+	name := attr.Args[0]
+	if unexpected, _, ok := checkTypeKind(name, kindIdent); !ok {
+		comp.error(name.Pos, "unexpected %v, expect kernel struct name", unexpected)
+		return ""
+	}
+	if len(name.Colon) != 0 || len(name.Args) != 0 {
+		comp.error(name.Pos, "kernel attribute has colon or args")
+		return ""
+	}
+	return name.Ident
+}
+
 func (comp *compiler) parseSizeAttr(attr *ast.Type) uint64 {
 	if len(attr.Args) != 1 {
 		comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
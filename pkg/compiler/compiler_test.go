@@ -243,6 +243,44 @@ s2 {
 	t.Logf("got: %#v", got)
 }
 
+func TestKernelAttr(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[in, s0$bar], c ptr[in, s1], d ptr[in, s2])
+s0 {
+	f0	int8
+} [kernel]
+s0$bar {
+	f0	int8
+} [packed, kernel]
+s1 {
+	f0	int8
+} [kernel[kernel_s1]]
+s2 {
+	f0	int8
+}
+	`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], nil)
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	want := map[string]string{
+		"s0":     "s0",
+		"s0$bar": "s0",
+		"s1":     "kernel_s1",
+		"s2":     "",
+	}
+	for _, s := range p.StructDescs {
+		if got := s.Desc.KernelName; got != want[s.Key.Name] {
+			t.Errorf("struct %v: kernel name %q, want %q", s.Key.Name, got, want[s.Key.Name])
+		}
+	}
+}
+
 func TestCollectUnusedError(t *testing.T) {
 	t.Parallel()
 	const input = `
@@ -0,0 +1,327 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ast"
+)
+
+// Kernel describes the kernel descriptions are compiled for, it's used to resolve @if directives.
+type Kernel struct {
+	// Version is the kernel version (e.g. {5, 10}), nil means the latest kernel:
+	// all KERNEL >= X conditions hold and all KERNEL < X don't.
+	Version []int
+	// Configs maps enabled CONFIG_ options to their values (y, m, numbers or strings),
+	// nil means that all options are enabled.
+	Configs map[string]string
+}
+
+// ParseKernel returns kernel for the version (e.g. 5.10 or v5.10.3) and .config file,
+// both are optional.
+func ParseKernel(version, config string) (*Kernel, error) {
+	kernel := new(Kernel)
+	if version != "" {
+		// Drop prefix and suffix of versions like v5.10.3-rc1.
+		version1 := strings.TrimPrefix(version, "v")
+		if pos := strings.IndexAny(version1, "-+"); pos != -1 {
+			version1 = version1[:pos]
+		}
+		v, err := parseVersion(version1)
+		if err != nil {
+			return nil, fmt.Errorf("bad kernel version %q: %v", version, err)
+		}
+		kernel.Version = v
+	}
+	if config != "" {
+		data, err := ioutil.ReadFile(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kernel config: %v", err)
+		}
+		kernel.Configs = make(map[string]string)
+		for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+			line := strings.TrimSpace(s.Text())
+			eq := strings.IndexByte(line, '=')
+			if !strings.HasPrefix(line, "CONFIG_") || eq == -1 {
+				continue
+			}
+			if val := line[eq+1:]; val != "n" {
+				kernel.Configs[line[:eq]] = val
+			}
+		}
+	}
+	return kernel, nil
+}
+
+// ResolveConds returns the description without @if/@else/@endif directives and without
+// declarations in the branches that are not taken for the kernel (nil means the latest kernel
+// with all options enabled). The returned description shares nodes with desc. Conditions are:
+//
+//	KERNEL op VERSION: op is one of ==, !=, <, <=, >, >= and VERSION is e.g. 5.10
+//	CONFIG_FOO: holds if the option is enabled (set to y, m or a value)
+//
+// combined with !, &&, || and parentheses. Directives can be nested, but can't cross file boundaries.
+func ResolveConds(desc *ast.Description, kernel *Kernel, eh ast.ErrorHandler) *ast.Description {
+	if eh == nil {
+		eh = ast.LoggingHandler
+	}
+	if kernel == nil {
+		kernel = new(Kernel)
+	}
+	type branch struct {
+		pos          ast.Pos
+		parentActive bool
+		cond         bool
+		inElse       bool
+	}
+	var stack []*branch
+	active, errors := true, 0
+	res := &ast.Description{}
+	for _, node := range desc.Nodes {
+		pos, _, _ := node.Info()
+		for len(stack) != 0 && stack[len(stack)-1].pos.File != pos.File {
+			eh(stack[len(stack)-1].pos, "@if without @endif")
+			errors++
+			active = stack[len(stack)-1].parentActive
+			stack = stack[:len(stack)-1]
+		}
+		switch n := node.(type) {
+		case *ast.If:
+			cond, err := evalCond(n.Cond, kernel)
+			if err != nil {
+				eh(n.Pos, fmt.Sprintf("bad condition %q: %v", n.Cond, err))
+				errors++
+			}
+			stack = append(stack, &branch{pos: n.Pos, parentActive: active, cond: cond})
+			active = active && cond
+		case *ast.Else:
+			if len(stack) == 0 || stack[len(stack)-1].inElse {
+				eh(n.Pos, "@else without @if")
+				errors++
+				continue
+			}
+			b := stack[len(stack)-1]
+			b.inElse = true
+			active = b.parentActive && !b.cond
+		case *ast.EndIf:
+			if len(stack) == 0 {
+				eh(n.Pos, "@endif without @if")
+				errors++
+				continue
+			}
+			active = stack[len(stack)-1].parentActive
+			stack = stack[:len(stack)-1]
+		default:
+			if active {
+				res.Nodes = append(res.Nodes, node)
+			}
+		}
+	}
+	for _, b := range stack {
+		eh(b.pos, "@if without @endif")
+		errors++
+	}
+	if errors != 0 {
+		return nil
+	}
+	return res
+}
+
+func evalCond(cond string, kernel *Kernel) (bool, error) {
+	p := &condParser{kernel: kernel}
+	if err := p.tokenize(cond); err != nil {
+		return false, err
+	}
+	res, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if p.pos != len(p.toks) {
+		return false, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return res, nil
+}
+
+type condParser struct {
+	kernel *Kernel
+	toks   []string
+	pos    int
+}
+
+func (p *condParser) tokenize(cond string) error {
+	for i := 0; i < len(cond); {
+		ch := cond[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case isCondIdentChar(ch):
+			// Identifiers and versions.
+			start := i
+			for ; i < len(cond) && (isCondIdentChar(cond[i]) || cond[i] == '.'); i++ {
+			}
+			p.toks = append(p.toks, cond[start:i])
+		default:
+			op := ""
+			for _, op1 := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(cond[i:], op1) {
+					op = op1
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected %q", ch)
+			}
+			p.toks = append(p.toks, op)
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func isCondIdentChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+func (p *condParser) peek() string {
+	if p.pos == len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *condParser) next() (string, error) {
+	if p.pos == len(p.toks) {
+		return "", fmt.Errorf("unexpected end of condition")
+	}
+	p.pos++
+	return p.toks[p.pos-1], nil
+}
+
+func (p *condParser) parseOr() (bool, error) {
+	res, err := p.parseAnd()
+	for err == nil && p.peek() == "||" {
+		p.pos++
+		var v bool
+		v, err = p.parseAnd()
+		res = res || v
+	}
+	return res, err
+}
+
+func (p *condParser) parseAnd() (bool, error) {
+	res, err := p.parseUnary()
+	for err == nil && p.peek() == "&&" {
+		p.pos++
+		var v bool
+		v, err = p.parseUnary()
+		res = res && v
+	}
+	return res, err
+}
+
+func (p *condParser) parseUnary() (bool, error) {
+	tok, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case tok == "!":
+		v, err := p.parseUnary()
+		return !v, err
+	case tok == "(":
+		v, err := p.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if tok, err := p.next(); err != nil || tok != ")" {
+			return false, fmt.Errorf("missing ')'")
+		}
+		return v, nil
+	case tok == "KERNEL":
+		return p.parseVersionCmp()
+	case strings.HasPrefix(tok, "CONFIG_"):
+		if p.kernel.Configs == nil {
+			return true, nil
+		}
+		_, ok := p.kernel.Configs[tok]
+		return ok, nil
+	}
+	return false, fmt.Errorf("unexpected %q, expect KERNEL or CONFIG_", tok)
+}
+
+func (p *condParser) parseVersionCmp() (bool, error) {
+	op, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return false, fmt.Errorf("unexpected %q, expect comparison", op)
+	}
+	tok, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	ver, err := parseVersion(tok)
+	if err != nil {
+		return false, err
+	}
+	cmp := 1 // the latest kernel is newer than any version
+	if p.kernel.Version != nil {
+		cmp = compareVersions(p.kernel.Version, ver)
+	}
+	switch op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func parseVersion(str string) ([]int, error) {
+	var res []int
+	for _, part := range strings.Split(str, ".") {
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad version %q", str)
+		}
+		res = append(res, int(v))
+	}
+	return res, nil
+}
+
+// compareVersions compares versions like 5.4 and 5.4.0 as equal.
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		va, vb := 0, 0
+		if i < len(a) {
+			va = a[i]
+		}
+		if i < len(b) {
+			vb = b[i]
+		}
+		if va != vb {
+			if va < vb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestResolveConds(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0()
+@if KERNEL >= 5.10
+foo$1()
+@if CONFIG_FOO && !(CONFIG_BAR || KERNEL == 6.1)
+foo$2()
+@endif
+@else
+foo$3()
+@endif
+@if KERNEL < 4.19.100 || KERNEL > 6
+foo$4()
+@endif
+`
+	tests := []struct {
+		version string
+		configs map[string]string
+		calls   []string
+	}{
+		{"", nil, []string{"foo$0", "foo$1", "foo$4"}},
+		{"", map[string]string{"CONFIG_FOO": "y"}, []string{"foo$0", "foo$1", "foo$2", "foo$4"}},
+		{"4.19", nil, []string{"foo$0", "foo$3", "foo$4"}},
+		{"4.19.100", nil, []string{"foo$0", "foo$3"}},
+		{"5.10", map[string]string{"CONFIG_FOO": "m"}, []string{"foo$0", "foo$1", "foo$2"}},
+		{"v6.1-rc3", map[string]string{"CONFIG_FOO": "y"}, []string{"foo$0", "foo$1", "foo$4"}},
+		{"6.2", map[string]string{"CONFIG_FOO": "y", "CONFIG_BAR": "y"}, []string{"foo$0", "foo$1", "foo$4"}},
+	}
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	for i, test := range tests {
+		kernel, err := ParseKernel(test.version, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kernel.Configs = test.configs
+		res := ResolveConds(desc, kernel, nil)
+		if res == nil {
+			t.Fatalf("test #%v: failed to resolve", i)
+		}
+		var calls []string
+		for _, node := range res.Nodes {
+			if call, ok := node.(*ast.Call); ok {
+				calls = append(calls, call.Name.Name)
+			}
+		}
+		if !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("test #%v: got calls %v, want %v", i, calls, test.calls)
+		}
+	}
+}
+
+func TestResolveCondsErrors(t *testing.T) {
+	t.Parallel()
+	inputs := map[string]string{
+		"@if KERNEL >= 5\n@else\n@else\n@endif\n": "@else without @if",
+		"@endif\n":                                    "@endif without @if",
+		"@if CONFIG_FOO\nfoo()\n":                     "@if without @endif",
+		"@if KERNEL >= five\n@endif\n":                `bad version "five"`,
+		"@if KERNEL ~ 5\n@endif\n":                    `unexpected '~'`,
+		"@if FOO\n@endif\n":                           `unexpected "FOO", expect KERNEL or CONFIG_`,
+		"@if (CONFIG_FOO\n@endif\n":                   "missing ')'",
+		"@if CONFIG_FOO CONFIG_BAR\n@endif\n":         `unexpected "CONFIG_BAR"`,
+		"@if KERNEL 5.10\n@endif\n":                   `unexpected "5.10", expect comparison`,
+		"@if CONFIG_FOO &&\nfoo()\n@endif\n":          "unexpected end of condition",
+		"@if KERNEL >= 5.10 || !\nfoo()\n@endif\n":    "unexpected end of condition",
+		"@if KERNEL == 5..10 && CONFIG_FOO\n@endif\n": `bad version "5..10"`,
+	}
+	for input, want := range inputs {
+		desc := ast.Parse([]byte(input), "input", nil)
+		if desc == nil {
+			t.Fatalf("failed to parse %q", input)
+		}
+		var errors []string
+		eh := func(pos ast.Pos, msg string) {
+			errors = append(errors, msg)
+		}
+		if ResolveConds(desc, nil, eh) != nil {
+			t.Errorf("no error for %q", input)
+			continue
+		}
+		if len(errors) != 1 || !strings.Contains(errors[0], want) {
+			t.Errorf("input %q: got errors %q, want %q", input, errors, want)
+		}
+	}
+}
+
+func TestResolveCondsFileBoundary(t *testing.T) {
+	t.Parallel()
+	desc0 := ast.Parse([]byte("@if CONFIG_FOO\nfoo$0()\n"), "file0", nil)
+	desc1 := ast.Parse([]byte("@endif\nfoo$1()\n"), "file1", nil)
+	desc := &ast.Description{Nodes: append(desc0.Nodes, desc1.Nodes...)}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, pos.File+": "+msg)
+	}
+	if ResolveConds(desc, nil, eh) != nil {
+		t.Fatal("directives crossing file boundary are not detected")
+	}
+	want := []string{"file0: @if without @endif", "file1: @endif without @if"}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors %q, want %q", errors, want)
+	}
+}
+
+func TestCompileConds(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b flags[foo_flags])
+@if KERNEL >= 5.10
+s0 {
+	f0	int64
+}
+foo_flags = 1, 2, 4
+@else
+s0 {
+	f0	int32
+}
+foo_flags = 1, 2
+@endif
+`
+	dir, err := ioutil.TempDir("", "syz-compiler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, ".config")
+	if err := ioutil.WriteFile(config, []byte("CONFIG_FOO=y\n# CONFIG_BAR is not set\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		version string
+		size    uint64
+	}{
+		{"", 8},
+		{"5.4.0", 4},
+	} {
+		desc := ast.Parse([]byte(input), "input", nil)
+		if desc == nil {
+			t.Fatal("failed to parse")
+		}
+		kernel, err := ParseKernel(test.version, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(kernel.Configs, map[string]string{"CONFIG_FOO": "y"}) {
+			t.Fatalf("bad configs: %v", kernel.Configs)
+		}
+		if desc = ResolveConds(desc, kernel, nil); desc == nil {
+			t.Fatal("failed to resolve")
+		}
+		p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], nil)
+		if p == nil {
+			t.Fatal("failed to compile")
+		}
+		if size := p.StructDescs[0].Desc.Size(); size != test.size {
+			t.Errorf("version %q: struct size %v, want %v", test.version, size, test.size)
+		}
+		if len(p.Syscalls) != 1 || len(p.Syscalls[0].Args) != 2 {
+			t.Fatalf("version %q: bad syscalls %+v", test.version, p.Syscalls)
+		}
+	}
+}
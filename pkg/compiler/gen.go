@@ -78,6 +78,7 @@ func (comp *compiler) genSyscall(n *ast.Call, maxArgs int) *prog.Syscall {
 		MissingArgs: maxArgs - len(n.Args),
 		Args:        comp.genFieldArray(n.Args, prog.DirIn, true),
 		Ret:         ret,
+		Requires:    comp.parseCallAttrs(n),
 	}
 }
 
@@ -203,6 +204,8 @@ func (ctx *structGen) walkStruct(t *prog.StructType) {
 	packed, sizeAttr, alignAttr := comp.parseStructAttrs(structNode)
 	t.Fields = comp.addAlignment(t.Fields, varlen, packed, alignAttr)
 	t.AlignAttr = alignAttr
+	t.KernelName = comp.structKernelName(structNode)
+	t.NlAttr = isNlAttrStruct(structNode)
 	t.TypeSize = 0
 	if !varlen {
 		for _, f := range t.Fields {
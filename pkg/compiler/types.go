@@ -429,23 +429,64 @@ var typeProc = &typeDesc{
 var typeText = &typeDesc{
 	Names:     []string{"text"},
 	CantBeOpt: true,
-	Args:      []namedArg{{Name: "kind", Type: typeArgTextType}},
+	OptArgs:   1,
+	Args:      []namedArg{{Name: "kind", Type: typeArgTextType}, {Name: "profile", Type: typeArgTextProfile}},
+	Check: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		if len(args) > 1 && args[0].Ident == "bpf" {
+			comp.error(args[1].Pos, "bpf text does not support profiles")
+		}
+	},
 	Varlen: func(comp *compiler, t *ast.Type, args []*ast.Type) bool {
 		return true
 	},
 	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
 		base.TypeSize = 0
+		profile := ""
+		if len(args) > 1 {
+			profile = args[1].Ident
+		}
 		return &prog.BufferType{
 			TypeCommon: base.TypeCommon,
 			Kind:       prog.BufferText,
 			Text:       genTextType(args[0]),
+			SubKind:    profile,
 		}
 	},
 }
 
 var typeArgTextType = &typeArg{
 	Kind:  kindIdent,
-	Names: []string{"target", "x86_real", "x86_16", "x86_32", "x86_64", "arm64"},
+	Names: []string{"target", "x86_real", "x86_16", "x86_32", "x86_64", "arm64", "bpf"},
+}
+
+// Guest code profiles: only unprivileged instructions, mostly privileged instructions,
+// mostly pseudo instructions that interact with the hypervisor (MSRs, ports, MMIO, hypercalls).
+var typeArgTextProfile = &typeArg{
+	Kind:  kindIdent,
+	Names: []string{"user", "priv", "io"},
+}
+
+var typeFsImage = &typeDesc{
+	Names:     []string{"fs_image"},
+	CantBeOpt: true,
+	Args:      []namedArg{{Name: "fs", Type: typeArgFsImageType}},
+	Varlen: func(comp *compiler, t *ast.Type, args []*ast.Type) bool {
+		return true
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		base.TypeSize = 0
+		return &prog.BufferType{
+			TypeCommon: base.TypeCommon,
+			Kind:       prog.BufferFsImage,
+			SubKind:    args[0].Ident,
+		}
+	},
+}
+
+// Filesystems with seed images and structure-aware mutation in prog.
+var typeArgFsImageType = &typeArg{
+	Kind:  kindIdent,
+	Names: []string{"ext4", "vfat", "msdos"},
 }
 
 func genTextType(t *ast.Type) prog.TextKind {
@@ -462,6 +503,8 @@ func genTextType(t *ast.Type) prog.TextKind {
 		return prog.TextX86bit64
 	case "arm64":
 		return prog.TextArm64
+	case "bpf":
+		return prog.TextBpf
 	default:
 		panic(fmt.Sprintf("unknown text type %q", t.Ident))
 	}
@@ -894,6 +937,7 @@ func init() {
 		typeCsum,
 		typeProc,
 		typeText,
+		typeFsImage,
 		typeString,
 		typeFmt,
 	}
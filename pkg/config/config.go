@@ -4,6 +4,7 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -29,11 +30,60 @@ func LoadData(data []byte, cfg interface{}) error {
 		return err
 	}
 	if err := json.Unmarshal(data, cfg); err != nil {
-		return fmt.Errorf("failed to parse config file: %v", err)
+		return parseError(data, err)
 	}
 	return nil
 }
 
+// parseError converts json errors into errors that point to the bad place in the config.
+func parseError(data []byte, err error) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		line, col := position(data, e.Offset-1)
+		return fmt.Errorf("failed to parse config file: line %v, column %v: %v", line, col, err)
+	case *json.UnmarshalTypeError:
+		if e.Field == "" {
+			return fmt.Errorf("bad config: want %v, got %v", typeName(e.Type), e.Value)
+		}
+		return fmt.Errorf("bad config param '%v': want %v, got %v",
+			strings.ToLower(e.Field), typeName(e.Type), e.Value)
+	}
+	return fmt.Errorf("failed to parse config file: %v", err)
+}
+
+// position returns 1-based line and column of the byte at offset.
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte{'\n'}) + 1
+	col := len(prefix) - bytes.LastIndexByte(prefix, '\n')
+	return line, col
+}
+
+func typeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+	return typ.String()
+}
+
 func SaveFile(filename string, cfg interface{}) error {
 	data, err := SaveData(cfg)
 	if err != nil {
@@ -79,15 +129,18 @@ func checkUnknownFieldsRec(data []byte, prefix string, typ reflect.Type) error {
 	}
 	f := make(map[string]interface{})
 	if err := json.Unmarshal(data, &f); err != nil {
-		return fmt.Errorf("failed to parse config file: %v", err)
+		return parseError(data, err)
 	}
 	for k, v := range f {
 		field, ok := fields[strings.ToLower(k)]
 		if !ok {
+			if similar := similarField(strings.ToLower(k), fields); similar != "" {
+				return fmt.Errorf("unknown field '%v%v' in config, did you mean '%v%v'?",
+					prefix, k, prefix, similar)
+			}
 			return fmt.Errorf("unknown field '%v%v' in config", prefix, k)
 		}
-		if v != nil && field.Kind() == reflect.Slice &&
-			(field.PkgPath() != "encoding/json" || field.Name() != "RawMessage") {
+		if v != nil && field.Kind() == reflect.Slice && field != rawMessageType {
 			vv := reflect.ValueOf(v)
 			if vv.Type().Kind() != reflect.Slice {
 				return fmt.Errorf("bad json array type '%v%v'", prefix, k)
@@ -107,6 +160,51 @@ func checkUnknownFieldsRec(data []byte, prefix string, typ reflect.Type) error {
 	return nil
 }
 
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// similarField returns the known field name that is most likely meant by the unknown name
+// (e.g. "kernelobj" or "kernel_objs" for "kernel_obj"), or "" if there is no such field.
+func similarField(name string, fields map[string]reflect.Type) string {
+	normalize := func(s string) string {
+		return strings.Replace(strings.Replace(s, "_", "", -1), "-", "", -1)
+	}
+	best, bestDist := "", len(name)/3+1
+	for field := range fields {
+		if normalize(field) == normalize(name) {
+			return field
+		}
+		if dist := editDistance(name, field); dist < bestDist || dist == bestDist && field < best {
+			best, bestDist = field, dist
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cur[j] = prev[j-1]
+			if a[i-1] != b[j-1] {
+				cur[j]++
+			}
+			if cur[j] > prev[j]+1 {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j] > cur[j-1]+1 {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
 func checkUnknownFieldsStruct(val interface{}, prefix string, typ reflect.Type) error {
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
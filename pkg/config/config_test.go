@@ -61,7 +61,22 @@ func TestLoad(t *testing.T) {
 		{
 			`{"foo": 1, "baz": "baz", "bar": "bar"}`,
 			Config{},
-			"unknown field 'baz' in config",
+			"unknown field 'baz' in config, did you mean 'bar'?",
+		},
+		{
+			`{"box": {"aaa": 12, "bb_b": "bbb"}}`,
+			Config{},
+			"unknown field 'box.bb_b' in config, did you mean 'box.bbb'?",
+		},
+		{
+			`{"foo": "42"}`,
+			Config{},
+			"bad config param 'foo': want integer, got string",
+		},
+		{
+			"{\n\t\"foo\": 42\n\t\"bar\": \"bar\"\n}",
+			Config{},
+			"failed to parse config file: line 3, column 2: invalid character '\"' after object key:value pair",
 		},
 		{
 			`{"foo": 1, "box": {"aaa": 12, "bbb": "bbb"}}`,
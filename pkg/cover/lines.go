@@ -0,0 +1,64 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+// Line is a source line that corresponds to a coverage PC.
+type Line struct {
+	Func string
+	File string
+	Line int
+}
+
+// Lines returns source lines (including lines of inlined functions) for each of the coverage pcs.
+// Files are returned as recorded in debug info, use SameFile to compare them with files in crash reports.
+func (rg *ReportGenerator) Lines(pcs []uint64) (map[uint64][]Line, error) {
+	if len(pcs) == 0 {
+		return nil, fmt.Errorf("no coverage data available")
+	}
+	// Coverage PCs are return addresses of __sanitizer_cov_trace_pc calls,
+	// but we need source lines of the calls themselves.
+	prev := make([]uint64, len(pcs))
+	orig := make(map[uint64]uint64, len(pcs))
+	for i, pc := range pcs {
+		prev[i] = PreviousInstructionPC(rg.arch, pc)
+		orig[prev[i]] = pc
+	}
+	symb := symbolizer.NewSymbolizer()
+	defer symb.Close()
+	frames, err := symb.SymbolizeArray(rg.vmlinux, prev)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint64][]Line)
+	for _, frame := range frames {
+		pc, ok := orig[frame.PC]
+		if !ok || frame.Line == 0 {
+			continue
+		}
+		res[pc] = append(res[pc], Line{
+			Func: frame.Func,
+			File: frame.File,
+			Line: frame.Line,
+		})
+	}
+	return res, nil
+}
+
+// SameFile returns true if the file from debug info refers to the file from a crash report.
+// Debug info contains absolute paths on the build machine, while crash reports
+// contain paths relative to the kernel source dir.
+func SameFile(coverFile, reportFile string) bool {
+	if coverFile == reportFile {
+		return true
+	}
+	reportFile = strings.TrimPrefix(reportFile, "./")
+	return strings.HasSuffix(coverFile, "/"+reportFile)
+}
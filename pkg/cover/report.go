@@ -54,42 +54,76 @@ func MakeReportGenerator(vmlinux, srcDir, arch string) (*ReportGenerator, error)
 	return rg, nil
 }
 
+// Do generates an HTML coverage report for pcs (return addresses of the coverage callbacks).
 func (rg *ReportGenerator) Do(w io.Writer, pcs []uint64) error {
+	files, err := rg.prepareFiles(pcs)
+	if err != nil {
+		return err
+	}
+	return rg.generate(w, files)
+}
+
+// DoLCOV is the same as Do, but generates the report in LCOV tracefile format
+// (accepted by genhtml, IDEs and most coverage services).
+func (rg *ReportGenerator) DoLCOV(w io.Writer, pcs []uint64) error {
+	files, err := rg.prepareFiles(pcs)
+	if err != nil {
+		return err
+	}
+	return generateLCOV(w, files)
+}
+
+type fileCoverage struct {
+	name  string // file name relative to the kernel source dir
+	path  string // path to the file on the build machine
+	lines []coverage
+}
+
+func (rg *ReportGenerator) prepareFiles(pcs []uint64) ([]fileCoverage, error) {
 	if len(pcs) == 0 {
-		return fmt.Errorf("no coverage data available")
+		return nil, fmt.Errorf("no coverage data available")
 	}
 	for i, pc := range pcs {
 		pcs[i] = PreviousInstructionPC(rg.arch, pc)
 	}
 	covered, prefix, err := rg.symbolize(pcs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(covered) == 0 {
-		return fmt.Errorf("'%s' does not have debug info (set CONFIG_DEBUG_INFO=y)", rg.vmlinux)
+		return nil, fmt.Errorf("'%s' does not have debug info (set CONFIG_DEBUG_INFO=y)", rg.vmlinux)
 	}
 	uncoveredPCs := rg.uncoveredPcsInFuncs(pcs)
 	uncovered, prefix2, err := rg.symbolize(uncoveredPCs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(uncoveredPCs) != 0 {
 		prefix = combinePrefix(prefix, prefix2)
 	}
-	return rg.generate(w, prefix, covered, uncovered)
-}
-
-func (rg *ReportGenerator) generate(w io.Writer, prefix string, covered, uncovered []symbolizer.Frame) error {
-	var d templateData
-	for f, covered := range fileSet(covered, uncovered) {
+	var files []fileCoverage
+	for f, lines := range fileSet(covered, uncovered) {
 		remain := filepath.Clean(strings.TrimPrefix(f, prefix))
 		if rg.srcDir != "" && !strings.HasPrefix(remain, rg.srcDir) {
 			f = filepath.Join(rg.srcDir, remain)
 		}
-		lines, err := parseFile(f)
+		files = append(files, fileCoverage{
+			name:  remain,
+			path:  f,
+			lines: lines,
+		})
+	}
+	return files, nil
+}
+
+func (rg *ReportGenerator) generate(w io.Writer, files []fileCoverage) error {
+	var d templateData
+	for _, file := range files {
+		lines, err := parseFile(file.path)
 		if err != nil {
 			return err
 		}
+		covered := file.lines
 		coverage := 0
 		var buf bytes.Buffer
 		for i, ln := range lines {
@@ -110,10 +144,9 @@ func (rg *ReportGenerator) generate(w io.Writer, prefix string, covered, uncover
 				buf.Write([]byte{'\n'})
 			}
 		}
-		f = filepath.Clean(remain)
 		d.Files = append(d.Files, &templateFile{
-			ID:       hash.String([]byte(f)),
-			Name:     f,
+			ID:       hash.String([]byte(file.name)),
+			Name:     file.name,
 			Body:     template.HTML(buf.String()),
 			Coverage: coverage,
 		})
@@ -122,6 +155,30 @@ func (rg *ReportGenerator) generate(w io.Writer, prefix string, covered, uncover
 	return coverTemplate.Execute(w, d)
 }
 
+// generateLCOV writes coverage in LCOV tracefile format. We don't know how many times
+// a line was executed, so covered lines have execution count 1.
+func generateLCOV(w io.Writer, files []fileCoverage) error {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "TN:\n")
+	for _, file := range files {
+		fmt.Fprintf(buf, "SF:%v\n", file.path)
+		hit := 0
+		for _, ln := range file.lines {
+			count := 0
+			if ln.covered {
+				count = 1
+				hit++
+			}
+			fmt.Fprintf(buf, "DA:%v,%v\n", ln.line, count)
+		}
+		fmt.Fprintf(buf, "LH:%v\nLF:%v\nend_of_record\n", hit, len(file.lines))
+	}
+	return buf.Flush()
+}
+
 func (rg *ReportGenerator) readSymbols() error {
 	symbols, err := symbolizer.ReadSymbols(rg.vmlinux)
 	if err != nil {
@@ -310,6 +367,25 @@ func fileSet(covered, uncovered []symbolizer.Frame) map[string][]coverage {
 	return res
 }
 
+// NextInstructionPC is the reverse of PreviousInstructionPC: it returns a coverage PC
+// (return address of the coverage callback) for the PC of the callback call instruction.
+func NextInstructionPC(arch string, pc uint64) uint64 {
+	switch arch {
+	case "amd64":
+		return pc + 5
+	case "386":
+		return pc + 1
+	case "arm64":
+		return pc + 4
+	case "arm":
+		return pc + 3
+	case "ppc64le":
+		return pc + 4
+	default:
+		panic("unknown arch")
+	}
+}
+
 func PreviousInstructionPC(arch string, pc uint64) uint64 {
 	switch arch {
 	case "amd64":
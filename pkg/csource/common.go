@@ -97,6 +97,7 @@ func defineList(p, mmapProg *prog.Prog, opts Options) (defines []string) {
 		"SYZ_HANDLE_SEGV":                   opts.HandleSegv,
 		"SYZ_REPRO":                         opts.Repro,
 		"SYZ_TRACE":                         opts.Trace,
+		"SYZ_STRACE":                        opts.Strace,
 		"SYZ_EXECUTOR_USES_SHMEM":           sysTarget.ExecutorUsesShmem,
 		"SYZ_EXECUTOR_USES_FORK_SERVER":     sysTarget.ExecutorUsesForkServer,
 	}
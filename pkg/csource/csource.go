@@ -25,15 +25,16 @@ func Write(p *prog.Prog, opts Options) ([]byte, error) {
 		target:    p.Target,
 		sysTarget: targets.Get(p.Target.OS, p.Target.Arch),
 		calls:     make(map[string]uint64),
+		includes:  make(map[string]bool),
 	}
 
-	calls, vars, err := ctx.generateProgCalls(ctx.p, opts.Trace)
+	calls, vars, err := ctx.generateProgCalls(ctx.p, opts.Trace, opts.Strace)
 	if err != nil {
 		return nil, err
 	}
 
 	mmapProg := p.Target.GenerateUberMmapProg()
-	mmapCalls, _, err := ctx.generateProgCalls(mmapProg, false)
+	mmapCalls, _, err := ctx.generateProgCalls(mmapProg, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +78,9 @@ func Write(p *prog.Prog, opts Options) ([]byte, error) {
 	specialCallTimeouts := map[string]int{
 		"syz_usb_connect":    2000,
 		"syz_usb_disconnect": 200,
+		"syz_9p_connect":     1000,
+		"syz_nfs_connect":    1000,
+		"syz_smb_connect":    1000,
 	}
 	timeoutExpr := "45"
 	for i, call := range p.Calls {
@@ -101,13 +105,14 @@ type context struct {
 	target    *prog.Target
 	sysTarget *targets.Target
 	calls     map[string]uint64 // CallName -> NR
+	includes  map[string]bool   // additional includes required by libc wrappers
 }
 
 func (ctx *context) generateSyscalls(calls []string, hasVars bool) string {
 	opts := ctx.opts
 	buf := new(bytes.Buffer)
 	if !opts.Threaded && !opts.Collide {
-		if hasVars || opts.Trace {
+		if hasVars || opts.Trace || opts.Strace {
 			fmt.Fprintf(buf, "\tintptr_t res = 0;\n")
 		}
 		if opts.Repro {
@@ -120,7 +125,7 @@ func (ctx *context) generateSyscalls(calls []string, hasVars bool) string {
 			fmt.Fprintf(buf, "%s", c)
 		}
 	} else {
-		if hasVars || opts.Trace {
+		if hasVars || opts.Trace || opts.Strace {
 			fmt.Fprintf(buf, "\tintptr_t res;")
 		}
 		fmt.Fprintf(buf, "\tswitch (call) {\n")
@@ -161,7 +166,7 @@ func (ctx *context) generateSyscallDefines() string {
 	return buf.String()
 }
 
-func (ctx *context) generateProgCalls(p *prog.Prog, trace bool) ([]string, []uint64, error) {
+func (ctx *context) generateProgCalls(p *prog.Prog, trace, strace bool) ([]string, []uint64, error) {
 	exec := make([]byte, prog.ExecBufferSize)
 	progSize, err := p.SerializeForExec(exec)
 	if err != nil {
@@ -171,11 +176,14 @@ func (ctx *context) generateProgCalls(p *prog.Prog, trace bool) ([]string, []uin
 	if err != nil {
 		return nil, nil, err
 	}
-	calls, vars := ctx.generateCalls(decoded, trace)
+	calls, vars := ctx.generateCalls(decoded, trace, strace)
+	if p == ctx.p {
+		calls = ctx.annotateCalls(p, calls)
+	}
 	return calls, vars, nil
 }
 
-func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint64) {
+func (ctx *context) generateCalls(p prog.ExecProg, trace, strace bool) ([]string, []uint64) {
 	var calls []string
 	csumSeq := 0
 	for ci, call := range p.Calls {
@@ -194,12 +202,16 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 		argCopyout := len(call.Copyout) != 0
 		emitCall := ctx.opts.EnableTun ||
 			callName != "syz_emit_ethernet" &&
-				callName != "syz_extract_tcp_res"
+				callName != "syz_extract_tcp_res" &&
+				!strings.HasPrefix(callName, "syz_vtcp_")
 		// TODO: if we don't emit the call we must also not emit copyin, copyout and fault injection.
 		// However, simply skipping whole iteration breaks tests due to unused static functions.
 		if emitCall {
-			ctx.emitCall(w, call, ci, resCopyout || argCopyout, trace)
-		} else if trace {
+			ctx.emitCall(w, call, ci, resCopyout || argCopyout, trace, strace)
+		} else if resCopyout || argCopyout {
+			// Pretend that the call has failed, so that copyout does not use garbage.
+			fmt.Fprintf(w, "\tres = -1;\n")
+		} else if trace || strace {
 			fmt.Fprintf(w, "\t(void)res;\n")
 		}
 
@@ -212,44 +224,59 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 	return calls, p.Vars
 }
 
-func (ctx *context) emitCall(w *bytes.Buffer, call prog.ExecCall, ci int, haveCopyout, trace bool) {
+func (ctx *context) emitCall(w *bytes.Buffer, call prog.ExecCall, ci int, haveCopyout, trace, strace bool) {
 	callName := call.Meta.CallName
 	native := ctx.sysTarget.SyscallNumbers && !strings.HasPrefix(callName, "syz_")
+	wrapper := ctx.libcWrapper(call, native)
+	if wrapper != nil {
+		native = false
+		ctx.includes[wrapper.include] = true
+	}
 	fmt.Fprintf(w, "\t")
-	if haveCopyout || trace {
+	if haveCopyout || trace || strace {
 		fmt.Fprintf(w, "res = ")
 	}
-	ctx.emitCallName(w, call, native)
+	var straceArgs []string
+	ctx.emitCallName(w, call, native, wrapper != nil)
 	for ai, arg := range call.Args {
 		if native || ai > 0 {
 			fmt.Fprintf(w, ", ")
 		}
+		var val string
 		switch arg := arg.(type) {
 		case prog.ExecArgConst:
 			if arg.Format != prog.FormatNative && arg.Format != prog.FormatBigEndian {
 				panic("sring format in syscall argument")
 			}
-			fmt.Fprintf(w, "%v", ctx.constArgToStr(arg, true))
+			val = ctx.constArgToStr(arg, true)
 		case prog.ExecArgResult:
 			if arg.Format != prog.FormatNative && arg.Format != prog.FormatBigEndian {
 				panic("sring format in syscall argument")
 			}
-			val := ctx.resultArgToStr(arg)
+			val = ctx.resultArgToStr(arg)
 			if native && ctx.target.PtrSize == 4 {
 				// syscall accepts args as ellipsis, resources are uint64
 				// and take 2 slots without the cast, which would be wrong.
 				val = "(intptr_t)" + val
 			}
-			fmt.Fprintf(w, "%v", val)
 		default:
 			panic(fmt.Sprintf("unknown arg type: %+v", arg))
 		}
+		straceArgs = append(straceArgs, val)
+		if wrapper != nil {
+			val = wrapper.cast(ai, val)
+		}
+		fmt.Fprintf(w, "%v", val)
 	}
 	for i := 0; i < call.Meta.MissingArgs; i++ {
-		if native || len(call.Args) != 0 {
+		if native || len(call.Args)+i != 0 {
 			fmt.Fprintf(w, ", ")
 		}
-		fmt.Fprintf(w, "0")
+		val := "0"
+		if wrapper != nil {
+			val = wrapper.cast(len(call.Args)+i, val)
+		}
+		fmt.Fprintf(w, "%v", val)
 	}
 	fmt.Fprintf(w, ");")
 	comment := ctx.target.AnnotateCall(call)
@@ -257,22 +284,97 @@ func (ctx *context) emitCall(w *bytes.Buffer, call prog.ExecCall, ci int, haveCo
 		fmt.Fprintf(w, " /* %s */", comment)
 	}
 	fmt.Fprintf(w, "\n")
+	cast := ""
+	if !native && !strings.HasPrefix(callName, "syz_") {
+		// Potentially we casted a function returning int to a function returning intptr_t.
+		// So instead of intptr_t -1 we can get 0x00000000ffffffff. Sign extend it to intptr_t.
+		cast = "(intptr_t)(int)"
+	}
+	if strace {
+		// Must go before trace as fprintf can clobber errno.
+		ctx.emitStrace(w, call, ci, straceArgs, cast)
+	}
 	if trace {
-		cast := ""
-		if !native && !strings.HasPrefix(callName, "syz_") {
-			// Potentially we casted a function returning int to a function returning intptr_t.
-			// So instead of intptr_t -1 we can get 0x00000000ffffffff. Sign extend it to intptr_t.
-			cast = "(intptr_t)(int)"
-		}
 		fmt.Fprintf(w, "\tfprintf(stderr, \"### call=%v errno=%%u\\n\", %vres == -1 ? errno : 0);\n", ci, cast)
 	}
 }
 
-func (ctx *context) emitCallName(w *bytes.Buffer, call prog.ExecCall, native bool) {
+// emitStrace emits strace-style logging of the call with its arguments and result, e.g.:
+//
+//	openat(fd=0xffffffffffffff9c, file=0x20000000 "./file0", flags=0x0, mode=0x0) = 3
+//	close(fd=0x3) = -1 (Bad file descriptor)
+//
+// Arguments are printed at runtime, so that results of previous calls are shown with actual values.
+// Strings are printed as they were written into memory before the call.
+func (ctx *context) emitStrace(w *bytes.Buffer, call prog.ExecCall, ci int, args []string, cast string) {
+	format := new(bytes.Buffer)
+	vals := new(bytes.Buffer)
+	fmt.Fprintf(format, "%v(", call.Meta.Name)
+	for ai, arg := range args {
+		if ai != 0 {
+			fmt.Fprintf(format, ", ")
+		}
+		if ai < len(call.Meta.Args) {
+			fmt.Fprintf(format, "%v=", call.Meta.Args[ai].FieldName())
+		}
+		fmt.Fprintf(format, "0x%%llx")
+		if str, ok := ctx.straceString(ci, ai); ok {
+			fmt.Fprintf(format, " %v", str)
+		}
+		fmt.Fprintf(vals, ", (unsigned long long)(%v)", arg)
+	}
+	fmt.Fprintf(format, ") = %%lld%%s%%s%%s\\n")
+	fmt.Fprintf(w, "\tfprintf(stderr, \"%v\"%v, (long long)%vres, "+
+		"%vres == -1 ? \" (\" : \"\", %vres == -1 ? strerror(errno) : \"\", %vres == -1 ? \")\" : \"\");\n",
+		format, vals, cast, cast, cast, cast)
+}
+
+// straceString returns quoted contents of the string pointed to by argument ai of call ci.
+func (ctx *context) straceString(ci, ai int) (string, bool) {
+	if ci >= len(ctx.p.Calls) || ai >= len(ctx.p.Calls[ci].Args) {
+		return "", false
+	}
+	ptr, ok := ctx.p.Calls[ci].Args[ai].(*prog.PointerArg)
+	if !ok || ptr.Res == nil {
+		return "", false
+	}
+	data, ok := ptr.Res.(*prog.DataArg)
+	if !ok || data.Type().Dir() == prog.DirOut {
+		return "", false
+	}
+	typ, ok := data.Type().(*prog.BufferType)
+	if !ok || typ.Kind != prog.BufferString && typ.Kind != prog.BufferFilename {
+		return "", false
+	}
+	const maxLen = 64
+	str := strings.TrimRight(string(data.Data()), "\x00")
+	buf := new(bytes.Buffer)
+	buf.WriteString(`\"`)
+	for i, c := range []byte(str) {
+		if i == maxLen {
+			buf.WriteString("...")
+			break
+		}
+		switch {
+		case c == '"' || c == '\\':
+			fmt.Fprintf(buf, `\\\%c`, c)
+		case c == '%':
+			buf.WriteString("%%")
+		case c >= 0x20 && c < 0x7f:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(buf, `\\x%02x`, c)
+		}
+	}
+	buf.WriteString(`\"`)
+	return buf.String(), true
+}
+
+func (ctx *context) emitCallName(w *bytes.Buffer, call prog.ExecCall, native, libc bool) {
 	callName := call.Meta.CallName
 	if native {
 		fmt.Fprintf(w, "syscall(%v%v", ctx.sysTarget.SyscallPrefix, callName)
-	} else if strings.HasPrefix(callName, "syz_") {
+	} else if libc || strings.HasPrefix(callName, "syz_") {
 		fmt.Fprintf(w, "%v(", callName)
 	} else {
 		args := strings.Repeat(",intptr_t", len(call.Args))
@@ -438,6 +540,9 @@ func (ctx *context) postProcess(result []byte) []byte {
 	result = regexp.MustCompile(`\t*exitf\((.*\n)*?.*\);\n`).ReplaceAll(result, []byte("\texit(1);\n"))
 	result = regexp.MustCompile(`\t*fail\((.*\n)*?.*\);\n`).ReplaceAll(result, []byte("\texit(1);\n"))
 
+	if ctx.opts.Readable {
+		result = ctx.makeReadable(result)
+	}
 	result = ctx.hoistIncludes(result)
 	result = ctx.removeEmptyLines(result)
 	return result
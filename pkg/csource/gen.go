@@ -33,6 +33,8 @@ func main() {
 		"common_kvm_amd64.h",
 		"common_kvm_arm64.h",
 		"common_usb.h",
+		"common_netfs.h",
+		"common_vnet.h",
 		"kvm.h",
 		"kvm.S.h",
 	} {
@@ -11,6 +11,14 @@ var commonHeader = `
 
 #if GOOS_freebsd || GOOS_test && HOSTGOOS_freebsd
 #include <sys/endian.h>
+#elif GOOS_darwin || GOOS_test && HOSTGOOS_darwin
+#include <libkern/OSByteOrder.h>
+#define htobe16(x) OSSwapHostToBigInt16(x)
+#define htobe32(x) OSSwapHostToBigInt32(x)
+#define htobe64(x) OSSwapHostToBigInt64(x)
+#define htole16(x) OSSwapHostToLittleInt16(x)
+#define htole32(x) OSSwapHostToLittleInt32(x)
+#define htole64(x) OSSwapHostToLittleInt64(x)
 #else
 #include <endian.h>
 #endif
@@ -19,7 +27,7 @@ var commonHeader = `
 #include <stdlib.h>
 #include <string.h>
 
-#if SYZ_TRACE
+#if SYZ_TRACE || SYZ_STRACE
 #include <errno.h>
 #endif
 
@@ -166,7 +174,7 @@ static void use_temporary_dir(void)
 #endif
 #endif
 
-#if GOOS_akaros || GOOS_netbsd || GOOS_freebsd || GOOS_openbsd || GOOS_test
+#if GOOS_akaros || GOOS_netbsd || GOOS_freebsd || GOOS_darwin || GOOS_openbsd || GOOS_test
 #if SYZ_EXECUTOR || SYZ_EXECUTOR_USES_FORK_SERVER && SYZ_REPEAT && SYZ_USE_TMP_DIR
 #include <dirent.h>
 #include <stdio.h>
@@ -248,7 +256,7 @@ static void thread_start(void* (*fn)(void*), void* arg)
 #endif
 #endif
 
-#if GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_akaros || GOOS_test
+#if GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_darwin || GOOS_akaros || GOOS_test
 #if SYZ_EXECUTOR || SYZ_THREADED
 
 #include <pthread.h>
@@ -331,7 +339,7 @@ static int event_timedwait(event_t* ev, uint64 timeout)
 			       (((type)(val) << (bf_off)) & BITMASK((bf_off), (bf_len))))
 #endif
 
-#if SYZ_EXECUTOR || SYZ_USE_CHECKSUMS
+#if SYZ_EXECUTOR || SYZ_USE_CHECKSUMS || SYZ_TUN_ENABLE && (__NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send)
 struct csum_inet {
 	uint32 acc;
 };
@@ -396,7 +404,7 @@ void child()
 }
 #endif
 
-#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd
+#elif GOOS_freebsd || GOOS_netbsd || GOOS_openbsd || GOOS_darwin
 
 #include <unistd.h>
 
@@ -430,6 +438,218 @@ static uintptr_t syz_open_pts(void)
 
 #endif
 
+#if GOOS_darwin
+
+#define __syscall syscall
+
+#include <mach/mach.h>
+#include <mach/mach_time.h>
+#include <mach/mach_vm.h>
+#include <mach/mk_timer.h>
+#include <mach/thread_switch.h>
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_allocate || __NR_syz_mach_port_deallocate ||                            \
+    __NR_syz_mach_port_destroy || __NR_syz_mach_port_mod_refs || __NR_syz_mach_port_insert_right ||            \
+    __NR_syz_mach_port_insert_member || __NR_syz_mach_port_extract_member || __NR_syz_mach_port_move_member || \
+    __NR_syz_mach_port_construct || __NR_syz_mach_port_destruct || __NR_syz_mach_port_guard ||                 \
+    __NR_syz_mach_port_unguard || __NR_syz_mach_port_type || __NR_syz_mach_msg || __NR_syz_mach_vm_allocate || \
+    __NR_syz_mach_vm_deallocate || __NR_syz_mach_vm_protect || __NR_syz_mk_timer_destroy ||                    \
+    __NR_syz_mk_timer_arm || __NR_syz_mk_timer_cancel || __NR_syz_thread_switch || __NR_syz_mach_timebase_info
+#include <errno.h>
+static intptr_t mach_result(kern_return_t kr)
+{
+	if (kr == KERN_SUCCESS)
+		return 0;
+	errno = kr;
+	return -1;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_reply_port
+static intptr_t syz_mach_reply_port()
+{
+	return mach_reply_port();
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_allocate
+static intptr_t syz_mach_port_allocate(intptr_t right, intptr_t name)
+{
+	return mach_result(mach_port_allocate(mach_task_self(), right, (mach_port_name_t*)name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_deallocate
+static intptr_t syz_mach_port_deallocate(intptr_t name)
+{
+	return mach_result(mach_port_deallocate(mach_task_self(), name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_destroy
+static intptr_t syz_mach_port_destroy(intptr_t name)
+{
+	return mach_result(mach_port_destroy(mach_task_self(), name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_mod_refs
+static intptr_t syz_mach_port_mod_refs(intptr_t name, intptr_t right, intptr_t delta)
+{
+	return mach_result(mach_port_mod_refs(mach_task_self(), name, right, delta));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_insert_right
+static intptr_t syz_mach_port_insert_right(intptr_t name, intptr_t poly, intptr_t poly_type)
+{
+	return mach_result(mach_port_insert_right(mach_task_self(), name, poly, poly_type));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_insert_member
+static intptr_t syz_mach_port_insert_member(intptr_t name, intptr_t pset)
+{
+	return mach_result(mach_port_insert_member(mach_task_self(), name, pset));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_extract_member
+static intptr_t syz_mach_port_extract_member(intptr_t name, intptr_t pset)
+{
+	return mach_result(mach_port_extract_member(mach_task_self(), name, pset));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_move_member
+static intptr_t syz_mach_port_move_member(intptr_t member, intptr_t after)
+{
+	return mach_result(mach_port_move_member(mach_task_self(), member, after));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_construct
+static intptr_t syz_mach_port_construct(intptr_t options, intptr_t context, intptr_t name)
+{
+	return mach_result(mach_port_construct(mach_task_self(), (mach_port_options_t*)options, context,
+					       (mach_port_name_t*)name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_destruct
+static intptr_t syz_mach_port_destruct(intptr_t name, intptr_t srdelta, intptr_t guard)
+{
+	return mach_result(mach_port_destruct(mach_task_self(), name, srdelta, guard));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_guard
+static intptr_t syz_mach_port_guard(intptr_t name, intptr_t guard, intptr_t strict)
+{
+	return mach_result(mach_port_guard(mach_task_self(), name, guard, strict));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_unguard
+static intptr_t syz_mach_port_unguard(intptr_t name, intptr_t guard)
+{
+	return mach_result(mach_port_unguard(mach_task_self(), name, guard));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_port_type
+static intptr_t syz_mach_port_type(intptr_t name, intptr_t ptype)
+{
+	return mach_result(mach_port_type(mach_task_self(), name, (mach_port_type_t*)ptype));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_msg
+static intptr_t syz_mach_msg(intptr_t msg, intptr_t option, intptr_t send_size, intptr_t rcv_size, intptr_t rcv_name)
+{
+	const mach_msg_timeout_t timeout = 10;
+	option |= MACH_SEND_TIMEOUT | MACH_RCV_TIMEOUT;
+	if (!(option & MACH_SEND_MSG))
+		send_size = 0;
+	if (!(option & MACH_RCV_MSG))
+		rcv_size = 0;
+	return mach_result(mach_msg((mach_msg_header_t*)msg, option, send_size, rcv_size, rcv_name,
+				    timeout, MACH_PORT_NULL));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_allocate
+static intptr_t syz_mach_vm_allocate(intptr_t addr, intptr_t size, intptr_t flags)
+{
+	return mach_result(mach_vm_allocate(mach_task_self(), (mach_vm_address_t*)addr, size, flags));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_deallocate
+static intptr_t syz_mach_vm_deallocate(intptr_t addr, intptr_t size)
+{
+	return mach_result(mach_vm_deallocate(mach_task_self(), addr, size));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_vm_protect
+static intptr_t syz_mach_vm_protect(intptr_t addr, intptr_t size, intptr_t set_max, intptr_t prot)
+{
+	return mach_result(mach_vm_protect(mach_task_self(), addr, size, set_max, prot));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_create
+static intptr_t syz_mk_timer_create()
+{
+	return mk_timer_create();
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_destroy
+static intptr_t syz_mk_timer_destroy(intptr_t name)
+{
+	return mach_result(mk_timer_destroy(name));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_arm
+static intptr_t syz_mk_timer_arm(intptr_t name, intptr_t expire_time)
+{
+	return mach_result(mk_timer_arm(name, expire_time));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mk_timer_cancel
+static intptr_t syz_mk_timer_cancel(intptr_t name, intptr_t result)
+{
+	return mach_result(mk_timer_cancel(name, (uint64_t*)result));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_thread_switch
+static intptr_t syz_thread_switch(intptr_t option, intptr_t time)
+{
+	return mach_result(thread_switch(MACH_PORT_NULL, option, time));
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_swtch_pri
+static intptr_t syz_swtch_pri(intptr_t pri)
+{
+	return swtch_pri(pri);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_mach_timebase_info
+static intptr_t syz_mach_timebase_info(intptr_t info)
+{
+	return mach_result(mach_timebase_info((mach_timebase_info_t)info));
+}
+#endif
+
+#endif
+
 #if GOOS_freebsd || GOOS_openbsd
 
 #if SYZ_EXECUTOR || SYZ_TUN_ENABLE
@@ -1397,15 +1617,15 @@ static void initialize_tun(void)
 
 	netlink_add_addr4(sock, TUN_IFACE, LOCAL_IPV4);
 	netlink_add_addr6(sock, TUN_IFACE, LOCAL_IPV6);
-	uint64 macaddr = REMOTE_MAC;
+	uint64 macaddr = LOCAL_MAC;
+	netlink_device_change(sock, TUN_IFACE, true, 0, &macaddr, ETH_ALEN);
+	macaddr = REMOTE_MAC;
 	struct in_addr in_addr;
 	inet_pton(AF_INET, REMOTE_IPV4, &in_addr);
 	netlink_add_neigh(sock, TUN_IFACE, &in_addr, sizeof(in_addr), &macaddr, ETH_ALEN);
 	struct in6_addr in6_addr;
 	inet_pton(AF_INET6, REMOTE_IPV6, &in6_addr);
 	netlink_add_neigh(sock, TUN_IFACE, &in6_addr, sizeof(in6_addr), &macaddr, ETH_ALEN);
-	macaddr = LOCAL_MAC;
-	netlink_device_change(sock, TUN_IFACE, true, 0, &macaddr, ETH_ALEN);
 	close(sock);
 }
 #endif
@@ -1429,6 +1649,19 @@ static void initialize_tun(void)
 #define DEV_IPV4 "172.20.20.%d"
 #define DEV_IPV6 "fe80::%02x"
 #define DEV_MAC 0x00aaaaaaaaaa
+#define NETDEV_VIRTUAL (1 << 0)
+#define NETDEV_VETH (1 << 1)
+#define NETDEV_BRIDGE (1 << 2)
+#define NETDEV_BOND (1 << 3)
+#define NETDEV_TEAM (1 << 4)
+#define NETDEV_HSR (1 << 5)
+#define NETDEV_NETDEVSIM (1 << 6)
+
+#if SYZ_EXECUTOR
+#define NETDEV_ENABLED(group) (flag_net_devices & (group))
+#else
+#define NETDEV_ENABLED(group) 1
+#endif
 static void initialize_netdevices(void)
 {
 #if SYZ_EXECUTOR
@@ -1440,21 +1673,29 @@ static void initialize_netdevices(void)
 	struct {
 		const char* type;
 		const char* dev;
+		int group;
 	} devtypes[] = {
-	    {"ip6gretap", "ip6gretap0"},
-	    {"bridge", "bridge0"},
-	    {"vcan", "vcan0"},
-	    {"bond", "bond0"},
-	    {"team", "team0"},
-	    {"dummy", "dummy0"},
-	    {"nlmon", "nlmon0"},
-	    {"caif", "caif0"},
-	    {"batadv", "batadv0"},
-	    {"vxcan", "vxcan1"},
-	    {"netdevsim", netdevsim},
-	    {"veth", 0},
+	    {"ip6gretap", "ip6gretap0", NETDEV_VIRTUAL},
+	    {"bridge", "bridge0", NETDEV_BRIDGE},
+	    {"vcan", "vcan0", NETDEV_VIRTUAL},
+	    {"bond", "bond0", NETDEV_BOND},
+	    {"team", "team0", NETDEV_TEAM},
+	    {"dummy", "dummy0", NETDEV_VIRTUAL},
+	    {"nlmon", "nlmon0", NETDEV_VIRTUAL},
+	    {"caif", "caif0", NETDEV_VIRTUAL},
+	    {"batadv", "batadv0", NETDEV_VIRTUAL},
+	    {"vxcan", "vxcan1", NETDEV_VIRTUAL},
+	    {"netdevsim", netdevsim, NETDEV_NETDEVSIM},
+	    {"veth", 0, NETDEV_VETH},
+	};
+	struct {
+		const char* type;
+		int group;
+	} devmasters[] = {
+	    {"bridge", NETDEV_BRIDGE},
+	    {"bond", NETDEV_BOND},
+	    {"team", NETDEV_TEAM},
 	};
-	const char* devmasters[] = {"bridge", "bond", "team"};
 	struct {
 		const char* name;
 		int macsize;
@@ -1497,27 +1738,33 @@ static void initialize_netdevices(void)
 	if (sock == -1)
 		fail("socket(AF_NETLINK) failed");
 	unsigned i;
-	for (i = 0; i < sizeof(devtypes) / sizeof(devtypes[0]); i++)
-		netlink_add_device(sock, devtypes[i].type, devtypes[i].dev);
+	for (i = 0; i < sizeof(devtypes) / sizeof(devtypes[0]); i++) {
+		if (NETDEV_ENABLED(devtypes[i].group))
+			netlink_add_device(sock, devtypes[i].type, devtypes[i].dev);
+	}
 	for (i = 0; i < sizeof(devmasters) / (sizeof(devmasters[0])); i++) {
+		if (!NETDEV_ENABLED(devmasters[i].group))
+			continue;
 		char master[32], slave0[32], veth0[32], slave1[32], veth1[32];
-		sprintf(slave0, "%s_slave_0", devmasters[i]);
-		sprintf(veth0, "veth0_to_%s", devmasters[i]);
+		sprintf(slave0, "%s_slave_0", devmasters[i].type);
+		sprintf(veth0, "veth0_to_%s", devmasters[i].type);
 		netlink_add_veth(sock, slave0, veth0);
-		sprintf(slave1, "%s_slave_1", devmasters[i]);
-		sprintf(veth1, "veth1_to_%s", devmasters[i]);
+		sprintf(slave1, "%s_slave_1", devmasters[i].type);
+		sprintf(veth1, "veth1_to_%s", devmasters[i].type);
 		netlink_add_veth(sock, slave1, veth1);
-		sprintf(master, "%s0", devmasters[i]);
+		sprintf(master, "%s0", devmasters[i].type);
 		netlink_device_change(sock, slave0, false, master, 0, 0);
 		netlink_device_change(sock, slave1, false, master, 0, 0);
 	}
 	netlink_device_change(sock, "bridge_slave_0", true, 0, 0, 0);
 	netlink_device_change(sock, "bridge_slave_1", true, 0, 0, 0);
-	netlink_add_veth(sock, "hsr_slave_0", "veth0_to_hsr");
-	netlink_add_veth(sock, "hsr_slave_1", "veth1_to_hsr");
-	netlink_add_hsr(sock, "hsr0", "hsr_slave_0", "hsr_slave_1");
-	netlink_device_change(sock, "hsr_slave_0", true, 0, 0, 0);
-	netlink_device_change(sock, "hsr_slave_1", true, 0, 0, 0);
+	if (NETDEV_ENABLED(NETDEV_HSR)) {
+		netlink_add_veth(sock, "hsr_slave_0", "veth0_to_hsr");
+		netlink_add_veth(sock, "hsr_slave_1", "veth1_to_hsr");
+		netlink_add_hsr(sock, "hsr0", "hsr_slave_0", "hsr_slave_1");
+		netlink_device_change(sock, "hsr_slave_0", true, 0, 0, 0);
+		netlink_device_change(sock, "hsr_slave_1", true, 0, 0, 0);
+	}
 
 	for (i = 0; i < sizeof(devices) / (sizeof(devices[0])); i++) {
 		char addr[32];
@@ -1569,7 +1816,7 @@ static void initialize_netdevices_init(void)
 }
 #endif
 
-#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || SYZ_REPEAT)
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || SYZ_REPEAT || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv)
 #include <errno.h>
 
 static int read_tun(char* data, int size)
@@ -1657,7 +1904,7 @@ static void flush_tun()
 }
 #endif
 
-#if SYZ_EXECUTOR || __NR_syz_extract_tcp_res && SYZ_TUN_ENABLE
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_extract_tcp_res || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send)
 #ifndef __ANDROID__
 struct ipv6hdr {
 	__u8 priority : 4,
@@ -1672,7 +1919,9 @@ struct ipv6hdr {
 	struct in6_addr daddr;
 };
 #endif
+#endif
 
+#if SYZ_EXECUTOR || __NR_syz_extract_tcp_res && SYZ_TUN_ENABLE
 struct tcp_resources {
 	uint32 seq;
 	uint32 ack;
@@ -1728,133 +1977,729 @@ static long syz_extract_tcp_res(volatile long a0, volatile long a1, volatile lon
 }
 #endif
 
-#if SYZ_EXECUTOR || __NR_syz_usb_connect
+#if SYZ_EXECUTOR || SYZ_TUN_ENABLE && (__NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send)
+#include <arpa/inet.h>
 #include <errno.h>
 #include <fcntl.h>
-#include <linux/usb/ch9.h>
-#include <stdarg.h>
+#include <linux/if_alg.h>
+#include <net/if.h>
+#include <poll.h>
 #include <stdbool.h>
-#include <stddef.h>
-#include <stdio.h>
-#include <sys/mount.h>
-#include <sys/stat.h>
-#include <sys/types.h>
-
-#define USB_MAX_EP_NUM 32
-
-struct usb_device_index {
-	struct usb_device_descriptor* dev;
-	struct usb_config_descriptor* config;
-	unsigned config_length;
-	struct usb_interface_descriptor* iface;
-	struct usb_endpoint_descriptor* eps[USB_MAX_EP_NUM];
-	unsigned eps_num;
-};
-
-static bool parse_usb_descriptor(char* buffer, size_t length, struct usb_device_index* index)
-{
-	if (length < sizeof(*index->dev) + sizeof(*index->config) + sizeof(*index->iface))
-		return false;
-
-	index->dev = (struct usb_device_descriptor*)buffer;
-	index->config = (struct usb_config_descriptor*)(buffer + sizeof(*index->dev));
-	index->config_length = length - sizeof(*index->dev);
-	index->iface = (struct usb_interface_descriptor*)(buffer + sizeof(*index->dev) + sizeof(*index->config));
-
-	index->eps_num = 0;
-	size_t offset = 0;
-
-	while (true) {
-		if (offset == length)
-			break;
-		if (offset + 1 < length)
-			break;
-		uint8 length = buffer[offset];
-		uint8 type = buffer[offset + 1];
-		if (type == USB_DT_ENDPOINT) {
-			index->eps[index->eps_num] = (struct usb_endpoint_descriptor*)(buffer + offset);
-			index->eps_num++;
-		}
-		if (index->eps_num == USB_MAX_EP_NUM)
-			break;
-		offset += length;
-	}
-
-	return true;
-}
-
-enum usb_fuzzer_event_type {
-	USB_FUZZER_EVENT_INVALID,
-	USB_FUZZER_EVENT_CONNECT,
-	USB_FUZZER_EVENT_DISCONNECT,
-	USB_FUZZER_EVENT_SUSPEND,
-	USB_FUZZER_EVENT_RESUME,
-	USB_FUZZER_EVENT_CONTROL,
-};
-
-struct usb_fuzzer_event {
-	uint32 type;
-	uint32 length;
-	char data[0];
-};
-
-struct usb_fuzzer_init {
-	uint64 speed;
-	const char* driver_name;
-	const char* device_name;
-};
+#include <string.h>
+#include <sys/socket.h>
+#include <unistd.h>
 
-struct usb_fuzzer_ep_io {
-	uint16 ep;
-	uint16 flags;
-	uint32 length;
-	char data[0];
+#define VTCP_MAX_CONNS 8
+#define VTCP_MAX_SEGMENT 1400
+#define VTCP_MAX_OPTIONS 40
+#define VTCP_MAX_PACKETS 32
+#define VTCP_WAIT_MS 20
+
+#define VTCP_FIXUP_PORTS (1 << 0)
+#define VTCP_FIXUP_SEQ (1 << 1)
+#define VTCP_FIXUP_ACK (1 << 2)
+#define VTCP_FIXUP_CSUM (1 << 3)
+
+#define VTCP_FLAG_FIN 0x01
+#define VTCP_FLAG_SYN 0x02
+#define VTCP_FLAG_RST 0x04
+#define VTCP_FLAG_PSH 0x08
+#define VTCP_FLAG_ACK 0x10
+#define VTCP_WINDOW 65535
+
+struct vtcp_conn {
+	bool ipv6;
+	uint16 lport;
+	uint16 rport;
+	uint32 snd_nxt;
+	uint32 rcv_nxt;
+	uint16 tls_version;
+	uint8 tls_key[16];
+	uint8 tls_salt[4];
+	uint8 tls_iv[8];
+	uint64 tls_seq;
 };
 
-#define USB_FUZZER_IOCTL_INIT _IOW('U', 0, struct usb_fuzzer_init)
-#define USB_FUZZER_IOCTL_RUN _IO('U', 1)
-#define USB_FUZZER_IOCTL_EP0_READ _IOWR('U', 2, struct usb_fuzzer_event)
-#define USB_FUZZER_IOCTL_EP0_WRITE _IOW('U', 3, struct usb_fuzzer_ep_io)
-#define USB_FUZZER_IOCTL_EP_ENABLE _IOW('U', 4, struct usb_endpoint_descriptor)
-#define USB_FUZZER_IOCTL_EP_WRITE _IOW('U', 6, struct usb_fuzzer_ep_io)
-#define USB_FUZZER_IOCTL_CONFIGURE _IO('U', 8)
-#define USB_FUZZER_IOCTL_VBUS_DRAW _IOW('U', 9, uint32)
+static struct vtcp_conn vtcp_conns[VTCP_MAX_CONNS];
+static int vtcp_nconns;
 
-int usb_fuzzer_open()
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept
+static long vtcp_add(struct vtcp_conn* conn)
 {
-	return open("/sys/kernel/debug/usb-fuzzer", O_RDWR);
+	int idx = __atomic_fetch_add(&vtcp_nconns, 1, __ATOMIC_SEQ_CST);
+	if (idx >= VTCP_MAX_CONNS) {
+		errno = EMFILE;
+		return -1;
+	}
+	vtcp_conns[idx] = *conn;
+	debug("vtcp: conn %d: ipv6=%d lport=%d rport=%d snd_nxt=%08x rcv_nxt=%08x\n",
+	      idx, conn->ipv6, conn->lport, conn->rport, conn->snd_nxt, conn->rcv_nxt);
+	return idx;
 }
+#endif
 
-int usb_fuzzer_init(int fd, uint32 speed, const char* driver, const char* device)
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send || __NR_syz_vtcp_recv || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send
+static struct vtcp_conn* vtcp_lookup(long idx)
 {
-	struct usb_fuzzer_init arg;
-	arg.speed = speed;
-	arg.driver_name = driver;
-	arg.device_name = device;
-	return ioctl(fd, USB_FUZZER_IOCTL_INIT, &arg);
+	if (idx < 0 || idx >= VTCP_MAX_CONNS || idx >= __atomic_load_n(&vtcp_nconns, __ATOMIC_SEQ_CST)) {
+		errno = EBADF;
+		return NULL;
+	}
+	return &vtcp_conns[idx];
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send
+static int vtcp_emit(struct vtcp_conn* conn, const uint8* seg, uint32 len, bool csum)
+{
+	uint8 frame[sizeof(struct ethhdr) + sizeof(struct ipv6hdr) + VTCP_MAX_SEGMENT];
+	if (len > VTCP_MAX_SEGMENT)
+		len = VTCP_MAX_SEGMENT;
+	struct ethhdr* eth = (struct ethhdr*)frame;
+	uint64 macaddr = LOCAL_MAC;
+	memcpy(eth->h_dest, &macaddr, ETH_ALEN);
+	macaddr = REMOTE_MAC;
+	memcpy(eth->h_source, &macaddr, ETH_ALEN);
+	struct csum_inet pseudo;
+	csum_inet_init(&pseudo);
+	uint32 off = sizeof(struct ethhdr);
+	if (!conn->ipv6) {
+		eth->h_proto = htons(ETH_P_IP);
+		struct iphdr* ip = (struct iphdr*)&frame[off];
+		memset(ip, 0, sizeof(*ip));
+		ip->version = 4;
+		ip->ihl = sizeof(*ip) / 4;
+		ip->tot_len = htons(sizeof(*ip) + len);
+		ip->ttl = 64;
+		ip->protocol = IPPROTO_TCP;
+		inet_pton(AF_INET, REMOTE_IPV4, &ip->saddr);
+		inet_pton(AF_INET, LOCAL_IPV4, &ip->daddr);
+		struct csum_inet csum_ip;
+		csum_inet_init(&csum_ip);
+		csum_inet_update(&csum_ip, (const uint8*)ip, sizeof(*ip));
+		ip->check = csum_inet_digest(&csum_ip);
+		csum_inet_update(&pseudo, (const uint8*)&ip->saddr, 2 * sizeof(ip->saddr));
+		off += sizeof(*ip);
+	} else {
+		eth->h_proto = htons(ETH_P_IPV6);
+		struct ipv6hdr* ip = (struct ipv6hdr*)&frame[off];
+		memset(ip, 0, sizeof(*ip));
+		ip->version = 6;
+		ip->payload_len = htons(len);
+		ip->nexthdr = IPPROTO_TCP;
+		ip->hop_limit = 64;
+		inet_pton(AF_INET6, REMOTE_IPV6, &ip->saddr);
+		inet_pton(AF_INET6, LOCAL_IPV6, &ip->daddr);
+		csum_inet_update(&pseudo, (const uint8*)&ip->saddr, 2 * sizeof(ip->saddr));
+		off += sizeof(*ip);
+	}
+	memcpy(&frame[off], seg, len);
+	if (csum && len >= sizeof(struct tcphdr)) {
+		uint8 rest[4] = {0, IPPROTO_TCP, (uint8)(len >> 8), (uint8)len};
+		csum_inet_update(&pseudo, rest, sizeof(rest));
+		struct tcphdr* tcp = (struct tcphdr*)&frame[off];
+		tcp->check = 0;
+		csum_inet_update(&pseudo, &frame[off], len);
+		tcp->check = csum_inet_digest(&pseudo);
+	}
+	debug_dump_data((const char*)frame, off + len);
+	if (write(tunfd, frame, off + len) != (ssize_t)(off + len))
+		return -1;
+	return 0;
 }
+#endif
 
-int usb_fuzzer_run(int fd)
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept
+static int vtcp_emit_ctl(struct vtcp_conn* conn, uint8 flags, const uint8* opts, uint32 optlen)
 {
-	return ioctl(fd, USB_FUZZER_IOCTL_RUN, 0);
+	uint8 seg[sizeof(struct tcphdr) + VTCP_MAX_OPTIONS];
+	memset(seg, 0, sizeof(seg));
+	if (optlen > VTCP_MAX_OPTIONS)
+		optlen = VTCP_MAX_OPTIONS;
+	if (opts)
+		NONFAILING(memcpy(&seg[sizeof(struct tcphdr)], opts, optlen));
+	optlen = (optlen + 3) & ~3;
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	tcp->source = htons(conn->rport);
+	tcp->dest = htons(conn->lport);
+	tcp->seq = htonl(conn->snd_nxt);
+	if (flags & VTCP_FLAG_ACK)
+		tcp->ack_seq = htonl(conn->rcv_nxt);
+	tcp->doff = (sizeof(struct tcphdr) + optlen) / 4;
+	seg[13] = flags;
+	tcp->window = htons(VTCP_WINDOW);
+	return vtcp_emit(conn, seg, sizeof(struct tcphdr) + optlen, true);
 }
+#endif
 
-int usb_fuzzer_ep0_read(int fd, struct usb_fuzzer_event* event)
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv
+static struct tcphdr* vtcp_parse(struct vtcp_conn* conn, uint8* data, uint32 length, uint32* payload)
 {
-	return ioctl(fd, USB_FUZZER_IOCTL_EP0_READ, event);
-}
-
-int usb_fuzzer_ep0_write(int fd, struct usb_fuzzer_ep_io* io)
+	if (length < sizeof(struct ethhdr))
+		return NULL;
+	struct ethhdr* eth = (struct ethhdr*)data;
+	uint32 off = sizeof(struct ethhdr);
+	uint32 total = 0;
+	if (!conn->ipv6) {
+		if (eth->h_proto != htons(ETH_P_IP) || length < off + sizeof(struct iphdr))
+			return NULL;
+		struct iphdr* ip = (struct iphdr*)&data[off];
+		struct in_addr remote;
+		inet_pton(AF_INET, REMOTE_IPV4, &remote);
+		if (ip->protocol != IPPROTO_TCP || ip->daddr != remote.s_addr ||
+		    ntohs(ip->tot_len) < ip->ihl * 4)
+			return NULL;
+		total = ntohs(ip->tot_len) - ip->ihl * 4;
+		off += ip->ihl * 4;
+	} else {
+		if (eth->h_proto != htons(ETH_P_IPV6) || length < off + sizeof(struct ipv6hdr))
+			return NULL;
+		struct ipv6hdr* ip = (struct ipv6hdr*)&data[off];
+		struct in6_addr remote;
+		inet_pton(AF_INET6, REMOTE_IPV6, &remote);
+		if (ip->nexthdr != IPPROTO_TCP || memcmp(&ip->daddr, &remote, sizeof(remote)) != 0)
+			return NULL;
+		total = ntohs(ip->payload_len);
+		off += sizeof(struct ipv6hdr);
+	}
+	if (length < off + sizeof(struct tcphdr) || total < sizeof(struct tcphdr))
+		return NULL;
+	struct tcphdr* tcp = (struct tcphdr*)&data[off];
+	if (ntohs(tcp->source) != conn->lport || ntohs(tcp->dest) != conn->rport ||
+	    tcp->doff * 4u < sizeof(struct tcphdr) || tcp->doff * 4u > total)
+		return NULL;
+	*payload = total - tcp->doff * 4;
+	return tcp;
+}
+static struct tcphdr* vtcp_receive(struct vtcp_conn* conn, uint8* data, int size, uint32* payload, int wait_ms)
 {
-	return ioctl(fd, USB_FUZZER_IOCTL_EP0_WRITE, io);
+	int i;
+	for (i = 0; i < VTCP_MAX_PACKETS;) {
+		int rv = read_tun((char*)data, size);
+		if (rv == -1) {
+			if (wait_ms <= 0)
+				return NULL;
+			struct pollfd pfd;
+			pfd.fd = tunfd;
+			pfd.events = POLLIN;
+			pfd.revents = 0;
+			poll(&pfd, 1, 1);
+			wait_ms--;
+			continue;
+		}
+		i++;
+		debug_dump_data((const char*)data, rv);
+		struct tcphdr* tcp = vtcp_parse(conn, data, rv, payload);
+		if (tcp)
+			return tcp;
+	}
+	return NULL;
 }
+#endif
 
-int usb_fuzzer_ep_write(int fd, struct usb_fuzzer_ep_io* io)
+#if SYZ_EXECUTOR || __NR_syz_vtcp_accept || __NR_syz_vtcp_recv
+static void vtcp_track(struct vtcp_conn* conn, struct tcphdr* tcp, uint32 payload)
 {
-	return ioctl(fd, USB_FUZZER_IOCTL_EP_WRITE, io);
+	uint32 end = ntohl(tcp->seq) + payload + tcp->syn + tcp->fin;
+	if ((int)(end - conn->rcv_nxt) > 0)
+		conn->rcv_nxt = end;
 }
+#endif
 
-int usb_fuzzer_ep_enable(int fd, struct usb_endpoint_descriptor* desc)
+#if SYZ_EXECUTOR || __NR_syz_vtcp_connect
+static long syz_vtcp_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3, volatile long a4, volatile long a5)
+{
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn conn;
+	memset(&conn, 0, sizeof(conn));
+	conn.ipv6 = a0 == AF_INET6;
+	conn.lport = ntohs((uint16)a1);
+	conn.rport = ntohs((uint16)a2);
+	conn.snd_nxt = (uint32)a3;
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_SYN, (const uint8*)a4, (uint32)a5))
+		return -1;
+	conn.snd_nxt++;
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, VTCP_WAIT_MS);
+		if (tcp == NULL) {
+			errno = ETIMEDOUT;
+			return -1;
+		}
+		if (tcp->rst) {
+			errno = ECONNREFUSED;
+			return -1;
+		}
+		if (tcp->syn && tcp->ack && ntohl(tcp->ack_seq) == conn.snd_nxt) {
+			conn.rcv_nxt = ntohl(tcp->seq) + 1;
+			break;
+		}
+	}
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_ACK, NULL, 0))
+		return -1;
+	return vtcp_add(&conn);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_accept
+static long syz_vtcp_accept(volatile long a0, volatile long a1, volatile long a2, volatile long a3, volatile long a4, volatile long a5)
+{
+	if (tunfd < 0)
+		return -1;
+	int fd = a0;
+	struct vtcp_conn conn;
+	memset(&conn, 0, sizeof(conn));
+	conn.ipv6 = a1 == AF_INET6;
+	conn.rport = ntohs((uint16)a2);
+	struct sockaddr_storage addr;
+	memset(&addr, 0, sizeof(addr));
+	socklen_t addrlen = 0;
+	if (!conn.ipv6) {
+		struct sockaddr_in* sin = (struct sockaddr_in*)&addr;
+		sin->sin_family = AF_INET;
+		sin->sin_port = htons(conn.rport);
+		inet_pton(AF_INET, REMOTE_IPV4, &sin->sin_addr);
+		addrlen = sizeof(*sin);
+	} else {
+		struct sockaddr_in6* sin6 = (struct sockaddr_in6*)&addr;
+		sin6->sin6_family = AF_INET6;
+		sin6->sin6_port = htons(conn.rport);
+		inet_pton(AF_INET6, REMOTE_IPV6, &sin6->sin6_addr);
+		sin6->sin6_scope_id = if_nametoindex(TUN_IFACE);
+		addrlen = sizeof(*sin6);
+	}
+	int flags = fcntl(fd, F_GETFL);
+	if (flags == -1)
+		return -1;
+	fcntl(fd, F_SETFL, flags | O_NONBLOCK);
+	int rv = connect(fd, (struct sockaddr*)&addr, addrlen);
+	int err = errno;
+	fcntl(fd, F_SETFL, flags);
+	if (rv == -1 && err != EINPROGRESS) {
+		errno = err;
+		return -1;
+	}
+	memset(&addr, 0, sizeof(addr));
+	addrlen = sizeof(addr);
+	if (getsockname(fd, (struct sockaddr*)&addr, &addrlen))
+		return -1;
+	conn.lport = ntohs(((struct sockaddr_in*)&addr)->sin_port);
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, VTCP_WAIT_MS);
+		if (tcp == NULL) {
+			errno = ETIMEDOUT;
+			return -1;
+		}
+		if (tcp->syn && !tcp->ack) {
+			conn.rcv_nxt = ntohl(tcp->seq) + 1;
+			break;
+		}
+	}
+	conn.snd_nxt = (uint32)a3;
+	if (vtcp_emit_ctl(&conn, VTCP_FLAG_SYN | VTCP_FLAG_ACK, (const uint8*)a4, (uint32)a5))
+		return -1;
+	conn.snd_nxt++;
+	struct tcphdr* tcp = vtcp_receive(&conn, data, sizeof(data), &payload, 0);
+	if (tcp)
+		vtcp_track(&conn, tcp, payload);
+	return vtcp_add(&conn);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send || __NR_syz_vtcp_tls_send
+static long vtcp_send(struct vtcp_conn* conn, uint32 fixups, uint8* seg, uint32 len)
+{
+	if (len < sizeof(struct tcphdr)) {
+		errno = EINVAL;
+		return -1;
+	}
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	if (fixups & VTCP_FIXUP_PORTS) {
+		tcp->source = htons(conn->rport);
+		tcp->dest = htons(conn->lport);
+	}
+	if (fixups & VTCP_FIXUP_SEQ)
+		tcp->seq = htonl(conn->snd_nxt);
+	if (fixups & VTCP_FIXUP_ACK)
+		tcp->ack_seq = htonl(conn->rcv_nxt);
+	uint32 hdrlen = tcp->doff * 4;
+	if (hdrlen < sizeof(struct tcphdr) || hdrlen > len)
+		hdrlen = len;
+	if (ntohl(tcp->seq) == conn->snd_nxt)
+		conn->snd_nxt += len - hdrlen + tcp->syn + tcp->fin;
+	return vtcp_emit(conn, seg, len, fixups & VTCP_FIXUP_CSUM);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_send
+static long syz_vtcp_send(volatile long a0, volatile long a1, volatile long a2)
+{
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	uint32 len = a2;
+	if (len < sizeof(uint32)) {
+		errno = EINVAL;
+		return -1;
+	}
+	len -= sizeof(uint32);
+	if (len > VTCP_MAX_SEGMENT)
+		len = VTCP_MAX_SEGMENT;
+	uint32 fixups = 0;
+	uint8 seg[VTCP_MAX_SEGMENT];
+	memset(seg, 0, sizeof(seg));
+	NONFAILING(fixups = *(uint32*)a1);
+	NONFAILING(memcpy(seg, (char*)a1 + sizeof(uint32), len));
+	return vtcp_send(conn, fixups, seg, len);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_recv
+static long syz_vtcp_recv(volatile long a0)
+{
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	uint8 data[SYZ_TUN_MAX_PACKET_SIZE];
+	uint32 payload = 0;
+	long total = 0;
+	int wait_ms = VTCP_WAIT_MS;
+	for (;;) {
+		struct tcphdr* tcp = vtcp_receive(conn, data, sizeof(data), &payload, wait_ms);
+		if (tcp == NULL)
+			break;
+		vtcp_track(conn, tcp, payload);
+		total += payload;
+		wait_ms = 0;
+	}
+	return total;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls || __NR_syz_vtcp_tls_send
+#ifndef SOL_TLS
+#define SOL_TLS 282
+#endif
+#ifndef TCP_ULP
+#define TCP_ULP 31
+#endif
+#define VTCP_TLS_TX 1
+#define VTCP_TLS_RX 2
+#define VTCP_TLS_1_3_VERSION 0x0304
+#define VTCP_TLS_MAX_DATA 1024
+#define VTCP_TLS_TAG_SIZE 16
+struct vtcp_tls_info {
+	uint16 version;
+	uint16 cipher_type;
+	uint8 iv[8];
+	uint8 key[16];
+	uint8 salt[4];
+	uint8 rec_seq[8];
+};
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls
+static long syz_vtcp_tls(volatile long a0, volatile long a1, volatile long a2)
+{
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	int fd = a1;
+	struct vtcp_tls_info info;
+	memset(&info, 0, sizeof(info));
+	NONFAILING(memcpy(&info, (void*)a2, sizeof(info)));
+	setsockopt(fd, IPPROTO_TCP, TCP_ULP, "tls", sizeof("tls"));
+	setsockopt(fd, SOL_TLS, VTCP_TLS_TX, &info, sizeof(info));
+	if (setsockopt(fd, SOL_TLS, VTCP_TLS_RX, &info, sizeof(info)))
+		return -1;
+	conn->tls_version = info.version;
+	memcpy(conn->tls_key, info.key, sizeof(conn->tls_key));
+	memcpy(conn->tls_salt, info.salt, sizeof(conn->tls_salt));
+	memcpy(conn->tls_iv, info.iv, sizeof(conn->tls_iv));
+	conn->tls_seq = 0;
+	unsigned i;
+	for (i = 0; i < sizeof(info.rec_seq); i++)
+		conn->tls_seq = (conn->tls_seq << 8) | info.rec_seq[i];
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_vtcp_tls_send
+#ifndef SOL_ALG
+#define SOL_ALG 279
+#endif
+static int vtcp_aead_encrypt(const uint8* key, const uint8* nonce, const uint8* aad, uint32 aadlen,
+			     const uint8* data, uint32 len, uint8* out)
+{
+	int tfm = socket(AF_ALG, SOCK_SEQPACKET, 0);
+	if (tfm == -1)
+		return -1;
+	struct sockaddr_alg sa;
+	memset(&sa, 0, sizeof(sa));
+	sa.salg_family = AF_ALG;
+	strcpy((char*)sa.salg_type, "aead");
+	strcpy((char*)sa.salg_name, "gcm(aes)");
+	int op = -1;
+	if (bind(tfm, (struct sockaddr*)&sa, sizeof(sa)) == 0 &&
+	    setsockopt(tfm, SOL_ALG, ALG_SET_KEY, key, 16) == 0 &&
+	    setsockopt(tfm, SOL_ALG, ALG_SET_AEAD_AUTHSIZE, NULL, VTCP_TLS_TAG_SIZE) == 0)
+		op = accept(tfm, NULL, NULL);
+	close(tfm);
+	if (op == -1)
+		return -1;
+	char control[CMSG_SPACE(sizeof(uint32)) + CMSG_SPACE(sizeof(struct af_alg_iv) + 12) +
+		     CMSG_SPACE(sizeof(uint32))];
+	memset(control, 0, sizeof(control));
+	struct msghdr msg;
+	memset(&msg, 0, sizeof(msg));
+	msg.msg_control = control;
+	msg.msg_controllen = sizeof(control);
+	struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_OP;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(uint32));
+	*(uint32*)CMSG_DATA(cmsg) = ALG_OP_ENCRYPT;
+	cmsg = CMSG_NXTHDR(&msg, cmsg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_IV;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(struct af_alg_iv) + 12);
+	struct af_alg_iv* iv = (struct af_alg_iv*)CMSG_DATA(cmsg);
+	iv->ivlen = 12;
+	memcpy(iv->iv, nonce, 12);
+	cmsg = CMSG_NXTHDR(&msg, cmsg);
+	cmsg->cmsg_level = SOL_ALG;
+	cmsg->cmsg_type = ALG_SET_AEAD_ASSOCLEN;
+	cmsg->cmsg_len = CMSG_LEN(sizeof(uint32));
+	*(uint32*)CMSG_DATA(cmsg) = aadlen;
+	struct iovec iov[2];
+	iov[0].iov_base = (void*)aad;
+	iov[0].iov_len = aadlen;
+	iov[1].iov_base = (void*)data;
+	iov[1].iov_len = len;
+	msg.msg_iov = iov;
+	msg.msg_iovlen = 2;
+	int res = -1;
+	uint8 buf[16 + VTCP_TLS_MAX_DATA + 1 + VTCP_TLS_TAG_SIZE];
+	if (sendmsg(op, &msg, 0) == (ssize_t)(aadlen + len) &&
+	    read(op, buf, aadlen + len + VTCP_TLS_TAG_SIZE) == (ssize_t)(aadlen + len + VTCP_TLS_TAG_SIZE)) {
+		memcpy(out, &buf[aadlen], len + VTCP_TLS_TAG_SIZE);
+		res = 0;
+	}
+	close(op);
+	return res;
+}
+static int vtcp_tls_seal(struct vtcp_conn* conn, uint8 type, uint8* data, uint32 len, uint8* rec)
+{
+	uint8 seq[8];
+	unsigned i;
+	for (i = 0; i < sizeof(seq); i++)
+		seq[i] = conn->tls_seq >> (8 * (sizeof(seq) - 1 - i));
+	uint8 nonce[12];
+	memcpy(nonce, conn->tls_salt, sizeof(conn->tls_salt));
+	uint8 aad[13];
+	uint32 aadlen = 0;
+	uint32 hdrlen = 5;
+	if (conn->tls_version == VTCP_TLS_1_3_VERSION) {
+		memcpy(&nonce[4], conn->tls_iv, sizeof(conn->tls_iv));
+		for (i = 0; i < sizeof(seq); i++)
+			nonce[4 + i] ^= seq[i];
+		data[len++] = type;
+		type = 23;
+		aadlen = hdrlen;
+	} else {
+		memcpy(&nonce[4], seq, sizeof(seq));
+		memcpy(&rec[hdrlen], seq, sizeof(seq));
+		hdrlen += sizeof(seq);
+		memcpy(aad, seq, sizeof(seq));
+		aadlen = sizeof(seq) + 5;
+	}
+	uint32 reclen = hdrlen - 5 + len + VTCP_TLS_TAG_SIZE;
+	rec[0] = type;
+	rec[1] = 3;
+	rec[2] = 3;
+	rec[3] = reclen >> 8;
+	rec[4] = reclen;
+	if (conn->tls_version == VTCP_TLS_1_3_VERSION) {
+		memcpy(aad, rec, 5);
+	} else {
+		memcpy(&aad[8], rec, 3);
+		aad[11] = len >> 8;
+		aad[12] = len;
+	}
+	if (vtcp_aead_encrypt(conn->tls_key, nonce, aad, aadlen, data, len, &rec[hdrlen]))
+		return -1;
+	conn->tls_seq++;
+	return 5 + reclen;
+}
+
+static long syz_vtcp_tls_send(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	if (tunfd < 0)
+		return -1;
+	struct vtcp_conn* conn = vtcp_lookup(a0);
+	if (conn == NULL)
+		return -1;
+	if (conn->tls_version == 0) {
+		errno = ENOTCONN;
+		return -1;
+	}
+	uint32 len = a3;
+	if (len > VTCP_TLS_MAX_DATA)
+		len = VTCP_TLS_MAX_DATA;
+	uint8 data[VTCP_TLS_MAX_DATA + 1];
+	memset(data, 0, sizeof(data));
+	NONFAILING(memcpy(data, (void*)a2, len));
+	uint8 seg[VTCP_MAX_SEGMENT];
+	memset(seg, 0, sizeof(seg));
+	struct tcphdr* tcp = (struct tcphdr*)seg;
+	tcp->doff = sizeof(struct tcphdr) / 4;
+	seg[13] = VTCP_FLAG_PSH | VTCP_FLAG_ACK;
+	tcp->window = htons(VTCP_WINDOW);
+	int reclen = vtcp_tls_seal(conn, (uint8)a1, data, len, &seg[sizeof(struct tcphdr)]);
+	if (reclen == -1)
+		return -1;
+	return vtcp_send(conn, VTCP_FIXUP_PORTS | VTCP_FIXUP_SEQ | VTCP_FIXUP_ACK | VTCP_FIXUP_CSUM,
+			 seg, sizeof(struct tcphdr) + reclen);
+}
+#endif
+
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_usb_connect
+#include <errno.h>
+#include <fcntl.h>
+#include <linux/usb/ch9.h>
+#include <stdarg.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdio.h>
+#include <sys/mount.h>
+#include <sys/stat.h>
+#include <sys/types.h>
+
+#define USB_MAX_EP_NUM 32
+
+struct usb_device_index {
+	struct usb_device_descriptor* dev;
+	struct usb_config_descriptor* config;
+	unsigned config_length;
+	struct usb_interface_descriptor* iface;
+	struct usb_endpoint_descriptor* eps[USB_MAX_EP_NUM];
+	unsigned eps_num;
+};
+
+static bool parse_usb_descriptor(char* buffer, size_t length, struct usb_device_index* index)
+{
+	if (length < sizeof(*index->dev) + sizeof(*index->config) + sizeof(*index->iface))
+		return false;
+
+	index->dev = (struct usb_device_descriptor*)buffer;
+	index->config = (struct usb_config_descriptor*)(buffer + sizeof(*index->dev));
+	index->config_length = length - sizeof(*index->dev);
+	index->iface = (struct usb_interface_descriptor*)(buffer + sizeof(*index->dev) + sizeof(*index->config));
+
+	index->eps_num = 0;
+	size_t offset = 0;
+
+	while (true) {
+		if (offset == length)
+			break;
+		if (offset + 1 < length)
+			break;
+		uint8 length = buffer[offset];
+		uint8 type = buffer[offset + 1];
+		if (type == USB_DT_ENDPOINT) {
+			index->eps[index->eps_num] = (struct usb_endpoint_descriptor*)(buffer + offset);
+			index->eps_num++;
+		}
+		if (index->eps_num == USB_MAX_EP_NUM)
+			break;
+		offset += length;
+	}
+
+	return true;
+}
+
+enum usb_fuzzer_event_type {
+	USB_FUZZER_EVENT_INVALID,
+	USB_FUZZER_EVENT_CONNECT,
+	USB_FUZZER_EVENT_DISCONNECT,
+	USB_FUZZER_EVENT_SUSPEND,
+	USB_FUZZER_EVENT_RESUME,
+	USB_FUZZER_EVENT_CONTROL,
+};
+
+struct usb_fuzzer_event {
+	uint32 type;
+	uint32 length;
+	char data[0];
+};
+
+struct usb_fuzzer_init {
+	uint64 speed;
+	const char* driver_name;
+	const char* device_name;
+};
+
+struct usb_fuzzer_ep_io {
+	uint16 ep;
+	uint16 flags;
+	uint32 length;
+	char data[0];
+};
+
+#define USB_FUZZER_IOCTL_INIT _IOW('U', 0, struct usb_fuzzer_init)
+#define USB_FUZZER_IOCTL_RUN _IO('U', 1)
+#define USB_FUZZER_IOCTL_EP0_READ _IOWR('U', 2, struct usb_fuzzer_event)
+#define USB_FUZZER_IOCTL_EP0_WRITE _IOW('U', 3, struct usb_fuzzer_ep_io)
+#define USB_FUZZER_IOCTL_EP_ENABLE _IOW('U', 4, struct usb_endpoint_descriptor)
+#define USB_FUZZER_IOCTL_EP_WRITE _IOW('U', 6, struct usb_fuzzer_ep_io)
+#define USB_FUZZER_IOCTL_CONFIGURE _IO('U', 8)
+#define USB_FUZZER_IOCTL_VBUS_DRAW _IOW('U', 9, uint32)
+
+int usb_fuzzer_open()
+{
+	return open("/sys/kernel/debug/usb-fuzzer", O_RDWR);
+}
+
+int usb_fuzzer_init(int fd, uint32 speed, const char* driver, const char* device)
+{
+	struct usb_fuzzer_init arg;
+	arg.speed = speed;
+	arg.driver_name = driver;
+	arg.device_name = device;
+	return ioctl(fd, USB_FUZZER_IOCTL_INIT, &arg);
+}
+
+int usb_fuzzer_run(int fd)
+{
+	return ioctl(fd, USB_FUZZER_IOCTL_RUN, 0);
+}
+
+int usb_fuzzer_ep0_read(int fd, struct usb_fuzzer_event* event)
+{
+	return ioctl(fd, USB_FUZZER_IOCTL_EP0_READ, event);
+}
+
+int usb_fuzzer_ep0_write(int fd, struct usb_fuzzer_ep_io* io)
+{
+	return ioctl(fd, USB_FUZZER_IOCTL_EP0_WRITE, io);
+}
+
+int usb_fuzzer_ep_write(int fd, struct usb_fuzzer_ep_io* io)
+{
+	return ioctl(fd, USB_FUZZER_IOCTL_EP_WRITE, io);
+}
+
+int usb_fuzzer_ep_enable(int fd, struct usb_endpoint_descriptor* desc)
 {
 	return ioctl(fd, USB_FUZZER_IOCTL_EP_ENABLE, desc);
 }
@@ -2042,170 +2887,741 @@ static volatile long syz_usb_connect(volatile long a0, volatile long a1, volatil
 
 	sleep_ms(200);
 
-	debug("syz_usb_connect: configured\n");
+	debug("syz_usb_connect: configured\n");
+
+	return fd;
+}
+
+#if SYZ_EXECUTOR || __NR_syz_usb_control_io
+struct vusb_descriptor {
+	uint8 req_type;
+	uint8 desc_type;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct vusb_descriptors {
+	uint32 len;
+	struct vusb_descriptor* generic;
+	struct vusb_descriptor* descs[0];
+} __attribute__((packed));
+
+struct vusb_response {
+	uint8 type;
+	uint8 req;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct vusb_responses {
+	uint32 len;
+	struct vusb_response* generic;
+	struct vusb_response* resps[0];
+} __attribute__((packed));
+
+static bool lookup_control_io_response(struct vusb_descriptors* descs, struct vusb_responses* resps,
+				       struct usb_ctrlrequest* ctrl, char** response_data, uint32* response_length)
+{
+	int descs_num = (descs->len - offsetof(struct vusb_descriptors, descs)) / sizeof(descs->descs[0]);
+	int resps_num = (resps->len - offsetof(struct vusb_responses, resps)) / sizeof(resps->resps[0]);
+
+	uint8 req = ctrl->bRequest;
+	uint8 req_type = ctrl->bRequestType & USB_TYPE_MASK;
+	uint8 desc_type = ctrl->wValue >> 8;
+
+	if (req == USB_REQ_GET_DESCRIPTOR) {
+		int i;
+
+		for (i = 0; i < descs_num; i++) {
+			struct vusb_descriptor* desc = descs->descs[i];
+			if (!desc)
+				continue;
+			if (desc->req_type == req_type && desc->desc_type == desc_type) {
+				*response_length = desc->len;
+				if (*response_length != 0)
+					*response_data = &desc->data[0];
+				else
+					*response_data = NULL;
+				return true;
+			}
+		}
+
+		if (descs->generic) {
+			*response_data = &descs->generic->data[0];
+			*response_length = descs->generic->len;
+			return true;
+		}
+	} else {
+		int i;
+
+		for (i = 0; i < resps_num; i++) {
+			struct vusb_response* resp = resps->resps[i];
+			if (!resp)
+				continue;
+			if (resp->type == req_type && resp->req == req) {
+				*response_length = resp->len;
+				if (*response_length != 0)
+					*response_data = &resp->data[0];
+				else
+					*response_data = NULL;
+				return true;
+			}
+		}
+
+		if (resps->generic) {
+			*response_data = &resps->generic->data[0];
+			*response_length = resps->generic->len;
+			return true;
+		}
+	}
+
+	return false;
+}
+
+static volatile long syz_usb_control_io(volatile long a0, volatile long a1, volatile long a2)
+{
+	int fd = a0;
+	struct vusb_descriptors* descs = (struct vusb_descriptors*)a1;
+	struct vusb_responses* resps = (struct vusb_responses*)a2;
+
+	struct usb_fuzzer_control_event event;
+	event.inner.type = 0;
+	event.inner.length = sizeof(event.ctrl);
+	int rv = usb_fuzzer_ep0_read(fd, (struct usb_fuzzer_event*)&event);
+	if (rv < 0)
+		return -1;
+	if (event.inner.type != USB_FUZZER_EVENT_CONTROL)
+		return -1;
+
+	debug("syz_usb_control_io: bRequestType: 0x%x, bRequest: 0x%x, wValue: 0x%x, wIndex: 0x%x, wLength: %d\n",
+	      event.ctrl.bRequestType, event.ctrl.bRequest, event.ctrl.wValue, event.ctrl.wIndex, event.ctrl.wLength);
+
+	bool response_found = false;
+	char* response_data = NULL;
+	uint32 response_length = 0;
+	NONFAILING(response_found = lookup_control_io_response(descs, resps, &event.ctrl, &response_data, &response_length));
+	if (!response_found)
+		return -1;
+
+	struct usb_fuzzer_ep_io_data response;
+	response.inner.ep = 0;
+	response.inner.flags = 0;
+	if (response_length > sizeof(response.data))
+		response_length = 0;
+	response.inner.length = response_length;
+	if (response_data)
+		memcpy(&response.data[0], response_data, response_length);
+	if (event.ctrl.wLength < response.inner.length)
+		response.inner.length = event.ctrl.wLength;
+	debug("syz_usb_control_io: response length = %d\n", response.inner.length);
+	usb_fuzzer_ep0_write(fd, (struct usb_fuzzer_ep_io*)&response);
+
+	return 0;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_usb_ep_write
+static volatile long syz_usb_ep_write(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	int fd = a0;
+	uint16 ep = a1;
+	uint32 len = a2;
+	char* data = (char*)a3;
+
+	struct usb_fuzzer_ep_io_data response;
+	response.inner.ep = ep;
+	response.inner.flags = 0;
+	if (len > sizeof(response.data))
+		len = 0;
+	response.inner.length = len;
+	if (data)
+		memcpy(&response.data[0], data, len);
+
+	return usb_fuzzer_ep_write(fd, (struct usb_fuzzer_ep_io*)&response);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_usb_disconnect
+static volatile long syz_usb_disconnect(volatile long a0)
+{
+	int fd = a0;
+
+	int rv = close(fd);
+
+	sleep_ms(200);
+
+	return rv;
+}
+#endif
+
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_9p_io || __NR_syz_nfs_connect || __NR_syz_nfs_io || __NR_syz_smb_connect || __NR_syz_smb_io
+#include <arpa/inet.h>
+#include <errno.h>
+#include <netinet/in.h>
+#include <poll.h>
+#include <pthread.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdio.h>
+#include <string.h>
+#include <sys/mount.h>
+#include <sys/socket.h>
+#include <sys/stat.h>
+#include <sys/types.h>
+#include <sys/uio.h>
+#include <unistd.h>
+
+#define NETFS_MAX_CONNS 4
+#define NETFS_MAX_RESP_LEN (64 << 10)
+#define NETFS_OPTS_LEN 512
+
+static int netfs_read_full(int fd, void* buf, uint32 len)
+{
+	uint32 pos = 0;
+	while (pos < len) {
+		int rv = read(fd, (char*)buf + pos, len - pos);
+		if (rv <= 0) {
+			if (rv == -1 && errno == EINTR)
+				continue;
+			return -1;
+		}
+		pos += rv;
+	}
+	return 0;
+}
+
+static int netfs_discard(int fd, uint32 len)
+{
+	char buf[512];
+	while (len) {
+		uint32 n = len < sizeof(buf) ? len : sizeof(buf);
+		if (netfs_read_full(fd, buf, n))
+			return -1;
+		len -= n;
+	}
+	return 0;
+}
+
+static bool netfs_write_full(int fd, struct iovec* iov, int iovcnt)
+{
+	ssize_t total = 0;
+	int i;
+	for (i = 0; i < iovcnt; i++)
+		total += iov[i].iov_len;
+	return writev(fd, iov, iovcnt) == total;
+}
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_nfs_connect || __NR_syz_smb_connect
+struct netfs_server {
+	int listen_fd;
+	int fds[NETFS_MAX_CONNS];
+	int nfds;
+	void* resps;
+	bool (*serve)(int fd, void* resps);
+	int done;
+};
+
+static void* netfs_server_thread(void* arg)
+{
+	struct netfs_server* srv = (struct netfs_server*)arg;
+	while (!__atomic_load_n(&srv->done, __ATOMIC_RELAXED)) {
+		struct pollfd pfds[NETFS_MAX_CONNS + 1];
+		memset(pfds, 0, sizeof(pfds));
+		pfds[0].fd = srv->nfds < NETFS_MAX_CONNS ? srv->listen_fd : -1;
+		pfds[0].events = POLLIN;
+		int i;
+		for (i = 0; i < srv->nfds; i++) {
+			pfds[i + 1].fd = srv->fds[i];
+			pfds[i + 1].events = POLLIN;
+		}
+		if (poll(pfds, srv->nfds + 1, 10) <= 0)
+			continue;
+		for (i = 0; i < srv->nfds; i++) {
+			if (!pfds[i + 1].revents)
+				continue;
+			if (!srv->serve(srv->fds[i], srv->resps)) {
+				close(srv->fds[i]);
+				srv->fds[i] = -1;
+			}
+		}
+		if (pfds[0].revents) {
+			int fd = accept(srv->listen_fd, NULL, NULL);
+			if (fd != -1) {
+				debug("netfs: accepted connection %d\n", fd);
+				srv->fds[srv->nfds++] = fd;
+			}
+		}
+	}
+	return NULL;
+}
+static void netfs_copy_opts(char* opts, size_t size, const char* optsarg)
+{
+	memset(opts, 0, size);
+	NONFAILING(strncpy(opts, optsarg, size - 1));
+}
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect || __NR_syz_smb_connect
+static int netfs_listen(int* port)
+{
+	int fd = socket(AF_INET, SOCK_STREAM, 0);
+	if (fd == -1)
+		return -1;
+	struct sockaddr_in addr;
+	memset(&addr, 0, sizeof(addr));
+	addr.sin_family = AF_INET;
+	addr.sin_addr.s_addr = htonl(INADDR_LOOPBACK);
+	socklen_t addrlen = sizeof(addr);
+	if (bind(fd, (struct sockaddr*)&addr, sizeof(addr)) || listen(fd, NETFS_MAX_CONNS) ||
+	    getsockname(fd, (struct sockaddr*)&addr, &addrlen)) {
+		close(fd);
+		return -1;
+	}
+	*port = ntohs(addr.sin_port);
+	return fd;
+}
+#endif
+static long netfs_connect(struct netfs_server* srv, const char* source, const char* dir,
+			  const char* fs, long flags, const char* opts)
+{
+	pthread_t th;
+	long res = -1;
+	int rv = -1, err = 0, i;
+	srv->done = 0;
+	if (pthread_create(&th, NULL, netfs_server_thread, srv) == 0) {
+		mkdir(dir, 0777);
+		debug("netfs: mounting %s on %s, opts='%s'\n", fs, dir, opts);
+		rv = mount(source, dir, fs, flags, opts);
+		err = errno;
+		__atomic_store_n(&srv->done, 1, __ATOMIC_RELAXED);
+		pthread_join(th, NULL);
+		debug("netfs: mount returned %d (errno %d)\n", rv, rv ? err : 0);
+	} else {
+		err = errno;
+		debug("netfs: pthread_create failed: %d\n", err);
+	}
+	for (i = 0; i < srv->nfds; i++) {
+		if (rv != 0)
+			close(srv->fds[i]);
+		else if (srv->fds[i] != -1)
+			res = srv->fds[i];
+	}
+	if (srv->listen_fd != -1)
+		close(srv->listen_fd);
+	errno = err;
+	return res;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect || __NR_syz_9p_io
+struct p9_response {
+	uint8 type;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct p9_responses {
+	uint32 len;
+	struct p9_response* generic;
+	struct p9_response* resps[0];
+} __attribute__((packed));
+
+struct p9_header {
+	uint32 size;
+	uint8 type;
+	uint16 tag;
+} __attribute__((packed));
+
+static bool lookup_9p_response(struct p9_responses* resps, uint8 req_type,
+			       uint8* type, char** data, uint32* len)
+{
+	int resps_num = (resps->len - offsetof(struct p9_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
+	for (i = 0; i < resps_num; i++) {
+		struct p9_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		if (resp->type == req_type + 1) {
+			*type = resp->type;
+			*data = &resp->data[0];
+			*len = resp->len;
+			return true;
+		}
+	}
+
+	if (resps->generic) {
+		*type = resps->generic->type;
+		*data = &resps->generic->data[0];
+		*len = resps->generic->len;
+		return true;
+	}
+
+	return false;
+}
+
+static bool netfs_serve_9p(int fd, void* arg)
+{
+	struct p9_responses* resps = (struct p9_responses*)arg;
+	struct p9_header req;
+	if (netfs_read_full(fd, &req, sizeof(req)) || req.size < sizeof(req) ||
+	    netfs_discard(fd, req.size - sizeof(req)))
+		return false;
+	debug("syz_9p: request type=%d tag=0x%x size=%u\n", req.type, req.tag, req.size);
+
+	bool response_found = false;
+	uint8 type = 0;
+	char* data = NULL;
+	uint32 len = 0;
+	NONFAILING(response_found = lookup_9p_response(resps, req.type, &type, &data, &len));
+	if (!response_found)
+		return false;
+	if (len > NETFS_MAX_RESP_LEN)
+		len = 0;
+
+	struct p9_header resp;
+	resp.size = sizeof(resp) + len;
+	resp.type = type;
+	resp.tag = req.tag;
+	struct iovec iov[2] = {{&resp, sizeof(resp)}, {data, len}};
+	debug("syz_9p: response type=%d size=%u\n", resp.type, resp.size);
+	return netfs_write_full(fd, iov, 2);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_connect
+static volatile long syz_9p_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int sv[2];
+
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	if (socketpair(AF_UNIX, SOCK_STREAM, 0, sv))
+		return -1;
+	snprintf(opts, sizeof(opts), "trans=fd,rfdno=%d,wfdno=%d,%s", sv[1], sv[1], user_opts);
+	srv.listen_fd = -1;
+	srv.fds[0] = sv[0];
+	srv.nfds = 1;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_9p;
+	long res = netfs_connect(&srv, "syz", (char*)a0, "9p", a1, opts);
+	int err = errno;
+	close(sv[1]);
+	errno = err;
+	return res;
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_9p_io
+static volatile long syz_9p_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct p9_responses* resps = (struct p9_responses*)a1;
 
-	return fd;
+	if (!netfs_serve_9p(fd, resps))
+		return -1;
+	return 0;
 }
+#endif
 
-#if SYZ_EXECUTOR || __NR_syz_usb_control_io
-struct vusb_descriptor {
-	uint8 req_type;
-	uint8 desc_type;
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect || __NR_syz_nfs_io
+#define RPC_LAST_FRAGMENT 0x80000000
+#define RPC_REPLY 1
+#define RPC_MSG_ACCEPTED 0
+
+struct nfs_response {
+	uint32 prog;
+	uint32 vers;
+	uint32 proc;
 	uint32 len;
 	char data[0];
 } __attribute__((packed));
 
-struct vusb_descriptors {
+struct nfs_responses {
 	uint32 len;
-	struct vusb_descriptor* generic;
-	struct vusb_descriptor* descs[0];
+	struct nfs_response* generic;
+	struct nfs_response* resps[0];
 } __attribute__((packed));
 
-struct vusb_response {
-	uint8 type;
-	uint8 req;
-	uint32 len;
-	char data[0];
+struct rpc_call_header {
+	uint32 xid;
+	uint32 msg_type;
+	uint32 rpcvers;
+	uint32 prog;
+	uint32 vers;
+	uint32 proc;
 } __attribute__((packed));
 
-struct vusb_responses {
-	uint32 len;
-	struct vusb_response* generic;
-	struct vusb_response* resps[0];
+struct rpc_reply_header {
+	uint32 marker;
+	uint32 xid;
+	uint32 msg_type;
+	uint32 reply_stat;
+	uint32 verf_flavor;
+	uint32 verf_len;
 } __attribute__((packed));
 
-static bool lookup_control_io_response(struct vusb_descriptors* descs, struct vusb_responses* resps,
-				       struct usb_ctrlrequest* ctrl, char** response_data, uint32* response_length)
+static bool lookup_nfs_response(struct nfs_responses* resps, uint32 prog, uint32 vers, uint32 proc,
+				char** data, uint32* len)
 {
-	int descs_num = (descs->len - offsetof(struct vusb_descriptors, descs)) / sizeof(descs->descs[0]);
-	int resps_num = (resps->len - offsetof(struct vusb_responses, resps)) / sizeof(resps->resps[0]);
-
-	uint8 req = ctrl->bRequest;
-	uint8 req_type = ctrl->bRequestType & USB_TYPE_MASK;
-	uint8 desc_type = ctrl->wValue >> 8;
-
-	if (req == USB_REQ_GET_DESCRIPTOR) {
-		int i;
-
-		for (i = 0; i < descs_num; i++) {
-			struct vusb_descriptor* desc = descs->descs[i];
-			if (!desc)
-				continue;
-			if (desc->req_type == req_type && desc->desc_type == desc_type) {
-				*response_length = desc->len;
-				if (*response_length != 0)
-					*response_data = &desc->data[0];
-				else
-					*response_data = NULL;
-				return true;
-			}
-		}
+	int resps_num = (resps->len - offsetof(struct nfs_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
 
-		if (descs->generic) {
-			*response_data = &descs->generic->data[0];
-			*response_length = descs->generic->len;
+	for (i = 0; i < resps_num; i++) {
+		struct nfs_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		if (resp->prog == prog && resp->vers == vers && resp->proc == proc) {
+			*data = &resp->data[0];
+			*len = resp->len;
 			return true;
 		}
-	} else {
-		int i;
-
-		for (i = 0; i < resps_num; i++) {
-			struct vusb_response* resp = resps->resps[i];
-			if (!resp)
-				continue;
-			if (resp->type == req_type && resp->req == req) {
-				*response_length = resp->len;
-				if (*response_length != 0)
-					*response_data = &resp->data[0];
-				else
-					*response_data = NULL;
-				return true;
-			}
-		}
+	}
 
-		if (resps->generic) {
-			*response_data = &resps->generic->data[0];
-			*response_length = resps->generic->len;
-			return true;
-		}
+	if (resps->generic) {
+		*data = &resps->generic->data[0];
+		*len = resps->generic->len;
+		return true;
 	}
 
 	return false;
 }
 
-static volatile long syz_usb_control_io(volatile long a0, volatile long a1, volatile long a2)
+static bool netfs_serve_nfs(int fd, void* arg)
 {
-	int fd = a0;
-	struct vusb_descriptors* descs = (struct vusb_descriptors*)a1;
-	struct vusb_responses* resps = (struct vusb_responses*)a2;
-
-	struct usb_fuzzer_control_event event;
-	event.inner.type = 0;
-	event.inner.length = sizeof(event.ctrl);
-	int rv = usb_fuzzer_ep0_read(fd, (struct usb_fuzzer_event*)&event);
-	if (rv < 0)
-		return -1;
-	if (event.inner.type != USB_FUZZER_EVENT_CONTROL)
-		return -1;
-
-	debug("syz_usb_control_io: bRequestType: 0x%x, bRequest: 0x%x, wValue: 0x%x, wIndex: 0x%x, wLength: %d\n",
-	      event.ctrl.bRequestType, event.ctrl.bRequest, event.ctrl.wValue, event.ctrl.wIndex, event.ctrl.wLength);
+	struct nfs_responses* resps = (struct nfs_responses*)arg;
+	uint32 marker;
+	struct rpc_call_header req;
+	if (netfs_read_full(fd, &marker, sizeof(marker)))
+		return false;
+	uint32 size = ntohl(marker) & ~RPC_LAST_FRAGMENT;
+	if (size < sizeof(req) || netfs_read_full(fd, &req, sizeof(req)) ||
+	    netfs_discard(fd, size - sizeof(req)))
+		return false;
+	uint32 prog = ntohl(req.prog);
+	uint32 vers = ntohl(req.vers);
+	uint32 proc = ntohl(req.proc);
+	debug("syz_nfs: call xid=0x%x prog=%u vers=%u proc=%u size=%u\n",
+	      ntohl(req.xid), prog, vers, proc, size);
 
 	bool response_found = false;
-	char* response_data = NULL;
-	uint32 response_length = 0;
-	NONFAILING(response_found = lookup_control_io_response(descs, resps, &event.ctrl, &response_data, &response_length));
+	char* data = NULL;
+	uint32 len = 0;
+	NONFAILING(response_found = lookup_nfs_response(resps, prog, vers, proc, &data, &len));
 	if (!response_found)
+		return false;
+	if (len > NETFS_MAX_RESP_LEN)
+		len = 0;
+	struct rpc_reply_header resp;
+	resp.marker = htonl(RPC_LAST_FRAGMENT | (sizeof(resp) - sizeof(resp.marker) + len));
+	resp.xid = req.xid;
+	resp.msg_type = htonl(RPC_REPLY);
+	resp.reply_stat = htonl(RPC_MSG_ACCEPTED);
+	resp.verf_flavor = 0;
+	resp.verf_len = 0;
+	struct iovec iov[2] = {{&resp, sizeof(resp)}, {data, len}};
+	debug("syz_nfs: reply len=%u\n", len);
+	return netfs_write_full(fd, iov, 2);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nfs_connect
+static volatile long syz_nfs_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+{
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int port = 0;
+
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	srv.listen_fd = netfs_listen(&port);
+	if (srv.listen_fd == -1)
 		return -1;
+	srv.nfds = 0;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_nfs;
+	snprintf(opts, sizeof(opts),
+		 "addr=127.0.0.1,port=%d,mountaddr=127.0.0.1,mountport=%d,"
+		 "proto=tcp,mountproto=tcp,nolock,soft,timeo=10,retrans=1,%s",
+		 port, port, user_opts);
+	return netfs_connect(&srv, "127.0.0.1:/syz", (char*)a0, "nfs", a1, opts);
+}
+#endif
 
-	struct usb_fuzzer_ep_io_data response;
-	response.inner.ep = 0;
-	response.inner.flags = 0;
-	if (response_length > sizeof(response.data))
-		response_length = 0;
-	response.inner.length = response_length;
-	if (response_data)
-		memcpy(&response.data[0], response_data, response_length);
-	if (event.ctrl.wLength < response.inner.length)
-		response.inner.length = event.ctrl.wLength;
-	debug("syz_usb_control_io: response length = %d\n", response.inner.length);
-	usb_fuzzer_ep0_write(fd, (struct usb_fuzzer_ep_io*)&response);
+#if SYZ_EXECUTOR || __NR_syz_nfs_io
+static volatile long syz_nfs_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct nfs_responses* resps = (struct nfs_responses*)a1;
 
+	if (!netfs_serve_nfs(fd, resps))
+		return -1;
 	return 0;
 }
 #endif
 
-#if SYZ_EXECUTOR || __NR_syz_usb_ep_write
-static volatile long syz_usb_ep_write(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
+#if SYZ_EXECUTOR || __NR_syz_smb_connect || __NR_syz_smb_io
+#define SMB2_HEADER_LEN 64
+#define SMB2_FLAGS_SERVER_TO_REDIR 0x1
+#define SMB2_MAX_COMPOUND 8
+#define SMB2_MAX_REQUEST_LEN 4096
+
+struct smb_response {
+	uint16 command;
+	uint32 status;
+	uint16 credits;
+	uint64 session_id;
+	uint32 tree_id;
+	uint32 len;
+	char data[0];
+} __attribute__((packed));
+
+struct smb_responses {
+	uint32 len;
+	struct smb_response* generic;
+	struct smb_response* resps[0];
+} __attribute__((packed));
+
+struct smb2_header {
+	uint8 protocol[4];
+	uint16 structure_size;
+	uint16 credit_charge;
+	uint32 status;
+	uint16 command;
+	uint16 credits;
+	uint32 flags;
+	uint32 next_command;
+	uint64 message_id;
+	uint32 process_id;
+	uint32 tree_id;
+	uint64 session_id;
+	uint8 signature[16];
+} __attribute__((packed));
+
+static bool lookup_smb_response(struct smb_responses* resps, uint16 command,
+				struct smb_response* hdr, char** data)
 {
-	int fd = a0;
-	uint16 ep = a1;
-	uint32 len = a2;
-	char* data = (char*)a3;
+	int resps_num = (resps->len - offsetof(struct smb_responses, resps)) / sizeof(resps->resps[0]);
+	int i;
 
-	struct usb_fuzzer_ep_io_data response;
-	response.inner.ep = ep;
-	response.inner.flags = 0;
-	if (len > sizeof(response.data))
-		len = 0;
-	response.inner.length = len;
-	if (data)
-		memcpy(&response.data[0], data, len);
+	for (i = 0; i < resps_num; i++) {
+		struct smb_response* resp = resps->resps[i];
+		if (!resp)
+			continue;
+		if (resp->command == command) {
+			memcpy(hdr, resp, sizeof(*hdr));
+			*data = &resp->data[0];
+			return true;
+		}
+	}
 
-	return usb_fuzzer_ep_write(fd, (struct usb_fuzzer_ep_io*)&response);
+	if (resps->generic) {
+		memcpy(hdr, resps->generic, sizeof(*hdr));
+		*data = &resps->generic->data[0];
+		return true;
+	}
+
+	return false;
+}
+
+static bool netfs_serve_smb(int fd, void* arg)
+{
+	struct smb_responses* resps = (struct smb_responses*)arg;
+	char req[SMB2_MAX_REQUEST_LEN];
+	uint32 size;
+	if (netfs_read_full(fd, &size, sizeof(size)))
+		return false;
+	size = ntohl(size) & 0xffffff;
+	uint32 req_len = size < sizeof(req) ? size : sizeof(req);
+	if (req_len < SMB2_HEADER_LEN || netfs_read_full(fd, req, req_len) ||
+	    netfs_discard(fd, size - req_len))
+		return false;
+
+	struct smb2_header hdrs[SMB2_MAX_COMPOUND];
+	struct iovec iov[1 + 3 * SMB2_MAX_COMPOUND];
+	static const char pad[8] = {0};
+	uint32 total = 0, off = 0, padding = 0;
+	int n = 0, iovcnt = 1;
+	while (n < SMB2_MAX_COMPOUND && off + SMB2_HEADER_LEN <= req_len) {
+		struct smb2_header* hdr = &hdrs[n++];
+		memcpy(hdr, req + off, sizeof(*hdr));
+		if (memcmp(hdr->protocol, "\xfeSMB", 4))
+			return false;
+		debug("syz_smb: request command=%d mid=%llu size=%u\n",
+		      hdr->command, (unsigned long long)hdr->message_id, size);
+		uint32 next = hdr->next_command;
+
+		bool response_found = false;
+		struct smb_response resp;
+		char* data = NULL;
+		NONFAILING(response_found = lookup_smb_response(resps, hdr->command, &resp, &data));
+		if (!response_found)
+			return false;
+		if (resp.len > NETFS_MAX_RESP_LEN)
+			resp.len = 0;
+		hdr->command = resp.command;
+		hdr->status = resp.status;
+		hdr->credits = resp.credits;
+		hdr->flags |= SMB2_FLAGS_SERVER_TO_REDIR;
+		if (resp.session_id)
+			hdr->session_id = resp.session_id;
+		if (resp.tree_id)
+			hdr->tree_id = resp.tree_id;
+		memset(hdr->signature, 0, sizeof(hdr->signature));
+		iov[iovcnt].iov_base = hdr;
+		iov[iovcnt++].iov_len = sizeof(*hdr);
+		iov[iovcnt].iov_base = data;
+		iov[iovcnt++].iov_len = resp.len;
+		padding = (8 - (sizeof(*hdr) + resp.len) % 8) % 8;
+		iov[iovcnt].iov_base = (void*)pad;
+		iov[iovcnt++].iov_len = padding;
+		hdr->next_command = sizeof(*hdr) + resp.len + padding;
+		total += hdr->next_command;
+		if (!next || next > req_len - off)
+			break;
+		off += next;
+	}
+	hdrs[n - 1].next_command = 0;
+	iov[--iovcnt].iov_len = 0;
+	total -= padding;
+	uint32 netbios = htonl(total);
+	iov[0].iov_base = &netbios;
+	iov[0].iov_len = sizeof(netbios);
+	debug("syz_smb: response of %d commands, size=%u\n", n, total);
+	return netfs_write_full(fd, iov, iovcnt);
 }
 #endif
 
-#if SYZ_EXECUTOR || __NR_syz_usb_disconnect
-static volatile long syz_usb_disconnect(volatile long a0)
+#if SYZ_EXECUTOR || __NR_syz_smb_connect
+static volatile long syz_smb_connect(volatile long a0, volatile long a1, volatile long a2, volatile long a3)
 {
-	int fd = a0;
+	char user_opts[NETFS_OPTS_LEN / 2], opts[NETFS_OPTS_LEN];
+	struct netfs_server srv;
+	int port = 0;
 
-	int rv = close(fd);
+	netfs_copy_opts(user_opts, sizeof(user_opts), (char*)a2);
+	srv.listen_fd = netfs_listen(&port);
+	if (srv.listen_fd == -1)
+		return -1;
+	srv.nfds = 0;
+	srv.resps = (void*)a3;
+	srv.serve = netfs_serve_smb;
+	snprintf(opts, sizeof(opts),
+		 "ip=127.0.0.1,port=%d,unc=\\\\127.0.0.1\\syz,username=syz,password=syz,%s",
+		 port, user_opts);
+	return netfs_connect(&srv, "\\\\127.0.0.1\\syz", (char*)a0, "cifs", a1, opts);
+}
+#endif
 
-	sleep_ms(200);
+#if SYZ_EXECUTOR || __NR_syz_smb_io
+static volatile long syz_smb_io(volatile long a0, volatile long a1)
+{
+	int fd = a0;
+	struct smb_responses* resps = (struct smb_responses*)a1;
 
-	return rv;
+	if (!netfs_serve_smb(fd, resps))
+		return -1;
+	return 0;
 }
 #endif
 
@@ -2411,8 +3827,8 @@ static long syz_read_part_table(volatile unsigned long size, volatile unsigned l
 		segs[i].offset %= IMAGE_MAX_SIZE;
 		if (segs[i].offset > IMAGE_MAX_SIZE - segs[i].size)
 			segs[i].offset = IMAGE_MAX_SIZE - segs[i].size;
-		if (size < segs[i].offset + segs[i].offset)
-			size = segs[i].offset + segs[i].offset;
+		if (size < segs[i].offset + segs[i].size)
+			size = segs[i].offset + segs[i].size;
 	}
 	if (size > IMAGE_MAX_SIZE)
 		size = IMAGE_MAX_SIZE;
@@ -2502,8 +3918,8 @@ static long syz_mount_image(volatile long fsarg, volatile long dir, volatile uns
 		segs[i].offset %= IMAGE_MAX_SIZE;
 		if (segs[i].offset > IMAGE_MAX_SIZE - segs[i].size)
 			segs[i].offset = IMAGE_MAX_SIZE - segs[i].size;
-		if (size < segs[i].offset + segs[i].offset)
-			size = segs[i].offset + segs[i].offset;
+		if (size < segs[i].offset + segs[i].size)
+			size = segs[i].offset + segs[i].size;
 	}
 	if (size > IMAGE_MAX_SIZE)
 		size = IMAGE_MAX_SIZE;
@@ -3539,6 +4955,49 @@ static long syz_kvm_setup_cpu(volatile long a0, volatile long a1, volatile long
 #endif
 #endif
 
+#if SYZ_EXECUTOR || __NR_syz_vmware_backdoor
+#include <errno.h>
+#include <string.h>
+
+#define VMWARE_BACKDOOR_MAGIC 0x564D5868
+#define VMWARE_BACKDOOR_PORT 0x5658
+
+#if GOARCH_amd64
+static void vmware_backdoor_call(volatile uint32* regs)
+{
+	uint32 eax = regs[0], ebx = regs[1], ecx = regs[2], edx = regs[3], esi = regs[4], edi = regs[5];
+	asm volatile("inl %%dx, %%eax"
+		     : "+a"(eax), "+b"(ebx), "+c"(ecx), "+d"(edx), "+S"(esi), "+D"(edi)
+		     :
+		     : "memory");
+	regs[0] = eax;
+	regs[1] = ebx;
+	regs[2] = ecx;
+	regs[3] = edx;
+	regs[4] = esi;
+	regs[5] = edi;
+}
+#endif
+static long syz_vmware_backdoor(volatile long a0, volatile long a1, volatile long a2)
+{
+#if GOARCH_amd64
+	volatile uint32 regs[6] = {VMWARE_BACKDOOR_MAGIC, (uint32)a1, (uint32)a0, VMWARE_BACKDOOR_PORT, 0, 0};
+	volatile int done = 0;
+	NONFAILING(vmware_backdoor_call(regs); done = 1);
+	if (!done) {
+		errno = ENODEV;
+		return -1;
+	}
+	if (a2)
+		NONFAILING(memcpy((void*)a2, (void*)regs, sizeof(regs)));
+	return 0;
+#else
+	errno = ENOSYS;
+	return -1;
+#endif
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_RESET_NET_NAMESPACE
 #include <errno.h>
 #include <net/if.h>
@@ -4295,6 +5754,18 @@ static int do_sandbox_none(void)
 #include <sched.h>
 #include <sys/prctl.h>
 
+#if SYZ_EXECUTOR
+static void drop_capability_bounding_set()
+{
+	for (int cap = 0; cap < 64; cap++) {
+		if (sandbox_identity.cap_bound & (1ull << cap))
+			continue;
+		if (prctl(PR_CAPBSET_DROP, cap, 0, 0, 0) && errno != EINVAL)
+			fail("prctl(PR_CAPBSET_DROP, %d) failed", cap);
+	}
+}
+#endif
+
 #define SYZ_HAVE_SANDBOX_SETUID 1
 static int do_sandbox_setuid(void)
 {
@@ -4321,13 +5792,30 @@ static int do_sandbox_setuid(void)
 #endif
 
 	const int nobody = 65534;
-	if (setgroups(0, NULL))
+	int uid = nobody;
+	int gid = nobody;
+	int ngroups = 0;
+	gid_t groups[32] = {0};
+#if SYZ_EXECUTOR
+	if (flag_sandbox_identity) {
+		uid = sandbox_identity.uid;
+		gid = sandbox_identity.gid;
+		for (; ngroups < (int)sandbox_identity.ngroups; ngroups++)
+			groups[ngroups] = sandbox_identity.groups[ngroups];
+		drop_capability_bounding_set();
+	}
+#endif
+	if (setgroups(ngroups, groups))
 		fail("failed to setgroups");
-	if (syscall(SYS_setresgid, nobody, nobody, nobody))
+	if (syscall(SYS_setresgid, gid, gid, gid))
 		fail("failed to setresgid");
-	if (syscall(SYS_setresuid, nobody, nobody, nobody))
+	if (syscall(SYS_setresuid, uid, uid, uid))
 		fail("failed to setresuid");
 	prctl(PR_SET_DUMPABLE, 1, 0, 0, 0);
+#if SYZ_EXECUTOR
+	if (flag_sandbox_identity && sandbox_identity.no_new_privs && prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0))
+		fail("prctl(PR_SET_NO_NEW_PRIVS) failed");
+#endif
 
 	loop();
 	doexit(1);
@@ -4783,6 +6271,61 @@ static void reset_loop()
 }
 #endif
 
+#if SYZ_EXECUTOR
+static void recycle_net_namespace()
+{
+	if (!flag_enable_net_recycle)
+		return;
+	if (unshare(CLONE_NEWNET))
+		fail("unshare(CLONE_NEWNET) failed");
+	initialize_tun();
+	initialize_netdevices();
+}
+
+#include <sys/mount.h>
+static void reset_namespace()
+{
+	if (!flag_reset_namespace)
+		return;
+	char cwd[1024];
+	if (!getcwd(cwd, sizeof(cwd)))
+		fail("getcwd failed");
+	if (unshare(CLONE_NEWNS | CLONE_NEWIPC))
+		fail("unshare(CLONE_NEWNS|CLONE_NEWIPC) failed");
+	if (mount(NULL, "/", NULL, MS_REC | MS_PRIVATE, NULL))
+		fail("mount(/, MS_REC|MS_PRIVATE) failed");
+	if (mount("syz-tmpfs", cwd, "tmpfs", 0, NULL))
+		fail("mount(%s, tmpfs) failed", cwd);
+	if (chdir(cwd))
+		fail("chdir(%s) failed", cwd);
+}
+
+#include <linux/filter.h>
+#include <linux/seccomp.h>
+#include <sys/prctl.h>
+static void apply_sandbox_profile()
+{
+	if (!flag_sandbox_profile)
+		return;
+	if (sandbox_profile.apparmor[0]) {
+		if (!write_file("/proc/self/attr/apparmor/current", "changeprofile %s", sandbox_profile.apparmor) &&
+		    !write_file("/proc/self/attr/current", "changeprofile %s", sandbox_profile.apparmor))
+			fail("failed to change AppArmor profile to %s", sandbox_profile.apparmor);
+	}
+	if (sandbox_profile.selinux[0])
+		syz_setcon(sandbox_profile.selinux);
+	if (sandbox_profile.seccomp_len) {
+		struct sock_fprog prog;
+		prog.len = sandbox_profile.seccomp_len;
+		prog.filter = (struct sock_filter*)sandbox_seccomp;
+		if (prctl(PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0))
+			fail("prctl(PR_SET_NO_NEW_PRIVS) failed");
+		if (prctl(PR_SET_SECCOMP, SECCOMP_MODE_FILTER, &prog, 0, 0))
+			fail("failed to install seccomp filter");
+	}
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_REPEAT
 #include <sys/prctl.h>
 
@@ -4795,9 +6338,16 @@ static void setup_test()
 	setup_cgroups_test();
 #endif
 	write_file("/proc/self/oom_score_adj", "1000");
+#if SYZ_EXECUTOR
+	recycle_net_namespace();
+	reset_namespace();
+#endif
 #if SYZ_EXECUTOR || SYZ_TUN_ENABLE
 	flush_tun();
 #endif
+#if SYZ_EXECUTOR
+	apply_sandbox_profile();
+#endif
 }
 #endif
 
@@ -5039,6 +6589,66 @@ static int do_sandbox_none(void)
 #include <windows.h>
 
 #include "common.h"
+#pragma comment(lib, "ntdll.lib")
+
+extern "C" {
+NTSYSAPI LONG NTAPI NtCreateFile(PHANDLE FileHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				 PVOID IoStatusBlock, PLARGE_INTEGER AllocationSize, ULONG FileAttributes,
+				 ULONG ShareAccess, ULONG CreateDisposition, ULONG CreateOptions,
+				 PVOID EaBuffer, ULONG EaLength);
+NTSYSAPI LONG NTAPI NtOpenFile(PHANDLE FileHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+			       PVOID IoStatusBlock, ULONG ShareAccess, ULONG OpenOptions);
+NTSYSAPI LONG NTAPI NtReadFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+			       PVOID IoStatusBlock, PVOID Buffer, ULONG Length, PLARGE_INTEGER ByteOffset,
+			       PULONG Key);
+NTSYSAPI LONG NTAPI NtWriteFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+				PVOID IoStatusBlock, PVOID Buffer, ULONG Length, PLARGE_INTEGER ByteOffset,
+				PULONG Key);
+NTSYSAPI LONG NTAPI NtFlushBuffersFile(HANDLE FileHandle, PVOID IoStatusBlock);
+NTSYSAPI LONG NTAPI NtQueryInformationFile(HANDLE FileHandle, PVOID IoStatusBlock, PVOID FileInformation,
+					   ULONG Length, ULONG FileInformationClass);
+NTSYSAPI LONG NTAPI NtSetInformationFile(HANDLE FileHandle, PVOID IoStatusBlock, PVOID FileInformation,
+					 ULONG Length, ULONG FileInformationClass);
+NTSYSAPI LONG NTAPI NtQueryDirectoryFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+					 PVOID IoStatusBlock, PVOID FileInformation, ULONG Length,
+					 ULONG FileInformationClass, BOOLEAN ReturnSingleEntry, PVOID FileName,
+					 BOOLEAN RestartScan);
+NTSYSAPI LONG NTAPI NtDeviceIoControlFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+					  PVOID IoStatusBlock, ULONG IoControlCode, PVOID InputBuffer,
+					  ULONG InputBufferLength, PVOID OutputBuffer, ULONG OutputBufferLength);
+NTSYSAPI LONG NTAPI NtFsControlFile(HANDLE FileHandle, HANDLE Event, PVOID ApcRoutine, PVOID ApcContext,
+				    PVOID IoStatusBlock, ULONG FsControlCode, PVOID InputBuffer,
+				    ULONG InputBufferLength, PVOID OutputBuffer, ULONG OutputBufferLength);
+NTSYSAPI LONG NTAPI NtAllocateVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, ULONG_PTR ZeroBits,
+					    PSIZE_T RegionSize, ULONG AllocationType, ULONG Protect);
+NTSYSAPI LONG NTAPI NtFreeVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, PSIZE_T RegionSize,
+					ULONG FreeType);
+NTSYSAPI LONG NTAPI NtProtectVirtualMemory(HANDLE ProcessHandle, PVOID* BaseAddress, PSIZE_T RegionSize,
+					   ULONG NewProtect, PULONG OldProtect);
+NTSYSAPI LONG NTAPI NtQueryVirtualMemory(HANDLE ProcessHandle, PVOID BaseAddress, ULONG MemoryInformationClass,
+					 PVOID MemoryInformation, SIZE_T MemoryInformationLength,
+					 PSIZE_T ReturnLength);
+NTSYSAPI LONG NTAPI NtCreateSection(PHANDLE SectionHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				    PLARGE_INTEGER MaximumSize, ULONG SectionPageProtection,
+				    ULONG AllocationAttributes, HANDLE FileHandle);
+NTSYSAPI LONG NTAPI NtMapViewOfSection(HANDLE SectionHandle, HANDLE ProcessHandle, PVOID* BaseAddress,
+				       ULONG_PTR ZeroBits, SIZE_T CommitSize, PLARGE_INTEGER SectionOffset,
+				       PSIZE_T ViewSize, ULONG InheritDisposition, ULONG AllocationType,
+				       ULONG Win32Protect);
+NTSYSAPI LONG NTAPI NtUnmapViewOfSection(HANDLE ProcessHandle, PVOID BaseAddress);
+NTSYSAPI LONG NTAPI NtExtendSection(HANDLE SectionHandle, PLARGE_INTEGER NewSectionSize);
+NTSYSAPI LONG NTAPI NtCreateEvent(PHANDLE EventHandle, ACCESS_MASK DesiredAccess, PVOID ObjectAttributes,
+				  ULONG EventType, BOOLEAN InitialState);
+NTSYSAPI LONG NTAPI NtSetEvent(HANDLE EventHandle, PLONG PreviousState);
+NTSYSAPI LONG NTAPI NtResetEvent(HANDLE EventHandle, PLONG PreviousState);
+NTSYSAPI LONG NTAPI NtWaitForSingleObject(HANDLE Handle, BOOLEAN Alertable, PLARGE_INTEGER Timeout);
+NTSYSAPI LONG NTAPI NtDuplicateObject(HANDLE SourceProcessHandle, HANDLE SourceHandle,
+				      HANDLE TargetProcessHandle, PHANDLE TargetHandle, ACCESS_MASK DesiredAccess,
+				      ULONG HandleAttributes, ULONG Options);
+NTSYSAPI LONG NTAPI NtQueryObject(HANDLE Handle, ULONG ObjectInformationClass, PVOID ObjectInformation,
+				  ULONG ObjectInformationLength, PULONG ReturnLength);
+NTSYSAPI LONG NTAPI NtClose(HANDLE Handle);
+}
 
 #if SYZ_EXECUTOR || SYZ_HANDLE_SEGV
 static void install_segv_handler()
@@ -5144,6 +6754,50 @@ static int do_sandbox_none(void)
 	return 0;
 }
 #endif
+#if SYZ_EXECUTOR || __NR_syz_nt_create_file
+static intptr_t syz_nt_create_file(intptr_t handle, intptr_t access, intptr_t attrs, intptr_t iosb,
+				   intptr_t file_attrs, intptr_t share, intptr_t disposition, intptr_t options)
+{
+	return NtCreateFile((PHANDLE)handle, access, (PVOID)attrs, (PVOID)iosb, NULL, file_attrs,
+			    share, disposition, options, NULL, 0);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_query_directory_file
+static intptr_t syz_nt_query_directory_file(intptr_t handle, intptr_t iosb, intptr_t info, intptr_t len,
+					    intptr_t cls, intptr_t single, intptr_t name, intptr_t restart)
+{
+	return NtQueryDirectoryFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, (PVOID)info, len,
+				    cls, (BOOLEAN)single, (PVOID)name, (BOOLEAN)restart);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_device_io_control_file
+static intptr_t syz_nt_device_io_control_file(intptr_t handle, intptr_t iosb, intptr_t code,
+					      intptr_t in, intptr_t inlen, intptr_t out, intptr_t outlen)
+{
+	return NtDeviceIoControlFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, code,
+				     (PVOID)in, inlen, (PVOID)out, outlen);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_fs_control_file
+static intptr_t syz_nt_fs_control_file(intptr_t handle, intptr_t iosb, intptr_t code,
+				       intptr_t in, intptr_t inlen, intptr_t out, intptr_t outlen)
+{
+	return NtFsControlFile((HANDLE)handle, NULL, NULL, NULL, (PVOID)iosb, code,
+			       (PVOID)in, inlen, (PVOID)out, outlen);
+}
+#endif
+
+#if SYZ_EXECUTOR || __NR_syz_nt_map_view_of_section
+static intptr_t syz_nt_map_view_of_section(intptr_t section, intptr_t addr, intptr_t commit, intptr_t offset,
+					   intptr_t size, intptr_t inherit, intptr_t type, intptr_t protect)
+{
+	return NtMapViewOfSection((HANDLE)section, GetCurrentProcess(), (PVOID*)addr, 0, commit,
+				  (PLARGE_INTEGER)offset, (PSIZE_T)size, inherit, type, protect);
+}
+#endif
 
 #else
 #error "unknown OS"
@@ -5345,7 +6999,8 @@ static void loop(void)
 				executed_calls = now_executed;
 				last_executed = now;
 			}
-			if ((now - start < 5 * 1000) && (now - start < 3 * 1000 || now - last_executed < 1000))
+			uint64 scale = slowdown_scale;
+			if ((now - start < 5000 * scale) && (now - start < 3000 * scale || now - last_executed < 1000 * scale))
 				continue;
 #else
 			if (current_time_ms() - start < 5 * 1000)
@@ -0,0 +1,166 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// WriteKselftest is the same as Write, but wraps the program into a kselftest-compatible test
+// that can be put into tools/testing/selftests. The test prints TAP output, is skipped if any of
+// the /dev nodes used by the program are missing, runs the program in a child process for at most
+// timeout and fails if the kernel becomes tainted (e.g. oopses/warns) or the program dies with a signal.
+func WriteKselftest(p *prog.Prog, opts Options, name string, timeout time.Duration) ([]byte, error) {
+	if p.Target.OS != linux {
+		return nil, fmt.Errorf("kselftest output is supported only for linux")
+	}
+	if !kselftestNameRe.MatchString(name) {
+		return nil, fmt.Errorf("bad kselftest name %q", name)
+	}
+	if timeout < time.Second {
+		return nil, fmt.Errorf("kselftest timeout %v is too small", timeout)
+	}
+	src, err := Write(p, opts)
+	if err != nil {
+		return nil, err
+	}
+	const mainDecl = "\nint main(void)\n"
+	if bytes.Count(src, []byte(mainDecl)) != 1 {
+		return nil, fmt.Errorf("can't find main function in the generated program")
+	}
+	src = bytes.Replace(src, []byte(mainDecl), []byte("\nstatic int syz_repro_main(void)\n"), 1)
+	devs := new(bytes.Buffer)
+	for _, dev := range requiredDevices(p) {
+		fmt.Fprintf(devs, "\"%v\", ", dev)
+	}
+	wrapper := strings.NewReplacer(
+		"/*NAME*/", name,
+		"/*DEVICES*/", devs.String(),
+		"/*TIMEOUT*/", fmt.Sprint(int(timeout/time.Second)),
+	).Replace(kselftestMain)
+	src = append(src, wrapper...)
+	ctx := &context{target: p.Target}
+	return ctx.removeEmptyLines(ctx.hoistIncludes(src)), nil
+}
+
+var kselftestNameRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// requiredDevices returns glob patterns of /dev nodes opened by the program.
+func requiredDevices(p *prog.Prog) []string {
+	devs := make(map[string]bool)
+	for _, c := range p.Calls {
+		prog.ForeachArg(c, func(arg prog.Arg, _ *prog.ArgCtx) {
+			data, ok := arg.(*prog.DataArg)
+			if !ok || data.Type().Dir() == prog.DirOut {
+				return
+			}
+			typ, ok := data.Type().(*prog.BufferType)
+			if !ok || typ.Kind != prog.BufferString && typ.Kind != prog.BufferFilename {
+				return
+			}
+			dev := strings.TrimRight(string(data.Data()), "\x00")
+			if !strings.HasPrefix(dev, "/dev/") || strings.ContainsAny(dev, "\"\\*?[\x00") {
+				return
+			}
+			// syz_open_dev replaces # with the device number.
+			devs[strings.Replace(dev, "#", "*", -1)] = true
+		})
+	}
+	var res []string
+	for dev := range devs {
+		res = append(res, dev)
+	}
+	sort.Strings(res)
+	return res
+}
+
+const kselftestMain = `
+#include <glob.h>
+#include <signal.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#define KSFT_PASS 0
+#define KSFT_FAIL 1
+#define KSFT_SKIP 4
+
+static const char* ksft_devices[] = {/*DEVICES*/ NULL};
+
+static int ksft_device_exists(const char* pattern)
+{
+	glob_t res;
+	int exists = glob(pattern, 0, NULL, &res) == 0 && res.gl_pathc != 0;
+	globfree(&res);
+	return exists;
+}
+
+static unsigned long ksft_tainted(void)
+{
+	unsigned long tainted = 0;
+	FILE* f = fopen("/proc/sys/kernel/tainted", "r");
+	if (f) {
+		if (fscanf(f, "%lu", &tainted) != 1)
+			tainted = 0;
+		fclose(f);
+	}
+	return tainted;
+}
+
+int main(void)
+{
+	printf("TAP version 13\n1..1\n");
+	fflush(stdout);
+	for (int i = 0; ksft_devices[i]; i++) {
+		if (!ksft_device_exists(ksft_devices[i])) {
+			printf("ok 1 /*NAME*/ # SKIP %s is missing\n", ksft_devices[i]);
+			return KSFT_SKIP;
+		}
+	}
+	unsigned long tainted = ksft_tainted();
+	int pid = fork();
+	if (pid < 0) {
+		printf("not ok 1 /*NAME*/ # fork failed\n");
+		return KSFT_FAIL;
+	}
+	if (pid == 0) {
+		setpgid(0, 0);
+		syz_repro_main();
+		exit(0);
+	}
+	int status = 0, exited = 0;
+	for (int i = 0; i < /*TIMEOUT*/ * 10; i++) {
+		if (waitpid(pid, &status, WNOHANG) == pid) {
+			exited = 1;
+			break;
+		}
+		usleep(100 * 1000);
+	}
+	if (!exited) {
+		// Reproducers frequently run forever, reaching the timeout is not a failure.
+		printf("# /*TIMEOUT*/ seconds timeout expired, killing the reproducer\n");
+		kill(-pid, SIGKILL);
+		kill(pid, SIGKILL);
+		waitpid(pid, &status, 0);
+	}
+	if (ksft_tainted() != tainted) {
+		printf("not ok 1 /*NAME*/ # kernel is tainted (0x%lx), check dmesg\n", ksft_tainted());
+		return KSFT_FAIL;
+	}
+	if (exited && WIFSIGNALED(status)) {
+		printf("not ok 1 /*NAME*/ # killed by signal %d\n", WTERMSIG(status));
+		return KSFT_FAIL;
+	}
+	printf("ok 1 /*NAME*/\n");
+	return KSFT_PASS;
+}
+`
@@ -0,0 +1,58 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestKselftest(t *testing.T) {
+	if runtime.GOOS != linux {
+		t.Skip("linux-only test")
+	}
+	target, err := prog.GetTarget(linux, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, err := exec.LookPath(targets.Get(target.OS, target.Arch).CCompiler); err != nil {
+		t.Skip(err)
+	}
+	p, err := target.Deserialize([]byte(`
+getpid()
+syz_open_dev$loop(&(0x7f0000000000)='/dev/loop#\x00', 0x0, 0x0)
+openat(0xffffffffffffff9c, &(0x7f0000000040)='/dev/null\x00', 0x0, 0x0)
+`), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if devs, want := requiredDevices(p), []string{"/dev/loop*", "/dev/null"}; !reflect.DeepEqual(devs, want) {
+		t.Fatalf("bad required devices: %q, want %q", devs, want)
+	}
+	if _, err := WriteKselftest(p, Options{}, "bad name", time.Minute); err == nil {
+		t.Fatalf("bad test name is accepted")
+	}
+	src, err := WriteKselftest(p, Options{}, "syz_test", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := Build(target, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin)
+	out, _ := osutil.RunCmd(time.Minute, "", bin)
+	if !bytes.HasPrefix(out, []byte("TAP version 13\n1..1\n")) || !bytes.Contains(out, []byte("\nok 1 syz_test")) {
+		t.Fatalf("bad test output:\n%s", out)
+	}
+}
@@ -45,6 +45,12 @@ type Options struct {
 	// which allows to detect hangs.
 	Repro bool `json:"repro,omitempty"`
 	Trace bool `json:"trace,omitempty"`
+	// Print each call with its arguments and result to stderr (strace-style).
+	Strace bool `json:"strace,omitempty"`
+
+	// Generate code that is easier to read for humans:
+	// comments with program calls, libc wrappers instead of raw syscalls, named results.
+	Readable bool `json:"readable,omitempty"`
 }
 
 // Check checks if the opts combination is valid or not.
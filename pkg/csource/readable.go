@@ -0,0 +1,240 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// This file implements Options.Readable mode which makes generated programs easier
+// to understand for humans. The mode does not change program semantics:
+//  - every call is preceded by a comment with the corresponding syzkaller program call;
+//  - raw syscall() invocations are replaced with libc wrappers for common syscalls;
+//  - results are stored in variables named after resource types (e.g. fd_kvm, sock_nl_route)
+//    instead of the r[N] array;
+//  - unused macros and helper functions are removed.
+
+type libcWrapper struct {
+	include string
+	args    []string // C types of the arguments
+}
+
+// cast converts i-th argument value val to the type expected by the wrapper.
+func (wrapper *libcWrapper) cast(i int, val string) string {
+	if strings.ContainsAny(val, " +-/*") && val != "-1" {
+		val = "(" + val + ")"
+	}
+	return "(" + wrapper.args[i] + ")" + val
+}
+
+// libcWrappers lists linux syscalls that have glibc wrappers with exactly the same semantics.
+var libcWrappers = map[string]*libcWrapper{
+	"read":       {"<unistd.h>", []string{"int", "void*", "size_t"}},
+	"write":      {"<unistd.h>", []string{"int", "void*", "size_t"}},
+	"close":      {"<unistd.h>", []string{"int"}},
+	"dup":        {"<unistd.h>", []string{"int"}},
+	"dup2":       {"<unistd.h>", []string{"int", "int"}},
+	"dup3":       {"<unistd.h>", []string{"int", "int", "int"}},
+	"pipe2":      {"<unistd.h>", []string{"void*", "int"}},
+	"fsync":      {"<unistd.h>", []string{"int"}},
+	"chdir":      {"<unistd.h>", []string{"void*"}},
+	"unlink":     {"<unistd.h>", []string{"void*"}},
+	"open":       {"<fcntl.h>", []string{"void*", "int", "mode_t"}},
+	"openat":     {"<fcntl.h>", []string{"int", "void*", "int", "mode_t"}},
+	"mkdir":      {"<sys/stat.h>", []string{"void*", "mode_t"}},
+	"ioctl":      {"<sys/ioctl.h>", []string{"int", "unsigned long", "long"}},
+	"socket":     {"<sys/socket.h>", []string{"int", "int", "int"}},
+	"socketpair": {"<sys/socket.h>", []string{"int", "int", "int", "void*"}},
+	"bind":       {"<sys/socket.h>", []string{"int", "void*", "socklen_t"}},
+	"connect":    {"<sys/socket.h>", []string{"int", "void*", "socklen_t"}},
+	"listen":     {"<sys/socket.h>", []string{"int", "int"}},
+	"accept":     {"<sys/socket.h>", []string{"int", "void*", "void*"}},
+	"accept4":    {"<sys/socket.h>", []string{"int", "void*", "void*", "int"}},
+	"shutdown":   {"<sys/socket.h>", []string{"int", "int"}},
+	"sendmsg":    {"<sys/socket.h>", []string{"int", "void*", "int"}},
+	"recvmsg":    {"<sys/socket.h>", []string{"int", "void*", "int"}},
+	"sendto":     {"<sys/socket.h>", []string{"int", "void*", "size_t", "int", "void*", "socklen_t"}},
+	"recvfrom":   {"<sys/socket.h>", []string{"int", "void*", "size_t", "int", "void*", "void*"}},
+	"setsockopt": {"<sys/socket.h>", []string{"int", "int", "int", "void*", "socklen_t"}},
+	"getsockopt": {"<sys/socket.h>", []string{"int", "int", "int", "void*", "void*"}},
+}
+
+// libcWrapper returns libc wrapper that can be used instead of the raw syscall, or nil.
+// We use wrappers only on 64-bit linux, because on 32-bit arches glibc wrappers
+// can translate arguments (e.g. offsets and file flags) in non-obvious ways.
+func (ctx *context) libcWrapper(call prog.ExecCall, native bool) *libcWrapper {
+	if !ctx.opts.Readable || !native || ctx.target.OS != linux || ctx.target.PtrSize != 8 {
+		return nil
+	}
+	wrapper := libcWrappers[call.Meta.CallName]
+	if wrapper == nil || len(call.Args)+call.Meta.MissingArgs != len(wrapper.args) {
+		return nil
+	}
+	return wrapper
+}
+
+// annotateCalls prepends generated code for each call with the call comment
+// (prog.Call.Comment) and, in readable mode, with a comment containing
+// the corresponding call in the syzkaller program syntax.
+func (ctx *context) annotateCalls(p *prog.Prog, calls []string) []string {
+	var lines []string
+	if ctx.opts.Readable {
+		lines = strings.Split(string(p.Serialize()), "\n")
+	}
+	res := make([]string, len(calls))
+	for i, call := range calls {
+		res[i] = call
+		if i < len(lines) {
+			res[i] = cComment(lines[i]) + res[i]
+		}
+		if i < len(p.Calls) && p.Calls[i].Comment != "" {
+			res[i] = cComment(p.Calls[i].Comment) + res[i]
+		}
+	}
+	return res
+}
+
+func cComment(text string) string {
+	const maxLen = 120
+	if len(text) > maxLen {
+		text = text[:maxLen] + "..."
+	}
+	// Trailing backslash would continue the comment onto the next line.
+	if strings.HasSuffix(text, "\\") {
+		text += "..."
+	}
+	return fmt.Sprintf("\t// %v\n", text)
+}
+
+func (ctx *context) makeReadable(result []byte) []byte {
+	result = ctx.nameResults(result)
+	var includes []string
+	for include := range ctx.includes {
+		includes = append(includes, "#include "+include+"\n")
+	}
+	if pos := bytes.Index(result, []byte("#include")); len(includes) != 0 && pos != -1 {
+		// hoistIncludes will sort and dedup them.
+		result = append(result[:pos:pos], append([]byte(strings.Join(includes, "")), result[pos:]...)...)
+	}
+	result = removeUnusedMacros(result)
+	result = removeUnusedFunctions(result)
+	return result
+}
+
+var resultsRe = regexp.MustCompile(`uint64(_t)? r\[([0-9]+)\] = \{(.*)\};\n`)
+
+// nameResults replaces the r[N] results array with separate variables
+// named after the corresponding resource types.
+func (ctx *context) nameResults(result []byte) []byte {
+	match := resultsRe.FindSubmatchIndex(result)
+	if match == nil {
+		return result
+	}
+	values := strings.Split(string(result[match[6]:match[7]]), ", ")
+	names := resultNames(ctx.p)
+	if n, _ := strconv.Atoi(string(result[match[4]:match[5]])); len(names) != n || len(values) != n {
+		return result
+	}
+	used := make(map[string]bool)
+	decl := new(bytes.Buffer)
+	for i, name := range names {
+		for seq := 1; used[name] || regexp.MustCompile(`\b`+name+`\b`).Match(result); seq++ {
+			name = fmt.Sprintf("%v_%v", names[i], seq)
+		}
+		used[name] = true
+		names[i] = name
+		fmt.Fprintf(decl, "uint64%s %v = %v;\n", result[match[2]:match[3]], name, values[i])
+	}
+	newResult := append([]byte{}, result[:match[0]]...)
+	newResult = append(newResult, decl.Bytes()...)
+	newResult = append(newResult, result[match[1]:]...)
+	return regexp.MustCompile(`\br\[([0-9]+)\]`).ReplaceAllFunc(newResult, func(ref []byte) []byte {
+		idx, err := strconv.Atoi(string(ref[2 : len(ref)-1]))
+		if err != nil || idx >= len(names) {
+			return ref
+		}
+		return []byte(names[idx])
+	})
+}
+
+// resultNames returns names of resources for all results of the program
+// in the order they are assigned indexes in the exec encoding.
+func resultNames(p *prog.Prog) []string {
+	used := make(map[*prog.ResultArg]bool)
+	for _, c := range p.Calls {
+		prog.ForeachArg(c, func(arg prog.Arg, _ *prog.ArgCtx) {
+			if res, ok := arg.(*prog.ResultArg); ok && res.Res != nil {
+				used[res.Res] = true
+			}
+		})
+	}
+	var names []string
+	for _, c := range p.Calls {
+		if c.Ret != nil && used[c.Ret] {
+			names = append(names, resultName(c.Ret))
+		}
+		prog.ForeachArg(c, func(arg prog.Arg, _ *prog.ArgCtx) {
+			if res, ok := arg.(*prog.ResultArg); ok && res != c.Ret && used[res] {
+				names = append(names, resultName(res))
+			}
+		})
+	}
+	return names
+}
+
+var identRe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func resultName(res *prog.ResultArg) string {
+	name := identRe.ReplaceAllString(res.Type().Name(), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "res_" + name
+	}
+	return name
+}
+
+var macroRe = regexp.MustCompile(`(?m)^#define ([a-zA-Z][a-zA-Z0-9_]*)(\(.*?\))?(.*\\\n)*.*\n`)
+
+// removeUnusedMacros removes macros that are not referenced anywhere.
+// Macros starting with underscore (e.g. _GNU_SOURCE) are kept as they affect system headers.
+func removeUnusedMacros(result []byte) []byte {
+	for _, match := range macroRe.FindAllSubmatch(result, -1) {
+		name := string(match[1])
+		if countIdent(result, name) == 1 {
+			result = bytes.Replace(result, match[0], nil, 1)
+		}
+	}
+	return result
+}
+
+var funcRe = regexp.MustCompile(`(?m)^static [^;=\n{]*?\b([a-zA-Z_][a-zA-Z0-9_]*)\([^;{]*\)\n\{\n(?:.*\n)*?\}\n`)
+
+// removeUnusedFunctions removes static functions that are not referenced anywhere.
+// Removal of a function can make other functions unused, so we iterate until fixed point.
+func removeUnusedFunctions(result []byte) []byte {
+	for changed := true; changed; {
+		changed = false
+		for _, match := range funcRe.FindAllSubmatch(result, -1) {
+			name := string(match[1])
+			// Remove the definition along with forward declarations.
+			newResult := bytes.Replace(result, match[0], nil, 1)
+			newResult = regexp.MustCompile(`(?m)^static [^;=\n{]*?\b`+name+`\([^;{]*\);\n`).ReplaceAll(newResult, nil)
+			if countIdent(newResult, name) != 0 {
+				continue
+			}
+			result = newResult
+			changed = true
+		}
+	}
+	return result
+}
+
+func countIdent(data []byte, name string) int {
+	return len(regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\b`).FindAllIndex(data, -1))
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+func TestReadable(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+r0 = openat$kvm(0xffffffffffffff9c, &(0x7f0000000000)='/dev/kvm\x00', 0x0, 0x0)
+r1 = ioctl$KVM_CREATE_VM(r0, 0xae01, 0x0)
+r2 = openat$kvm(0xffffffffffffff9c, &(0x7f0000000000)='/dev/kvm\x00', 0x0, 0x0)
+close(r1)
+close(r2)
+`), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{
+		Procs:    1,
+		Sandbox:  "none",
+		Readable: true,
+	}
+	src, err := Write(p, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// r0 = openat$kvm(0xffffffffffffff9c",
+		"// close(r1)\n",
+		"uint64_t fd_kvm = 0xffffffffffffffff;\n",
+		"uint64_t fd_kvm_1 = 0xffffffffffffffff;\n",
+		"uint64_t fd_kvmvm = 0xffffffffffffffff;\n",
+		"res = openat((int)0xffffffffffffff9c, (void*)0x20000000, (int)0, (mode_t)0);",
+		"res = ioctl((int)fd_kvm, (unsigned long)0xae01, (long)0);",
+		"close((int)fd_kvmvm);",
+		"close((int)fd_kvm_1);",
+		"#include <sys/ioctl.h>\n",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("no %q in the program:\n%s", want, src)
+		}
+	}
+	for _, bad := range []string{"r[", "syscall(__NR_close"} {
+		if strings.Contains(string(src), bad) {
+			t.Errorf("%q in the program:\n%s", bad, src)
+		}
+	}
+}
+
+func TestCallComments(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+# setup
+r0 = openat$kvm(0xffffffffffffff9c, &(0x7f0000000000)='/dev/kvm\x00', 0x0, 0x0)
+# triggers the crash
+close(r0)
+`), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Write(p, Options{Procs: 1, Sandbox: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := strings.Index(string(src), "// setup\n")
+	trigger := strings.Index(string(src), "// triggers the crash\n")
+	if setup == -1 || trigger == -1 || setup > trigger {
+		t.Fatalf("bad call comments in the program:\n%s", src)
+	}
+	if !strings.Contains(string(src[trigger:]), "__NR_close") {
+		t.Fatalf("trigger comment is not before the close call:\n%s", src)
+	}
+}
+
+func TestRemoveUnused(t *testing.T) {
+	src := `
+#define _GNU_SOURCE
+#define USED 1
+#define UNUSED 2
+#define UNUSED_MULTI(x) \
+	do { \
+	} while (0)
+
+static void unused2(void);
+
+static void unused1(void)
+{
+	unused2();
+}
+
+static void unused2(void)
+{
+	if (USED) {
+	}
+}
+
+static int used(int x)
+{
+	return x;
+}
+
+int main(void)
+{
+	return used(0);
+}
+`
+	want := `
+#define _GNU_SOURCE
+#define USED 1
+
+static int used(int x)
+{
+	return x;
+}
+
+int main(void)
+{
+	return used(0);
+}
+`
+	ctx := &context{}
+	got := string(ctx.removeEmptyLines(removeUnusedFunctions(removeUnusedMacros([]byte(src)))))
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// WriteXfstests converts the program into an xfstests regression test. It returns files keyed
+// by path relative to the xfstests tree: the C program (src/name.c), the test script
+// (tests/generic/name) and the golden output (tests/generic/name.out). The test runs the program
+// in TEST_DIR for at most timeout; kernel oopses/warnings are detected by the xfstests dmesg check.
+// Note: xfstests tests are numbered, the test should be renamed with the ./new script,
+// and the program needs to be added to src/Makefile.
+func WriteXfstests(p *prog.Prog, opts Options, name string, timeout time.Duration) (map[string][]byte, error) {
+	if p.Target.OS != linux {
+		return nil, fmt.Errorf("xfstests output is supported only for linux")
+	}
+	if !kselftestNameRe.MatchString(name) {
+		return nil, fmt.Errorf("bad xfstests test name %q", name)
+	}
+	if timeout < time.Second {
+		return nil, fmt.Errorf("xfstests timeout %v is too small", timeout)
+	}
+	src, err := Write(p, opts)
+	if err != nil {
+		return nil, err
+	}
+	var requires []string
+	for _, dev := range requiredDevices(p) {
+		if strings.HasPrefix(dev, "/dev/loop") {
+			requires = append(requires, "_require_loop")
+			break
+		}
+	}
+	script := strings.NewReplacer(
+		"/*NAME*/", name,
+		"/*REQUIRES*/", strings.Join(append(requires, ""), "\n"),
+		"/*TIMEOUT*/", fmt.Sprint(int(timeout/time.Second)),
+	).Replace(xfstestsScript)
+	files := map[string][]byte{
+		"src/" + name + ".c":             src,
+		"tests/generic/" + name:          []byte(script),
+		"tests/generic/" + name + ".out": []byte("QA output created by " + name + "\nSilence is golden\n"),
+	}
+	return files, nil
+}
+
+const xfstestsScript = `#! /bin/bash
+# SPDX-License-Identifier: GPL-2.0
+#
+# FS QA Test No. /*NAME*/
+#
+# Regression test for a kernel bug found by syzkaller.
+# The test runs the reproducer for /*TIMEOUT*/ seconds,
+# the kernel must not crash or print warnings.
+#
+seq=` + "`basename $0`" + `
+seqres=$RESULT_DIR/$seq
+echo "QA output created by $seq"
+
+here=` + "`pwd`" + `
+tmp=/tmp/$$
+status=1	# failure is the default!
+trap "_cleanup; exit \$status" 0 1 2 3 15
+
+_cleanup()
+{
+	cd /
+	rm -f $tmp.*
+}
+
+# get standard environment, filters and checks
+. ./common/rc
+. ./common/filter
+
+# real QA test starts here
+_supported_fs generic
+_supported_os Linux
+_require_test
+_require_test_program "/*NAME*/"
+_require_command "$TIMEOUT_PROG" timeout
+/*REQUIRES*/
+rm -rf $TEST_DIR/$seq
+mkdir $TEST_DIR/$seq
+cd $TEST_DIR/$seq
+# Reproducers frequently run forever, so reaching the timeout is not a failure.
+$TIMEOUT_PROG -s KILL /*TIMEOUT*/ $here/src//*NAME*/ >> $seqres.full 2>&1
+cd /
+rm -rf $TEST_DIR/$seq
+
+echo "Silence is golden"
+status=0
+exit
+`
@@ -0,0 +1,64 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestXfstests(t *testing.T) {
+	if runtime.GOOS != linux {
+		t.Skip("linux-only test")
+	}
+	target, err := prog.GetTarget(linux, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, err := exec.LookPath(targets.Get(target.OS, target.Arch).CCompiler); err != nil {
+		t.Skip(err)
+	}
+	p, err := target.Deserialize([]byte(`
+syz_open_dev$loop(&(0x7f0000000000)='/dev/loop#\x00', 0x0, 0x0)
+mkdirat(0xffffffffffffff9c, &(0x7f0000000040)='./file0\x00', 0x0)
+`), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteXfstests(p, Options{}, "../bad", time.Minute); err == nil {
+		t.Fatalf("bad test name is accepted")
+	}
+	files, err := WriteXfstests(p, Options{}, "syz_test", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %v files, want 3", len(files))
+	}
+	script := files["tests/generic/syz_test"]
+	for _, want := range []string{
+		`_require_test_program "syz_test"`,
+		"_require_loop\n",
+		"$TIMEOUT_PROG -s KILL 10 $here/src/syz_test >>",
+	} {
+		if !bytes.Contains(script, []byte(want)) {
+			t.Errorf("test script does not contain %q:\n%s", want, script)
+		}
+	}
+	if out := string(files["tests/generic/syz_test.out"]); out != "QA output created by syz_test\nSilence is golden\n" {
+		t.Errorf("bad golden output: %q", out)
+	}
+	bin, err := Build(target, files["src/syz_test.c"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(bin)
+}
@@ -0,0 +1,201 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+// Serialize returns contents of a database file with the given records
+// (keys are recalculated as value hashes, same as Create does).
+func Serialize(version uint64, records []Record) []byte {
+	buf := new(bytes.Buffer)
+	serializeHeader(buf, version)
+	for _, rec := range records {
+		serializeRecord(buf, hash.String(rec.Val), rec.Val, rec.Seq)
+	}
+	return buf.Bytes()
+}
+
+// Deserialize parses contents of a database file.
+// Unlike Open it fails on any corruption.
+func Deserialize(data []byte) (uint64, map[string]Record, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	version, err := deserializeHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	records := make(map[string]Record)
+	for {
+		key, val, seq, err := deserializeRecord(r)
+		if err == io.EOF {
+			return version, records, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if seq == seqDeleted {
+			delete(records, key)
+		} else {
+			records[key] = Record{val, seq}
+		}
+	}
+}
+
+// IsDB returns true if data looks like contents of a database file.
+func IsDB(data []byte) bool {
+	return len(data) >= 4 && binary.LittleEndian.Uint32(data) == dbMagic
+}
+
+// WriteTarGz writes a gzipped tarball with one file per record into w.
+// Files are named as key+ext (e.g. "hash.syz"). Records are written sorted by key.
+func WriteTarGz(w io.Writer, records map[string]Record, ext string) error {
+	files := make(map[string][]byte)
+	for key, rec := range records {
+		files[key+ext] = rec.Val
+	}
+	return WriteTarGzFiles(w, files)
+}
+
+// WriteTarGzFiles writes a gzipped tarball with the files into w. Files are written sorted by name.
+func WriteTarGzFiles(w io.Writer, files map[string][]byte) error {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range names {
+		val := files[name]
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(val)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(val); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadTarGzFiles returns all regular files from a gzipped tarball keyed by base name.
+// The decompressed tarball and each file must not be larger than limit bytes.
+func ReadTarGzFiles(data []byte, limit int64) (map[string][]byte, error) {
+	data, err := decompress(data, limit)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		val, err := readAllLimited(tr, limit)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Base(hdr.Name)] = val
+	}
+}
+
+// ReadArchive extracts values from data that can be a tarball (optionally gzipped)
+// with one value per regular file, or contents of a database file.
+// Any other data is considered to be a single value.
+// Decompressed data and each tarball file must not be larger than limit bytes.
+func ReadArchive(data []byte, limit int64) ([][]byte, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		var err error
+		if data, err = decompress(data, limit); err != nil {
+			return nil, err
+		}
+	}
+	if IsDB(data) {
+		_, records, err := Deserialize(data)
+		if err != nil {
+			return nil, err
+		}
+		var res [][]byte
+		for _, rec := range records {
+			res = append(res, rec.Val)
+		}
+		return res, nil
+	}
+	if len(data) >= 262 && bytes.Equal(data[257:262], []byte("ustar")) {
+		var res [][]byte
+		tr := tar.NewReader(bytes.NewReader(data))
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return res, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !hdr.FileInfo().Mode().IsRegular() {
+				continue
+			}
+			val, err := readAllLimited(tr, limit)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, val)
+		}
+	}
+	return [][]byte{data}, nil
+}
+
+func decompress(data []byte, limit int64) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	data, err = readAllLimited(gz, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %v", err)
+	}
+	return data, nil
+}
+
+// readAllLimited reads r until EOF, but fails if there are more than limit bytes
+// (e.g. a small gzip bomb in a request that decompresses into gigabytes).
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("data is larger than %v bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+func TestArchive(t *testing.T) {
+	vals := [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")}
+	var records []Record
+	recordMap := make(map[string]Record)
+	for i, val := range vals {
+		rec := Record{Val: val, Seq: uint64(i)}
+		records = append(records, rec)
+		recordMap[hash.String(val)] = rec
+	}
+	data := Serialize(42, records)
+	if !IsDB(data) {
+		t.Fatalf("serialized db is not recognized")
+	}
+	version, got, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 42 || !reflect.DeepEqual(got, recordMap) {
+		t.Fatalf("bad deserialized db: version %v, records %+v", version, got)
+	}
+	if _, _, err := Deserialize(data[:len(data)-1]); err == nil {
+		t.Fatalf("truncated db deserialized successfully")
+	}
+	tarball := new(bytes.Buffer)
+	if err := WriteTarGz(tarball, recordMap, ".syz"); err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range [][]byte{data, tarball.Bytes()} {
+		res, err := ReadArchive(input, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			return len(res[i]) < len(res[j])
+		})
+		if !reflect.DeepEqual(res, vals) {
+			t.Fatalf("bad archive values: %q", res)
+		}
+	}
+	res, err := ReadArchive([]byte("foo()\n"), 1<<20)
+	if err != nil || len(res) != 1 || string(res[0]) != "foo()\n" {
+		t.Fatalf("bad plain value: %q, %v", res, err)
+	}
+}
+
+func TestArchiveLimit(t *testing.T) {
+	const limit = 1 << 10
+	big := bytes.Repeat([]byte{'a'}, limit+1)
+	bomb := new(bytes.Buffer)
+	gz := gzip.NewWriter(bomb)
+	gz.Write(big)
+	gz.Close()
+	if _, err := ReadArchive(bomb.Bytes(), limit); err == nil {
+		t.Fatalf("oversized gzip data is read successfully")
+	}
+	if res, err := ReadArchive(bomb.Bytes(), limit+1); err != nil || len(res) != 1 || len(res[0]) != limit+1 {
+		t.Fatalf("failed to read gzip data within the limit: %v", err)
+	}
+	tarball := new(bytes.Buffer)
+	if err := WriteTarGzFiles(tarball, map[string][]byte{"big": big}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTarGzFiles(tarball.Bytes(), limit); err == nil {
+		t.Fatalf("oversized tarball is read successfully")
+	}
+	if _, err := ReadArchive(tarball.Bytes(), limit); err == nil {
+		t.Fatalf("oversized tarball is read successfully")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+// ManifestFile is the name of the manifest file in corpus exports.
+const ManifestFile = "manifest.json"
+
+// Manifest describes a corpus export: a set of files (one per record, named by the record key)
+// accompanied with the manifest file. It allows to validate the export on import
+// and to audit what exactly was exported.
+type Manifest struct {
+	Version  uint64           `json:"version"`            // database version
+	Target   string           `json:"target,omitempty"`   // OS/arch the programs were validated for
+	Revision string           `json:"revision,omitempty"` // syzkaller revision that made the export
+	Created  time.Time        `json:"created"`
+	Records  []ManifestRecord `json:"records"`
+}
+
+type ManifestRecord struct {
+	Hash string `json:"hash"`
+	Seq  uint64 `json:"seq"`
+	Size int    `json:"size"`
+}
+
+// Export returns files of a corpus export for the records (including the manifest).
+func Export(version uint64, records map[string]Record, target, revision string) (map[string][]byte, error) {
+	manifest := &Manifest{
+		Version:  version,
+		Target:   target,
+		Revision: revision,
+		Created:  time.Now().UTC(),
+	}
+	files := make(map[string][]byte)
+	for _, rec := range records {
+		// Keys are recalculated as value hashes, same as Create does.
+		sig := hash.String(rec.Val)
+		if _, ok := files[sig]; ok {
+			continue
+		}
+		files[sig] = rec.Val
+		manifest.Records = append(manifest.Records, ManifestRecord{
+			Hash: sig,
+			Seq:  rec.Seq,
+			Size: len(rec.Val),
+		})
+	}
+	sort.Slice(manifest.Records, func(i, j int) bool {
+		return manifest.Records[i].Hash < manifest.Records[j].Hash
+	})
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	files[ManifestFile] = append(data, '\n')
+	return files, nil
+}
+
+// Import validates files of a corpus export against its manifest and returns the records.
+// All files listed in the manifest must be present and match their hashes,
+// and there must be no files that are not listed in the manifest.
+func Import(files map[string][]byte) (*Manifest, []Record, error) {
+	data, ok := files[ManifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("no %v in the export", ManifestFile)
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %v: %v", ManifestFile, err)
+	}
+	listed := make(map[string]bool)
+	var records []Record
+	for _, mrec := range manifest.Records {
+		if listed[mrec.Hash] {
+			return nil, nil, fmt.Errorf("file %v is listed in the manifest twice", mrec.Hash)
+		}
+		listed[mrec.Hash] = true
+		val, ok := files[mrec.Hash]
+		if !ok {
+			return nil, nil, fmt.Errorf("file %v is missing", mrec.Hash)
+		}
+		if len(val) != mrec.Size {
+			return nil, nil, fmt.Errorf("file %v has size %v, manifest says %v", mrec.Hash, len(val), mrec.Size)
+		}
+		if sig := hash.String(val); sig != mrec.Hash {
+			return nil, nil, fmt.Errorf("file %v has hash %v", mrec.Hash, sig)
+		}
+		records = append(records, Record{Val: val, Seq: mrec.Seq})
+	}
+	for name := range files {
+		if name != ManifestFile && !listed[name] {
+			return nil, nil, fmt.Errorf("file %v is not listed in the manifest", name)
+		}
+	}
+	return manifest, records, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+func TestManifest(t *testing.T) {
+	records := make(map[string]Record)
+	for i, val := range []string{"a", "bb", "ccc"} {
+		records[hash.String([]byte(val))] = Record{Val: []byte(val), Seq: uint64(i)}
+	}
+	files, err := Export(3, records, "linux/amd64", "rev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(records)+1 {
+		t.Fatalf("got %v files, want %v", len(files), len(records)+1)
+	}
+	tarball := new(bytes.Buffer)
+	if err := WriteTarGzFiles(tarball, files); err != nil {
+		t.Fatal(err)
+	}
+	files1, err := ReadTarGzFiles(tarball.Bytes(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, files1) {
+		t.Fatalf("tarball files differ")
+	}
+	manifest, got, err := Import(files1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Version != 3 || manifest.Target != "linux/amd64" || manifest.Revision != "rev" {
+		t.Fatalf("bad manifest: %+v", manifest)
+	}
+	var want []Record
+	for _, rec := range records {
+		want = append(want, rec)
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i].Seq < want[j].Seq })
+	sort.Slice(got, func(i, j int) bool { return got[i].Seq < got[j].Seq })
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad imported records: %+v", got)
+	}
+
+	corrupt := func(name string, f func(files map[string][]byte)) {
+		res := make(map[string][]byte)
+		for k, v := range files {
+			res[k] = v
+		}
+		f(res)
+		if _, _, err := Import(res); err == nil {
+			t.Errorf("%v: import succeeded", name)
+		}
+	}
+	key := hash.String([]byte("bb"))
+	corrupt("missing", func(files map[string][]byte) { delete(files, key) })
+	corrupt("modified", func(files map[string][]byte) { files[key] = []byte("xx") })
+	corrupt("extra", func(files map[string][]byte) { files["foo"] = []byte("foo") })
+	corrupt("no manifest", func(files map[string][]byte) { delete(files, ManifestFile) })
+}
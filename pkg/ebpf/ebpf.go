@@ -0,0 +1,251 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package ebpf allows to generate and mutate eBPF programs.
+// Programs are built from small self-contained snippets that respect the verifier rules
+// (registers and stack are initialized before use, pointers are null-checked,
+// helper arguments match helper prototypes, jumps go forward and stay within the program),
+// so most of generated programs pass verification and reach the interpreter/JIT.
+// Maps are expected to be loaded into R6 and R7 before the generated text (see Config.Maps).
+package ebpf
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+type Config struct {
+	Len  int // number of snippets in a generated program
+	Maps int // number of map pointers preloaded into R6, R7
+}
+
+const (
+	InsnSize = 8
+	MaxMaps  = 2
+
+	RegMap0 = 6 // first map pointer
+	RegFP   = 10
+
+	StackSize = 512
+)
+
+// Instruction classes, sizes, modes and opcodes (include/uapi/linux/bpf.h).
+const (
+	classLD    = 0x00
+	classLDX   = 0x01
+	classST    = 0x02
+	classSTX   = 0x03
+	classALU   = 0x04
+	classJMP   = 0x05
+	classJMP32 = 0x06
+	classALU64 = 0x07
+
+	sizeW  = 0x00
+	sizeH  = 0x08
+	sizeB  = 0x10
+	sizeDW = 0x18
+
+	modeIMM  = 0x00
+	modeMEM  = 0x60
+	modeXADD = 0xc0
+
+	srcK = 0x00
+	srcX = 0x08
+
+	aluADD  = 0x00
+	aluSUB  = 0x10
+	aluMUL  = 0x20
+	aluDIV  = 0x30
+	aluOR   = 0x40
+	aluAND  = 0x50
+	aluLSH  = 0x60
+	aluRSH  = 0x70
+	aluNEG  = 0x80
+	aluMOD  = 0x90
+	aluXOR  = 0xa0
+	aluMOV  = 0xb0
+	aluARSH = 0xc0
+
+	jmpJA   = 0x00
+	jmpJEQ  = 0x10
+	jmpJGT  = 0x20
+	jmpJGE  = 0x30
+	jmpJSET = 0x40
+	jmpJNE  = 0x50
+	jmpJSGT = 0x60
+	jmpJSGE = 0x70
+	jmpCALL = 0x80
+	jmpEXIT = 0x90
+	jmpJLT  = 0xa0
+	jmpJLE  = 0xb0
+	jmpJSLT = 0xc0
+	jmpJSLE = 0xd0
+
+	codeLdImm64 = classLD | sizeDW | modeIMM
+)
+
+// insn is a decoded instruction. 16-byte ld_imm64 is represented as a single insn.
+// Jump targets are kept as instruction indexes, so that instructions can be freely
+// inserted and removed, offsets are recalculated during encoding.
+type insn struct {
+	code   uint8
+	dst    uint8
+	src    uint8
+	off    int16
+	imm    int64
+	target int // index of the jump target
+}
+
+func (in *insn) class() uint8 {
+	return in.code & 0x07
+}
+
+func (in *insn) op() uint8 {
+	return in.code & 0xf0
+}
+
+func (in *insn) isLdImm64() bool {
+	return in.code == codeLdImm64
+}
+
+func (in *insn) isCondJump() bool {
+	cls, op := in.class(), in.op()
+	return (cls == classJMP || cls == classJMP32) && op != jmpJA && op != jmpCALL && op != jmpEXIT
+}
+
+func (in *insn) isJump() bool {
+	return in.isCondJump() || in.class() == classJMP && in.op() == jmpJA
+}
+
+func (in *insn) slots() int {
+	if in.isLdImm64() {
+		return 2
+	}
+	return 1
+}
+
+// Generate generates a program with cfg.Len snippets.
+func Generate(cfg *Config, r *rand.Rand) []byte {
+	g := newGen(cfg, r)
+	for i := 0; i < cfg.Len; i++ {
+		g.snippet(0)
+	}
+	g.exit()
+	return encode(g.insns)
+}
+
+// Mutate mutates the program keeping it verifiable: snippets are inserted and removed
+// only at positions where no registers are live and no jumps cross, operands are
+// changed only where any value is accepted by the verifier.
+// Text that does not decode to a well-formed program is replaced with a new program.
+func Mutate(cfg *Config, r *rand.Rand, text []byte) []byte {
+	insns := decode(text)
+	if insns == nil || r.Intn(20) == 0 {
+		return Generate(cfg, r)
+	}
+	for stop := false; !stop; stop = r.Intn(2) == 0 {
+		switch x := r.Intn(100); {
+		case x < 30:
+			// insert a new snippet
+			bounds := boundaries(insns)
+			if len(bounds) == 0 {
+				continue
+			}
+			pos := bounds[r.Intn(len(bounds))]
+			g := newGen(cfg, r)
+			g.snippet(0)
+			insns = insertInsns(insns, pos, g.insns)
+		case x < 45:
+			// remove snippets
+			bounds := boundaries(insns)
+			if len(bounds) < 2 {
+				continue
+			}
+			i := r.Intn(len(bounds) - 1)
+			j := i + 1 + r.Intn(len(bounds)-i-1)
+			insns = removeInsns(insns, bounds[i], bounds[j])
+		default:
+			// mutate operands
+			mutateOperand(r, insns, r.Intn(len(insns)))
+		}
+	}
+	return encode(insns)
+}
+
+func encode(insns []insn) []byte {
+	pos := make([]int, len(insns)+1)
+	for i := range insns {
+		pos[i+1] = pos[i] + insns[i].slots()
+	}
+	text := make([]byte, 0, pos[len(insns)]*InsnSize)
+	for i := range insns {
+		in := &insns[i]
+		off := in.off
+		if in.isJump() {
+			off = int16(pos[in.target] - pos[i+1])
+		}
+		text = appendSlot(text, in.code, in.dst, in.src, off, int32(in.imm))
+		if in.isLdImm64() {
+			text = appendSlot(text, 0, 0, 0, 0, int32(in.imm>>32))
+		}
+	}
+	return text
+}
+
+func appendSlot(text []byte, code, dst, src uint8, off int16, imm int32) []byte {
+	var buf [InsnSize]byte
+	buf[0] = code
+	buf[1] = dst&0xf | src<<4
+	binary.LittleEndian.PutUint16(buf[2:], uint16(off))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(imm))
+	return append(text, buf[:]...)
+}
+
+// decode returns nil if the text is not a well-formed program
+// (partial instructions, jumps outside of the program or backwards, missing exit).
+func decode(text []byte) []insn {
+	if len(text) == 0 || len(text)%InsnSize != 0 {
+		return nil
+	}
+	var insns []insn
+	var slotIdx []int // slot -> instruction index (-1 for second half of ld_imm64)
+	for len(text) != 0 {
+		in := insn{
+			code: text[0],
+			dst:  text[1] & 0xf,
+			src:  text[1] >> 4,
+			off:  int16(binary.LittleEndian.Uint16(text[2:])),
+			imm:  int64(int32(binary.LittleEndian.Uint32(text[4:]))),
+		}
+		text = text[InsnSize:]
+		slotIdx = append(slotIdx, len(insns))
+		if in.isLdImm64() {
+			if len(text) == 0 {
+				return nil
+			}
+			in.imm = int64(uint32(in.imm)) | int64(binary.LittleEndian.Uint32(text[4:]))<<32
+			text = text[InsnSize:]
+			slotIdx = append(slotIdx, -1)
+		}
+		insns = append(insns, in)
+	}
+	slotIdx = append(slotIdx, len(insns))
+	slot := 0
+	for i := range insns {
+		in := &insns[i]
+		slot += in.slots()
+		if !in.isJump() {
+			continue
+		}
+		target := slot + int(in.off)
+		if in.off < 0 || target >= len(slotIdx) || slotIdx[target] < 0 || slotIdx[target] == len(insns) {
+			return nil
+		}
+		in.target = slotIdx[target]
+	}
+	last := insns[len(insns)-1]
+	if last.class() != classJMP || last.op() != jmpEXIT {
+		return nil
+	}
+	return insns
+}
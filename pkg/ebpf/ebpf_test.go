@@ -0,0 +1,268 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ebpf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func initTest(t *testing.T) *rand.Rand {
+	seed := time.Now().UnixNano()
+	t.Logf("seed=%v", seed)
+	return rand.New(rand.NewSource(seed))
+}
+
+func TestGenerate(t *testing.T) {
+	r := initTest(t)
+	for i := 0; i < 1000; i++ {
+		cfg := &Config{Len: 1 + r.Intn(10), Maps: r.Intn(MaxMaps + 1)}
+		text := Generate(cfg, r)
+		insns := decode(text)
+		if insns == nil {
+			t.Fatalf("failed to decode generated program:\n%v", hex.Dump(text))
+		}
+		if !bytes.Equal(encode(insns), text) {
+			t.Fatalf("program changed after decode/encode:\n%v", hex.Dump(text))
+		}
+		if err := verify(cfg, insns); err != nil {
+			t.Fatalf("generated program does not verify: %v\n%v", err, dump(insns))
+		}
+	}
+}
+
+func TestMutate(t *testing.T) {
+	r := initTest(t)
+	for i := 0; i < 300; i++ {
+		cfg := &Config{Len: 1 + r.Intn(10), Maps: r.Intn(MaxMaps + 1)}
+		text := Generate(cfg, r)
+		for j := 0; j < 20; j++ {
+			text1 := Mutate(cfg, r, text)
+			insns := decode(text1)
+			if insns == nil {
+				t.Fatalf("failed to decode mutated program:\n%v", hex.Dump(text1))
+			}
+			if err := verify(cfg, insns); err != nil {
+				t.Fatalf("mutated program does not verify: %v\nbefore:\n%v\nafter:\n%v",
+					err, dump(decode(text)), dump(insns))
+			}
+			text = text1
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	r := initTest(t)
+	for i := 0; i < 1000; i++ {
+		text := make([]byte, r.Intn(10)*InsnSize)
+		r.Read(text)
+		if insns := decode(text); insns != nil {
+			// Random programs may decode, but must encode back to the same bytes.
+			if !bytes.Equal(encode(insns), text) {
+				t.Fatalf("program changed after decode/encode:\n%v", hex.Dump(text))
+			}
+		}
+		// Mutate must not crash on arbitrary data.
+		Mutate(&Config{Len: 3, Maps: 1}, r, text)
+	}
+}
+
+func dump(insns []insn) string {
+	buf := new(bytes.Buffer)
+	for i, in := range insns {
+		fmt.Fprintf(buf, "%3v: code=0x%02x dst=r%v src=r%v off=%v imm=%v target=%v\n",
+			i, in.code, in.dst, in.src, in.off, in.imm, in.target)
+	}
+	return buf.String()
+}
+
+// verify is a simplified version of the kernel verifier that checks rules the generator
+// is supposed to follow. It walks all paths, which is fine for forward-only jumps
+// in small programs.
+func verify(cfg *Config, insns []insn) error {
+	type reg struct {
+		kind regKind
+		off  int // for regStack
+	}
+	type state struct {
+		pc    int
+		regs  [RegFP + 1]reg
+		stack [StackSize]bool
+	}
+	const regMap = regMapValue + 1
+	var init state
+	init.regs[RegFP] = reg{kind: regStack}
+	for i := 0; i < cfg.Maps; i++ {
+		init.regs[RegMap0+i] = reg{kind: regMap}
+	}
+	visited := make(map[string]bool)
+	queue := []state{init}
+	for len(queue) != 0 {
+		st := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		key := fmt.Sprintf("%v", st)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		for {
+			if st.pc >= len(insns) {
+				return fmt.Errorf("fell off the program")
+			}
+			in := &insns[st.pc]
+			use, def := in.regs()
+			for r := 0; r <= RegFP; r++ {
+				if use&(1<<uint(r)) != 0 && st.regs[r].kind == regNone {
+					return fmt.Errorf("insn %v: read of uninit r%v", st.pc, r)
+				}
+				if def&(1<<uint(r)) != 0 && scratchMask&(1<<uint(r)) == 0 {
+					return fmt.Errorf("insn %v: write to r%v", st.pc, r)
+				}
+			}
+			access := func(ptr uint8, off int16, size int, read bool) error {
+				p := st.regs[ptr]
+				switch p.kind {
+				case regStack:
+					start := StackSize + p.off + int(off)
+					if start < 0 || start+size > StackSize || start%size != 0 {
+						return fmt.Errorf("insn %v: bad stack access %v/%v", st.pc, p.off+int(off), size)
+					}
+					for i := start; i < start+size; i++ {
+						if read && !st.stack[i] {
+							return fmt.Errorf("insn %v: read of uninit stack %v", st.pc, i-StackSize)
+						}
+						st.stack[i] = true
+					}
+				case regMapValue:
+					if off != 0 {
+						return fmt.Errorf("insn %v: map value access at offset %v", st.pc, off)
+					}
+				default:
+					return fmt.Errorf("insn %v: memory access via r%v of kind %v", st.pc, ptr, p.kind)
+				}
+				return nil
+			}
+			scalar := func(r uint8) error {
+				if st.regs[r].kind != regScalar {
+					return fmt.Errorf("insn %v: r%v is not a scalar (%v)", st.pc, r, st.regs[r].kind)
+				}
+				return nil
+			}
+			size := sizeBytes(in.code & 0x18)
+			switch cls := in.class(); {
+			case in.isLdImm64():
+				st.regs[in.dst] = reg{kind: regScalar}
+			case cls == classALU || cls == classALU64:
+				if in.code == classALU64|aluMOV|srcX {
+					st.regs[in.dst] = st.regs[in.src]
+					break
+				}
+				if in.code == classALU64|aluADD|srcK && st.regs[in.dst].kind == regStack {
+					st.regs[in.dst].off += int(in.imm)
+					break
+				}
+				if in.op() != aluMOV && in.op() != aluNEG {
+					if err := scalar(in.dst); err != nil {
+						return err
+					}
+				}
+				if in.code&srcX != 0 {
+					if err := scalar(in.src); err != nil {
+						return err
+					}
+				} else if (in.op() == aluDIV || in.op() == aluMOD) && in.imm == 0 {
+					return fmt.Errorf("insn %v: division by zero", st.pc)
+				}
+				st.regs[in.dst] = reg{kind: regScalar}
+			case cls == classLDX:
+				if err := access(in.src, in.off, size, true); err != nil {
+					return err
+				}
+				st.regs[in.dst] = reg{kind: regScalar}
+			case cls == classST:
+				if err := access(in.dst, in.off, size, false); err != nil {
+					return err
+				}
+			case cls == classSTX:
+				if err := scalar(in.src); err != nil {
+					return err
+				}
+				if err := access(in.dst, in.off, size, in.code&0xe0 == modeXADD); err != nil {
+					return err
+				}
+			case cls == classJMP && in.op() == jmpEXIT:
+				if err := scalar(0); err != nil {
+					return err
+				}
+			case cls == classJMP && in.op() == jmpCALL:
+				h := helperByID[int32(in.imm)]
+				if h == nil {
+					return fmt.Errorf("insn %v: unknown helper %v", st.pc, in.imm)
+				}
+				for i, arg := range h.args {
+					r := st.regs[i+1]
+					switch arg {
+					case argMap:
+						if r.kind != regMap {
+							return fmt.Errorf("insn %v: %v arg %v is not a map", st.pc, h.name, i)
+						}
+					case argMapKey, argMapValue, argMem, argUninitValue:
+						if r.kind != regStack {
+							return fmt.Errorf("insn %v: %v arg %v is not a stack pointer", st.pc, h.name, i)
+						}
+						if err := access(uint8(i+1), 0, argAreaSize, arg != argUninitValue); err != nil {
+							return err
+						}
+					default:
+						if err := scalar(uint8(i + 1)); err != nil {
+							return err
+						}
+					}
+				}
+				for i := 0; i <= 5; i++ {
+					st.regs[i] = reg{}
+				}
+				st.regs[0] = reg{kind: regScalar}
+				if h.ret == retMapValueOrNull {
+					st.regs[0] = reg{kind: regMapValueOrNull}
+				}
+			case in.isCondJump():
+				taken, fallthru := st, st
+				taken.pc, fallthru.pc = in.target, st.pc+1
+				if st.regs[in.dst].kind == regMapValueOrNull {
+					if in.code&srcX != 0 || in.imm != 0 || in.op() != jmpJEQ && in.op() != jmpJNE {
+						return fmt.Errorf("insn %v: bad null check", st.pc)
+					}
+					null, nonNull := &taken, &fallthru
+					if in.op() == jmpJNE {
+						null, nonNull = nonNull, null
+					}
+					null.regs[in.dst] = reg{kind: regScalar}
+					nonNull.regs[in.dst] = reg{kind: regMapValue}
+				} else if err := scalar(in.dst); err != nil {
+					return err
+				}
+				if in.code&srcX != 0 {
+					if err := scalar(in.src); err != nil {
+						return err
+					}
+				}
+				queue = append(queue, taken)
+				st = fallthru
+				continue
+			default:
+				return fmt.Errorf("insn %v: unexpected instruction 0x%02x", st.pc, in.code)
+			}
+			if in.class() == classJMP && in.op() == jmpEXIT {
+				break
+			}
+			st.pc++
+		}
+	}
+	return nil
+}
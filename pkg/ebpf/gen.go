@@ -0,0 +1,351 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ebpf
+
+import (
+	"math/rand"
+)
+
+// Register state as tracked by the verifier (enum bpf_reg_type), simplified.
+type regKind int
+
+const (
+	regNone regKind = iota
+	regScalar
+	regStack
+	regMapValueOrNull
+	regMapValue
+)
+
+// Registers that snippets can clobber, R6/R7 hold maps and R10 is read-only.
+var scratchRegs = []uint8{0, 1, 2, 3, 4, 5, 8, 9}
+
+const (
+	scratchMask = 1<<0 | 1<<1 | 1<<2 | 1<<3 | 1<<4 | 1<<5 | 1<<8 | 1<<9
+	argAreaSize = 32 // size of stack areas passed to helpers
+)
+
+type gen struct {
+	cfg    *Config
+	r      *rand.Rand
+	insns  []insn
+	regs   [RegFP]regKind
+	locked [RegFP]bool
+}
+
+func newGen(cfg *Config, r *rand.Rand) *gen {
+	return &gen{cfg: cfg, r: r}
+}
+
+func (g *gen) emit(in insn) int {
+	g.insns = append(g.insns, in)
+	return len(g.insns) - 1
+}
+
+// snippet generates a self-contained piece of code: it reads only registers and stack
+// it has written itself and does not jump outside of itself. This allows to insert and
+// remove snippets anywhere between other snippets.
+func (g *gen) snippet(depth int) {
+	g.regs = [RegFP]regKind{}
+	g.locked = [RegFP]bool{}
+	switch x := g.r.Intn(100); {
+	case x < 25:
+		for n := 1 + g.r.Intn(4); n > 0; n-- {
+			g.alu()
+		}
+	case x < 45:
+		g.stack()
+	case x < 80:
+		g.call()
+	case depth < 2:
+		g.branch(depth)
+	default:
+		g.alu()
+	}
+}
+
+func (g *gen) exit() {
+	imm := int64(0)
+	if g.r.Intn(4) == 0 {
+		imm = randImm(g.r)
+	}
+	g.emit(insn{code: classALU64 | aluMOV | srcK, dst: 0, imm: imm})
+	g.emit(insn{code: classJMP | jmpEXIT})
+}
+
+func (g *gen) alu() {
+	dst := g.scalarReg()
+	cls := uint8(classALU64)
+	if g.r.Intn(4) == 0 {
+		cls = classALU
+	}
+	ops := []uint8{aluADD, aluSUB, aluMUL, aluDIV, aluOR, aluAND, aluLSH, aluRSH,
+		aluNEG, aluMOD, aluXOR, aluMOV, aluARSH}
+	op := ops[g.r.Intn(len(ops))]
+	in := insn{code: cls | op, dst: dst}
+	if op != aluNEG && g.r.Intn(3) == 0 {
+		in.code |= srcX
+		g.locked[dst] = true
+		in.src = g.scalarReg()
+		g.locked[dst] = false
+	} else if op != aluNEG {
+		in.imm = aluImm(g.r, cls, op)
+	}
+	g.emit(in)
+	g.regs[dst] = regScalar
+}
+
+// stack stores to a random stack slot and loads it back, the slot is addressed
+// through a base register to keep the store and the load within the snippet.
+func (g *gen) stack() {
+	base := g.stackPtr(8)
+	g.locked[base] = true
+	sizes := []uint8{sizeB, sizeH, sizeW, sizeDW}
+	// The first store initializes the whole slot.
+	g.emit(insn{code: classST | modeMEM | sizeDW, dst: base, imm: randImm(g.r)})
+	for n := g.r.Intn(3); n > 0; n-- {
+		size := sizes[g.r.Intn(len(sizes))]
+		off := int16(g.r.Intn(8/sizeBytes(size))) * int16(sizeBytes(size))
+		if g.r.Intn(2) == 0 {
+			g.emit(insn{code: classST | modeMEM | size, dst: base, off: off, imm: randImm(g.r)})
+		} else {
+			g.emit(insn{code: classSTX | modeMEM | size, dst: base, src: g.scalarReg(), off: off})
+		}
+	}
+	if g.r.Intn(4) == 0 {
+		g.emit(insn{code: classSTX | modeXADD | sizeDW, dst: base, src: g.scalarReg()})
+	}
+	for n := 1 + g.r.Intn(2); n > 0; n-- {
+		size := sizes[g.r.Intn(len(sizes))]
+		off := int16(g.r.Intn(8/sizeBytes(size))) * int16(sizeBytes(size))
+		dst := g.freeReg()
+		g.emit(insn{code: classLDX | modeMEM | size, dst: dst, src: base, off: off})
+		g.regs[dst] = regScalar
+	}
+	if g.r.Intn(2) == 0 {
+		g.alu()
+	}
+}
+
+func (g *gen) call() {
+	var candidates []*helper
+	for _, h := range helpers {
+		if g.cfg.Maps != 0 || !h.usesMaps() {
+			candidates = append(candidates, h)
+		}
+	}
+	h := candidates[g.r.Intn(len(candidates))]
+	memSize := 0
+	for i, arg := range h.args {
+		reg := uint8(i + 1)
+		switch arg {
+		case argAnything:
+			g.movImm(reg)
+		case argFlags:
+			g.emit(insn{code: classALU64 | aluMOV | srcK, dst: reg, imm: int64(g.r.Intn(4))})
+		case argMap:
+			g.emit(insn{code: classALU64 | aluMOV | srcX, dst: reg, src: RegMap0 + uint8(g.r.Intn(g.cfg.Maps))})
+		case argMapKey, argMapValue, argMem:
+			g.stackArea(reg, argAreaSize, true)
+			memSize = argAreaSize
+		case argUninitValue:
+			g.stackArea(reg, argAreaSize, false)
+		case argMemSize:
+			g.emit(insn{code: classALU64 | aluMOV | srcK, dst: reg, imm: int64(1 + g.r.Intn(memSize))})
+		default:
+			panic("unknown helper argument kind")
+		}
+		g.locked[reg] = true
+	}
+	g.emitCall(h)
+	if h.ret == retMapValueOrNull && g.r.Intn(5) != 0 {
+		g.nullCheck()
+	}
+}
+
+func (g *gen) emitCall(h *helper) {
+	g.emit(insn{code: classJMP | jmpCALL, imm: int64(h.id)})
+	for reg := 0; reg <= 5; reg++ {
+		g.regs[reg] = regNone
+		g.locked[reg] = false
+	}
+	g.regs[0] = regScalar
+	if h.ret == retMapValueOrNull {
+		g.regs[0] = regMapValueOrNull
+	}
+}
+
+// nullCheck accesses the map value returned in R0 if it's not NULL.
+// Value size is not known, so accesses are done at the beginning of the value.
+func (g *gen) nullCheck() {
+	jmp := g.emit(insn{code: classJMP | jmpJEQ | srcK, dst: 0, imm: 0})
+	g.regs[0] = regMapValue
+	g.locked[0] = true
+	sizes := []uint8{sizeB, sizeH, sizeW, sizeDW}
+	for n := 1 + g.r.Intn(3); n > 0; n-- {
+		size := sizes[g.r.Intn(len(sizes))]
+		switch g.r.Intn(4) {
+		case 0:
+			dst := g.freeReg()
+			g.emit(insn{code: classLDX | modeMEM | size, dst: dst, src: 0})
+			g.regs[dst] = regScalar
+		case 1:
+			g.emit(insn{code: classST | modeMEM | size, dst: 0, imm: randImm(g.r)})
+		case 2:
+			g.emit(insn{code: classSTX | modeMEM | size, dst: 0, src: g.scalarReg()})
+		case 3:
+			size = []uint8{sizeW, sizeDW}[g.r.Intn(2)]
+			g.emit(insn{code: classSTX | modeXADD | size, dst: 0, src: g.scalarReg()})
+		}
+	}
+	g.insns[jmp].target = len(g.insns)
+	g.locked[0] = false
+	g.regs[0] = regMapValueOrNull
+}
+
+// branch conditionally skips several nested snippets.
+func (g *gen) branch(depth int) {
+	var dst uint8
+	if g.r.Intn(3) == 0 {
+		g.emitCall(helperByID[7]) // get_prandom_u32
+		dst = 0
+	} else {
+		dst = g.scalarReg()
+		if g.r.Intn(2) == 0 {
+			g.alu()
+		}
+	}
+	cls := uint8(classJMP)
+	if g.r.Intn(4) == 0 {
+		cls = classJMP32
+	}
+	in := insn{code: cls | condJumps[g.r.Intn(len(condJumps))], dst: dst}
+	if g.r.Intn(3) == 0 {
+		in.code |= srcX
+		g.locked[dst] = true
+		in.src = g.scalarReg()
+	} else {
+		in.imm = randImm(g.r)
+	}
+	jmp := g.emit(in)
+	for n := 1 + g.r.Intn(2); n > 0; n-- {
+		g.snippet(depth + 1)
+	}
+	g.insns[jmp].target = len(g.insns)
+}
+
+var condJumps = []uint8{jmpJEQ, jmpJGT, jmpJGE, jmpJSET, jmpJNE, jmpJSGT, jmpJSGE,
+	jmpJLT, jmpJLE, jmpJSLT, jmpJSLE}
+
+// stackArea points reg to a stack area of the given size and optionally initializes it.
+func (g *gen) stackArea(reg uint8, size int, init bool) {
+	off := -size * (1 + g.r.Intn(StackSize/size))
+	g.emit(insn{code: classALU64 | aluMOV | srcX, dst: reg, src: RegFP})
+	g.emit(insn{code: classALU64 | aluADD | srcK, dst: reg, imm: int64(off)})
+	g.regs[reg] = regStack
+	for i := 0; init && i < size; i += 8 {
+		g.emit(insn{code: classST | modeMEM | sizeDW, dst: reg, off: int16(i), imm: randImm(g.r)})
+	}
+}
+
+// stackPtr allocates a free register pointing to a stack area of the given size.
+func (g *gen) stackPtr(size int) uint8 {
+	reg := g.freeReg()
+	g.stackArea(reg, size, false)
+	return reg
+}
+
+// scalarReg returns an unlocked register with a scalar value, it may initialize a new register.
+func (g *gen) scalarReg() uint8 {
+	var regs []uint8
+	for _, reg := range scratchRegs {
+		if g.regs[reg] == regScalar && !g.locked[reg] {
+			regs = append(regs, reg)
+		}
+	}
+	if len(regs) != 0 && g.r.Intn(4) != 0 {
+		return regs[g.r.Intn(len(regs))]
+	}
+	reg := g.freeReg()
+	g.movImm(reg)
+	return reg
+}
+
+func (g *gen) freeReg() uint8 {
+	for {
+		reg := scratchRegs[g.r.Intn(len(scratchRegs))]
+		if !g.locked[reg] {
+			return reg
+		}
+	}
+}
+
+func (g *gen) movImm(reg uint8) {
+	if g.r.Intn(5) == 0 {
+		g.emit(insn{code: codeLdImm64, dst: reg, imm: randImm64(g.r)})
+	} else {
+		g.emit(insn{code: classALU64 | aluMOV | srcK, dst: reg, imm: randImm(g.r)})
+	}
+	g.regs[reg] = regScalar
+}
+
+func sizeBytes(size uint8) int {
+	switch size {
+	case sizeB:
+		return 1
+	case sizeH:
+		return 2
+	case sizeW:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// aluImm returns an immediate accepted by the verifier for the operation:
+// no division by zero and no shifts wider than the operand.
+func aluImm(r *rand.Rand, cls, op uint8) int64 {
+	switch op {
+	case aluLSH, aluRSH, aluARSH:
+		if cls == classALU {
+			return int64(r.Intn(32))
+		}
+		return int64(r.Intn(64))
+	case aluDIV, aluMOD:
+		if imm := randImm(r); imm != 0 {
+			return imm
+		}
+		return 1
+	case aluNEG:
+		return 0
+	default:
+		return randImm(r)
+	}
+}
+
+func randImm(r *rand.Rand) int64 {
+	switch x := r.Intn(10); {
+	case x < 4:
+		return int64(r.Intn(16))
+	case x < 6:
+		return -int64(r.Intn(16))
+	case x < 8:
+		return int64(int32(1) << uint(r.Intn(32)))
+	default:
+		return int64(int32(r.Uint32()))
+	}
+}
+
+func randImm64(r *rand.Rand) int64 {
+	switch x := r.Intn(10); {
+	case x < 3:
+		return int64(r.Intn(1 << 16))
+	case x < 6:
+		return int64(uint64(1) << uint(r.Intn(64)))
+	case x < 8:
+		return -int64(r.Intn(1 << 16))
+	default:
+		return int64(r.Uint64())
+	}
+}
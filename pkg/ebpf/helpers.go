@@ -0,0 +1,61 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ebpf
+
+// Helper argument kinds (enum bpf_arg_type in include/linux/bpf.h).
+const (
+	argAnything    = iota
+	argFlags       // small integer flags
+	argMap         // ARG_CONST_MAP_PTR
+	argMapKey      // ARG_PTR_TO_MAP_KEY, initialized stack
+	argMapValue    // ARG_PTR_TO_MAP_VALUE, initialized stack
+	argUninitValue // ARG_PTR_TO_UNINIT_MAP_VALUE, stack written by the helper
+	argMem         // ARG_PTR_TO_MEM, followed by argMemSize
+	argMemSize     // ARG_CONST_SIZE for the previous argMem
+)
+
+// Helper return kinds (enum bpf_return_type in include/linux/bpf.h).
+const (
+	retInteger = iota
+	retMapValueOrNull
+)
+
+type helper struct {
+	name string
+	id   int32
+	args []int
+	ret  int
+}
+
+// Helpers that are available to all program types (bpf_base_func_proto).
+var helpers = []*helper{
+	{"map_lookup_elem", 1, []int{argMap, argMapKey}, retMapValueOrNull},
+	{"map_update_elem", 2, []int{argMap, argMapKey, argMapValue, argFlags}, retInteger},
+	{"map_delete_elem", 3, []int{argMap, argMapKey}, retInteger},
+	{"ktime_get_ns", 5, nil, retInteger},
+	{"trace_printk", 6, []int{argMem, argMemSize}, retInteger},
+	{"get_prandom_u32", 7, nil, retInteger},
+	{"get_smp_processor_id", 8, nil, retInteger},
+	{"get_numa_node_id", 42, nil, retInteger},
+	{"map_push_elem", 87, []int{argMap, argMapValue, argFlags}, retInteger},
+	{"map_pop_elem", 88, []int{argMap, argUninitValue}, retInteger},
+	{"map_peek_elem", 89, []int{argMap, argUninitValue}, retInteger},
+}
+
+var helperByID = func() map[int32]*helper {
+	m := make(map[int32]*helper)
+	for _, h := range helpers {
+		m[h.id] = h
+	}
+	return m
+}()
+
+func (h *helper) usesMaps() bool {
+	for _, arg := range h.args {
+		if arg == argMap {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ebpf
+
+import (
+	"math/rand"
+)
+
+// regs returns masks of registers read and written by the instruction.
+func (in *insn) regs() (use, def uint16) {
+	dst, src := uint16(1)<<in.dst, uint16(1)<<in.src
+	switch cls := in.class(); cls {
+	case classALU, classALU64:
+		switch {
+		case in.op() == aluMOV && in.code&srcX != 0:
+			use = src
+		case in.op() == aluMOV:
+		case in.code&srcX != 0 && in.op() != aluNEG:
+			use = dst | src
+		default:
+			use = dst
+		}
+		def = dst
+	case classLD:
+		if in.isLdImm64() {
+			def = dst
+		} else {
+			// Legacy packet access uses ctx in R6 and clobbers caller-saved registers.
+			use, def = 1<<6|src, 0x3f
+		}
+	case classLDX:
+		use, def = src, dst
+	case classST:
+		use = dst
+	case classSTX:
+		use = dst | src
+	case classJMP, classJMP32:
+		switch {
+		case cls == classJMP && in.op() == jmpCALL:
+			nargs := 5
+			if h := helperByID[int32(in.imm)]; h != nil {
+				nargs = len(h.args)
+			}
+			use, def = uint16(1)<<uint(nargs+1)-2, 0x3f
+		case cls == classJMP && in.op() == jmpEXIT:
+			use = 1 << 0
+		case cls == classJMP && in.op() == jmpJA:
+		case in.code&srcX != 0:
+			use = dst | src
+		default:
+			use = dst
+		}
+	}
+	return
+}
+
+// liveness returns masks of registers live before each instruction.
+// Jumps go only forward, so a single backward pass is enough.
+func liveness(insns []insn) []uint16 {
+	live := make([]uint16, len(insns)+1)
+	for i := len(insns) - 1; i >= 0; i-- {
+		in := &insns[i]
+		out := live[i+1]
+		switch {
+		case in.isCondJump():
+			out |= live[in.target]
+		case in.isJump():
+			out = live[in.target]
+		case in.class() == classJMP && in.op() == jmpEXIT:
+			out = 0
+		}
+		use, def := in.regs()
+		live[i] = use | out&^def
+	}
+	return live
+}
+
+// boundaries returns positions between snippets: no scratch registers are live there
+// and no jumps cross them. Snippets can be inserted and removed at these positions.
+func boundaries(insns []insn) []int {
+	live := liveness(insns)
+	crossing := make([]int, len(insns)+1)
+	for i := range insns {
+		if in := &insns[i]; in.isJump() && in.target > i+1 {
+			crossing[i+1]++
+			crossing[in.target]--
+		}
+	}
+	var res []int
+	jumps := 0
+	for i := range insns {
+		jumps += crossing[i]
+		if jumps == 0 && live[i]&scratchMask == 0 {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func insertInsns(insns []insn, pos int, add []insn) []insn {
+	res := make([]insn, 0, len(insns)+len(add))
+	res = append(res, insns[:pos]...)
+	for _, in := range add {
+		in.target += pos
+		res = append(res, in)
+	}
+	res = append(res, insns[pos:]...)
+	for i := range res {
+		if (i < pos || i >= pos+len(add)) && res[i].isJump() && res[i].target > pos {
+			res[i].target += len(add)
+		}
+	}
+	return res
+}
+
+func removeInsns(insns []insn, start, end int) []insn {
+	res := append(append([]insn{}, insns[:start]...), insns[end:]...)
+	for i := range res {
+		if res[i].isJump() && res[i].target >= end {
+			res[i].target -= end - start
+		}
+	}
+	return res
+}
+
+// mutateOperand changes operands of the i-th instruction that can take any value
+// without breaking verification. Pointer arithmetic, memory offsets and null checks
+// are left intact.
+func mutateOperand(r *rand.Rand, insns []insn, i int) {
+	in := &insns[i]
+	switch cls := in.class(); {
+	case in.isLdImm64():
+		if in.src == 0 {
+			in.imm = randImm64(r)
+		}
+	case cls == classALU || cls == classALU64:
+		if in.op() == aluMOV && in.code&srcX != 0 || isPointerArith(insns, i) {
+			return // register moves may copy pointers
+		}
+		safeOps := []uint8{aluADD, aluSUB, aluMUL, aluOR, aluAND, aluXOR}
+		if r.Intn(2) == 0 && in.op() != aluMOV && in.op() != aluNEG {
+			in.code = in.code&^0xf0 | safeOps[r.Intn(len(safeOps))]
+		}
+		if r.Intn(4) == 0 {
+			in.code ^= classALU ^ classALU64
+		}
+		if in.code&srcX == 0 || in.op() == aluNEG {
+			in.imm = aluImm(r, in.class(), in.op())
+		}
+	case in.isCondJump():
+		if in.code&srcX == 0 && in.imm == 0 && (in.op() == jmpJEQ || in.op() == jmpJNE) {
+			return // possibly a null check
+		}
+		if r.Intn(2) == 0 {
+			in.code = in.code&^0xf0 | condJumps[r.Intn(len(condJumps))]
+		}
+		if r.Intn(4) == 0 {
+			in.code ^= classJMP ^ classJMP32
+		}
+		if in.code&srcX == 0 {
+			for in.imm = randImm(r); in.imm == 0; in.imm = randImm(r) {
+			}
+		}
+	case cls == classST:
+		in.imm = randImm(r)
+	case cls == classJMP && in.op() == jmpCALL:
+		h := helperByID[int32(in.imm)]
+		if h == nil {
+			return
+		}
+		var same []*helper
+		for _, h1 := range helpers {
+			if equalArgs(h.args, h1.args) && h.ret == h1.ret {
+				same = append(same, h1)
+			}
+		}
+		in.imm = int64(same[r.Intn(len(same))].id)
+	}
+}
+
+// isPointerArith returns true if the instruction adjusts a stack pointer
+// (mov rX, r10; add rX, imm).
+func isPointerArith(insns []insn, i int) bool {
+	in := &insns[i]
+	if in.op() != aluADD || i == 0 {
+		return false
+	}
+	prev := &insns[i-1]
+	return prev.code == classALU64|aluMOV|srcX && prev.dst == in.dst && prev.src == RegFP
+}
+
+func equalArgs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -20,7 +20,8 @@ func DetectSupportedSyscalls(target *prog.Target, sandbox string) (
 	supported := make(map[*prog.Syscall]bool)
 	unsupported := make(map[*prog.Syscall]string)
 	// Akaros does not have own host and parasitizes on some other OS.
-	if target.OS == "akaros" || target.OS == "test" {
+	// Zephyr executor runs on the device, the host part only relays the programs.
+	if target.OS == "akaros" || target.OS == "zephyr" || target.OS == "test" {
 		for _, c := range target.Syscalls {
 			supported[c] = true
 		}
@@ -74,6 +75,7 @@ const (
 	FeatureLeakChecking
 	FeatureNetworkInjection
 	FeatureNetworkDevices
+	FeatureRaceScheduling
 	numFeatures
 )
 
@@ -105,10 +107,18 @@ func Check(target *prog.Target) (*Features, error) {
 		FeatureLeakChecking:               {Name: "leak checking", Reason: unsupported},
 		FeatureNetworkInjection:           {Name: "net packet injection", Reason: unsupported},
 		FeatureNetworkDevices:             {Name: "net device setup", Reason: unsupported},
+		FeatureRaceScheduling:             {Name: "race scheduling", Reason: unsupported},
 	}
 	if target.OS == "akaros" || target.OS == "test" {
 		return res, nil
 	}
+	if target.OS == "zephyr" {
+		// Coverage works if the firmware is built with CONFIG_SYZ_COVERAGE,
+		// otherwise the executor just returns no signal.
+		res[FeatureCoverage].Enabled = true
+		res[FeatureCoverage].Reason = "enabled"
+		return res, nil
+	}
 	for n, check := range checkFeature {
 		if check == nil {
 			continue
@@ -126,7 +136,7 @@ func Check(target *prog.Target) (*Features, error) {
 // Setup enables and does any one-time setup for the requested features on the host.
 // Note: this can be called multiple times and must be idempotent.
 func Setup(target *prog.Target, features *Features, featureFlags csource.Features, executor string) error {
-	if target.OS == "akaros" {
+	if target.OS == "akaros" || target.OS == "zephyr" {
 		return nil
 	}
 	args := []string{"setup"}
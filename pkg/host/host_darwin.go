@@ -4,9 +4,22 @@
 package host
 
 import (
+	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/prog"
 )
 
 func isSupported(c *prog.Syscall, target *prog.Target, sandbox string) (bool, string) {
-	return false, ""
+	return true, ""
+}
+
+func init() {
+	checkFeature[FeatureCoverage] = checkCoverage
+}
+
+func checkCoverage() string {
+	// ksancov is present only in kernels built with KSANCOV=1.
+	if !osutil.IsExist("/dev/ksancov") {
+		return "/dev/ksancov does not exist"
+	}
+	return ""
 }
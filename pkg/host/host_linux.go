@@ -70,8 +70,12 @@ func isSupported(c *prog.Syscall, target *prog.Target, sandbox string) (bool, st
 		kallsymsSyscallSet = parseKallsyms(kallsyms, target.Arch)
 	})
 	if !testFallback && len(kallsymsSyscallSet) != 0 {
-		r, v := isSupportedKallsyms(c)
-		return r, v
+		if r, v := isSupportedKallsyms(c); !r {
+			return r, v
+		}
+		// Kallsyms also lists syscalls that are compiled out (COND_SYSCALL aliases of sys_ni_syscall
+		// on some arches), and it can't tell if syscall numbers in descriptions match the kernel.
+		return isSupportedProbe(c, target)
 	}
 	return isSupportedTrial(c)
 }
@@ -128,6 +132,93 @@ func isSupportedTrial(c *prog.Syscall) (bool, string) {
 	return res, "ENOSYS"
 }
 
+// isSupportedProbe executes the syscall (in a subprocess) and checks that it does not return ENOSYS.
+func isSupportedProbe(c *prog.Syscall, target *prog.Target) (bool, string) {
+	// We can probe only syscalls of the native arch (e.g. not 386 syscalls on amd64).
+	if target.Arch != runtime.GOARCH || probeSkip[c.CallName] {
+		return true, ""
+	}
+	probeOnce.Do(func() {
+		var nrs []uint64
+		dup := make(map[uint64]bool)
+		for _, c := range target.Syscalls {
+			if strings.HasPrefix(c.CallName, "syz_") || probeSkip[c.CallName] || dup[c.NR] {
+				continue
+			}
+			dup[c.NR] = true
+			nrs = append(nrs, c.NR)
+		}
+		// Some syscalls return ENOSYS for invalid arguments (e.g. unknown futex op or modify_ldt func),
+		// so we re-probe such syscalls with zero arguments.
+		probeENOSYS = probeSyscalls(probeSyscalls(nrs, "invalid"), "zero")
+		for _, nr := range probeENOSYS {
+			log.Logf(1, "syscall %v returns ENOSYS", nr)
+		}
+	})
+	for _, nr := range probeENOSYS {
+		if nr == c.NR {
+			return false, fmt.Sprintf("sys_%v is present in /proc/kallsyms, but returns ENOSYS"+
+				" (compiled out or wrong syscall number %v)", c.CallName, c.NR)
+		}
+	}
+	return true, ""
+}
+
+// probeSyscalls returns syscalls that return ENOSYS. Syscalls that crash or hang the subprocess
+// are considered supported, the subprocess is restarted after such syscalls.
+func probeSyscalls(nrs []uint64, args string) []uint64 {
+	var enosys []uint64
+	for restarts := 0; len(nrs) != 0 && restarts < 10; restarts++ {
+		var list []string
+		for _, nr := range nrs {
+			list = append(list, fmt.Sprint(nr))
+		}
+		cmd := osutil.Command(os.Args[0])
+		cmd.Env = []string{fmt.Sprintf("SYZ_PROBE_SYSCALLS=%v:%v", args, strings.Join(list, ","))}
+		output, _ := osutil.Run(10*time.Second, cmd)
+		done := 0
+		for _, line := range strings.Split(string(output), "\n") {
+			var nr uint64
+			var errno int
+			if n, _ := fmt.Sscanf(line, "syscall %d: %d", &nr, &errno); n != 2 || done == len(nrs) ||
+				nrs[done] != nr {
+				continue
+			}
+			done++
+			if syscall.Errno(errno) == syscall.ENOSYS {
+				enosys = append(enosys, nr)
+			}
+		}
+		if done == len(nrs) {
+			break
+		}
+		// Skip the syscall that killed or hanged the subprocess.
+		nrs = nrs[done+1:]
+	}
+	return enosys
+}
+
+func init() {
+	str := os.Getenv("SYZ_PROBE_SYSCALLS")
+	if str == "" {
+		return
+	}
+	parts := strings.SplitN(str, ":", 2)
+	arg := uintptr(0)
+	if parts[0] == "invalid" {
+		arg = ^uintptr(0) - 1e4
+	}
+	for _, nrStr := range strings.Split(parts[1], ",") {
+		nr, err := strconv.ParseUint(nrStr, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+		_, _, errno := syscall.Syscall6(uintptr(nr), arg, arg, arg, arg, arg, arg)
+		fmt.Printf("syscall %v: %v\n", nr, int(errno))
+	}
+	os.Exit(0)
+}
+
 func init() {
 	str := os.Getenv("SYZ_TRIAL_TEST")
 	if str == "" {
@@ -162,6 +253,25 @@ var (
 	filesystemsOnce sync.Once
 )
 
+var (
+	probeOnce   sync.Once
+	probeENOSYS []uint64
+	// Syscalls that are not probed by execution because they kill or hang the process
+	// or affect the whole machine even with invalid arguments.
+	probeSkip = map[string]bool{
+		"exit":         true,
+		"exit_group":   true,
+		"pause":        true,
+		"vfork":        true,
+		"fork":         true,
+		"clone":        true,
+		"clone3":       true,
+		"rt_sigreturn": true,
+		"vhangup":      true,
+		"reboot":       true,
+	}
+)
+
 // The function is lengthy as it handles all pseudo-syscalls,
 // but it does not seem to cause comprehension problems as there is no shared state.
 // Splitting this per-syscall will only increase code size.
@@ -199,12 +309,19 @@ func isSupportedSyzkall(sandbox string, c *prog.Syscall) (bool, string) {
 		return true, ""
 	case "syz_open_pts":
 		return true, ""
-	case "syz_emit_ethernet", "syz_extract_tcp_res":
+	case "syz_emit_ethernet", "syz_extract_tcp_res", "syz_vtcp_connect", "syz_vtcp_accept",
+		"syz_vtcp_send", "syz_vtcp_recv", "syz_vtcp_tls", "syz_vtcp_tls_send":
 		reason := checkNetworkInjection()
 		return reason == "", reason
 	case "syz_usb_connect", "syz_usb_disconnect", "syz_usb_control_io", "syz_usb_ep_write":
 		reason := checkUSBInjection()
 		return reason == "", reason
+	case "syz_9p_connect", "syz_9p_io":
+		return isSupportedNetFilesystem("9p", sandbox)
+	case "syz_nfs_connect", "syz_nfs_io":
+		return isSupportedNetFilesystem("nfs", sandbox)
+	case "syz_smb_connect", "syz_smb_io":
+		return isSupportedNetFilesystem("cifs", sandbox)
 	case "syz_kvm_setup_cpu":
 		switch c.Name {
 		case "syz_kvm_setup_cpu$x86":
@@ -217,6 +334,11 @@ func isSupportedSyzkall(sandbox string, c *prog.Syscall) (bool, string) {
 			}
 		}
 		return false, "unsupported arch"
+	case "syz_vmware_backdoor":
+		if runtime.GOARCH != "amd64" {
+			return false, "unsupported arch"
+		}
+		return true, ""
 	case "syz_init_net_socket":
 		// Unfortunately this only works with sandbox none at the moment.
 		// The problem is that setns of a network namespace requires CAP_SYS_ADMIN
@@ -333,6 +455,16 @@ func isSupportedMount(c *prog.Syscall, sandbox string) (bool, string) {
 	}
 }
 
+// isSupportedNetFilesystem checks calls that mount a network filesystem
+// against a server in the executor (see executor/common_netfs.h).
+// None of these filesystems can be mounted in a user namespace.
+func isSupportedNetFilesystem(fstype, sandbox string) (bool, string) {
+	if ok, reason := onlySandboxNone(sandbox); !ok {
+		return ok, reason
+	}
+	return isSupportedFilesystem(fstype)
+}
+
 func isSupportedFilesystem(fstype string) (bool, string) {
 	filesystemsOnce.Do(func() {
 		filesystems, _ = ioutil.ReadFile("/proc/filesystems")
@@ -370,6 +502,7 @@ func init() {
 	checkFeature[FeatureLeakChecking] = checkLeakChecking
 	checkFeature[FeatureNetworkInjection] = checkNetworkInjection
 	checkFeature[FeatureNetworkDevices] = checkNetworkDevices
+	checkFeature[FeatureRaceScheduling] = checkRaceScheduling
 }
 
 func checkCoverage() string {
@@ -479,6 +612,21 @@ func checkFaultInjection() string {
 	return ""
 }
 
+func checkRaceScheduling() string {
+	// Preempting schedule works on a single CPU, but on such machines
+	// the parallel schedule degrades to it and most of the exploration is wasted.
+	if runtime.NumCPU() < 2 {
+		return "need at least 2 CPUs"
+	}
+	var cpus [128]byte
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0,
+		uintptr(len(cpus)), uintptr(unsafe.Pointer(&cpus[0])))
+	if errno != 0 {
+		return fmt.Sprintf("sched_getaffinity failed: %v", errno)
+	}
+	return ""
+}
+
 func checkLeakChecking() string {
 	if reason := checkDebugFS(); reason != "" {
 		return reason
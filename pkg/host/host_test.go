@@ -60,3 +60,16 @@ func TestCheck(t *testing.T) {
 		t.Logf("%-24v: %v", feat.Name, feat.Reason)
 	}
 }
+
+func TestParseKernelConfig(t *testing.T) {
+	configs := parseKernelConfig([]byte(`
+# CONFIG_KVM is not set
+CONFIG_TUN=y
+CONFIG_IP_SCTP=m
+CONFIG_LOG_BUF_SHIFT=18
+`))
+	want := "map[CONFIG_IP_SCTP:true CONFIG_TUN:true]"
+	if got := fmt.Sprint(configs); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
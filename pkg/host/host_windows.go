@@ -4,9 +4,27 @@
 package host
 
 import (
+	"os"
+
 	"github.com/google/syzkaller/prog"
 )
 
 func isSupported(c *prog.Syscall, target *prog.Target, sandbox string) (bool, string) {
 	return true, ""
 }
+
+func init() {
+	checkFeature[FeatureCoverage] = checkCoverage
+}
+
+// kcovDevice is exposed by the coverage driver (see docs/windows/README.md).
+const kcovDevice = `\\.\kcov`
+
+func checkCoverage() string {
+	f, err := os.OpenFile(kcovDevice, os.O_RDWR, 0)
+	if err != nil {
+		return "coverage driver is not loaded: " + err.Error()
+	}
+	f.Close()
+	return ""
+}
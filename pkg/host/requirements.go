@@ -0,0 +1,83 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package host
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+)
+
+// MissingRequirements returns human-readable descriptions of kernel requirements of the syscall
+// (see the requires call attribute) that are not satisfied in the running kernel.
+// The result is nil if the syscall has no known requirements or all of them are satisfied.
+func MissingRequirements(c *prog.Syscall) []string {
+	req := c.Requires
+	if req == nil {
+		return nil
+	}
+	var missing []string
+	configs, configFile := kernelConfig()
+	for _, cfg := range req.Configs {
+		if configs == nil {
+			missing = append(missing, fmt.Sprintf("%v is possibly not enabled (no kernel config to check)", cfg))
+		} else if !configs[cfg] {
+			missing = append(missing, fmt.Sprintf("%v is not enabled in %v", cfg, configFile))
+		}
+	}
+	for _, file := range req.Files {
+		if err := osutil.IsAccessible(file); err != nil {
+			missing = append(missing, fmt.Sprintf("%v is not accessible", file))
+		}
+	}
+	return missing
+}
+
+var (
+	kernelConfigOnce sync.Once
+	kernelConfigs    map[string]bool
+	kernelConfigFile string
+)
+
+// kernelConfig returns enabled (=y or =m) configs of the running kernel
+// and the file they were read from, or nil if the config is not available.
+func kernelConfig() (map[string]bool, string) {
+	kernelConfigOnce.Do(func() {
+		if data, err := ioutil.ReadFile("/proc/config.gz"); err == nil {
+			if r, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+				if data, err = ioutil.ReadAll(r); err == nil {
+					kernelConfigs, kernelConfigFile = parseKernelConfig(data), "/proc/config.gz"
+					return
+				}
+			}
+		}
+		release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+		if err != nil {
+			return
+		}
+		file := "/boot/config-" + strings.TrimSpace(string(release))
+		if data, err := ioutil.ReadFile(file); err == nil {
+			kernelConfigs, kernelConfigFile = parseKernelConfig(data), file
+		}
+	})
+	return kernelConfigs, kernelConfigFile
+}
+
+func parseKernelConfig(data []byte) map[string]bool {
+	configs := make(map[string]bool)
+	for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+		line := s.Text()
+		if strings.HasSuffix(line, "=y") || strings.HasSuffix(line, "=m") {
+			configs[line[:len(line)-2]] = true
+		}
+	}
+	return configs
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package arm64 allows to generate and mutate arm64 machine code.
+// Instructions are generated from encoding templates with random operands,
+// so unlike random bytes almost all generated words are valid instructions.
+// The package uses ifuzz.Config, Mode is ignored.
+package arm64
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/google/syzkaller/pkg/ifuzz"
+)
+
+// Insn is an instruction encoding template.
+type Insn struct {
+	Name   string
+	Priv   bool // requires EL1
+	Pseudo bool // pseudo instructions can consist of several real instructions
+	Bits   uint32
+	Fields []Field
+
+	generator func(cfg *ifuzz.Config, r *rand.Rand) []uint32 // for pseudo instructions
+}
+
+// Field is an operand of an instruction encoded in Bits [Pos, Pos+Size).
+type Field struct {
+	Kind int
+	Pos  uint
+	Size uint
+}
+
+const (
+	FieldReg        = iota // general-purpose register, 31 is SP or XZR
+	FieldImm               // immediate
+	FieldSysReg            // system register encoding (op0:op1:CRn:CRm:op2)
+	FieldUserSysReg        // system register accessible at EL0
+)
+
+// Instructions indexed by ifuzz.ClassXXX.
+var classInsns = make(map[int][]*Insn)
+
+func init() {
+	for _, insn := range Insns {
+		class := ifuzz.ClassUser
+		if insn.Pseudo {
+			class = ifuzz.ClassExec
+		} else if insn.Priv {
+			class = ifuzz.ClassPriv
+		}
+		classInsns[class] = append(classInsns[class], insn)
+	}
+}
+
+// Generate generates cfg.Len instructions.
+func Generate(cfg *ifuzz.Config, r *rand.Rand) []byte {
+	var words []uint32
+	for i := 0; i < cfg.Len; i++ {
+		words = append(words, randInsn(cfg, r).Encode(cfg, r)...)
+	}
+	return encode(words)
+}
+
+// Mutate mutates text on instruction boundaries (text is split into 4-byte words).
+func Mutate(cfg *ifuzz.Config, r *rand.Rand, text []byte) []byte {
+	words := decode(text)
+	retry := false
+	for stop := false; !stop || retry || len(words) == 0; stop = r.Intn(2) == 0 {
+		retry = false
+		switch x := r.Intn(100); {
+		case x < 10 && len(words) != 0:
+			// delete instruction
+			i := r.Intn(len(words))
+			words = append(words[:i], words[i+1:]...)
+		case x < 40 && len(words) != 0:
+			// replace instruction with another
+			i := r.Intn(len(words))
+			words = append(words[:i], append(randInsn(cfg, r).Encode(cfg, r), words[i+1:]...)...)
+		case x < 70 && len(words) != 0:
+			// mutate instruction
+			i := r.Intn(len(words))
+			if insn := Decode(words[i]); insn != nil && len(insn.Fields) != 0 && r.Intn(4) != 0 {
+				f := insn.Fields[r.Intn(len(insn.Fields))]
+				words[i] = f.set(words[i], f.generate(cfg, r))
+			} else {
+				words[i] ^= 1 << uint(r.Intn(32))
+			}
+		case len(words) < cfg.Len*2:
+			// insert a new instruction
+			i := r.Intn(len(words) + 1)
+			words = append(words[:i], append(randInsn(cfg, r).Encode(cfg, r), words[i:]...)...)
+		default:
+			retry = true
+		}
+	}
+	return encode(words)
+}
+
+// Decode returns template of the instruction, or nil if the word does not match any template.
+func Decode(word uint32) *Insn {
+	for _, insn := range Insns {
+		if !insn.Pseudo && word&^insn.fieldMask() == insn.Bits {
+			return insn
+		}
+	}
+	return nil
+}
+
+// Encode returns the instruction with random operands (several instructions for pseudo instructions).
+func (insn *Insn) Encode(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+	if insn.Pseudo {
+		return insn.generator(cfg, r)
+	}
+	word := insn.Bits
+	for _, f := range insn.Fields {
+		word = f.set(word, f.generate(cfg, r))
+	}
+	return []uint32{word}
+}
+
+func (insn *Insn) fieldMask() uint32 {
+	mask := uint32(0)
+	for _, f := range insn.Fields {
+		mask |= f.mask()
+	}
+	return mask
+}
+
+func (f Field) mask() uint32 {
+	return (1<<f.Size - 1) << f.Pos
+}
+
+func (f Field) set(word, v uint32) uint32 {
+	return word&^f.mask() | v<<f.Pos&f.mask()
+}
+
+func (f Field) generate(cfg *ifuzz.Config, r *rand.Rand) uint32 {
+	switch f.Kind {
+	case FieldReg:
+		if r.Intn(10) == 0 {
+			return 31
+		}
+		// Low registers are used by pseudo instructions to pass values.
+		if r.Intn(2) == 0 {
+			return uint32(r.Intn(4))
+		}
+		return uint32(r.Intn(31))
+	case FieldSysReg, FieldUserSysReg:
+		regs := userSysRegs
+		if f.Kind == FieldSysReg && cfg.Priv && r.Intn(5) != 0 {
+			regs = privSysRegs
+		}
+		return regs[r.Intn(len(regs))]
+	default:
+		switch x := r.Intn(10); {
+		case x < 5:
+			return uint32(r.Intn(16))
+		case x < 7:
+			return uint32(r.Intn(1 << f.Size))
+		case x < 9:
+			return 1<<f.Size - 1
+		default:
+			return 1 << uint(r.Intn(int(f.Size)))
+		}
+	}
+}
+
+func randInsn(cfg *ifuzz.Config, r *rand.Rand) *Insn {
+	insns := classInsns[ifuzz.RandClass(cfg, r)]
+	return insns[r.Intn(len(insns))]
+}
+
+func encode(words []uint32) []byte {
+	text := make([]byte, len(words)*4)
+	for i, w := range words {
+		binary.LittleEndian.PutUint32(text[i*4:], w)
+	}
+	return text
+}
+
+func decode(text []byte) []uint32 {
+	words := make([]uint32, 0, (len(text)+3)/4)
+	for ; len(text) >= 4; text = text[4:] {
+		words = append(words, binary.LittleEndian.Uint32(text))
+	}
+	if len(text) != 0 {
+		// Partial instruction is padded with zeros (which is an undefined instruction).
+		var tail [4]byte
+		copy(tail[:], text)
+		words = append(words, binary.LittleEndian.Uint32(tail[:]))
+	}
+	return words
+}
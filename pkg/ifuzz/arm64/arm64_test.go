@@ -0,0 +1,85 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package arm64
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/ifuzz"
+)
+
+func TestGenerate(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed=%v", seed)
+	r := rand.New(rand.NewSource(seed))
+	for priv := 0; priv < 2; priv++ {
+		cfg := &ifuzz.Config{
+			Len:        10,
+			Priv:       priv != 0,
+			Exec:       true,
+			MemRegions: []ifuzz.MemRegion{{Start: 0x1000, Size: 0x1000}},
+		}
+		for i := 0; i < 1000; i++ {
+			text := Generate(cfg, r)
+			if len(text) < cfg.Len*4 || len(text)%4 != 0 {
+				t.Fatalf("bad text size %v", len(text))
+			}
+			for pos := 0; pos < len(text); pos += 4 {
+				word := binary.LittleEndian.Uint32(text[pos:])
+				insn := Decode(word)
+				if insn == nil {
+					t.Fatalf("generated unknown instruction 0x%08x", word)
+				}
+				if insn.Priv && !cfg.Priv {
+					t.Fatalf("generated privileged instruction %v 0x%08x", insn.Name, word)
+				}
+			}
+		}
+	}
+}
+
+func TestMutate(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed=%v", seed)
+	r := rand.New(rand.NewSource(seed))
+	cfg := &ifuzz.Config{
+		Len:  10,
+		Priv: true,
+		Exec: true,
+	}
+	for i := 0; i < 1000; i++ {
+		text := Generate(cfg, r)
+		text = append(text, 0xff) // partial instruction
+		for j := 0; j < 10; j++ {
+			text = Mutate(cfg, r, text)
+			if len(text) == 0 || len(text)%4 != 0 {
+				t.Fatalf("bad mutated text size %v", len(text))
+			}
+		}
+	}
+}
+
+func TestSysReg(t *testing.T) {
+	// mrs x0, sctlr_el1
+	if word := 0xd5300000 | sysReg(3, 0, 1, 0, 0)<<5; word != 0xd5381000 {
+		t.Fatalf("bad sctlr_el1 encoding: 0x%08x", word)
+	}
+	// mov64 must load the exact value.
+	for _, v := range []uint64{0, 1, 0x10000, 0xdeadbeef12345678} {
+		got := uint64(0)
+		for _, word := range mov64(3, v) {
+			hw := word >> 21 & 3
+			got |= uint64(word>>5&0xffff) << (hw * 16)
+			if word&0x1f != 3 {
+				t.Fatalf("mov64 uses wrong register: 0x%08x", word)
+			}
+		}
+		if got != v {
+			t.Fatalf("mov64 loads 0x%x instead of 0x%x", got, v)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package arm64
+
+var (
+	rd         = Field{Kind: FieldReg, Pos: 0, Size: 5}
+	rn         = Field{Kind: FieldReg, Pos: 5, Size: 5}
+	ra         = Field{Kind: FieldReg, Pos: 10, Size: 5}
+	rm         = Field{Kind: FieldReg, Pos: 16, Size: 5}
+	sysreg     = Field{Kind: FieldSysReg, Pos: 5, Size: 15}
+	userSysreg = Field{Kind: FieldUserSysReg, Pos: 5, Size: 15}
+)
+
+func imm(pos, size uint) Field {
+	return Field{Kind: FieldImm, Pos: pos, Size: size}
+}
+
+// Insns is the list of all instruction templates (see Arm Architecture Reference Manual, C4.1).
+var Insns = append([]*Insn{
+	// Data processing.
+	{Name: "NOP", Bits: 0xd503201f},
+	{Name: "YIELD", Bits: 0xd503203f},
+	{Name: "ADD_IMM", Bits: 0x91000000, Fields: []Field{rd, rn, imm(10, 12), imm(22, 1)}},
+	{Name: "ADDS_IMM", Bits: 0xb1000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "SUB_IMM", Bits: 0xd1000000, Fields: []Field{rd, rn, imm(10, 12), imm(22, 1)}},
+	{Name: "SUBS_IMM", Bits: 0xf1000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "ADD_REG", Bits: 0x8b000000, Fields: []Field{rd, rn, rm, imm(10, 6)}},
+	{Name: "SUB_REG", Bits: 0xcb000000, Fields: []Field{rd, rn, rm, imm(10, 6)}},
+	{Name: "AND_REG", Bits: 0x8a000000, Fields: []Field{rd, rn, rm, imm(10, 6)}},
+	{Name: "ORR_REG", Bits: 0xaa000000, Fields: []Field{rd, rn, rm, imm(10, 6)}},
+	{Name: "EOR_REG", Bits: 0xca000000, Fields: []Field{rd, rn, rm, imm(10, 6)}},
+	{Name: "MOVZ", Bits: 0xd2800000, Fields: []Field{rd, imm(5, 16), imm(21, 2)}},
+	{Name: "MOVK", Bits: 0xf2800000, Fields: []Field{rd, imm(5, 16), imm(21, 2)}},
+	{Name: "MOVN", Bits: 0x92800000, Fields: []Field{rd, imm(5, 16), imm(21, 2)}},
+	{Name: "UDIV", Bits: 0x9ac00800, Fields: []Field{rd, rn, rm}},
+	{Name: "MADD", Bits: 0x9b000000, Fields: []Field{rd, rn, rm, ra}},
+	// Loads and stores.
+	{Name: "LDR", Bits: 0xf9400000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "STR", Bits: 0xf9000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "LDRW", Bits: 0xb9400000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "STRW", Bits: 0xb9000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "LDRH", Bits: 0x79400000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "STRH", Bits: 0x79000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "LDRB", Bits: 0x39400000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "STRB", Bits: 0x39000000, Fields: []Field{rd, rn, imm(10, 12)}},
+	{Name: "LDP", Bits: 0xa9400000, Fields: []Field{rd, rn, ra, imm(15, 7)}},
+	{Name: "STP", Bits: 0xa9000000, Fields: []Field{rd, rn, ra, imm(15, 7)}},
+	{Name: "LDXR", Bits: 0xc85f7c00, Fields: []Field{rd, rn}},
+	{Name: "STXR", Bits: 0xc8007c00, Fields: []Field{rd, rn, rm}},
+	{Name: "LDAR", Bits: 0xc8dffc00, Fields: []Field{rd, rn}},
+	{Name: "STLR", Bits: 0xc89ffc00, Fields: []Field{rd, rn}},
+	{Name: "LDADD", Bits: 0xf8200000, Fields: []Field{rd, rn, rm}},
+	{Name: "CAS", Bits: 0xc8a07c00, Fields: []Field{rd, rn, rm}},
+	// Branches (offsets are kept small to stay within the text).
+	{Name: "B", Bits: 0x14000000, Fields: []Field{imm(0, 4)}},
+	{Name: "B_BACK", Bits: 0x17fffff0, Fields: []Field{imm(0, 4)}},
+	{Name: "CBZ", Bits: 0xb4000000, Fields: []Field{rd, imm(5, 4)}},
+	{Name: "CBNZ", Bits: 0xb5000000, Fields: []Field{rd, imm(5, 4)}},
+	{Name: "BR", Bits: 0xd61f0000, Fields: []Field{rn}},
+	{Name: "BLR", Bits: 0xd63f0000, Fields: []Field{rn}},
+	{Name: "RET", Bits: 0xd65f0000, Fields: []Field{rn}},
+	// Exceptions and barriers.
+	{Name: "SVC", Bits: 0xd4000001, Fields: []Field{imm(5, 16)}},
+	{Name: "BRK", Bits: 0xd4200000, Fields: []Field{imm(5, 16)}},
+	{Name: "DMB", Bits: 0xd50330bf, Fields: []Field{imm(8, 4)}},
+	{Name: "DSB", Bits: 0xd503309f, Fields: []Field{imm(8, 4)}},
+	{Name: "ISB", Bits: 0xd5033fdf},
+	{Name: "MRS_EL0", Bits: 0xd5300000, Fields: []Field{rd, userSysreg}},
+	{Name: "MSR_EL0", Bits: 0xd5100000, Fields: []Field{rd, userSysreg}},
+	{Name: "DC_CIVAC", Bits: 0xd50b7e20, Fields: []Field{rd}},
+	{Name: "DC_ZVA", Bits: 0xd50b7420, Fields: []Field{rd}},
+	// Privileged instructions.
+	{Name: "MRS", Priv: true, Bits: 0xd5300000, Fields: []Field{rd, sysreg}},
+	{Name: "MSR", Priv: true, Bits: 0xd5100000, Fields: []Field{rd, sysreg}},
+	{Name: "MSR_DAIFSET", Priv: true, Bits: 0xd50340df, Fields: []Field{imm(8, 4)}},
+	{Name: "MSR_DAIFCLR", Priv: true, Bits: 0xd50340ff, Fields: []Field{imm(8, 4)}},
+	{Name: "MSR_SPSEL", Priv: true, Bits: 0xd50040bf, Fields: []Field{imm(8, 1)}},
+	{Name: "HVC", Priv: true, Bits: 0xd4000002, Fields: []Field{imm(5, 16)}},
+	{Name: "SMC", Priv: true, Bits: 0xd4000003, Fields: []Field{imm(5, 16)}},
+	{Name: "ERET", Priv: true, Bits: 0xd69f03e0},
+	{Name: "WFI", Priv: true, Bits: 0xd503207f},
+	{Name: "WFE", Priv: true, Bits: 0xd503205f},
+	{Name: "SEV", Priv: true, Bits: 0xd503209f},
+	{Name: "TLBI_VMALLE1IS", Priv: true, Bits: 0xd508831f},
+	{Name: "TLBI_VMALLE1", Priv: true, Bits: 0xd508871f},
+	{Name: "TLBI_VAE1", Priv: true, Bits: 0xd5088720, Fields: []Field{rd}},
+	{Name: "IC_IALLU", Priv: true, Bits: 0xd508751f},
+	{Name: "IC_IALLUIS", Priv: true, Bits: 0xd508711f},
+	{Name: "DC_IVAC", Priv: true, Bits: 0xd5087620, Fields: []Field{rd}},
+	{Name: "AT_S1E1R", Priv: true, Bits: 0xd5087800, Fields: []Field{rd}},
+	{Name: "AT_S1E0W", Priv: true, Bits: 0xd5087860, Fields: []Field{rd}},
+}, pseudoInsns...)
+
+// sysReg encodes a system register as it's encoded in bits [19:5] of MRS/MSR (op0 is always 2 or 3).
+func sysReg(op0, op1, crn, crm, op2 uint32) uint32 {
+	return (op0-2)<<14 | op1<<11 | crn<<7 | crm<<3 | op2
+}
+
+var userSysRegs = []uint32{
+	sysReg(3, 3, 13, 0, 2), // TPIDR_EL0
+	sysReg(3, 3, 13, 0, 3), // TPIDRRO_EL0
+	sysReg(3, 3, 14, 0, 0), // CNTFRQ_EL0
+	sysReg(3, 3, 14, 0, 1), // CNTPCT_EL0
+	sysReg(3, 3, 14, 0, 2), // CNTVCT_EL0
+	sysReg(3, 3, 4, 2, 0),  // NZCV
+	sysReg(3, 3, 4, 4, 0),  // FPCR
+	sysReg(3, 3, 4, 4, 1),  // FPSR
+	sysReg(3, 3, 0, 0, 7),  // DCZID_EL0
+	sysReg(3, 3, 9, 12, 0), // PMCR_EL0
+}
+
+var privSysRegs = []uint32{
+	sysReg(3, 0, 0, 0, 0),   // MIDR_EL1
+	sysReg(3, 0, 0, 0, 5),   // MPIDR_EL1
+	sysReg(3, 0, 0, 4, 0),   // ID_AA64PFR0_EL1
+	sysReg(3, 0, 0, 5, 0),   // ID_AA64DFR0_EL1
+	sysReg(3, 0, 0, 7, 0),   // ID_AA64MMFR0_EL1
+	sysReg(3, 0, 1, 0, 0),   // SCTLR_EL1
+	sysReg(3, 0, 1, 0, 2),   // CPACR_EL1
+	sysReg(3, 0, 2, 0, 0),   // TTBR0_EL1
+	sysReg(3, 0, 2, 0, 1),   // TTBR1_EL1
+	sysReg(3, 0, 2, 0, 2),   // TCR_EL1
+	sysReg(3, 0, 4, 0, 0),   // SPSR_EL1
+	sysReg(3, 0, 4, 0, 1),   // ELR_EL1
+	sysReg(3, 0, 4, 1, 0),   // SP_EL0
+	sysReg(3, 0, 4, 6, 0),   // ICC_PMR_EL1
+	sysReg(3, 0, 5, 1, 0),   // AFSR0_EL1
+	sysReg(3, 0, 5, 2, 0),   // ESR_EL1
+	sysReg(3, 0, 6, 0, 0),   // FAR_EL1
+	sysReg(3, 0, 7, 4, 0),   // PAR_EL1
+	sysReg(3, 0, 10, 2, 0),  // MAIR_EL1
+	sysReg(3, 0, 12, 0, 0),  // VBAR_EL1
+	sysReg(3, 0, 12, 11, 5), // ICC_SGI1R_EL1
+	sysReg(3, 0, 12, 12, 0), // ICC_IAR1_EL1
+	sysReg(3, 0, 12, 12, 1), // ICC_EOIR1_EL1
+	sysReg(3, 0, 12, 12, 5), // ICC_SRE_EL1
+	sysReg(3, 0, 12, 12, 7), // ICC_IGRPEN1_EL1
+	sysReg(3, 0, 13, 0, 1),  // CONTEXTIDR_EL1
+	sysReg(3, 0, 13, 0, 4),  // TPIDR_EL1
+	sysReg(3, 0, 14, 1, 0),  // CNTKCTL_EL1
+	sysReg(3, 3, 4, 2, 1),   // DAIF
+	sysReg(3, 3, 14, 2, 1),  // CNTP_CTL_EL0
+	sysReg(3, 3, 14, 2, 2),  // CNTP_CVAL_EL0
+	sysReg(3, 3, 14, 3, 1),  // CNTV_CTL_EL0
+	sysReg(3, 3, 14, 3, 2),  // CNTV_CVAL_EL0
+	sysReg(2, 0, 0, 2, 2),   // MDSCR_EL1
+	sysReg(2, 0, 0, 0, 4),   // DBGBVR0_EL1
+	sysReg(2, 0, 0, 0, 5),   // DBGBCR0_EL1
+	sysReg(2, 0, 1, 0, 4),   // OSLAR_EL1
+	sysReg(3, 3, 9, 13, 0),  // PMCCNTR_EL0
+	sysReg(3, 0, 9, 14, 1),  // PMINTENSET_EL1
+	sysReg(3, 4, 1, 1, 0),   // HCR_EL2 (undefined at EL1)
+}
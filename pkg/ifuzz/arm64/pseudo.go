@@ -0,0 +1,141 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package arm64
+
+import (
+	"math/rand"
+
+	"github.com/google/syzkaller/pkg/ifuzz"
+)
+
+var pseudoInsns = []*Insn{
+	{
+		Name:   "PSEUDO_MMIO_READ",
+		Priv:   true,
+		Pseudo: true,
+		generator: func(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+			addr := randAddr(cfg, r)
+			return append(mov64(1, addr), loadStore(r, false, 0, 1)) // ldr x0, [x1]
+		},
+	},
+	{
+		Name:   "PSEUDO_MMIO_WRITE",
+		Priv:   true,
+		Pseudo: true,
+		generator: func(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+			addr := randAddr(cfg, r)
+			text := append(mov64(1, addr), mov64(0, randValue(r))...)
+			return append(text, loadStore(r, true, 0, 1)) // str x0, [x1]
+		},
+	},
+	{
+		Name:   "PSEUDO_HVC",
+		Priv:   true,
+		Pseudo: true,
+		generator: func(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+			// SMCCC calls handled by KVM: function ID in x0, arguments in x1-x3.
+			text := mov64(0, uint64(hypercalls[r.Intn(len(hypercalls))]))
+			for reg := uint32(1); reg <= 3; reg++ {
+				if r.Intn(2) == 0 {
+					text = append(text, mov64(reg, randValue(r))...)
+				}
+			}
+			insn := uint32(0xd4000002) // hvc #0
+			if r.Intn(5) == 0 {
+				insn = 0xd4000003 // smc #0
+			}
+			return append(text, insn)
+		},
+	},
+	{
+		Name:   "PSEUDO_MSR",
+		Priv:   true,
+		Pseudo: true,
+		generator: func(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+			reg := privSysRegs[r.Intn(len(privSysRegs))]
+			text := mov64(0, randValue(r))
+			return append(text, 0xd5100000|reg<<5, 0xd5033fdf) // msr reg, x0; isb
+		},
+	},
+	{
+		Name:   "PSEUDO_SGI",
+		Priv:   true,
+		Pseudo: true,
+		generator: func(cfg *ifuzz.Config, r *rand.Rand) []uint32 {
+			// ICC_SGI1R_EL1: INTID in bits [27:24], target list in [15:0], IRM in bit 40.
+			val := uint64(r.Intn(16))<<24 | uint64(r.Intn(1<<16))
+			if r.Intn(4) == 0 {
+				val |= 1 << 40
+			}
+			text := mov64(0, val)
+			return append(text, 0xd5100000|sysReg(3, 0, 12, 11, 5)<<5, 0xd5033fdf)
+		},
+	},
+}
+
+// SMCCC/PSCI function IDs.
+var hypercalls = []uint32{
+	0x80000000, // SMCCC_VERSION
+	0x80000001, // SMCCC_ARCH_FEATURES
+	0x80008000, // SMCCC_ARCH_WORKAROUND_1
+	0x80007fff, // SMCCC_ARCH_WORKAROUND_2
+	0x84000000, // PSCI_VERSION
+	0x84000001, // PSCI_CPU_SUSPEND
+	0xc4000001, // PSCI_CPU_SUSPEND (64-bit)
+	0x84000002, // PSCI_CPU_OFF
+	0xc4000003, // PSCI_CPU_ON (64-bit)
+	0xc4000004, // PSCI_AFFINITY_INFO (64-bit)
+	0x84000006, // PSCI_MIGRATE_INFO_TYPE
+	0x84000008, // PSCI_SYSTEM_OFF
+	0x84000009, // PSCI_SYSTEM_RESET
+	0x8400000a, // PSCI_FEATURES
+	0x86000000, // vendor hypervisor service call
+	0xc6000000, // vendor hypervisor service call (64-bit)
+}
+
+// mov64 loads v into register reg with movz/movk.
+func mov64(reg uint32, v uint64) []uint32 {
+	text := []uint32{0xd2800000 | uint32(v&0xffff)<<5 | reg} // movz
+	for hw := uint32(1); hw < 4; hw++ {
+		if part := uint32(v >> (hw * 16) & 0xffff); part != 0 {
+			text = append(text, 0xf2800000|hw<<21|part<<5|reg) // movk
+		}
+	}
+	return text
+}
+
+func loadStore(r *rand.Rand, store bool, rt, rn uint32) uint32 {
+	ops := []uint32{0xf9400000, 0xb9400000, 0x79400000, 0x39400000} // ldr x, w, h, b
+	insn := ops[r.Intn(len(ops))]
+	if store {
+		insn &^= 1 << 22
+	}
+	return insn | rn<<5 | rt
+}
+
+func randAddr(cfg *ifuzz.Config, r *rand.Rand) uint64 {
+	if len(cfg.MemRegions) == 0 {
+		return uint64(r.Intn(1 << 20))
+	}
+	mem := cfg.MemRegions[r.Intn(len(cfg.MemRegions))]
+	addr := mem.Start
+	if mem.Size != 0 {
+		addr += uint64(r.Int63()) % mem.Size
+	}
+	// Keep natural alignment for the widest access.
+	return addr &^ 7
+}
+
+func randValue(r *rand.Rand) uint64 {
+	switch x := r.Intn(10); {
+	case x < 4:
+		return uint64(r.Intn(1 << 8))
+	case x < 6:
+		return uint64(r.Intn(1 << 16))
+	case x < 8:
+		return 1 << uint(r.Intn(64))
+	default:
+		return uint64(r.Int63())
+	}
+}
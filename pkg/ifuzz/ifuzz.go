@@ -60,6 +60,10 @@ type Config struct {
 	Priv       bool        // generate CPL=0 instructions
 	Exec       bool        // generate instructions sequences interesting for execution
 	MemRegions []MemRegion // generated instructions will reference these regions
+	// Percentage of privileged and Exec (pseudo) instructions, the rest are user instructions.
+	// If both are 0, all allowed instruction classes are equally likely.
+	PrivPercent int
+	ExecPercent int
 }
 
 type MemRegion struct {
@@ -75,6 +79,13 @@ const (
 	typeLast
 )
 
+// Instruction classes returned by RandClass.
+const (
+	ClassExec = typeExec
+	ClassPriv = typePriv
+	ClassUser = typeUser
+)
+
 var modeInsns [ModeLast][typeLast][]*Insn
 
 var (
@@ -198,17 +209,31 @@ func Mutate(cfg *Config, r *rand.Rand, text []byte) []byte {
 }
 
 func randInsn(cfg *Config, r *rand.Rand) *Insn {
-	var insns []*Insn
-	if cfg.Priv && cfg.Exec {
-		insns = modeInsns[cfg.Mode][r.Intn(3)]
-	} else if cfg.Priv {
-		insns = modeInsns[cfg.Mode][r.Intn(2)]
-	} else {
-		insns = modeInsns[cfg.Mode][typeUser]
-	}
+	insns := modeInsns[cfg.Mode][RandClass(cfg, r)]
 	return insns[r.Intn(len(insns))]
 }
 
+// RandClass returns class (ClassXXX) of the next instruction to generate according to cfg.
+func RandClass(cfg *Config, r *rand.Rand) int {
+	if !cfg.Priv {
+		return typeUser
+	}
+	if cfg.PrivPercent == 0 && cfg.ExecPercent == 0 {
+		if cfg.Exec {
+			return r.Intn(3)
+		}
+		return typePriv + r.Intn(2)
+	}
+	switch x := r.Intn(100); {
+	case cfg.Exec && x < cfg.ExecPercent:
+		return typeExec
+	case x < cfg.ExecPercent+cfg.PrivPercent:
+		return typePriv
+	default:
+		return typeUser
+	}
+}
+
 func split(cfg *Config, text []byte) [][]byte {
 	text = append([]byte{}, text...)
 	var insns [][]byte
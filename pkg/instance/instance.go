@@ -292,9 +292,9 @@ func (inst *inst) testInstance() error {
 		return &TestError{Title: fmt.Sprintf("failed to copy test binary to VM: %v", err)}
 	}
 
-	cmd := FuzzerCmd(fuzzerBin, executorBin, "test", inst.cfg.TargetOS, inst.cfg.TargetArch, fwdAddr,
-		inst.cfg.Sandbox, 0, 0, inst.cfg.Cover, false, true, false)
-	outc, errc, err := inst.vm.Run(10*time.Minute, nil, cmd)
+	cmd := FuzzerCmd(fuzzerBin, executorBin, "", "test", inst.cfg.TargetOS, inst.cfg.TargetArch, fwdAddr,
+		inst.cfg.Sandbox, 0, 0, inst.cfg.Slowdown, inst.cfg.Cover, false, true, false)
+	outc, errc, err := inst.vm.Run(10*time.Minute*time.Duration(inst.cfg.Slowdown), nil, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to run binary in VM: %v", err)
 	}
@@ -327,6 +327,13 @@ func (inst *inst) testRepro() error {
 	if err != nil {
 		return &TestError{Title: fmt.Sprintf("failed to copy test binary to VM: %v", err)}
 	}
+	descriptionsFile := ""
+	if cfg.ExtraDescriptionsFile != "" {
+		descriptionsFile, err = inst.vm.Copy(cfg.ExtraDescriptionsFile)
+		if err != nil {
+			return &TestError{Title: fmt.Sprintf("failed to copy descriptions to VM: %v", err)}
+		}
+	}
 	progFile := filepath.Join(cfg.Workdir, "repro.prog")
 	if err := osutil.WriteFile(progFile, inst.reproSyz); err != nil {
 		return fmt.Errorf("failed to write temp file: %v", err)
@@ -349,9 +356,9 @@ func (inst *inst) testRepro() error {
 	if !opts.Fault {
 		opts.FaultCall = -1
 	}
-	cmdSyz := ExecprogCmd(execprogBin, executorBin, cfg.TargetOS, cfg.TargetArch, opts.Sandbox,
-		true, true, true, cfg.Procs, opts.FaultCall, opts.FaultNth, vmProgFile)
-	if err := inst.testProgram(cmdSyz, 7*time.Minute); err != nil {
+	cmdSyz := ExecprogCmd(execprogBin, executorBin, descriptionsFile, cfg.TargetOS, cfg.TargetArch, opts.Sandbox,
+		true, true, true, cfg.Procs, opts.FaultCall, opts.FaultNth, cfg.Slowdown, vmProgFile)
+	if err := inst.testProgram(cmdSyz, 7*time.Minute*time.Duration(cfg.Slowdown)); err != nil {
 		return err
 	}
 	if len(inst.reproC) == 0 {
@@ -391,11 +398,11 @@ func (inst *inst) testProgram(command string, testTime time.Duration) error {
 	return &CrashError{Report: rep}
 }
 
-func FuzzerCmd(fuzzer, executor, name, OS, arch, fwdAddr, sandbox string, procs, verbosity int,
-	cover, debug, test, runtest bool) string {
+func FuzzerCmd(fuzzer, executor, descriptions, name, OS, arch, fwdAddr, sandbox string,
+	procs, verbosity, slowdown int, cover, debug, test, runtest bool) string {
 	osArg := ""
-	if OS == "akaros" {
-		// Only akaros needs OS, because the rest assume host OS.
+	if OS == "akaros" || OS == "zephyr" {
+		// Only akaros and zephyr need OS, because the rest assume host OS.
 		// But speciying OS for all OSes breaks patch testing on syzbot
 		// because old execprog does not have os flag.
 		osArg = " -os=" + OS
@@ -405,27 +412,45 @@ func FuzzerCmd(fuzzer, executor, name, OS, arch, fwdAddr, sandbox string, procs,
 		runtestArg = " -runtest"
 	}
 	return fmt.Sprintf("%v -executor=%v -name=%v -arch=%v%v -manager=%v -sandbox=%v"+
-		" -procs=%v -v=%d -cover=%v -debug=%v -test=%v%v",
+		" -procs=%v -v=%d -cover=%v -debug=%v -test=%v%v%v%v",
 		fuzzer, executor, name, arch, osArg, fwdAddr, sandbox,
-		procs, verbosity, cover, debug, test, runtestArg)
+		procs, verbosity, cover, debug, test, runtestArg, slowdownArg(slowdown), descriptionsArg(descriptions))
 }
 
-func ExecprogCmd(execprog, executor, OS, arch, sandbox string, repeat, threaded, collide bool,
-	procs, faultCall, faultNth int, progFile string) string {
+func ExecprogCmd(execprog, executor, descriptions, OS, arch, sandbox string, repeat, threaded, collide bool,
+	procs, faultCall, faultNth, slowdown int, progFile string) string {
 	repeatCount := 1
 	if repeat {
 		repeatCount = 0
 	}
 	osArg := ""
-	if OS == "akaros" {
+	if OS == "akaros" || OS == "zephyr" {
 		osArg = " -os=" + OS
 	}
 	return fmt.Sprintf("%v -executor=%v -arch=%v%v -sandbox=%v"+
 		" -procs=%v -repeat=%v -threaded=%v -collide=%v -cover=0"+
-		" -fault_call=%v -fault_nth=%v %v",
+		" -fault_call=%v -fault_nth=%v%v%v %v",
 		execprog, executor, arch, osArg, sandbox,
 		procs, repeatCount, threaded, collide,
-		faultCall, faultNth, progFile)
+		faultCall, faultNth, slowdownArg(slowdown), descriptionsArg(descriptions), progFile)
+}
+
+// slowdownArg is passed only for slow kernels, so that old binaries without the flag still work
+// (e.g. for patch testing of old kernels on syzbot).
+func slowdownArg(slowdown int) string {
+	if slowdown <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" -slowdown=%v", slowdown)
+}
+
+// descriptionsArg passes descriptions of out-of-tree modules (see extra_descriptions config param),
+// it's passed only if they are used for the same reason as slowdownArg.
+func descriptionsArg(file string) string {
+	if file == "" {
+		return ""
+	}
+	return " -extra_descriptions=" + file
 }
 
 var MakeBin = func() string {
@@ -37,6 +37,9 @@ const (
 	FlagEnableNetReset                                  // reset network namespace between programs
 	FlagEnableCgroups                                   // setup cgroups for testing
 	FlagEnableCloseFds                                  // close fds after each program
+	FlagSandboxProfile                                  // apply Config.SandboxProfile to test processes
+	FlagEnableNetRecycle                                // create a fresh net namespace for every program
+	FlagSandboxIdentity                                 // impersonate Config.SandboxIdentity instead of nobody
 	// Executor does not know about these:
 	FlagUseShmem      // use shared memory instead of pipes for communication
 	FlagUseForkServer // use extended protocol with handshake
@@ -46,18 +49,35 @@ const (
 type ExecFlags uint64
 
 const (
-	FlagCollectCover ExecFlags = 1 << iota // collect coverage
-	FlagDedupCover                         // deduplicate coverage in executor
-	FlagInjectFault                        // inject a fault in this execution (see ExecOpts)
-	FlagCollectComps                       // collect KCOV comparisons
-	FlagThreaded                           // use multiple threads to mitigate blocked syscalls
-	FlagCollide                            // collide syscalls to provoke data races
+	FlagCollectCover      ExecFlags = 1 << iota // collect coverage
+	FlagDedupCover                              // deduplicate coverage in executor
+	FlagInjectFault                             // inject a fault in this execution (see ExecOpts)
+	FlagCollectComps                            // collect KCOV comparisons
+	FlagThreaded                                // use multiple threads to mitigate blocked syscalls
+	FlagCollide                                 // collide syscalls to provoke data races
+	FlagRace                                    // race a pair of calls under a forced schedule (see ExecOpts)
+	FlagResetNamespace                          // run the program in fresh namespaces (see ResetNamespace)
+	FlagDetectSideEffects                       // check global state after every call (see SideEffects)
+)
+
+// RaceMode says how executor interleaves the raced calls.
+type RaceMode int
+
+const (
+	// Both calls run on the same CPU, the second call preempts the first one after RaceDelay.
+	RacePreempt RaceMode = iota
+	// Calls run on different CPUs, the second call is started RaceDelay after the first one.
+	RaceParallel
 )
 
 type ExecOpts struct {
 	Flags     ExecFlags
 	FaultCall int // call index for fault injection (0-based)
 	FaultNth  int // fault n-th operation in the call (0-based)
+	RaceCall1 int // first raced call index (0-based)
+	RaceCall2 int // second raced call index, must be larger than RaceCall1
+	RaceMode  RaceMode
+	RaceDelay int // delay of the second raced call in nanoseconds
 }
 
 // Config is the configuration for Env.
@@ -70,6 +90,106 @@ type Config struct {
 
 	// Timeout is the execution timeout for a single program.
 	Timeout time.Duration
+
+	// Slowdown scales all executor timeouts for slow kernels (e.g. with heavy debugging configs).
+	Slowdown int
+
+	// SandboxProfile is applied to test processes if FlagSandboxProfile is set (linux only).
+	SandboxProfile *SandboxProfile
+
+	// NetDevices limits network devices created with FlagEnableNetDev (all devices if 0).
+	NetDevices NetDevices
+
+	// SandboxIdentity is used by FlagSandboxSetuid if FlagSandboxIdentity is set (linux only).
+	SandboxIdentity *SandboxIdentity
+}
+
+// NetDevices is a set of network device groups.
+// Note: values correspond to NETDEV_* in executor/common_linux.h.
+type NetDevices uint64
+
+const (
+	NetDevVirtual   NetDevices = 1 << iota // dummy, vcan, nlmon, caif, batadv, vxcan, ip6gretap
+	NetDevVeth                             // veth0/veth1 pair
+	NetDevBridge                           // bridge0 with enslaved veth pairs
+	NetDevBond                             // bond0 with enslaved veth pairs
+	NetDevTeam                             // team0 with enslaved veth pairs
+	NetDevHSR                              // hsr0 with slave veth pairs
+	NetDevNetdevsim                        // netdevsimN
+)
+
+var netDevNames = map[string]NetDevices{
+	"virtual":   NetDevVirtual,
+	"veth":      NetDevVeth,
+	"bridge":    NetDevBridge,
+	"bond":      NetDevBond,
+	"team":      NetDevTeam,
+	"hsr":       NetDevHSR,
+	"netdevsim": NetDevNetdevsim,
+}
+
+// ParseNetDevices converts a list of device group names (e.g. "bridge") into a set.
+func ParseNetDevices(names []string) (NetDevices, error) {
+	var devs NetDevices
+	for _, name := range names {
+		dev, ok := netDevNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown network device group %q", name)
+		}
+		devs |= dev
+	}
+	return devs, nil
+}
+
+// SandboxProfile models userspace confinement of the fuzzed programs
+// (e.g. default profile of a container runtime). All parts are optional.
+type SandboxProfile struct {
+	Seccomp  []byte // seccomp filter as an array of struct sock_filter (see pkg/seccomp)
+	SELinux  string // SELinux context to switch to
+	AppArmor string // AppArmor profile to switch to
+}
+
+// SandboxIdentity is the user identity test processes run under with the setuid sandbox.
+type SandboxIdentity struct {
+	UID        uint32
+	GID        uint32
+	Groups     []uint32 // supplementary groups (at most MaxSandboxGroups)
+	NoNewPrivs bool     // set no_new_privs, so that execve can't grant privileges
+	CapBound   uint64   // capabilities kept in the bounding set, bit per CAP_* number (^0 keeps all)
+}
+
+const MaxSandboxGroups = 32
+
+// Note: indexes correspond to CAP_* values in linux/capability.h.
+var capabilityNames = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER", "CAP_FSETID",
+	"CAP_KILL", "CAP_SETGID", "CAP_SETUID", "CAP_SETPCAP", "CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST", "CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_IPC_LOCK",
+	"CAP_IPC_OWNER", "CAP_SYS_MODULE", "CAP_SYS_RAWIO", "CAP_SYS_CHROOT", "CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT", "CAP_SYS_ADMIN", "CAP_SYS_BOOT", "CAP_SYS_NICE", "CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_MKNOD", "CAP_LEASE", "CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL", "CAP_SETFCAP", "CAP_MAC_OVERRIDE", "CAP_MAC_ADMIN", "CAP_SYSLOG",
+	"CAP_WAKE_ALARM", "CAP_BLOCK_SUSPEND", "CAP_AUDIT_READ", "CAP_PERFMON", "CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// ParseCapabilities converts a list of capability names (e.g. "CAP_NET_RAW") into a set.
+func ParseCapabilities(names []string) (uint64, error) {
+	var caps uint64
+	for _, name := range names {
+		found := false
+		for cap, name1 := range capabilityNames {
+			if name1 == name {
+				caps |= 1 << uint(cap)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown capability %q", name)
+		}
+	}
+	return caps, nil
 }
 
 type CallFlags uint32
@@ -79,6 +199,7 @@ const (
 	CallFinished                            // finished executing (rather than blocked forever)
 	CallBlocked                             // finished but blocked during execution
 	CallFaultInjected                       // fault was injected into this call
+	CallRaced                               // call was raced with another call (see FlagRace)
 )
 
 type CallInfo struct {
@@ -86,8 +207,9 @@ type CallInfo struct {
 	Signal []uint32 // feedback signal, filled if FlagSignal is set
 	Cover  []uint32 // per-call coverage, filled if FlagSignal is set and cover == true,
 	// if dedup == false, then cov effectively contains a trace, otherwise duplicates are removed
-	Comps prog.CompMap // per-call comparison operands
-	Errno int          // call errno (0 if the call was successful)
+	Comps       prog.CompMap // per-call comparison operands
+	Errno       int          // call errno (0 if the call was successful)
+	SideEffects SideEffects  // global state changed by the call, filled if FlagDetectSideEffects is set
 }
 
 type ProgInfo struct {
@@ -189,6 +311,27 @@ func MakeEnv(config *Config, pid int) (*Env, error) {
 	if len(env.bin) == 0 {
 		return nil, fmt.Errorf("binary is empty string")
 	}
+	if config.Flags&FlagSandboxProfile != 0 {
+		if config.SandboxProfile == nil || config.Flags&FlagUseForkServer == 0 {
+			return nil, fmt.Errorf("sandbox profile requires the profile and fork server")
+		}
+		if _, err := config.SandboxProfile.serialize(); err != nil {
+			return nil, err
+		}
+	}
+	if config.Flags&FlagSandboxIdentity != 0 {
+		if config.SandboxIdentity == nil || config.Flags&FlagUseForkServer == 0 ||
+			config.Flags&FlagSandboxSetuid == 0 {
+			return nil, fmt.Errorf("sandbox identity requires the identity, fork server and setuid sandbox")
+		}
+		if _, err := config.SandboxIdentity.serialize(); err != nil {
+			return nil, err
+		}
+	}
+	unprivileged := config.Flags&(FlagSandboxSetuid|FlagSandboxAndroidUntrustedApp) != 0
+	if config.Flags&FlagEnableNetRecycle != 0 && (config.Flags&FlagUseForkServer == 0 || unprivileged) {
+		return nil, fmt.Errorf("net namespace recycling requires fork server and sandbox none/namespace")
+	}
 	env.bin[0] = osutil.Abs(env.bin[0]) // we are going to chdir
 	// Append pid to binary name.
 	// E.g. if binary is 'syz-executor' and pid=15,
@@ -245,6 +388,12 @@ var rateLimit = time.NewTicker(1 * time.Second)
 // hanged: program hanged and was killed
 // err0: failed to start the process or bug in executor itself
 func (env *Env) Exec(opts *ExecOpts, p *prog.Prog) (output []byte, info *ProgInfo, hanged bool, err0 error) {
+	unprivileged := env.config.Flags&(FlagSandboxSetuid|FlagSandboxAndroidUntrustedApp) != 0
+	if opts.Flags&FlagResetNamespace != 0 && (env.config.Flags&FlagUseForkServer == 0 || unprivileged) {
+		err0 = fmt.Errorf("namespace reset requires fork server and sandbox none/namespace")
+		return
+	}
+
 	// Copy-in serialized program.
 	progSize, err := p.SerializeForExec(env.in)
 	if err != nil {
@@ -338,7 +487,7 @@ func (env *Env) parseOutput(p *prog.Prog) (*ProgInfo, error) {
 			if int(reply.index) >= len(info.Calls) {
 				return nil, fmt.Errorf("bad call %v index %v/%v", i, reply.index, len(info.Calls))
 			}
-			if num := p.Calls[reply.index].Meta.ID; int(reply.num) != num {
+			if num := p.Calls[reply.index].Meta.ExecID(); int(reply.num) != num {
 				return nil, fmt.Errorf("wrong call %v num %v/%v", i, reply.num, num)
 			}
 			inf = &info.Calls[reply.index]
@@ -347,6 +496,7 @@ func (env *Env) parseOutput(p *prog.Prog) (*ProgInfo, error) {
 			}
 			inf.Errno = int(reply.errno)
 			inf.Flags = CallFlags(reply.flags)
+			inf.SideEffects = SideEffects(reply.sideEffects)
 		} else {
 			extraParts = append(extraParts, CallInfo{})
 			inf = &extraParts[len(extraParts)-1]
@@ -483,15 +633,72 @@ const (
 )
 
 type handshakeReq struct {
-	magic uint64
-	flags uint64 // env flags
-	pid   uint64
+	magic      uint64
+	flags      uint64 // env flags
+	pid        uint64
+	netDevices uint64
 }
 
 type handshakeReply struct {
 	magic uint32
 }
 
+// sandboxProfileReq follows handshakeReq if FlagSandboxProfile is set.
+type sandboxProfileReq struct {
+	seccompLen uint64 // number of seccomp filter instructions that follow the request
+	selinux    [256]byte
+	apparmor   [256]byte
+	// seccomp filter follows
+}
+
+const seccompInstrSize = 8 // sizeof(struct sock_filter)
+
+func (p *SandboxProfile) serialize() ([]byte, error) {
+	req := &sandboxProfileReq{
+		seccompLen: uint64(len(p.Seccomp) / seccompInstrSize),
+	}
+	if len(p.Seccomp)%seccompInstrSize != 0 {
+		return nil, fmt.Errorf("bad seccomp filter size %v", len(p.Seccomp))
+	}
+	if len(p.SELinux) >= len(req.selinux) || len(p.AppArmor) >= len(req.apparmor) {
+		return nil, fmt.Errorf("too long sandbox profile name")
+	}
+	copy(req.selinux[:], p.SELinux)
+	copy(req.apparmor[:], p.AppArmor)
+	reqData := (*[unsafe.Sizeof(*req)]byte)(unsafe.Pointer(req))[:]
+	return append(append([]byte{}, reqData...), p.Seccomp...), nil
+}
+
+// sandboxIdentityReq follows handshakeReq (and sandboxProfileReq) if FlagSandboxIdentity is set.
+type sandboxIdentityReq struct {
+	uid        uint64
+	gid        uint64
+	noNewPrivs uint64
+	capBound   uint64
+	ngroups    uint64
+	groups     [MaxSandboxGroups]uint64
+}
+
+func (id *SandboxIdentity) serialize() ([]byte, error) {
+	if len(id.Groups) > MaxSandboxGroups {
+		return nil, fmt.Errorf("too many supplementary groups %v (max %v)", len(id.Groups), MaxSandboxGroups)
+	}
+	req := &sandboxIdentityReq{
+		uid:      uint64(id.UID),
+		gid:      uint64(id.GID),
+		capBound: id.CapBound,
+		ngroups:  uint64(len(id.Groups)),
+	}
+	if id.NoNewPrivs {
+		req.noNewPrivs = 1
+	}
+	for i, group := range id.Groups {
+		req.groups[i] = uint64(group)
+	}
+	reqData := (*[unsafe.Sizeof(*req)]byte)(unsafe.Pointer(req))[:]
+	return append([]byte{}, reqData...), nil
+}
+
 type executeReq struct {
 	magic     uint64
 	envFlags  uint64 // env flags
@@ -499,6 +706,11 @@ type executeReq struct {
 	pid       uint64
 	faultCall uint64
 	faultNth  uint64
+	raceCall1 uint64
+	raceCall2 uint64
+	raceMode  uint64
+	raceDelay uint64
+	slowdown  uint64
 	progSize  uint64
 	// prog follows on pipe or in shmem
 }
@@ -512,13 +724,14 @@ type executeReply struct {
 }
 
 type callReply struct {
-	index      uint32 // call index in the program
-	num        uint32 // syscall number (for cross-checking)
-	errno      uint32
-	flags      uint32 // see CallFlags
-	signalSize uint32
-	coverSize  uint32
-	compsSize  uint32
+	index       uint32 // call index in the program
+	num         uint32 // syscall number (for cross-checking)
+	errno       uint32
+	flags       uint32 // see CallFlags
+	sideEffects uint32 // see SideEffects
+	signalSize  uint32
+	coverSize   uint32
+	compsSize   uint32
 	// signal/cover/comps follow
 }
 
@@ -651,11 +864,26 @@ func (c *command) close() {
 // handshake sends handshakeReq and waits for handshakeReply.
 func (c *command) handshake() error {
 	req := &handshakeReq{
-		magic: inMagic,
-		flags: uint64(c.config.Flags),
-		pid:   uint64(c.pid),
+		magic:      inMagic,
+		flags:      uint64(c.config.Flags),
+		pid:        uint64(c.pid),
+		netDevices: uint64(c.config.NetDevices),
 	}
 	reqData := (*[unsafe.Sizeof(*req)]byte)(unsafe.Pointer(req))[:]
+	if c.config.Flags&FlagSandboxProfile != 0 {
+		profileData, err := c.config.SandboxProfile.serialize()
+		if err != nil {
+			return c.handshakeError(err)
+		}
+		reqData = append(append([]byte{}, reqData...), profileData...)
+	}
+	if c.config.Flags&FlagSandboxIdentity != 0 {
+		identityData, err := c.config.SandboxIdentity.serialize()
+		if err != nil {
+			return c.handshakeError(err)
+		}
+		reqData = append(append([]byte{}, reqData...), identityData...)
+	}
 	if _, err := c.outwp.Write(reqData); err != nil {
 		return c.handshakeError(fmt.Errorf("failed to write control pipe: %v", err))
 	}
@@ -715,6 +943,11 @@ func (c *command) exec(opts *ExecOpts, progData []byte) (output []byte, hanged b
 		pid:       uint64(c.pid),
 		faultCall: uint64(opts.FaultCall),
 		faultNth:  uint64(opts.FaultNth),
+		raceCall1: uint64(opts.RaceCall1),
+		raceCall2: uint64(opts.RaceCall2),
+		raceMode:  uint64(opts.RaceMode),
+		raceDelay: uint64(opts.RaceDelay),
+		slowdown:  uint64(c.config.Slowdown),
 		progSize:  uint64(len(progData)),
 	}
 	reqData := (*[unsafe.Sizeof(*req)]byte)(unsafe.Pointer(req))[:]
@@ -767,13 +1000,24 @@ func (c *command) exec(opts *ExecOpts, progData []byte) (output []byte, hanged b
 		if _, err := io.ReadFull(c.inrp, callReplyData); err != nil {
 			break
 		}
-		if callReply.signalSize != 0 || callReply.coverSize != 0 || callReply.compsSize != 0 {
+		if callReply.compsSize != 0 {
 			// This is unsupported yet.
-			fmt.Fprintf(os.Stderr, "executor %v: got call reply with coverage\n", c.pid)
+			fmt.Fprintf(os.Stderr, "executor %v: got call reply with comparisons\n", c.pid)
 			os.Exit(1)
 		}
 		copy(outmem, callReplyData)
 		outmem = outmem[len(callReplyData):]
+		// Signal and coverage follow the reply in the same layout as in the shmem output.
+		coverSize := int(callReply.signalSize+callReply.coverSize) * 4
+		if coverSize > len(outmem) {
+			fmt.Fprintf(os.Stderr, "executor %v: output overflow\n", c.pid)
+			os.Exit(1)
+		}
+		coverData := outmem[:coverSize]
+		if _, err := io.ReadFull(c.inrp, coverData); err != nil {
+			break
+		}
+		outmem = outmem[len(coverData):]
 		*completedCalls++
 	}
 	close(done)
@@ -818,6 +1062,9 @@ func sanitizeTimeout(config *Config) time.Duration {
 			timeout = executorTimeout
 		}
 	}
+	if config.Slowdown > 1 {
+		timeout *= time.Duration(config.Slowdown)
+	}
 	// IPC timeout must be larger then executor timeout.
 	// Otherwise IPC will kill parent executor but leave child executor alive.
 	if config.Flags&FlagUseForkServer != 0 && timeout < minTimeout {
@@ -16,6 +16,7 @@ import (
 	. "github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/ipc/ipcconfig"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/seccomp"
 	"github.com/google/syzkaller/prog"
 	_ "github.com/google/syzkaller/sys"
 )
@@ -116,6 +117,286 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+func TestSandboxProfile(t *testing.T) {
+	target, _, _, configFlags := initTest(t)
+	if target.OS != "linux" {
+		t.Skip("sandbox profiles are supported only on linux")
+	}
+	// The simple program maps the data page, fail only this mmap.
+	profile, err := seccomp.Parse([]byte(fmt.Sprintf(`{
+		"defaultAction": "SCMP_ACT_ALLOW",
+		"syscalls": [{"names": ["mmap"], "action": "SCMP_ACT_ERRNO", "errnoRet": 95,
+			"args": [{"index": 0, "value": %v, "op": "SCMP_CMP_EQ"}]}]
+	}`, target.DataOffset)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := seccomp.Compile(profile, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := buildExecutor(t, target)
+	defer os.Remove(bin)
+	cfg := &Config{
+		Executor:       bin,
+		Flags:          configFlags | FlagSandboxProfile,
+		Timeout:        timeout,
+		SandboxProfile: &SandboxProfile{Seccomp: filter},
+	}
+	env, err := MakeEnv(cfg, 0)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env.Close()
+	for _, flag := range []ExecFlags{0, FlagThreaded} {
+		output, info, hanged, err := env.Exec(&ExecOpts{Flags: flag}, target.GenerateSimpleProg())
+		if err != nil {
+			t.Fatalf("failed to run executor: %v", err)
+		}
+		if hanged {
+			t.Fatalf("program hanged:\n%s", output)
+		}
+		if len(info.Calls) == 0 || info.Calls[0].Errno != 95 {
+			t.Fatalf("mmap was not filtered: %+v\n%s", info.Calls, output)
+		}
+	}
+}
+
+func TestNetRecycle(t *testing.T) {
+	target, _, _, configFlags := initTest(t)
+	if target.OS != "linux" || os.Getuid() != 0 {
+		t.Skip("net namespace recycling requires root on linux")
+	}
+	bin := buildExecutor(t, target)
+	defer os.Remove(bin)
+	cfg := &Config{
+		Executor:   bin,
+		Flags:      configFlags | FlagEnableNetDev | FlagEnableNetRecycle,
+		Timeout:    timeout,
+		NetDevices: NetDevVirtual | NetDevVeth,
+	}
+	env, err := MakeEnv(cfg, 0)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env.Close()
+	for i := 0; i < 3; i++ {
+		output, info, hanged, err := env.Exec(&ExecOpts{}, target.GenerateSimpleProg())
+		if err != nil {
+			t.Fatalf("failed to run executor: %v\n%s", err, output)
+		}
+		if hanged {
+			t.Fatalf("program hanged:\n%s", output)
+		}
+		if len(info.Calls) == 0 || info.Calls[0].Errno != 0 {
+			t.Fatalf("simple call failed: %+v\n%s", info.Calls, output)
+		}
+	}
+	cfg.Flags |= FlagSandboxSetuid
+	if _, err := MakeEnv(cfg, 0); err == nil {
+		t.Fatalf("no error for net namespace recycling with setuid sandbox")
+	}
+}
+
+func TestResetNamespace(t *testing.T) {
+	target, _, _, configFlags := initTest(t)
+	if target.OS != "linux" || os.Getuid() != 0 {
+		t.Skip("namespace reset requires root on linux")
+	}
+	bin := buildExecutor(t, target)
+	defer os.Remove(bin)
+	cfg := &Config{
+		Executor: bin,
+		Flags:    configFlags,
+		Timeout:  timeout,
+	}
+	env, err := MakeEnv(cfg, 0)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env.Close()
+	p, err := target.Deserialize([]byte(`mkdir(&(0x7f0000000000)='./file0\x00', 0x0)
+mount(0x0, &(0x7f0000000000)='./file0\x00', &(0x7f0000000040)='tmpfs\x00', 0x0, 0x0)
+`), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &ExecOpts{Flags: FlagResetNamespace}
+	for i := 0; i < 3; i++ {
+		output, info, hanged, err := env.Exec(opts, p)
+		if err != nil {
+			t.Fatalf("failed to run executor: %v\n%s", err, output)
+		}
+		if hanged {
+			t.Fatalf("program hanged:\n%s", output)
+		}
+		if len(info.Calls) != 2 || info.Calls[1].Errno != 0 {
+			t.Fatalf("mount failed: %+v\n%s", info.Calls, output)
+		}
+	}
+	cfg.Flags |= FlagSandboxSetuid
+	env1, err := MakeEnv(cfg, 1)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env1.Close()
+	if _, _, _, err := env1.Exec(opts, p); err == nil {
+		t.Fatalf("no error for namespace reset with setuid sandbox")
+	}
+}
+
+func TestSideEffects(t *testing.T) {
+	target, _, _, configFlags := initTest(t)
+	if target.OS != "linux" || os.Getuid() != 0 {
+		t.Skip("side effects detection requires root on linux")
+	}
+	bin := buildExecutor(t, target)
+	defer os.Remove(bin)
+	cfg := &Config{
+		Executor: bin,
+		Flags:    configFlags,
+		Timeout:  timeout,
+	}
+	env, err := MakeEnv(cfg, 0)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env.Close()
+	// Mounts in the work dir are not side effects, the parent dir is outside of the work dir.
+	// Namespace reset makes sure that the mount does not leak out of the test.
+	p, err := target.Deserialize([]byte(`mkdir(&(0x7f0000000000)='./file0\x00', 0x0)
+mount(0x0, &(0x7f0000000000)='./file0\x00', &(0x7f0000000040)='tmpfs\x00', 0x0, 0x0)
+mount(0x0, &(0x7f0000000080)='..\x00', &(0x7f0000000040)='tmpfs\x00', 0x0, 0x0)
+`), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &ExecOpts{Flags: FlagResetNamespace | FlagDetectSideEffects}
+	output, info, hanged, err := env.Exec(opts, p)
+	if err != nil {
+		t.Fatalf("failed to run executor: %v\n%s", err, output)
+	}
+	if hanged {
+		t.Fatalf("program hanged:\n%s", output)
+	}
+	want := []SideEffects{0, 0, SideEffectMount}
+	for i, inf := range info.Calls {
+		if inf.Errno != 0 {
+			t.Fatalf("call %v failed: %+v\n%s", i, info.Calls, output)
+		}
+		if inf.SideEffects != want[i] {
+			t.Errorf("call %v: got side effects %q, want %q", i, inf.SideEffects, want[i])
+		}
+	}
+}
+
+func TestSideEffectsString(t *testing.T) {
+	tests := []struct {
+		effects SideEffects
+		str     string
+		level   ResetLevel
+	}{
+		{0, "", ResetProc},
+		{SideEffectMount, "mount", ResetNamespace},
+		{SideEffectMount | SideEffectLoop, "mount,loop", ResetReboot},
+		{SideEffectModule | SideEffectSysctl, "module,sysctl", ResetReboot},
+	}
+	for _, test := range tests {
+		if str := test.effects.String(); str != test.str {
+			t.Errorf("%#x: got %q, want %q", uint32(test.effects), str, test.str)
+		}
+		if level := test.effects.ResetLevel(); level != test.level {
+			t.Errorf("%#x: got level %v, want %v", uint32(test.effects), level, test.level)
+		}
+	}
+}
+
+func TestResetLevels(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := ParseResetLevels(map[string]string{ResetClassIPC: "proc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels, err := MakeResetLevels(overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		prog  string
+		level ResetLevel
+	}{
+		{"getpid()\n", ResetProc},
+		{"getpid()\nchroot(0x0)\n", ResetNamespace},
+		{"chroot(0x0)\ninit_module(0x0, 0x0, 0x0)\n", ResetReboot},
+		{"msgget(0x0, 0x0)\n", ResetProc},
+	}
+	for _, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), prog.NonStrict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if level := levels.Prog(p); level != test.level {
+			t.Errorf("program:\n%v\ngot level %v, want %v", test.prog, level, test.level)
+		}
+	}
+	if _, err := ParseResetLevels(map[string]string{"foo": "proc"}); err == nil {
+		t.Errorf("no error for unknown program class")
+	}
+	if _, err := ParseResetLevels(map[string]string{ResetClassMount: "foo"}); err == nil {
+		t.Errorf("no error for unknown reset level")
+	}
+}
+
+func TestSandboxIdentity(t *testing.T) {
+	caps, err := ParseCapabilities([]string{"CAP_CHOWN", "CAP_NET_RAW"})
+	if err != nil || caps != 1<<0|1<<13 {
+		t.Fatalf("bad capabilities 0x%x: %v", caps, err)
+	}
+	if _, err := ParseCapabilities([]string{"NET_RAW"}); err == nil {
+		t.Fatalf("no error for unknown capability")
+	}
+	target, _, _, configFlags := initTest(t)
+	if target.OS != "linux" || os.Getuid() != 0 {
+		t.Skip("sandbox identity requires root on linux")
+	}
+	bin := buildExecutor(t, target)
+	defer os.Remove(bin)
+	cfg := &Config{
+		Executor: bin,
+		Flags:    configFlags | FlagSandboxIdentity,
+		Timeout:  timeout,
+		SandboxIdentity: &SandboxIdentity{
+			UID:        1000,
+			GID:        1000,
+			Groups:     []uint32{36, 1001},
+			NoNewPrivs: true,
+			CapBound:   caps,
+		},
+	}
+	if _, err := MakeEnv(cfg, 0); err == nil {
+		t.Fatalf("no error for sandbox identity without setuid sandbox")
+	}
+	cfg.Flags |= FlagSandboxSetuid
+	env, err := MakeEnv(cfg, 0)
+	if err != nil {
+		t.Fatalf("failed to create env: %v", err)
+	}
+	defer env.Close()
+	output, info, hanged, err := env.Exec(&ExecOpts{}, target.GenerateSimpleProg())
+	if err != nil {
+		t.Fatalf("failed to run executor: %v\n%s", err, output)
+	}
+	if hanged {
+		t.Fatalf("program hanged:\n%s", output)
+	}
+	if len(info.Calls) == 0 || info.Calls[0].Errno != 0 {
+		t.Fatalf("simple call failed: %+v\n%s", info.Calls, output)
+	}
+}
+
 func TestParallel(t *testing.T) {
 	target, _, _, configFlags := initTest(t)
 	bin := buildExecutor(t, target)
@@ -19,12 +19,14 @@ var (
 	flagSandbox  = flag.String("sandbox", "none", "sandbox for fuzzing (none/setuid/namespace/android_untrusted_app)")
 	flagDebug    = flag.Bool("debug", false, "debug output from executor")
 	flagTimeout  = flag.Duration("timeout", 0, "execution timeout")
+	flagSlowdown = flag.Int("slowdown", 1, "scale executor timeouts by this factor for slow kernels")
 )
 
 func Default(target *prog.Target) (*ipc.Config, *ipc.ExecOpts, error) {
 	c := &ipc.Config{
 		Executor: *flagExecutor,
 		Timeout:  *flagTimeout,
+		Slowdown: *flagSlowdown,
 	}
 	if *flagSignal {
 		c.Flags |= ipc.FlagSignal
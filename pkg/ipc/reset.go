@@ -0,0 +1,160 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ipc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// ResetLevel says how thoroughly the execution environment is reset for a program.
+// Programs that leave persistent state behind (mounts, loaded modules) make subsequent
+// programs behave differently and crashes hard to reproduce, but higher levels are more expensive,
+// so they are used only for the program classes that are suspected to leave such state.
+type ResetLevel int
+
+const (
+	// Fresh test process (done for all programs).
+	ResetProc ResetLevel = iota
+	// Fresh mount and ipc namespaces and a tmpfs work dir (FlagResetNamespace, linux only).
+	ResetNamespace
+	// Reboot of the machine after the program. It's not done by Env, but by syz-fuzzer/syz-manager.
+	ResetReboot
+)
+
+var resetLevelNames = []string{
+	ResetProc:      "proc",
+	ResetNamespace: "namespace",
+	ResetReboot:    "reboot",
+}
+
+func (level ResetLevel) String() string {
+	return resetLevelNames[level]
+}
+
+func ParseResetLevel(name string) (ResetLevel, error) {
+	for level, name1 := range resetLevelNames {
+		if name1 == name {
+			return ResetLevel(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown reset level %q (want proc/namespace/reboot)", name)
+}
+
+// Classes of programs that are suspected to leave persistent state behind.
+const (
+	ResetClassMount  = "mount"  // mount filesystems or change root
+	ResetClassIPC    = "ipc"    // create System V IPC objects
+	ResetClassModule = "module" // load/unload kernel modules
+)
+
+var resetClassCalls = map[string][]string{
+	ResetClassMount: {"mount", "umount2", "syz_mount_image", "fsmount", "move_mount", "open_tree",
+		"pivot_root", "chroot"},
+	ResetClassIPC:    {"msgget", "semget", "shmget"},
+	ResetClassModule: {"init_module", "finit_module", "delete_module"},
+}
+
+// DefaultResetLevels returns reset levels for all program classes.
+func DefaultResetLevels() map[string]ResetLevel {
+	return map[string]ResetLevel{
+		ResetClassMount:  ResetNamespace,
+		ResetClassIPC:    ResetNamespace,
+		ResetClassModule: ResetReboot,
+	}
+}
+
+// ParseResetLevels converts program class -> level name map (e.g. "module": "namespace")
+// into program class -> level map.
+func ParseResetLevels(names map[string]string) (map[string]ResetLevel, error) {
+	levels := make(map[string]ResetLevel)
+	for class, name := range names {
+		level, err := ParseResetLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[class] = level
+	}
+	if _, err := MakeResetLevels(levels); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// ResetLevels maps call names (prog.Syscall.CallName) to reset levels.
+type ResetLevels map[string]ResetLevel
+
+// MakeResetLevels creates ResetLevels from per-class levels (classes not present in levels
+// get the default level).
+func MakeResetLevels(levels map[string]ResetLevel) (ResetLevels, error) {
+	res := make(ResetLevels)
+	for class, level := range DefaultResetLevels() {
+		if level1, ok := levels[class]; ok {
+			level = level1
+		}
+		for _, call := range resetClassCalls[class] {
+			res[call] = level
+		}
+	}
+	for class := range levels {
+		if resetClassCalls[class] == nil {
+			return nil, fmt.Errorf("unknown program class %q (want mount/ipc/module)", class)
+		}
+	}
+	return res, nil
+}
+
+// Prog returns the reset level required for p, which is the max level of its calls.
+func (levels ResetLevels) Prog(p *prog.Prog) ResetLevel {
+	res := ResetProc
+	for _, c := range p.Calls {
+		if level := levels[c.Meta.CallName]; res < level {
+			res = level
+		}
+	}
+	return res
+}
+
+// Call returns the reset level required for the call.
+func (levels ResetLevels) Call(meta *prog.Syscall) ResetLevel {
+	return levels[meta.CallName]
+}
+
+// SideEffects is a mask of global state changes detected after a call (see FlagDetectSideEffects).
+// The values must match side_effect_* in executor_linux.h.
+type SideEffects uint32
+
+const (
+	SideEffectMount  SideEffects = 1 << iota // mount table has changed (outside of the work dir)
+	SideEffectModule                         // set of loaded kernel modules has changed
+	SideEffectSysctl                         // global sysctls (panic_on_oops, printk, etc) have changed
+	SideEffectLoop                           // loop devices were created or left attached
+)
+
+var sideEffectNames = []string{"mount", "module", "sysctl", "loop"}
+
+func (effects SideEffects) String() string {
+	var names []string
+	for i, name := range sideEffectNames {
+		if effects&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ResetLevel returns the reset level required to undo the effects.
+// Mounts go away with the mount namespace, everything else requires a reboot.
+func (effects SideEffects) ResetLevel() ResetLevel {
+	switch {
+	case effects&^SideEffectMount != 0:
+		return ResetReboot
+	case effects != 0:
+		return ResetNamespace
+	default:
+		return ResetProc
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package kconfig implements simple manipulations with Linux kernel .config files.
+// It does not know about Kconfig dependencies, these are resolved by make oldconfig during build.
+package kconfig
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var optionRe = regexp.MustCompile(`^(?:(CONFIG_[A-Za-z0-9_]+)=.*|# (CONFIG_[A-Za-z0-9_]+) is not set)$`)
+
+// Merge merges config fragments into the config (similar to scripts/kconfig/merge_config.sh):
+// options set in fragments replace the same options in config, new options are appended.
+func Merge(config []byte, fragments ...[]byte) []byte {
+	lines := bytes.Split(config, []byte{'\n'})
+	index := make(map[string]int)
+	for i, line := range lines {
+		if name := Option(line); name != "" {
+			index[name] = i
+		}
+	}
+	if len(lines) != 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	for _, frag := range fragments {
+		for _, line := range bytes.Split(frag, []byte{'\n'}) {
+			name := Option(line)
+			if name == "" {
+				continue
+			}
+			if i, ok := index[name]; ok {
+				lines[i] = line
+				continue
+			}
+			index[name] = len(lines)
+			lines = append(lines, line)
+		}
+	}
+	return append(bytes.Join(lines, []byte{'\n'}), '\n')
+}
+
+// Diff returns option lines of config that are missing in base or have a different value there.
+// Options that are not set in config and are missing in base are not returned.
+func Diff(base, config []byte) [][]byte {
+	values := make(map[string]string)
+	for _, line := range bytes.Split(base, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if name := Option(line); name != "" {
+			values[name] = string(line)
+		}
+	}
+	var diff [][]byte
+	for _, line := range bytes.Split(config, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		name := Option(line)
+		if name == "" {
+			continue
+		}
+		val, ok := values[name]
+		if !ok && bytes.HasPrefix(line, []byte("# ")) || val == string(line) {
+			continue
+		}
+		diff = append(diff, line)
+	}
+	return diff
+}
+
+// Option returns name of the option set by the config line, or an empty string.
+func Option(line []byte) string {
+	match := optionRe.FindSubmatch(bytes.TrimSpace(line))
+	if match == nil {
+		return ""
+	}
+	if len(match[1]) != 0 {
+		return string(match[1])
+	}
+	return string(match[2])
+}
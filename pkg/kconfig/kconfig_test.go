@@ -0,0 +1,58 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package kconfig
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	config := `CONFIG_A=y
+# CONFIG_KASAN is not set
+CONFIG_B="foo"
+# some comment
+`
+	fragment1 := `CONFIG_KASAN=y
+CONFIG_KASAN_INLINE=y
+`
+	fragment2 := `# CONFIG_KASAN is not set
+CONFIG_KCSAN=y
+CONFIG_B="bar"
+`
+	want := `CONFIG_A=y
+# CONFIG_KASAN is not set
+CONFIG_B="bar"
+# some comment
+CONFIG_KASAN_INLINE=y
+CONFIG_KCSAN=y
+`
+	if got := string(Merge([]byte(config), []byte(fragment1), []byte(fragment2))); got != want {
+		t.Fatalf("bad merged config:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	base := `CONFIG_A=y
+CONFIG_B=m
+# CONFIG_C is not set
+`
+	config := `CONFIG_A=y
+CONFIG_B=y
+CONFIG_C=y
+# CONFIG_D is not set
+# some comment
+CONFIG_E="foo"
+`
+	want := []string{"CONFIG_B=y", "CONFIG_C=y", `CONFIG_E="foo"`}
+	diff := Diff([]byte(base), []byte(config))
+	if len(diff) != len(want) {
+		t.Fatalf("bad diff: %q, want: %q", diff, want)
+	}
+	for i := range want {
+		if !bytes.Equal(diff[i], []byte(want[i])) {
+			t.Fatalf("bad diff: %q, want: %q", diff, want)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package ktypes loads type information from the kernel debug info (BTF or DWARF).
+package ktypes
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+)
+
+// Type is a kernel type loaded from BTF or DWARF.
+// Qualifiers (const, volatile, restrict) are dropped during loading.
+type Type struct {
+	Kind   Kind
+	Name   string // struct/union/enum/typedef/int name, empty for anonymous types
+	Size   int64  // in bytes, -1 if unknown
+	Signed bool
+	Elem   *Type // pointee/element/typedef target
+	Count  int64 // number of array elements, -1 for flexible arrays
+	Fields []*Field
+}
+
+// Field is a struct or union field.
+type Field struct {
+	Name    string // empty for anonymous struct/union fields
+	Off     int64  // in bits
+	BitSize int64  // 0 if not a bitfield
+	Type    *Type
+}
+
+type Kind int
+
+const (
+	KindVoid Kind = iota
+	KindInt
+	KindEnum
+	KindPtr
+	KindArray
+	KindStruct
+	KindUnion
+	KindTypedef
+	KindFunc
+)
+
+// Types is the subset of the kernel debug info: structs, typedefs and enumerators.
+type Types struct {
+	Enumerators map[string]int64
+	structs     map[string]*Type // both structs and unions, they share the namespace in C
+	typedefs    map[string]*Type
+	// Loaded lazily since conversion of all kernel types is expensive.
+	lookupStruct  func(name string) *Type
+	lookupTypedef func(name string) *Type
+}
+
+// Struct returns struct or union with the name, or nil if there is no such type.
+func (kt *Types) Struct(name string) *Type {
+	return lazyLookup(kt.structs, kt.lookupStruct, name)
+}
+
+// Typedef returns typedef with the name, or nil if there is no such type.
+func (kt *Types) Typedef(name string) *Type {
+	return lazyLookup(kt.typedefs, kt.lookupTypedef, name)
+}
+
+func lazyLookup(cache map[string]*Type, lookup func(name string) *Type, name string) *Type {
+	if t, ok := cache[name]; ok {
+		return t
+	}
+	var t *Type
+	if lookup != nil {
+		t = lookup(name)
+	}
+	cache[name] = t
+	return t
+}
+
+// Load loads types from the .BTF section of the kernel binary if present (CONFIG_DEBUG_INFO_BTF=y),
+// otherwise from DWARF (CONFIG_DEBUG_INFO=y).
+func Load(vmlinux string, preferDWARF bool) (*Types, error) {
+	file, err := elf.Open(vmlinux)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if sec := file.Section(".BTF"); sec != nil && !preferDWARF {
+		data, err := sec.Data()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .BTF section: %v", err)
+		}
+		ptrSize := int64(8)
+		if file.Class == elf.ELFCLASS32 {
+			ptrSize = 4
+		}
+		return parseBTF(data, file.ByteOrder, ptrSize)
+	}
+	data, err := file.DWARF()
+	if err != nil {
+		return nil, fmt.Errorf("%v has neither BTF nor DWARF debug info: %v", vmlinux, err)
+	}
+	return loadDWARF(data)
+}
+
+func newTypes() *Types {
+	return &Types{
+		structs:     make(map[string]*Type),
+		typedefs:    make(map[string]*Type),
+		Enumerators: make(map[string]int64),
+	}
+}
+
+func loadDWARF(data *dwarf.Data) (*Types, error) {
+	kt := newTypes()
+	structOffs := make(map[string]dwarf.Offset)
+	typedefOffs := make(map[string]dwarf.Offset)
+	for r := data.Reader(); ; {
+		e, err := r.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read debug info: %v", err)
+		}
+		if e == nil {
+			break
+		}
+		name, _ := e.Val(dwarf.AttrName).(string)
+		if name == "" {
+			continue
+		}
+		switch e.Tag {
+		case dwarf.TagStructType, dwarf.TagUnionType:
+			if decl, _ := e.Val(dwarf.AttrDeclaration).(bool); decl {
+				continue
+			}
+			if _, ok := structOffs[name]; !ok {
+				structOffs[name] = e.Offset
+			}
+		case dwarf.TagTypedef:
+			if _, ok := typedefOffs[name]; !ok {
+				typedefOffs[name] = e.Offset
+			}
+		case dwarf.TagEnumerator:
+			if _, ok := kt.Enumerators[name]; !ok {
+				val, _ := e.Val(dwarf.AttrConstValue).(int64)
+				kt.Enumerators[name] = val
+			}
+		}
+	}
+	conv := &dwarfConverter{cache: make(map[dwarf.Type]*Type)}
+	lookup := func(offs map[string]dwarf.Offset) func(name string) *Type {
+		return func(name string) *Type {
+			off, ok := offs[name]
+			if !ok {
+				return nil
+			}
+			typ, err := data.Type(off)
+			if err != nil {
+				return nil
+			}
+			return conv.convert(typ)
+		}
+	}
+	kt.lookupStruct = lookup(structOffs)
+	kt.lookupTypedef = lookup(typedefOffs)
+	return kt, nil
+}
+
+type dwarfConverter struct {
+	cache map[dwarf.Type]*Type
+}
+
+func (conv *dwarfConverter) convert(typ dwarf.Type) *Type {
+	if t := conv.cache[typ]; t != nil {
+		return t
+	}
+	t := &Type{Size: typ.Size()}
+	// Cache the type before converting children, structs may be recursive.
+	conv.cache[typ] = t
+	switch typ := typ.(type) {
+	case *dwarf.QualType:
+		res := conv.convert(typ.Type)
+		conv.cache[typ] = res
+		return res
+	case *dwarf.IntType, *dwarf.CharType:
+		t.Kind, t.Name, t.Signed = KindInt, typ.Common().Name, true
+	case *dwarf.UintType, *dwarf.UcharType, *dwarf.BoolType:
+		t.Kind, t.Name = KindInt, typ.Common().Name
+	case *dwarf.EnumType:
+		t.Kind, t.Name = KindEnum, typ.EnumName
+	case *dwarf.PtrType:
+		t.Kind, t.Elem = KindPtr, conv.convert(typ.Type)
+	case *dwarf.ArrayType:
+		t.Kind, t.Elem, t.Count = KindArray, conv.convert(typ.Type), typ.Count
+	case *dwarf.TypedefType:
+		t.Kind, t.Name, t.Elem = KindTypedef, typ.Name, conv.convert(typ.Type)
+	case *dwarf.StructType:
+		t.Kind, t.Name = KindStruct, typ.StructName
+		if typ.Kind == "union" {
+			t.Kind = KindUnion
+		}
+		for _, f := range typ.Field {
+			off := f.ByteOffset * 8
+			if f.BitSize != 0 {
+				if f.ByteSize != 0 {
+					// DWARF 2/3: BitOffset is counted from the most significant bit of the storage unit.
+					off += f.ByteSize*8 - f.BitOffset - f.BitSize
+				} else {
+					// DWARF 4+: DataBitOffset is counted from the beginning of the struct.
+					off += f.DataBitOffset
+				}
+			}
+			t.Fields = append(t.Fields, &Field{
+				Name:    f.Name,
+				Off:     off,
+				BitSize: f.BitSize,
+				Type:    conv.convert(f.Type),
+			})
+		}
+	case *dwarf.FuncType:
+		t.Kind = KindFunc
+	case *dwarf.VoidType:
+		t.Kind = KindVoid
+	default:
+		t.Kind = KindVoid
+	}
+	return t
+}
+
+// BTF format is described in Documentation/bpf/btf.rst.
+const (
+	btfMagic = 0xeb9f
+
+	btfKindInt      = 1
+	btfKindPtr      = 2
+	btfKindArray    = 3
+	btfKindStruct   = 4
+	btfKindUnion    = 5
+	btfKindEnum     = 6
+	btfKindFwd      = 7
+	btfKindTypedef  = 8
+	btfKindVolatile = 9
+	btfKindConst    = 10
+	btfKindRestrict = 11
+	btfKindFunc     = 12
+	btfKindFuncProt = 13
+	btfKindVar      = 14
+	btfKindDatasec  = 15
+	btfKindFloat    = 16
+	btfKindDeclTag  = 17
+	btfKindTypeTag  = 18
+	btfKindEnum64   = 19
+)
+
+type btfType struct {
+	name     string
+	kind     int
+	vlen     int
+	kindFlag bool
+	sizeType uint32 // size or type depending on kind
+	extra    []uint32
+}
+
+func parseBTF(data []byte, order binary.ByteOrder, ptrSize int64) (*Types, error) {
+	if len(data) < 24 || order.Uint16(data) != btfMagic {
+		return nil, fmt.Errorf("bad BTF header")
+	}
+	hdrLen := order.Uint32(data[4:])
+	typeOff, typeLen := order.Uint32(data[8:]), order.Uint32(data[12:])
+	strOff, strLen := order.Uint32(data[16:]), order.Uint32(data[20:])
+	if uint64(hdrLen)+uint64(typeOff)+uint64(typeLen) > uint64(len(data)) ||
+		uint64(hdrLen)+uint64(strOff)+uint64(strLen) > uint64(len(data)) {
+		return nil, fmt.Errorf("BTF sections are out of bounds")
+	}
+	types := data[hdrLen+typeOff : hdrLen+typeOff+typeLen]
+	strs := data[hdrLen+strOff : hdrLen+strOff+strLen]
+	str := func(off uint32) string {
+		if off >= uint32(len(strs)) {
+			return ""
+		}
+		s := strs[off:]
+		if end := bytes.IndexByte(s, 0); end != -1 {
+			s = s[:end]
+		}
+		return string(s)
+	}
+	// Type ID 0 is void.
+	raw := []*btfType{{}}
+	for pos := 0; pos < len(types); {
+		if pos+12 > len(types) {
+			return nil, fmt.Errorf("truncated BTF type at %v", pos)
+		}
+		info := order.Uint32(types[pos+4:])
+		bt := &btfType{
+			name:     str(order.Uint32(types[pos:])),
+			kind:     int(info >> 24 & 0x1f),
+			vlen:     int(info & 0xffff),
+			kindFlag: info>>31 != 0,
+			sizeType: order.Uint32(types[pos+8:]),
+		}
+		pos += 12
+		words := 0
+		switch bt.kind {
+		case btfKindInt, btfKindVar, btfKindDeclTag:
+			words = 1
+		case btfKindArray:
+			words = 3
+		case btfKindStruct, btfKindUnion, btfKindDatasec, btfKindEnum64:
+			words = 3 * bt.vlen
+		case btfKindEnum, btfKindFuncProt:
+			words = 2 * bt.vlen
+		}
+		if pos+words*4 > len(types) {
+			return nil, fmt.Errorf("truncated BTF type at %v", pos)
+		}
+		for i := 0; i < words; i++ {
+			bt.extra = append(bt.extra, order.Uint32(types[pos+i*4:]))
+		}
+		pos += words * 4
+		raw = append(raw, bt)
+	}
+	kt := newTypes()
+	conv := &btfConverter{raw: raw, cache: make(map[uint32]*Type), ptrSize: ptrSize, str: str}
+	for id, bt := range raw {
+		switch bt.kind {
+		case btfKindStruct, btfKindUnion:
+			if bt.name != "" && kt.structs[bt.name] == nil {
+				kt.structs[bt.name] = conv.convert(uint32(id))
+			}
+		case btfKindTypedef:
+			if kt.typedefs[bt.name] == nil {
+				kt.typedefs[bt.name] = conv.convert(uint32(id))
+			}
+		case btfKindEnum:
+			for i := 0; i < bt.vlen; i++ {
+				kt.Enumerators[str(bt.extra[i*2])] = int64(int32(bt.extra[i*2+1]))
+			}
+		case btfKindEnum64:
+			for i := 0; i < bt.vlen; i++ {
+				kt.Enumerators[str(bt.extra[i*3])] = int64(uint64(bt.extra[i*3+2])<<32 | uint64(bt.extra[i*3+1]))
+			}
+		}
+	}
+	return kt, nil
+}
+
+type btfConverter struct {
+	raw     []*btfType
+	cache   map[uint32]*Type
+	ptrSize int64
+	str     func(off uint32) string
+}
+
+func (conv *btfConverter) convert(id uint32) *Type {
+	if t := conv.cache[id]; t != nil {
+		return t
+	}
+	if id >= uint32(len(conv.raw)) {
+		return &Type{Kind: KindVoid, Size: -1}
+	}
+	bt := conv.raw[id]
+	t := &Type{Name: bt.name, Size: int64(bt.sizeType)}
+	conv.cache[id] = t
+	switch bt.kind {
+	case 0:
+		t.Kind, t.Size = KindVoid, -1
+	case btfKindInt:
+		t.Kind, t.Signed = KindInt, bt.extra[0]>>24&1 != 0
+	case btfKindFloat:
+		t.Kind = KindInt
+	case btfKindEnum, btfKindEnum64:
+		t.Kind = KindEnum
+	case btfKindPtr:
+		t.Kind, t.Size, t.Elem = KindPtr, conv.ptrSize, conv.convert(bt.sizeType)
+	case btfKindArray:
+		t.Kind, t.Elem, t.Count = KindArray, conv.convert(bt.extra[0]), int64(bt.extra[2])
+		t.Size = -1
+		if t.Elem.Size >= 0 {
+			t.Size = t.Elem.Size * t.Count
+		}
+	case btfKindStruct, btfKindUnion:
+		t.Kind = KindStruct
+		if bt.kind == btfKindUnion {
+			t.Kind = KindUnion
+		}
+		for i := 0; i < bt.vlen; i++ {
+			f := &Field{
+				Name: conv.str(bt.extra[i*3]),
+				Type: conv.convert(bt.extra[i*3+1]),
+				Off:  int64(bt.extra[i*3+2]),
+			}
+			if bt.kindFlag {
+				f.BitSize, f.Off = f.Off>>24, f.Off&0xffffff
+			} else if tid := bt.extra[i*3+1]; tid < uint32(len(conv.raw)) && conv.raw[tid].kind == btfKindInt &&
+				conv.raw[tid].extra[0]&0xff < conv.raw[tid].sizeType*8 {
+				// Without kind_flag bitfields are encoded in the int type.
+				f.BitSize = int64(conv.raw[tid].extra[0] & 0xff)
+			}
+			t.Fields = append(t.Fields, f)
+		}
+	case btfKindTypedef:
+		t.Kind, t.Elem = KindTypedef, conv.convert(bt.sizeType)
+		t.Size = t.Elem.Size
+	case btfKindConst, btfKindVolatile, btfKindRestrict, btfKindTypeTag:
+		res := conv.convert(bt.sizeType)
+		conv.cache[id] = res
+		return res
+	case btfKindFunc, btfKindFuncProt:
+		t.Kind, t.Size = KindFunc, -1
+	default:
+		// Forward declarations, vars, datasecs.
+		t.Kind, t.Size = KindVoid, -1
+	}
+	return t
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ktypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestBTFBitfields(t *testing.T) {
+	// struct foo { u32 a:3; u32 b:5; u32 c; } along with the u32 int type.
+	strs := []byte("\x00u32\x00foo\x00a\x00b\x00c\x00")
+	buf := new(bytes.Buffer)
+	w := func(vals ...uint32) {
+		for _, v := range vals {
+			binary.Write(buf, binary.LittleEndian, v)
+		}
+	}
+	w(1, btfKindInt<<24, 4, 32)
+	w(5, 1<<31|btfKindStruct<<24|3, 8)
+	w(9, 1, 3<<24)
+	w(11, 1, 5<<24|3)
+	w(13, 1, 32)
+	types := buf.Bytes()
+	hdr := new(bytes.Buffer)
+	binary.Write(hdr, binary.LittleEndian, uint16(btfMagic))
+	hdr.Write([]byte{1, 0})
+	binary.Write(hdr, binary.LittleEndian, []uint32{24, 0, uint32(len(types)), uint32(len(types)), uint32(len(strs))})
+	data := append(append(hdr.Bytes(), types...), strs...)
+	kt, err := parseBTF(data, binary.LittleEndian, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foo := kt.Struct("foo")
+	if foo == nil || foo.Kind != KindStruct || foo.Size != 8 || len(foo.Fields) != 3 {
+		t.Fatalf("bad struct foo: %+v", foo)
+	}
+	want := []Field{{"a", 0, 3, nil}, {"b", 3, 5, nil}, {"c", 32, 0, nil}}
+	for i, f := range foo.Fields {
+		if f.Name != want[i].Name || f.Off != want[i].Off || f.BitSize != want[i].BitSize || f.Type.Size != 4 {
+			t.Errorf("field #%v: got %+v, want %+v", i, *f, want[i])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ktypes
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// LayoutError says that a struct with the kernel attribute does not match the kernel struct.
+type LayoutError struct {
+	Struct  string // struct name in descriptions
+	Kernel  string // kernel struct name
+	Problem string
+}
+
+func (err *LayoutError) Error() string {
+	return fmt.Sprintf("struct %v (kernel struct %v): %v", err.Struct, err.Kernel, err.Problem)
+}
+
+// CheckLayouts compares layouts of structs with the kernel attribute used by the syscalls
+// with the kernel types. Sizes of structs and offsets and sizes of all fields, including bitfields,
+// are compared with bit granularity. Fields are matched by position since names in descriptions
+// frequently differ from the kernel names. Only the first mismatch in each struct is reported,
+// subsequent fields are usually shifted as well.
+func CheckLayouts(kt *Types, syscalls []*prog.Syscall) []*LayoutError {
+	structs := make(map[string]*prog.StructType)
+	for _, c := range syscalls {
+		prog.ForeachType(c, func(typ prog.Type) {
+			if t, ok := typ.(*prog.StructType); ok && t.StructDesc != nil && t.KernelName != "" {
+				structs[t.Name()] = t
+			}
+		})
+	}
+	var res []*LayoutError
+	for name, t := range structs {
+		problem := "no such struct in the kernel"
+		if ks := kt.Struct(t.KernelName); ks != nil {
+			problem = CheckLayout(t, ks)
+		}
+		if problem != "" {
+			res = append(res, &LayoutError{
+				Struct:  name,
+				Kernel:  t.KernelName,
+				Problem: problem,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Struct < res[j].Struct
+	})
+	return res
+}
+
+// CheckLayout compares layout of the description struct or union typ with the kernel type ks
+// and returns description of the first mismatch, or an empty string if they match.
+// Fields of unions are not compared.
+func CheckLayout(typ prog.Type, ks *Type) string {
+	_, isUnion := typ.(*prog.UnionType)
+	if isUnion && ks.Kind != KindUnion {
+		return "kernel type is a struct"
+	}
+	if !isUnion && ks.Kind != KindStruct {
+		return "kernel type is a union"
+	}
+	if !typ.Varlen() && ks.Size >= 0 && typ.Size() != uint64(ks.Size) {
+		return fmt.Sprintf("size %v, kernel size %v", typ.Size(), ks.Size)
+	}
+	if t, ok := typ.(*prog.StructType); ok {
+		return compareFields(descFields(t), kernelFields(ks))
+	}
+	return ""
+}
+
+// layoutField is a struct field with static offset.
+type layoutField struct {
+	name     string
+	off      uint64 // in bits
+	size     uint64 // in bits, 0 if unknown (varlen fields, flexible arrays)
+	bitfield bool
+}
+
+func descFields(t *prog.StructType) []layoutField {
+	var res []layoutField
+	off := uint64(0)
+	for _, f := range t.Fields {
+		if prog.IsPad(f) {
+			off += f.Size()
+			continue
+		}
+		if f.Varlen() {
+			// Offsets of the following fields are not static.
+			res = append(res, layoutField{name: f.FieldName(), off: off * 8})
+			break
+		}
+		if f.BitfieldLength() != 0 {
+			// Bitfields are stored starting from the least significant bit of the storage unit.
+			res = append(res, layoutField{
+				name:     f.FieldName(),
+				off:      off*8 + f.BitfieldOffset(),
+				size:     f.BitfieldLength(),
+				bitfield: true,
+			})
+		} else {
+			res = append(res, layoutField{name: f.FieldName(), off: off * 8, size: f.Size() * 8})
+		}
+		if !f.BitfieldMiddle() {
+			off += f.Size()
+		}
+	}
+	return res
+}
+
+func kernelFields(ks *Type) []layoutField {
+	var res []layoutField
+	for i, f := range ks.Fields {
+		fld := layoutField{name: f.Name, off: uint64(f.Off)}
+		if fld.name == "" {
+			fld.name = fmt.Sprintf("#%v", i)
+		}
+		if f.BitSize != 0 {
+			fld.size, fld.bitfield = uint64(f.BitSize), true
+		} else if f.Type.Size > 0 {
+			// Flexible arrays have unknown size.
+			fld.size = uint64(f.Type.Size) * 8
+		}
+		res = append(res, fld)
+	}
+	return res
+}
+
+func compareFields(desc, kernel []layoutField) string {
+	for i := 0; i < len(desc) && i < len(kernel); i++ {
+		d, k := desc[i], kernel[i]
+		bits := d.bitfield || k.bitfield
+		if d.off != k.off {
+			return fmt.Sprintf("field %v has %v, kernel field %v has %v",
+				d.name, formatBits("offset", d.off, bits), k.name, formatBits("offset", k.off, bits))
+		}
+		if d.size != 0 && k.size != 0 && d.size != k.size {
+			return fmt.Sprintf("field %v has %v, kernel field %v has %v",
+				d.name, formatBits("size", d.size, bits), k.name, formatBits("size", k.size, bits))
+		}
+	}
+	if len(desc) != 0 && desc[len(desc)-1].size == 0 {
+		// Varlen fields at the end are frequently used for the kernel tail fields.
+		return ""
+	}
+	if len(desc) != len(kernel) {
+		return fmt.Sprintf("%v fields, kernel has %v fields", len(desc), len(kernel))
+	}
+	return ""
+}
+
+func formatBits(what string, bits uint64, bitfield bool) string {
+	if bitfield || bits%8 != 0 {
+		return fmt.Sprintf("bit %v %v", what, bits)
+	}
+	return fmt.Sprintf("%v %v", what, bits/8)
+}
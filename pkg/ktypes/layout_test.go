@@ -0,0 +1,92 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ktypes
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+func TestCheckLayouts(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u32 := &Type{Kind: KindInt, Name: "u32", Size: 4}
+	u64 := &Type{Kind: KindInt, Name: "u64", Size: 8}
+	bitfields := func(typ *Type, size int64, sizes ...int64) *Type {
+		st := &Type{Kind: KindStruct, Size: size}
+		off := int64(0)
+		for _, sz := range sizes {
+			st.Fields = append(st.Fields, &Field{Name: "f", Off: off, BitSize: sz, Type: typ})
+			off += sz
+		}
+		return st
+	}
+	tests := []struct {
+		structs map[string]*Type
+		errors  []string
+	}{
+		{
+			structs: map[string]*Type{
+				"syz_bf_struct1_internal": bitfields(u32, 4, 10, 10, 10),
+				"syz_bf_struct2":          bitfields(u64, 8, 4, 8, 12, 20, 16),
+			},
+		},
+		{
+			structs: map[string]*Type{
+				"syz_bf_struct1_internal": bitfields(u32, 4, 10, 10),
+				"syz_bf_struct2":          bitfields(u64, 8, 4, 8, 13, 20, 16),
+			},
+			errors: []string{
+				"struct syz_bf_struct1_internal (kernel struct syz_bf_struct1_internal): 3 fields, kernel has 2 fields",
+				"struct syz_bf_struct2 (kernel struct syz_bf_struct2): " +
+					"field f2 has bit size 12, kernel field f has bit size 13",
+			},
+		},
+		{
+			structs: map[string]*Type{
+				"syz_bf_struct1_internal": bitfields(u32, 8, 10, 10, 10),
+				"syz_bf_struct2":          {Kind: KindUnion, Size: 8},
+			},
+			errors: []string{
+				"struct syz_bf_struct1_internal (kernel struct syz_bf_struct1_internal): size 4, kernel size 8",
+				"struct syz_bf_struct2 (kernel struct syz_bf_struct2): kernel type is a union",
+			},
+		},
+		{
+			structs: map[string]*Type{
+				"syz_bf_struct1_internal": {Kind: KindStruct, Size: 4, Fields: []*Field{
+					{Name: "a", Off: 0, BitSize: 10, Type: u32},
+					{Name: "b", Off: 16, BitSize: 10, Type: u32},
+					{Name: "c", Off: 26, BitSize: 6, Type: u32},
+				}},
+			},
+			errors: []string{
+				"struct syz_bf_struct1_internal (kernel struct syz_bf_struct1_internal): " +
+					"field f1 has bit offset 10, kernel field b has bit offset 16",
+				"struct syz_bf_struct2 (kernel struct syz_bf_struct2): no such struct in the kernel",
+			},
+		},
+	}
+	for i, test := range tests {
+		kt := newTypes()
+		kt.structs = test.structs
+		var errors []string
+		for _, err := range CheckLayouts(kt, target.Syscalls) {
+			errors = append(errors, err.Error())
+		}
+		if len(errors) != len(test.errors) {
+			t.Errorf("test #%v: got errors:\n%q\nwant:\n%q", i, errors, test.errors)
+			continue
+		}
+		for j := range errors {
+			if errors[j] != test.errors[j] {
+				t.Errorf("test #%v: got error:\n%v\nwant:\n%v", i, errors[j], test.errors[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package mgrapi defines data structures served by syz-manager JSON HTTP API
+// and provides a client for it.
+package mgrapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Summary is served on /api/summary and describes the current manager state.
+type Summary struct {
+	Name        string
+	Revision    string
+	UpTime      time.Duration
+	FuzzingTime time.Duration
+	Corpus      int
+	Candidates  int
+	Cover       uint64
+	Signal      uint64
+	// All manager and fuzzer stats as displayed in the web UI.
+	Stats   map[string]uint64
+	VMs     VMStatus
+	Crashes []*Crash
+}
+
+type VMStatus struct {
+	Total       int
+	Fuzzing     int
+	Reproducing int
+}
+
+type Crash struct {
+	ID        string
+	Title     string
+	Count     int
+	LastTime  time.Time
+	FirstTime time.Time // zero if the crash was saved before the manager tracked crash history
+	Active    bool
+	// Repro status, e.g. "has C repro" or "reproducing".
+	Repro string
+	Score int
+	Tags  []string
+	// "spike" if the crash suddenly became more frequent (possible new regression),
+	// "stopped" if it was not seen on newer kernel builds (possibly fixed).
+	Trend string
+}
+
+type Client struct {
+	Addr   string
+	client *http.Client
+}
+
+// New creates a client for the manager serving web UI at addr (e.g. "http://localhost:56741").
+func New(addr string) *Client {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	return &Client{
+		Addr:   strings.TrimSuffix(addr, "/"),
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (c *Client) Summary() (*Summary, error) {
+	res := new(Summary)
+	if err := c.get("/api/summary", res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RawCover returns corpus coverage PCs served on /rawcover
+// (PCs of the coverage callback calls, one hex PC per line).
+func (c *Client) RawCover() ([]uint64, error) {
+	body, err := c.request("/rawcover")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ParseRawCover(body)
+}
+
+// ParseRawCover parses raw coverage in the /rawcover format.
+func ParseRawCover(r io.Reader) ([]uint64, error) {
+	var pcs []uint64
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		ln := strings.TrimSpace(s.Text())
+		if ln == "" {
+			continue
+		}
+		pc, err := strconv.ParseUint(ln, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad raw coverage line %q", ln)
+		}
+		pcs = append(pcs, pc)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read raw coverage: %v", err)
+	}
+	return pcs, nil
+}
+
+func (c *Client) get(path string, res interface{}) error {
+	body, err := c.request(path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(res); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) request(path string) (io.ReadCloser, error) {
+	resp, err := c.client.Get(c.Addr + path)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("request %v failed: %v", path, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -24,6 +24,11 @@ type Config struct {
 	KernelObj string `json:"kernel_obj"`
 	// Kernel source directory (if not set defaults to KernelObj).
 	KernelSrc string `json:"kernel_src,omitempty"`
+	// Directory for the persistent symbolization cache (optional).
+	// The cache is keyed by kernel build ID, so it can be shared by several managers and tools.
+	SymbolizerCache string `json:"symbolizer_cache,omitempty"`
+	// Address of a syz-symbolizer daemon to use for symbolization (optional).
+	SymbolizerServer string `json:"symbolizer_server,omitempty"`
 	// Arbitrary optional tag that is saved along with crash reports (e.g. branch/commit).
 	Tag string `json:"tag,omitempty"`
 	// Location of the disk image file.
@@ -37,6 +42,10 @@ type Config struct {
 	HubClient string `json:"hub_client,omitempty"`
 	HubAddr   string `json:"hub_addr,omitempty"`
 	HubKey    string `json:"hub_key,omitempty"`
+	// Connect to hub over TLS.
+	HubTLS bool `json:"hub_tls,omitempty"`
+	// CA certificate to verify hub certificate with, system roots are used by default (optional).
+	HubCACert string `json:"hub_ca_cert,omitempty"`
 
 	// List of email addresses to receive notifications when bugs are encountered for the first time (optional).
 	// Mailx is the only supported mailer. Please set it up prior to using this function.
@@ -46,6 +55,11 @@ type Config struct {
 	DashboardAddr   string `json:"dashboard_addr,omitempty"`
 	DashboardKey    string `json:"dashboard_key,omitempty"`
 
+	// Address of an OpenTelemetry collector accepting OTLP/HTTP (e.g. "localhost:4318").
+	// If set, manager exports trace spans for RPC handlers, corpus ingestion,
+	// candidate triage and VM lifecycle there (optional).
+	TracingAddr string `json:"tracing_addr,omitempty"`
+
 	// Location of the syzkaller checkout, syz-manager will look
 	// for binaries in bin subdir (does not have to be syzkaller checkout as
 	// long as it preserves `bin` dir structure)
@@ -63,11 +77,64 @@ type Config struct {
 	//	CONFIG_PID_NS and CONFIG_NET_NS. Supported only for some OSes.
 	// "android_untrusted_app": (Android) Emulate permissions of an untrusted app.
 	Sandbox string `json:"sandbox"`
+	// Confine test programs with a seccomp filter and/or an SELinux/AppArmor profile to model
+	// userspace confinement, e.g. the default profile of a container runtime (optional, linux only).
+	// Crashes found under the profile are marked as reachable under the profile name.
+	SandboxProfile *SandboxProfile `json:"sandbox_profile,omitempty"`
+	// Identity test programs run under with sandbox "setuid" instead of user nobody (optional, linux only),
+	// e.g. to check what is reachable by uid 1000 in group kvm.
+	SandboxIdentity *SandboxIdentity `json:"sandbox_identity,omitempty"`
+	// Move every executed program into a fresh network namespace with new network devices
+	// instead of partially resetting the namespace between programs (default: false).
+	// Network state left by previous programs then can't cause irreproducible crashes,
+	// but execution is considerably slower. Supported only for linux with sandbox none/namespace.
+	NetRecycle bool `json:"net_recycle,omitempty"`
+	// Groups of network devices created in test network namespaces (default: all), any of:
+	// "virtual" (dummy, vcan, nlmon, caif, batadv, vxcan, ip6gretap), "veth", "bridge", "bond",
+	// "team", "hsr", "netdevsim". Creating fewer devices makes net_recycle faster.
+	NetDevices []string `json:"net_devices,omitempty"`
+	// Overrides how the execution environment is reset for classes of programs that are suspected
+	// to leave persistent state behind, e.g. {"module": "namespace"}. Program classes and defaults:
+	// "mount" (mount, chroot, etc): "namespace"; "ipc" (msgget, semget, shmget): "namespace";
+	// "module" (init_module, finit_module, delete_module): "reboot".
+	// Reset levels:
+	// "proc": only a fresh test process (what is done for all other programs);
+	// "namespace": fresh mount/ipc namespaces and a tmpfs work dir
+	//	(linux with sandbox none/namespace only, "proc" is used otherwise);
+	// "reboot": "namespace" and the VM is rebooted if any of the class syscalls succeeds.
+	ResetLevels map[string]string `json:"reset_levels,omitempty"`
+	// Check global state (mounts, kernel modules, sysctls, loop devices) after every call
+	// and report calls that change it (default: false, linux only). Such calls are executed
+	// with a deeper reset level later and are listed on the syscalls page of the manager.
+	// Makes execution slower.
+	DetectSideEffects bool `json:"detect_side_effects,omitempty"`
 
 	// Use KCOV coverage (default: true).
 	Cover bool `json:"cover"`
 	// Reproduce, localize and minimize crashers (default: true).
 	Reproduce bool `json:"reproduce"`
+	// Constrain reproducer extraction and minimization to preserve the original crash:
+	// "": accept any crash, default
+	// "class": the crash must be of the same type and bug class (title without location,
+	//	e.g. "KASAN: use-after-free Read")
+	// "title": the crash must have exactly the same title
+	ReproPreserveCrash string `json:"repro_preserve_crash,omitempty"`
+	// If the crash can't be reproduced with repro_preserve_crash constraint
+	// (or, for memory leaks, with the same allocation stack of the leaked object),
+	// retry reproduction accepting any crash (default: false).
+	ReproPreserveFallback bool `json:"repro_preserve_fallback,omitempty"`
+
+	// Directory with descriptions of out-of-tree kernel modules (optional).
+	// The *.txt files there can use everything declared in syzkaller/sys/OS, const values are
+	// extracted with syz-extract -extra_descriptions=DIR against the vendor kernel headers.
+	// The descriptions are compiled on start and merged with the built-in ones,
+	// the new syscalls can be used in enable_syscalls/disable_syscalls.
+	ExtraDescriptions string `json:"extra_descriptions,omitempty"`
+
+	// Validate layouts of structs with the kernel attribute used by the enabled syscalls against
+	// the debug info of kernel_obj (requires CONFIG_DEBUG_INFO_BTF=y or CONFIG_DEBUG_INFO=y).
+	// The manager refuses to start if a struct does not match the kernel (optional).
+	ValidateLayouts bool `json:"validate_layouts,omitempty"`
 
 	// List of syscalls to test (optional).
 	EnabledSyscalls []string `json:"enable_syscalls,omitempty"`
@@ -80,6 +147,47 @@ type Config struct {
 	// Completely ignore reports matching these regexps (don't save nor reboot),
 	// must match the first line of crash message.
 	Ignores []string `json:"ignores,omitempty"`
+	// Regexps for kernel source files of the subsystem being fuzzed (e.g. ["^net/", "^drivers/net/"]).
+	// When selecting the guilty file for a crash report, the innermost frame in these files
+	// is preferred over frames in other files (optional).
+	FocusSubsystem []string `json:"focus_subsystem,omitempty"`
+	// Regexps for source files that must never be selected as guilty for a crash report,
+	// in addition to the built-in per-OS list (optional).
+	GuiltyIgnores []string `json:"guilty_ignores,omitempty"`
+	// Use the call graph extracted from the kernel binary to skip stack frames that can't be
+	// on the actual call chain when selecting the guilty file (default: false).
+	// Extraction of the call graph takes a while for large kernels.
+	GuiltyCallGraph bool `json:"guilty_call_graph,omitempty"`
+	// Save console output that does not match any known oops format, but looks suspicious
+	// (stack dumps outside of oopses, firmware errors, repeated error messages)
+	// in workdir/suspicious (default: false). Only supported for linux.
+	SuspiciousOutput bool `json:"suspicious_output,omitempty"`
+	// Collect non-fatal kernel messages of this or higher severity (e.g. pr_warn_once from drivers,
+	// firmware errors) printed while fuzzing: one of "emerg", "alert", "crit", "err", "warning",
+	// "notice", "info" (default: not collected). Messages that are crashes are not collected.
+	// Messages are deduplicated (messages that differ only in numbers are the same) and saved in
+	// workdir/suspicious along with programs that were executing at that time. Only supported for linux.
+	KmsgSeverity string `json:"kmsg_severity,omitempty"`
+	// Append classification of the faulting address (e.g. "(near-null-ptr-deref)", "(poison-ptr-deref)")
+	// to titles of general protection faults and page faults (default: false). Only supported for linux.
+	// Note: this changes crash titles and thus deduplication of existing crashes.
+	ClassifyFaults bool `json:"classify_faults,omitempty"`
+	// Restart VMs that look degraded before they start producing junk crashes:
+	// VMs that execute programs too slowly, print OOM kills all the time,
+	// report errors of the VM disk or are slow to respond over ssh (default: false).
+	// Numbers of restarts per reason are shown in manager stats as "vm recycled: REASON".
+	VMHealth bool `json:"vm_health,omitempty"`
+	// Collect memory state diagnostics (/proc/meminfo, /proc/slabinfo, lockdep stats, task list)
+	// after non-fatal crashes and make the kernel print memory info and held locks on panic
+	// (default: false). Saved as diagnosticsN in the crash dir. Only supported for linux.
+	CrashDiagnostics bool `json:"crash_diagnostics,omitempty"`
+	// Timeout scaling factor for slow kernels (e.g. KASAN+KCSAN+lockdep or emulation without KVM).
+	// VM boot, ssh and no output timeouts, and fuzzer/executor timeouts are multiplied by it (default: 1).
+	Slowdown int `json:"slowdown,omitempty"`
+
+	// Experiments enable fuzzer features (see rpctype.ExperimentFeatures) on a part of VMs
+	// and compare coverage/crash metrics with the rest of VMs on the /experiments page (optional).
+	Experiments []*Experiment `json:"experiments,omitempty"`
 
 	// Type of virtual machine to use, e.g. "qemu", "gce", "android", "isolated", etc.
 	Type string `json:"type"`
@@ -96,4 +204,45 @@ type Config struct {
 	SyzFuzzerBin   string `json:"-"`
 	SyzExecprogBin string `json:"-"`
 	SyzExecutorBin string `json:"-"`
+	// Compiled extra_descriptions that are passed to syz-fuzzer/syz-execprog (empty if not used).
+	ExtraDescriptionsFile string `json:"-"`
+}
+
+type SandboxProfile struct {
+	// Name of the profile shown for crashes (required).
+	Name string `json:"name"`
+	// Seccomp profile in the OCI/Docker JSON format, e.g. moby/profiles/seccomp/default.json.
+	Seccomp string `json:"seccomp,omitempty"`
+	// SELinux context to switch test processes to, e.g. "system_u:system_r:container_t:s0".
+	SELinux string `json:"selinux,omitempty"`
+	// AppArmor profile to switch test processes to, e.g. "docker-default".
+	// The profile must be loaded in the image.
+	AppArmor string `json:"apparmor,omitempty"`
+
+	// Seccomp compiled for the target.
+	SeccompFilter []byte `json:"-"`
+}
+
+type SandboxIdentity struct {
+	// User and group IDs to switch to (required, uid must not be 0).
+	UID int `json:"uid"`
+	GID int `json:"gid"`
+	// Supplementary group IDs, e.g. [36] for group kvm (see /etc/group in the image).
+	Groups []int `json:"groups,omitempty"`
+	// Set no_new_privs, so that execve of setuid/file-capability binaries can't grant privileges.
+	NoNewPrivs bool `json:"no_new_privs,omitempty"`
+	// Capabilities kept in the bounding set, e.g. ["CAP_NET_RAW"] (default: the set is not changed).
+	// The bounding set limits capabilities that can be gained via execve, [] drops all of them.
+	CapBound []string `json:"cap_bound"`
+}
+
+type Experiment struct {
+	// Name of the experiment (required, unique).
+	Name string `json:"name"`
+	// Fuzzer feature the experiment enables, e.g. "generate-often" (required).
+	Feature string `json:"feature"`
+	// Percent of VMs the feature is enabled on [0, 100]. VMs are assigned by a hash
+	// of the experiment name and VM index, so increasing the percent rolls out the feature
+	// to more VMs while VMs that already had it keep it.
+	Percent int `json:"percent"`
 }
@@ -0,0 +1,193 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package mgrconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Template describes a config for a common setup that syz-manager -genconfig can generate.
+type Template struct {
+	Name        string
+	Description string
+	Params      []TemplateParam
+	text        string
+}
+
+type TemplateParam struct {
+	Name     string
+	Help     string
+	Default  string
+	Kind     TemplateParamKind
+	Optional bool // the param may be empty
+}
+
+type TemplateParamKind int
+
+const (
+	ParamString TemplateParamKind = iota
+	ParamPath                     // converted to an absolute path
+	ParamInt
+	ParamList // comma-separated list of strings
+)
+
+var Templates = []*Template{
+	{
+		Name:        "qemu",
+		Description: "upstream linux/amd64 kernel in qemu with a Debian image created by tools/create-image.sh",
+		Params: []TemplateParam{
+			{"syzkaller", "syzkaller checkout with built binaries", ".", ParamPath, false},
+			{"workdir", "working directory for corpus and crashes", "workdir", ParamPath, false},
+			{"kernel", "kernel build directory (with vmlinux and arch/x86/boot/bzImage)", "", ParamPath, false},
+			{"image", "disk image", "stretch.img", ParamPath, false},
+			{"sshkey", "ssh key for the image", "stretch.id_rsa", ParamPath, false},
+			{"http", "address of the web interface", "127.0.0.1:56741", ParamString, false},
+			{"vms", "number of VMs", "4", ParamInt, false},
+			{"cpu", "number of CPUs per VM", "2", ParamInt, false},
+			{"mem", "memory per VM in MB", "2048", ParamInt, false},
+			{"procs", "number of parallel test processes per VM", "8", ParamInt, false},
+		},
+		text: `{
+	"target": "linux/amd64",
+	"http": {{quote .http}},
+	"workdir": {{quote .workdir}},
+	"kernel_obj": {{quote .kernel}},
+	"image": {{quote .image}},
+	"sshkey": {{quote .sshkey}},
+	"syzkaller": {{quote .syzkaller}},
+	"procs": {{.procs}},
+	"type": "qemu",
+	"vm": {
+		"count": {{.vms}},
+		"kernel": {{quote (print .kernel "/arch/x86/boot/bzImage")}},
+		"cpu": {{.cpu}},
+		"mem": {{.mem}}
+	}
+}
+`,
+	},
+	{
+		Name:        "isolated",
+		Description: "linux/amd64 physical machines or VMs managed outside of syzkaller and accessible over ssh",
+		Params: []TemplateParam{
+			{"syzkaller", "syzkaller checkout with built binaries", ".", ParamPath, false},
+			{"workdir", "working directory for corpus and crashes", "workdir", ParamPath, false},
+			{"kernel", "kernel build directory (with vmlinux)", "", ParamPath, true},
+			{"targets", "comma-separated list of target machines (host[:port])", "", ParamList, false},
+			{"target_dir", "directory on the targets to copy binaries to", "/tmp/syzkaller", ParamString, false},
+			{"sshkey", "ssh key for the targets", "", ParamPath, true},
+			{"http", "address of the web interface", "127.0.0.1:56741", ParamString, false},
+			{"procs", "number of parallel test processes per target", "8", ParamInt, false},
+		},
+		text: `{
+	"target": "linux/amd64",
+	"http": {{quote .http}},
+	"workdir": {{quote .workdir}},
+	"kernel_obj": {{quote .kernel}},
+	"sshkey": {{quote .sshkey}},
+	"syzkaller": {{quote .syzkaller}},
+	"procs": {{.procs}},
+	"type": "isolated",
+	"vm": {
+		"targets": {{.targets}},
+		"target_dir": {{quote .target_dir}},
+		"target_reboot": false
+	}
+}
+`,
+	},
+}
+
+func LookupTemplate(name string) *Template {
+	for _, tmpl := range Templates {
+		if tmpl.Name == name {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// Generate creates config from the template and param values (missing params get default values).
+// The result is checked with LoadPartialData, but not with Complete: kernel, image and
+// syzkaller binaries don't have to exist yet.
+func (tmpl *Template) Generate(values map[string]string) ([]byte, error) {
+	args := make(map[string]interface{})
+	for _, param := range tmpl.Params {
+		val, ok := values[param.Name]
+		if !ok || val == "" {
+			val = param.Default
+		}
+		if val == "" && !param.Optional {
+			return nil, fmt.Errorf("%v (%v) is required", param.Name, param.Help)
+		}
+		switch param.Kind {
+		case ParamString:
+			args[param.Name] = val
+		case ParamPath:
+			if val != "" {
+				abs, err := filepath.Abs(val)
+				if err != nil {
+					return nil, fmt.Errorf("bad %v: %v", param.Name, err)
+				}
+				val = abs
+			}
+			args[param.Name] = val
+		case ParamInt:
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("bad %v: want a number, got %q", param.Name, val)
+			}
+			args[param.Name] = n
+		case ParamList:
+			list := []string{}
+			for _, elem := range strings.Split(val, ",") {
+				if elem = strings.TrimSpace(elem); elem != "" {
+					list = append(list, elem)
+				}
+			}
+			data, err := json.Marshal(list)
+			if err != nil {
+				return nil, err
+			}
+			args[param.Name] = string(data)
+		}
+	}
+	for name := range values {
+		if !tmpl.hasParam(name) {
+			return nil, fmt.Errorf("unknown param %v for template %v", name, tmpl.Name)
+		}
+	}
+	t, err := template.New(tmpl.Name).Funcs(template.FuncMap{
+		"quote": func(s string) (string, error) {
+			data, err := json.Marshal(s)
+			return string(data), err
+		},
+	}).Parse(tmpl.text)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, args); err != nil {
+		return nil, err
+	}
+	if _, err := LoadPartialData(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("generated bad config: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (tmpl *Template) hasParam(name string) bool {
+	for _, param := range tmpl.Params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
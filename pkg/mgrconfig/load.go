@@ -4,13 +4,19 @@
 package mgrconfig
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/overlay"
+	"github.com/google/syzkaller/pkg/seccomp"
 	"github.com/google/syzkaller/prog"
 	_ "github.com/google/syzkaller/sys" // most mgrconfig users want targets too
 	"github.com/google/syzkaller/sys/targets"
@@ -62,6 +68,7 @@ func defaultValues() *Config {
 		Sandbox:   "none",
 		RPC:       ":0",
 		Procs:     1,
+		Slowdown:  1,
 	}
 }
 
@@ -88,6 +95,41 @@ func Complete(cfg *Config) error {
 	if err := completeBinaries(cfg); err != nil {
 		return err
 	}
+	if err := completeDescriptions(cfg); err != nil {
+		return err
+	}
+	if err := completeSandboxProfile(cfg); err != nil {
+		return err
+	}
+	if err := checkSandboxIdentity(cfg); err != nil {
+		return err
+	}
+	if err := checkExperiments(cfg); err != nil {
+		return err
+	}
+	if cfg.NetRecycle && (cfg.TargetOS != "linux" || cfg.Sandbox != "none" && cfg.Sandbox != "namespace") {
+		return fmt.Errorf("config param net_recycle is supported only for linux with sandbox none/namespace")
+	}
+	if _, err := ipc.ParseNetDevices(cfg.NetDevices); err != nil {
+		return fmt.Errorf("bad config param net_devices: %v", err)
+	}
+	if _, err := ipc.ParseResetLevels(cfg.ResetLevels); err != nil {
+		return fmt.Errorf("bad config param reset_levels: %v", err)
+	}
+	if cfg.DetectSideEffects && cfg.TargetOS != "linux" {
+		return fmt.Errorf("config param detect_side_effects is supported only for linux")
+	}
+	if cfg.KmsgSeverity != "" {
+		if cfg.TargetOS != "linux" {
+			return fmt.Errorf("config param kmsg_severity is supported only for linux")
+		}
+		// Note: pkg/report depends on mgrconfig, so we can't use report.ParseKmsgSeverity.
+		switch cfg.KmsgSeverity {
+		case "emerg", "alert", "crit", "err", "warning", "notice", "info":
+		default:
+			return fmt.Errorf("bad config param kmsg_severity: %q", cfg.KmsgSeverity)
+		}
+	}
 	if cfg.HTTP == "" {
 		return fmt.Errorf("config param http is empty")
 	}
@@ -97,23 +139,50 @@ func Complete(cfg *Config) error {
 	if cfg.Procs < 1 || cfg.Procs > 32 {
 		return fmt.Errorf("bad config param procs: '%v', want [1, 32]", cfg.Procs)
 	}
+	if cfg.Slowdown < 1 || cfg.Slowdown > 20 {
+		return fmt.Errorf("bad config param slowdown: '%v', want [1, 20]", cfg.Slowdown)
+	}
 	switch cfg.Sandbox {
 	case "none", "setuid", "namespace", "android_untrusted_app":
 	default:
 		return fmt.Errorf("config param sandbox must contain one of none/setuid/namespace/android_untrusted_app")
 	}
+	switch cfg.ReproPreserveCrash {
+	case "", "class", "title":
+	default:
+		return fmt.Errorf("config param repro_preserve_crash must contain one of class/title")
+	}
+	if cfg.Sandbox == "android_untrusted_app" && cfg.TargetOS != "linux" {
+		return fmt.Errorf("config param sandbox android_untrusted_app is supported only for linux targets")
+	}
+	if cfg.RPC == cfg.HTTP {
+		return fmt.Errorf("config params http and rpc use the same address %v", cfg.HTTP)
+	}
 	if err := checkSSHParams(cfg); err != nil {
 		return err
 	}
+	if err := checkVMParams(cfg); err != nil {
+		return err
+	}
 
+	if cfg.ValidateLayouts && cfg.KernelObj == "" {
+		return fmt.Errorf("validate_layouts is set, but kernel_obj is empty")
+	}
 	cfg.KernelObj = osutil.Abs(cfg.KernelObj)
 	if cfg.KernelSrc == "" {
 		cfg.KernelSrc = cfg.KernelObj // assume in-tree build by default
 	}
 	cfg.KernelSrc = osutil.Abs(cfg.KernelSrc)
+	if cfg.SymbolizerCache != "" {
+		cfg.SymbolizerCache = osutil.Abs(cfg.SymbolizerCache)
+	}
 	if cfg.HubClient != "" && (cfg.Name == "" || cfg.HubAddr == "" || cfg.HubKey == "") {
 		return fmt.Errorf("hub_client is set, but name/hub_addr/hub_key is empty")
 	}
+	if cfg.HubCACert != "" {
+		cfg.HubCACert = osutil.Abs(cfg.HubCACert)
+		cfg.HubTLS = true
+	}
 	if cfg.DashboardClient != "" && (cfg.Name == "" ||
 		cfg.DashboardAddr == "" ||
 		cfg.DashboardKey == "") {
@@ -140,6 +209,37 @@ func checkSSHParams(cfg *Config) error {
 	return nil
 }
 
+// checkVMParams does cross-checks of the VM type, image and VM-type-specific parameters.
+// Parameters of concrete VM types are checked by vm packages on VM pool creation,
+// here we catch only the most common mistakes.
+func checkVMParams(cfg *Config) error {
+	vm := make(map[string]interface{})
+	if len(cfg.VM) != 0 {
+		if err := json.Unmarshal(cfg.VM, &vm); err != nil {
+			return fmt.Errorf("bad config param vm: want object")
+		}
+	}
+	switch cfg.Type {
+	case "none":
+		if cfg.Image != "" {
+			return fmt.Errorf("config param image is not used with vm type none, remove it")
+		}
+	case "qemu":
+		if cfg.Image == "" && vm["kernel"] == nil {
+			return fmt.Errorf("vm type qemu needs either image or vm.kernel (or both)")
+		}
+		if cfg.Image != "" && cfg.Image != "9p" && !osutil.IsExist(cfg.Image) {
+			return fmt.Errorf("bad config param image: %v does not exist"+
+				" (see tools/create-image.sh to create an image)", cfg.Image)
+		}
+		if kernel, ok := vm["kernel"].(string); ok && kernel != "" && !osutil.IsExist(kernel) {
+			return fmt.Errorf("bad config param vm.kernel: %v does not exist"+
+				" (e.g. arch/x86/boot/bzImage in the kernel build dir)", kernel)
+		}
+	}
+	return nil
+}
+
 func completeBinaries(cfg *Config) error {
 	sysTarget := targets.Get(cfg.TargetOS, cfg.TargetArch)
 	if sysTarget == nil {
@@ -153,14 +253,128 @@ func completeBinaries(cfg *Config) error {
 	cfg.SyzFuzzerBin = targetBin("syz-fuzzer", cfg.TargetVMArch)
 	cfg.SyzExecprogBin = targetBin("syz-execprog", cfg.TargetVMArch)
 	cfg.SyzExecutorBin = targetBin("syz-executor", cfg.TargetArch)
-	if !osutil.IsExist(cfg.SyzFuzzerBin) {
-		return fmt.Errorf("bad config syzkaller param: can't find %v", cfg.SyzFuzzerBin)
+	for _, bin := range []string{cfg.SyzFuzzerBin, cfg.SyzExecprogBin, cfg.SyzExecutorBin} {
+		if !osutil.IsExist(bin) {
+			return fmt.Errorf("bad config syzkaller param: can't find %v"+
+				" (build it with 'make TARGETOS=%v TARGETVMARCH=%v TARGETARCH=%v' in %v)",
+				bin, cfg.TargetOS, cfg.TargetVMArch, cfg.TargetArch, cfg.Syzkaller)
+		}
 	}
-	if !osutil.IsExist(cfg.SyzExecprogBin) {
-		return fmt.Errorf("bad config syzkaller param: can't find %v", cfg.SyzExecprogBin)
+	return nil
+}
+
+// completeDescriptions compiles extra_descriptions and merges them into the target,
+// so that all users of the config see the new syscalls.
+func completeDescriptions(cfg *Config) error {
+	if cfg.ExtraDescriptions == "" {
+		return nil
 	}
-	if !osutil.IsExist(cfg.SyzExecutorBin) {
-		return fmt.Errorf("bad config syzkaller param: can't find %v", cfg.SyzExecutorBin)
+	cfg.ExtraDescriptions = osutil.Abs(cfg.ExtraDescriptions)
+	target, err := prog.GetTarget(cfg.TargetOS, cfg.TargetArch)
+	if err != nil {
+		return err
+	}
+	desc, err := overlay.Compile(target, filepath.Join(cfg.Syzkaller, "sys", target.OS), cfg.ExtraDescriptions)
+	if err != nil {
+		return fmt.Errorf("bad config extra_descriptions param: %v", err)
+	}
+	if err := overlay.Apply(target, desc); err != nil {
+		return fmt.Errorf("bad config extra_descriptions param: %v", err)
+	}
+	data, err := desc.Serialize()
+	if err != nil {
+		return err
+	}
+	if err := osutil.MkdirAll(cfg.Workdir); err != nil {
+		return fmt.Errorf("failed to create workdir: %v", err)
+	}
+	cfg.ExtraDescriptionsFile = filepath.Join(cfg.Workdir, "extra_descriptions")
+	if err := osutil.WriteFile(cfg.ExtraDescriptionsFile, data); err != nil {
+		return fmt.Errorf("failed to write descriptions: %v", err)
+	}
+	return nil
+}
+
+// Profile names are used in crash tags.
+var sandboxProfileNameRe = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+
+func completeSandboxProfile(cfg *Config) error {
+	p := cfg.SandboxProfile
+	if p == nil {
+		return nil
+	}
+	if cfg.TargetOS != "linux" {
+		return fmt.Errorf("config param sandbox_profile is supported only for linux targets")
+	}
+	if !sandboxProfileNameRe.MatchString(p.Name) {
+		return fmt.Errorf("bad config param sandbox_profile.name: %q, want [a-zA-Z0-9_.-]+", p.Name)
+	}
+	if p.Seccomp == "" && p.SELinux == "" && p.AppArmor == "" {
+		return fmt.Errorf("config param sandbox_profile has neither seccomp nor selinux/apparmor")
+	}
+	if p.Seccomp == "" {
+		return nil
+	}
+	p.Seccomp = osutil.Abs(p.Seccomp)
+	data, err := ioutil.ReadFile(p.Seccomp)
+	if err != nil {
+		return fmt.Errorf("failed to read sandbox_profile.seccomp: %v", err)
+	}
+	profile, err := seccomp.Parse(data)
+	if err != nil {
+		return fmt.Errorf("bad config sandbox_profile.seccomp: %v", err)
+	}
+	target, err := prog.GetTarget(cfg.TargetOS, cfg.TargetArch)
+	if err != nil {
+		return err
+	}
+	if p.SeccompFilter, err = seccomp.Compile(profile, target); err != nil {
+		return fmt.Errorf("bad config sandbox_profile.seccomp: %v", err)
+	}
+	return nil
+}
+
+func checkSandboxIdentity(cfg *Config) error {
+	id := cfg.SandboxIdentity
+	if id == nil {
+		return nil
+	}
+	if cfg.TargetOS != "linux" || cfg.Sandbox != "setuid" {
+		return fmt.Errorf("config param sandbox_identity is supported only for linux with sandbox setuid")
+	}
+	// -1 means "don't change" for setresuid/setresgid.
+	const maxID = int64(1<<32 - 2)
+	if id.UID <= 0 || int64(id.UID) > maxID || id.GID < 0 || int64(id.GID) > maxID {
+		return fmt.Errorf("bad config param sandbox_identity: uid %v, gid %v", id.UID, id.GID)
+	}
+	if len(id.Groups) > ipc.MaxSandboxGroups {
+		return fmt.Errorf("config param sandbox_identity.groups has more than %v groups", ipc.MaxSandboxGroups)
+	}
+	for _, group := range id.Groups {
+		if group < 0 || int64(group) > maxID {
+			return fmt.Errorf("bad config param sandbox_identity.groups: %v", group)
+		}
+	}
+	if _, err := ipc.ParseCapabilities(id.CapBound); err != nil {
+		return fmt.Errorf("bad config param sandbox_identity.cap_bound: %v", err)
+	}
+	return nil
+}
+
+func checkExperiments(cfg *Config) error {
+	names := make(map[string]bool)
+	for _, exp := range cfg.Experiments {
+		if exp.Name == "" || names[exp.Name] {
+			return fmt.Errorf("config param experiments: empty or duplicate name %q", exp.Name)
+		}
+		names[exp.Name] = true
+		if exp.Feature == "" {
+			return fmt.Errorf("config param experiments: %v has no feature", exp.Name)
+		}
+		if exp.Percent < 0 || exp.Percent > 100 {
+			return fmt.Errorf("config param experiments: %v has bad percent %v, want [0, 100]",
+				exp.Name, exp.Percent)
+		}
 	}
 	return nil
 }
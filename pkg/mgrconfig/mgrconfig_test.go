@@ -62,3 +62,44 @@ func TestMatchSyscall(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerate(t *testing.T) {
+	values := map[string]string{
+		"kernel":  "/linux",
+		"targets": "10.0.0.1, 10.0.0.2:22",
+	}
+	for _, tmpl := range Templates {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			params := make(map[string]string)
+			for _, param := range tmpl.Params {
+				if val, ok := values[param.Name]; ok {
+					params[param.Name] = val
+				}
+			}
+			data, err := tmpl.Generate(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadPartialData(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Type != tmpl.Name {
+				t.Fatalf("generated config has type %v", cfg.Type)
+			}
+			if cfg.Workdir == "" || !filepath.IsAbs(cfg.Workdir) {
+				t.Fatalf("bad generated workdir %q", cfg.Workdir)
+			}
+			params["no_such_param"] = "1"
+			if _, err := tmpl.Generate(params); err == nil {
+				t.Fatalf("no error for unknown param")
+			}
+		})
+	}
+	if _, err := LookupTemplate("qemu").Generate(map[string]string{"kernel": "/linux", "procs": "eight"}); err == nil {
+		t.Fatalf("no error for bad int param")
+	}
+	if _, err := LookupTemplate("qemu").Generate(nil); err == nil {
+		t.Fatalf("no error for missing kernel param")
+	}
+}
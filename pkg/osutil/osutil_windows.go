@@ -7,6 +7,8 @@ package osutil
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"syscall"
@@ -15,6 +17,12 @@ import (
 func HandleInterrupts(shutdown chan struct{}) {
 }
 
+// ProcessTempDir creates a new temp dir in where.
+// Unlike the unix version it does not clean up temp dirs after dead processes.
+func ProcessTempDir(where string) (string, error) {
+	return ioutil.TempDir(where, "instance-")
+}
+
 func RemoveAll(dir string) error {
 	return os.RemoveAll(dir)
 }
@@ -22,6 +30,14 @@ func RemoveAll(dir string) error {
 func prolongPipe(r, w *os.File) {
 }
 
+func LongPipe() (io.ReadCloser, io.WriteCloser, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create pipe: %v", err)
+	}
+	return r, w, err
+}
+
 func CreateMemMappedFile(size int) (f *os.File, mem []byte, err error) {
 	return nil, nil, fmt.Errorf("CreateMemMappedFile is not implemented")
 }
@@ -0,0 +1,220 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package overlay supports descriptions of out-of-tree kernel modules that live outside of sys/OS.
+// Such descriptions are compiled together with the built-in descriptions on the host
+// and the result is merged into the built-in target at runtime (see prog.Target.Extend),
+// so vendors don't need to fork sys/OS to describe their drivers.
+package overlay
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/pkg/compiler"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// Descriptions is the part of compiled descriptions that is missing in the built-in target.
+type Descriptions struct {
+	// Revision of the built-in descriptions the overlay was compiled against.
+	Revision  string
+	Syscalls  []*prog.Syscall
+	Resources []*prog.ResourceDesc
+	Structs   []*prog.KeyedStruct
+}
+
+func init() {
+	gob.Register(new(prog.ResourceType))
+	gob.Register(new(prog.ConstType))
+	gob.Register(new(prog.IntType))
+	gob.Register(new(prog.FlagsType))
+	gob.Register(new(prog.LenType))
+	gob.Register(new(prog.ProcType))
+	gob.Register(new(prog.CsumType))
+	gob.Register(new(prog.VmaType))
+	gob.Register(new(prog.BufferType))
+	gob.Register(new(prog.ArrayType))
+	gob.Register(new(prog.PtrType))
+	gob.Register(new(prog.StructType))
+	gob.Register(new(prog.UnionType))
+}
+
+// Compile compiles descriptions in dir (*.txt files along with *_ARCH.const files produced by
+// syz-extract -extra_descriptions=dir) together with the built-in descriptions in sysDir
+// (e.g. syzkaller/sys/linux) and returns syscalls, resources and structs missing in the target.
+func Compile(target *prog.Target, sysDir, dir string) (*Descriptions, error) {
+	sysTarget := targets.Get(target.OS, target.Arch)
+	if sysTarget == nil {
+		return nil, fmt.Errorf("unknown target %v/%v", target.OS, target.Arch)
+	}
+	errors := new(bytes.Buffer)
+	eh := func(pos ast.Pos, msg string) {
+		fmt.Fprintf(errors, "%v: %v\n", pos, msg)
+	}
+	builtin := ast.ParseGlob(filepath.Join(sysDir, "*.txt"), eh)
+	if builtin == nil {
+		return nil, fmt.Errorf("failed to parse built-in descriptions:\n%s", errors.Bytes())
+	}
+	extra := ast.ParseGlob(filepath.Join(dir, "*.txt"), eh)
+	if extra == nil {
+		return nil, fmt.Errorf("failed to parse descriptions:\n%s", errors.Bytes())
+	}
+	// Positions contain only base file names, so names must not clash.
+	builtinFiles := make(map[string]bool)
+	for _, node := range builtin.Nodes {
+		pos, _, _ := node.Info()
+		builtinFiles[pos.File] = true
+	}
+	for _, node := range extra.Nodes {
+		if pos, _, _ := node.Info(); builtinFiles[pos.File] {
+			return nil, fmt.Errorf("%v: file with the same name is present in %v", pos.File, sysDir)
+		}
+	}
+	desc := &ast.Description{Nodes: append(builtin.Nodes, extra.Nodes...)}
+	consts := compiler.DeserializeConstsGlob(filepath.Join(sysDir, "*_"+target.Arch+".const"), eh)
+	if consts == nil {
+		return nil, fmt.Errorf("failed to read built-in consts:\n%s", errors.Bytes())
+	}
+	// Descriptions may not use any new consts, then there are no const files.
+	constGlob := filepath.Join(dir, "*_"+target.Arch+".const")
+	if files, _ := filepath.Glob(constGlob); len(files) != 0 {
+		extraConsts := compiler.DeserializeConstsGlob(constGlob, eh)
+		if extraConsts == nil {
+			return nil, fmt.Errorf("failed to read consts:\n%s", errors.Bytes())
+		}
+		// Values of the consts used by the built-in descriptions are fixed in the binaries.
+		for name, val := range extraConsts {
+			if _, ok := consts[name]; !ok {
+				consts[name] = val
+			}
+		}
+	}
+	prg := compiler.Compile(desc, consts, sysTarget, eh)
+	if prg == nil {
+		return nil, fmt.Errorf("failed to compile descriptions:\n%s", errors.Bytes())
+	}
+	return extract(target, prg)
+}
+
+// extract returns part of the compiled descriptions that is missing in the target.
+func extract(target *prog.Target, prg *compiler.Prog) (*Descriptions, error) {
+	compiled := make(map[string]bool)
+	for _, c := range prg.Syscalls {
+		compiled[c.Name] = true
+	}
+	for _, c := range target.Syscalls {
+		if !compiled[c.Name] {
+			return nil, fmt.Errorf("built-in descriptions don't match the binaries"+
+				" (%v is missing), rebuild syzkaller", c.Name)
+		}
+	}
+	res := &Descriptions{Revision: target.Revision}
+	known := make(map[string]bool)
+	for _, r := range target.Resources {
+		known[r.Name] = true
+	}
+	for _, r := range prg.Resources {
+		if !known[r.Name] {
+			res.Resources = append(res.Resources, r)
+		}
+	}
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	// Structs are detached from types by the compiler, collect the ones used by the new syscalls.
+	used := make(map[prog.StructKey]bool)
+	var walk func(typ prog.Type)
+	walkStruct := func(key prog.StructKey) {
+		if used[key] {
+			return
+		}
+		used[key] = true
+		if desc := descs[key]; desc != nil {
+			res.Structs = append(res.Structs, &prog.KeyedStruct{Key: key, Desc: desc})
+			for _, fld := range desc.Fields {
+				walk(fld)
+			}
+		}
+	}
+	walk = func(typ prog.Type) {
+		switch t := typ.(type) {
+		case *prog.PtrType:
+			walk(t.Type)
+		case *prog.ArrayType:
+			walk(t.Type)
+		case *prog.StructType:
+			walkStruct(t.Key)
+		case *prog.UnionType:
+			walkStruct(t.Key)
+		}
+	}
+	for _, c := range prg.Syscalls {
+		if target.SyscallMap[c.Name] != nil {
+			continue
+		}
+		res.Syscalls = append(res.Syscalls, c)
+		for _, arg := range c.Args {
+			walk(arg)
+		}
+		if c.Ret != nil {
+			walk(c.Ret)
+		}
+	}
+	sort.Slice(res.Structs, func(i, j int) bool {
+		a, b := res.Structs[i].Key, res.Structs[j].Key
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Dir < b.Dir
+	})
+	return res, nil
+}
+
+func (d *Descriptions) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(d); err != nil {
+		return nil, fmt.Errorf("failed to serialize descriptions: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func Deserialize(data []byte) (*Descriptions, error) {
+	d := new(Descriptions)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(d); err != nil {
+		return nil, fmt.Errorf("failed to deserialize descriptions: %v", err)
+	}
+	return d, nil
+}
+
+// Apply merges the descriptions into the target.
+func Apply(target *prog.Target, d *Descriptions) error {
+	if d.Revision != target.Revision {
+		return fmt.Errorf("descriptions were compiled for revision %v, but target has revision %v",
+			d.Revision, target.Revision)
+	}
+	if err := target.Extend(d.Syscalls, d.Resources, d.Structs); err != nil {
+		return fmt.Errorf("failed to extend target: %v", err)
+	}
+	return nil
+}
+
+// Load applies serialized descriptions from the file to the target.
+func Load(target *prog.Target, file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read descriptions: %v", err)
+	}
+	d, err := Deserialize(data)
+	if err != nil {
+		return err
+	}
+	return Apply(target, d)
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package overlay
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+const vendorDescriptions = `
+resource vendor_res[syz_res]
+
+test$vendor0(a ptr[in, vendor_struct]) vendor_res
+test$vendor1(a0 vendor_res, a1 ptr[inout, syz_struct0])
+
+vendor_struct {
+	f0	int32
+	f1	ptr[in, vendor_struct, opt]
+	f2	vendor_union
+}
+
+vendor_union [
+	u0	int8
+	u1	array[int16, 3]
+]
+`
+
+func TestOverlay(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "syz-overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "vendor.txt"), []byte(vendorDescriptions), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sysDir := filepath.Join("..", "..", "sys", "test")
+	desc, err := Compile(target, sysDir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desc.Syscalls) != 2 || len(desc.Resources) != 1 {
+		t.Fatalf("got %v syscalls and %v resources, want 2 and 1", len(desc.Syscalls), len(desc.Resources))
+	}
+	data, err := desc.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err = Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numBuiltin := len(target.Syscalls)
+	if err := Apply(target, desc); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Syscalls) != numBuiltin+2 {
+		t.Fatalf("target has %v syscalls, want %v", len(target.Syscalls), numBuiltin+2)
+	}
+	base := target.SyscallMap["test"]
+	for _, name := range []string{"test$vendor0", "test$vendor1"} {
+		c := target.SyscallMap[name]
+		if c == nil {
+			t.Fatalf("no %v in the target", name)
+		}
+		if c.ID < numBuiltin || c.ExecID() != base.ID {
+			t.Fatalf("%v: id %v, exec id %v, want exec id %v", name, c.ID, c.ExecID(), base.ID)
+		}
+	}
+	p, err := target.Deserialize([]byte("r0 = test$vendor0(&(0x7f0000000000)={0x1, 0x0, @u0=0x2})\n"+
+		"test$vendor1(r0, &(0x7f0000000100))\n"), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := make([]byte, prog.ExecBufferSize)
+	if _, err := p.SerializeForExec(exec); err != nil {
+		t.Fatal(err)
+	}
+	enabled := map[*prog.Syscall]bool{
+		target.SyscallMap["test$vendor0"]: true,
+		target.SyscallMap["test$vendor1"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	rs := rand.NewSource(0)
+	for i := 0; i < 100; i++ {
+		p := target.Generate(rs, 10, ct)
+		p.Mutate(rs, 10, ct, nil)
+		if _, err := target.Deserialize(p.Serialize(), prog.NonStrict); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Compiling again against the extended target must not find anything new.
+	desc, err = Compile(target, sysDir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desc.Syscalls) != 0 || len(desc.Resources) != 0 {
+		t.Fatalf("got %v syscalls and %v resources after extension", len(desc.Syscalls), len(desc.Resources))
+	}
+}
+
+func TestOverlayFileClash(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "syz-overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "test.txt"), []byte("test$clash()\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Compile(target, filepath.Join("..", "..", "sys", "test"), dir); err == nil {
+		t.Fatal("clashing file name was not detected")
+	}
+}
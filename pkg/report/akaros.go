@@ -13,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/google/syzkaller/pkg/symbolizer"
-	"github.com/google/syzkaller/sys/targets"
 )
 
 type akaros struct {
@@ -21,13 +20,12 @@ type akaros struct {
 	objfile string
 }
 
-func ctorAkaros(target *targets.Target, kernelSrc, kernelObj string,
-	ignores []*regexp.Regexp) (Reporter, []string, error) {
+func ctorAkaros(cfg *config) (Reporter, []string, error) {
 	ctx := &akaros{
-		ignores: ignores,
+		ignores: cfg.ignores,
 	}
-	if kernelObj != "" {
-		ctx.objfile = filepath.Join(kernelObj, target.KernelObject)
+	if cfg.kernelObj != "" {
+		ctx.objfile = filepath.Join(cfg.kernelObj, cfg.target.KernelObject)
 	}
 	return ctx, nil, nil
 }
@@ -0,0 +1,188 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// Anomaly is a piece of console output that does not match any known oops format,
+// but still suggests that something went wrong in the kernel: a stack dump printed
+// outside of an oops, a firmware error or a driver spamming the same error message.
+// Anomalies are low-priority findings: they don't terminate the test,
+// and their titles (all starting with AnomalyPrefix) are deduplicated separately from crashes.
+type Anomaly struct {
+	Title string
+	// Output contains the console lines that triggered the anomaly.
+	Output []byte
+}
+
+const AnomalyPrefix = "SUSPICIOUS: "
+
+const (
+	// Stack dumps and errors closer than this number of lines after an oops header
+	// are considered to be part of the oops.
+	anomalyOopsContext = 100
+	// Number of lines preceding a stack dump that are included into the anomaly output.
+	anomalyStackContext = 5
+	// Max number of lines in a stack dump.
+	anomalyMaxStackLines = 64
+	// Number of times the same error message needs to be printed to be considered spam.
+	anomalyRepeatThreshold = 50
+	// Max number of distinct error messages we count repetitions for.
+	anomalyMaxMessages = 1000
+)
+
+// AnomalyDetector incrementally scans console output for anomalies.
+type AnomalyDetector struct {
+	oopses    []*oops
+	partial   []byte   // incomplete last line
+	context   [][]byte // last lines preceding the current one
+	sinceOops int
+	stack     [][]byte // lines of the stack dump being collected
+	counts    map[string]int
+	titles    map[string]bool
+	anomalies []*Anomaly
+}
+
+var (
+	anomalyConsoleRe  = regexp.MustCompile(`^(?:\<[0-9]+\>)?\[ *[0-9]+\.[0-9]+\](?:\[ *(?:C|T)[0-9]+\])? `)
+	anomalyStackRe    = regexp.MustCompile(`Call [Tt]race:`)
+	anomalyFirmwareRe = regexp.MustCompile(`\[Firmware (?:Bug|Warn)\]|ACPI (?:BIOS )?Error|` +
+		`Direct firmware load for .* failed|firmware: failed to load`)
+	anomalyErrorRe  = regexp.MustCompile(`(?i)\b(?:error|failed|failure|timed out|timeout)\b`)
+	anomalyNumberRe = regexp.MustCompile(`\b(?:0x[0-9a-f]+|[0-9]+)\b`)
+	// Lines that may be part of a stack dump besides the frames themselves.
+	anomalyStackLineRe = regexp.MustCompile(`^ *(?:<IRQ>|</IRQ>|<NMI>|</NMI>|<EOI>|<TASK>|</TASK>|` +
+		`\? |(?:\[\<?(?:0x)?[0-9a-f]+\>?\] ?){0,2}[ \t]*(?:[0-9]+:)?[a-zA-Z0-9_.]+\+0x[0-9a-f]+/0x[0-9a-f]+)`)
+)
+
+// NewAnomalyDetector creates a detector for console output of the reporter OS.
+// Returns nil if the OS is not supported (only Linux is supported for now).
+func NewAnomalyDetector(reporter Reporter) *AnomalyDetector {
+	wrap, ok := reporter.(*reporterWrapper)
+	if !ok || wrap.typ != "linux" {
+		return nil
+	}
+	return &AnomalyDetector{
+		oopses:    linuxOopses,
+		sinceOops: anomalyOopsContext + 1,
+		counts:    make(map[string]int),
+		titles:    make(map[string]bool),
+	}
+}
+
+// Feed processes the next chunk of console output.
+func (det *AnomalyDetector) Feed(output []byte) {
+	det.partial = append(det.partial, output...)
+	for {
+		pos := bytes.IndexByte(det.partial, '\n')
+		if pos == -1 {
+			break
+		}
+		det.processLine(bytes.TrimRight(det.partial[:pos], "\r"))
+		det.partial = det.partial[pos+1:]
+	}
+	// Don't hold onto the underlying array of the whole output.
+	det.partial = append([]byte{}, det.partial...)
+}
+
+// Anomalies returns all anomalies found so far. Each title is returned only once.
+func (det *AnomalyDetector) Anomalies() []*Anomaly {
+	det.finishStack()
+	return det.anomalies
+}
+
+func (det *AnomalyDetector) processLine(line []byte) {
+	line = append([]byte{}, line...)
+	text, kernel := line, false
+	if match := anomalyConsoleRe.FindIndex(line); match != nil {
+		text, kernel = line[match[1]:], true
+	}
+	if det.stack != nil {
+		if anomalyStackLineRe.Match(text) && len(det.stack) < anomalyMaxStackLines {
+			det.stack = append(det.stack, line)
+			return
+		}
+		det.finishStack()
+	}
+	det.sinceOops++
+	for _, oops := range det.oopses {
+		if matchOops(line, oops, nil) {
+			det.sinceOops = 0
+			break
+		}
+	}
+	if det.sinceOops > anomalyOopsContext {
+		switch {
+		case anomalyStackRe.Match(text):
+			det.stack = append(append([][]byte{}, det.context...), line)
+		case anomalyFirmwareRe.Match(text):
+			det.add("firmware error: "+normalizeAnomalyMessage(text), line)
+		case kernel && anomalyErrorRe.Match(text):
+			// Only kernel messages (the ones with console timestamps) are counted,
+			// fuzzer output is not interesting.
+			det.countMessage(text, line)
+		}
+	}
+	det.context = append(det.context, line)
+	if len(det.context) > anomalyStackContext {
+		det.context = det.context[1:]
+	}
+}
+
+func (det *AnomalyDetector) finishStack() {
+	if det.stack == nil {
+		return
+	}
+	output := bytes.Join(det.stack, []byte{'\n'})
+	text := new(bytes.Buffer)
+	for _, line := range det.stack {
+		text.Write(anomalyConsoleRe.ReplaceAll(line, nil))
+		text.WriteByte('\n')
+	}
+	det.stack = nil
+	title := "stack dump"
+	frame, _ := extractStackFrame(linuxStackParams, &stackFmt{
+		parts: []*regexp.Regexp{anomalyStackRe, parseStackTrace},
+		skip:  []string{"show_stack", "dump_backtrace", "show_regs", "sysrq"},
+	}, text.Bytes())
+	if frame != "" {
+		title = fmt.Sprintf("stack dump in %v", frame)
+	}
+	det.add(title, output)
+}
+
+func (det *AnomalyDetector) countMessage(text, line []byte) {
+	msg := normalizeAnomalyMessage(text)
+	if len(det.counts) >= anomalyMaxMessages && det.counts[msg] == 0 {
+		// Something prints lots of distinct errors, start counting from scratch
+		// instead of consuming unbounded amounts of memory.
+		det.counts = make(map[string]int)
+	}
+	det.counts[msg]++
+	if det.counts[msg] == anomalyRepeatThreshold {
+		det.add("repeated message: "+msg, line)
+	}
+}
+
+func (det *AnomalyDetector) add(title string, output []byte) {
+	title = AnomalyPrefix + title
+	if det.titles[title] {
+		return
+	}
+	det.titles[title] = true
+	det.anomalies = append(det.anomalies, &Anomaly{
+		Title:  title,
+		Output: output,
+	})
+}
+
+// normalizeAnomalyMessage removes numbers and addresses from a console message,
+// so that messages that differ only in e.g. device numbers or sizes get the same title.
+func normalizeAnomalyMessage(text []byte) string {
+	return sanitizeTitle(string(anomalyNumberRe.ReplaceAll(bytes.TrimSpace(text), []byte("NUM"))))
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"regexp"
+)
+
+type darwin struct {
+	kernelSrc string
+	kernelObj string
+	ignores   []*regexp.Regexp
+}
+
+func ctorDarwin(cfg *config) (Reporter, []string, error) {
+	ctx := &darwin{
+		kernelSrc: cfg.kernelSrc,
+		kernelObj: cfg.kernelObj,
+		ignores:   cfg.ignores,
+	}
+	return ctx, nil, nil
+}
+
+func (ctx *darwin) ContainsCrash(output []byte) bool {
+	return containsCrash(output, darwinOopses, ctx.ignores)
+}
+
+func (ctx *darwin) Parse(output []byte) *Report {
+	return simpleLineParser(output, darwinOopses, darwinStackParams, ctx.ignores)
+}
+
+func (ctx *darwin) Symbolize(rep *Report) error {
+	// Development kernels print symbolized backtraces themselves.
+	return nil
+}
+
+var (
+	darwinPanicRe     = `panic\(cpu [0-9]+ caller {{ADDR}}\): `
+	darwinBacktraceRe = compile(`Backtrace \(CPU [0-9]+\), (?:panicked thread: {{ADDR}}, )?Frame : Return Address`)
+)
+
+var darwinStackParams = &stackParams{
+	stackStartRes: []*regexp.Regexp{
+		darwinBacktraceRe,
+	},
+	frameRes: []*regexp.Regexp{
+		compile(`^\s*{{ADDR}} : {{ADDR}} [a-z_.]+ : _+([a-zA-Z0-9_]+) \+ 0x[0-9a-f]+`),
+	},
+	skipPatterns: []string{
+		"^panic",
+		"^Debugger",
+		"^DebuggerTrapWithState",
+		"^handle_debugger_trap",
+		"^kdp_i386_trap",
+		"^kernel_trap",
+		"^trap_from_kernel",
+		"^hndl_alltraps",
+		"^return_from_trap",
+		"^sleh_",
+		"^fleh_",
+		"^Assert",
+		"^kasan_",
+		"^asan_",
+		"^zone_require_panic",
+		"^zone_element_was_modified_panic",
+		"^backup_ptr_mismatch_panic",
+	},
+}
+
+var darwinOopses = []*oops{
+	{
+		[]byte("panic(cpu "),
+		[]oopsFormat{
+			{
+				title: compile(darwinPanicRe + `Kernel trap at {{ADDR}}, type [0-9]+=([a-z ]+),`),
+				fmt:   "%[1]v in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						darwinBacktraceRe,
+						parseStackTrace,
+					},
+				},
+			},
+			{
+				title: compile(darwinPanicRe + `"?KASan: invalid [0-9]+-byte (load|store) from {{ADDR}} \[([A-Z_]+)\]`),
+				fmt:   "KASan: %[2]v %[1]v in %[3]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						darwinBacktraceRe,
+						parseStackTrace,
+					},
+				},
+			},
+			{
+				title: compile(darwinPanicRe + `"?a freed zone element has been modified in zone ([^:]+):`),
+				fmt:   "zone element modified after free in %[1]v",
+			},
+			{
+				title: compile(darwinPanicRe + `"?[Aa]ssertion failed: `),
+				fmt:   "assertion failed in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						darwinBacktraceRe,
+						parseStackTrace,
+					},
+				},
+			},
+			{
+				title: compile(darwinPanicRe),
+				fmt:   "panic in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						darwinBacktraceRe,
+						parseStackTrace,
+					},
+				},
+			},
+		},
+		[]*regexp.Regexp{},
+	},
+}
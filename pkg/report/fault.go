@@ -0,0 +1,191 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fault describes the bad memory access of a kernel crash decoded from the register dump
+// and the reported faulting address.
+type Fault struct {
+	// Faulting address (for KASAN shadow accesses, the decoded original address).
+	Addr uint64
+	// Class of the faulting address: one of the Fault* constants.
+	Class string
+	// Offset of the accessed field from the base pointer for near-NULL and poison accesses.
+	Offset uint64 `json:",omitempty"`
+	// Name of the poison value (e.g. LIST_POISON1, POISON_FREE) for poison accesses.
+	Poison string `json:",omitempty"`
+	// Registers contains values of general-purpose registers at the time of the crash.
+	Registers map[string]uint64 `json:",omitempty"`
+}
+
+const (
+	FaultNull     = "null-ptr-deref"
+	FaultNearNull = "near-null-ptr-deref"
+	FaultErrPtr   = "err-ptr-deref"
+	FaultPoison   = "poison-ptr-deref"
+	FaultWild     = "wild-ptr-deref"
+)
+
+const (
+	// Accesses to addresses below this are considered to be NULL pointer (field) dereferences.
+	nearNullLimit = 4 << 10
+	// KASAN_SHADOW_OFFSET and KASAN_SHADOW_SCALE_SHIFT on x86_64.
+	kasanShadowOffset = 0xdffffc0000000000
+	kasanShadowShift  = 3
+	// Start of the canonical kernel half of the address space.
+	kernelAddrStart = 0xffff800000000000
+	// ERR_PTR values are in [-MAX_ERRNO, -1], accesses to fields of ERR_PTR wrap around to low addresses.
+	maxErrno    = 4095
+	errPtrStart = ^uint64(0) - maxErrno - nearNullLimit + 1
+)
+
+// linuxPoisons lists poison values that kernel puts into freed objects and unlinked list entries.
+var linuxPoisons = []struct {
+	name  string
+	value uint64
+}{
+	{"LIST_POISON1", 0xdead000000000100},
+	{"LIST_POISON2", 0xdead000000000122},
+	{"POISON_FREE", 0x6b6b6b6b6b6b6b6b},
+	{"POISON_INUSE", 0x5a5a5a5a5a5a5a5a},
+	{"POISON_FREE_INITMEM", 0xcccccccccccccccc},
+	{"POISON_POINTER_DELTA", 0xdead000000000000},
+}
+
+var (
+	faultKasanRangeRe = regexp.MustCompile(`KASAN: (?:null-ptr-deref|maybe wild-memory-access) in range ` +
+		`\[0x([0-9a-f]+)-0x[0-9a-f]+\]`)
+	faultGPFRe = regexp.MustCompile(`general protection fault, probably for non-canonical address 0x([0-9a-f]+)`)
+	faultCR2Re = regexp.MustCompile(`CR2: ([0-9a-f]{8,16})`)
+	// x86 (RAX: ..., RSP: 0018:...) and arm64 (x0 : ..., sp : ...) register dump entries.
+	faultRegRe = regexp.MustCompile(`(?m)(?:^|[ \t])([A-Z][A-Z0-9]{1,2}|x[0-9]{1,2}|sp|lr) ?: ` +
+		`(?:[0-9a-f]{4}:)?([0-9a-f]{8,16})\b`)
+)
+
+// parseLinuxFault decodes the faulting access for memory access crashes (general protection faults,
+// page faults, KASAN wild accesses). Returns nil for other crashes or if the address is unknown.
+func parseLinuxFault(title string, report []byte) *Fault {
+	if !isFaultTitle(title) {
+		return nil
+	}
+	regs := parseRegisters(report)
+	fault := &Fault{Registers: regs}
+	if match := faultKasanRangeRe.FindSubmatch(report); match != nil {
+		fault.Addr, _ = strconv.ParseUint(string(match[1]), 16, 64)
+	} else if match := faultGPFRe.FindSubmatch(report); match != nil {
+		fault.Addr, _ = strconv.ParseUint(string(match[1]), 16, 64)
+		if fault.Addr >= kasanShadowOffset && fault.Addr < kernelAddrStart {
+			// Instrumented code checks KASAN shadow before the access itself,
+			// so the non-canonical address is the shadow of the actual bad address.
+			fault.Addr = (fault.Addr - kasanShadowOffset) << kasanShadowShift
+		}
+	} else if match := structPageFaultRe.FindSubmatch(report); match != nil {
+		fault.Addr, _ = strconv.ParseUint(string(match[1]), 16, 64)
+	} else if match := faultCR2Re.FindSubmatch(report); match != nil &&
+		!strings.HasPrefix(title, "general protection fault") {
+		// CR2 is updated only by page faults.
+		fault.Addr, _ = strconv.ParseUint(string(match[1]), 16, 64)
+	} else {
+		// Old kernels don't print the address for general protection faults,
+		// but the bad pointer is usually still in one of the registers.
+		for _, reg := range sortedRegisters(regs) {
+			if name, off := matchPoison(regs[reg]); name != "" {
+				fault.Addr, fault.Class, fault.Poison, fault.Offset = regs[reg], FaultPoison, name, off
+				return fault
+			}
+		}
+		return nil
+	}
+	fault.Class, fault.Poison, fault.Offset = classifyFaultAddr(fault.Addr)
+	if fault.Class == "" {
+		return nil
+	}
+	return fault
+}
+
+func isFaultTitle(title string) bool {
+	return strings.HasPrefix(title, "general protection fault") ||
+		strings.HasPrefix(title, "BUG: unable to handle") ||
+		strings.HasPrefix(title, "Unable to handle kernel") ||
+		strings.HasPrefix(title, "KASAN: null-ptr-deref") ||
+		strings.HasPrefix(title, "KASAN: wild-memory-access")
+}
+
+// classifyFaultAddr returns class of the faulting address, poison name and offset.
+// Returns empty class for valid-looking kernel addresses.
+func classifyFaultAddr(addr uint64) (class, poison string, offset uint64) {
+	switch {
+	case addr == 0:
+		return FaultNull, "", 0
+	case addr < nearNullLimit:
+		return FaultNearNull, "", addr
+	case addr >= errPtrStart:
+		return FaultErrPtr, "", 0
+	}
+	if name, off := matchPoison(addr); name != "" {
+		return FaultPoison, name, off
+	}
+	if addr < kernelAddrStart {
+		// User-space or non-canonical address.
+		return FaultWild, "", 0
+	}
+	return "", "", 0
+}
+
+// matchPoison returns name of the poison value that v is closest to and the offset from it.
+func matchPoison(v uint64) (string, uint64) {
+	name, offset := "", uint64(nearNullLimit)
+	for _, poison := range linuxPoisons {
+		if v >= poison.value && v-poison.value < offset {
+			name, offset = poison.name, v-poison.value
+		}
+	}
+	if name == "" {
+		return "", 0
+	}
+	return name, offset
+}
+
+// parseRegisters extracts values of registers from the first register dump in the report.
+func parseRegisters(report []byte) map[string]uint64 {
+	regs := make(map[string]uint64)
+	for _, match := range faultRegRe.FindAllSubmatch(report, -1) {
+		name := string(match[1])
+		if _, ok := regs[name]; ok {
+			// Registers of the user-space part of the stack, or of another CPU.
+			continue
+		}
+		regs[name], _ = strconv.ParseUint(string(match[2]), 16, 64)
+	}
+	if len(regs) == 0 {
+		return nil
+	}
+	return regs
+}
+
+func sortedRegisters(regs map[string]uint64) []string {
+	var names []string
+	for name := range regs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// faultTitleSuffix returns the classification suffix appended to titles.
+func faultTitleSuffix(title string, fault *Fault) string {
+	if fault == nil || strings.HasPrefix(title, "KASAN: ") ||
+		fault.Class == FaultNull && strings.Contains(title, "NULL pointer dereference") {
+		// The title already says it.
+		return ""
+	}
+	return fmt.Sprintf(" (%v)", fault.Class)
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+func TestClassifyFaultAddr(t *testing.T) {
+	tests := []struct {
+		addr   uint64
+		class  string
+		poison string
+		offset uint64
+	}{
+		{0x0, FaultNull, "", 0},
+		{0x28, FaultNearNull, "", 0x28},
+		{0xfff, FaultNearNull, "", 0xfff},
+		{0xfffffffffffffff2, FaultErrPtr, "", 0},
+		{0xffffffffffffffea + 0x10, FaultErrPtr, "", 0},
+		{0xdead000000000108, FaultPoison, "LIST_POISON1", 0x8},
+		{0xdead000000000122, FaultPoison, "LIST_POISON2", 0},
+		{0x6b6b6b6b6b6b6b8b, FaultPoison, "POISON_FREE", 0x20},
+		{0xdead000000000050, FaultPoison, "POISON_POINTER_DELTA", 0x50},
+		{0x20000080, FaultWild, "", 0},
+		{0x1ffff11004a9e005, FaultWild, "", 0},
+		{0xffff88802a4f0028, "", "", 0},
+		{0xffffffff81000000, "", "", 0},
+	}
+	for _, test := range tests {
+		class, poison, offset := classifyFaultAddr(test.addr)
+		if class != test.class || poison != test.poison || offset != test.offset {
+			t.Errorf("addr 0x%x: got %q/%q/0x%x, want %q/%q/0x%x", test.addr,
+				class, poison, offset, test.class, test.poison, test.offset)
+		}
+	}
+}
+
+func TestLinuxFault(t *testing.T) {
+	tests := []struct {
+		log   string
+		title string
+		fault *Fault
+	}{
+		{
+			log: `[   86.392571] general protection fault, probably for non-canonical address 0xdffffc0000000005: 0000 [#1] PREEMPT SMP KASAN
+[   86.393625] KASAN: null-ptr-deref in range [0x0000000000000028-0x000000000000002f]
+[   86.394336] CPU: 1 PID: 8035 Comm: syz-executor.0 Not tainted 5.7.0-rc1+ #1
+[   86.395012] RIP: 0010:foo_release+0x4a/0x110 drivers/foo/foo.c:100
+[   86.395710] RSP: 0018:ffffc90001b87d48 EFLAGS: 00010202
+[   86.396240] RAX: dffffc0000000000 RBX: 0000000000000000 RCX: ffffffff83f5d4a8
+[   86.396950] RDX: 0000000000000005 RSI: ffffffff83f5d4b6 RDI: 0000000000000028
+[   86.397660] Call Trace:
+[   86.397990]  __fput+0x33e/0x880 fs/file_table.c:280
+[   86.398500]  task_work_run+0xf4/0x1b0 kernel/task_work.c:123
+`,
+			title: "general protection fault in foo_release (near-null-ptr-deref)",
+			fault: &Fault{
+				Addr:   0x28,
+				Class:  FaultNearNull,
+				Offset: 0x28,
+			},
+		},
+		{
+			log: `[   86.392571] general protection fault, probably for non-canonical address 0xdead000000000108: 0000 [#1] PREEMPT SMP
+[   86.394336] CPU: 1 PID: 8035 Comm: syz-executor.0 Not tainted 5.7.0-rc1+ #1
+[   86.395012] RIP: 0010:__list_del_entry+0x4a/0x110 include/linux/list.h:132
+[   86.395710] RSP: 0018:ffffc90001b87d48 EFLAGS: 00010202
+[   86.396240] RAX: dead000000000100 RBX: ffff88809a4f0000 RCX: ffffffff83f5d4a8
+[   86.397660] Call Trace:
+[   86.397990]  bar_remove+0x33e/0x880 drivers/bar/bar.c:280
+[   86.398500]  task_work_run+0xf4/0x1b0 kernel/task_work.c:123
+`,
+			title: "general protection fault in bar_remove (poison-ptr-deref)",
+			fault: &Fault{
+				Addr:   0xdead000000000108,
+				Class:  FaultPoison,
+				Offset: 0x8,
+				Poison: "LIST_POISON1",
+			},
+		},
+		{
+			log: `[   61.895826] BUG: unable to handle kernel NULL pointer dereference at           (null)
+[   61.895826] IP: [<ffffffff82d4e304>] inet_sendmsg+0x24/0x30 net/ipv4/af_inet.c:761
+[   61.895826] Oops: 0000 [#1] SMP KASAN
+[   61.895826] CPU: 1 PID: 4070 Comm: syz-executor Not tainted 4.8.0-rc3+ #33
+[   61.895826] RIP: 0010:[<ffffffff82d4e304>]  [<ffffffff82d4e304>] inet_sendmsg+0x24/0x30
+[   61.895826] RSP: 0018:ffff880066befc88  EFLAGS: 00010006
+[   61.895826] RAX: 0000000000000000 RBX: ffff880073b55b00 RCX: 0000000000000006
+[   61.895826] CR2: 0000000000000000 CR3: 0000000073722000 CR4: 00000000000006e0
+[   61.895826] Call Trace:
+[   61.895826]  [<ffffffff8536bfc0>] sock_sendmsg+0xd0/0x110 net/socket.c:622
+`,
+			title: "BUG: unable to handle kernel NULL pointer dereference in inet_sendmsg",
+			fault: &Fault{
+				Addr:  0x0,
+				Class: FaultNull,
+			},
+		},
+		{
+			log: `[   61.895826] BUG: unable to handle kernel paging request at ffff88002bde1e40
+[   61.895826] IP: [<ffffffff82d4e304>] __memset+0x24/0x30
+[   61.895826] Oops: 0002 [#1] SMP KASAN
+[   61.895826] CPU: 1 PID: 4070 Comm: syz-executor Not tainted 4.8.0-rc3+ #33
+[   61.895826] RIP: 0010:[<ffffffff82d4e304>]  [<ffffffff82d4e304>] __memset+0x24/0x30
+[   61.895826] Call Trace:
+[   61.895826]  [<ffffffff8536bfc0>] baz_init+0xd0/0x110 drivers/baz/baz.c:622
+`,
+			title: "BUG: unable to handle kernel paging request in baz_init",
+		},
+	}
+	reporter, err := NewReporter(&mgrconfig.Config{
+		TargetOS:       "linux",
+		TargetArch:     "amd64",
+		ClassifyFaults: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		rep := reporter.Parse([]byte(test.log))
+		if rep == nil {
+			t.Fatalf("test #%v: no report", i)
+		}
+		if rep.Title != test.title {
+			t.Errorf("test #%v: title %q, want %q", i, rep.Title, test.title)
+		}
+		if test.fault == nil {
+			if rep.Fault != nil {
+				t.Errorf("test #%v: unexpected fault %+v", i, rep.Fault)
+			}
+			continue
+		}
+		if rep.Fault == nil {
+			t.Fatalf("test #%v: no fault", i)
+		}
+		if rep.Fault.Addr != test.fault.Addr || rep.Fault.Class != test.fault.Class ||
+			rep.Fault.Offset != test.fault.Offset || rep.Fault.Poison != test.fault.Poison {
+			t.Errorf("test #%v: fault %+v, want %+v", i, rep.Fault, test.fault)
+		}
+		if rep.Fault.Registers["RAX"] == 0 && rep.Fault.Registers["RBX"] == 0 {
+			t.Errorf("test #%v: registers are not parsed: %v", i, rep.Fault.Registers)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+// Max number of normalized frames that contribute to the fingerprint.
+// Outer frames (syscall entry, workqueues, etc) change between kernel versions
+// more frequently, but don't help to identify the bug.
+const fingerprintFrames = 8
+
+// Lines that differ by less than this are considered to be the same line in SameStack.
+const FingerprintLineTolerance = 10
+
+var (
+	// Suffixes added by compiler to cloned/partial functions, e.g. foo.isra.0, foo.constprop.3, foo.cold.
+	frameSuffixRe = regexp.MustCompile(`(?:\.(?:isra|constprop|part|cold|lto_priv|llvm)(?:\.[0-9]+)*)+$`)
+	// Sanitizer, debugging and reporting frames that are present in stacks by accident
+	// or depend on kernel config.
+	fingerprintSkipRe = regexp.MustCompile(strings.Join(append([]string{
+		"__ubsan_",
+		"^ubsan_",
+		"kcsan",
+		"__tsan_",
+		"kfence",
+		"^print_report",
+		"^end_report",
+		"^show_stack",
+		"^handle_bug",
+		"exc_invalid_op",
+	}, linuxStackParams.skipPatterns...), "|"))
+	// Syscall handler bodies, frames after them are entry code.
+	fingerprintSyscallRe = regexp.MustCompile(`^(?:__do_sys_|__se_sys_|__do_compat_sys_|__se_compat_sys_)`)
+	// Entry code frames, stacks are cut at these frames.
+	fingerprintEntryRe = regexp.MustCompile(`^(?:do_syscall_|entry_SYSCALL|__x64_sys_|__ia32_sys_|` +
+		`__arm64_sys_|el0_svc|ret_from_fork|kthread$|worker_thread$|process_one_work$|Xsyscall$|syscall$)`)
+)
+
+// NormalizeFrame returns the function name with compiler-generated suffixes removed.
+func NormalizeFrame(fn string) string {
+	return frameSuffixRe.ReplaceAllString(fn, "")
+}
+
+// NormalizeStack returns the stack with the frames that don't identify the bug removed:
+// unreliable frames, sanitizer and reporting frames and entry code.
+// Function names are normalized with NormalizeFrame, module offsets are dropped.
+func NormalizeStack(frames []*StackFrame) []*StackFrame {
+	var res []*StackFrame
+	for _, frame := range frames {
+		if frame.Unreliable {
+			continue
+		}
+		fn := NormalizeFrame(frame.Func)
+		if fingerprintSkipRe.MatchString(fn) {
+			continue
+		}
+		if fingerprintEntryRe.MatchString(fn) {
+			if len(res) != 0 {
+				break
+			}
+			continue
+		}
+		res = append(res, &StackFrame{
+			Func:   fn,
+			Module: frame.Module,
+			File:   frame.File,
+			Line:   frame.Line,
+			Inline: frame.Inline,
+		})
+		if fingerprintSyscallRe.MatchString(fn) {
+			break
+		}
+	}
+	return res
+}
+
+// SameStack returns true if the normalized stacks consist of the same functions
+// in the same files, and line numbers (if known on both sides) differ by less than tolerance.
+func SameStack(a, b []*StackFrame, tolerance int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		fa, fb := a[i], b[i]
+		if fa.Func != fb.Func || fa.Module != fb.Module ||
+			fa.File != "" && fb.File != "" && fa.File != fb.File {
+			return false
+		}
+		if fa.Line != 0 && fb.Line != 0 && (fa.Line-fb.Line >= tolerance || fb.Line-fa.Line >= tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fingerprint returns an identifier of the bug that is stable across kernel versions
+// and configs: it is derived from the bug class (title without location) and the top frames
+// of the normalized primary stack trace, so it does not depend on function offsets,
+// line numbers, inlining decisions and entry code. It is preferably computed
+// on symbolized reports. Returns empty string if the report has no stack trace.
+func Fingerprint(rep *Report) string {
+	frames := FingerprintStack(rep)
+	if len(frames) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(TitleClass(rep.Title))
+	for _, frame := range frames {
+		fmt.Fprintf(&sb, "\n%v", frame.Func)
+		if frame.Module != "" {
+			fmt.Fprintf(&sb, " [%v]", frame.Module)
+		}
+	}
+	return hash.String([]byte(sb.String()))
+}
+
+// FingerprintStack returns the normalized top frames of the primary stack trace of the report.
+// Inline frames are treated as normal frames: in symbolized reports inlined functions are present
+// in the stack regardless of inlining decisions of the compiler.
+func FingerprintStack(rep *Report) []*StackFrame {
+	for _, stack := range extractStackTraces(rep.Report[rep.reportPrefixLen:]) {
+		if isRegisterStack(stack.Name) {
+			continue
+		}
+		frames := NormalizeStack(stack.Frames)
+		if len(frames) > fingerprintFrames {
+			frames = frames[:fingerprintFrames]
+		}
+		for _, frame := range frames {
+			frame.Inline = false
+		}
+		if len(frames) != 0 {
+			return frames
+		}
+	}
+	return nil
+}
+
+// isRegisterStack returns true for single-frame stacks extracted from register dumps (RIP, pc).
+func isRegisterStack(name string) bool {
+	return name == "RIP" || name == "IP" || name == "pc"
+}
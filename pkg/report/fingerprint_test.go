@@ -0,0 +1,130 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+func TestNormalizeFrame(t *testing.T) {
+	tests := map[string]string{
+		"foo":                  "foo",
+		"foo.isra.0":           "foo",
+		"foo.constprop.3":      "foo",
+		"foo.part.1.isra.2":    "foo",
+		"foo.cold":             "foo",
+		"foo.isra.0.cold":      "foo",
+		"tcp_v4_rcv.lto_priv":  "tcp_v4_rcv",
+		"foo.bar":              "foo.bar",
+		"__do_sys_foo.isra.12": "__do_sys_foo",
+	}
+	for fn, want := range tests {
+		if got := NormalizeFrame(fn); got != want {
+			t.Errorf("NormalizeFrame(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	reporter, err := NewReporter(&mgrconfig.Config{
+		TargetOS:   "linux",
+		TargetArch: "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parse := func(output string) *Report {
+		rep := reporter.Parse([]byte(output))
+		if rep == nil {
+			t.Fatalf("no report in:\n%v", output)
+		}
+		return rep
+	}
+	rep1 := parse(`
+BUG: KASAN: use-after-free in tcp_ack+0x2f5/0x330 net/ipv4/tcp_input.c:1748
+Read of size 8 at addr ffff88004fab1858 by task syz-executor0/30168
+
+CPU: 1 PID: 30168 Comm: syz-executor0 Not tainted 4.12.0-rc3+ #3
+Call Trace:
+ __dump_stack lib/dump_stack.c:16 [inline]
+ dump_stack+0x292/0x395 lib/dump_stack.c:52
+ print_address_description+0x73/0x280 mm/kasan/report.c:252
+ kasan_report+0x22b/0x340 mm/kasan/report.c:408
+ tcp_ack+0x2f5/0x330 net/ipv4/tcp_input.c:1748
+ tcp_rcv_established+0x10/0x20 net/ipv4/tcp_input.c:5710
+ tcp_v4_do_rcv+0x10/0x20 net/ipv4/tcp_ipv4.c:1560
+ __do_sys_sendmsg net/socket.c:2300 [inline]
+ __se_sys_sendmsg net/socket.c:2298 [inline]
+ __x64_sys_sendmsg+0x10/0x20 net/socket.c:2298
+ do_syscall_64+0x10/0x20 arch/x86/entry/common.c:300
+ entry_SYSCALL_64_after_hwframe+0x49/0xbe
+`)
+	// The same bug in a different kernel version: different offsets and lines,
+	// a compiler-generated clone and a tcp_rcv_established frame inlined into its caller.
+	rep2 := parse(`
+BUG: KASAN: use-after-free in tcp_ack.isra.0+0x3f5/0x530 net/ipv4/tcp_input.c:1752
+Read of size 8 at addr ffff88004fab1858 by task syz-executor1/100
+
+CPU: 0 PID: 100 Comm: syz-executor1 Not tainted 5.6.0+ #3
+Call Trace:
+ __dump_stack lib/dump_stack.c:77 [inline]
+ dump_stack+0x107/0x163 lib/dump_stack.c:118
+ print_address_description.constprop.0+0x1a/0x210 mm/kasan/report.c:375
+ __kasan_report mm/kasan/report.c:507 [inline]
+ kasan_report.cold+0x1f/0x37 mm/kasan/report.c:524
+ tcp_ack.isra.0+0x3f5/0x530 net/ipv4/tcp_input.c:1752
+ ? tcp_ack.isra.0+0x100/0x530 net/ipv4/tcp_input.c:1700
+ tcp_rcv_established net/ipv4/tcp_input.c:5716 [inline]
+ tcp_v4_do_rcv+0x30/0x50 net/ipv4/tcp_ipv4.c:1565
+ __do_sys_sendmsg net/socket.c:2305 [inline]
+ __se_sys_sendmsg net/socket.c:2308 [inline]
+ __x64_sys_sendmsg+0x10/0x20 net/socket.c:2308
+ do_syscall_64+0x2d/0x70 arch/x86/entry/common.c:46
+ entry_SYSCALL_64_after_hwframe+0x44/0xa9
+`)
+	// A different bug in the same function.
+	rep3 := parse(`
+BUG: KASAN: slab-out-of-bounds in tcp_ack+0x2f5/0x330 net/ipv4/tcp_input.c:1748
+Read of size 8 at addr ffff88004fab1858 by task syz-executor0/30168
+
+CPU: 1 PID: 30168 Comm: syz-executor0 Not tainted 4.12.0-rc3+ #3
+Call Trace:
+ dump_stack+0x292/0x395 lib/dump_stack.c:52
+ tcp_ack+0x2f5/0x330 net/ipv4/tcp_input.c:1748
+ tcp_rcv_established+0x10/0x20 net/ipv4/tcp_input.c:5710
+ tcp_v4_do_rcv+0x10/0x20 net/ipv4/tcp_ipv4.c:1560
+`)
+	stack1, stack2 := FingerprintStack(rep1), FingerprintStack(rep2)
+	var funcs []string
+	for _, frame := range stack1 {
+		funcs = append(funcs, frame.Func)
+	}
+	want := []string{"tcp_ack", "tcp_rcv_established", "tcp_v4_do_rcv", "__do_sys_sendmsg"}
+	if len(funcs) != len(want) {
+		t.Fatalf("got normalized stack %q, want %q", funcs, want)
+	}
+	for i := range want {
+		if funcs[i] != want[i] {
+			t.Fatalf("got normalized stack %q, want %q", funcs, want)
+		}
+	}
+	if !SameStack(stack1, stack2, FingerprintLineTolerance) {
+		t.Errorf("stacks are not the same")
+	}
+	if SameStack(stack1, stack2, 2) {
+		t.Errorf("stacks are the same with small line tolerance")
+	}
+	fp1, fp2, fp3 := Fingerprint(rep1), Fingerprint(rep2), Fingerprint(rep3)
+	if fp1 == "" || fp1 != fp2 {
+		t.Errorf("different fingerprints for the same bug: %q/%q", fp1, fp2)
+	}
+	if fp1 == fp3 {
+		t.Errorf("same fingerprint for different bugs")
+	}
+	if fp := Fingerprint(parse("BUG: spinlock bad magic on CPU#0\n")); fp != "" {
+		t.Errorf("got fingerprint %q for a report without stack", fp)
+	}
+}
@@ -6,8 +6,6 @@ package report
 import (
 	"bytes"
 	"regexp"
-
-	"github.com/google/syzkaller/sys/targets"
 )
 
 type freebsd struct {
@@ -16,12 +14,11 @@ type freebsd struct {
 	ignores   []*regexp.Regexp
 }
 
-func ctorFreebsd(target *targets.Target, kernelSrc, kernelObj string,
-	ignores []*regexp.Regexp) (Reporter, []string, error) {
+func ctorFreebsd(cfg *config) (Reporter, []string, error) {
 	ctx := &freebsd{
-		kernelSrc: kernelSrc,
-		kernelObj: kernelObj,
-		ignores:   ignores,
+		kernelSrc: cfg.kernelSrc,
+		kernelObj: cfg.kernelObj,
+		ignores:   cfg.ignores,
 	}
 	return ctx, nil, nil
 }
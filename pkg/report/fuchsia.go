@@ -13,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/google/syzkaller/pkg/symbolizer"
-	"github.com/google/syzkaller/sys/targets"
 	"github.com/ianlancetaylor/demangle"
 )
 
@@ -27,6 +26,7 @@ var (
 	zirconBT           = regexp.MustCompile(`^bt#[0-9]+: (0x[0-9a-f]+)`)
 	zirconReportEnd    = []byte("Halted")
 	zirconAssertFailed = []byte("ASSERT FAILED at")
+	zirconCrashlog     = []byte("ZIRCON REBOOT REASON (")
 	zirconLinePrefix   = regexp.MustCompile(`^\[\d+\.\d+\] \d+\.\d+> `)
 	zirconUnrelated    = []*regexp.Regexp{
 		regexp.MustCompile(`^$`),
@@ -39,13 +39,12 @@ var (
 	}
 )
 
-func ctorFuchsia(target *targets.Target, kernelSrc, kernelObj string,
-	ignores []*regexp.Regexp) (Reporter, []string, error) {
+func ctorFuchsia(cfg *config) (Reporter, []string, error) {
 	ctx := &fuchsia{
-		ignores: ignores,
+		ignores: cfg.ignores,
 	}
-	if kernelObj != "" {
-		ctx.obj = filepath.Join(kernelObj, target.KernelObject)
+	if cfg.kernelObj != "" {
+		ctx.obj = filepath.Join(cfg.kernelObj, cfg.target.KernelObject)
 	}
 	suppressions := []string{
 		"fatal exception: process /tmp/syz-fuzzer", // OOM presumably
@@ -64,6 +63,19 @@ func (ctx *fuchsia) Parse(output []byte) *Report {
 	if rep == nil {
 		return nil
 	}
+	if rep.Title == unexpectedKernelReboot {
+		// After a kernel panic the reboot is followed by the crashlog of the previous boot,
+		// which describes the crash much better than just an unexpected reboot.
+		if pos := bytes.Index(symbolized[rep.StartPos:], zirconCrashlog); pos != -1 {
+			pos += rep.StartPos
+			crashlog := simpleLineParser(symbolized[pos:], zirconOopses, zirconStackParams, ctx.ignores)
+			if crashlog != nil && crashlog.Title != unexpectedKernelReboot {
+				crashlog.StartPos += pos
+				crashlog.EndPos += pos
+				rep = crashlog
+			}
+		}
+	}
 	rep.Output = output
 	if report := ctx.shortenReport(rep.Report); len(report) != 0 {
 		rep.Report = report
@@ -276,6 +288,38 @@ var zirconOopses = []*oops{
 		},
 		[]*regexp.Regexp{},
 	},
+	{
+		// Crashlog of the previous boot, printed after reboot.
+		[]byte("ZIRCON REBOOT REASON ("),
+		[]oopsFormat{
+			{
+				title: compile("ZIRCON REBOOT REASON \\(KERNEL PANIC\\)(?:.*\\n)+?.*ASSERT FAILED(?:.*\\n)+?.*bt#00:"),
+				fmt:   "ASSERT FAILED in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						parseStackTrace,
+					},
+				},
+			},
+			{
+				title: compile("ZIRCON REBOOT REASON \\(KERNEL PANIC\\)(?:.*\\n)+?.*bt#00:"),
+				fmt:   "KERNEL PANIC in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						parseStackTrace,
+					},
+				},
+			},
+			{
+				title:        compile("ZIRCON REBOOT REASON \\(([A-Z ]+)\\)"),
+				fmt:          "REBOOT REASON: %[1]v",
+				noStackTrace: true,
+			},
+		},
+		[]*regexp.Regexp{
+			compile("ZIRCON REBOOT REASON \\((?:NO CRASH|UNKNOWN)\\)"),
+		},
+	},
 	// We should detect just "stopping other cpus" as some kernel crash rather then as "lost connection",
 	// but if we add oops for "stopping other cpus", then it will interfere with other formats,
 	// because "stopping other cpus" usually goes after "ZIRCON KERNEL PANIC", but sometimes before. Mess.
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/sys/targets"
 )
 
 func Fuzz(data []byte) int {
@@ -45,21 +46,26 @@ func Fuzz(data []byte) int {
 var fuzzReporters = func() []Reporter {
 	var reporters []Reporter
 	for os := range ctors {
-		if os == "windows" {
-			continue
-		}
 		cfg := &mgrconfig.Config{
 			TargetOS:   os,
-			TargetArch: "amd64",
+			TargetArch: testArch(os),
 		}
 		reporter, err := NewReporter(cfg)
 		if err != nil {
 			panic(err)
 		}
-		if _, ok := reporter.(*stub); ok {
-			continue
-		}
 		reporters = append(reporters, reporter)
 	}
 	return reporters
 }()
+
+// testArch returns arch to create reporters with in tests and fuzzing:
+// amd64 if the OS supports it, otherwise any arch supported by the OS.
+func testArch(OS string) string {
+	if archs := targets.List[OS]; archs != nil && archs["amd64"] == nil {
+		for arch := range archs {
+			return arch
+		}
+	}
+	return "amd64"
+}
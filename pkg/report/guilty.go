@@ -0,0 +1,155 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+// guiltyIgnores lists source files (regexps) that are never blamed for a crash, per OS.
+// These are generic allocator, library, debugging, locking and scheduling files
+// that are present in lots of stack traces, but are rarely the culprit.
+var guiltyIgnores = map[string][]string{
+	"linux": {
+		`.*\.h`,
+		`^lib/.*`,
+		`^virt/lib/.*`,
+		`^mm/kasan/.*`,
+		`^mm/kmsan/.*`,
+		`^mm/kfence/.*`,
+		`^kernel/kcsan/.*`,
+		`^kernel/kcov.c`,
+		`^mm/sl.b.c`,
+		`^mm/memory.c`,
+		`^mm/percpu.*`,
+		`^mm/vmalloc.c`,
+		`^mm/page_alloc.c`,
+		`^mm/util.c`,
+		`^kernel/rcu/.*`,
+		`^arch/.*/kernel/traps.c`,
+		`^arch/.*/mm/fault.c`,
+		`^arch/.*/mm/physaddr.c`,
+		`^kernel/locking/.*`,
+		`^kernel/panic.c`,
+		`^kernel/printk/printk.*.c`,
+		`^kernel/softirq.c`,
+		`^kernel/kthread.c`,
+		`^kernel/sched/.*.c`,
+		`^kernel/time/timer.c`,
+		`^kernel/workqueue.c`,
+		`^net/core/dev.c`,
+		`^net/core/sock.c`,
+		`^net/core/skbuff.c`,
+		`^fs/proc/generic.c`,
+		`^trusty/`, // Trusty sources are not in linux kernel tree.
+	},
+}
+
+// guiltyFileIgnores returns compiled guilty ignores for the OS plus extra ignores.
+func guiltyFileIgnores(os string, extra []*regexp.Regexp) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, re := range guiltyIgnores[os] {
+		res = append(res, regexp.MustCompile(re))
+	}
+	return append(res, extra...)
+}
+
+type guiltyFrame struct {
+	fn     string // empty if the line does not look like a stack frame
+	file   string
+	inline bool
+}
+
+var guiltyFrameRe = regexp.MustCompile(`^\s*(?:\[\<?(?:0x)?[0-9a-f]+\>?\]\s+)?` +
+	`([a-zA-Z0-9_.]+)(?:\+0x[0-9a-f]+/0x[0-9a-f]+)?(?: \[[a-zA-Z0-9_\-]+\])? $`)
+
+// extractGuiltyFrames returns all source file references in the report in order,
+// along with the function names for the references that are part of stack frames.
+func extractGuiltyFrames(report []byte) []guiltyFrame {
+	var frames []guiltyFrame
+	for _, ln := range bytes.Split(report, []byte{'\n'}) {
+		for _, match := range filenameRe.FindAllIndex(ln, -1) {
+			frame := guiltyFrame{
+				file: filepath.Clean(string(bytes.Split(ln[match[0]:match[1]], []byte{':'})[0])),
+			}
+			if m := guiltyFrameRe.FindSubmatch(ln[:match[0]]); m != nil {
+				frame.fn = string(m[1])
+				frame.inline = bytes.HasSuffix(ln, []byte(" [inline]"))
+			}
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+// selectGuiltyFile returns the innermost file that is not ignored, giving preference
+// to files of the focus subsystem (if any). If the call graph is available,
+// frames that can't be on the actual call chain are skipped.
+func selectGuiltyFile(frames []guiltyFrame, ignores, focus []*regexp.Regexp,
+	callGraph symbolizer.CallGraph) string {
+	if callGraph != nil {
+		frames = filterCallChain(frames, callGraph)
+	}
+	fallback := ""
+	for _, frame := range frames {
+		if matchesAnyString(frame.file, ignores) {
+			continue
+		}
+		if len(focus) == 0 || matchesAnyString(frame.file, focus) {
+			return frame.file
+		}
+		if fallback == "" {
+			fallback = frame.file
+		}
+	}
+	return fallback
+}
+
+// filterCallChain removes stack frames that are not called by the next (outer) frame.
+// Such frames are usually stale entries left on stack that unwinder mistakenly reported.
+// Frames are checked from the outermost to the innermost one, so that a single bogus frame
+// does not invalidate the rest of the stack. Frames of functions unknown to the call graph,
+// inline frames and source references that are not stack frames are always preserved.
+func filterCallChain(frames []guiltyFrame, callGraph symbolizer.CallGraph) []guiltyFrame {
+	keep := make([]bool, len(frames))
+	caller := ""
+	for i := len(frames) - 1; i >= 0; i-- {
+		frame := frames[i]
+		keep[i] = true
+		if frame.fn == "" {
+			caller = ""
+			continue
+		}
+		if frame.inline {
+			// Calls from inlined functions are attributed to the function they are inlined into.
+			continue
+		}
+		if caller != "" && callGraph.Known(caller) && callGraph.Known(frame.fn) &&
+			!callGraph.Calls(caller, frame.fn) {
+			keep[i] = false
+			continue
+		}
+		caller = frame.fn
+	}
+	var res []guiltyFrame
+	for i, frame := range frames {
+		if keep[i] {
+			res = append(res, frame)
+		}
+	}
+	return res
+}
+
+func matchesAnyString(s string, res []*regexp.Regexp) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
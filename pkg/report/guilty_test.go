@@ -0,0 +1,81 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+func TestSelectGuiltyFile(t *testing.T) {
+	const report = `BUG: KASAN: use-after-free in skb_release_data+0x10/0x20 net/core/skbuff.c:100
+Call Trace:
+ __dump_stack lib/dump_stack.c:77 [inline]
+ dump_stack+0x10/0x20 lib/dump_stack.c:118
+ skb_release_data+0x10/0x20 net/core/skbuff.c:100
+ stale_func+0x10/0x20 fs/stale.c:10
+ tcp_inline net/ipv4/tcp.c:20 [inline]
+ tcp_close+0x10/0x20 net/ipv4/tcp.c:30
+ e1000_clean+0x10/0x20 drivers/net/ethernet/intel/e1000/e1000_main.c:40
+ do_syscall_64+0x10/0x20 arch/x86/entry/common.c:50
+`
+	frames := extractGuiltyFrames([]byte(report))
+	ignores := guiltyFileIgnores("linux", []*regexp.Regexp{regexp.MustCompile(`^arch/x86/entry/`)})
+	callGraph := symbolizer.CallGraph{
+		"dump_stack":       {},
+		"skb_release_data": {"dump_stack": true},
+		"stale_func":       {},
+		"tcp_close":        {"skb_release_data": true},
+		"e1000_clean":      {"tcp_close": true},
+		"do_syscall_64":    {},
+	}
+	tests := []struct {
+		focus     []string
+		callGraph symbolizer.CallGraph
+		file      string
+	}{
+		{nil, nil, "fs/stale.c"},
+		{nil, callGraph, "net/ipv4/tcp.c"},
+		{[]string{"^drivers/net/"}, nil, "drivers/net/ethernet/intel/e1000/e1000_main.c"},
+		{[]string{"^net/ipv4/", "^drivers/net/"}, nil, "net/ipv4/tcp.c"},
+		{[]string{"^sound/"}, callGraph, "net/ipv4/tcp.c"},
+	}
+	for i, test := range tests {
+		focus, err := compileRegexps(test.focus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := selectGuiltyFile(frames, ignores, focus, test.callGraph); got != test.file {
+			t.Errorf("test #%v: got guilty file %q, want %q", i, got, test.file)
+		}
+	}
+}
+
+func TestSelectGuiltyFileIndirectCall(t *testing.T) {
+	const report = `BUG: KASAN: use-after-free in ext4_read+0x10/0x20 fs/ext4/file.c:100
+Call Trace:
+ ext4_read+0x10/0x20 fs/ext4/file.c:100
+ ext4_file_read_iter+0x10/0x20 fs/ext4/file.c:130
+ call_read_iter include/linux/fs.h:1900 [inline]
+ new_sync_read+0x10/0x20 fs/read_write.c:400
+ vfs_read+0x10/0x20 fs/read_write.c:480
+ do_syscall_64+0x10/0x20 arch/x86/entry/common.c:50
+`
+	frames := extractGuiltyFrames([]byte(report))
+	ignores := guiltyFileIgnores("linux", []*regexp.Regexp{regexp.MustCompile(`^arch/x86/entry/`)})
+	// new_sync_read calls f_op->read_iter via a retpoline thunk,
+	// so the call graph has no edge to ext4_file_read_iter.
+	callGraph := symbolizer.CallGraph{
+		"ext4_read":           {},
+		"ext4_file_read_iter": {"ext4_read": true},
+		"new_sync_read":       {symbolizer.IndirectCall: true},
+		"vfs_read":            {"new_sync_read": true},
+		"do_syscall_64":       {symbolizer.IndirectCall: true},
+	}
+	if got := selectGuiltyFile(frames, ignores, nil, callGraph); got != "fs/ext4/file.c" {
+		t.Errorf("got guilty file %q, want %q", got, "fs/ext4/file.c")
+	}
+}
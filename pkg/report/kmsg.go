@@ -0,0 +1,71 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// KmsgSeverity is the syslog severity of a kernel message (KERN_EMERG..KERN_DEBUG).
+// Lower values are more severe.
+type KmsgSeverity int
+
+var kmsgSeverityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+func (sev KmsgSeverity) String() string {
+	if sev < 0 || int(sev) >= len(kmsgSeverityNames) {
+		return fmt.Sprintf("severity%v", int(sev))
+	}
+	return kmsgSeverityNames[sev]
+}
+
+func ParseKmsgSeverity(name string) (KmsgSeverity, error) {
+	for sev, name1 := range kmsgSeverityNames {
+		if name1 == name {
+			return KmsgSeverity(sev), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown kernel message severity %q (want emerg/alert/crit/err/warning/notice/info/debug)",
+		name)
+}
+
+// KmsgRecord is a kernel message read from /dev/kmsg.
+type KmsgRecord struct {
+	Severity KmsgSeverity
+	Text     []byte
+}
+
+// ParseKmsgRecord parses a single /dev/kmsg record of the form:
+// "PRIO,SEQ,USEC,FLAGS[,...];TEXT\n" optionally followed by " KEY=VALUE\n" lines.
+// Returns nil for malformed records and for messages that don't come from the kernel
+// (written to /dev/kmsg by user-space, including the fuzzer itself).
+func ParseKmsgRecord(data []byte) *KmsgRecord {
+	pos := bytes.IndexByte(data, ';')
+	if pos == -1 {
+		return nil
+	}
+	header, text := data[:pos], data[pos+1:]
+	if end := bytes.IndexByte(text, '\n'); end != -1 {
+		text = text[:end]
+	}
+	if comma := bytes.IndexByte(header, ','); comma != -1 {
+		header = header[:comma]
+	}
+	prio, err := strconv.ParseUint(string(header), 10, 32)
+	if err != nil || prio>>3 != 0 {
+		return nil
+	}
+	return &KmsgRecord{
+		Severity: KmsgSeverity(prio & 7),
+		Text:     text,
+	}
+}
+
+// KmsgTitle returns the title of a non-fatal kernel message finding.
+// Messages that differ only in numbers get the same title.
+func KmsgTitle(rec *KmsgRecord) string {
+	return fmt.Sprintf("%vkernel %v: %v", AnomalyPrefix, rec.Severity, normalizeAnomalyMessage(rec.Text))
+}
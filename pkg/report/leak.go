@@ -0,0 +1,48 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	linuxLeakAllocRe = regexp.MustCompile(strings.Join(linuxLeakAllocFrames, "|"))
+	// Matches both raw and symbolized (including inline) backtrace frames.
+	linuxLeakFrameRe = compile(`^\s*{{PC}}\s+([a-zA-Z0-9_]+)`)
+)
+
+// LeakStack returns functions of the allocation stack of the first leaked object
+// in a kmemleak report. Leading memory allocator frames are skipped, so the first
+// returned function is the one the report title refers to.
+// Returns nil if rep is not a memory leak report or the stack can't be parsed.
+func LeakStack(rep *Report) []string {
+	if rep == nil || rep.Type != MemoryLeak {
+		return nil
+	}
+	pos := bytes.Index(rep.Report, []byte("backtrace:"))
+	if pos == -1 {
+		return nil
+	}
+	s := bufio.NewScanner(bytes.NewReader(rep.Report[pos:]))
+	s.Scan()
+	var frames []string
+	for s.Scan() {
+		ln := bytes.Trim(s.Bytes(), "\r")
+		match := linuxLeakFrameRe.FindSubmatch(ln)
+		if match == nil {
+			// Backtrace ends with the first non-frame line.
+			break
+		}
+		frame := string(match[1])
+		if len(frames) == 0 && linuxLeakAllocRe.MatchString(frame) {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net/mail"
 	"path/filepath"
 	"regexp"
@@ -15,8 +16,8 @@ import (
 	"time"
 
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/subsystem"
 	"github.com/google/syzkaller/pkg/symbolizer"
-	"github.com/google/syzkaller/sys/targets"
 )
 
 type linux struct {
@@ -30,16 +31,20 @@ type linux struct {
 	taskContext           *regexp.Regexp
 	cpuContext            *regexp.Regexp
 	guiltyFileBlacklist   []*regexp.Regexp
+	focus                 []*regexp.Regexp
+	callGraph             symbolizer.CallGraph
 	reportStartIgnores    []*regexp.Regexp
 	infoMessagesWithStack [][]byte
 	eoi                   []byte
+	classifyFaults        bool
+	subsystems            *subsystem.Matcher
 }
 
-func ctorLinux(target *targets.Target, kernelSrc, kernelObj string, ignores []*regexp.Regexp) (Reporter, []string, error) {
+func ctorLinux(cfg *config) (Reporter, []string, error) {
 	var symbols map[string][]symbolizer.Symbol
 	vmlinux := ""
-	if kernelObj != "" {
-		vmlinux = filepath.Join(kernelObj, target.KernelObject)
+	if cfg.kernelObj != "" {
+		vmlinux = filepath.Join(cfg.kernelObj, cfg.target.KernelObject)
 		var err error
 		symbols, err = symbolizer.ReadSymbols(vmlinux)
 		if err != nil {
@@ -47,11 +52,11 @@ func ctorLinux(target *targets.Target, kernelSrc, kernelObj string, ignores []*r
 		}
 	}
 	ctx := &linux{
-		kernelSrc: kernelSrc,
-		kernelObj: kernelObj,
+		kernelSrc: cfg.kernelSrc,
+		kernelObj: cfg.kernelObj,
 		vmlinux:   vmlinux,
 		symbols:   symbols,
-		ignores:   ignores,
+		ignores:   cfg.ignores,
 	}
 	ctx.consoleOutputRe = regexp.MustCompile(`^(?:\*\* [0-9]+ printk messages dropped \*\* )?(?:.* login: )?(?:\<[0-9]+\>)?\[ *[0-9]+\.[0-9]+\](\[ *(?:C|T)[0-9]+\])? `)
 	ctx.questionableRes = []*regexp.Regexp{
@@ -61,36 +66,29 @@ func ctorLinux(target *targets.Target, kernelSrc, kernelObj string, ignores []*r
 	ctx.taskContext = regexp.MustCompile(`\[ *T[0-9]+\]`)
 	ctx.cpuContext = regexp.MustCompile(`\[ *C[0-9]+\]`)
 	ctx.eoi = []byte("<EOI>")
-	ctx.guiltyFileBlacklist = []*regexp.Regexp{
-		regexp.MustCompile(`.*\.h`),
-		regexp.MustCompile(`^lib/.*`),
-		regexp.MustCompile(`^virt/lib/.*`),
-		regexp.MustCompile(`^mm/kasan/.*`),
-		regexp.MustCompile(`^mm/kmsan/.*`),
-		regexp.MustCompile(`^kernel/kcov.c`),
-		regexp.MustCompile(`^mm/sl.b.c`),
-		regexp.MustCompile(`^mm/memory.c`),
-		regexp.MustCompile(`^mm/percpu.*`),
-		regexp.MustCompile(`^mm/vmalloc.c`),
-		regexp.MustCompile(`^mm/page_alloc.c`),
-		regexp.MustCompile(`^mm/util.c`),
-		regexp.MustCompile(`^kernel/rcu/.*`),
-		regexp.MustCompile(`^arch/.*/kernel/traps.c`),
-		regexp.MustCompile(`^arch/.*/mm/fault.c`),
-		regexp.MustCompile(`^arch/.*/mm/physaddr.c`),
-		regexp.MustCompile(`^kernel/locking/.*`),
-		regexp.MustCompile(`^kernel/panic.c`),
-		regexp.MustCompile(`^kernel/printk/printk.*.c`),
-		regexp.MustCompile(`^kernel/softirq.c`),
-		regexp.MustCompile(`^kernel/kthread.c`),
-		regexp.MustCompile(`^kernel/sched/.*.c`),
-		regexp.MustCompile(`^kernel/time/timer.c`),
-		regexp.MustCompile(`^kernel/workqueue.c`),
-		regexp.MustCompile(`^net/core/dev.c`),
-		regexp.MustCompile(`^net/core/sock.c`),
-		regexp.MustCompile(`^net/core/skbuff.c`),
-		regexp.MustCompile(`^fs/proc/generic.c`),
-		regexp.MustCompile(`^trusty/`), // Trusty sources are not in linux kernel tree.
+	ctx.guiltyFileBlacklist = guiltyFileIgnores("linux", cfg.guiltyIgnores)
+	ctx.focus = cfg.focus
+	ctx.classifyFaults = cfg.classifyFaults
+	if cfg.guiltyCallGraph && vmlinux != "" {
+		callGraph, err := symbolizer.ReadCallGraph(vmlinux)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx.callGraph = callGraph
+	}
+	if cfg.kernelSrc != "" {
+		maintainers := filepath.Join(cfg.kernelSrc, "MAINTAINERS")
+		if osutil.IsExist(maintainers) {
+			data, err := ioutil.ReadFile(maintainers)
+			if err != nil {
+				return nil, nil, err
+			}
+			subsystems, err := subsystem.Parse(data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse MAINTAINERS: %v", err)
+			}
+			ctx.subsystems = subsystem.NewMatcher(subsystems)
+		}
 	}
 	// These pattern do _not_ start a new report, i.e. can be in a middle of another report.
 	ctx.reportStartIgnores = []*regexp.Regexp{
@@ -142,8 +140,12 @@ func (ctx *linux) Parse(output []byte) *Report {
 		Output:   output,
 		StartPos: startPos,
 	}
-	endPos, reportEnd, report, prefix := ctx.findReport(output, oops, startPos, context)
+	endPos, reportEnd, report, prefix, secondReportPos := ctx.findReport(output, oops, startPos, context)
 	rep.EndPos = endPos
+	rep.nextPos = len(output)
+	if secondReportPos != 0 {
+		rep.nextPos = secondReportPos
+	}
 	title, corrupted, format := extractDescription(report[:reportEnd], oops, linuxStackParams)
 	if title == "" {
 		prefix = nil
@@ -166,6 +168,11 @@ func (ctx *linux) Parse(output []byte) *Report {
 	if !rep.Corrupted {
 		rep.Corrupted, rep.CorruptedReason = ctx.isCorrupted(title, report, format)
 	}
+	rep.Details = parseLinuxDetails(title, report)
+	rep.Fault = parseLinuxFault(title, report)
+	if ctx.classifyFaults {
+		rep.Title += faultTitleSuffix(title, rep.Fault)
+	}
 	return rep
 }
 
@@ -193,7 +200,7 @@ func (ctx *linux) findFirstOops(output []byte) (oops *oops, startPos int, contex
 // Yes, it is complex, but all state and logic are tightly coupled. It's unclear how to simplify it.
 // nolint: gocyclo
 func (ctx *linux) findReport(output []byte, oops *oops, startPos int, context string) (
-	endPos, reportEnd int, report []byte, prefix [][]byte) {
+	endPos, reportEnd int, report []byte, prefix [][]byte, secondReportPos int) {
 	// Prepend 5 lines preceding start of the report,
 	// they can contain additional info related to the report.
 	maxPrefix := 5
@@ -201,7 +208,6 @@ func (ctx *linux) findReport(output []byte, oops *oops, startPos int, context st
 		// If we have CONFIG_PRINTK_CALLER, we collect more b/c it comes from the same task.
 		maxPrefix = 50
 	}
-	secondReportPos := 0
 	textLines := 0
 	skipText, cpuTraceback := false, false
 	for pos, next := 0, 0; pos < len(output); pos = next + 1 {
@@ -329,9 +335,37 @@ func (ctx *linux) Symbolize(rep *Report) error {
 		}
 		rep.Maintainers = maintainers
 	}
+	ctx.classify(rep)
 	return nil
 }
 
+// classify fills in rep.Subsystems and rep.SubsystemCC based on the guilty file,
+// the title and the rest of the files in the report.
+func (ctx *linux) classify(rep *Report) {
+	if ctx.subsystems == nil {
+		return
+	}
+	crash := &subsystem.Crash{
+		Title:      rep.Title,
+		GuiltyFile: rep.guiltyFile,
+	}
+	for _, frame := range extractGuiltyFrames(rep.Report[rep.reportPrefixLen:]) {
+		if !matchesAnyString(frame.file, ctx.guiltyFileBlacklist) {
+			crash.Files = append(crash.Files, frame.file)
+		}
+	}
+	dedup := make(map[string]bool)
+	for _, s := range ctx.subsystems.Classify(crash) {
+		rep.Subsystems = append(rep.Subsystems, s.Name)
+		for _, addr := range s.Recipients() {
+			if !dedup[addr] {
+				dedup[addr] = true
+				rep.SubsystemCC = append(rep.SubsystemCC, addr)
+			}
+		}
+	}
+}
+
 func (ctx *linux) symbolize(rep *Report) error {
 	symb := symbolizer.NewSymbolizer()
 	defer symb.Close()
@@ -447,21 +481,17 @@ func (ctx *linux) extractGuiltyFile(rep *Report) string {
 			}
 		}
 	}
+	if rep.Details != nil && rep.Details.Location != "" {
+		// UBSAN reports point directly to the source location of the bug.
+		if file := ctx.extractGuiltyFileImpl([]byte(rep.Details.Location)); file != "" {
+			return file
+		}
+	}
 	return ctx.extractGuiltyFileImpl(report)
 }
 
 func (ctx *linux) extractGuiltyFileImpl(report []byte) string {
-	files := ctx.extractFiles(report)
-nextFile:
-	for _, file := range files {
-		for _, re := range ctx.guiltyFileBlacklist {
-			if re.MatchString(file) {
-				continue nextFile
-			}
-		}
-		return file
-	}
-	return ""
+	return selectGuiltyFile(extractGuiltyFrames(report), ctx.guiltyFileBlacklist, ctx.focus, ctx.callGraph)
 }
 
 func (ctx *linux) getMaintainers(file string) ([]string, error) {
@@ -503,16 +533,6 @@ func (ctx *linux) getMaintainersImpl(file string, blame bool) ([]string, error)
 	return mtrs, nil
 }
 
-func (ctx *linux) extractFiles(report []byte) []string {
-	matches := filenameRe.FindAll(report, -1)
-	var files []string
-	for _, match := range matches {
-		f := string(bytes.Split(match, []byte{':'})[0])
-		files = append(files, filepath.Clean(f))
-	}
-	return files
-}
-
 func (ctx *linux) isCorrupted(title string, report []byte, format oopsFormat) (bool, string) {
 	// Check for common title corruptions.
 	for _, re := range linuxCorruptedTitles {
@@ -671,6 +691,7 @@ var (
 	stackFrameRe     = regexp.MustCompile(`^ *(?:\[\<?(?:[0-9a-f]+)\>?\] ?){0,2}[ \t]+(?:[0-9]+:)?([a-zA-Z0-9_.]+)\+0x([0-9a-f]+)/0x([0-9a-f]+)`)
 	linuxRcuStall    = compile("INFO: rcu_(?:preempt|sched|bh) (?:self-)?detected(?: expedited)? stall")
 	linuxRipFrame    = compile(`IP: (?:(?:[0-9]+:)?(?:{{PC}} +){0,2}{{FUNC}}|[0-9]+:0x[0-9a-f]+|(?:[0-9]+:)?{{PC}} +\[< *\(null\)>\] +\(null\)|[0-9]+: +\(null\))`)
+	xenRipFrame      = compile(`\(XEN\) RIP: +[0-9a-f]+:{{PC}} (?:[a-zA-Z0-9_\-./]+#)?{{FUNC}}`)
 )
 
 var linuxCorruptedTitles = []*regexp.Regexp{
@@ -688,6 +709,12 @@ var linuxStackKeywords = []*regexp.Regexp{
 	regexp.MustCompile(`[^k] backtrace:`),
 }
 
+// linuxLeakAllocFrames match memory allocator functions in kmemleak backtraces.
+var linuxLeakAllocFrames = []string{"kmemleak", "kmalloc", "kcalloc", "kzalloc",
+	"vmalloc", "mmap", "kmem", "slab", "alloc", "create_object",
+	"idr_get", "list_lru_init", "kasprintf", "kvasprintf",
+	"pcpu_create", "strdup", "strndup", "memdup"}
+
 var linuxStackParams = &stackParams{
 	stackStartRes: linuxStackKeywords,
 	frameRes: []*regexp.Regexp{
@@ -860,6 +887,35 @@ var linuxOopses = []*oops{
 					},
 				},
 			},
+			{
+				title:        compile("BUG: KCSAN: data-race"),
+				report:       compile("BUG: KCSAN: data-race in ([a-zA-Z0-9_.]+) / ([a-zA-Z0-9_.]+)"),
+				fmt:          "KCSAN: data-race in %[1]v / %[2]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("BUG: KCSAN: data-race"),
+				report:       compile("BUG: KCSAN: data-race in ([a-zA-Z0-9_.]+)"),
+				fmt:          "KCSAN: data-race in %[1]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("BUG: KCSAN: (.*)"),
+				fmt:          "KCSAN: %[1]v",
+				noStackTrace: true,
+			},
+			{
+				title:  compile("BUG: KFENCE:"),
+				report: compile("BUG: KFENCE: ([a-z\\- ]+?) in {{FUNC}}"),
+				fmt:    "KFENCE: %[1]v in %[2]v",
+				// KFENCE reports don't contain "Call Trace", stacks are printed without a header.
+				noStackTrace: true,
+			},
+			{
+				title:     compile("BUG: KFENCE: (.*)"),
+				fmt:       "KFENCE: %[1]v",
+				corrupted: true,
+			},
 			{
 				title: compile("BUG: (?:unable to handle kernel paging request|unable to handle page fault for address)"),
 				fmt:   "BUG: unable to handle kernel paging request in %[1]v",
@@ -1001,10 +1057,7 @@ var linuxOopses = []*oops{
 						compile("backtrace:"),
 						parseStackTrace,
 					},
-					skip: []string{"kmemleak", "kmalloc", "kcalloc", "kzalloc",
-						"vmalloc", "mmap", "kmem", "slab", "alloc", "create_object",
-						"idr_get", "list_lru_init", "kasprintf", "kvasprintf",
-						"pcpu_create", "strdup", "strndup", "memdup"},
+					skip: linuxLeakAllocFrames,
 				},
 			},
 			{
@@ -1301,6 +1354,24 @@ var linuxOopses = []*oops{
 		},
 		[]*regexp.Regexp{},
 	},
+	{
+		// Newer kernels print the faulting address if it is non-canonical.
+		[]byte("general protection fault, probably for non-canonical address"),
+		[]oopsFormat{
+			{
+				title: compile("general protection fault, probably for non-canonical address"),
+				fmt:   "general protection fault in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						linuxRipFrame,
+						compile("Call Trace:"),
+						parseStackTrace,
+					},
+				},
+			},
+		},
+		[]*regexp.Regexp{},
+	},
 	{
 		[]byte("Kernel panic"),
 		[]oopsFormat{
@@ -1476,6 +1547,28 @@ var linuxOopses = []*oops{
 	{
 		[]byte("UBSAN:"),
 		[]oopsFormat{
+			{
+				title: compile("UBSAN: ([a-z\\-]+) in"),
+				fmt:   "UBSAN: %[1]v in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						compile("Call Trace:"),
+						parseStackTrace,
+					},
+					skip: []string{"ubsan"},
+				},
+			},
+			{
+				// Reports produced with CONFIG_UBSAN_TRAP on arm64.
+				title: compile("Internal error: UBSAN: ([a-z ]+): [0-9a-f]+"),
+				fmt:   "UBSAN: %[1]v in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						compile("pc : (?:{{PC}} )?{{FUNC}}"),
+					},
+				},
+				noStackTrace: true,
+			},
 			{
 				title: compile("UBSAN: (.*)"),
 				fmt:   "UBSAN: %[1]v",
@@ -1528,4 +1621,62 @@ var linuxOopses = []*oops{
 		},
 		[]*regexp.Regexp{},
 	},
+	// Xen hypervisor crashes, visible when the Xen console is multiplexed into the guest/dom0 console.
+	// The crash reason is printed after the register dump, in the "Panic on CPU" section.
+	{
+		[]byte("(XEN) ----[ Xen-"),
+		[]oopsFormat{
+			{
+				title: compile("\\(XEN\\) ----\\[ Xen-(?:.*\\n)+?\\(XEN\\) Assertion '(.*)' failed at"),
+				fmt:   "xen: assertion '%[1]v' failed in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						xenRipFrame,
+					},
+				},
+				noStackTrace: true,
+			},
+			{
+				title: compile("\\(XEN\\) ----\\[ Xen-(?:.*\\n)+?\\(XEN\\) Xen BUG at "),
+				fmt:   "xen: BUG in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						xenRipFrame,
+					},
+				},
+				noStackTrace: true,
+			},
+			{
+				title: compile("\\(XEN\\) ----\\[ Xen-(?:.*\\n)+?\\(XEN\\) FATAL PAGE FAULT"),
+				fmt:   "xen: page fault in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						xenRipFrame,
+					},
+				},
+				noStackTrace: true,
+			},
+			{
+				title: compile("\\(XEN\\) ----\\[ Xen-(?:.*\\n)+?\\(XEN\\) FATAL TRAP: vector = [0-9]+ \\((.*)\\)"),
+				fmt:   "xen: %[1]v in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						xenRipFrame,
+					},
+				},
+				noStackTrace: true,
+			},
+			{
+				title: compile("\\(XEN\\) ----\\[ Xen-"),
+				fmt:   "xen: panic in %[1]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						xenRipFrame,
+					},
+				},
+				noStackTrace: true,
+			},
+		},
+		[]*regexp.Regexp{},
+	},
 }
@@ -0,0 +1,166 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	linuxKcsanTitleRe  = regexp.MustCompile(`^BUG: KCSAN: ([a-z\-]+)`)
+	linuxKcsanAccessRe = regexp.MustCompile(`^(?:race at unknown origin, with )?(read-write|read|write)` +
+		`(?: \([a-z ]+\))? to (0x[0-9a-f]+) of ([0-9]+) bytes by (?:task ([0-9]+)|interrupt) on cpu ([0-9]+):`)
+	linuxKfenceTitleRe  = regexp.MustCompile(`^BUG: KFENCE: ([a-z\- ]+?)(?: in |$)`)
+	linuxKfenceAccessRe = regexp.MustCompile(`^[A-Z][a-zA-Z\- ]* (?:at|of) (0x[0-9a-f]+)`)
+	linuxKfenceAllocRe  = regexp.MustCompile(`^kfence-#[0-9]+ \[(0x[0-9a-f]+)-0x[0-9a-f]+, size=([0-9]+)` +
+		`.*\] allocated by task ([0-9]+)(?: on cpu ([0-9]+))?`)
+	linuxKfenceFreeRe  = regexp.MustCompile(`^freed by task ([0-9]+)(?: on cpu ([0-9]+))?`)
+	linuxUbsanTitleRe  = regexp.MustCompile(`^UBSAN: ([a-z\-]+|Undefined behaviour) in ([^ ]+?:[0-9]+)(?::[0-9]+)?$`)
+	linuxUbsanTrapRe   = regexp.MustCompile(`Internal error: UBSAN: ([a-z ]+):`)
+	linuxDetailsFrame  = regexp.MustCompile(`^(?:\[\<?(?:0x)?[0-9a-f]+\>?\] )?([a-zA-Z0-9_.]+)(?:\+0x[0-9a-f]+/0x[0-9a-f]+)?(?: |$)`)
+	linuxCallTraceLine = regexp.MustCompile(`^Call [Tt]race:`)
+)
+
+// parseLinuxDetails extracts structured information from KCSAN, KFENCE and UBSAN reports.
+// Returns nil for other report types.
+func parseLinuxDetails(title string, report []byte) *Details {
+	lines := bytes.Split(report, []byte{'\n'})
+	for i, ln := range lines {
+		lines[i] = bytes.TrimRight(ln, "\r")
+	}
+	switch {
+	case strings.HasPrefix(title, "KCSAN: "):
+		return parseKcsanDetails(lines)
+	case strings.HasPrefix(title, "KFENCE: "):
+		return parseKfenceDetails(lines)
+	case strings.HasPrefix(title, "UBSAN: "):
+		return parseUbsanDetails(lines)
+	}
+	return nil
+}
+
+func parseKcsanDetails(lines [][]byte) *Details {
+	details := &Details{Sanitizer: "KCSAN"}
+	for i := 0; i < len(lines); i++ {
+		if match := linuxKcsanTitleRe.FindSubmatch(lines[i]); match != nil && details.Kind == "" {
+			details.Kind = string(match[1])
+			continue
+		}
+		match := linuxKcsanAccessRe.FindSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		stack := &Stack{
+			Kind: string(match[1]),
+			Addr: parseDetailsUint(match[2]),
+			Size: parseDetailsInt(match[3]),
+			Task: parseDetailsInt(match[4]),
+			CPU:  parseDetailsInt(match[5]),
+		}
+		stack.Frames, i = parseDetailsFrames(lines, i+1)
+		details.Stacks = append(details.Stacks, stack)
+	}
+	return details
+}
+
+func parseKfenceDetails(lines [][]byte) *Details {
+	details := &Details{Sanitizer: "KFENCE"}
+	for i := 0; i < len(lines); i++ {
+		ln := lines[i]
+		if match := linuxKfenceTitleRe.FindSubmatch(ln); match != nil && details.Kind == "" {
+			details.Kind = string(match[1])
+			continue
+		}
+		var stack *Stack
+		if match := linuxKfenceAllocRe.FindSubmatch(ln); match != nil {
+			stack = &Stack{
+				Kind: "alloc",
+				Addr: parseDetailsUint(match[1]),
+				Size: parseDetailsInt(match[2]),
+				Task: parseDetailsInt(match[3]),
+				CPU:  parseDetailsInt(match[4]),
+			}
+		} else if match := linuxKfenceFreeRe.FindSubmatch(ln); match != nil {
+			stack = &Stack{
+				Kind: "free",
+				Task: parseDetailsInt(match[1]),
+				CPU:  parseDetailsInt(match[2]),
+			}
+		} else if match := linuxKfenceAccessRe.FindSubmatch(ln); match != nil && len(details.Stacks) == 0 {
+			stack = &Stack{
+				Kind: "access",
+				Addr: parseDetailsUint(match[1]),
+				Task: -1,
+				CPU:  -1,
+			}
+		} else {
+			continue
+		}
+		stack.Frames, i = parseDetailsFrames(lines, i+1)
+		details.Stacks = append(details.Stacks, stack)
+	}
+	return details
+}
+
+func parseUbsanDetails(lines [][]byte) *Details {
+	details := &Details{Sanitizer: "UBSAN"}
+	for i := 0; i < len(lines); i++ {
+		ln := lines[i]
+		if match := linuxUbsanTitleRe.FindSubmatch(ln); match != nil && details.Location == "" {
+			if kind := string(match[1]); kind != "Undefined behaviour" {
+				details.Kind = kind
+			}
+			details.Location = string(match[2])
+			continue
+		}
+		if match := linuxUbsanTrapRe.FindSubmatch(ln); match != nil && details.Kind == "" {
+			details.Kind = strings.Replace(string(match[1]), " ", "-", -1)
+			continue
+		}
+		if linuxCallTraceLine.Match(ln) && len(details.Stacks) == 0 {
+			stack := &Stack{Kind: "call", Task: -1, CPU: -1}
+			stack.Frames, i = parseDetailsFrames(lines, i+1)
+			details.Stacks = append(details.Stacks, stack)
+		}
+	}
+	return details
+}
+
+// parseDetailsFrames parses stack frames starting at lines[i].
+// Stack frames are indented, the stack ends with the first non-indented line.
+// Returns the frames and index of the last line of the stack.
+func parseDetailsFrames(lines [][]byte, i int) ([]string, int) {
+	var frames []string
+	for ; i < len(lines); i++ {
+		ln := lines[i]
+		if len(ln) == 0 || ln[0] != ' ' && ln[0] != '\t' {
+			break
+		}
+		ln = bytes.TrimSpace(ln)
+		if bytes.HasPrefix(ln, []byte("? ")) {
+			// Unreliable frame.
+			continue
+		}
+		if match := linuxDetailsFrame.FindSubmatch(ln); match != nil {
+			frames = append(frames, string(match[1]))
+		}
+	}
+	return frames, i - 1
+}
+
+func parseDetailsUint(data []byte) uint64 {
+	v, _ := strconv.ParseUint(string(data), 0, 64)
+	return v
+}
+
+func parseDetailsInt(data []byte) int {
+	v, err := strconv.Atoi(string(data))
+	if err != nil {
+		return -1
+	}
+	return v
+}
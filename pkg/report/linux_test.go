@@ -4,7 +4,11 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/google/syzkaller/pkg/mgrconfig"
@@ -227,3 +231,256 @@ func TestLinuxSymbolizeLine(t *testing.T) {
 		})
 	}
 }
+
+func TestLinuxDetails(t *testing.T) {
+	tests := []struct {
+		file    string
+		details *Details
+	}{
+		{
+			file: "391",
+			details: &Details{
+				Sanitizer: "KCSAN",
+				Kind:      "data-race",
+				Stacks: []*Stack{
+					{
+						Kind: "write",
+						Addr: 0xffff88812271a558,
+						Size: 4,
+						Task: 9151,
+						CPU:  1,
+						Frames: []string{"__ext4_new_inode", "ext4_mkdir", "vfs_mkdir", "do_mkdirat",
+							"__x64_sys_mkdir", "do_syscall_64", "entry_SYSCALL_64_after_hwframe"},
+					},
+					{
+						Kind: "read",
+						Addr: 0xffff88812271a558,
+						Size: 4,
+						Task: 8838,
+						CPU:  0,
+						Frames: []string{"find_group_other", "__ext4_new_inode", "ext4_create", "path_openat",
+							"do_filp_open", "do_sys_openat2", "__x64_sys_open", "do_syscall_64",
+							"entry_SYSCALL_64_after_hwframe"},
+					},
+				},
+			},
+		},
+		{
+			file: "392",
+			details: &Details{
+				Sanitizer: "KFENCE",
+				Kind:      "use-after-free read",
+				Stacks: []*Stack{
+					{
+						Kind: "access",
+						Addr: 0xffffffffb673dfe0,
+						Task: -1,
+						CPU:  -1,
+						Frames: []string{"test_use_after_free_read", "kunit_try_run_case",
+							"kunit_generic_run_threadfn_adapter", "kthread", "ret_from_fork"},
+					},
+					{
+						Kind: "alloc",
+						Addr: 0xffffffffb673dfe0,
+						Size: 32,
+						Task: 507,
+						CPU:  -1,
+						Frames: []string{"test_alloc", "test_use_after_free_read", "kunit_try_run_case",
+							"kunit_generic_run_threadfn_adapter", "kthread", "ret_from_fork"},
+					},
+					{
+						Kind: "free",
+						Task: 507,
+						CPU:  -1,
+						Frames: []string{"test_use_after_free_read", "kunit_try_run_case",
+							"kunit_generic_run_threadfn_adapter", "kthread", "ret_from_fork"},
+					},
+				},
+			},
+		},
+		{
+			file: "394",
+			details: &Details{
+				Sanitizer: "UBSAN",
+				Kind:      "shift-out-of-bounds",
+				Stacks: []*Stack{
+					{
+						Kind: "call",
+						Task: -1,
+						CPU:  -1,
+						Frames: []string{"tcp_ack", "tcp_rcv_established", "tcp_v4_do_rcv", "__release_sock",
+							"release_sock", "tcp_sendmsg", "inet_sendmsg", "__sys_sendto", "__arm64_sys_sendto",
+							"invoke_syscall", "el0_svc_common", "do_el0_svc", "el0_svc",
+							"el0t_64_sync_handler", "el0t_64_sync"},
+					},
+				},
+			},
+		},
+	}
+	cfg := &mgrconfig.Config{
+		TargetOS:   "linux",
+		TargetArch: "amd64",
+	}
+	reporter, err := NewReporter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.file, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "linux", "report", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Skip the test headers.
+			data = data[bytes.Index(data, []byte("\n\n"))+2:]
+			rep := reporter.Parse(data)
+			if rep == nil {
+				t.Fatalf("did not find crash")
+			}
+			if !reflect.DeepEqual(rep.Details, test.details) {
+				got, want := new(bytes.Buffer), new(bytes.Buffer)
+				fmt.Fprintf(got, "%+v", *rep.Details)
+				fmt.Fprintf(want, "%+v", *test.details)
+				for _, stack := range rep.Details.Stacks {
+					fmt.Fprintf(got, "\n%+v", *stack)
+				}
+				for _, stack := range test.details.Stacks {
+					fmt.Fprintf(want, "\n%+v", *stack)
+				}
+				t.Fatalf("got details:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestLinuxUbsanDetails(t *testing.T) {
+	details := parseLinuxDetails("UBSAN: shift-out-of-bounds in tcp_ack", []byte(`
+UBSAN: shift-out-of-bounds in net/ipv4/tcp_input.c:3784:27
+shift exponent 32 is too large for 32-bit type 'unsigned int'
+Call Trace:
+ <IRQ>
+ dump_stack+0x107/0x163
+ ? tcp_ack+0x3f00/0x5bd0
+ tcp_ack+0x3f2c/0x5bd0
+ tcp_rcv_established net/ipv4/tcp_input.c:5710 [inline]
+RIP: 0033:0x45deb9
+`))
+	want := &Details{
+		Sanitizer: "UBSAN",
+		Kind:      "shift-out-of-bounds",
+		Location:  "net/ipv4/tcp_input.c:3784",
+		Stacks: []*Stack{
+			{
+				Kind:   "call",
+				Task:   -1,
+				CPU:    -1,
+				Frames: []string{"dump_stack", "tcp_ack", "tcp_rcv_established"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Fatalf("got details %+v %+v, want %+v %+v", details, details.Stacks[0], want, want.Stacks[0])
+	}
+}
+
+func TestLinuxAnomalies(t *testing.T) {
+	reporter, err := NewReporter(&mgrconfig.Config{
+		TargetOS:   "linux",
+		TargetArch: "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := new(bytes.Buffer)
+	output.WriteString(`[   10.000000] ACPI BIOS Error (bug): Could not resolve symbol [\_SB.PCI0], AE_NOT_FOUND (20200110/psargs-330)
+[   11.000000] CPU: 0 PID: 5 Comm: kworker/0:0 Not tainted 5.6.0+ #2
+[   11.000000] Call Trace:
+[   11.000000]  dump_stack+0x107/0x163
+[   11.000000]  ? foo_irq+0x10/0x20
+[   11.000000]  foo_probe+0x12/0x80 drivers/foo/foo.c:123
+[   11.000000]  really_probe+0x281/0x6d0
+[   11.000000] foo: probe done
+2020/01/01 00:00:00 executing program 0: error
+`)
+	for i := 0; i < 2*anomalyRepeatThreshold; i++ {
+		fmt.Fprintf(output, "[   12.%06d] usb 1-%v: device descriptor read/64, error -71\n", i, i)
+	}
+	// Stack dumps that are part of known oopses are not anomalies.
+	output.WriteString(`[   13.000000] WARNING: CPU: 0 PID: 1 at net/core/dev.c:123 bar+0x10/0x20
+[   13.000000] Call Trace:
+[   13.000000]  bar+0x10/0x20
+[   13.000000]  baz+0x10/0x20
+[   13.000000] Call Trace:
+[   13.000000]  bar+0x10/0x20
+`)
+	det := NewAnomalyDetector(reporter)
+	// Feed output in small chunks to check handling of partial lines.
+	data := output.Bytes()
+	for len(data) != 0 {
+		n := 7
+		if n > len(data) {
+			n = len(data)
+		}
+		det.Feed(data[:n])
+		data = data[n:]
+	}
+	var titles []string
+	for _, anomaly := range det.Anomalies() {
+		titles = append(titles, anomaly.Title)
+	}
+	want := []string{
+		`SUSPICIOUS: firmware error: ACPI BIOS Error (bug): Could not resolve symbol [\_SB.PCI0], ` +
+			`AE_NOT_FOUND (NUM/psargs-NUM)`,
+		"SUSPICIOUS: stack dump in foo_probe",
+		"SUSPICIOUS: repeated message: usb NUM-NUM: device descriptor read/NUM, error -NUM",
+	}
+	if !reflect.DeepEqual(titles, want) {
+		t.Fatalf("got anomalies:\n%q\nwant:\n%q", titles, want)
+	}
+	if NewAnomalyDetector(nil) != nil {
+		t.Fatalf("got anomaly detector for an unsupported reporter")
+	}
+}
+
+func TestKmsgRecord(t *testing.T) {
+	tests := []struct {
+		record string
+		title  string
+	}{
+		{
+			record: "4,1234,5678901,-;usb 1-1: device descriptor read/64, error -71\n SUBSYSTEM=usb\n",
+			title:  "SUSPICIOUS: kernel warning: usb NUM-NUM: device descriptor read/NUM, error -NUM",
+		},
+		{
+			record: "3,10,20,-,caller=T1;firmware: failed to load foo.bin (-2)\n",
+			title:  "SUSPICIOUS: kernel err: firmware: failed to load foo.bin (-NUM)",
+		},
+		{
+			// User-space message (facility LOG_USER).
+			record: "12,11,21,-;syzkaller: executing program 0\n",
+		},
+		{
+			record: "garbage\n",
+		},
+	}
+	for _, test := range tests {
+		rec := ParseKmsgRecord([]byte(test.record))
+		title := ""
+		if rec != nil {
+			title = KmsgTitle(rec)
+		}
+		if title != test.title {
+			t.Errorf("record %q: got title %q, want %q", test.record, title, test.title)
+		}
+	}
+	for _, name := range kmsgSeverityNames {
+		sev, err := ParseKmsgSeverity(name)
+		if err != nil || sev.String() != name {
+			t.Errorf("failed to parse severity %v: %v %v", name, sev, err)
+		}
+	}
+	if _, err := ParseKmsgSeverity("warn"); err == nil {
+		t.Errorf("no error for bad severity")
+	}
+}
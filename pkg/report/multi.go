@@ -0,0 +1,153 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Max number of reports extracted from a single console output.
+// Kernel can print an unbounded cascade of reports after memory corruption.
+const maxReports = 20
+
+// ParseAll extracts all reports from the console output in the order of appearance.
+// StartPos/EndPos of the returned reports are relative to output.
+func ParseAll(reporter Reporter, output []byte) []*Report {
+	var reports []*Report
+	var prevTasks map[int]bool
+	for pos := 0; pos < len(output) && len(reports) < maxReports; {
+		rep := reporter.Parse(output[pos:])
+		if rep == nil {
+			break
+		}
+		next := rep.nextPos
+		if next <= rep.StartPos {
+			next = rep.EndPos + 1
+		}
+		rep.Output = output
+		rep.StartPos += pos
+		rep.EndPos += pos
+		rep.nextPos = 0
+		rep.Order = len(reports)
+		pos += next
+		// Note: rep.Report does not help here, for some OSes it includes all subsequent reports.
+		tasks := reportTasks(output[rep.StartPos:minInt(pos, len(output))])
+		rep.Consequence = isConsequence(prevTasks, tasks)
+		prevTasks = tasks
+		reports = append(reports, rep)
+	}
+	return reports
+}
+
+// isConsequence returns true if a report was likely caused by the preceding report:
+// kernel frequently continues after WARNINGs and some BUGs, and the task
+// that hit the first bug then crashes again on the corrupted state.
+func isConsequence(prevTasks, tasks map[int]bool) bool {
+	for pid := range tasks {
+		if prevTasks[pid] {
+			return true
+		}
+	}
+	return false
+}
+
+func reportTasks(text []byte) map[int]bool {
+	_, tasks := extractTasks(text, nil)
+	res := make(map[int]bool)
+	for _, task := range tasks {
+		res[task.PID] = true
+	}
+	return res
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Severity returns relative severity of the report, higher values are more severe.
+// Memory safety bugs are the most severe, then crashes, then data races and undefined
+// behavior, then hangs, and then warnings and other informational reports.
+func Severity(rep *Report) int {
+	title := rep.Title
+	switch {
+	case strings.HasPrefix(title, "KASAN:"), strings.HasPrefix(title, "KMSAN:"),
+		strings.HasPrefix(title, "KFENCE:"), strings.Contains(title, "use-after-free"),
+		strings.Contains(title, "out-of-bounds"), strings.Contains(title, "double-free"):
+		return 5
+	case strings.HasPrefix(title, "general protection fault"), strings.HasPrefix(title, "BUG:"),
+		strings.HasPrefix(title, "kernel BUG"), strings.HasPrefix(title, "Kernel panic"),
+		strings.HasPrefix(title, "PANIC:"), strings.HasPrefix(title, "divide error"),
+		strings.HasPrefix(title, "invalid opcode"), strings.HasPrefix(title, "unable to handle"):
+		return 4
+	case strings.HasPrefix(title, "KCSAN:"), strings.HasPrefix(title, "UBSAN:"),
+		strings.Contains(title, "deadlock"):
+		return 3
+	case rep.Type == Hang:
+		return 2
+	case rep.Type == UnexpectedReboot:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// SelectPrimary selects the most important report among reports returned by ParseAll,
+// the rest of reports are stored in Secondary of the selected report in the original order.
+// Suppressed and corrupted reports are selected only if there are no other reports,
+// among reports with the same severity the first one is selected.
+func SelectPrimary(reports []*Report) *Report {
+	if len(reports) == 0 {
+		return nil
+	}
+	primary := reports[0]
+	for _, rep := range reports[1:] {
+		if morePrimary(rep, primary) {
+			primary = rep
+		}
+	}
+	primary.Secondary = nil
+	for _, rep := range reports {
+		if rep != primary {
+			primary.Secondary = append(primary.Secondary, rep)
+		}
+	}
+	return primary
+}
+
+func morePrimary(rep, than *Report) bool {
+	if rep.Suppressed != than.Suppressed {
+		return !rep.Suppressed
+	}
+	if rep.Corrupted != than.Corrupted {
+		return !rep.Corrupted
+	}
+	return Severity(rep) > Severity(than)
+}
+
+// SecondaryText returns titles and texts of the secondary reports in a single text blob.
+func (rep *Report) SecondaryText() []byte {
+	buf := new(bytes.Buffer)
+	for _, sec := range rep.Secondary {
+		consequence := ""
+		if sec.Consequence {
+			consequence = " (consequence of the preceding report)"
+		}
+		order := "after"
+		if sec.Order < rep.Order {
+			order = "before"
+		}
+		buf.WriteString(strings.Repeat("=", 70) + "\n")
+		buf.WriteString(sec.Title + "\n")
+		buf.WriteString("reported " + order + " the primary report" + consequence + "\n\n")
+		buf.Write(sec.Report)
+		if len(sec.Report) != 0 && sec.Report[len(sec.Report)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
@@ -3,11 +3,7 @@
 
 package report
 
-import (
-	"regexp"
-
-	"github.com/google/syzkaller/sys/targets"
-)
+import "regexp"
 
 type netbsd struct {
 	kernelSrc string
@@ -15,12 +11,11 @@ type netbsd struct {
 	ignores   []*regexp.Regexp
 }
 
-func ctorNetbsd(target *targets.Target, kernelSrc, kernelObj string,
-	ignores []*regexp.Regexp) (Reporter, []string, error) {
-	ignores = append(ignores, regexp.MustCompile("event_init: unable to initialize")) // postfix output
+func ctorNetbsd(cfg *config) (Reporter, []string, error) {
+	ignores := append(cfg.ignores, regexp.MustCompile("event_init: unable to initialize")) // postfix output
 	ctx := &netbsd{
-		kernelSrc: kernelSrc,
-		kernelObj: kernelObj,
+		kernelSrc: cfg.kernelSrc,
+		kernelObj: cfg.kernelObj,
 		ignores:   ignores,
 	}
 	return ctx, nil, nil
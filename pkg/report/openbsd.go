@@ -13,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/google/syzkaller/pkg/symbolizer"
-	"github.com/google/syzkaller/sys/targets"
 )
 
 type openbsd struct {
@@ -33,12 +32,11 @@ var (
 	}
 )
 
-func ctorOpenbsd(target *targets.Target, kernelSrc, kernelObj string,
-	ignores []*regexp.Regexp) (Reporter, []string, error) {
+func ctorOpenbsd(cfg *config) (Reporter, []string, error) {
 	var symbols map[string][]symbolizer.Symbol
 	kernelObject := ""
-	if kernelObj != "" {
-		kernelObject = filepath.Join(kernelObj, target.KernelObject)
+	if cfg.kernelObj != "" {
+		kernelObject = filepath.Join(cfg.kernelObj, cfg.target.KernelObject)
 		var err error
 		symbols, err = symbolizer.ReadSymbols(kernelObject)
 		if err != nil {
@@ -46,11 +44,11 @@ func ctorOpenbsd(target *targets.Target, kernelSrc, kernelObj string,
 		}
 	}
 	ctx := &openbsd{
-		kernelSrc:    kernelSrc,
-		kernelObj:    kernelObj,
+		kernelSrc:    cfg.kernelSrc,
+		kernelObj:    cfg.kernelObj,
 		kernelObject: kernelObject,
 		symbols:      symbols,
-		ignores:      ignores,
+		ignores:      cfg.ignores,
 	}
 	return ctx, nil, nil
 }
@@ -65,7 +63,7 @@ func (ctx *openbsd) Parse(output []byte) *Report {
 	for len(stripped) != 0 && stripped[0] == '\r' {
 		stripped = stripped[1:]
 	}
-	rep := simpleLineParser(stripped, openbsdOopses, nil, ctx.ignores)
+	rep := simpleLineParser(stripped, openbsdOopses, openbsdStackParams, ctx.ignores)
 	if rep == nil {
 		return nil
 	}
@@ -139,6 +137,26 @@ func (ctx *openbsd) symbolizeLine(symbFunc func(bin string, pc uint64) ([]symbol
 	return symbolized
 }
 
+var openbsdStackParams = &stackParams{
+	frameRes: []*regexp.Regexp{
+		// ddb trace.
+		compile(`^([a-zA-Z0-9_]+)\([^)]*\) at [a-zA-Z0-9_]+\+0x`),
+		// witness.
+		compile(`^#[0-9]+ +([a-zA-Z0-9_]+)\+0x`),
+	},
+	skipPatterns: []string{
+		"^db_enter$",
+		"^db_ktrap$",
+		"^panic$",
+		"^__assert$",
+		"^kerntrap$",
+		"^trap$",
+		"^alltraps",
+		"^Xtrap",
+		"^witness_",
+	},
+}
+
 var openbsdOopses = []*oops{
 	{
 		[]byte("cleaned vnode"),
@@ -169,6 +187,16 @@ var openbsdOopses = []*oops{
 				title: compile("panic: timeout_add: to_ticks \\(.+\\) < 0"),
 				fmt:   "panic: timeout_add: to_ticks < 0",
 			},
+			{
+				// Fatal traps in kernel mode without ddb, the trap frame is followed by the trace.
+				title: compile("panic: trap type ([0-9]+), code=[0-9a-f]+, pc=[0-9a-f]+"),
+				fmt:   "panic: trap type %[1]v in %[2]v",
+				stack: &stackFmt{
+					parts: []*regexp.Regexp{
+						parseStackTrace,
+					},
+				},
+			},
 		},
 		[]*regexp.Regexp{},
 	},
@@ -213,6 +241,10 @@ var openbsdOopses = []*oops{
 				title: compile("kernel: page fault trap, code=0.*\\nStopped at[ ]+([^\\+]+)"),
 				fmt:   "uvm_fault: %[1]v",
 			},
+			{
+				title: compile("kernel: ([a-z ]+ trap), code=[0-9]+.*\\nStopped at[ ]+([^\\+]+)"),
+				fmt:   "kernel: %[1]v in %[2]v",
+			},
 		},
 		[]*regexp.Regexp{
 			compile("reorder_kernel"),
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/symbolizer"
 	"github.com/google/syzkaller/sys/targets"
 )
 
@@ -50,10 +51,59 @@ type Report struct {
 	CorruptedReason string
 	// Maintainers is list of maintainer emails (filled in by Symbolize).
 	Maintainers []string
+	// Subsystems are names of MAINTAINERS entries the crash is attributed to,
+	// SubsystemCC are their mailing lists, maintainers and reviewers (filled in by Symbolize
+	// if the kernel source contains MAINTAINERS file).
+	Subsystems  []string
+	SubsystemCC []string
+	// Details contains structured information extracted from sanitizer reports
+	// (KCSAN, KFENCE, UBSAN), or nil if the report format is not supported.
+	Details *Details
+	// Fault contains the decoded faulting address and registers for bad memory accesses,
+	// or nil if the report is not a bad memory access or the address is unknown.
+	Fault *Fault
+	// Order is the index of the report among all reports in the console output (filled in by ParseAll).
+	Order int
+	// Consequence is set if the report is likely caused by the preceding report
+	// in the console output, e.g. printed by the same task (filled in by ParseAll).
+	Consequence bool
+	// Secondary contains the rest of the reports found in the console output
+	// for the report selected as primary by SelectPrimary.
+	Secondary []*Report
 	// guiltyFile is the source file that we think is to blame for the crash  (filled in by Symbolize).
 	guiltyFile string
 	// reportPrefixLen is length of additional prefix lines that we added before actual crash report.
 	reportPrefixLen int
+	// nextPos is position in Output where the next report starts, or 0 if unknown.
+	nextPos int
+}
+
+// Details describes a sanitizer report in a structured form.
+type Details struct {
+	// Sanitizer that produced the report (e.g. KCSAN, KFENCE, UBSAN).
+	Sanitizer string
+	// Bug kind as reported by the sanitizer (e.g. data-race, use-after-free, shift-out-of-bounds).
+	Kind string
+	// Source location reported by the sanitizer (file:line), if any.
+	Location string
+	// Stacks lists stacks contained in the report in the order of appearance:
+	// both racing accesses for KCSAN, the access, allocation and free stacks for KFENCE,
+	// the reporting stack for UBSAN.
+	Stacks []*Stack
+}
+
+// Stack is a single stack trace in a sanitizer report.
+type Stack struct {
+	// Kind of the stack: read, write, read-write, access, alloc, free or call.
+	Kind string
+	// Accessed address and access size (0 if unknown).
+	Addr uint64
+	Size int
+	// Task pid and CPU (-1 if unknown).
+	Task int
+	CPU  int
+	// Function names of the stack frames starting from the innermost one.
+	Frames []string
 }
 
 type Type int
@@ -98,7 +148,28 @@ func NewReporter(cfg *mgrconfig.Config) (Reporter, error) {
 	if target == nil && typ != "gvisor" {
 		return nil, fmt.Errorf("unknown target %v/%v", cfg.TargetOS, cfg.TargetArch)
 	}
-	rep, suppressions, err := ctor(target, cfg.KernelSrc, cfg.KernelObj, ignores)
+	// Symbolizers are created deep inside of reporters and coverage reports,
+	// so we configure them globally.
+	symbolizer.Configure(cfg.SymbolizerCache, cfg.SymbolizerServer)
+	focus, err := compileRegexps(cfg.FocusSubsystem)
+	if err != nil {
+		return nil, err
+	}
+	guiltyIgnores, err := compileRegexps(cfg.GuiltyIgnores)
+	if err != nil {
+		return nil, err
+	}
+	config := &config{
+		target:          target,
+		kernelSrc:       cfg.KernelSrc,
+		kernelObj:       cfg.KernelObj,
+		ignores:         ignores,
+		focus:           focus,
+		guiltyIgnores:   guiltyIgnores,
+		guiltyCallGraph: cfg.GuiltyCallGraph,
+		classifyFaults:  cfg.ClassifyFaults,
+	}
+	rep, suppressions, err := ctor(config)
 	if err != nil {
 		return nil, err
 	}
@@ -116,16 +187,33 @@ const (
 
 var ctors = map[string]fn{
 	"akaros":  ctorAkaros,
+	"darwin":  ctorDarwin,
 	"linux":   ctorLinux,
 	"gvisor":  ctorGvisor,
 	"freebsd": ctorFreebsd,
 	"netbsd":  ctorNetbsd,
 	"openbsd": ctorOpenbsd,
 	"fuchsia": ctorFuchsia,
-	"windows": ctorStub,
+	"windows": ctorWindows,
+	"zephyr":  ctorZephyr,
 }
 
-type fn func(*targets.Target, string, string, []*regexp.Regexp) (Reporter, []string, error)
+// config contains parameters of OS-specific reporters.
+type config struct {
+	target    *targets.Target
+	kernelSrc string
+	kernelObj string
+	ignores   []*regexp.Regexp
+	// Source files of the focus subsystem and additional files that can't be guilty.
+	focus         []*regexp.Regexp
+	guiltyIgnores []*regexp.Regexp
+	// Use the kernel call graph for guilty file selection.
+	guiltyCallGraph bool
+	// Append fault address classification to titles.
+	classifyFaults bool
+}
+
+type fn func(cfg *config) (Reporter, []string, error)
 
 func compileRegexps(list []string) ([]*regexp.Regexp, error) {
 	compiled := make([]*regexp.Regexp, len(list))
@@ -185,6 +273,21 @@ func IsSuppressed(reporter Reporter, output []byte) bool {
 		bytes.Contains(output, gceConsoleHangup)
 }
 
+// TitleClass returns the part of the crash title that describes the bug class
+// without the crash location, e.g. "KASAN: use-after-free Read" for
+// "KASAN: use-after-free Read in foo". Titles without location are returned as is.
+func TitleClass(title string) string {
+	if pos := strings.Index(title, " in "); pos != -1 {
+		return title[:pos]
+	}
+	return title
+}
+
+// SameClass returns true if crash titles describe the same class of bugs (see TitleClass).
+func SameClass(title1, title2 string) bool {
+	return TitleClass(title1) == TitleClass(title2)
+}
+
 // GCE console connection sometimes fails with this message.
 // The message frequently happens right after a kernel panic.
 // So if we see it in output where we recognized a crash, we mark the report as corrupted
@@ -243,6 +346,16 @@ var dynamicTitleReplacement = []replacement{
 		regexp.MustCompile(`CPU#[0-9]+`),
 		"CPU",
 	},
+	{
+		// UBSAN trap reports name bug kinds differently from normal UBSAN reports,
+		// use the same titles for both.
+		regexp.MustCompile(`^UBSAN: array index out of bounds`),
+		"UBSAN: array-index-out-of-bounds",
+	},
+	{
+		regexp.MustCompile(`^UBSAN: shift out of bounds`),
+		"UBSAN: shift-out-of-bounds",
+	},
 }
 
 func sanitizeTitle(title string) string {
@@ -6,11 +6,13 @@ package report
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -313,7 +315,7 @@ func forEachFile(t *testing.T, dir string, fn func(t *testing.T, reporter Report
 		}
 		cfg := &mgrconfig.Config{
 			TargetOS:   os,
-			TargetArch: "amd64",
+			TargetArch: testArch(os),
 		}
 		reporter, err := NewReporter(cfg)
 		if err != nil {
@@ -361,6 +363,68 @@ func TestReplace(t *testing.T) {
 	}
 }
 
+func TestTitleClass(t *testing.T) {
+	tests := []struct {
+		title string
+		class string
+	}{
+		{"KASAN: use-after-free Read in foo", "KASAN: use-after-free Read"},
+		{"KASAN: slab-out-of-bounds Write in bar", "KASAN: slab-out-of-bounds Write"},
+		{"WARNING in foo", "WARNING"},
+		{"general protection fault in foo", "general protection fault"},
+		{"INFO: task hung in foo", "INFO: task hung"},
+		{"memory leak in foo (2)", "memory leak"},
+		{"lost connection to test machine", "lost connection to test machine"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if class := TitleClass(test.title); class != test.class {
+			t.Errorf("title %q: want class %q, got %q", test.title, test.class, class)
+		}
+	}
+	if !SameClass("WARNING in foo", "WARNING in bar") {
+		t.Errorf("WARNINGs are not of the same class")
+	}
+	if SameClass("KASAN: use-after-free Read in foo", "WARNING in foo") {
+		t.Errorf("KASAN and WARNING are of the same class")
+	}
+}
+
+func TestLeakStack(t *testing.T) {
+	rep := &Report{
+		Type: MemoryLeak,
+		Report: []byte(`BUG: memory leak
+unreferenced object 0xffff88810b2a5d00 (size 32):
+  comm "syz-executor", pid 7503, jiffies 4294942823 (age 13.940s)
+  hex dump (first 32 bytes):
+    00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  ................
+  backtrace:
+    [<00000000a3ac0bd6>] kmemleak_alloc_recursive include/linux/kmemleak.h:43 [inline]
+    [<00000000a3ac0bd6>] slab_post_alloc_hook mm/slab.h:522 [inline]
+    [<00000000a3ac0bd6>] kmem_cache_alloc_trace+0x145/0x2c0 mm/slab.c:3549
+    [<00000000c8234a88>] kmalloc include/linux/slab.h:556 [inline]
+    [<00000000c8234a88>] sget_userns+0x91e/0xe70 fs/super.c:212
+    [<00000000494d4fe2>] sget+0xd2/0x120
+    [<0000000096a3fef4>] mount_single+0x3e/0x160
+    [<0000000058f3812e>] simple_pin_fs+0xec/0x180
+
+BUG: memory leak
+unreferenced object 0xffff88810b2a5e00 (size 32):
+  backtrace:
+    [<000000005cb5aa0e>] kmem_cache_alloc_node_trace+0x1d3/0x3f0
+    [<00000000abadf90a>] foo+0x572/0x800
+`),
+	}
+	want := []string{"sget_userns", "sget", "mount_single", "simple_pin_fs"}
+	if got := LeakStack(rep); !reflect.DeepEqual(got, want) {
+		t.Errorf("want stack %q, got %q", want, got)
+	}
+	rep.Type = Unknown
+	if got := LeakStack(rep); got != nil {
+		t.Errorf("got stack %q for a non-leak report", got)
+	}
+}
+
 func TestFuzz(t *testing.T) {
 	for _, data := range []string{
 		"kernel panicType 'help' for a list of commands",
@@ -375,3 +439,152 @@ func TestFuzz(t *testing.T) {
 		Fuzz([]byte(data))
 	}
 }
+
+func TestStructure(t *testing.T) {
+	reporter, err := NewReporter(&mgrconfig.Config{
+		TargetOS:   "linux",
+		TargetArch: "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := reporter.Parse([]byte(`
+[  123.456789] ==================================================================
+[  123.456789] BUG: KASAN: use-after-free in ip6_send_skb+0x2f5/0x330 net/ipv6/ip6_output.c:1748
+[  123.456789] Read of size 8 at addr ffff88004fab1858 by task syz-executor0/30168
+[  123.456789] 
+[  123.456789] CPU: 1 PID: 30168 Comm: syz-executor0 Not tainted 4.12.0-rc3+ #3
+[  123.456789] Call Trace:
+[  123.456789]  dump_stack+0x292/0x395 lib/dump_stack.c:52
+[  123.456789]  ip6_send_skb+0x2f5/0x330 net/ipv6/ip6_output.c:1748
+[  123.456789]  rawv6_push_pending_frames net/ipv6/raw.c:613 [inline]
+[  123.456789]  nf_hook+0x10/0x20 [nf_tables]
+[  123.456789] RIP: 0033:0x446179
+[  123.456789] 
+[  123.456789] Allocated by task 30170:
+[  123.456789]  kmalloc+0x10/0x20 mm/slab.c:100
+[  123.456789] ==================================================================
+`))
+	if rep == nil {
+		t.Fatal("no report")
+	}
+	st := Structure(rep)
+	want := &Structured{
+		Title:   "KASAN: use-after-free Read in ip6_send_skb",
+		Type:    "UNKNOWN",
+		BugType: "KASAN: use-after-free Read",
+		Frame:   "ip6_send_skb",
+		Access: &Access{
+			Kind:      "read",
+			Size:      8,
+			Addr:      0xffff88004fab1858,
+			AddrClass: "kernel",
+		},
+		Stacks: []*StackTrace{
+			{
+				Name: "Call Trace",
+				Frames: []*StackFrame{
+					{Func: "dump_stack", Offset: 0x292, Size: 0x395, File: "lib/dump_stack.c", Line: 52},
+					{Func: "ip6_send_skb", Offset: 0x2f5, Size: 0x330, File: "net/ipv6/ip6_output.c", Line: 1748},
+					{Func: "rawv6_push_pending_frames", File: "net/ipv6/raw.c", Line: 613, Inline: true},
+					{Func: "nf_hook", Offset: 0x10, Size: 0x20, Module: "nf_tables"},
+				},
+			},
+			{
+				Name: "Allocated by task 30170",
+				Frames: []*StackFrame{
+					{Func: "kmalloc", Offset: 0x10, Size: 0x20, File: "mm/slab.c", Line: 100},
+				},
+			},
+		},
+		CPUs: []int{1},
+		Tasks: []*Task{
+			{PID: 30168, Comm: "syz-executor0"},
+			{PID: 30170},
+		},
+	}
+	if !reflect.DeepEqual(st, want) {
+		got, _ := json.MarshalIndent(st, "", "\t")
+		exp, _ := json.MarshalIndent(want, "", "\t")
+		t.Fatalf("got:\n%s\nwant:\n%s", got, exp)
+	}
+}
+
+func TestAddrClass(t *testing.T) {
+	for addr, class := range map[uint64]string{
+		0x0:                "null",
+		0x28:               "null",
+		0x20000000:         "user",
+		0x7fffffffffff:     "user",
+		0xdffffc0000000000: "non-canonical",
+		0xffff88004fab1858: "kernel",
+	} {
+		if got := addrClass(addr); got != class {
+			t.Errorf("addr 0x%x: got class %v, want %v", addr, got, class)
+		}
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	reporter, err := NewReporter(&mgrconfig.Config{
+		TargetOS:   "linux",
+		TargetArch: "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := []byte(`
+[   10.000000] WARNING: CPU: 0 PID: 100 at net/core/dev.c:123 foo+0x10/0x20
+[   10.000000] CPU: 0 PID: 100 Comm: syz-executor Not tainted 5.6.0+ #2
+[   10.000000] Call Trace:
+[   10.000000]  foo+0x10/0x20 net/core/dev.c:123
+[   10.000000]  bar+0x10/0x20 net/core/dev.c:456
+[   11.000000] ==================================================================
+[   11.000000] BUG: KASAN: use-after-free in baz+0x10/0x20 net/core/sock.c:100
+[   11.000000] Read of size 8 at addr ffff88004fab1858 by task syz-executor/100
+[   11.000000] CPU: 0 PID: 100 Comm: syz-executor Not tainted 5.6.0+ #2
+[   11.000000] Call Trace:
+[   11.000000]  baz+0x10/0x20 net/core/sock.c:100
+[   11.000000]  bar+0x10/0x20 net/core/dev.c:456
+[   11.000000] ==================================================================
+[   12.000000] BUG: unable to handle kernel NULL pointer dereference at 0000000000000010
+[   12.000000] CPU: 1 PID: 200 Comm: kworker/1:1 Not tainted 5.6.0+ #2
+[   12.000000] RIP: 0010:qux+0x10/0x20 fs/qux.c:10
+[   12.000000] Call Trace:
+[   12.000000]  qux+0x10/0x20 fs/qux.c:10
+[   12.000000]  process_one_work+0x10/0x20 kernel/workqueue.c:100
+`)
+	reports := ParseAll(reporter, output)
+	type result struct {
+		Title       string
+		Order       int
+		Consequence bool
+	}
+	var got []result
+	for _, rep := range reports {
+		got = append(got, result{rep.Title, rep.Order, rep.Consequence})
+		if !bytes.HasPrefix(output[rep.StartPos:], []byte("[  ")) {
+			t.Errorf("report %q starts at a bad position %v", rep.Title, rep.StartPos)
+		}
+	}
+	want := []result{
+		{"WARNING in foo", 0, false},
+		{"KASAN: use-after-free Read in baz", 1, true},
+		{"BUG: unable to handle kernel NULL pointer dereference in qux", 2, false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got reports:\n%+v\nwant:\n%+v", got, want)
+	}
+	primary := SelectPrimary(reports)
+	if primary != reports[1] {
+		t.Fatalf("selected primary report %q", primary.Title)
+	}
+	if len(primary.Secondary) != 2 || primary.Secondary[0] != reports[0] || primary.Secondary[1] != reports[2] {
+		t.Fatalf("bad secondary reports %+v", primary.Secondary)
+	}
+	text := string(primary.SecondaryText())
+	if !strings.Contains(text, "WARNING in foo\nreported before the primary report\n") ||
+		!strings.Contains(text, "in qux\nreported after the primary report\n") {
+		t.Fatalf("bad secondary text:\n%v", text)
+	}
+}
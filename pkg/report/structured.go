@@ -0,0 +1,269 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Structured is a machine-readable representation of a crash report.
+// It is produced from the report text (preferably after Symbolize),
+// so that downstream tools don't need to parse free-form kernel output.
+type Structured struct {
+	Title           string
+	Type            string
+	BugType         string // title without location (see TitleClass)
+	Frame           string
+	Corrupted       bool
+	CorruptedReason string  `json:",omitempty"`
+	Access          *Access `json:",omitempty"`
+	Fault           *Fault  `json:",omitempty"`
+	Stacks          []*StackTrace
+	CPUs            []int
+	Tasks           []*Task
+	Sanitizer       *Details `json:",omitempty"`
+	Maintainers     []string `json:",omitempty"`
+	Subsystems      []string `json:",omitempty"`
+	// Titles of other reports found in the same console output (see SelectPrimary).
+	Secondary []string `json:",omitempty"`
+}
+
+// Access describes the bad memory access that caused the crash.
+type Access struct {
+	Kind string `json:",omitempty"` // read/write/read-write
+	Size int    `json:",omitempty"`
+	Addr uint64
+	// AddrClass classifies Addr: null, user, non-canonical or kernel.
+	AddrClass string
+}
+
+// StackTrace is a single stack trace in the report, e.g. "Call Trace" or "Allocated by task 1".
+type StackTrace struct {
+	Name   string
+	Frames []*StackFrame
+}
+
+type StackFrame struct {
+	Func   string
+	Offset uint64 `json:",omitempty"`
+	Size   uint64 `json:",omitempty"`
+	Module string `json:",omitempty"`
+	File   string `json:",omitempty"`
+	Line   int    `json:",omitempty"`
+	Inline bool   `json:",omitempty"`
+	// Unreliable frames are marked with "?" in the report.
+	Unreliable bool `json:",omitempty"`
+}
+
+type Task struct {
+	PID  int
+	Comm string `json:",omitempty"`
+}
+
+// Structure converts rep to the structured representation.
+func Structure(rep *Report) *Structured {
+	st := &Structured{
+		Title:           rep.Title,
+		Type:            rep.Type.String(),
+		BugType:         TitleClass(rep.Title),
+		Frame:           rep.Frame,
+		Corrupted:       rep.Corrupted,
+		CorruptedReason: rep.CorruptedReason,
+		Sanitizer:       rep.Details,
+		Fault:           rep.Fault,
+		Maintainers:     rep.Maintainers,
+		Subsystems:      rep.Subsystems,
+	}
+	text := rep.Report[rep.reportPrefixLen:]
+	st.Access = extractAccess(text, rep.Details)
+	st.Stacks = extractStackTraces(text)
+	st.CPUs, st.Tasks = extractTasks(text, rep.Details)
+	for _, sec := range rep.Secondary {
+		st.Secondary = append(st.Secondary, sec.Title)
+	}
+	return st
+}
+
+// JSON returns the structured representation of rep serialized as JSON.
+func (rep *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(Structure(rep), "", "\t")
+}
+
+var (
+	structFrameRe = regexp.MustCompile(`^\s*(?:\[\<?(?:0x)?[0-9a-f]+\>?\]\s+)?(\? )?([a-zA-Z0-9_.]+)` +
+		`(?:\+0x([0-9a-f]+)/0x([0-9a-f]+))?(?: \[([a-zA-Z0-9_\-]+)\])?` +
+		`(?: ([a-zA-Z0-9_\-/.]+\.[a-zA-Z]+):([0-9]+))?( \[inline\])?\s*$`)
+	structRipRe       = regexp.MustCompile(`^(?:RIP|IP|pc) ?: (?:[0-9a-f]{4}:)?(.*)$`)
+	structStackHdrRe  = regexp.MustCompile(`^[A-Za-z][^:]*:$`)
+	structKasanRe     = regexp.MustCompile(`(Read|Write) of size ([0-9]+) at addr ([0-9a-f]+)`)
+	structPageFaultRe = regexp.MustCompile(`(?:unable to handle kernel paging request|` +
+		`unable to handle page fault for address|unable to handle kernel NULL pointer dereference)` +
+		`(?: at)?:? (?:virtual address )?([0-9a-f]{4,})`)
+	structFaultKindRe = regexp.MustCompile(`#PF: [a-z]+ (read|write) access`)
+	structGPFRe       = regexp.MustCompile(`general protection fault, probably for non-canonical address (0x[0-9a-f]+)`)
+	structCPURe       = regexp.MustCompile(`CPU: ([0-9]+) PID: ([0-9]+) Comm: ([^ ]+)`)
+	structTaskRe      = regexp.MustCompile(` by task ([^ ]+)/([0-9]+)`)
+	structTaskPIDRe   = regexp.MustCompile(`(?:Allocated|Freed|allocated|freed) by task ([0-9]+)`)
+)
+
+func extractAccess(text []byte, details *Details) *Access {
+	access := new(Access)
+	if match := structKasanRe.FindSubmatch(text); match != nil {
+		access.Kind = strings.ToLower(string(match[1]))
+		access.Size, _ = strconv.Atoi(string(match[2]))
+		access.Addr, _ = strconv.ParseUint(string(match[3]), 16, 64)
+	} else if match := structPageFaultRe.FindSubmatch(text); match != nil {
+		access.Addr, _ = strconv.ParseUint(string(match[1]), 16, 64)
+		if match := structFaultKindRe.FindSubmatch(text); match != nil {
+			access.Kind = string(match[1])
+		}
+	} else if match := structGPFRe.FindSubmatch(text); match != nil {
+		access.Addr, _ = strconv.ParseUint(string(match[1]), 0, 64)
+	} else if details != nil && len(details.Stacks) != 0 && details.Stacks[0].Addr != 0 {
+		stack := details.Stacks[0]
+		access.Addr = stack.Addr
+		access.Size = stack.Size
+		switch stack.Kind {
+		case "read", "write", "read-write":
+			access.Kind = stack.Kind
+		default:
+			if strings.HasSuffix(details.Kind, " read") {
+				access.Kind = "read"
+			} else if strings.HasSuffix(details.Kind, " write") {
+				access.Kind = "write"
+			}
+		}
+	} else {
+		return nil
+	}
+	access.AddrClass = addrClass(access.Addr)
+	return access
+}
+
+// addrClass classifies a 64-bit address.
+func addrClass(addr uint64) string {
+	switch {
+	case addr < 4<<10:
+		return "null"
+	case addr < 1<<47:
+		return "user"
+	case addr < 0xffff800000000000:
+		return "non-canonical"
+	default:
+		return "kernel"
+	}
+}
+
+func extractStackTraces(text []byte) []*StackTrace {
+	var stacks []*StackTrace
+	var cur *StackTrace
+	for _, ln := range bytes.Split(text, []byte{'\n'}) {
+		ln = bytes.TrimRight(ln, "\r")
+		if match := structRipRe.FindSubmatch(ln); match != nil {
+			if frame := parseStructFrame(match[1]); frame != nil {
+				stacks = append(stacks, &StackTrace{
+					Name:   string(bytes.TrimSpace(ln[:bytes.IndexByte(ln, ':')])),
+					Frames: []*StackFrame{frame},
+				})
+			}
+			cur = nil
+			continue
+		}
+		if frame := parseStructFrame(ln); frame != nil {
+			if cur == nil {
+				cur = new(StackTrace)
+				stacks = append(stacks, cur)
+			}
+			cur.Frames = append(cur.Frames, frame)
+			continue
+		}
+		if trimmed := bytes.TrimSpace(ln); structStackHdrRe.Match(trimmed) {
+			cur = &StackTrace{Name: string(trimmed[:len(trimmed)-1])}
+			stacks = append(stacks, cur)
+		}
+	}
+	// Drop headers that are not followed by any frames.
+	res := stacks[:0]
+	for _, stack := range stacks {
+		if len(stack.Frames) != 0 {
+			res = append(res, stack)
+		}
+	}
+	return res
+}
+
+func parseStructFrame(ln []byte) *StackFrame {
+	match := structFrameRe.FindSubmatch(ln)
+	if match == nil || match[3] == nil && match[6] == nil {
+		// Without an offset or a source location it's not a frame.
+		return nil
+	}
+	frame := &StackFrame{
+		Func:       string(match[2]),
+		Module:     string(match[5]),
+		File:       string(match[6]),
+		Inline:     match[8] != nil,
+		Unreliable: match[1] != nil,
+	}
+	frame.Offset, _ = strconv.ParseUint(string(match[3]), 16, 64)
+	frame.Size, _ = strconv.ParseUint(string(match[4]), 16, 64)
+	frame.Line, _ = strconv.Atoi(string(match[7]))
+	return frame
+}
+
+func extractTasks(text []byte, details *Details) ([]int, []*Task) {
+	cpus := make(map[int]bool)
+	tasks := make(map[int]*Task)
+	addTask := func(pid int, comm string) {
+		if task := tasks[pid]; task != nil {
+			if task.Comm == "" {
+				task.Comm = comm
+			}
+			return
+		}
+		tasks[pid] = &Task{PID: pid, Comm: comm}
+	}
+	for _, match := range structCPURe.FindAllSubmatch(text, -1) {
+		cpu, _ := strconv.Atoi(string(match[1]))
+		cpus[cpu] = true
+		pid, _ := strconv.Atoi(string(match[2]))
+		addTask(pid, string(match[3]))
+	}
+	for _, match := range structTaskRe.FindAllSubmatch(text, -1) {
+		pid, _ := strconv.Atoi(string(match[2]))
+		addTask(pid, string(match[1]))
+	}
+	for _, match := range structTaskPIDRe.FindAllSubmatch(text, -1) {
+		pid, _ := strconv.Atoi(string(match[1]))
+		addTask(pid, "")
+	}
+	if details != nil {
+		for _, stack := range details.Stacks {
+			if stack.CPU >= 0 {
+				cpus[stack.CPU] = true
+			}
+			if stack.Task >= 0 {
+				addTask(stack.Task, "")
+			}
+		}
+	}
+	var cpuList []int
+	for cpu := range cpus {
+		cpuList = append(cpuList, cpu)
+	}
+	sort.Ints(cpuList)
+	var taskList []*Task
+	for _, task := range tasks {
+		taskList = append(taskList, task)
+	}
+	sort.Slice(taskList, func(i, j int) bool {
+		return taskList[i].PID < taskList[j].PID
+	})
+	return cpuList, taskList
+}
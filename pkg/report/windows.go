@@ -0,0 +1,151 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type windows struct {
+	kernelSrc string
+	kernelObj string
+	ignores   []*regexp.Regexp
+}
+
+func ctorWindows(cfg *config) (Reporter, []string, error) {
+	ctx := &windows{
+		kernelSrc: cfg.kernelSrc,
+		kernelObj: cfg.kernelObj,
+		ignores:   cfg.ignores,
+	}
+	return ctx, nil, nil
+}
+
+func (ctx *windows) ContainsCrash(output []byte) bool {
+	return containsCrash(output, windowsOopses, ctx.ignores)
+}
+
+func (ctx *windows) Parse(output []byte) *Report {
+	rep := simpleLineParser(output, windowsOopses, nil, ctx.ignores)
+	if rep == nil {
+		return nil
+	}
+	rep.Title = windowsBugcheckTitle(rep.Title)
+	// The debugger prints the bugcheck code several times (Fatal System Error, BugCheck),
+	// the report extends till the end of the last of these lines.
+	for _, re := range windowsReportEnd {
+		if match := re.FindAllIndex(output[rep.StartPos:], -1); match != nil {
+			if end := rep.StartPos + match[len(match)-1][1]; end > rep.EndPos {
+				rep.EndPos = end
+			}
+		}
+	}
+	return rep
+}
+
+func (ctx *windows) Symbolize(rep *Report) error {
+	// Bugchecks are symbolized by the kernel debugger.
+	return nil
+}
+
+var (
+	windowsBugcheckRe = regexp.MustCompile(`^BUGCHECK ([0-9a-fA-F]+)`)
+	windowsReportEnd  = []*regexp.Regexp{
+		regexp.MustCompile(`BugCheck [0-9a-fA-F]+, {.*`),
+		regexp.MustCompile(`Probably caused by : .*`),
+	}
+)
+
+// windowsBugcheckTitle replaces the numeric bugcheck code in the title with the bugcheck name,
+// so that the same bugcheck gets the same title regardless of how the code was printed.
+func windowsBugcheckTitle(title string) string {
+	match := windowsBugcheckRe.FindStringSubmatchIndex(title)
+	if match == nil {
+		return title
+	}
+	code, err := strconv.ParseUint(title[match[2]:match[3]], 16, 32)
+	if err != nil {
+		return title
+	}
+	name := windowsBugchecks[code]
+	if name == "" {
+		name = fmt.Sprintf("BUGCHECK 0x%X", code)
+	}
+	return name + title[match[1]:]
+}
+
+// Names of common bugcheck codes as in the Windows bug check code reference.
+var windowsBugchecks = map[uint64]string{
+	0x0A:  "IRQL_NOT_LESS_OR_EQUAL",
+	0x19:  "BAD_POOL_HEADER",
+	0x1A:  "MEMORY_MANAGEMENT",
+	0x1E:  "KMODE_EXCEPTION_NOT_HANDLED",
+	0x3B:  "SYSTEM_SERVICE_EXCEPTION",
+	0x4A:  "IRQL_GT_ZERO_AT_SYSTEM_SERVICE",
+	0x50:  "PAGE_FAULT_IN_NONPAGED_AREA",
+	0x7E:  "SYSTEM_THREAD_EXCEPTION_NOT_HANDLED",
+	0x7F:  "UNEXPECTED_KERNEL_MODE_TRAP",
+	0xC2:  "BAD_POOL_CALLER",
+	0xC4:  "DRIVER_VERIFIER_DETECTED_VIOLATION",
+	0xC5:  "DRIVER_CORRUPTED_EXPOOL",
+	0xD1:  "DRIVER_IRQL_NOT_LESS_OR_EQUAL",
+	0xEF:  "CRITICAL_PROCESS_DIED",
+	0x101: "CLOCK_WATCHDOG_TIMEOUT",
+	0x133: "DPC_WATCHDOG_VIOLATION",
+	0x139: "KERNEL_SECURITY_CHECK_FAILURE",
+	0x13A: "KERNEL_MODE_HEAP_CORRUPTION",
+	0x154: "UNEXPECTED_STORE_EXCEPTION",
+	0x1CA: "SYNTHETIC_WATCHDOG_TIMEOUT",
+}
+
+var windowsOopses = []*oops{
+	{
+		// Printed by the kernel debugger when the system bugchecks.
+		[]byte("Fatal System Error: "),
+		[]oopsFormat{
+			{
+				title: compile("Fatal System Error: 0x([0-9a-fA-F]+)(?:.*\\n)+?" +
+					".*Probably caused by : .*\\( *([a-zA-Z0-9_]+![a-zA-Z0-9_]+)"),
+				fmt:          "BUGCHECK %[1]v in %[2]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("Fatal System Error: 0x([0-9a-fA-F]+)(?:.*\\n)+?.*Probably caused by : ([a-zA-Z0-9_]+)"),
+				fmt:          "BUGCHECK %[1]v in %[2]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("Fatal System Error: 0x([0-9a-fA-F]+)"),
+				fmt:          "BUGCHECK %[1]v",
+				noStackTrace: true,
+			},
+		},
+		[]*regexp.Regexp{},
+	},
+	{
+		// Printed by !analyze.
+		[]byte("BugCheck "),
+		[]oopsFormat{
+			{
+				title: compile("BugCheck ([0-9a-fA-F]+), {(?:.*\\n)+?" +
+					".*Probably caused by : .*\\( *([a-zA-Z0-9_]+![a-zA-Z0-9_]+)"),
+				fmt:          "BUGCHECK %[1]v in %[2]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("BugCheck ([0-9a-fA-F]+), {(?:.*\\n)+?.*Probably caused by : ([a-zA-Z0-9_]+)"),
+				fmt:          "BUGCHECK %[1]v in %[2]v",
+				noStackTrace: true,
+			},
+			{
+				title:        compile("BugCheck ([0-9a-fA-F]+), {"),
+				fmt:          "BUGCHECK %[1]v",
+				noStackTrace: true,
+			},
+		},
+		[]*regexp.Regexp{},
+	},
+}
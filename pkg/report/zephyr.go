@@ -0,0 +1,180 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+type zephyr struct {
+	ignores []*regexp.Regexp
+	objfile string
+}
+
+func ctorZephyr(cfg *config) (Reporter, []string, error) {
+	ctx := &zephyr{
+		ignores: cfg.ignores,
+	}
+	if cfg.kernelObj != "" {
+		ctx.objfile = filepath.Join(cfg.kernelObj, cfg.target.KernelObject)
+	}
+	return ctx, nil, nil
+}
+
+// Every fatal error is printed as ">>> ZEPHYR FATAL ERROR", but the executor
+// lets the kernel kill the thread that passed bad syscall arguments (Kernel oops).
+// Only the errors that halt the system are crashes (see executor/zephyr).
+var (
+	zephyrHaltRe   = regexp.MustCompile(`(?m)^(?:E: )?Halting system`)
+	zephyrFatalRe  = regexp.MustCompile(`>>> ZEPHYR FATAL ERROR [0-9]+: ([A-Za-z ]+?) on CPU [0-9]+`)
+	zephyrBannerRe = regexp.MustCompile(`(?m)^(?:E: )?\*\*\*\*\* ([A-Za-z ]+?) \*\*\*\*\*`)
+	zephyrAssertRe = regexp.MustCompile(`(?m)^ASSERTION FAIL \[(.+)\] @ (?:.*/)?([a-zA-Z0-9_\-.]+\.[ch]):[0-9]+`)
+	zephyrPCRe     = regexp.MustCompile(`Faulting instruction address \(r15/pc\): (0x[0-9a-f]+)`)
+)
+
+func (ctx *zephyr) ContainsCrash(output []byte) bool {
+	return ctx.findHalt(output) != nil
+}
+
+func (ctx *zephyr) findHalt(output []byte) []int {
+	for pos := 0; pos < len(output); {
+		match := zephyrHaltRe.FindIndex(output[pos:])
+		if match == nil {
+			return nil
+		}
+		start, end := pos+match[0], pos+match[1]
+		if next := bytes.IndexByte(output[end:], '\n'); next != -1 {
+			end += next
+		} else {
+			end = len(output)
+		}
+		if !matchesAny(output[start:end], ctx.ignores) {
+			return []int{start, end}
+		}
+		pos = end
+	}
+	return nil
+}
+
+func (ctx *zephyr) Parse(output []byte) *Report {
+	halt := ctx.findHalt(output)
+	if halt == nil {
+		return nil
+	}
+	rep := &Report{
+		Output:   output,
+		StartPos: lineStart(output, halt[0]),
+		EndPos:   halt[1],
+	}
+	// The fatal error message precedes the halt, and the fault description precedes
+	// the fatal error message. Anything before the previous fatal error belongs to
+	// an earlier (non-fatal) error.
+	reason := ""
+	if fatals := zephyrFatalRe.FindAllSubmatchIndex(output[:halt[0]], -1); len(fatals) != 0 {
+		fatal := fatals[len(fatals)-1]
+		reason = string(output[fatal[2]:fatal[3]])
+		rep.StartPos = lineStart(output, fatal[0])
+		prev := 0
+		if len(fatals) > 1 {
+			prev = fatals[len(fatals)-2][1]
+		}
+		for _, re := range []*regexp.Regexp{zephyrBannerRe, zephyrAssertRe} {
+			if match := re.FindIndex(output[prev:fatal[0]]); match != nil && prev+match[0] < rep.StartPos {
+				rep.StartPos = lineStart(output, prev+match[0])
+			}
+		}
+	}
+	rep.Report = output[rep.StartPos:rep.EndPos]
+	rep.Title, rep.Frame = ctx.title(rep.Report, reason)
+	return rep
+}
+
+func (ctx *zephyr) title(report []byte, reason string) (string, string) {
+	if match := zephyrAssertRe.FindSubmatch(report); match != nil {
+		return fmt.Sprintf("zephyr: assertion '%s' failed in %s", match[1], match[2]), ""
+	}
+	title := "zephyr: system halted"
+	if reason != "" {
+		title = "zephyr: " + reason
+	}
+	if match := zephyrBannerRe.FindSubmatch(report); match != nil && reason == "CPU exception" {
+		title = "zephyr: " + string(match[1])
+	}
+	frame := ctx.faultingFunc(report)
+	if frame != "" {
+		title += " in " + frame
+	}
+	return title, frame
+}
+
+// faultingFunc returns the function that contains the faulting PC.
+// Zephyr does not have symbols on the device, so this works only with kernel_obj.
+func (ctx *zephyr) faultingFunc(report []byte) string {
+	if ctx.objfile == "" {
+		return ""
+	}
+	match := zephyrPCRe.FindSubmatch(report)
+	if match == nil {
+		return ""
+	}
+	pc, err := strconv.ParseUint(string(match[1]), 0, 32)
+	if err != nil {
+		return ""
+	}
+	symb := symbolizer.NewSymbolizer()
+	defer symb.Close()
+	frames, err := symb.Symbolize(ctx.objfile, pc)
+	if err != nil || len(frames) == 0 {
+		return ""
+	}
+	// The last frame is the non-inlined function.
+	return frames[len(frames)-1].Func
+}
+
+func (ctx *zephyr) Symbolize(rep *Report) error {
+	if ctx.objfile == "" {
+		return nil
+	}
+	symb := symbolizer.NewSymbolizer()
+	defer symb.Close()
+	var symbolized []byte
+	for _, line := range bytes.SplitAfter(rep.Report, []byte{'\n'}) {
+		symbolized = append(symbolized, ctx.symbolizeLine(symb.Symbolize, line)...)
+	}
+	rep.Report = symbolized
+	return nil
+}
+
+func (ctx *zephyr) symbolizeLine(symbFunc func(bin string, pc uint64) ([]symbolizer.Frame, error),
+	line []byte) []byte {
+	match := zephyrPCRe.FindSubmatchIndex(line)
+	if match == nil {
+		return line
+	}
+	pc, err := strconv.ParseUint(string(line[match[2]:match[3]]), 0, 32)
+	if err != nil {
+		return line
+	}
+	frames, err := symbFunc(ctx.objfile, pc)
+	if err != nil || len(frames) == 0 {
+		return line
+	}
+	var symbolized []byte
+	end := bytes.TrimRight(line, "\r\n")
+	for _, frame := range frames {
+		symbolized = append(symbolized, end...)
+		symbolized = append(symbolized, fmt.Sprintf(" %v %v:%v\n", frame.Func, frame.File, frame.Line)...)
+	}
+	return symbolized
+}
+
+func lineStart(output []byte, pos int) int {
+	return bytes.LastIndexByte(output[:pos], '\n') + 1
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+)
+
+// Reproduction stages recorded in checkpoints, in the order of execution.
+const (
+	StageExtracted  = "extracted"
+	StageMinimized  = "minimized"
+	StageExtractedC = "extracted C"
+	StageSimplified = "simplified"
+)
+
+var stageOrder = map[string]int{
+	"":              0,
+	StageExtracted:  1,
+	StageMinimized:  2,
+	StageExtractedC: 3,
+	StageSimplified: 4,
+}
+
+// Checkpoint records progress of a reproduction session.
+// Checkpoints are stored in the workdir, so that a session interrupted
+// (e.g. because all VMs failed) resumes where it stopped instead of starting over.
+type Checkpoint struct {
+	Title string
+	// Stage is the last completed reproduction stage (one of Stage* constants).
+	Stage string
+	// LogHash identifies the crash log the extraction progress relates to.
+	LogHash string
+	// Number of extraction timeouts that were tried without success for the log.
+	ExtractTimeouts int
+	// Set if the reproduction fell back to accepting any crash (see ReproPreserveFallback).
+	Relaxed bool
+	// The current reproducer (empty before StageExtracted).
+	Prog     []byte
+	Opts     csource.Options
+	Duration time.Duration
+	CRepro   bool
+	Strategy string
+	// Number of program option simplifications that were already tried.
+	Simplified int
+	Time       time.Time
+	// Log is the crash log being reproduced (stored in a separate file).
+	Log []byte `json:"-"`
+}
+
+func (cp *Checkpoint) reached(stage string) bool {
+	return stageOrder[cp.Stage] >= stageOrder[stage]
+}
+
+func checkpointDir(workdir, title string) string {
+	return filepath.Join(workdir, "repro", hash.String([]byte(title)))
+}
+
+// ReadCheckpoint returns the checkpoint of the reproduction session for the crash title
+// (without the crash log), or nil if there is no unfinished session.
+func ReadCheckpoint(workdir, title string) *Checkpoint {
+	if workdir == "" {
+		return nil
+	}
+	return readCheckpoint(checkpointDir(workdir, title), false)
+}
+
+// Checkpoints returns all unfinished reproduction sessions stored in the workdir.
+func Checkpoints(workdir string) []*Checkpoint {
+	dirs, err := osutil.ListDir(filepath.Join(workdir, "repro"))
+	if err != nil {
+		return nil
+	}
+	var checkpoints []*Checkpoint
+	for _, dir := range dirs {
+		if cp := readCheckpoint(filepath.Join(workdir, "repro", dir), true); cp != nil {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	return checkpoints
+}
+
+func readCheckpoint(dir string, withLog bool) *Checkpoint {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "checkpoint"))
+	if err != nil {
+		return nil
+	}
+	cp := new(Checkpoint)
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil
+	}
+	if withLog {
+		if cp.Log, err = ioutil.ReadFile(filepath.Join(dir, "log")); err != nil {
+			return nil
+		}
+	}
+	return cp
+}
+
+// loadCheckpoint loads the checkpoint for the current session (if any)
+// and prepares the checkpoint dir for saving progress.
+func (ctx *context) loadCheckpoint(crashLog []byte) {
+	if ctx.cfg.Workdir == "" {
+		return
+	}
+	ctx.checkpointDir = checkpointDir(ctx.cfg.Workdir, ctx.crashTitle)
+	logHash := hash.String(crashLog)
+	ctx.checkpoint = readCheckpoint(ctx.checkpointDir, false)
+	if ctx.checkpoint != nil {
+		ctx.reproLog(0, "resuming reproduction, completed stage: %q", ctx.checkpoint.Stage)
+		if ctx.checkpoint.LogHash != logHash {
+			// We can reuse the extracted program, but not the extraction progress.
+			ctx.checkpoint.ExtractTimeouts = 0
+		}
+	} else {
+		ctx.checkpoint = &Checkpoint{Title: ctx.crashTitle}
+	}
+	ctx.checkpoint.LogHash = logHash
+	ctx.checkpoint.Log = crashLog
+	if err := osutil.MkdirAll(ctx.checkpointDir); err != nil {
+		ctx.reproLog(0, "failed to create checkpoint dir: %v", err)
+		ctx.checkpointDir = ""
+		return
+	}
+	if err := osutil.WriteFile(filepath.Join(ctx.checkpointDir, "log"), crashLog); err != nil {
+		ctx.reproLog(0, "failed to write checkpoint log: %v", err)
+	}
+	ctx.saveCheckpoint()
+}
+
+// saveCheckpoint stores the current session progress.
+func (ctx *context) saveCheckpoint() {
+	if ctx.checkpointDir == "" {
+		return
+	}
+	ctx.checkpoint.Time = time.Now()
+	data, err := json.MarshalIndent(ctx.checkpoint, "", "\t")
+	if err != nil {
+		ctx.reproLog(0, "failed to marshal checkpoint: %v", err)
+		return
+	}
+	if err := osutil.WriteFile(filepath.Join(ctx.checkpointDir, "checkpoint"), data); err != nil {
+		ctx.reproLog(0, "failed to write checkpoint: %v", err)
+	}
+}
+
+// resumed returns true if the stage was completed in the interrupted session.
+func (ctx *context) resumed(stage string) bool {
+	return ctx.checkpoint != nil && ctx.checkpoint.reached(stage)
+}
+
+// completeStage records that the stage is completed with the reproducer res.
+func (ctx *context) completeStage(stage string, res *Result) {
+	if ctx.checkpoint == nil {
+		return
+	}
+	ctx.checkpoint.Stage = stage
+	ctx.saveProgress(res)
+}
+
+// saveProgress records the current reproducer res within the current stage.
+func (ctx *context) saveProgress(res *Result) {
+	cp := ctx.checkpoint
+	if cp == nil {
+		return
+	}
+	cp.Prog = res.Prog.Serialize()
+	cp.Opts = res.Opts
+	cp.Duration = res.Duration
+	cp.CRepro = res.CRepro
+	cp.Strategy = res.Strategy
+	ctx.saveCheckpoint()
+}
+
+// restoreResult returns the reproducer recorded in the checkpoint, or nil.
+func (ctx *context) restoreResult(target *prog.Target) *Result {
+	cp := ctx.checkpoint
+	if cp == nil || len(cp.Prog) == 0 {
+		return nil
+	}
+	p, err := target.Deserialize(cp.Prog, prog.NonStrict)
+	if err != nil {
+		ctx.reproLog(0, "failed to deserialize checkpoint program: %v", err)
+		cp.Stage = ""
+		return nil
+	}
+	return &Result{
+		Prog:     p,
+		Opts:     cp.Opts,
+		Duration: cp.Duration,
+		CRepro:   cp.CRepro,
+		Strategy: cp.Strategy,
+	}
+}
+
+// removeCheckpoint removes the checkpoint after the session has finished.
+func (ctx *context) removeCheckpoint() {
+	if ctx.checkpointDir == "" {
+		return
+	}
+	if err := os.RemoveAll(ctx.checkpointDir); err != nil {
+		ctx.reproLog(0, "failed to remove checkpoint: %v", err)
+	}
+}
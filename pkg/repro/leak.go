@@ -0,0 +1,56 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/report"
+)
+
+// Number of top allocation stack frames that must match for two leaks to be considered the same.
+// Deeper frames frequently differ depending on the syscall that triggered the allocation.
+const leakStackDepth = 4
+
+// sameLeakStack returns true if the reproduced leak allocation stack matches the original one.
+func sameLeakStack(orig, leak []string) bool {
+	n := len(orig)
+	if n > leakStackDepth {
+		n = leakStackDepth
+	}
+	if len(leak) < n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if orig[i] != leak[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// leaksSameStack returns true if rep leaks an object allocated with the same stack as
+// the original leak, or if the original stack is unknown.
+func (ctx *context) leaksSameStack(rep *report.Report) bool {
+	if len(ctx.leakStack) == 0 {
+		return true
+	}
+	return sameLeakStack(ctx.leakStack, report.LeakStack(rep))
+}
+
+// LeakComment returns a comment describing the allocation stack of the leaked object
+// (or an empty string if the reproducer is not for a memory leak).
+// Every line of the comment starts with prefix (e.g. "# " or "// ").
+func (res *Result) LeakComment(prefix string) string {
+	if len(res.LeakStack) == 0 {
+		return ""
+	}
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "%vleaked object allocation stack:\n", prefix)
+	for _, frame := range res.LeakStack {
+		fmt.Fprintf(buf, "%v  %v\n", prefix, frame)
+	}
+	return buf.String()
+}
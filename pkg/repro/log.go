@@ -0,0 +1,51 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var (
+	// Timestamps prepended to every line by CI systems and loggers
+	// (e.g. "2020-01-02T03:04:05.678Z " or "2020/01/02 03:04:05 ").
+	logTimestampRe = regexp.MustCompile(`^(?:\d{4}-\d\d-\d\d[T ]\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:?\d\d)?|` +
+		`\d{4}/\d\d/\d\d \d\d:\d\d:\d\d(?:\.\d+)?)\s+`)
+	// Kernel console and dmesg prefixes (e.g. "<6>[   12.345678][ T1234] ").
+	kernelPrefixRe = regexp.MustCompile(`^\s*(?:<\d+>)?\[\s*\d+\.\d+\](?:\[\s*[TC]\d+\])?\s*`)
+	// Lines that can be part of executed programs in the log.
+	progLineRe = regexp.MustCompile(`^\s*(?:executing program \d+|r\d+ = [a-zA-Z0-9_$]+\(|[a-zA-Z0-9_$]+\()`)
+)
+
+// NormalizeLog converts a log containing executed programs in an arbitrary format
+// (raw console output, dmesg dumps, CI logs that mix output of several tools)
+// into the format of logs saved by syz-manager, so that it can be used for reproduction.
+// Carriage returns and CI/logger timestamps are removed from all lines, kernel prefixes
+// and indentation are removed from lines that look like program lines.
+// Kernel output is otherwise preserved, so that the crash can still be parsed from the result.
+// Interleaved kernel output and programs truncated at the beginning or at the end of the log
+// are handled by prog.Target.ParseLog, which skips lines that don't parse as calls.
+func NormalizeLog(data []byte) []byte {
+	res := make([]byte, 0, len(data))
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n')
+		if end == -1 {
+			end = len(data)
+		} else {
+			end += pos
+		}
+		line := bytes.TrimRight(data[pos:end], "\r")
+		pos = end + 1
+		if loc := logTimestampRe.FindIndex(line); loc != nil {
+			line = line[loc[1]:]
+		}
+		if stripped := kernelPrefixRe.ReplaceAll(line, nil); progLineRe.Match(stripped) {
+			line = bytes.TrimLeft(stripped, " \t")
+		}
+		res = append(res, line...)
+		res = append(res, '\n')
+	}
+	return res
+}
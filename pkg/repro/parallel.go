@@ -0,0 +1,142 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"sync"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/report"
+)
+
+// parallelTest runs test(i) for all i in [0, n) distributing the tests across all VMs.
+// Tests are started in the order of increasing i. A test returns the crash report,
+// or nil if it did not crash. If stopOnCrash is set, no new tests are started once
+// any test crashes, but the tests that are already running are allowed to finish.
+// Returns results of all tests (tests that were not started are false).
+// ctx.report is set to the report of the first crashed test.
+func (ctx *context) parallelTest(n int, stopOnCrash bool, test func(i int) (*report.Report, error)) (
+	[]bool, error) {
+	parallel := ctx.numVMs
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]bool, n)
+	reports := make([]*report.Report, n)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		crashed bool
+		err     error
+	)
+	sem := make(chan bool, parallel)
+	for i := 0; i < n; i++ {
+		sem <- true
+		mu.Lock()
+		stop := err != nil || stopOnCrash && crashed
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rep, testErr := test(i)
+			mu.Lock()
+			defer mu.Unlock()
+			if testErr != nil {
+				if err == nil {
+					err = testErr
+				}
+				return
+			}
+			results[i] = rep != nil
+			reports[i] = rep
+			crashed = crashed || rep != nil
+		}(i)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if i := firstCrashed(results); i != -1 {
+		ctx.recordReport(reports[i], nil)
+	}
+	return results, nil
+}
+
+// firstCrashed returns index of the first test that crashed or -1.
+func firstCrashed(results []bool) int {
+	for i, crashed := range results {
+		if crashed {
+			return i
+		}
+	}
+	return -1
+}
+
+// parallelSimplify applies simplifications to opts and returns the simplified options.
+// First, all simplifications are verified in parallel on top of opts. Then all
+// successful ones are applied together and the combination is verified once more.
+// If the combination does not crash (simplifications may interfere with each other),
+// we fall back to applying successful simplifications one-by-one.
+func (ctx *context) parallelSimplify(opts csource.Options, simplifies []Simplify,
+	test func(opts csource.Options) (*report.Report, error)) (csource.Options, error) {
+	var candidates []csource.Options
+	var applicable []Simplify
+	for _, simplify := range simplifies {
+		newOpts := opts
+		if simplify(&newOpts) {
+			candidates = append(candidates, newOpts)
+			applicable = append(applicable, simplify)
+		}
+	}
+	results, err := ctx.parallelTest(len(candidates), false, func(i int) (*report.Report, error) {
+		return test(candidates[i])
+	})
+	if err != nil {
+		return opts, err
+	}
+	var successful []Simplify
+	combined := opts
+	for i, crashed := range results {
+		if crashed && applicable[i](&combined) {
+			successful = append(successful, applicable[i])
+		}
+	}
+	switch len(successful) {
+	case 0:
+		return opts, nil
+	case 1:
+		return combined, nil
+	}
+	ctx.reproLog(3, "simplify: %v of %v simplifications succeeded, testing combination",
+		len(successful), len(candidates))
+	crashed, err := ctx.recordReport(test(combined))
+	if err != nil {
+		return opts, err
+	}
+	if crashed {
+		return combined, nil
+	}
+	ctx.reproLog(3, "simplify: combination did not crash, applying simplifications one-by-one")
+	for _, simplify := range successful {
+		newOpts := opts
+		if !simplify(&newOpts) {
+			continue
+		}
+		crashed, err := ctx.recordReport(test(newOpts))
+		if err != nil {
+			return opts, err
+		}
+		if crashed {
+			opts = newOpts
+		}
+	}
+	return opts, nil
+}
@@ -26,6 +26,16 @@ type Result struct {
 	Duration time.Duration
 	Opts     csource.Options
 	CRepro   bool
+	// Strategy describes options combination found by systematic options search
+	// (empty if the crash reproduced with options recorded in the log).
+	Strategy string
+	// Validation holds results of repeated runs of the final reproducer.
+	Validation *Validation
+	// LeakStack is the allocation stack of the leaked object for memory leak reproducers.
+	LeakStack []string
+	// TriggerCall is index of the call that triggers the crash, preceding calls only do setup
+	// (-1 if unknown).
+	TriggerCall int
 	// Information about the final (non-symbolized) crash that we reproduced.
 	// Can be different from what we started reproducing.
 	Report *report.Report
@@ -34,10 +44,13 @@ type Result struct {
 type Stats struct {
 	Log              []byte
 	ExtractProgTime  time.Duration
+	SearchOptsTime   time.Duration
 	MinimizeProgTime time.Duration
 	SimplifyProgTime time.Duration
 	ExtractCTime     time.Duration
 	SimplifyCTime    time.Duration
+	TriggerCallTime  time.Duration
+	ValidateTime     time.Duration
 }
 
 type context struct {
@@ -45,11 +58,19 @@ type context struct {
 	reporter     report.Reporter
 	crashTitle   string
 	crashType    report.Type
+	preserve     string   // see mgrconfig.Config.ReproPreserveCrash
+	leakStack    []string // allocation stack of the original leak (see report.LeakStack)
 	instances    chan *instance
 	bootRequests chan int
+	numVMs       int
 	timeouts     []time.Duration
 	stats        *Stats
-	report       *report.Report
+	// Progress of the session, nil if checkpointing is disabled (see checkpoint.go).
+	checkpoint    *Checkpoint
+	checkpointDir string
+	// Protects report and stats.Log, which are updated by tests running in parallel.
+	mu     sync.Mutex
+	report *report.Report
 }
 
 type instance struct {
@@ -57,6 +78,8 @@ type instance struct {
 	index       int
 	execprogBin string
 	executorBin string
+	// Descriptions of out-of-tree modules (empty if not used).
+	descriptionsFile string
 }
 
 func Run(crashLog []byte, cfg *mgrconfig.Config, reporter report.Reporter, vmPool *vm.Pool,
@@ -74,14 +97,19 @@ func Run(crashLog []byte, cfg *mgrconfig.Config, reporter report.Reporter, vmPoo
 	}
 	crashStart := len(crashLog)
 	crashTitle, crashType := "", report.Unknown
+	preserve := ""
+	var leakStack []string
 	if rep := reporter.Parse(crashLog); rep != nil {
 		crashStart = rep.StartPos
 		crashTitle = rep.Title
 		crashType = rep.Type
+		// There is nothing to preserve for no output/lost connection.
+		preserve = cfg.ReproPreserveCrash
+		leakStack = report.LeakStack(rep)
 	}
 	// The shortest duration is 10 seconds to detect simple crashes (i.e. no races and no hangs).
 	// The longest duration is 6 minutes to catch races and hangs.
-	noOutputTimeout := vm.NoOutputTimeout + time.Minute
+	noOutputTimeout := vm.NoOutputTimeout*time.Duration(cfg.Slowdown) + time.Minute
 	timeouts := []time.Duration{15 * time.Second, time.Minute, noOutputTimeout}
 	switch {
 	case crashTitle == "":
@@ -95,19 +123,79 @@ func Run(crashLog []byte, cfg *mgrconfig.Config, reporter report.Reporter, vmPoo
 		// Memory leaks can't be detected quickly because of expensive setup and scanning.
 		timeouts = []time.Duration{time.Minute, noOutputTimeout}
 	case crashType == report.Hang:
-		timeouts = []time.Duration{noOutputTimeout}
+		// Hangs frequently need long execution to trigger, and hang detectors
+		// report them only after some delay (see testImpl).
+		timeouts = []time.Duration{noOutputTimeout, 2 * noOutputTimeout}
 	}
 	ctx := &context{
 		cfg:          cfg,
 		reporter:     reporter,
 		crashTitle:   crashTitle,
 		crashType:    crashType,
+		preserve:     preserve,
+		leakStack:    leakStack,
 		instances:    make(chan *instance, len(vmIndexes)),
 		bootRequests: make(chan int, len(vmIndexes)),
+		numVMs:       len(vmIndexes),
 		timeouts:     timeouts,
 		stats:        new(Stats),
 	}
 	ctx.reproLog(0, "%v programs, %v VMs, timeouts %v", len(entries), len(vmIndexes), timeouts)
+	ctx.loadCheckpoint(crashLog)
+	stop := ctx.createInstances(vmPool, vmIndexes)
+	defer stop()
+
+	res, err := ctx.repro(entries, crashStart)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res != nil {
+		if ctx.report != nil {
+			ctx.reproLog(3, "repro crashed as (corrupted=%v):\n%s",
+				ctx.report.Corrupted, ctx.report.Report)
+		}
+		// Try to rerun the repro if the report is corrupted. There is no report at all
+		// if the session was resumed from a checkpoint and no test has crashed since then.
+		for attempts := 0; (ctx.report == nil || ctx.report.Corrupted) && attempts < 3; attempts++ {
+			ctx.reproLog(3, "no report or report is corrupted, running repro again")
+			if res.CRepro {
+				_, err = ctx.testCProg(res.Prog, res.Duration, res.Opts)
+			} else {
+				_, err = ctx.testProg(res.Prog, res.Duration, res.Opts)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if ctx.report == nil {
+			// The resumed reproducer does not crash anymore, start from scratch next time.
+			ctx.reproLog(0, "resumed reproducer does not crash")
+			ctx.removeCheckpoint()
+			return nil, ctx.stats, nil
+		}
+		ctx.reproLog(3, "final repro crashed as (corrupted=%v):\n%s",
+			ctx.report.Corrupted, ctx.report.Report)
+		res.Report = ctx.report
+		res.LeakStack = report.LeakStack(ctx.report)
+		// Note: the checkpoint is kept on errors (all VMs failed), the final reproducer
+		// is rerun to get the report when the session is resumed.
+		res.TriggerCall, err = ctx.findTriggerCall(res)
+		if err != nil {
+			return nil, nil, err
+		}
+		res.Validation, err = ctx.validate(res)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	ctx.removeCheckpoint()
+	return res, ctx.stats, nil
+}
+
+// createInstances starts booting VMs with the given indexes in background.
+// Booted instances are delivered over ctx.instances. The returned function
+// stops booting and shuts down all instances.
+func (ctx *context) createInstances(vmPool *vm.Pool, vmIndexes []int) func() {
 	var wg sync.WaitGroup
 	wg.Add(len(vmIndexes))
 	for _, vmIndex := range vmIndexes {
@@ -131,25 +219,36 @@ func Run(crashLog []byte, cfg *mgrconfig.Config, reporter report.Reporter, vmPoo
 						continue
 
 					}
-					execprogBin, err := vmInst.Copy(cfg.SyzExecprogBin)
+					execprogBin, err := vmInst.Copy(ctx.cfg.SyzExecprogBin)
 					if err != nil {
 						ctx.reproLog(0, "failed to copy to VM: %v", err)
 						vmInst.Close()
 						time.Sleep(10 * time.Second)
 						continue
 					}
-					executorBin, err := vmInst.Copy(cfg.SyzExecutorBin)
+					executorBin, err := vmInst.Copy(ctx.cfg.SyzExecutorBin)
 					if err != nil {
 						ctx.reproLog(0, "failed to copy to VM: %v", err)
 						vmInst.Close()
 						time.Sleep(10 * time.Second)
 						continue
 					}
+					descriptionsFile := ""
+					if ctx.cfg.ExtraDescriptionsFile != "" {
+						descriptionsFile, err = vmInst.Copy(ctx.cfg.ExtraDescriptionsFile)
+						if err != nil {
+							ctx.reproLog(0, "failed to copy to VM: %v", err)
+							vmInst.Close()
+							time.Sleep(10 * time.Second)
+							continue
+						}
+					}
 					inst = &instance{
-						Instance:    vmInst,
-						index:       vmIndex,
-						execprogBin: execprogBin,
-						executorBin: executorBin,
+						Instance:         vmInst,
+						index:            vmIndex,
+						execprogBin:      execprogBin,
+						executorBin:      executorBin,
+						descriptionsFile: descriptionsFile,
 					}
 					break
 				}
@@ -164,37 +263,12 @@ func Run(crashLog []byte, cfg *mgrconfig.Config, reporter report.Reporter, vmPoo
 		wg.Wait()
 		close(ctx.instances)
 	}()
-	defer func() {
+	return func() {
 		close(ctx.bootRequests)
 		for inst := range ctx.instances {
 			inst.Close()
 		}
-	}()
-
-	res, err := ctx.repro(entries, crashStart)
-	if err != nil {
-		return nil, nil, err
 	}
-	if res != nil {
-		ctx.reproLog(3, "repro crashed as (corrupted=%v):\n%s",
-			ctx.report.Corrupted, ctx.report.Report)
-		// Try to rerun the repro if the report is corrupted.
-		for attempts := 0; ctx.report.Corrupted && attempts < 3; attempts++ {
-			ctx.reproLog(3, "report is corrupted, running repro again")
-			if res.CRepro {
-				_, err = ctx.testCProg(res.Prog, res.Duration, res.Opts)
-			} else {
-				_, err = ctx.testProg(res.Prog, res.Duration, res.Opts)
-			}
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-		ctx.reproLog(3, "final repro crashed as (corrupted=%v):\n%s",
-			ctx.report.Corrupted, ctx.report.Report)
-		res.Report = ctx.report
-	}
-	return res, ctx.stats, nil
 }
 
 func (ctx *context) repro(entries []*prog.LogEntry, crashStart int) (*Result, error) {
@@ -211,43 +285,79 @@ func (ctx *context) repro(entries []*prog.LogEntry, crashStart int) (*Result, er
 		ctx.reproLog(3, "reproducing took %s", time.Since(reproStart))
 	}()
 
-	res, err := ctx.extractProg(entries)
-	if err != nil {
-		return nil, err
+	if ctx.checkpoint != nil && ctx.checkpoint.Relaxed {
+		ctx.preserve = ""
+		ctx.leakStack = nil
+	}
+	var res *Result
+	if ctx.resumed(StageExtracted) {
+		res = ctx.restoreResult(entries[0].P.Target)
 	}
 	if res == nil {
-		return nil, nil
+		var err error
+		res, err = ctx.extractProg(entries)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil && (ctx.preserve != "" || len(ctx.leakStack) != 0) && ctx.cfg.ReproPreserveFallback {
+			ctx.reproLog(0, "failed to reproduce preserving the crash, retrying with any crash")
+			ctx.preserve = ""
+			ctx.leakStack = nil
+			if ctx.checkpoint != nil {
+				ctx.checkpoint.Relaxed = true
+				ctx.checkpoint.ExtractTimeouts = 0
+				ctx.saveCheckpoint()
+			}
+			res, err = ctx.extractProg(entries)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if res == nil {
+			return nil, nil
+		}
+		ctx.completeStage(StageExtracted, res)
 	}
 	defer func() {
 		if res != nil {
 			res.Opts.Repro = false
 		}
 	}()
-	res, err = ctx.minimizeProg(res)
-	if err != nil {
-		return nil, err
+	var err error
+	if !ctx.resumed(StageMinimized) {
+		res, err = ctx.minimizeProg(res)
+		if err != nil {
+			return nil, err
+		}
+		ctx.completeStage(StageMinimized, res)
 	}
 
 	// Try extracting C repro without simplifying options first.
-	res, err = ctx.extractC(res)
-	if err != nil {
-		return nil, err
-	}
-
-	// Simplify options and try extracting C repro.
-	if !res.CRepro {
-		res, err = ctx.simplifyProg(res)
+	if !ctx.resumed(StageExtractedC) {
+		res, err = ctx.extractC(res)
 		if err != nil {
 			return nil, err
 		}
+		ctx.completeStage(StageExtractedC, res)
 	}
 
-	// Simplify C related options.
-	if res.CRepro {
-		res, err = ctx.simplifyC(res)
-		if err != nil {
-			return nil, err
+	if !ctx.resumed(StageSimplified) {
+		// Simplify options and try extracting C repro.
+		if !res.CRepro {
+			res, err = ctx.simplifyProg(res)
+			if err != nil {
+				return nil, err
+			}
 		}
+
+		// Simplify C related options.
+		if res.CRepro {
+			res, err = ctx.simplifyC(res)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ctx.completeStage(StageSimplified, res)
 	}
 
 	return res, nil
@@ -274,7 +384,11 @@ func (ctx *context) extractProg(entries []*prog.LogEntry) (*Result, error) {
 	for i := len(indices) - 1; i >= 0; i-- {
 		lastEntries = append(lastEntries, entries[indices[i]])
 	}
-	for _, timeout := range ctx.timeouts {
+	for i, timeout := range ctx.timeouts {
+		if ctx.checkpoint != nil && i < ctx.checkpoint.ExtractTimeouts {
+			ctx.reproLog(3, "skipping timeout %v tried in the interrupted session", timeout)
+			continue
+		}
 		// Execute each program separately to detect simple crashes caused by a single program.
 		// Programs are executed in reverse order, usually the last program is the guilty one.
 		res, err := ctx.extractProgSingle(lastEntries, timeout)
@@ -287,21 +401,34 @@ func (ctx *context) extractProg(entries []*prog.LogEntry) (*Result, error) {
 		}
 
 		// Don't try bisecting if there's only one entry.
-		if len(entries) == 1 {
-			continue
-		}
-
-		// Execute all programs and bisect the log to find multiple guilty programs.
-		res, err = ctx.extractProgBisect(entries, timeout)
-		if err != nil {
-			return nil, err
+		if len(entries) != 1 {
+			// Execute all programs and bisect the log to find multiple guilty programs.
+			res, err = ctx.extractProgBisect(entries, timeout)
+			if err != nil {
+				return nil, err
+			}
+			if res != nil {
+				ctx.reproLog(3, "found reproducer with %d syscalls", len(res.Prog.Calls))
+				return res, nil
+			}
 		}
-		if res != nil {
-			ctx.reproLog(3, "found reproducer with %d syscalls", len(res.Prog.Calls))
-			return res, nil
+		if ctx.checkpoint != nil {
+			ctx.checkpoint.ExtractTimeouts = i + 1
+			ctx.saveCheckpoint()
 		}
 	}
 
+	// The crash may depend on options that are not recorded in the log,
+	// try to find them systematically.
+	res, err := ctx.searchOpts(lastEntries)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		ctx.reproLog(3, "found reproducer with %d syscalls using %v", len(res.Prog.Calls), res.Strategy)
+		return res, nil
+	}
+
 	ctx.reproLog(0, "failed to extract reproducer")
 	return nil, nil
 }
@@ -309,30 +436,35 @@ func (ctx *context) extractProg(entries []*prog.LogEntry) (*Result, error) {
 func (ctx *context) extractProgSingle(entries []*prog.LogEntry, duration time.Duration) (*Result, error) {
 	ctx.reproLog(3, "single: executing %d programs separately with timeout %s", len(entries), duration)
 
-	opts := csource.DefaultOpts(ctx.cfg)
+	baseOpts := csource.DefaultOpts(ctx.cfg)
 	if ctx.crashType == report.MemoryLeak {
-		opts.Leak = true
+		baseOpts.Leak = true
 	}
-	for _, ent := range entries {
+	entryOpts := func(ent *prog.LogEntry) csource.Options {
+		opts := baseOpts
 		opts.Fault = ent.Fault
 		opts.FaultCall = ent.FaultCall
 		opts.FaultNth = ent.FaultNth
 		if opts.FaultCall < 0 || opts.FaultCall >= len(ent.P.Calls) {
 			opts.FaultCall = len(ent.P.Calls) - 1
 		}
-		crashed, err := ctx.testProg(ent.P, duration, opts)
-		if err != nil {
-			return nil, err
-		}
-		if crashed {
-			res := &Result{
-				Prog:     ent.P,
-				Duration: duration * 3 / 2,
-				Opts:     opts,
-			}
-			ctx.reproLog(3, "single: successfully extracted reproducer")
-			return res, nil
+		return opts
+	}
+	// Programs are tested in parallel, but we still prefer the earliest crashed program.
+	results, err := ctx.parallelTest(len(entries), true, func(i int) (*report.Report, error) {
+		return ctx.runProg(entries[i].P, duration, entryOpts(entries[i]))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if i := firstCrashed(results); i != -1 {
+		res := &Result{
+			Prog:     entries[i].P,
+			Duration: duration * 3 / 2,
+			Opts:     entryOpts(entries[i]),
 		}
+		ctx.reproLog(3, "single: successfully extracted reproducer")
+		return res, nil
 	}
 
 	ctx.reproLog(3, "single: failed to extract reproducer")
@@ -454,7 +586,14 @@ func (ctx *context) simplifyProg(res *Result) (*Result, error) {
 		ctx.stats.SimplifyProgTime = time.Since(start)
 	}()
 
-	for _, simplify := range progSimplifies {
+	for i, simplify := range progSimplifies {
+		if ctx.checkpoint != nil {
+			if i < ctx.checkpoint.Simplified {
+				continue
+			}
+			ctx.checkpoint.Simplified = i
+			ctx.saveProgress(res)
+		}
 		opts := res.Opts
 		if !simplify(&opts) {
 			continue
@@ -504,51 +643,74 @@ func (ctx *context) simplifyC(res *Result) (*Result, error) {
 		ctx.stats.SimplifyCTime = time.Since(start)
 	}()
 
-	for _, simplify := range cSimplifies {
-		opts := res.Opts
-		if simplify(&opts) {
-			crashed, err := ctx.testCProg(res.Prog, res.Duration, opts)
-			if err != nil {
-				return nil, err
-			}
-			if crashed {
-				res.Opts = opts
-			}
-		}
+	opts, err := ctx.parallelSimplify(res.Opts, cSimplifies, func(opts csource.Options) (*report.Report, error) {
+		return ctx.runCProg(res.Prog, res.Duration, opts)
+	})
+	if err != nil {
+		return nil, err
 	}
+	res.Opts = opts
 	return res, nil
 }
 
+// testProg, testProgs and testCProg run the program(s) and set ctx.report if they crash.
+// Tests that run in parallel use runProg/runCProg instead and leave the report
+// to parallelTest, which chooses the report of the selected test.
 func (ctx *context) testProg(p *prog.Prog, duration time.Duration, opts csource.Options) (crashed bool, err error) {
+	return ctx.recordReport(ctx.runProg(p, duration, opts))
+}
+
+func (ctx *context) testProgs(entries []*prog.LogEntry, duration time.Duration, opts csource.Options) (
+	crashed bool, err error) {
+	return ctx.recordReport(ctx.runProgs(entries, duration, opts))
+}
+
+func (ctx *context) testCProg(p *prog.Prog, duration time.Duration, opts csource.Options) (crashed bool, err error) {
+	return ctx.recordReport(ctx.runCProg(p, duration, opts))
+}
+
+// recordReport sets ctx.report to rep if the test crashed and returns whether it crashed.
+func (ctx *context) recordReport(rep *report.Report, err error) (bool, error) {
+	if err != nil || rep == nil {
+		return false, err
+	}
+	ctx.mu.Lock()
+	ctx.report = rep
+	ctx.mu.Unlock()
+	return true, nil
+}
+
+// runProg runs the program and returns the crash report (nil if it did not crash).
+func (ctx *context) runProg(p *prog.Prog, duration time.Duration, opts csource.Options) (*report.Report, error) {
 	entry := prog.LogEntry{P: p}
 	if opts.Fault {
 		entry.Fault = true
 		entry.FaultCall = opts.FaultCall
 		entry.FaultNth = opts.FaultNth
 	}
-	return ctx.testProgs([]*prog.LogEntry{&entry}, duration, opts)
+	return ctx.runProgs([]*prog.LogEntry{&entry}, duration, opts)
 }
 
-func (ctx *context) testProgs(entries []*prog.LogEntry, duration time.Duration, opts csource.Options) (
-	crashed bool, err error) {
+func (ctx *context) runProgs(entries []*prog.LogEntry, duration time.Duration, opts csource.Options) (
+	*report.Report, error) {
 	inst := <-ctx.instances
 	if inst == nil {
-		return false, fmt.Errorf("all VMs failed to boot")
+		return nil, fmt.Errorf("all VMs failed to boot")
 	}
 	defer ctx.returnInstance(inst)
 	if len(entries) == 0 {
-		return false, fmt.Errorf("no programs to execute")
+		return nil, fmt.Errorf("no programs to execute")
 	}
 
 	pstr := encodeEntries(entries)
 	progFile, err := osutil.WriteTempFile(pstr)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer os.Remove(progFile)
 	vmProgFile, err := inst.Copy(progFile)
 	if err != nil {
-		return false, fmt.Errorf("failed to copy to VM: %v", err)
+		return nil, fmt.Errorf("failed to copy to VM: %v", err)
 	}
 
 	if !opts.Fault {
@@ -566,68 +728,111 @@ func (ctx *context) testProgs(entries []*prog.LogEntry, duration time.Duration,
 		program += "]"
 	}
 
-	command := instancePkg.ExecprogCmd(inst.execprogBin, inst.executorBin,
+	command := instancePkg.ExecprogCmd(inst.execprogBin, inst.executorBin, inst.descriptionsFile,
 		ctx.cfg.TargetOS, ctx.cfg.TargetArch, opts.Sandbox, opts.Repeat,
-		opts.Threaded, opts.Collide, opts.Procs, -1, -1, vmProgFile)
+		opts.Threaded, opts.Collide, opts.Procs, -1, -1, ctx.cfg.Slowdown, vmProgFile)
 	ctx.reproLog(2, "testing program (duration=%v, %+v): %s", duration, opts, program)
 	ctx.reproLog(3, "detailed listing:\n%s", pstr)
 	return ctx.testImpl(inst.Instance, command, duration)
 }
 
-func (ctx *context) testCProg(p *prog.Prog, duration time.Duration, opts csource.Options) (crashed bool, err error) {
+func (ctx *context) runCProg(p *prog.Prog, duration time.Duration, opts csource.Options) (*report.Report, error) {
 	src, err := csource.Write(p, opts)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	bin, err := csource.BuildNoWarn(p.Target, src)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer os.Remove(bin)
 	ctx.reproLog(2, "testing compiled C program (duration=%v, %+v): %s", duration, opts, p)
-	crashed, err = ctx.testBin(bin, duration)
-	if err != nil {
-		return false, err
-	}
-	return crashed, nil
+	return ctx.runBin(bin, duration)
 }
 
-func (ctx *context) testBin(bin string, duration time.Duration) (crashed bool, err error) {
+func (ctx *context) runBin(bin string, duration time.Duration) (*report.Report, error) {
 	inst := <-ctx.instances
 	if inst == nil {
-		return false, fmt.Errorf("all VMs failed to boot")
+		return nil, fmt.Errorf("all VMs failed to boot")
 	}
 	defer ctx.returnInstance(inst)
 
-	bin, err = inst.Copy(bin)
+	bin, err := inst.Copy(bin)
 	if err != nil {
-		return false, fmt.Errorf("failed to copy to VM: %v", err)
+		return nil, fmt.Errorf("failed to copy to VM: %v", err)
 	}
 	return ctx.testImpl(inst.Instance, bin, duration)
 }
 
-func (ctx *context) testImpl(inst *vm.Instance, command string, duration time.Duration) (crashed bool, err error) {
+// testImpl runs the command and returns the crash report (nil if it did not crash
+// or the crash is not acceptable for this reproduction session).
+func (ctx *context) testImpl(inst *vm.Instance, command string, duration time.Duration) (*report.Report, error) {
 	outc, errc, err := inst.Run(duration, nil, command)
 	if err != nil {
-		return false, fmt.Errorf("failed to run command in VM: %v", err)
+		return nil, fmt.Errorf("failed to run command in VM: %v", err)
+	}
+	exit := vm.ExitTimeout | vm.ExitNormal | vm.ExitError
+	var rep *report.Report
+	if ctx.crashType == report.Hang {
+		// Hung task and stall detectors fire only some time after the hang has happened,
+		// so keep watching kernel output after the program finishes or times out.
+		rep = inst.MonitorExecutionDelayed(outc, errc, ctx.reporter, exit, vm.StallDetectionDelay)
+	} else {
+		rep = inst.MonitorExecution(outc, errc, ctx.reporter, exit)
 	}
-	rep := inst.MonitorExecution(outc, errc, ctx.reporter,
-		vm.ExitTimeout|vm.ExitNormal|vm.ExitError)
 	if rep == nil {
 		ctx.reproLog(2, "program did not crash")
-		return false, nil
+		return nil, nil
 	}
 	if rep.Suppressed {
 		ctx.reproLog(2, "suppressed program crash: %v", rep.Title)
-		return false, nil
+		return nil, nil
 	}
 	if ctx.crashType == report.MemoryLeak && rep.Type != report.MemoryLeak {
 		ctx.reproLog(2, "not a leak crash: %v", rep.Title)
-		return false, nil
+		return nil, nil
+	}
+	if ctx.crashType == report.MemoryLeak && !ctx.leaksSameStack(rep) {
+		ctx.reproLog(2, "leaked object has a different allocation stack: %v", rep.Title)
+		return nil, nil
+	}
+	if ctx.crashType == report.Hang && rep.Type != report.Hang {
+		// Other crashes will most likely prevent us from observing the hang,
+		// so they are not a proof that the program reproduces it.
+		ctx.reproLog(2, "not a hang: %v", rep.Title)
+		return nil, nil
+	}
+	if !ctx.preservesCrash(rep) {
+		ctx.reproLog(2, "crash does not match the original crash: %v", rep.Title)
+		return nil, nil
 	}
-	ctx.report = rep
 	ctx.reproLog(2, "program crashed: %v", rep.Title)
-	return true, nil
+	return rep, nil
+}
+
+// saveReport returns a function that restores ctx.report to the current value.
+// It is used by auxiliary runs of the final reproducer that must not change the final report.
+func (ctx *context) saveReport() func() {
+	ctx.mu.Lock()
+	rep := ctx.report
+	ctx.mu.Unlock()
+	return func() {
+		ctx.mu.Lock()
+		ctx.report = rep
+		ctx.mu.Unlock()
+	}
+}
+
+// preservesCrash returns true if rep is acceptable according to ctx.preserve mode.
+func (ctx *context) preservesCrash(rep *report.Report) bool {
+	switch ctx.preserve {
+	case "title":
+		return rep.Title == ctx.crashTitle
+	case "class":
+		return rep.Type == ctx.crashType && report.SameClass(rep.Title, ctx.crashTitle)
+	default:
+		return true
+	}
 }
 
 func (ctx *context) returnInstance(inst *instance) {
@@ -638,7 +843,9 @@ func (ctx *context) returnInstance(inst *instance) {
 func (ctx *context) reproLog(level int, format string, args ...interface{}) {
 	prefix := fmt.Sprintf("reproducing crash '%v': ", ctx.crashTitle)
 	log.Logf(level, prefix+format, args...)
+	ctx.mu.Lock()
 	ctx.stats.Log = append(ctx.stats.Log, []byte(fmt.Sprintf(format, args...)+"\n")...)
+	ctx.mu.Unlock()
 }
 
 func (ctx *context) bisectProgs(progs []*prog.LogEntry, pred func([]*prog.LogEntry) (bool, error)) (
@@ -894,4 +1101,12 @@ var cSimplifies = append(progSimplifies, []Simplify{
 		opts.HandleSegv = false
 		return true
 	},
+	func(opts *csource.Options) bool {
+		// Not really a simplification of the program, but of the resulting C source.
+		if opts.Readable {
+			return false
+		}
+		opts.Readable = true
+		return true
+	},
 }...)
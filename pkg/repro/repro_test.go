@@ -4,12 +4,19 @@
 package repro
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/report"
 	"github.com/google/syzkaller/prog"
 )
 
@@ -105,3 +112,381 @@ func TestSimplifies(t *testing.T) {
 	}
 	check(opts, 0)
 }
+
+func TestOptsCombinations(t *testing.T) {
+	ctx := &context{
+		cfg: &mgrconfig.Config{TargetOS: "linux"},
+	}
+	base := csource.Options{
+		Threaded:       true,
+		Collide:        true,
+		Repeat:         true,
+		Procs:          4,
+		Sandbox:        "none",
+		EnableTun:      true,
+		EnableNetDev:   true,
+		EnableNetReset: true,
+		EnableCgroups:  true,
+		UseTmpDir:      true,
+		HandleSegv:     true,
+		Repro:          true,
+	}
+	p := &prog.Prog{Calls: make([]*prog.Call, 5)}
+	combinations := ctx.optsCombinations(p, base)
+	if len(combinations) == 0 {
+		t.Fatalf("no combinations")
+	}
+	// Base options were already tried, the most distinguishing single dimension goes first.
+	first := base
+	first.Collide = false
+	if combinations[0].Opts != first {
+		t.Fatalf("first combination is not threaded base opts: %v", combinations[0].Strategy)
+	}
+	// Default variants of dimensions in the order of decreasing importance.
+	defaults := []string{"threaded+collide", "no-fault", "repeat", "sandbox=none", "procs=4"}
+	prevDeviate := []int{}
+	dedup := map[csource.Options]bool{base: true}
+	faults := make(map[int]bool)
+	for _, comb := range combinations {
+		var deviate []int
+		for i, name := range strings.Split(comb.Strategy, " ") {
+			if name != defaults[i] {
+				deviate = append(deviate, i)
+			}
+		}
+		if len(deviate) < len(prevDeviate) ||
+			len(deviate) == len(prevDeviate) && len(deviate) != 0 && deviate[0] < prevDeviate[0] {
+			t.Fatalf("combination %v goes after a less likely combination", comb.Strategy)
+		}
+		prevDeviate = deviate
+		if err := comb.Opts.Check("linux"); err != nil {
+			t.Fatalf("combination %v is invalid: %v", comb.Strategy, err)
+		}
+		if dedup[comb.Opts] {
+			t.Fatalf("duplicate combination %v", comb.Strategy)
+		}
+		dedup[comb.Opts] = true
+		if comb.Opts.Fault {
+			faults[comb.Opts.FaultCall] = true
+		}
+	}
+	if len(faults) != optsSearchFaultCalls || !faults[4] || faults[1] {
+		t.Fatalf("wrong fault calls: %v", faults)
+	}
+}
+
+func TestInterleaveCombinations(t *testing.T) {
+	comb := func(s string) *Result { return &Result{Strategy: s} }
+	res := interleaveCombinations([][]*Result{
+		{comb("a0"), comb("a1"), comb("a2")},
+		{comb("b0")},
+		{comb("c0"), comb("c1")},
+	})
+	var got []string
+	for _, r := range res {
+		got = append(got, r.Strategy)
+	}
+	if want := "a0 b0 c0 a1 c1 a2"; strings.Join(got, " ") != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		v   Validation
+		rel float64
+		str string
+	}{
+		{Validation{}, 0, "0/0 (0%)"},
+		{Validation{Runs: 5, Crashed: 5}, 1, "5/5 (100%)"},
+		{Validation{Runs: 5, Crashed: 2}, 0.4, "2/5 (40%)"},
+		{Validation{Runs: 3, Crashed: 0}, 0, "0/3 (0%)"},
+	}
+	for _, test := range tests {
+		if rel := test.v.Reliability(); rel != test.rel {
+			t.Errorf("%+v: got reliability %v, want %v", test.v, rel, test.rel)
+		}
+		if str := test.v.String(); str != test.str {
+			t.Errorf("%+v: got %q, want %q", test.v, str, test.str)
+		}
+	}
+}
+
+func TestPreservesCrash(t *testing.T) {
+	orig := &report.Report{Title: "KASAN: use-after-free Read in foo", Type: report.Unknown}
+	tests := []struct {
+		rep   *report.Report
+		title bool
+		class bool
+	}{
+		{orig, true, true},
+		{&report.Report{Title: "KASAN: use-after-free Read in bar"}, false, true},
+		{&report.Report{Title: "KASAN: use-after-free Write in foo"}, false, false},
+		{&report.Report{Title: "WARNING in foo"}, false, false},
+		{&report.Report{Title: "KASAN: use-after-free Read in bar", Type: report.Hang}, false, false},
+	}
+	for _, test := range tests {
+		for _, mode := range []string{"", "title", "class"} {
+			ctx := &context{
+				crashTitle: orig.Title,
+				crashType:  orig.Type,
+				preserve:   mode,
+			}
+			want := mode == "" || mode == "title" && test.title || mode == "class" && test.class
+			if got := ctx.preservesCrash(test.rep); got != want {
+				t.Errorf("mode %q, title %q: got %v, want %v", mode, test.rep.Title, got, want)
+			}
+		}
+	}
+}
+
+func TestParallelTest(t *testing.T) {
+	ctx := &context{
+		numVMs: 4,
+		stats:  new(Stats),
+	}
+	var mu sync.Mutex
+	started := make(map[int]bool)
+	results, err := ctx.parallelTest(100, true, func(i int) (*report.Report, error) {
+		mu.Lock()
+		started[i] = true
+		mu.Unlock()
+		if i == 10 {
+			// Let the later crash finish first, the report must still be taken from test 10.
+			time.Sleep(100 * time.Millisecond)
+		}
+		return testReport(i == 10 || i == 12, fmt.Sprintf("crash %v", i)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := firstCrashed(results); first != 10 {
+		t.Fatalf("first crashed %v, want 10", first)
+	}
+	if ctx.report == nil || ctx.report.Title != "crash 10" {
+		t.Fatalf("report %+v, want the report of test 10", ctx.report)
+	}
+	if len(started) > 10+1+ctx.numVMs {
+		t.Fatalf("started %v tests after the crash", len(started))
+	}
+	results, err = ctx.parallelTest(20, false, func(i int) (*report.Report, error) {
+		return testReport(i%2 == 0, "crash"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, crashed := range results {
+		if crashed != (i%2 == 0) {
+			t.Fatalf("test %v: crashed %v", i, crashed)
+		}
+	}
+	_, err = ctx.parallelTest(20, false, func(i int) (*report.Report, error) {
+		if i == 5 {
+			return nil, fmt.Errorf("test failed")
+		}
+		return nil, nil
+	})
+	if err == nil {
+		t.Fatalf("no error")
+	}
+}
+
+func TestParallelSimplify(t *testing.T) {
+	ctx := &context{
+		numVMs: 4,
+		stats:  new(Stats),
+	}
+	opts := csource.Options{
+		Threaded:      true,
+		Collide:       true,
+		Repeat:        true,
+		Procs:         4,
+		Sandbox:       "none",
+		EnableTun:     true,
+		EnableNetDev:  true,
+		EnableCgroups: true,
+		UseTmpDir:     true,
+		HandleSegv:    true,
+	}
+	// The crash needs tun and either collide or cgroups, but not both of them disabled.
+	test := func(opts csource.Options) (*report.Report, error) {
+		return testReport(opts.EnableTun && (opts.Collide || opts.EnableCgroups), "crash"), nil
+	}
+	res, err := ctx.parallelSimplify(opts, cSimplifies, test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep, _ := test(res); rep == nil {
+		t.Fatalf("simplified opts don't crash: %+v", res)
+	}
+	if res.EnableNetDev || res.HandleSegv || !res.Readable {
+		t.Fatalf("opts are not simplified: %+v", res)
+	}
+}
+
+func testReport(crashed bool, title string) *report.Report {
+	if !crashed {
+		return nil
+	}
+	return &report.Report{Title: title}
+}
+
+func TestSameLeakStack(t *testing.T) {
+	tests := []struct {
+		orig []string
+		leak []string
+		same bool
+	}{
+		{[]string{"foo", "bar"}, []string{"foo", "bar"}, true},
+		{[]string{"foo", "bar"}, []string{"foo", "bar", "baz"}, true},
+		{[]string{"foo", "bar", "baz"}, []string{"foo", "bar"}, false},
+		{[]string{"foo", "bar"}, []string{"foo", "baz"}, false},
+		{[]string{"a", "b", "c", "d", "e"}, []string{"a", "b", "c", "d", "f"}, true},
+		{[]string{"foo"}, nil, false},
+	}
+	for i, test := range tests {
+		if same := sameLeakStack(test.orig, test.leak); same != test.same {
+			t.Errorf("test #%v: %q vs %q: want %v, got %v", i, test.orig, test.leak, test.same, same)
+		}
+	}
+}
+
+func TestAnnotated(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+r0 = test$res0()
+test$res1(r0)
+test$res1(r0)
+`), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &Result{Prog: p, TriggerCall: 1}
+	annotated, data := res.Annotated()
+	want := "# setup\nr0 = test$res0()\n# triggers the crash\ntest$res1(r0)\ntest$res1(r0)\n"
+	if string(data) != want {
+		t.Fatalf("want:\n%v\ngot:\n%s", want, data)
+	}
+	if annotated.Calls[1].Comment != TriggerComment || p.Calls[1].Comment != "" {
+		t.Fatalf("bad call comments")
+	}
+	p1, err := target.Deserialize(data, prog.Strict)
+	if err != nil {
+		t.Fatalf("failed to parse annotated program: %v", err)
+	}
+	if p1.Calls[0].Comment != SetupComment || p1.Calls[1].Comment != TriggerComment {
+		t.Fatalf("comments are lost after deserialization")
+	}
+	res.TriggerCall = -1
+	if _, data = res.Annotated(); string(data) != string(p.Serialize()) {
+		t.Fatalf("unknown trigger call: got annotations:\n%s", data)
+	}
+}
+
+func TestNormalizeLog(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("2020-01-02T03:04:05.678Z [   12.345678][ T1234] kernel: booted\r\n" +
+		"2020-01-02T03:04:05.679Z 2020/01/02 03:04:05 executing program 1:\r\n" +
+		"2020-01-02T03:04:05.680Z r0 = test$res0()\r\n" +
+		"2020-01-02T03:04:05.681Z [   12.345679][ T1234] kernel: interleaved output\r\n" +
+		"2020-01-02T03:04:05.682Z   test$res1(r0)\r\n" +
+		"<6>[   13.000000] executing program 0:\n" +
+		"<6>[   13.000001] test$res1(0x1)\n" +
+		"<6>[   13.000002] test$res1(0x")
+	entries := target.ParseLog(data)
+	if len(entries) != 0 {
+		t.Fatalf("raw log unexpectedly contains programs")
+	}
+	log := NormalizeLog(data)
+	if !bytes.Contains(log, []byte("\n[   12.345679][ T1234] kernel: interleaved output\n")) {
+		t.Errorf("kernel output is not preserved:\n%s", log)
+	}
+	entries = target.ParseLog(log)
+	want := []struct {
+		proc int
+		prog string
+	}{
+		{1, "r0 = test$res0()\ntest$res1(r0)\n"},
+		{0, "test$res1(0x1)\n"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("want %v programs, got %v:\n%s", len(want), len(entries), log)
+	}
+	for i, ent := range entries {
+		if ent.Proc != want[i].proc || string(ent.P.Serialize()) != want[i].prog {
+			t.Errorf("program #%v: want proc %v:\n%v\ngot proc %v:\n%s",
+				i, want[i].proc, want[i].prog, ent.Proc, ent.P.Serialize())
+		}
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte("r0 = test$res0()\ntest$res1(r0)\n"), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workdir, err := ioutil.TempDir("", "syz-repro-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+	newContext := func() *context {
+		return &context{
+			cfg:        &mgrconfig.Config{Workdir: workdir},
+			crashTitle: "WARNING in foo",
+			stats:      new(Stats),
+		}
+	}
+	crashLog := []byte("executing program 0:\ntest$res0()\n")
+	ctx := newContext()
+	ctx.loadCheckpoint(crashLog)
+	if ctx.resumed(StageExtracted) {
+		t.Fatalf("new session is resumed")
+	}
+	opts := csource.Options{Threaded: true, Procs: 2, Sandbox: "none"}
+	ctx.checkpoint.ExtractTimeouts = 1
+	ctx.completeStage(StageExtracted, &Result{Prog: p, Opts: opts, Duration: time.Minute})
+
+	ctx = newContext()
+	ctx.loadCheckpoint(crashLog)
+	if !ctx.resumed(StageExtracted) || ctx.resumed(StageMinimized) {
+		t.Fatalf("bad resumed stage %q", ctx.checkpoint.Stage)
+	}
+	if ctx.checkpoint.ExtractTimeouts != 1 {
+		t.Fatalf("extraction progress is lost")
+	}
+	res := ctx.restoreResult(target)
+	if res == nil || string(res.Prog.Serialize()) != string(p.Serialize()) ||
+		res.Opts != opts || res.Duration != time.Minute {
+		t.Fatalf("bad restored result %+v", res)
+	}
+	if cp := ReadCheckpoint(workdir, "WARNING in foo"); cp == nil || cp.Stage != StageExtracted {
+		t.Fatalf("bad checkpoint %+v", cp)
+	}
+	cps := Checkpoints(workdir)
+	if len(cps) != 1 || string(cps[0].Log) != string(crashLog) {
+		t.Fatalf("bad checkpoints %+v", cps)
+	}
+
+	// Extraction progress is not reused for a different log.
+	ctx = newContext()
+	ctx.loadCheckpoint(append(crashLog, "test$res1(0x0)\n"...))
+	if !ctx.resumed(StageExtracted) || ctx.checkpoint.ExtractTimeouts != 0 {
+		t.Fatalf("bad checkpoint for a different log %+v", ctx.checkpoint)
+	}
+
+	ctx.removeCheckpoint()
+	if cps := Checkpoints(workdir); len(cps) != 0 {
+		t.Fatalf("checkpoint is not removed")
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/prog"
+)
+
+const (
+	// Total time budget for the systematic options search.
+	optsSearchBudget = 30 * time.Minute
+	// Number of last programs that we try with all option combinations.
+	optsSearchProgs = 3
+	// Max number of calls (counting from the end) to inject faults into.
+	optsSearchFaultCalls = 3
+	// Max nth fault to inject into each call.
+	optsSearchFaultNth = 3
+)
+
+// optsVariant is a named set of options for a single dimension of the search space.
+type optsVariant struct {
+	name  string
+	apply func(opts *csource.Options)
+}
+
+// optsCombinations returns option combinations to try for program p in order of decreasing
+// likelihood: combinations that differ from base options in fewer dimensions go first,
+// and among them the ones that differ in the most distinguishing dimensions
+// (execution mode, fault injection, repeat, sandbox, procs, in this order).
+// Base options are not included, they were already tried by extractProgSingle.
+func (ctx *context) optsCombinations(p *prog.Prog, base csource.Options) []*Result {
+	sandboxes := []optsVariant{{"sandbox=" + base.Sandbox, func(opts *csource.Options) {}}}
+	for _, sandbox := range []string{"none", "setuid", "namespace"} {
+		if sandbox == base.Sandbox {
+			continue
+		}
+		sandbox := sandbox
+		sandboxes = append(sandboxes, optsVariant{"sandbox=" + sandbox, func(opts *csource.Options) {
+			opts.Sandbox = sandbox
+			if sandbox == "setuid" {
+				opts.EnableNetReset = false
+			}
+		}})
+	}
+	modes := []optsVariant{
+		{"threaded+collide", func(opts *csource.Options) { opts.Threaded, opts.Collide = true, true }},
+		{"threaded", func(opts *csource.Options) { opts.Threaded, opts.Collide = true, false }},
+		{"sequential", func(opts *csource.Options) { opts.Threaded, opts.Collide = false, false }},
+	}
+	repeats := []optsVariant{
+		{"repeat", func(opts *csource.Options) { opts.Repeat = true }},
+		{"once", func(opts *csource.Options) {
+			opts.Repeat = false
+			opts.EnableCgroups = false
+			opts.EnableNetReset = false
+			opts.Procs = 1
+		}},
+	}
+	procs := []optsVariant{{fmt.Sprintf("procs=%v", base.Procs), func(opts *csource.Options) {}}}
+	if base.Procs > 1 {
+		procs = append(procs, optsVariant{"procs=1", func(opts *csource.Options) { opts.Procs = 1 }})
+	}
+	faults := []optsVariant{{"no-fault", func(opts *csource.Options) { opts.Fault = false }}}
+	for call := len(p.Calls) - 1; call >= 0 && call >= len(p.Calls)-optsSearchFaultCalls; call-- {
+		for nth := 0; nth < optsSearchFaultNth; nth++ {
+			call, nth := call, nth
+			faults = append(faults, optsVariant{fmt.Sprintf("fault=%v/%v", call, nth),
+				func(opts *csource.Options) {
+					opts.Fault = true
+					opts.FaultCall = call
+					opts.FaultNth = nth
+				}})
+		}
+	}
+	// Dimensions in the order of decreasing importance, the first variant of each is the default.
+	dims := [][]optsVariant{modes, faults, repeats, sandboxes, procs}
+	type combination struct {
+		res     *Result
+		deviate []int // indexes of dimensions that use non-default variants
+	}
+	var combs []combination
+	dedup := map[string]bool{string(base.Serialize()): true}
+	variants := make([]int, len(dims))
+	for {
+		opts := base
+		var names []string
+		var deviate []int
+		for dim, variant := range variants {
+			v := dims[dim][variant]
+			v.apply(&opts)
+			names = append(names, v.name)
+			if variant != 0 {
+				deviate = append(deviate, dim)
+			}
+		}
+		key := string(opts.Serialize())
+		if opts.Check(ctx.cfg.TargetOS) == nil && !dedup[key] {
+			dedup[key] = true
+			combs = append(combs, combination{
+				res: &Result{
+					Prog:     p,
+					Opts:     opts,
+					Strategy: strings.Join(names, " "),
+				},
+				deviate: deviate,
+			})
+		}
+		// Advance to the next combination of variants.
+		dim := len(dims) - 1
+		for ; dim >= 0; dim-- {
+			if variants[dim]++; variants[dim] < len(dims[dim]) {
+				break
+			}
+			variants[dim] = 0
+		}
+		if dim < 0 {
+			break
+		}
+	}
+	sort.SliceStable(combs, func(i, j int) bool {
+		di, dj := combs[i].deviate, combs[j].deviate
+		if len(di) != len(dj) {
+			return len(di) < len(dj)
+		}
+		for k := range di {
+			if di[k] != dj[k] {
+				return di[k] < dj[k]
+			}
+		}
+		return false
+	})
+	res := make([]*Result, len(combs))
+	for i, comb := range combs {
+		res[i] = comb.res
+	}
+	return res
+}
+
+// interleaveCombinations merges per-program combinations, so that the most likely
+// combinations of all programs are tried before the less likely ones of the first program.
+func interleaveCombinations(perProg [][]*Result) []*Result {
+	var res []*Result
+	for i := 0; ; i++ {
+		added := false
+		for _, combs := range perProg {
+			if i < len(combs) {
+				res = append(res, combs[i])
+				added = true
+			}
+		}
+		if !added {
+			return res
+		}
+	}
+}
+
+// searchOpts systematically explores option combinations on the last programs
+// from the log within optsSearchBudget. This catches crashes that require
+// specific sandbox, threading mode or fault injection that the log does not record.
+func (ctx *context) searchOpts(entries []*prog.LogEntry) (*Result, error) {
+	if len(entries) > optsSearchProgs {
+		entries = entries[:optsSearchProgs]
+	}
+	start := time.Now()
+	defer func() {
+		ctx.stats.SearchOptsTime = time.Since(start)
+	}()
+	duration := ctx.timeouts[len(ctx.timeouts)-1]
+	base := csource.DefaultOpts(ctx.cfg)
+	if ctx.crashType == report.MemoryLeak {
+		base.Leak = true
+	}
+	var perProg [][]*Result
+	for _, ent := range entries {
+		perProg = append(perProg, ctx.optsCombinations(ent.P, base))
+	}
+	combinations := interleaveCombinations(perProg)
+	ctx.reproLog(2, "searching options: %v combinations on %v programs, budget %v",
+		len(combinations), len(entries), optsSearchBudget)
+	results, err := ctx.parallelTest(len(combinations), true, func(i int) (*report.Report, error) {
+		if time.Since(start) > optsSearchBudget {
+			return nil, nil
+		}
+		comb := combinations[i]
+		ctx.reproLog(3, "options search: trying %v", comb.Strategy)
+		return ctx.runProg(comb.Prog, duration, comb.Opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if i := firstCrashed(results); i != -1 {
+		comb := combinations[i]
+		comb.Duration = duration * 3 / 2
+		ctx.reproLog(2, "options search: reproduced with %v", comb.Strategy)
+		return comb, nil
+	}
+	if time.Since(start) > optsSearchBudget {
+		ctx.reproLog(2, "options search: budget exhausted")
+	}
+	ctx.reproLog(2, "options search: failed to reproduce")
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/prog"
+)
+
+// Call comments used to annotate reproducers (see Result.Annotated).
+const (
+	SetupComment   = "setup"
+	TriggerComment = "triggers the crash"
+)
+
+// findTriggerCall returns index of the call that triggers the crash.
+// The triggering call is the last call of the shortest program prefix
+// that still crashes, all calls before it are considered setup.
+// If no proper prefix crashes, the last call is the triggering one.
+func (ctx *context) findTriggerCall(res *Result) (int, error) {
+	last := len(res.Prog.Calls) - 1
+	if last <= 0 {
+		return last, nil
+	}
+	ctx.reproLog(2, "searching for the triggering call among %v calls", last+1)
+	start := time.Now()
+	defer func() {
+		ctx.stats.TriggerCallTime = time.Since(start)
+	}()
+	defer ctx.saveReport()()
+	results, err := ctx.parallelTest(last, true, func(i int) (*report.Report, error) {
+		opts := res.Opts
+		if opts.Fault && opts.FaultCall > i {
+			// The fault is injected into a call that is not part of the prefix.
+			return nil, nil
+		}
+		p := res.Prog.Clone()
+		for len(p.Calls) > i+1 {
+			p.RemoveCall(len(p.Calls) - 1)
+		}
+		if res.CRepro {
+			return ctx.runCProg(p, res.Duration, opts)
+		}
+		return ctx.runProg(p, res.Duration, opts)
+	})
+	if err != nil {
+		return 0, err
+	}
+	trigger := last
+	if idx := firstCrashed(results); idx != -1 {
+		trigger = idx
+	}
+	ctx.reproLog(2, "call #%v (%v) triggers the crash", trigger, res.Prog.Calls[trigger].Meta.Name)
+	return trigger, nil
+}
+
+// Annotated returns a copy of the reproducer program with call comments that mark
+// the start of setup calls and the call that triggers the crash (see SetupComment
+// and TriggerComment), along with the serialized program that includes the comments.
+func (res *Result) Annotated() (*prog.Prog, []byte) {
+	p := res.Prog.Clone()
+	if res.TriggerCall < 0 || res.TriggerCall >= len(p.Calls) {
+		return p, p.Serialize()
+	}
+	if res.TriggerCall > 0 {
+		p.Calls[0].Comment = SetupComment
+	}
+	p.Calls[res.TriggerCall].Comment = TriggerComment
+	buf := new(bytes.Buffer)
+	// Serialize emits exactly one line per call.
+	for i, line := range bytes.SplitAfter(p.Serialize(), []byte("\n")) {
+		if i < len(p.Calls) && p.Calls[i].Comment != "" {
+			fmt.Fprintf(buf, "# %v\n", p.Calls[i].Comment)
+		}
+		buf.Write(line)
+	}
+	return p, buf.Bytes()
+}
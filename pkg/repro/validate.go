@@ -0,0 +1,95 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/vm"
+)
+
+// Number of times the final reproducer is executed to estimate its reliability.
+const validateRuns = 5
+
+// Validation holds results of repeated runs of a reproducer.
+// Each run happens on a freshly booted VM.
+type Validation struct {
+	Runs    int
+	Crashed int
+}
+
+// Reliability returns the estimated probability that a single run of the reproducer triggers the crash.
+func (v *Validation) Reliability() float64 {
+	if v.Runs == 0 {
+		return 0
+	}
+	return float64(v.Crashed) / float64(v.Runs)
+}
+
+func (v *Validation) String() string {
+	return fmt.Sprintf("%v/%v (%.0f%%)", v.Crashed, v.Runs, v.Reliability()*100)
+}
+
+// Validate re-runs an existing reproducer res on VMs with the given indexes
+// and estimates its reliability. It is used to re-validate stored reproducers
+// (e.g. after kernel updates). res.Report is used only to identify the crash.
+func Validate(res *Result, cfg *mgrconfig.Config, reporter report.Reporter, vmPool *vm.Pool,
+	vmIndexes []int) (*Validation, *Stats, error) {
+	if len(vmIndexes) == 0 {
+		return nil, nil, fmt.Errorf("no VMs provided")
+	}
+	preserve := ""
+	if res.Report.Title != "" {
+		preserve = cfg.ReproPreserveCrash
+	}
+	ctx := &context{
+		cfg:          cfg,
+		reporter:     reporter,
+		crashTitle:   res.Report.Title,
+		crashType:    res.Report.Type,
+		preserve:     preserve,
+		leakStack:    report.LeakStack(res.Report),
+		instances:    make(chan *instance, len(vmIndexes)),
+		bootRequests: make(chan int, len(vmIndexes)),
+		numVMs:       len(vmIndexes),
+		stats:        new(Stats),
+	}
+	stop := ctx.createInstances(vmPool, vmIndexes)
+	defer stop()
+	v, err := ctx.validate(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, ctx.stats, nil
+}
+
+func (ctx *context) validate(res *Result) (*Validation, error) {
+	ctx.reproLog(2, "validating reproducer with %v runs", validateRuns)
+	start := time.Now()
+	defer func() {
+		ctx.stats.ValidateTime = time.Since(start)
+	}()
+	// Validation runs must not change the final report.
+	defer ctx.saveReport()()
+	results, err := ctx.parallelTest(validateRuns, false, func(int) (*report.Report, error) {
+		if res.CRepro {
+			return ctx.runCProg(res.Prog, res.Duration, res.Opts)
+		}
+		return ctx.runProg(res.Prog, res.Duration, res.Opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	v := &Validation{Runs: validateRuns}
+	for _, crashed := range results {
+		if crashed {
+			v.Crashed++
+		}
+	}
+	ctx.reproLog(2, "validation: reproducer crashed %v", v)
+	return v, nil
+}
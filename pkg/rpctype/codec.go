@@ -0,0 +1,271 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpctype
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net/rpc"
+)
+
+// The RPC codec sends every request/response as a single length-prefixed frame:
+//	uint32 (big endian) payload length
+//	uint8 payload encoding (frameRaw/frameFlate)
+//	payload: gob-encoded header and body
+// Gob encoder/decoder persist across frames, so type descriptions are sent only once per connection.
+// Compared to compressing the whole gob stream (what the legacy protocol does), this allows
+// to compress only large messages with a fast level, to reuse buffers across messages,
+// and to reject corrupted or malicious frames before allocating memory for them.
+// The encoding byte allows to add other compression algorithms later (e.g. zstd).
+//
+// Connections start with codecMagic sent by the client and echoed back by the server.
+// The first byte of the magic is an invalid flate block header, so servers that speak only
+// the legacy protocol drop such connections right away and the client falls back to the legacy protocol.
+// TODO: remove the legacy protocol support after the next release.
+
+const (
+	frameRaw   = 0
+	frameFlate = 1
+
+	// Frames larger than this are compressed.
+	compressThreshold = 4 << 10
+	// Max size of a frame (both compressed and uncompressed).
+	maxFrameSize = 512 << 20
+	// Read buffers larger than this are not retained between messages.
+	maxRetainedBuffer = 1 << 20
+)
+
+var codecMagic = []byte("\xffSYZRPC2")
+
+type codec struct {
+	conn io.ReadWriteCloser
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	enc     *gob.Encoder
+	encBuf  bytes.Buffer
+	flateW  *flate.Writer
+	flateWb bytes.Buffer
+
+	dec    *gob.Decoder
+	frame  frameReader
+	readB  []byte
+	flateR io.ReadCloser
+	inflB  bytes.Buffer
+}
+
+// frameReader feeds the current frame to the gob decoder.
+// It implements io.ByteReader so that gob does not add own buffering on top.
+type frameReader struct {
+	bytes.Reader
+}
+
+func newCodec(conn io.ReadWriteCloser, r *bufio.Reader) *codec {
+	if r == nil {
+		r = bufio.NewReader(conn)
+	}
+	c := &codec{
+		conn: conn,
+		r:    r,
+		w:    bufio.NewWriter(conn),
+	}
+	c.enc = gob.NewEncoder(&c.encBuf)
+	c.dec = gob.NewDecoder(&c.frame)
+	return c
+}
+
+func (c *codec) writeFrame(header, body interface{}) error {
+	c.encBuf.Reset()
+	if err := c.enc.Encode(header); err != nil {
+		return err
+	}
+	if err := c.enc.Encode(body); err != nil {
+		return err
+	}
+	payload, encoding := c.encBuf.Bytes(), byte(frameRaw)
+	if len(payload) >= compressThreshold {
+		c.flateWb.Reset()
+		if c.flateW == nil {
+			var err error
+			if c.flateW, err = flate.NewWriter(&c.flateWb, flate.BestSpeed); err != nil {
+				return err
+			}
+		} else {
+			c.flateW.Reset(&c.flateWb)
+		}
+		if _, err := c.flateW.Write(payload); err != nil {
+			return err
+		}
+		if err := c.flateW.Close(); err != nil {
+			return err
+		}
+		if c.flateWb.Len() < len(payload) {
+			payload, encoding = c.flateWb.Bytes(), frameFlate
+		}
+	}
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("rpc message is too large: %v bytes", len(payload))
+	}
+	var hdr [5]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)))
+	hdr[4] = encoding
+	if _, err := c.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(payload); err != nil {
+		return err
+	}
+	err := c.w.Flush()
+	if c.encBuf.Cap() > maxRetainedBuffer {
+		c.encBuf = bytes.Buffer{}
+		c.flateWb = bytes.Buffer{}
+	}
+	return err
+}
+
+func (c *codec) readFrame() error {
+	var hdr [5]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(hdr[:])
+	if size > maxFrameSize {
+		return fmt.Errorf("rpc frame is too large: %v bytes", size)
+	}
+	if cap(c.readB) < int(size) {
+		c.readB = make([]byte, size)
+	}
+	data := c.readB[:size]
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return err
+	}
+	if cap(c.readB) > maxRetainedBuffer {
+		c.readB = nil
+	}
+	switch hdr[4] {
+	case frameRaw:
+	case frameFlate:
+		if c.flateR == nil {
+			c.flateR = flate.NewReader(bytes.NewReader(data))
+		} else if err := c.flateR.(flate.Resetter).Reset(bytes.NewReader(data), nil); err != nil {
+			return err
+		}
+		c.inflB.Reset()
+		if _, err := c.inflB.ReadFrom(io.LimitReader(c.flateR, maxFrameSize+1)); err != nil {
+			return fmt.Errorf("corrupted rpc frame: %v", err)
+		}
+		if c.inflB.Len() > maxFrameSize {
+			return fmt.Errorf("rpc frame is too large after decompression")
+		}
+		data = c.inflB.Bytes()
+	default:
+		return fmt.Errorf("unknown rpc frame encoding %v", hdr[4])
+	}
+	c.frame.Reset(data)
+	return nil
+}
+
+func (c *codec) readHeader(header interface{}) error {
+	if err := c.readFrame(); err != nil {
+		return err
+	}
+	return c.dec.Decode(header)
+}
+
+func (c *codec) readBody(body interface{}) error {
+	// Decode(nil) discards the body.
+	err := c.dec.Decode(body)
+	if c.inflB.Cap() > maxRetainedBuffer {
+		c.inflB = bytes.Buffer{}
+	}
+	return err
+}
+
+func (c *codec) Close() error {
+	return c.conn.Close()
+}
+
+type serverCodec struct {
+	*codec
+}
+
+func (c serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	return c.readHeader(r)
+}
+
+func (c serverCodec) ReadRequestBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+func (c serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	return c.writeFrame(r, body)
+}
+
+type clientCodec struct {
+	*codec
+}
+
+func (c clientCodec) WriteRequest(r *rpc.Request, body interface{}) error {
+	return c.writeFrame(r, body)
+}
+
+func (c clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	return c.readHeader(r)
+}
+
+func (c clientCodec) ReadResponseBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+// serverHandshake determines protocol of a new server connection.
+// It returns the codec for new clients, and a connection for the legacy protocol otherwise.
+func serverHandshake(conn io.ReadWriteCloser) (rpc.ServerCodec, io.ReadWriteCloser, error) {
+	r := bufio.NewReader(conn)
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if first[0] != codecMagic[0] {
+		return nil, &bufferedConn{r, conn}, nil
+	}
+	magic := make([]byte, len(codecMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(magic, codecMagic) {
+		return nil, nil, fmt.Errorf("bad rpc protocol magic %q", magic)
+	}
+	if _, err := conn.Write(codecMagic); err != nil {
+		return nil, nil, err
+	}
+	return serverCodec{newCodec(conn, r)}, nil, nil
+}
+
+// clientHandshake returns nil codec if the server does not support the new protocol.
+func clientHandshake(conn io.ReadWriteCloser) rpc.ClientCodec {
+	if _, err := conn.Write(codecMagic); err != nil {
+		return nil
+	}
+	r := bufio.NewReader(conn)
+	magic := make([]byte, len(codecMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, codecMagic) {
+		return nil
+	}
+	return clientCodec{newCodec(conn, r)}
+}
+
+// bufferedConn returns data that was already read into the bufio.Reader during handshake.
+type bufferedConn struct {
+	r *bufio.Reader
+	io.ReadWriteCloser
+}
+
+func (bc *bufferedConn) Read(data []byte) (int, error) {
+	return bc.r.Read(data)
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpctype
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"testing"
+)
+
+type EchoReceiver struct{}
+
+type EchoArgs struct {
+	Data []byte
+	Seq  []uint32
+}
+
+func (EchoReceiver) Echo(a *EchoArgs, r *EchoArgs) error {
+	*r = *a
+	return nil
+}
+
+func echoArgsOfSize(size int) *EchoArgs {
+	rnd := rand.New(rand.NewSource(int64(size)))
+	a := &EchoArgs{
+		Data: bytes.Repeat([]byte("syzkaller"), size/9),
+	}
+	for i := 0; i < size/4; i++ {
+		a.Seq = append(a.Seq, rnd.Uint32())
+	}
+	return a
+}
+
+func testEcho(t *testing.T, cli *rpc.Client) {
+	for _, size := range []int{0, 10, compressThreshold, 1 << 20, 10} {
+		a := echoArgsOfSize(size)
+		r := new(EchoArgs)
+		if err := cli.Call("Test.Echo", a, r); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a.Data, r.Data) || len(a.Seq) != len(r.Seq) {
+			t.Fatalf("echo mismatch for size %v", size)
+		}
+		for i := range a.Seq {
+			if a.Seq[i] != r.Seq[i] {
+				t.Fatalf("echo mismatch for size %v", size)
+			}
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	serv, err := NewRPCServer("127.0.0.1:0", "Test", EchoReceiver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serv.Serve()
+	addr := serv.Addr().String()
+
+	cli, err := NewRPCClient(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEcho(t, cli.c)
+	cli.Close()
+	if legacyAddrs[addr] {
+		t.Fatalf("fell back to the legacy protocol")
+	}
+
+	// Legacy client against the new server.
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyCli := rpc.NewClient(newFlateConn(conn))
+	testEcho(t, legacyCli)
+	legacyCli.Close()
+}
+
+func TestCodecLegacyServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s := rpc.NewServer()
+	if err := s.RegisterName("Test", EchoReceiver{}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.ServeConn(newFlateConn(conn))
+		}
+	}()
+	addr := ln.Addr().String()
+	for i := 0; i < 2; i++ {
+		cli, err := NewRPCClient(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testEcho(t, cli.c)
+		cli.Close()
+	}
+	if !legacyAddrs[addr] {
+		t.Fatalf("legacy server is not remembered")
+	}
+}
+
+func TestCodecCorruptedFrame(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		client.Write([]byte{0xff, 0xff, 0xff, 0xff, frameRaw})
+	}()
+	c := serverCodec{newCodec(server, nil)}
+	var req rpc.Request
+	if err := c.ReadRequestHeader(&req); err == nil {
+		t.Fatalf("no error for a too large frame")
+	}
+}
@@ -5,22 +5,31 @@ package rpctype
 
 import (
 	"compress/flate"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/rpc"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/syzkaller/pkg/log"
 )
 
 type RPCServer struct {
-	ln net.Listener
-	s  *rpc.Server
+	ln  net.Listener
+	s   *rpc.Server
+	tls *tls.Config
 }
 
 func NewRPCServer(addr, name string, receiver interface{}) (*RPCServer, error) {
+	return NewRPCServerTLS(addr, name, receiver, nil)
+}
+
+// NewRPCServerTLS is like NewRPCServer, but serves TLS connections if tlsCfg is not nil.
+func NewRPCServerTLS(addr, name string, receiver interface{}, tlsCfg *tls.Config) (*RPCServer, error) {
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen on %v: %v", addr, err)
@@ -30,8 +39,9 @@ func NewRPCServer(addr, name string, receiver interface{}) (*RPCServer, error) {
 		return nil, err
 	}
 	serv := &RPCServer{
-		ln: ln,
-		s:  s,
+		ln:  ln,
+		s:   s,
+		tls: tlsCfg,
 	}
 	return serv, nil
 }
@@ -44,8 +54,29 @@ func (serv *RPCServer) Serve() {
 			continue
 		}
 		setupKeepAlive(conn, 10*time.Second)
-		go serv.s.ServeConn(newFlateConn(conn))
+		if serv.tls != nil {
+			conn = tls.Server(conn, serv.tls)
+		}
+		go serv.serveConn(conn)
+	}
+}
+
+func (serv *RPCServer) serveConn(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	codec, legacyConn, err := serverHandshake(conn)
+	conn.SetDeadline(time.Time{})
+	if err != nil {
+		if err != io.EOF {
+			log.Logf(0, "rpc handshake with %v failed: %v", conn.RemoteAddr(), err)
+		}
+		conn.Close()
+		return
 	}
+	if codec == nil {
+		serv.s.ServeConn(newFlateConn(legacyConn))
+		return
+	}
+	serv.s.ServeCodec(codec)
 }
 
 func (serv *RPCServer) Addr() net.Addr {
@@ -64,6 +95,14 @@ func Dial(addr string) (net.Conn, error) {
 		// This is used by vm/gvisor which passes us a unix socket connection in stdin.
 		return net.FileConn(os.Stdin)
 	}
+	if strings.HasPrefix(addr, "vsock:") {
+		// This is used by VMs that connect to the manager over virtio-vsock (vm/cloudhypervisor).
+		var cid, port uint32
+		if _, err := fmt.Sscanf(addr, "vsock:%d:%d", &cid, &port); err != nil {
+			return nil, fmt.Errorf("bad vsock address %q: %v", addr, err)
+		}
+		return dialVsock(cid, port)
+	}
 	if conn, err = net.DialTimeout("tcp", addr, 60*time.Second); err != nil {
 		return nil, err
 	}
@@ -72,10 +111,38 @@ func Dial(addr string) (net.Conn, error) {
 }
 
 func NewRPCClient(addr string) (*RPCClient, error) {
-	conn, err := Dial(addr)
+	return NewRPCClientTLS(addr, nil)
+}
+
+// NewRPCClientTLS is like NewRPCClient, but uses TLS if tlsCfg is not nil.
+func NewRPCClientTLS(addr string, tlsCfg *tls.Config) (*RPCClient, error) {
+	legacyMu.Lock()
+	legacy := legacyAddrs[addr]
+	legacyMu.Unlock()
+	conn, err := dialTLS(addr, tlsCfg)
 	if err != nil {
 		return nil, err
 	}
+	if !legacy {
+		conn.SetDeadline(time.Now().Add(60 * time.Second))
+		codec := clientHandshake(conn)
+		conn.SetDeadline(time.Time{})
+		if codec != nil {
+			return &RPCClient{conn, rpc.NewClientWithCodec(codec)}, nil
+		}
+		// The server supports only the legacy protocol, reconnect.
+		conn.Close()
+		if addr == "stdin" {
+			return nil, fmt.Errorf("rpc handshake failed")
+		}
+		log.Logf(0, "%v does not support the new rpc protocol, using the legacy one", addr)
+		legacyMu.Lock()
+		legacyAddrs[addr] = true
+		legacyMu.Unlock()
+		if conn, err = dialTLS(addr, tlsCfg); err != nil {
+			return nil, err
+		}
+	}
 	cli := &RPCClient{
 		conn: conn,
 		c:    rpc.NewClient(newFlateConn(conn)),
@@ -83,6 +150,33 @@ func NewRPCClient(addr string) (*RPCClient, error) {
 	return cli, nil
 }
 
+var (
+	legacyMu    sync.Mutex
+	legacyAddrs = make(map[string]bool) // servers that don't support the new protocol
+)
+
+func dialTLS(addr string, tlsCfg *tls.Config) (net.Conn, error) {
+	conn, err := Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		if tlsCfg.ServerName == "" {
+			tlsCfg = tlsCfg.Clone()
+			tlsCfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tlsConn := tls.Client(conn, tlsCfg)
+		tlsConn.SetDeadline(time.Now().Add(60 * time.Second))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
 func (cli *RPCClient) Call(method string, args, reply interface{}) error {
 	// Note: SetDeadline is not implemented on fuchsia, so don't fail on error.
 	cli.conn.SetDeadline(time.Now().Add(5 * 60 * time.Second))
@@ -95,7 +189,11 @@ func (cli *RPCClient) Close() {
 }
 
 func RPCCall(addr, method string, args, reply interface{}) error {
-	c, err := NewRPCClient(addr)
+	return RPCCallTLS(addr, nil, method, args, reply)
+}
+
+func RPCCallTLS(addr string, tlsCfg *tls.Config, method string, args, reply interface{}) error {
+	c, err := NewRPCClientTLS(addr, tlsCfg)
 	if err != nil {
 		return err
 	}
@@ -108,7 +206,7 @@ func setupKeepAlive(conn net.Conn, keepAlive time.Duration) {
 	conn.(*net.TCPConn).SetKeepAlivePeriod(keepAlive)
 }
 
-// flateConn wraps net.Conn in flate.Reader/Writer for compressed traffic.
+// flateConn wraps net.Conn in flate.Reader/Writer for compressed traffic (the legacy protocol).
 type flateConn struct {
 	r io.ReadCloser
 	w *flate.Writer
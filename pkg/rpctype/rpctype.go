@@ -6,6 +6,8 @@
 package rpctype
 
 import (
+	"time"
+
 	"github.com/google/syzkaller/pkg/host"
 	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/signal"
@@ -35,6 +37,42 @@ type ConnectRes struct {
 	AllSandboxes     bool
 	CheckResult      *CheckArgs
 	MemoryLeakFrames []string
+	// Newly enabled syscalls that have no corpus programs yet.
+	BackfillCalls []int
+	// Sandbox profile to apply to test processes (nil if not used).
+	SandboxProfile     *ipc.SandboxProfile
+	SandboxProfileName string
+	// Identity to use with the setuid sandbox instead of nobody (nil if not used).
+	SandboxIdentity *ipc.SandboxIdentity
+	// Experimental features enabled on this fuzzer (see ExperimentFeatures).
+	Experiments []string
+	// Network namespace setup for test processes (see mgrconfig.Config.NetRecycle/NetDevices).
+	NetRecycle bool
+	NetDevices ipc.NetDevices
+	// Reset levels for program classes that differ from ipc.DefaultResetLevels.
+	ResetLevels map[string]ipc.ResetLevel
+	// Detect side effects of calls (see mgrconfig.Config.DetectSideEffects)
+	// and side effects of syscalls detected so far (keyed by syscall name).
+	DetectSideEffects bool
+	SideEffects       map[string]ipc.SideEffects
+	// Collect kernel messages of this or higher severity (see mgrconfig.Config.KmsgSeverity).
+	KmsgSeverity string
+}
+
+// Fuzzer features that can be enabled on a part of fuzzers by manager experiments
+// to compare them with the rest (see mgrconfig.Experiment).
+const (
+	ExperimentGenerateOften = "generate-often" // generate new programs 10x more often
+	ExperimentNoHints       = "no-hints"       // don't mutate programs with comparison operands
+	ExperimentNoRace        = "no-race"        // don't explore forced race schedules
+	ExperimentNoBackfill    = "no-backfill"    // don't backfill corpus for new syscalls
+)
+
+var ExperimentFeatures = []string{
+	ExperimentGenerateOften,
+	ExperimentNoHints,
+	ExperimentNoRace,
+	ExperimentNoBackfill,
 }
 
 type CheckArgs struct {
@@ -53,6 +91,13 @@ type SyscallReason struct {
 type NewInputArgs struct {
 	Name string
 	RPCInput
+	// Fuzzer did not minimize the input (e.g. it was found under a forced race schedule).
+	Unminimized bool
+	// If set, the input is a re-minimized version of the corpus input with this hash.
+	ReminimizedFrom string
+	// If set, the input was triaged from the manager candidate with this hash
+	// (the input itself may differ from the candidate after minimization).
+	Candidate string
 }
 
 type PollArgs struct {
@@ -60,12 +105,44 @@ type PollArgs struct {
 	NeedCandidates bool
 	MaxSignal      signal.Serial
 	Stats          map[string]uint64
+	// Syscalls that the fuzzer disabled during fuzzing because they don't work in the kernel.
+	DisabledCalls []SyscallReason
+	// Syscalls that always fail because the kernel lacks some feature (reported once per syscall).
+	Unsupported []SyscallReason
+	// Side effects of syscalls detected since the last poll (keyed by syscall name).
+	SideEffects map[string]ipc.SideEffects
+	// Non-fatal kernel messages printed since the last poll (see KmsgSeverity).
+	KernelMessages []KernelMessage
+}
+
+type KernelMessage struct {
+	Title string // see report.KmsgTitle
+	Text  []byte
+	// Programs that were executing (or the last ones executed) by fuzzer procs
+	// when the message was printed.
+	Progs [][]byte
 }
 
 type PollRes struct {
 	Candidates []RPCCandidate
 	NewInputs  []RPCInput
 	MaxSignal  signal.Serial
+	// Corpus inputs that the fuzzer needs to re-minimize.
+	Reminimize []RPCInput
+	// If set, the fuzzer needs to collect the profile and send it with Manager.Profile.
+	Profile *ProfileRequest
+}
+
+type ProfileRequest struct {
+	Kind     string        // cpu/heap/goroutine
+	Duration time.Duration // for cpu profiles
+}
+
+type ProfileArgs struct {
+	Name  string
+	Kind  string
+	Data  []byte // in pprof format
+	Error string
 }
 
 type HubConnectArgs struct {
@@ -81,6 +158,20 @@ type HubConnectArgs struct {
 	Calls []string
 	// Current manager corpus.
 	Corpus [][]byte
+	// If Session is set, the corpus is uploaded in several Connect calls
+	// (with the same Session and the rest of args) and the connection is established
+	// once all parts are received. This allows to resume large uploads over flaky links.
+	Session string
+	// Index of the first program in Corpus in the whole corpus.
+	Offset int
+	// This is the last part of the corpus.
+	Done bool
+}
+
+type HubConnectRes struct {
+	// Number of programs of the whole corpus received by hub in the current session.
+	// Manager should continue upload from this index.
+	Received int
 }
 
 type HubSyncArgs struct {
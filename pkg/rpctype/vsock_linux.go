@@ -0,0 +1,60 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpctype
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// dialVsock connects to the given port of the VM socket context cid (2 is the host).
+func dialVsock(cid, port uint32) (net.Conn, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vsock socket: %v", err)
+	}
+	if err := unix.Connect(fd, &unix.SockaddrVM{CID: cid, Port: port}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to connect to vsock %v:%v: %v", cid, port, err)
+	}
+	// Non-blocking mode makes the file pollable, which is required for deadlines.
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// Note: net.FileConn does not support AF_VSOCK, so we wrap the file.
+	return &vsockConn{
+		File:   os.NewFile(uintptr(fd), "vsock"),
+		remote: &vsockAddr{cid, port},
+	}, nil
+}
+
+type vsockConn struct {
+	*os.File
+	remote *vsockAddr
+}
+
+func (conn *vsockConn) LocalAddr() net.Addr {
+	return &vsockAddr{unix.VMADDR_CID_ANY, unix.VMADDR_PORT_ANY}
+}
+
+func (conn *vsockConn) RemoteAddr() net.Addr {
+	return conn.remote
+}
+
+type vsockAddr struct {
+	cid  uint32
+	port uint32
+}
+
+func (addr *vsockAddr) Network() string {
+	return "vsock"
+}
+
+func (addr *vsockAddr) String() string {
+	return fmt.Sprintf("vsock:%v:%v", addr.cid, addr.port)
+}
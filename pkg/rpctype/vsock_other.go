@@ -0,0 +1,15 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build !linux
+
+package rpctype
+
+import (
+	"fmt"
+	"net"
+)
+
+func dialVsock(cid, port uint32) (net.Conn, error) {
+	return nil, fmt.Errorf("vsock is not supported on this OS")
+}
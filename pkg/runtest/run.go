@@ -120,9 +120,9 @@ func (ctx *Context) Run() error {
 }
 
 func (ctx *Context) generatePrograms(progs chan *RunRequest) error {
-	files, err := ioutil.ReadDir(ctx.Dir)
+	files, err := progFileList(ctx.Dir)
 	if err != nil {
-		return fmt.Errorf("failed to read %v: %v", ctx.Dir, err)
+		return err
 	}
 	cover := []bool{false}
 	if ctx.Features[host.FeatureCoverage].Enabled {
@@ -137,19 +137,21 @@ func (ctx *Context) generatePrograms(progs chan *RunRequest) error {
 	closedDone := make(chan struct{})
 	close(closedDone)
 	for _, file := range files {
-		if strings.HasSuffix(file.Name(), "~") {
-			continue
-		}
-		if strings.HasSuffix(file.Name(), ".swp") {
-			continue
-		}
-		p, requires, results, err := ctx.parseProg(file.Name())
+		p, requires, results, err := ctx.parseProg(file)
 		if err != nil {
 			return err
 		}
+		if p == nil {
+			progs <- &RunRequest{
+				Done: closedDone,
+				name: file,
+				skip: fmt.Sprintf("excluded for arch %v", ctx.Target.Arch),
+			}
+			continue
+		}
 	nextSandbox:
 		for _, sandbox := range sandboxes {
-			name := fmt.Sprintf("%v %v", file.Name(), sandbox)
+			name := fmt.Sprintf("%v %v", file, sandbox)
 			for _, call := range p.Calls {
 				if !ctx.EnabledCalls[sandbox][call.Meta] {
 					progs <- &RunRequest{
@@ -161,7 +163,8 @@ func (ctx *Context) generatePrograms(progs chan *RunRequest) error {
 				}
 			}
 			properties := map[string]bool{
-				"sandbox=" + sandbox: true,
+				"sandbox=" + sandbox:      true,
+				"arch=" + ctx.Target.Arch: true,
 			}
 			for _, threaded := range []bool{false, true} {
 				name := name
@@ -226,43 +229,64 @@ func TestParseProg(target *prog.Target, dir, filename string) error {
 	return err
 }
 
+// progFileList returns names of test programs in dir.
+func progFileList(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", dir, err)
+	}
+	var res []string
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), "~") ||
+			strings.HasSuffix(file.Name(), ".swp") {
+			continue
+		}
+		res = append(res, file.Name())
+	}
+	return res, nil
+}
+
+// parseProg returns nil program if the program is excluded for the target arch
+// (programs that use arch-specific calls can't be deserialized for other arches).
 func parseProg(target *prog.Target, dir, filename string) (*prog.Prog, map[string]bool, *ipc.ProgInfo, error) {
 	data, err := ioutil.ReadFile(filepath.Join(dir, filename))
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to read %v: %v", filename, err)
 	}
+	requires := parseRequires(data)
+	if !checkArch(requires, target.Arch) {
+		return nil, nil, nil, nil
+	}
 	p, err := target.Deserialize(data, prog.Strict)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to deserialize %v: %v", filename, err)
 	}
-	requires := make(map[string]bool)
-	for _, comment := range p.Comments {
-		const prefix = "requires:"
-		if !strings.HasPrefix(comment, prefix) {
-			continue
-		}
-		for _, req := range strings.Fields(comment[len(prefix):]) {
-			positive := true
-			if req[0] == '-' {
-				positive = false
-				req = req[1:]
-			}
-			requires[req] = positive
-		}
-	}
 	errnos := map[string]int{
-		"":           0,
-		"EPERM":      1,
-		"ENOENT":     2,
-		"E2BIG":      7,
-		"ENOEXEC":    8,
-		"EBADF":      9,
-		"ENOMEM":     12,
-		"EACCES":     13,
-		"EFAULT":     14,
-		"EINVAL":     22,
-		"ENOTTY":     25,
-		"EOPNOTSUPP": 95,
+		"":                0,
+		"EPERM":           1,
+		"ENOENT":          2,
+		"E2BIG":           7,
+		"ENOEXEC":         8,
+		"EBADF":           9,
+		"EAGAIN":          11,
+		"ENOMEM":          12,
+		"EACCES":          13,
+		"EFAULT":          14,
+		"EBUSY":           16,
+		"EEXIST":          17,
+		"ENODEV":          19,
+		"ENOTDIR":         20,
+		"EISDIR":          21,
+		"EINVAL":          22,
+		"ENOTTY":          25,
+		"ENOSPC":          28,
+		"ERANGE":          34,
+		"ENOSYS":          38,
+		"ENOPROTOOPT":     92,
+		"EPROTONOSUPPORT": 93,
+		"EOPNOTSUPP":      95,
+		"EAFNOSUPPORT":    97,
+		"ENOTCONN":        107,
 	}
 	info := &ipc.ProgInfo{Calls: make([]ipc.CallInfo, len(p.Calls))}
 	for i, call := range p.Calls {
@@ -286,6 +310,42 @@ func parseProg(target *prog.Target, dir, filename string) (*prog.Prog, map[strin
 	return p, requires, info, nil
 }
 
+// parseRequires parses "# requires: ..." comments before the program is deserialized.
+func parseRequires(data []byte) map[string]bool {
+	requires := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		const prefix = "# requires:"
+		line := s.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		for _, req := range strings.Fields(line[len(prefix):]) {
+			positive := true
+			if req[0] == '-' {
+				positive = false
+				req = req[1:]
+			}
+			requires[req] = positive
+		}
+	}
+	return requires
+}
+
+// checkArch returns false if arch=... requirements exclude the arch.
+func checkArch(requires map[string]bool, arch string) bool {
+	for req, positive := range requires {
+		const prefix = "arch="
+		if !strings.HasPrefix(req, prefix) {
+			continue
+		}
+		if req[len(prefix):] == arch != positive {
+			return false
+		}
+	}
+	return true
+}
+
 func (ctx *Context) produceTest(progs chan *RunRequest, req *RunRequest, name string,
 	properties, requires map[string]bool, results *ipc.ProgInfo) {
 	req.name = name
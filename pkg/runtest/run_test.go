@@ -11,11 +11,61 @@ import (
 
 	"github.com/google/syzkaller/pkg/csource"
 	"github.com/google/syzkaller/pkg/host"
+	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
 	"github.com/google/syzkaller/sys/targets"
-	_ "github.com/google/syzkaller/sys/test/gen" // pull in the test target
 )
 
+// TestParsing checks that all test programs are consistent with the current descriptions
+// on all arches, so that description changes that break them are caught without running them.
+func TestParsing(t *testing.T) {
+	for OS, arches := range targets.List {
+		dir := filepath.Join("..", "..", "sys", OS, "test")
+		if !osutil.IsExist(dir) {
+			continue
+		}
+		files, err := progFileList(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for arch := range arches {
+			target, err := prog.GetTarget(OS, arch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range files {
+				if _, _, _, err := parseProg(target, dir, file); err != nil {
+					t.Errorf("%v/%v: %v", OS, arch, err)
+				}
+			}
+		}
+	}
+}
+
+func TestRequires(t *testing.T) {
+	requires := parseRequires([]byte("# requires: arch=amd64 -sandbox=setuid\nfoo()\n# requires: -C,norepeat\n"))
+	want := map[string]bool{
+		"arch=amd64":     true,
+		"sandbox=setuid": false,
+		"C,norepeat":     false,
+	}
+	if len(requires) != len(want) {
+		t.Fatalf("got %v, want %v", requires, want)
+	}
+	for req, positive := range want {
+		if v, ok := requires[req]; !ok || v != positive {
+			t.Fatalf("got %v, want %v", requires, want)
+		}
+	}
+	if !checkArch(requires, "amd64") || checkArch(requires, "arm64") {
+		t.Fatalf("arch=amd64 is not handled")
+	}
+	if !checkArch(map[string]bool{"arch=arm": false}, "amd64") || checkArch(map[string]bool{"arch=arm": false}, "arm") {
+		t.Fatalf("-arch=arm is not handled")
+	}
+}
+
 func Test(t *testing.T) {
 	switch runtime.GOOS {
 	case "openbsd":
@@ -0,0 +1,269 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package seccomp compiles seccomp profiles in the OCI/Docker JSON format into BPF programs
+// for the linux seccomp filter (SECCOMP_MODE_FILTER).
+package seccomp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+type Profile struct {
+	DefaultAction   string  `json:"defaultAction"`
+	DefaultErrnoRet *uint32 `json:"defaultErrnoRet"`
+	Syscalls        []*Rule `json:"syscalls"`
+}
+
+type Rule struct {
+	Names    []string `json:"names"`
+	Name     string   `json:"name"` // older Docker format with a single name per rule
+	Action   string   `json:"action"`
+	ErrnoRet *uint32  `json:"errnoRet"`
+	Args     []*Arg   `json:"args"`
+	Includes Filter   `json:"includes"`
+	Excludes Filter   `json:"excludes"`
+}
+
+type Arg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+// Filter limits a rule to some arches or capabilities (Docker extension).
+// Programs are not given any capabilities by the profile, so rules that are included
+// only for some capabilities are ignored, and rules that are excluded for some are used.
+type Filter struct {
+	Arches []string `json:"arches"`
+	Caps   []string `json:"caps"`
+}
+
+func Parse(data []byte) (*Profile, error) {
+	p := new(Profile)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse seccomp profile: %v", err)
+	}
+	if p.DefaultAction == "" {
+		return nil, fmt.Errorf("seccomp profile has no defaultAction")
+	}
+	return p, nil
+}
+
+// BPF instruction encoding and seccomp constants from linux/filter.h and linux/seccomp.h.
+const (
+	bpfLdWAbs = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeqK   = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgeK   = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfAndK   = 0x54 // BPF_ALU | BPF_AND | BPF_K
+	bpfRetK   = 0x06 // BPF_RET | BPF_K
+
+	retKillThread  = 0x00000000
+	retKillProcess = 0x80000000
+	retTrap        = 0x00030000
+	retErrno       = 0x00050000
+	retTrace       = 0x7ff00000
+	retLog         = 0x7ffc0000
+	retAllow       = 0x7fff0000
+
+	offNR   = 0
+	offArch = 4
+	offArgs = 16
+
+	x32SyscallBit = 0x40000000
+	eperm         = 1
+
+	// BPF_MAXINSNS.
+	MaxInstructions = 4096
+)
+
+var auditArches = map[string]uint32{
+	"amd64":   0xc000003e,
+	"386":     0x40000003,
+	"arm64":   0xc00000b7,
+	"arm":     0x40000028,
+	"ppc64le": 0xc0000015,
+}
+
+type instr struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+// Compile compiles the profile for the target into an array of struct sock_filter.
+// Syscalls that the target does not have are ignored.
+func Compile(p *Profile, target *prog.Target) ([]byte, error) {
+	auditArch, ok := auditArches[target.Arch]
+	if target.OS != "linux" || !ok {
+		return nil, fmt.Errorf("seccomp profiles are not supported on %v/%v", target.OS, target.Arch)
+	}
+	defaultAction, err := action(p.DefaultAction, p.DefaultErrnoRet)
+	if err != nil {
+		return nil, err
+	}
+	nrs := make(map[string]uint64)
+	for _, c := range target.Syscalls {
+		if !strings.HasPrefix(c.CallName, "syz_") {
+			nrs[c.CallName] = c.NR
+		}
+	}
+	insns := []instr{
+		{bpfLdWAbs, 0, 0, offArch},
+		{bpfJeqK, 1, 0, auditArch},
+		{bpfRetK, 0, 0, defaultAction},
+	}
+	if target.Arch == "amd64" {
+		// x32 syscalls have the same audit arch.
+		insns = append(insns,
+			instr{bpfLdWAbs, 0, 0, offNR},
+			instr{bpfJgeK, 0, 1, x32SyscallBit},
+			instr{bpfRetK, 0, 0, defaultAction})
+	}
+	for _, rule := range p.Syscalls {
+		if !rule.applies(target.Arch) {
+			continue
+		}
+		act, err := action(rule.Action, rule.ErrnoRet)
+		if err != nil {
+			return nil, err
+		}
+		names := rule.Names
+		if rule.Name != "" {
+			names = append(names, rule.Name)
+		}
+		for _, name := range names {
+			nr, ok := nrs[name]
+			if !ok {
+				continue
+			}
+			block, err := compileRule(nr, rule.Args, act)
+			if err != nil {
+				return nil, fmt.Errorf("bad rule for %v: %v", name, err)
+			}
+			insns = append(insns, block...)
+		}
+	}
+	insns = append(insns, instr{bpfRetK, 0, 0, defaultAction})
+	if len(insns) > MaxInstructions {
+		return nil, fmt.Errorf("seccomp profile is too large: %v instructions (max %v)",
+			len(insns), MaxInstructions)
+	}
+	// All supported arches are little-endian.
+	data := make([]byte, len(insns)*8)
+	for i, ins := range insns {
+		binary.LittleEndian.PutUint16(data[i*8:], ins.code)
+		data[i*8+2] = ins.jt
+		data[i*8+3] = ins.jf
+		binary.LittleEndian.PutUint32(data[i*8+4:], ins.k)
+	}
+	return data, nil
+}
+
+func (rule *Rule) applies(arch string) bool {
+	if len(rule.Includes.Caps) != 0 {
+		return false
+	}
+	if len(rule.Includes.Arches) != 0 {
+		found := false
+		for _, a := range rule.Includes.Arches {
+			found = found || a == arch || a == "x86" && arch == "386"
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, a := range rule.Excludes.Arches {
+		if a == arch || a == "x86" && arch == "386" {
+			return false
+		}
+	}
+	return true
+}
+
+func action(name string, errnoRet *uint32) (uint32, error) {
+	switch name {
+	case "SCMP_ACT_ALLOW":
+		return retAllow, nil
+	case "SCMP_ACT_ERRNO":
+		errno := uint32(eperm)
+		if errnoRet != nil {
+			errno = *errnoRet
+		}
+		if errno > 0xffff {
+			return 0, fmt.Errorf("bad errno %v", errno)
+		}
+		return retErrno | errno, nil
+	case "SCMP_ACT_KILL", "SCMP_ACT_KILL_THREAD":
+		return retKillThread, nil
+	case "SCMP_ACT_KILL_PROCESS":
+		return retKillProcess, nil
+	case "SCMP_ACT_TRAP":
+		return retTrap, nil
+	case "SCMP_ACT_TRACE":
+		return retTrace, nil
+	case "SCMP_ACT_LOG":
+		return retLog, nil
+	}
+	return 0, fmt.Errorf("unsupported seccomp action %q", name)
+}
+
+// compileRule returns instructions that return act if the syscall number is nr
+// and all args match, or fall through to the next rule otherwise.
+// Jumps with jt/jf equal to failJump go past the end of the block.
+func compileRule(nr uint64, args []*Arg, act uint32) ([]instr, error) {
+	const failJump = 0xff
+	block := []instr{
+		{bpfLdWAbs, 0, 0, offNR},
+		{bpfJeqK, 0, failJump, uint32(nr)},
+	}
+	for _, arg := range args {
+		if arg.Index >= 6 {
+			return nil, fmt.Errorf("bad arg index %v", arg.Index)
+		}
+		lo := uint32(offArgs + 8*arg.Index)
+		hi := lo + 4
+		switch arg.Op {
+		case "SCMP_CMP_EQ":
+			block = append(block,
+				instr{bpfLdWAbs, 0, 0, lo},
+				instr{bpfJeqK, 0, failJump, uint32(arg.Value)},
+				instr{bpfLdWAbs, 0, 0, hi},
+				instr{bpfJeqK, 0, failJump, uint32(arg.Value >> 32)})
+		case "SCMP_CMP_NE":
+			block = append(block,
+				instr{bpfLdWAbs, 0, 0, lo},
+				instr{bpfJeqK, 0, 2, uint32(arg.Value)},
+				instr{bpfLdWAbs, 0, 0, hi},
+				instr{bpfJeqK, failJump, 0, uint32(arg.Value >> 32)})
+		case "SCMP_CMP_MASKED_EQ":
+			block = append(block,
+				instr{bpfLdWAbs, 0, 0, lo},
+				instr{bpfAndK, 0, 0, uint32(arg.Value)},
+				instr{bpfJeqK, 0, failJump, uint32(arg.ValueTwo)},
+				instr{bpfLdWAbs, 0, 0, hi},
+				instr{bpfAndK, 0, 0, uint32(arg.Value >> 32)},
+				instr{bpfJeqK, 0, failJump, uint32(arg.ValueTwo >> 32)})
+		default:
+			return nil, fmt.Errorf("unsupported arg comparison %q", arg.Op)
+		}
+	}
+	block = append(block, instr{bpfRetK, 0, 0, act})
+	for i := range block {
+		fail := uint8(len(block) - i - 1)
+		if block[i].jt == failJump {
+			block[i].jt = fail
+		}
+		if block[i].jf == failJump {
+			block[i].jf = fail
+		}
+	}
+	return block, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package seccomp
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+// run interprets the compiled filter for the given syscall (only the instructions Compile emits).
+func run(t *testing.T, filter []byte, arch uint32, nr uint32, args [6]uint64) uint32 {
+	data := make([]byte, 64)
+	binary.LittleEndian.PutUint32(data[offNR:], nr)
+	binary.LittleEndian.PutUint32(data[offArch:], arch)
+	for i, arg := range args {
+		binary.LittleEndian.PutUint64(data[offArgs+8*i:], arg)
+	}
+	a := uint32(0)
+	for pc := 0; pc < len(filter)/8; pc++ {
+		code := binary.LittleEndian.Uint16(filter[pc*8:])
+		jt, jf := int(filter[pc*8+2]), int(filter[pc*8+3])
+		k := binary.LittleEndian.Uint32(filter[pc*8+4:])
+		switch code {
+		case bpfLdWAbs:
+			a = binary.LittleEndian.Uint32(data[k:])
+		case bpfAndK:
+			a &= k
+		case bpfJeqK, bpfJgeK:
+			if code == bpfJeqK && a == k || code == bpfJgeK && a >= k {
+				pc += jt
+			} else {
+				pc += jf
+			}
+		case bpfRetK:
+			return k
+		default:
+			t.Fatalf("unknown instruction 0x%x", code)
+		}
+	}
+	t.Fatalf("filter did not return")
+	return 0
+}
+
+func TestCompile(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse([]byte(`{
+	"defaultAction": "SCMP_ACT_ERRNO",
+	"syscalls": [
+		{"names": ["read", "write", "no_such_syscall"], "action": "SCMP_ACT_ALLOW"},
+		{"names": ["personality"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 0, "value": 8, "op": "SCMP_CMP_EQ"}]},
+		{"names": ["clone"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 0, "value": 2114060288, "valueTwo": 0, "op": "SCMP_CMP_MASKED_EQ"}]},
+		{"names": ["socket"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 0, "value": 16, "op": "SCMP_CMP_NE"}]},
+		{"names": ["mount"], "action": "SCMP_ACT_ALLOW", "includes": {"caps": ["CAP_SYS_ADMIN"]}},
+		{"names": ["ptrace"], "action": "SCMP_ACT_ERRNO", "errnoRet": 38},
+		{"names": ["arch_prctl"], "action": "SCMP_ACT_ALLOW", "includes": {"arches": ["amd64"]}},
+		{"names": ["uselib"], "action": "SCMP_ACT_ALLOW", "excludes": {"arches": ["amd64"]}}
+	]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := Compile(p, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amd64 := auditArches["amd64"]
+	nr := func(name string) uint32 {
+		for _, c := range target.Syscalls {
+			if c.CallName == name {
+				return uint32(c.NR)
+			}
+		}
+		t.Fatalf("no syscall %v", name)
+		return 0
+	}
+	const errnoEPERM = retErrno | eperm
+	tests := []struct {
+		arch uint32
+		nr   uint32
+		args [6]uint64
+		ret  uint32
+	}{
+		{amd64, nr("read"), [6]uint64{}, retAllow},
+		{amd64, nr("write"), [6]uint64{1, 2, 3}, retAllow},
+		{amd64, nr("close"), [6]uint64{}, errnoEPERM},
+		{amd64, nr("read") | x32SyscallBit, [6]uint64{}, errnoEPERM},
+		{auditArches["386"], nr("read"), [6]uint64{}, errnoEPERM},
+		{amd64, nr("personality"), [6]uint64{8}, retAllow},
+		{amd64, nr("personality"), [6]uint64{9}, errnoEPERM},
+		{amd64, nr("personality"), [6]uint64{8 | 1<<32}, errnoEPERM},
+		{amd64, nr("clone"), [6]uint64{0x11}, retAllow},
+		{amd64, nr("clone"), [6]uint64{0x10000000}, errnoEPERM},
+		{amd64, nr("socket"), [6]uint64{2}, retAllow},
+		{amd64, nr("socket"), [6]uint64{16}, errnoEPERM},
+		{amd64, nr("socket"), [6]uint64{16 | 1<<32}, retAllow},
+		{amd64, nr("mount"), [6]uint64{}, errnoEPERM},
+		{amd64, nr("ptrace"), [6]uint64{}, retErrno | 38},
+		{amd64, nr("arch_prctl"), [6]uint64{}, retAllow},
+		{amd64, nr("uselib"), [6]uint64{}, errnoEPERM},
+	}
+	for i, test := range tests {
+		if ret := run(t, filter, test.arch, test.nr, test.args); ret != test.ret {
+			t.Errorf("test #%v: got 0x%x, want 0x%x", i, ret, test.ret)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{
+		`{"defaultAction": "SCMP_ACT_FOO"}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["read"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 0, "value": 1, "op": "SCMP_CMP_GT"}]}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["read"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 6, "value": 1, "op": "SCMP_CMP_EQ"}]}]}`,
+	} {
+		p, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Compile(p, target); err == nil {
+			t.Errorf("no error for %v", data)
+		}
+	}
+	if _, err := Parse([]byte(`{"syscalls": []}`)); err == nil {
+		t.Errorf("no error for a profile without default action")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package subsystem
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher attributes source files and crashes to subsystems.
+type Matcher struct {
+	subsystems []*Subsystem
+	// Lowercase words of subsystem names -> subsystems, used for title heuristics.
+	words map[string][]*Subsystem
+}
+
+func NewMatcher(subsystems []*Subsystem) *Matcher {
+	m := &Matcher{
+		subsystems: subsystems,
+		words:      make(map[string][]*Subsystem),
+	}
+	for _, s := range subsystems {
+		seen := make(map[string]bool)
+		for _, word := range nameWordRe.FindAllString(strings.ToLower(s.Name), -1) {
+			if !seen[word] {
+				seen[word] = true
+				m.words[word] = append(m.words[word], s)
+			}
+		}
+	}
+	return m
+}
+
+var nameWordRe = regexp.MustCompile(`[a-z0-9]+`)
+
+// Match returns the most specific subsystems the file belongs to
+// (usually one, but several entries may list the same files).
+func (m *Matcher) Match(file string) []*Subsystem {
+	var res []*Subsystem
+	best := 0
+	for _, s := range m.subsystems {
+		spec := s.match(file)
+		if spec == 0 || spec < best {
+			continue
+		}
+		if spec > best {
+			best, res = spec, nil
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// Crash describes a crash to classify.
+type Crash struct {
+	Title string
+	// The file that is blamed for the crash (see report.Report.guiltyFile).
+	GuiltyFile string
+	// Other source files related to the crash, e.g. files from the stack trace
+	// or files covered by the reproducer (optional).
+	Files []string
+}
+
+const (
+	guiltyWeight = 10
+	titleWeight  = 3
+	filesWeight  = 4
+	minScore     = 3
+	// Title words that match more subsystems than this are too generic (e.g. "usb", "net").
+	maxTitleMatches = 3
+)
+
+// Classify returns subsystems the crash most likely belongs to (usually one),
+// or nil if it can't be classified. The decision is based on the guilty file
+// (the strongest signal), subsystem names mentioned in the crash title
+// (e.g. "ext4" in "KASAN: use-after-free Read in ext4_xattr_set_entry")
+// and the share of the other related files that belong to the subsystem.
+func (m *Matcher) Classify(crash *Crash) []*Subsystem {
+	scores := make(map[*Subsystem]float64)
+	if crash.GuiltyFile != "" {
+		for _, s := range m.Match(crash.GuiltyFile) {
+			scores[s] += guiltyWeight
+		}
+	}
+	for _, s := range m.titleMatches(crash.Title) {
+		scores[s] += titleWeight
+	}
+	if len(crash.Files) != 0 {
+		share := float64(filesWeight) / float64(len(crash.Files))
+		for _, file := range crash.Files {
+			for _, s := range m.Match(file) {
+				scores[s] += share
+			}
+		}
+	}
+	var res []*Subsystem
+	best := float64(minScore)
+	for s, score := range scores {
+		if score < best {
+			continue
+		}
+		if score > best {
+			best, res = score, nil
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+var titleIdentRe = regexp.MustCompile(`[a-zA-Z0-9]+(?:_[a-zA-Z0-9_]+)`)
+
+// titleMatches returns subsystems whose names contain the prefix of the function mentioned
+// in the title (e.g. "ext4" for ext4_fill_super).
+func (m *Matcher) titleMatches(title string) []*Subsystem {
+	var res []*Subsystem
+	seen := make(map[*Subsystem]bool)
+	for _, ident := range titleIdentRe.FindAllString(title, -1) {
+		prefix := strings.ToLower(strings.SplitN(strings.TrimLeft(ident, "_"), "_", 2)[0])
+		matches := m.words[prefix]
+		if len(prefix) < 3 || len(matches) > maxTitleMatches {
+			continue
+		}
+		for _, s := range matches {
+			if !seen[s] {
+				seen[s] = true
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
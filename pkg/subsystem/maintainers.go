@@ -0,0 +1,163 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package subsystem attributes kernel crashes to subsystems described in the Linux MAINTAINERS file
+// and selects recipients for bug reports.
+package subsystem
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Subsystem is a single MAINTAINERS entry.
+type Subsystem struct {
+	Name        string
+	Status      string   // S: (e.g. Maintained, Supported, Odd Fixes)
+	Lists       []string // L: mailing lists
+	Maintainers []string // M: maintainer emails
+	Reviewers   []string // R: reviewer emails
+	files       []string // F: file patterns
+	excludes    []string // X: excluded file patterns
+	regexps     []*regexp.Regexp
+}
+
+var tagRe = regexp.MustCompile(`^([A-Z]):\s*(.*?)\s*$`)
+
+// Parse parses MAINTAINERS file. The file consists of entries separated by empty lines,
+// each entry is a subsystem name line followed by "T:\tvalue" lines.
+// Paragraphs that don't have this form (e.g. the format description at the beginning) are skipped.
+func Parse(data []byte) ([]*Subsystem, error) {
+	var res []*Subsystem
+	for _, para := range bytes.Split(bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1), []byte("\n\n")) {
+		lines := strings.Split(strings.Trim(string(para), "\n"), "\n")
+		if len(lines) < 2 || tagRe.MatchString(lines[0]) || strings.TrimSpace(lines[0]) == "" {
+			continue
+		}
+		s := &Subsystem{Name: strings.TrimSpace(lines[0])}
+		valid := true
+		for _, line := range lines[1:] {
+			match := tagRe.FindStringSubmatch(line)
+			if match == nil {
+				valid = false
+				break
+			}
+			val := match[2]
+			switch match[1] {
+			case "S":
+				s.Status = val
+			case "L":
+				if fields := strings.Fields(val); len(fields) != 0 {
+					s.Lists = append(s.Lists, fields[0])
+				}
+			case "M":
+				if addr := parseEmail(val); addr != "" {
+					s.Maintainers = append(s.Maintainers, addr)
+				}
+			case "R":
+				if addr := parseEmail(val); addr != "" {
+					s.Reviewers = append(s.Reviewers, addr)
+				}
+			case "F":
+				s.files = append(s.files, val)
+			case "X":
+				s.excludes = append(s.excludes, val)
+			case "N":
+				re, err := regexp.Compile(val)
+				if err != nil {
+					return nil, fmt.Errorf("%v: bad N: regexp %q: %v", s.Name, val, err)
+				}
+				s.regexps = append(s.regexps, re)
+			}
+		}
+		if valid {
+			res = append(res, s)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no subsystems found")
+	}
+	return res, nil
+}
+
+func parseEmail(val string) string {
+	addr, err := mail.ParseAddress(val)
+	if err != nil {
+		// Mailing lists and some maintainers are specified without names.
+		if strings.Contains(val, "@") && !strings.ContainsAny(val, " <>") {
+			return val
+		}
+		return ""
+	}
+	return addr.Address
+}
+
+// Recipients returns mailing lists, maintainers and reviewers of the subsystem.
+func (s *Subsystem) Recipients() []string {
+	var res []string
+	res = append(res, s.Lists...)
+	res = append(res, s.Maintainers...)
+	res = append(res, s.Reviewers...)
+	return res
+}
+
+// match returns specificity of the match of the file against the subsystem (longer patterns
+// are more specific), or 0 if the file does not belong to the subsystem.
+func (s *Subsystem) match(file string) int {
+	for _, pattern := range s.excludes {
+		if matchPattern(pattern, file) > 0 {
+			return 0
+		}
+	}
+	best := 0
+	for _, pattern := range s.files {
+		if spec := matchPattern(pattern, file); spec > best {
+			best = spec
+		}
+	}
+	if best == 0 {
+		for _, re := range s.regexps {
+			if re.MatchString(file) {
+				best = 1
+				break
+			}
+		}
+	}
+	return best
+}
+
+// matchPattern matches the file against F:/X: pattern with get_maintainer.pl semantics:
+// "dir/" matches all files in and below dir, "dir/*" matches files in dir, but not below,
+// other patterns match either the file itself or the directory the file is in.
+// Catch-all patterns (e.g. "*") have 0 specificity and never match.
+func matchPattern(pattern, file string) int {
+	spec := len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+	if spec <= 1 {
+		return 0
+	}
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if !strings.ContainsAny(pattern, "*?[") {
+		if file == pattern || strings.HasPrefix(file, pattern+"/") {
+			return spec
+		}
+		return 0
+	}
+	if !dir {
+		if ok, _ := path.Match(pattern, file); ok {
+			return spec
+		}
+		return 0
+	}
+	// The pattern may match any of the parent directories of the file.
+	for d := path.Dir(file); d != "." && d != "/"; d = path.Dir(d) {
+		if ok, _ := path.Match(pattern, d); ok {
+			return spec
+		}
+	}
+	return 0
+}
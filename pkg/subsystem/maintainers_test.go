@@ -0,0 +1,190 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package subsystem
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testMaintainers = `
+List of maintainers and how to submit kernel changes
+
+Descriptions of section entries and preferred order
+	M: *Mail* patches to: FullName <address@domain>
+	F: *Files* and directories wildcard patterns.
+
+Maintainers List
+----------------
+
+EXT4 FILE SYSTEM
+M:	"Theodore Ts'o" <tytso@mit.edu>
+M:	Andreas Dilger <adilger.kernel@dilger.ca>
+L:	linux-ext4@vger.kernel.org
+S:	Maintained
+F:	Documentation/filesystems/ext4/
+F:	fs/ext4/
+F:	include/trace/events/ext4.h
+
+FILESYSTEMS (VFS and infrastructure)
+M:	Alexander Viro <viro@zeniv.linux.org.uk>
+L:	linux-fsdevel@vger.kernel.org
+S:	Maintained
+F:	fs/*
+F:	include/linux/fs.h
+
+NETWORKING [GENERAL]
+M:	"David S. Miller" <davem@davemloft.net>
+L:	netdev@vger.kernel.org
+S:	Odd Fixes
+F:	net/
+X:	net/bluetooth/
+
+BLUETOOTH SUBSYSTEM
+M:	Marcel Holtmann <marcel@holtmann.org>
+R:	reviewer@bluetooth.org
+L:	linux-bluetooth@vger.kernel.org (moderated for non-subscribers)
+S:	Supported
+F:	net/bluetooth/
+F:	include/net/bluetooth/
+
+USB SUBSYSTEM
+L:	linux-usb@vger.kernel.org
+S:	Supported
+F:	drivers/usb/
+N:	usb
+
+THE REST
+M:	Linus Torvalds <torvalds@linux-foundation.org>
+L:	linux-kernel@vger.kernel.org
+S:	Buried alive in reporters
+F:	*
+F:	*/
+`
+
+func TestParse(t *testing.T) {
+	subsystems, err := Parse([]byte(testMaintainers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range subsystems {
+		names = append(names, s.Name)
+	}
+	wantNames := []string{"EXT4 FILE SYSTEM", "FILESYSTEMS (VFS and infrastructure)", "NETWORKING [GENERAL]",
+		"BLUETOOTH SUBSYSTEM", "USB SUBSYSTEM", "THE REST"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("got subsystems:\n%q\nwant:\n%q", names, wantNames)
+	}
+	ext4 := subsystems[0]
+	want := []string{"linux-ext4@vger.kernel.org", "tytso@mit.edu", "adilger.kernel@dilger.ca"}
+	if !reflect.DeepEqual(ext4.Recipients(), want) {
+		t.Fatalf("got recipients %q, want %q", ext4.Recipients(), want)
+	}
+	bt := subsystems[3]
+	want = []string{"linux-bluetooth@vger.kernel.org", "marcel@holtmann.org", "reviewer@bluetooth.org"}
+	if !reflect.DeepEqual(bt.Recipients(), want) {
+		t.Fatalf("got recipients %q, want %q", bt.Recipients(), want)
+	}
+	if bt.Status != "Supported" {
+		t.Fatalf("got status %q", bt.Status)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	subsystems, err := Parse([]byte(testMaintainers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMatcher(subsystems)
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"fs/ext4/inode.c", []string{"EXT4 FILE SYSTEM"}},
+		{"fs/namei.c", []string{"FILESYSTEMS (VFS and infrastructure)"}},
+		{"fs/fat/inode.c", nil},
+		{"include/linux/fs.h", []string{"FILESYSTEMS (VFS and infrastructure)"}},
+		{"net/ipv4/tcp.c", []string{"NETWORKING [GENERAL]"}},
+		{"net/bluetooth/hci_core.c", []string{"BLUETOOTH SUBSYSTEM"}},
+		{"drivers/usb/core/hub.c", []string{"USB SUBSYSTEM"}},
+		{"drivers/net/usbnet.c", []string{"USB SUBSYSTEM"}},
+		{"kernel/fork.c", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, s := range m.Match(test.file) {
+			got = append(got, s.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %q, want %q", test.file, got, test.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	subsystems, err := Parse([]byte(testMaintainers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMatcher(subsystems)
+	tests := []struct {
+		crash *Crash
+		want  []string
+	}{
+		{
+			crash: &Crash{
+				Title:      "KASAN: use-after-free Read in ext4_xattr_set_entry",
+				GuiltyFile: "fs/ext4/xattr.c",
+				Files:      []string{"fs/ext4/xattr.c", "fs/ext4/inode.c", "fs/namei.c"},
+			},
+			want: []string{"EXT4 FILE SYSTEM"},
+		},
+		{
+			// Nothing except the title, and the title does not mention a subsystem name.
+			crash: &Crash{
+				Title: "WARNING in hci_conn_timeout",
+			},
+			want: nil,
+		},
+		{
+			crash: &Crash{
+				Title: "general protection fault in ext4_fill_super",
+			},
+			want: []string{"EXT4 FILE SYSTEM"},
+		},
+		{
+			// No guilty file, but most of the stack is in bluetooth code.
+			crash: &Crash{
+				Title: "WARNING in kernfs_put",
+				Files: []string{"fs/kernfs/dir.c", "net/bluetooth/hci_sysfs.c",
+					"net/bluetooth/hci_core.c", "net/bluetooth/hci_sock.c"},
+			},
+			want: []string{"BLUETOOTH SUBSYSTEM"},
+		},
+		{
+			crash: &Crash{
+				Title:      "BUG: unable to handle kernel paging request in tcp_sendmsg",
+				GuiltyFile: "net/ipv4/tcp.c",
+			},
+			want: []string{"NETWORKING [GENERAL]"},
+		},
+		{
+			crash: &Crash{
+				Title:      "INFO: task hung in do_exit",
+				GuiltyFile: "kernel/exit.c",
+			},
+			want: nil,
+		},
+	}
+	for i, test := range tests {
+		var got []string
+		for _, s := range m.Classify(test.crash) {
+			got = append(got, s.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test #%v: got %q, want %q", i, got, test.want)
+		}
+	}
+}
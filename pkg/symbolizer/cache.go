@@ -0,0 +1,203 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package symbolizer
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// cache holds symbolization results for a single binary.
+// Results are persisted in the cache dir in a file named after the binary build ID,
+// so the same file can be shared by all processes that symbolize the same kernel.
+type cache struct {
+	mu    sync.Mutex
+	file  string
+	pcs   map[uint64][]Frame
+	dirty bool
+}
+
+var (
+	cachesMu sync.Mutex
+	caches   = make(map[string]*cache) // keyed by cache file
+)
+
+// openCache returns cache for the binary bin stored in dir.
+// Caches are shared by all symbolizers in the process.
+func openCache(dir, bin string) (*cache, error) {
+	id, err := BuildID(bin)
+	if err != nil {
+		return nil, err
+	}
+	file := filepath.Join(dir, id)
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	if c := caches[file]; c != nil {
+		return c, nil
+	}
+	if err := osutil.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	c := &cache{
+		file: file,
+		pcs:  readCacheFile(file),
+	}
+	caches[file] = c
+	return c, nil
+}
+
+func readCacheFile(file string) map[uint64][]Frame {
+	pcs := make(map[uint64][]Frame)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return pcs
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&pcs); err != nil {
+		// Corrupted cache, start from scratch.
+		return make(map[uint64][]Frame)
+	}
+	return pcs
+}
+
+// missing returns PCs that are not present in the cache.
+func (c *cache) missing(pcs []uint64) []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var res []uint64
+	seen := make(map[uint64]bool)
+	for _, pc := range pcs {
+		if _, ok := c.pcs[pc]; !ok && !seen[pc] {
+			seen[pc] = true
+			res = append(res, pc)
+		}
+	}
+	return res
+}
+
+// add stores symbolization results frames for pcs.
+// PCs without frames are stored as well, so that we don't try to symbolize them again.
+func (c *cache) add(pcs []uint64, frames []Frame) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pc := range pcs {
+		c.pcs[pc] = nil
+	}
+	for _, frame := range frames {
+		c.pcs[frame.PC] = append(c.pcs[frame.PC], frame)
+	}
+	c.dirty = c.dirty || len(pcs) != 0
+}
+
+// get returns frames for pcs in the same format as addr2line produces them.
+// All pcs must be present in the cache.
+func (c *cache) get(pcs []uint64) []Frame {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var frames []Frame
+	for _, pc := range pcs {
+		frames = append(frames, c.pcs[pc]...)
+	}
+	return frames
+}
+
+// save writes the cache to disk if it has new entries.
+// Entries added to the cache file by other processes are merged in.
+func (c *cache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	for pc, frames := range readCacheFile(c.file) {
+		if _, ok := c.pcs[pc]; !ok {
+			c.pcs[pc] = frames
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(c.pcs); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%v.tmp%v", c.file, os.Getpid())
+	if err := osutil.WriteFile(tmp, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// BuildID returns an identifier of the binary contents.
+// For ELF binaries with a GNU build ID note it is the build ID,
+// for other binaries it is derived from the file path, size and modification time.
+func BuildID(bin string) (string, error) {
+	stat, err := os.Stat(bin)
+	if err != nil {
+		return "", err
+	}
+	if id := elfBuildID(bin); id != "" {
+		return id, nil
+	}
+	abs, err := filepath.Abs(bin)
+	if err != nil {
+		return "", err
+	}
+	return hash.String([]byte(fmt.Sprintf("%v-%v-%v", abs, stat.Size(), stat.ModTime().UnixNano()))), nil
+}
+
+func elfBuildID(bin string) string {
+	file, err := elf.Open(bin)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	for _, sec := range file.Sections {
+		if sec.Type != elf.SHT_NOTE {
+			continue
+		}
+		data, err := sec.Data()
+		if err != nil {
+			continue
+		}
+		if id := parseBuildIDNote(data, file.ByteOrder); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// parseBuildIDNote extracts NT_GNU_BUILD_ID descriptor from contents of a note section.
+func parseBuildIDNote(data []byte, order binary.ByteOrder) string {
+	const ntGNUBuildID = 3
+	align := func(v uint32) int { return int((v + 3) &^ 3) }
+	for len(data) >= 12 {
+		nameSize := order.Uint32(data[0:])
+		descSize := order.Uint32(data[4:])
+		typ := order.Uint32(data[8:])
+		data = data[12:]
+		if align(nameSize)+align(descSize) > len(data) {
+			break
+		}
+		name := data[:nameSize]
+		desc := data[align(nameSize) : align(nameSize)+int(descSize)]
+		if typ == ntGNUBuildID && string(name) == "GNU\x00" {
+			return hex.EncodeToString(desc)
+		}
+		data = data[align(nameSize)+align(descSize):]
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package symbolizer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildID(t *testing.T) {
+	id, err := BuildID("testdata/nm.test.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "400dd7ee7638fc48c200b01164ab56c60ebb50e1"; id != want {
+		t.Fatalf("got build id %q, want %q", id, want)
+	}
+	dir, err := ioutil.TempDir("", "syz-symbolizer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Non-ELF files still get a stable identifier.
+	bin := filepath.Join(dir, "bin")
+	if err := ioutil.WriteFile(bin, []byte("not an elf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id1, err := BuildID(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := BuildID(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 == "" || id1 != id2 {
+		t.Fatalf("bad build ids for non-ELF file: %q/%q", id1, id2)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-symbolizer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := openCache(dir, "testdata/nm.test.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []Frame{
+		{PC: 0x10, Func: "inlined", File: "foo.c", Line: 1, Inline: true},
+		{PC: 0x10, Func: "foo", File: "foo.c", Line: 10},
+		{PC: 0x30, Func: "bar", File: "bar.c", Line: 20},
+	}
+	pcs := []uint64{0x10, 0x20, 0x30, 0x10}
+	missing := c.missing(pcs)
+	if want := []uint64{0x10, 0x20, 0x30}; !reflect.DeepEqual(missing, want) {
+		t.Fatalf("got missing %v, want %v", missing, want)
+	}
+	c.add(missing, frames)
+	if missing := c.missing(pcs); len(missing) != 0 {
+		t.Fatalf("got missing %v after add", missing)
+	}
+	want := append(append([]Frame{}, frames...), frames[:2]...)
+	if got := c.get(pcs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got frames %+v, want %+v", got, want)
+	}
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+	// The saved cache must be found by build ID.
+	pcs1 := readCacheFile(filepath.Join(dir, "400dd7ee7638fc48c200b01164ab56c60ebb50e1"))
+	if len(pcs1) != 3 || pcs1[0x20] != nil || !reflect.DeepEqual(pcs1[0x10], frames[:2]) {
+		t.Fatalf("bad saved cache: %+v", pcs1)
+	}
+}
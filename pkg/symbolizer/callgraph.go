@@ -0,0 +1,116 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package symbolizer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// CallGraph maps function names to the set of functions they call (including tail calls).
+type CallGraph map[string]map[string]bool
+
+// Pseudo-callees for indirect calls and tail calls (including calls via retpoline thunks).
+// Targets of indirect calls are unknown, so such calls are assumed to call any function.
+const (
+	IndirectCall = "*call"
+	IndirectJump = "*jump"
+)
+
+// Known returns true if the function is present in the binary.
+func (cg CallGraph) Known(fn string) bool {
+	_, ok := cg[fn]
+	return ok
+}
+
+// Calls returns true if caller calls callee directly or via a single intermediate function
+// (stack traces don't contain functions that tail-called the next function).
+func (cg CallGraph) Calls(caller, callee string) bool {
+	callees := cg[caller]
+	if callees[callee] || callees[IndirectCall] || callees[IndirectJump] {
+		return true
+	}
+	for mid := range callees {
+		if cg[mid][callee] || cg[mid][IndirectJump] {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadCallGraph extracts call graph from the binary using objdump.
+func ReadCallGraph(bin string) (CallGraph, error) {
+	cmd := osutil.Command("objdump", "-d", "--no-show-raw-insn", bin)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		stdout.Close()
+		return nil, err
+	}
+	cg, err := parseCallGraph(stdout)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("objdump failed: %v", err)
+	}
+	return cg, nil
+}
+
+var (
+	objdumpFuncRe = regexp.MustCompile(`^[0-9a-f]+ <([^>]+)>:$`)
+	// Calls and jumps to function entries (jumps within functions have +0x offsets).
+	objdumpCallRe = regexp.MustCompile(`^\s*[0-9a-f]+:\s+(callq?|jmpq?|bl|b)\s+[0-9a-f]+ <([^+>]+)>`)
+	// Indirect calls and jumps via a register or memory.
+	objdumpIndirectRe = regexp.MustCompile(`^\s*[0-9a-f]+:\s+(?:(callq?|jmpq?)\s+\*|(blr|br)\s)`)
+	// Retpoline thunks, calls to them are indirect calls.
+	objdumpThunkRe = regexp.MustCompile(`^__x86_indirect_(?:call_|jump_)?thunk_`)
+)
+
+// indirectCallee returns pseudo-callee for an indirect call or jump instruction.
+func indirectCallee(instr string) string {
+	if instr[0] == 'c' || instr == "bl" || instr == "blr" {
+		return IndirectCall
+	}
+	return IndirectJump
+}
+
+func parseCallGraph(r io.Reader) (CallGraph, error) {
+	cg := make(CallGraph)
+	var callees map[string]bool
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		ln := s.Bytes()
+		if match := objdumpFuncRe.FindSubmatch(ln); match != nil {
+			fn := string(match[1])
+			if callees = cg[fn]; callees == nil {
+				callees = make(map[string]bool)
+				cg[fn] = callees
+			}
+			continue
+		}
+		if callees == nil {
+			continue
+		}
+		if match := objdumpCallRe.FindSubmatch(ln); match != nil {
+			callee := string(match[2])
+			if objdumpThunkRe.MatchString(callee) {
+				callee = indirectCallee(string(match[1]))
+			}
+			callees[callee] = true
+		} else if match := objdumpIndirectRe.FindSubmatch(ln); match != nil {
+			callees[indirectCallee(string(match[1])+string(match[2]))] = true
+		}
+	}
+	return cg, s.Err()
+}
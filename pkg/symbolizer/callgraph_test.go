@@ -0,0 +1,75 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package symbolizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCallGraph(t *testing.T) {
+	const objdump = `
+vmlinux:     file format elf64-x86-64
+
+Disassembly of section .text:
+
+ffffffff81000000 <foo>:
+ffffffff81000000:	push   %rbp
+ffffffff81000001:	callq  ffffffff81000100 <bar>
+ffffffff81000006:	call   ffffffff81000200 <baz.isra.0>
+ffffffff8100000b:	jne    ffffffff81000001 <foo+0x1>
+ffffffff8100000d:	jmp    ffffffff81000300 <qux>
+
+ffffffff81000100 <bar>:
+ffffffff81000100:	jmpq   *%rax
+ffffffff81000102:	callq  ffffffff81000100 <bar+0x10>
+
+ffffffff81000400 <vfs_read>:
+ffffffff81000400:	call   ffffffff81000500 <__x86_indirect_thunk_rax>
+ffffffff81000405:	callq  *0x10(%rbx)
+
+ffff800010001000 <arm>:
+ffff800010001000:	bl	ffff800010002000 <bar>
+ffff800010001004:	b	ffff800010003000 <qux>
+ffff800010001008:	b.ne	ffff800010001000 <arm>
+
+ffff800010004000 <arm_indirect>:
+ffff800010004000:	blr	x2
+`
+	cg, err := parseCallGraph(strings.NewReader(objdump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CallGraph{
+		"foo":          {"bar": true, "baz.isra.0": true, "qux": true},
+		"bar":          {IndirectJump: true},
+		"vfs_read":     {IndirectCall: true},
+		"arm":          {"bar": true, "qux": true},
+		"arm_indirect": {IndirectCall: true},
+	}
+	if !reflect.DeepEqual(cg, want) {
+		t.Fatalf("got call graph %v, want %v", cg, want)
+	}
+	cg["qux"] = map[string]bool{"quux": true}
+	for _, test := range []struct {
+		caller, callee string
+		calls          bool
+	}{
+		{"foo", "bar", true},
+		{"foo", "quux", true},     // via tail call in qux
+		{"bar", "foo", true},      // indirect tail call
+		{"arm", "foo", true},      // via indirect tail call in bar
+		{"vfs_read", "foo", true}, // indirect call
+		{"qux", "foo", false},
+		{"qux", "arm", false},
+	} {
+		if got := cg.Calls(test.caller, test.callee); got != test.calls {
+			t.Errorf("Calls(%v, %v) = %v, want %v", test.caller, test.callee, got, test.calls)
+		}
+	}
+	if !cg.Known("bar") || cg.Known("unknown") {
+		t.Errorf("wrong Known results")
+	}
+}
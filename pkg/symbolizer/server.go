@@ -0,0 +1,66 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package symbolizer
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+type SymbolizeArgs struct {
+	Bin string
+	PCs []uint64
+}
+
+type SymbolizeRes struct {
+	Frames []Frame
+}
+
+// Server serves symbolization requests from other processes (see Configure).
+// All requests share the same addr2line subprocesses and the persistent cache.
+type Server struct {
+	mu   sync.Mutex
+	symb *Symbolizer
+}
+
+func NewServer(cacheDir string) *Server {
+	return &Server{
+		symb: NewCachingSymbolizer(cacheDir),
+	}
+}
+
+func (srv *Server) Symbolize(args *SymbolizeArgs, res *SymbolizeRes) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	frames, err := srv.symb.SymbolizeArray(args.Bin, args.PCs)
+	if err != nil {
+		return err
+	}
+	res.Frames = frames
+	return nil
+}
+
+// Flush saves new symbolization results to the persistent cache.
+func (srv *Server) Flush() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.symb.Flush()
+}
+
+// Serve accepts connections on ln and serves requests on them. Serve blocks.
+func (srv *Server) Serve(ln net.Listener) error {
+	s := rpc.NewServer()
+	if err := s.RegisterName("Symbolizer", srv); err != nil {
+		return err
+	}
+	s.Accept(ln)
+	return nil
+}
+
+func (srv *Server) Close() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.symb.Close()
+}
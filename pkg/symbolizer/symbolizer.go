@@ -9,15 +9,22 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"net/rpc"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
 )
 
 type Symbolizer struct {
 	subprocs map[string]*subprocess
+	cacheDir string
+	server   string
+	client   *rpc.Client
+	caches   map[string]*cache
 }
 
 type Frame struct {
@@ -36,8 +43,35 @@ type subprocess struct {
 	scanner *bufio.Scanner
 }
 
+var defaults struct {
+	sync.Mutex
+	cacheDir string
+	server   string
+}
+
+// Configure sets up symbolizers created by NewSymbolizer afterwards.
+// If cacheDir is not empty, symbolization results are persistently cached in that dir.
+// If server is not empty, symbolization requests are sent to syz-symbolizer daemon
+// listening on that address (symbolizers fall back to local symbolization if the server is not available).
+func Configure(cacheDir, server string) {
+	defaults.Lock()
+	defer defaults.Unlock()
+	defaults.cacheDir = cacheDir
+	defaults.server = server
+}
+
 func NewSymbolizer() *Symbolizer {
-	return &Symbolizer{}
+	defaults.Lock()
+	defer defaults.Unlock()
+	return &Symbolizer{
+		cacheDir: defaults.cacheDir,
+		server:   defaults.server,
+	}
+}
+
+// NewCachingSymbolizer creates a local symbolizer that caches results in cacheDir.
+func NewCachingSymbolizer(cacheDir string) *Symbolizer {
+	return &Symbolizer{cacheDir: cacheDir}
 }
 
 func (s *Symbolizer) Symbolize(bin string, pc uint64) ([]Frame, error) {
@@ -45,6 +79,29 @@ func (s *Symbolizer) Symbolize(bin string, pc uint64) ([]Frame, error) {
 }
 
 func (s *Symbolizer) SymbolizeArray(bin string, pcs []uint64) ([]Frame, error) {
+	if s.server != "" {
+		frames, err := s.symbolizeRemote(bin, pcs)
+		if err == nil {
+			return frames, nil
+		}
+		log.Logf(0, "symbolization server %v failed, symbolizing locally: %v", s.server, err)
+		s.server = ""
+	}
+	c := s.getCache(bin)
+	if c == nil {
+		return s.symbolizeLocal(bin, pcs)
+	}
+	if missing := c.missing(pcs); len(missing) != 0 {
+		frames, err := s.symbolizeLocal(bin, missing)
+		if err != nil {
+			return nil, err
+		}
+		c.add(missing, frames)
+	}
+	return c.get(pcs), nil
+}
+
+func (s *Symbolizer) symbolizeLocal(bin string, pcs []uint64) ([]Frame, error) {
 	sub, err := s.getSubprocess(bin)
 	if err != nil {
 		return nil, err
@@ -52,7 +109,61 @@ func (s *Symbolizer) SymbolizeArray(bin string, pcs []uint64) ([]Frame, error) {
 	return symbolize(sub.input, sub.scanner, pcs)
 }
 
+func (s *Symbolizer) symbolizeRemote(bin string, pcs []uint64) ([]Frame, error) {
+	if s.client == nil {
+		client, err := rpc.Dial("tcp", s.server)
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+	args := &SymbolizeArgs{
+		Bin: bin,
+		PCs: pcs,
+	}
+	res := new(SymbolizeRes)
+	if err := s.client.Call("Symbolizer.Symbolize", args, res); err != nil {
+		return nil, err
+	}
+	return res.Frames, nil
+}
+
+func (s *Symbolizer) getCache(bin string) *cache {
+	if s.cacheDir == "" {
+		return nil
+	}
+	if c, ok := s.caches[bin]; ok {
+		return c
+	}
+	c, err := openCache(s.cacheDir, bin)
+	if err != nil {
+		log.Logf(0, "failed to open symbolization cache for %v: %v", bin, err)
+		c = nil
+	}
+	if s.caches == nil {
+		s.caches = make(map[string]*cache)
+	}
+	s.caches[bin] = c
+	return c
+}
+
+// Flush saves new symbolization results to the persistent cache.
+func (s *Symbolizer) Flush() {
+	for bin, c := range s.caches {
+		if c == nil {
+			continue
+		}
+		if err := c.save(); err != nil {
+			log.Logf(0, "failed to save symbolization cache for %v: %v", bin, err)
+		}
+	}
+}
+
 func (s *Symbolizer) Close() {
+	s.Flush()
+	if s.client != nil {
+		s.client.Close()
+	}
 	for _, sub := range s.subprocs {
 		sub.stdin.Close()
 		sub.stdout.Close()
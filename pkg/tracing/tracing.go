@@ -0,0 +1,322 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package tracing implements a minimal OpenTelemetry-compatible tracer.
+// Finished spans are batched and exported to a collector using OTLP/HTTP with JSON encoding.
+// A nil *Tracer and nil *Span are valid and do nothing, so callers don't need
+// to check whether tracing is enabled.
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Tracer struct {
+	url        string
+	service    string
+	instance   string
+	client     *http.Client
+	batchSize  int
+	mu         sync.Mutex
+	pending    []*Span
+	flushQueue chan []*Span
+}
+
+type Span struct {
+	tracer  *Tracer
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	name    string
+	start   time.Time
+	end     time.Time
+	attrs   map[string]interface{}
+	err     string
+	mu      sync.Mutex
+	ended   bool
+}
+
+// New creates a tracer that exports spans to the OTLP/HTTP collector at addr
+// (e.g. "localhost:4318" or "http://collector:4318").
+// service and instance are used as service.name and service.instance.id resource attributes.
+func New(addr, service, instance string) *Tracer {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	tracer := &Tracer{
+		url:        strings.TrimSuffix(addr, "/") + "/v1/traces",
+		service:    service,
+		instance:   instance,
+		client:     &http.Client{Timeout: time.Minute},
+		batchSize:  512,
+		flushQueue: make(chan []*Span, 16),
+	}
+	go tracer.exportLoop()
+	go func() {
+		for range time.NewTicker(10 * time.Second).C {
+			tracer.Flush()
+		}
+	}()
+	return tracer
+}
+
+// Start starts a new root span.
+func (tracer *Tracer) Start(name string) *Span {
+	if tracer == nil {
+		return nil
+	}
+	span := &Span{
+		tracer: tracer,
+		name:   name,
+		start:  time.Now(),
+	}
+	rand.Read(span.traceID[:])
+	rand.Read(span.spanID[:])
+	return span
+}
+
+// Child starts a new span that has span as parent.
+func (span *Span) Child(name string) *Span {
+	if span == nil {
+		return nil
+	}
+	child := &Span{
+		tracer:  span.tracer,
+		traceID: span.traceID,
+		parent:  span.spanID,
+		name:    name,
+		start:   time.Now(),
+	}
+	rand.Read(child.spanID[:])
+	return child
+}
+
+// StartTime returns the time the span was started.
+func (span *Span) StartTime() time.Time {
+	if span == nil {
+		return time.Time{}
+	}
+	return span.start
+}
+
+// SetAttr attaches a key/value attribute to the span.
+// Supported value types are string, bool, integers and floats,
+// everything else is converted to string.
+func (span *Span) SetAttr(key string, val interface{}) {
+	if span == nil {
+		return
+	}
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	if span.attrs == nil {
+		span.attrs = make(map[string]interface{})
+	}
+	span.attrs[key] = val
+}
+
+// SetError marks the span as failed. A nil err is ignored.
+func (span *Span) SetError(err error) {
+	if span == nil || err == nil {
+		return
+	}
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	span.err = err.Error()
+}
+
+// End finishes the span and queues it for export. Subsequent calls are no-ops.
+func (span *Span) End() {
+	if span == nil {
+		return
+	}
+	span.mu.Lock()
+	if span.ended {
+		span.mu.Unlock()
+		return
+	}
+	span.ended = true
+	span.end = time.Now()
+	span.mu.Unlock()
+	tracer := span.tracer
+	tracer.mu.Lock()
+	tracer.pending = append(tracer.pending, span)
+	full := len(tracer.pending) >= tracer.batchSize
+	tracer.mu.Unlock()
+	if full {
+		tracer.Flush()
+	}
+}
+
+// Flush queues all finished spans for export.
+func (tracer *Tracer) Flush() {
+	if tracer == nil {
+		return
+	}
+	tracer.mu.Lock()
+	spans := tracer.pending
+	tracer.pending = nil
+	tracer.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	select {
+	case tracer.flushQueue <- spans:
+	default:
+		// The collector is not keeping up, drop the batch rather than block fuzzing.
+	}
+}
+
+func (tracer *Tracer) exportLoop() {
+	for spans := range tracer.flushQueue {
+		// Errors are intentionally ignored: tracing is best-effort.
+		tracer.export(spans)
+	}
+}
+
+func (tracer *Tracer) export(spans []*Span) error {
+	data, err := json.Marshal(tracer.serialize(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := tracer.client.Post(tracer.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector returned %v", resp.Status)
+	}
+	return nil
+}
+
+// The types below mirror the OTLP/JSON protocol encoding of ExportTraceServiceRequest.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string      `json:"traceId"`
+	SpanID       string      `json:"spanId"`
+	ParentSpanID string      `json:"parentSpanId,omitempty"`
+	Name         string      `json:"name"`
+	Kind         int         `json:"kind"`
+	Start        string      `json:"startTimeUnixNano"`
+	End          string      `json:"endTimeUnixNano"`
+	Attributes   []otlpAttr  `json:"attributes,omitempty"`
+	Status       *otlpStatus `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	String *string  `json:"stringValue,omitempty"`
+	Bool   *bool    `json:"boolValue,omitempty"`
+	Int    *string  `json:"intValue,omitempty"`
+	Double *float64 `json:"doubleValue,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+func (tracer *Tracer) serialize(spans []*Span) *otlpRequest {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "github.com/google/syzkaller/pkg/tracing"}}
+	for _, span := range spans {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:    hex.EncodeToString(span.traceID[:]),
+			SpanID:     hex.EncodeToString(span.spanID[:]),
+			Name:       span.name,
+			Kind:       spanKindInternal,
+			Start:      fmt.Sprint(span.start.UnixNano()),
+			End:        fmt.Sprint(span.end.UnixNano()),
+			Attributes: serializeAttrs(span.attrs),
+		}
+		if span.parent != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parent[:])
+		}
+		if span.err != "" {
+			s.Status = &otlpStatus{Code: statusError, Message: span.err}
+		}
+		span.mu.Unlock()
+		scope.Spans = append(scope.Spans, s)
+	}
+	resource := otlpResource{Attributes: serializeAttrs(map[string]interface{}{
+		"service.name":        tracer.service,
+		"service.instance.id": tracer.instance,
+	})}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   resource,
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	}
+}
+
+func serializeAttrs(attrs map[string]interface{}) []otlpAttr {
+	var keys []string
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var res []otlpAttr
+	for _, k := range keys {
+		var val otlpValue
+		v := attrs[k]
+		switch v := v.(type) {
+		case string:
+			val.String = &v
+		case bool:
+			val.Bool = &v
+		case int, int32, int64, uint, uint32, uint64:
+			str := fmt.Sprint(v)
+			val.Int = &str
+		case float32:
+			f := float64(v)
+			val.Double = &f
+		case float64:
+			val.Double = &v
+		default:
+			str := fmt.Sprint(v)
+			val.String = &str
+		}
+		res = append(res, otlpAttr{Key: k, Value: val})
+	}
+	return res
+}
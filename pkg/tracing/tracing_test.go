@@ -0,0 +1,91 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNil(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("foo")
+	child := span.Child("bar")
+	child.SetAttr("key", 1)
+	child.SetError(errors.New("error"))
+	child.End()
+	span.End()
+	tracer.Flush()
+}
+
+func TestExport(t *testing.T) {
+	requests := make(chan *otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("bad request path %q", r.URL.Path)
+		}
+		req := new(otlpRequest)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	tracer := New(server.URL, "test-service", "test-instance")
+	root := tracer.Start("root")
+	root.SetAttr("str", "value")
+	root.SetAttr("int", 42)
+	root.SetAttr("bool", true)
+	child := root.Child("child")
+	child.SetError(errors.New("child failed"))
+	child.End()
+	child.End()
+	root.End()
+	tracer.Flush()
+
+	var req *otlpRequest
+	select {
+	case req = <-requests:
+	case <-time.After(time.Minute):
+		t.Fatalf("no export request")
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("bad request structure: %+v", req)
+	}
+	resource := req.ResourceSpans[0].Resource
+	if len(resource.Attributes) != 2 || resource.Attributes[0].Key != "service.instance.id" ||
+		*resource.Attributes[0].Value.String != "test-instance" ||
+		*resource.Attributes[1].Value.String != "test-service" {
+		t.Fatalf("bad resource attributes: %+v", resource.Attributes)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %v spans, want 2", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.Name != "child" || r.Name != "root" {
+		t.Fatalf("bad span names: %q, %q", c.Name, r.Name)
+	}
+	if c.TraceID != r.TraceID || len(r.TraceID) != 32 {
+		t.Fatalf("bad trace ids: %q, %q", c.TraceID, r.TraceID)
+	}
+	if c.ParentSpanID != r.SpanID || r.ParentSpanID != "" {
+		t.Fatalf("bad parent span ids: %q/%q, %q", c.ParentSpanID, r.SpanID, r.ParentSpanID)
+	}
+	if c.Status == nil || c.Status.Code != statusError || c.Status.Message != "child failed" {
+		t.Fatalf("bad child status: %+v", c.Status)
+	}
+	if r.Status != nil {
+		t.Fatalf("bad root status: %+v", r.Status)
+	}
+	if len(r.Attributes) != 3 || *r.Attributes[0].Value.Bool != true ||
+		*r.Attributes[1].Value.Int != "42" || *r.Attributes[2].Value.String != "value" {
+		t.Fatalf("bad root attributes: %+v", r.Attributes)
+	}
+}
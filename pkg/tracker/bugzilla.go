@@ -0,0 +1,95 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package tracker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Bugzilla REST API (Bugzilla 5.0+).
+// Attachments are attached to the bug as plain text files.
+type bugzilla struct {
+	cfg *Config
+	client
+}
+
+func newBugzilla(cfg *Config) *bugzilla {
+	return &bugzilla{
+		cfg: cfg,
+		client: client{
+			url: cfg.URL,
+			header: http.Header{
+				"X-Bugzilla-Api-Key": []string{cfg.Token},
+			},
+		},
+	}
+}
+
+func (bz *bugzilla) Create(issue *Issue) (string, string, error) {
+	version := bz.cfg.Version
+	if version == "" {
+		version = "unspecified"
+	}
+	req := map[string]interface{}{
+		"product":     bz.cfg.Project,
+		"component":   bz.cfg.Component,
+		"version":     version,
+		"summary":     issue.Title,
+		"description": issue.Body,
+	}
+	reply := new(struct {
+		ID int `json:"id"`
+	})
+	if err := bz.query("POST", "/rest/bug", req, reply); err != nil {
+		return "", "", err
+	}
+	id := strconv.Itoa(reply.ID)
+	link := fmt.Sprintf("%v/show_bug.cgi?id=%v", strings.TrimSuffix(bz.cfg.URL, "/"), id)
+	return id, link, bz.attach(id, issue.Attachments)
+}
+
+func (bz *bugzilla) Comment(id string, comment *Issue) error {
+	if err := bz.attach(id, comment.Attachments); err != nil {
+		return err
+	}
+	req := map[string]interface{}{
+		"comment": comment.Body,
+	}
+	return bz.query("POST", "/rest/bug/"+id+"/comment", req, nil)
+}
+
+func (bz *bugzilla) Close(id, comment string, fixed bool) error {
+	resolution := "INVALID"
+	if fixed {
+		resolution = "FIXED"
+	}
+	req := map[string]interface{}{
+		"status":     "RESOLVED",
+		"resolution": resolution,
+		"comment": map[string]interface{}{
+			"body": comment,
+		},
+	}
+	return bz.query("PUT", "/rest/bug/"+id, req, nil)
+}
+
+func (bz *bugzilla) attach(id string, attachments []Attachment) error {
+	for _, att := range attachments {
+		req := map[string]interface{}{
+			"ids":          []string{id},
+			"data":         base64.StdEncoding.EncodeToString(att.Data),
+			"file_name":    att.Name,
+			"summary":      att.Name,
+			"content_type": "text/plain",
+		}
+		if err := bz.query("POST", "/rest/bug/"+id+"/attachment", req, nil); err != nil {
+			return fmt.Errorf("failed to attach %v: %v", att.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package tracker
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// GitHub Issues REST API v3.
+// GitHub does not support attachments via API, so they are inlined into the issue text.
+type gitHub struct {
+	cfg *Config
+	client
+}
+
+func newGitHub(cfg *Config) *gitHub {
+	url := cfg.URL
+	if url == "" {
+		url = "https://api.github.com"
+	}
+	return &gitHub{
+		cfg: cfg,
+		client: client{
+			url: url,
+			header: http.Header{
+				"Accept":        []string{"application/vnd.github.v3+json"},
+				"Authorization": []string{"token " + cfg.Token},
+			},
+		},
+	}
+}
+
+func (gh *gitHub) Create(issue *Issue) (string, string, error) {
+	req := map[string]interface{}{
+		"title": issue.Title,
+		"body":  inlineAttachments(issue.Body, issue.Attachments),
+	}
+	if len(gh.cfg.Labels) != 0 {
+		req["labels"] = gh.cfg.Labels
+	}
+	reply := new(struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	})
+	if err := gh.query("POST", gh.path(""), req, reply); err != nil {
+		return "", "", err
+	}
+	return strconv.Itoa(reply.Number), reply.HTMLURL, nil
+}
+
+func (gh *gitHub) Comment(id string, comment *Issue) error {
+	req := map[string]interface{}{
+		"body": inlineAttachments(comment.Body, comment.Attachments),
+	}
+	return gh.query("POST", gh.path("/"+id+"/comments"), req, nil)
+}
+
+func (gh *gitHub) Close(id, comment string, fixed bool) error {
+	if err := gh.Comment(id, &Issue{Body: comment}); err != nil {
+		return err
+	}
+	req := map[string]interface{}{
+		"state": "closed",
+	}
+	return gh.query("PATCH", gh.path("/"+id), req, nil)
+}
+
+func (gh *gitHub) path(suffix string) string {
+	return fmt.Sprintf("/repos/%v/issues%v", gh.cfg.Project, suffix)
+}
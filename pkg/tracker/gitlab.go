@@ -0,0 +1,108 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package tracker
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GitLab Issues REST API v4.
+// Attachments are uploaded to the project and linked from the issue text.
+type gitLab struct {
+	cfg *Config
+	client
+}
+
+func newGitLab(cfg *Config) *gitLab {
+	url := cfg.URL
+	if url == "" {
+		url = "https://gitlab.com/api/v4"
+	}
+	return &gitLab{
+		cfg: cfg,
+		client: client{
+			url: url,
+			header: http.Header{
+				"Private-Token": []string{cfg.Token},
+			},
+		},
+	}
+}
+
+func (gl *gitLab) Create(issue *Issue) (string, string, error) {
+	text, err := gl.upload(issue.Body, issue.Attachments)
+	if err != nil {
+		return "", "", err
+	}
+	req := map[string]interface{}{
+		"title":       issue.Title,
+		"description": text,
+	}
+	if len(gl.cfg.Labels) != 0 {
+		req["labels"] = strings.Join(gl.cfg.Labels, ",")
+	}
+	reply := new(struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	})
+	if err := gl.query("POST", gl.path("/issues"), req, reply); err != nil {
+		return "", "", err
+	}
+	return strconv.Itoa(reply.IID), reply.WebURL, nil
+}
+
+func (gl *gitLab) Comment(id string, comment *Issue) error {
+	text, err := gl.upload(comment.Body, comment.Attachments)
+	if err != nil {
+		return err
+	}
+	req := map[string]interface{}{
+		"body": text,
+	}
+	return gl.query("POST", gl.path("/issues/"+id+"/notes"), req, nil)
+}
+
+func (gl *gitLab) Close(id, comment string, fixed bool) error {
+	if err := gl.Comment(id, &Issue{Body: comment}); err != nil {
+		return err
+	}
+	req := map[string]interface{}{
+		"state_event": "close",
+	}
+	return gl.query("PUT", gl.path("/issues/"+id), req, nil)
+}
+
+// upload uploads attachments to the project and returns text with links to the uploaded files.
+func (gl *gitLab) upload(text string, attachments []Attachment) (string, error) {
+	for _, att := range attachments {
+		body := new(bytes.Buffer)
+		w := multipart.NewWriter(body)
+		part, err := w.CreateFormFile("file", att.Name)
+		if err != nil {
+			return "", err
+		}
+		part.Write(att.Data)
+		if err := w.Close(); err != nil {
+			return "", err
+		}
+		reply := new(struct {
+			Markdown string `json:"markdown"`
+		})
+		if err := gl.do("POST", gl.path("/uploads"), w.FormDataContentType(), body, reply); err != nil {
+			return "", fmt.Errorf("failed to upload %v: %v", att.Name, err)
+		}
+		text += "\n\n" + reply.Markdown
+	}
+	return text, nil
+}
+
+func (gl *gitLab) path(suffix string) string {
+	return "/projects/" + url.PathEscape(gl.cfg.Project) + suffix
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package tracker provides minimal clients for external issue trackers
+// (GitHub Issues, GitLab and Bugzilla) used to file syzkaller bugs.
+package tracker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type Config struct {
+	// One of "github", "gitlab" or "bugzilla".
+	Type string `json:"type"`
+	// API base URL. Defaults to https://api.github.com for github
+	// and to https://gitlab.com/api/v4 for gitlab, required for bugzilla.
+	URL string `json:"url"`
+	// github: owner/repo, gitlab: project ID or full path, bugzilla: product.
+	Project string `json:"project"`
+	// Bugzilla component and version of the product.
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	// API token (github personal access token, gitlab private token, bugzilla API key).
+	Token string `json:"token"`
+	// Labels for new issues (github and gitlab only).
+	Labels []string `json:"labels"`
+}
+
+type Issue struct {
+	Title       string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a text file attached to an issue (e.g. report, reproducer or kernel config).
+// Trackers that don't support attachments inline them into the issue text.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+type Tracker interface {
+	// Create files a new issue and returns its tracker-specific ID and a link to the issue.
+	Create(issue *Issue) (id, link string, err error)
+	// Comment adds a comment (with optional attachments) to the issue.
+	Comment(id string, comment *Issue) error
+	// Close closes the issue with the comment. Fixed says if the issue is closed as fixed
+	// (for trackers that distinguish resolutions).
+	Close(id, comment string, fixed bool) error
+}
+
+func New(cfg *Config) (Tracker, error) {
+	if cfg.Project == "" {
+		return nil, fmt.Errorf("tracker project is not specified")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("tracker token is not specified")
+	}
+	switch cfg.Type {
+	case "github":
+		return newGitHub(cfg), nil
+	case "gitlab":
+		return newGitLab(cfg), nil
+	case "bugzilla":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("bugzilla URL is not specified")
+		}
+		if cfg.Component == "" {
+			return nil, fmt.Errorf("bugzilla component is not specified")
+		}
+		return newBugzilla(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown tracker type %q", cfg.Type)
+	}
+}
+
+// client implements the common part of the JSON REST APIs.
+type client struct {
+	url    string
+	header http.Header
+}
+
+func (cl *client) query(method, path string, req, reply interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %v", err)
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+	return cl.do(method, path, contentType, body, reply)
+}
+
+func (cl *client) do(method, path, contentType string, body io.Reader, reply interface{}) error {
+	r, err := http.NewRequest(method, strings.TrimSuffix(cl.url, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	for key, val := range cl.header {
+		r.Header[key] = val
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return fmt.Errorf("%v %v failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%v %v failed: %v", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%v %v failed: %v\n%s", method, path, resp.Status, data)
+	}
+	if reply != nil {
+		if err := json.Unmarshal(data, reply); err != nil {
+			return fmt.Errorf("failed to unmarshal reply: %v\n%s", err, data)
+		}
+	}
+	return nil
+}
+
+// maxInlineAttachment limits size of attachments inlined into issue text.
+const maxInlineAttachment = 16 << 10
+
+// inlineAttachments appends attachments to the markdown text as collapsible sections.
+func inlineAttachments(text string, attachments []Attachment) string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(text)
+	for _, att := range attachments {
+		data, truncated := att.Data, ""
+		if len(data) > maxInlineAttachment {
+			data, truncated = data[:maxInlineAttachment], "\n<truncated>"
+		}
+		fmt.Fprintf(buf, "\n\n<details><summary>%v</summary>\n\n```\n%s%v\n```\n\n</details>",
+			att.Name, bytes.Replace(data, []byte("```"), []byte("` ` `"), -1), truncated)
+	}
+	return buf.String()
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// testServer records all requests and replies with canned JSON bodies keyed by "METHOD path".
+func testServer(t *testing.T, auth string, replies map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(fmt.Sprint(r.Header), auth) {
+			t.Errorf("%v %v: no auth header %q in %v", r.Method, r.URL.Path, auth, r.Header)
+		}
+		req := request{Method: r.Method, Path: r.URL.EscapedPath()}
+		if r.Header.Get("Content-Type") == "application/json" {
+			data, _ := ioutil.ReadAll(r.Body)
+			if err := json.Unmarshal(data, &req.Body); err != nil {
+				t.Errorf("bad request body: %v\n%s", err, data)
+			}
+		}
+		requests = append(requests, req)
+		reply, ok := replies[r.Method+" "+req.Path]
+		if !ok {
+			reply = "{}"
+		}
+		w.Write([]byte(reply))
+	}))
+	return srv, &requests
+}
+
+func TestGitHub(t *testing.T) {
+	srv, requests := testServer(t, "token secret", map[string]string{
+		"POST /repos/foo/bar/issues": `{"number": 42, "html_url": "https://github.com/foo/bar/issues/42"}`,
+	})
+	defer srv.Close()
+	tr, err := New(&Config{
+		Type:    "github",
+		URL:     srv.URL,
+		Project: "foo/bar",
+		Token:   "secret",
+		Labels:  []string{"syzbot"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, link, err := tr.Create(&Issue{
+		Title:       "KASAN: use-after-free in foo",
+		Body:        "description",
+		Attachments: []Attachment{{Name: "report", Data: []byte("BUG: KASAN")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "42" || link != "https://github.com/foo/bar/issues/42" {
+		t.Fatalf("got id=%q link=%q", id, link)
+	}
+	if err := tr.Close(id, "fixed", true); err != nil {
+		t.Fatal(err)
+	}
+	want := []request{
+		{"POST", "/repos/foo/bar/issues", map[string]interface{}{
+			"title":  "KASAN: use-after-free in foo",
+			"body":   "description\n\n<details><summary>report</summary>\n\n```\nBUG: KASAN\n```\n\n</details>",
+			"labels": []interface{}{"syzbot"},
+		}},
+		{"POST", "/repos/foo/bar/issues/42/comments", map[string]interface{}{"body": "fixed"}},
+		{"PATCH", "/repos/foo/bar/issues/42", map[string]interface{}{"state": "closed"}},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Fatalf("got requests:\n%+v\nwant:\n%+v", *requests, want)
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	srv, requests := testServer(t, "secret", map[string]string{
+		"POST /projects/foo%2Fbar/uploads": `{"markdown": "[report](/uploads/1/report)"}`,
+		"POST /projects/foo%2Fbar/issues":  `{"iid": 7, "web_url": "https://gitlab.com/foo/bar/issues/7"}`,
+	})
+	defer srv.Close()
+	tr, err := New(&Config{
+		Type:    "gitlab",
+		URL:     srv.URL,
+		Project: "foo/bar",
+		Token:   "secret",
+		Labels:  []string{"syzbot", "kernel"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, link, err := tr.Create(&Issue{
+		Title:       "WARNING in bar",
+		Body:        "description",
+		Attachments: []Attachment{{Name: "report", Data: []byte("WARNING")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "7" || link != "https://gitlab.com/foo/bar/issues/7" {
+		t.Fatalf("got id=%q link=%q", id, link)
+	}
+	if err := tr.Comment(id, &Issue{Body: "repro"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Close(id, "obsolete", false); err != nil {
+		t.Fatal(err)
+	}
+	want := []request{
+		{"POST", "/projects/foo%2Fbar/uploads", nil},
+		{"POST", "/projects/foo%2Fbar/issues", map[string]interface{}{
+			"title":       "WARNING in bar",
+			"description": "description\n\n[report](/uploads/1/report)",
+			"labels":      "syzbot,kernel",
+		}},
+		{"POST", "/projects/foo%2Fbar/issues/7/notes", map[string]interface{}{"body": "repro"}},
+		{"POST", "/projects/foo%2Fbar/issues/7/notes", map[string]interface{}{"body": "obsolete"}},
+		{"PUT", "/projects/foo%2Fbar/issues/7", map[string]interface{}{"state_event": "close"}},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Fatalf("got requests:\n%+v\nwant:\n%+v", *requests, want)
+	}
+}
+
+func TestBugzilla(t *testing.T) {
+	srv, requests := testServer(t, "secret", map[string]string{
+		"POST /rest/bug": `{"id": 1000}`,
+	})
+	defer srv.Close()
+	tr, err := New(&Config{
+		Type:      "bugzilla",
+		URL:       srv.URL,
+		Project:   "Linux",
+		Component: "Kernel",
+		Token:     "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, link, err := tr.Create(&Issue{
+		Title:       "INFO: task hung in baz",
+		Body:        "description",
+		Attachments: []Attachment{{Name: "repro.c", Data: []byte("int main() {}")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "1000" || link != srv.URL+"/show_bug.cgi?id=1000" {
+		t.Fatalf("got id=%q link=%q", id, link)
+	}
+	if err := tr.Close(id, "fixed by commit", true); err != nil {
+		t.Fatal(err)
+	}
+	want := []request{
+		{"POST", "/rest/bug", map[string]interface{}{
+			"product":     "Linux",
+			"component":   "Kernel",
+			"version":     "unspecified",
+			"summary":     "INFO: task hung in baz",
+			"description": "description",
+		}},
+		{"POST", "/rest/bug/1000/attachment", map[string]interface{}{
+			"ids":          []interface{}{"1000"},
+			"data":         "aW50IG1haW4oKSB7fQ==",
+			"file_name":    "repro.c",
+			"summary":      "repro.c",
+			"content_type": "text/plain",
+		}},
+		{"PUT", "/rest/bug/1000", map[string]interface{}{
+			"status":     "RESOLVED",
+			"resolution": "FIXED",
+			"comment":    map[string]interface{}{"body": "fixed by commit"},
+		}},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Fatalf("got requests:\n%+v\nwant:\n%+v", *requests, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, cfg := range []*Config{
+		{Type: "jira", Project: "foo", Token: "secret"},
+		{Type: "github", Token: "secret"},
+		{Type: "github", Project: "foo/bar"},
+		{Type: "bugzilla", Project: "Linux", Component: "Kernel", Token: "secret"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("no error for config %+v", cfg)
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	tr, err := New(&Config{Type: "github", URL: srv.URL, Project: "foo/bar", Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tr.Create(&Issue{Title: "title"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
@@ -148,16 +148,21 @@ func (target *Target) isAnyPtr(typ Type) bool {
 
 func (p *Prog) complexPtrs() (res []*PointerArg) {
 	for _, c := range p.Calls {
-		ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
-			if ptrArg, ok := arg.(*PointerArg); ok && p.Target.isComplexPtr(ptrArg) {
-				res = append(res, ptrArg)
-				ctx.Stop = true
-			}
-		})
+		res = append(res, p.callComplexPtrs(c)...)
 	}
 	return
 }
 
+func (p *Prog) callComplexPtrs(c *Call) (res []*PointerArg) {
+	ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+		if ptrArg, ok := arg.(*PointerArg); ok && p.Target.isComplexPtr(ptrArg) {
+			res = append(res, ptrArg)
+			ctx.Stop = true
+		}
+	})
+	return
+}
+
 func (target *Target) isComplexPtr(arg *PointerArg) bool {
 	if arg.Res == nil || arg.Type().Dir() != DirIn {
 		return false
@@ -83,7 +83,7 @@ func (w *execContext) serializeCall(c *Call) {
 	// since checksum values can depend on values of the latter ones
 	w.writeChecksums()
 	// Generate the call itself.
-	w.write(uint64(c.Meta.ID))
+	w.write(uint64(c.Meta.execID))
 	if c.Ret != nil && len(c.Ret.uses) != 0 {
 		if _, ok := w.args[c.Ret]; ok {
 			panic("argInfo is already created for return value")
@@ -0,0 +1,331 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// fsImageFormat describes a filesystem image format for structure-aware generation
+// and mutation of fs_image arguments. Mutation of opaque blobs is very unlikely to produce
+// an image that passes superblock validation, so images are generated from a valid seed
+// and then metadata fields are mutated as integers and checksums are recomputed.
+type fsImageFormat struct {
+	// seed returns a small valid image.
+	seed func() []byte
+	// fields are metadata fields (superblock, group descriptors, etc) of the seed image.
+	fields []fsImageField
+	// regions are metadata regions (inode tables, directories, etc) of the seed image
+	// that are mutated as byte arrays.
+	regions []fsImageRegion
+	// fixup recomputes checksums after mutation, can be nil.
+	fixup func(data []byte)
+}
+
+type fsImageField struct {
+	off       uint64
+	size      uint64 // 1, 2, 4 or 8
+	bigEndian bool
+}
+
+type fsImageRegion struct {
+	off  uint64
+	size uint64
+}
+
+// fsImageFormats is indexed by BufferType.SubKind of BufferFsImage types.
+var fsImageFormats = map[string]*fsImageFormat{
+	"ext4":  extImageFormat,
+	"vfat":  fatImageFormat,
+	"msdos": fatImageFormat,
+}
+
+func (r *randGen) generateFsImage(t *BufferType) []byte {
+	format := fsImageFormats[t.SubKind]
+	data := format.seed()
+	if r.bin() {
+		data = r.mutateFsImage(t, data)
+	}
+	return data
+}
+
+func (r *randGen) mutateFsImage(t *BufferType, data []byte) []byte {
+	format := fsImageFormats[t.SubKind]
+	for stop := false; !stop; stop = r.oneOf(3) {
+		switch {
+		case r.nOutOf(7, 10):
+			f := format.fields[r.Intn(len(format.fields))]
+			if f.off+f.size > uint64(len(data)) {
+				continue
+			}
+			f.store(data, r.mutateFsImageField(f.load(data), f.size))
+		case r.nOutOf(2, 3):
+			reg := format.regions[r.Intn(len(format.regions))]
+			if reg.off+reg.size > uint64(len(data)) {
+				continue
+			}
+			region := append([]byte{}, data[reg.off:reg.off+reg.size]...)
+			copy(data[reg.off:], mutateData(r, region, reg.size, reg.size))
+		default:
+			// Keep the image size, offsets of metadata depend on it.
+			data = mutateData(r, data, uint64(len(data)), uint64(len(data)))
+		}
+	}
+	fixupFsImage(t, data)
+	return data
+}
+
+func (r *randGen) mutateFsImageField(v, size uint64) uint64 {
+	switch {
+	case r.nOutOf(1, 4):
+		v += uint64(r.Intn(4)) + 1
+	case r.nOutOf(1, 3):
+		v -= uint64(r.Intn(4)) + 1
+	case r.nOutOf(1, 2):
+		v ^= 1 << uint64(r.Intn(int(size*8)))
+	default:
+		v = r.randInt()
+	}
+	return v
+}
+
+// fixupFsImage recomputes checksums of the image after it was changed.
+func fixupFsImage(t *BufferType, data []byte) {
+	if format := fsImageFormats[t.SubKind]; format.fixup != nil {
+		format.fixup(data)
+	}
+}
+
+func (f fsImageField) load(data []byte) uint64 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+	switch f.size {
+	case 1:
+		return uint64(data[f.off])
+	case 2:
+		return uint64(order.Uint16(data[f.off:]))
+	case 4:
+		return uint64(order.Uint32(data[f.off:]))
+	case 8:
+		return order.Uint64(data[f.off:])
+	default:
+		panic("bad fs image field size")
+	}
+}
+
+func (f fsImageField) store(data []byte, v uint64) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+	switch f.size {
+	case 1:
+		data[f.off] = byte(v)
+	case 2:
+		order.PutUint16(data[f.off:], uint16(v))
+	case 4:
+		order.PutUint32(data[f.off:], uint32(v))
+	case 8:
+		order.PutUint64(data[f.off:], v)
+	default:
+		panic("bad fs image field size")
+	}
+}
+
+func fsImageFields(base uint64, sizes ...uint64) []fsImageField {
+	var fields []fsImageField
+	for i := 0; i < len(sizes); i += 2 {
+		fields = append(fields, fsImageField{off: base + sizes[i], size: sizes[i+1]})
+	}
+	return fields
+}
+
+// Layout of the ext2 seed image: 1KB blocks, a single block group.
+// The image uses only features supported by ext2, ext3 and ext4 drivers.
+const (
+	extBlockSize      = 1024
+	extBlocks         = 16
+	extInodes         = 16
+	extInodeSize      = 128
+	extSuperBlock     = 1 * extBlockSize
+	extGroupDesc      = 2 * extBlockSize
+	extBlockBitmap    = 3
+	extInodeBitmap    = 4
+	extInodeTable     = 5
+	extRootDir        = 7
+	extUsedBlocks     = 7 // blocks 1-7, block 0 is not part of the group
+	extFirstIno       = 11
+	extSuperBlockSize = 1024
+	extChecksumOff    = 0x3fc
+
+	extFeatureRoCompatMetadataCsum = 0x400
+	extFeatureIncompatFiletype     = 0x2
+)
+
+var extImageFormat = &fsImageFormat{
+	seed: extImageSeed,
+	fields: append(
+		// Offset/size pairs of superblock fields up to s_kbytes_written (see struct ext4_super_block).
+		fsImageFields(extSuperBlock,
+			0, 4, 4, 4, 8, 4, 12, 4, 16, 4, 20, 4, 24, 4, 28, 4, 32, 4, 36, 4, 40, 4, 52, 2, 54, 2,
+			56, 2, 58, 2, 60, 2, 62, 2, 76, 4, 84, 4, 88, 2, 90, 2, 92, 4, 96, 4, 100, 4, 204, 1,
+			205, 1, 206, 2, 224, 4, 228, 4, 232, 4, 252, 1, 253, 1, 254, 2, 256, 4, 260, 4, 336, 4,
+			340, 4, 344, 4, 348, 2, 350, 2, 352, 4, 356, 2, 358, 2, 360, 8, 368, 4, 372, 1, 373, 1,
+			374, 1, 376, 8),
+		// Group descriptor and root inode (see struct ext4_group_desc and struct ext4_inode).
+		append(fsImageFields(extGroupDesc, 0, 4, 4, 4, 8, 4, 12, 2, 14, 2, 16, 2, 18, 2, 28, 2),
+			fsImageFields(extInodeTable*extBlockSize+extInodeSize,
+				0, 2, 4, 4, 20, 4, 26, 2, 28, 4, 32, 4, 40, 4, 44, 4, 100, 4, 104, 4, 108, 4)...)...),
+	regions: []fsImageRegion{
+		{extSuperBlock, extSuperBlockSize},
+		{extGroupDesc, 32},
+		{extBlockBitmap * extBlockSize, 16},
+		{extInodeBitmap * extBlockSize, 16},
+		{extInodeTable * extBlockSize, 2 * extBlockSize},
+		{extRootDir * extBlockSize, 32},
+	},
+	fixup: extImageFixup,
+}
+
+func extImageSeed() []byte {
+	data := make([]byte, extBlocks*extBlockSize)
+	le := binary.LittleEndian
+	sb := data[extSuperBlock:]
+	le.PutUint32(sb[0:], extInodes)                   // s_inodes_count
+	le.PutUint32(sb[4:], extBlocks)                   // s_blocks_count_lo
+	le.PutUint32(sb[12:], extBlocks-1-extUsedBlocks)  // s_free_blocks_count_lo
+	le.PutUint32(sb[16:], extInodes-(extFirstIno-1))  // s_free_inodes_count
+	le.PutUint32(sb[20:], 1)                          // s_first_data_block
+	le.PutUint32(sb[32:], extBlockSize*8)             // s_blocks_per_group
+	le.PutUint32(sb[36:], extBlockSize*8)             // s_clusters_per_group
+	le.PutUint32(sb[40:], extInodes)                  // s_inodes_per_group
+	le.PutUint16(sb[54:], 0xffff)                     // s_max_mnt_count
+	le.PutUint16(sb[56:], 0xef53)                     // s_magic
+	le.PutUint16(sb[58:], 1)                          // s_state: clean
+	le.PutUint16(sb[60:], 1)                          // s_errors: continue
+	le.PutUint32(sb[76:], 1)                          // s_rev_level: dynamic
+	le.PutUint32(sb[84:], extFirstIno)                // s_first_ino
+	le.PutUint16(sb[88:], extInodeSize)               // s_inode_size
+	le.PutUint32(sb[96:], extFeatureIncompatFiletype) // s_feature_incompat
+	copy(sb[104:], "syzkallersyzkall")                // s_uuid
+	copy(sb[120:], "syzkaller")                       // s_volume_name
+	gd := data[extGroupDesc:]
+	le.PutUint32(gd[0:], extBlockBitmap)             // bg_block_bitmap_lo
+	le.PutUint32(gd[4:], extInodeBitmap)             // bg_inode_bitmap_lo
+	le.PutUint32(gd[8:], extInodeTable)              // bg_inode_table_lo
+	le.PutUint16(gd[12:], extBlocks-1-extUsedBlocks) // bg_free_blocks_count_lo
+	le.PutUint16(gd[14:], extInodes-(extFirstIno-1)) // bg_free_inodes_count_lo
+	le.PutUint16(gd[16:], 1)                         // bg_used_dirs_count_lo
+	// Bits past the end of the group are set as mke2fs does.
+	extSetBits(data[extBlockBitmap*extBlockSize:], 0, extUsedBlocks)
+	extSetBits(data[extBlockBitmap*extBlockSize:], extBlocks-1, extBlockSize*8)
+	extSetBits(data[extInodeBitmap*extBlockSize:], 0, extFirstIno-1)
+	extSetBits(data[extInodeBitmap*extBlockSize:], extInodes, extBlockSize*8)
+	root := data[extInodeTable*extBlockSize+extInodeSize:]
+	le.PutUint16(root[0:], 0x41ed)            // i_mode: S_IFDIR | 0755
+	le.PutUint32(root[4:], extBlockSize)      // i_size_lo
+	le.PutUint16(root[26:], 2)                // i_links_count
+	le.PutUint32(root[28:], extBlockSize/512) // i_blocks_lo
+	le.PutUint32(root[40:], extRootDir)       // i_block[0]
+	dir := data[extRootDir*extBlockSize:]
+	extDirEntry(dir[0:], 2, 12, ".")
+	extDirEntry(dir[12:], 2, extBlockSize-12, "..")
+	return data
+}
+
+func extSetBits(bitmap []byte, from, to int) {
+	for i := from; i < to; i++ {
+		bitmap[i/8] |= 1 << uint(i%8)
+	}
+}
+
+func extDirEntry(data []byte, inode uint32, recLen uint16, name string) {
+	binary.LittleEndian.PutUint32(data[0:], inode)
+	binary.LittleEndian.PutUint16(data[4:], recLen)
+	data[6] = byte(len(name))
+	data[7] = 2 // EXT4_FT_DIR
+	copy(data[8:], name)
+}
+
+// extImageFixup recomputes the superblock and group descriptor checksums
+// if the metadata_csum feature is enabled (other metadata checksums are left to the kernel
+// to catch, the seed image does not use metadata_csum).
+func extImageFixup(data []byte) {
+	if len(data) < extGroupDesc+32 {
+		return
+	}
+	le := binary.LittleEndian
+	sb := data[extSuperBlock : extSuperBlock+extSuperBlockSize]
+	if le.Uint32(sb[100:])&extFeatureRoCompatMetadataCsum == 0 {
+		return
+	}
+	sb[373] = 1 // s_checksum_type: crc32c, the only supported type
+	// Note: the kernel uses crc32c without the final inversion.
+	le.PutUint32(sb[extChecksumOff:], ^crc32.Checksum(sb[:extChecksumOff], castagnoliTable))
+	seed := ^crc32.Checksum(sb[104:120], castagnoliTable) // s_uuid
+	gd := data[extGroupDesc : extGroupDesc+32]
+	crc := crc32c(seed, []byte{0, 0, 0, 0}) // group number
+	crc = crc32c(crc, gd[:30])
+	crc = crc32c(crc, []byte{0, 0}) // bg_checksum
+	le.PutUint16(gd[30:], uint16(crc))
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc32c continues the kernel crc32c (without pre and post inversion) from crc.
+func crc32c(crc uint32, data []byte) uint32 {
+	return ^crc32.Update(^crc, castagnoliTable, data)
+}
+
+// Layout of the FAT12 seed image: 512-byte sectors, 1 sector per cluster.
+const (
+	fatSectorSize  = 512
+	fatSectors     = 32
+	fatRootEntries = 16
+	fatFirstFAT    = 1 * fatSectorSize
+	fatRootDir     = 3 * fatSectorSize
+)
+
+var fatImageFormat = &fsImageFormat{
+	seed: fatImageSeed,
+	// Offset/size pairs of boot sector fields (see struct fat_boot_sector).
+	fields: fsImageFields(0,
+		11, 2, 13, 1, 14, 2, 16, 1, 17, 2, 19, 2, 21, 1, 22, 2, 24, 2, 26, 2, 28, 4, 32, 4, 36, 4,
+		38, 1, 39, 4, 40, 2, 42, 2, 44, 4, 48, 2, 50, 2, 510, 2),
+	regions: []fsImageRegion{
+		{0, 64},
+		{fatFirstFAT, 16},
+		{fatFirstFAT + fatSectorSize, 16},
+		{fatRootDir, 64},
+	},
+}
+
+func fatImageSeed() []byte {
+	data := make([]byte, fatSectors*fatSectorSize)
+	le := binary.LittleEndian
+	copy(data[0:], "\xeb\x3c\x90mkfs.fat")  // jump and OEM name
+	le.PutUint16(data[11:], fatSectorSize)  // sector_size
+	data[13] = 1                            // sec_per_clus
+	le.PutUint16(data[14:], 1)              // reserved
+	data[16] = 2                            // fats
+	le.PutUint16(data[17:], fatRootEntries) // dir_entries
+	le.PutUint16(data[19:], fatSectors)     // sectors
+	data[21] = 0xf8                         // media
+	le.PutUint16(data[22:], 1)              // fat_length
+	le.PutUint16(data[24:], 32)             // secs_track
+	le.PutUint16(data[26:], 2)              // heads
+	data[36] = 0x80                         // drive_number
+	data[38] = 0x29                         // signature
+	le.PutUint32(data[39:], 0x73797a6b)     // vol_id
+	copy(data[43:], "SYZKALLER  FAT12   ")  // vol_label and fs_type
+	le.PutUint16(data[510:], 0xaa55)        // boot sector signature
+	for _, fat := range []int{fatFirstFAT, fatFirstFAT + fatSectorSize} {
+		copy(data[fat:], "\xf8\xff\xff") // media descriptor and end of chain
+	}
+	copy(data[fatRootDir:], "SYZKALLER  \x08") // volume label entry
+	return data
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFsImageExtSeed(t *testing.T) {
+	data := extImageSeed()
+	le := binary.LittleEndian
+	sb := data[extSuperBlock:]
+	if magic := le.Uint16(sb[56:]); magic != 0xef53 {
+		t.Fatalf("bad magic 0x%x", magic)
+	}
+	if size := le.Uint32(sb[4:]) * extBlockSize; size != uint32(len(data)) {
+		t.Fatalf("blocks count does not match image size: %v vs %v", size, len(data))
+	}
+	// Free counts must match bitmaps, otherwise the kernel complains.
+	countFree := func(bitmap []byte, n int) uint32 {
+		free := uint32(0)
+		for i := 0; i < n; i++ {
+			if bitmap[i/8]&(1<<uint(i%8)) == 0 {
+				free++
+			}
+		}
+		return free
+	}
+	freeBlocks := countFree(data[extBlockBitmap*extBlockSize:], extBlocks-1)
+	freeInodes := countFree(data[extInodeBitmap*extBlockSize:], extInodes)
+	gd := data[extGroupDesc:]
+	if freeBlocks != le.Uint32(sb[12:]) || freeBlocks != uint32(le.Uint16(gd[12:])) {
+		t.Errorf("free blocks: bitmap %v, superblock %v, group %v",
+			freeBlocks, le.Uint32(sb[12:]), le.Uint16(gd[12:]))
+	}
+	if freeInodes != le.Uint32(sb[16:]) || freeInodes != uint32(le.Uint16(gd[14:])) {
+		t.Errorf("free inodes: bitmap %v, superblock %v, group %v",
+			freeInodes, le.Uint32(sb[16:]), le.Uint16(gd[14:]))
+	}
+	dir := data[extRootDir*extBlockSize:]
+	if recLen := le.Uint16(dir[4:]) + le.Uint16(dir[12+4:]); recLen != extBlockSize {
+		t.Errorf("root dir entries cover %v bytes", recLen)
+	}
+}
+
+func TestFsImageFatSeed(t *testing.T) {
+	data := fatImageSeed()
+	le := binary.LittleEndian
+	if sectors := le.Uint16(data[19:]); int(sectors)*fatSectorSize != len(data) {
+		t.Fatalf("sectors count does not match image size: %v", sectors)
+	}
+	dataStart := int(le.Uint16(data[14:])) + int(data[16])*int(le.Uint16(data[22:])) +
+		int(le.Uint16(data[17:]))*32/fatSectorSize
+	if dataStart*fatSectorSize != fatRootDir+fatSectorSize {
+		t.Fatalf("bad data start sector %v", dataStart)
+	}
+	// The kernel determines FAT type by the number of clusters.
+	if clusters := fatSectors - dataStart; clusters >= 4085 {
+		t.Fatalf("too many clusters for FAT12: %v", clusters)
+	}
+	if le.Uint16(data[510:]) != 0xaa55 {
+		t.Fatalf("no boot sector signature")
+	}
+}
+
+func TestFsImageExtFixup(t *testing.T) {
+	data := extImageSeed()
+	le := binary.LittleEndian
+	sb := data[extSuperBlock : extSuperBlock+extSuperBlockSize]
+	orig := append([]byte{}, data...)
+	extImageFixup(data)
+	if !bytes.Equal(data, orig) {
+		t.Fatalf("fixup changed image without metadata_csum")
+	}
+	le.PutUint32(sb[100:], le.Uint32(sb[100:])|extFeatureRoCompatMetadataCsum)
+	extImageFixup(data)
+	// Check against a bitwise implementation of the kernel crc32c.
+	crc32cBitwise := func(crc uint32, data []byte) uint32 {
+		for _, b := range data {
+			crc ^= uint32(b)
+			for i := 0; i < 8; i++ {
+				crc = crc>>1 ^ 0x82f63b78&-(crc&1)
+			}
+		}
+		return crc
+	}
+	if want := crc32cBitwise(^uint32(0), sb[:extChecksumOff]); le.Uint32(sb[extChecksumOff:]) != want {
+		t.Errorf("superblock checksum 0x%x, want 0x%x", le.Uint32(sb[extChecksumOff:]), want)
+	}
+	gd := append([]byte{}, data[extGroupDesc:extGroupDesc+32]...)
+	le.PutUint16(gd[30:], 0)
+	want := crc32cBitwise(crc32cBitwise(^uint32(0), sb[104:120]), []byte{0, 0, 0, 0})
+	want = crc32cBitwise(want, gd)
+	if got := le.Uint16(data[extGroupDesc+30:]); got != uint16(want) {
+		t.Errorf("group descriptor checksum 0x%x, want 0x%x", got, uint16(want))
+	}
+}
+
+func TestFsImageMutate(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	r := newRand(target, rs)
+	for _, name := range []string{"test$fs_image_ext4", "test$fs_image_vfat"} {
+		meta := target.SyscallMap[name]
+		typ := meta.Args[0].(*PtrType).Type.(*BufferType)
+		seed := fsImageFormats[typ.SubKind].seed()
+		for i := 0; i < iters; i++ {
+			data := r.mutateFsImage(typ, append([]byte{}, seed...))
+			if len(data) != len(seed) {
+				t.Fatalf("%v: mutation changed image size from %v to %v", name, len(seed), len(data))
+			}
+			if typ.SubKind != "ext4" {
+				continue
+			}
+			fixed := append([]byte{}, data...)
+			extImageFixup(fixed)
+			if !bytes.Equal(fixed, data) {
+				t.Fatalf("%v: mutated image has stale checksums", name)
+			}
+		}
+	}
+}
+
+func TestFsImageSerialize(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, nil)
+	r := newRand(target, rs)
+	for i := 0; i < iters/10+1; i++ {
+		p := &Prog{Target: target}
+		for _, name := range []string{"test$fs_image_ext4", "test$fs_image_vfat"} {
+			s := analyze(ct, p, nil)
+			p.Calls = append(p.Calls, r.generateParticularCall(s, target.SyscallMap[name])...)
+		}
+		p.Mutate(rs, 10, ct, nil)
+		data := p.Serialize()
+		p1, err := target.Deserialize(data, NonStrict)
+		if err != nil {
+			t.Fatalf("failed to deserialize: %v\n%s", err, data)
+		}
+		if data1 := p1.Serialize(); !bytes.Equal(data, data1) {
+			t.Fatalf("program changed after serialize/deserialize\noriginal:\n%s\nnew:\n%s", data, data1)
+		}
+	}
+}
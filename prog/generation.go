@@ -25,3 +25,19 @@ func (target *Target) Generate(rs rand.Source, ncalls int, ct *ChoiceTable) *Pro
 	p.debugValidate()
 	return p
 }
+
+// GenerateWithCall generates a program that contains the given syscall
+// preceded by calls that create resources required by it.
+func (target *Target) GenerateWithCall(rs rand.Source, meta *Syscall, ct *ChoiceTable) *Prog {
+	p := &Prog{
+		Target: target,
+	}
+	r := newRand(target, rs)
+	s := newState(target, ct)
+	for _, c := range r.generateParticularCall(s, meta) {
+		s.analyze(c)
+		p.Calls = append(p.Calls, c)
+	}
+	p.debugValidate()
+	return p
+}
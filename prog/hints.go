@@ -70,7 +70,11 @@ func (p *Prog) MutateWithHints(callIndex int, comps CompMap, exec func(p *Prog))
 		p.debugValidate()
 		exec(p)
 	}
+	nlLens := nlAttrLens(c)
 	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if nlLens[arg] {
+			return
+		}
 		generateHints(comps, arg, execValidate)
 	})
 }
@@ -93,6 +97,18 @@ func generateHints(compMap CompMap, arg Arg, exec func()) {
 			// This can generate escaping paths and is probably not too useful anyway.
 			return
 		}
+		if t.Kind == BufferFsImage {
+			// Checksums need to be recomputed for every replacement,
+			// and restored along with the original data afterwards.
+			a := arg.(*DataArg)
+			original := append([]byte{}, a.Data()...)
+			exec0 := exec
+			exec = func() {
+				fixupFsImage(t, a.Data())
+				exec0()
+			}
+			defer func() { a.data = original }()
+		}
 	}
 
 	switch a := arg.(type) {
@@ -12,6 +12,26 @@ import (
 const maxBlobLen = uint64(100 << 10)
 
 func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Prog) {
+	p.MutateRestricted(rs, ncalls, ct, corpus, MutateAllOps, nil)
+}
+
+// MutateOps is a set of mutation operators.
+type MutateOps int
+
+const (
+	MutateSquash MutateOps = 1 << iota // mutate data in ANY-squashed complex pointers
+	MutateSplice                       // splice a corpus program in
+	MutateInsert                       // insert a new call
+	MutateArg                          // mutate arguments of a call
+	MutateRemove                       // remove a call
+	MutateAllOps = MutateSquash | MutateSplice | MutateInsert | MutateArg | MutateRemove
+)
+
+// MutateRestricted is the same as Mutate, but uses only the given mutation operators.
+// If calls is not nil, only calls for which it returns true are mutated/removed/squashed
+// (new calls can be still inserted).
+func (p *Prog) MutateRestricted(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Prog,
+	ops MutateOps, calls func(*Call) bool) {
 	r := newRand(p.Target, rs)
 	ctx := &mutator{
 		p:      p,
@@ -19,21 +39,31 @@ func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Pro
 		ncalls: ncalls,
 		ct:     ct,
 		corpus: corpus,
+		ops:    ops,
+		calls:  calls,
 	}
-	for stop, ok := false, false; !stop; stop = ok && r.oneOf(3) {
+	restricted := ops != MutateAllOps || calls != nil
+	for stop, ok, failed := false, false, 0; !stop; stop = ok && r.oneOf(3) {
+		// Restricted mutations may not have anything to mutate at all.
+		if restricted && failed >= maxFailedMutations {
+			break
+		}
 		switch {
 		case r.oneOf(5):
 			// Not all calls have anything squashable,
 			// so this has lower priority in reality.
-			ok = ctx.squashAny()
+			ok = ops&MutateSquash != 0 && ctx.squashAny()
 		case r.nOutOf(1, 100):
-			ok = ctx.splice()
+			ok = ops&MutateSplice != 0 && ctx.splice()
 		case r.nOutOf(20, 31):
-			ok = ctx.insertCall()
+			ok = ops&MutateInsert != 0 && ctx.insertCall()
 		case r.nOutOf(10, 11):
-			ok = ctx.mutateArg()
+			ok = ops&MutateArg != 0 && ctx.mutateArg()
 		default:
-			ok = ctx.removeCall()
+			ok = ops&MutateRemove != 0 && ctx.removeCall()
+		}
+		if !ok {
+			failed++
 		}
 	}
 	for _, c := range p.Calls {
@@ -42,12 +72,30 @@ func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Pro
 	p.debugValidate()
 }
 
+const maxFailedMutations = 1000
+
 type mutator struct {
 	p      *Prog
 	r      *randGen
 	ncalls int
 	ct     *ChoiceTable
 	corpus []*Prog
+	ops    MutateOps
+	calls  func(*Call) bool
+}
+
+// eligibleCalls returns calls that can be mutated.
+func (ctx *mutator) eligibleCalls() []*Call {
+	if ctx.calls == nil {
+		return ctx.p.Calls
+	}
+	var res []*Call
+	for _, c := range ctx.p.Calls {
+		if ctx.calls(c) {
+			res = append(res, c)
+		}
+	}
+	return res
 }
 
 func (ctx *mutator) splice() bool {
@@ -60,14 +108,17 @@ func (ctx *mutator) splice() bool {
 	idx := r.Intn(len(p.Calls))
 	p.Calls = append(p.Calls[:idx], append(p0c.Calls, p.Calls[idx:]...)...)
 	for i := len(p.Calls) - 1; i >= ctx.ncalls; i-- {
-		p.removeCall(i)
+		p.RemoveCall(i)
 	}
 	return true
 }
 
 func (ctx *mutator) squashAny() bool {
 	p, r := ctx.p, ctx.r
-	complexPtrs := p.complexPtrs()
+	var complexPtrs []*PointerArg
+	for _, c := range ctx.eligibleCalls() {
+		complexPtrs = append(complexPtrs, p.callComplexPtrs(c)...)
+	}
 	if len(complexPtrs) == 0 {
 		return false
 	}
@@ -122,20 +173,27 @@ func (ctx *mutator) insertCall() bool {
 
 func (ctx *mutator) removeCall() bool {
 	p, r := ctx.p, ctx.r
-	if len(p.Calls) == 0 {
+	calls := ctx.eligibleCalls()
+	if len(calls) == 0 {
 		return false
 	}
-	idx := r.Intn(len(p.Calls))
-	p.removeCall(idx)
+	c := calls[r.Intn(len(calls))]
+	for idx := range p.Calls {
+		if p.Calls[idx] == c {
+			p.RemoveCall(idx)
+			break
+		}
+	}
 	return true
 }
 
 func (ctx *mutator) mutateArg() bool {
 	p, r := ctx.p, ctx.r
-	if len(p.Calls) == 0 {
+	calls := ctx.eligibleCalls()
+	if len(calls) == 0 {
 		return false
 	}
-	c := p.Calls[r.Intn(len(p.Calls))]
+	c := calls[r.Intn(len(calls))]
 	if len(c.Args) == 0 {
 		return false
 	}
@@ -143,7 +201,7 @@ func (ctx *mutator) mutateArg() bool {
 	updateSizes := true
 	for stop, ok := false, false; !stop; stop = ok && r.oneOf(3) {
 		ok = true
-		ma := &mutationArgs{target: p.Target}
+		ma := &mutationArgs{target: p.Target, ignoreArgs: nlAttrLens(c)}
 		ForeachArg(c, ma.collectArg)
 		if len(ma.args) == 0 {
 			return false
@@ -264,7 +322,10 @@ func (t *BufferType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []
 		a.data = []byte(r.filename(s, t))
 	case BufferText:
 		data := append([]byte{}, a.Data()...)
-		a.data = r.mutateText(t.Text, data)
+		a.data = r.mutateText(t, data)
+	case BufferFsImage:
+		data := append([]byte{}, a.Data()...)
+		a.data = r.mutateFsImage(t, data)
 	default:
 		panic("unknown buffer kind")
 	}
@@ -377,11 +438,15 @@ type mutationArgs struct {
 	args          []Arg
 	ctxes         []ArgCtx
 	ignoreSpecial bool
+	ignoreArgs    map[Arg]bool
 }
 
 func (ma *mutationArgs) collectArg(arg Arg, ctx *ArgCtx) {
 	ignoreSpecial := ma.ignoreSpecial
 	ma.ignoreSpecial = false
+	if ma.ignoreArgs[arg] {
+		return
+	}
 	switch typ := arg.Type().(type) {
 	case *StructType:
 		if ma.target.SpecialTypes[typ.Name()] == nil || ignoreSpecial {
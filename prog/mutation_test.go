@@ -67,6 +67,29 @@ func TestMutateCorpus(t *testing.T) {
 	}
 }
 
+func TestMutateRestricted(t *testing.T) {
+	target, rs, iters := initTest(t)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, nil)
+		keep := p.Calls[0]
+		p.MutateRestricted(rs, 10, nil, nil, MutateRemove, func(c *Call) bool { return c != keep })
+		if p.Calls[0] != keep {
+			t.Fatalf("call removed despite the filter:\n%s", p.Serialize())
+		}
+		data := p.Serialize()
+		p.MutateRestricted(rs, 10, nil, nil, MutateRemove|MutateArg|MutateSquash,
+			func(c *Call) bool { return false })
+		if data1 := p.Serialize(); !bytes.Equal(data, data1) {
+			t.Fatalf("program changed without eligible calls\noriginal:\n%s\n\nnew:\n%s", data, data1)
+		}
+		calls := len(p.Calls)
+		p.MutateRestricted(rs, 20, nil, nil, MutateInsert, nil)
+		if len(p.Calls) <= calls {
+			t.Fatalf("no calls inserted:\n%s", p.Serialize())
+		}
+	}
+}
+
 func TestMutateTable(t *testing.T) {
 	target := initTargetTest(t, "test", "64")
 	tests := [][2]string{
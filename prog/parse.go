@@ -26,7 +26,8 @@ func (target *Target) ParseLog(data []byte) []*LogEntry {
 	for pos := 0; pos < len(data); {
 		nl := bytes.IndexByte(data[pos:], '\n')
 		if nl == -1 {
-			nl = len(data)
+			// The last line of a truncated log.
+			nl = len(data) - 1
 		} else {
 			nl += pos
 		}
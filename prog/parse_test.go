@@ -40,6 +40,25 @@ gettid()
 	}
 }
 
+func TestParseTruncated(t *testing.T) {
+	t.Parallel()
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const execLog = "executing program 1:\ngetpid()\ngettid("
+	entries := target.ParseLog([]byte(execLog))
+	if len(entries) != 1 {
+		t.Fatalf("got %v programs, want 1", len(entries))
+	}
+	if got, want := entries[0].P.String(), "getpid"; got != want {
+		t.Fatalf("bad program: %s, want %s", got, want)
+	}
+	if entries[0].End != len(execLog) {
+		t.Fatalf("end offset %v, want %v", entries[0].End, len(execLog))
+	}
+}
+
 func TestParseMulti(t *testing.T) {
 	t.Parallel()
 	target, err := GetTarget("linux", "amd64")
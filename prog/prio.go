@@ -101,7 +101,7 @@ func (target *Target) calcResourceUsage() map[string]map[int]float32 {
 				}
 			case *BufferType:
 				switch a.Kind {
-				case BufferBlobRand, BufferBlobRange, BufferText:
+				case BufferBlobRand, BufferBlobRange, BufferText, BufferFsImage:
 				case BufferString:
 					if a.SubKind != "" {
 						noteUsage(uses, c, 0.2, fmt.Sprintf("str-%v", a.SubKind))
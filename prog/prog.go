@@ -377,8 +377,8 @@ func removeArg(arg0 Arg) {
 	})
 }
 
-// removeCall removes call idx from p.
-func (p *Prog) removeCall(idx int) {
+// RemoveCall removes call idx from p.
+func (p *Prog) RemoveCall(idx int) {
 	c := p.Calls[idx]
 	for _, arg := range c.Args {
 		removeArg(arg)
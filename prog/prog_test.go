@@ -18,6 +18,17 @@ func TestGeneration(t *testing.T) {
 	}
 }
 
+func TestGenerateWithCall(t *testing.T) {
+	target, rs, _ := initTest(t)
+	ct := target.BuildChoiceTable(nil, nil)
+	for _, meta := range target.Syscalls {
+		p := target.GenerateWithCall(rs, meta, ct)
+		if last := p.Calls[len(p.Calls)-1]; last.Meta != meta {
+			t.Fatalf("generated program for %v ends with %v:\n%s", meta.Name, last.Meta.Name, p.Serialize())
+		}
+	}
+}
+
 func TestDefault(t *testing.T) {
 	target, _, _ := initTest(t)
 	for _, meta := range target.Syscalls {
@@ -12,7 +12,9 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/google/syzkaller/pkg/ebpf"
 	"github.com/google/syzkaller/pkg/ifuzz"
+	"github.com/google/syzkaller/pkg/ifuzz/arm64"
 	_ "github.com/google/syzkaller/pkg/ifuzz/generated" // pull in generated instruction descriptions
 )
 
@@ -132,7 +134,8 @@ func (r *randGen) randPageCount() (n uint64) {
 	case r.nOutOf(5, 6):
 		n = r.rand(20) + 1
 	default:
-		n = (r.rand(3) + 1) * 512
+		// 512, 1024 or 1536 pages on targets with 4k pages.
+		n = (r.rand(3) + 1) * (r.target.NumPages / 8)
 	}
 	return
 }
@@ -337,40 +340,80 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 		res.Desc.Kind[0], strings.Join(ctors, ", ")))
 }
 
-func (r *randGen) generateText(kind TextKind) []byte {
-	switch kind {
-	case TextTarget:
-		if r.target.Arch == "amd64" || r.target.Arch == "386" {
-			cfg := createTargetIfuzzConfig(r.target)
-			return ifuzz.Generate(cfg, r.Rand)
-		}
-		fallthrough
-	case TextArm64:
+func (r *randGen) generateText(t *BufferType) []byte {
+	if t.Text == TextBpf {
+		return ebpf.Generate(createBpfConfig(), r.Rand)
+	}
+	switch cfg, arch := createTextConfig(r.target, t); arch {
+	case "x86":
+		return ifuzz.Generate(cfg, r.Rand)
+	case "arm64":
+		return arm64.Generate(cfg, r.Rand)
+	default:
 		// Just a stub, need something better.
 		text := make([]byte, 50)
 		for i := range text {
 			text[i] = byte(r.Intn(256))
 		}
 		return text
+	}
+}
+
+func (r *randGen) mutateText(t *BufferType, text []byte) []byte {
+	if t.Text == TextBpf {
+		return ebpf.Mutate(createBpfConfig(), r.Rand, text)
+	}
+	switch cfg, arch := createTextConfig(r.target, t); arch {
+	case "x86":
+		return ifuzz.Mutate(cfg, r.Rand, text)
+	case "arm64":
+		return arm64.Mutate(cfg, r.Rand, text)
 	default:
-		cfg := createIfuzzConfig(kind)
-		return ifuzz.Generate(cfg, r.Rand)
+		return mutateData(r, text, 40, 60)
 	}
 }
 
-func (r *randGen) mutateText(kind TextKind, text []byte) []byte {
-	switch kind {
+// createTextConfig returns generator config for the text type
+// and the instruction set ("x86", "arm64" or "" if there is no generator).
+func createTextConfig(target *Target, t *BufferType) (*ifuzz.Config, string) {
+	var cfg *ifuzz.Config
+	arch := "x86"
+	switch t.Text {
 	case TextTarget:
-		if r.target.Arch == "amd64" || r.target.Arch == "386" {
-			cfg := createTargetIfuzzConfig(r.target)
-			return ifuzz.Mutate(cfg, r.Rand, text)
+		switch target.Arch {
+		case "amd64", "386":
+		case "arm64":
+			arch = "arm64"
+		default:
+			return nil, ""
 		}
-		fallthrough
+		cfg = createTargetIfuzzConfig(target)
 	case TextArm64:
-		return mutateData(r, text, 40, 60)
+		cfg = createArm64Config()
+		arch = "arm64"
 	default:
-		cfg := createIfuzzConfig(kind)
-		return ifuzz.Mutate(cfg, r.Rand, text)
+		cfg = createIfuzzConfig(t.Text)
+	}
+	switch t.SubKind {
+	case "":
+	case "user":
+		cfg.Priv = false
+	case "priv":
+		cfg.PrivPercent, cfg.ExecPercent = 60, 10
+	case "io":
+		cfg.PrivPercent, cfg.ExecPercent = 10, 60
+	default:
+		panic(fmt.Sprintf("unknown text profile %q", t.SubKind))
+	}
+	return cfg, arch
+}
+
+// createBpfConfig returns generator config for bpf text.
+// Descriptions load map fds into R6 and R7 before the text (see bpf_generated_program).
+func createBpfConfig() *ebpf.Config {
+	return &ebpf.Config{
+		Len:  10,
+		Maps: ebpf.MaxMaps,
 	}
 }
 
@@ -393,6 +436,7 @@ func createTargetIfuzzConfig(target *Target) *ifuzz.Config {
 		cfg.Mode = ifuzz.ModeLong64
 	case "386":
 		cfg.Mode = ifuzz.ModeProt32
+	case "arm64":
 	default:
 		panic("unknown text kind")
 	}
@@ -434,6 +478,23 @@ func createIfuzzConfig(kind TextKind) *ifuzz.Config {
 	return cfg
 }
 
+func createArm64Config() *ifuzz.Config {
+	// Guest memory of syz_kvm_setup_cpu$arm64 is 24 pages at 0,
+	// accesses outside of it exit to userspace as MMIO.
+	return &ifuzz.Config{
+		Len:  10,
+		Priv: true,
+		Exec: true,
+		MemRegions: []ifuzz.MemRegion{
+			{Start: 0, Size: 24 << 12},
+			{Start: 0x08000000, Size: 0x10000}, // GIC distributor on the virt machine
+			{Start: 0x080a0000, Size: 0x20000}, // GICv3 redistributor
+			{Start: 0x09000000, Size: 0x1000},  // PL011 UART
+			{Start: 1 << 30, Size: 1 << 12},    // unmapped memory
+		},
+	}
+}
+
 // nOutOf returns true n out of outOf times.
 func (r *randGen) nOutOf(n, outOf int) bool {
 	if n <= 0 || n >= outOf {
@@ -660,7 +721,13 @@ func (a *BufferType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 		if a.Dir() == DirOut {
 			return MakeOutDataArg(a, uint64(r.Intn(100))), nil
 		}
-		return MakeDataArg(a, r.generateText(a.Text)), nil
+		return MakeDataArg(a, r.generateText(a)), nil
+	case BufferFsImage:
+		data := r.generateFsImage(a)
+		if a.Dir() == DirOut {
+			return MakeOutDataArg(a, uint64(len(data))), nil
+		}
+		return MakeDataArg(a, data), nil
 	default:
 		panic("unknown buffer kind")
 	}
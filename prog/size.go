@@ -148,6 +148,19 @@ func (target *Target) assignSizesCall(c *Call) {
 	target.assignSizesArray(c.Args, nil)
 }
 
+// nlAttrLens returns nla_len args of all netlink attributes in the call.
+// They are not mutated: nla_validate rejects the whole message if any of the lengths is wrong,
+// so a single bad nested length prevents all other attributes from being parsed.
+func nlAttrLens(c *Call) map[Arg]bool {
+	lens := make(map[Arg]bool)
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if t, ok := arg.Type().(*StructType); ok && t.NlAttr {
+			lens[arg.(*GroupArg).Inner[0]] = true
+		}
+	})
+	return lens
+}
+
 func (r *randGen) mutateSize(arg *ConstArg, parent []Arg) bool {
 	typ := arg.Type().(*LenType)
 	elemSize := typ.BitSize / 8
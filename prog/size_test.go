@@ -28,6 +28,27 @@ func TestAssignSizeRandom(t *testing.T) {
 	}
 }
 
+func TestMutateNlAttrLens(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$nlattr"]: true})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				if typ, ok := arg.Type().(*StructType); !ok || !typ.NlAttr {
+					return
+				}
+				nlaLen := arg.(*GroupArg).Inner[0].(*ConstArg).Val
+				if nlaLen != arg.Size() {
+					t.Fatalf("bad nla_len %v for attribute of size %v:\n%s",
+						nlaLen, arg.Size(), p.Serialize())
+				}
+			})
+		}
+	}
+}
+
 func TestAssignSize(t *testing.T) {
 	target := initTargetTest(t, "test", "64")
 	// nolint: lll
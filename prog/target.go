@@ -146,33 +146,103 @@ func (target *Target) initTarget() {
 	target.SyscallMap = make(map[string]*Syscall)
 	for i, c := range target.Syscalls {
 		c.ID = i
+		c.execID = i
 		target.SyscallMap[c.Name] = c
-		ForeachType(c, func(t0 Type) {
-			switch t := t0.(type) {
-			case *ResourceType:
-				t.Desc = target.resourceMap[t.TypeName]
-				if t.Desc == nil {
-					panic("no resource desc")
-				}
-			case *StructType:
-				t.StructDesc = keyedStructs[t.Key]
-				if t.StructDesc == nil {
-					panic("no struct desc")
+		if err := target.initSyscallTypes(c, keyedStructs); err != nil {
+			panic(err)
+		}
+	}
+
+	target.initResourceCtors()
+	initAnyTypes(target)
+}
+
+func (target *Target) initSyscallTypes(c *Syscall, keyedStructs map[StructKey]*StructDesc) error {
+	var err error
+	// ForeachType recurses into struct fields, so missing descs are replaced with empty ones.
+	ForeachType(c, func(t0 Type) {
+		switch t := t0.(type) {
+		case *ResourceType:
+			t.Desc = target.resourceMap[t.TypeName]
+			if t.Desc == nil && err == nil {
+				err = fmt.Errorf("no resource desc %v", t.TypeName)
+			}
+		case *StructType:
+			t.StructDesc = keyedStructs[t.Key]
+			if t.StructDesc == nil {
+				t.StructDesc = new(StructDesc)
+				if err == nil {
+					err = fmt.Errorf("no struct desc %v", t.Key.Name)
 				}
-			case *UnionType:
-				t.StructDesc = keyedStructs[t.Key]
-				if t.StructDesc == nil {
-					panic("no union desc")
+			}
+		case *UnionType:
+			t.StructDesc = keyedStructs[t.Key]
+			if t.StructDesc == nil {
+				t.StructDesc = new(StructDesc)
+				if err == nil {
+					err = fmt.Errorf("no union desc %v", t.Key.Name)
 				}
 			}
-		})
-	}
+		}
+	})
+	return err
+}
 
+func (target *Target) initResourceCtors() {
 	target.resourceCtors = make(map[string][]*Syscall)
 	for _, res := range target.Resources {
 		target.resourceCtors[res.Name] = target.calcResourceCtors(res.Kind, false)
 	}
-	initAnyTypes(target)
+}
+
+// Extend adds syscalls, resources and structs compiled from additional descriptions
+// (e.g. for out-of-tree kernel modules) to the target. Syscalls and resources that
+// the target already has are skipped. Must be called before the target is used.
+// The executor does not know about the new syscalls, so they are executed as
+// built-in syscalls with the same CallName (e.g. ioctl$VENDOR_CMD as ioctl).
+func (target *Target) Extend(syscalls []*Syscall, resources []*ResourceDesc, structs []*KeyedStruct) error {
+	target.init.Do(target.lazyInit)
+	execIDs := make(map[string]int)
+	for _, c := range target.Syscalls {
+		if _, ok := execIDs[c.CallName]; !ok {
+			execIDs[c.CallName] = c.execID
+		}
+	}
+	var newSyscalls []*Syscall
+	dups := make(map[string]bool)
+	for _, c := range syscalls {
+		if target.SyscallMap[c.Name] != nil || dups[c.Name] {
+			continue
+		}
+		dups[c.Name] = true
+		if _, ok := execIDs[c.CallName]; !ok {
+			return fmt.Errorf("%v: executor does not support %v", c.Name, c.CallName)
+		}
+		newSyscalls = append(newSyscalls, c)
+	}
+	for _, res := range resources {
+		if target.resourceMap[res.Name] == nil {
+			target.Resources = append(target.Resources, res)
+			target.resourceMap[res.Name] = res
+		}
+	}
+	keyedStructs := make(map[StructKey]*StructDesc)
+	for _, desc := range structs {
+		keyedStructs[desc.Key] = desc.Desc
+	}
+	for _, c := range newSyscalls {
+		if err := target.initSyscallTypes(c, keyedStructs); err != nil {
+			return fmt.Errorf("%v: %v", c.Name, err)
+		}
+	}
+	for _, c := range newSyscalls {
+		c.ID = len(target.Syscalls)
+		c.execID = execIDs[c.CallName]
+		target.Syscalls = append(target.Syscalls, c)
+		target.SyscallMap[c.Name] = c
+	}
+	target.initResourceCtors()
+	return nil
 }
 
 func (target *Target) GetConst(name string) uint64 {
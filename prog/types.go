@@ -15,6 +15,22 @@ type Syscall struct {
 	MissingArgs int // number of trailing args that should be zero-filled
 	Args        []Type
 	Ret         Type
+	// What the syscall needs from the kernel to work (from the requires attribute, optional).
+	// Used to explain why a syscall always fails with ENODEV/EOPNOTSUPP.
+	Requires *KernelRequirements
+
+	// Number of the call in the executor syscall table (differs from ID for calls added with Target.Extend).
+	execID int
+}
+
+type KernelRequirements struct {
+	Configs []string // kernel configs, e.g. CONFIG_KVM
+	Files   []string // device nodes and other files, e.g. /dev/kvm
+}
+
+// ExecID returns number of the syscall in the executor syscall table.
+func (c *Syscall) ExecID() int {
+	return c.execID
 }
 
 type Dir int
@@ -339,6 +355,7 @@ const (
 	BufferString
 	BufferFilename
 	BufferText
+	BufferFsImage
 )
 
 type TextKind int
@@ -350,6 +367,7 @@ const (
 	TextX86bit32
 	TextX86bit64
 	TextArm64
+	TextBpf
 )
 
 type BufferType struct {
@@ -358,7 +376,7 @@ type BufferType struct {
 	RangeBegin uint64   // for BufferBlobRange kind
 	RangeEnd   uint64   // for BufferBlobRange kind
 	Text       TextKind // for BufferText
-	SubKind    string
+	SubKind    string   // string flags name for BufferString, filesystem for BufferFsImage, profile for BufferText
 	Values     []string // possible values for BufferString kind
 	NoZ        bool     // non-zero terminated BufferString/BufferFilename
 }
@@ -526,6 +544,12 @@ type StructDesc struct {
 	TypeCommon
 	Fields    []Type
 	AlignAttr uint64
+	// KernelName is the name of the kernel struct with the same layout (set with the kernel attribute),
+	// the layout is validated against kernel debug info (see pkg/ktypes).
+	KernelName string
+	// NlAttr is set for netlink attribute TLVs (structs with the nlattr attribute),
+	// the first field is nla_len.
+	NlAttr bool
 }
 
 func (t *StructDesc) FieldName() string {
@@ -0,0 +1,22 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package darwin
+
+import (
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func InitTarget(target *prog.Target) {
+	arch := &arch{
+		unix: targets.MakeUnixSanitizer(target),
+	}
+
+	target.MakeMmap = targets.MakePosixMmap(target)
+	target.SanitizeCall = arch.unix.SanitizeCall
+}
+
+type arch struct {
+	unix *targets.UnixSanitizer
+}
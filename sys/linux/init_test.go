@@ -167,3 +167,28 @@ syz_open_procfs(0x0, &(0x7f0000000000)='net\x00')
 		})
 	}
 }
+
+func TestRequirements(t *testing.T) {
+	target, err := prog.GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		call string
+		req  string
+	}{
+		{"read", "<nil>"},
+		{"ioctl$KVM_RUN", "&{[CONFIG_KVM] [/dev/kvm]}"},
+		{"syz_usb_connect", "&{[CONFIG_USB_RAW_GADGET CONFIG_USB_DUMMY_HCD] [/dev/raw-gadget]}"},
+		{"socket$inet_sctp", "&{[CONFIG_IP_SCTP] []}"},
+	}
+	for _, test := range tests {
+		c := target.SyscallMap[test.call]
+		if c == nil {
+			t.Fatalf("no syscall %v", test.call)
+		}
+		if got := fmt.Sprint(c.Requires); got != test.req {
+			t.Errorf("%v: got %v, want %v", test.call, got, test.req)
+		}
+	}
+}
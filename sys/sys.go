@@ -9,6 +9,7 @@ import (
 
 	// Import all targets, so that users only need to import sys.
 	_ "github.com/google/syzkaller/sys/akaros/gen"
+	_ "github.com/google/syzkaller/sys/darwin/gen"
 	_ "github.com/google/syzkaller/sys/freebsd/gen"
 	_ "github.com/google/syzkaller/sys/fuchsia/gen"
 	_ "github.com/google/syzkaller/sys/linux/gen"
@@ -16,6 +17,7 @@ import (
 	_ "github.com/google/syzkaller/sys/openbsd/gen"
 	_ "github.com/google/syzkaller/sys/test/gen"
 	_ "github.com/google/syzkaller/sys/windows/gen"
+	_ "github.com/google/syzkaller/sys/zephyr/gen"
 )
 
 var (
@@ -0,0 +1,46 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/compiler"
+)
+
+type darwin struct{}
+
+func (*darwin) prepare(sourcedir string, build bool, arches []string) error {
+	// Consts are extracted from the macOS SDK headers, kernel sources are not needed.
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("darwin consts can be extracted only on macOS")
+	}
+	return nil
+}
+
+func (*darwin) prepareArch(arch *Arch) error {
+	return nil
+}
+
+func (*darwin) processFile(arch *Arch, info *compiler.ConstInfo) (map[string]uint64, map[string]bool, error) {
+	clangArch := map[string]string{
+		"amd64": "x86_64",
+		"arm64": "arm64",
+	}[arch.target.Arch]
+	args := []string{
+		"-fmessage-length=0",
+		"-arch", clangArch,
+	}
+	for _, incdir := range info.Incdirs {
+		args = append(args, "-I"+incdir)
+	}
+	if arch.includeDirs != "" {
+		for _, dir := range strings.Split(arch.includeDirs, ",") {
+			args = append(args, "-I"+dir)
+		}
+	}
+	return extract(info, "cc", args, "#include <sys/syscall.h>", false)
+}
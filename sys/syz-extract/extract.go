@@ -27,6 +27,12 @@ var (
 	flagIncludes  = flag.String("includedirs", "", "path to other kernel source include dirs separated by commas")
 	flagBuildDir  = flag.String("builddir", "", "path to kernel build dir")
 	flagArch      = flag.String("arch", "", "comma-separated list of arches to generate (all by default)")
+	flagExtra     = flag.String("extra_descriptions", "", "dir with descriptions of out-of-tree modules"+
+		" (consts are extracted only for these descriptions and are saved in the same dir)")
+	flagVmlinux = flag.String("vmlinux", "", "compiled kernel with BTF or DWARF debug info"+
+		" (values of enums are taken from it instead of headers)")
+	flagKernelConfig = flag.String("kernel_config", "", "kernel .config"+
+		" (values of CONFIG_ consts are taken from it instead of headers, @if directives are resolved for it)")
 )
 
 type Arch struct {
@@ -36,8 +42,10 @@ type Arch struct {
 	buildDir    string
 	build       bool
 	files       []*File
-	err         error
-	done        chan bool
+	// Values of consts in the compiled kernel (-vmlinux/-kernel_config), they take precedence over headers.
+	kernelConsts map[string]uint64
+	err          error
+	done         chan bool
 }
 
 type File struct {
@@ -46,6 +54,7 @@ type File struct {
 	consts     map[string]uint64
 	undeclared map[string]bool
 	info       *compiler.ConstInfo
+	overrides  []string // consts with values different in headers and in the compiled kernel
 	err        error
 	done       chan bool
 }
@@ -59,6 +68,7 @@ type Extractor interface {
 var extractors = map[string]Extractor{
 	"akaros":  new(akaros),
 	"linux":   new(linux),
+	"darwin":  new(darwin),
 	"freebsd": new(freebsd),
 	"netbsd":  new(netbsd),
 	"openbsd": new(openbsd),
@@ -66,6 +76,7 @@ var extractors = map[string]Extractor{
 	"fuchsia": new(fuchsia),
 	"windows": new(windows),
 	"trusty":  new(trusty),
+	"zephyr":  new(zephyr),
 }
 
 func main() {
@@ -77,11 +88,17 @@ func main() {
 	if *flagBuild && *flagBuildDir != "" {
 		failf("-build and -builddir is an invalid combination")
 	}
+	if *flagBuild && (*flagVmlinux != "" || *flagKernelConfig != "") {
+		failf("-build and -vmlinux/-kernel_config is an invalid combination")
+	}
 
 	OS, archArray, files, err := archFileList(*flagOS, *flagArch, flag.Args())
 	if err != nil {
 		failf("%v", err)
 	}
+	if (*flagVmlinux != "" || *flagKernelConfig != "") && len(archArray) != 1 {
+		failf("-vmlinux/-kernel_config require a single -arch")
+	}
 
 	extractor := extractors[OS]
 	if extractor == nil {
@@ -139,6 +156,9 @@ func main() {
 				fmt.Printf("	%v\n", f.err)
 				continue
 			}
+			for _, override := range f.overrides {
+				fmt.Printf("	%v\n", override)
+			}
 		}
 		fmt.Printf("\n")
 	}
@@ -240,6 +260,16 @@ func archFileList(os, arch string, files []string) (string, []string, []string,
 		}
 		sort.Strings(arches)
 	}
+	if len(files) == 0 && *flagExtra != "" {
+		matches, err := filepath.Glob(filepath.Join(*flagExtra, "*.txt"))
+		if err != nil || len(matches) == 0 {
+			return "", nil, nil, fmt.Errorf("failed to find descriptions in %v: %v", *flagExtra, err)
+		}
+		for _, f := range matches {
+			files = append(files, filepath.Base(f))
+		}
+		sort.Strings(files)
+	}
 	if len(files) == 0 {
 		matches, err := filepath.Glob(filepath.Join("sys", os, "*.txt"))
 		if err != nil || len(matches) == 0 {
@@ -280,6 +310,34 @@ func processArch(extractor Extractor, arch *Arch) (map[string]*compiler.ConstInf
 	if top == nil {
 		return nil, fmt.Errorf("%v", errBuf.String())
 	}
+	if *flagExtra != "" {
+		// Out-of-tree descriptions can use everything declared in the built-in descriptions.
+		extra := ast.ParseGlob(filepath.Join(*flagExtra, "*.txt"), eh)
+		if extra == nil {
+			return nil, fmt.Errorf("%v", errBuf.String())
+		}
+		builtin := make(map[string]bool)
+		for _, node := range top.Nodes {
+			pos, _, _ := node.Info()
+			builtin[pos.File] = true
+		}
+		for _, node := range extra.Nodes {
+			if pos, _, _ := node.Info(); builtin[pos.File] {
+				return nil, fmt.Errorf("%v: file with the same name is present in sys/%v",
+					pos.File, arch.target.OS)
+			}
+		}
+		top.Nodes = append(top.Nodes, extra.Nodes...)
+	}
+	// Extract only consts used for the kernel we extract from,
+	// consts in the other @if branches may be missing in its headers.
+	kernel, err := compiler.ParseKernel(kernelVersion(arch.sourceDir), *flagKernelConfig)
+	if err != nil {
+		return nil, err
+	}
+	if top = compiler.ResolveConds(top, kernel, eh); top == nil {
+		return nil, fmt.Errorf("%v", errBuf.String())
+	}
 	infos := compiler.ExtractConsts(top, arch.target, eh)
 	if infos == nil {
 		return nil, fmt.Errorf("%v", errBuf.String())
@@ -287,11 +345,22 @@ func processArch(extractor Extractor, arch *Arch) (map[string]*compiler.ConstInf
 	if err := extractor.prepareArch(arch); err != nil {
 		return nil, err
 	}
+	if *flagVmlinux != "" || *flagKernelConfig != "" {
+		consts, err := kernelConsts(*flagVmlinux, *flagKernelConfig)
+		if err != nil {
+			return nil, err
+		}
+		arch.kernelConsts = consts
+	}
 	return infos, nil
 }
 
 func processFile(extractor Extractor, arch *Arch, file *File) (map[string]uint64, map[string]bool, error) {
-	inname := filepath.Join("sys", arch.target.OS, file.name)
+	dir := filepath.Join("sys", arch.target.OS)
+	if *flagExtra != "" {
+		dir = *flagExtra
+	}
+	inname := filepath.Join(dir, file.name)
 	outname := strings.TrimSuffix(inname, ".txt") + "_" + arch.target.Arch + ".const"
 	if file.info == nil {
 		return nil, nil, fmt.Errorf("input file %v is missing", inname)
@@ -304,6 +373,19 @@ func processFile(extractor Extractor, arch *Arch, file *File) (map[string]uint64
 	if err != nil {
 		return nil, nil, err
 	}
+	for _, name := range file.info.Consts {
+		val, ok := arch.kernelConsts[name]
+		if !ok {
+			continue
+		}
+		if old, declared := consts[name]; declared && old != val {
+			file.overrides = append(file.overrides, fmt.Sprintf("%v = %v (headers: %v)", name, val, old))
+		} else if !declared {
+			file.overrides = append(file.overrides, fmt.Sprintf("%v = %v (undeclared in headers)", name, val))
+		}
+		consts[name] = val
+		delete(undeclared, name)
+	}
 	data := compiler.SerializeConsts(consts, undeclared)
 	if err := osutil.WriteFile(outname, data); err != nil {
 		return nil, nil, fmt.Errorf("failed to write output file: %v", err)
@@ -0,0 +1,252 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// kernelConsts returns values of consts as they are in the compiled kernel:
+// enumerators from BTF (or DWARF if there is no BTF) of the kernel binary
+// and CONFIG_ values from the kernel config. Headers don't give precise values for enums
+// that depend on configs or are computed (e.g. enum { FOO = sizeof(struct bar) }).
+func kernelConsts(vmlinux, config string) (map[string]uint64, error) {
+	res := make(map[string]uint64)
+	if vmlinux != "" {
+		file, err := elf.Open(vmlinux)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		var enums map[string]uint64
+		if sec := file.Section(".BTF"); sec != nil {
+			data, err := sec.Data()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read .BTF section: %v", err)
+			}
+			enums, err = btfEnums(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse BTF: %v", err)
+			}
+		} else {
+			enums, err = dwarfEnums(file)
+			if err != nil {
+				return nil, fmt.Errorf("no BTF and failed to read DWARF in %v: %v", vmlinux, err)
+			}
+		}
+		for name, val := range enums {
+			res[name] = val
+		}
+	}
+	if config != "" {
+		configs, err := kconfigConsts(config)
+		if err != nil {
+			return nil, err
+		}
+		for name, val := range configs {
+			res[name] = val
+		}
+	}
+	return res, nil
+}
+
+// enumCollector collects enumerators and drops the ones with conflicting values
+// (different compilation units may define the same name differently).
+type enumCollector struct {
+	vals      map[string]uint64
+	conflicts map[string]bool
+}
+
+func (ec *enumCollector) add(name string, val uint64) {
+	if name == "" || ec.conflicts[name] {
+		return
+	}
+	if old, ok := ec.vals[name]; ok && old != val {
+		delete(ec.vals, name)
+		ec.conflicts[name] = true
+		return
+	}
+	ec.vals[name] = val
+}
+
+func newEnumCollector() *enumCollector {
+	return &enumCollector{
+		vals:      make(map[string]uint64),
+		conflicts: make(map[string]bool),
+	}
+}
+
+// See include/uapi/linux/btf.h.
+const (
+	btfKindEnum   = 6
+	btfKindEnum64 = 19
+	btfMagic      = 0xeb9f
+)
+
+func btfEnums(data []byte) (map[string]uint64, error) {
+	if len(data) < 24 {
+		return nil, fmt.Errorf("section is too small")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.BigEndian.Uint16(data) == btfMagic {
+		order = binary.BigEndian
+	} else if order.Uint16(data) != btfMagic {
+		return nil, fmt.Errorf("bad magic 0x%x", order.Uint16(data))
+	}
+	hdrLen := order.Uint32(data[4:])
+	typeOff, typeLen := order.Uint32(data[8:]), order.Uint32(data[12:])
+	strOff, strLen := order.Uint32(data[16:]), order.Uint32(data[20:])
+	if uint64(hdrLen)+uint64(typeOff)+uint64(typeLen) > uint64(len(data)) ||
+		uint64(hdrLen)+uint64(strOff)+uint64(strLen) > uint64(len(data)) {
+		return nil, fmt.Errorf("bad header")
+	}
+	types := data[hdrLen+typeOff : hdrLen+typeOff+typeLen]
+	strs := data[hdrLen+strOff : hdrLen+strOff+strLen]
+	str := func(off uint32) string {
+		if off >= uint32(len(strs)) {
+			return ""
+		}
+		s := strs[off:]
+		if end := bytes.IndexByte(s, 0); end != -1 {
+			s = s[:end]
+		}
+		return string(s)
+	}
+	ec := newEnumCollector()
+	for pos := 0; pos < len(types); {
+		if pos+12 > len(types) {
+			return nil, fmt.Errorf("truncated type at offset %v", pos)
+		}
+		info := order.Uint32(types[pos+4:])
+		kind, vlen := int(info>>24&0x1f), int(info&0xffff)
+		pos += 12
+		var size int
+		switch kind {
+		case 1, 14, 17: // INT, VAR, DECL_TAG
+			size = 4
+		case 3: // ARRAY
+			size = 12
+		case 4, 5, 15: // STRUCT, UNION, DATASEC
+			size = 12 * vlen
+		case 13: // FUNC_PROTO
+			size = 8 * vlen
+		case btfKindEnum:
+			size = 8 * vlen
+		case btfKindEnum64:
+			size = 12 * vlen
+		case 2, 7, 8, 9, 10, 11, 12, 16, 18:
+		default:
+			return nil, fmt.Errorf("unknown type kind %v at offset %v", kind, pos-12)
+		}
+		if pos+size > len(types) {
+			return nil, fmt.Errorf("truncated type at offset %v", pos-12)
+		}
+		for i := 0; i < vlen; i++ {
+			switch kind {
+			case btfKindEnum:
+				e := types[pos+i*8:]
+				// Enumerators are ints in C, headers produce sign-extended values for negative ones.
+				ec.add(str(order.Uint32(e)), uint64(int64(int32(order.Uint32(e[4:])))))
+			case btfKindEnum64:
+				e := types[pos+i*12:]
+				ec.add(str(order.Uint32(e)), uint64(order.Uint32(e[8:]))<<32|uint64(order.Uint32(e[4:])))
+			}
+		}
+		pos += size
+	}
+	return ec.vals, nil
+}
+
+func dwarfEnums(file *elf.File) (map[string]uint64, error) {
+	debugInfo, err := file.DWARF()
+	if err != nil {
+		return nil, err
+	}
+	ec := newEnumCollector()
+	for r := debugInfo.Reader(); ; {
+		ent, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if ent == nil {
+			break
+		}
+		if ent.Tag != dwarf.TagEnumerator {
+			continue
+		}
+		name, _ := ent.Val(dwarf.AttrName).(string)
+		switch val := ent.Val(dwarf.AttrConstValue).(type) {
+		case int64:
+			ec.add(name, uint64(val))
+		case uint64:
+			ec.add(name, val)
+		}
+	}
+	return ec.vals, nil
+}
+
+// kconfigConsts returns values of CONFIG_ consts as they are defined in autoconf.h
+// for the config: enabled bool/tristate options are 1 (CONFIG_FOO_MODULE for modules),
+// int/hex options have their values, strings are skipped.
+func kconfigConsts(file string) (map[string]uint64, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]uint64)
+	for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+		line := strings.TrimSpace(s.Text())
+		eq := strings.IndexByte(line, '=')
+		if !strings.HasPrefix(line, "CONFIG_") || eq == -1 {
+			continue
+		}
+		name, val := line[:eq], line[eq+1:]
+		switch val {
+		case "y":
+			res[name] = 1
+		case "m":
+			res[name+"_MODULE"] = 1
+		default:
+			if v, err := strconv.ParseUint(val, 0, 64); err == nil {
+				res[name] = v
+			} else if v, err := strconv.ParseInt(val, 0, 64); err == nil {
+				res[name] = uint64(v)
+			}
+		}
+	}
+	return res, nil
+}
+
+// kernelVersion returns version of the kernel source checkout (e.g. 5.10.3) from the top Makefile,
+// or an empty string if the version is unknown (e.g. not a Linux checkout).
+func kernelVersion(sourceDir string) string {
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, "Makefile"))
+	if err != nil {
+		return ""
+	}
+	vars := make(map[string]string)
+	for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+		parts := strings.SplitN(s.Text(), "=", 2)
+		if len(parts) == 2 {
+			vars[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if vars["VERSION"] == "" || vars["PATCHLEVEL"] == "" {
+		return ""
+	}
+	version := vars["VERSION"] + "." + vars["PATCHLEVEL"]
+	if vars["SUBLEVEL"] != "" {
+		version += "." + vars["SUBLEVEL"]
+	}
+	return version
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/compiler"
+)
+
+type zephyr struct{}
+
+func (*zephyr) prepare(sourcedir string, build bool, arches []string) error {
+	if sourcedir == "" {
+		return fmt.Errorf("provide path to zephyr checkout via -sourcedir flag (or make extract SOURCEDIR)")
+	}
+	if build {
+		return fmt.Errorf("zephyr does not support -build, build executor/zephyr and pass its build dir via -builddir")
+	}
+	return nil
+}
+
+func (*zephyr) prepareArch(arch *Arch) error {
+	return nil
+}
+
+func (*zephyr) processFile(arch *Arch, info *compiler.ConstInfo) (map[string]uint64, map[string]bool, error) {
+	// Kernel object types and syscall headers are generated during build,
+	// so headers are taken both from the source tree and from the build dir.
+	dir := arch.sourceDir
+	args := []string{
+		"-fmessage-length=0",
+		"-D__ZEPHYR__",
+		"-DKERNEL",
+		"-include", filepath.Join(arch.buildDir, "zephyr", "include", "generated", "autoconf.h"),
+		"-I", filepath.Join(dir, "include"),
+		"-I", filepath.Join(dir, "arch", "arm", "include"),
+		"-I", filepath.Join(arch.buildDir, "zephyr", "include", "generated"),
+	}
+	for _, incdir := range info.Incdirs {
+		args = append(args, "-I"+filepath.Join(dir, incdir))
+	}
+	if arch.includeDirs != "" {
+		for _, dir := range strings.Split(arch.includeDirs, ",") {
+			args = append(args, "-I"+dir)
+		}
+	}
+	return extract(info, "gcc", args, "", true)
+}
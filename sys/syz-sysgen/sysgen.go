@@ -29,7 +29,9 @@ import (
 )
 
 var (
-	flagMemProfile = flag.String("memprofile", "", "write a memory profile to the file")
+	flagMemProfile    = flag.String("memprofile", "", "write a memory profile to the file")
+	flagKernelVersion = flag.String("kernel_version", "", "resolve @if directives for the kernel version (e.g. 5.10)")
+	flagKernelConfig  = flag.String("kernel_config", "", "resolve @if directives for the kernel .config")
 )
 
 type SyscallData struct {
@@ -58,12 +60,19 @@ type OSData struct {
 func main() {
 	flag.Parse()
 
+	kernel, err := compiler.ParseKernel(*flagKernelVersion, *flagKernelConfig)
+	if err != nil {
+		failf("%v", err)
+	}
 	var oses []OSData
 	for OS, archs := range targets.List {
 		top := ast.ParseGlob(filepath.Join("sys", OS, "*.txt"), nil)
 		if top == nil {
 			os.Exit(1)
 		}
+		if top = compiler.ResolveConds(top, kernel, nil); top == nil {
+			os.Exit(1)
+		}
 		osutil.MkdirAll(filepath.Join("sys", OS, "gen"))
 
 		type Job struct {
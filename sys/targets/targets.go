@@ -238,6 +238,28 @@ var List = map[string]map[string]*Target{
 			},
 		},
 	},
+	"darwin": {
+		"amd64": {
+			PtrSize:  8,
+			PageSize: 4 << 10,
+			// Keep data above __PAGEZERO, the main binary and the dyld shared cache.
+			DataOffset: 64 << 30,
+			CFlags:     []string{"-m64"},
+			CCompiler:  "c++",
+			// syscall(2) is deprecated in macOS SDK, but we don't have an alternative.
+			CrossCFlags:       []string{"-m64", "-Wno-deprecated-declarations"},
+			NeedSyscallDefine: dontNeedSyscallDefine,
+		},
+		"arm64": {
+			PtrSize: 8,
+			// Apple Silicon kernels use 16k pages.
+			PageSize:          16 << 10,
+			DataOffset:        64 << 30,
+			CCompiler:         "c++",
+			CrossCFlags:       []string{"-Wno-deprecated-declarations"},
+			NeedSyscallDefine: dontNeedSyscallDefine,
+		},
+	},
 	"fuchsia": {
 		"amd64": {
 			PtrSize:          8,
@@ -300,6 +322,19 @@ var List = map[string]map[string]*Target{
 			NeedSyscallDefine: dontNeedSyscallDefine,
 		},
 	},
+	"zephyr": {
+		"arm": {
+			PtrSize:  4,
+			PageSize: 4 << 10,
+			// Microcontrollers don't have virtual memory, so the data region is a fixed
+			// 256K window in SRAM that the executor reserves in devicetree
+			// (see executor/zephyr/boards).
+			DataOffset:        0x20200000,
+			NumPages:          64,
+			NeedSyscallDefine: dontNeedSyscallDefine,
+			CCompilerPrefix:   "arm-zephyr-eabi-",
+		},
+	},
 }
 
 var oses = map[string]osCommon{
@@ -336,6 +371,14 @@ var oses = map[string]osCommon{
 		KernelObject:           "bsd.gdb",
 		CPP:                    "ecpp",
 	},
+	"darwin": {
+		SyscallNumbers:         true,
+		SyscallPrefix:          "SYS_",
+		ExecutorUsesShmem:      true,
+		ExecutorUsesForkServer: true,
+		KernelObject:           "kernel.development",
+		CPP:                    "cpp",
+	},
 	"fuchsia": {
 		BuildOS:                "linux",
 		SyscallNumbers:         false,
@@ -366,6 +409,16 @@ var oses = map[string]osCommon{
 		SyscallPrefix:  "__NR_",
 		CPP:            "cpp",
 	},
+	"zephyr": {
+		// Executor runs on the device and talks to the fuzzer over serial,
+		// so Go binaries and the executor relay are built for the host.
+		BuildOS:                "linux",
+		SyscallNumbers:         false,
+		ExecutorUsesShmem:      false,
+		ExecutorUsesForkServer: true,
+		KernelObject:           "zephyr.elf",
+		CPP:                    "cpp",
+	},
 }
 
 var (
@@ -416,8 +469,12 @@ func initTarget(target *Target, OS, arch string) {
 	if target.NeedSyscallDefine == nil {
 		target.NeedSyscallDefine = needSyscallDefine
 	}
-	target.DataOffset = 512 << 20
-	target.NumPages = (16 << 20) / target.PageSize
+	if target.DataOffset == 0 {
+		target.DataOffset = 512 << 20
+	}
+	if target.NumPages == 0 {
+		target.NumPages = (16 << 20) / target.PageSize
+	}
 	if OS == "linux" && arch == runtime.GOARCH {
 		// Don't use cross-compiler for native compilation, there are cases when this does not work:
 		// https://github.com/google/syzkaller/pull/619
@@ -13,10 +13,26 @@ func init() {
 
 var resources_amd64 = []*ResourceDesc{
 	{Name: "HANDLE", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8}}}, Kind: []string{"HANDLE"}, Values: []uint64{18446744073709551615}},
+	{Name: "hEvent", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8}}}, Kind: []string{"HANDLE", "hEvent"}, Values: []uint64{18446744073709551615}},
 	{Name: "hFile", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8}}}, Kind: []string{"HANDLE", "hFile"}, Values: []uint64{18446744073709551615}},
+	{Name: "hSection", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8}}}, Kind: []string{"HANDLE", "hSection"}, Values: []uint64{18446744073709551615}},
 }
 
 var structDescs_amd64 = []*KeyedStruct{
+	{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "IO_STATUS_BLOCK", TypeSize: 16, ArgDir: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "Status", TypeSize: 8, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "Information", TypeSize: 8, ArgDir: 1}}},
+	}}},
+	{Key: StructKey{Name: "OBJECT_ATTRIBUTES"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "OBJECT_ATTRIBUTES", TypeSize: 48}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 4}}, Path: []string{"parent"}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "RootDirectory", TypeSize: 8, IsOptional: true}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectName", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "UNICODE_STRING"}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_object_attributes", FldName: "Attributes", TypeSize: 4}}, Vals: []uint64{2, 16, 32, 64, 128, 256, 1024}, BitMask: true},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "SecurityDescriptor", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "SECURITY_DESCRIPTOR"}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "SecurityQualityOfService", TypeSize: 8}}},
+	}}},
 	{Key: StructKey{Name: "SECURITY_ATTRIBUTES"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "SECURITY_ATTRIBUTES", TypeSize: 24}, Fields: []Type{
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "nLength", TypeSize: 4}}, Path: []string{"parent"}},
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
@@ -27,6 +43,22 @@ var structDescs_amd64 = []*KeyedStruct{
 	{Key: StructKey{Name: "SECURITY_DESCRIPTOR"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "SECURITY_DESCRIPTOR", TypeSize: 4}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "stub", TypeSize: 4}}},
 	}}},
+	{Key: StructKey{Name: "UNICODE_STRING"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "UNICODE_STRING", TypeSize: 16}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "Length", TypeSize: 2}}, BitSize: 8, Path: []string{"Buffer"}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "MaximumLength", TypeSize: 2}}, BitSize: 8, Path: []string{"Buffer"}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Buffer", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "nt_file_name"}}},
+	}}},
+	{Key: StructKey{Name: "nt_file_name"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nt_file_name", TypeSize: 10}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "f", TypeSize: 2}}, Val: 102},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "i", TypeSize: 2}}, Val: 105},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "l", TypeSize: 2}}, Val: 108},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "e", TypeSize: 2}}, Val: 101},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "n", TypeSize: 2}}, Kind: 2, RangeBegin: 48, RangeEnd: 57},
+	}}},
+	{Key: StructKey{Name: "nt_timeout"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nt_timeout", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "t", TypeSize: 8}}, Kind: 2, RangeBegin: 18446744073709451616},
+	}}},
 }
 
 var syscalls_amd64 = []*Syscall{
@@ -9038,6 +9070,139 @@ var syscalls_amd64 = []*Syscall{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "idObject", TypeSize: 4}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "idChild", TypeSize: 4}}},
 	}},
+	{Name: "NtAllocateVirtualMemory", CallName: "NtAllocateVirtualMemory", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "BaseAddress", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ZeroBits", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "RegionSize", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "allocation_type", FldName: "AllocationType", TypeSize: 8}}, Vals: []uint64{4096, 8192, 524288, 16777216, 536870912, 4194304, 1048576, 2097152}, BitMask: true},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "protect_flags", FldName: "Protect", TypeSize: 8}}, Vals: []uint64{16, 32, 64, 128, 1, 2, 4, 8, 1073741824, 1073741824, 256, 512, 1024, 2147483648, 536870912}},
+	}},
+	{Name: "NtClose", CallName: "NtClose", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "HANDLE", FldName: "Handle", TypeSize: 8}},
+	}},
+	{Name: "NtCreateEvent", CallName: "NtCreateEvent", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "EventHandle", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "hEvent", TypeSize: 8, ArgDir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_event_access", FldName: "DesiredAccess", TypeSize: 8}}, Vals: []uint64{1, 2, 2031619, 1048576}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectAttributes", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "OBJECT_ATTRIBUTES"}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "EventType", TypeSize: 4}}, Kind: 2, RangeEnd: 1},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "InitialState", TypeSize: 1}}, Kind: 2, RangeEnd: 1},
+	}},
+	{Name: "NtCreateSection", CallName: "NtCreateSection", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "SectionHandle", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "hSection", TypeSize: 8, ArgDir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_section_access", FldName: "DesiredAccess", TypeSize: 8}}, Vals: []uint64{1, 2, 4, 8, 16, 983071}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectAttributes", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "OBJECT_ATTRIBUTES"}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "MaximumSize", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "protect_flags", FldName: "SectionPageProtection", TypeSize: 8}}, Vals: []uint64{16, 32, 64, 128, 1, 2, 4, 8, 1073741824, 1073741824, 256, 512, 1024, 2147483648, 536870912}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_section_attributes", FldName: "AllocationAttributes", TypeSize: 8}}, Vals: []uint64{134217728, 67108864, 16777216, 268435456, 1073741824, 2147483648}, BitMask: true},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8, IsOptional: true}},
+	}},
+	{Name: "NtDuplicateObject", CallName: "NtDuplicateObject", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "SourceProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "HANDLE", FldName: "SourceHandle", TypeSize: 8}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "TargetProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "TargetHandle", TypeSize: 8, IsOptional: true}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "HANDLE", TypeSize: 8, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "DesiredAccess", TypeSize: 4}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_object_attributes", FldName: "HandleAttributes", TypeSize: 8}}, Vals: []uint64{2, 16, 32, 64, 128, 256, 1024}, BitMask: true},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_duplicate_options", FldName: "Options", TypeSize: 8}}, Vals: []uint64{1, 2, 4}, BitMask: true},
+	}},
+	{Name: "NtExtendSection", CallName: "NtExtendSection", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hSection", FldName: "SectionHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "NewSectionSize", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8, ArgDir: 2}}}},
+	}},
+	{Name: "NtFlushBuffersFile", CallName: "NtFlushBuffersFile", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+	}},
+	{Name: "NtFreeVirtualMemory", CallName: "NtFreeVirtualMemory", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "BaseAddress", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "RegionSize", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_free_type", FldName: "FreeType", TypeSize: 8}}, Vals: []uint64{16384, 32768}, BitMask: true},
+	}},
+	{Name: "NtOpenFile", CallName: "NtOpenFile", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileHandle", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", TypeSize: 8, ArgDir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "file_access_rights", FldName: "DesiredAccess", TypeSize: 8}}, Vals: []uint64{65536, 131072, 1048576, 262144, 524288, 2, 4, 2032127, 4, 4, 64, 32, 1, 128, 1, 8, 32, 256, 2, 16}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectAttributes", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "OBJECT_ATTRIBUTES"}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "file_share_mode", FldName: "ShareAccess", TypeSize: 8}}, Vals: []uint64{4, 1, 2}, BitMask: true},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_create_options", FldName: "OpenOptions", TypeSize: 8}}, Vals: []uint64{1, 2, 4, 8, 16, 32, 64, 256, 512, 2048, 4096, 16384, 32768, 2097152, 4194304}, BitMask: true},
+	}},
+	{Name: "NtProtectVirtualMemory", CallName: "NtProtectVirtualMemory", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "BaseAddress", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "RegionSize", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "protect_flags", FldName: "NewProtect", TypeSize: 8}}, Vals: []uint64{16, 32, 64, 128, 1, 2, 4, 8, 1073741824, 1073741824, 256, 512, 1024, 2147483648, 536870912}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "OldProtect", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}},
+	{Name: "NtQueryInformationFile", CallName: "NtQueryInformationFile", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileInformation", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 8}}, Path: []string{"FileInformation"}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "FileInformationClass", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 76},
+	}},
+	{Name: "NtQueryObject", CallName: "NtQueryObject", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "HANDLE", FldName: "Handle", TypeSize: 8}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "ObjectInformationClass", TypeSize: 4}}, Kind: 2, RangeEnd: 4},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectInformation", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "ObjectInformationLength", TypeSize: 8}}, Path: []string{"ObjectInformation"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ReturnLength", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}},
+	{Name: "NtQueryVirtualMemory", CallName: "NtQueryVirtualMemory", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "BaseAddress", TypeSize: 8}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "MemoryInformationClass", TypeSize: 4}}, Kind: 2, RangeEnd: 7},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "MemoryInformation", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "MemoryInformationLength", TypeSize: 8}}, Path: []string{"MemoryInformation"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ReturnLength", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 1}}}},
+	}},
+	{Name: "NtReadFile", CallName: "NtReadFile", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hEvent", FldName: "Event", TypeSize: 8, IsOptional: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ApcRoutine", TypeSize: 8}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ApcContext", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Buffer", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 8}}, Path: []string{"Buffer"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ByteOffset", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Key", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}},
+	}},
+	{Name: "NtResetEvent", CallName: "NtResetEvent", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hEvent", FldName: "EventHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "PreviousState", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}},
+	{Name: "NtSetEvent", CallName: "NtSetEvent", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hEvent", FldName: "EventHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "PreviousState", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}},
+	{Name: "NtSetInformationFile", CallName: "NtSetInformationFile", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileInformation", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 8}}, Path: []string{"FileInformation"}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "FileInformationClass", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 76},
+	}},
+	{Name: "NtUnmapViewOfSection", CallName: "NtUnmapViewOfSection", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ProcessHandle", TypeSize: 8}}, Val: 18446744073709551615},
+		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "BaseAddress", TypeSize: 8}},
+	}},
+	{Name: "NtWaitForSingleObject", CallName: "NtWaitForSingleObject", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "HANDLE", FldName: "Handle", TypeSize: 8}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "Alertable", TypeSize: 1}}, Kind: 2, RangeEnd: 1},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Timeout", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "nt_timeout"}}},
+	}},
+	{Name: "NtWriteFile", CallName: "NtWriteFile", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hEvent", FldName: "Event", TypeSize: 8, IsOptional: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ApcRoutine", TypeSize: 8}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "ApcContext", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Buffer", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 8}}, Path: []string{"Buffer"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ByteOffset", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "Key", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}},
+	}},
 	{Name: "ObjectCloseAuditAlarmA", CallName: "ObjectCloseAuditAlarmA", Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "SubsystemName", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", TypeSize: 1, ArgDir: 2}}}},
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "HandleId", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 2, IsVarlen: true}}},
@@ -14276,6 +14441,54 @@ var syscalls_amd64 = []*Syscall{
 	{Name: "syz_execute_func", CallName: "syz_execute_func", Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "text", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "text", IsVarlen: true}, Kind: 4}},
 	}},
+	{Name: "syz_nt_create_file", CallName: "syz_nt_create_file", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileHandle", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", TypeSize: 8, ArgDir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "file_access_rights", FldName: "DesiredAccess", TypeSize: 8}}, Vals: []uint64{65536, 131072, 1048576, 262144, 524288, 2, 4, 2032127, 4, 4, 64, 32, 1, 128, 1, 8, 32, 256, 2, 16}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ObjectAttributes", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "OBJECT_ATTRIBUTES"}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "file_attributes", FldName: "FileAttributes", TypeSize: 8}}, Vals: []uint64{32, 16384, 2, 128, 4096, 1, 4, 256, 33554432, 67108864, 536870912, 1048576, 2097152, 1073741824, 16777216, 268435456, 8388608, 134217728, 2147483648, 0, 262144, 196608, 524288, 65536, 131072}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "file_share_mode", FldName: "ShareAccess", TypeSize: 8}}, Vals: []uint64{4, 1, 2}, BitMask: true},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_create_disposition", FldName: "CreateDisposition", TypeSize: 8}}, Vals: []uint64{0, 1, 2, 3, 4, 5}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "nt_create_options", FldName: "CreateOptions", TypeSize: 8}}, Vals: []uint64{1, 2, 4, 8, 16, 32, 64, 256, 512, 2048, 4096, 16384, 32768, 2097152, 4194304}, BitMask: true},
+	}},
+	{Name: "syz_nt_device_io_control_file", CallName: "syz_nt_device_io_control_file", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "IoControlCode", TypeSize: 4}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "InputBuffer", TypeSize: 8, IsOptional: true}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "InputBufferLength", TypeSize: 8}}, Path: []string{"InputBuffer"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "OutputBuffer", TypeSize: 8, IsOptional: true}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "OutputBufferLength", TypeSize: 8}}, Path: []string{"OutputBuffer"}},
+	}},
+	{Name: "syz_nt_fs_control_file", CallName: "syz_nt_fs_control_file", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "FsControlCode", TypeSize: 4}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "InputBuffer", TypeSize: 8, IsOptional: true}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "InputBufferLength", TypeSize: 8}}, Path: []string{"InputBuffer"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "OutputBuffer", TypeSize: 8, IsOptional: true}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "OutputBufferLength", TypeSize: 8}}, Path: []string{"OutputBuffer"}},
+	}},
+	{Name: "syz_nt_map_view_of_section", CallName: "syz_nt_map_view_of_section", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hSection", FldName: "SectionHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "BaseAddress", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "CommitSize", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "SectionOffset", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8, ArgDir: 2}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ViewSize", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 8, ArgDir: 2}}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "InheritDisposition", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 2},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "allocation_type", FldName: "AllocationType", TypeSize: 8}}, Vals: []uint64{4096, 8192, 524288, 16777216, 536870912, 4194304, 1048576, 2097152}, BitMask: true},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "protect_flags", FldName: "Win32Protect", TypeSize: 8}}, Vals: []uint64{16, 32, 64, 128, 1, 2, 4, 8, 1073741824, 1073741824, 256, 512, 1024, 2147483648, 536870912}},
+	}},
+	{Name: "syz_nt_query_directory_file", CallName: "syz_nt_query_directory_file", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "hFile", FldName: "FileHandle", TypeSize: 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "IoStatusBlock", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "IO_STATUS_BLOCK", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileInformation", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "Length", TypeSize: 8}}, Path: []string{"FileInformation"}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "FileInformationClass", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 76},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "ReturnSingleEntry", TypeSize: 1}}, Kind: 2, RangeEnd: 1},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "FileName", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "UNICODE_STRING"}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "RestartScan", TypeSize: 1}}, Kind: 2, RangeEnd: 1},
+	}},
 	{Name: "timeBeginPeriod", CallName: "timeBeginPeriod", Args: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "uPeriod", TypeSize: 4}}},
 	}},
@@ -14543,6 +14756,12 @@ var consts_amd64 = []ConstValue{
 	{Name: "CREATE_ALWAYS", Value: 2},
 	{Name: "CREATE_NEW", Value: 1},
 	{Name: "DELETE", Value: 65536},
+	{Name: "DUPLICATE_CLOSE_SOURCE", Value: 1},
+	{Name: "DUPLICATE_SAME_ACCESS", Value: 2},
+	{Name: "DUPLICATE_SAME_ATTRIBUTES", Value: 4},
+	{Name: "EVENT_ALL_ACCESS", Value: 2031619},
+	{Name: "EVENT_MODIFY_STATE", Value: 2},
+	{Name: "EVENT_QUERY_STATE", Value: 1},
 	{Name: "FILE_ADD_FILE", Value: 2},
 	{Name: "FILE_ADD_SUBDIRECTORY", Value: 4},
 	{Name: "FILE_ALL_ACCESS", Value: 2032127},
@@ -14555,8 +14774,12 @@ var consts_amd64 = []ConstValue{
 	{Name: "FILE_ATTRIBUTE_READONLY", Value: 1},
 	{Name: "FILE_ATTRIBUTE_SYSTEM", Value: 4},
 	{Name: "FILE_ATTRIBUTE_TEMPORARY", Value: 256},
+	{Name: "FILE_COMPLETE_IF_OPLOCKED", Value: 256},
+	{Name: "FILE_CREATE", Value: 2},
 	{Name: "FILE_CREATE_PIPE_INSTANCE", Value: 4},
 	{Name: "FILE_DELETE_CHILD", Value: 64},
+	{Name: "FILE_DELETE_ON_CLOSE", Value: 4096},
+	{Name: "FILE_DIRECTORY_FILE", Value: 1},
 	{Name: "FILE_EXECUTE", Value: 32},
 	{Name: "FILE_FLAG_BACKUP_SEMANTICS", Value: 33554432},
 	{Name: "FILE_FLAG_DELETE_ON_CLOSE", Value: 67108864},
@@ -14570,25 +14793,51 @@ var consts_amd64 = []ConstValue{
 	{Name: "FILE_FLAG_SESSION_AWARE", Value: 8388608},
 	{Name: "FILE_FLAG_WRITE_THROUGH", Value: 2147483648},
 	{Name: "FILE_LIST_DIRECTORY", Value: 1},
+	{Name: "FILE_NON_DIRECTORY_FILE", Value: 64},
+	{Name: "FILE_NO_COMPRESSION", Value: 32768},
+	{Name: "FILE_NO_EA_KNOWLEDGE", Value: 512},
+	{Name: "FILE_NO_INTERMEDIATE_BUFFERING", Value: 8},
+	{Name: "FILE_OPEN", Value: 1},
+	{Name: "FILE_OPEN_FOR_BACKUP_INTENT", Value: 16384},
+	{Name: "FILE_OPEN_IF", Value: 3},
+	{Name: "FILE_OPEN_NO_RECALL", Value: 4194304},
+	{Name: "FILE_OPEN_REPARSE_POINT", Value: 2097152},
+	{Name: "FILE_OVERWRITE", Value: 4},
+	{Name: "FILE_OVERWRITE_IF", Value: 5},
+	{Name: "FILE_RANDOM_ACCESS", Value: 2048},
 	{Name: "FILE_READ_ATTRIBUTES", Value: 128},
 	{Name: "FILE_READ_DATA", Value: 1},
 	{Name: "FILE_READ_EA", Value: 8},
+	{Name: "FILE_SEQUENTIAL_ONLY", Value: 4},
 	{Name: "FILE_SHARE_DELETE", Value: 4},
 	{Name: "FILE_SHARE_READ", Value: 1},
 	{Name: "FILE_SHARE_WRITE", Value: 2},
+	{Name: "FILE_SUPERSEDE"},
+	{Name: "FILE_SYNCHRONOUS_IO_ALERT", Value: 16},
+	{Name: "FILE_SYNCHRONOUS_IO_NONALERT", Value: 32},
 	{Name: "FILE_TRAVERSE", Value: 32},
 	{Name: "FILE_WRITE_ATTRIBUTES", Value: 256},
 	{Name: "FILE_WRITE_DATA", Value: 2},
 	{Name: "FILE_WRITE_EA", Value: 16},
+	{Name: "FILE_WRITE_THROUGH", Value: 2},
 	{Name: "INVALID_HANDLE_VALUE", Value: 18446744073709551615},
 	{Name: "MEM_COMMIT", Value: 4096},
+	{Name: "MEM_DECOMMIT", Value: 16384},
 	{Name: "MEM_LARGE_PAGES", Value: 536870912},
 	{Name: "MEM_PHYSICAL", Value: 4194304},
+	{Name: "MEM_RELEASE", Value: 32768},
 	{Name: "MEM_RESERVE", Value: 8192},
 	{Name: "MEM_RESET", Value: 524288},
 	{Name: "MEM_RESET_UNDO", Value: 16777216},
 	{Name: "MEM_TOP_DOWN", Value: 1048576},
 	{Name: "MEM_WRITE_WATCH", Value: 2097152},
+	{Name: "OBJ_CASE_INSENSITIVE", Value: 64},
+	{Name: "OBJ_EXCLUSIVE", Value: 32},
+	{Name: "OBJ_FORCE_ACCESS_CHECK", Value: 1024},
+	{Name: "OBJ_INHERIT", Value: 2},
+	{Name: "OBJ_OPENIF", Value: 128},
+	{Name: "OBJ_OPENLINK", Value: 256},
+	{Name: "OBJ_PERMANENT", Value: 16},
 	{Name: "OPEN_ALWAYS", Value: 4},
 	{Name: "OPEN_EXISTING", Value: 3},
 	{Name: "PAGE_ENCLAVE_THREAD_CONTROL", Value: 2147483648},
@@ -14607,16 +14856,28 @@ var consts_amd64 = []ConstValue{
 	{Name: "PAGE_WRITECOMBINE", Value: 1024},
 	{Name: "PAGE_WRITECOPY", Value: 8},
 	{Name: "READ_CONTROL", Value: 131072},
+	{Name: "SECTION_ALL_ACCESS", Value: 983071},
+	{Name: "SECTION_EXTEND_SIZE", Value: 16},
+	{Name: "SECTION_MAP_EXECUTE", Value: 8},
+	{Name: "SECTION_MAP_READ", Value: 4},
+	{Name: "SECTION_MAP_WRITE", Value: 2},
+	{Name: "SECTION_QUERY", Value: 1},
 	{Name: "SECURITY_ANONYMOUS"},
 	{Name: "SECURITY_CONTEXT_TRACKING", Value: 262144},
 	{Name: "SECURITY_DELEGATION", Value: 196608},
 	{Name: "SECURITY_EFFECTIVE_ONLY", Value: 524288},
 	{Name: "SECURITY_IDENTIFICATION", Value: 65536},
 	{Name: "SECURITY_IMPERSONATION", Value: 131072},
+	{Name: "SEC_COMMIT", Value: 134217728},
+	{Name: "SEC_IMAGE", Value: 16777216},
+	{Name: "SEC_LARGE_PAGES", Value: 2147483648},
+	{Name: "SEC_NOCACHE", Value: 268435456},
+	{Name: "SEC_RESERVE", Value: 67108864},
+	{Name: "SEC_WRITECOMBINE", Value: 1073741824},
 	{Name: "SYNCHRONIZE", Value: 1048576},
 	{Name: "TRUNCATE_EXISTING", Value: 5},
 	{Name: "WRITE_DAC", Value: 262144},
 	{Name: "WRITE_OWNER", Value: 524288},
 }
 
-const revision_amd64 = "75e6a1fa03cbbd34d40cee331e1f7e5f3d26d4ef"
+const revision_amd64 = "05435fe25097cce845a64b8dd2091ef6467219f6"
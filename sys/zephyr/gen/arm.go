@@ -0,0 +1,245 @@
+// AUTOGENERATED FILE
+// +build !codeanalysis
+// +build !syz_target syz_target,syz_os_zephyr,syz_arch_arm
+
+package gen
+
+import . "github.com/google/syzkaller/prog"
+import . "github.com/google/syzkaller/sys/zephyr"
+
+func init() {
+	RegisterTarget(&Target{OS: "zephyr", Arch: "arm", Revision: revision_arm, PtrSize: 4, PageSize: 4096, NumPages: 64, DataOffset: 538968064, Syscalls: syscalls_arm, Resources: resources_arm, Structs: structDescs_arm, Consts: consts_arm}, InitTarget)
+}
+
+var resources_arm = []*ResourceDesc{
+	{Name: "k_msgq", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_msgq"}, Values: []uint64{0}},
+	{Name: "k_mutex", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_mutex"}, Values: []uint64{0}},
+	{Name: "k_object", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object"}, Values: []uint64{0}},
+	{Name: "k_pipe", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_pipe"}, Values: []uint64{0}},
+	{Name: "k_poll_signal", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_poll_signal"}, Values: []uint64{0}},
+	{Name: "k_queue", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_queue"}, Values: []uint64{0}},
+	{Name: "k_sem", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_sem"}, Values: []uint64{0}},
+	{Name: "k_stack", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4}}}, Kind: []string{"k_object", "k_stack"}, Values: []uint64{0}},
+}
+
+var structDescs_arm = []*KeyedStruct{
+	{Key: StructKey{Name: "k_msgq_attrs", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "k_msgq_attrs", TypeSize: 12, ArgDir: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "msg_size", TypeSize: 4, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "max_msgs", TypeSize: 4, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "used_msgs", TypeSize: 4, ArgDir: 1}}},
+	}}},
+	{Key: StructKey{Name: "k_poll_event", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "k_poll_event", TypeSize: 20, ArgDir: 2}, Fields: []Type{
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "node", TypeSize: 8, ArgDir: 2}, Type: &ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", TypeSize: 4, ArgDir: 2}}}, Kind: 1, RangeBegin: 2, RangeEnd: 2},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "poller", TypeSize: 4, ArgDir: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "tag", TypeSize: 4, ArgDir: 2}, BitfieldLen: 8, BitfieldMdl: true}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_poll_type", FldName: "type", TypeSize: 4, ArgDir: 2}, BitfieldOff: 8, BitfieldLen: 3, BitfieldMdl: true}, Vals: []uint64{1, 2, 4}, BitMask: true},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "state", TypeSize: 4, ArgDir: 2}, BitfieldOff: 11, BitfieldLen: 5, BitfieldMdl: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "mode", TypeSize: 4, ArgDir: 2}, BitfieldOff: 16, BitfieldLen: 1, BitfieldMdl: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "unused", TypeSize: 4, ArgDir: 2}, BitfieldOff: 17, BitfieldLen: 15}},
+		&UnionType{Key: StructKey{Name: "k_poll_object", Dir: 2}, FldName: "obj"},
+	}}},
+	{Key: StructKey{Name: "k_poll_object", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "k_poll_object", TypeSize: 4, ArgDir: 2}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4, ArgDir: 2}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4, ArgDir: 2}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "signal", TypeSize: 4, ArgDir: 2}},
+	}}},
+}
+
+var syscalls_arm = []*Syscall{
+	{Name: "k_msgq_alloc_init", CallName: "k_msgq_alloc_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "msg_size", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 64},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "max_msgs", TypeSize: 4}}, Kind: 2, RangeEnd: 16},
+	}},
+	{Name: "k_msgq_get_attrs", CallName: "k_msgq_get_attrs", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "attrs", TypeSize: 4}, Type: &StructType{Key: StructKey{Name: "k_msgq_attrs", Dir: 1}}},
+	}},
+	{Name: "k_msgq_num_free_get", CallName: "k_msgq_num_free_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+	}},
+	{Name: "k_msgq_num_used_get", CallName: "k_msgq_num_used_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+	}},
+	{Name: "k_msgq_peek", CallName: "k_msgq_peek", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 64, ArgDir: 1}, Kind: 1, RangeBegin: 64, RangeEnd: 64}},
+	}},
+	{Name: "k_msgq_purge", CallName: "k_msgq_purge", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+	}},
+	{Name: "k_mutex_init", CallName: "k_mutex_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_mutex", FldName: "mutex", TypeSize: 4}},
+	}},
+	{Name: "k_mutex_unlock", CallName: "k_mutex_unlock", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_mutex", FldName: "mutex", TypeSize: 4}},
+	}},
+	{Name: "k_object_alloc$msgq", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 2},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$mutex", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 3},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_mutex", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$pipe", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 4},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_pipe", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$poll_signal", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 6},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$queue", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 5},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$sem", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 7},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_alloc$stack", CallName: "k_object_alloc", Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "otype", TypeSize: 4}}, Val: 8},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "k_stack", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "k_object_release", CallName: "k_object_release", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_object", FldName: "obj", TypeSize: 4}},
+	}},
+	{Name: "k_pipe_alloc_init", CallName: "k_pipe_alloc_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_pipe", FldName: "pipe", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "size", TypeSize: 4}}, Kind: 2, RangeEnd: 1024},
+	}},
+	{Name: "k_poll_signal_check", CallName: "k_poll_signal_check", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "sig", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "signaled", TypeSize: 4}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "result", TypeSize: 4}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}},
+	{Name: "k_poll_signal_init", CallName: "k_poll_signal_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "sig", TypeSize: 4}},
+	}},
+	{Name: "k_poll_signal_raise", CallName: "k_poll_signal_raise", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "sig", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "result", TypeSize: 4}}},
+	}},
+	{Name: "k_poll_signal_reset", CallName: "k_poll_signal_reset", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_poll_signal", FldName: "sig", TypeSize: 4}},
+	}},
+	{Name: "k_queue_alloc_append", CallName: "k_queue_alloc_append", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 16}, Kind: 1, RangeBegin: 16, RangeEnd: 16}},
+	}},
+	{Name: "k_queue_alloc_prepend", CallName: "k_queue_alloc_prepend", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 16}, Kind: 1, RangeBegin: 16, RangeEnd: 16}},
+	}},
+	{Name: "k_queue_cancel_wait", CallName: "k_queue_cancel_wait", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+	}},
+	{Name: "k_queue_init", CallName: "k_queue_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+	}},
+	{Name: "k_queue_is_empty", CallName: "k_queue_is_empty", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+	}},
+	{Name: "k_queue_peek_head", CallName: "k_queue_peek_head", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+	}},
+	{Name: "k_queue_peek_tail", CallName: "k_queue_peek_tail", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+	}},
+	{Name: "k_sem_count_get", CallName: "k_sem_count_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4}},
+	}},
+	{Name: "k_sem_give", CallName: "k_sem_give", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4}},
+	}},
+	{Name: "k_sem_init", CallName: "k_sem_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "initial_count", TypeSize: 4}}, Kind: 2, RangeEnd: 4},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "limit", TypeSize: 4}}, Kind: 2, RangeEnd: 4},
+	}},
+	{Name: "k_sem_reset", CallName: "k_sem_reset", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4}},
+	}},
+	{Name: "k_stack_alloc_init", CallName: "k_stack_alloc_init", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_stack", FldName: "stack", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "num_entries", TypeSize: 4}}, Kind: 2, RangeBegin: 1, RangeEnd: 32},
+	}},
+	{Name: "k_stack_push", CallName: "k_stack_push", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_stack", FldName: "stack", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "data", TypeSize: 4}}},
+	}},
+	{Name: "k_str_out", CallName: "k_str_out", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "c", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "n", TypeSize: 4}}, Path: []string{"c"}},
+	}},
+	{Name: "k_uptime_ticks", CallName: "k_uptime_ticks"},
+	{Name: "k_usleep", CallName: "k_usleep", Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "us", TypeSize: 4}}, Kind: 2, RangeEnd: 1000},
+	}},
+	{Name: "k_yield", CallName: "k_yield"},
+	{Name: "syz_k_msgq_get", CallName: "syz_k_msgq_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 64, ArgDir: 1}, Kind: 1, RangeBegin: 64, RangeEnd: 64}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_msgq_put", CallName: "syz_k_msgq_put", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_msgq", FldName: "msgq", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 64}, Kind: 1, RangeBegin: 64, RangeEnd: 64}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_mutex_lock", CallName: "syz_k_mutex_lock", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_mutex", FldName: "mutex", TypeSize: 4}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_pipe_get", CallName: "syz_k_pipe_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_pipe", FldName: "pipe", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "bytes_to_read", TypeSize: 4}}, Path: []string{"data"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "bytes_read", TypeSize: 4}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "min_xfer", TypeSize: 4}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_pipe_put", CallName: "syz_k_pipe_put", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_pipe", FldName: "pipe", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "bytes_to_write", TypeSize: 4}}, Path: []string{"data"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "bytes_written", TypeSize: 4}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "min_xfer", TypeSize: 4}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_poll", CallName: "syz_k_poll", Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "events", TypeSize: 4}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 2, IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "k_poll_event", Dir: 2}}, Kind: 1, RangeBegin: 1, RangeEnd: 4}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "num_events", TypeSize: 4}}, Path: []string{"events"}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_queue_get", CallName: "syz_k_queue_get", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_queue", FldName: "queue", TypeSize: 4}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_sem_take", CallName: "syz_k_sem_take", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_sem", FldName: "sem", TypeSize: 4}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_sleep", CallName: "syz_k_sleep", Args: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_k_stack_pop", CallName: "syz_k_stack_pop", Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "k_stack", FldName: "stack", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "data", TypeSize: 4}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", TypeSize: 4, ArgDir: 1}}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "k_timeout_ms", FldName: "timeout", TypeSize: 4}}, Vals: []uint64{0, 1, 5, 10}},
+	}},
+	{Name: "syz_mmap", CallName: "syz_mmap", Args: []Type{
+		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "addr", TypeSize: 4}, RangeBegin: 1, RangeEnd: 16},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "len", TypeSize: 4}}, Path: []string{"addr"}},
+	}},
+}
+
+var consts_arm = []ConstValue{
+	{Name: "K_OBJ_MSGQ", Value: 2},
+	{Name: "K_OBJ_MUTEX", Value: 3},
+	{Name: "K_OBJ_PIPE", Value: 4},
+	{Name: "K_OBJ_POLL_SIGNAL", Value: 6},
+	{Name: "K_OBJ_QUEUE", Value: 5},
+	{Name: "K_OBJ_SEM", Value: 7},
+	{Name: "K_OBJ_STACK", Value: 8},
+	{Name: "K_POLL_MODE_NOTIFY_ONLY"},
+	{Name: "K_POLL_TYPE_DATA_AVAILABLE", Value: 4},
+	{Name: "K_POLL_TYPE_SEM_AVAILABLE", Value: 2},
+	{Name: "K_POLL_TYPE_SIGNAL", Value: 1},
+}
+
+const revision_arm = "99259bf3db86ab0b53c0f332f62b054034ae790e"
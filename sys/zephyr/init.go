@@ -0,0 +1,13 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package zephyr
+
+import (
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func InitTarget(target *prog.Target) {
+	target.MakeMmap = targets.MakeSyzMmap(target)
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -37,15 +38,18 @@ type JobProcessor struct {
 	stop            chan struct{}
 	shutdownPending chan struct{}
 	dash            *dashapi.Dashboard
+	local           *LocalJobs
 	syzkallerRepo   string
 	syzkallerBranch string
 }
 
-func newJobProcessor(cfg *Config, managers []*Manager, stop, shutdownPending chan struct{}) *JobProcessor {
+func newJobProcessor(cfg *Config, managers []*Manager, local *LocalJobs,
+	stop, shutdownPending chan struct{}) *JobProcessor {
 	jp := &JobProcessor{
 		cfg:             cfg,
 		name:            fmt.Sprintf("%v-job", cfg.Name),
 		managers:        managers,
+		local:           local,
 		knownCommits:    make(map[string]bool),
 		stop:            stop,
 		shutdownPending: shutdownPending,
@@ -84,6 +88,9 @@ loop:
 			if jp.cfg.EnableJobs {
 				jp.pollJobs()
 			}
+			if jp.local != nil {
+				jp.pollLocalJobs()
+			}
 			if time.Since(lastCommitPoll) > commitPollPeriod {
 				jp.pollCommits()
 				lastCommitPoll = time.Now()
@@ -239,14 +246,22 @@ func (jp *JobProcessor) pollJobs() {
 		req: req,
 		mgr: mgr,
 	}
-	jp.processJob(job)
+	resp := jp.processJob(job)
+	if resp == nil {
+		return
+	}
+	if err := jp.dash.JobDone(resp); err != nil {
+		jp.Errorf("failed to mark job as done: %v", err)
+		return
+	}
 }
 
-func (jp *JobProcessor) processJob(job *Job) {
+// processJob returns nil if the job was interrupted by shutdown.
+func (jp *JobProcessor) processJob(job *Job) *dashapi.JobDoneReq {
 	select {
 	case kernelBuildSem <- struct{}{}:
 	case <-jp.stop:
-		return
+		return nil
 	}
 	defer func() { <-kernelBuildSem }()
 
@@ -261,14 +276,11 @@ func (jp *JobProcessor) processJob(job *Job) {
 		if len(resp.Error) != 0 {
 			// Ctrl+C can kill a child process which will cause an error.
 			log.Logf(0, "ignoring error: shutdown pending")
-			return
+			return nil
 		}
 	default:
 	}
-	if err := jp.dash.JobDone(resp); err != nil {
-		jp.Errorf("failed to mark job as done: %v", err)
-		return
-	}
+	return resp
 }
 
 type Job struct {
@@ -395,14 +407,38 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 			C:    req.ReproC,
 		},
 		Manager: *mgrcfg,
+		Retries: bisect.DefaultRetries,
+	}
+	if mgr.mgrcfg.BisectBaselineConfig != "" {
+		baseline, err := ioutil.ReadFile(mgr.mgrcfg.BisectBaselineConfig)
+		if err != nil {
+			return fmt.Errorf("failed to read baseline config: %v", err)
+		}
+		cfg.Kernel.BaselineConfig = baseline
 	}
 
-	commits, rep, err := bisect.Run(cfg)
+	res, err := bisect.Run(cfg)
 	resp.Log = trace.Bytes()
+	if mgr.mgrcfg.UploadAssets && mgr.dash != nil {
+		// The log is truncated by the dashboard if it's too large, upload the full version.
+		osutil.MkdirAll(cfg.DebugDir)
+		logFile := filepath.Join(cfg.DebugDir, "bisect.log")
+		if err := osutil.WriteFile(logFile, resp.Log); err != nil {
+			jp.Errorf("failed to write bisection log: %v", err)
+		} else if asset, err := mgr.dash.UploadAsset(dashapi.AssetBisectLog, logFile); err != nil {
+			jp.Errorf("failed to upload bisection log: %v", err)
+		} else {
+			resp.Assets = append(resp.Assets, *asset)
+		}
+	}
+	if data, err := json.Marshal(res); err == nil {
+		resp.BisectResult = data
+	}
 	if err != nil {
+		_, resp.InfraFailure = err.(*bisect.InfraError)
 		return err
 	}
-	for _, com := range commits {
+	for _, com := range res.Commits {
 		resp.Commits = append(resp.Commits, dashapi.Commit{
 			Hash:       com.Hash,
 			Title:      com.Title,
@@ -412,7 +448,7 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 			Date:       com.Date,
 		})
 	}
-	if rep != nil {
+	if rep := res.Report; rep != nil {
 		resp.CrashTitle = rep.Title
 		resp.CrashReport = rep.Report
 		resp.CrashLog = rep.Output
@@ -0,0 +1,294 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/sys"
+)
+
+// LocalJobs implements patch testing API that does not require dashboard
+// (see tools/syz-testpatch for a client):
+//
+//	POST /api/jobs		submits a job (LocalJobReq), returns LocalJob
+//	GET  /api/jobs		returns all jobs (without results)
+//	GET  /api/jobs/ID	returns the job with results
+//
+// Requests are authenticated with "Authorization: Bearer <jobs_api_key>" header.
+// Jobs are persisted in jobs/local, so results survive restarts.
+type LocalJobs struct {
+	mu       sync.Mutex
+	key      string
+	dir      string
+	managers []*Manager
+	jobs     map[string]*LocalJob
+	seq      int
+}
+
+// LocalJobReq asks to test a kernel tree (optionally with a patch) with a reproducer.
+type LocalJobReq struct {
+	// Name of the syz-ci manager to test on (managers[].name in syz-ci config).
+	Manager string
+	// Kernel repo and branch/commit to test, default to the manager repo and branch.
+	KernelRepo   string
+	KernelBranch string
+	// Kernel config, defaults to the manager kernel config.
+	KernelConfig []byte
+	// Patch to apply on top of the kernel tree (optional).
+	Patch []byte
+	// Reproducer options (first line of syz reproducers) and programs (ReproC is optional).
+	ReproOpts []byte
+	ReproSyz  []byte
+	ReproC    []byte
+}
+
+type LocalJob struct {
+	ID       string
+	Status   string // "pending", "running" or "done"
+	Created  time.Time
+	Started  time.Time
+	Finished time.Time
+	Req      *LocalJobReq        `json:",omitempty"`
+	Result   *dashapi.JobDoneReq `json:",omitempty"`
+}
+
+const (
+	localJobPending = "pending"
+	localJobRunning = "running"
+	localJobDone    = "done"
+)
+
+func newLocalJobs(key, dir string, managers []*Manager) (*LocalJobs, error) {
+	lj := &LocalJobs{
+		key:      key,
+		dir:      dir,
+		managers: managers,
+		jobs:     make(map[string]*LocalJob),
+	}
+	if err := osutil.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		job := new(LocalJob)
+		if err := json.Unmarshal(data, job); err != nil {
+			log.Logf(0, "failed to parse local job %v: %v", file, err)
+			continue
+		}
+		if job.Status == localJobRunning {
+			// Interrupted by restart.
+			job.Status = localJobPending
+		}
+		lj.jobs[job.ID] = job
+		lj.seq++
+	}
+	return lj, nil
+}
+
+func (lj *LocalJobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+lj.key)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var res interface{}
+	var err error
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/jobs"), "/")
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		req := new(LocalJobReq)
+		if err = json.NewDecoder(r.Body).Decode(req); err != nil {
+			err = fmt.Errorf("failed to parse request: %v", err)
+			break
+		}
+		res, err = lj.submit(req)
+	case r.Method == http.MethodGet && id == "":
+		res = lj.list()
+	case r.Method == http.MethodGet:
+		job := lj.get(id)
+		if job == nil {
+			http.Error(w, fmt.Sprintf("no job %v", id), http.StatusNotFound)
+			return
+		}
+		res = job
+	default:
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Logf(0, "failed to write local job response: %v", err)
+	}
+}
+
+func (lj *LocalJobs) submit(req *LocalJobReq) (*LocalJob, error) {
+	mgr := lj.manager(req.Manager)
+	if mgr == nil {
+		return nil, fmt.Errorf("unknown manager %q", req.Manager)
+	}
+	if len(req.ReproSyz) == 0 || len(req.ReproOpts) == 0 {
+		return nil, fmt.Errorf("reproducer program and options are required")
+	}
+	if req.KernelRepo == "" {
+		req.KernelRepo = mgr.mgrcfg.Repo
+	}
+	if req.KernelBranch == "" {
+		req.KernelBranch = mgr.mgrcfg.Branch
+	}
+	if len(req.KernelConfig) == 0 {
+		req.KernelConfig = mgr.configData
+	}
+	if len(req.KernelConfig) == 0 {
+		return nil, fmt.Errorf("kernel config is required for manager %v", req.Manager)
+	}
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	lj.seq++
+	job := &LocalJob{
+		ID:      fmt.Sprintf("%v-%v", time.Now().Format("20060102-150405"), lj.seq),
+		Status:  localJobPending,
+		Created: time.Now(),
+		Req:     req,
+	}
+	lj.jobs[job.ID] = job
+	if err := lj.save(job); err != nil {
+		delete(lj.jobs, job.ID)
+		return nil, err
+	}
+	log.Logf(0, "local job %v submitted for %v on %v/%v", job.ID, req.Manager, req.KernelRepo, req.KernelBranch)
+	return job, nil
+}
+
+func (lj *LocalJobs) list() []*LocalJob {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	var res []*LocalJob
+	for _, job := range lj.jobs {
+		job1 := *job
+		job1.Req, job1.Result = nil, nil
+		res = append(res, &job1)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res
+}
+
+func (lj *LocalJobs) get(id string) *LocalJob {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	job := lj.jobs[id]
+	if job == nil {
+		return nil
+	}
+	job1 := *job
+	return &job1
+}
+
+// next returns the oldest pending job and marks it as running.
+func (lj *LocalJobs) next() *LocalJob {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	var next *LocalJob
+	for _, job := range lj.jobs {
+		if job.Status == localJobPending && (next == nil || job.Created.Before(next.Created)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	next.Status = localJobRunning
+	next.Started = time.Now()
+	if err := lj.save(next); err != nil {
+		log.Logf(0, "failed to save local job %v: %v", next.ID, err)
+	}
+	job1 := *next
+	return &job1
+}
+
+// done stores job results, nil result returns the job back to the queue.
+func (lj *LocalJobs) done(id string, res *dashapi.JobDoneReq) {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	job := lj.jobs[id]
+	if res == nil {
+		job.Status = localJobPending
+	} else {
+		job.Status = localJobDone
+		job.Finished = time.Now()
+		job.Result = res
+	}
+	if err := lj.save(job); err != nil {
+		log.Logf(0, "failed to save local job %v: %v", job.ID, err)
+	}
+}
+
+func (lj *LocalJobs) save(job *LocalJob) error {
+	data, err := json.MarshalIndent(job, "", "\t")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(filepath.Join(lj.dir, job.ID+".json"), data)
+}
+
+func (lj *LocalJobs) manager(name string) *Manager {
+	for _, mgr := range lj.managers {
+		if mgr.mgrcfg.Name == name || mgr.name == name {
+			return mgr
+		}
+	}
+	return nil
+}
+
+// pollLocalJobs runs the next local job, if any.
+func (jp *JobProcessor) pollLocalJobs() {
+	job := jp.local.next()
+	if job == nil {
+		return
+	}
+	mgr := jp.local.manager(job.Req.Manager)
+	req := job.Req
+	res := jp.processJob(&Job{
+		req: &dashapi.JobPollResp{
+			ID:              job.ID,
+			Type:            dashapi.JobTestPatch,
+			Manager:         mgr.name,
+			KernelRepo:      req.KernelRepo,
+			KernelBranch:    req.KernelBranch,
+			KernelConfig:    req.KernelConfig,
+			SyzkallerCommit: sys.GitRevisionBase,
+			Patch:           req.Patch,
+			ReproOpts:       req.ReproOpts,
+			ReproSyz:        req.ReproSyz,
+			ReproC:          req.ReproC,
+		},
+		mgr: mgr,
+	})
+	jp.local.done(job.ID, res)
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+)
+
+func TestLocalJobs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-ci-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	managers := []*Manager{{
+		name:       "ci-upstream",
+		configData: []byte("CONFIG_KASAN=y"),
+		mgrcfg: &ManagerConfig{
+			Name:   "upstream",
+			Repo:   "git://repo",
+			Branch: "master",
+		},
+	}}
+	lj, err := newLocalJobs("secret", dir, managers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(method, path, key string, req, res interface{}) int {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(method, path, bytes.NewReader(body))
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		lj.ServeHTTP(w, httpReq)
+		if w.Code == http.StatusOK && res != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code
+	}
+	req := &LocalJobReq{
+		Manager:   "upstream",
+		Patch:     []byte("patch"),
+		ReproOpts: []byte("{}"),
+		ReproSyz:  []byte("getpid()"),
+	}
+	if code := call("POST", "/api/jobs", "wrong", req, nil); code != http.StatusUnauthorized {
+		t.Fatalf("request with a wrong key returned %v", code)
+	}
+	if code := call("POST", "/api/jobs", "secret", &LocalJobReq{Manager: "foo"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("request for unknown manager returned %v", code)
+	}
+	job := new(LocalJob)
+	if code := call("POST", "/api/jobs", "secret", req, job); code != http.StatusOK {
+		t.Fatalf("submit returned %v", code)
+	}
+	if job.Status != localJobPending || job.Req.KernelRepo != "git://repo" ||
+		string(job.Req.KernelConfig) != "CONFIG_KASAN=y" {
+		t.Fatalf("bad submitted job: %+v", job)
+	}
+	if code := call("GET", "/api/jobs/foo", "secret", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("request for unknown job returned %v", code)
+	}
+
+	next := lj.next()
+	if next == nil || next.ID != job.ID || lj.next() != nil {
+		t.Fatalf("bad next job")
+	}
+	// The job survives restart and is requeued.
+	lj, err = newLocalJobs("secret", dir, managers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next = lj.next(); next == nil || next.ID != job.ID {
+		t.Fatalf("job is lost after restart")
+	}
+	lj.done(job.ID, &dashapi.JobDoneReq{CrashTitle: "KASAN: use-after-free"})
+
+	var jobs []*LocalJob
+	if code := call("GET", "/api/jobs", "secret", nil, &jobs); code != http.StatusOK {
+		t.Fatalf("list returned %v", code)
+	}
+	if len(jobs) != 1 || jobs[0].Status != localJobDone || jobs[0].Result != nil {
+		t.Fatalf("bad job list: %+v", jobs)
+	}
+	res := new(LocalJob)
+	if code := call("GET", "/api/jobs/"+job.ID, "secret", nil, res); code != http.StatusOK {
+		t.Fatalf("get returned %v", code)
+	}
+	if res.Result == nil || res.Result.CrashTitle != "KASAN: use-after-free" {
+		t.Fatalf("bad job result: %+v", res)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/syzkaller/dashboard/dashapi"
@@ -18,6 +19,7 @@ import (
 	"github.com/google/syzkaller/pkg/gcs"
 	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/kconfig"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
@@ -58,8 +60,8 @@ func init() {
 // Manager represents a single syz-manager instance.
 // Handles kernel polling, image rebuild and manager process management.
 // As syzkaller builder, it maintains 2 builds:
-//  - latest: latest known good kernel build
-//  - current: currently used kernel build
+//   - latest: latest known good kernel build
+//   - current: currently used kernel build
 type Manager struct {
 	name       string
 	workDir    string
@@ -76,6 +78,9 @@ type Manager struct {
 	cmd        *ManagerCmd
 	dash       *dashapi.Dashboard
 	stop       chan struct{}
+	// Result of the last build failure bisection (see bisectBuildFailure).
+	buildBisectGood    string
+	buildBisectCulprit string
 }
 
 func createManager(cfg *Config, mgrcfg *ManagerConfig, stop chan struct{}) (*Manager, error) {
@@ -103,6 +108,13 @@ func createManager(cfg *Config, mgrcfg *ManagerConfig, stop chan struct{}) (*Man
 			return nil, err
 		}
 	}
+	for _, file := range mgrcfg.KernelConfigFragments {
+		fragment, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		configData = kconfig.Merge(configData, fragment)
+	}
 	kernelDir := filepath.Join(dir, "kernel")
 	repo, err := vcs.NewRepo(mgrcfg.managercfg.TargetOS, mgrcfg.managercfg.Type, kernelDir)
 	if err != nil {
@@ -299,6 +311,11 @@ func (mgr *Manager) build(kernelCommit *vcs.Commit) error {
 				Report: []byte(buildErr.Title),
 				Output: buildErr.Output,
 			}
+			if mgr.mgrcfg.BisectBuildFailures {
+				if culprit := mgr.bisectBuildFailure(kernelCommit); culprit != "" {
+					rep.Report = append(rep.Report, fmt.Sprintf("\n\nbuild failure bisected to: %v", culprit)...)
+				}
+			}
 			if err := mgr.reportBuildError(rep, info, tmpDir); err != nil {
 				mgr.Errorf("failed to report image error: %v", err)
 			}
@@ -317,6 +334,54 @@ func (mgr *Manager) build(kernelCommit *vcs.Commit) error {
 	return osutil.Rename(tmpDir, mgr.latestDir)
 }
 
+// bisectBuildFailure finds the commit that broke the build between the latest good build
+// and the failed commit. The result is cached until the latest good build changes,
+// because next commits usually fail for the same reason.
+func (mgr *Manager) bisectBuildFailure(bad *vcs.Commit) string {
+	latest := mgr.checkLatest()
+	bisecter, ok := mgr.repo.(vcs.Bisecter)
+	if latest == nil || !ok || latest.KernelCommit == bad.Hash ||
+		latest.CompilerID != mgr.compilerID || latest.KernelConfigTag != mgr.configTag {
+		// The previous good build is not comparable with this one.
+		return ""
+	}
+	if mgr.buildBisectGood == latest.KernelCommit {
+		return mgr.buildBisectCulprit
+	}
+	log.Logf(0, "%v: bisecting build failure %v..%v", mgr.name, latest.KernelCommit, bad.Hash)
+	tmpDir := mgr.latestDir + ".bisect"
+	defer os.RemoveAll(tmpDir)
+	pred := func() (vcs.BisectResult, error) {
+		os.RemoveAll(tmpDir)
+		if err := osutil.MkdirAll(tmpDir); err != nil {
+			return 0, err
+		}
+		err := build.Image(mgr.managercfg.TargetOS, mgr.managercfg.TargetVMArch, mgr.managercfg.Type,
+			mgr.kernelDir, tmpDir, mgr.mgrcfg.Compiler, mgr.mgrcfg.Userspace,
+			mgr.mgrcfg.KernelCmdline, mgr.mgrcfg.KernelSysctl, mgr.configData)
+		if err == nil {
+			return vcs.BisectGood, nil
+		}
+		if _, ok := err.(build.KernelBuildError); ok {
+			return vcs.BisectBad, nil
+		}
+		return vcs.BisectSkip, nil
+	}
+	commits, err := bisecter.Bisect(bad.Hash, latest.KernelCommit, log.VerboseWriter(1), pred)
+	if err != nil {
+		mgr.Errorf("build failure bisection failed: %v", err)
+		return ""
+	}
+	var culprits []string
+	for _, com := range commits {
+		culprits = append(culprits, fmt.Sprintf("%v %q", com.Hash, com.Title))
+	}
+	mgr.buildBisectGood = latest.KernelCommit
+	mgr.buildBisectCulprit = strings.Join(culprits, ", ")
+	log.Logf(0, "%v: build failure bisected to: %v", mgr.name, mgr.buildBisectCulprit)
+	return mgr.buildBisectCulprit
+}
+
 func (mgr *Manager) restartManager() {
 	if !osutil.FilesExist(mgr.latestDir, imageFiles) {
 		mgr.Errorf("can't start manager, image files missing")
@@ -423,6 +488,8 @@ func (mgr *Manager) reportBuildError(rep *report.Report, info *BuildInfo, imageD
 			Title:       rep.Title,
 			Corrupted:   false, // Otherwise they get merged with other corrupted reports.
 			Maintainers: rep.Maintainers,
+			Subsystems:  rep.Subsystems,
+			SubsystemCC: rep.SubsystemCC,
 			Log:         rep.Output,
 			Report:      rep.Report,
 		},
@@ -500,6 +567,9 @@ func (mgr *Manager) uploadBuild(info *BuildInfo, imageDir string) (string, error
 	}
 	build.Commits = commitTitles
 	build.FixCommits = fixCommits
+	if mgr.mgrcfg.UploadAssets {
+		build.Assets = mgr.uploadBuildAssets(imageDir)
+	}
 	if err := mgr.dash.UploadBuild(build); err != nil {
 		return "", err
 	}
@@ -539,6 +609,31 @@ func (mgr *Manager) createDashboardBuild(info *BuildInfo, imageDir, typ string)
 	return build, nil
 }
 
+// uploadBuildAssets uploads disk and kernel images of the build to the dashboard asset storage.
+// Failures are not fatal, the build is just uploaded without the failed assets.
+func (mgr *Manager) uploadBuildAssets(imageDir string) []dashapi.Asset {
+	files := map[dashapi.AssetType]string{
+		dashapi.AssetDiskImage: "image",
+	}
+	if obj := targets.Get(mgr.managercfg.TargetOS, mgr.managercfg.TargetArch).KernelObject; obj != "" {
+		files[dashapi.AssetKernel] = filepath.Join("obj", obj)
+	}
+	var assets []dashapi.Asset
+	for typ, name := range files {
+		file := filepath.Join(imageDir, name)
+		if !osutil.IsExist(file) {
+			continue
+		}
+		asset, err := mgr.dash.UploadAsset(typ, file)
+		if err != nil {
+			mgr.Errorf("failed to upload %v: %v", name, err)
+			continue
+		}
+		assets = append(assets, *asset)
+	}
+	return assets
+}
+
 // pollCommits asks dashboard what commits it is interested in (i.e. fixes for
 // open bugs) and returns subset of these commits that are present in a build
 // on commit buildCommit.
@@ -92,8 +92,15 @@ type Config struct {
 	SyzkallerDescriptions string `json:"syzkaller_descriptions"`
 	// GCS path to upload coverage reports from managers (optional).
 	CoverUploadPath string `json:"cover_upload_path"`
+	// Dir for the persistent symbolization cache shared by all managers (optional).
+	SymbolizerCache string `json:"symbolizer_cache"`
+	// Address of a syz-symbolizer daemon used by all managers (optional).
+	SymbolizerServer string `json:"symbolizer_server"`
 	// Enable patch testing jobs.
-	EnableJobs   bool             `json:"enable_jobs"`
+	EnableJobs bool `json:"enable_jobs"`
+	// Key for the local patch testing API (/api/jobs), which works without dashboard.
+	// The API is disabled if the key is not set (optional).
+	JobsAPIKey   string           `json:"jobs_api_key"`
 	BisectBinDir string           `json:"bisect_bin_dir"`
 	Managers     []*ManagerConfig `json:"managers"`
 }
@@ -109,12 +116,25 @@ type ManagerConfig struct {
 	Compiler     string `json:"compiler"`
 	Userspace    string `json:"userspace"`
 	KernelConfig string `json:"kernel_config"`
+	// Config fragments merged into kernel_config, e.g. to enable KCSAN (optional).
+	KernelConfigFragments []string `json:"kernel_config_fragments"`
 	// File with kernel cmdline values (optional).
 	KernelCmdline string `json:"kernel_cmdline"`
 	// File with sysctl values (e.g. output of sysctl -a, optional).
 	KernelSysctl string `json:"kernel_sysctl"`
 	PollCommits  bool   `json:"poll_commits"`
 	Bisect       bool   `json:"bisect"`
+	// Bisect kernel build failures to the commit that broke the build (optional).
+	BisectBuildFailures bool `json:"bisect_build_failures"`
+	// Small kernel config used to minimize the crash config before bisection (optional),
+	// see pkg/bisect.KernelConfig.BaselineConfig.
+	BisectBaselineConfig string `json:"bisect_baseline_config"`
+	// Upload disk and kernel images of builds and full bisection logs to the dashboard asset storage,
+	// so that they are linked from bug reports (optional, requires dashboard_client).
+	UploadAssets bool `json:"upload_assets"`
+	// Build variants (e.g. gcc/clang, KASAN/KCSAN), each variant runs as a separate
+	// manager named name-variant with own kernel builds (optional).
+	Variants []*ManagerVariant `json:"variants"`
 
 	ManagerConfig json.RawMessage `json:"manager_config"`
 	managercfg    *mgrconfig.Config
@@ -186,7 +206,16 @@ func main() {
 		}
 	}
 
-	jp := newJobProcessor(cfg, managers, stop, shutdownPending)
+	var local *LocalJobs
+	if cfg.JobsAPIKey != "" {
+		local, err = newLocalJobs(cfg.JobsAPIKey, osutil.Abs(filepath.Join("jobs", "local")), managers)
+		if err != nil {
+			log.Fatalf("failed to load local jobs: %v", err)
+		}
+		http.Handle("/api/jobs", local)
+		http.Handle("/api/jobs/", local)
+	}
+	jp := newJobProcessor(cfg, managers, local, stop, shutdownPending)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -250,6 +279,10 @@ func loadConfig(filename string) (*Config, error) {
 	if cfg.EnableJobs && cfg.BisectBinDir == "" {
 		return nil, fmt.Errorf("enabled_jobs is set but no bisect_bin_dir")
 	}
+	var err error
+	if cfg.Managers, err = expandVariants(cfg.Managers); err != nil {
+		return nil, err
+	}
 	// Manager name must not contain dots because it is used as GCE image name prefix.
 	managerNameRe := regexp.MustCompile("^[a-zA-Z0-9-_]{4,64}$")
 	for i, mgr := range cfg.Managers {
@@ -273,6 +306,12 @@ func loadConfig(filename string) (*Config, error) {
 			managercfg.HTTP = fmt.Sprintf(":%v", cfg.ManagerPort)
 			cfg.ManagerPort++
 		}
+		if managercfg.SymbolizerCache == "" && cfg.SymbolizerCache != "" {
+			managercfg.SymbolizerCache = osutil.Abs(cfg.SymbolizerCache)
+		}
+		if managercfg.SymbolizerServer == "" {
+			managercfg.SymbolizerServer = cfg.SymbolizerServer
+		}
 	}
 	return cfg, nil
 }
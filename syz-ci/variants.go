@@ -0,0 +1,77 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ManagerVariant describes one build variant of a manager (see ManagerConfig.Variants).
+// Non-empty fields override the corresponding manager fields.
+type ManagerVariant struct {
+	Name         string `json:"name"`
+	Compiler     string `json:"compiler"`
+	KernelConfig string `json:"kernel_config"`
+	// Appended to the manager kernel_config_fragments.
+	KernelConfigFragments []string `json:"kernel_config_fragments"`
+	// Top-level fields override the manager manager_config fields.
+	ManagerConfig json.RawMessage `json:"manager_config"`
+}
+
+// expandVariants replaces managers with variants with a separate manager per variant.
+// Each variant gets own kernel checkout, builds, workdir and dashboard name.
+func expandVariants(managers []*ManagerConfig) ([]*ManagerConfig, error) {
+	var res []*ManagerConfig
+	for _, mgr := range managers {
+		if len(mgr.Variants) == 0 {
+			res = append(res, mgr)
+			continue
+		}
+		for _, v := range mgr.Variants {
+			if v.Name == "" {
+				return nil, fmt.Errorf("manager %v: variant without name", mgr.Name)
+			}
+			mgr1 := new(ManagerConfig)
+			*mgr1 = *mgr
+			mgr1.Variants = nil
+			mgr1.Name = mgr.Name + "-" + v.Name
+			if v.Compiler != "" {
+				mgr1.Compiler = v.Compiler
+			}
+			if v.KernelConfig != "" {
+				mgr1.KernelConfig = v.KernelConfig
+			}
+			mgr1.KernelConfigFragments = append(append([]string{}, mgr.KernelConfigFragments...),
+				v.KernelConfigFragments...)
+			if len(v.ManagerConfig) != 0 {
+				merged, err := mergeJSON(mgr.ManagerConfig, v.ManagerConfig)
+				if err != nil {
+					return nil, fmt.Errorf("manager %v: %v", mgr1.Name, err)
+				}
+				mgr1.ManagerConfig = merged
+			}
+			res = append(res, mgr1)
+		}
+	}
+	return res, nil
+}
+
+// mergeJSON overrides top-level fields of the base object with fields of the override object.
+func mergeJSON(base, override json.RawMessage) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(base) != 0 {
+		if err := json.Unmarshal(base, &fields); err != nil {
+			return nil, fmt.Errorf("failed to parse manager_config: %v", err)
+		}
+	}
+	var fields1 map[string]json.RawMessage
+	if err := json.Unmarshal(override, &fields1); err != nil {
+		return nil, fmt.Errorf("failed to parse variant manager_config: %v", err)
+	}
+	for k, v := range fields1 {
+		fields[k] = v
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExpandVariants(t *testing.T) {
+	managers := []*ManagerConfig{
+		{
+			Name:          "upstream",
+			Compiler:      "gcc",
+			KernelConfig:  "upstream.config",
+			ManagerConfig: json.RawMessage(`{"procs": 8, "vm": {"count": 10}}`),
+			Variants: []*ManagerVariant{
+				{Name: "kasan"},
+				{
+					Name:                  "kcsan",
+					Compiler:              "clang",
+					KernelConfigFragments: []string{"kcsan.config"},
+					ManagerConfig:         json.RawMessage(`{"vm": {"count": 2}}`),
+				},
+			},
+		},
+		{
+			Name: "next",
+		},
+	}
+	res, err := expandVariants(managers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 || res[0].Name != "upstream-kasan" || res[1].Name != "upstream-kcsan" || res[2].Name != "next" {
+		t.Fatalf("bad expanded managers: %+v", res)
+	}
+	if res[0].Compiler != "gcc" || string(res[0].ManagerConfig) != `{"procs": 8, "vm": {"count": 10}}` {
+		t.Fatalf("bad kasan variant: %+v", res[0])
+	}
+	kcsan := res[1]
+	if kcsan.Compiler != "clang" || kcsan.KernelConfig != "upstream.config" ||
+		len(kcsan.KernelConfigFragments) != 1 || len(kcsan.Variants) != 0 {
+		t.Fatalf("bad kcsan variant: %+v", kcsan)
+	}
+	if string(kcsan.ManagerConfig) != `{"procs":8,"vm":{"count":2}}` {
+		t.Fatalf("bad kcsan manager config: %s", kcsan.ManagerConfig)
+	}
+	managers[0].Variants = append(managers[0].Variants, &ManagerVariant{})
+	if _, err := expandVariants(managers); err == nil {
+		t.Fatalf("variant without name is accepted")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/prog"
+)
+
+// When enable_syscalls is expanded, the corpus has no programs with the new syscalls
+// and mutation rarely inserts them. The manager sends such syscalls on connect and we generate
+// programs with each of them (plus calls that create the required resources)
+// until they get into corpus.
+
+// Number of backfill programs we generate for a syscall before giving up
+// (some syscalls never give new signal).
+const backfillAttempts = 1000
+
+func (fuzzer *Fuzzer) initBackfill(calls []int) {
+	fuzzer.backfill = make(map[int]int)
+	for _, id := range calls {
+		fuzzer.backfill[id] = backfillAttempts
+	}
+	if len(calls) != 0 {
+		log.Logf(0, "backfilling corpus for %v new syscalls", len(calls))
+	}
+}
+
+// backfillCall returns a syscall to generate a backfill program for (with probability 1/2),
+// or nil if all syscalls are in corpus.
+func (fuzzer *Fuzzer) backfillCall(rnd *rand.Rand) *prog.Syscall {
+	fuzzer.corpusMu.Lock()
+	defer fuzzer.corpusMu.Unlock()
+	if len(fuzzer.backfill) == 0 || rnd.Intn(2) == 0 {
+		return nil
+	}
+	n := rnd.Intn(len(fuzzer.backfill))
+	for id := range fuzzer.backfill {
+		if n--; n >= 0 {
+			continue
+		}
+		meta := fuzzer.target.Syscalls[id]
+		if fuzzer.backfill[id]--; fuzzer.backfill[id] == 0 {
+			log.Logf(0, "giving up backfilling %v", meta.Name)
+			delete(fuzzer.backfill, id)
+		}
+		return meta
+	}
+	return nil
+}
+
+// backfillDone removes syscalls used by the new corpus program p from backfill.
+// Must be called with corpusMu held.
+func (fuzzer *Fuzzer) backfillDone(p *prog.Prog) {
+	for _, c := range p.Calls {
+		if _, ok := fuzzer.backfill[c.Meta.ID]; ok {
+			log.Logf(1, "backfilled %v", c.Meta.Name)
+			delete(fuzzer.backfill, c.Meta.ID)
+		}
+	}
+}
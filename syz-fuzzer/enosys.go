@@ -0,0 +1,105 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Syscalls that are described, but don't exist in the kernel are normally detected during machine check.
+// But the check can miss some (e.g. syscalls filtered by seccomp of a container runtime, or
+// kernels without kallsyms for non-native arches). So we also watch results of executed syscalls
+// and disable syscalls that return nothing but ENOSYS instead of wasting executions on them.
+
+const (
+	linuxENOSYS = 38
+	// Number of executions of a syscall (all variants of the same syscall number in total)
+	// that return ENOSYS after which we disable it.
+	enosysThreshold = 100
+)
+
+type callResults struct {
+	enosys      uint32
+	other       uint32
+	unsupported uint32 // subset of other, see isUnsupportedErrno
+}
+
+func (fuzzer *Fuzzer) countCallResults(p *prog.Prog, info *ipc.ProgInfo) {
+	if fuzzer.callResults == nil || info == nil {
+		return
+	}
+	for i, inf := range info.Calls {
+		if i >= len(p.Calls) || inf.Flags&ipc.CallFinished == 0 {
+			continue
+		}
+		res := &fuzzer.callResults[p.Calls[i].Meta.ID]
+		if inf.Errno == linuxENOSYS {
+			atomic.AddUint32(&res.enosys, 1)
+		} else {
+			atomic.AddUint32(&res.other, 1)
+			if isUnsupportedErrno(inf.Errno) {
+				atomic.AddUint32(&res.unsupported, 1)
+			}
+		}
+	}
+}
+
+// disableENOSYSCalls disables syscalls that only returned ENOSYS and returns them.
+// It's called only from the poll goroutine.
+func (fuzzer *Fuzzer) disableENOSYSCalls() []rpctype.SyscallReason {
+	if fuzzer.callResults == nil {
+		return nil
+	}
+	enosys := make(map[uint64]uint32)
+	other := make(map[uint64]bool)
+	for c := range fuzzer.enabledCalls {
+		if strings.HasPrefix(c.CallName, "syz_") {
+			// Pseudo-syscalls may legitimately return ENOSYS and don't have real numbers.
+			continue
+		}
+		res := &fuzzer.callResults[c.ID]
+		enosys[c.NR] += atomic.LoadUint32(&res.enosys)
+		if atomic.LoadUint32(&res.other) != 0 {
+			other[c.NR] = true
+		}
+	}
+	var disabled []rpctype.SyscallReason
+	for c := range fuzzer.enabledCalls {
+		if strings.HasPrefix(c.CallName, "syz_") || other[c.NR] || enosys[c.NR] < enosysThreshold {
+			continue
+		}
+		if len(disabled)+1 == len(fuzzer.enabledCalls) {
+			// Something is badly broken, don't leave ourselves without syscalls.
+			break
+		}
+		reason := fmt.Sprintf("returned ENOSYS in all %v executions of %v", enosys[c.NR], c.CallName)
+		log.Logf(0, "disabling %v: %v", c.Name, reason)
+		disabled = append(disabled, rpctype.SyscallReason{ID: c.ID, Reason: reason})
+	}
+	if len(disabled) == 0 {
+		return nil
+	}
+	for _, dc := range disabled {
+		delete(fuzzer.enabledCalls, fuzzer.target.Syscalls[dc.ID])
+	}
+	prios := fuzzer.target.CalculatePriorities(fuzzer.corpusSnapshot())
+	ct := fuzzer.target.BuildChoiceTable(prios, fuzzer.enabledCalls)
+	fuzzer.ctMu.Lock()
+	fuzzer.choiceTable = ct
+	fuzzer.ctMu.Unlock()
+	return disabled
+}
+
+func (fuzzer *Fuzzer) choiceTableSnapshot() *prog.ChoiceTable {
+	fuzzer.ctMu.RLock()
+	defer fuzzer.ctMu.RUnlock()
+	return fuzzer.choiceTable
+}
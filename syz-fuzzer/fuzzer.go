@@ -22,6 +22,8 @@ import (
 	"github.com/google/syzkaller/pkg/ipc/ipcconfig"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/overlay"
+	"github.com/google/syzkaller/pkg/report"
 	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
@@ -37,6 +39,7 @@ type Fuzzer struct {
 	gate              *ipc.Gate
 	workQueue         *WorkQueue
 	needPoll          chan struct{}
+	ctMu              sync.RWMutex
 	choiceTable       *prog.ChoiceTable
 	stats             [StatCount]uint64
 	manager           *rpctype.RPCClient
@@ -45,15 +48,38 @@ type Fuzzer struct {
 
 	faultInjectionEnabled    bool
 	comparisonTracingEnabled bool
+	raceSchedulingEnabled    bool
+	experiments              map[string]bool // experimental features enabled by the manager
 
 	corpusMu     sync.RWMutex
 	corpus       []*prog.Prog
 	corpusHashes map[hash.Sig]struct{}
+	backfill     map[int]int // syscalls without corpus programs -> remaining attempts
+
+	resetMu        sync.RWMutex
+	resetLevels    ipc.ResetLevels
+	resetNamespace bool // namespace reset is supported
+	needReboot     chan struct{}
+	rebootOnce     sync.Once
+	sideEffects    map[string]ipc.SideEffects // known side effects of syscalls
+	newSideEffects map[string]ipc.SideEffects // side effects not yet reported to the manager
+
+	kmsgFile      *os.File // nil if kernel messages are not collected
+	kmsgThreshold report.KmsgSeverity
+	kmsgMu        sync.Mutex
+	kmsgTitles    map[string]bool
+	kmsgs         []rpctype.KernelMessage // messages not yet sent to the manager
 
 	signalMu     sync.RWMutex
 	corpusSignal signal.Signal // signal of inputs in corpus
 	maxSignal    signal.Signal // max signal ever observed including flakes
 	newSignal    signal.Signal // diff of maxSignal since last sync with master
+	raceSignal   signal.Signal // signal observed only under forced race schedules
+
+	enabledCalls map[*prog.Syscall]bool
+	callResults  []callResults // per syscall ID, nil if we don't track results
+	// Syscalls already reported to the manager as unsupported (accessed only by the poll goroutine).
+	reportedUnsupported map[int]bool
 
 	logMu sync.Mutex
 }
@@ -69,6 +95,8 @@ const (
 	StatSmash
 	StatHint
 	StatSeed
+	StatRace
+	StatBackfill
 	StatCount
 )
 
@@ -81,6 +109,8 @@ var statNames = [StatCount]string{
 	StatSmash:     "exec smash",
 	StatHint:      "exec hints",
 	StatSeed:      "exec seeds",
+	StatRace:      "exec race",
+	StatBackfill:  "exec backfill",
 }
 
 type OutputType int
@@ -105,6 +135,7 @@ func main() {
 		flagPprof   = flag.String("pprof", "", "address to serve pprof profiles")
 		flagTest    = flag.Bool("test", false, "enable image testing mode")      // used by syz-ci
 		flagRunTest = flag.Bool("runtest", false, "enable program testing mode") // used by pkg/runtest
+		flagExtra   = flag.String("extra_descriptions", "", "compiled descriptions of out-of-tree modules")
 	)
 	flag.Parse()
 	outputType := parseOutputType(*flagOutput)
@@ -114,6 +145,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
+	if *flagExtra != "" {
+		if err := overlay.Load(target, *flagExtra); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
 
 	config, execOpts, err := ipcconfig.Default(target)
 	if err != nil {
@@ -201,15 +237,37 @@ func main() {
 	if r.CheckResult.Features[host.FeatureNetworkDevices].Enabled {
 		config.Flags |= ipc.FlagEnableNetDev
 	}
-	config.Flags |= ipc.FlagEnableNetReset
+	if r.NetRecycle {
+		config.Flags |= ipc.FlagEnableNetRecycle
+	} else {
+		config.Flags |= ipc.FlagEnableNetReset
+	}
+	config.NetDevices = r.NetDevices
 	config.Flags |= ipc.FlagEnableCgroups
 	config.Flags |= ipc.FlagEnableCloseFds
+	if r.DetectSideEffects {
+		execOpts.Flags |= ipc.FlagDetectSideEffects
+	}
 
 	if *flagRunTest {
 		runTest(target, manager, *flagName, config.Executor)
 		return
 	}
+	if r.SandboxProfile != nil {
+		log.Logf(0, "using sandbox profile %v", r.SandboxProfileName)
+		config.SandboxProfile = r.SandboxProfile
+		config.Flags |= ipc.FlagSandboxProfile
+	}
+	if id := r.SandboxIdentity; id != nil {
+		log.Logf(0, "using sandbox identity uid=%v gid=%v groups=%v no_new_privs=%v cap_bound=0x%x",
+			id.UID, id.GID, id.Groups, id.NoNewPrivs, id.CapBound)
+		config.SandboxIdentity = id
+		config.Flags |= ipc.FlagSandboxIdentity
+	}
 
+	// Race scheduling needs threaded mode in executor.
+	raceScheduling := r.CheckResult.Features[host.FeatureRaceScheduling].Enabled &&
+		execOpts.Flags&ipc.FlagThreaded != 0
 	needPoll := make(chan struct{}, 1)
 	needPoll <- struct{}{}
 	fuzzer := &Fuzzer{
@@ -223,6 +281,7 @@ func main() {
 		target:                   target,
 		faultInjectionEnabled:    r.CheckResult.Features[host.FeatureFaultInjection].Enabled,
 		comparisonTracingEnabled: r.CheckResult.Features[host.FeatureComparisons].Enabled,
+		raceSchedulingEnabled:    raceScheduling,
 		corpusHashes:             make(map[hash.Sig]struct{}),
 	}
 	var gateCallback func()
@@ -230,14 +289,24 @@ func main() {
 		gateCallback = func() { fuzzer.gateCallback(r.MemoryLeakFrames) }
 	}
 	fuzzer.gate = ipc.NewGate(2**flagProcs, gateCallback)
+	fuzzer.applyExperiments(r.Experiments)
+	if !fuzzer.experiments[rpctype.ExperimentNoBackfill] {
+		fuzzer.initBackfill(r.BackfillCalls)
+	}
+	fuzzer.initReset(r, sandbox)
+	fuzzer.initKmsg(r.KmsgSeverity)
 	for i := 0; fuzzer.poll(i == 0, nil); i++ {
 	}
-	calls := make(map[*prog.Syscall]bool)
+	fuzzer.enabledCalls = make(map[*prog.Syscall]bool)
 	for _, id := range r.CheckResult.EnabledCalls[sandbox] {
-		calls[target.Syscalls[id]] = true
+		fuzzer.enabledCalls[target.Syscalls[id]] = true
+	}
+	if target.OS == "linux" {
+		fuzzer.callResults = make([]callResults, len(target.Syscalls))
+		fuzzer.reportedUnsupported = make(map[int]bool)
 	}
 	prios := target.CalculatePriorities(fuzzer.corpus)
-	fuzzer.choiceTable = target.BuildChoiceTable(prios, calls)
+	fuzzer.choiceTable = target.BuildChoiceTable(prios, fuzzer.enabledCalls)
 
 	for pid := 0; pid < *flagProcs; pid++ {
 		proc, err := newProc(fuzzer, pid)
@@ -247,10 +316,28 @@ func main() {
 		fuzzer.procs = append(fuzzer.procs, proc)
 		go proc.loop()
 	}
+	if fuzzer.kmsgFile != nil {
+		go fuzzer.kmsgLoop()
+	}
 
 	fuzzer.pollLoop()
 }
 
+// applyExperiments enables experimental features the manager has chosen for this fuzzer.
+func (fuzzer *Fuzzer) applyExperiments(features []string) {
+	fuzzer.experiments = make(map[string]bool)
+	for _, feature := range features {
+		log.Logf(0, "experiment: %v", feature)
+		fuzzer.experiments[feature] = true
+	}
+	if fuzzer.experiments[rpctype.ExperimentNoHints] {
+		fuzzer.comparisonTracingEnabled = false
+	}
+	if fuzzer.experiments[rpctype.ExperimentNoRace] {
+		fuzzer.raceSchedulingEnabled = false
+	}
+}
+
 func (fuzzer *Fuzzer) gateCallback(leakFrames []string) {
 	// Leak checking is very slow so we don't do it while triaging the corpus
 	// (otherwise it takes infinity). When we have presumably triaged the corpus
@@ -281,19 +368,22 @@ func (fuzzer *Fuzzer) pollLoop() {
 	var lastPrint time.Time
 	ticker := time.NewTicker(3 * time.Second).C
 	for {
-		poll := false
+		poll, reboot := false, false
 		select {
 		case <-ticker:
 		case <-fuzzer.needPoll:
 			poll = true
+		case <-fuzzer.needReboot:
+			reboot = true
 		}
 		if fuzzer.outputType != OutputStdout && time.Since(lastPrint) > 10*time.Second {
 			// Keep-alive for manager.
 			log.Logf(0, "alive, executed %v", execTotal)
 			lastPrint = time.Now()
 		}
-		if poll || time.Since(lastPoll) > 10*time.Second {
-			needCandidates := fuzzer.workQueue.wantCandidates()
+		if poll || reboot || time.Since(lastPoll) > 10*time.Second {
+			// Don't take candidates from the manager if we are going to lose them.
+			needCandidates := fuzzer.workQueue.wantCandidates() && !reboot
 			if poll && !needCandidates {
 				continue
 			}
@@ -307,9 +397,16 @@ func (fuzzer *Fuzzer) pollLoop() {
 				stats[statNames[stat]] = v
 				execTotal += v
 			}
+			if reboot {
+				stats["reset reboots"]++
+			}
 			if !fuzzer.poll(needCandidates, stats) {
 				lastPoll = time.Now()
 			}
+			if reboot {
+				log.Logf(0, "SYZ-FUZZER: REBOOT")
+				os.Exit(1)
+			}
 		}
 	}
 }
@@ -320,6 +417,10 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 		NeedCandidates: needCandidates,
 		MaxSignal:      fuzzer.grabNewSignal().Serialize(),
 		Stats:          stats,
+		DisabledCalls:  fuzzer.disableENOSYSCalls(),
+		Unsupported:    fuzzer.unsupportedCalls(),
+		SideEffects:    fuzzer.grabNewSideEffects(),
+		KernelMessages: fuzzer.grabKmsgs(),
 	}
 	r := &rpctype.PollRes{}
 	if err := fuzzer.manager.Call("Manager.Poll", a, r); err != nil {
@@ -329,6 +430,9 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	log.Logf(1, "poll: candidates=%v inputs=%v signal=%v",
 		len(r.Candidates), len(r.NewInputs), maxSignal.Len())
 	fuzzer.addMaxSignal(maxSignal)
+	if r.Profile != nil {
+		go fuzzer.sendProfile(r.Profile)
+	}
 	for _, inp := range r.NewInputs {
 		fuzzer.addInputFromAnotherFuzzer(inp)
 	}
@@ -347,6 +451,19 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 		fuzzer.workQueue.enqueue(&WorkCandidate{
 			p:     p,
 			flags: flags,
+			hash:  hash.String(candidate.Prog),
+		})
+	}
+	for _, inp := range r.Reminimize {
+		p, err := fuzzer.target.Deserialize(inp.Prog, prog.NonStrict)
+		if err != nil {
+			log.Fatalf("failed to parse program from manager: %v", err)
+		}
+		fuzzer.workQueue.enqueue(&WorkReminimize{
+			p:      p,
+			call:   inp.Call,
+			signal: inp.Signal.Deserialize(),
+			orig:   hash.String(inp.Prog),
 		})
 	}
 	if needCandidates && len(r.Candidates) == 0 && atomic.LoadUint32(&fuzzer.triagedCandidates) == 0 {
@@ -355,11 +472,8 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	return len(r.NewInputs) != 0 || len(r.Candidates) != 0 || maxSignal.Len() != 0
 }
 
-func (fuzzer *Fuzzer) sendInputToManager(inp rpctype.RPCInput) {
-	a := &rpctype.NewInputArgs{
-		Name:     fuzzer.name,
-		RPCInput: inp,
-	}
+func (fuzzer *Fuzzer) sendInputToManager(a *rpctype.NewInputArgs) {
+	a.Name = fuzzer.name
 	if err := fuzzer.manager.Call("Manager.NewInput", a, nil); err != nil {
 		log.Fatalf("Manager.NewInput call failed: %v", err)
 	}
@@ -380,6 +494,9 @@ func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig has
 	if _, ok := fuzzer.corpusHashes[sig]; !ok {
 		fuzzer.corpus = append(fuzzer.corpus, p)
 		fuzzer.corpusHashes[sig] = struct{}{}
+		if len(fuzzer.backfill) != 0 {
+			fuzzer.backfillDone(p)
+		}
 	}
 	fuzzer.corpusMu.Unlock()
 
@@ -449,6 +566,20 @@ func (fuzzer *Fuzzer) checkNewCallSignal(p *prog.Prog, info *ipc.CallInfo, call
 	return true
 }
 
+func (fuzzer *Fuzzer) checkNewRaceSignal(sign signal.Signal) bool {
+	if sign.Empty() {
+		return false
+	}
+	fuzzer.signalMu.Lock()
+	defer fuzzer.signalMu.Unlock()
+	diff := fuzzer.raceSignal.Diff(sign)
+	if diff.Empty() {
+		return false
+	}
+	fuzzer.raceSignal.Merge(diff)
+	return true
+}
+
 func signalPrio(p *prog.Prog, info *ipc.CallInfo, call int) (prio uint8) {
 	if call == -1 {
 		return 0
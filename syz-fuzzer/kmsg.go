@@ -0,0 +1,97 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Some bug classes never escalate to an oops, but print a warning or an error message.
+// If the manager asks for it, we read such messages from /dev/kmsg (which, unlike console output,
+// contains message severity) and send them to the manager along with the programs
+// that were executing at that time. The manager filters out crashes and saves the rest.
+
+// Max number of distinct messages reported by a single fuzzer.
+const maxKmsgTitles = 1000
+
+func (fuzzer *Fuzzer) initKmsg(severity string) {
+	if severity == "" {
+		return
+	}
+	threshold, err := report.ParseKmsgSeverity(severity)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	f, err := os.Open("/dev/kmsg")
+	if err != nil {
+		log.Logf(0, "failed to open /dev/kmsg: %v", err)
+		return
+	}
+	// Messages printed before fuzzing are not caused by programs.
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		log.Logf(0, "failed to seek /dev/kmsg: %v", err)
+		f.Close()
+		return
+	}
+	fuzzer.kmsgFile = f
+	fuzzer.kmsgThreshold = threshold
+	fuzzer.kmsgTitles = make(map[string]bool)
+}
+
+func (fuzzer *Fuzzer) kmsgLoop() {
+	// A single read returns a single record, records are limited to 1K (plus dictionary).
+	buf := make([]byte, 8<<10)
+	for {
+		n, err := fuzzer.kmsgFile.Read(buf)
+		if err != nil {
+			// EPIPE means that some messages were overwritten before we read them.
+			if perr, ok := err.(*os.PathError); ok && perr.Err == syscall.EPIPE {
+				continue
+			}
+			log.Logf(0, "failed to read /dev/kmsg: %v", err)
+			return
+		}
+		rec := report.ParseKmsgRecord(buf[:n])
+		if rec == nil || rec.Severity > fuzzer.kmsgThreshold {
+			continue
+		}
+		title := report.KmsgTitle(rec)
+		fuzzer.kmsgMu.Lock()
+		if !fuzzer.kmsgTitles[title] && len(fuzzer.kmsgTitles) < maxKmsgTitles {
+			fuzzer.kmsgTitles[title] = true
+			fuzzer.kmsgs = append(fuzzer.kmsgs, rpctype.KernelMessage{
+				Title: title,
+				Text:  append([]byte{}, rec.Text...),
+				Progs: fuzzer.lastProgs(),
+			})
+		}
+		fuzzer.kmsgMu.Unlock()
+	}
+}
+
+// lastProgs returns the programs that are executing (or were executed last) by all procs.
+func (fuzzer *Fuzzer) lastProgs() [][]byte {
+	var progs [][]byte
+	for _, proc := range fuzzer.procs {
+		if data, _ := proc.lastProg.Load().([]byte); data != nil {
+			progs = append(progs, data)
+		}
+	}
+	return progs
+}
+
+// grabKmsgs returns kernel messages collected since the previous call.
+func (fuzzer *Fuzzer) grabKmsgs() []rpctype.KernelMessage {
+	fuzzer.kmsgMu.Lock()
+	defer fuzzer.kmsgMu.Unlock()
+	res := fuzzer.kmsgs
+	fuzzer.kmsgs = nil
+	return res
+}
@@ -36,6 +36,7 @@ type Proc struct {
 	execOptsCover     *ipc.ExecOpts
 	execOptsComps     *ipc.ExecOpts
 	execOptsNoCollide *ipc.ExecOpts
+	lastProg          atomic.Value // []byte, the last executed program (if kernel messages are collected)
 }
 
 func newProc(fuzzer *Fuzzer, pid int) (*Proc, error) {
@@ -69,6 +70,8 @@ func (proc *Proc) loop() {
 		// If we don't have real coverage signal, generate programs more frequently
 		// because fallback signal is weak.
 		generatePeriod = 2
+	} else if proc.fuzzer.experiments[rpctype.ExperimentGenerateOften] {
+		generatePeriod = 10
 	}
 	for i := 0; ; i++ {
 		item := proc.fuzzer.workQueue.dequeue()
@@ -77,22 +80,29 @@ func (proc *Proc) loop() {
 			case *WorkTriage:
 				proc.triageInput(item)
 			case *WorkCandidate:
-				proc.execute(proc.execOpts, item.p, item.flags, StatCandidate)
+				proc.executeCandidate(proc.execOpts, item.p, item.flags, StatCandidate, item.hash)
 			case *WorkSmash:
 				proc.smashInput(item)
+			case *WorkReminimize:
+				proc.reminimizeInput(item)
 			default:
 				log.Fatalf("unknown work type: %#v", item)
 			}
 			continue
 		}
 
-		ct := proc.fuzzer.choiceTable
+		ct := proc.fuzzer.choiceTableSnapshot()
 		corpus := proc.fuzzer.corpusSnapshot()
 		if len(corpus) == 0 || i%generatePeriod == 0 {
 			// Generate a new prog.
 			p := proc.fuzzer.target.Generate(proc.rnd, programLength, ct)
 			log.Logf(1, "#%v: generated", proc.pid)
 			proc.execute(proc.execOpts, p, ProgNormal, StatGenerate)
+		} else if meta := proc.fuzzer.backfillCall(proc.rnd); meta != nil {
+			// Generate a prog with a syscall that has no corpus programs yet.
+			p := proc.fuzzer.target.GenerateWithCall(proc.rnd, meta, ct)
+			log.Logf(1, "#%v: generated backfill for %v", proc.pid, meta.Name)
+			proc.execute(proc.execOpts, p, ProgNormal, StatBackfill)
 		} else {
 			// Mutate an existing prog.
 			p := corpus[proc.rnd.Intn(len(corpus))].Clone()
@@ -124,10 +134,16 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		signalRuns       = 3
 		minimizeAttempts = 3
 	)
+	execOptsCover := proc.execOptsCover
+	if item.race != nil {
+		opts := *item.race
+		opts.Flags |= ipc.FlagCollectCover
+		execOptsCover = &opts
+	}
 	// Compute input coverage and non-flaky signal for minimization.
 	notexecuted := 0
 	for i := 0; i < signalRuns; i++ {
-		info := proc.executeRaw(proc.execOptsCover, item.p, StatTriage)
+		info := proc.executeRaw(execOptsCover, item.p, StatTriage)
 		if !reexecutionSuccess(info, &item.info, item.call) {
 			// The call was not executed or failed.
 			notexecuted++
@@ -145,7 +161,8 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		}
 		inputCover.Merge(thisCover)
 	}
-	if item.flags&ProgMinimized == 0 {
+	// Minimization changes call indices, so we can't minimize raced inputs.
+	if item.flags&ProgMinimized == 0 && item.race == nil {
 		item.p, item.call = prog.Minimize(item.p, item.call, false,
 			func(p1 *prog.Prog, call1 int) bool {
 				for i := 0; i < minimizeAttempts; i++ {
@@ -167,11 +184,15 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 	sig := hash.Hash(data)
 
 	log.Logf(2, "added new input for %v to corpus:\n%s", logCallName, data)
-	proc.fuzzer.sendInputToManager(rpctype.RPCInput{
-		Call:   callName,
-		Prog:   data,
-		Signal: inputSignal.Serialize(),
-		Cover:  inputCover.Serialize(),
+	proc.fuzzer.sendInputToManager(&rpctype.NewInputArgs{
+		RPCInput: rpctype.RPCInput{
+			Call:   callName,
+			Prog:   data,
+			Signal: inputSignal.Serialize(),
+			Cover:  inputCover.Serialize(),
+		},
+		Unminimized: item.race != nil,
+		Candidate:   item.candidate,
 	})
 
 	proc.fuzzer.addInputToCorpus(item.p, inputSignal, sig)
@@ -181,6 +202,89 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 	}
 }
 
+func (proc *Proc) reminimizeInput(item *WorkReminimize) {
+	log.Logf(1, "#%v: re-minimizing corpus input for %v", proc.pid, item.call)
+	const (
+		signalRuns       = 3
+		minimizeAttempts = 3
+	)
+	// Find the call that gives the input signal and the non-flaky part of the signal.
+	call := -2
+	inputSignal := item.signal
+	for i := 0; i < signalRuns; i++ {
+		info := proc.executeRaw(proc.execOptsNoCollide, item.p, StatMinimize)
+		if call == -2 {
+			call = reminimizeCall(item, info)
+		}
+		if call == -2 || call >= len(info.Calls) {
+			return
+		}
+		thisSignal, _ := getSignalAndCover(item.p, info, call)
+		inputSignal = inputSignal.Intersection(thisSignal)
+	}
+	if inputSignal.Empty() {
+		return
+	}
+	p, call := prog.Minimize(item.p, call, false,
+		func(p1 *prog.Prog, call1 int) bool {
+			for i := 0; i < minimizeAttempts; i++ {
+				info := proc.execute(proc.execOptsNoCollide, p1, ProgNormal, StatMinimize)
+				if info == nil || call1 >= len(info.Calls) {
+					continue
+				}
+				thisSignal, _ := getSignalAndCover(p1, info, call1)
+				if inputSignal.Intersection(thisSignal).Len() == inputSignal.Len() {
+					return true
+				}
+			}
+			return false
+		})
+	info := proc.executeRaw(proc.execOptsCover, p, StatMinimize)
+	if info == nil || call >= len(info.Calls) {
+		return
+	}
+	thisSignal, thisCover := getSignalAndCover(p, info, call)
+	thisSignal.Merge(inputSignal)
+	var inputCover cover.Cover
+	inputCover.Merge(thisCover)
+	callName := ".extra"
+	if call != -1 {
+		callName = p.Calls[call].Meta.CallName
+	}
+	data := p.Serialize()
+	log.Logf(2, "re-minimized corpus input for %v: %v -> %v calls",
+		callName, len(item.p.Calls), len(p.Calls))
+	proc.fuzzer.sendInputToManager(&rpctype.NewInputArgs{
+		RPCInput: rpctype.RPCInput{
+			Call:   callName,
+			Prog:   data,
+			Signal: thisSignal.Serialize(),
+			Cover:  inputCover.Serialize(),
+		},
+		ReminimizedFrom: item.orig,
+	})
+	proc.fuzzer.addInputToCorpus(p, thisSignal, hash.Hash(data))
+}
+
+// reminimizeCall returns index of the call that gives the signal of the corpus input,
+// -1 for extra signal, or -2 if no call gives the signal anymore.
+func reminimizeCall(item *WorkReminimize, info *ipc.ProgInfo) int {
+	if item.call == ".extra" {
+		return -1
+	}
+	call, best := -2, 0
+	for i := range info.Calls {
+		if item.p.Calls[i].Meta.CallName != item.call {
+			continue
+		}
+		thisSignal, _ := getSignalAndCover(item.p, info, i)
+		if n := item.signal.Intersection(thisSignal).Len(); n > best {
+			call, best = i, n
+		}
+	}
+	return call
+}
+
 func reexecutionSuccess(info *ipc.ProgInfo, oldInfo *ipc.CallInfo, call int) bool {
 	if info == nil || len(info.Calls) == 0 {
 		return false
@@ -211,10 +315,13 @@ func (proc *Proc) smashInput(item *WorkSmash) {
 	if proc.fuzzer.comparisonTracingEnabled && item.call != -1 {
 		proc.executeHintSeed(item.p, item.call)
 	}
+	if proc.fuzzer.raceSchedulingEnabled && item.call != -1 {
+		proc.exploreRaces(item.p, item.call)
+	}
 	corpus := proc.fuzzer.corpusSnapshot()
 	for i := 0; i < 100; i++ {
 		p := item.p.Clone()
-		p.Mutate(proc.rnd, programLength, proc.fuzzer.choiceTable, corpus)
+		p.Mutate(proc.rnd, programLength, proc.fuzzer.choiceTableSnapshot(), corpus)
 		log.Logf(1, "#%v: smash mutated", proc.pid)
 		proc.execute(proc.execOpts, p, ProgNormal, StatSmash)
 	}
@@ -252,28 +359,43 @@ func (proc *Proc) executeHintSeed(p *prog.Prog, call int) {
 }
 
 func (proc *Proc) execute(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat) *ipc.ProgInfo {
+	return proc.executeCandidate(execOpts, p, flags, stat, "")
+}
+
+// executeCandidate is execute for programs that come from manager candidates,
+// candidate is passed through triage back to manager (see rpctype.NewInputArgs).
+func (proc *Proc) executeCandidate(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat,
+	candidate string) *ipc.ProgInfo {
 	info := proc.executeRaw(execOpts, p, stat)
 	calls, extra := proc.fuzzer.checkNewSignal(p, info)
+	var race *ipc.ExecOpts
+	if execOpts.Flags&ipc.FlagRace != 0 {
+		// Signal of raced calls is reproducible only under the same schedule.
+		race = execOpts
+	}
 	for _, callIndex := range calls {
-		proc.enqueueCallTriage(p, flags, callIndex, info.Calls[callIndex])
+		proc.enqueueCallTriage(p, flags, callIndex, info.Calls[callIndex], race, candidate)
 	}
 	if extra {
-		proc.enqueueCallTriage(p, flags, -1, info.Extra)
+		proc.enqueueCallTriage(p, flags, -1, info.Extra, race, candidate)
 	}
 	return info
 }
 
-func (proc *Proc) enqueueCallTriage(p *prog.Prog, flags ProgTypes, callIndex int, info ipc.CallInfo) {
+func (proc *Proc) enqueueCallTriage(p *prog.Prog, flags ProgTypes, callIndex int, info ipc.CallInfo,
+	race *ipc.ExecOpts, candidate string) {
 	// info.Signal points to the output shmem region, detach it before queueing.
 	info.Signal = append([]uint32{}, info.Signal...)
 	// None of the caller use Cover, so just nil it instead of detaching.
 	// Note: triage input uses executeRaw to get coverage.
 	info.Cover = nil
 	proc.fuzzer.workQueue.enqueue(&WorkTriage{
-		p:     p.Clone(),
-		call:  callIndex,
-		info:  info,
-		flags: flags,
+		p:         p.Clone(),
+		call:      callIndex,
+		info:      info,
+		flags:     flags,
+		race:      race,
+		candidate: candidate,
 	})
 }
 
@@ -281,12 +403,17 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 	if opts.Flags&ipc.FlagDedupCover == 0 {
 		log.Fatalf("dedup cover is not enabled")
 	}
+	proc.fuzzer.waitReboot()
+	opts, level := proc.fuzzer.resetOpts(opts, p)
 
 	// Limit concurrency window and do leak checking once in a while.
 	ticket := proc.fuzzer.gate.Enter()
 	defer proc.fuzzer.gate.Leave(ticket)
 
 	proc.logProgram(opts, p)
+	if proc.fuzzer.kmsgFile != nil {
+		proc.lastProg.Store(p.Serialize())
+	}
 	for try := 0; ; try++ {
 		atomic.AddUint64(&proc.fuzzer.stats[stat], 1)
 		output, info, hanged, err := proc.env.Exec(opts, p)
@@ -300,6 +427,13 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 			continue
 		}
 		log.Logf(2, "result hanged=%v: %s", hanged, output)
+		proc.fuzzer.countCallResults(p, info)
+		if level == ipc.ResetReboot {
+			proc.fuzzer.checkReboot(p, info)
+		}
+		if opts.Flags&ipc.FlagDetectSideEffects != 0 {
+			proc.fuzzer.checkSideEffects(p, info)
+		}
 		return info
 	}
 }
@@ -314,6 +448,10 @@ func (proc *Proc) logProgram(opts *ipc.ExecOpts, p *prog.Prog) {
 	if opts.Flags&ipc.FlagInjectFault != 0 {
 		strOpts = fmt.Sprintf(" (fault-call:%v fault-nth:%v)", opts.FaultCall, opts.FaultNth)
 	}
+	if opts.Flags&ipc.FlagRace != 0 {
+		strOpts = fmt.Sprintf(" (race-calls:%v/%v race-mode:%v race-delay:%v)",
+			opts.RaceCall1, opts.RaceCall2, opts.RaceMode, opts.RaceDelay)
+	}
 
 	// The following output helps to understand what program crashed kernel.
 	// It must not be intermixed.
@@ -0,0 +1,63 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// heapProfileRate is used when the manager requests a heap profile,
+// but heap profiling was disabled at startup (no -pprof flag).
+const heapProfileRate = 512 << 10
+
+// sendProfile collects the profile requested by the manager and sends it back.
+func (fuzzer *Fuzzer) sendProfile(req *rpctype.ProfileRequest) {
+	log.Logf(0, "collecting %v profile", req.Kind)
+	a := &rpctype.ProfileArgs{
+		Name: fuzzer.name,
+		Kind: req.Kind,
+	}
+	data, err := collectProfile(req)
+	if err != nil {
+		a.Error = err.Error()
+	}
+	a.Data = data
+	if err := fuzzer.manager.Call("Manager.Profile", a, nil); err != nil {
+		log.Fatalf("Manager.Profile call failed: %v", err)
+	}
+}
+
+func collectProfile(req *rpctype.ProfileRequest) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	switch req.Kind {
+	case "cpu":
+		if err := pprof.StartCPUProfile(buf); err != nil {
+			return nil, err
+		}
+		time.Sleep(req.Duration)
+		pprof.StopCPUProfile()
+	case "heap":
+		if runtime.MemProfileRate == 0 {
+			runtime.MemProfileRate = heapProfileRate
+			return nil, fmt.Errorf("heap profiling was disabled, enabled it now, request the profile again later")
+		}
+		fallthrough
+	default:
+		p := pprof.Lookup(req.Kind)
+		if p == nil {
+			return nil, fmt.Errorf("unknown profile %q", req.Kind)
+		}
+		if err := p.WriteTo(buf, 0); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
+)
+
+// Race exploration executes pairs of calls that work on the same resource
+// under schedules forced by executor (see ipc.FlagRace): either the second call
+// preempts the first one on the same CPU, or the calls run on different CPUs
+// with the second one delayed. We start with a coarse grid of delays and refine
+// around schedules that produce new race signal, that is signal of the raced calls
+// that they don't produce when executed sequentially.
+// The raced executions also go through the normal new signal checks,
+// such inputs are triaged under the same schedule.
+
+const (
+	maxRacePairs     = 4  // per input
+	maxRaceSchedules = 24 // per pair of calls
+)
+
+// raceDelays is the initial grid of delays of the second raced call (in ns).
+var raceDelays = []int{0, 2000, 8000, 32000, 128000}
+
+var raceModes = []ipc.RaceMode{ipc.RacePreempt, ipc.RaceParallel}
+
+type raceSched struct {
+	mode  ipc.RaceMode
+	delay int
+}
+
+func (proc *Proc) exploreRaces(p *prog.Prog, call int) {
+	pairs := proc.racePairs(p, call)
+	if len(pairs) == 0 {
+		return
+	}
+	// Sequential execution gives the baseline for race signal.
+	info := proc.executeRaw(proc.execOptsNoCollide, p, StatRace)
+	if info == nil {
+		return
+	}
+	bases := make([]signal.Signal, len(pairs))
+	for i, pair := range pairs {
+		bases[i] = raceCallsSignal(p, info, pair, false)
+	}
+	for i, pair := range pairs {
+		proc.explorePair(p, pair, bases[i])
+	}
+}
+
+func (proc *Proc) explorePair(p *prog.Prog, pair [2]int, base signal.Signal) {
+	var queue []raceSched
+	for _, mode := range raceModes {
+		for _, delay := range raceDelays {
+			queue = append(queue, raceSched{mode, delay})
+		}
+	}
+	seen := make(map[raceSched]bool)
+	for execs := 0; execs < maxRaceSchedules && len(queue) != 0; {
+		sched := queue[0]
+		queue = queue[1:]
+		if seen[sched] {
+			continue
+		}
+		seen[sched] = true
+		execs++
+		log.Logf(1, "#%v: racing calls %v/%v mode=%v delay=%v",
+			proc.pid, pair[0], pair[1], sched.mode, sched.delay)
+		opts := *proc.execOptsNoCollide
+		opts.Flags |= ipc.FlagRace
+		opts.RaceCall1 = pair[0]
+		opts.RaceCall2 = pair[1]
+		opts.RaceMode = sched.mode
+		opts.RaceDelay = sched.delay
+		info := proc.execute(&opts, p, ProgNormal, StatRace)
+		if info == nil {
+			continue
+		}
+		raced := raceCallsSignal(p, info, pair, true)
+		if !proc.fuzzer.checkNewRaceSignal(base.Diff(raced)) {
+			continue
+		}
+		// This schedule hits an interleaving-sensitive path, look closer around it.
+		if sched.delay == 0 {
+			queue = append(queue, raceSched{sched.mode, raceDelays[1] / 4})
+		} else {
+			queue = append(queue,
+				raceSched{sched.mode, sched.delay / 2},
+				raceSched{sched.mode, sched.delay + sched.delay/2})
+		}
+	}
+}
+
+// racePairs returns pairs of calls that use the same resource as the call.
+// Calls between the raced calls are executed before the raced calls,
+// so the pairs are ordered by call index.
+func (proc *Proc) racePairs(p *prog.Prog, call int) [][2]int {
+	used := raceResources(p.Calls[call])
+	if len(used) == 0 {
+		return nil
+	}
+	var pairs [][2]int
+	for _, i := range proc.rnd.Perm(len(p.Calls)) {
+		if i == call {
+			continue
+		}
+		for res := range raceResources(p.Calls[i]) {
+			if !used[res] {
+				continue
+			}
+			if i < call {
+				pairs = append(pairs, [2]int{i, call})
+			} else {
+				pairs = append(pairs, [2]int{call, i})
+			}
+			break
+		}
+		if len(pairs) == maxRacePairs {
+			break
+		}
+	}
+	return pairs
+}
+
+// raceResources returns resources created by other calls that the call uses.
+func raceResources(c *prog.Call) map[*prog.ResultArg]bool {
+	res := make(map[*prog.ResultArg]bool)
+	prog.ForeachArg(c, func(arg prog.Arg, _ *prog.ArgCtx) {
+		if a, ok := arg.(*prog.ResultArg); ok && a.Res != nil {
+			res[a.Res] = true
+		}
+	})
+	return res
+}
+
+// raceCallsSignal returns the combined signal of both calls of the pair.
+// If raced is set, it returns nil unless executor actually raced the calls.
+func raceCallsSignal(p *prog.Prog, info *ipc.ProgInfo, pair [2]int, raced bool) signal.Signal {
+	var sign signal.Signal
+	for _, call := range pair {
+		if call >= len(info.Calls) {
+			return nil
+		}
+		inf := &info.Calls[call]
+		if raced && inf.Flags&ipc.CallRaced == 0 {
+			return nil
+		}
+		sign.Merge(signal.FromRaw(inf.Signal, signalPrio(p, inf, call)))
+	}
+	return sign
+}
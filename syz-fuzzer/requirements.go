@@ -0,0 +1,60 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/host"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Syscalls that exist, but always fail with ENODEV/EOPNOTSUPP and similar errors most likely need
+// a kernel config or a device that is missing. We don't disable them (some arguments may still work),
+// but report them to the manager together with missing requirements, so that the user knows
+// what this run can't exercise.
+
+const (
+	linuxENODEV          = 19
+	linuxEPROTONOSUPPORT = 93
+	linuxEOPNOTSUPP      = 95
+	linuxEAFNOSUPPORT    = 97
+	// Number of executions of a syscall after which we report it if all of them failed.
+	unsupportedThreshold = 100
+)
+
+func isUnsupportedErrno(errno int) bool {
+	switch errno {
+	case linuxENODEV, linuxEPROTONOSUPPORT, linuxEOPNOTSUPP, linuxEAFNOSUPPORT:
+		return true
+	}
+	return false
+}
+
+// unsupportedCalls returns syscalls that failed with unsupported errors in all executions
+// and were not reported yet. It's called only from the poll goroutine.
+func (fuzzer *Fuzzer) unsupportedCalls() []rpctype.SyscallReason {
+	if fuzzer.callResults == nil {
+		return nil
+	}
+	var res []rpctype.SyscallReason
+	for c := range fuzzer.enabledCalls {
+		if fuzzer.reportedUnsupported[c.ID] {
+			continue
+		}
+		results := &fuzzer.callResults[c.ID]
+		other := atomic.LoadUint32(&results.other)
+		if other < unsupportedThreshold || atomic.LoadUint32(&results.unsupported) != other {
+			continue
+		}
+		fuzzer.reportedUnsupported[c.ID] = true
+		// The reason is empty if we don't know what's missing.
+		reason := strings.Join(host.MissingRequirements(c), ", ")
+		log.Logf(0, "%v failed with ENODEV/EOPNOTSUPP in all %v executions: %v", c.Name, other, reason)
+		res = append(res, rpctype.SyscallReason{ID: c.ID, Reason: reason})
+	}
+	return res
+}
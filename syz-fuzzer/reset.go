@@ -0,0 +1,135 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Programs that are suspected to leave persistent state behind (mounts, loaded modules)
+// are executed with an escalated reset level (see ipc.ResetLevel). Namespace reset is cheap enough
+// to be done for every such program. Reboot is expensive, so we request it only after a reboot-level
+// call has actually succeeded: we send the remaining stats to the manager and exit with
+// "SYZ-FUZZER: REBOOT", which the vm package treats as a normal exit, so the manager restarts the VM.
+// If side effects detection is enabled, calls that are observed to change global state are escalated
+// to the namespace level, and the machine is rebooted right away if the state can't be undone otherwise.
+
+func (fuzzer *Fuzzer) initReset(r *rpctype.ConnectRes, sandbox string) {
+	levels, err := ipc.MakeResetLevels(r.ResetLevels)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fuzzer.resetLevels = levels
+	fuzzer.sideEffects = make(map[string]ipc.SideEffects)
+	fuzzer.newSideEffects = make(map[string]ipc.SideEffects)
+	for name, effects := range r.SideEffects {
+		meta := fuzzer.target.SyscallMap[name]
+		if meta == nil {
+			continue
+		}
+		fuzzer.sideEffects[name] = effects
+		fuzzer.escalateReset(meta, effects)
+	}
+	fuzzer.resetNamespace = fuzzer.target.OS == "linux" && fuzzer.config.Flags&ipc.FlagUseForkServer != 0 &&
+		(sandbox == "none" || sandbox == "namespace")
+	fuzzer.needReboot = make(chan struct{})
+}
+
+// resetOpts returns exec options for p with the required reset level.
+func (fuzzer *Fuzzer) resetOpts(opts *ipc.ExecOpts, p *prog.Prog) (*ipc.ExecOpts, ipc.ResetLevel) {
+	fuzzer.resetMu.RLock()
+	level := fuzzer.resetLevels.Prog(p)
+	fuzzer.resetMu.RUnlock()
+	if level >= ipc.ResetNamespace && fuzzer.resetNamespace {
+		opts1 := *opts
+		opts1.Flags |= ipc.FlagResetNamespace
+		opts = &opts1
+	}
+	return opts, level
+}
+
+// checkReboot requests VM reboot if a reboot-level call of p has succeeded.
+func (fuzzer *Fuzzer) checkReboot(p *prog.Prog, info *ipc.ProgInfo) {
+	for i, inf := range info.Calls {
+		if i >= len(p.Calls) || inf.Flags&ipc.CallFinished == 0 || inf.Errno != 0 {
+			continue
+		}
+		fuzzer.resetMu.RLock()
+		level := fuzzer.resetLevels.Call(p.Calls[i].Meta)
+		fuzzer.resetMu.RUnlock()
+		if level == ipc.ResetReboot {
+			fuzzer.requestReboot(p.Calls[i].Meta.Name + " has succeeded")
+			return
+		}
+	}
+}
+
+func (fuzzer *Fuzzer) requestReboot(reason string) {
+	fuzzer.rebootOnce.Do(func() {
+		log.Logf(0, "%v, requesting reboot", reason)
+		close(fuzzer.needReboot)
+	})
+}
+
+// checkSideEffects records side effects of calls of p reported by executor
+// and requests the reset level required to undo them.
+func (fuzzer *Fuzzer) checkSideEffects(p *prog.Prog, info *ipc.ProgInfo) {
+	var reboot *prog.Syscall
+	for i, inf := range info.Calls {
+		if i >= len(p.Calls) || inf.SideEffects == 0 {
+			continue
+		}
+		meta := p.Calls[i].Meta
+		log.Logf(1, "%v has side effects: %v", meta.Name, inf.SideEffects)
+		fuzzer.resetMu.Lock()
+		if fuzzer.sideEffects[meta.Name]&inf.SideEffects != inf.SideEffects {
+			fuzzer.sideEffects[meta.Name] |= inf.SideEffects
+			fuzzer.newSideEffects[meta.Name] |= inf.SideEffects
+			fuzzer.escalateReset(meta, inf.SideEffects)
+		}
+		fuzzer.resetMu.Unlock()
+		if inf.SideEffects.ResetLevel() == ipc.ResetReboot && reboot == nil {
+			reboot = meta
+		}
+	}
+	if reboot != nil {
+		fuzzer.requestReboot(reboot.Name + " has side effects")
+	}
+}
+
+// escalateReset raises reset level of the call to undo effects for subsequent programs.
+// Only namespace level is inherited by programs: effects that require reboot are often caused
+// by generic calls (e.g. write to a sysctl file), rebooting after each of them would make
+// fuzzing impossible. Instead, we reboot once the effects are actually observed.
+// Must be called with resetMu held.
+func (fuzzer *Fuzzer) escalateReset(meta *prog.Syscall, effects ipc.SideEffects) {
+	if effects.ResetLevel() >= ipc.ResetNamespace && fuzzer.resetLevels[meta.CallName] < ipc.ResetNamespace {
+		fuzzer.resetLevels[meta.CallName] = ipc.ResetNamespace
+	}
+}
+
+// grabNewSideEffects returns side effects detected since the previous call.
+func (fuzzer *Fuzzer) grabNewSideEffects() map[string]ipc.SideEffects {
+	fuzzer.resetMu.Lock()
+	defer fuzzer.resetMu.Unlock()
+	if len(fuzzer.newSideEffects) == 0 {
+		return nil
+	}
+	res := fuzzer.newSideEffects
+	fuzzer.newSideEffects = make(map[string]ipc.SideEffects)
+	return res
+}
+
+// waitReboot blocks forever once reboot is requested:
+// the machine state is not trusted anymore, so we don't execute any more programs.
+func (fuzzer *Fuzzer) waitReboot() {
+	select {
+	case <-fuzzer.needReboot:
+		select {}
+	default:
+	}
+}
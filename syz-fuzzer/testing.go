@@ -171,10 +171,11 @@ func checkMachine(args *checkArgs) (*rpctype.CheckArgs, error) {
 	if args.allSandboxes {
 		var enabled []int
 		for _, id := range res.EnabledCalls["none"] {
-			switch args.target.Syscalls[id].Name {
+			switch args.target.Syscalls[id].CallName {
 			default:
 				enabled = append(enabled, id)
-			case "syz_emit_ethernet", "syz_extract_tcp_res":
+			case "syz_emit_ethernet", "syz_extract_tcp_res", "syz_vtcp_connect", "syz_vtcp_accept",
+				"syz_vtcp_send", "syz_vtcp_recv", "syz_vtcp_tls", "syz_vtcp_tls_send":
 				// Tun is not setup without sandbox, this is a hacky way to workaround this.
 			}
 		}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 )
 
@@ -20,6 +21,7 @@ type WorkQueue struct {
 	candidate       []*WorkCandidate
 	triage          []*WorkTriage
 	smash           []*WorkSmash
+	reminimize      []*WorkReminimize
 
 	procs          int
 	needCandidates chan struct{}
@@ -43,6 +45,9 @@ type WorkTriage struct {
 	call  int
 	info  ipc.CallInfo
 	flags ProgTypes
+	race  *ipc.ExecOpts // non-nil if the signal was observed under a forced race schedule
+	// Hash of the manager candidate the program comes from, if any.
+	candidate string
 }
 
 // WorkCandidate are programs from hub.
@@ -51,6 +56,7 @@ type WorkTriage struct {
 type WorkCandidate struct {
 	p     *prog.Prog
 	flags ProgTypes
+	hash  string // hash of the program as sent by manager
 }
 
 // WorkSmash are programs just added to corpus.
@@ -61,6 +67,16 @@ type WorkSmash struct {
 	call int
 }
 
+// WorkReminimize are inputs from manager corpus that need to be minimized again
+// (e.g. they were added before improvements in program minimization).
+// This is the least important work, it's done only when there is nothing else to do.
+type WorkReminimize struct {
+	p      *prog.Prog
+	call   string // name of the call that gives the signal, or .extra
+	signal signal.Signal
+	orig   string // hash of the input in manager corpus
+}
+
 func newWorkQueue(procs int, needCandidates chan struct{}) *WorkQueue {
 	return &WorkQueue{
 		procs:          procs,
@@ -82,6 +98,8 @@ func (wq *WorkQueue) enqueue(item interface{}) {
 		wq.candidate = append(wq.candidate, item)
 	case *WorkSmash:
 		wq.smash = append(wq.smash, item)
+	case *WorkReminimize:
+		wq.reminimize = append(wq.reminimize, item)
 	default:
 		panic("unknown work type")
 	}
@@ -89,7 +107,7 @@ func (wq *WorkQueue) enqueue(item interface{}) {
 
 func (wq *WorkQueue) dequeue() (item interface{}) {
 	wq.mu.RLock()
-	if len(wq.triageCandidate)+len(wq.candidate)+len(wq.triage)+len(wq.smash) == 0 {
+	if len(wq.triageCandidate)+len(wq.candidate)+len(wq.triage)+len(wq.smash)+len(wq.reminimize) == 0 {
 		wq.mu.RUnlock()
 		return nil
 	}
@@ -113,6 +131,10 @@ func (wq *WorkQueue) dequeue() (item interface{}) {
 		last := len(wq.smash) - 1
 		item = wq.smash[last]
 		wq.smash = wq.smash[:last]
+	} else if len(wq.reminimize) != 0 {
+		last := len(wq.reminimize) - 1
+		item = wq.reminimize[last]
+		wq.reminimize = wq.reminimize[:last]
 	}
 	wq.mu.Unlock()
 	if wantCandidates {
@@ -0,0 +1,130 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// ClientDB holds clients created in the admin UI. Only hashes of client tokens are stored.
+type ClientDB struct {
+	file    string
+	Clients []*Client
+}
+
+type Client struct {
+	Name      string
+	Namespace string
+	TokenHash string
+	Created   time.Time
+	Revoked   time.Time // zero for active clients
+}
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func loadClientDB(file string) (*ClientDB, error) {
+	db := &ClientDB{file: file}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if osutil.IsExist(file) {
+			return nil, err
+		}
+		return db, nil
+	}
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", file, err)
+	}
+	return db, nil
+}
+
+func (db *ClientDB) save() error {
+	data, err := json.MarshalIndent(db, "", "\t")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(db.file, data)
+}
+
+// Active returns the active client with the given name, or nil.
+func (db *ClientDB) Active(name string) *Client {
+	for _, c := range db.Clients {
+		if c.Name == name && c.Revoked.IsZero() {
+			return c
+		}
+	}
+	return nil
+}
+
+// Create creates a new client and returns its token (the token is not stored and can't be recovered).
+func (db *ClientDB) Create(name, namespace string) (string, error) {
+	if !nameRe.MatchString(name) {
+		return "", fmt.Errorf("bad client name %q", name)
+	}
+	if namespace != "" && !nameRe.MatchString(namespace) {
+		return "", fmt.Errorf("bad namespace %q", namespace)
+	}
+	if db.Active(name) != nil {
+		return "", fmt.Errorf("client %v already exists", name)
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	db.Clients = append(db.Clients, &Client{
+		Name:      name,
+		Namespace: namespace,
+		TokenHash: hashToken(token),
+		Created:   time.Now(),
+	})
+	return token, db.save()
+}
+
+// Revoke revokes the client with the given name, or all clients in the given namespace.
+func (db *ClientDB) Revoke(name, namespace string) (int, error) {
+	revoked := 0
+	for _, c := range db.Clients {
+		if !c.Revoked.IsZero() || name != "" && c.Name != name ||
+			name == "" && c.Namespace != namespace {
+			continue
+		}
+		c.Revoked = time.Now()
+		revoked++
+	}
+	if revoked == 0 {
+		return 0, fmt.Errorf("no active clients found")
+	}
+	return revoked, db.save()
+}
+
+// Auth returns the active client with the given name and token, or nil.
+func (db *ClientDB) Auth(name, token string) *Client {
+	c := db.Active(name)
+	if c == nil || subtle.ConstantTimeCompare([]byte(c.TokenHash), []byte(hashToken(token))) != 1 {
+		return nil
+	}
+	return c
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -4,24 +4,33 @@
 package main
 
 import (
+	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
 	"html/template"
 	"net"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/syzkaller/pkg/log"
 )
 
-func (hub *Hub) initHTTP(addr string) {
+func (hub *Hub) initHTTP(addr string, tlsCfg *tls.Config) {
 	http.HandleFunc("/", hub.httpSummary)
+	http.HandleFunc("/admin", hub.httpAdmin)
 
 	ln, err := net.Listen("tcp4", addr)
 	if err != nil {
 		log.Fatalf("failed to listen on %v: %v", addr, err)
 	}
-	log.Logf(0, "serving http on http://%v", ln.Addr())
+	scheme := "http"
+	if tlsCfg != nil {
+		ln = tls.NewListener(ln, tlsCfg)
+		scheme = "https"
+	}
+	log.Logf(0, "serving http on %v://%v", scheme, ln.Addr())
 	go func() {
 		err := http.Serve(ln, nil)
 		log.Fatalf("failed to serve http: %v", err)
@@ -36,25 +45,38 @@ func (hub *Hub) httpSummary(w http.ResponseWriter, r *http.Request) {
 		Log: log.CachedLogOutput(),
 	}
 	total := UIManager{
-		Name:   "total",
-		Corpus: len(hub.st.Corpus.Records),
-		Repros: len(hub.st.Repros.Records),
+		Name: "total",
 	}
-	for name, mgr := range hub.st.Managers {
-		total.Added += mgr.Added
-		total.Deleted += mgr.Deleted
-		total.New += mgr.New
-		total.SentRepros += mgr.SentRepros
-		total.RecvRepros += mgr.RecvRepros
-		data.Managers = append(data.Managers, UIManager{
-			Name:       name,
-			Corpus:     len(mgr.Corpus.Records),
-			Added:      mgr.Added,
-			Deleted:    mgr.Deleted,
-			New:        mgr.New,
-			SentRepros: mgr.SentRepros,
-			RecvRepros: mgr.RecvRepros,
-		})
+	for ns, st := range hub.states {
+		total.Corpus += len(st.Corpus.Records)
+		total.Repros += len(st.Repros.Records)
+		for name, mgr := range st.Managers {
+			if ns != "" {
+				name = ns + "/" + name
+			}
+			total.Added += mgr.Added
+			total.Deleted += mgr.Deleted
+			total.New += mgr.New
+			total.SentRepros += mgr.SentRepros
+			total.RecvRepros += mgr.RecvRepros
+			total.Delivered += mgr.Delivered
+			total.Useful += mgr.Useful
+			data.Managers = append(data.Managers, UIManager{
+				Name:       name,
+				Corpus:     len(mgr.Corpus.Records),
+				Added:      mgr.Added,
+				Deleted:    mgr.Deleted,
+				New:        mgr.New,
+				SentRepros: mgr.SentRepros,
+				RecvRepros: mgr.RecvRepros,
+				Delivered:  mgr.Delivered,
+				Useful:     mgr.Useful,
+				Reputation: formatReputation(mgr.Reputation()),
+			})
+		}
+	}
+	if total.Delivered != 0 {
+		total.Reputation = formatReputation(float64(total.Useful) / float64(total.Delivered))
 	}
 	sort.Sort(UIManagerArray(data.Managers))
 	data.Managers = append([]UIManager{total}, data.Managers...)
@@ -65,6 +87,102 @@ func (hub *Hub) httpSummary(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (hub *Hub) httpAdmin(w http.ResponseWriter, r *http.Request) {
+	if hub.adminKey == "" {
+		http.Error(w, "admin UI is disabled", http.StatusForbidden)
+		return
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != "admin" || subtle.ConstantTimeCompare([]byte(pass), []byte(hub.adminKey)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="syz-hub"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	data := &UIAdminData{CSRF: hub.csrfToken}
+	if r.Method == http.MethodPost {
+		// Basic auth credentials are sent by the browser with requests from any page,
+		// so state-changing requests must also carry the token from the admin page.
+		if subtle.ConstantTimeCompare([]byte(r.FormValue("csrf")), []byte(hub.csrfToken)) != 1 {
+			http.Error(w, "bad CSRF token", http.StatusForbidden)
+			return
+		}
+		var err error
+		data.Token, data.Message, err = hub.adminAction(r.FormValue("action"),
+			r.FormValue("name"), r.FormValue("namespace"))
+		if err != nil {
+			data.Message = err.Error()
+		}
+	}
+	for name := range hub.keys {
+		data.Clients = append(data.Clients, UIClient{
+			Name:      name,
+			Namespace: hub.namespaces[name],
+			Static:    true,
+		})
+	}
+	for _, c := range hub.clients.Clients {
+		data.Clients = append(data.Clients, UIClient{
+			Name:      c.Name,
+			Namespace: c.Namespace,
+			Created:   c.Created,
+			Revoked:   c.Revoked,
+		})
+	}
+	sort.Slice(data.Clients, func(i, j int) bool {
+		c1, c2 := data.Clients[i], data.Clients[j]
+		if c1.Namespace != c2.Namespace {
+			return c1.Namespace < c2.Namespace
+		}
+		return c1.Name < c2.Name
+	})
+	if err := adminTemplate.Execute(w, data); err != nil {
+		log.Logf(0, "failed to execute template: %v", err)
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// adminAction creates or revokes clients, it returns token of a created client.
+func (hub *Hub) adminAction(action, name, namespace string) (string, string, error) {
+	switch action {
+	case "create":
+		if _, ok := hub.keys[name]; ok {
+			return "", "", fmt.Errorf("client %v is defined in the config", name)
+		}
+		token, err := hub.clients.Create(name, namespace)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := hub.state(namespace); err != nil {
+			return "", "", err
+		}
+		log.Logf(0, "admin: created client %v in namespace %q", name, namespace)
+		return token, fmt.Sprintf("created client %v, save the token, it is not shown again", name), nil
+	case "revoke":
+		if name == "" && namespace == "" {
+			return "", "", fmt.Errorf("client name or namespace is required")
+		}
+		n, err := hub.clients.Revoke(name, namespace)
+		if err != nil {
+			return "", "", err
+		}
+		log.Logf(0, "admin: revoked %v clients (name %q, namespace %q)", n, name, namespace)
+		return "", fmt.Sprintf("revoked %v clients", n), nil
+	default:
+		return "", "", fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func formatReputation(rep float64) string {
+	if rep < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", rep)
+}
+
 func compileTemplate(html string) *template.Template {
 	return template.Must(template.New("").Parse(strings.Replace(html, "{{STYLE}}", htmlStyle, -1)))
 }
@@ -83,6 +201,24 @@ type UIManager struct {
 	Repros     int
 	SentRepros int
 	RecvRepros int
+	Delivered  int
+	Useful     int
+	Reputation string
+}
+
+type UIAdminData struct {
+	Clients []UIClient
+	Message string
+	Token   string
+	CSRF    string
+}
+
+type UIClient struct {
+	Name      string
+	Namespace string
+	Static    bool
+	Created   time.Time
+	Revoked   time.Time
 }
 
 type UIManagerArray []UIManager
@@ -113,6 +249,9 @@ var summaryTemplate = compileTemplate(`
 		<th>Repros</th>
 		<th>Sent</th>
 		<th>Recv</th>
+		<th>Delivered</th>
+		<th>Useful</th>
+		<th>Reputation</th>
 	</tr>
 	{{range $m := $.Managers}}
 	<tr>
@@ -124,6 +263,9 @@ var summaryTemplate = compileTemplate(`
 		<td>{{$m.Repros}}</td>
 		<td>{{$m.SentRepros}}</td>
 		<td>{{$m.RecvRepros}}</td>
+		<td>{{$m.Delivered}}</td>
+		<td>{{$m.Useful}}</td>
+		<td>{{$m.Reputation}}</td>
 	</tr>
 	{{end}}
 </table>
@@ -142,6 +284,58 @@ Log:
 </body></html>
 `)
 
+var adminTemplate = compileTemplate(`
+<!doctype html>
+<html>
+<head>
+	<title>syz-hub admin</title>
+	{{STYLE}}
+</head>
+<body>
+<b>syz-hub admin</b>
+<br><br>
+{{if .Message}}{{.Message}}<br>{{end}}
+{{if .Token}}Token: <b>{{.Token}}</b><br>{{end}}
+<br>
+
+<table>
+	<caption>Clients:</caption>
+	<tr>
+		<th>Name</th>
+		<th>Namespace</th>
+		<th>Created</th>
+		<th>Status</th>
+	</tr>
+	{{range $c := $.Clients}}
+	<tr>
+		<td>{{$c.Name}}</td>
+		<td>{{$c.Namespace}}</td>
+		<td>{{if not $c.Static}}{{$c.Created.Format "2006/01/02 15:04"}}{{end}}</td>
+		<td>{{if $c.Static}}config{{else if $c.Revoked.IsZero}}active{{else}}revoked {{$c.Revoked.Format "2006/01/02 15:04"}}{{end}}</td>
+	</tr>
+	{{end}}
+</table>
+<br><br>
+
+<form method="post">
+	<input type="hidden" name="action" value="create">
+	<input type="hidden" name="csrf" value="{{$.CSRF}}">
+	Name: <input type="text" name="name">
+	Namespace: <input type="text" name="namespace">
+	<input type="submit" value="Create client">
+</form>
+<br>
+<form method="post">
+	<input type="hidden" name="action" value="revoke">
+	<input type="hidden" name="csrf" value="{{$.CSRF}}">
+	Name: <input type="text" name="name">
+	or namespace: <input type="text" name="namespace">
+	<input type="submit" value="Revoke">
+</form>
+
+</body></html>
+`)
+
 const htmlStyle = `
 	<style type="text/css" media="screen">
 		table {
@@ -4,8 +4,10 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -23,16 +25,47 @@ type Config struct {
 	HTTP    string
 	RPC     string
 	Workdir string
+	// TLS certificate and key files, if set both RPC and HTTP are served over TLS.
+	TLSCert string `json:"tls_cert"`
+	TLSKey  string `json:"tls_key"`
+	// Password for the admin UI (user "admin"), the admin UI is disabled if not set.
+	// Requires TLS, since the password is sent with every request.
+	AdminKey string `json:"admin_key"`
+	// Static clients, more clients can be created in the admin UI.
 	Clients []struct {
 		Name string
 		Key  string
+		// Managers exchange programs only with managers in the same namespace.
+		Namespace string `json:"namespace"`
+		// Programs are sent to managers of the client only if all their calls
+		// match one of these patterns (same syntax as enable_syscalls in manager config).
+		Calls []string `json:"calls"`
+		// Maximum number of programs sent to each manager of the client per hour.
+		PullRate int `json:"pull_rate"`
+		// Don't send programs from managers with lower reputation
+		// (fraction of their inputs that gave new coverage to other managers).
+		MinReputation float64 `json:"min_reputation"`
 	}
 }
 
 type Hub struct {
-	mu   sync.Mutex
-	st   *state.State
-	keys map[string]string
+	mu         sync.Mutex
+	workdir    string
+	adminKey   string
+	csrfToken  string                  // included in admin UI forms and checked on submit
+	states     map[string]*state.State // namespace -> state
+	keys       map[string]string
+	namespaces map[string]string
+	exchange   map[string]state.Exchange
+	clients    *ClientDB
+	uploads    map[string]*upload
+}
+
+// upload is a corpus uploaded in several Connect calls.
+type upload struct {
+	session string
+	corpus  [][]byte
+	done    bool
 }
 
 func main() {
@@ -43,21 +76,63 @@ func main() {
 	}
 	log.EnableLogCaching(1000, 1<<20)
 
-	st, err := state.Make(cfg.Workdir)
+	if cfg.AdminKey != "" && cfg.TLSCert == "" {
+		log.Fatalf("admin_key requires tls_cert and tls_key, otherwise the admin key is sent in cleartext")
+	}
+	clients, err := loadClientDB(filepath.Join(cfg.Workdir, "clients.json"))
 	if err != nil {
-		log.Fatalf("failed to load state: %v", err)
+		log.Fatalf("failed to load clients: %v", err)
 	}
 	hub := &Hub{
-		st:   st,
-		keys: make(map[string]string),
+		workdir:    cfg.Workdir,
+		adminKey:   cfg.AdminKey,
+		states:     make(map[string]*state.State),
+		keys:       make(map[string]string),
+		namespaces: make(map[string]string),
+		exchange:   make(map[string]state.Exchange),
+		clients:    clients,
+		uploads:    make(map[string]*upload),
+	}
+	if hub.adminKey != "" {
+		if hub.csrfToken, err = randomToken(); err != nil {
+			log.Fatalf("failed to generate CSRF token: %v", err)
+		}
 	}
 	for _, mgr := range cfg.Clients {
 		hub.keys[mgr.Name] = mgr.Key
+		hub.namespaces[mgr.Name] = mgr.Namespace
+		hub.exchange[mgr.Name] = state.Exchange{
+			Calls:         mgr.Calls,
+			PullRate:      mgr.PullRate,
+			MinReputation: mgr.MinReputation,
+		}
+		if _, err := hub.state(mgr.Namespace); err != nil {
+			log.Fatalf("failed to load state: %v", err)
+		}
+	}
+	for _, client := range clients.Clients {
+		if !client.Revoked.IsZero() {
+			continue
+		}
+		if _, err := hub.state(client.Namespace); err != nil {
+			log.Fatalf("failed to load state: %v", err)
+		}
+	}
+	if _, err := hub.state(""); err != nil {
+		log.Fatalf("failed to load state: %v", err)
 	}
 
-	hub.initHTTP(cfg.HTTP)
+	var tlsCfg *tls.Config
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			log.Fatalf("failed to load TLS certificate: %v", err)
+		}
+		tlsCfg = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	hub.initHTTP(cfg.HTTP, tlsCfg)
 
-	s, err := rpctype.NewRPCServer(cfg.RPC, "Hub", hub)
+	s, err := rpctype.NewRPCServerTLS(cfg.RPC, "Hub", hub, tlsCfg)
 	if err != nil {
 		log.Fatalf("failed to create rpc server: %v", err)
 	}
@@ -65,7 +140,18 @@ func main() {
 	s.Serve()
 }
 
+// Connect connects the manager with the whole corpus sent in a single call.
+// This is the original protocol, it is kept for managers that don't use ConnectPart.
 func (hub *Hub) Connect(a *rpctype.HubConnectArgs, r *int) error {
+	return hub.connect(a, new(rpctype.HubConnectRes))
+}
+
+// ConnectPart is Connect that allows to upload the corpus in several calls (see HubConnectArgs.Session).
+func (hub *Hub) ConnectPart(a *rpctype.HubConnectArgs, r *rpctype.HubConnectRes) error {
+	return hub.connect(a, r)
+}
+
+func (hub *Hub) connect(a *rpctype.HubConnectArgs, r *rpctype.HubConnectRes) error {
 	name, err := hub.auth(a.Client, a.Key, a.Manager)
 	if err != nil {
 		return err
@@ -73,15 +159,52 @@ func (hub *Hub) Connect(a *rpctype.HubConnectArgs, r *int) error {
 	hub.mu.Lock()
 	defer hub.mu.Unlock()
 
+	st, err := hub.state(hub.namespace(a.Client))
+	if err != nil {
+		return err
+	}
+	corpus := a.Corpus
+	var up *upload
+	if a.Session != "" {
+		up = hub.upload(hub.namespace(a.Client)+"/"+name, a)
+		r.Received = len(up.corpus)
+		if up.done || !a.Done || a.Offset+len(a.Corpus) != len(up.corpus) {
+			log.Logf(0, "connect from %v: received %v programs", name, len(up.corpus))
+			return nil
+		}
+		corpus = up.corpus
+	}
 	log.Logf(0, "connect from %v: fresh=%v calls=%v corpus=%v",
-		name, a.Fresh, len(a.Calls), len(a.Corpus))
-	if err := hub.st.Connect(name, a.Fresh, a.Calls, a.Corpus); err != nil {
+		name, a.Fresh, len(a.Calls), len(corpus))
+	if err := st.Connect(name, a.Fresh, a.Calls, corpus); err != nil {
 		log.Logf(0, "connect error: %v", err)
+		if up != nil {
+			up.session = ""
+		}
 		return err
 	}
+	st.Managers[name].Exchange = hub.exchange[a.Client]
+	if up != nil {
+		// Keep the session to answer retries of the last call, but free the corpus.
+		up.done = true
+		up.corpus = make([][]byte, len(up.corpus))
+	}
 	return nil
 }
 
+// upload appends the corpus part to the manager upload, if the part continues the upload.
+func (hub *Hub) upload(key string, a *rpctype.HubConnectArgs) *upload {
+	up := hub.uploads[key]
+	if up == nil || up.session != a.Session {
+		up = &upload{session: a.Session}
+		hub.uploads[key] = up
+	}
+	if a.Offset == len(up.corpus) && !up.done {
+		up.corpus = append(up.corpus, a.Corpus...)
+	}
+	return up
+}
+
 func (hub *Hub) Sync(a *rpctype.HubSyncArgs, r *rpctype.HubSyncRes) error {
 	name, err := hub.auth(a.Client, a.Key, a.Manager)
 	if err != nil {
@@ -90,7 +213,11 @@ func (hub *Hub) Sync(a *rpctype.HubSyncArgs, r *rpctype.HubSyncRes) error {
 	hub.mu.Lock()
 	defer hub.mu.Unlock()
 
-	progs, more, err := hub.st.Sync(name, a.Add, a.Del)
+	st, err := hub.state(hub.namespace(a.Client))
+	if err != nil {
+		return err
+	}
+	progs, more, err := st.Sync(name, a.Add, a.Del)
 	if err != nil {
 		log.Logf(0, "sync error: %v", err)
 		return err
@@ -98,12 +225,12 @@ func (hub *Hub) Sync(a *rpctype.HubSyncArgs, r *rpctype.HubSyncRes) error {
 	r.Progs = progs
 	r.More = more
 	for _, repro := range a.Repros {
-		if err := hub.st.AddRepro(name, repro); err != nil {
+		if err := st.AddRepro(name, repro); err != nil {
 			log.Logf(0, "add repro error: %v", err)
 		}
 	}
 	if a.NeedRepros {
-		repro, err := hub.st.PendingRepro(name)
+		repro, err := st.PendingRepro(name)
 		if err != nil {
 			log.Logf(0, "sync error: %v", err)
 		}
@@ -117,7 +244,7 @@ func (hub *Hub) Sync(a *rpctype.HubSyncArgs, r *rpctype.HubSyncRes) error {
 }
 
 func (hub *Hub) auth(client, key, manager string) (string, error) {
-	if expectedKey, ok := hub.keys[client]; !ok || key != expectedKey {
+	if !hub.checkKey(client, key) {
 		log.Logf(0, "connect from unauthorized client %v", client)
 		return "", fmt.Errorf("unauthorized manager")
 	}
@@ -129,3 +256,41 @@ func (hub *Hub) auth(client, key, manager string) (string, error) {
 	}
 	return manager, nil
 }
+
+func (hub *Hub) checkKey(client, key string) bool {
+	if expectedKey, ok := hub.keys[client]; ok {
+		return key == expectedKey
+	}
+	return hub.clients != nil && hub.clients.Auth(client, key) != nil
+}
+
+// namespace returns namespace of an authenticated client.
+func (hub *Hub) namespace(client string) string {
+	if _, ok := hub.keys[client]; ok {
+		return hub.namespaces[client]
+	}
+	if hub.clients != nil {
+		if c := hub.clients.Active(client); c != nil {
+			return c.Namespace
+		}
+	}
+	return ""
+}
+
+// state returns state of the namespace, loading it if necessary.
+// The default namespace lives in the root of workdir for compatibility.
+func (hub *Hub) state(namespace string) (*state.State, error) {
+	if st := hub.states[namespace]; st != nil {
+		return st, nil
+	}
+	dir := hub.workdir
+	if namespace != "" {
+		dir = filepath.Join(hub.workdir, "namespaces", namespace)
+	}
+	st, err := state.Make(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state for namespace %q: %v", namespace, err)
+	}
+	hub.states[namespace] = st
+	return st, nil
+}
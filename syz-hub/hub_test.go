@@ -5,7 +5,17 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/syz-hub/state"
 )
 
 func TestAuth(t *testing.T) {
@@ -101,3 +111,182 @@ func TestAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestClientDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-hub-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hub := testHub(t, dir)
+	hub.keys["foo"] = "1234"
+
+	if _, _, err := hub.adminAction("create", "foo", ""); err == nil {
+		t.Fatalf("created client with the same name as a config client")
+	}
+	if _, _, err := hub.adminAction("create", "bad/name", ""); err == nil {
+		t.Fatalf("created client with a bad name")
+	}
+	token1, _, err := hub.adminAction("create", "bar", "ns1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := hub.adminAction("create", "bar", ""); err == nil {
+		t.Fatalf("created duplicate client")
+	}
+	token2, _, err := hub.adminAction("create", "baz", "ns1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hub.auth("bar", token2, "bar"); err == nil {
+		t.Fatalf("auth with a wrong token succeeded")
+	}
+	if _, err := hub.auth("bar", token1, "bar-1"); err != nil {
+		t.Fatal(err)
+	}
+	if ns := hub.namespace("bar"); ns != "ns1" {
+		t.Fatalf("bad namespace %q", ns)
+	}
+	if hub.states["ns1"] == nil {
+		t.Fatalf("namespace state is not created")
+	}
+
+	// Clients must survive restart.
+	hub = testHub(t, dir)
+	if _, err := hub.auth("baz", token2, "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := hub.adminAction("revoke", "", "ns1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range []string{"bar", "baz"} {
+		if _, err := hub.auth(client, token1, client); err == nil {
+			t.Fatalf("auth of revoked client %v succeeded", client)
+		}
+	}
+	if _, _, err := hub.adminAction("revoke", "bar", ""); err == nil {
+		t.Fatalf("revoked client twice")
+	}
+	// Revoked names can be reused.
+	if _, _, err := hub.adminAction("create", "bar", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConnectResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-hub-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hub := testHub(t, dir)
+	hub.keys["foo"] = "1234"
+	var corpus [][]byte
+	for i := 0; i < 10; i++ {
+		corpus = append(corpus, []byte(fmt.Sprintf("open(0x%x)", i)))
+	}
+	connect := func(session string, offset, end int, received int) {
+		a := &rpctype.HubConnectArgs{
+			Client:  "foo",
+			Key:     "1234",
+			Manager: "foo",
+			Calls:   []string{"open"},
+			Session: session,
+			Offset:  offset,
+			Corpus:  corpus[offset:end],
+			Done:    end == len(corpus),
+		}
+		r := new(rpctype.HubConnectRes)
+		if err := hub.ConnectPart(a, r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Received != received {
+			t.Fatalf("received %v, want %v", r.Received, received)
+		}
+	}
+	connect("s1", 0, 4, 4)
+	// A part after a lost one.
+	connect("s1", 8, 10, 4)
+	connect("s1", 4, 8, 8)
+	// Retry of an already received part.
+	connect("s1", 4, 8, 8)
+	if hub.states[""].Managers["foo"] != nil {
+		t.Fatalf("manager connected before the upload is finished")
+	}
+	connect("s1", 8, 10, 10)
+	if n := len(hub.states[""].Managers["foo"].Corpus.Records); n != len(corpus) {
+		t.Fatalf("manager corpus has %v programs, want %v", n, len(corpus))
+	}
+	// Retry of the last part.
+	connect("s1", 8, 10, 10)
+	// A new session starts from scratch.
+	connect("s2", 4, 8, 0)
+	connect("s2", 0, 4, 4)
+	// Managers that don't use ConnectPart send the whole corpus in Connect.
+	hub.keys["bar"] = "abcd"
+	a := &rpctype.HubConnectArgs{
+		Client:  "bar",
+		Key:     "abcd",
+		Manager: "bar",
+		Calls:   []string{"open"},
+		Corpus:  corpus,
+	}
+	if err := hub.Connect(a, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(hub.states[""].Managers["bar"].Corpus.Records); n != len(corpus) {
+		t.Fatalf("manager corpus has %v programs, want %v", n, len(corpus))
+	}
+}
+
+func TestAdminCSRF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-hub-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hub := testHub(t, dir)
+	hub.adminKey = "secret"
+	hub.csrfToken = "token"
+	for _, test := range []struct {
+		csrf   string
+		status int
+	}{
+		{"", http.StatusForbidden},
+		{"bad", http.StatusForbidden},
+		{"token", http.StatusOK},
+	} {
+		form := url.Values{"action": {"create"}, "name": {"foo" + test.csrf}, "csrf": {test.csrf}}
+		req := httptest.NewRequest(http.MethodPost, "/admin", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("admin", "secret")
+		w := httptest.NewRecorder()
+		hub.httpAdmin(w, req)
+		if w.Code != test.status {
+			t.Errorf("csrf %q: got status %v, want %v", test.csrf, w.Code, test.status)
+		}
+		if created := hub.clients.Active("foo"+test.csrf) != nil; created != (test.status == http.StatusOK) {
+			t.Errorf("csrf %q: client created %v", test.csrf, created)
+		}
+	}
+}
+
+func testHub(t *testing.T, dir string) *Hub {
+	clients, err := loadClientDB(filepath.Join(dir, "clients.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := &Hub{
+		workdir:    dir,
+		states:     make(map[string]*state.State),
+		keys:       make(map[string]string),
+		namespaces: make(map[string]string),
+		exchange:   make(map[string]state.Exchange),
+		clients:    clients,
+		uploads:    make(map[string]*upload),
+	}
+	if _, err := hub.state(""); err != nil {
+		t.Fatal(err)
+	}
+	return hub
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/syzkaller/pkg/db"
@@ -29,6 +30,8 @@ type State struct {
 	Corpus    *db.DB
 	Repros    *db.DB
 	Managers  map[string]*Manager
+	// Manager that first added the program to the corpus.
+	origin map[string]*Manager
 }
 
 // Manager represents one syz-manager instance.
@@ -48,13 +51,41 @@ type Manager struct {
 	RecvRepros    int
 	Calls         map[string]struct{}
 	Corpus        *db.DB
+	Exchange      Exchange
+	// Own inputs sent to other managers and how many of them other managers added
+	// to their corpus (i.e. they gave new coverage), see Reputation.
+	Delivered  int
+	Useful     int
+	pullTokens float64
+	pullTime   time.Time
 }
 
+// Exchange restricts programs sent to a manager.
+type Exchange struct {
+	// Programs are sent only if all their calls match one of these patterns
+	// (same syntax as enable_syscalls in manager config), empty means all calls.
+	Calls []string
+	// Maximum number of programs sent per hour, 0 means no limit.
+	PullRate int
+	// Programs from managers with lower reputation are not sent.
+	MinReputation float64
+}
+
+const (
+	// Seq of manager corpus records that were first added by the manager.
+	originSeq = 1
+	// Reputation is not computed until that many inputs are delivered.
+	minDelivered = 100
+)
+
+var timeNow = time.Now
+
 // Make creates State and initializes it from dir.
 func Make(dir string) (*State, error) {
 	st := &State{
 		dir:      dir,
 		Managers: make(map[string]*Manager),
+		origin:   make(map[string]*Manager),
 	}
 
 	osutil.MkdirAll(st.dir)
@@ -68,10 +99,15 @@ func Make(dir string) (*State, error) {
 		return nil, fmt.Errorf("failed to read %v dir: %v", managersDir, err)
 	}
 	for _, manager := range managers {
-		_, err := st.createManager(manager.Name())
+		mgr, err := st.createManager(manager.Name())
 		if err != nil {
 			return nil, err
 		}
+		for sig, rec := range mgr.Corpus.Records {
+			if rec.Seq == originSeq {
+				st.origin[sig] = mgr
+			}
+		}
 	}
 	log.Logf(0, "purging corpus...")
 	st.purgeCorpus()
@@ -150,7 +186,7 @@ func (st *State) Connect(name string, fresh bool, calls []string, corpus [][]byt
 			return err
 		}
 	}
-	mgr.Connected = time.Now()
+	mgr.Connected = timeNow()
 	if fresh {
 		mgr.corpusSeq = 0
 		mgr.reproSeq = st.reproSeq
@@ -170,7 +206,7 @@ func (st *State) Connect(name string, fresh bool, calls []string, corpus [][]byt
 		log.Logf(0, "failed to open corpus database: %v", err)
 		return err
 	}
-	st.addInputs(mgr, corpus)
+	st.addInputs(mgr, corpus, false)
 	st.purgeCorpus()
 	return nil
 }
@@ -189,7 +225,7 @@ func (st *State) Sync(name string, add [][]byte, del []string) ([][]byte, int, e
 		}
 		st.purgeCorpus()
 	}
-	st.addInputs(mgr, add)
+	st.addInputs(mgr, add, true)
 	progs, more, err := st.pendingInputs(mgr)
 	mgr.Added += len(add)
 	mgr.Deleted += len(del)
@@ -269,7 +305,12 @@ func (st *State) pendingInputs(mgr *Manager) ([][]byte, int, error) {
 	if mgr.corpusSeq == st.corpusSeq {
 		return nil, 0, nil
 	}
+	limit := mgr.pullLimit()
+	if limit == 0 {
+		return nil, 0, nil
+	}
 	var records []db.Record
+	var origins []*Manager
 	for key, rec := range st.Corpus.Records {
 		if mgr.corpusSeq >= rec.Seq {
 			continue
@@ -281,42 +322,124 @@ func (st *State) pendingInputs(mgr *Manager) ([][]byte, int, error) {
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to extract call set: %v\nprogram: %s", err, rec.Val)
 		}
-		if !managerSupportsAllCalls(mgr.Calls, calls) {
+		if !managerSupportsAllCalls(mgr.Calls, calls) || !mgr.Exchange.allowsCalls(calls) {
 			continue
 		}
+		origin := st.origin[key]
+		if origin != nil {
+			if rep := origin.Reputation(); rep >= 0 && rep < mgr.Exchange.MinReputation {
+				continue
+			}
+		}
 		records = append(records, rec)
+		origins = append(origins, origin)
 	}
 	maxSeq := st.corpusSeq
 	more := 0
-	// Send at most that many records (rounded up to next seq number).
-	const maxRecords = 100
-	if len(records) > maxRecords {
-		sort.Sort(recordSeqSorter(records))
-		pos := maxRecords
-		maxSeq = records[pos].Seq
-		for pos+1 < len(records) && records[pos+1].Seq == maxSeq {
+	// Send at most that many records (rounded up to next seq number,
+	// old databases can contain several records with the same seq).
+	if len(records) > limit {
+		sort.Sort(recordSeqSorter{records, origins})
+		pos := limit
+		maxSeq = records[pos-1].Seq
+		for pos < len(records) && records[pos].Seq == maxSeq {
 			pos++
 		}
-		pos++
-		more = len(records) - pos
+		if limit == maxRecords {
+			// Don't ask the manager to sync again right away if the pull rate is exhausted.
+			more = len(records) - pos
+		}
 		records = records[:pos]
 	}
-	progs := make([][]byte, len(records))
-	for _, rec := range records {
+	progs := make([][]byte, 0, len(records))
+	for i, rec := range records {
 		progs = append(progs, rec.Val)
+		if origins[i] != nil && origins[i] != mgr {
+			origins[i].Delivered++
+		}
 	}
+	mgr.pullTokens -= float64(len(records))
 	mgr.corpusSeq = maxSeq
 	saveSeqFile(mgr.corpusSeqFile, mgr.corpusSeq)
 	return progs, more, nil
 }
 
-func (st *State) addInputs(mgr *Manager, inputs [][]byte) {
+// Send at most that many records per sync.
+const maxRecords = 100
+
+// pullLimit returns max number of records that can be sent to the manager now.
+// Pull rate is enforced with a token bucket that holds up to an hour worth of records.
+func (mgr *Manager) pullLimit() int {
+	rate := float64(mgr.Exchange.PullRate)
+	if rate == 0 {
+		return maxRecords
+	}
+	now := timeNow()
+	if mgr.pullTime.IsZero() {
+		mgr.pullTokens = rate
+	} else {
+		mgr.pullTokens += now.Sub(mgr.pullTime).Hours() * rate
+		if mgr.pullTokens > rate {
+			mgr.pullTokens = rate
+		}
+	}
+	mgr.pullTime = now
+	if mgr.pullTokens < 1 {
+		return 0
+	}
+	if mgr.pullTokens < maxRecords {
+		return int(mgr.pullTokens)
+	}
+	return maxRecords
+}
+
+// Reputation returns the fraction of inputs delivered to other managers that gave them new coverage,
+// or -1 if too few inputs were delivered yet. A delivered input is considered to give new coverage
+// when the other manager adds it to its corpus (corpus programs are already minimized,
+// so they usually come back unchanged).
+func (mgr *Manager) Reputation() float64 {
+	if mgr.Delivered < minDelivered {
+		return -1
+	}
+	return float64(mgr.Useful) / float64(mgr.Delivered)
+}
+
+func (ex *Exchange) allowsCalls(calls map[string]struct{}) bool {
+	if len(ex.Calls) == 0 {
+		return true
+	}
+	for call := range calls {
+		allowed := false
+		for _, pattern := range ex.Calls {
+			if matchCall(call, pattern) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func matchCall(name, pattern string) bool {
+	if pattern == name || strings.HasPrefix(name, pattern+"$") {
+		return true
+	}
+	if len(pattern) > 1 && pattern[len(pattern)-1] == '*' &&
+		strings.HasPrefix(name, pattern[:len(pattern)-1]) {
+		return true
+	}
+	return false
+}
+
+func (st *State) addInputs(mgr *Manager, inputs [][]byte, synced bool) {
 	if len(inputs) == 0 {
 		return
 	}
-	st.corpusSeq++
 	for _, input := range inputs {
-		st.addInput(mgr, input)
+		st.addInput(mgr, input, synced)
 	}
 	if err := mgr.Corpus.Flush(); err != nil {
 		log.Logf(0, "failed to flush corpus database: %v", err)
@@ -326,16 +449,30 @@ func (st *State) addInputs(mgr *Manager, inputs [][]byte) {
 	}
 }
 
-func (st *State) addInput(mgr *Manager, input []byte) {
+func (st *State) addInput(mgr *Manager, input []byte, synced bool) {
 	if _, err := prog.CallSet(input); err != nil {
 		log.Logf(0, "manager %v: failed to extract call set: %v, program:\n%v", mgr.name, err, string(input))
 		return
 	}
 	sig := hash.String(input)
-	mgr.Corpus.Save(sig, nil, 0)
-	if _, ok := st.Corpus.Records[sig]; !ok {
+	rec, exists := st.Corpus.Records[sig]
+	origin := st.origin[sig]
+	if !exists {
+		// Each input gets own seq, so that pendingInputs can send any number of them.
+		st.corpusSeq++
 		st.Corpus.Save(sig, input, st.corpusSeq)
-	}
+		origin = mgr
+		st.origin[sig] = mgr
+	} else if _, own := mgr.Corpus.Records[sig]; synced && !own && origin != nil &&
+		origin != mgr && rec.Seq <= mgr.corpusSeq {
+		// The manager has received the input from hub and it gave new coverage.
+		origin.Useful++
+	}
+	seq := uint64(0)
+	if origin == mgr {
+		seq = originSeq
+	}
+	mgr.Corpus.Save(sig, nil, seq)
 }
 
 func (st *State) purgeCorpus() {
@@ -350,6 +487,7 @@ func (st *State) purgeCorpus() {
 			continue
 		}
 		st.Corpus.Delete(key)
+		delete(st.origin, key)
 	}
 	if err := st.Corpus.Flush(); err != nil {
 		log.Logf(0, "failed to flush corpus database: %v", err)
@@ -381,16 +519,21 @@ func loadSeqFile(filename string) uint64 {
 	return seq
 }
 
-type recordSeqSorter []db.Record
+// recordSeqSorter sorts records and their origins by seq.
+type recordSeqSorter struct {
+	records []db.Record
+	origins []*Manager
+}
 
 func (a recordSeqSorter) Len() int {
-	return len(a)
+	return len(a.records)
 }
 
 func (a recordSeqSorter) Less(i, j int) bool {
-	return a[i].Seq < a[j].Seq
+	return a.records[i].Seq < a.records[j].Seq
 }
 
 func (a recordSeqSorter) Swap(i, j int) {
-	a[i], a[j] = a[j], a[i]
+	a.records[i], a.records[j] = a.records[j], a.records[i]
+	a.origins[i], a.origins[j] = a.origins[j], a.origins[i]
 }
@@ -10,6 +10,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
 )
 
 func TestState(t *testing.T) {
@@ -98,6 +101,112 @@ func TestRepro(t *testing.T) {
 	checkPendingRepro(t, st, "foo", "")
 }
 
+func TestExchange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-hub-state-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	st, err := Make(dir)
+	if err != nil {
+		t.Fatalf("failed to make state: %v", err)
+	}
+	calls := []string{"open", "read", "socket$inet", "socket$inet6", "sendmsg$nl_route"}
+	for _, name := range []string{"foo", "bar"} {
+		if err := st.Connect(name, false, calls, nil); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+	}
+	st.Managers["bar"].Exchange = Exchange{
+		Calls:    []string{"socket", "sendmsg$nl*", "read"},
+		PullRate: 10,
+	}
+	var add [][]byte
+	for i := 0; i < 15; i++ {
+		add = append(add,
+			[]byte(fmt.Sprintf("socket$inet(0x%x)\nread()", i)),
+			[]byte(fmt.Sprintf("socket$inet6(0x%x)\nsendmsg$nl_route()", i)),
+			[]byte(fmt.Sprintf("open(0x%x)\nread()", i)))
+	}
+	checkSync(t, st, "foo", add, 0, 0)
+	// Programs with open are filtered, 10 programs are sent right away,
+	// the rest is sent as the pull rate allows.
+	checkSync(t, st, "bar", nil, 10, 0)
+	checkSync(t, st, "bar", nil, 0, 0)
+	now = now.Add(30 * time.Minute)
+	checkSync(t, st, "bar", nil, 5, 0)
+	now = now.Add(10 * time.Hour)
+	checkSync(t, st, "bar", nil, 10, 0)
+	checkSync(t, st, "bar", nil, 0, 0)
+	now = now.Add(time.Hour)
+	checkSync(t, st, "bar", nil, 5, 0)
+	checkSync(t, st, "bar", nil, 0, 0)
+}
+
+func TestReputation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-hub-state-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	st, err := Make(dir)
+	if err != nil {
+		t.Fatalf("failed to make state: %v", err)
+	}
+	calls := []string{"open", "read"}
+	for _, name := range []string{"foo", "bar", "baz"} {
+		if err := st.Connect(name, false, calls, nil); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+	}
+	st.Managers["baz"].Exchange.MinReputation = 0.5
+	var add [][]byte
+	for i := 0; i < minDelivered; i++ {
+		add = append(add, []byte(fmt.Sprintf("open(0x%x)", i)))
+	}
+	checkSync(t, st, "foo", add, 0, 0)
+	progs := checkSync(t, st, "bar", nil, minDelivered, 0)
+	// bar has found a third of the inputs useful.
+	checkSync(t, st, "bar", progs[:minDelivered/3], 0, 0)
+	foo := st.Managers["foo"]
+	if foo.Delivered != minDelivered || foo.Useful != minDelivered/3 {
+		t.Fatalf("foo: delivered %v, useful %v", foo.Delivered, foo.Useful)
+	}
+	if rep := foo.Reputation(); rep < 0.3 || rep > 0.34 {
+		t.Fatalf("foo: reputation %v", rep)
+	}
+	// baz does not want inputs from foo, but receives inputs that came only from bar.
+	checkSync(t, st, "bar", [][]byte{[]byte("read()")}, 0, 0)
+	checkSync(t, st, "baz", nil, 1, 0)
+
+	// Origin of inputs must survive restart.
+	st, err = Make(dir)
+	if err != nil {
+		t.Fatalf("failed to make state: %v", err)
+	}
+	if st.origin[hash.String([]byte("open(0x0)"))] != st.Managers["foo"] ||
+		st.origin[hash.String([]byte("read()"))] != st.Managers["bar"] {
+		t.Fatalf("input origin is lost after restart")
+	}
+}
+
+func checkSync(t *testing.T, st *State, name string, add [][]byte, progs, more int) [][]byte {
+	res, resMore, err := st.Sync(name, add, nil)
+	if err != nil {
+		t.Fatalf("\n%v: Sync failed: %v", caller(1), err)
+	}
+	if len(res) != progs || resMore != more {
+		t.Fatalf("\n%v: Sync returned %v progs, more %v; want %v progs, more %v",
+			caller(1), len(res), resMore, progs, more)
+	}
+	return res
+}
+
 func checkPendingRepro(t *testing.T, st *State, name, result string) {
 	repro, err := st.PendingRepro(name)
 	if err != nil {
@@ -0,0 +1,105 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+)
+
+// When enable_syscalls is expanded, the corpus has no programs with the new syscalls.
+// We remember enabled syscalls in workdir/enabled_syscalls and ask fuzzers to generate
+// programs with syscalls that were not enabled in the previous run and are not used
+// by corpus programs (see syz-fuzzer/backfill.go). Syscalls that don't have corpus programs yet
+// are kept in workdir/backfill_syscalls, so backfill continues after restarts.
+const (
+	enabledSyscallsFile  = "enabled_syscalls"
+	backfillSyscallsFile = "backfill_syscalls"
+)
+
+// initBackfill is called on corpus load with enabled syscalls and syscalls used by corpus programs.
+func (mgr *Manager) initBackfill(enabled, used map[int]bool) {
+	prev, err := readSyscallList(filepath.Join(mgr.cfg.Workdir, enabledSyscallsFile))
+	pending, _ := readSyscallList(filepath.Join(mgr.cfg.Workdir, backfillSyscallsFile))
+	mgr.backfillCalls = make(map[int]bool)
+	var names []string
+	for id := range enabled {
+		name := mgr.target.Syscalls[id].Name
+		names = append(names, name)
+		// If there is no previous list, this is either a new workdir or a workdir
+		// from an older version, we can't tell what is new in both cases.
+		if err == nil && (!prev[name] || pending[name]) && !used[id] {
+			mgr.backfillCalls[id] = true
+		}
+	}
+	sort.Strings(names)
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, enabledSyscallsFile),
+		[]byte(strings.Join(names, "\n")+"\n")); err != nil {
+		log.Logf(0, "failed to write enabled syscalls: %v", err)
+	}
+	mgr.writeBackfill()
+	log.Logf(0, "%-24v: %v", "backfill syscalls", len(mgr.backfillCalls))
+}
+
+// backfillDone removes syscalls used by the new corpus program from backfill.
+func (mgr *Manager) backfillDone(data []byte) {
+	p, err := mgr.target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		return
+	}
+	done := 0
+	for _, c := range p.Calls {
+		if mgr.backfillCalls[c.Meta.ID] {
+			log.Logf(1, "backfilled %v", c.Meta.Name)
+			delete(mgr.backfillCalls, c.Meta.ID)
+			done++
+		}
+	}
+	if done != 0 {
+		mgr.stats.backfilled.add(done)
+		mgr.writeBackfill()
+	}
+}
+
+func (mgr *Manager) backfillList() []int {
+	var calls []int
+	for id := range mgr.backfillCalls {
+		calls = append(calls, id)
+	}
+	sort.Ints(calls)
+	return calls
+}
+
+func (mgr *Manager) writeBackfill() {
+	var names []string
+	for id := range mgr.backfillCalls {
+		names = append(names, mgr.target.Syscalls[id].Name)
+	}
+	sort.Strings(names)
+	data := []byte(strings.Join(names, "\n"))
+	if len(names) != 0 {
+		data = append(data, '\n')
+	}
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, backfillSyscallsFile), data); err != nil {
+		log.Logf(0, "failed to write backfill syscalls: %v", err)
+	}
+}
+
+func readSyscallList(file string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	calls := make(map[string]bool)
+	for _, name := range strings.Fields(string(data)) {
+		calls[name] = true
+	}
+	return calls, nil
+}
@@ -23,6 +23,10 @@ var (
 	initCoverError    error
 	initCoverVMOffset uint32
 	reportGenerator   *cover.ReportGenerator
+
+	// Source lines of PCs symbolized by coverLines so far.
+	coverLinesMu    sync.Mutex
+	coverLinesCache = make(map[uint32][]cover.Line)
 )
 
 func initCover(kernelObj, kernelObjName, kernelSrc, arch, OS string) error {
@@ -39,7 +43,8 @@ func initCover(kernelObj, kernelObjName, kernelSrc, arch, OS string) error {
 	return err
 }
 
-func generateCoverHTML(w io.Writer, kernelObj, kernelObjName, kernelSrc, arch, OS string, cov cover.Cover) error {
+func generateCoverHTML(w io.Writer, kernelObj, kernelObjName, kernelSrc, arch, OS string, cov cover.Cover,
+	lcov bool) error {
 	if len(cov) == 0 {
 		return fmt.Errorf("no coverage data available")
 	}
@@ -51,6 +56,9 @@ func generateCoverHTML(w io.Writer, kernelObj, kernelObjName, kernelSrc, arch, O
 	for pc := range cov {
 		pcs = append(pcs, cover.RestorePC(pc, initCoverVMOffset))
 	}
+	if lcov {
+		return reportGenerator.DoLCOV(w, pcs)
+	}
 	return reportGenerator.Do(w, pcs)
 }
 
@@ -89,3 +97,40 @@ func getVMOffset(vmlinux, OS string) (uint32, error) {
 	}
 	return addr, nil
 }
+
+// coverLines returns source lines for each PC in cov.
+func coverLines(kernelObj, kernelObjName, kernelSrc, arch, OS string, cov cover.Cover) (
+	map[uint32][]cover.Line, error) {
+	if len(cov) == 0 {
+		return nil, fmt.Errorf("no coverage data available")
+	}
+	initCoverOnce.Do(func() { initCoverError = initCover(kernelObj, kernelObjName, kernelSrc, arch, OS) })
+	if initCoverError != nil {
+		return nil, initCoverError
+	}
+	coverLinesMu.Lock()
+	defer coverLinesMu.Unlock()
+	var pcs []uint64
+	for pc := range cov {
+		if _, ok := coverLinesCache[pc]; !ok {
+			pcs = append(pcs, cover.RestorePC(pc, initCoverVMOffset))
+		}
+	}
+	if len(pcs) != 0 {
+		lines, err := reportGenerator.Lines(pcs)
+		if err != nil {
+			return nil, err
+		}
+		for _, pc := range pcs {
+			// PCs without source lines are cached too, so that they are not symbolized again.
+			coverLinesCache[uint32(pc)] = lines[pc]
+		}
+	}
+	res := make(map[uint32][]cover.Line, len(cov))
+	for pc := range cov {
+		if ln := coverLinesCache[pc]; len(ln) != 0 {
+			res[pc] = ln
+		}
+	}
+	return res, nil
+}
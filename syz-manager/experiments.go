@@ -0,0 +1,225 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/html"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Experiments enable a fuzzer feature on a percent of VMs (the treatment arm)
+// and collect the same metrics for them and for the rest of VMs (the control arm),
+// so that algorithm changes can be evaluated on real workloads before enabling them everywhere.
+// Metrics are accumulated since the manager start.
+
+type experiments struct {
+	mu   sync.Mutex
+	exps []*experiment
+}
+
+type experiment struct {
+	*mgrconfig.Experiment
+	arms [2]experimentArm // control and treatment
+}
+
+type experimentArm struct {
+	fuzzers map[string]bool
+	execs   uint64
+	inputs  uint64
+	signal  uint64 // new corpus signal
+	crashes uint64
+	titles  map[string]bool
+}
+
+func newExperiments(cfgs []*mgrconfig.Experiment) (*experiments, error) {
+	e := new(experiments)
+	for _, cfg := range cfgs {
+		known := false
+		for _, feature := range rpctype.ExperimentFeatures {
+			known = known || feature == cfg.Feature
+		}
+		if !known {
+			return nil, fmt.Errorf("experiment %v: unknown feature %q, known features: %q",
+				cfg.Name, cfg.Feature, rpctype.ExperimentFeatures)
+		}
+		exp := &experiment{Experiment: cfg}
+		for i := range exp.arms {
+			exp.arms[i].fuzzers = make(map[string]bool)
+			exp.arms[i].titles = make(map[string]bool)
+		}
+		e.exps = append(e.exps, exp)
+	}
+	return e, nil
+}
+
+// arm returns the fuzzer's arm (1 if the feature is enabled on it).
+// It depends only on the experiment and fuzzer names, so fuzzers stay in the same arm
+// across VM restarts and only move to the treatment arm when Percent is increased.
+func (exp *experiment) arm(fuzzer string) *experimentArm {
+	sig := hash.Hash([]byte(exp.Name), []byte{0}, []byte(fuzzer))
+	if int(binary.LittleEndian.Uint32(sig[:])%100) < exp.Percent {
+		return &exp.arms[1]
+	}
+	return &exp.arms[0]
+}
+
+// connect returns features enabled on the fuzzer.
+func (e *experiments) connect(fuzzer string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var features []string
+	for _, exp := range e.exps {
+		arm := exp.arm(fuzzer)
+		arm.fuzzers[fuzzer] = true
+		if arm == &exp.arms[1] {
+			features = append(features, exp.Feature)
+		}
+	}
+	return features
+}
+
+func (e *experiments) poll(fuzzer string, stats map[string]uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, exp := range e.exps {
+		exp.arm(fuzzer).execs += stats["exec total"]
+	}
+}
+
+func (e *experiments) newInput(fuzzer string, signal int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, exp := range e.exps {
+		arm := exp.arm(fuzzer)
+		arm.inputs++
+		arm.signal += uint64(signal)
+	}
+}
+
+func (e *experiments) crash(fuzzer, title string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, exp := range e.exps {
+		// Ignore crashes of VMs that did not start fuzzing (e.g. boot crashes).
+		if arm := exp.arm(fuzzer); arm.fuzzers[fuzzer] {
+			arm.crashes++
+			arm.titles[title] = true
+		}
+	}
+}
+
+func (mgr *Manager) httpExperiments(w http.ResponseWriter, r *http.Request) {
+	data := &UIExperimentsData{
+		Name: mgr.cfg.Name,
+	}
+	e := mgr.experiments
+	e.mu.Lock()
+	for _, exp := range e.exps {
+		uiExp := &UIExperiment{
+			Name:    exp.Name,
+			Feature: exp.Feature,
+			Percent: exp.Percent,
+		}
+		for i, arm := range exp.arms {
+			uiArm := &uiExp.Arms[i]
+			uiArm.Name = "control"
+			if i == 1 {
+				uiArm.Name = "treatment"
+			}
+			uiArm.VMs = len(arm.fuzzers)
+			uiArm.Execs = arm.execs
+			uiArm.Inputs = arm.inputs
+			uiArm.Signal = arm.signal
+			uiArm.Crashes = arm.crashes
+			uiArm.CrashTypes = len(arm.titles)
+			// Normalize by the number of executions since arms have different sizes.
+			if arm.execs != 0 {
+				uiArm.SignalRate = fmt.Sprintf("%.1f", float64(arm.signal)*1e6/float64(arm.execs))
+				uiArm.CrashRate = fmt.Sprintf("%.2f", float64(arm.crashes)*1e6/float64(arm.execs))
+			}
+		}
+		data.Experiments = append(data.Experiments, uiExp)
+	}
+	e.mu.Unlock()
+	if err := experimentsTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
+type UIExperimentsData struct {
+	Name        string
+	Experiments []*UIExperiment
+}
+
+type UIExperiment struct {
+	Name    string
+	Feature string
+	Percent int
+	Arms    [2]UIExperimentArm
+}
+
+type UIExperimentArm struct {
+	Name       string
+	VMs        int
+	Execs      uint64
+	Inputs     uint64
+	Signal     uint64
+	Crashes    uint64
+	CrashTypes int
+	SignalRate string // new signal per 1M execs
+	CrashRate  string // crashes per 1M execs
+}
+
+var experimentsTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name}} experiments</title>
+	{{HEAD}}
+</head>
+<body>
+<b>{{.Name}} experiments</b>
+<br>
+Metrics are collected since the manager start, rates are per 1M executions.
+{{range $exp := $.Experiments}}
+<table class="list_table">
+	<caption>{{$exp.Name}}: {{$exp.Feature}} on {{$exp.Percent}}% of VMs</caption>
+	<tr>
+		<th>Arm</th>
+		<th>VMs</th>
+		<th>Execs</th>
+		<th>New inputs</th>
+		<th>New signal</th>
+		<th>Signal rate</th>
+		<th>Crashes</th>
+		<th>Crash types</th>
+		<th>Crash rate</th>
+	</tr>
+	{{range $arm := $exp.Arms}}
+	<tr>
+		<td>{{$arm.Name}}</td>
+		<td>{{$arm.VMs}}</td>
+		<td>{{$arm.Execs}}</td>
+		<td>{{$arm.Inputs}}</td>
+		<td>{{$arm.Signal}}</td>
+		<td>{{$arm.SignalRate}}</td>
+		<td>{{$arm.Crashes}}</td>
+		<td>{{$arm.CrashTypes}}</td>
+		<td>{{$arm.CrashRate}}</td>
+	</tr>
+	{{end}}
+</table>
+<br>
+{{end}}
+</body></html>
+`)
@@ -0,0 +1,65 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// genConfig asks for values of the template params on stdin and writes the generated config
+// to the file (or to stdout if the file is not specified). Answers can be piped in as well,
+// one per line in the order of questions, empty lines select the default values.
+func genConfig(name, file string) {
+	tmpl := mgrconfig.LookupTemplate(name)
+	if tmpl == nil {
+		if name != "list" {
+			fmt.Fprintf(os.Stderr, "unknown setup %q\n", name)
+		}
+		fmt.Fprintf(os.Stderr, "supported setups:\n")
+		for _, tmpl := range mgrconfig.Templates {
+			fmt.Fprintf(os.Stderr, "  %-10v %v\n", tmpl.Name, tmpl.Description)
+		}
+		if name != "list" {
+			os.Exit(1)
+		}
+		return
+	}
+	if file != "" && osutil.IsExist(file) {
+		log.Fatalf("%v already exists, won't overwrite it", file)
+	}
+	values := make(map[string]string)
+	in := bufio.NewScanner(os.Stdin)
+	for _, param := range tmpl.Params {
+		fmt.Fprintf(os.Stderr, "%v", param.Help)
+		if param.Default != "" {
+			fmt.Fprintf(os.Stderr, " [%v]", param.Default)
+		}
+		fmt.Fprintf(os.Stderr, ": ")
+		if !in.Scan() {
+			fmt.Fprintf(os.Stderr, "\n")
+			break
+		}
+		values[param.Name] = strings.TrimSpace(in.Text())
+	}
+	data, err := tmpl.Generate(values)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if file == "" {
+		os.Stdout.Write(data)
+	} else if err := osutil.WriteFile(file, data); err != nil {
+		log.Fatalf("%v", err)
+	}
+	// Tell what else needs to be done before the manager can start (build binaries, create image, etc).
+	if _, err := mgrconfig.LoadData(data); err != nil {
+		fmt.Fprintf(os.Stderr, "note: the config is not usable yet: %v\n", err)
+	}
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,12 +19,19 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/syzkaller/pkg/cover"
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/html"
 	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrapi"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/repro"
+	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/pkg/vcs"
 	"github.com/google/syzkaller/prog"
@@ -35,13 +43,20 @@ func (mgr *Manager) initHTTP() {
 	http.HandleFunc("/config", mgr.httpConfig)
 	http.HandleFunc("/syscalls", mgr.httpSyscalls)
 	http.HandleFunc("/corpus", mgr.httpCorpus)
+	http.HandleFunc("/corpus/export", mgr.httpCorpusExport)
+	http.HandleFunc("/corpus/import", mgr.httpCorpusImport)
 	http.HandleFunc("/crash", mgr.httpCrash)
+	http.HandleFunc("/crash/tags", mgr.httpCrashTags)
+	http.HandleFunc("/crash/cover", mgr.httpCrashCover)
 	http.HandleFunc("/cover", mgr.httpCover)
 	http.HandleFunc("/prio", mgr.httpPrio)
 	http.HandleFunc("/file", mgr.httpFile)
 	http.HandleFunc("/report", mgr.httpReport)
 	http.HandleFunc("/rawcover", mgr.httpRawCover)
 	http.HandleFunc("/input", mgr.httpInput)
+	http.HandleFunc("/profile", mgr.httpProfile)
+	http.HandleFunc("/experiments", mgr.httpExperiments)
+	http.HandleFunc("/api/summary", mgr.httpAPISummary)
 	// Browsers like to request this, without special handler this goes to / handler.
 	http.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -61,13 +76,20 @@ func (mgr *Manager) httpSummary(w http.ResponseWriter, r *http.Request) {
 		Name:  mgr.cfg.Name,
 		Log:   log.CachedLogOutput(),
 		Stats: mgr.collectStats(),
+		Tag:   r.FormValue("tag"),
 	}
+	data.Warnings = mgr.unsupportedWarnings()
 
-	var err error
-	if data.Crashes, err = mgr.collectCrashes(mgr.cfg.Workdir); err != nil {
+	crashes, err := mgr.collectCrashes(mgr.cfg.Workdir)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to collect crashes: %v", err), http.StatusInternalServerError)
 		return
 	}
+	for _, crash := range crashes {
+		if data.Tag == "" || crash.hasTag(data.Tag) {
+			data.Crashes = append(data.Crashes, crash)
+		}
+	}
 
 	if err := summaryTemplate.Execute(w, data); err != nil {
 		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
@@ -76,6 +98,78 @@ func (mgr *Manager) httpSummary(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// unsupportedWarnings groups unsupported syscalls by the missing requirements.
+func (mgr *Manager) unsupportedWarnings() []UIWarning {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	calls := make(map[string][]string)
+	for call, reason := range mgr.unsupportedSyscalls {
+		calls[reason] = append(calls[reason], call)
+	}
+	var warnings []UIWarning
+	for reason, list := range calls {
+		sort.Strings(list)
+		if reason == "" {
+			reason = "for unknown reason (no known kernel configs or devices are missing)"
+		} else {
+			reason = "because " + reason
+		}
+		warnings = append(warnings, UIWarning{Reason: reason, Calls: list})
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return len(warnings[i].Calls) > len(warnings[j].Calls)
+	})
+	return warnings
+}
+
+func (mgr *Manager) httpAPISummary(w http.ResponseWriter, r *http.Request) {
+	crashes, err := mgr.collectCrashes(mgr.cfg.Workdir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to collect crashes: %v", err), http.StatusInternalServerError)
+		return
+	}
+	rawStats := mgr.stats.all()
+	mgr.mu.Lock()
+	summary := &mgrapi.Summary{
+		Name:        mgr.cfg.Name,
+		Revision:    sys.GitRevisionBase,
+		UpTime:      time.Since(mgr.startTime),
+		FuzzingTime: mgr.fuzzingTime,
+		Corpus:      len(mgr.corpus),
+		Candidates:  len(mgr.candidates),
+		Cover:       rawStats["cover"],
+		Signal:      rawStats["signal"],
+		Stats:       rawStats,
+	}
+	mgr.mu.Unlock()
+	if mgr.vmPool != nil {
+		summary.VMs.Total = mgr.vmPool.Count()
+	}
+	summary.VMs.Fuzzing = int(atomic.LoadUint32(&mgr.numFuzzing))
+	summary.VMs.Reproducing = int(atomic.LoadUint32(&mgr.numReproducing))
+	for _, crash := range crashes {
+		summary.Crashes = append(summary.Crashes, &mgrapi.Crash{
+			ID:        crash.ID,
+			Title:     crash.Description,
+			Count:     crash.Count,
+			LastTime:  crash.LastTime,
+			FirstTime: crash.FirstTime,
+			Active:    crash.Active,
+			Repro:     crash.Triaged,
+			Score:     crash.Score,
+			Tags:      append(append([]string{}, crash.Tags...), crash.ManualTags...),
+			Trend:     crash.Trend,
+		})
+	}
+	data, err := json.MarshalIndent(summary, "", "\t")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode json: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
 func (mgr *Manager) httpConfig(w http.ResponseWriter, r *http.Request) {
 	data, err := json.MarshalIndent(mgr.cfg, "", "\t")
 	if err != nil {
@@ -100,6 +194,20 @@ func (mgr *Manager) httpSyscalls(w http.ResponseWriter, r *http.Request) {
 	sort.Slice(data.Calls, func(i, j int) bool {
 		return data.Calls[i].Name < data.Calls[j].Name
 	})
+	mgr.mu.Lock()
+	for name, reason := range mgr.disabledSyscalls {
+		data.Disabled = append(data.Disabled, UIDisabledCall{name, reason})
+	}
+	for name, effects := range mgr.sideEffects {
+		data.SideEffects = append(data.SideEffects, UIDisabledCall{name, effects.String()})
+	}
+	mgr.mu.Unlock()
+	sort.Slice(data.Disabled, func(i, j int) bool {
+		return data.Disabled[i].Name < data.Disabled[j].Name
+	})
+	sort.Slice(data.SideEffects, func(i, j int) bool {
+		return data.SideEffects[i].Name < data.SideEffects[j].Name
+	})
 	if err := syscallsTemplate.Execute(w, data); err != nil {
 		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
 			http.StatusInternalServerError)
@@ -137,6 +245,14 @@ func (mgr *Manager) collectStats() []UIStat {
 			Link:  "/syscalls",
 		})
 	}
+	if len(mgr.backfillCalls) != 0 {
+		stats = append(stats, UIStat{Name: "backfill syscalls", Value: fmt.Sprint(len(mgr.backfillCalls))})
+	}
+	stats = append(stats, UIStat{Name: "fuzzer profiles", Value: "request", Link: "/profile"})
+	if len(mgr.cfg.Experiments) != 0 {
+		stats = append(stats, UIStat{Name: "experiments", Value: fmt.Sprint(len(mgr.cfg.Experiments)),
+			Link: "/experiments"})
+	}
 
 	secs := uint64(1)
 	if !mgr.firstConnect.IsZero() {
@@ -196,6 +312,180 @@ func (mgr *Manager) httpCrash(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// httpCrashTags returns severity score and tags of a crash as JSON on GET,
+// and replaces manual tags of the crash with the "tags" form value on POST.
+func (mgr *Manager) httpCrashTags(w http.ResponseWriter, r *http.Request) {
+	crashID := r.FormValue("id")
+	crash := readCrash(mgr.cfg.Workdir, crashID, nil, mgr.startTime, false)
+	if crash == nil {
+		http.Error(w, fmt.Sprintf("failed to read crash info"), http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodPost {
+		tags := parseTags(r.FormValue("tags"))
+		if err := writeManualTags(filepath.Join(mgr.crashdir, crash.ID), tags); err != nil {
+			http.Error(w, fmt.Sprintf("failed to save tags: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if r.FormValue("redirect") != "" {
+			http.Redirect(w, r, "/crash?id="+crash.ID, http.StatusFound)
+			return
+		}
+		crash.ManualTags = tags
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"id":          crash.ID,
+		"title":       crash.Description,
+		"score":       crash.Score,
+		"tags":        crash.Tags,
+		"manual_tags": crash.ManualTags,
+	}, "", "\t")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode json: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// Max number of stack frames and corpus inputs per frame shown on the crash coverage page.
+const (
+	crashCoverFrames = 16
+	crashCoverInputs = 10
+)
+
+// httpCrashCover shows whether source lines of the crash stack were covered by the corpus,
+// and which corpus inputs cover them.
+func (mgr *Manager) httpCrashCover(w http.ResponseWriter, r *http.Request) {
+	crashID := r.FormValue("id")
+	index, err := strconv.Atoi(r.FormValue("index"))
+	if _, hexErr := hex.DecodeString(crashID); len(crashID) != 40 || hexErr != nil || err != nil {
+		http.Error(w, "bad crash id", http.StatusBadRequest)
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(mgr.crashdir, crashID, fmt.Sprintf("structured%v", index)))
+	if err != nil {
+		http.Error(w, "failed to read structured report", http.StatusInternalServerError)
+		return
+	}
+	st := new(report.Structured)
+	if err := json.Unmarshal(data, st); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse structured report: %v", err), http.StatusInternalServerError)
+		return
+	}
+	res := &UICrashCover{
+		ID:    crashID,
+		Title: st.Title,
+		Lines: crashCoverLines(st),
+	}
+	if len(res.Lines) == 0 {
+		http.Error(w, "the report has no symbolized stack frames", http.StatusInternalServerError)
+		return
+	}
+
+	if !mgr.cfg.Cover || mgr.cfg.KernelObj == "" {
+		http.Error(w, "coverage is not enabled or no kernel_obj in config file", http.StatusInternalServerError)
+		return
+	}
+	// Symbolization is slow, so don't hold the manager lock while doing it.
+	mgr.mu.Lock()
+	corpus := make(map[string]rpctype.RPCInput, len(mgr.corpus))
+	for sig, inp := range mgr.corpus {
+		corpus[sig] = inp
+	}
+	mgr.mu.Unlock()
+
+	var cov cover.Cover
+	for _, inp := range corpus {
+		cov.Merge(inp.Cover)
+	}
+	pcLines, err := coverLines(mgr.cfg.KernelObj, mgr.sysTarget.KernelObject,
+		mgr.cfg.KernelSrc, mgr.cfg.TargetVMArch, mgr.cfg.TargetOS, cov)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to symbolize coverage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// PCs that cover each of the crash lines.
+	linePCs := make(map[uint32][]*UICrashCoverLine)
+	for pc, lines := range pcLines {
+		for _, ln := range lines {
+			for _, crashLine := range res.Lines {
+				if !cover.SameFile(ln.File, crashLine.File) {
+					continue
+				}
+				if ln.Func == crashLine.Func {
+					crashLine.FuncCovered = true
+				}
+				if ln.Line == crashLine.Line {
+					crashLine.Covered = true
+					linePCs[pc] = append(linePCs[pc], crashLine)
+				}
+			}
+		}
+	}
+	for sig, inp := range corpus {
+		seen := make(map[*UICrashCoverLine]bool)
+		for _, pc := range inp.Cover {
+			for _, crashLine := range linePCs[pc] {
+				if seen[crashLine] {
+					continue
+				}
+				seen[crashLine] = true
+				crashLine.NumInputs++
+				if len(crashLine.Inputs) >= crashCoverInputs {
+					continue
+				}
+				p, err := mgr.target.Deserialize(inp.Prog, prog.NonStrict)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("failed to deserialize program: %v", err),
+						http.StatusInternalServerError)
+					return
+				}
+				crashLine.Inputs = append(crashLine.Inputs, &UIInput{
+					Sig:   sig,
+					Short: p.String(),
+					Cover: len(inp.Cover),
+				})
+			}
+		}
+	}
+	for _, crashLine := range res.Lines {
+		sort.Slice(crashLine.Inputs, func(i, j int) bool {
+			return crashLine.Inputs[i].Sig < crashLine.Inputs[j].Sig
+		})
+	}
+	if err := crashCoverTemplate.Execute(w, res); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		return
+	}
+	runtime.GC()
+}
+
+// crashCoverLines returns distinct file:line frames of the register dump (e.g. RIP)
+// and of the first stack trace of the report.
+func crashCoverLines(st *report.Structured) []*UICrashCoverLine {
+	var res []*UICrashCoverLine
+	dedup := make(map[string]bool)
+	for _, stack := range st.Stacks {
+		for _, frame := range stack.Frames {
+			key := fmt.Sprintf("%v:%v", frame.File, frame.Line)
+			if frame.File == "" || frame.Line == 0 || dedup[key] || len(res) >= crashCoverFrames {
+				continue
+			}
+			dedup[key] = true
+			res = append(res, &UICrashCoverLine{
+				Func: frame.Func,
+				File: frame.File,
+				Line: frame.Line,
+			})
+		}
+		if stack.Name != "RIP" && stack.Name != "IP" && stack.Name != "pc" && len(res) != 0 {
+			break
+		}
+	}
+	return res
+}
+
 func (mgr *Manager) httpCorpus(w http.ResponseWriter, r *http.Request) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
@@ -232,6 +522,150 @@ func (mgr *Manager) httpCorpus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// httpCorpusExport serves the current corpus either as a gzipped tarball
+// of .syz files (format=tar, default) or as a packed corpus.db (format=db).
+func (mgr *Manager) httpCorpusExport(w http.ResponseWriter, r *http.Request) {
+	mgr.mu.Lock()
+	records := make(map[string]db.Record, len(mgr.corpus))
+	for sig, inp := range mgr.corpus {
+		records[sig] = db.Record{Val: inp.Prog}
+	}
+	mgr.mu.Unlock()
+	name := mgr.cfg.Name
+	if name == "" {
+		name = "corpus"
+	}
+	switch format := r.FormValue("format"); format {
+	case "", "tar":
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%v.tar.gz", name))
+		if err := db.WriteTarGz(w, records, ".syz"); err != nil {
+			log.Logf(0, "failed to export corpus: %v", err)
+		}
+	case "db":
+		var list []db.Record
+		for _, rec := range records {
+			list = append(list, rec)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%v.db", name))
+		w.Write(db.Serialize(currentDBVersion, list))
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q, want tar or db", format), http.StatusBadRequest)
+	}
+}
+
+// httpCorpusImport accepts programs in the request body (or "file" form file)
+// as a tarball, packed db, execution log or a single program.
+// Valid programs are deserialized leniently, checked against enabled syscalls,
+// and queued as candidates for triage.
+func (mgr *Manager) httpCorpusImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST to import programs", http.StatusMethodNotAllowed)
+		return
+	}
+	body := io.Reader(r.Body)
+	if f, _, err := r.FormFile("file"); err == nil {
+		defer f.Close()
+		body = f
+	}
+	const maxImportSize = 512 << 20
+	// Read one byte more than allowed to detect oversized requests instead of silently truncating them.
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxImportSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxImportSize {
+		http.Error(w, fmt.Sprintf("request is larger than %v bytes", maxImportSize),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+	vals, err := db.ReadArchive(data, maxImportSize)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse archive: %v", err), http.StatusBadRequest)
+		return
+	}
+	mgr.mu.Lock()
+	if mgr.checkResult == nil {
+		mgr.mu.Unlock()
+		http.Error(w, "machine is not checked yet", http.StatusServiceUnavailable)
+		return
+	}
+	enabled := make(map[int]bool)
+	for _, id := range mgr.checkResult.EnabledCalls[mgr.cfg.Sandbox] {
+		enabled[id] = true
+	}
+	existing := make(map[string]bool, len(mgr.corpus))
+	for sig := range mgr.corpus {
+		existing[sig] = true
+	}
+	mgr.mu.Unlock()
+
+	var progs [][]byte
+	var errors []string
+	duplicates := 0
+	for _, val := range vals {
+		parsed, err := mgr.parseImportedProgs(val, enabled)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		for _, data := range parsed {
+			sig := hash.String(data)
+			if existing[sig] {
+				duplicates++
+				continue
+			}
+			existing[sig] = true
+			progs = append(progs, data)
+		}
+	}
+	if len(progs) != 0 {
+		mgr.addNewCandidates(progs)
+	}
+	log.Logf(0, "imported %v programs over http (%v duplicate, %v rejected)",
+		len(progs), duplicates, len(errors))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "imported: %v\nduplicate: %v\nrejected: %v\n", len(progs), duplicates, len(errors))
+	const maxErrors = 20
+	for i, err := range errors {
+		if i == maxErrors {
+			fmt.Fprintf(w, "...\n")
+			break
+		}
+		fmt.Fprintf(w, "%v\n", err)
+	}
+}
+
+func (mgr *Manager) parseImportedProgs(data []byte, enabled map[int]bool) ([][]byte, error) {
+	var progs []*prog.Prog
+	if p, err := mgr.target.Deserialize(data, prog.NonStrict); err == nil {
+		progs = append(progs, p)
+	} else {
+		entries := mgr.target.ParseLog(data)
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("failed to deserialize program: %v", err)
+		}
+		for _, ent := range entries {
+			progs = append(progs, ent.P)
+		}
+	}
+	var res [][]byte
+	for _, p := range progs {
+		if len(p.Calls) == 0 {
+			continue
+		}
+		for _, c := range p.Calls {
+			if !enabled[c.Meta.ID] {
+				return nil, fmt.Errorf("program uses disabled syscall %v", c.Meta.Name)
+			}
+		}
+		res = append(res, p.Serialize())
+	}
+	return res, nil
+}
+
 func (mgr *Manager) httpCover(w http.ResponseWriter, r *http.Request) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
@@ -264,8 +698,13 @@ func (mgr *Manager) httpCoverCover(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// format=lcov allows to feed the coverage into genhtml or IDEs.
+	lcov := r.FormValue("format") == "lcov"
+	if lcov {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
 	if err := generateCoverHTML(w, mgr.cfg.KernelObj, mgr.sysTarget.KernelObject,
-		mgr.cfg.KernelSrc, mgr.cfg.TargetVMArch, mgr.cfg.TargetOS, cov); err != nil {
+		mgr.cfg.KernelSrc, mgr.cfg.TargetVMArch, mgr.cfg.TargetOS, cov, lcov); err != nil {
 		http.Error(w, fmt.Sprintf("failed to generate coverage profile: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -351,10 +790,12 @@ func (mgr *Manager) httpPrio(w http.ResponseWriter, r *http.Request) {
 
 func (mgr *Manager) httpFile(w http.ResponseWriter, r *http.Request) {
 	file := filepath.Clean(r.FormValue("name"))
-	if !strings.HasPrefix(file, "crashes/") && !strings.HasPrefix(file, "corpus/") {
+	if !strings.HasPrefix(file, "crashes/") && !strings.HasPrefix(file, "corpus/") &&
+		!strings.HasPrefix(file, profilesDir+"/") {
 		http.Error(w, "oh, oh, oh!", http.StatusInternalServerError)
 		return
 	}
+	binary := strings.HasPrefix(file, profilesDir+"/")
 	file = filepath.Join(mgr.cfg.Workdir, file)
 	f, err := os.Open(file)
 	if err != nil {
@@ -362,7 +803,12 @@ func (mgr *Manager) httpFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer f.Close()
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if binary {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filepath.Base(file))
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
 	io.Copy(w, f)
 }
 
@@ -534,6 +980,18 @@ func readCrash(workdir, dir string, repros map[string]bool, start time.Time, ful
 			if osutil.IsExist(filepath.Join(workdir, reportFile)) {
 				crash.Report = reportFile
 			}
+			structuredFile := filepath.Join("crashes", dir, "structured"+index)
+			if osutil.IsExist(filepath.Join(workdir, structuredFile)) {
+				crash.Structured = structuredFile
+			}
+			secondaryFile := filepath.Join("crashes", dir, "secondary"+index)
+			if osutil.IsExist(filepath.Join(workdir, secondaryFile)) {
+				crash.Secondary = secondaryFile
+			}
+			diagnosticsFile := filepath.Join("crashes", dir, "diagnostics"+index)
+			if osutil.IsExist(filepath.Join(workdir, diagnosticsFile)) {
+				crash.Diagnostics = diagnosticsFile
+			}
 		}
 		sort.Slice(crashes, func(i, j int) bool {
 			return crashes[i].Time.After(crashes[j].Time)
@@ -541,15 +999,96 @@ func readCrash(workdir, dir string, repros map[string]bool, start time.Time, ful
 	}
 
 	triaged := reproStatus(hasRepro, hasCRepro, repros[desc], reproAttempts >= maxReproAttempts)
-	return &UICrashType{
+	if hasRepro {
+		if v := readReproValidation(filepath.Join(crashdir, dir)); v != nil {
+			triaged += fmt.Sprintf(", reliability %v", v.validation())
+		}
+	} else if cp := repro.ReadCheckpoint(workdir, desc); cp != nil {
+		if !repros[desc] {
+			triaged = "repro interrupted"
+		}
+		if cp.Stage != "" {
+			triaged += fmt.Sprintf(", %v", cp.Stage)
+		}
+	}
+	triggerCall := ""
+	if full && hasRepro {
+		triggerCall = reproTriggerCall(filepath.Join(crashdir, dir, "repro.prog"))
+	}
+	score, tags := crashSeverity(desc, readCrashSandboxes(filepath.Join(crashdir, dir)), hasRepro, hasCRepro)
+	crash := &UICrashType{
 		Description: desc,
 		LastTime:    modTime,
 		Active:      modTime.After(start),
 		ID:          dir,
 		Count:       len(crashes),
 		Triaged:     triaged,
+		TriggerCall: triggerCall,
+		Score:       score,
+		Tags:        tags,
+		ManualTags:  readManualTags(filepath.Join(crashdir, dir)),
 		Crashes:     crashes,
 	}
+	for _, profile := range readSandboxProfiles(filepath.Join(crashdir, dir)) {
+		crash.Tags = append(crash.Tags, "profile:"+profile)
+	}
+	// Same fingerprint as an earlier crash with a different title (see Manager.saveCrash).
+	if osutil.IsExist(filepath.Join(crashdir, dir, "duplicate_of")) {
+		crash.Tags = append(crash.Tags, "duplicate")
+	}
+	// Crashes saved before history tracking don't have it.
+	if history := readCrashHistory(filepath.Join(crashdir, dir)); history != nil {
+		now := time.Now()
+		crash.FirstTime = history.FirstSeen
+		crash.Total = history.Count
+		crash.Sparkline = history.sparkline(now)
+		crash.Trend, crash.TrendReason = history.trend(readBuilds(workdir), now)
+		if crash.Trend != "" {
+			crash.Tags = append(crash.Tags, crash.Trend)
+		}
+		if full {
+			crash.Builds = history.Builds
+		}
+	}
+	return crash
+}
+
+// reproTriggerCall returns name of the call that triggers the crash
+// according to annotations in the repro.prog file (see repro.Result.Annotated).
+func reproTriggerCall(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines)-1; i++ {
+		if lines[i] != "# "+repro.TriggerComment {
+			continue
+		}
+		call := lines[i+1]
+		if pos := strings.IndexByte(call, '('); pos != -1 {
+			call = call[:pos]
+		}
+		if pos := strings.Index(call, " = "); pos != -1 {
+			call = call[pos+3:]
+		}
+		return call
+	}
+	return ""
+}
+
+func (crash *UICrashType) hasTag(tag string) bool {
+	for _, t := range crash.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	for _, t := range crash.ManualTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
 
 func reproStatus(hasRepro, hasCRepro, reproducing, nonReproducible bool) string {
@@ -575,15 +1114,31 @@ func trimNewLines(data []byte) []byte {
 }
 
 type UISummaryData struct {
-	Name    string
-	Stats   []UIStat
-	Crashes []*UICrashType
-	Log     string
+	Name     string
+	Stats    []UIStat
+	Warnings []UIWarning
+	Crashes  []*UICrashType
+	Tag      string
+	Log      string
+}
+
+// UIWarning describes syscalls that this run can't exercise.
+type UIWarning struct {
+	Reason string
+	Calls  []string
 }
 
 type UISyscallsData struct {
-	Name  string
-	Calls []UICallType
+	Name     string
+	Calls    []UICallType
+	Disabled []UIDisabledCall
+	// Syscalls that changed global state during fuzzing (see side_effects.go).
+	SideEffects []UIDisabledCall
+}
+
+type UIDisabledCall struct {
+	Name   string
+	Reason string
 }
 
 type UICrashType struct {
@@ -593,16 +1148,46 @@ type UICrashType struct {
 	ID          string
 	Count       int
 	Triaged     string
+	TriggerCall string // call that triggers the crash in the reproducer
+	Score       int
+	Tags        []string // automatically assigned tags
+	ManualTags  []string // tags assigned by user
 	Crashes     []*UICrash
+	FirstTime   time.Time
+	Total       int    // number of crashes since FirstTime (Count is capped by the number of stored logs)
+	Sparkline   string // daily crash counts for the last sparklineDays
+	Trend       string // trendSpike/trendStopped
+	TrendReason string
+	Builds      []*crashBuildHistory
 }
 
 type UICrash struct {
-	Index  int
-	Time   time.Time
-	Active bool
-	Log    string
-	Report string
-	Tag    string
+	Index      int
+	Time       time.Time
+	Active     bool
+	Log        string
+	Report     string
+	Structured string
+	Secondary  string
+	Tag        string
+	// Memory state collected after the crash (see crash_diagnostics config param).
+	Diagnostics string
+}
+
+type UICrashCover struct {
+	ID    string
+	Title string
+	Lines []*UICrashCoverLine
+}
+
+type UICrashCoverLine struct {
+	Func        string
+	File        string
+	Line        int
+	Covered     bool
+	FuncCovered bool
+	NumInputs   int
+	Inputs      []*UIInput
 }
 
 type UIStat struct {
@@ -655,24 +1240,45 @@ var summaryTemplate = html.CreatePage(`
 	{{end}}
 </table>
 
+{{if $.Warnings}}
+<table class="list_table">
+	<caption>Warnings:</caption>
+	{{range $w := $.Warnings}}
+	<tr>
+		<td>this run cannot exercise {{len $w.Calls}} syscalls {{$w.Reason}}</td>
+		<td title="{{range $c := $w.Calls}}{{$c}} {{end}}">{{index $w.Calls 0}}{{if gt (len $w.Calls) 1}}, ...{{end}}</td>
+	</tr>
+	{{end}}
+</table>
+{{end}}
+
 <table class="list_table">
-	<caption>Crashes:</caption>
+	<caption>Crashes{{if $.Tag}} tagged {{$.Tag}} (<a href="/">all</a>){{end}}:</caption>
 	<tr>
 		<th><a onclick="return sortTable(this, 'Description', textSort)" href="#">Description</a></th>
+		<th><a onclick="return sortTable(this, 'Score', numSort)" href="#">Score</a></th>
 		<th><a onclick="return sortTable(this, 'Count', numSort)" href="#">Count</a></th>
 		<th><a onclick="return sortTable(this, 'Last Time', textSort, true)" href="#">Last Time</a></th>
+		<th><a onclick="return sortTable(this, 'Trend', textSort)" href="#">Trend</a></th>
 		<th><a onclick="return sortTable(this, 'Report', textSort)" href="#">Report</a></th>
+		<th><a onclick="return sortTable(this, 'Tags', textSort)" href="#">Tags</a></th>
 	</tr>
 	{{range $c := $.Crashes}}
 	<tr>
 		<td class="title"><a href="/crash?id={{$c.ID}}">{{$c.Description}}</a></td>
+		<td class="stat">{{$c.Score}}</td>
 		<td class="stat {{if not $c.Active}}inactive{{end}}">{{$c.Count}}</td>
 		<td class="time {{if not $c.Active}}inactive{{end}}">{{formatTime $c.LastTime}}</td>
+		<td title="{{$c.TrendReason}}">{{$c.Sparkline}} {{if $c.Trend}}<b>{{$c.Trend}}</b>{{end}}</td>
 		<td>
 			{{if $c.Triaged}}
 				<a href="/report?id={{$c.ID}}">{{$c.Triaged}}</a>
 			{{end}}
 		</td>
+		<td>
+			{{range $t := $c.Tags}}<a href="/?tag={{$t}}">{{$t}}</a> {{end}}
+			{{range $t := $c.ManualTags}}<a href="/?tag={{$t}}"><b>{{$t}}</b></a> {{end}}
+		</td>
 	</tr>
 	{{end}}
 </table>
@@ -715,6 +1321,38 @@ var syscallsTemplate = html.CreatePage(`
 	</tr>
 	{{end}}
 </table>
+
+{{if $.Disabled}}
+<table class="list_table">
+	<caption>Syscalls that don't work in the kernel:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Syscall', textSort)" href="#">Syscall</a></th>
+		<th><a onclick="return sortTable(this, 'Reason', textSort)" href="#">Reason</a></th>
+	</tr>
+	{{range $c := $.Disabled}}
+	<tr>
+		<td>{{$c.Name}}</td>
+		<td>{{$c.Reason}}</td>
+	</tr>
+	{{end}}
+</table>
+{{end}}
+
+{{if $.SideEffects}}
+<table class="list_table">
+	<caption>Syscalls that changed global state:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Syscall', textSort)" href="#">Syscall</a></th>
+		<th><a onclick="return sortTable(this, 'Side effects', textSort)" href="#">Side effects</a></th>
+	</tr>
+	{{range $c := $.SideEffects}}
+	<tr>
+		<td>{{$c.Name}}</td>
+		<td>{{$c.Reason}}</td>
+	</tr>
+	{{end}}
+</table>
+{{end}}
 </body></html>
 `)
 
@@ -731,6 +1369,43 @@ var crashTemplate = html.CreatePage(`
 {{if .Triaged}}
 Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 {{end}}
+{{if .TriggerCall}}
+<br>
+Triggering call: {{.TriggerCall}}
+{{end}}
+<br>
+Severity: {{.Score}}
+{{range $t := .Tags}}<a href="/?tag={{$t}}">{{$t}}</a> {{end}}
+<form action="/crash/tags" method="post">
+	<input type="hidden" name="id" value="{{.ID}}">
+	<input type="hidden" name="redirect" value="1">
+	Tags: <input type="text" name="tags" value="{{range $t := .ManualTags}}{{$t}} {{end}}">
+	<input type="submit" value="Save">
+</form>
+
+{{if .Builds}}
+First seen: {{formatTime .FirstTime}}, last seen: {{formatTime .LastTime}}, {{.Total}} crashes
+<br>
+Last days: {{.Sparkline}}
+{{if .Trend}}<br><b>{{.Trend}}</b>: {{.TrendReason}}{{end}}
+<table class="list_table">
+	<caption>Per-build history:</caption>
+	<tr>
+		<th>Build</th>
+		<th>First seen</th>
+		<th>Last seen</th>
+		<th>Count</th>
+	</tr>
+	{{range $b := $.Builds}}
+	<tr>
+		<td class="tag" title="{{$b.Build}}">{{formatShortHash $b.Build}}</td>
+		<td class="time">{{formatTime $b.FirstSeen}}</td>
+		<td class="time">{{formatTime $b.LastSeen}}</td>
+		<td class="stat">{{$b.Count}}</td>
+	</tr>
+	{{end}}
+</table>
+{{end}}
 
 <table class="list_table">
 	<tr>
@@ -746,7 +1421,17 @@ Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 		<td><a href="/file?name={{$c.Log}}">log</a></td>
 		<td>
 			{{if $c.Report}}
-				<a href="/file?name={{$c.Report}}">report</a></td>
+				<a href="/file?name={{$c.Report}}">report</a>
+			{{end}}
+			{{if $c.Structured}}
+				<a href="/file?name={{$c.Structured}}">json</a>
+				<a href="/crash/cover?id={{$.ID}}&index={{$c.Index}}">coverage</a>
+			{{end}}
+			{{if $c.Secondary}}
+				<a href="/file?name={{$c.Secondary}}">secondary</a>
+			{{end}}
+			{{if $c.Diagnostics}}
+				<a href="/file?name={{$c.Diagnostics}}">diagnostics</a>
 			{{end}}
 		</td>
 		<td class="time {{if not $c.Active}}inactive{{end}}">{{formatTime $c.Time}}</td>
@@ -757,6 +1442,44 @@ Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 </body></html>
 `)
 
+var crashCoverTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Title}} coverage</title>
+	{{HEAD}}
+</head>
+<body>
+<b><a href="/crash?id={{.ID}}">{{.Title}}</a></b>
+
+<table class="list_table">
+	<caption>Coverage of the crash stack by the corpus:</caption>
+	<tr>
+		<th>Function</th>
+		<th>Line</th>
+		<th>Covered</th>
+		<th>Inputs</th>
+	</tr>
+	{{range $ln := $.Lines}}
+	<tr>
+		<td>{{$ln.Func}}</td>
+		<td>{{$ln.File}}:{{$ln.Line}}</td>
+		<td>{{if $ln.Covered}}yes{{else if $ln.FuncCovered}}function only{{else}}no{{end}}</td>
+		<td>
+			{{range $inp := $ln.Inputs}}
+				<a href="/input?sig={{$inp.Sig}}">{{$inp.Short}}</a>
+				(<a href="/cover?input={{$inp.Sig}}">cover</a>)<br>
+			{{end}}
+			{{if gt $ln.NumInputs (len $ln.Inputs)}}
+				({{$ln.NumInputs}} inputs in total)
+			{{end}}
+		</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
 var corpusTemplate = html.CreatePage(`
 <!doctype html>
 <html>
@@ -767,7 +1490,8 @@ var corpusTemplate = html.CreatePage(`
 <body>
 
 <table class="list_table">
-	<caption>Corpus{{if $.Call}} for {{$.Call}}{{end}}:</caption>
+	<caption>Corpus{{if $.Call}} for {{$.Call}}{{end}}
+		(export: <a href="/corpus/export?format=tar">tar</a>, <a href="/corpus/export?format=db">db</a>):</caption>
 	<tr>
 		<th>Coverage</th>
 		<th>Program</th>
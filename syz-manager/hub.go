@@ -4,6 +4,11 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/google/syzkaller/pkg/hash"
@@ -29,12 +34,35 @@ func (mgr *Manager) hubSyncLoop() {
 	if mgr.cfg.Reproduce && mgr.dash != nil {
 		hc.needMoreRepros = mgr.needMoreRepros
 	}
+	if mgr.cfg.HubTLS {
+		var err error
+		if hc.tls, err = hubTLSConfig(mgr.cfg.HubCACert); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
 	hc.loop()
 }
 
+func hubTLSConfig(caCert string) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if caCert == "" {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hub CA certificate: %v", err)
+	}
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("failed to parse hub CA certificate %v", caCert)
+	}
+	return cfg, nil
+}
+
 type HubConnector struct {
 	mgr            HubManagerView
 	cfg            *mgrconfig.Config
+	tls            *tls.Config
 	target         *prog.Target
 	stats          *Stats
 	enabledCalls   []int
@@ -80,6 +108,7 @@ func (hc *HubConnector) connect(corpus [][]byte) (*rpctype.RPCClient, error) {
 		Key:     hc.cfg.HubKey,
 		Manager: hc.cfg.Name,
 		Fresh:   hc.fresh,
+		Session: fmt.Sprintf("%v-%v", hc.cfg.Name, time.Now().UnixNano()),
 	}
 	for _, id := range hc.enabledCalls {
 		a.Calls = append(a.Calls, hc.target.Syscalls[id].Name)
@@ -87,14 +116,11 @@ func (hc *HubConnector) connect(corpus [][]byte) (*rpctype.RPCClient, error) {
 	hubCorpus := make(map[hash.Sig]bool)
 	for _, inp := range corpus {
 		hubCorpus[hash.Hash(inp)] = true
-		a.Corpus = append(a.Corpus, inp)
 	}
-	// Hub.Connect request can be very large, so do it on a transient connection
-	// (rpc connection buffers never shrink).
-	if err := rpctype.RPCCall(hc.cfg.HubAddr, "Hub.Connect", a, nil); err != nil {
+	if err := hc.upload(a, corpus); err != nil {
 		return nil, err
 	}
-	hub, err := rpctype.NewRPCClient(hc.cfg.HubAddr)
+	hub, err := rpctype.NewRPCClientTLS(hc.cfg.HubAddr, hc.tls)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +129,44 @@ func (hc *HubConnector) connect(corpus [][]byte) (*rpctype.RPCClient, error) {
 	return hub, nil
 }
 
+// upload sends corpus to hub in parts, a failed part is retried and the upload
+// is resumed from the last part received by hub.
+// Hub.Connect requests can be large, so they are done on transient connections
+// (rpc connection buffers never shrink).
+func (hc *HubConnector) upload(a *rpctype.HubConnectArgs, corpus [][]byte) error {
+	const (
+		partSize   = 1000
+		maxRetries = 5
+	)
+	for offset, retries := 0, 0; ; {
+		end := offset + partSize
+		if end > len(corpus) {
+			end = len(corpus)
+		}
+		a.Offset, a.Corpus, a.Done = offset, corpus[offset:end], end == len(corpus)
+		r := new(rpctype.HubConnectRes)
+		if err := rpctype.RPCCallTLS(hc.cfg.HubAddr, hc.tls, "Hub.ConnectPart", a, r); err != nil {
+			if strings.Contains(err.Error(), "can't find method") {
+				// Old hub that does not support resumable uploads, send the whole corpus at once.
+				a.Session, a.Offset, a.Corpus, a.Done = "", 0, corpus, true
+				return rpctype.RPCCallTLS(hc.cfg.HubAddr, hc.tls, "Hub.Connect", a, nil)
+			}
+			if retries++; retries > maxRetries {
+				return err
+			}
+			log.Logf(0, "hub corpus upload failed at %v/%v: %v", offset, len(corpus), err)
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		retries = 0
+		// Hub receives the whole corpus only with the last part, at which point it connects the manager.
+		if r.Received == len(corpus) {
+			return nil
+		}
+		offset = r.Received
+	}
+}
+
 func (hc *HubConnector) sync(hub *rpctype.RPCClient, corpus [][]byte) error {
 	a := &rpctype.HubSyncArgs{
 		Client:  hc.cfg.HubClient,
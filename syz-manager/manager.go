@@ -8,11 +8,13 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,6 +27,8 @@ import (
 	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/host"
 	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/ktypes"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
@@ -32,6 +36,7 @@ import (
 	"github.com/google/syzkaller/pkg/repro"
 	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/tracing"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys"
 	"github.com/google/syzkaller/sys/targets"
@@ -42,6 +47,8 @@ var (
 	flagConfig = flag.String("config", "", "configuration file")
 	flagDebug  = flag.Bool("debug", false, "dump all VM output to console")
 	flagBench  = flag.String("bench", "", "write execution statistics into this file periodically")
+	flagGen    = flag.String("genconfig", "", "generate config for a common setup (\"list\" to list setups)"+
+		" and write it to -config file")
 )
 
 type Manager struct {
@@ -58,24 +65,46 @@ type Manager struct {
 	fuzzingTime    time.Duration
 	stats          *Stats
 	crashTypes     map[string]bool
+	fingerprints   map[string]string // report.Fingerprint -> title of the first crash with it
 	vmStop         chan bool
 	checkResult    *rpctype.CheckArgs
 	fresh          bool
 	numFuzzing     uint32
 	numReproducing uint32
 
-	dash *dashapi.Dashboard
+	dash   *dashapi.Dashboard
+	tracer *tracing.Tracer
 
 	mu              sync.Mutex
 	phase           int
 	enabledSyscalls []int
 
 	candidates       []rpctype.RPCCandidate // untriaged inputs from corpus and hub
+	reminimize       []rpctype.RPCInput     // corpus inputs scheduled for re-minimization
 	disabledHashes   map[string]struct{}
 	corpus           map[string]rpctype.RPCInput
 	newRepros        [][]byte
 	lastMinCorpus    int
 	memoryLeakFrames map[string]bool
+	// The last crash without a kernel report per VM index (e.g. lost connection),
+	// the report recovered from pstore on the next boot is attached to its log.
+	lostCrashes map[int]*Crash
+	// Spans for candidates handed out to fuzzers, keyed by candidate program hash
+	// (see rpctype.NewInputArgs.Candidate).
+	// Used only if tracing is enabled.
+	triageSpans map[string]*tracing.Span
+	// Described syscalls that don't work in the tested kernel: syscall name -> reason.
+	// Detected on machine check and during fuzzing.
+	disabledSyscalls map[string]string
+	profile          profileState
+	experiments      *experiments
+	// Syscalls that always fail in the tested kernel, most likely due to a missing kernel config:
+	// syscall name -> missing requirements (empty if unknown).
+	unsupportedSyscalls map[string]string
+	// Newly enabled syscalls without corpus programs (see backfill.go).
+	backfillCalls map[int]bool
+	// Syscalls that changed global state: syscall name -> side effects (see side_effects.go).
+	sideEffects map[string]ipc.SideEffects
 
 	needMoreRepros chan chan bool
 	hubReproQueue  chan *Crash
@@ -106,6 +135,15 @@ const currentDBVersion = 3
 type Crash struct {
 	vmIndex int
 	hub     bool // this crash was created based on a repro from hub
+	// Stored reproducer that needs to be re-validated (e.g. after a kernel update).
+	revalidate *repro.Result
+	// VM state dump (memory, registers) moved into the crash dir, if any.
+	dump string
+	// Memory state collected after the crash (see vm.Instance.CollectDiagnostics), if any.
+	diagnostics []byte
+	// The crash was recovered from pstore of the previous boot without the log of the crashed run,
+	// so there are no programs to reproduce it.
+	pstoreOnly bool
 	*report.Report
 }
 
@@ -114,6 +152,10 @@ func main() {
 		log.Fatalf("Bad syz-manager build. Build with make, run bin/syz-manager.")
 	}
 	flag.Parse()
+	if *flagGen != "" {
+		genConfig(*flagGen, *flagConfig)
+		return
+	}
 	log.EnableLogCaching(1000, 1<<20)
 	cfg, err := mgrconfig.LoadFile(*flagConfig)
 	if err != nil {
@@ -131,9 +173,35 @@ func main() {
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
+	if cfg.ValidateLayouts {
+		validateLayouts(cfg, target, sysTarget, syscalls)
+	}
 	RunManager(cfg, target, sysTarget, syscalls)
 }
 
+// validateLayouts checks that structs with the kernel attribute used by the enabled syscalls
+// match the tested kernel, otherwise we would silently generate mis-laid-out payloads.
+func validateLayouts(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.Target, syscalls []int) {
+	vmlinux := filepath.Join(cfg.KernelObj, sysTarget.KernelObject)
+	kt, err := ktypes.Load(vmlinux, false)
+	if err != nil {
+		log.Fatalf("failed to load kernel types: %v", err)
+	}
+	var calls []*prog.Syscall
+	for _, id := range syscalls {
+		calls = append(calls, target.Syscalls[id])
+	}
+	errors := ktypes.CheckLayouts(kt, calls)
+	if len(errors) == 0 {
+		log.Logf(0, "struct layouts match %v", vmlinux)
+		return
+	}
+	for _, err := range errors {
+		log.Logf(0, "%v", err)
+	}
+	log.Fatalf("%v structs don't match %v, update the descriptions for this kernel", len(errors), vmlinux)
+}
+
 func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.Target, syscalls []int) {
 	var vmPool *vm.Pool
 	// Type "none" is a special case for debugging/development when manager
@@ -149,6 +217,8 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 
 	crashdir := filepath.Join(cfg.Workdir, "crashes")
 	osutil.MkdirAll(crashdir)
+	// Crash dumps of the previous run that were not moved into crashdir.
+	os.RemoveAll(filepath.Join(cfg.Workdir, "crashdump"))
 
 	reporter, err := report.NewReporter(cfg)
 	if err != nil {
@@ -165,17 +235,29 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 		startTime:        time.Now(),
 		stats:            new(Stats),
 		crashTypes:       make(map[string]bool),
+		fingerprints:     loadFingerprints(crashdir),
 		enabledSyscalls:  syscalls,
 		corpus:           make(map[string]rpctype.RPCInput),
 		disabledHashes:   make(map[string]struct{}),
 		memoryLeakFrames: make(map[string]bool),
+		lostCrashes:      make(map[int]*Crash),
 		fresh:            true,
 		vmStop:           make(chan bool),
 		hubReproQueue:    make(chan *Crash, 10),
 		needMoreRepros:   make(chan chan bool),
 		reproRequest:     make(chan chan map[string]bool),
 		usedFiles:        make(map[string]time.Time),
+		triageSpans:      make(map[string]*tracing.Span),
+		disabledSyscalls: make(map[string]string),
+	}
+	if cfg.TracingAddr != "" {
+		mgr.tracer = tracing.New(cfg.TracingAddr, "syz-manager", cfg.Name)
 	}
+	recordBuild(cfg.Workdir, mgr.buildID(), mgr.startTime)
+	if mgr.experiments, err = newExperiments(cfg.Experiments); err != nil {
+		log.Fatalf("%v", err)
+	}
+	mgr.loadSideEffects()
 
 	log.Logf(0, "loading corpus...")
 	mgr.corpusDB, err = db.Open(filepath.Join(cfg.Workdir, "corpus.db"))
@@ -209,6 +291,7 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 				continue
 			}
 			mgr.fuzzingTime += diff * time.Duration(atomic.LoadUint32(&mgr.numFuzzing))
+			mgr.expireTriageSpans()
 			executed := mgr.stats.execTotal.get()
 			crashes := mgr.stats.crashes.get()
 			signal := mgr.stats.corpusSignal.get()
@@ -255,6 +338,7 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 	if mgr.dash != nil {
 		go mgr.dashboardReporter()
 	}
+	go mgr.reminimizeLoop()
 
 	osutil.HandleInterrupts(vm.Shutdown)
 	if mgr.vmPool == nil {
@@ -280,6 +364,9 @@ type ReproResult struct {
 	stats     *repro.Stats
 	err       error
 	hub       bool // repro came from hub
+	// Set if this was re-validation of a stored reproducer.
+	revalidate *repro.Result
+	validation *repro.Validation
 }
 
 // Manager needs to be refactored (#605).
@@ -304,6 +391,19 @@ func (mgr *Manager) vmLoop() {
 	reproDone := make(chan *ReproResult, 1)
 	stopPending := false
 	shutdown := vm.Shutdown
+	for _, crash := range mgr.staleRepros() {
+		log.Logf(1, "loop: add to repro queue for re-validation '%v'", crash.Title)
+		reproducing[crash.Title] = true
+		reproQueue = append(reproQueue, crash)
+	}
+	for _, cp := range repro.Checkpoints(mgr.cfg.Workdir) {
+		log.Logf(1, "loop: resuming interrupted repro of '%v'", cp.Title)
+		rep := mgr.reporter.Parse(cp.Log)
+		if rep == nil {
+			rep = &report.Report{Title: cp.Title, Output: cp.Log}
+		}
+		pendingRepro[&Crash{Report: rep}] = true
+	}
 	for shutdown != nil || len(instances) != vmCount {
 		mgr.mu.Lock()
 		phase := mgr.phase
@@ -342,8 +442,27 @@ func (mgr *Manager) vmLoop() {
 				reproInstances += instancesPerRepro
 				atomic.AddUint32(&mgr.numReproducing, 1)
 				log.Logf(1, "loop: starting repro of '%v' on instances %+v", crash.Title, vmIndexes)
+				if crash.revalidate != nil {
+					go func() {
+						v, _, err := repro.Validate(crash.revalidate, mgr.cfg, mgr.reporter, mgr.vmPool, vmIndexes)
+						reproDone <- &ReproResult{
+							instances:  vmIndexes,
+							report0:    crash.Report,
+							err:        err,
+							revalidate: crash.revalidate,
+							validation: v,
+						}
+					}()
+					continue
+				}
 				go func() {
+					span := mgr.tracer.Start("repro")
+					span.SetAttr("crash.title", crash.Title)
+					span.SetAttr("vm.count", len(vmIndexes))
 					res, stats, err := repro.Run(crash.Output, mgr.cfg, mgr.reporter, mgr.vmPool, vmIndexes)
+					span.SetAttr("reproduced", res != nil)
+					span.SetError(err)
+					span.End()
 					reproDone <- &ReproResult{
 						instances: vmIndexes,
 						report0:   crash.Report,
@@ -382,12 +501,19 @@ func (mgr *Manager) vmLoop() {
 				log.Logf(0, "%v", res.err)
 			}
 			stopPending = false
+			mgr.mu.Lock()
+			if res.crash != nil && vm.NoReport(res.crash.Title) {
+				mgr.lostCrashes[res.idx] = res.crash
+			} else {
+				delete(mgr.lostCrashes, res.idx)
+			}
+			mgr.mu.Unlock()
 			instances = append(instances, res.idx)
 			// On shutdown qemu crashes with "qemu: terminating on signal 2",
 			// which we detect as "lost connection". Don't save that as crash.
 			if shutdown != nil && res.crash != nil {
 				needRepro := mgr.saveCrash(res.crash)
-				if needRepro {
+				if needRepro && !res.crash.pstoreOnly {
 					log.Logf(1, "loop: add pending repro for '%v'", res.crash.Title)
 					pendingRepro[res.crash] = true
 				}
@@ -404,12 +530,22 @@ func (mgr *Manager) vmLoop() {
 				res.instances, res.report0.Title, res.res != nil, crepro, title)
 			if res.err != nil {
 				log.Logf(0, "repro failed: %v", res.err)
+				if res.revalidate == nil && repro.ReadCheckpoint(mgr.cfg.Workdir, res.report0.Title) != nil {
+					log.Logf(0, "repro of '%v' was interrupted, it will be resumed", res.report0.Title)
+				}
 			}
 			delete(reproducing, res.report0.Title)
 			instances = append(instances, res.instances...)
 			reproInstances -= instancesPerRepro
-			if res.res == nil {
-				if !res.hub {
+			if res.revalidate != nil {
+				if res.validation != nil {
+					log.Logf(0, "re-validated repro for '%v': crashed %v", res.report0.Title, res.validation)
+					dir := filepath.Join(mgr.crashdir, hash.String([]byte(res.report0.Title)))
+					mgr.saveReproValidation(dir, res.revalidate, res.validation)
+				}
+			} else if res.res == nil {
+				// Interrupted sessions are resumed later and are not failed attempts.
+				if !res.hub && res.err == nil {
 					mgr.saveFailedRepro(res.report0, res.stats)
 				}
 			} else {
@@ -437,6 +573,8 @@ func (mgr *Manager) vmLoop() {
 }
 
 func (mgr *Manager) loadCorpus() {
+	span := mgr.tracer.Start("corpus.load")
+	defer span.End()
 	// By default we don't re-minimize/re-smash programs from corpus,
 	// it takes lots of time on start and is unnecessary.
 	// However, on version bumps we can selectively re-minimize/re-smash.
@@ -460,6 +598,7 @@ func (mgr *Manager) loadCorpus() {
 	for _, id := range mgr.checkResult.EnabledCalls[mgr.cfg.Sandbox] {
 		syscalls[id] = true
 	}
+	used := make(map[int]bool)
 	deleted := 0
 	for key, rec := range mgr.corpusDB.Records {
 		p, err := mgr.target.Deserialize(rec.Val, prog.NonStrict)
@@ -485,6 +624,9 @@ func (mgr *Manager) loadCorpus() {
 			mgr.disabledHashes[hash.String(rec.Val)] = struct{}{}
 			continue
 		}
+		for _, c := range p.Calls {
+			used[c.Meta.ID] = true
+		}
 		mgr.candidates = append(mgr.candidates, rpctype.RPCCandidate{
 			Prog:      rec.Val,
 			Minimized: minimized,
@@ -493,6 +635,10 @@ func (mgr *Manager) loadCorpus() {
 	}
 	mgr.fresh = len(mgr.corpusDB.Records) == 0
 	log.Logf(0, "%-24v: %v (%v deleted)", "corpus", len(mgr.candidates), deleted)
+	span.SetAttr("corpus.candidates", len(mgr.candidates))
+	span.SetAttr("corpus.deleted", deleted)
+	span.SetAttr("corpus.disabled", len(mgr.disabledHashes))
+	mgr.initBackfill(syscalls, used)
 
 	// Now this is ugly.
 	// We duplicate all inputs in the corpus and shuffle the second part.
@@ -512,26 +658,69 @@ func (mgr *Manager) loadCorpus() {
 	mgr.phase = phaseLoadedCorpus
 }
 
-func (mgr *Manager) runInstance(index int) (*Crash, error) {
+func (mgr *Manager) runInstance(index int) (crash *Crash, err error) {
 	mgr.checkUsedFiles()
+	span := mgr.tracer.Start("vm.instance")
+	span.SetAttr("vm.index", index)
+	defer func() {
+		span.SetAttr("crashed", crash != nil)
+		if crash != nil {
+			span.SetAttr("crash.title", crash.Title)
+		}
+		span.SetError(err)
+		span.End()
+	}()
+	bootSpan := span.Child("vm.boot")
 	inst, err := mgr.vmPool.Create(index)
+	bootSpan.SetError(err)
+	bootSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create instance: %v", err)
 	}
 	defer inst.Close()
+	var anomalies *report.AnomalyDetector
+	if mgr.cfg.SuspiciousOutput {
+		anomalies = report.NewAnomalyDetector(mgr.reporter)
+		inst.DetectAnomalies(anomalies)
+	}
+	if mgr.cfg.VMHealth {
+		inst.MonitorHealth(vm.DefaultHealthConfig())
+	}
+	// The previous run on this machine could crash with dead consoles, report what was saved in pstore.
+	if output := inst.PstoreLog(); len(output) != 0 {
+		if crash := mgr.pstoreCrash(index, output); crash != nil {
+			return crash, nil
+		}
+	}
 
+	setupSpan := span.Child("vm.setup")
 	fwdAddr, err := inst.Forward(mgr.port)
 	if err != nil {
+		setupSpan.SetError(err)
+		setupSpan.End()
 		return nil, fmt.Errorf("failed to setup port forwarding: %v", err)
 	}
 	fuzzerBin, err := inst.Copy(mgr.cfg.SyzFuzzerBin)
 	if err != nil {
+		setupSpan.SetError(err)
+		setupSpan.End()
 		return nil, fmt.Errorf("failed to copy binary: %v", err)
 	}
 	executorBin, err := inst.Copy(mgr.cfg.SyzExecutorBin)
+	descriptionsFile := ""
+	if err == nil && mgr.cfg.ExtraDescriptionsFile != "" {
+		descriptionsFile, err = inst.Copy(mgr.cfg.ExtraDescriptionsFile)
+	}
+	setupSpan.SetError(err)
+	setupSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to copy binary: %v", err)
 	}
+	if mgr.cfg.CrashDiagnostics {
+		if err := inst.SetupDiagnostics(); err != nil {
+			log.Logf(1, "vm-%v: failed to setup diagnostics: %v", index, err)
+		}
+	}
 
 	fuzzerV := 0
 	procs := mgr.cfg.Procs
@@ -544,28 +733,87 @@ func (mgr *Manager) runInstance(index int) (*Crash, error) {
 	start := time.Now()
 	atomic.AddUint32(&mgr.numFuzzing, 1)
 	defer atomic.AddUint32(&mgr.numFuzzing, ^uint32(0))
-	cmd := instance.FuzzerCmd(fuzzerBin, executorBin, fmt.Sprintf("vm-%v", index),
-		mgr.cfg.TargetOS, mgr.cfg.TargetArch, fwdAddr, mgr.cfg.Sandbox, procs, fuzzerV,
+	cmd := instance.FuzzerCmd(fuzzerBin, executorBin, descriptionsFile, fmt.Sprintf("vm-%v", index),
+		mgr.cfg.TargetOS, mgr.cfg.TargetArch, fwdAddr, mgr.cfg.Sandbox, procs, fuzzerV, mgr.cfg.Slowdown,
 		mgr.cfg.Cover, *flagDebug, false, false)
 	outc, errc, err := inst.Run(time.Hour, mgr.vmStop, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to run fuzzer: %v", err)
 	}
 
+	fuzzSpan := span.Child("vm.fuzz")
 	rep := inst.MonitorExecution(outc, errc, mgr.reporter, vm.ExitTimeout)
+	fuzzSpan.End()
+	if anomalies != nil {
+		for _, anomaly := range anomalies.Anomalies() {
+			mgr.saveAnomaly(fmt.Sprintf("vm-%v", index), anomaly)
+		}
+	}
 	if rep == nil {
 		// This is the only "OK" outcome.
+		if reason := inst.RecycleReason(); reason != "" {
+			log.Logf(0, "vm-%v: running for %v, recycling degraded VM: %v", index, time.Since(start), reason)
+			mgr.stats.vmRecycled.inc()
+			mgr.stats.mergeNamed(map[string]uint64{"vm recycled: " + reason: 1})
+			return nil, nil
+		}
 		log.Logf(0, "vm-%v: running for %v, restarting", index, time.Since(start))
 		return nil, nil
 	}
-	crash := &Crash{
+	// The first report is not necessarily the most important one
+	// (e.g. a WARNING frequently precedes a use-after-free), so select the most severe one.
+	if reports := report.ParseAll(mgr.reporter, rep.Output); len(reports) > 1 && reports[0].Title == rep.Title {
+		rep = report.SelectPrimary(reports)
+	}
+	crash = &Crash{
 		vmIndex: index,
 		hub:     false,
 		Report:  rep,
 	}
+	// The dump needs to be moved out of the instance before it's closed.
+	dumpDir := filepath.Join(mgr.cfg.Workdir, "crashdump")
+	osutil.MkdirAll(dumpDir)
+	dump := filepath.Join(dumpDir, fmt.Sprintf("vm%v-%v", index, time.Now().UnixNano()))
+	if ok, err := inst.SaveCrashDump(dump); err != nil {
+		log.Logf(0, "vm-%v: %v", index, err)
+	} else if ok {
+		crash.dump = dump
+	}
+	if mgr.cfg.CrashDiagnostics {
+		// Fails quickly if the kernel has panicked, the memory info is in the log then.
+		diag, err := inst.CollectDiagnostics()
+		if err != nil {
+			log.Logf(1, "vm-%v: %v", index, err)
+		}
+		crash.diagnostics = diag
+	}
 	return crash, nil
 }
 
+// pstoreCrash parses kernel log of the previous boot recovered from pstore.
+// If the previous run on the machine ended with a crash without a report (e.g. lost connection),
+// the recovered report is attached to the log of that run, so that the crash can be reproduced.
+func (mgr *Manager) pstoreCrash(index int, output []byte) *Crash {
+	mgr.mu.Lock()
+	lost := mgr.lostCrashes[index]
+	delete(mgr.lostCrashes, index)
+	mgr.mu.Unlock()
+	if lost != nil {
+		output = append(append(append([]byte{}, lost.Output...),
+			"\n<<<<<<<<<<<<<<< recovered from pstore on the next boot >>>>>>>>>>>>>>>\n"...), output...)
+	}
+	rep := mgr.reporter.Parse(output)
+	if rep == nil {
+		return nil
+	}
+	log.Logf(0, "vm-%v: recovered crash of the previous boot from pstore: %v", index, rep.Title)
+	return &Crash{
+		vmIndex:    index,
+		pstoreOnly: lost == nil,
+		Report:     rep,
+	}
+}
+
 func (mgr *Manager) emailCrash(crash *Crash) {
 	if len(mgr.cfg.EmailAddrs) == 0 {
 		return
@@ -582,6 +830,10 @@ func (mgr *Manager) emailCrash(crash *Crash) {
 }
 
 func (mgr *Manager) saveCrash(crash *Crash) bool {
+	if crash.dump != "" {
+		// Removes the dump if it's not moved into the crash dir below.
+		defer os.RemoveAll(crash.dump)
+	}
 	if crash.Type == report.MemoryLeak {
 		mgr.mu.Lock()
 		mgr.memoryLeakFrames[crash.Frame] = true
@@ -600,10 +852,33 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	if err := mgr.reporter.Symbolize(crash.Report); err != nil {
 		log.Logf(0, "failed to symbolize report: %v", err)
 	}
+	for _, sec := range crash.Secondary {
+		if err := mgr.reporter.Symbolize(sec); err != nil {
+			log.Logf(0, "failed to symbolize report: %v", err)
+		}
+	}
+	fingerprint := ""
+	if !crash.Corrupted {
+		fingerprint = report.Fingerprint(crash.Report)
+	}
+	duplicateOf := ""
+	if fingerprint != "" {
+		mgr.mu.Lock()
+		if title := mgr.fingerprints[fingerprint]; title == "" {
+			mgr.fingerprints[fingerprint] = crash.Title
+		} else if title != crash.Title {
+			// The same bug with a different title (e.g. a renamed or an inlined function).
+			// The crash keeps its own title, the relation is recorded in the crash dir.
+			log.Logf(0, "vm-%v: crash %v is a duplicate of %v", crash.vmIndex, crash.Title, title)
+			duplicateOf = title
+		}
+		mgr.mu.Unlock()
+	}
 
 	mgr.stats.crashes.inc()
+	mgr.experiments.crash(fmt.Sprintf("vm-%v", crash.vmIndex), crash.Title)
 	mgr.mu.Lock()
-	if !mgr.crashTypes[crash.Title] {
+	if !mgr.crashTypes[crash.Title] && duplicateOf == "" {
 		mgr.crashTypes[crash.Title] = true
 		mgr.stats.crashTypes.inc()
 	}
@@ -618,13 +893,27 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 			Title:       crash.Title,
 			Corrupted:   crash.Corrupted,
 			Maintainers: crash.Maintainers,
+			Subsystems:  crash.Subsystems,
+			SubsystemCC: crash.SubsystemCC,
 			Log:         crash.Output,
 			Report:      crash.Report.Report,
+			Fingerprint: fingerprint,
+		}
+		if crash.dump != "" {
+			dc.Assets = mgr.uploadCrashDump(crash.dump)
+		}
+		if len(crash.diagnostics) != 0 {
+			if asset := mgr.uploadDiagnostics(crash.diagnostics); asset != nil {
+				dc.Assets = append(dc.Assets, *asset)
+			}
 		}
 		resp, err := mgr.dash.ReportCrash(dc)
 		if err != nil {
 			log.Logf(0, "failed to report crash to dashboard: %v", err)
 		} else {
+			if crash.dump != "" {
+				os.RemoveAll(crash.dump)
+			}
 			// Don't store the crash locally, if we've successfully
 			// uploaded it to the dashboard. These will just eat disk space.
 			return resp.NeedRepro
@@ -638,6 +927,17 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	if err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(crash.Title+"\n")); err != nil {
 		log.Logf(0, "failed to write crash: %v", err)
 	}
+	recordCrashHistory(dir, mgr.buildID(), time.Now())
+	recordCrashSandbox(dir, mgr.cfg.Sandbox)
+	if mgr.cfg.SandboxProfile != nil {
+		recordSandboxProfile(dir, mgr.cfg.SandboxProfile.Name)
+	}
+	if fingerprint != "" && !osutil.IsExist(filepath.Join(dir, "fingerprint")) {
+		osutil.WriteFile(filepath.Join(dir, "fingerprint"), []byte(fingerprint))
+	}
+	if duplicateOf != "" {
+		osutil.WriteFile(filepath.Join(dir, "duplicate_of"), []byte(duplicateOf+"\n"))
+	}
 	// Save up to 100 reports. If we already have 100, overwrite the oldest one.
 	// Newer reports are generally more useful. Overwriting is also needed
 	// to be able to understand if a particular bug still happens or already fixed.
@@ -663,11 +963,150 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	}
 	if len(crash.Report.Report) > 0 {
 		osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("report%v", oldestI)), crash.Report.Report)
+		if data, err := crash.Report.JSON(); err != nil {
+			log.Logf(0, "failed to serialize report: %v", err)
+		} else {
+			osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("structured%v", oldestI)), data)
+		}
+	}
+	if crash.dump != "" {
+		// Dumps are large, so keep only the latest one.
+		dumpFile := filepath.Join(dir, "dump")
+		os.RemoveAll(dumpFile)
+		if err := os.Rename(crash.dump, dumpFile); err != nil {
+			log.Logf(0, "failed to save crash dump: %v", err)
+		}
+	}
+	diagnosticsFile := filepath.Join(dir, fmt.Sprintf("diagnostics%v", oldestI))
+	if len(crash.diagnostics) != 0 {
+		osutil.WriteFile(diagnosticsFile, crash.diagnostics)
+	} else {
+		os.Remove(diagnosticsFile)
+	}
+	secondaryFile := filepath.Join(dir, fmt.Sprintf("secondary%v", oldestI))
+	if len(crash.Secondary) != 0 {
+		osutil.WriteFile(secondaryFile, crash.SecondaryText())
+	} else {
+		os.Remove(secondaryFile)
 	}
 
 	return mgr.needLocalRepro(crash)
 }
 
+// uploadCrashDump uploads files of the crash dump (see vm.Instance.SaveCrashDump) to the dashboard
+// asset storage, because the dashboard does not store large files itself.
+func (mgr *Manager) uploadCrashDump(dir string) []dashapi.Asset {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Logf(0, "failed to read crash dump: %v", err)
+		return nil
+	}
+	var assets []dashapi.Asset
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		asset, err := mgr.dash.UploadAsset(dashapi.AssetMemoryDump, filepath.Join(dir, file.Name()))
+		if err != nil {
+			log.Logf(0, "failed to upload crash dump: %v", err)
+			continue
+		}
+		assets = append(assets, *asset)
+	}
+	return assets
+}
+
+func (mgr *Manager) uploadDiagnostics(diag []byte) *dashapi.Asset {
+	f, err := osutil.TempFile("syz-diagnostics")
+	if err != nil {
+		log.Logf(0, "failed to upload diagnostics: %v", err)
+		return nil
+	}
+	defer os.Remove(f)
+	if err := osutil.WriteFile(f, diag); err != nil {
+		log.Logf(0, "failed to upload diagnostics: %v", err)
+		return nil
+	}
+	asset, err := mgr.dash.UploadAsset(dashapi.AssetDiagnostics, f)
+	if err != nil {
+		log.Logf(0, "failed to upload diagnostics: %v", err)
+		return nil
+	}
+	return asset
+}
+
+// saveAnomaly saves suspicious console output in workdir/suspicious.
+// These are not crashes, so they are not reported to the dashboard and are not reproduced.
+func (mgr *Manager) saveAnomaly(name string, anomaly *report.Anomaly) {
+	log.Logf(1, "%v: %v", name, anomaly.Title)
+	mgr.stats.suspicious.inc()
+	dir := filepath.Join(mgr.cfg.Workdir, "suspicious", hash.String([]byte(anomaly.Title)))
+	osutil.MkdirAll(dir)
+	if err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(anomaly.Title+"\n")); err != nil {
+		log.Logf(0, "failed to write suspicious output: %v", err)
+		return
+	}
+	// Save up to 10 samples, newer ones overwrite the oldest.
+	oldestI := 0
+	var oldestTime time.Time
+	for i := 0; i < 10; i++ {
+		info, err := os.Stat(filepath.Join(dir, fmt.Sprintf("log%v", i)))
+		if err != nil {
+			oldestI = i
+			break
+		}
+		if oldestTime.IsZero() || info.ModTime().Before(oldestTime) {
+			oldestI = i
+			oldestTime = info.ModTime()
+		}
+	}
+	osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("log%v", oldestI)), anomaly.Output)
+}
+
+// kernelMessages is called when a fuzzer reports non-fatal kernel messages (see kmsg_severity).
+// Crashes are detected in console output, so messages that look like crashes are ignored here.
+func (mgr *Manager) kernelMessages(name string, msgs []rpctype.KernelMessage) {
+	for _, msg := range msgs {
+		if mgr.reporter.ContainsCrash(msg.Text) {
+			continue
+		}
+		output := new(bytes.Buffer)
+		fmt.Fprintf(output, "%s\n", msg.Text)
+		for _, p := range msg.Progs {
+			fmt.Fprintf(output, "\nexecuting program:\n%s", p)
+		}
+		mgr.saveAnomaly(name, &report.Anomaly{
+			Title:  msg.Title,
+			Output: output.Bytes(),
+		})
+	}
+}
+
+// loadFingerprints reads fingerprints of crashes saved in crashdir by previous runs.
+func loadFingerprints(crashdir string) map[string]string {
+	fingerprints := make(map[string]string)
+	dirs, err := osutil.ListDir(crashdir)
+	if err != nil {
+		return fingerprints
+	}
+	for _, dir := range dirs {
+		if osutil.IsExist(filepath.Join(crashdir, dir, "duplicate_of")) {
+			// Duplicates share the fingerprint with the first crash, which owns it.
+			continue
+		}
+		fingerprint, err := ioutil.ReadFile(filepath.Join(crashdir, dir, "fingerprint"))
+		if err != nil {
+			continue
+		}
+		desc, err := ioutil.ReadFile(filepath.Join(crashdir, dir, "description"))
+		if err != nil {
+			continue
+		}
+		fingerprints[string(fingerprint)] = strings.TrimSpace(string(desc))
+	}
+	return fingerprints
+}
+
 const maxReproAttempts = 3
 
 func (mgr *Manager) needLocalRepro(crash *Crash) bool {
@@ -748,7 +1187,11 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 		log.Logf(0, "failed to symbolize repro: %v", err)
 	}
 	opts := fmt.Sprintf("# %+v\n", res.Opts)
-	prog := res.Prog.Serialize()
+	if res.Strategy != "" {
+		opts += fmt.Sprintf("# strategy: %v\n", res.Strategy)
+	}
+	opts += res.LeakComment("# ")
+	annotated, prog := res.Annotated()
 
 	// Append this repro to repro list to send to hub if it didn't come from hub originally.
 	if !hub {
@@ -761,12 +1204,17 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 
 	var cprogText []byte
 	if res.CRepro {
-		cprog, err := csource.Write(res.Prog, res.Opts)
+		cprog, err := csource.Write(annotated, res.Opts)
 		if err == nil {
 			formatted, err := csource.Format(cprog)
 			if err == nil {
 				cprog = formatted
 			}
+			if comment := res.LeakComment("// "); comment != "" {
+				// Keep the "autogenerated by syzkaller" line first.
+				pos := bytes.IndexByte(cprog, '\n') + 1
+				cprog = append(cprog[:pos:pos], append([]byte(comment), cprog[pos:]...)...)
+			}
 			cprogText = cprog
 		} else {
 			log.Logf(0, "failed to write C source: %v", err)
@@ -783,10 +1231,12 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 			BuildID:     mgr.cfg.Tag,
 			Title:       res.Report.Title,
 			Maintainers: res.Report.Maintainers,
+			Subsystems:  res.Report.Subsystems,
+			SubsystemCC: res.Report.SubsystemCC,
 			Log:         res.Report.Output,
 			Report:      res.Report.Report,
 			ReproOpts:   res.Opts.Serialize(),
-			ReproSyz:    res.Prog.Serialize(),
+			ReproSyz:    prog,
 			ReproC:      cprogText,
 		}
 		if _, err := mgr.dash.ReportCrash(dc); err != nil {
@@ -805,6 +1255,7 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 		log.Logf(0, "failed to write crash: %v", err)
 	}
 	osutil.WriteFile(filepath.Join(dir, "repro.prog"), append([]byte(opts), prog...))
+	recordCrashSandbox(dir, res.Opts.Sandbox)
 	if len(mgr.cfg.Tag) > 0 {
 		osutil.WriteFile(filepath.Join(dir, "repro.tag"), []byte(mgr.cfg.Tag))
 	}
@@ -814,6 +1265,9 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 	if len(rep.Report) > 0 {
 		osutil.WriteFile(filepath.Join(dir, "repro.report"), rep.Report)
 	}
+	if res.Validation != nil {
+		mgr.saveReproValidation(dir, res, res.Validation)
+	}
 	if len(cprogText) > 0 {
 		osutil.WriteFile(filepath.Join(dir, "repro.cprog"), cprogText)
 	}
@@ -823,10 +1277,12 @@ func (mgr *Manager) saveRepro(res *repro.Result, stats *repro.Stats, hub bool) {
 func saveReproStats(filename string, stats *repro.Stats) {
 	text := ""
 	if stats != nil {
-		text = fmt.Sprintf("Extracting prog: %v\nMinimizing prog: %v\n"+
-			"Simplifying prog options: %v\nExtracting C: %v\nSimplifying C: %v\n\n\n%s",
-			stats.ExtractProgTime, stats.MinimizeProgTime,
-			stats.SimplifyProgTime, stats.ExtractCTime, stats.SimplifyCTime, stats.Log)
+		text = fmt.Sprintf("Extracting prog: %v\nSearching options: %v\nMinimizing prog: %v\n"+
+			"Simplifying prog options: %v\nExtracting C: %v\nSimplifying C: %v\n"+
+			"Finding triggering call: %v\nValidating: %v\n\n\n%s",
+			stats.ExtractProgTime, stats.SearchOptsTime, stats.MinimizeProgTime,
+			stats.SimplifyProgTime, stats.ExtractCTime, stats.SimplifyCTime,
+			stats.TriggerCallTime, stats.ValidateTime, stats.Log)
 	}
 	osutil.WriteFile(filename, []byte(text))
 }
@@ -895,7 +1351,7 @@ func (mgr *Manager) minimizeCorpus() {
 	mgr.corpusDB.BumpVersion(currentDBVersion)
 }
 
-func (mgr *Manager) fuzzerConnect() ([]rpctype.RPCInput, []string) {
+func (mgr *Manager) fuzzerConnect() ([]rpctype.RPCInput, []string, []int) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
@@ -908,7 +1364,7 @@ func (mgr *Manager) fuzzerConnect() ([]rpctype.RPCInput, []string) {
 	for frame := range mgr.memoryLeakFrames {
 		memoryLeakFrames = append(memoryLeakFrames, frame)
 	}
-	return corpus, memoryLeakFrames
+	return corpus, memoryLeakFrames, mgr.backfillList()
 }
 
 func (mgr *Manager) machineChecked(a *rpctype.CheckArgs) {
@@ -926,6 +1382,9 @@ func (mgr *Manager) machineChecked(a *rpctype.CheckArgs) {
 			}
 		}
 	}
+	for _, dc := range a.DisabledCalls[mgr.cfg.Sandbox] {
+		mgr.disabledSyscalls[mgr.target.Syscalls[dc.ID].Name] = dc.Reason
+	}
 	if a.Error != "" {
 		log.Fatalf("machine check: %v", a.Error)
 	}
@@ -940,11 +1399,49 @@ func (mgr *Manager) machineChecked(a *rpctype.CheckArgs) {
 	mgr.firstConnect = time.Now()
 }
 
-func (mgr *Manager) newInput(inp rpctype.RPCInput, sign signal.Signal) {
+// callsDisabled is called when a fuzzer disables syscalls that turned out to not work during fuzzing.
+func (mgr *Manager) callsDisabled(name string, calls []rpctype.SyscallReason) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, dc := range calls {
+		call := mgr.target.Syscalls[dc.ID].Name
+		if _, ok := mgr.disabledSyscalls[call]; !ok {
+			log.Logf(0, "%v: disabled %v: %v", name, call, dc.Reason)
+			mgr.disabledSyscalls[call] = dc.Reason
+		}
+	}
+}
+
+func (mgr *Manager) callsUnsupported(name string, calls []rpctype.SyscallReason) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.unsupportedSyscalls == nil {
+		mgr.unsupportedSyscalls = make(map[string]string)
+	}
+	for _, uc := range calls {
+		call := mgr.target.Syscalls[uc.ID].Name
+		if _, ok := mgr.unsupportedSyscalls[call]; !ok {
+			log.Logf(0, "%v: %v always fails: %v", name, call, uc.Reason)
+			mgr.unsupportedSyscalls[call] = uc.Reason
+		}
+	}
+}
+
+func (mgr *Manager) newInput(inp rpctype.RPCInput, sign signal.Signal, unminimized bool, candidate string) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 	sig := hash.String(inp.Prog)
+	span := mgr.tracer.Start("corpus.admit")
+	span.SetAttr("call", inp.Call)
+	span.SetAttr("signal", sign.Len())
+	defer span.End()
+	if triageSpan := mgr.triageSpans[candidate]; triageSpan != nil {
+		triageSpan.SetAttr("admitted", true)
+		triageSpan.End()
+		delete(mgr.triageSpans, candidate)
+	}
 	if old, ok := mgr.corpus[sig]; ok {
+		span.SetAttr("new", false)
 		// The input is already present, but possibly with diffent signal/coverage/call.
 		sign.Merge(old.Signal.Deserialize())
 		old.Signal = sign.Serialize()
@@ -954,10 +1451,22 @@ func (mgr *Manager) newInput(inp rpctype.RPCInput, sign signal.Signal) {
 		old.Cover = cov.Serialize()
 		mgr.corpus[sig] = old
 	} else {
+		span.SetAttr("new", true)
 		mgr.corpus[sig] = inp
-		mgr.corpusDB.Save(sig, inp.Prog, 0)
+		if len(mgr.backfillCalls) != 0 {
+			mgr.backfillDone(inp.Prog)
+		}
+		seq := uint64(minimizeVersion)
+		if rec, ok := mgr.corpusDB.Records[sig]; ok {
+			// Inputs from the persistent corpus are not re-minimized on triage.
+			seq = rec.Seq
+		} else if unminimized {
+			seq = 0
+		}
+		mgr.corpusDB.Save(sig, inp.Prog, seq)
 		if err := mgr.corpusDB.Flush(); err != nil {
 			log.Logf(0, "failed to save corpus database: %v", err)
+			span.SetError(err)
 		}
 	}
 }
@@ -972,6 +1481,9 @@ func (mgr *Manager) candidateBatch(size int) []rpctype.RPCCandidate {
 		mgr.candidates[last] = rpctype.RPCCandidate{}
 		mgr.candidates = mgr.candidates[:last]
 	}
+	if mgr.tracer != nil {
+		mgr.traceCandidates(res)
+	}
 	if len(mgr.candidates) == 0 {
 		mgr.candidates = nil
 		if mgr.phase == phaseLoadedCorpus {
@@ -988,6 +1500,37 @@ func (mgr *Manager) candidateBatch(size int) []rpctype.RPCCandidate {
 	return res
 }
 
+// triageSpanTimeout is how long we wait for a candidate to come back as a new input
+// before we consider it dropped by triage.
+const triageSpanTimeout = time.Hour
+
+func (mgr *Manager) traceCandidates(candidates []rpctype.RPCCandidate) {
+	for _, cand := range candidates {
+		sig := hash.String(cand.Prog)
+		if mgr.triageSpans[sig] != nil {
+			// Corpus candidates are queued twice, trace only the first attempt.
+			continue
+		}
+		span := mgr.tracer.Start("triage.candidate")
+		span.SetAttr("minimized", cand.Minimized)
+		span.SetAttr("smashed", cand.Smashed)
+		span.SetAttr("queue", len(mgr.candidates))
+		mgr.triageSpans[sig] = span
+	}
+}
+
+// expireTriageSpans ends spans of candidates that did not come back within triageSpanTimeout
+// (e.g. they did not give new signal, or the VM crashed during triage).
+func (mgr *Manager) expireTriageSpans() {
+	for sig, span := range mgr.triageSpans {
+		if time.Since(span.StartTime()) > triageSpanTimeout {
+			span.SetAttr("admitted", false)
+			span.End()
+			delete(mgr.triageSpans, sig)
+		}
+	}
+}
+
 func (mgr *Manager) collectUsedFiles() {
 	if mgr.vmPool == nil {
 		return
@@ -0,0 +1,156 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/syzkaller/pkg/html"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Fuzzer profiles are requested from the web UI, handed out to the next polling fuzzer
+// (or to the given one), and sent back with Manager.Profile. They are stored in workdir/profiles
+// and can be analyzed with go tool pprof (the syz-fuzzer binary is in bin/OS_ARCH).
+
+const profilesDir = "profiles"
+
+var profileKinds = []string{"cpu", "heap", "goroutine"}
+
+type profileState struct {
+	req    *rpctype.ProfileRequest // pending request
+	fuzzer string                  // fuzzer the pending request is for (any if empty)
+	status string
+}
+
+func (mgr *Manager) profileRequest(name string) *rpctype.ProfileRequest {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	req := mgr.profile.req
+	if req == nil || mgr.profile.fuzzer != "" && mgr.profile.fuzzer != name {
+		return nil
+	}
+	mgr.profile.req = nil
+	mgr.profile.status = fmt.Sprintf("%v profile requested from %v at %v",
+		req.Kind, name, time.Now().Format("15:04:05"))
+	return req
+}
+
+func (mgr *Manager) profileReceived(a *rpctype.ProfileArgs) {
+	status := ""
+	if a.Error != "" {
+		status = fmt.Sprintf("%v failed to collect %v profile: %v", a.Name, a.Kind, a.Error)
+	} else {
+		dir := filepath.Join(mgr.cfg.Workdir, profilesDir)
+		file := fmt.Sprintf("%v-%v-%v.pprof", a.Name, a.Kind, time.Now().Format("20060102-150405"))
+		if err := osutil.MkdirAll(dir); err != nil {
+			status = fmt.Sprintf("failed to create profiles dir: %v", err)
+		} else if err := osutil.WriteFile(filepath.Join(dir, file), a.Data); err != nil {
+			status = fmt.Sprintf("failed to write profile: %v", err)
+		} else {
+			status = fmt.Sprintf("received %v (%v bytes)", file, len(a.Data))
+		}
+	}
+	log.Logf(0, "%v", status)
+	mgr.mu.Lock()
+	mgr.profile.status = status
+	mgr.mu.Unlock()
+}
+
+var fuzzerNameRe = regexp.MustCompile(`^[a-zA-Z0-9_\-]*$`)
+
+func (mgr *Manager) httpProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		req := &rpctype.ProfileRequest{Kind: r.FormValue("kind")}
+		known := false
+		for _, kind := range profileKinds {
+			known = known || kind == req.Kind
+		}
+		seconds, err := strconv.Atoi(r.FormValue("seconds"))
+		if !known || req.Kind == "cpu" && (err != nil || seconds <= 0 || seconds > 600) {
+			http.Error(w, "bad profile request", http.StatusBadRequest)
+			return
+		}
+		req.Duration = time.Duration(seconds) * time.Second
+		fuzzer := r.FormValue("fuzzer")
+		if !fuzzerNameRe.MatchString(fuzzer) {
+			http.Error(w, "bad fuzzer name", http.StatusBadRequest)
+			return
+		}
+		mgr.mu.Lock()
+		mgr.profile.req = req
+		mgr.profile.fuzzer = fuzzer
+		if fuzzer == "" {
+			fuzzer = "any fuzzer"
+		}
+		mgr.profile.status = fmt.Sprintf("waiting for %v to collect %v profile", fuzzer, req.Kind)
+		mgr.mu.Unlock()
+		http.Redirect(w, r, "/profile", http.StatusFound)
+		return
+	}
+	data := &UIProfileData{
+		Name:  mgr.cfg.Name,
+		Kinds: profileKinds,
+	}
+	mgr.mu.Lock()
+	data.Status = mgr.profile.status
+	mgr.mu.Unlock()
+	files, _ := osutil.ListDir(filepath.Join(mgr.cfg.Workdir, profilesDir))
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	for _, file := range files {
+		data.Files = append(data.Files, filepath.Join(profilesDir, file))
+	}
+	if err := profileTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
+type UIProfileData struct {
+	Name   string
+	Kinds  []string
+	Status string
+	Files  []string
+}
+
+var profileTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name}} fuzzer profiles</title>
+	{{HEAD}}
+</head>
+<body>
+<b>{{.Name}} fuzzer profiles</b>
+<br>
+<form action="/profile" method="post">
+	Profile:
+	<select name="kind">
+		{{range $k := $.Kinds}}<option value="{{$k}}">{{$k}}</option>{{end}}
+	</select>
+	CPU profile duration (seconds): <input type="text" name="seconds" value="30" size="4">
+	Fuzzer (any if empty): <input type="text" name="fuzzer" value="">
+	<input type="submit" value="Request">
+</form>
+{{if .Status}}<br>Status: {{.Status}}<br>{{end}}
+<br>
+Heap profiles are not collected by default, the first heap profile request enables them.
+Analyze profiles with <code>go tool pprof bin/OS_ARCH/syz-fuzzer PROFILE</code>.
+<table class="list_table">
+	<caption>Profiles:</caption>
+	{{range $f := $.Files}}
+	<tr><td><a href="/file?name={{$f}}">{{$f}}</a></td></tr>
+	{{end}}
+</table>
+</body></html>
+`)
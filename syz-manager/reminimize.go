@@ -0,0 +1,106 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Corpus inputs are re-minimized in the background if they were added before
+// the last improvement of program minimization, or were added without minimization.
+// Corpus database record Seq holds the minimization version of the input (0 means unminimized).
+// Bump minimizeVersion when prog.Minimize gets better to re-minimize the existing corpus.
+const minimizeVersion = 1
+
+const (
+	// The job runs once a day at this hour (local time), when nobody looks at the manager.
+	reminimizeHour = 3
+	// Max number of inputs re-minimized per night.
+	reminimizeBudget = 1000
+)
+
+func (mgr *Manager) reminimizeLoop() {
+	var lastRun time.Time
+	for range time.NewTicker(10 * time.Minute).C {
+		now := time.Now()
+		if now.Hour() != reminimizeHour || now.Sub(lastRun) < 12*time.Hour {
+			continue
+		}
+		lastRun = now
+		mgr.scheduleReminimization()
+	}
+}
+
+func (mgr *Manager) scheduleReminimization() {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	// Don't compete with triage of the persistent corpus and hub inputs.
+	if mgr.phase < phaseTriagedHub {
+		return
+	}
+	var inputs []rpctype.RPCInput
+	for sig, inp := range mgr.corpus {
+		if rec, ok := mgr.corpusDB.Records[sig]; ok && rec.Seq < minimizeVersion {
+			inputs = append(inputs, inp)
+		}
+	}
+	// Start with the longest programs, they give the most savings.
+	sort.Slice(inputs, func(i, j int) bool {
+		return bytes.Count(inputs[i].Prog, []byte{'\n'}) > bytes.Count(inputs[j].Prog, []byte{'\n'})
+	})
+	if len(inputs) > reminimizeBudget {
+		inputs = inputs[:reminimizeBudget]
+	}
+	log.Logf(0, "scheduled re-minimization of %v corpus inputs (%v left in the previous run)",
+		len(inputs), len(mgr.reminimize))
+	mgr.reminimize = inputs
+}
+
+func (mgr *Manager) reminimizeBatch(size int) []rpctype.RPCInput {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	var res []rpctype.RPCInput
+	for i := 0; i < size && len(mgr.reminimize) > 0; i++ {
+		last := len(mgr.reminimize) - 1
+		res = append(res, mgr.reminimize[last])
+		mgr.reminimize[last] = rpctype.RPCInput{}
+		mgr.reminimize = mgr.reminimize[:last]
+	}
+	if len(mgr.reminimize) == 0 {
+		mgr.reminimize = nil
+	}
+	return res
+}
+
+// inputReminimized replaces the corpus input orig with its re-minimized version inp.
+func (mgr *Manager) inputReminimized(orig string, inp rpctype.RPCInput) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	old, ok := mgr.corpus[orig]
+	if !ok {
+		// Dropped by corpus minimization in the meantime.
+		return
+	}
+	sig := hash.String(inp.Prog)
+	if sig != orig {
+		log.Logf(1, "re-minimized corpus input %v: %v -> %v calls", orig,
+			bytes.Count(old.Prog, []byte{'\n'}), bytes.Count(inp.Prog, []byte{'\n'}))
+		delete(mgr.corpus, orig)
+		mgr.corpusDB.Delete(orig)
+		if _, ok := mgr.corpus[sig]; !ok {
+			mgr.corpus[sig] = inp
+		}
+	}
+	mgr.corpusDB.Save(sig, inp.Prog, minimizeVersion)
+	if err := mgr.corpusDB.Flush(); err != nil {
+		log.Logf(0, "failed to save corpus database: %v", err)
+	}
+	mgr.stats.reminimized.inc()
+}
@@ -8,9 +8,12 @@ import (
 	"sync"
 
 	"github.com/google/syzkaller/pkg/cover"
+	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/tracing"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys"
 )
@@ -19,7 +22,16 @@ type RPCServer struct {
 	mgr             RPCManagerView
 	target          *prog.Target
 	enabledSyscalls []int
+	sandboxProfile  *mgrconfig.SandboxProfile
+	sandboxIdentity *ipc.SandboxIdentity
+	experiments     *experiments
+	netRecycle      bool
+	sideEffects     bool // detect_side_effects is enabled
+	kmsgSeverity    string
+	netDevices      ipc.NetDevices
+	resetLevels     map[string]ipc.ResetLevel
 	stats           *Stats
+	tracer          *tracing.Tracer
 	batchSize       int
 
 	mu           sync.Mutex
@@ -38,10 +50,19 @@ type Fuzzer struct {
 
 // RPCManagerView restricts interface between RPCServer and Manager.
 type RPCManagerView interface {
-	fuzzerConnect() ([]rpctype.RPCInput, []string)
+	fuzzerConnect() ([]rpctype.RPCInput, []string, []int)
 	machineChecked(result *rpctype.CheckArgs)
-	newInput(inp rpctype.RPCInput, sign signal.Signal)
+	callsDisabled(name string, calls []rpctype.SyscallReason)
+	callsUnsupported(name string, calls []rpctype.SyscallReason)
+	knownSideEffects() map[string]ipc.SideEffects
+	callsSideEffects(name string, effects map[string]ipc.SideEffects)
+	kernelMessages(name string, msgs []rpctype.KernelMessage)
+	profileRequest(name string) *rpctype.ProfileRequest
+	profileReceived(a *rpctype.ProfileArgs)
+	newInput(inp rpctype.RPCInput, sign signal.Signal, unminimized bool, candidate string)
 	candidateBatch(size int) []rpctype.RPCCandidate
+	reminimizeBatch(size int) []rpctype.RPCInput
+	inputReminimized(orig string, inp rpctype.RPCInput)
 }
 
 func startRPCServer(mgr *Manager) (int, error) {
@@ -49,9 +70,32 @@ func startRPCServer(mgr *Manager) (int, error) {
 		mgr:             mgr,
 		target:          mgr.target,
 		enabledSyscalls: mgr.enabledSyscalls,
+		sandboxProfile:  mgr.cfg.SandboxProfile,
+		experiments:     mgr.experiments,
+		netRecycle:      mgr.cfg.NetRecycle,
+		sideEffects:     mgr.cfg.DetectSideEffects,
+		kmsgSeverity:    mgr.cfg.KmsgSeverity,
 		stats:           mgr.stats,
+		tracer:          mgr.tracer,
 		fuzzers:         make(map[string]*Fuzzer),
 	}
+	// Checked by mgrconfig.
+	serv.netDevices, _ = ipc.ParseNetDevices(mgr.cfg.NetDevices)
+	if id := mgr.cfg.SandboxIdentity; id != nil {
+		serv.sandboxIdentity = &ipc.SandboxIdentity{
+			UID:        uint32(id.UID),
+			GID:        uint32(id.GID),
+			NoNewPrivs: id.NoNewPrivs,
+			CapBound:   ^uint64(0),
+		}
+		for _, group := range id.Groups {
+			serv.sandboxIdentity.Groups = append(serv.sandboxIdentity.Groups, uint32(group))
+		}
+		if id.CapBound != nil {
+			serv.sandboxIdentity.CapBound, _ = ipc.ParseCapabilities(id.CapBound)
+		}
+	}
+	serv.resetLevels, _ = ipc.ParseResetLevels(mgr.cfg.ResetLevels)
 	serv.batchSize = 5
 	if serv.batchSize < mgr.cfg.Procs {
 		serv.batchSize = mgr.cfg.Procs
@@ -68,9 +112,13 @@ func startRPCServer(mgr *Manager) (int, error) {
 
 func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) error {
 	log.Logf(1, "fuzzer %v connected", a.Name)
+	span := serv.tracer.Start("rpc.Connect")
+	span.SetAttr("fuzzer", a.Name)
+	defer span.End()
 	serv.stats.vmRestarts.inc()
 
-	corpus, memoryLeakFrames := serv.mgr.fuzzerConnect()
+	corpus, memoryLeakFrames, backfillCalls := serv.mgr.fuzzerConnect()
+	sideEffects := serv.mgr.knownSideEffects()
 
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
@@ -81,14 +129,35 @@ func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) er
 		newMaxSignal: serv.maxSignal.Copy(),
 	}
 	r.MemoryLeakFrames = memoryLeakFrames
+	r.BackfillCalls = backfillCalls
+	r.Experiments = serv.experiments.connect(a.Name)
+	r.NetRecycle = serv.netRecycle
+	r.NetDevices = serv.netDevices
+	r.ResetLevels = serv.resetLevels
+	r.DetectSideEffects = serv.sideEffects
+	r.SideEffects = sideEffects
+	r.KmsgSeverity = serv.kmsgSeverity
 	r.EnabledCalls = serv.enabledSyscalls
 	r.CheckResult = serv.checkResult
 	r.GitRevision = sys.GitRevision
 	r.TargetRevision = serv.target.Revision
+	if p := serv.sandboxProfile; p != nil {
+		r.SandboxProfile = &ipc.SandboxProfile{
+			Seccomp:  p.SeccompFilter,
+			SELinux:  p.SELinux,
+			AppArmor: p.AppArmor,
+		}
+		r.SandboxProfileName = p.Name
+	}
+	r.SandboxIdentity = serv.sandboxIdentity
+	span.SetAttr("corpus", len(corpus))
 	return nil
 }
 
 func (serv *RPCServer) Check(a *rpctype.CheckArgs, r *int) error {
+	span := serv.tracer.Start("rpc.Check")
+	span.SetAttr("fuzzer", a.Name)
+	defer span.End()
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
 
@@ -105,18 +174,32 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 	inputSignal := a.Signal.Deserialize()
 	log.Logf(4, "new input from %v for syscall %v (signal=%v, cover=%v)",
 		a.Name, a.Call, inputSignal.Len(), len(a.Cover))
+	span := serv.tracer.Start("rpc.NewInput")
+	span.SetAttr("fuzzer", a.Name)
+	span.SetAttr("call", a.Call)
+	defer span.End()
 	if _, err := serv.target.Deserialize(a.RPCInput.Prog, prog.NonStrict); err != nil {
 		// This should not happen, but we see such cases episodically, reason unknown.
 		log.Logf(0, "failed to deserialize program from fuzzer: %v\n%s", err, a.RPCInput.Prog)
+		span.SetError(err)
+		return nil
+	}
+	if a.ReminimizedFrom != "" {
+		// Re-minimized inputs don't give new signal, they replace the original input.
+		serv.mgr.inputReminimized(a.ReminimizedFrom, a.RPCInput)
 		return nil
 	}
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
 
-	if serv.corpusSignal.Diff(inputSignal).Empty() {
+	newSignal := serv.corpusSignal.Diff(inputSignal)
+	if newSignal.Empty() {
+		span.SetAttr("new_signal", false)
 		return nil
 	}
-	serv.mgr.newInput(a.RPCInput, inputSignal)
+	span.SetAttr("new_signal", true)
+	serv.mgr.newInput(a.RPCInput, inputSignal, a.Unminimized, a.Candidate)
+	serv.experiments.newInput(a.Name, newSignal.Len())
 
 	serv.stats.newInputs.inc()
 	serv.corpusSignal.Merge(inputSignal)
@@ -135,7 +218,23 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 }
 
 func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
+	span := serv.tracer.Start("rpc.Poll")
+	span.SetAttr("fuzzer", a.Name)
+	defer span.End()
 	serv.stats.mergeNamed(a.Stats)
+	serv.experiments.poll(a.Name, a.Stats)
+	if len(a.DisabledCalls) != 0 {
+		serv.mgr.callsDisabled(a.Name, a.DisabledCalls)
+	}
+	if len(a.Unsupported) != 0 {
+		serv.mgr.callsUnsupported(a.Name, a.Unsupported)
+	}
+	if len(a.SideEffects) != 0 {
+		serv.mgr.callsSideEffects(a.Name, a.SideEffects)
+	}
+	if len(a.KernelMessages) != 0 {
+		serv.mgr.kernelMessages(a.Name, a.KernelMessages)
+	}
 
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
@@ -177,8 +276,24 @@ func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 		if len(f.inputs) == 0 {
 			f.inputs = nil
 		}
+		if !a.NeedCandidates {
+			// Re-minimization is a low-priority background work,
+			// hand it out slowly to fuzzers that are not busy with triage.
+			r.Reminimize = serv.mgr.reminimizeBatch(1)
+		}
 	}
-	log.Logf(4, "poll from %v: candidates=%v inputs=%v maxsignal=%v",
-		a.Name, len(r.Candidates), len(r.NewInputs), len(r.MaxSignal.Elems))
+	r.Profile = serv.mgr.profileRequest(a.Name)
+	log.Logf(4, "poll from %v: candidates=%v inputs=%v maxsignal=%v reminimize=%v",
+		a.Name, len(r.Candidates), len(r.NewInputs), len(r.MaxSignal.Elems), len(r.Reminimize))
+	span.SetAttr("candidates", len(r.Candidates))
+	span.SetAttr("new_inputs", len(r.NewInputs))
+	return nil
+}
+
+func (serv *RPCServer) Profile(a *rpctype.ProfileArgs, r *int) error {
+	span := serv.tracer.Start("rpc.Profile")
+	span.SetAttr("fuzzer", a.Name)
+	defer span.End()
+	serv.mgr.profileReceived(a)
 	return nil
 }
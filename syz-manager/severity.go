@@ -0,0 +1,178 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// severityClass describes a class of crashes recognized by title.
+// Classes are matched in order, the first matching one wins.
+type severityClass struct {
+	re    *regexp.Regexp
+	score int
+	tag   string
+}
+
+var severityClasses = []severityClass{
+	{regexp.MustCompile(`use-after-free Write|double-free|invalid-free`), 90, "uaf-write"},
+	{regexp.MustCompile(`out-of-bounds Write`), 85, "oob-write"},
+	{regexp.MustCompile(`use-after-free Read`), 70, "uaf-read"},
+	{regexp.MustCompile(`out-of-bounds Read`), 65, "oob-read"},
+	{regexp.MustCompile(`^KASAN:|^KFENCE:`), 60, "memory-safety"},
+	{regexp.MustCompile(`general protection fault|unable to handle kernel paging request`), 55, "bad-access"},
+	{regexp.MustCompile(`NULL pointer dereference`), 45, "null-deref"},
+	{regexp.MustCompile(`^KMSAN:`), 45, "uninit"},
+	{regexp.MustCompile(`^kernel BUG|^BUG:`), 40, "bug"},
+	{regexp.MustCompile(`^KCSAN:|^ThreadSanitizer:`), 30, "data-race"},
+	{regexp.MustCompile(`^UBSAN:`), 30, "ubsan"},
+	{regexp.MustCompile(`^WARNING|^WARN`), 20, "warning"},
+	{regexp.MustCompile(`^INFO: task hung|^INFO: rcu detected stall|soft lockup`), 15, "hang"},
+	{regexp.MustCompile(`^memory leak`), 10, "leak"},
+	{regexp.MustCompile(`^no output|^lost connection|^unexpected kernel reboot|^SYZFAIL|^SYZFATAL`), 5, "infra"},
+}
+
+const (
+	defaultSeverityScore   = 25
+	unprivilegedScoreBonus = 10
+	reproScoreBonus        = 10
+	cReproScoreBonus       = 5
+)
+
+// crashSeverity heuristically estimates how severe a crash is based on its title,
+// the sandboxes it was found under (see recordCrashSandbox) and its reproducibility.
+// Returns a score in [0, 100] (higher is more severe) and a set of tags describing the crash.
+func crashSeverity(title string, sandboxes []string, hasRepro, hasCRepro bool) (int, []string) {
+	score := defaultSeverityScore
+	var tags []string
+	for _, class := range severityClasses {
+		if class.re.MatchString(title) {
+			score = class.score
+			tags = append(tags, class.tag)
+			break
+		}
+	}
+	for _, sandbox := range sandboxes {
+		if unprivilegedSandbox(sandbox) {
+			score += unprivilegedScoreBonus
+			tags = append(tags, "unprivileged")
+			break
+		}
+	}
+	if hasRepro {
+		score += reproScoreBonus
+		tags = append(tags, "repro")
+	}
+	if hasCRepro {
+		score += cReproScoreBonus
+		tags = append(tags, "c-repro")
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, tags
+}
+
+func unprivilegedSandbox(sandbox string) bool {
+	switch sandbox {
+	case "setuid", "namespace", "android_untrusted_app":
+		return true
+	}
+	return false
+}
+
+const (
+	manualTagsFile      = "tags"
+	sandboxProfilesFile = "sandbox_profiles"
+	crashSandboxesFile  = "sandboxes"
+)
+
+// readManualTags returns tags that were assigned to the crash by a user.
+func readManualTags(crashdir string) []string {
+	data, err := ioutil.ReadFile(filepath.Join(crashdir, manualTagsFile))
+	if err != nil {
+		return nil
+	}
+	return parseTags(string(data))
+}
+
+func writeManualTags(crashdir string, tags []string) error {
+	if len(tags) == 0 {
+		return osutil.WriteFile(filepath.Join(crashdir, manualTagsFile), nil)
+	}
+	return osutil.WriteFile(filepath.Join(crashdir, manualTagsFile),
+		[]byte(strings.Join(tags, "\n")+"\n"))
+}
+
+// parseTags splits a comma/whitespace-separated list of tags,
+// drops duplicates and returns the tags sorted.
+func parseTags(str string) []string {
+	dedup := make(map[string]bool)
+	var tags []string
+	for _, tag := range strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		tag = strings.ToLower(tag)
+		if dedup[tag] {
+			continue
+		}
+		dedup[tag] = true
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// recordSandboxProfile remembers that the crash is reachable under the sandbox profile
+// (see mgrconfig.SandboxProfile). Such crashes get "profile:NAME" tags.
+func recordSandboxProfile(crashdir, name string) {
+	recordUnique(filepath.Join(crashdir, sandboxProfilesFile), name)
+}
+
+func readSandboxProfiles(crashdir string) []string {
+	return readUnique(filepath.Join(crashdir, sandboxProfilesFile))
+}
+
+// recordCrashSandbox remembers that the crash happened under the sandbox
+// (the fuzzing sandbox for crashes, the reproducer sandbox for reproducers).
+// The sandboxes are used to estimate severity of the crash (see crashSeverity).
+func recordCrashSandbox(crashdir, sandbox string) {
+	if sandbox == "" {
+		sandbox = "none"
+	}
+	recordUnique(filepath.Join(crashdir, crashSandboxesFile), sandbox)
+}
+
+func readCrashSandboxes(crashdir string) []string {
+	return readUnique(filepath.Join(crashdir, crashSandboxesFile))
+}
+
+// recordUnique adds the value to the file with a list of values, unless it's already there.
+func recordUnique(file, value string) {
+	values := readUnique(file)
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+	values = append(values, value)
+	if err := osutil.WriteFile(file, []byte(strings.Join(values, "\n")+"\n")); err != nil {
+		log.Logf(0, "failed to write %v: %v", file, err)
+	}
+}
+
+func readUnique(file string) []string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
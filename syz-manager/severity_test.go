@@ -0,0 +1,74 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCrashSeverity(t *testing.T) {
+	tests := []struct {
+		title     string
+		sandboxes []string
+		repro     bool
+		crepro    bool
+		score     int
+		tags      []string
+	}{
+		{"KASAN: use-after-free Write in foo", nil, false, false, 90, []string{"uaf-write"}},
+		{"KASAN: double-free in foo", nil, false, false, 90, []string{"uaf-write"}},
+		{"KASAN: slab-out-of-bounds Write in foo", nil, false, false, 85, []string{"oob-write"}},
+		{"KASAN: use-after-free Read in foo", nil, false, false, 70, []string{"uaf-read"}},
+		{"KASAN: global-out-of-bounds Read in foo", nil, false, false, 65, []string{"oob-read"}},
+		{"KASAN: wild-memory-access in foo", nil, false, false, 60, []string{"memory-safety"}},
+		{"general protection fault in foo", nil, false, false, 55, []string{"bad-access"}},
+		{"BUG: unable to handle kernel NULL pointer dereference in foo", nil, false, false,
+			45, []string{"null-deref"}},
+		{"KMSAN: uninit-value in foo", nil, false, false, 45, []string{"uninit"}},
+		{"kernel BUG at fs/foo.c:LINE!", nil, false, false, 40, []string{"bug"}},
+		{"KCSAN: data-race in foo / bar", nil, false, false, 30, []string{"data-race"}},
+		{"UBSAN: shift-out-of-bounds in foo", nil, false, false, 30, []string{"ubsan"}},
+		{"WARNING in foo", nil, false, false, 20, []string{"warning"}},
+		{"INFO: task hung in foo", nil, false, false, 15, []string{"hang"}},
+		{"memory leak in foo", nil, false, false, 10, []string{"leak"}},
+		{"lost connection to test machine", nil, false, false, 5, []string{"infra"}},
+		{"possible deadlock in foo", nil, false, false, 25, nil},
+		{"WARNING in foo", []string{"none"}, false, false, 20, []string{"warning"}},
+		{"WARNING in foo", []string{"none", "setuid"}, false, false, 30, []string{"warning", "unprivileged"}},
+		{"WARNING in foo", []string{"namespace", "android_untrusted_app"}, false, false,
+			30, []string{"warning", "unprivileged"}},
+		{"WARNING in foo", nil, true, false, 30, []string{"warning", "repro"}},
+		{"WARNING in foo", []string{"setuid"}, true, true, 45, []string{"warning", "unprivileged", "repro", "c-repro"}},
+		{"KASAN: use-after-free Write in foo", []string{"setuid"}, true, true,
+			100, []string{"uaf-write", "unprivileged", "repro", "c-repro"}},
+	}
+	for _, test := range tests {
+		score, tags := crashSeverity(test.title, test.sandboxes, test.repro, test.crepro)
+		if score != test.score || !reflect.DeepEqual(tags, test.tags) {
+			t.Errorf("%q %v repro=%v crepro=%v: got %v %q, want %v %q", test.title, test.sandboxes,
+				test.repro, test.crepro, score, tags, test.score, test.tags)
+		}
+	}
+}
+
+func TestCrashSandboxes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-manager-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if sandboxes := readCrashSandboxes(dir); sandboxes != nil {
+		t.Fatalf("got sandboxes %q for a new crash", sandboxes)
+	}
+	recordCrashSandbox(dir, "setuid")
+	recordCrashSandbox(dir, "")
+	recordCrashSandbox(dir, "setuid")
+	want := []string{"setuid", "none"}
+	if sandboxes := readCrashSandboxes(dir); !reflect.DeepEqual(sandboxes, want) {
+		t.Fatalf("got sandboxes %q, want %q", sandboxes, want)
+	}
+}
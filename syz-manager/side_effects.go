@@ -0,0 +1,73 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// If detect_side_effects is enabled, fuzzers report syscalls that changed global state
+// (mounts, kernel modules, sysctls, loop devices). We keep them in workdir/side_effects,
+// send them to fuzzers on connect (so that the calls are executed with a deeper reset level
+// right away) and show them on the syscalls page: such descriptions trash the test environment.
+const sideEffectsFile = "side_effects"
+
+func (mgr *Manager) loadSideEffects() {
+	mgr.sideEffects = make(map[string]ipc.SideEffects)
+	data, err := ioutil.ReadFile(filepath.Join(mgr.cfg.Workdir, sideEffectsFile))
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &mgr.sideEffects); err != nil {
+		log.Logf(0, "failed to parse side effects: %v", err)
+		mgr.sideEffects = make(map[string]ipc.SideEffects)
+	}
+	// The descriptions may have changed since the previous run.
+	for name := range mgr.sideEffects {
+		if mgr.target.SyscallMap[name] == nil {
+			delete(mgr.sideEffects, name)
+		}
+	}
+}
+
+func (mgr *Manager) knownSideEffects() map[string]ipc.SideEffects {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	res := make(map[string]ipc.SideEffects, len(mgr.sideEffects))
+	for name, effects := range mgr.sideEffects {
+		res[name] = effects
+	}
+	return res
+}
+
+// callsSideEffects is called when a fuzzer reports side effects of syscalls.
+func (mgr *Manager) callsSideEffects(name string, effects map[string]ipc.SideEffects) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	changed := false
+	for call, eff := range effects {
+		if mgr.target.SyscallMap[call] == nil || mgr.sideEffects[call]&eff == eff {
+			continue
+		}
+		log.Logf(0, "%v: %v has side effects: %v", name, call, eff)
+		mgr.sideEffects[call] |= eff
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	data, err := json.MarshalIndent(mgr.sideEffects, "", "\t")
+	if err != nil {
+		log.Fatalf("failed to marshal side effects: %v", err)
+	}
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, sideEffectsFile), data); err != nil {
+		log.Logf(0, "failed to write side effects: %v", err)
+	}
+}
@@ -14,7 +14,9 @@ type Stats struct {
 	crashes          Stat
 	crashTypes       Stat
 	crashSuppressed  Stat
+	suspicious       Stat
 	vmRestarts       Stat
+	vmRecycled       Stat
 	newInputs        Stat
 	execTotal        Stat
 	hubSendProgAdd   Stat
@@ -26,6 +28,8 @@ type Stats struct {
 	hubRecvReproDrop Stat
 	corpusCover      Stat
 	corpusSignal     Stat
+	reminimized      Stat
+	backfilled       Stat
 
 	mu         sync.Mutex
 	namedStats map[string]uint64
@@ -36,7 +40,9 @@ func (stats *Stats) all() map[string]uint64 {
 		"crashes":              stats.crashes.get(),
 		"crash types":          stats.crashTypes.get(),
 		"suppressed":           stats.crashSuppressed.get(),
+		"suspicious output":    stats.suspicious.get(),
 		"vm restarts":          stats.vmRestarts.get(),
+		"vm recycled":          stats.vmRecycled.get(),
 		"manager new inputs":   stats.newInputs.get(),
 		"exec total":           stats.execTotal.get(),
 		"hub: send prog add":   stats.hubSendProgAdd.get(),
@@ -48,6 +54,8 @@ func (stats *Stats) all() map[string]uint64 {
 		"hub: recv repro drop": stats.hubRecvReproDrop.get(),
 		"cover":                stats.corpusCover.get(),
 		"signal":               stats.corpusSignal.get(),
+		"corpus reminimized":   stats.reminimized.get(),
+		"backfilled syscalls":  stats.backfilled.get(),
 	}
 	stats.mu.Lock()
 	defer stats.mu.Unlock()
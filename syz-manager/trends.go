@@ -0,0 +1,242 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// For every crash title we keep the occurrence history in the crash dir
+// (first/last seen time and counts per kernel build and per day).
+// Together with the list of builds the manager has fuzzed (workdir/builds)
+// it allows to flag crashes that stopped happening after some build (possibly silently fixed)
+// and crashes that suddenly spiked (possibly a new regression).
+const (
+	crashHistoryFile = "history"
+	buildsFile       = "builds"
+
+	historyDays   = 90 // number of days we keep daily counts for
+	sparklineDays = 14
+
+	// A day is a spike if it has at least spikeMinCount crashes and spikeFactor times
+	// more than the average over the previous spikeBaseDays days.
+	spikeMinCount = 5
+	spikeFactor   = 4
+	spikeBaseDays = 7
+
+	// A crash is considered to have stopped if newer builds were fuzzed for stoppedFactor
+	// average intervals between crashes (but at least stoppedMinTime) without the crash.
+	stoppedMinTime = 24 * time.Hour
+	stoppedFactor  = 3
+
+	trendSpike   = "spike"
+	trendStopped = "stopped"
+)
+
+type crashHistory struct {
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int
+	Builds    []*crashBuildHistory // in the order of appearance
+	Days      map[string]int       // YYYY-MM-DD (UTC) -> number of crashes
+}
+
+type crashBuildHistory struct {
+	Build     string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int
+}
+
+type buildRecord struct {
+	Build string
+	Start time.Time
+}
+
+func readCrashHistory(crashdir string) *crashHistory {
+	data, err := ioutil.ReadFile(filepath.Join(crashdir, crashHistoryFile))
+	if err != nil {
+		return nil
+	}
+	h := new(crashHistory)
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func recordCrashHistory(crashdir, build string, now time.Time) {
+	h := readCrashHistory(crashdir)
+	if h == nil {
+		h = &crashHistory{FirstSeen: now}
+	}
+	h.LastSeen = now
+	h.Count++
+	var bh *crashBuildHistory
+	for _, bh1 := range h.Builds {
+		if bh1.Build == build {
+			bh = bh1
+		}
+	}
+	if bh == nil {
+		bh = &crashBuildHistory{Build: build, FirstSeen: now}
+		h.Builds = append(h.Builds, bh)
+	}
+	bh.LastSeen = now
+	bh.Count++
+	if h.Days == nil {
+		h.Days = make(map[string]int)
+	}
+	h.Days[historyDay(now)]++
+	oldest := historyDay(now.AddDate(0, 0, -historyDays))
+	for day := range h.Days {
+		if day < oldest {
+			delete(h.Days, day)
+		}
+	}
+	data, err := json.MarshalIndent(h, "", "\t")
+	if err != nil {
+		log.Logf(0, "failed to serialize crash history: %v", err)
+		return
+	}
+	if err := osutil.WriteFile(filepath.Join(crashdir, crashHistoryFile), data); err != nil {
+		log.Logf(0, "failed to write crash history: %v", err)
+	}
+}
+
+func historyDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// dailyCounts returns number of crashes for each of the last n days (the last element is today).
+func (h *crashHistory) dailyCounts(now time.Time, n int) []int {
+	counts := make([]int, n)
+	for i := range counts {
+		counts[i] = h.Days[historyDay(now.AddDate(0, 0, i-n+1))]
+	}
+	return counts
+}
+
+func (h *crashHistory) sparkline(now time.Time) string {
+	const bars = "▁▂▃▄▅▆▇█"
+	levels := []rune(bars)
+	counts := h.dailyCounts(now, sparklineDays)
+	max := 0
+	for _, c := range counts {
+		if max < c {
+			max = c
+		}
+	}
+	var res []rune
+	for _, c := range counts {
+		level := 0
+		if c != 0 {
+			level = 1 + c*(len(levels)-2)/max
+		}
+		res = append(res, levels[level])
+	}
+	return string(res)
+}
+
+// trend returns trendSpike/trendStopped and a human-readable explanation,
+// or empty strings if there is nothing notable.
+func (h *crashHistory) trend(builds []buildRecord, now time.Time) (string, string) {
+	counts := h.dailyCounts(now, spikeBaseDays+1)
+	today, base := counts[spikeBaseDays], 0
+	for _, c := range counts[:spikeBaseDays] {
+		base += c
+	}
+	if today >= spikeMinCount && today*spikeBaseDays > spikeFactor*base {
+		return trendSpike, fmt.Sprintf("%v crashes today, %v in the previous %v days",
+			today, base, spikeBaseDays)
+	}
+	if len(h.Builds) == 0 {
+		return "", ""
+	}
+	last := h.Builds[0]
+	for _, bh := range h.Builds {
+		if last.LastSeen.Before(bh.LastSeen) {
+			last = bh
+		}
+	}
+	// Find the first build that started after the last crash and was different from the crashed one.
+	var next *buildRecord
+	for i := range builds {
+		if builds[i].Build != last.Build && builds[i].Start.After(last.LastSeen) {
+			next = &builds[i]
+			break
+		}
+	}
+	if next == nil {
+		return "", ""
+	}
+	threshold := stoppedMinTime
+	if h.Count > 1 {
+		if interval := h.LastSeen.Sub(h.FirstSeen) / time.Duration(h.Count-1); threshold < stoppedFactor*interval {
+			threshold = stoppedFactor * interval
+		}
+	}
+	if since := now.Sub(next.Start); since >= threshold {
+		return trendStopped, fmt.Sprintf("not seen since build %v, possibly fixed in %v (fuzzed for %v)",
+			last.Build, next.Build, since.Truncate(time.Hour))
+	}
+	return "", ""
+}
+
+func readBuilds(workdir string) []buildRecord {
+	f, err := os.Open(filepath.Join(workdir, buildsFile))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var builds []buildRecord
+	for s := bufio.NewScanner(f); s.Scan(); {
+		fields := strings.SplitN(s.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		builds = append(builds, buildRecord{Build: fields[1], Start: time.Unix(sec, 0)})
+	}
+	return builds
+}
+
+// recordBuild appends the build to workdir/builds if it differs from the last recorded one.
+func recordBuild(workdir, build string, now time.Time) {
+	if builds := readBuilds(workdir); len(builds) != 0 && builds[len(builds)-1].Build == build {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(workdir, buildsFile),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, osutil.DefaultFilePerm)
+	if err != nil {
+		log.Logf(0, "failed to record build: %v", err)
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%v %v\n", now.Unix(), build)
+}
+
+// buildID identifies the kernel build for crash history.
+// It's the manager tag, or a hash of the kernel binaries identity if the tag is not set.
+func (mgr *Manager) buildID() string {
+	if mgr.cfg.Tag != "" {
+		return mgr.cfg.Tag
+	}
+	return hash.String([]byte(mgr.kernelID()))[:12]
+}
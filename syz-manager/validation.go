@@ -0,0 +1,167 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/repro"
+	"github.com/google/syzkaller/prog"
+)
+
+const reproValidationFile = "repro.validation"
+
+// reproValidation is stored in the crash dir next to repro.prog
+// and records how reliably the reproducer triggers the crash.
+type reproValidation struct {
+	Runs    int
+	Crashed int
+	// Kernel identifies the kernel the reproducer was last validated on (see kernelID).
+	Kernel   string
+	Time     time.Time
+	Duration time.Duration
+	Opts     string
+	CRepro   bool
+}
+
+func (v *reproValidation) validation() *repro.Validation {
+	return &repro.Validation{Runs: v.Runs, Crashed: v.Crashed}
+}
+
+func readReproValidation(crashdir string) *reproValidation {
+	data, err := ioutil.ReadFile(filepath.Join(crashdir, reproValidationFile))
+	if err != nil {
+		return nil
+	}
+	v := new(reproValidation)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil
+	}
+	return v
+}
+
+func writeReproValidation(crashdir string, v *reproValidation) error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(filepath.Join(crashdir, reproValidationFile), data)
+}
+
+// kernelID returns a string that changes whenever the tested kernel changes.
+// It is based on the manager tag and on size/modification time of the kernel binaries,
+// so that we don't need to read the (potentially huge) files.
+func (mgr *Manager) kernelID() string {
+	id := mgr.cfg.Tag
+	files := []string{mgr.cfg.Image}
+	if mgr.cfg.KernelObj != "" {
+		files = append(files, filepath.Join(mgr.cfg.KernelObj, mgr.sysTarget.KernelObject))
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		id += fmt.Sprintf("|%v:%v:%v", file, stat.Size(), stat.ModTime().UnixNano())
+	}
+	if id == "" {
+		return ""
+	}
+	return hash.String([]byte(id))
+}
+
+// staleRepros returns crashes with reproducers that were validated on a different kernel
+// and need re-validation.
+func (mgr *Manager) staleRepros() []*Crash {
+	kernel := mgr.kernelID()
+	if kernel == "" {
+		return nil
+	}
+	dirs, err := osutil.ListDir(mgr.crashdir)
+	if err != nil {
+		return nil
+	}
+	var crashes []*Crash
+	for _, dir := range dirs {
+		crashdir := filepath.Join(mgr.crashdir, dir)
+		v := readReproValidation(crashdir)
+		if v == nil || v.Kernel == kernel {
+			continue
+		}
+		title, err := ioutil.ReadFile(filepath.Join(crashdir, "description"))
+		if err != nil {
+			continue
+		}
+		res, err := mgr.loadRepro(crashdir, v)
+		if err != nil {
+			log.Logf(0, "failed to load repro for re-validation from %v: %v", crashdir, err)
+			continue
+		}
+		res.Report.Title = string(trimNewLines(title))
+		crashes = append(crashes, &Crash{
+			Report:     res.Report,
+			revalidate: res,
+		})
+	}
+	return crashes
+}
+
+// loadRepro loads a reproducer stored in crashdir in the form suitable for repro.Validate.
+func (mgr *Manager) loadRepro(crashdir string, v *reproValidation) (*repro.Result, error) {
+	data, err := ioutil.ReadFile(filepath.Join(crashdir, "repro.prog"))
+	if err != nil {
+		return nil, err
+	}
+	p, err := mgr.target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := csource.DeserializeOptions([]byte(v.Opts))
+	if err != nil {
+		return nil, err
+	}
+	res := &repro.Result{
+		Prog:     p,
+		Opts:     opts,
+		CRepro:   v.CRepro,
+		Duration: v.Duration,
+		// Validation does not need the triggering call.
+		TriggerCall: -1,
+	}
+	if output, err := ioutil.ReadFile(filepath.Join(crashdir, "repro.log")); err == nil {
+		res.Report = mgr.reporter.Parse(output)
+	}
+	if res.Report == nil {
+		res.Report = new(report.Report)
+	}
+	return res, nil
+}
+
+func (mgr *Manager) saveReproValidation(crashdir string, res *repro.Result, v *repro.Validation) {
+	err := writeReproValidation(crashdir, &reproValidation{
+		Runs:     v.Runs,
+		Crashed:  v.Crashed,
+		Kernel:   mgr.kernelID(),
+		Time:     time.Now(),
+		Duration: res.Duration,
+		Opts:     string(res.Opts.Serialize()),
+		CRepro:   res.CRepro,
+	})
+	if err != nil {
+		log.Logf(0, "failed to write repro validation: %v", err)
+	}
+}
@@ -17,6 +17,9 @@
 //  - syzkaller.commit: hash of syzkaller commit which was used to trigger the crash
 //  - kernel.commit: hash of kernel commit on which the crash was triggered
 //  - kernel.config: kernel config file
+//
+// Bisection debug output and machine-readable log (bisect.json, see pkg/bisect.Result)
+// are saved to the crash dir.
 package main
 
 import (
@@ -56,6 +59,8 @@ type Config struct {
 	// dashboard/config/upstream-selinux.cmdline
 	Sysctl  string `json:"sysctl"`
 	Cmdline string `json:"cmdline"`
+	// Small kernel config used to minimize the crash config before bisection (optional).
+	BaselineConfig string `json:"baseline_config"`
 	// Manager config that was used to obtain the crash.
 	Manager json.RawMessage `json:"manager"`
 }
@@ -97,13 +102,20 @@ func main() {
 			Repo: mycfg.SyzkallerRepo,
 		},
 		Manager: *mgrcfg,
+		Retries: bisect.DefaultRetries,
+	}
+	if mycfg.BaselineConfig != "" {
+		if cfg.Kernel.BaselineConfig, err = ioutil.ReadFile(mycfg.BaselineConfig); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	}
 	loadString("syzkaller.commit", &cfg.Syzkaller.Commit)
 	loadString("kernel.commit", &cfg.Kernel.Commit)
 	loadFile("kernel.config", &cfg.Kernel.Config)
 	loadFile("repro.syz", &cfg.Repro.Syz)
 	loadFile("repro.opts", &cfg.Repro.Opts)
-	if _, _, err := bisect.Run(cfg); err != nil {
+	if _, err := bisect.Run(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "bisection failed: %v\n", err)
 		os.Exit(1)
 	}
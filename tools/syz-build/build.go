@@ -0,0 +1,210 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-build builds a Linux kernel and a bootable image suitable for fuzzing the same way syz-ci does,
+// and checks that the result boots and works under qemu. Usage:
+//
+//	sudo syz-build -kernel_src $LINUX_CHECKOUT -userspace $USERSPACE -output $OUTPUT_DIR \
+//		[-config my.config] [-base_config dashboard/config/upstream-kasan.config]
+//
+// The kernel config is base_config (or defconfig+kvm_guest.config if not specified),
+// with the options recommended for syzkaller (KCOV, KASAN, debug info, etc) and then
+// the config fragment applied on top. After the build the tool reports options that
+// did not make it into the final config (e.g. because of unmet dependencies).
+// The output dir contains the image, ssh key, kernel.config and obj/vmlinux
+// and can be used directly in the manager config.
+// The boot test requires syzkaller binaries for the target (make TARGETOS=linux TARGETARCH=amd64).
+// The binary needs to run under root because it creates images.
+// A suitable userspace can be created with tools/create-image.sh or downloaded from:
+// https://storage.googleapis.com/syzkaller/wheezy.tar.gz
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/google/syzkaller/pkg/build"
+	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+var (
+	flagArch          = flag.String("arch", runtime.GOARCH, "target arch")
+	flagKernelSrc     = flag.String("kernel_src", "", "path to kernel checkout (required)")
+	flagUserspace     = flag.String("userspace", "", "path to userspace for the image (required)")
+	flagOutput        = flag.String("output", "", "output dir (required)")
+	flagConfig        = flag.String("config", "", "kernel config fragment applied on top of the base config")
+	flagBaseConfig    = flag.String("base_config", "", "full kernel config (defconfig+kvm_guest.config if empty)")
+	flagCompiler      = flag.String("compiler", "gcc", "compiler binary")
+	flagKernelSysctl  = flag.String("sysctl", "", "kernel sysctl file")
+	flagKernelCmdline = flag.String("cmdline", "", "kernel cmdline file")
+	flagBoot          = flag.Bool("boot", true, "test that the kernel boots in qemu")
+	flagSyzkaller     = flag.String("syzkaller", ".", "path to built syzkaller")
+	flagSandbox       = flag.String("sandbox", "none", "sandbox to use for boot testing")
+)
+
+const (
+	targetOS = "linux"
+	vmType   = "qemu"
+)
+
+func main() {
+	flag.Parse()
+	if *flagKernelSrc == "" || *flagUserspace == "" || *flagOutput == "" {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if os.Getuid() != 0 {
+		failf("image build will fail, run under root")
+	}
+	os.Setenv("SYZ_DISABLE_SANDBOXING", "yes")
+	fragment := &kconfig{values: make(map[string]string)}
+	if *flagConfig != "" {
+		data, err := ioutil.ReadFile(*flagConfig)
+		if err != nil {
+			fail(err)
+		}
+		if fragment, err = parseKconfig(data); err != nil {
+			failf("failed to parse %v: %v", *flagConfig, err)
+		}
+	}
+	base, err := baseConfig()
+	if err != nil {
+		fail(err)
+	}
+	base.merge(recommendedKconfig())
+	base.merge(fragment)
+	log.Printf("building kernel in %v", *flagKernelSrc)
+	if err := build.Image(targetOS, *flagArch, vmType, *flagKernelSrc, *flagOutput, *flagCompiler,
+		*flagUserspace, *flagKernelCmdline, *flagKernelSysctl, base.serialize()); err != nil {
+		if verr, ok := err.(build.KernelBuildError); ok {
+			logFile := filepath.Join(*flagOutput, "build.log")
+			osutil.WriteFile(logFile, verr.Output)
+			failf("kernel build failed: %v (build output is saved to %v)", verr.Title, logFile)
+		}
+		failf("build failed: %v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(*flagOutput, "kernel.config"))
+	if err != nil {
+		fail(err)
+	}
+	final, err := parseKconfig(data)
+	if err != nil {
+		failf("failed to parse the resulting config: %v", err)
+	}
+	problems := checkKconfig(final, fragment)
+	for _, problem := range problems {
+		log.Printf("config: %v", problem)
+	}
+	log.Printf("build OK: %v", *flagOutput)
+	if *flagBoot {
+		if err := testBoot(final.value("CONFIG_KCOV") == "y"); err != nil {
+			failf("boot test failed: %v", err)
+		}
+		log.Printf("boot OK")
+	}
+	if len(problems) != 0 {
+		os.Exit(1)
+	}
+}
+
+// baseConfig returns -base_config, or generates a config suitable for running in qemu.
+func baseConfig() (*kconfig, error) {
+	if *flagBaseConfig != "" {
+		data, err := ioutil.ReadFile(*flagBaseConfig)
+		if err != nil {
+			return nil, err
+		}
+		return parseKconfig(data)
+	}
+	if err := makeKernel("defconfig"); err != nil {
+		return nil, err
+	}
+	// kvmconfig was renamed to kvm_guest.config in v5.10.
+	if err := makeKernel("kvm_guest.config"); err != nil {
+		if err := makeKernel("kvmconfig"); err != nil {
+			return nil, err
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(*flagKernelSrc, ".config"))
+	if err != nil {
+		return nil, err
+	}
+	return parseKconfig(data)
+}
+
+func makeKernel(target string) error {
+	cmd := osutil.Command("make", target, "CC="+*flagCompiler)
+	if err := osutil.Sandbox(cmd, true, true); err != nil {
+		return err
+	}
+	cmd.Dir = *flagKernelSrc
+	_, err := osutil.Run(10*time.Minute, cmd)
+	return err
+}
+
+func testBoot(cover bool) error {
+	dir, err := ioutil.TempDir("", "syz-build")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	cfg := &mgrconfig.Config{
+		Target:       targetOS + "/" + *flagArch,
+		TargetOS:     targetOS,
+		TargetArch:   *flagArch,
+		TargetVMArch: *flagArch,
+		HTTP:         ":0",
+		Workdir:      dir,
+		KernelSrc:    *flagKernelSrc,
+		Syzkaller:    *flagSyzkaller,
+		Sandbox:      *flagSandbox,
+		SSHUser:      "root",
+		Procs:        1,
+		Cover:        cover,
+		Type:         vmType,
+		VM:           json.RawMessage(`{ "count": 1, "cpu": 2, "mem": 2048 }`),
+	}
+	if err := instance.SetConfigImage(cfg, *flagOutput, true); err != nil {
+		return err
+	}
+	env, err := instance.NewEnv(cfg)
+	if err != nil {
+		return err
+	}
+	results, err := env.Test(1, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if res := results[0]; res != nil {
+		if testErr, ok := res.(*instance.TestError); ok {
+			output := testErr.Output
+			if testErr.Report != nil {
+				output = testErr.Report.Output
+			}
+			logFile := filepath.Join(*flagOutput, "boot.log")
+			if len(output) != 0 && osutil.WriteFile(logFile, output) == nil {
+				return fmt.Errorf("%v (console output is saved to %v)", testErr, logFile)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func fail(err error) {
+	failf("%v", err)
+}
+
+func failf(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
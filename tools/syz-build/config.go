@@ -0,0 +1,139 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// recommendedConfig is the set of options that syzkaller needs or benefits from,
+// see docs/linux/kernel_configs.md for details.
+var recommendedConfig = []struct {
+	name   string
+	value  string
+	reason string
+}{
+	{"CONFIG_KCOV", "y", "coverage collection"},
+	{"CONFIG_KCOV_INSTRUMENT_ALL", "y", "coverage collection"},
+	{"CONFIG_KCOV_ENABLE_COMPARISONS", "y", "comparison operands collection (requires gcc8+)"},
+	{"CONFIG_DEBUG_FS", "y", "coverage collection and fault injection"},
+	{"CONFIG_DEBUG_INFO", "y", "coverage reports and report symbolization"},
+	{"CONFIG_KALLSYMS", "y", "detection of enabled syscalls"},
+	{"CONFIG_KALLSYMS_ALL", "y", "detection of enabled syscalls"},
+	{"CONFIG_KASAN", "y", "use-after-free and out-of-bounds detection"},
+	{"CONFIG_KASAN_INLINE", "y", "faster KASAN"},
+	{"CONFIG_NAMESPACES", "y", "sandboxing"},
+	{"CONFIG_USER_NS", "y", "namespace sandbox"},
+	{"CONFIG_UTS_NS", "y", "sandboxing"},
+	{"CONFIG_IPC_NS", "y", "sandboxing"},
+	{"CONFIG_PID_NS", "y", "sandboxing"},
+	{"CONFIG_NET_NS", "y", "sandboxing"},
+	{"CONFIG_CGROUP_PIDS", "y", "sandboxing"},
+	{"CONFIG_MEMCG", "y", "sandboxing"},
+	{"CONFIG_CONFIGFS_FS", "y", "booting the image"},
+	{"CONFIG_SECURITYFS", "y", "booting the image"},
+	{"CONFIG_FAULT_INJECTION", "y", "fault injection"},
+	{"CONFIG_FAULT_INJECTION_DEBUG_FS", "y", "fault injection"},
+	{"CONFIG_FAILSLAB", "y", "fault injection"},
+	{"CONFIG_FAIL_PAGE_ALLOC", "y", "fault injection"},
+	{"CONFIG_RANDOMIZE_BASE", "n", "coverage on kernels without KASLR support in kcov"},
+}
+
+// kconfig is a parsed kernel config: option name -> value ("n" for unset options).
+// Options are kept in the original order, so that the resulting config is readable.
+type kconfig struct {
+	names  []string
+	values map[string]string
+}
+
+var (
+	kconfigSetRe   = regexp.MustCompile(`^(CONFIG_[A-Za-z0-9_]+)=(.*)$`)
+	kconfigUnsetRe = regexp.MustCompile(`^# (CONFIG_[A-Za-z0-9_]+) is not set$`)
+)
+
+func parseKconfig(data []byte) (*kconfig, error) {
+	cfg := &kconfig{values: make(map[string]string)}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for i := 1; s.Scan(); i++ {
+		ln := strings.TrimSpace(s.Text())
+		if match := kconfigSetRe.FindStringSubmatch(ln); match != nil {
+			cfg.set(match[1], match[2])
+		} else if match := kconfigUnsetRe.FindStringSubmatch(ln); match != nil {
+			cfg.set(match[1], "n")
+		} else if ln != "" && ln[0] != '#' {
+			return nil, fmt.Errorf("line %v: bad config line %q", i, ln)
+		}
+	}
+	return cfg, s.Err()
+}
+
+func (cfg *kconfig) set(name, value string) {
+	if _, ok := cfg.values[name]; !ok {
+		cfg.names = append(cfg.names, name)
+	}
+	cfg.values[name] = value
+}
+
+// value returns the option value, missing options are unset.
+func (cfg *kconfig) value(name string) string {
+	if v, ok := cfg.values[name]; ok {
+		return v
+	}
+	return "n"
+}
+
+func (cfg *kconfig) merge(other *kconfig) {
+	for _, name := range other.names {
+		cfg.set(name, other.values[name])
+	}
+}
+
+func (cfg *kconfig) serialize() []byte {
+	buf := new(bytes.Buffer)
+	for _, name := range cfg.names {
+		if v := cfg.values[name]; v == "n" {
+			fmt.Fprintf(buf, "# %v is not set\n", name)
+		} else {
+			fmt.Fprintf(buf, "%v=%v\n", name, v)
+		}
+	}
+	return buf.Bytes()
+}
+
+func recommendedKconfig() *kconfig {
+	cfg := &kconfig{values: make(map[string]string)}
+	for _, opt := range recommendedConfig {
+		cfg.set(opt.name, opt.value)
+	}
+	return cfg
+}
+
+// checkKconfig returns descriptions of options requested by the fragment or recommended by syzkaller
+// that don't have the requested value in the final config (e.g. were dropped by make oldconfig
+// because of unmet dependencies, or are not supported by the kernel/compiler).
+func checkKconfig(final, fragment *kconfig) []string {
+	var problems []string
+	reported := make(map[string]bool)
+	for _, name := range fragment.names {
+		if want, have := fragment.values[name], final.value(name); want != have {
+			problems = append(problems, fmt.Sprintf("%v=%v was requested, but the config has %v",
+				name, want, have))
+		}
+		reported[name] = true
+	}
+	for _, opt := range recommendedConfig {
+		if reported[opt.name] {
+			continue
+		}
+		if have := final.value(opt.name); have != opt.value {
+			problems = append(problems, fmt.Sprintf("%v=%v is recommended for %v, but the config has %v",
+				opt.name, opt.value, opt.reason, have))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,233 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-check cross-checks syscall descriptions against a kernel build and reports mismatches
+// along with suggested fixes. It is intended to be run by description authors before
+// submitting changes. Usage (from syzkaller checkout):
+//
+//	syz-check -os=linux -arch=amd64 -obj=$KERNEL_BUILD
+//
+// The following checks are done:
+//   - sizes and field offsets of structs/unions are compared with the kernel debug info
+//     (requires CONFIG_DEBUG_INFO=y), a suggested layout is printed on mismatch;
+//   - values of consts that are kernel enumerators are compared with the debug info;
+//   - ioctl commands that encode argument size and direction (_IOR/_IOW/_IOWR)
+//     are checked against the size and direction of the described argument
+//     (this check does not require the kernel build, so -obj can be omitted).
+//
+// Exit status is 1 if any mismatches were found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/pkg/ktypes"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+var (
+	flagOS     = flag.String("os", runtime.GOOS, "target OS")
+	flagArch   = flag.String("arch", runtime.GOARCH, "target arch")
+	flagObj    = flag.String("obj", "", "kernel build dir with vmlinux (if empty, only ioctls are checked)")
+	flagFilter = flag.String("filter", "", "check only structs/calls/consts matching the regexp")
+)
+
+func main() {
+	flag.Parse()
+	target, err := prog.GetTarget(*flagOS, *flagArch)
+	if err != nil {
+		failf("%v", err)
+	}
+	var filter *regexp.Regexp
+	if *flagFilter != "" {
+		if filter, err = regexp.Compile(*flagFilter); err != nil {
+			failf("bad filter: %v", err)
+		}
+	}
+	chk := &checker{
+		target: target,
+		filter: filter,
+		pos:    descriptionPositions(target.OS),
+	}
+	chk.checkIoctls()
+	if *flagObj != "" {
+		sysTarget := targets.Get(target.OS, target.Arch)
+		vmlinux := filepath.Join(*flagObj, sysTarget.KernelObject)
+		kt, err := ktypes.Load(vmlinux, false)
+		if err != nil {
+			failf("%v", err)
+		}
+		chk.checkStructs(kt)
+		chk.checkConsts(kt, chk.descConsts())
+	}
+	chk.print()
+	if len(chk.warnings) != 0 {
+		os.Exit(1)
+	}
+}
+
+type checker struct {
+	target   *prog.Target
+	filter   *regexp.Regexp
+	pos      map[string]ast.Pos
+	warnings []*warning
+}
+
+type warning struct {
+	key string
+	pos ast.Pos
+	msg string
+	fix string // suggested fix, can be empty
+}
+
+func (chk *checker) matches(name string) bool {
+	return chk.filter == nil || chk.filter.MatchString(name)
+}
+
+// warn records a mismatch for the description node key (see descriptionPositions).
+func (chk *checker) warn(key, fix, msg string, args ...interface{}) {
+	chk.warnings = append(chk.warnings, &warning{
+		key: key,
+		pos: chk.pos[key],
+		msg: fmt.Sprintf(msg, args...),
+		fix: fix,
+	})
+}
+
+func (chk *checker) print() {
+	sort.Slice(chk.warnings, func(i, j int) bool {
+		w1, w2 := chk.warnings[i], chk.warnings[j]
+		if w1.pos.File != w2.pos.File {
+			return w1.pos.File < w2.pos.File
+		}
+		if w1.pos.Line != w2.pos.Line {
+			return w1.pos.Line < w2.pos.Line
+		}
+		return w1.key < w2.key
+	})
+	for _, w := range chk.warnings {
+		pos := w.key
+		if w.pos.File != "" {
+			pos = w.pos.String()
+		}
+		fmt.Printf("%v: %v\n", pos, w.msg)
+		if w.fix != "" {
+			for _, ln := range strings.Split(strings.TrimRight(w.fix, "\n"), "\n") {
+				fmt.Printf("\t%v\n", ln)
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "found %v mismatches\n", len(chk.warnings))
+}
+
+// descriptionPositions returns positions of structs/unions ("struct foo"),
+// calls ("call foo$bar") and flags values ("const FOO") in the descriptions.
+func descriptionPositions(OS string) map[string]ast.Pos {
+	res := make(map[string]ast.Pos)
+	top := ast.ParseGlob(filepath.Join("sys", OS, "*.txt"), func(pos ast.Pos, msg string) {})
+	if top == nil {
+		fmt.Fprintf(os.Stderr, "can't parse descriptions (not in syzkaller checkout?), positions won't be reported\n")
+		return res
+	}
+	for _, node := range top.Nodes {
+		switch n := node.(type) {
+		case *ast.Struct:
+			res["struct "+n.Name.Name] = n.Pos
+		case *ast.Call:
+			res["call "+n.Name.Name] = n.Pos
+		case *ast.IntFlags:
+			for _, v := range n.Values {
+				if v.Ident != "" {
+					if _, ok := res["const "+v.Ident]; !ok {
+						res["const "+v.Ident] = v.Pos
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Bits of ioctl command encoding, see include/uapi/asm-generic/ioctl.h.
+type ioctlEncoding struct {
+	sizeBits uint64
+	dirNone  uint64
+	dirWrite uint64
+	dirRead  uint64
+}
+
+func archIoctlEncoding(arch string) ioctlEncoding {
+	switch arch {
+	case "ppc64le":
+		return ioctlEncoding{sizeBits: 13, dirNone: 1, dirWrite: 4, dirRead: 2}
+	default:
+		return ioctlEncoding{sizeBits: 14, dirNone: 0, dirWrite: 1, dirRead: 2}
+	}
+}
+
+func (chk *checker) checkIoctls() {
+	enc := archIoctlEncoding(chk.target.Arch)
+	for _, c := range chk.target.Syscalls {
+		if c.CallName != "ioctl" || len(c.Args) < 3 || !chk.matches(c.Name) {
+			continue
+		}
+		cmdType, ok := c.Args[1].(*prog.ConstType)
+		if !ok {
+			continue
+		}
+		ptr, ok := c.Args[2].(*prog.PtrType)
+		if !ok {
+			continue
+		}
+		cmd := cmdType.Val
+		if cmd>>32 != 0 || cmd>>8&0xff == 0 {
+			// Not an _IOC command (e.g. legacy tty ioctls).
+			continue
+		}
+		size := cmd >> 16 & (1<<enc.sizeBits - 1)
+		dir := cmd >> (16 + enc.sizeBits)
+		if dir == enc.dirNone || size == 0 {
+			continue
+		}
+		key := "call " + c.Name
+		if !ptr.Type.Varlen() && ptr.Type.Size() != size {
+			chk.warn(key, fmt.Sprintf("argument type must have size %v, or the command is wrong", size),
+				"%v: command 0x%x encodes argument size %v, but %v has size %v",
+				c.Name, cmd, size, ptr.Type.Name(), ptr.Type.Size())
+		}
+		kernelReads, kernelWrites := dir&enc.dirWrite != 0, dir&enc.dirRead != 0
+		argDir := ptr.Type.Dir()
+		want, macro := "", "_IOR"
+		if kernelReads {
+			macro = "_IOW"
+		}
+		switch {
+		case kernelReads && kernelWrites:
+			// inout is not required, ioctls frequently only read or only write despite _IOWR.
+		case kernelReads && argDir == prog.DirOut:
+			want = "in"
+		case kernelWrites && argDir == prog.DirIn:
+			want = "out"
+		}
+		if want != "" {
+			chk.warn(key, fmt.Sprintf("use ptr[%v, %v]", want, ptr.Type.Name()),
+				"%v: command 0x%x is %v, but the argument is %v",
+				c.Name, cmd, macro, argDir)
+		}
+	}
+}
+
+func failf(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ktypes"
+	"github.com/google/syzkaller/prog"
+)
+
+// structBase returns kernel name for the struct/union name in descriptions:
+// foo$bar is a variant of foo, template instantiations are not checked.
+func structBase(name string) string {
+	if strings.Contains(name, "[") {
+		return ""
+	}
+	if pos := strings.IndexByte(name, '$'); pos != -1 {
+		name = name[:pos]
+	}
+	return name
+}
+
+// descStructs returns all structs and unions used by syscalls keyed by name.
+func (chk *checker) descStructs() map[string]prog.Type {
+	res := make(map[string]prog.Type)
+	for _, c := range chk.target.Syscalls {
+		prog.ForeachType(c, func(typ prog.Type) {
+			switch typ.(type) {
+			case *prog.StructType, *prog.UnionType:
+				if res[typ.Name()] == nil {
+					res[typ.Name()] = typ
+				}
+			}
+		})
+	}
+	return res
+}
+
+// descConsts returns values of consts used by descriptions.
+func (chk *checker) descConsts() map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, c := range chk.target.Consts {
+		if chk.matches(c.Name) {
+			res[c.Name] = c.Value
+		}
+	}
+	return res
+}
+
+func (chk *checker) checkConsts(kt *ktypes.Types, consts map[string]uint64) {
+	for name, val := range consts {
+		kval, ok := kt.Enumerators[name]
+		if !ok || kval == int64(val) {
+			continue
+		}
+		chk.warn("const "+name,
+			fmt.Sprintf("update %v = %v in sys/%v/*_%v.const (or re-run make extract)",
+				name, kval, chk.target.OS, chk.target.Arch),
+			"const %v has value %v, but kernel enumerator has value %v", name, val, kval)
+	}
+}
+
+func (chk *checker) checkStructs(kt *ktypes.Types) {
+	for name, typ := range chk.descStructs() {
+		base := structBase(name)
+		if base == "" || !chk.matches(name) {
+			continue
+		}
+		ks := kt.Struct(base)
+		if ks == nil {
+			continue
+		}
+		problem := ktypes.CheckLayout(typ, ks)
+		if problem == "" {
+			continue
+		}
+		kind := "struct"
+		if _, isUnion := typ.(*prog.UnionType); isUnion {
+			kind = "union"
+		}
+		chk.warn("struct "+name, suggestLayout(name, ks), "%v %v: %v", kind, name, problem)
+	}
+}
+
+func suggestLayout(name string, ks *ktypes.Type) string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "suggested layout from kernel debug info:\n")
+	lbrace, rbrace := "{", "}"
+	if ks.Kind == ktypes.KindUnion {
+		lbrace, rbrace = "[", "]"
+	}
+	fmt.Fprintf(buf, "%v %v\n", name, lbrace)
+	for i, f := range ks.Fields {
+		fname := f.Name
+		if fname == "" {
+			fname = fmt.Sprintf("anon%v", i)
+		}
+		typ := syzType(f.Type)
+		if f.BitSize != 0 {
+			typ += fmt.Sprintf(":%v", f.BitSize)
+		}
+		fmt.Fprintf(buf, "\t%v\t%v\n", fname, typ)
+	}
+	fmt.Fprintf(buf, "%v\n", rbrace)
+	return buf.String()
+}
+
+// syzType returns description type for the kernel type.
+func syzType(t *ktypes.Type) string {
+	switch t.Kind {
+	case ktypes.KindTypedef:
+		return syzType(t.Elem)
+	case ktypes.KindInt, ktypes.KindEnum:
+		return fmt.Sprintf("int%v", t.Size*8)
+	case ktypes.KindPtr:
+		if t.Elem.Kind == ktypes.KindStruct && t.Elem.Name != "" {
+			return fmt.Sprintf("ptr[inout, %v]", t.Elem.Name)
+		}
+		return "ptr[inout, array[int8]]"
+	case ktypes.KindArray:
+		if t.Count < 0 {
+			return fmt.Sprintf("array[%v]", syzType(t.Elem))
+		}
+		return fmt.Sprintf("array[%v, %v]", syzType(t.Elem), t.Count)
+	case ktypes.KindStruct, ktypes.KindUnion:
+		if t.Name != "" {
+			return t.Name
+		}
+	}
+	return fmt.Sprintf("array[int8, %v]", t.Size)
+}
@@ -1,7 +1,8 @@
 // Copyright 2018 syzkaller project authors. All rights reserved.
 // Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
 
-// syz-cover generates coverage HTML report from raw coverage files.
+// syz-cover generates coverage HTML or LCOV report from raw coverage files
+// without a running manager (the reports are the same as the manager /cover page produces).
 // Raw coverage files are text files with one PC in hex form per line, e.g.:
 //
 //	0xffffffff8398658d
@@ -9,10 +10,14 @@
 //	0xffffffff8398633f
 //
 // Raw coverage files can be obtained either from /rawcover manager HTTP handler,
-// or from syz-execprog with -coverfile flag.
+// or from syz-execprog with -coverfile or -rawcover flags. Manager exports PCs of
+// the coverage callback calls rather than the callback return addresses, for such files
+// -manager_export flag needs to be specified.
 //
 // Usage:
-//	syz-cover [-os=OS -arch=ARCH -kernel_src=. -kernel_obj=.] rawcover.file*
+//	syz-cover [-os=OS -arch=ARCH -kernel_src=. -kernel_obj=. -format=html|lcov -o=file] rawcover.file*
+//
+// By default the HTML report is opened in the browser and the LCOV report is written to stdout.
 package main
 
 import (
@@ -38,6 +43,9 @@ func main() {
 		flagArch      = flag.String("arch", runtime.GOARCH, "target arch")
 		flagKernelSrc = flag.String("kernel_src", "", "path to kernel sources")
 		flagKernelObj = flag.String("kernel_obj", "", "path to kernel build/obj dir")
+		flagFormat    = flag.String("format", "html", "report format (html, lcov)")
+		flagOutput    = flag.String("o", "", "write the report to the file")
+		flagExport    = flag.Bool("manager_export", false, "input files were obtained from manager /rawcover")
 	)
 	flag.Parse()
 
@@ -56,29 +64,51 @@ func main() {
 	if target == nil {
 		failf("unknown target %v/%v", *flagOS, *flagArch)
 	}
+	if *flagFormat != "html" && *flagFormat != "lcov" {
+		failf("unknown report format %q", *flagFormat)
+	}
 	pcs, err := readPCs(flag.Args())
 	if err != nil {
 		failf("%v", err)
 	}
+	if *flagExport {
+		for i, pc := range pcs {
+			pcs[i] = cover.NextInstructionPC(*flagArch, pc)
+		}
+	}
 	kernelObj := filepath.Join(*flagKernelObj, target.KernelObject)
 	rg, err := cover.MakeReportGenerator(kernelObj, *flagKernelSrc, *flagArch)
 	if err != nil {
 		failf("%v", err)
 	}
 	buf := new(bytes.Buffer)
-	if err := rg.Do(buf, pcs); err != nil {
-		failf("%v", err)
+	if *flagFormat == "lcov" {
+		err = rg.DoLCOV(buf, pcs)
+	} else {
+		err = rg.Do(buf, pcs)
 	}
-	fn, err := osutil.TempFile("syz-cover")
 	if err != nil {
 		failf("%v", err)
 	}
-	fn += ".html"
-	if err := osutil.WriteFile(fn, buf.Bytes()); err != nil {
-		failf("%v", err)
-	}
-	if err := exec.Command("xdg-open", fn).Start(); err != nil {
-		failf("failed to start browser: %v", err)
+	switch {
+	case *flagOutput != "":
+		if err := osutil.WriteFile(*flagOutput, buf.Bytes()); err != nil {
+			failf("%v", err)
+		}
+	case *flagFormat == "lcov":
+		os.Stdout.Write(buf.Bytes())
+	default:
+		fn, err := osutil.TempFile("syz-cover")
+		if err != nil {
+			failf("%v", err)
+		}
+		fn += ".html"
+		if err := osutil.WriteFile(fn, buf.Bytes()); err != nil {
+			failf("%v", err)
+		}
+		if err := exec.Command("xdg-open", fn).Start(); err != nil {
+			failf("failed to start browser: %v", err)
+		}
 	}
 }
 
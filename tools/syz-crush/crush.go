@@ -2,15 +2,20 @@
 // Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
 
 // syz-crush replays crash log on multiple VMs. Usage:
-//   syz-crush -config=config.file execution.log
+//   syz-crush -config=config.file [-duration=1h] [-sweep=procs -sweep_values=1,4,8] execution.log
 // Intended for reproduction of particularly elusive crashes.
+// When finished (or interrupted) it prints crash probability, mean time to crash and crash titles.
+// With -sweep the VMs run with different values of the swept parameter,
+// which allows to figure out under what conditions a flaky reproducer works.
 package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/syzkaller/pkg/instance"
@@ -23,9 +28,23 @@ import (
 )
 
 var (
-	flagConfig = flag.String("config", "", "configuration file")
+	flagConfig      = flag.String("config", "", "configuration file")
+	flagDuration    = flag.Duration("duration", 0, "total run time (0 - run until interrupted)")
+	flagRestartTime = flag.Duration("restart_time", time.Hour, "restart VM if it did not crash for this long")
+	flagSweep       = flag.String("sweep", "", "parameter to sweep across VMs (procs, sandbox)")
+	flagSweepValues = flag.String("sweep_values", "", "comma-separated values of the swept parameter")
 )
 
+// crushConfig is the part of the manager config that is varied across VMs.
+type crushConfig struct {
+	procs   int
+	sandbox string
+}
+
+func (cc crushConfig) String() string {
+	return fmt.Sprintf("procs=%v sandbox=%v", cc.procs, cc.sandbox)
+}
+
 func main() {
 	flag.Parse()
 	cfg, err := mgrconfig.LoadFile(*flagConfig)
@@ -38,6 +57,10 @@ func main() {
 	if _, err := prog.GetTarget(cfg.TargetOS, cfg.TargetArch); err != nil {
 		log.Fatalf("%v", err)
 	}
+	configs, err := sweepConfigs(cfg, *flagSweep, *flagSweepValues)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 	vmPool, err := vm.Create(cfg, false)
 	if err != nil {
 		log.Fatalf("%v", err)
@@ -47,78 +70,162 @@ func main() {
 		log.Fatalf("%v", err)
 	}
 
+	stop := make(chan bool)
+	var stopOnce sync.Once
+	stopAll := func() { stopOnce.Do(func() { close(stop) }) }
+	shutdownC := make(chan struct{})
+	osutil.HandleInterrupts(shutdownC)
+	go func() {
+		<-shutdownC
+		stopAll()
+	}()
+	deadline := time.Time{}
+	if *flagDuration != 0 {
+		deadline = time.Now().Add(*flagDuration)
+		time.AfterFunc(*flagDuration, stopAll)
+	}
+
 	log.Logf(0, "booting test machines...")
-	var shutdown uint32
+	stats := newCrushStats(configs)
 	var wg sync.WaitGroup
-	wg.Add(vmPool.Count() + 1)
 	for i := 0; i < vmPool.Count(); i++ {
 		i := i
+		// Distribute the swept values across VMs, so that they run in parallel.
+		cc := configs[i%len(configs)]
+		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for {
-				runInstance(cfg, reporter, vmPool, i)
-				if atomic.LoadUint32(&shutdown) != 0 {
-					break
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				timeout := *flagRestartTime
+				if !deadline.IsZero() && time.Until(deadline) < timeout {
+					timeout = time.Until(deadline)
+				}
+				if timeout <= 0 {
+					return
 				}
+				stats.add(cc, runInstance(cfg, cc, reporter, vmPool, i, timeout, stop))
 			}
 		}()
 	}
-
-	shutdownC := make(chan struct{})
-	osutil.HandleInterrupts(shutdownC)
-	go func() {
-		<-shutdownC
-		wg.Done()
-		atomic.StoreUint32(&shutdown, 1)
-	}()
 	wg.Wait()
+	stats.print()
+}
+
+func sweepConfigs(cfg *mgrconfig.Config, sweep, values string) ([]crushConfig, error) {
+	base := crushConfig{
+		procs:   cfg.Procs,
+		sandbox: cfg.Sandbox,
+	}
+	if sweep == "" {
+		if values != "" {
+			return nil, fmt.Errorf("-sweep_values requires -sweep")
+		}
+		return []crushConfig{base}, nil
+	}
+	if values == "" {
+		return nil, fmt.Errorf("-sweep requires -sweep_values")
+	}
+	var configs []crushConfig
+	for _, val := range strings.Split(values, ",") {
+		cc := base
+		switch sweep {
+		case "procs":
+			procs, err := strconv.Atoi(val)
+			if err != nil || procs < 1 || procs > prog.MaxPids {
+				return nil, fmt.Errorf("bad procs value %q", val)
+			}
+			cc.procs = procs
+		case "sandbox":
+			switch val {
+			case "none", "setuid", "namespace", "android_untrusted_app":
+			default:
+				return nil, fmt.Errorf("bad sandbox value %q", val)
+			}
+			cc.sandbox = val
+		default:
+			return nil, fmt.Errorf("unknown sweep parameter %q, supported: procs, sandbox", sweep)
+		}
+		configs = append(configs, cc)
+	}
+	return configs, nil
 }
 
-func runInstance(cfg *mgrconfig.Config, reporter report.Reporter, vmPool *vm.Pool, index int) {
+// runResult is the outcome of a single reproducer run.
+type runResult struct {
+	failed   bool          // the run failed due to an infrastructure problem, it's not accounted
+	title    string        // crash title, empty if the VM did not crash
+	duration time.Duration // time from the reproducer start to the crash/restart
+}
+
+func runInstance(cfg *mgrconfig.Config, cc crushConfig, reporter report.Reporter, vmPool *vm.Pool,
+	index int, timeout time.Duration, stop <-chan bool) *runResult {
+	failed := &runResult{failed: true}
 	inst, err := vmPool.Create(index)
 	if err != nil {
 		log.Logf(0, "failed to create instance: %v", err)
-		return
+		return failed
 	}
 	defer inst.Close()
 
 	execprogBin, err := inst.Copy(cfg.SyzExecprogBin)
 	if err != nil {
 		log.Logf(0, "failed to copy execprog: %v", err)
-		return
+		return failed
 	}
 	executorBin, err := inst.Copy(cfg.SyzExecutorBin)
 	if err != nil {
 		log.Logf(0, "failed to copy executor: %v", err)
-		return
+		return failed
+	}
+	descriptionsFile := ""
+	if cfg.ExtraDescriptionsFile != "" {
+		descriptionsFile, err = inst.Copy(cfg.ExtraDescriptionsFile)
+		if err != nil {
+			log.Logf(0, "failed to copy descriptions: %v", err)
+			return failed
+		}
 	}
 	logFile, err := inst.Copy(flag.Args()[0])
 	if err != nil {
 		log.Logf(0, "failed to copy log: %v", err)
-		return
+		return failed
 	}
 
-	cmd := instance.ExecprogCmd(execprogBin, executorBin, cfg.TargetOS, cfg.TargetArch, cfg.Sandbox,
-		true, true, true, cfg.Procs, -1, -1, logFile)
-	outc, errc, err := inst.Run(time.Hour, nil, cmd)
+	cmd := instance.ExecprogCmd(execprogBin, executorBin, descriptionsFile, cfg.TargetOS, cfg.TargetArch, cc.sandbox,
+		true, true, true, cc.procs, -1, -1, cfg.Slowdown, logFile)
+	start := time.Now()
+	outc, errc, err := inst.Run(timeout, stop, cmd)
 	if err != nil {
 		log.Logf(0, "failed to run execprog: %v", err)
-		return
+		return failed
 	}
 
-	log.Logf(0, "vm-%v: crushing...", index)
+	log.Logf(0, "vm-%v: crushing with %v...", index, cc)
 	rep := inst.MonitorExecution(outc, errc, reporter, vm.ExitTimeout)
+	res := &runResult{duration: time.Since(start)}
 	if rep == nil {
+		if reason := inst.RecycleReason(); reason != "" {
+			log.Logf(0, "vm-%v: degraded (%v), restarting", index, reason)
+			return failed
+		}
 		// This is the only "OK" outcome.
 		log.Logf(0, "vm-%v: running long enough, restarting", index)
-	} else {
-		f, err := ioutil.TempFile(".", "syz-crush")
-		if err != nil {
-			log.Logf(0, "failed to create temp file: %v", err)
-			return
-		}
-		defer f.Close()
-		log.Logf(0, "vm-%v: crashed: %v, saving to %v", index, rep.Title, f.Name())
-		f.Write(rep.Output)
+		return res
+	}
+	res.title = rep.Title
+	f, err := ioutil.TempFile(".", "syz-crush")
+	if err != nil {
+		log.Logf(0, "failed to create temp file: %v", err)
+		return res
 	}
+	defer f.Close()
+	log.Logf(0, "vm-%v: crashed after %v: %v, saving to %v",
+		index, res.duration.Truncate(time.Second), rep.Title, f.Name())
+	f.Write(rep.Output)
+	return res
 }
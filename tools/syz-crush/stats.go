@@ -0,0 +1,92 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type crushStats struct {
+	mu      sync.Mutex
+	configs []crushConfig
+	stats   map[crushConfig]*configStats
+	failed  int
+}
+
+type configStats struct {
+	runs      int
+	crashes   int
+	runTime   time.Duration // total time of all runs
+	crashTime time.Duration // total time to crash of crashed runs
+	titles    map[string]int
+}
+
+func newCrushStats(configs []crushConfig) *crushStats {
+	cs := &crushStats{
+		configs: configs,
+		stats:   make(map[crushConfig]*configStats),
+	}
+	for _, cc := range configs {
+		cs.stats[cc] = &configStats{titles: make(map[string]int)}
+	}
+	return cs
+}
+
+func (cs *crushStats) add(cc crushConfig, res *runResult) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if res.failed {
+		cs.failed++
+		return
+	}
+	st := cs.stats[cc]
+	st.runs++
+	st.runTime += res.duration
+	if res.title != "" {
+		st.crashes++
+		st.crashTime += res.duration
+		st.titles[res.title]++
+	}
+}
+
+func (cs *crushStats) print() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	fmt.Printf("\nsummary:\n")
+	for _, cc := range cs.configs {
+		st := cs.stats[cc]
+		fmt.Printf("%v: %v runs, %v crashes", cc, st.runs, st.crashes)
+		if st.runs != 0 {
+			fmt.Printf(", crash probability %.1f%%", float64(st.crashes)*100/float64(st.runs))
+		}
+		if st.crashes != 0 {
+			mean := st.crashTime / time.Duration(st.crashes)
+			fmt.Printf(", mean time to crash %v", mean.Truncate(time.Second))
+		}
+		if st.runTime >= time.Minute {
+			fmt.Printf(", %.2f crashes/hour", float64(st.crashes)/st.runTime.Hours())
+		}
+		fmt.Printf("\n")
+		var titles []string
+		for title := range st.titles {
+			titles = append(titles, title)
+		}
+		sort.Slice(titles, func(i, j int) bool {
+			if st.titles[titles[i]] != st.titles[titles[j]] {
+				return st.titles[titles[i]] > st.titles[titles[j]]
+			}
+			return titles[i] < titles[j]
+		})
+		for _, title := range titles {
+			fmt.Printf("\t%5.1f%% %v: %v\n", float64(st.titles[title])*100/float64(st.crashes),
+				st.titles[title], title)
+		}
+	}
+	if cs.failed != 0 {
+		fmt.Printf("%v runs failed due to infrastructure errors and are not accounted\n", cs.failed)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys"
+)
+
+// Corpus exports are either a directory or a gzipped tarball with one file per program
+// (named by the program hash) and a manifest (see db.Manifest).
+// If target is specified, all programs are checked to parse for the target on export and import.
+
+// maxTarballSize limits size of the decompressed tarball on import.
+const maxTarballSize = 4 << 30
+
+func isTarball(file string) bool {
+	return strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz")
+}
+
+func export(file, out string, target *prog.Target) {
+	if !osutil.IsExist(file) {
+		failf("database %v does not exist", file)
+	}
+	corpus, err := db.Open(file)
+	if err != nil {
+		failf("failed to open database: %v", err)
+	}
+	targetName := ""
+	if target != nil {
+		targetName = target.OS + "/" + target.Arch
+		for key, rec := range corpus.Records {
+			if _, err := target.Deserialize(rec.Val, prog.NonStrict); err != nil {
+				failf("failed to deserialize %v: %v", key, err)
+			}
+		}
+	}
+	files, err := db.Export(corpus.Version, corpus.Records, targetName, sys.GitRevision)
+	if err != nil {
+		failf("%v", err)
+	}
+	if isTarball(out) {
+		buf := new(bytes.Buffer)
+		if err := db.WriteTarGzFiles(buf, files); err != nil {
+			failf("failed to create tarball: %v", err)
+		}
+		if err := osutil.WriteFile(out, buf.Bytes()); err != nil {
+			failf("failed to write tarball: %v", err)
+		}
+	} else {
+		if err := osutil.MkdirAll(out); err != nil {
+			failf("failed to create dir: %v", err)
+		}
+		for name, data := range files {
+			if err := osutil.WriteFile(filepath.Join(out, name), data); err != nil {
+				failf("failed to output file: %v", err)
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %v programs\n", len(files)-1)
+}
+
+func importCorpus(in, file string, target *prog.Target) {
+	files := make(map[string][]byte)
+	if isTarball(in) {
+		data, err := ioutil.ReadFile(in)
+		if err != nil {
+			failf("failed to read tarball: %v", err)
+		}
+		if files, err = db.ReadTarGzFiles(data, maxTarballSize); err != nil {
+			failf("failed to read tarball: %v", err)
+		}
+	} else {
+		entries, err := ioutil.ReadDir(in)
+		if err != nil {
+			failf("failed to read dir: %v", err)
+		}
+		for _, entry := range entries {
+			if !entry.Mode().IsRegular() {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(in, entry.Name()))
+			if err != nil {
+				failf("failed to read file %v: %v", entry.Name(), err)
+			}
+			files[entry.Name()] = data
+		}
+	}
+	manifest, records, err := db.Import(files)
+	if err != nil {
+		failf("invalid export: %v", err)
+	}
+	if target != nil {
+		if targetName := target.OS + "/" + target.Arch; manifest.Target != "" && manifest.Target != targetName {
+			failf("export is for target %v, not %v", manifest.Target, targetName)
+		}
+		for _, rec := range records {
+			if _, err := target.Deserialize(rec.Val, prog.NonStrict); err != nil {
+				failf("failed to deserialize program: %v\n%s", err, rec.Val)
+			}
+		}
+	}
+	if err := db.Create(file, manifest.Version, records); err != nil {
+		failf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %v programs exported at %v by revision %q for target %q\n",
+		len(records), manifest.Created.Format("2006-01-02 15:04:05 MST"), manifest.Revision, manifest.Target)
+}
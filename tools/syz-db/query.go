@@ -0,0 +1,156 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+)
+
+type filter struct {
+	call   *regexp.Regexp
+	minLen int
+	maxLen int
+}
+
+func makeFilter(call string, minLen, maxLen int) (*filter, error) {
+	f := &filter{
+		minLen: minLen,
+		maxLen: maxLen,
+	}
+	if call != "" {
+		re, err := regexp.Compile(call)
+		if err != nil {
+			return nil, fmt.Errorf("bad call regexp: %v", err)
+		}
+		f.call = re
+	}
+	if minLen < 0 || maxLen < 0 || maxLen != 0 && maxLen < minLen {
+		return nil, fmt.Errorf("bad program length range [%v, %v]", minLen, maxLen)
+	}
+	return f, nil
+}
+
+func (f *filter) empty() bool {
+	return f.call == nil && f.minLen == 0 && f.maxLen == 0
+}
+
+func (f *filter) match(p *prog.Prog) bool {
+	if len(p.Calls) < f.minLen || f.maxLen != 0 && len(p.Calls) > f.maxLen {
+		return false
+	}
+	if f.call == nil {
+		return true
+	}
+	for _, c := range p.Calls {
+		if f.call.MatchString(c.Meta.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+type callStat struct {
+	name  string
+	progs int // number of programs that contain the call
+	calls int // total number of calls
+}
+
+func query(cmd, file string, target *prog.Target, f *filter) {
+	if cmd == "delete" && f.empty() {
+		failf("delete requires at least one filter flag")
+	}
+	if !osutil.IsExist(file) {
+		failf("database %v does not exist", file)
+	}
+	corpus, err := db.Open(file)
+	if err != nil {
+		failf("failed to open database: %v", err)
+	}
+	keys := make([]string, 0, len(corpus.Records))
+	for key := range corpus.Records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	stats := make(map[string]*callStat)
+	broken, selected, totalLen := 0, 0, 0
+	for _, key := range keys {
+		rec := corpus.Records[key]
+		p, err := target.Deserialize(rec.Val, prog.NonStrict)
+		if err != nil {
+			broken++
+			continue
+		}
+		if !f.match(p) {
+			continue
+		}
+		selected++
+		totalLen += len(p.Calls)
+		switch cmd {
+		case "list":
+			var calls []string
+			for _, c := range p.Calls {
+				calls = append(calls, c.Meta.Name)
+			}
+			fmt.Printf("%v %v\n", key, strings.Join(calls, " "))
+		case "grep":
+			fmt.Printf("# %v\n%s\n", key, rec.Val)
+		case "stats":
+			seen := make(map[string]bool)
+			for _, c := range p.Calls {
+				stat := stats[c.Meta.Name]
+				if stat == nil {
+					stat = &callStat{name: c.Meta.Name}
+					stats[c.Meta.Name] = stat
+				}
+				stat.calls++
+				if !seen[c.Meta.Name] {
+					seen[c.Meta.Name] = true
+					stat.progs++
+				}
+			}
+		case "delete":
+			corpus.Delete(key)
+		}
+	}
+	if cmd == "stats" {
+		printStats(stats, selected, totalLen)
+	}
+	if cmd == "delete" {
+		if err := corpus.Flush(); err != nil {
+			failf("failed to save database: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "deleted %v programs\n", selected)
+	}
+	fmt.Fprintf(os.Stderr, "selected %v out of %v programs (%v can't be parsed)\n",
+		selected, len(keys), broken)
+}
+
+func printStats(stats map[string]*callStat, progs, totalLen int) {
+	var sorted []*callStat
+	for _, stat := range stats {
+		sorted = append(sorted, stat)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].progs != sorted[j].progs {
+			return sorted[i].progs > sorted[j].progs
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	for _, stat := range sorted {
+		fmt.Printf("%-50v progs %-8v calls %v\n", stat.name, stat.progs, stat.calls)
+	}
+	avg := 0.0
+	if progs != 0 {
+		avg = float64(totalLen) / float64(progs)
+	}
+	fmt.Printf("programs %v, distinct calls %v, average program length %.1f\n", progs, len(stats), avg)
+}
@@ -24,10 +24,13 @@ func main() {
 		flagVersion = flag.Uint64("version", 0, "database version")
 		flagOS      = flag.String("os", "", "target OS")
 		flagArch    = flag.String("arch", "", "target arch")
+		flagCall    = flag.String("call", "", "select programs that contain a call matching the regexp")
+		flagMinLen  = flag.Int("min_len", 0, "select programs with at least that many calls")
+		flagMaxLen  = flag.Int("max_len", 0, "select programs with at most that many calls")
 	)
 	flag.Parse()
 	args := flag.Args()
-	if len(args) != 3 {
+	if len(args) == 0 {
 		usage()
 	}
 	var target *prog.Target
@@ -40,9 +43,37 @@ func main() {
 	}
 	switch args[0] {
 	case "pack":
+		if len(args) != 3 {
+			usage()
+		}
 		pack(args[1], args[2], target, *flagVersion)
 	case "unpack":
+		if len(args) != 3 {
+			usage()
+		}
 		unpack(args[1], args[2])
+	case "export":
+		if len(args) != 3 {
+			usage()
+		}
+		export(args[1], args[2], target)
+	case "import":
+		if len(args) != 3 {
+			usage()
+		}
+		importCorpus(args[1], args[2], target)
+	case "list", "grep", "stats", "delete":
+		if len(args) != 2 {
+			usage()
+		}
+		if target == nil {
+			failf("%v requires -os and -arch flags", args[0])
+		}
+		filter, err := makeFilter(*flagCall, *flagMinLen, *flagMaxLen)
+		if err != nil {
+			failf("%v", err)
+		}
+		query(args[0], args[1], target, filter)
 	default:
 		usage()
 	}
@@ -52,6 +83,14 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "usage:\n")
 	fmt.Fprintf(os.Stderr, "  syz-db pack dir corpus.db\n")
 	fmt.Fprintf(os.Stderr, "  syz-db unpack corpus.db dir\n")
+	fmt.Fprintf(os.Stderr, "  syz-db [-os=OS -arch=ARCH] export corpus.db dir|corpus.tar.gz\n")
+	fmt.Fprintf(os.Stderr, "  syz-db [-os=OS -arch=ARCH] import dir|corpus.tar.gz corpus.db\n")
+	fmt.Fprintf(os.Stderr, "  syz-db -os=OS -arch=ARCH [filter flags] list corpus.db\n")
+	fmt.Fprintf(os.Stderr, "  syz-db -os=OS -arch=ARCH [filter flags] grep corpus.db\n")
+	fmt.Fprintf(os.Stderr, "  syz-db -os=OS -arch=ARCH [filter flags] stats corpus.db\n")
+	fmt.Fprintf(os.Stderr, "  syz-db -os=OS -arch=ARCH filter flags delete corpus.db\n")
+	fmt.Fprintf(os.Stderr, "filter flags: -call=regexp -min_len=N -max_len=N\n")
+	fmt.Fprintf(os.Stderr, "programs that can't be parsed for the target are never selected\n")
 	os.Exit(1)
 }
 
@@ -0,0 +1,280 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-descgen generates draft descriptions for ioctl commands and generic netlink families
+// that are not yet described. Struct layouts are taken from the kernel BTF (CONFIG_DEBUG_INFO_BTF=y)
+// or DWARF (CONFIG_DEBUG_INFO=y), netlink attributes are taken from the policy dumps
+// (supported since v5.8). Usage (from syzkaller checkout):
+//
+//	# inside of the test machine:
+//	syz-descgen -dump_netlink > netlink.json
+//	# on the host:
+//	syz-descgen -vmlinux=$KERNEL_BUILD/vmlinux -netlink=netlink.json \
+//		-headers="$KERNEL/include/uapi/linux/*.h" -out=drafts
+//
+// For every header with undescribed ioctl commands the tool writes drafts/auto_ioctl_HEADER.txt,
+// for every undescribed netlink family drafts/auto_genl_FAMILY.txt. Drafts are compiled
+// together with the existing descriptions, and everything that could not be inferred
+// (pointer directions, unknown names, struct size mismatches, compilation errors)
+// is marked with TODO comments. Drafts need to be reviewed and moved to sys/linux.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/pkg/compiler"
+	"github.com/google/syzkaller/pkg/ktypes"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+var (
+	flagArch        = flag.String("arch", runtime.GOARCH, "target arch")
+	flagVmlinux     = flag.String("vmlinux", "", "kernel binary with BTF or DWARF debug info")
+	flagDWARF       = flag.Bool("dwarf", false, "use DWARF even if BTF is present")
+	flagHeaders     = flag.String("headers", "", "comma-separated list of globs of headers with ioctl commands")
+	flagNetlink     = flag.String("netlink", "", "netlink policy dump produced with -dump_netlink")
+	flagDumpNetlink = flag.Bool("dump_netlink", false, "dump netlink policies of the running kernel to stdout")
+	flagFilter      = flag.String("filter", "", "generate only ioctl commands/netlink families matching the regexp")
+	flagOut         = flag.String("out", ".", "output dir for drafts")
+)
+
+var filterRe *regexp.Regexp
+
+func matches(name string) bool {
+	return filterRe == nil || filterRe.MatchString(name)
+}
+
+func main() {
+	flag.Parse()
+	if *flagDumpNetlink {
+		families, err := dumpNetlink()
+		if err != nil {
+			failf("%v", err)
+		}
+		data, err := json.MarshalIndent(families, "", "\t")
+		if err != nil {
+			failf("%v", err)
+		}
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if *flagHeaders == "" && *flagNetlink == "" {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if *flagFilter != "" {
+		var err error
+		if filterRe, err = regexp.Compile(*flagFilter); err != nil {
+			failf("bad filter: %v", err)
+		}
+	}
+	target := targets.Get("linux", *flagArch)
+	if target == nil {
+		failf("unknown arch %v", *flagArch)
+	}
+	top := ast.ParseGlob(filepath.Join("sys", "linux", "*.txt"), nil)
+	if top == nil {
+		failf("failed to parse descriptions (not in syzkaller checkout?)")
+	}
+	g := &generator{
+		existing: make(map[string]bool),
+		structs:  make(map[string]*ktypes.Type),
+	}
+	for _, node := range top.Nodes {
+		if _, isCall := node.(*ast.Call); isCall {
+			continue
+		}
+		if _, _, name := node.Info(); name != "" {
+			g.existing[name] = true
+		}
+	}
+	if *flagVmlinux != "" {
+		var err error
+		if g.kt, err = ktypes.Load(*flagVmlinux, *flagDWARF); err != nil {
+			failf("%v", err)
+		}
+	}
+	var drafts []*draft
+	if *flagHeaders != "" {
+		if g.kt == nil {
+			fmt.Fprintf(os.Stderr, "no -vmlinux, ioctl argument types won't be generated\n")
+		}
+		described := describedConsts(top, target)
+		for _, glob := range strings.Split(*flagHeaders, ",") {
+			files, err := filepath.Glob(glob)
+			if err != nil {
+				failf("bad glob %v: %v", glob, err)
+			}
+			for _, file := range files {
+				d, err := g.genIoctls(file, described)
+				if err != nil {
+					failf("%v", err)
+				}
+				if d != nil {
+					drafts = append(drafts, d)
+				}
+			}
+		}
+	}
+	if *flagNetlink != "" {
+		data, err := ioutil.ReadFile(*flagNetlink)
+		if err != nil {
+			failf("%v", err)
+		}
+		var families []*nlFamily
+		if err := json.Unmarshal(data, &families); err != nil {
+			failf("failed to parse %v: %v", *flagNetlink, err)
+		}
+		described := describedFamilies(top)
+		for _, fam := range families {
+			// nlctrl is the controller that is used to get family ids.
+			if described[fam.Name] || fam.Name == "nlctrl" || len(fam.Ops) == 0 || !matches(fam.Name) {
+				continue
+			}
+			drafts = append(drafts, g.genNetlink(fam))
+		}
+	}
+	g.validate(top, drafts, target)
+	if err := osutil.MkdirAll(*flagOut); err != nil {
+		failf("%v", err)
+	}
+	for _, d := range drafts {
+		data, _ := d.render()
+		desc := ast.Parse(data, d.file, func(pos ast.Pos, msg string) {})
+		if desc != nil {
+			data = ast.Format(desc)
+		}
+		file := filepath.Join(*flagOut, d.file)
+		if err := osutil.WriteFile(file, data); err != nil {
+			failf("%v", err)
+		}
+		todos := 0
+		for _, it := range d.items {
+			todos += len(it.todos) + strings.Count(it.text, "# TODO:")
+		}
+		fmt.Printf("%v: %v declarations, %v TODOs\n", file, len(d.items), todos)
+	}
+}
+
+// describedConsts returns all consts used in the descriptions (ioctl commands in particular).
+func describedConsts(top *ast.Description, target *targets.Target) map[string]bool {
+	infos := compiler.ExtractConsts(top, target, nil)
+	if infos == nil {
+		failf("failed to compile descriptions")
+	}
+	res := make(map[string]bool)
+	for _, info := range infos {
+		for _, name := range info.Consts {
+			res[name] = true
+		}
+	}
+	return res
+}
+
+var familyNameRe = regexp.MustCompile(`string\["([^"]+)"\]`)
+
+// describedFamilies returns names of generic netlink families used in the descriptions.
+func describedFamilies(top *ast.Description) map[string]bool {
+	res := make(map[string]bool)
+	for _, node := range top.Nodes {
+		if call, ok := node.(*ast.Call); ok && call.CallName == "syz_genetlink_get_family_id" {
+			if match := familyNameRe.FindStringSubmatch(ast.SerializeNode(call)); match != nil {
+				res[match[1]] = true
+			}
+		}
+	}
+	return res
+}
+
+// validate compiles drafts with the existing descriptions and adds TODOs for compilation errors
+// and for structs that have size different from the kernel.
+func (g *generator) validate(top *ast.Description, drafts []*draft, target *targets.Target) {
+	lines := make(map[string]map[int]*item)
+	// Errors in the existing descriptions are printed only if they break compilation
+	// (warnings are not interesting here).
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		if it := lines[pos.File][pos.Line]; it != nil {
+			addTodo(it, "compiler: "+msg)
+			return
+		}
+		errors = append(errors, fmt.Sprintf("%v: %v", pos, msg))
+	}
+	desc := &ast.Description{Nodes: append([]ast.Node{}, top.Nodes...)}
+	for _, d := range drafts {
+		data, dlines := d.render()
+		lines[d.file] = dlines
+		if dd := ast.Parse(data, d.file, eh); dd != nil {
+			desc.Nodes = append(desc.Nodes, dd.Nodes...)
+		}
+	}
+	failed := func() {
+		sort.Strings(errors)
+		for _, err := range errors {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "failed to compile drafts with the existing descriptions, sizes are not checked\n")
+	}
+	consts := compiler.DeserializeConstsGlob(filepath.Join("sys", "linux", "*_"+target.Arch+".const"), eh)
+	infos := compiler.ExtractConsts(desc, target, eh)
+	if consts == nil || infos == nil {
+		failed()
+		return
+	}
+	// Values of new consts are not known until make extract, but they don't affect compilation.
+	for _, info := range infos {
+		for _, name := range info.Consts {
+			if _, ok := consts[name]; !ok && g.kt != nil {
+				consts[name] = uint64(g.kt.Enumerators[name])
+			} else if !ok {
+				consts[name] = 0
+			}
+		}
+	}
+	prg := compiler.Compile(desc, consts, target, eh)
+	if prg == nil {
+		failed()
+		return
+	}
+	items := make(map[string]*item)
+	for _, d := range drafts {
+		for _, it := range d.items {
+			items[it.name] = it
+		}
+	}
+	for _, s := range prg.StructDescs {
+		kt, it := g.structs[s.Key.Name], items[s.Key.Name]
+		if kt == nil || it == nil || s.Desc.Varlen() || kt.Size <= 0 {
+			continue
+		}
+		if size := s.Desc.Size(); size != uint64(kt.Size) {
+			addTodo(it, fmt.Sprintf("size is %v, but kernel size is %v (check padding and alignment)",
+				size, kt.Size))
+		}
+	}
+}
+
+func addTodo(it *item, todo string) {
+	for _, t := range it.todos {
+		if t == todo {
+			return
+		}
+	}
+	it.todos = append(it.todos, todo)
+}
+
+func failf(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,267 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ktypes"
+)
+
+// draft is a generated description file.
+type draft struct {
+	file     string
+	source   string
+	includes []string
+	items    []*item
+}
+
+// item is a top-level declaration in a draft along with the things that need manual attention.
+type item struct {
+	name  string
+	todos []string
+	text  string
+}
+
+func (d *draft) add(name, text string, todos ...string) *item {
+	it := &item{name: name, text: text, todos: todos}
+	d.items = append(d.items, it)
+	return it
+}
+
+// render returns contents of the description file and the item for each line.
+func (d *draft) render() ([]byte, map[int]*item) {
+	buf := new(bytes.Buffer)
+	lines := make(map[int]*item)
+	line := 1
+	write := func(it *item, s string) {
+		buf.WriteString(s)
+		for n := strings.Count(s, "\n"); n > 0; n-- {
+			lines[line] = it
+			line++
+		}
+	}
+	write(nil, "# Copyright 2020 syzkaller project authors. All rights reserved.\n"+
+		"# Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.\n\n")
+	write(nil, fmt.Sprintf("# Draft descriptions generated by syz-descgen from %v.\n", d.source))
+	write(nil, "# Fix the TODOs, then run make extract and make generate.\n\n")
+	for _, inc := range d.includes {
+		write(nil, fmt.Sprintf("include <%v>\n", inc))
+	}
+	for _, it := range d.items {
+		write(it, "\n")
+		for _, todo := range it.todos {
+			write(it, fmt.Sprintf("# TODO: %v\n", todo))
+		}
+		write(it, it.text)
+	}
+	return buf.Bytes(), lines
+}
+
+// generator converts kernel types into description types.
+type generator struct {
+	kt       *ktypes.Types
+	existing map[string]bool // types declared in existing descriptions
+	// Structs/unions generated so far and their kernel types (used for layout validation).
+	structs map[string]*ktypes.Type
+}
+
+var identRe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// reservedNames can't be used as struct names because they are builtin types or keywords.
+var reservedNames = map[string]bool{
+	"array": true, "bool8": true, "bool16": true, "bool32": true, "bool64": true, "boolptr": true,
+	"bytesize": true, "bitsize": true, "const": true, "csum": true, "fd": true, "filename": true,
+	"flags": true, "fmt": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"intptr": true, "len": true, "offsetof": true, "opt": true, "parent": true, "proc": true,
+	"ptr": true, "ptr64": true, "string": true, "stringnoz": true, "text": true, "vma": true,
+	"vma64": true, "void": true,
+}
+
+func syzName(name string) string {
+	name = identRe.ReplaceAllString(name, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' || reservedNames[name] {
+		name = "_" + name
+	}
+	return name
+}
+
+// typedefTypes are kernel typedefs that have more precise description types.
+var typedefTypes = map[string]string{
+	"pid_t":            "pid",
+	"__kernel_pid_t":   "pid",
+	"uid_t":            "uid",
+	"__kernel_uid_t":   "uid",
+	"__kernel_uid32_t": "uid",
+	"gid_t":            "gid",
+	"__kernel_gid_t":   "gid",
+	"__kernel_gid32_t": "gid",
+	"__be16":           "int16be",
+	"__be32":           "int32be",
+	"__be64":           "int64be",
+	"__aligned_u64":    "int64",
+}
+
+// resolve skips typedefs.
+func resolve(t *ktypes.Type) *ktypes.Type {
+	for t.Kind == ktypes.KindTypedef && t.Elem != nil {
+		t = t.Elem
+	}
+	return t
+}
+
+func intType(size int64) string {
+	switch size {
+	case 1, 2, 4, 8:
+		return fmt.Sprintf("int%v", size*8)
+	}
+	return fmt.Sprintf("array[int8, %v]", size)
+}
+
+// typeRef returns description type for the kernel type and an explanation
+// if the type could not be inferred precisely. Structs/unions are generated into d on demand,
+// anonymous ones are named after ctx.
+func (g *generator) typeRef(t *ktypes.Type, ctx string, d *draft) (string, string) {
+	switch t.Kind {
+	case ktypes.KindTypedef:
+		if typ := typedefTypes[t.Name]; typ != "" {
+			return typ, ""
+		}
+		return g.typeRef(t.Elem, ctx, d)
+	case ktypes.KindInt:
+		if t.Name == "_Bool" {
+			return "bool8", ""
+		}
+		return intType(t.Size), ""
+	case ktypes.KindEnum:
+		return intType(t.Size), ""
+	case ktypes.KindPtr:
+		elem := resolve(t.Elem)
+		switch {
+		case elem.Kind == ktypes.KindInt && elem.Size == 1:
+			return "ptr[in, string]", "pointer to char: check that it's a string"
+		case elem.Kind == ktypes.KindStruct || elem.Kind == ktypes.KindUnion:
+			typ, _ := g.typeRef(elem, ctx, d)
+			return fmt.Sprintf("ptr[inout, %v]", typ), "pointer: specify direction"
+		case elem.Kind == ktypes.KindFunc:
+			return "intptr", "function pointer"
+		}
+		return "ptr[inout, array[int8]]", "pointer: specify direction and pointee type"
+	case ktypes.KindArray:
+		elem, what := g.typeRef(t.Elem, ctx, d)
+		if t.Count <= 0 {
+			return fmt.Sprintf("array[%v]", elem), what
+		}
+		if e := resolve(t.Elem); e.Kind == ktypes.KindInt && e.Size == 1 && what == "" {
+			what = "char array: check if it's a string"
+		}
+		return fmt.Sprintf("array[%v, %v]", elem, t.Count), what
+	case ktypes.KindStruct, ktypes.KindUnion:
+		name := t.Name
+		if name == "" {
+			name = ctx
+		}
+		return g.structRef(t, name, d), ""
+	}
+	if t.Size > 0 {
+		return fmt.Sprintf("array[int8, %v]", t.Size), "unknown type"
+	}
+	return "void", "unknown type"
+}
+
+// structRef generates struct/union t (if it's not yet described) and returns its name.
+func (g *generator) structRef(t *ktypes.Type, name string, d *draft) string {
+	name = syzName(name)
+	if g.existing[name] || g.structs[name] != nil {
+		return name
+	}
+	g.structs[name] = t
+	isUnion := t.Kind == ktypes.KindUnion
+	buf := new(bytes.Buffer)
+	lbrace, rbrace := "{", "}"
+	if isUnion {
+		lbrace, rbrace = "[", "]"
+	}
+	fmt.Fprintf(buf, "%v %v\n", name, lbrace)
+	var todos []string
+	packed := false
+	for i, f := range t.Fields {
+		fname := f.Name
+		if fname == "" {
+			fname = fmt.Sprintf("anon%v", i)
+		}
+		fname = syzName(fname)
+		typ, what := g.typeRef(f.Type, name+"_"+fname, d)
+		if f.BitSize != 0 {
+			typ = fmt.Sprintf("%v:%v", intType(resolve(f.Type).Size), f.BitSize)
+		} else if align := g.align(f.Type); align > 1 && f.Off%(align*8) != 0 {
+			packed = true
+		}
+		if what != "" {
+			fmt.Fprintf(buf, "# TODO: %v: %v\n", fname, what)
+		}
+		fmt.Fprintf(buf, "\t%v\t%v\n", fname, typ)
+	}
+	if len(t.Fields) == 0 {
+		todos = append(todos, "kernel type has no fields")
+		fmt.Fprintf(buf, "\tdata\tarray[int8, %v]\n", t.Size)
+	}
+	if align := g.align(t); !isUnion && align > 1 && t.Size%align != 0 {
+		packed = true
+	}
+	fmt.Fprintf(buf, "%v", rbrace)
+	if packed {
+		fmt.Fprintf(buf, " [packed]")
+	}
+	fmt.Fprintf(buf, "\n")
+	d.add(name, buf.String(), todos...)
+	return name
+}
+
+// align returns natural alignment of the type.
+func (g *generator) align(t *ktypes.Type) int64 {
+	t = resolve(t)
+	switch t.Kind {
+	case ktypes.KindInt, ktypes.KindEnum, ktypes.KindPtr:
+		return t.Size
+	case ktypes.KindArray:
+		return g.align(t.Elem)
+	case ktypes.KindStruct, ktypes.KindUnion:
+		align := int64(1)
+		for _, f := range t.Fields {
+			if a := g.align(f.Type); a > align {
+				align = a
+			}
+		}
+		return align
+	}
+	return 1
+}
+
+// enumName returns name of the enumerator with the value that has one of the prefixes.
+func (g *generator) enumName(prefixes []string, val int64) string {
+	if g.kt == nil {
+		return ""
+	}
+	var names []string
+	for name, v := range g.kt.Enumerators {
+		if v != val {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
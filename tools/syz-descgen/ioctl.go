@@ -0,0 +1,191 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ioctlCmd is an ioctl command defined in a header with _IO/_IOR/_IOW/_IOWR.
+type ioctlCmd struct {
+	name string
+	dir  string // "", "R", "W" or "WR"
+	arg  string // C type of the argument
+}
+
+var ioctlDefineRe = regexp.MustCompile(`^\s*#\s*define\s+([A-Za-z0-9_]+)\s+_IO(R|W|WR)?\s*\((.*)\)\s*(/\*.*)?$`)
+
+func parseIoctls(data []byte) []*ioctlCmd {
+	var cmds []*ioctlCmd
+	dups := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		match := ioctlDefineRe.FindStringSubmatch(s.Text())
+		if match == nil || dups[match[1]] {
+			continue
+		}
+		args := splitArgs(match[3])
+		cmd := &ioctlCmd{name: match[1], dir: match[2]}
+		switch {
+		case cmd.dir == "" && len(args) == 2:
+		case cmd.dir != "" && len(args) == 3:
+			cmd.arg = args[2]
+		default:
+			continue
+		}
+		dups[cmd.name] = true
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// splitArgs splits macro arguments on top-level commas.
+func splitArgs(s string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(args, strings.TrimSpace(s[start:]))
+}
+
+// headerInclude returns include path for the header (e.g. uapi/linux/foo.h).
+func headerInclude(file string) string {
+	file = filepath.ToSlash(file)
+	if pos := strings.LastIndex(file, "/include/"); pos != -1 {
+		return file[pos+len("/include/"):]
+	}
+	return filepath.Base(file)
+}
+
+// genIoctls generates a draft for undescribed ioctl commands in the header.
+func (g *generator) genIoctls(header string, described map[string]bool) (*draft, error) {
+	data, err := ioutil.ReadFile(header)
+	if err != nil {
+		return nil, err
+	}
+	base := syzName(strings.TrimSuffix(filepath.Base(header), ".h"))
+	d := &draft{
+		file:     "auto_ioctl_" + base + ".txt",
+		source:   headerInclude(header),
+		includes: []string{headerInclude(header)},
+	}
+	fd := "fd_" + base
+	var calls []*item
+	for _, cmd := range parseIoctls(data) {
+		if described[cmd.name] || !matches(cmd.name) {
+			continue
+		}
+		call := "ioctl$" + cmd.name
+		var todos []string
+		arg := "arg intptr"
+		if cmd.dir != "" {
+			typ, what := g.cType(cmd.arg, strings.ToLower(cmd.name), d)
+			if what != "" {
+				todos = append(todos, fmt.Sprintf("argument %v: %v", cmd.arg, what))
+			}
+			dir := map[string]string{"R": "out", "W": "in", "WR": "inout"}[cmd.dir]
+			arg = fmt.Sprintf("arg ptr[%v, %v]", dir, typ)
+		}
+		calls = append(calls, &item{
+			name:  call,
+			text:  fmt.Sprintf("%v(fd %v, cmd const[%v], %v)\n", call, fd, cmd.name, arg),
+			todos: todos,
+		})
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	if !g.existing[fd] {
+		// Resources and calls go first.
+		d.items = append([]*item{{
+			name: fd,
+			text: fmt.Sprintf("resource %v[fd]\n\nopenat$%v(fd const[AT_FDCWD], file ptr[in, string[\"/dev/%v\"]],"+
+				" flags flags[open_flags], mode const[0]) %v\n", fd, base, base, fd),
+			todos: []string{"check how the fd is created, the device name is a guess"},
+		}}, append(calls, d.items...)...)
+	} else {
+		d.items = append(calls, d.items...)
+	}
+	return d, nil
+}
+
+// cTypes are C types that can be used as ioctl arguments.
+var cTypes = map[string]string{
+	"char":               "int8",
+	"signed char":        "int8",
+	"unsigned char":      "int8",
+	"short":              "int16",
+	"unsigned short":     "int16",
+	"int":                "int32",
+	"unsigned int":       "int32",
+	"unsigned":           "int32",
+	"long":               "intptr",
+	"unsigned long":      "intptr",
+	"long long":          "int64",
+	"unsigned long long": "int64",
+	"size_t":             "intptr",
+	"__u8":               "int8",
+	"__s8":               "int8",
+	"__u16":              "int16",
+	"__s16":              "int16",
+	"__le16":             "int16",
+	"__u32":              "int32",
+	"__s32":              "int32",
+	"__le32":             "int32",
+	"__u64":              "int64",
+	"__s64":              "int64",
+	"__le64":             "int64",
+	"__kernel_size_t":    "intptr",
+}
+
+var cArrayRe = regexp.MustCompile(`^(.*)\[([0-9]+)\]$`)
+
+// cType returns description type for a C type name used in an ioctl command.
+func (g *generator) cType(name, ctx string, d *draft) (string, string) {
+	name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "const "))
+	if match := cArrayRe.FindStringSubmatch(name); match != nil {
+		elem, what := g.cType(match[1], ctx, d)
+		return fmt.Sprintf("array[%v, %v]", elem, match[2]), what
+	}
+	if strings.HasSuffix(name, "*") {
+		return "intptr", "pointer argument: describe the pointee"
+	}
+	if typ := typedefTypes[name]; typ != "" {
+		return typ, ""
+	}
+	if typ := cTypes[name]; typ != "" {
+		return typ, ""
+	}
+	if g.kt == nil {
+		return "array[int8]", "no kernel debug info"
+	}
+	if strings.HasPrefix(name, "struct ") || strings.HasPrefix(name, "union ") {
+		tag := strings.TrimSpace(name[strings.IndexByte(name, ' '):])
+		if t := g.kt.Struct(tag); t != nil {
+			return g.typeRef(t, ctx, d)
+		}
+		return "array[int8]", "type is not present in the debug info"
+	}
+	if t := g.kt.Typedef(name); t != nil {
+		return g.typeRef(t, ctx, d)
+	}
+	return "array[int8]", "unknown type"
+}
@@ -0,0 +1,219 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nlFamily is a generic netlink family along with its policies as dumped by the kernel
+// (CTRL_CMD_GETFAMILY and CTRL_CMD_GETPOLICY, the latter is supported since v5.8).
+type nlFamily struct {
+	Name     string
+	ID       int
+	Version  int
+	HdrSize  int
+	MaxAttr  int
+	Ops      []*nlOp
+	Policies map[int]map[int]*nlAttrPolicy // policy index -> attribute -> policy
+}
+
+type nlOp struct {
+	Cmd int
+	// Indexes of policies used by the command, -1 if the command does not accept attributes.
+	// Per-command policies are dumped since v5.12, on older kernels all commands use policy 0.
+	DoPolicy   int
+	DumpPolicy int
+}
+
+// nlAttrPolicy corresponds to NL_POLICY_TYPE_ATTR_* attributes.
+type nlAttrPolicy struct {
+	Type      string  // netlink_attribute_type without the NL_ATTR_TYPE_ prefix, lower case
+	MinValueS *int64  `json:",omitempty"`
+	MaxValueS *int64  `json:",omitempty"`
+	MinValueU *uint64 `json:",omitempty"`
+	MaxValueU *uint64 `json:",omitempty"`
+	MinLength *uint32 `json:",omitempty"`
+	MaxLength *uint32 `json:",omitempty"`
+	PolicyIdx *int    `json:",omitempty"`
+}
+
+var nlAttrTypes = []string{"invalid", "flag", "u8", "u16", "u32", "u64", "s8", "s16", "s32", "s64",
+	"binary", "string", "nul_string", "nested", "nested_array", "bitfield32"}
+
+// genNetlink generates a draft for a generic netlink family.
+func (g *generator) genNetlink(fam *nlFamily) *draft {
+	name := syzName(strings.ToLower(fam.Name))
+	prefix := strings.ToUpper(name)
+	d := &draft{
+		file:     "auto_genl_" + name + ".txt",
+		source:   fmt.Sprintf("policy dump of %q generic netlink family", fam.Name),
+		includes: []string{"linux/net.h", "uapi/linux/netlink.h", "uapi/linux/genetlink.h"},
+	}
+	id := "genl_" + name + "_family_id"
+	msghdr := "msghdr_nl_" + name
+	payload := "genlmsghdr_t[CMD]"
+	var todos []string
+	var payloadDecl string
+	if fam.HdrSize != 0 {
+		payload = name + "_genlmsghdr[CMD]"
+		payloadDecl = fmt.Sprintf("\ntype %v {\n\tgenl\tgenlmsghdr_t[CMD]\n\thdr\tarray[int8, %v]\n}\n",
+			payload, fam.HdrSize)
+		todos = append(todos, "describe the family header")
+	}
+	// Policy names used by commands.
+	policyNames := make(map[int]string)
+	policyName := func(idx int) string {
+		if idx < 0 || len(fam.Policies[idx]) == 0 {
+			return "void"
+		}
+		if policyNames[idx] == "" {
+			policyNames[idx] = fmt.Sprintf("%v_policy%v", name, idx)
+			if len(fam.Policies) == 1 {
+				policyNames[idx] = name + "_policy"
+			}
+		}
+		return policyNames[idx]
+	}
+	single := true
+	for _, op := range fam.Ops {
+		if op.DoPolicy != fam.Ops[0].DoPolicy {
+			single = false
+		}
+	}
+	header := new(bytes.Buffer)
+	fmt.Fprintf(header, "resource %v[int16]\n", id)
+	if single && len(fam.Ops) != 0 {
+		fmt.Fprintf(header, "type %v[CMD] msghdr_netlink[netlink_msg_t[%v, %v, %v]]\n",
+			msghdr, id, payload, policyName(fam.Ops[0].DoPolicy))
+	} else {
+		fmt.Fprintf(header, "type %v[CMD, POLICY] msghdr_netlink[netlink_msg_t[%v, %v, POLICY]]\n",
+			msghdr, id, payload)
+	}
+	fmt.Fprintf(header, "\nsyz_genetlink_get_family_id$%v(name ptr[in, string[\"%v\"]]) %v\n",
+		name, fam.Name, id)
+	d.add(id, header.String()+payloadDecl, todos...)
+	for _, op := range fam.Ops {
+		var todos []string
+		cmd := g.enumName([]string{prefix + "_CMD_", prefix + "_C_"}, int64(op.Cmd))
+		call := "sendmsg$" + cmd
+		if cmd == "" {
+			cmd = fmt.Sprint(op.Cmd)
+			call = fmt.Sprintf("sendmsg$%v_cmd%v", name, op.Cmd)
+			todos = append(todos, "command name is unknown")
+		}
+		msg := fmt.Sprintf("%v[%v]", msghdr, cmd)
+		if !single {
+			msg = fmt.Sprintf("%v[%v, %v]", msghdr, cmd, policyName(op.DoPolicy))
+		}
+		d.add(call, fmt.Sprintf("%v(fd sock_nl_generic, msg ptr[in, %v], f flags[send_flags])\n", call, msg), todos...)
+	}
+	// Nested policies are named on demand while generating policies.
+	for done := make(map[int]bool); ; {
+		idx := -1
+		for i := range policyNames {
+			if !done[i] && (idx == -1 || i < idx) {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			break
+		}
+		done[idx] = true
+		d.add(policyNames[idx], g.nlPolicy(prefix, policyNames[idx], fam.Policies[idx], policyName))
+	}
+	return d
+}
+
+func (g *generator) nlPolicy(prefix, name string, attrs map[int]*nlAttrPolicy, policyName func(int) string) string {
+	var ids []int
+	for id := range attrs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%v [\n", name)
+	for _, id := range ids {
+		attr := attrs[id]
+		typ, what := nlAttrType(attr, policyName)
+		if typ == "" {
+			// NL_ATTR_TYPE_INVALID: the attribute is rejected.
+			continue
+		}
+		attrName := g.enumName([]string{prefix + "_ATTR_", prefix + "_A_"}, int64(id))
+		fieldName, attrConst := attrName, attrName
+		if attrName == "" {
+			fieldName, attrConst = fmt.Sprintf("attr%v", id), fmt.Sprint(id)
+			if what == "" {
+				what = "attribute name is unknown"
+			} else {
+				what = "attribute name is unknown, " + what
+			}
+		}
+		if what != "" {
+			fmt.Fprintf(buf, "# TODO: %v: %v\n", fieldName, what)
+		}
+		templ := "nlattr"
+		if attr.Type == "nested" || attr.Type == "nested_array" {
+			// Strict validation requires NLA_F_NESTED.
+			templ = "nlnest"
+		}
+		fmt.Fprintf(buf, "\t%v\t%v[%v, %v]\n", fieldName, templ, attrConst, typ)
+	}
+	fmt.Fprintf(buf, "] [varlen]\n")
+	return buf.String()
+}
+
+// nlAttrType returns description type of the attribute payload.
+func nlAttrType(attr *nlAttrPolicy, policyName func(int) string) (string, string) {
+	nested := "void"
+	if attr.PolicyIdx != nil {
+		nested = policyName(*attr.PolicyIdx)
+	}
+	switch attr.Type {
+	case "invalid":
+		return "", ""
+	case "flag":
+		return "void", ""
+	case "u8", "u16", "u32", "u64":
+		bits := strings.TrimPrefix(attr.Type, "u")
+		typ := "int" + bits
+		// The kernel dumps full range of the type for attributes without range checks.
+		full := map[string]uint64{"8": 1<<8 - 1, "16": 1<<16 - 1, "32": 1<<32 - 1, "64": 1<<64 - 1}[bits]
+		if attr.MinValueU != nil && attr.MaxValueU != nil && (*attr.MinValueU != 0 || *attr.MaxValueU != full) {
+			typ += fmt.Sprintf("[%v:%v]", *attr.MinValueU, *attr.MaxValueU)
+		}
+		return typ, ""
+	case "s8", "s16", "s32", "s64":
+		typ := "int" + strings.TrimPrefix(attr.Type, "s")
+		// Negative ranges can't be expressed in descriptions (this also skips full type ranges).
+		if attr.MinValueS != nil && attr.MaxValueS != nil && *attr.MinValueS >= 0 {
+			typ += fmt.Sprintf("[%v:%v]", *attr.MinValueS, *attr.MaxValueS)
+		}
+		return typ, ""
+	case "string", "nul_string":
+		return "string", ""
+	case "binary":
+		if attr.MinLength != nil && attr.MaxLength != nil && *attr.MinLength == *attr.MaxLength {
+			return fmt.Sprintf("array[int8, %v]", *attr.MinLength), "describe the binary payload"
+		}
+		return "array[int8]", "describe the binary payload"
+	case "nested":
+		if nested == "void" {
+			return "array[nl_generic_attr]", "nested policy is unknown"
+		}
+		return fmt.Sprintf("array[%v]", nested), ""
+	case "nested_array":
+		if nested == "void" {
+			return "array[nlattr_anytype[array[nl_generic_attr]]]", "nested policy is unknown"
+		}
+		return fmt.Sprintf("array[nlattr_anytype[array[%v]]]", nested), ""
+	case "bitfield32":
+		return "nla_bitfield32", ""
+	}
+	return "array[int8]", fmt.Sprintf("unknown attribute type %q", attr.Type)
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// See include/uapi/linux/genetlink.h and include/uapi/linux/netlink.h.
+const (
+	genlIDCtrl = 0x10
+
+	ctrlCmdGetFamily = 3
+	ctrlCmdGetPolicy = 10
+
+	ctrlAttrFamilyID   = 1
+	ctrlAttrFamilyName = 2
+	ctrlAttrVersion    = 3
+	ctrlAttrHdrSize    = 4
+	ctrlAttrMaxAttr    = 5
+	ctrlAttrOps        = 6
+	ctrlAttrPolicy     = 8
+	ctrlAttrOpPolicy   = 9
+
+	ctrlAttrOpID    = 1
+	ctrlAttrOpFlags = 2
+
+	ctrlAttrPolicyDo   = 1
+	ctrlAttrPolicyDump = 2
+
+	genlCmdCapHasPol = 0x8
+
+	nlPolicyTypeAttrType      = 1
+	nlPolicyTypeAttrMinValueS = 2
+	nlPolicyTypeAttrMaxValueS = 3
+	nlPolicyTypeAttrMinValueU = 4
+	nlPolicyTypeAttrMaxValueU = 5
+	nlPolicyTypeAttrMinLength = 6
+	nlPolicyTypeAttrMaxLength = 7
+	nlPolicyTypeAttrPolicyIdx = 8
+
+	nlaTypeMask = 0x3fff
+)
+
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 0 {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// dumpNetlink queries all generic netlink families and their policies from the running kernel.
+func dumpNetlink() ([]*nlFamily, error) {
+	sock, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_GENERIC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create netlink socket: %v", err)
+	}
+	defer syscall.Close(sock)
+	msgs, err := genlDump(sock, ctrlCmdGetFamily, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump families: %v", err)
+	}
+	var families []*nlFamily
+	for _, msg := range msgs {
+		fam := &nlFamily{Policies: make(map[int]map[int]*nlAttrPolicy)}
+		for _, attr := range parseAttrs(msg) {
+			switch attr.typ {
+			case ctrlAttrFamilyID:
+				fam.ID = int(attrU(attr.data))
+			case ctrlAttrFamilyName:
+				fam.Name = attrString(attr.data)
+			case ctrlAttrVersion:
+				fam.Version = int(attrU(attr.data))
+			case ctrlAttrHdrSize:
+				fam.HdrSize = int(attrU(attr.data))
+			case ctrlAttrMaxAttr:
+				fam.MaxAttr = int(attrU(attr.data))
+			case ctrlAttrOps:
+				for _, op := range parseAttrs(attr.data) {
+					nop := &nlOp{DoPolicy: -1, DumpPolicy: -1}
+					for _, opAttr := range parseAttrs(op.data) {
+						switch opAttr.typ {
+						case ctrlAttrOpID:
+							nop.Cmd = int(attrU(opAttr.data))
+						case ctrlAttrOpFlags:
+							if attrU(opAttr.data)&genlCmdCapHasPol != 0 {
+								nop.DoPolicy, nop.DumpPolicy = 0, 0
+							}
+						}
+					}
+					fam.Ops = append(fam.Ops, nop)
+				}
+			}
+		}
+		// ENODATA means that the family does not have policies.
+		if err := dumpPolicy(sock, fam); err != nil && err != syscall.ENODATA {
+			fmt.Fprintf(os.Stderr, "failed to dump %v policy: %v\n", fam.Name, err)
+		}
+		families = append(families, fam)
+	}
+	return families, nil
+}
+
+func dumpPolicy(sock int, fam *nlFamily) error {
+	name := append([]byte(fam.Name), 0)
+	msgs, err := genlDump(sock, ctrlCmdGetPolicy, serializeAttr(ctrlAttrFamilyName, name))
+	if err != nil {
+		return err
+	}
+	ops := make(map[int]*nlOp)
+	for _, op := range fam.Ops {
+		ops[op.Cmd] = op
+	}
+	for _, msg := range msgs {
+		for _, attr := range parseAttrs(msg) {
+			switch attr.typ {
+			case ctrlAttrPolicy:
+				for _, policy := range parseAttrs(attr.data) {
+					attrs := fam.Policies[int(policy.typ)]
+					if attrs == nil {
+						attrs = make(map[int]*nlAttrPolicy)
+						fam.Policies[int(policy.typ)] = attrs
+					}
+					for _, a := range parseAttrs(policy.data) {
+						attrs[int(a.typ)] = parseAttrPolicy(a.data)
+					}
+				}
+			case ctrlAttrOpPolicy:
+				for _, opPolicy := range parseAttrs(attr.data) {
+					op := ops[int(opPolicy.typ)]
+					if op == nil {
+						continue
+					}
+					op.DoPolicy, op.DumpPolicy = -1, -1
+					for _, a := range parseAttrs(opPolicy.data) {
+						switch a.typ {
+						case ctrlAttrPolicyDo:
+							op.DoPolicy = int(attrU(a.data))
+						case ctrlAttrPolicyDump:
+							op.DumpPolicy = int(attrU(a.data))
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func parseAttrPolicy(data []byte) *nlAttrPolicy {
+	policy := new(nlAttrPolicy)
+	for _, a := range parseAttrs(data) {
+		v := attrU(a.data)
+		switch a.typ {
+		case nlPolicyTypeAttrType:
+			policy.Type = fmt.Sprintf("type%v", v)
+			if v < uint64(len(nlAttrTypes)) {
+				policy.Type = nlAttrTypes[v]
+			}
+		case nlPolicyTypeAttrMinValueS:
+			s := int64(v)
+			policy.MinValueS = &s
+		case nlPolicyTypeAttrMaxValueS:
+			s := int64(v)
+			policy.MaxValueS = &s
+		case nlPolicyTypeAttrMinValueU:
+			policy.MinValueU = &v
+		case nlPolicyTypeAttrMaxValueU:
+			policy.MaxValueU = &v
+		case nlPolicyTypeAttrMinLength:
+			l := uint32(v)
+			policy.MinLength = &l
+		case nlPolicyTypeAttrMaxLength:
+			l := uint32(v)
+			policy.MaxLength = &l
+		case nlPolicyTypeAttrPolicyIdx:
+			idx := int(v)
+			policy.PolicyIdx = &idx
+		}
+	}
+	return policy
+}
+
+// genlDump sends a dump request to the generic netlink controller and returns payloads
+// (attributes following genlmsghdr) of the replies.
+func genlDump(sock int, cmd byte, attrs []byte) ([][]byte, error) {
+	const hdrSize = syscall.NLMSG_HDRLEN + 4
+	req := make([]byte, hdrSize+len(attrs))
+	nativeEndian.PutUint32(req[0:], uint32(len(req)))
+	nativeEndian.PutUint16(req[4:], genlIDCtrl)
+	nativeEndian.PutUint16(req[6:], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	nativeEndian.PutUint32(req[8:], 1)
+	req[syscall.NLMSG_HDRLEN] = cmd
+	req[syscall.NLMSG_HDRLEN+1] = 1
+	copy(req[hdrSize:], attrs)
+	if err := syscall.Sendto(sock, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+	var res [][]byte
+	buf := make([]byte, 1<<16)
+	for {
+		n, _, err := syscall.Recvfrom(sock, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return res, nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					if errno := int32(nativeEndian.Uint32(msg.Data)); errno != 0 {
+						return nil, syscall.Errno(-errno)
+					}
+				}
+				return res, nil
+			}
+			if len(msg.Data) >= 4 {
+				res = append(res, msg.Data[4:])
+			}
+		}
+	}
+}
+
+type nlAttr struct {
+	typ  uint16
+	data []byte
+}
+
+func parseAttrs(data []byte) []nlAttr {
+	var attrs []nlAttr
+	for len(data) >= syscall.NLA_HDRLEN {
+		size := int(nativeEndian.Uint16(data))
+		if size < syscall.NLA_HDRLEN || size > len(data) {
+			break
+		}
+		attrs = append(attrs, nlAttr{
+			typ:  nativeEndian.Uint16(data[2:]) & nlaTypeMask,
+			data: data[syscall.NLA_HDRLEN:size],
+		})
+		size = (size + syscall.NLA_ALIGNTO - 1) &^ (syscall.NLA_ALIGNTO - 1)
+		if size > len(data) {
+			break
+		}
+		data = data[size:]
+	}
+	return attrs
+}
+
+func serializeAttr(typ uint16, data []byte) []byte {
+	size := syscall.NLA_HDRLEN + len(data)
+	res := make([]byte, (size+syscall.NLA_ALIGNTO-1)&^(syscall.NLA_ALIGNTO-1))
+	nativeEndian.PutUint16(res, uint16(size))
+	nativeEndian.PutUint16(res[2:], typ)
+	copy(res[syscall.NLA_HDRLEN:], data)
+	return res
+}
+
+// attrU returns value of an integer attribute of any size.
+func attrU(data []byte) uint64 {
+	switch len(data) {
+	case 1:
+		return uint64(data[0])
+	case 2:
+		return uint64(nativeEndian.Uint16(data))
+	case 4:
+		return uint64(nativeEndian.Uint32(data))
+	case 8:
+		return nativeEndian.Uint64(data)
+	}
+	return 0
+}
+
+func attrString(data []byte) string {
+	for i, c := range data {
+		if c == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,14 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+)
+
+func dumpNetlink() ([]*nlFamily, error) {
+	return nil, fmt.Errorf("netlink policy dumps are supported only on linux")
+}
@@ -0,0 +1,337 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-diff compares findings of two managers: coverage, crash titles and stats.
+// It's intended for side-by-side evaluation of e.g. a kernel config change,
+// a new mitigation setting or a syzkaller upgrade. Usage:
+//
+//	syz-diff [-cover_diff=prefix] A B
+//
+// A and B are either manager web UI addresses (e.g. http://host:56741),
+// or snapshot dirs saved with:
+//
+//	syz-diff -save=dir http://host:56741
+//
+// Snapshots allow to compare against a manager that is not running anymore.
+// A manager workdir can be used as a snapshot too, but it contains only crashes.
+// With -cover_diff PCs covered by only one of the managers are written to prefix.only_a/prefix.only_b,
+// these files can be converted to coverage reports with syz-cover -manager_export.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/syzkaller/pkg/mgrapi"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+var (
+	flagSave      = flag.String("save", "", "save snapshot of the manager into the dir")
+	flagCoverDiff = flag.String("cover_diff", "", "write PCs covered by only one side to files with this prefix")
+)
+
+const (
+	summaryFile = "summary.json"
+	coverFile   = "rawcover"
+)
+
+// snapshot is the state of a manager that is compared.
+type snapshot struct {
+	source  string
+	summary *mgrapi.Summary
+	cover   []uint64 // nil if coverage is not available
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: syz-diff [flags] A B\n")
+		fmt.Fprintf(os.Stderr, "       syz-diff -save=dir manager-addr\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *flagSave != "" {
+		if len(flag.Args()) != 1 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		snap, err := loadManager(flag.Args()[0])
+		if err != nil {
+			failf("%v", err)
+		}
+		if err := saveSnapshot(snap, *flagSave); err != nil {
+			failf("%v", err)
+		}
+		return
+	}
+	if len(flag.Args()) != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	var snaps []*snapshot
+	for _, arg := range flag.Args() {
+		snap, err := load(arg)
+		if err != nil {
+			failf("%v: %v", arg, err)
+		}
+		snaps = append(snaps, snap)
+	}
+	a, b := snaps[0], snaps[1]
+	printSummary(a, b)
+	printCover(a, b)
+	printCrashes(a, b)
+	printStats(a, b)
+}
+
+func load(source string) (*snapshot, error) {
+	if osutil.IsExist(source) {
+		return loadDir(source)
+	}
+	return loadManager(source)
+}
+
+func loadManager(addr string) (*snapshot, error) {
+	client := mgrapi.New(addr)
+	summary, err := client.Summary()
+	if err != nil {
+		return nil, err
+	}
+	cover, err := client.RawCover()
+	if err != nil {
+		// Coverage is not available if coverage is disabled in the config.
+		fmt.Fprintf(os.Stderr, "%v: failed to get coverage: %v\n", addr, err)
+	}
+	return &snapshot{
+		source:  client.Addr,
+		summary: summary,
+		cover:   cover,
+	}, nil
+}
+
+func saveSnapshot(snap *snapshot, dir string) error {
+	if err := osutil.MkdirAll(dir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snap.summary, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFile(filepath.Join(dir, summaryFile), data); err != nil {
+		return err
+	}
+	if snap.cover != nil {
+		return writeCover(filepath.Join(dir, coverFile), snap.cover)
+	}
+	return nil
+}
+
+func loadDir(dir string) (*snapshot, error) {
+	snap := &snapshot{source: dir}
+	data, err := ioutil.ReadFile(filepath.Join(dir, summaryFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if snap.summary, err = loadWorkdir(dir); err != nil {
+			return nil, err
+		}
+	} else {
+		snap.summary = new(mgrapi.Summary)
+		if err := json.Unmarshal(data, snap.summary); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %v", summaryFile, err)
+		}
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, coverFile)); err == nil {
+		if snap.cover, err = mgrapi.ParseRawCover(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// loadWorkdir collects crashes from a manager workdir.
+func loadWorkdir(workdir string) (*mgrapi.Summary, error) {
+	crashdir := filepath.Join(workdir, "crashes")
+	dirs, err := osutil.ListDir(crashdir)
+	if err != nil {
+		return nil, fmt.Errorf("neither %v nor crashes dir found: %v", summaryFile, err)
+	}
+	summary := &mgrapi.Summary{Name: filepath.Base(filepath.Clean(workdir))}
+	for _, dir := range dirs {
+		desc, err := ioutil.ReadFile(filepath.Join(crashdir, dir, "description"))
+		if err != nil || len(bytes.TrimSpace(desc)) == 0 {
+			continue
+		}
+		files, err := osutil.ListDir(filepath.Join(crashdir, dir))
+		if err != nil {
+			return nil, err
+		}
+		crash := &mgrapi.Crash{
+			ID:    dir,
+			Title: string(bytes.TrimSpace(desc)),
+		}
+		for _, f := range files {
+			if strings.HasPrefix(f, "log") {
+				crash.Count++
+			}
+		}
+		summary.Crashes = append(summary.Crashes, crash)
+	}
+	return summary, nil
+}
+
+func printSummary(a, b *snapshot) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\tA\tB\n")
+	fmt.Fprintf(w, "source\t%v\t%v\n", a.source, b.source)
+	fmt.Fprintf(w, "name\t%v\t%v\n", a.summary.Name, b.summary.Name)
+	fmt.Fprintf(w, "revision\t%v\t%v\n", a.summary.Revision, b.summary.Revision)
+	fmt.Fprintf(w, "fuzzing time\t%v\t%v\n", a.summary.FuzzingTime.Truncate(time.Minute),
+		b.summary.FuzzingTime.Truncate(time.Minute))
+	fmt.Fprintf(w, "corpus\t%v\t%v\n", a.summary.Corpus, b.summary.Corpus)
+	fmt.Fprintf(w, "signal\t%v\t%v\n", a.summary.Signal, b.summary.Signal)
+	fmt.Fprintf(w, "crash types\t%v\t%v\n", len(a.summary.Crashes), len(b.summary.Crashes))
+	w.Flush()
+}
+
+func printCover(a, b *snapshot) {
+	if a.cover == nil || b.cover == nil {
+		fmt.Printf("\ncoverage: not available for both sides\n")
+		return
+	}
+	onlyA, common, onlyB := diffPCs(a.cover, b.cover)
+	fmt.Printf("\ncoverage: A %v, B %v, common %v, only A %v, only B %v\n",
+		len(a.cover), len(b.cover), len(common), len(onlyA), len(onlyB))
+	if *flagCoverDiff == "" {
+		return
+	}
+	for _, f := range []struct {
+		suffix string
+		pcs    []uint64
+	}{{".only_a", onlyA}, {".only_b", onlyB}} {
+		if err := writeCover(*flagCoverDiff+f.suffix, f.pcs); err != nil {
+			failf("%v", err)
+		}
+	}
+}
+
+// diffPCs returns PCs that are present only in a, in both, and only in b.
+func diffPCs(a, b []uint64) (onlyA, common, onlyB []uint64) {
+	inB := make(map[uint64]bool, len(b))
+	for _, pc := range b {
+		inB[pc] = true
+	}
+	inA := make(map[uint64]bool, len(a))
+	for _, pc := range a {
+		inA[pc] = true
+		if inB[pc] {
+			common = append(common, pc)
+		} else {
+			onlyA = append(onlyA, pc)
+		}
+	}
+	for _, pc := range b {
+		if !inA[pc] {
+			onlyB = append(onlyB, pc)
+		}
+	}
+	return
+}
+
+func writeCover(file string, pcs []uint64) error {
+	buf := new(bytes.Buffer)
+	for _, pc := range pcs {
+		fmt.Fprintf(buf, "0x%x\n", pc)
+	}
+	return osutil.WriteFile(file, buf.Bytes())
+}
+
+func printCrashes(a, b *snapshot) {
+	crashesA, crashesB := crashMap(a.summary), crashMap(b.summary)
+	var onlyA, onlyB, common []string
+	for title := range crashesA {
+		if crashesB[title] != nil {
+			common = append(common, title)
+		} else {
+			onlyA = append(onlyA, title)
+		}
+	}
+	for title := range crashesB {
+		if crashesA[title] == nil {
+			onlyB = append(onlyB, title)
+		}
+	}
+	printTitles := func(what string, titles []string, f func(title string) string) {
+		sort.Strings(titles)
+		fmt.Printf("\n%v (%v):\n", what, len(titles))
+		for _, title := range titles {
+			fmt.Printf("\t%v\t%v\n", f(title), title)
+		}
+	}
+	printTitles("crashes only in A", onlyA, func(title string) string {
+		return fmt.Sprint(crashesA[title].Count)
+	})
+	printTitles("crashes only in B", onlyB, func(title string) string {
+		return fmt.Sprint(crashesB[title].Count)
+	})
+	printTitles("common crashes (A/B count)", common, func(title string) string {
+		return fmt.Sprintf("%v/%v", crashesA[title].Count, crashesB[title].Count)
+	})
+}
+
+func crashMap(summary *mgrapi.Summary) map[string]*mgrapi.Crash {
+	res := make(map[string]*mgrapi.Crash)
+	for _, crash := range summary.Crashes {
+		res[crash.Title] = crash
+	}
+	return res
+}
+
+func printStats(a, b *snapshot) {
+	if len(a.summary.Stats) == 0 || len(b.summary.Stats) == 0 {
+		return
+	}
+	names := make(map[string]bool)
+	for name := range a.summary.Stats {
+		names[name] = true
+	}
+	for name := range b.summary.Stats {
+		names[name] = true
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	// Stats are also normalized per fuzzing hour, since the managers may have run for different time.
+	hoursA, hoursB := a.summary.FuzzingTime.Hours(), b.summary.FuzzingTime.Hours()
+	fmt.Printf("\nstats:\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\tA\tB\tA/hour\tB/hour\n")
+	for _, name := range sorted {
+		valA, valB := a.summary.Stats[name], b.summary.Stats[name]
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", name, valA, valB, perHour(valA, hoursA), perHour(valB, hoursB))
+	}
+	w.Flush()
+}
+
+func perHour(val uint64, hours float64) string {
+	if hours < 1.0/60 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(val)/hours)
+}
+
+func failf(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"os"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,6 +23,8 @@ import (
 	"github.com/google/syzkaller/pkg/ipc/ipcconfig"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/overlay"
+	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 	_ "github.com/google/syzkaller/sys"
 )
@@ -30,6 +33,8 @@ var (
 	flagOS        = flag.String("os", runtime.GOOS, "target os")
 	flagArch      = flag.String("arch", runtime.GOARCH, "target arch")
 	flagCoverFile = flag.String("coverfile", "", "write coverage to the file")
+	flagCoverSum  = flag.Bool("cover_summary", false, "print per-call signal/coverage summary for all executed programs")
+	flagRawCover  = flag.String("rawcover", "", "write coverage of all executed programs to the file (syz-cover format)")
 	flagRepeat    = flag.Int("repeat", 1, "repeat execution that many times (0 for infinite loop)")
 	flagProcs     = flag.Int("procs", 1, "number of parallel processes to execute programs")
 	flagOutput    = flag.Bool("output", false, "write programs and results to stdout")
@@ -38,6 +43,9 @@ var (
 	flagFaultNth  = flag.Int("fault_nth", 0, "inject fault on n-th operation (0-based)")
 	flagEnable    = flag.String("enable", "none", "enable only listed additional features")
 	flagDisable   = flag.String("disable", "none", "enable all additional features except listed")
+	flagSoak      = flag.Duration("soak", 0, "execute programs for this long with randomized options (soak mode)")
+	flagSoakSbox  = flag.String("soak_sandboxes", "none,setuid,namespace", "comma-separated sandboxes for soak mode")
+	flagExtra     = flag.String("extra_descriptions", "", "compiled descriptions of out-of-tree modules")
 )
 
 func main() {
@@ -60,6 +68,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
+	if *flagExtra != "" {
+		if err := overlay.Load(target, *flagExtra); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
 	entries := loadPrograms(target, flag.Args())
 	if len(entries) == 0 {
 		return
@@ -94,6 +107,19 @@ func main() {
 		gate:     ipc.NewGate(2**flagProcs, gateCallback),
 		shutdown: make(chan struct{}),
 		repeat:   *flagRepeat,
+		stats:    make(map[string]*callStats),
+	}
+	if *flagSoak != 0 {
+		maxCalls := 0
+		for _, entry := range entries {
+			if maxCalls < len(entry.P.Calls) {
+				maxCalls = len(entry.P.Calls)
+			}
+		}
+		ctx.soakCfg, err = createSoakConfig(*flagSoak, *flagSoakSbox, features, maxCalls)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
 	}
 	var wg sync.WaitGroup
 	wg.Add(*flagProcs)
@@ -101,11 +127,21 @@ func main() {
 		pid := p
 		go func() {
 			defer wg.Done()
-			ctx.run(pid)
+			if ctx.soakCfg != nil {
+				ctx.soak(pid)
+			} else {
+				ctx.run(pid)
+			}
 		}()
 	}
 	osutil.HandleInterrupts(ctx.shutdown)
 	wg.Wait()
+	if *flagCoverSum {
+		ctx.printCoverSummary()
+	}
+	if *flagRawCover != "" {
+		ctx.dumpRawCover(*flagRawCover)
+	}
 }
 
 type Context struct {
@@ -119,6 +155,18 @@ type Context struct {
 	repeat    int
 	pos       int
 	lastPrint time.Time
+	statsMu   sync.Mutex
+	stats     map[string]*callStats
+	extra     callStats
+	cover     cover.Cover
+	soakCfg   *soakConfig
+}
+
+// callStats is coverage aggregated over all executions of a syscall.
+type callStats struct {
+	execs  int
+	signal signal.Signal
+	cover  cover.Cover
 }
 
 func (ctx *Context) run(pid int) {
@@ -170,6 +218,9 @@ func (ctx *Context) execute(pid int, env *ipc.Env, entry *prog.LogEntry) {
 		if *flagCoverFile != "" {
 			ctx.dumpCoverage(*flagCoverFile, info)
 		}
+		if *flagCoverSum || *flagRawCover != "" {
+			ctx.collectCoverage(entry.P, info)
+		}
 	} else {
 		log.Logf(1, "RESULT: no calls executed")
 	}
@@ -257,6 +308,77 @@ func (ctx *Context) dumpCoverage(coverFile string, info *ipc.ProgInfo) {
 	ctx.dumpCallCoverage(fmt.Sprintf("%v.extra", coverFile), &info.Extra)
 }
 
+func (ctx *Context) collectCoverage(p *prog.Prog, info *ipc.ProgInfo) {
+	ctx.statsMu.Lock()
+	defer ctx.statsMu.Unlock()
+	for i, inf := range info.Calls {
+		if inf.Flags&ipc.CallExecuted == 0 {
+			continue
+		}
+		name := p.Calls[i].Meta.Name
+		stat := ctx.stats[name]
+		if stat == nil {
+			stat = new(callStats)
+			ctx.stats[name] = stat
+		}
+		stat.add(&inf)
+		ctx.cover.Merge(inf.Cover)
+	}
+	if len(info.Extra.Signal) != 0 || len(info.Extra.Cover) != 0 {
+		ctx.extra.add(&info.Extra)
+		ctx.cover.Merge(info.Extra.Cover)
+	}
+}
+
+func (stat *callStats) add(inf *ipc.CallInfo) {
+	stat.execs++
+	stat.signal.Merge(signal.FromRaw(inf.Signal, 0))
+	stat.cover.Merge(inf.Cover)
+}
+
+func (ctx *Context) printCoverSummary() {
+	ctx.statsMu.Lock()
+	defer ctx.statsMu.Unlock()
+	var names []string
+	for name := range ctx.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var total signal.Signal
+	for _, name := range names {
+		stat := ctx.stats[name]
+		total.Merge(stat.signal)
+		fmt.Printf("%-40v: executed %v, signal %v, coverage %v\n",
+			name, stat.execs, stat.signal.Len(), len(stat.cover))
+	}
+	if ctx.extra.execs != 0 {
+		total.Merge(ctx.extra.signal)
+		fmt.Printf("%-40v: executed %v, signal %v, coverage %v\n",
+			"extra", ctx.extra.execs, ctx.extra.signal.Len(), len(ctx.extra.cover))
+	}
+	fmt.Printf("total: signal %v, coverage %v\n", total.Len(), len(ctx.cover))
+}
+
+func (ctx *Context) dumpRawCover(file string) {
+	ctx.statsMu.Lock()
+	defer ctx.statsMu.Unlock()
+	pcs := make([]uint64, 0, len(ctx.cover))
+	for pc := range ctx.cover {
+		pcs = append(pcs, cover.RestorePC(pc, 0xffffffff))
+	}
+	sort.Slice(pcs, func(i, j int) bool {
+		return pcs[i] < pcs[j]
+	})
+	buf := new(bytes.Buffer)
+	for _, pc := range pcs {
+		fmt.Fprintf(buf, "0x%x\n", pc)
+	}
+	if err := osutil.WriteFile(file, buf.Bytes()); err != nil {
+		log.Fatalf("failed to write coverage file: %v", err)
+	}
+	log.Logf(0, "written %v PCs to %v", len(pcs), file)
+}
+
 func (ctx *Context) getProgramIndex() int {
 	ctx.posMu.Lock()
 	idx := ctx.pos
@@ -297,6 +419,10 @@ func createConfig(target *prog.Target, entries []*prog.LogEntry,
 		execOpts.Flags |= ipc.FlagCollectCover
 		execOpts.Flags &^= ipc.FlagDedupCover
 	}
+	if *flagCoverSum || *flagRawCover != "" {
+		config.Flags |= ipc.FlagSignal
+		execOpts.Flags |= ipc.FlagCollectCover
+	}
 	if *flagHints {
 		if execOpts.Flags&ipc.FlagCollectCover != 0 {
 			execOpts.Flags ^= ipc.FlagCollectCover
@@ -0,0 +1,148 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/host"
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// Soak mode (-soak flag).
+// The programs are executed continuously in rounds until the deadline. Each round uses a random
+// combination of sandbox, threaded/collide, fault injection and repeat count. Options of each round
+// are printed in the form of syz-execprog flags before the round starts, so that if the kernel crashes,
+// the last printed line for the proc gives the exact option set to reproduce the crash with.
+
+type soakConfig struct {
+	deadline  time.Time
+	sandboxes []string
+	fault     bool
+	maxCalls  int
+}
+
+type soakOpts struct {
+	sandbox   string
+	threaded  bool
+	collide   bool
+	faultCall int
+	faultNth  int
+	repeat    int
+}
+
+func (opts *soakOpts) String() string {
+	return fmt.Sprintf("-sandbox=%v -threaded=%v -collide=%v -fault_call=%v -fault_nth=%v -repeat=%v",
+		opts.sandbox, opts.threaded, opts.collide, opts.faultCall, opts.faultNth, opts.repeat)
+}
+
+func createSoakConfig(duration time.Duration, sandboxes string, features *host.Features,
+	maxCalls int) (*soakConfig, error) {
+	cfg := &soakConfig{
+		deadline: time.Now().Add(duration),
+		fault:    features[host.FeatureFaultInjection].Enabled,
+		maxCalls: maxCalls,
+	}
+	supported := map[string]bool{
+		"none":                  true,
+		"setuid":                features[host.FeatureSandboxSetuid].Enabled,
+		"namespace":             features[host.FeatureSandboxNamespace].Enabled,
+		"android_untrusted_app": features[host.FeatureSandboxAndroidUntrustedApp].Enabled,
+	}
+	for _, sandbox := range strings.Split(sandboxes, ",") {
+		if _, err := ipc.SandboxToFlags(sandbox); err != nil {
+			return nil, err
+		}
+		if !supported[sandbox] {
+			log.Logf(0, "sandbox %v is not supported, skipping", sandbox)
+			continue
+		}
+		cfg.sandboxes = append(cfg.sandboxes, sandbox)
+	}
+	if len(cfg.sandboxes) == 0 {
+		return nil, fmt.Errorf("none of the sandboxes %q are supported", sandboxes)
+	}
+	return cfg, nil
+}
+
+func (cfg *soakConfig) generate(rnd *rand.Rand) *soakOpts {
+	opts := &soakOpts{
+		sandbox:   cfg.sandboxes[rnd.Intn(len(cfg.sandboxes))],
+		threaded:  rnd.Intn(2) == 0,
+		faultCall: -1,
+		repeat:    1 + rnd.Intn(10),
+	}
+	// Collide mode requires threaded mode.
+	opts.collide = opts.threaded && rnd.Intn(2) == 0
+	if cfg.fault && rnd.Intn(3) == 0 {
+		opts.faultCall = rnd.Intn(cfg.maxCalls)
+		opts.faultNth = rnd.Intn(10)
+	}
+	return opts
+}
+
+func (ctx *Context) soak(pid int) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(pid)*1e12))
+	for round := 0; time.Now().Before(ctx.soakCfg.deadline); round++ {
+		select {
+		case <-ctx.shutdown:
+			return
+		default:
+		}
+		opts := ctx.soakCfg.generate(rnd)
+		log.Logf(0, "soak round %v proc %v: %v", round, pid, opts)
+		if !ctx.soakRound(pid, opts) {
+			return
+		}
+	}
+}
+
+func (ctx *Context) soakRound(pid int, opts *soakOpts) bool {
+	config := *ctx.config
+	config.Flags &^= ipc.FlagSandboxSetuid | ipc.FlagSandboxNamespace | ipc.FlagSandboxAndroidUntrustedApp
+	sandboxFlags, _ := ipc.SandboxToFlags(opts.sandbox)
+	config.Flags |= sandboxFlags
+	execOpts := *ctx.execOpts
+	execOpts.Flags &^= ipc.FlagThreaded | ipc.FlagCollide | ipc.FlagInjectFault
+	if opts.threaded {
+		execOpts.Flags |= ipc.FlagThreaded
+	}
+	if opts.collide {
+		execOpts.Flags |= ipc.FlagCollide
+	}
+	if opts.faultCall >= 0 {
+		execOpts.Flags |= ipc.FlagInjectFault
+		execOpts.FaultCall = opts.faultCall
+		execOpts.FaultNth = opts.faultNth
+	}
+	env, err := ipc.MakeEnv(&config, pid)
+	if err != nil {
+		log.Fatalf("failed to create ipc env: %v", err)
+	}
+	defer env.Close()
+	for i := 0; i < opts.repeat; i++ {
+		for _, entry := range ctx.entries {
+			select {
+			case <-ctx.shutdown:
+				return false
+			default:
+			}
+			ticket := ctx.gate.Enter()
+			if *flagOutput {
+				ctx.logProgram(pid, entry.P, &execOpts)
+			}
+			output, _, hanged, err := env.Exec(&execOpts, entry.P)
+			ctx.gate.Leave(ticket)
+			if err != nil {
+				log.Logf(0, "executor failure with options %v: hanged=%v err=%v\n%s\n%s",
+					opts, hanged, err, entry.P.Serialize(), output)
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,386 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-fleet aggregates state of several syz-manager instances into a single web page.
+// It periodically polls /api/summary of every manager and renders combined
+// crashes, coverage and VM health, and per-manager drill-down pages. Usage:
+//
+//	syz-fleet -http=:8080 mgr1=http://host1:56741 http://host2:56741 ...
+//
+// If a name is not specified for a manager, the name reported by the manager is used.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/html"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrapi"
+)
+
+var (
+	flagHTTP   = flag.String("http", ":8080", "address to serve web UI on")
+	flagPeriod = flag.Duration("period", 30*time.Second, "managers polling period")
+)
+
+type Fleet struct {
+	managers []*Manager
+}
+
+type Manager struct {
+	Name   string
+	Addr   string
+	client *mgrapi.Client
+
+	mu      sync.Mutex
+	summary *mgrapi.Summary
+	err     error
+	updated time.Time
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: syz-fleet [flags] [name=]manager-addr...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if len(flag.Args()) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	fleet := new(Fleet)
+	for _, arg := range flag.Args() {
+		mgr := &Manager{Addr: arg}
+		if eq := strings.IndexByte(arg, '='); eq != -1 {
+			mgr.Name, mgr.Addr = arg[:eq], arg[eq+1:]
+		}
+		mgr.client = mgrapi.New(mgr.Addr)
+		mgr.Addr = mgr.client.Addr
+		fleet.managers = append(fleet.managers, mgr)
+		go mgr.poll(*flagPeriod)
+	}
+	http.HandleFunc("/", fleet.httpSummary)
+	http.HandleFunc("/manager", fleet.httpManager)
+	http.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
+	ln, err := net.Listen("tcp", *flagHTTP)
+	if err != nil {
+		log.Fatalf("failed to listen on %v: %v", *flagHTTP, err)
+	}
+	log.Logf(0, "serving http on http://%v", ln.Addr())
+	log.Fatalf("failed to serve http: %v", http.Serve(ln, nil))
+}
+
+func (mgr *Manager) poll(period time.Duration) {
+	for {
+		summary, err := mgr.client.Summary()
+		mgr.mu.Lock()
+		mgr.err = err
+		if err == nil {
+			mgr.summary = summary
+			mgr.updated = time.Now()
+			if mgr.Name == "" {
+				mgr.Name = summary.Name
+			}
+		} else {
+			log.Logf(0, "%v: %v", mgr.Addr, err)
+		}
+		mgr.mu.Unlock()
+		time.Sleep(period)
+	}
+}
+
+func (mgr *Manager) name() string {
+	if mgr.Name != "" {
+		return mgr.Name
+	}
+	return mgr.Addr
+}
+
+type UIFleetData struct {
+	Managers []*UIManager
+	Crashes  []*UICrash
+	Total    UIManager
+}
+
+type UIManager struct {
+	Name        string
+	Addr        string
+	Error       string
+	Updated     time.Time
+	UpTime      time.Duration
+	FuzzingTime time.Duration
+	Corpus      int
+	Cover       uint64
+	Execs       uint64
+	Crashes     int
+	VMs         mgrapi.VMStatus
+}
+
+type UICrash struct {
+	Title    string
+	Count    int
+	Score    int
+	Repro    string
+	LastTime time.Time
+	Active   bool
+	Managers []UICrashManager
+}
+
+type UICrashManager struct {
+	Name string
+	Link string
+}
+
+func (fleet *Fleet) httpSummary(w http.ResponseWriter, r *http.Request) {
+	data := new(UIFleetData)
+	crashes := make(map[string]*UICrash)
+	for _, mgr := range fleet.managers {
+		mgr.mu.Lock()
+		ui := &UIManager{
+			Name:    mgr.name(),
+			Addr:    mgr.Addr,
+			Updated: mgr.updated,
+		}
+		if mgr.err != nil {
+			ui.Error = mgr.err.Error()
+		}
+		if s := mgr.summary; s != nil {
+			ui.UpTime = s.UpTime / time.Second * time.Second
+			ui.FuzzingTime = s.FuzzingTime / time.Minute * time.Minute
+			ui.Corpus = s.Corpus
+			ui.Cover = s.Cover
+			ui.Execs = s.Stats["exec total"]
+			ui.Crashes = len(s.Crashes)
+			ui.VMs = s.VMs
+			for _, crash := range s.Crashes {
+				uc := crashes[crash.Title]
+				if uc == nil {
+					uc = &UICrash{Title: crash.Title}
+					crashes[crash.Title] = uc
+				}
+				uc.Count += crash.Count
+				if uc.Score < crash.Score {
+					uc.Score = crash.Score
+				}
+				if uc.Repro == "" || strings.HasPrefix(crash.Repro, "has") {
+					uc.Repro = crash.Repro
+				}
+				if uc.LastTime.Before(crash.LastTime) {
+					uc.LastTime = crash.LastTime
+				}
+				uc.Active = uc.Active || crash.Active
+				uc.Managers = append(uc.Managers, UICrashManager{
+					Name: ui.Name,
+					Link: mgr.Addr + "/crash?id=" + crash.ID,
+				})
+			}
+		}
+		mgr.mu.Unlock()
+		data.Total.Corpus += ui.Corpus
+		data.Total.Cover += ui.Cover
+		data.Total.Execs += ui.Execs
+		data.Total.FuzzingTime += ui.FuzzingTime
+		data.Total.VMs.Total += ui.VMs.Total
+		data.Total.VMs.Fuzzing += ui.VMs.Fuzzing
+		data.Total.VMs.Reproducing += ui.VMs.Reproducing
+		data.Managers = append(data.Managers, ui)
+	}
+	for _, crash := range crashes {
+		data.Crashes = append(data.Crashes, crash)
+	}
+	sort.Slice(data.Crashes, func(i, j int) bool {
+		a, b := data.Crashes[i], data.Crashes[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+	data.Total.Crashes = len(data.Crashes)
+	if err := fleetTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+type UIManagerData struct {
+	Name    string
+	Addr    string
+	Error   string
+	Updated time.Time
+	Summary *mgrapi.Summary
+	Stats   []UIStat
+}
+
+type UIStat struct {
+	Name  string
+	Value uint64
+}
+
+func (fleet *Fleet) httpManager(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	for _, mgr := range fleet.managers {
+		mgr.mu.Lock()
+		if mgr.name() != name {
+			mgr.mu.Unlock()
+			continue
+		}
+		data := &UIManagerData{
+			Name:    mgr.name(),
+			Addr:    mgr.Addr,
+			Updated: mgr.updated,
+			Summary: mgr.summary,
+		}
+		if mgr.err != nil {
+			data.Error = mgr.err.Error()
+		}
+		mgr.mu.Unlock()
+		if data.Summary != nil {
+			for k, v := range data.Summary.Stats {
+				data.Stats = append(data.Stats, UIStat{k, v})
+			}
+			sort.Slice(data.Stats, func(i, j int) bool {
+				return data.Stats[i].Name < data.Stats[j].Name
+			})
+		}
+		if err := managerTemplate.Execute(w, data); err != nil {
+			http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	http.Error(w, fmt.Sprintf("unknown manager %q", name), http.StatusNotFound)
+}
+
+var fleetTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>syzkaller fleet</title>
+	{{HEAD}}
+</head>
+<body>
+<b>syzkaller fleet</b>
+<br>
+
+<table class="list_table">
+	<caption>Managers:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Name', textSort)" href="#">Name</a></th>
+		<th>Status</th>
+		<th>Uptime</th>
+		<th>Fuzzing</th>
+		<th><a onclick="return sortTable(this, 'Corpus', numSort)" href="#">Corpus</a></th>
+		<th><a onclick="return sortTable(this, 'Cover', numSort)" href="#">Cover</a></th>
+		<th><a onclick="return sortTable(this, 'Execs', numSort)" href="#">Execs</a></th>
+		<th><a onclick="return sortTable(this, 'Crashes', numSort)" href="#">Crashes</a></th>
+		<th>VMs (fuzzing/repro/total)</th>
+	</tr>
+	{{range $m := $.Managers}}
+	<tr>
+		<td><a href="/manager?name={{$m.Name}}">{{$m.Name}}</a> (<a href="{{$m.Addr}}">ui</a>)</td>
+		<td>{{if $m.Error}}<span title="{{$m.Error}}">unreachable</span>{{else}}ok{{end}}
+			{{if not $m.Updated.IsZero}}({{formatTime $m.Updated}}){{end}}</td>
+		<td>{{$m.UpTime}}</td>
+		<td>{{$m.FuzzingTime}}</td>
+		<td>{{$m.Corpus}}</td>
+		<td>{{$m.Cover}}</td>
+		<td>{{$m.Execs}}</td>
+		<td>{{$m.Crashes}}</td>
+		<td>{{$m.VMs.Fuzzing}}/{{$m.VMs.Reproducing}}/{{$m.VMs.Total}}</td>
+	</tr>
+	{{end}}
+	<tr>
+		<td><b>total</b></td>
+		<td></td>
+		<td></td>
+		<td>{{$.Total.FuzzingTime}}</td>
+		<td>{{$.Total.Corpus}}</td>
+		<td>{{$.Total.Cover}}</td>
+		<td>{{$.Total.Execs}}</td>
+		<td>{{$.Total.Crashes}}</td>
+		<td>{{$.Total.VMs.Fuzzing}}/{{$.Total.VMs.Reproducing}}/{{$.Total.VMs.Total}}</td>
+	</tr>
+</table>
+
+<table class="list_table">
+	<caption>Crashes:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Description', textSort)" href="#">Description</a></th>
+		<th><a onclick="return sortTable(this, 'Score', numSort)" href="#">Score</a></th>
+		<th><a onclick="return sortTable(this, 'Count', numSort)" href="#">Count</a></th>
+		<th><a onclick="return sortTable(this, 'Last Time', textSort, true)" href="#">Last Time</a></th>
+		<th><a onclick="return sortTable(this, 'Repro', textSort)" href="#">Repro</a></th>
+		<th>Managers</th>
+	</tr>
+	{{range $c := $.Crashes}}
+	<tr>
+		<td class="title">{{$c.Title}}</td>
+		<td class="stat">{{$c.Score}}</td>
+		<td class="stat {{if not $c.Active}}inactive{{end}}">{{$c.Count}}</td>
+		<td class="time {{if not $c.Active}}inactive{{end}}">{{formatTime $c.LastTime}}</td>
+		<td>{{$c.Repro}}</td>
+		<td>{{range $m := $c.Managers}}<a href="{{$m.Link}}">{{$m.Name}}</a> {{end}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
+var managerTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name}} syzkaller</title>
+	{{HEAD}}
+</head>
+<body>
+<b><a href="/">fleet</a> / {{.Name}}</b> (<a href="{{.Addr}}">{{.Addr}}</a>)
+<br>
+{{if .Error}}<b>Error:</b> {{.Error}}<br>{{end}}
+{{if not .Updated.IsZero}}Updated: {{formatTime .Updated}}<br>{{end}}
+
+{{if .Summary}}
+<table class="list_table">
+	<caption>Stats:</caption>
+	<tr><td class="stat_name">revision</td><td class="stat_value">{{formatShortHash .Summary.Revision}}</td></tr>
+	<tr><td class="stat_name">uptime</td><td class="stat_value">{{.Summary.UpTime}}</td></tr>
+	<tr><td class="stat_name">corpus</td><td class="stat_value">{{.Summary.Corpus}}</td></tr>
+	<tr><td class="stat_name">triage queue</td><td class="stat_value">{{.Summary.Candidates}}</td></tr>
+	<tr><td class="stat_name">VMs</td><td class="stat_value">
+		{{.Summary.VMs.Fuzzing}} fuzzing, {{.Summary.VMs.Reproducing}} reproducing, {{.Summary.VMs.Total}} total
+	</td></tr>
+	{{range $s := $.Stats}}
+	<tr><td class="stat_name">{{$s.Name}}</td><td class="stat_value">{{$s.Value}}</td></tr>
+	{{end}}
+</table>
+
+<table class="list_table">
+	<caption>Crashes:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Description', textSort)" href="#">Description</a></th>
+		<th><a onclick="return sortTable(this, 'Score', numSort)" href="#">Score</a></th>
+		<th><a onclick="return sortTable(this, 'Count', numSort)" href="#">Count</a></th>
+		<th><a onclick="return sortTable(this, 'Last Time', textSort, true)" href="#">Last Time</a></th>
+		<th><a onclick="return sortTable(this, 'Repro', textSort)" href="#">Repro</a></th>
+		<th>Tags</th>
+	</tr>
+	{{range $c := $.Summary.Crashes}}
+	<tr>
+		<td class="title"><a href="{{$.Addr}}/crash?id={{$c.ID}}">{{$c.Title}}</a></td>
+		<td class="stat">{{$c.Score}}</td>
+		<td class="stat {{if not $c.Active}}inactive{{end}}">{{$c.Count}}</td>
+		<td class="time {{if not $c.Active}}inactive{{end}}">{{formatTime $c.LastTime}}</td>
+		<td>{{$c.Repro}}</td>
+		<td>{{formatList $c.Tags}}</td>
+	</tr>
+	{{end}}
+</table>
+{{end}}
+</body></html>
+`)
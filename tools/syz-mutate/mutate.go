@@ -2,6 +2,8 @@
 // Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
 
 // mutates mutates a given program and prints result.
+// With -n it prints that many independent variants of the program,
+// -ops and -calls restrict mutation operators and calls of the program that are mutated.
 package main
 
 import (
@@ -11,6 +13,7 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,8 +28,19 @@ var (
 	flagSeed   = flag.Int("seed", -1, "prng seed")
 	flagLen    = flag.Int("len", 30, "number of calls in programs")
 	flagEnable = flag.String("enable", "", "comma-separated list of enabled syscalls")
+	flagN      = flag.Int("n", 1, "number of mutated variants of the program to print")
+	flagOps    = flag.String("ops", "", "comma-separated list of mutation operators (squash,splice,insert,arg,remove)")
+	flagCalls  = flag.String("calls", "", "comma-separated list of calls (indices or names) to mutate")
 )
 
+var mutateOps = map[string]prog.MutateOps{
+	"squash": prog.MutateSquash,
+	"splice": prog.MutateSplice,
+	"insert": prog.MutateInsert,
+	"arg":    prog.MutateArg,
+	"remove": prog.MutateRemove,
+}
+
 func main() {
 	flag.Parse()
 	target, err := prog.GetTarget(*flagOS, *flagArch)
@@ -59,21 +73,86 @@ func main() {
 	rs := rand.NewSource(seed)
 	prios := target.CalculatePriorities(nil)
 	ct := target.BuildChoiceTable(prios, syscalls)
-	var p *prog.Prog
 	if flag.NArg() == 0 {
-		p = target.Generate(rs, *flagLen, ct)
-	} else {
-		data, err := ioutil.ReadFile(flag.Arg(0))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to read prog file: %v\n", err)
-			os.Exit(1)
+		p := target.Generate(rs, *flagLen, ct)
+		fmt.Printf("%s\n", p.Serialize())
+		return
+	}
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read prog file: %v\n", err)
+		os.Exit(1)
+	}
+	p, err := target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to deserialize the program: %v\n", err)
+		os.Exit(1)
+	}
+	ops, err := parseOps(*flagOps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	calls, err := parseCalls(p, *flagCalls)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	for i := 0; i < *flagN; i++ {
+		p1 := p.Clone()
+		var filter func(*prog.Call) bool
+		if calls != nil {
+			// Calls are matched by identity, so that indices are not affected by inserted/removed calls.
+			selected := make(map[*prog.Call]bool)
+			for idx := range calls {
+				selected[p1.Calls[idx]] = true
+			}
+			filter = func(c *prog.Call) bool { return selected[c] }
 		}
-		p, err = target.Deserialize(data, prog.NonStrict)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to deserialize the program: %v\n", err)
-			os.Exit(1)
+		p1.MutateRestricted(rs, *flagLen, ct, nil, ops, filter)
+		fmt.Printf("%s\n", p1.Serialize())
+	}
+}
+
+func parseOps(list string) (prog.MutateOps, error) {
+	if list == "" {
+		return prog.MutateAllOps, nil
+	}
+	var ops prog.MutateOps
+	for _, name := range strings.Split(list, ",") {
+		op, ok := mutateOps[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown mutation operator %q", name)
+		}
+		ops |= op
+	}
+	return ops, nil
+}
+
+// parseCalls returns indices of calls of p selected by the list of call indices/names.
+func parseCalls(p *prog.Prog, list string) (map[int]bool, error) {
+	if list == "" {
+		return nil, nil
+	}
+	calls := make(map[int]bool)
+	for _, call := range strings.Split(list, ",") {
+		if idx, err := strconv.Atoi(call); err == nil {
+			if idx < 0 || idx >= len(p.Calls) {
+				return nil, fmt.Errorf("call index %v is out of range [0, %v)", idx, len(p.Calls))
+			}
+			calls[idx] = true
+			continue
+		}
+		found := false
+		for idx, c := range p.Calls {
+			if c.Meta.Name == call {
+				calls[idx] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no call %v in the program", call)
 		}
-		p.Mutate(rs, *flagLen, ct, nil)
 	}
-	fmt.Printf("%s\n", p.Serialize())
+	return calls, nil
 }
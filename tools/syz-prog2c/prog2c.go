@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/google/syzkaller/pkg/csource"
 	"github.com/google/syzkaller/prog"
@@ -31,6 +32,10 @@ var (
 	flagHandleSegv = flag.Bool("segv", false, "catch and ignore SIGSEGV")
 	flagUseTmpDir  = flag.Bool("tmpdir", false, "create a temporary dir and execute inside it")
 	flagTrace      = flag.Bool("trace", false, "trace syscall results")
+	flagKselftest  = flag.String("kselftest", "", "wrap the program into a kselftest with this test name")
+	flagKselftestT = flag.Duration("kselftest_timeout", time.Minute, "timeout for the kselftest program")
+	flagStrace     = flag.Bool("strace", false, "print syscalls with arguments and results to stderr (strace-style)")
+	flagReadable   = flag.Bool("readable", false, "generate more human-readable program")
 	flagStrict     = flag.Bool("strict", false, "parse input program in strict mode")
 	flagLeak       = flag.Bool("leak", false, "do leak checking")
 	flagEnable     = flag.String("enable", "none", "enable only listed additional features")
@@ -70,11 +75,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, "failed to deserialize the program: %v\n", err)
 		os.Exit(1)
 	}
+	repeatTimes := *flagRepeat
+	if repeatTimes < 0 {
+		repeatTimes = 0
+	}
 	opts := csource.Options{
 		Threaded:         *flagThreaded,
 		Collide:          *flagCollide,
 		Repeat:           *flagRepeat != 1,
-		RepeatTimes:      *flagRepeat,
+		RepeatTimes:      repeatTimes,
 		Procs:            *flagProcs,
 		Sandbox:          *flagSandbox,
 		Fault:            *flagFaultCall >= 0,
@@ -91,8 +100,19 @@ func main() {
 		HandleSegv:       *flagHandleSegv,
 		Repro:            false,
 		Trace:            *flagTrace,
+		Strace:           *flagStrace,
+		Readable:         *flagReadable,
+	}
+	if opts.Procs > 1 && !opts.Repeat {
+		fmt.Fprintf(os.Stderr, "-procs>1 requires -repeat!=1 (use -repeat=0 to loop forever)\n")
+		os.Exit(1)
+	}
+	var src []byte
+	if *flagKselftest != "" {
+		src, err = csource.WriteKselftest(p, opts, *flagKselftest, *flagKselftestT)
+	} else {
+		src, err = csource.Write(p, opts)
 	}
-	src, err := csource.Write(p, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to generate C source: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,203 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-prog2test converts reproducers or corpus programs into regression tests
+// for upstream test suites, so that fixed bugs get durable regression tests. Usage:
+//
+//	syz-prog2test -format=xfstests -out=dir -name=syz_test repro.prog...
+//	syz-prog2test -format=kselftest -out=dir -corpus=corpus.db -sig=hash1,hash2
+//
+// Supported formats are xfstests (shell test script + C program, for filesystem reproducers)
+// and kselftest (self-contained C program with TAP output).
+// KUnit is not supported: KUnit tests run inside of the kernel and can't execute syscalls.
+// Options are taken from the JSON options comment at the beginning of the program
+// (as stored by the dashboard), or regression-test defaults are used.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+var (
+	flagOS      = flag.String("os", runtime.GOOS, "target os")
+	flagArch    = flag.String("arch", runtime.GOARCH, "target arch")
+	flagFormat  = flag.String("format", "xfstests", "test format (xfstests, kselftest)")
+	flagOut     = flag.String("out", "", "output dir (required)")
+	flagName    = flag.String("name", "syz_regression", "test name (index is appended for multiple programs)")
+	flagTimeout = flag.Duration("timeout", time.Minute, "run time of each test")
+	flagCorpus  = flag.String("corpus", "", "take programs from the corpus.db")
+	flagSig     = flag.String("sig", "", "comma-separated keys of programs in the corpus")
+)
+
+// test is a program to convert along with its name.
+type test struct {
+	name string
+	p    *prog.Prog
+	opts csource.Options
+}
+
+func main() {
+	flag.Parse()
+	if *flagOut == "" || (*flagCorpus == "") == (len(flag.Args()) == 0) {
+		fmt.Fprintf(os.Stderr, "usage: syz-prog2test -format=xfstests|kselftest -out=dir"+
+			" (prog files... | -corpus=corpus.db -sig=keys)\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if *flagFormat != "xfstests" && *flagFormat != "kselftest" {
+		failf("unknown format %q", *flagFormat)
+	}
+	target, err := prog.GetTarget(*flagOS, *flagArch)
+	if err != nil {
+		failf("%v", err)
+	}
+	var data [][]byte
+	if *flagCorpus != "" {
+		data, err = loadCorpus(*flagCorpus, *flagSig)
+	} else {
+		data, err = loadFiles(flag.Args())
+	}
+	if err != nil {
+		failf("%v", err)
+	}
+	for i, d := range data {
+		name := *flagName
+		if len(data) > 1 {
+			name = fmt.Sprintf("%v_%v", name, i)
+		}
+		t, err := parseTest(target, name, d)
+		if err != nil {
+			failf("%v: %v", name, err)
+		}
+		files, err := generate(t)
+		if err != nil {
+			failf("%v: %v", name, err)
+		}
+		var names []string
+		for file := range files {
+			names = append(names, file)
+		}
+		sort.Strings(names)
+		for _, file := range names {
+			content := files[file]
+			file = filepath.Join(*flagOut, filepath.FromSlash(file))
+			if err := osutil.MkdirAll(filepath.Dir(file)); err != nil {
+				failf("%v", err)
+			}
+			write := osutil.WriteFile
+			if strings.HasSuffix(filepath.Dir(file), filepath.Join("tests", "generic")) &&
+				filepath.Ext(file) == "" {
+				// xfstests test scripts must be executable.
+				write = osutil.WriteExecFile
+			}
+			if err := write(file, content); err != nil {
+				failf("%v", err)
+			}
+			fmt.Printf("%v\n", file)
+		}
+	}
+	if *flagFormat == "xfstests" {
+		fmt.Fprintf(os.Stderr, "note: rename the tests with xfstests ./new script"+
+			" and add the programs to src/Makefile\n")
+	}
+}
+
+func loadFiles(files []string) ([][]byte, error) {
+	var res [][]byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+func loadCorpus(file, sigs string) ([][]byte, error) {
+	if sigs == "" {
+		return nil, fmt.Errorf("-corpus requires -sig")
+	}
+	corpus, err := db.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	var res [][]byte
+	for _, sig := range strings.Split(sigs, ",") {
+		rec, ok := corpus.Records[sig]
+		if !ok {
+			return nil, fmt.Errorf("no program %v in the corpus", sig)
+		}
+		res = append(res, rec.Val)
+	}
+	return res, nil
+}
+
+func parseTest(target *prog.Target, name string, data []byte) (*test, error) {
+	p, err := target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize the program: %v", err)
+	}
+	if len(p.Calls) == 0 {
+		return nil, fmt.Errorf("the program is empty")
+	}
+	t := &test{
+		name: name,
+		p:    p,
+		// Run the program in a loop in a tmp dir, so that the test cleans up after itself.
+		opts: csource.Options{
+			Threaded:       true,
+			Repeat:         true,
+			Procs:          1,
+			Sandbox:        "none",
+			EnableCloseFds: true,
+			UseTmpDir:      true,
+			HandleSegv:     true,
+			Readable:       true,
+		},
+	}
+	if len(p.Comments) != 0 && strings.HasPrefix(strings.TrimSpace(p.Comments[0]), "{\"") {
+		opts, err := csource.DeserializeOptions([]byte(p.Comments[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse options: %v", err)
+		}
+		// Otherwise the test either runs too short, or not in the tmp dir.
+		opts.Repeat, opts.RepeatTimes, opts.UseTmpDir = true, 0, true
+		opts.Repro, opts.Trace, opts.Strace = false, false, false
+		t.opts = opts
+	}
+	return t, nil
+}
+
+func generate(t *test) (map[string][]byte, error) {
+	switch *flagFormat {
+	case "xfstests":
+		return csource.WriteXfstests(t.p, t.opts, t.name, *flagTimeout)
+	case "kselftest":
+		src, err := csource.WriteKselftest(t.p, t.opts, t.name, *flagTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{t.name + ".c": src}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", *flagFormat)
+}
+
+func failf(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
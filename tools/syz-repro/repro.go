@@ -28,21 +28,41 @@ var (
 func main() {
 	os.Args = append(append([]string{}, os.Args[0], "-v=10"), os.Args[1:]...)
 	flag.Parse()
-	if len(flag.Args()) != 1 || *flagConfig == "" {
-		log.Fatalf("usage: syz-repro -config=manager.cfg execution.log")
+	if len(flag.Args()) != 1 || (*flagConfig == "") == (*flagKernel == "") {
+		log.Fatalf("usage: syz-repro -config=manager.cfg execution.log\n" +
+			"       syz-repro -kernel=bzImage [-image=rootfs -sshkey=key] execution.log")
 	}
-	cfg, err := mgrconfig.LoadFile(*flagConfig)
-	if err != nil {
-		log.Fatalf("%v: %v", *flagConfig, err)
+	var cfg *mgrconfig.Config
+	var err error
+	if *flagConfig != "" {
+		cfg, err = mgrconfig.LoadFile(*flagConfig)
+		if err != nil {
+			log.Fatalf("%v: %v", *flagConfig, err)
+		}
+	} else {
+		var cleanup func()
+		cfg, cleanup, err = standaloneConfig()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer cleanup()
 	}
 	logFile := flag.Args()[0]
 	data, err := ioutil.ReadFile(logFile)
 	if err != nil {
 		log.Fatalf("failed to open log file %v: %v", logFile, err)
 	}
-	if _, err := prog.GetTarget(cfg.TargetOS, cfg.TargetArch); err != nil {
+	target, err := prog.GetTarget(cfg.TargetOS, cfg.TargetArch)
+	if err != nil {
 		log.Fatalf("%v", err)
 	}
+	// The log does not have to come from syz-manager, it can be e.g. a raw console log.
+	data = repro.NormalizeLog(data)
+	entries := target.ParseLog(data)
+	if len(entries) == 0 {
+		log.Fatalf("no programs found in %v", logFile)
+	}
+	log.Logf(0, "found %v programs in %v", len(entries), logFile)
 	vmPool, err := vm.Create(cfg, *flagDebug)
 	if err != nil {
 		log.Fatalf("%v", err)
@@ -70,19 +90,31 @@ func main() {
 	}
 	if stats != nil {
 		fmt.Printf("Extracting prog: %v\n", stats.ExtractProgTime)
+		fmt.Printf("Searching options: %v\n", stats.SearchOptsTime)
 		fmt.Printf("Minimizing prog: %v\n", stats.MinimizeProgTime)
 		fmt.Printf("Simplifying prog options: %v\n", stats.SimplifyProgTime)
 		fmt.Printf("Extracting C: %v\n", stats.ExtractCTime)
 		fmt.Printf("Simplifying C: %v\n", stats.SimplifyCTime)
+		fmt.Printf("Finding triggering call: %v\n", stats.TriggerCallTime)
+		fmt.Printf("Validating: %v\n", stats.ValidateTime)
 	}
 	if res == nil {
 		return
 	}
 
-	fmt.Printf("opts: %+v crepro: %v\n\n", res.Opts, res.CRepro)
-	fmt.Printf("%s\n", res.Prog.Serialize())
+	fmt.Printf("opts: %+v crepro: %v\n", res.Opts, res.CRepro)
+	if res.Strategy != "" {
+		fmt.Printf("strategy: %v\n", res.Strategy)
+	}
+	if res.Validation != nil {
+		fmt.Printf("reliability: %v\n", res.Validation)
+	}
+	fmt.Printf("\n")
+	annotated, data := res.Annotated()
+	fmt.Printf("%v", res.LeakComment("# "))
+	fmt.Printf("%s\n", data)
 	if res.CRepro {
-		src, err := csource.Write(res.Prog, res.Opts)
+		src, err := csource.Write(annotated, res.Opts)
 		if err != nil {
 			log.Fatalf("failed to generate C repro: %v", err)
 		}
@@ -0,0 +1,91 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// Standalone mode allows to run syz-repro without a manager config and image set:
+// a temporary qemu pool is created for the given kernel and rootfs image
+// (or host root filesystem shared with the VMs via 9p if the image is not specified).
+var (
+	flagKernel    = flag.String("kernel", "", "standalone mode: kernel image to boot (e.g. arch/x86/boot/bzImage)")
+	flagKernelObj = flag.String("kernel_obj", "", "standalone mode: kernel build dir with vmlinux for symbolization")
+	flagImage     = flag.String("image", "", "standalone mode: rootfs image (host root is shared via 9p if empty)")
+	flagSSHKey    = flag.String("sshkey", "", "standalone mode: ssh key for the image")
+	flagTarget    = flag.String("target", runtime.GOOS+"/"+runtime.GOARCH, "standalone mode: target OS/arch")
+	flagSyzkaller = flag.String("syzkaller", "", "standalone mode: syzkaller dir with built binaries"+
+		" (by default derived from syz-repro location)")
+	flagCPU = flag.Int("cpu", 2, "standalone mode: number of VM CPUs")
+	flagMem = flag.Int("mem", 2048, "standalone mode: VM memory in MiB")
+)
+
+// standaloneConfig creates manager config with a temporary workdir for the standalone mode.
+// The returned function removes the workdir.
+func standaloneConfig() (*mgrconfig.Config, func(), error) {
+	if !osutil.IsExist(*flagKernel) {
+		return nil, nil, fmt.Errorf("kernel %v does not exist", *flagKernel)
+	}
+	syzkaller := *flagSyzkaller
+	if syzkaller == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get syzkaller dir, specify -syzkaller: %v", err)
+		}
+		// syz-repro is normally located in syzkaller/bin.
+		syzkaller = filepath.Dir(filepath.Dir(exe))
+	}
+	image := "9p"
+	if *flagImage != "" {
+		image = osutil.Abs(*flagImage)
+	}
+	count := *flagCount
+	if count <= 0 {
+		count = 2
+	}
+	workdir, err := ioutil.TempDir("", "syz-repro")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(workdir) }
+	raw := map[string]interface{}{
+		"target":     *flagTarget,
+		"http":       "127.0.0.1:0",
+		"workdir":    workdir,
+		"syzkaller":  syzkaller,
+		"image":      image,
+		"kernel_obj": *flagKernelObj,
+		"type":       "qemu",
+		"vm": map[string]interface{}{
+			"count":  count,
+			"kernel": osutil.Abs(*flagKernel),
+			"cpu":    *flagCPU,
+			"mem":    *flagMem,
+		},
+	}
+	if *flagSSHKey != "" {
+		raw["sshkey"] = osutil.Abs(*flagSSHKey)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cfg, err := mgrconfig.LoadData(data)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return cfg, cleanup, nil
+}
@@ -171,8 +171,8 @@ func (mgr *Manager) boot(name string, index int) (*report.Report, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to copy binary: %v", err)
 	}
-	cmd := instance.FuzzerCmd(fuzzerBin, executorBin, name,
-		mgr.cfg.TargetOS, mgr.cfg.TargetArch, fwdAddr, mgr.cfg.Sandbox, mgr.cfg.Procs, 0,
+	cmd := instance.FuzzerCmd(fuzzerBin, executorBin, "", name,
+		mgr.cfg.TargetOS, mgr.cfg.TargetArch, fwdAddr, mgr.cfg.Sandbox, mgr.cfg.Procs, 0, mgr.cfg.Slowdown,
 		mgr.cfg.Cover, mgr.debug, false, true)
 	outc, errc, err := inst.Run(time.Hour, mgr.vmStop, cmd)
 	if err != nil {
@@ -0,0 +1,160 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build linux
+
+// syz-serialexec relays executor protocol between the fuzzer and an executor that runs
+// on a device connected over serial (e.g. executor/zephyr). It is used in place of
+// syz-executor: the fuzzer talks to it over stdin/stdout as with a normal executor
+// without shmem, and it forwards the bytes to the serial line as is.
+// Usage:
+//	syz-serialexec -dev=/dev/ttyACM1 -baud=115200 [version]
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	flagDev  = flag.String("dev", "", "serial device of the executor channel")
+	flagBaud = flag.Int("baud", 115200, "serial baud rate")
+)
+
+// These must match executor/zephyr/src/executor.c.
+var (
+	syncMagic    = []byte("SYZ-SYNC")
+	versionMagic = []byte("SYZ-VERS")
+)
+
+func main() {
+	flag.Parse()
+	if *flagDev == "" {
+		fail("-dev is not specified")
+	}
+	dev, err := openSerial(*flagDev, *flagBaud)
+	if err != nil {
+		fail("%v", err)
+	}
+	defer dev.Close()
+	// Only one relay can talk to the device at a time,
+	// a concurrent one would corrupt the stream.
+	if err := unix.Flock(int(dev.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		fail("%v is used by another process: %v", *flagDev, err)
+	}
+	switch args := flag.Args(); {
+	case len(args) == 0:
+		relay(dev)
+	case len(args) == 1 && args[0] == "version":
+		version(dev)
+	default:
+		fail("unsupported command %q", args)
+	}
+}
+
+func relay(dev *os.File) {
+	// The sync tells the device to drop whatever is left from the previous
+	// relay and wait for a new handshake.
+	if _, err := dev.Write(syncMagic); err != nil {
+		fail("failed to write to %v: %v", *flagDev, err)
+	}
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(dev, os.Stdin)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(os.Stdout, dev)
+		errc <- err
+	}()
+	// The fuzzer kills us when it's done with the executor.
+	if err := <-errc; err != nil {
+		fail("relay failed: %v", err)
+	}
+}
+
+func version(dev *os.File) {
+	req := append(append([]byte{}, syncMagic...), versionMagic...)
+	if _, err := dev.Write(req); err != nil {
+		fail("failed to write to %v: %v", *flagDev, err)
+	}
+	done := make(chan string, 1)
+	go func() {
+		// Skip any leftovers of the previous program output.
+		r := bufio.NewReader(dev)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				fail("failed to read from %v: %v", *flagDev, err)
+			}
+			if len(strings.Fields(line)) == 4 {
+				done <- line
+				return
+			}
+		}
+	}()
+	select {
+	case line := <-done:
+		fmt.Print(line)
+	case <-time.After(time.Minute):
+		fail("device did not reply to version request")
+	}
+}
+
+var baudRates = map[int]uint32{
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	2000000: unix.B2000000,
+}
+
+// openSerial opens the device and puts it into raw mode, similar to vmimpl.OpenConsole.
+func openSerial(name string, baud int) (*os.File, error) {
+	speed, ok := baudRates[baud]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate %v", baud)
+	}
+	fd, err := unix.Open(name, unix.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %v: %v", name, err)
+	}
+	term, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to get %v termios: %v", name, err)
+	}
+	// 8N1, no flow control, raw binary input and output.
+	term.Cflag &^= unix.CBAUD | unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CRTSCTS
+	term.Cflag |= speed | unix.CS8 | unix.CLOCAL | unix.CREAD
+	term.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR |
+		unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF
+	term.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	term.Oflag &^= unix.OPOST
+	term.Ispeed = speed
+	term.Ospeed = speed
+	term.Cc[unix.VMIN] = 1
+	term.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, term); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to set %v termios: %v", name, err)
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+func fail(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
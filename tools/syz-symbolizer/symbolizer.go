@@ -0,0 +1,52 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-symbolizer is a local symbolization daemon that allows managers, syz-repro, syz-ci
+// and other tools running on the same machine to share addr2line processes
+// and symbolization results. Start it with -addr and -cache flags
+// and then set symbolizer_server in manager configs to the same address.
+package main
+
+import (
+	"flag"
+	"net"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+var (
+	flagAddr  = flag.String("addr", "localhost:23456", "address to serve symbolization requests on")
+	flagCache = flag.String("cache", "", "dir for the persistent symbolization cache")
+	flagFlush = flag.Duration("flush", time.Minute, "period of saving the cache to disk")
+)
+
+func main() {
+	flag.Parse()
+	if *flagCache == "" {
+		log.Fatalf("-cache is not specified")
+	}
+	ln, err := net.Listen("tcp", *flagAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on %v: %v", *flagAddr, err)
+	}
+	srv := symbolizer.NewServer(osutil.Abs(*flagCache))
+	go func() {
+		for range time.NewTicker(*flagFlush).C {
+			srv.Flush()
+		}
+	}()
+	shutdown := make(chan struct{})
+	osutil.HandleInterrupts(shutdown)
+	go func() {
+		<-shutdown
+		srv.Close()
+		ln.Close()
+	}()
+	log.Logf(0, "serving symbolization requests on %v", ln.Addr())
+	if err := srv.Serve(ln); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-testpatch submits a patch testing job to syz-ci local jobs API and waits for the result.
+// It's useful for self-hosted syz-ci instances that don't use dashboard. Usage:
+//
+//	syz-testpatch -ci=http://host:port -key=KEY -manager=upstream-kasan \
+//		[-repo=URL -branch=BRANCH] [-config=.config] [-patch=fix.patch] -repro=repro.syz [-reproc=repro.c]
+//
+// The job can also be queried later with -job=ID.
+// syz-ci needs jobs_api_key in config for the API to work.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+var (
+	flagCI      = flag.String("ci", "", "syz-ci http address (e.g. http://localhost:8080)")
+	flagKey     = flag.String("key", "", "syz-ci jobs_api_key")
+	flagManager = flag.String("manager", "", "syz-ci manager name")
+	flagRepo    = flag.String("repo", "", "kernel repo (defaults to the manager repo)")
+	flagBranch  = flag.String("branch", "", "kernel branch or commit (defaults to the manager branch)")
+	flagConfig  = flag.String("config", "", "kernel config file (defaults to the manager config)")
+	flagPatch   = flag.String("patch", "", "patch file to apply")
+	flagRepro   = flag.String("repro", "", "syz reproducer file")
+	flagReproC  = flag.String("reproc", "", "C reproducer file")
+	flagOpts    = flag.String("opts", "", "reproducer options (default: first line of the syz reproducer)")
+	flagJob     = flag.String("job", "", "query result of an existing job")
+	flagWait    = flag.Bool("wait", true, "wait for the job to finish")
+)
+
+// Subset of syz-ci LocalJobReq/LocalJob.
+type localJobReq struct {
+	Manager      string
+	KernelRepo   string
+	KernelBranch string
+	KernelConfig []byte
+	Patch        []byte
+	ReproOpts    []byte
+	ReproSyz     []byte
+	ReproC       []byte
+}
+
+type localJob struct {
+	ID     string
+	Status string
+	Result *dashapi.JobDoneReq
+}
+
+func main() {
+	flag.Parse()
+	if *flagCI == "" || *flagKey == "" {
+		log.Fatalf("-ci and -key are required")
+	}
+	id := *flagJob
+	if id == "" {
+		job, err := submit()
+		if err != nil {
+			log.Fatal(err)
+		}
+		id = job.ID
+		fmt.Printf("submitted job %v\n", id)
+	}
+	for {
+		job := new(localJob)
+		if err := call(http.MethodGet, "/api/jobs/"+id, nil, job); err != nil {
+			log.Fatal(err)
+		}
+		if job.Status == "done" {
+			os.Exit(printResult(job.Result))
+		}
+		if !*flagWait {
+			fmt.Printf("job %v is %v\n", id, job.Status)
+			return
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+func submit() (*localJob, error) {
+	if *flagManager == "" || *flagRepro == "" {
+		return nil, fmt.Errorf("-manager and -repro are required")
+	}
+	req := &localJobReq{
+		Manager:      *flagManager,
+		KernelRepo:   *flagRepo,
+		KernelBranch: *flagBranch,
+		ReproOpts:    []byte(*flagOpts),
+	}
+	var err error
+	files := []struct {
+		name string
+		data *[]byte
+	}{
+		{*flagConfig, &req.KernelConfig},
+		{*flagPatch, &req.Patch},
+		{*flagRepro, &req.ReproSyz},
+		{*flagReproC, &req.ReproC},
+	}
+	for _, f := range files {
+		if f.name == "" {
+			continue
+		}
+		if *f.data, err = ioutil.ReadFile(f.name); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.ReproOpts) == 0 {
+		// Reproducers saved by syz-manager start with "# {options}".
+		line := string(req.ReproSyz)
+		if pos := strings.IndexByte(line, '\n'); pos != -1 {
+			line = line[:pos]
+		}
+		if !strings.HasPrefix(line, "#") {
+			return nil, fmt.Errorf("no -opts and the reproducer does not start with options")
+		}
+		req.ReproOpts = []byte(strings.TrimSpace(line[1:]))
+	}
+	job := new(localJob)
+	return job, call(http.MethodPost, "/api/jobs", req, job)
+}
+
+func call(method, path string, req, res interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+	httpReq, err := http.NewRequest(method, strings.TrimSuffix(*flagCI, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+*flagKey)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("request failed: %v: %s", resp.Status, data)
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+// printResult prints the job result and returns exit status:
+// 0 if the reproducer did not crash the kernel, 1 if it crashed, 2 if the job failed.
+func printResult(res *dashapi.JobDoneReq) int {
+	fmt.Printf("kernel: %v %v (%v)\n", res.Build.KernelRepo, res.Build.KernelCommit, res.Build.KernelCommitTitle)
+	switch {
+	case len(res.Error) != 0:
+		fmt.Printf("job failed:\n%s\n", res.Error)
+		return 2
+	case res.CrashTitle != "":
+		fmt.Printf("kernel crashed: %v\n\n%s\n", res.CrashTitle, res.CrashReport)
+		return 1
+	default:
+		fmt.Printf("reproducer did not trigger any issue\n")
+		return 0
+	}
+}
@@ -201,6 +201,8 @@ func (ctx *context) genArray(syzType *prog.ArrayType, traceType parser.IrType) p
 		for i := 0; i < len(a.Elems); i++ {
 			args = append(args, ctx.genArgs(syzType.Type, a.Elems[i]))
 		}
+	case *parser.BufferType:
+		return ctx.genRaw(syzType, a)
 	default:
 		log.Fatalf("unsupported type for array: %#v", traceType)
 	}
@@ -231,10 +233,10 @@ func (ctx *context) genStruct(syzType *prog.StructType, traceType parser.IrType)
 			j++
 		}
 	case *parser.BufferType:
-		// We could have a case like the following:
+		// Either a raw memory dump of the struct, or a case like the following:
 		// ioctl(3, 35111, {ifr_name="\x6c\x6f", ifr_hwaddr=00:00:00:00:00:00}) = 0
 		// if_hwaddr gets parsed as a BufferType but our syscall descriptions have it as a struct type
-		return syzType.DefaultArg()
+		return ctx.genRaw(syzType, a)
 	default:
 		log.Fatalf("unsupported type for struct: %#v", a)
 	}
@@ -262,6 +262,14 @@ ioctl(3, 0x4028700f, {enabled=0, pending=0, time={tm_sec=0, tm_min=0, tm_hour=0,
 r0 = openat$rtc(0xffffffffffffff9c, &(0x7f0000000000)='/dev/rtc0\x00', 0x0, 0x0)
 ioctl$RTC_WKALM_SET(r0, 0x4028700f, &(0x7f0000000040)={0x0, 0x0, {0x0, 0x0, 0x0, 0x0, 0x10000, 0x5181}})`,
 		},
+		{
+			`
+openat(-100, "\x2f\x64\x65\x76\x2f\x72\x74\x63\x30", 0) = 3
+ioctl(3, 0x4028700f, "\x01\x00\x00\x00\x05\x00\x00\x00\x0a\x00\x00\x00\x0c\x00\x00\x00\x01\x00\x00\x00") = 0`,
+			`
+r0 = openat$rtc(0xffffffffffffff9c, &(0x7f0000000000)='/dev/rtc0\x00', 0x0, 0x0)
+ioctl$RTC_WKALM_SET(r0, 0x4028700f, &(0x7f0000000040)={0x1, 0x0, {0x5, 0xa, 0xc, 0x1}})`,
+		},
 	}
 	target, err := prog.GetTarget("linux", "amd64")
 	if err != nil {
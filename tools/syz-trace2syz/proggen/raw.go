@@ -0,0 +1,142 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package proggen
+
+import (
+	"encoding/binary"
+	"regexp"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/tools/syz-trace2syz/parser"
+)
+
+// Strace prints payloads it can't decode (e.g. arguments of unknown ioctls) as raw memory dumps
+// ("\x01\x00\x00\x00..." with -xx). genRaw decodes such dumps into structured arguments
+// using layout of the syzkaller descriptions, instead of using default values.
+// Pointers are not followed (they are addresses in the traced process).
+
+var macRe = regexp.MustCompile(`^([0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}$`)
+
+func (ctx *context) genRaw(syzType prog.Type, traceType *parser.BufferType) prog.Arg {
+	if macRe.MatchString(traceType.Val) {
+		// MAC addresses are parsed as buffers, but they are not memory dumps.
+		return syzType.DefaultArg()
+	}
+	log.Logf(3, "decoding raw memory of size %v as %v", len(traceType.Val), syzType.Name())
+	arg, _ := ctx.decodeRaw(syzType, []byte(traceType.Val))
+	return arg
+}
+
+// decodeRaw decodes an argument of type syzType from data and returns it along with the number of consumed bytes.
+func (ctx *context) decodeRaw(syzType prog.Type, data []byte) (prog.Arg, uint64) {
+	size := uint64(0)
+	if !syzType.Varlen() {
+		size = syzType.Size()
+		if syzType.BitfieldMiddle() {
+			size = 0
+		}
+		// Trailing zeros may be missing in the dump.
+		for uint64(len(data)) < syzType.Size() {
+			data = append(data, 0)
+		}
+	}
+	if syzType.Dir() == prog.DirOut {
+		if _, ok := syzType.(*prog.ResourceType); !ok && !syzType.Varlen() {
+			return syzType.DefaultArg(), size
+		}
+	}
+	switch typ := syzType.(type) {
+	case *prog.IntType, *prog.FlagsType:
+		if typ.Format() != prog.FormatNative && typ.Format() != prog.FormatBigEndian {
+			return syzType.DefaultArg(), size
+		}
+		return prog.MakeConstArg(syzType, rawInt(typ, data)), size
+	case *prog.ResourceType:
+		if typ.Dir() == prog.DirOut {
+			return prog.MakeResultArg(typ, nil, typ.Default()), size
+		}
+		return ctx.genResource(typ, parser.Constant(rawInt(typ, data))), size
+	case *prog.BufferType:
+		if !typ.Varlen() {
+			return prog.MakeDataArg(typ, data[:size]), size
+		}
+		if typ.Dir() == prog.DirOut {
+			return prog.MakeOutDataArg(typ, uint64(len(data))), uint64(len(data))
+		}
+		if typ.Kind == prog.BufferBlobRange && uint64(len(data)) > typ.RangeEnd {
+			data = data[:typ.RangeEnd]
+		}
+		return prog.MakeDataArg(typ, data), uint64(len(data))
+	case *prog.StructType:
+		var args []prog.Arg
+		off := uint64(0)
+		for _, field := range typ.Fields {
+			if off > uint64(len(data)) {
+				off = uint64(len(data))
+			}
+			arg, n := ctx.decodeRaw(field, data[off:])
+			args = append(args, arg)
+			off += n
+		}
+		if typ.Varlen() {
+			size = off
+		}
+		return prog.MakeGroupArg(typ, args), size
+	case *prog.UnionType:
+		// We can't know what option was used, so take the first as the most common one.
+		arg, n := ctx.decodeRaw(typ.Fields[0], data)
+		if typ.Varlen() {
+			size = n
+		}
+		return prog.MakeUnionArg(typ, arg), size
+	case *prog.ArrayType:
+		var args []prog.Arg
+		off := uint64(0)
+		fixed := typ.Kind == prog.ArrayRangeLen
+		for i := uint64(0); !fixed || i < typ.RangeEnd; i++ {
+			if off >= uint64(len(data)) && (!fixed || i >= typ.RangeBegin) {
+				break
+			}
+			if off > uint64(len(data)) {
+				off = uint64(len(data))
+			}
+			arg, n := ctx.decodeRaw(typ.Type, data[off:])
+			args = append(args, arg)
+			if n == 0 {
+				break
+			}
+			off += n
+		}
+		if typ.Varlen() {
+			size = off
+		}
+		return prog.MakeGroupArg(typ, args), size
+	default:
+		// Pointers, vma, const, len, csum and proc values are not taken from the dump.
+		return syzType.DefaultArg(), size
+	}
+}
+
+func rawInt(typ prog.Type, data []byte) uint64 {
+	order := binary.ByteOrder(binary.LittleEndian)
+	if typ.Format() == prog.FormatBigEndian {
+		order = binary.BigEndian
+	}
+	var val uint64
+	switch typ.Size() {
+	case 1:
+		val = uint64(data[0])
+	case 2:
+		val = uint64(order.Uint16(data))
+	case 4:
+		val = uint64(order.Uint32(data))
+	case 8:
+		val = order.Uint64(data)
+	}
+	if typ.BitfieldLength() != 0 {
+		val = val >> typ.BitfieldOffset() & (1<<typ.BitfieldLength() - 1)
+	}
+	return val
+}
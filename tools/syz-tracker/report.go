@@ -0,0 +1,112 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"github.com/google/syzkaller/pkg/tracker"
+)
+
+// formatReport formats a dashboard report as a tracker issue (for new bugs) or comment.
+func formatReport(rep *dashapi.BugReport) *tracker.Issue {
+	buf := new(bytes.Buffer)
+	switch rep.Type {
+	case dashapi.ReportNew:
+		fmt.Fprintf(buf, "syzbot found the following crash.\n\n")
+	case dashapi.ReportRepro:
+		fmt.Fprintf(buf, "syzbot has found a reproducer for this crash.\n\n")
+	case dashapi.ReportTestPatch:
+		if len(rep.Error) != 0 || rep.CrashTitle != "" {
+			fmt.Fprintf(buf, "Patch testing failed: %v\n\n", rep.CrashTitle)
+		} else {
+			fmt.Fprintf(buf, "The patch is tested and the reproducer did not trigger the crash.\n\n")
+		}
+		if rep.PatchLink != "" {
+			fmt.Fprintf(buf, "Patch: %v\n", rep.PatchLink)
+		}
+	case dashapi.ReportBisectCause:
+		formatBisection(buf, "cause", rep.BisectCause)
+	case dashapi.ReportBisectFix:
+		formatBisection(buf, "fix", rep.BisectFix)
+	}
+	fmt.Fprintf(buf, "Dashboard link: %v\n", rep.Link)
+	if rep.KernelCommit != "" {
+		fmt.Fprintf(buf, "Kernel: %v %v %v\n", rep.KernelRepoAlias, rep.KernelCommit, rep.KernelCommitTitle)
+	}
+	if rep.CompilerID != "" {
+		fmt.Fprintf(buf, "Compiler: %v\n", rep.CompilerID)
+	}
+	links := []struct {
+		title string
+		link  string
+	}{
+		{"Console output", rep.LogLink},
+		{"Kernel config", rep.KernelConfigLink},
+		{"Syz reproducer", rep.ReproSyzLink},
+		{"C reproducer", rep.ReproCLink},
+		{"Error log", rep.ErrorLink},
+	}
+	for _, l := range links {
+		if l.link != "" {
+			fmt.Fprintf(buf, "%v: %v\n", l.title, l.link)
+		}
+	}
+	for _, asset := range rep.Assets {
+		fmt.Fprintf(buf, "Download %v: %v (expires %v)\n", asset.Title, asset.Link,
+			asset.Expires.Format("2006-01-02"))
+	}
+	if len(rep.HappenedOn) != 0 {
+		fmt.Fprintf(buf, "Happened on: %v\n", strings.Join(rep.HappenedOn, ", "))
+	}
+	issue := &tracker.Issue{
+		Title: rep.Title,
+		Body:  buf.String(),
+	}
+	attachments := []struct {
+		name string
+		data []byte
+	}{
+		{"report.txt", rep.Report},
+		{"repro.syz", rep.ReproSyz},
+		{"repro.c", rep.ReproC},
+		{"error.txt", rep.Error},
+	}
+	for _, att := range attachments {
+		if len(att.data) != 0 {
+			issue.Attachments = append(issue.Attachments, tracker.Attachment{Name: att.name, Data: att.data})
+		}
+	}
+	// Config is large and is the same for all reports from the same build,
+	// so attach it only to new issues.
+	if rep.Type == dashapi.ReportNew && len(rep.KernelConfig) != 0 {
+		issue.Attachments = append(issue.Attachments, tracker.Attachment{Name: "config.txt", Data: rep.KernelConfig})
+	}
+	return issue
+}
+
+func formatBisection(buf *bytes.Buffer, what string, res *dashapi.BisectResult) {
+	if res == nil {
+		return
+	}
+	switch {
+	case res.Commit != nil:
+		fmt.Fprintf(buf, "Bisection found the %v commit:\n\n%v %v\nAuthor: %v\n\n",
+			what, res.Commit.Hash, res.Commit.Title, res.Commit.Author)
+	case len(res.Commits) != 0:
+		fmt.Fprintf(buf, "Bisection of the %v is inconclusive, the first commit could be any of:\n\n", what)
+		for _, com := range res.Commits {
+			fmt.Fprintf(buf, "%v %v\n", com.Hash, com.Title)
+		}
+		fmt.Fprintf(buf, "\n")
+	default:
+		fmt.Fprintf(buf, "Bisection of the %v did not find the commit.\n\n", what)
+	}
+	if res.LogLink != "" {
+		fmt.Fprintf(buf, "Bisection log: %v\n", res.LogLink)
+	}
+}
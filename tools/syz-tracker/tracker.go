@@ -0,0 +1,322 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-tracker files dashboard bugs into an external issue tracker (GitHub Issues, GitLab or Bugzilla).
+// It implements the external reporting protocol: the dashboard reporting stage that should be
+// handled by syz-tracker needs to have ExternalConfig with ID equal to the reporting param in
+// the syz-tracker config. syz-tracker periodically:
+//   - polls new bugs and files them into the tracker with the report, reproducers and config attached,
+//   - adds reproducers and patch testing/bisection results as comments to the existing issues,
+//   - closes issues for bugs that were obsoleted, invalidated or fixed on the dashboard
+//     (including fixes detected by fix commits in tested trees).
+//
+// Usage:
+//
+//	syz-tracker -config=tracker.cfg
+//
+// Config example:
+//
+//	{
+//		"dashboard_addr": "https://syzkaller.appspot.com",
+//		"dashboard_client": "tracker",
+//		"dashboard_key": "...",
+//		"reporting": "github-foo",
+//		"state": "/var/lib/syz-tracker/state.json",
+//		"tracker": {
+//			"type": "github",
+//			"project": "foo/bar",
+//			"token": "...",
+//			"labels": ["syzbot"]
+//		}
+//	}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/tracker"
+)
+
+var flagConfig = flag.String("config", "", "config file")
+
+type Config struct {
+	DashboardAddr   string `json:"dashboard_addr"`
+	DashboardClient string `json:"dashboard_client"`
+	DashboardKey    string `json:"dashboard_key"`
+	// Reporting type, must match ID of the dashboard ExternalConfig reporting stage.
+	Reporting string `json:"reporting"`
+	// File where the set of open issues is persisted across restarts.
+	State string `json:"state"`
+	// Polling period in seconds (60 by default).
+	PollPeriod int            `json:"poll_period"`
+	Tracker    tracker.Config `json:"tracker"`
+}
+
+// Issue is an open tracker issue for a dashboard bug.
+type Issue struct {
+	ID    string // tracker issue ID
+	Title string
+	Link  string // dashboard link
+}
+
+type Syncer struct {
+	cfg     *Config
+	dash    *dashapi.Dashboard
+	tracker tracker.Tracker
+	// Open issues keyed by bug reporting ID.
+	issues map[string]*Issue
+}
+
+func main() {
+	flag.Parse()
+	cfg, err := loadConfig(*flagConfig)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	tr, err := tracker.New(&cfg.Tracker)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	s := &Syncer{
+		cfg:     cfg,
+		dash:    dashapi.New(cfg.DashboardClient, cfg.DashboardAddr, cfg.DashboardKey),
+		tracker: tr,
+		issues:  make(map[string]*Issue),
+	}
+	if err := s.loadState(); err != nil {
+		log.Fatalf("%v", err)
+	}
+	shutdownPending := make(chan struct{})
+	osutil.HandleInterrupts(shutdownPending)
+	for {
+		s.poll()
+		select {
+		case <-time.After(time.Duration(cfg.PollPeriod) * time.Second):
+		case <-shutdownPending:
+			return
+		}
+	}
+}
+
+func loadConfig(filename string) (*Config, error) {
+	cfg := &Config{
+		PollPeriod: 60,
+	}
+	if err := config.LoadFile(filename, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.DashboardAddr == "" || cfg.DashboardClient == "" {
+		return nil, fmt.Errorf("no dashboard info")
+	}
+	if cfg.Reporting == "" {
+		return nil, fmt.Errorf("param 'reporting' is empty")
+	}
+	if cfg.State == "" {
+		return nil, fmt.Errorf("param 'state' is empty")
+	}
+	if cfg.PollPeriod <= 0 {
+		return nil, fmt.Errorf("param 'poll_period' has bad value %v", cfg.PollPeriod)
+	}
+	return cfg, nil
+}
+
+func (s *Syncer) poll() {
+	if err := s.pollBugs(); err != nil {
+		log.Logf(0, "failed to poll bugs: %v", err)
+	}
+	if err := s.pollNotifications(); err != nil {
+		log.Logf(0, "failed to poll notifications: %v", err)
+	}
+	if err := s.pollClosed(); err != nil {
+		log.Logf(0, "failed to poll closed bugs: %v", err)
+	}
+	if err := s.saveState(); err != nil {
+		log.Logf(0, "%v", err)
+	}
+}
+
+func (s *Syncer) pollBugs() error {
+	resp, err := s.dash.ReportingPollBugs(s.cfg.Reporting)
+	if err != nil {
+		return err
+	}
+	for _, rep := range resp.Reports {
+		if err := s.report(rep); err != nil {
+			log.Logf(0, "failed to report %q: %v", rep.Title, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) report(rep *dashapi.BugReport) error {
+	if rep.JobID != "" {
+		// Patch testing and bisection results go as comments to the existing issue.
+		if rep.ExtID != "" {
+			if err := s.tracker.Comment(rep.ExtID, formatReport(rep)); err != nil {
+				return err
+			}
+		}
+		return s.update(&dashapi.BugUpdate{JobID: rep.JobID})
+	}
+	if rep.ExtID == "" {
+		newID, link, err := s.tracker.Create(formatReport(rep))
+		if err != nil {
+			return err
+		}
+		log.Logf(0, "filed %q as %v", rep.Title, link)
+		upd := &dashapi.BugUpdate{
+			ID:    rep.ID,
+			ExtID: newID,
+			Link:  link,
+		}
+		// Remember the issue before the update, so that it's closed later even if the update fails.
+		s.issues[rep.ID] = &Issue{ID: newID, Title: rep.Title, Link: rep.Link}
+		return s.updateBug(upd, rep)
+	}
+	// The bug is already filed, this is a report with a newly found reproducer.
+	if err := s.tracker.Comment(rep.ExtID, formatReport(rep)); err != nil {
+		return err
+	}
+	s.issues[rep.ID] = &Issue{ID: rep.ExtID, Title: rep.Title, Link: rep.Link}
+	return s.updateBug(&dashapi.BugUpdate{ID: rep.ID}, rep)
+}
+
+func (s *Syncer) updateBug(upd *dashapi.BugUpdate, rep *dashapi.BugReport) error {
+	upd.Status = dashapi.BugStatusOpen
+	upd.CrashID = rep.CrashID
+	upd.ReproLevel = dashapi.ReproLevelNone
+	if len(rep.ReproC) != 0 {
+		upd.ReproLevel = dashapi.ReproLevelC
+	} else if len(rep.ReproSyz) != 0 {
+		upd.ReproLevel = dashapi.ReproLevelSyz
+	}
+	return s.update(upd)
+}
+
+func (s *Syncer) update(upd *dashapi.BugUpdate) error {
+	reply, err := s.dash.ReportingUpdate(upd)
+	if err != nil {
+		return err
+	}
+	if !reply.OK {
+		return fmt.Errorf("dashboard rejected update: %v", reply.Text)
+	}
+	return nil
+}
+
+func (s *Syncer) pollNotifications() error {
+	resp, err := s.dash.ReportingPollNotifications(s.cfg.Reporting)
+	if err != nil {
+		return err
+	}
+	for _, notif := range resp.Notifications {
+		if err := s.notify(notif); err != nil {
+			log.Logf(0, "failed to send notification for %q: %v", notif.Title, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) notify(notif *dashapi.BugNotification) error {
+	status := dashapi.BugStatusOpen
+	switch notif.Type {
+	case dashapi.BugNotifUpstream:
+		status = dashapi.BugStatusUpstream
+		if err := s.tracker.Comment(notif.ExtID, &tracker.Issue{
+			Body: "Sending this report upstream.",
+		}); err != nil {
+			return err
+		}
+	case dashapi.BugNotifBadCommit:
+		if err := s.tracker.Comment(notif.ExtID, &tracker.Issue{
+			Body: fmt.Sprintf("This bug is marked as fixed by commit:\n%v\n"+
+				"But the commit is not found in any tested tree for a long time.\n"+
+				"Until the commit is corrected the bug is still considered open and\n"+
+				"new crashes with the same signature are ignored.", notif.Text),
+		}); err != nil {
+			return err
+		}
+	case dashapi.BugNotifObsoleted:
+		status = dashapi.BugStatusInvalid
+		if err := s.tracker.Close(notif.ExtID, "Auto-closing this bug as obsolete.\n"+
+			"Crashes did not happen for a while, no reproducer and no activity.", false); err != nil {
+			return err
+		}
+		delete(s.issues, notif.ID)
+	default:
+		return fmt.Errorf("bad notification type %v", notif.Type)
+	}
+	return s.update(&dashapi.BugUpdate{
+		ID:           notif.ID,
+		Status:       status,
+		Notification: true,
+	})
+}
+
+// pollClosed closes issues for bugs that were closed on the dashboard
+// (fixed by a commit, marked as invalid or duplicate, or upstreamed to the next reporting).
+func (s *Syncer) pollClosed() error {
+	if len(s.issues) == 0 {
+		return nil
+	}
+	var ids []string
+	for id := range s.issues {
+		ids = append(ids, id)
+	}
+	resp := new(dashapi.PollClosedResponse)
+	if err := s.dash.Query("reporting_poll_closed", &dashapi.PollClosedRequest{IDs: ids}, resp); err != nil {
+		return err
+	}
+	fixed := make(map[string]bool)
+	for _, id := range resp.Fixed {
+		fixed[id] = true
+	}
+	for _, id := range resp.IDs {
+		issue := s.issues[id]
+		comment := fmt.Sprintf("This bug is closed on the dashboard:\n%v", issue.Link)
+		if fixed[id] {
+			comment = fmt.Sprintf("This bug is fixed, see the fixing commit on the dashboard:\n%v", issue.Link)
+		}
+		if err := s.tracker.Close(issue.ID, comment, fixed[id]); err != nil {
+			log.Logf(0, "failed to close %q: %v", issue.Title, err)
+			continue
+		}
+		log.Logf(0, "closed %q", issue.Title)
+		delete(s.issues, id)
+	}
+	return nil
+}
+
+func (s *Syncer) loadState() error {
+	data, err := ioutil.ReadFile(s.cfg.State)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.issues); err != nil {
+		return fmt.Errorf("failed to parse state file %v: %v", s.cfg.State, err)
+	}
+	return nil
+}
+
+func (s *Syncer) saveState() error {
+	data, err := json.MarshalIndent(s.issues, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFile(s.cfg.State, data); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	return nil
+}
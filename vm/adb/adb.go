@@ -50,6 +50,7 @@ type instance struct {
 	console string
 	closed  chan bool
 	debug   bool
+	pstore  []byte
 }
 
 func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
@@ -113,6 +114,11 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		return nil, err
 	}
 	inst.adb("shell", "echo 0 > /proc/sys/kernel/kptr_restrict")
+	// Phones are frequently in a state where the serial console does not work,
+	// but ramoops keeps the log of the crashed boot.
+	if out, err := inst.adb("shell", vmimpl.PstoreCommand); err == nil && len(out) != 0 {
+		inst.pstore = out
+	}
 	closeInst = nil
 	return inst, nil
 }
@@ -404,6 +410,10 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 	return vmimpl.Multiplex(adb, merger, tty, timeout, stop, inst.closed, inst.debug)
 }
 
+func (inst *instance) PstoreLog() []byte {
+	return inst.pstore
+}
+
 func (inst *instance) Diagnose() ([]byte, bool) {
 	return nil, false
 }
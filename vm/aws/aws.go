@@ -0,0 +1,369 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package aws allows to use Amazon EC2 instances as VMs.
+// It is assumed that syz-manager also runs on EC2 as VMs are created in the current region/subnet.
+// The aws command line tool must be installed and the manager instance role must allow
+// EC2 instance/image management, S3 uploads (if image is used) and EC2 serial console access.
+//
+// Kernel output is captured with EC2 serial console, which needs to be enabled for the account:
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ec2-serial-console.html
+// Serial console is supported only on Nitro-based instance types.
+// Importing disk images as AMIs requires the vmimport service role:
+// https://docs.aws.amazon.com/vm-import/latest/userguide/vmie_prereqs.html
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/syzkaller/pkg/aws"
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("aws", ctor, true)
+}
+
+type Config struct {
+	Count        int    `json:"count"`         // number of VMs to use
+	InstanceType string `json:"instance_type"` // EC2 instance type (e.g. "c5.large"), must be Nitro-based
+	S3Path       string `json:"s3_path"`       // S3 path to upload image (s3://bucket/dir)
+	AMI          string `json:"ami"`           // pre-created AMI to use
+	Spot         bool   `json:"spot"`          // use spot instances if available (defaults to true)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+	AWS *aws.Context
+}
+
+type instance struct {
+	env      *vmimpl.Env
+	cfg      *Config
+	AWS      *aws.Context
+	debug    bool
+	name     string
+	id       string
+	ip       string
+	conKey   string // per-instance private ssh key used for serial console access
+	sshKey   string
+	sshUser  string
+	closed   chan bool
+	consolew io.WriteCloser
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	if env.Name == "" {
+		return nil, fmt.Errorf("config param name is empty (required for AWS)")
+	}
+	cfg := &Config{
+		Count: 1,
+		Spot:  true,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse aws vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 1000 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 1000]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.InstanceType == "" {
+		return nil, fmt.Errorf("instance_type parameter is empty")
+	}
+	if cfg.AMI == "" && cfg.S3Path == "" {
+		return nil, fmt.Errorf("s3_path parameter is empty")
+	}
+	if cfg.AMI == "" && env.Image == "" {
+		return nil, fmt.Errorf("config param image is empty (required for AWS)")
+	}
+	if cfg.AMI != "" && env.Image != "" {
+		return nil, fmt.Errorf("both image and ami are specified")
+	}
+	if env.SSHKey == "" {
+		return nil, fmt.Errorf("config param sshkey is empty (required for AWS)")
+	}
+	arch := ""
+	switch env.Arch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "arm64"
+	default:
+		return nil, fmt.Errorf("aws does not support %v arch", env.Arch)
+	}
+
+	AWS, err := aws.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init aws: %v", err)
+	}
+	log.Logf(0, "AWS initialized: running on %v, internal IP %v, region %v, subnet %v, security groups %v",
+		AWS.Instance, AWS.InternalIP, AWS.Region, AWS.Subnet, AWS.SecurityGroups)
+
+	if cfg.AMI == "" {
+		s3Image := cfg.S3Path + "/" + env.Name + "-image.raw"
+		log.Logf(0, "uploading image %v to %v...", env.Image, s3Image)
+		if err := AWS.UploadFile(env.Image, s3Image); err != nil {
+			return nil, fmt.Errorf("failed to upload image: %v", err)
+		}
+		log.Logf(0, "creating AMI %v...", env.Name)
+		cfg.AMI, err = AWS.CreateImage(env.Name, s3Image, arch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AMI: %v", err)
+		}
+	}
+	pool := &Pool{
+		cfg: cfg,
+		env: env,
+		AWS: AWS,
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	name := fmt.Sprintf("%v-%v", pool.env.Name, index)
+	// Create SSH key for serial console access.
+	conKey := filepath.Join(workdir, "key")
+	keygen := osutil.Command("ssh-keygen", "-t", "rsa", "-b", "2048", "-N", "", "-C", "syzkaller", "-f", conKey)
+	if out, err := keygen.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to execute ssh-keygen: %v\n%s", err, out)
+	}
+
+	log.Logf(0, "deleting instance: %v", name)
+	if err := pool.AWS.DeleteInstance(name, true); err != nil {
+		return nil, err
+	}
+	log.Logf(0, "creating instance: %v", name)
+	awsInst, err := pool.AWS.CreateInstance(name, pool.cfg.InstanceType, pool.cfg.AMI, pool.cfg.Spot)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := false
+	defer func() {
+		if !ok {
+			pool.AWS.DeleteInstance(name, false)
+		}
+	}()
+	log.Logf(0, "wait instance to boot: %v (%v, %v)", name, awsInst.ID, awsInst.IP)
+	if err := vmimpl.WaitForSSH(pool.env.Debug, 5*time.Minute, awsInst.IP,
+		pool.env.SSHKey, pool.env.SSHUser, pool.env.OS, 22, nil); err != nil {
+		output, outputErr := pool.AWS.ConsoleOutput(awsInst.ID)
+		if outputErr != nil {
+			output = []byte(fmt.Sprintf("failed to get boot output: %v", outputErr))
+		}
+		return nil, vmimpl.MakeBootError(err, output)
+	}
+	ok = true
+	inst := &instance{
+		env:     pool.env,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		AWS:     pool.AWS,
+		name:    name,
+		id:      awsInst.ID,
+		ip:      awsInst.IP,
+		conKey:  conKey,
+		sshKey:  pool.env.SSHKey,
+		sshUser: pool.env.SSHUser,
+		closed:  make(chan bool),
+	}
+	return inst, nil
+}
+
+func (inst *instance) Close() {
+	close(inst.closed)
+	inst.AWS.DeleteInstance(inst.name, false)
+	if inst.consolew != nil {
+		inst.consolew.Close()
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.AWS.InternalIP, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := "./" + filepath.Base(hostSrc)
+	args := append(vmimpl.SCPArgs(inst.debug, inst.sshKey, 22), hostSrc, inst.sshUser+"@"+inst.ip+":"+vmDst)
+	if err := runCmd(inst.debug, "scp", args...); err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	// The pushed key is valid for 60 seconds, connection must be established within this time.
+	if err := inst.AWS.SendSerialConsoleKey(inst.id, inst.conKey+".pub"); err != nil {
+		return nil, nil, fmt.Errorf("failed to authorize console key: %v", err)
+	}
+	conRpipe, conWpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	conArgs := append(vmimpl.SSHArgs(inst.debug, inst.conKey, 22), inst.AWS.SerialConsoleAddr(inst.id))
+	con := osutil.Command("ssh", conArgs...)
+	con.Env = []string{}
+	con.Stdout = conWpipe
+	con.Stderr = conWpipe
+	conw, err := con.StdinPipe()
+	if err != nil {
+		conRpipe.Close()
+		conWpipe.Close()
+		return nil, nil, err
+	}
+	if inst.consolew != nil {
+		inst.consolew.Close()
+	}
+	inst.consolew = conw
+	if err := con.Start(); err != nil {
+		conRpipe.Close()
+		conWpipe.Close()
+		return nil, nil, fmt.Errorf("failed to connect to console server: %v", err)
+	}
+	conWpipe.Close()
+
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	merger := vmimpl.NewOutputMerger(tee)
+	merger.Add("console", conRpipe)
+	if err := waitForConsoleConnect(merger); err != nil {
+		con.Process.Kill()
+		merger.Wait()
+		return nil, nil, err
+	}
+	sshRpipe, sshWpipe, err := osutil.LongPipe()
+	if err != nil {
+		con.Process.Kill()
+		merger.Wait()
+		return nil, nil, err
+	}
+	if inst.env.OS == "linux" {
+		if inst.sshUser != "root" {
+			command = fmt.Sprintf("sudo bash -c '%v'", command)
+		}
+	}
+	args := append(vmimpl.SSHArgs(inst.debug, inst.sshKey, 22), inst.sshUser+"@"+inst.ip, command)
+	ssh := osutil.Command("ssh", args...)
+	ssh.Stdout = sshWpipe
+	ssh.Stderr = sshWpipe
+	if err := ssh.Start(); err != nil {
+		con.Process.Kill()
+		merger.Wait()
+		sshRpipe.Close()
+		sshWpipe.Close()
+		return nil, nil, fmt.Errorf("failed to connect to instance: %v", err)
+	}
+	sshWpipe.Close()
+	merger.Add("ssh", sshRpipe)
+
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case <-inst.closed:
+			signal(fmt.Errorf("instance closed"))
+		case err := <-merger.Err:
+			con.Process.Kill()
+			ssh.Process.Kill()
+			merger.Wait()
+			con.Wait()
+			if cmdErr := ssh.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			} else if merr, ok := err.(vmimpl.MergerError); ok && merr.R == conRpipe {
+				// Console connection must never fail. If it does, it's either
+				// spot instance interruption or an EC2 problem. In either case, not a kernel bug.
+				log.Logf(1, "%v: aws console connection failed with %v", inst.name, merr.Err)
+				err = vmimpl.ErrTimeout
+			} else {
+				// Check if the instance was terminated due to spot interruption.
+				time.Sleep(5 * time.Second) // just to avoid any EC2 races
+				if !inst.AWS.IsInstanceRunning(inst.id) {
+					log.Logf(1, "%v: ssh exited but instance is not running", inst.name)
+					err = vmimpl.ErrTimeout
+				}
+			}
+			signal(err)
+			return
+		}
+		con.Process.Kill()
+		ssh.Process.Kill()
+		merger.Wait()
+		con.Wait()
+		ssh.Wait()
+	}()
+	return merger.Output, errc, nil
+}
+
+func waitForConsoleConnect(merger *vmimpl.OutputMerger) error {
+	// We've started the console reading ssh command, but it has not necessary connected yet.
+	// If we proceed to running the target command right away, we can miss part
+	// of console output. EC2 serial console does not print anything on connect
+	// and does not replay old output, so we just give ssh some time to connect
+	// and check that the key was accepted.
+	timeout := time.NewTimer(15 * time.Second)
+	defer timeout.Stop()
+	permissionDeniedMsg := []byte("Permission denied (publickey)")
+	var output []byte
+	for {
+		select {
+		case out := <-merger.Output:
+			output = append(output, out...)
+			if bytes.Contains(output, permissionDeniedMsg) {
+				return fmt.Errorf("broken console: %s", permissionDeniedMsg)
+			}
+		case err := <-merger.Err:
+			return fmt.Errorf("broken console: %v\n%s", err, output)
+		case <-timeout.C:
+			return nil
+		}
+	}
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	if inst.env.OS == "openbsd" {
+		return nil, vmimpl.DiagnoseOpenBSD(inst.consolew)
+	}
+	return nil, false
+}
+
+func runCmd(debug bool, bin string, args ...string) error {
+	if debug {
+		log.Logf(0, "running command: %v %#v", bin, args)
+	}
+	output, err := osutil.RunCmd(time.Minute, "", bin, args...)
+	if debug {
+		log.Logf(0, "result: %v\n%s", err, output)
+	}
+	return err
+}
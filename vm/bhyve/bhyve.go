@@ -28,27 +28,32 @@ type Config struct {
 	Count   int    `json:"count"`   // number of VMs to use
 	HostIP  string `json:"hostip"`  // VM host IP address
 	Mem     string `json:"mem"`     // amount of VM memory
+	CPU     int    `json:"cpu"`     // number of VM CPUs
 	Dataset string `json:"dataset"` // ZFS dataset containing VM image
 }
 
 type Pool struct {
-	env *vmimpl.Env
-	cfg *Config
+	env      *vmimpl.Env
+	cfg      *Config
+	snapshot string // snapshot of the dataset that VM clones are created from
+	prefix   string // dataset mountpoint
+	image    string // image path relative to the dataset mountpoint
 }
 
 type instance struct {
-	cfg      *Config
-	snapshot string
-	tapdev   string
-	image    string
-	debug    bool
-	os       string
-	sshkey   string
-	sshuser  string
-	sshhost  string
-	merger   *vmimpl.OutputMerger
-	vmName   string
-	bhyve    *exec.Cmd
+	env     *vmimpl.Env
+	cfg     *Config
+	clone   string
+	tapdev  string
+	image   string
+	debug   bool
+	os      string
+	sshkey  string
+	sshuser string
+	sshhost string
+	merger  *vmimpl.OutputMerger
+	vmName  string
+	bhyve   *exec.Cmd
 }
 
 var ipRegex = regexp.MustCompile(`bound to (([0-9]+\.){3}[0-9]+) `)
@@ -58,6 +63,7 @@ func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
 	cfg := &Config{
 		Count: 1,
 		Mem:   "512M",
+		CPU:   1,
 	}
 	if err := config.LoadData(env.Config, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse bhyve vm config: %v", err)
@@ -69,61 +75,75 @@ func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
 		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
 		cfg.Count = 1
 	}
+	if cfg.CPU < 1 || cfg.CPU > 16 {
+		return nil, fmt.Errorf("invalid config param cpu: %v, want [1-16]", cfg.CPU)
+	}
+	if cfg.Dataset == "" {
+		return nil, fmt.Errorf("config param dataset is empty")
+	}
+	mountpoint, err := osutil.RunCmd(time.Minute, "", "zfs", "get", "-H", "-o", "value", "mountpoint", cfg.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(string(mountpoint), "\n") + "/"
+	image := strings.TrimPrefix(env.Image, prefix)
+	if image == env.Image {
+		return nil, fmt.Errorf("image file %v not contained in dataset %v", image, prefix)
+	}
 	pool := &Pool{
-		cfg: cfg,
-		env: env,
+		cfg:      cfg,
+		env:      env,
+		snapshot: fmt.Sprintf("%v@bhyve-syzkaller-%v", cfg.Dataset, env.Name),
+		prefix:   prefix,
+		image:    image,
+	}
+	// Stop instances from a previous run in case they are still running.
+	for i := 0; i < cfg.Count; i++ {
+		osutil.RunCmd(time.Minute, "", "bhyvectl", "--destroy", fmt.Sprintf("--vm=%v", pool.vmName(i)))
+	}
+	// Create a snapshot of the data set containing the VM image. Each VM uses
+	// a clone of the snapshot, which gets recreated every time the VM is restarted.
+	// Clones are cheap, so reprovisioning a VM does not require copying the image.
+	// This is all to work around bhyve's current lack of an image snapshot facility.
+	// Destroy a lingering snapshot and its clones first, the image may have changed since the previous run.
+	osutil.RunCmd(time.Minute, "", "zfs", "destroy", "-R", pool.snapshot)
+	if _, err := osutil.RunCmd(time.Minute, "", "zfs", "snapshot", pool.snapshot); err != nil {
+		return nil, err
 	}
 	return pool, nil
 }
 
+func (pool *Pool) vmName(index int) string {
+	return fmt.Sprintf("syzkaller-%v-%v", pool.env.Name, index)
+}
+
 func (pool *Pool) Count() int {
 	return pool.cfg.Count
 }
 
 func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 	inst := &instance{
+		env:     pool.env,
 		cfg:     pool.cfg,
 		debug:   pool.env.Debug,
 		os:      pool.env.OS,
 		sshkey:  pool.env.SSHKey,
 		sshuser: pool.env.SSHUser,
-		vmName:  fmt.Sprintf("syzkaller-%v-%v", pool.env.Name, index),
-	}
-
-	dataset := inst.cfg.Dataset
-	mountpoint, err := osutil.RunCmd(time.Minute, "", "zfs", "get", "-H", "-o", "value", "mountpoint", dataset)
-	if err != nil {
-		return nil, err
+		vmName:  pool.vmName(index),
 	}
 
-	snapshot := fmt.Sprintf("%v@bhyve-%v", dataset, inst.vmName)
-	clone := fmt.Sprintf("%v/bhyve-%v", dataset, inst.vmName)
-
-	prefix := strings.TrimSuffix(string(mountpoint), "\n") + "/"
-	image := strings.TrimPrefix(pool.env.Image, prefix)
-	if image == pool.env.Image {
-		return nil, fmt.Errorf("image file %v not contained in dataset %v", image, prefix)
-	}
-	inst.image = prefix + fmt.Sprintf("bhyve-%v", inst.vmName) + "/" + image
+	clone := fmt.Sprintf("%v/bhyve-%v", inst.cfg.Dataset, inst.vmName)
+	inst.image = pool.prefix + fmt.Sprintf("bhyve-%v", inst.vmName) + "/" + pool.image
 
 	// Stop the instance from a previous run in case it's still running.
 	osutil.RunCmd(time.Minute, "", "bhyvectl", "--destroy", fmt.Sprintf("--vm=%v", inst.vmName))
-	// Destroy a lingering snapshot and clone.
-	osutil.RunCmd(time.Minute, "", "zfs", "destroy", "-R", snapshot)
-
-	// Create a snapshot of the data set containing the VM image.  bhyve will
-	// use a clone of the snapshot, which gets recreated every time the VM
-	// is restarted.  This is all to work around bhyve's current lack of an
-	// image snapshot facility.
-	if _, err := osutil.RunCmd(time.Minute, "", "zfs", "snapshot", snapshot); err != nil {
-		inst.Close()
-		return nil, err
-	}
-	inst.snapshot = snapshot
-	if _, err := osutil.RunCmd(time.Minute, "", "zfs", "clone", snapshot, clone); err != nil {
-		inst.Close()
+	// Destroy a lingering clone.
+	osutil.RunCmd(time.Minute, "", "zfs", "destroy", "-f", clone)
+
+	if _, err := osutil.RunCmd(time.Minute, "", "zfs", "clone", pool.snapshot, clone); err != nil {
 		return nil, err
 	}
+	inst.clone = clone
 
 	tapdev, err := osutil.RunCmd(time.Minute, "", "ifconfig", "tap", "create")
 	if err != nil {
@@ -156,14 +176,15 @@ func (inst *instance) Boot() error {
 	// Stop the instance from the previous run in case it's still running.
 	osutil.RunCmd(time.Minute, "", "bhyvectl", "--destroy", fmt.Sprintf("--vm=%v", inst.vmName))
 
-	_, err := osutil.RunCmd(time.Minute, "", "bhyveload", loaderArgs...)
+	// The loader writes to the VM console as well, keep it as the beginning of the boot output.
+	loaderOutput, err := osutil.RunCmd(time.Minute, "", "bhyveload", loaderArgs...)
 	if err != nil {
-		return err
+		return vmimpl.MakeBootError(err, nil)
 	}
 
 	bhyveArgs := []string{
 		"-H", "-A", "-P",
-		"-c", "1",
+		"-c", fmt.Sprint(inst.cfg.CPU),
 		"-m", inst.cfg.Mem,
 		"-s", "0:0,hostbridge",
 		"-s", "1:0,lpc",
@@ -198,7 +219,7 @@ func (inst *instance) Boot() error {
 	inst.merger.Add("console", outr)
 	outr = nil
 
-	var bootOutput []byte
+	bootOutput := loaderOutput
 	bootOutputStop := make(chan bool)
 	ipch := make(chan string, 1)
 	go func() {
@@ -228,13 +249,13 @@ func (inst *instance) Boot() error {
 		bootOutputStop <- true
 		<-bootOutputStop
 		return vmimpl.BootError{Title: "bhyve exited", Output: bootOutput}
-	case <-time.After(10 * time.Minute):
+	case <-time.After(inst.env.Timeout(10 * time.Minute)):
 		bootOutputStop <- true
 		<-bootOutputStop
 		return vmimpl.BootError{Title: "no IP found", Output: bootOutput}
 	}
 
-	if err := vmimpl.WaitForSSH(inst.debug, 10*time.Minute, inst.sshhost,
+	if err := vmimpl.WaitForSSH(inst.debug, inst.env.Timeout(10*time.Minute), inst.sshhost,
 		inst.sshkey, inst.sshuser, inst.os, 22, nil); err != nil {
 		bootOutputStop <- true
 		<-bootOutputStop
@@ -246,14 +267,12 @@ func (inst *instance) Boot() error {
 
 func (inst *instance) Close() {
 	if inst.bhyve != nil {
-		inst.bhyve.Process.Kill()
-		inst.bhyve.Wait()
-		osutil.RunCmd(time.Minute, "", "bhyvectl", fmt.Sprintf("--vm=%v", inst.vmName), "--destroy")
+		inst.powerOff()
 		inst.bhyve = nil
 	}
-	if inst.snapshot != "" {
-		osutil.RunCmd(time.Minute, "", "zfs", "destroy", "-R", inst.snapshot)
-		inst.snapshot = ""
+	if inst.clone != "" {
+		osutil.RunCmd(time.Minute, "", "zfs", "destroy", "-f", inst.clone)
+		inst.clone = ""
 	}
 	if inst.tapdev != "" {
 		osutil.RunCmd(time.Minute, "", "ifconfig", inst.tapdev, "destroy")
@@ -261,6 +280,31 @@ func (inst *instance) Close() {
 	}
 }
 
+// powerOff stops the VM. A hanged VM (e.g. stuck in device emulation or a vmm ioctl)
+// may not react to signals, so it's first forcibly powered off and then destroyed.
+func (inst *instance) powerOff() {
+	vm := fmt.Sprintf("--vm=%v", inst.vmName)
+	done := make(chan bool)
+	go func() {
+		inst.bhyve.Wait()
+		close(done)
+	}()
+	osutil.RunCmd(time.Minute, "", "bhyvectl", vm, "--force-poweroff")
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		inst.bhyve.Process.Kill()
+		// Destroying the VM makes pending vmm ioctls fail, so that the killed process can exit.
+		osutil.RunCmd(time.Minute, "", "bhyvectl", vm, "--destroy")
+		select {
+		case <-done:
+		case <-time.After(time.Minute):
+			log.Logf(0, "bhyve %v did not exit after destroy", inst.vmName)
+		}
+	}
+	osutil.RunCmd(time.Minute, "", "bhyvectl", vm, "--destroy")
+}
+
 func (inst *instance) Forward(port int) (string, error) {
 	return fmt.Sprintf("%v:%v", inst.cfg.HostIP, port), nil
 }
@@ -0,0 +1,411 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package board implements VMs that are physical boards in a board farm (e.g. managed by labgrid).
+// syzkaller does not manage the boards directly, instead it uses user-provided hooks:
+// power cycling with a command or a REST hook, flashing with a script, console via
+// conserver, telnet or any command that prints console output. Programs are executed over ssh.
+//
+// Commands can use the following placeholders: {{NAME}} (board name), {{ADDR}} (board ssh address),
+// {{IMAGE}} (image from the manager config).
+package board
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("board", ctor, false)
+}
+
+type Config struct {
+	Boards    []*Board `json:"boards"`     // boards to use
+	TargetDir string   `json:"target_dir"` // directory to copy/run on boards
+	// Command that power cycles the board (e.g. "labgrid-client -p {{NAME}} power cycle").
+	PowerCycle string `json:"power_cycle"`
+	// Alternatively, URL that power cycles the board on POST request.
+	PowerURL string `json:"power_url"`
+	// Command that flashes the image onto the board (optional), runs once per board
+	// before the first power cycle (e.g. "flash.sh {{NAME}} {{IMAGE}}").
+	Flash string `json:"flash"`
+	// Command that is executed on the board over ssh after boot to check that it's healthy
+	// (optional, default is just a successful ssh connection).
+	HealthCheck string `json:"health_check"`
+	BootTimeout int    `json:"boot_timeout"` // timeout for board boot in seconds (600 by default)
+	Retries     int    `json:"retries"`      // number of power cycles before giving up on a board (3 by default)
+}
+
+type Board struct {
+	Name string `json:"name"` // board name in the farm
+	Addr string `json:"addr"` // ssh address: (hostname|ip)(:port)?
+	// Board console: "telnet://host:port", "conserver://name" (read-only console -s),
+	// or a shell command that prints console output.
+	Console string `json:"console"`
+}
+
+type Pool struct {
+	env     *vmimpl.Env
+	cfg     *Config
+	flashMu sync.Mutex
+	flashed map[int]bool
+}
+
+type instance struct {
+	pool        *Pool
+	cfg         *Config
+	board       *Board
+	debug       bool
+	addr        string
+	port        int
+	console     io.ReadCloser
+	merger      *vmimpl.OutputMerger
+	closed      chan bool
+	forwardPort int
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		BootTimeout: 600,
+		Retries:     3,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse board vm config: %v", err)
+	}
+	if len(cfg.Boards) == 0 {
+		return nil, fmt.Errorf("config param boards is empty")
+	}
+	if cfg.TargetDir == "" {
+		return nil, fmt.Errorf("config param target_dir is empty")
+	}
+	if cfg.PowerCycle == "" && cfg.PowerURL == "" {
+		return nil, fmt.Errorf("either power_cycle or power_url must be specified")
+	}
+	if cfg.PowerCycle != "" && cfg.PowerURL != "" {
+		return nil, fmt.Errorf("both power_cycle and power_url are specified")
+	}
+	if cfg.BootTimeout <= 0 {
+		return nil, fmt.Errorf("bad board boot_timeout: %v", cfg.BootTimeout)
+	}
+	if cfg.Retries <= 0 {
+		return nil, fmt.Errorf("bad board retries: %v", cfg.Retries)
+	}
+	for _, board := range cfg.Boards {
+		if board.Name == "" {
+			return nil, fmt.Errorf("board name is empty")
+		}
+		if _, _, err := splitAddrPort(board.Addr); err != nil {
+			return nil, fmt.Errorf("bad board %v addr %q: %v", board.Name, board.Addr, err)
+		}
+		if board.Console == "" {
+			return nil, fmt.Errorf("board %v console is empty", board.Name)
+		}
+	}
+	if env.Debug && len(cfg.Boards) > 1 {
+		log.Logf(0, "limiting number of boards from %v to 1 in debug mode", len(cfg.Boards))
+		cfg.Boards = cfg.Boards[:1]
+	}
+	pool := &Pool{
+		env:     env,
+		cfg:     cfg,
+		flashed: make(map[int]bool),
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return len(pool.cfg.Boards)
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	board := pool.cfg.Boards[index]
+	addr, port, _ := splitAddrPort(board.Addr)
+	inst := &instance{
+		pool:   pool,
+		cfg:    pool.cfg,
+		board:  board,
+		debug:  pool.env.Debug,
+		addr:   addr,
+		port:   port,
+		closed: make(chan bool),
+	}
+	if err := pool.flash(index); err != nil {
+		return nil, err
+	}
+	var err error
+	for try := 0; try < pool.cfg.Retries; try++ {
+		if err = inst.boot(); err == nil {
+			break
+		}
+		log.Logf(0, "board %v: boot failed (try %v): %v", board.Name, try, err)
+		inst.Close()
+		inst.closed = make(chan bool)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Create working dir and remove temp files from previous runs.
+	if err := inst.ssh(time.Minute, fmt.Sprintf("mkdir -p '%v' && rm -rf '%v'",
+		inst.cfg.TargetDir, filepath.Join(inst.cfg.TargetDir, "*"))); err != nil {
+		inst.Close()
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (pool *Pool) flash(index int) error {
+	if pool.cfg.Flash == "" {
+		return nil
+	}
+	pool.flashMu.Lock()
+	defer pool.flashMu.Unlock()
+	if pool.flashed[index] {
+		return nil
+	}
+	board := pool.cfg.Boards[index]
+	log.Logf(0, "board %v: flashing %v", board.Name, pool.env.Image)
+	_, err := pool.hook(30*time.Minute, pool.cfg.Flash, board)
+	if err != nil {
+		// Not cached, the next attempt may succeed.
+		return fmt.Errorf("failed to flash board %v: %v", board.Name, err)
+	}
+	pool.flashed[index] = true
+	return nil
+}
+
+func (inst *instance) boot() error {
+	var err error
+	if inst.console, err = openConsole(inst.board.Console); err != nil {
+		return err
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", inst.console)
+
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	if err = inst.powerCycle(); err == nil {
+		timeout := time.Duration(inst.cfg.BootTimeout) * time.Second
+		// Give the board some time to actually go down.
+		time.Sleep(5 * time.Second)
+		err = vmimpl.WaitForSSH(inst.debug, timeout, inst.addr, inst.pool.env.SSHKey,
+			inst.pool.env.SSHUser, inst.pool.env.OS, inst.port, nil)
+	}
+	if err == nil && inst.cfg.HealthCheck != "" {
+		if err = inst.ssh(time.Minute, inst.cfg.HealthCheck); err != nil {
+			err = fmt.Errorf("health check failed: %v", err)
+		}
+	}
+	bootOutputStop <- true
+	<-bootOutputStop
+	if err != nil {
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	return nil
+}
+
+func (inst *instance) powerCycle() error {
+	if inst.cfg.PowerURL != "" {
+		url := expand(inst.cfg.PowerURL, inst.board, inst.pool.env.Image)
+		client := &http.Client{Timeout: time.Minute}
+		resp, err := client.Post(url, "text/plain", nil)
+		if err != nil {
+			return fmt.Errorf("power cycle request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("power cycle request failed: %v", resp.Status)
+		}
+		return nil
+	}
+	if _, err := inst.pool.hook(5*time.Minute, inst.cfg.PowerCycle, inst.board); err != nil {
+		return fmt.Errorf("power cycle failed: %v", err)
+	}
+	return nil
+}
+
+func (inst *instance) ssh(timeout time.Duration, command string) error {
+	args := append(vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, inst.port),
+		inst.pool.env.SSHUser+"@"+inst.addr, command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	_, err := osutil.RunCmd(timeout, "", "ssh", args...)
+	return err
+}
+
+func (inst *instance) Close() {
+	close(inst.closed)
+	if inst.console != nil {
+		inst.console.Close()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+		inst.merger = nil
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	if inst.forwardPort != 0 {
+		return "", fmt.Errorf("board: Forward port already set")
+	}
+	if port == 0 {
+		return "", fmt.Errorf("board: Forward port is zero")
+	}
+	inst.forwardPort = port
+	return fmt.Sprintf("127.0.0.1:%v", port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join(inst.cfg.TargetDir, filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.pool.env.SSHKey, inst.port),
+		hostSrc, inst.pool.env.SSHUser+"@"+inst.addr+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	if _, err := osutil.RunCmd(3*time.Minute, "", "scp", args...); err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	args := vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, inst.port)
+	// Forward target port as part of the ssh connection (reverse proxy).
+	if inst.forwardPort != 0 {
+		args = append(args, "-R", fmt.Sprintf("%v:127.0.0.1:%v", inst.forwardPort, inst.forwardPort))
+	}
+	args = append(args, inst.pool.env.SSHUser+"@"+inst.addr, "cd "+inst.cfg.TargetDir+" && exec "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	inst.merger.Add("ssh", rpipe)
+	merger := inst.merger
+	// Multiplex waits for the merger.
+	inst.merger = nil
+	return vmimpl.Multiplex(cmd, merger, inst.console, timeout, stop, inst.closed, inst.debug)
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+// hook runs the user command for the board.
+func (pool *Pool) hook(timeout time.Duration, command string, board *Board) ([]byte, error) {
+	command = expand(command, board, pool.env.Image)
+	if pool.env.Debug {
+		log.Logf(0, "running command: %v", command)
+	}
+	return osutil.RunCmd(timeout, "", "sh", "-c", command)
+}
+
+func expand(command string, board *Board, image string) string {
+	return strings.NewReplacer(
+		"{{NAME}}", board.Name,
+		"{{ADDR}}", board.Addr,
+		"{{IMAGE}}", image,
+	).Replace(command)
+}
+
+func openConsole(console string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasPrefix(console, "telnet://"):
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(console, "telnet://"), time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to console: %v", err)
+		}
+		return vmimpl.NewTelnetConn(conn), nil
+	case strings.HasPrefix(console, "conserver://"):
+		args := []string{"-s", strings.TrimPrefix(console, "conserver://")}
+		return startConsole(osutil.Command("console", args...))
+	default:
+		return startConsole(osutil.Command("sh", "-c", console))
+	}
+}
+
+// cmdConsole is console output of a command, closing it kills the command.
+type cmdConsole struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func startConsole(cmd *exec.Cmd) (io.ReadCloser, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, fmt.Errorf("failed to start console: %v", err)
+	}
+	wpipe.Close()
+	return &cmdConsole{rpipe, cmd}, nil
+}
+
+func (con *cmdConsole) Close() error {
+	con.cmd.Process.Kill()
+	con.cmd.Wait()
+	return con.ReadCloser.Close()
+}
+
+func splitAddrPort(addr string) (string, int, error) {
+	host := addr
+	port := 22
+	if colonPos := strings.Index(addr, ":"); colonPos != -1 {
+		p, err := strconv.ParseUint(addr[colonPos+1:], 10, 16)
+		if err != nil {
+			return "", 0, err
+		}
+		host = addr[:colonPos]
+		port = int(p)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("host is empty")
+	}
+	return host, port, nil
+}
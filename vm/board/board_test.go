@@ -0,0 +1,15 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package board
+
+import "testing"
+
+func TestExpand(t *testing.T) {
+	board := &Board{Name: "rpi4-3", Addr: "10.0.0.3:2222"}
+	got := expand("flash.sh {{NAME}} {{ADDR}} {{IMAGE}} {{NAME}}", board, "/img/disk.img")
+	want := "flash.sh rpi4-3 10.0.0.3:2222 /img/disk.img rpi4-3"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
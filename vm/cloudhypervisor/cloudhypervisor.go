@@ -0,0 +1,372 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package cloudhypervisor implements VMs that run in cloud-hypervisor.
+// Kernel output is captured from virtio-console (hvc0). The fuzzer connects
+// to the manager over virtio-vsock: cloud-hypervisor forwards guest connections
+// to host port P to unix socket <vsock socket>_P, which we proxy to the manager port.
+// ssh/scp use a tap device with a point-to-point /30 network, the guest address
+// is configured with the ip= kernel parameter (requires CONFIG_IP_PNP).
+package cloudhypervisor
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("cloudhypervisor", ctor, true)
+}
+
+type Config struct {
+	Count           int    `json:"count"`            // number of VMs to run in parallel
+	CloudHypervisor string `json:"cloud_hypervisor"` // cloud-hypervisor binary name ("cloud-hypervisor" by default)
+	// Additional command line arguments for cloud-hypervisor binary.
+	Args string `json:"args"`
+	// Location of the kernel (vmlinux for x86_64, Image for arm64).
+	Kernel string `json:"kernel"`
+	// Additional command line options for the booting kernel.
+	Cmdline string `json:"cmdline"`
+	CPU     int    `json:"cpu"` // number of VM CPUs
+	Mem     int    `json:"mem"` // amount of VM memory in MiB
+}
+
+type Pool struct {
+	env   *vmimpl.Env
+	cfg   *Config
+	cache *vmimpl.ImageCache
+}
+
+type instance struct {
+	env       *vmimpl.Env
+	cfg       *Config
+	debug     bool
+	os        string
+	workdir   string
+	image     string
+	sshkey    string
+	sshuser   string
+	tapdev    string
+	hostIP    string
+	guestIP   string
+	vsock     string
+	rpipe     io.ReadCloser
+	wpipe     io.WriteCloser
+	ch        *exec.Cmd
+	merger    *vmimpl.OutputMerger
+	listeners []net.Listener
+}
+
+var linuxCmdline = []string{
+	"console=hvc0",
+	"root=/dev/vda",
+	"rw",
+	"oops=panic",
+	"nmi_watchdog=panic",
+	"panic_on_warn=1",
+	"panic=1",
+	"ftrace_dump_on_oops=orig_cpu",
+	"rodata=n",
+	"vsyscall=native",
+	"net.ifnames=0",
+	"biosdevname=0",
+}
+
+const (
+	// Guest vsock context id, host is always 2.
+	guestCID = 3
+	hostCID  = 2
+)
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Count:           1,
+		CloudHypervisor: "cloud-hypervisor",
+		CPU:             1,
+		Mem:             1024,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse cloudhypervisor vm config: %v", err)
+	}
+	if env.OS != "linux" || env.Arch != "amd64" && env.Arch != "arm64" {
+		return nil, fmt.Errorf("cloudhypervisor supports only linux/amd64 and linux/arm64")
+	}
+	if cfg.Count < 1 || cfg.Count > 128 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 128]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.CPU <= 0 || cfg.CPU > 254 {
+		return nil, fmt.Errorf("bad cloudhypervisor cpu: %v, want [1-254]", cfg.CPU)
+	}
+	if cfg.Mem < 128 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad cloudhypervisor mem: %v, want [128-1048576]", cfg.Mem)
+	}
+	if cfg.Kernel == "" {
+		return nil, fmt.Errorf("cloudhypervisor requires kernel")
+	}
+	cfg.Kernel = osutil.Abs(cfg.Kernel)
+	if !osutil.IsExist(cfg.Kernel) {
+		return nil, fmt.Errorf("kernel file '%v' does not exist", cfg.Kernel)
+	}
+	if !osutil.IsExist(env.Image) {
+		return nil, fmt.Errorf("image file '%v' does not exist", env.Image)
+	}
+	if _, err := exec.LookPath(cfg.CloudHypervisor); err != nil {
+		return nil, err
+	}
+	pool := &Pool{
+		cfg:   cfg,
+		env:   env,
+		cache: vmimpl.NewImageCache(env.Workdir),
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	addr := index * 4
+	inst := &instance{
+		env:     pool.env,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		os:      pool.env.OS,
+		workdir: workdir,
+		image:   filepath.Join(workdir, "image"),
+		sshkey:  pool.env.SSHKey,
+		sshuser: pool.env.SSHUser,
+		tapdev:  fmt.Sprintf("chtap%v", index),
+		hostIP:  fmt.Sprintf("172.17.%v.%v", addr/256, addr%256+1),
+		guestIP: fmt.Sprintf("172.17.%v.%v", addr/256, addr%256+2),
+		vsock:   filepath.Join(workdir, "vsock"),
+	}
+	closeInst := inst
+	defer func() {
+		if closeInst != nil {
+			closeInst.Close()
+		}
+	}()
+	// The image is writable, so each VM needs own copy.
+	if err := pool.cache.Clone(pool.env.Image, inst.image); err != nil {
+		return nil, err
+	}
+	var err error
+	inst.rpipe, inst.wpipe, err = osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := inst.boot(); err != nil {
+		return nil, err
+	}
+	closeInst = nil
+	return inst, nil
+}
+
+func (inst *instance) Close() {
+	for _, ln := range inst.listeners {
+		ln.Close()
+	}
+	if inst.ch != nil {
+		inst.ch.Process.Kill()
+		inst.ch.Wait()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+	if inst.rpipe != nil {
+		inst.rpipe.Close()
+	}
+	if inst.wpipe != nil {
+		inst.wpipe.Close()
+	}
+	os.Remove(inst.image)
+}
+
+func (inst *instance) boot() error {
+	cmdline := append([]string{}, linuxCmdline...)
+	cmdline = append(cmdline,
+		fmt.Sprintf("ip=%v::%v:255.255.255.252::eth0:off", inst.guestIP, inst.hostIP),
+		inst.cfg.Cmdline)
+	args := []string{
+		"--kernel", inst.cfg.Kernel,
+		"--cmdline", strings.Join(cmdline, " "),
+		"--disk", "path=" + inst.image,
+		"--cpus", fmt.Sprintf("boot=%v", inst.cfg.CPU),
+		"--memory", fmt.Sprintf("size=%vM", inst.cfg.Mem),
+		// cloud-hypervisor creates the tap device and assigns the host address to it.
+		"--net", fmt.Sprintf("tap=%v,ip=%v,mask=255.255.255.252", inst.tapdev, inst.hostIP),
+		"--vsock", fmt.Sprintf("cid=%v,socket=%v", guestCID, inst.vsock),
+		"--console", "tty",
+		"--serial", "off",
+	}
+	if inst.cfg.Args != "" {
+		args = append(args, strings.Split(inst.cfg.Args, " ")...)
+	}
+	if inst.debug {
+		log.Logf(0, "running command: %v %#v", inst.cfg.CloudHypervisor, args)
+	}
+	ch := osutil.Command(inst.cfg.CloudHypervisor, args...)
+	ch.Stdout = inst.wpipe
+	ch.Stderr = inst.wpipe
+	if err := ch.Start(); err != nil {
+		return fmt.Errorf("failed to start %v %+v: %v", inst.cfg.CloudHypervisor, args, err)
+	}
+	inst.wpipe.Close()
+	inst.wpipe = nil
+	inst.ch = ch
+
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("cloud-hypervisor", inst.rpipe)
+	inst.rpipe = nil
+
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	if err := vmimpl.WaitForSSH(inst.debug, inst.env.Timeout(10*time.Minute), inst.guestIP,
+		inst.sshkey, inst.sshuser, inst.os, 22, inst.merger.Err); err != nil {
+		bootOutputStop <- true
+		<-bootOutputStop
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	bootOutputStop <- true
+	return nil
+}
+
+// Forward proxies guest vsock connections to the host port to localhost:port.
+func (inst *instance) Forward(port int) (string, error) {
+	ln, err := net.Listen("unix", fmt.Sprintf("%v_%v", inst.vsock, port))
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on vsock socket: %v", err)
+	}
+	inst.listeners = append(inst.listeners, ln)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go proxy(conn, fmt.Sprintf("localhost:%v", port))
+		}
+	}()
+	return fmt.Sprintf("vsock:%v:%v", hostCID, port), nil
+}
+
+func proxy(conn net.Conn, addr string) {
+	defer conn.Close()
+	host, err := net.Dial("tcp", addr)
+	if err != nil {
+		log.Logf(0, "failed to connect to %v: %v", addr, err)
+		return
+	}
+	defer host.Close()
+	done := make(chan bool, 2)
+	go func() {
+		io.Copy(host, conn)
+		done <- true
+	}()
+	go func() {
+		io.Copy(conn, host)
+		done <- true
+	}()
+	<-done
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join("/", filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.sshkey, 22),
+		hostSrc, inst.sshuser+"@"+inst.guestIP+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	args := append(vmimpl.SSHArgs(inst.debug, inst.sshkey, 22),
+		inst.sshuser+"@"+inst.guestIP, "cd / && "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
@@ -0,0 +1,417 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package cuttlefish implements VMs that are Android Cuttlefish virtual devices.
+// Devices are started with launch_cvd from the Cuttlefish host package (cvd_dir)
+// either on the local machine, or on a remote host accessed over ssh. The remote host can
+// also be created on GCE (gce_image), in this case syz-manager needs to run on GCE as well
+// and the image needs to be created with nested virtualization license.
+// Each VM is a separate Cuttlefish instance (--base_instance_num=index+1),
+// programs are run with adb and kernel output is read from the instance kernel.log.
+//
+// See https://source.android.com/setup/create/cuttlefish for details.
+package cuttlefish
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/gce"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("cuttlefish", ctor, true)
+}
+
+type Config struct {
+	Count int `json:"count"` // number of VMs to run in parallel
+	// Directory with Cuttlefish host package and device images on the host (required).
+	CvdDir string `json:"cvd_dir"`
+	// Host to run devices on as user@address (local machine by default).
+	Host    string `json:"host"`
+	HostKey string `json:"host_key"` // ssh key for the host
+	// Create the host on GCE from this image instead of using host.
+	GCEImage       string `json:"gce_image"`
+	GCEMachineType string `json:"gce_machine_type"` // GCE machine type for the host (e.g. "n1-standard-32")
+	Adb            string `json:"adb"`              // adb binary name on the host ("adb" by default)
+	Kernel         string `json:"kernel"`           // kernel to boot instead of the one in the device images
+	Initramfs      string `json:"initramfs"`        // initramfs with kernel modules matching kernel
+	CPU            int    `json:"cpu"`              // number of VM CPUs
+	Mem            int    `json:"mem"`              // amount of VM memory in MiB
+	// Additional command line arguments for launch_cvd.
+	Args string `json:"args"`
+}
+
+type Pool struct {
+	env       *vmimpl.Env
+	cfg       *Config
+	GCE       *gce.Context
+	kernel    string // kernel path on the host
+	initramfs string // initramfs path on the host
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	debug   bool
+	num     int    // Cuttlefish instance number
+	device  string // adb device serial
+	hostDir string // dir for copied files on the host
+	closed  chan bool
+	tunnels []*exec.Cmd
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Count: 1,
+		Adb:   "adb",
+		CPU:   2,
+		Mem:   2048,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse cuttlefish vm config: %v", err)
+	}
+	if env.OS != "linux" || env.Arch != "amd64" && env.Arch != "arm64" {
+		return nil, fmt.Errorf("cuttlefish supports only linux/amd64 and linux/arm64")
+	}
+	if cfg.Count < 1 || cfg.Count > 64 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 64]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.CvdDir == "" {
+		return nil, fmt.Errorf("cvd_dir parameter is empty")
+	}
+	if cfg.CPU <= 0 || cfg.CPU > 64 {
+		return nil, fmt.Errorf("bad cuttlefish cpu: %v, want [1-64]", cfg.CPU)
+	}
+	if cfg.Mem < 1024 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad cuttlefish mem: %v, want [1024-1048576]", cfg.Mem)
+	}
+	if cfg.Initramfs != "" && cfg.Kernel == "" {
+		return nil, fmt.Errorf("initramfs can only be specified with kernel")
+	}
+	if cfg.GCEImage != "" && cfg.Host != "" {
+		return nil, fmt.Errorf("both host and gce_image are specified")
+	}
+	if cfg.GCEImage != "" && cfg.GCEMachineType == "" {
+		return nil, fmt.Errorf("gce_machine_type parameter is empty")
+	}
+	for _, file := range []*string{&cfg.Kernel, &cfg.Initramfs} {
+		if *file == "" {
+			continue
+		}
+		*file = osutil.Abs(*file)
+		if !osutil.IsExist(*file) {
+			return nil, fmt.Errorf("file '%v' does not exist", *file)
+		}
+	}
+	pool := &Pool{
+		env:       env,
+		cfg:       cfg,
+		kernel:    cfg.Kernel,
+		initramfs: cfg.Initramfs,
+	}
+	if cfg.GCEImage != "" {
+		if err := pool.createGCEHost(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Host != "" {
+		for _, file := range []*string{&pool.kernel, &pool.initramfs} {
+			if *file == "" {
+				continue
+			}
+			hostFile := filepath.Join(cfg.CvdDir, "syzkaller-"+filepath.Base(*file))
+			if err := pool.copyToHost(*file, hostFile); err != nil {
+				return nil, err
+			}
+			*file = hostFile
+		}
+	}
+	return pool, nil
+}
+
+// createGCEHost creates the host GCE instance with a fresh ssh key and waits until it's reachable.
+func (pool *Pool) createGCEHost() error {
+	var err error
+	if pool.GCE, err = gce.NewContext(); err != nil {
+		return fmt.Errorf("failed to init gce: %v", err)
+	}
+	name := pool.env.Name + "-cuttlefish-host"
+	key := filepath.Join(pool.env.Workdir, "cuttlefish-host-key")
+	os.Remove(key)
+	os.Remove(key + ".pub")
+	keygen := osutil.Command("ssh-keygen", "-t", "rsa", "-b", "2048", "-N", "", "-C", "syzkaller", "-f", key)
+	if out, err := keygen.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to execute ssh-keygen: %v\n%s", err, out)
+	}
+	keyPub, err := ioutil.ReadFile(key + ".pub")
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+	log.Logf(0, "deleting cuttlefish host instance: %v", name)
+	if err := pool.GCE.DeleteInstance(name, true); err != nil {
+		return err
+	}
+	log.Logf(0, "creating cuttlefish host instance: %v", name)
+	ip, err := pool.GCE.CreateInstance(name, pool.cfg.GCEMachineType, pool.cfg.GCEImage, string(keyPub), false)
+	if err != nil {
+		return err
+	}
+	if err := vmimpl.WaitForSSH(pool.env.Debug, 10*time.Minute, ip, key, "syzkaller", "linux", 22, nil); err != nil {
+		pool.GCE.DeleteInstance(name, false)
+		return fmt.Errorf("cuttlefish host did not start: %v", err)
+	}
+	pool.cfg.Host = "syzkaller@" + ip
+	pool.cfg.HostKey = key
+	return nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	num := index + 1
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		num:     num,
+		device:  fmt.Sprintf("127.0.0.1:%v", 6520+num-1),
+		hostDir: filepath.Join(pool.cfg.CvdDir, fmt.Sprintf("syzkaller-%v", num)),
+		closed:  make(chan bool),
+	}
+	closeInst := inst
+	defer func() {
+		if closeInst != nil {
+			closeInst.Close()
+		}
+	}()
+	// The device may be left running from a previous run.
+	inst.stop()
+	if err := inst.boot(); err != nil {
+		return nil, err
+	}
+	closeInst = nil
+	return inst, nil
+}
+
+func (inst *instance) boot() error {
+	args := []string{
+		"bin/launch_cvd",
+		"--daemon",
+		"--resume=false",
+		fmt.Sprintf("--base_instance_num=%v", inst.num),
+		"--num_instances=1",
+		fmt.Sprintf("--cpus=%v", inst.cfg.CPU),
+		fmt.Sprintf("--memory_mb=%v", inst.cfg.Mem),
+	}
+	if inst.pool.kernel != "" {
+		args = append(args, "--kernel_path="+inst.pool.kernel)
+	}
+	if inst.pool.initramfs != "" {
+		args = append(args, "--initramfs_path="+inst.pool.initramfs)
+	}
+	if inst.cfg.Args != "" {
+		args = append(args, inst.cfg.Args)
+	}
+	// launch_cvd --daemon returns after the device has booted.
+	if out, err := inst.pool.hostRun(10*time.Minute, inst.cvdCmd(strings.Join(args, " "))); err != nil {
+		output, _ := inst.pool.hostRun(time.Minute, "tail -n 10000 "+inst.kernelLog())
+		return vmimpl.MakeBootError(err, append(out, output...))
+	}
+	if _, err := inst.pool.hostRun(time.Minute, fmt.Sprintf("%v connect %v", inst.cfg.Adb, inst.device)); err != nil {
+		return err
+	}
+	if _, err := inst.adb(time.Minute, "wait-for-device"); err != nil {
+		return err
+	}
+	if _, err := inst.adb(time.Minute, "root"); err != nil {
+		return err
+	}
+	if _, err := inst.adb(time.Minute, "wait-for-device"); err != nil {
+		return err
+	}
+	if _, err := inst.adb(time.Minute, "shell", shellQuote("echo 0 > /proc/sys/kernel/kptr_restrict")); err != nil {
+		return err
+	}
+	if _, err := inst.pool.hostRun(time.Minute, "mkdir -p "+inst.hostDir); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (inst *instance) stop() {
+	inst.pool.hostRun(time.Minute, inst.cvdCmd("bin/stop_cvd"))
+	inst.pool.hostRun(time.Minute, fmt.Sprintf("%v disconnect %v", inst.cfg.Adb, inst.device))
+}
+
+func (inst *instance) cvdCmd(command string) string {
+	return fmt.Sprintf("cd %v && HOME=%v CUTTLEFISH_INSTANCE=%v %v",
+		inst.cfg.CvdDir, inst.cfg.CvdDir, inst.num, command)
+}
+
+func (inst *instance) kernelLog() string {
+	return filepath.Join(inst.cfg.CvdDir, fmt.Sprintf("cuttlefish_runtime.%v", inst.num), "kernel.log")
+}
+
+func (inst *instance) Close() {
+	close(inst.closed)
+	for _, tunnel := range inst.tunnels {
+		tunnel.Process.Kill()
+		tunnel.Wait()
+	}
+	inst.stop()
+	inst.pool.hostRun(time.Minute, "rm -rf "+inst.hostDir)
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	hostPort := port
+	if inst.cfg.Host != "" {
+		// Forward the manager port to the host first.
+		hostPort = vmimpl.RandomPort()
+		args := append(vmimpl.SSHArgs(inst.debug, inst.cfg.HostKey, 22),
+			"-N", "-o", "ExitOnForwardFailure=yes",
+			"-R", fmt.Sprintf("%v:127.0.0.1:%v", hostPort, port), inst.cfg.Host)
+		tunnel := osutil.Command("ssh", args...)
+		if err := tunnel.Start(); err != nil {
+			return "", fmt.Errorf("failed to start ssh tunnel: %v", err)
+		}
+		inst.tunnels = append(inst.tunnels, tunnel)
+	}
+	var err error
+	for i := 0; i < 1000; i++ {
+		devicePort := vmimpl.RandomPort()
+		_, err = inst.adb(time.Minute, "reverse", fmt.Sprintf("tcp:%v", devicePort), fmt.Sprintf("tcp:%v", hostPort))
+		if err == nil {
+			return fmt.Sprintf("127.0.0.1:%v", devicePort), nil
+		}
+	}
+	return "", err
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join("/data", filepath.Base(hostSrc))
+	src := hostSrc
+	if inst.cfg.Host != "" {
+		src = filepath.Join(inst.hostDir, filepath.Base(hostSrc))
+		if err := inst.pool.copyToHost(hostSrc, src); err != nil {
+			return "", err
+		}
+	}
+	if _, err := inst.adb(3*time.Minute, "push", src, vmDst); err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	con := inst.pool.hostCommand("tail -n 0 -F " + inst.kernelLog())
+	conRpipe, conWpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	con.Stdout = conWpipe
+	con.Stderr = conWpipe
+	if err := con.Start(); err != nil {
+		conRpipe.Close()
+		conWpipe.Close()
+		return nil, nil, fmt.Errorf("failed to read kernel log: %v", err)
+	}
+	conWpipe.Close()
+
+	adbRpipe, adbWpipe, err := osutil.LongPipe()
+	if err != nil {
+		con.Process.Kill()
+		con.Wait()
+		conRpipe.Close()
+		return nil, nil, err
+	}
+	if inst.debug {
+		log.Logf(0, "starting: adb shell %v", command)
+	}
+	adb := inst.pool.hostCommand(fmt.Sprintf("%v -s %v shell %v", inst.cfg.Adb, inst.device, shellQuote("cd /data; "+command)))
+	adb.Stdout = adbWpipe
+	adb.Stderr = adbWpipe
+	if err := adb.Start(); err != nil {
+		con.Process.Kill()
+		con.Wait()
+		conRpipe.Close()
+		adbRpipe.Close()
+		adbWpipe.Close()
+		return nil, nil, fmt.Errorf("failed to start adb: %v", err)
+	}
+	adbWpipe.Close()
+
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	merger := vmimpl.NewOutputMerger(tee)
+	merger.Add("console", conRpipe)
+	merger.Add("adb", adbRpipe)
+	return vmimpl.Multiplex(adb, merger, &killer{con}, timeout, stop, inst.closed, inst.debug)
+}
+
+// killer closes the console by killing the kernel log reader.
+type killer struct {
+	cmd *exec.Cmd
+}
+
+func (k *killer) Close() error {
+	k.cmd.Process.Kill()
+	return k.cmd.Wait()
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+func (inst *instance) adb(timeout time.Duration, args ...string) ([]byte, error) {
+	return inst.pool.hostRun(timeout, fmt.Sprintf("%v -s %v %v", inst.cfg.Adb, inst.device, strings.Join(args, " ")))
+}
+
+// hostCommand returns command that executes the shell command on the host.
+func (pool *Pool) hostCommand(command string) *exec.Cmd {
+	if pool.env.Debug {
+		log.Logf(0, "running command on cuttlefish host: %v", command)
+	}
+	if pool.cfg.Host == "" {
+		return osutil.Command("bash", "-c", command)
+	}
+	args := append(vmimpl.SSHArgs(pool.env.Debug, pool.cfg.HostKey, 22), pool.cfg.Host, command)
+	return osutil.Command("ssh", args...)
+}
+
+func (pool *Pool) hostRun(timeout time.Duration, command string) ([]byte, error) {
+	cmd := pool.hostCommand(command)
+	return osutil.Run(timeout, cmd)
+}
+
+func (pool *Pool) copyToHost(src, dst string) error {
+	args := append(vmimpl.SCPArgs(pool.env.Debug, pool.cfg.HostKey, 22), src, pool.cfg.Host+":"+dst)
+	if pool.env.Debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	return err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
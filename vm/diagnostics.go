@@ -0,0 +1,113 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Crash-time diagnostics give context for OOMs, leaks and other memory-related crashes.
+// Fatal crashes (panics) leave the machine dead, so for them the kernel itself prints memory state
+// and held locks to the console after the oops (see diagnosticsSetupCommand); this ends up
+// in the crash log or in pstore. For non-fatal crashes (WARNINGs and KASAN reports without
+// panic_on_warn, memory leaks, lockdep reports) the machine is still alive
+// and CollectDiagnostics snapshots the memory state from user-space.
+
+const (
+	diagnosticsBegin = "SYZ-DIAGNOSTICS-BEGIN"
+	diagnosticsEnd   = "SYZ-DIAGNOSTICS-END"
+
+	// panic_print bits: 0x2 - memory info, 0x8 - held locks.
+	diagnosticsSetupCommand = "echo 10 > /proc/sys/kernel/panic_print"
+
+	diagnosticsCommand = "echo " + diagnosticsBegin + "; " +
+		"for f in /proc/meminfo /proc/slabinfo /proc/buddyinfo /proc/lockdep_stats /proc/pagetypeinfo; do " +
+		"echo \"=== $f\"; cat $f 2>&1; done; " +
+		"echo '=== tasks'; ps -e -o pid,ppid,stat,rss,vsz,wchan:32,comm 2>/dev/null || ps; " +
+		"echo " + diagnosticsEnd
+
+	diagnosticsTimeout = time.Minute
+	// Limits output if the machine is in a bad state (e.g. flooding console).
+	maxDiagnosticsSize = 4 << 20
+)
+
+// SetupDiagnostics configures the kernel to print memory state on panic.
+// Failures are not fatal: older kernels don't have panic_print.
+func (inst *Instance) SetupDiagnostics() error {
+	_, err := inst.runCommand(diagnosticsSetupCommand)
+	return err
+}
+
+// CollectDiagnostics snapshots memory state (/proc/meminfo, /proc/slabinfo, lockdep stats, tasks)
+// of the machine after a crash. It works only if the machine is still alive.
+func (inst *Instance) CollectDiagnostics() ([]byte, error) {
+	output, err := inst.runCommand(diagnosticsCommand)
+	if diag := extractDiagnostics(output); diag != nil {
+		return diag, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("no diagnostics in the output")
+	}
+	return nil, fmt.Errorf("failed to collect diagnostics: %v", err)
+}
+
+// runCommand runs the command in the VM and waits for it to finish.
+// Note: the output may also contain console output.
+func (inst *Instance) runCommand(command string) ([]byte, error) {
+	stop := make(chan bool)
+	defer close(stop)
+	timeout := diagnosticsTimeout * time.Duration(inst.slowdown)
+	outc, errc, err := inst.impl.Run(timeout, stop, command)
+	if err != nil {
+		return nil, err
+	}
+	var output []byte
+	deadline := time.After(timeout + time.Minute)
+	for {
+		select {
+		case out, ok := <-outc:
+			if !ok {
+				outc = nil
+				continue
+			}
+			if len(output) < maxDiagnosticsSize {
+				output = append(output, out...)
+			}
+		case err := <-errc:
+			// Pick up output that was produced before the command finished.
+			for drained := false; !drained; {
+				select {
+				case out, ok := <-outc:
+					output = append(output, out...)
+					drained = !ok
+				default:
+					drained = true
+				}
+			}
+			return output, err
+		case <-deadline:
+			// Protection against VM implementations that don't enforce the timeout.
+			return output, fmt.Errorf("command did not finish in %v", timeout)
+		}
+	}
+}
+
+// extractDiagnostics returns the output between diagnostics markers (without them), or nil.
+func extractDiagnostics(output []byte) []byte {
+	begin := bytes.LastIndex(output, []byte(diagnosticsBegin))
+	if begin == -1 {
+		return nil
+	}
+	output = output[begin+len(diagnosticsBegin):]
+	if nl := bytes.IndexByte(output, '\n'); nl != -1 {
+		output = output[nl+1:]
+	}
+	end := bytes.Index(output, []byte(diagnosticsEnd))
+	if end == -1 {
+		return nil
+	}
+	return output[:end]
+}
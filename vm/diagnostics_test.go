@@ -0,0 +1,45 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"testing"
+)
+
+func TestExtractDiagnostics(t *testing.T) {
+	tests := []struct {
+		output string
+		diag   string
+		ok     bool
+	}{
+		{
+			output: "",
+			ok:     false,
+		},
+		{
+			output: "SYZ-DIAGNOSTICS-BEGIN\n=== /proc/meminfo\nMemTotal: 1 kB\nSYZ-DIAGNOSTICS-END\n",
+			diag:   "=== /proc/meminfo\nMemTotal: 1 kB\n",
+			ok:     true,
+		},
+		{
+			// Echoed command, console output and \r\n.
+			output: "# echo SYZ-DIAGNOSTICS-BEGIN; cat; echo SYZ-DIAGNOSTICS-END\r\n" +
+				"[  100.1] random: crng init done\r\n" +
+				"SYZ-DIAGNOSTICS-BEGIN\r\n=== tasks\r\n1 0 S init\r\nSYZ-DIAGNOSTICS-END\r\n",
+			diag: "=== tasks\r\n1 0 S init\r\n",
+			ok:   true,
+		},
+		{
+			// The command was killed in the middle.
+			output: "SYZ-DIAGNOSTICS-BEGIN\n=== /proc/meminfo\n",
+			ok:     false,
+		},
+	}
+	for i, test := range tests {
+		diag := extractDiagnostics([]byte(test.output))
+		if test.ok != (diag != nil) || string(diag) != test.diag {
+			t.Errorf("test #%v: got %q, want %q", i, diag, test.diag)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package external implements a VM type that delegates VM provisioning to an external adapter binary.
+// This allows to integrate custom/proprietary provisioning systems without changes to syzkaller.
+//
+// The adapter is invoked as "adapter [adapter_args...] command" with a JSON-encoded Request on stdin.
+// Non-zero exit status means failure, stderr is used as the error message.
+// Commands:
+//
+//	create:   provision and boot a VM, print CreateResponse on stdout.
+//	          The VM must accept ssh connections when the command returns.
+//	console:  stream kernel console output of the VM (Request.ID) on stdout until killed.
+//	          Invoked only if CreateResponse.Console is set, otherwise "dmesg -w" over ssh is used.
+//	diagnose: print additional debugging info for the VM (e.g. after sending NMI) on stdout (optional).
+//	destroy:  destroy the VM.
+//
+// All remaining operations (copying files, running commands) are done by syzkaller over ssh.
+// The protocol is versioned with Request.Version, adapters should reject versions they don't know.
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("external", ctor, false)
+}
+
+// ProtocolVersion is the current version of the adapter protocol.
+const ProtocolVersion = 1
+
+// Request is passed to all adapter commands on stdin.
+type Request struct {
+	Version int             `json:"version"`
+	Name    string          `json:"name"`    // name of the VM pool, unique among all managers
+	OS      string          `json:"os"`      // target OS
+	Arch    string          `json:"arch"`    // target arch
+	Image   string          `json:"image"`   // image from the manager config (may be empty)
+	Index   int             `json:"index"`   // index of the VM in the pool
+	Workdir string          `json:"workdir"` // per-VM working dir, the adapter can store temp files there
+	Config  json.RawMessage `json:"config"`  // adapter-specific config from the manager config
+	ID      string          `json:"id"`      // VM ID returned by create (for all other commands)
+}
+
+// CreateResponse is printed by the adapter on stdout for the create command.
+type CreateResponse struct {
+	ID       string `json:"id"`        // opaque VM ID passed to subsequent commands
+	SSHAddr  string `json:"ssh_addr"`  // host name or IP address to ssh into the VM
+	SSHPort  int    `json:"ssh_port"`  // 22 by default
+	SSHUser  string `json:"ssh_user"`  // overrides ssh_user from the manager config
+	SSHKey   string `json:"ssh_key"`   // overrides sshkey from the manager config
+	HostAddr string `json:"host_addr"` // address of the host as seen from the VM (ports are forwarded over ssh if empty)
+	Console  bool   `json:"console"`   // the adapter supports the console command
+}
+
+type Config struct {
+	Count       int             `json:"count"`        // number of VMs to use
+	Adapter     string          `json:"adapter"`      // path to the adapter binary
+	AdapterArgs []string        `json:"adapter_args"` // additional arguments for the adapter
+	Config      json.RawMessage `json:"config"`       // adapter-specific config passed as is
+	TargetDir   string          `json:"target_dir"`   // directory to copy/run on target (/ by default)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+}
+
+type instance struct {
+	cfg         *Config
+	req         Request
+	resp        CreateResponse
+	debug       bool
+	closed      chan bool
+	closeOnce   sync.Once
+	forwardPort int
+}
+
+const (
+	createTimeout  = time.Hour
+	commandTimeout = 10 * time.Minute
+)
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Count:     1,
+		TargetDir: "/",
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse external vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 1000 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 1000]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.Adapter == "" {
+		return nil, fmt.Errorf("config param adapter is empty")
+	}
+	cfg.Adapter = osutil.Abs(cfg.Adapter)
+	if err := osutil.IsAccessible(cfg.Adapter); err != nil {
+		return nil, err
+	}
+	pool := &Pool{
+		env: env,
+		cfg: cfg,
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		cfg: pool.cfg,
+		req: Request{
+			Version: ProtocolVersion,
+			Name:    pool.env.Name,
+			OS:      pool.env.OS,
+			Arch:    pool.env.Arch,
+			Image:   pool.env.Image,
+			Index:   index,
+			Workdir: osutil.Abs(workdir),
+			Config:  pool.cfg.Config,
+		},
+		debug:  pool.env.Debug,
+		closed: make(chan bool),
+	}
+	out, err := inst.adapter(createTimeout, "create")
+	if err != nil {
+		return nil, vmimpl.MakeBootError(err, nil)
+	}
+	if err := json.Unmarshal(out, &inst.resp); err != nil {
+		return nil, fmt.Errorf("failed to parse adapter create response: %v\n%s", err, out)
+	}
+	inst.req.ID = inst.resp.ID
+	if inst.resp.SSHAddr == "" {
+		inst.Close()
+		return nil, fmt.Errorf("adapter create response has empty ssh_addr")
+	}
+	if inst.resp.SSHPort == 0 {
+		inst.resp.SSHPort = 22
+	}
+	if inst.resp.SSHUser == "" {
+		inst.resp.SSHUser = pool.env.SSHUser
+	}
+	if inst.resp.SSHKey == "" {
+		inst.resp.SSHKey = pool.env.SSHKey
+	}
+	if err := vmimpl.WaitForSSH(inst.debug, pool.env.Timeout(10*time.Minute), inst.resp.SSHAddr, inst.resp.SSHKey,
+		inst.resp.SSHUser, pool.env.OS, inst.resp.SSHPort, nil); err != nil {
+		inst.Close()
+		return nil, vmimpl.MakeBootError(err, nil)
+	}
+	return inst, nil
+}
+
+// adapter runs the adapter command and returns its stdout.
+func (inst *instance) adapter(timeout time.Duration, command string) ([]byte, error) {
+	req, err := json.Marshal(inst.req)
+	if err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, inst.cfg.AdapterArgs...), command)
+	if inst.debug {
+		log.Logf(0, "running adapter %v %v", inst.cfg.Adapter, args)
+	}
+	cmd := osutil.Command(inst.cfg.Adapter, args...)
+	cmd.Stdin = bytes.NewReader(req)
+	stdout := new(bytes.Buffer)
+	cmd.Stdout = stdout
+	if _, err := osutil.Run(timeout, cmd); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	if inst.resp.HostAddr != "" {
+		return fmt.Sprintf("%v:%v", inst.resp.HostAddr, port), nil
+	}
+	if inst.forwardPort != 0 {
+		return "", fmt.Errorf("external: Forward port already set")
+	}
+	inst.forwardPort = port
+	return fmt.Sprintf("127.0.0.1:%v", port), nil
+}
+
+func (inst *instance) sshTarget() string {
+	return inst.resp.SSHUser + "@" + inst.resp.SSHAddr
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join(inst.cfg.TargetDir, filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.resp.SSHKey, inst.resp.SSHPort),
+		hostSrc, inst.sshTarget()+":"+vmDst)
+	if _, err := osutil.RunCmd(3*time.Minute, "", "scp", args...); err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	console, err := inst.openConsole()
+	if err != nil {
+		return nil, nil, err
+	}
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		console.Close()
+		return nil, nil, err
+	}
+	args := vmimpl.SSHArgs(inst.debug, inst.resp.SSHKey, inst.resp.SSHPort)
+	if inst.forwardPort != 0 {
+		args = append(args, "-R", fmt.Sprintf("%v:127.0.0.1:%v", inst.forwardPort, inst.forwardPort))
+	}
+	args = append(args, inst.sshTarget(), "cd "+inst.cfg.TargetDir+" && exec "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		console.Close()
+		rpipe.Close()
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	merger := vmimpl.NewOutputMerger(tee)
+	merger.Add("console", console)
+	merger.Add("ssh", rpipe)
+	return vmimpl.Multiplex(cmd, merger, console, timeout, stop, inst.closed, inst.debug)
+}
+
+func (inst *instance) openConsole() (io.ReadCloser, error) {
+	if !inst.resp.Console {
+		args := append(vmimpl.SSHArgs(inst.debug, inst.resp.SSHKey, inst.resp.SSHPort), inst.sshTarget())
+		return vmimpl.OpenRemoteConsole("ssh", args...)
+	}
+	req, err := json.Marshal(inst.req)
+	if err != nil {
+		return nil, err
+	}
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd := osutil.Command(inst.cfg.Adapter, append(append([]string{}, inst.cfg.AdapterArgs...), "console")...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, fmt.Errorf("failed to start adapter console: %v", err)
+	}
+	wpipe.Close()
+	con := &console{
+		rpipe: rpipe,
+		stop: func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		},
+	}
+	return con, nil
+}
+
+type console struct {
+	rpipe io.ReadCloser
+	stop  func()
+	once  sync.Once
+}
+
+func (con *console) Read(buf []byte) (int, error) {
+	return con.rpipe.Read(buf)
+}
+
+func (con *console) Close() error {
+	con.once.Do(func() {
+		con.stop()
+		con.rpipe.Close()
+	})
+	return nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	out, err := inst.adapter(commandTimeout, "diagnose")
+	if err != nil {
+		log.Logf(0, "adapter diagnose failed: %v", err)
+		return nil, false
+	}
+	return out, false
+}
+
+func (inst *instance) Close() {
+	inst.closeOnce.Do(func() {
+		close(inst.closed)
+		if _, err := inst.adapter(commandTimeout, "destroy"); err != nil {
+			log.Logf(0, "adapter destroy failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,633 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package firecracker implements VMs that run as Firecracker microVMs.
+// Each VM gets a tap device with a point-to-point /30 network, the guest
+// address is configured with the ip= kernel parameter (requires CONFIG_IP_PNP).
+// The fuzzer connects to the manager over the tap network, so the manager
+// must listen on all interfaces (e.g. "rpc": "0.0.0.0:0").
+package firecracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("firecracker", ctor, true)
+}
+
+type Config struct {
+	Count       int    `json:"count"`       // number of VMs to run in parallel
+	Firecracker string `json:"firecracker"` // firecracker binary name ("firecracker" by default)
+	// Uncompressed kernel image (vmlinux for x86_64, Image for arm64).
+	Kernel string `json:"kernel"`
+	// Additional command line options for the booting kernel.
+	Cmdline string `json:"cmdline"`
+	CPU     int    `json:"cpu"` // number of VM CPUs
+	Mem     int    `json:"mem"` // amount of VM memory in MiB
+	// If set, firecracker is started by the given jailer binary
+	// in a chroot under chroot_base as jailer_uid/jailer_gid.
+	Jailer     string `json:"jailer"`
+	ChrootBase string `json:"chroot_base"` // "/srv/jailer" by default
+	JailerUID  int    `json:"jailer_uid"`
+	JailerGID  int    `json:"jailer_gid"`
+	// Take a snapshot of each VM after the first boot and restore VMs from it
+	// on subsequent restarts instead of booting the kernel again (default: false).
+	// The image is copied for each VM, so it should be reasonably small.
+	Snapshot bool `json:"snapshot"`
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+	dir string // per-VM state (images, sockets, snapshots) lives in dir/index
+
+	cache *vmimpl.ImageCache
+
+	mu        sync.Mutex
+	snapshots map[int]bool
+}
+
+type instance struct {
+	pool     *Pool
+	cfg      *Config
+	index    int
+	debug    bool
+	os       string
+	sshkey   string
+	sshuser  string
+	id       string
+	tapdev   string
+	hostIP   string
+	guestIP  string
+	dir      string // directory with the files referenced by the VM config
+	jailPath string // root of the jailer chroot (paths in the VM config are relative to it)
+	client   *http.Client
+	rpipe    io.ReadCloser
+	wpipe    io.WriteCloser
+	proc     *exec.Cmd
+	merger   *vmimpl.OutputMerger
+}
+
+var idRe = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+var linuxCmdline = []string{
+	"console=ttyS0",
+	"reboot=k",
+	"panic=1",
+	"pci=off",
+	"root=/dev/vda",
+	"rw",
+	"oops=panic",
+	"nmi_watchdog=panic",
+	"panic_on_warn=1",
+	"ftrace_dump_on_oops=orig_cpu",
+	"rodata=n",
+	"vsyscall=native",
+	"net.ifnames=0",
+	"biosdevname=0",
+}
+
+const (
+	apiSocket = "firecracker.socket"
+	rootfs    = "rootfs"
+	vmState   = "vmstate"
+	memFile   = "mem"
+)
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Count:       1,
+		Firecracker: "firecracker",
+		CPU:         1,
+		Mem:         1024,
+		ChrootBase:  "/srv/jailer",
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse firecracker vm config: %v", err)
+	}
+	if env.OS != "linux" || env.Arch != "amd64" && env.Arch != "arm64" {
+		return nil, fmt.Errorf("firecracker supports only linux/amd64 and linux/arm64")
+	}
+	if cfg.Count < 1 || cfg.Count > 128 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 128]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.CPU <= 0 || cfg.CPU > 32 {
+		return nil, fmt.Errorf("bad firecracker cpu: %v, want [1-32]", cfg.CPU)
+	}
+	if cfg.Mem < 128 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad firecracker mem: %v, want [128-1048576]", cfg.Mem)
+	}
+	if cfg.Kernel == "" {
+		return nil, fmt.Errorf("firecracker requires kernel")
+	}
+	cfg.Kernel = osutil.Abs(cfg.Kernel)
+	if !osutil.IsExist(cfg.Kernel) {
+		return nil, fmt.Errorf("kernel file '%v' does not exist", cfg.Kernel)
+	}
+	if !osutil.IsExist(env.Image) {
+		return nil, fmt.Errorf("image file '%v' does not exist", env.Image)
+	}
+	if _, err := exec.LookPath(cfg.Firecracker); err != nil {
+		return nil, err
+	}
+	if cfg.Jailer != "" {
+		if _, err := exec.LookPath(cfg.Jailer); err != nil {
+			return nil, err
+		}
+		// Jailer requires absolute path to the firecracker binary.
+		var err error
+		if cfg.Firecracker, err = exec.LookPath(cfg.Firecracker); err != nil {
+			return nil, err
+		}
+		cfg.Firecracker = osutil.Abs(cfg.Firecracker)
+	}
+	dir := filepath.Join(env.Workdir, "firecracker")
+	// Snapshots from the previous run may belong to a different kernel/image.
+	os.RemoveAll(dir)
+	pool := &Pool{
+		cfg:       cfg,
+		env:       env,
+		dir:       dir,
+		cache:     vmimpl.NewImageCache(env.Workdir),
+		snapshots: make(map[int]bool),
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	// Each VM index uses the same tap device, addresses and file paths across restarts,
+	// this is required to restore VMs from snapshots.
+	addr := index * 4
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		index:   index,
+		debug:   pool.env.Debug,
+		os:      pool.env.OS,
+		sshkey:  pool.env.SSHKey,
+		sshuser: pool.env.SSHUser,
+		id:      idRe.ReplaceAllString(fmt.Sprintf("syzkaller-%v-%v", pool.env.Name, index), "-"),
+		tapdev:  fmt.Sprintf("fctap%v", index),
+		hostIP:  fmt.Sprintf("172.16.%v.%v", addr/256, addr%256+1),
+		guestIP: fmt.Sprintf("172.16.%v.%v", addr/256, addr%256+2),
+	}
+	closeInst := inst
+	defer func() {
+		if closeInst != nil {
+			closeInst.Close()
+		}
+	}()
+	if err := inst.setupNetwork(); err != nil {
+		return nil, err
+	}
+	if err := inst.setupFiles(); err != nil {
+		return nil, err
+	}
+	var err error
+	inst.rpipe, inst.wpipe, err = osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := inst.boot(); err != nil {
+		return nil, err
+	}
+	closeInst = nil
+	return inst, nil
+}
+
+func (inst *instance) setupNetwork() error {
+	// Remove the tap device left from the previous instance/run.
+	osutil.RunCmd(time.Minute, "", "ip", "link", "del", inst.tapdev)
+	args := []string{"tuntap", "add", "dev", inst.tapdev, "mode", "tap"}
+	if inst.cfg.Jailer != "" {
+		args = append(args, "user", strconv.Itoa(inst.cfg.JailerUID))
+	}
+	if _, err := osutil.RunCmd(time.Minute, "", "ip", args...); err != nil {
+		return err
+	}
+	if _, err := osutil.RunCmd(time.Minute, "", "ip", "addr", "add", inst.hostIP+"/30",
+		"dev", inst.tapdev); err != nil {
+		return err
+	}
+	_, err := osutil.RunCmd(time.Minute, "", "ip", "link", "set", inst.tapdev, "up")
+	return err
+}
+
+func (inst *instance) setupFiles() error {
+	inst.dir = filepath.Join(inst.pool.dir, strconv.Itoa(inst.index))
+	if inst.cfg.Jailer != "" {
+		// Jailer creates device nodes in the chroot and fails if they already exist.
+		jailDir := filepath.Join(inst.cfg.ChrootBase, filepath.Base(inst.cfg.Firecracker), inst.id)
+		if err := os.RemoveAll(jailDir); err != nil {
+			return fmt.Errorf("failed to remove jailer dir: %v", err)
+		}
+		inst.jailPath = filepath.Join(jailDir, "root")
+		inst.dir = inst.jailPath
+	}
+	if err := osutil.MkdirAll(inst.dir); err != nil {
+		return err
+	}
+	os.Remove(filepath.Join(inst.dir, apiSocket))
+	// The image is writable, so each VM needs own copy.
+	if inst.pool.hasSnapshot(inst.index) {
+		err := vmimpl.CopyImage(filepath.Join(inst.pool.snapshotDir(inst.index), rootfs),
+			filepath.Join(inst.dir, rootfs))
+		if err != nil {
+			return err
+		}
+	} else if err := inst.pool.cache.Clone(inst.pool.env.Image, filepath.Join(inst.dir, rootfs)); err != nil {
+		return err
+	}
+	files := []string{rootfs}
+	if inst.pool.hasSnapshot(inst.index) {
+		for _, file := range []string{vmState, memFile} {
+			if err := copyOrLink(filepath.Join(inst.pool.snapshotDir(inst.index), file),
+				filepath.Join(inst.dir, file)); err != nil {
+				return err
+			}
+			files = append(files, file)
+		}
+	}
+	if inst.cfg.Jailer == "" {
+		return nil
+	}
+	// Firecracker can access only files inside of the chroot.
+	if err := copyOrLink(inst.cfg.Kernel, filepath.Join(inst.dir, "kernel")); err != nil {
+		return err
+	}
+	files = append(files, "kernel")
+	for _, file := range files {
+		if err := os.Chown(filepath.Join(inst.dir, file), inst.cfg.JailerUID, inst.cfg.JailerGID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyOrLink(src, dst string) error {
+	os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return osutil.CopyFile(src, dst)
+}
+
+// vmPath returns path to the file in the VM dir as seen by firecracker.
+func (inst *instance) vmPath(file string) string {
+	if inst.jailPath != "" {
+		return "/" + file
+	}
+	return filepath.Join(inst.dir, file)
+}
+
+func (inst *instance) Close() {
+	if inst.proc != nil {
+		inst.proc.Process.Kill()
+		inst.proc.Wait()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+	if inst.rpipe != nil {
+		inst.rpipe.Close()
+	}
+	if inst.wpipe != nil {
+		inst.wpipe.Close()
+	}
+	osutil.RunCmd(time.Minute, "", "ip", "link", "del", inst.tapdev)
+	if inst.dir != "" {
+		for _, file := range []string{rootfs, vmState, memFile, apiSocket} {
+			os.Remove(filepath.Join(inst.dir, file))
+		}
+	}
+}
+
+func (inst *instance) boot() error {
+	var args []string
+	bin := inst.cfg.Firecracker
+	if inst.cfg.Jailer != "" {
+		bin = inst.cfg.Jailer
+		args = []string{
+			"--id", inst.id,
+			"--exec-file", inst.cfg.Firecracker,
+			"--uid", strconv.Itoa(inst.cfg.JailerUID),
+			"--gid", strconv.Itoa(inst.cfg.JailerGID),
+			"--chroot-base-dir", inst.cfg.ChrootBase,
+			"--",
+		}
+	}
+	args = append(args, "--api-sock", inst.vmPath(apiSocket))
+	if inst.debug {
+		log.Logf(0, "running command: %v %#v", bin, args)
+	}
+	proc := osutil.Command(bin, args...)
+	proc.Stdout = inst.wpipe
+	proc.Stderr = inst.wpipe
+	if err := proc.Start(); err != nil {
+		return fmt.Errorf("failed to start %v %+v: %v", bin, args, err)
+	}
+	inst.wpipe.Close()
+	inst.wpipe = nil
+	inst.proc = proc
+
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("firecracker", inst.rpipe)
+	inst.rpipe = nil
+
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	stopBootOutput := func() {
+		bootOutputStop <- true
+		<-bootOutputStop
+	}
+	if err := inst.start(); err != nil {
+		stopBootOutput()
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	if err := vmimpl.WaitForSSH(inst.debug, inst.pool.env.Timeout(10*time.Minute), inst.guestIP,
+		inst.sshkey, inst.sshuser, inst.os, 22, inst.merger.Err); err != nil {
+		stopBootOutput()
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	if inst.cfg.Snapshot && !inst.pool.hasSnapshot(inst.index) {
+		if err := inst.snapshot(); err != nil {
+			stopBootOutput()
+			return vmimpl.MakeBootError(err, bootOutput)
+		}
+	}
+	bootOutputStop <- true
+	return nil
+}
+
+// start configures and starts the VM, or restores it from the snapshot.
+func (inst *instance) start() error {
+	sock := filepath.Join(inst.dir, apiSocket)
+	inst.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", sock)
+			},
+		},
+		Timeout: time.Minute,
+	}
+	for start := time.Now(); !osutil.IsExist(sock); {
+		if time.Since(start) > 10*time.Second {
+			return fmt.Errorf("firecracker did not create api socket")
+		}
+		if !vmimpl.SleepInterruptible(100 * time.Millisecond) {
+			return fmt.Errorf("shutdown in progress")
+		}
+	}
+	if inst.pool.hasSnapshot(inst.index) {
+		return inst.restore()
+	}
+	cmdline := append([]string{}, linuxCmdline...)
+	cmdline = append(cmdline,
+		fmt.Sprintf("ip=%v::%v:255.255.255.252::eth0:off", inst.guestIP, inst.hostIP),
+		inst.cfg.Cmdline)
+	requests := []struct {
+		path string
+		body interface{}
+	}{
+		{"/machine-config", map[string]interface{}{
+			"vcpu_count":   inst.cfg.CPU,
+			"mem_size_mib": inst.cfg.Mem,
+		}},
+		{"/boot-source", map[string]interface{}{
+			"kernel_image_path": inst.kernelPath(),
+			"boot_args":         strings.Join(cmdline, " "),
+		}},
+		{"/drives/rootfs", map[string]interface{}{
+			"drive_id":       "rootfs",
+			"path_on_host":   inst.vmPath(rootfs),
+			"is_root_device": true,
+			"is_read_only":   false,
+		}},
+		{"/network-interfaces/eth0", map[string]interface{}{
+			"iface_id":      "eth0",
+			"host_dev_name": inst.tapdev,
+			"guest_mac":     fmt.Sprintf("06:00:ac:10:%02x:%02x", inst.index*4/256, inst.index*4%256+2),
+		}},
+		{"/actions", map[string]interface{}{
+			"action_type": "InstanceStart",
+		}},
+	}
+	for _, req := range requests {
+		if err := inst.api(http.MethodPut, req.path, req.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *instance) kernelPath() string {
+	if inst.jailPath != "" {
+		return inst.vmPath("kernel")
+	}
+	return inst.cfg.Kernel
+}
+
+// snapshot takes a snapshot of the freshly booted VM.
+func (inst *instance) snapshot() error {
+	dir := inst.pool.snapshotDir(inst.index)
+	if err := osutil.MkdirAll(dir); err != nil {
+		return err
+	}
+	if err := inst.api(http.MethodPatch, "/vm", map[string]interface{}{"state": "Paused"}); err != nil {
+		return err
+	}
+	if err := inst.api(http.MethodPut, "/snapshot/create", map[string]interface{}{
+		"snapshot_type": "Full",
+		"snapshot_path": inst.vmPath(vmState),
+		"mem_file_path": inst.vmPath(memFile),
+	}); err != nil {
+		return err
+	}
+	// The disk is saved while the VM is paused, so that it's consistent with the memory snapshot.
+	for _, file := range []string{vmState, memFile, rootfs} {
+		if err := osutil.CopyFile(filepath.Join(inst.dir, file), filepath.Join(dir, file)); err != nil {
+			return err
+		}
+	}
+	os.Remove(filepath.Join(inst.dir, vmState))
+	os.Remove(filepath.Join(inst.dir, memFile))
+	if err := inst.api(http.MethodPatch, "/vm", map[string]interface{}{"state": "Resumed"}); err != nil {
+		return err
+	}
+	inst.pool.mu.Lock()
+	inst.pool.snapshots[inst.index] = true
+	inst.pool.mu.Unlock()
+	return nil
+}
+
+func (inst *instance) restore() error {
+	err := inst.api(http.MethodPut, "/snapshot/load", map[string]interface{}{
+		"snapshot_path": inst.vmPath(vmState),
+		"mem_file_path": inst.vmPath(memFile),
+	})
+	if err == nil {
+		err = inst.api(http.MethodPatch, "/vm", map[string]interface{}{"state": "Resumed"})
+	}
+	if err != nil {
+		// Boot from scratch next time, the snapshot may be broken.
+		inst.pool.mu.Lock()
+		delete(inst.pool.snapshots, inst.index)
+		inst.pool.mu.Unlock()
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+	return nil
+}
+
+func (pool *Pool) hasSnapshot(index int) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.snapshots[index]
+}
+
+func (pool *Pool) snapshotDir(index int) string {
+	return filepath.Join(pool.dir, "snapshot", strconv.Itoa(index))
+}
+
+// api sends a request to the firecracker API server.
+func (inst *instance) api(method, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if inst.debug {
+		log.Logf(0, "firecracker api: %v %v %s", method, path, data)
+	}
+	req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := inst.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("firecracker api %v %v failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		reply, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("firecracker api %v %v failed: %v: %s", method, path, resp.Status, reply)
+	}
+	return nil
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.hostIP, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join("/", filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.sshkey, 22),
+		hostSrc, inst.sshuser+"@"+inst.guestIP+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	args := append(vmimpl.SSHArgs(inst.debug, inst.sshkey, 22),
+		inst.sshuser+"@"+inst.guestIP, "cd / && "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
@@ -166,7 +166,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		sshUser = "syzkaller"
 	}
 	log.Logf(0, "wait instance to boot: %v (%v)", name, ip)
-	if err := vmimpl.WaitForSSH(pool.env.Debug, 5*time.Minute, ip,
+	if err := vmimpl.WaitForSSH(pool.env.Debug, pool.env.Timeout(5*time.Minute), ip,
 		sshKey, sshUser, pool.env.OS, 22, nil); err != nil {
 		output, outputErr := pool.getSerialPortOutput(name, gceKey)
 		if outputErr != nil {
@@ -21,6 +21,7 @@ import (
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/vm/vmimpl"
+	"golang.org/x/sys/unix"
 )
 
 func init() {
@@ -30,6 +31,12 @@ func init() {
 type Config struct {
 	Count     int    `json:"count"` // number of VMs to use
 	RunscArgs string `json:"runsc_args"`
+	// Host device files passed into sandboxes, the sandbox with index i gets devices[i]
+	// (e.g. [["/dev/nvidia0", "/dev/nvidiactl", "/dev/nvidia-uvm"]]). The devices stay bound
+	// to host drivers, runsc needs to proxy them (e.g. -nvproxy in runsc_args).
+	Devices [][]string `json:"devices"`
+	// Allowlist of device files that can be passed into sandboxes (glob patterns, e.g. ["/dev/nvidia*"]).
+	DevicesAllow []string `json:"devices_allow"`
 }
 
 type Pool struct {
@@ -66,6 +73,9 @@ func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
 	if !osutil.IsExist(env.Image) {
 		return nil, fmt.Errorf("image file %q does not exist", env.Image)
 	}
+	if err := checkDevices(cfg); err != nil {
+		return nil, err
+	}
 	pool := &Pool{
 		cfg: cfg,
 		env: env,
@@ -92,7 +102,17 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		}
 		caps += "\"" + c + "\""
 	}
-	vmConfig := fmt.Sprintf(configTempl, imageDir, caps)
+	devices := ""
+	if len(pool.cfg.Devices) != 0 {
+		if index >= len(pool.cfg.Devices) {
+			return nil, fmt.Errorf("no devices for VM %v", index)
+		}
+		var err error
+		if devices, err = devicesConfig(pool.cfg.Devices[index]); err != nil {
+			return nil, err
+		}
+	}
+	vmConfig := fmt.Sprintf(configTempl, imageDir, caps, devices)
 	if err := osutil.WriteFile(filepath.Join(bundleDir, "config.json"), []byte(vmConfig)); err != nil {
 		return nil, err
 	}
@@ -360,10 +380,67 @@ const configTempl = `
                 	"permitted": [%[2]v],
                 	"ambient": [%[2]v]
                 }
+	},
+	"linux": {
+		"devices": [%[3]v]
 	}
 }
 `
 
+func checkDevices(cfg *Config) error {
+	if len(cfg.Devices) == 0 {
+		return nil
+	}
+	if len(cfg.Devices) < cfg.Count {
+		return fmt.Errorf("devices are specified for %v VMs, but count is %v", len(cfg.Devices), cfg.Count)
+	}
+	used := make(map[string]bool)
+	for _, devs := range cfg.Devices {
+		for _, dev := range devs {
+			allowed := false
+			for _, pattern := range cfg.DevicesAllow {
+				if ok, _ := filepath.Match(pattern, dev); ok {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return fmt.Errorf("device %v is not in the allowlist", dev)
+			}
+			// Proxied devices like nvidiactl are shared by all sandboxes, but actual devices are not.
+			if used[dev] && !strings.HasSuffix(dev, "ctl") && !strings.HasSuffix(dev, "-uvm") {
+				return fmt.Errorf("device %v is passed into several VMs", dev)
+			}
+			used[dev] = true
+		}
+	}
+	return nil
+}
+
+// devicesConfig returns linux.devices entries of the OCI runtime spec for the device files.
+func devicesConfig(devs []string) (string, error) {
+	var res []string
+	for _, dev := range devs {
+		var st syscall.Stat_t
+		if err := syscall.Stat(dev, &st); err != nil {
+			return "", fmt.Errorf("failed to stat device %v: %v", dev, err)
+		}
+		typ := ""
+		switch st.Mode & syscall.S_IFMT {
+		case syscall.S_IFCHR:
+			typ = "c"
+		case syscall.S_IFBLK:
+			typ = "b"
+		default:
+			return "", fmt.Errorf("%v is not a device", dev)
+		}
+		rdev := uint64(st.Rdev)
+		res = append(res, fmt.Sprintf(`{"path": %q, "type": %q, "major": %v, "minor": %v, "fileMode": 438}`,
+			dev, typ, unix.Major(rdev), unix.Minor(rdev)))
+	}
+	return strings.Join(res, ", "), nil
+}
+
 var sandboxCaps = []string{
 	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER", "CAP_FSETID",
 	"CAP_KILL", "CAP_SETGID", "CAP_SETUID", "CAP_SETPCAP", "CAP_LINUX_IMMUTABLE",
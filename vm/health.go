@@ -0,0 +1,124 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HealthConfig contains thresholds used to detect degraded VMs.
+// A degraded VM still works, but is not worth running: it executes programs too slowly
+// or its root filesystem is broken, so it will most likely produce only junk crashes
+// (lost connections, no output, corrupted reports). Such VMs are recycled proactively.
+type HealthConfig struct {
+	// Period of time in which executed programs and OOM kills are counted.
+	Window time.Duration
+	// Min number of programs that need to be executed within Window.
+	// Not checked in the first Window after start (fuzzer startup, corpus triage).
+	MinExecs int
+	// Max number of OOM kills within Window.
+	MaxOOMs int
+	// Max duration of a single ssh operation (binary copying).
+	MaxSSHLatency time.Duration
+}
+
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		Window:        10 * time.Minute,
+		MinExecs:      100,
+		MaxOOMs:       100,
+		MaxSSHLatency: 2 * time.Minute,
+	}
+}
+
+// Reasons for recycling of a degraded VM returned by Instance.RecycleReason.
+const (
+	RecycleLowThroughput = "low exec throughput"
+	RecycleOOMStorm      = "oom storm"
+	RecycleFSCorruption  = "filesystem corruption"
+	RecycleSlowSSH       = "slow ssh"
+)
+
+var (
+	// Errors of the VM disks (as opposed to loop devices used by the fuzzed programs).
+	healthFSErrorRe = regexp.MustCompile(strings.Replace(`EXT4-fs error \(device DISK|`+
+		`EXT4-fs \(DISK\): Remounting filesystem read-only|XFS \(DISK\): .*[Cc]orrupt|`+
+		`BTRFS (?:error|critical) \(device DISK|I/O error,? (?:on )?dev DISK`,
+		"DISK", `(?:sd|vd|hd|xvd|nvme|mmcblk)[a-z0-9]*`, -1))
+	healthOOMRe = regexp.MustCompile(`Out of memory: Kill`)
+)
+
+type healthMonitor struct {
+	cfg         *HealthConfig
+	windowStart time.Time
+	warm        bool // the first window has passed
+	execs       int
+	ooms        int
+	partial     []byte
+	reason      string
+}
+
+func newHealthMonitor(cfg *HealthConfig, now time.Time) *healthMonitor {
+	return &healthMonitor{
+		cfg:         cfg,
+		windowStart: now,
+	}
+}
+
+// feed processes the next chunk of console output.
+func (hm *healthMonitor) feed(output []byte) {
+	hm.partial = append(hm.partial, output...)
+	for {
+		pos := bytes.IndexByte(hm.partial, '\n')
+		if pos == -1 {
+			break
+		}
+		hm.processLine(hm.partial[:pos])
+		hm.partial = hm.partial[pos+1:]
+	}
+	hm.partial = append([]byte{}, hm.partial...)
+}
+
+func (hm *healthMonitor) processLine(line []byte) {
+	switch {
+	case bytes.Contains(line, executingProgram1) || bytes.Contains(line, executingProgram2):
+		hm.execs++
+	case healthOOMRe.Match(line):
+		hm.ooms++
+		if hm.ooms > hm.cfg.MaxOOMs {
+			hm.recycle(RecycleOOMStorm)
+		}
+	case healthFSErrorRe.Match(line):
+		hm.recycle(RecycleFSCorruption)
+	}
+}
+
+// check is called periodically and checks execution throughput.
+func (hm *healthMonitor) check(now time.Time) {
+	if now.Sub(hm.windowStart) < hm.cfg.Window {
+		return
+	}
+	if hm.warm && hm.execs < hm.cfg.MinExecs {
+		hm.recycle(RecycleLowThroughput)
+	}
+	hm.warm = true
+	hm.windowStart = now
+	hm.execs = 0
+	hm.ooms = 0
+}
+
+func (hm *healthMonitor) sshOperation(latency time.Duration) {
+	if latency > hm.cfg.MaxSSHLatency {
+		hm.recycle(RecycleSlowSSH)
+	}
+}
+
+func (hm *healthMonitor) recycle(reason string) {
+	if hm.reason == "" {
+		hm.reason = reason
+	}
+}
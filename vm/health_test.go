@@ -0,0 +1,99 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	cfg := &HealthConfig{
+		Window:        time.Minute,
+		MinExecs:      3,
+		MaxOOMs:       2,
+		MaxSSHLatency: time.Second,
+	}
+	start := time.Now()
+	type Step struct {
+		output  string
+		elapsed time.Duration
+		ssh     time.Duration
+	}
+	tests := []struct {
+		name   string
+		steps  []Step
+		reason string
+	}{
+		{
+			name: "healthy",
+			steps: []Step{
+				// Nothing is checked in the first window.
+				{output: "executing program 0\n", elapsed: time.Minute},
+				{output: "executing program 0\nexecuting program 1\nexecuting ", elapsed: time.Minute + 30*time.Second},
+				{output: "program 0\n[  100.1] Out of memory: Killed process 1 (syz-executor)\n", elapsed: 2 * time.Minute},
+				{output: "[  110.1] EXT4-fs error (device loop0): ext4_fill_super: bad inode\n"},
+				{output: "[  110.2] blk_update_request: I/O error, dev nbd0, sector 0\n", ssh: time.Second / 2},
+			},
+		},
+		{
+			name: "low-throughput",
+			steps: []Step{
+				{output: strings.Repeat("executing program 0\n", 10), elapsed: time.Minute},
+				{output: "executing program 0\nexecuting program 1\n", elapsed: 2 * time.Minute},
+			},
+			reason: RecycleLowThroughput,
+		},
+		{
+			name: "oom-storm",
+			steps: []Step{
+				{output: strings.Repeat("[  100.1] Out of memory: Killed process 1 (syz-executor)\n", 3)},
+			},
+			reason: RecycleOOMStorm,
+		},
+		{
+			name: "ext4-error",
+			steps: []Step{
+				{output: "[   50.1] EXT4-fs error (device sda1): ext4_lookup:1575: inode #2: comm syz-executor: deleted inode\n"},
+			},
+			reason: RecycleFSCorruption,
+		},
+		{
+			name: "io-error",
+			steps: []Step{
+				{output: "[   50.1] print_req_error: I/O error, dev vda, sector 123\n"},
+			},
+			reason: RecycleFSCorruption,
+		},
+		{
+			name: "read-only",
+			steps: []Step{
+				{output: "[   50.1] EXT4-fs (nvme0n1p1): Remounting filesystem read-only\n"},
+			},
+			reason: RecycleFSCorruption,
+		},
+		{
+			name: "slow-ssh",
+			steps: []Step{
+				{ssh: 2 * time.Second},
+			},
+			reason: RecycleSlowSSH,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			hm := newHealthMonitor(cfg, start)
+			for _, step := range test.steps {
+				hm.feed([]byte(step.output))
+				hm.check(start.Add(step.elapsed))
+				hm.sshOperation(step.ssh)
+			}
+			if hm.reason != test.reason {
+				t.Fatalf("got reason %q, want %q", hm.reason, test.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package hyperv implements VMs running on Hyper-V.
+// syz-manager needs to run on the Hyper-V host itself with permissions to manage VMs,
+// VMs are managed with the Hyper-V PowerShell module.
+// Each VM boots from a differencing disk on top of the manager image (a .vhdx file),
+// so the image is never modified. The image must be bootable with sshd set up.
+//
+// Kernel output is collected through the VM COM1 port that is connected to a named pipe
+// on the host. The guest needs to direct kernel debug output to COM1
+// (for Windows see docs/windows/README.md).
+package hyperv
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("hyperv", ctor, true)
+}
+
+type Config struct {
+	Count      int    `json:"count"`      // number of VMs to run in parallel
+	Switch     string `json:"switch"`     // virtual switch to connect VMs to ("Default Switch" by default)
+	HostAddr   string `json:"host_addr"`  // manager address reachable from VMs
+	Generation int    `json:"generation"` // VM generation, 1 or 2 (2 by default)
+	CPU        int    `json:"cpu"`        // number of VM CPUs (1 by default)
+	Mem        int    `json:"mem"`        // amount of VM memory in MiB (1024 by default)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	debug   bool
+	name    string
+	disk    string
+	workdir string
+	ip      string
+	console io.ReadCloser
+	merger  *vmimpl.OutputMerger
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	if runtime.GOOS != "windows" {
+		return nil, fmt.Errorf("hyperv VMs can be used only on Windows hosts")
+	}
+	if env.Name == "" {
+		return nil, fmt.Errorf("config param name is empty (required for hyperv)")
+	}
+	cfg := &Config{
+		Count:      1,
+		Switch:     "Default Switch",
+		Generation: 2,
+		CPU:        1,
+		Mem:        1024,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse hyperv vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 128 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 128]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.HostAddr == "" {
+		return nil, fmt.Errorf("config param host_addr is empty")
+	}
+	if cfg.Generation != 1 && cfg.Generation != 2 {
+		return nil, fmt.Errorf("bad hyperv generation: %v, want 1 or 2", cfg.Generation)
+	}
+	if cfg.CPU < 1 || cfg.CPU > 240 {
+		return nil, fmt.Errorf("bad hyperv cpu: %v, want [1-240]", cfg.CPU)
+	}
+	if cfg.Mem < 128 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad hyperv mem: %v, want [128-1048576]", cfg.Mem)
+	}
+	if env.Image == "" {
+		return nil, fmt.Errorf("config param image is empty (required for hyperv)")
+	}
+	if env.SSHKey == "" {
+		return nil, fmt.Errorf("hyperv requires ssh key")
+	}
+	pool := &Pool{
+		env: env,
+		cfg: cfg,
+	}
+	if _, err := pool.powershell("Get-VMHost | Out-Null"); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		name:    fmt.Sprintf("%v-%v", pool.env.Name, index),
+		disk:    filepath.Join(workdir, "disk.vhdx"),
+		workdir: workdir,
+	}
+	// VMs left from previous runs may be based on an old image.
+	inst.destroy()
+	if err := inst.boot(); err != nil {
+		inst.Close()
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (inst *instance) boot() error {
+	pipe := inst.pipeName()
+	script := []string{
+		fmt.Sprintf("New-VHD -Path %v -ParentPath %v -Differencing | Out-Null",
+			quote(inst.disk), quote(inst.pool.env.Image)),
+		fmt.Sprintf("New-VM -Name %v -Generation %v -MemoryStartupBytes %vMB -VHDPath %v -SwitchName %v | Out-Null",
+			quote(inst.name), inst.cfg.Generation, inst.cfg.Mem, quote(inst.disk), quote(inst.cfg.Switch)),
+		fmt.Sprintf("Set-VMProcessor -VMName %v -Count %v", quote(inst.name), inst.cfg.CPU),
+		fmt.Sprintf("Set-VM -Name %v -AutomaticCheckpointsEnabled $false -AutomaticStopAction TurnOff",
+			quote(inst.name)),
+		fmt.Sprintf("Set-VMComPort -VMName %v -Number 1 -Path %v", quote(inst.name), quote(pipe)),
+	}
+	if inst.cfg.Generation == 2 {
+		// Test kernels are not signed.
+		script = append(script, fmt.Sprintf("Set-VMFirmware -VMName %v -EnableSecureBoot Off",
+			quote(inst.name)))
+	}
+	script = append(script, fmt.Sprintf("Start-VM -Name %v", quote(inst.name)))
+	if _, err := inst.pool.powershell(script...); err != nil {
+		return err
+	}
+	if err := inst.connectConsole(pipe); err != nil {
+		return err
+	}
+	return inst.waitForBoot(10 * time.Minute)
+}
+
+func (inst *instance) pipeName() string {
+	return `\\.\pipe\syz-` + inst.name
+}
+
+// connectConsole connects to the COM port pipe, the pipe server is created by Hyper-V when the VM starts.
+func (inst *instance) connectConsole(pipe string) error {
+	var err error
+	for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(time.Second) {
+		var f *os.File
+		if f, err = os.OpenFile(pipe, os.O_RDWR, 0); err == nil {
+			inst.console = f
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to the serial port pipe %v: %v", pipe, err)
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", inst.console)
+	return nil
+}
+
+func (inst *instance) waitForBoot(timeout time.Duration) error {
+	timeout = inst.pool.env.Timeout(timeout)
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	err := inst.waitForIP(timeout)
+	if err == nil {
+		err = vmimpl.WaitForSSH(inst.debug, timeout, inst.ip, inst.pool.env.SSHKey,
+			inst.pool.env.SSHUser, inst.pool.env.OS, 22, inst.merger.Err)
+	}
+	bootOutputStop <- true
+	<-bootOutputStop
+	if err != nil {
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	return nil
+}
+
+// waitForIP waits for the integration services to report an IPv4 address of the VM.
+func (inst *instance) waitForIP(timeout time.Duration) error {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(5 * time.Second) {
+		out, err := inst.pool.powershell(fmt.Sprintf("(Get-VMNetworkAdapter -VMName %v).IPAddresses",
+			quote(inst.name)))
+		if err != nil {
+			return fmt.Errorf("failed to get VM IP: %v", err)
+		}
+		for _, ip := range strings.Fields(string(out)) {
+			if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil {
+				inst.ip = ip
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("VM did not get an IP address in %v", timeout)
+}
+
+func (inst *instance) destroy() {
+	inst.pool.powershell(
+		fmt.Sprintf("Stop-VM -Name %v -TurnOff -Force -ErrorAction SilentlyContinue", quote(inst.name)),
+		fmt.Sprintf("Remove-VM -Name %v -Force -ErrorAction SilentlyContinue", quote(inst.name)),
+	)
+	os.Remove(inst.disk)
+}
+
+func (inst *instance) Close() {
+	inst.destroy()
+	if inst.console != nil {
+		inst.console.Close()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.cfg.HostAddr, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	// Windows sshd starts commands in the user home dir and does not understand absolute unix paths.
+	vmDst := filepath.Base(hostSrc)
+	if inst.pool.env.OS != "windows" {
+		vmDst = "/" + vmDst
+	}
+	args := append(vmimpl.SCPArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		hostSrc, inst.pool.env.SSHUser+"@"+inst.ip+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	if inst.pool.env.OS != "windows" {
+		command = "cd / && " + command
+	}
+	args := append(vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		inst.pool.env.SSHUser+"@"+inst.ip, command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+// powershell runs the script lines as a single PowerShell command, stopping on the first error.
+func (pool *Pool) powershell(script ...string) ([]byte, error) {
+	text := "$ErrorActionPreference = 'Stop'; " + strings.Join(script, "; ")
+	if pool.env.Debug {
+		log.Logf(0, "running command: powershell %q", text)
+	}
+	cmd := osutil.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", text)
+	return osutil.Run(10*time.Minute, cmd)
+}
+
+// quote quotes s as a PowerShell string literal.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
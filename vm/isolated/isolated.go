@@ -44,6 +44,7 @@ type instance struct {
 	sshUser     string
 	sshKey      string
 	forwardPort int
+	pstore      []byte
 }
 
 func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
@@ -99,6 +100,14 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		return nil, err
 	}
 
+	// Machines without serial console lose crash reports when the network dies,
+	// but ramoops keeps the log of the crashed boot.
+	args := append(vmimpl.SSHArgs(inst.debug, inst.sshKey, inst.targetPort),
+		inst.sshUser+"@"+inst.targetAddr, vmimpl.PstoreCommand)
+	if out, err := osutil.RunCmd(time.Minute, "", "ssh", args...); err == nil && len(out) != 0 {
+		inst.pstore = out
+	}
+
 	// Create working dir if doesn't exist.
 	inst.ssh("mkdir -p '" + inst.cfg.TargetDir + "'")
 
@@ -303,6 +312,10 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 	return vmimpl.Multiplex(cmd, merger, dmesg, timeout, stop, inst.closed, inst.debug)
 }
 
+func (inst *instance) PstoreLog() []byte {
+	return inst.pstore
+}
+
 func (inst *instance) Diagnose() ([]byte, bool) {
 	return nil, false
 }
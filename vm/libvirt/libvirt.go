@@ -0,0 +1,567 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package libvirt implements VMs managed by libvirt.
+// Domains and storage volumes are managed with virsh, so any libvirt connection URI can be used,
+// e.g. an existing virtualization host with its own storage pools and networks.
+// The image is uploaded to the storage pool once, and each VM gets a qcow2 overlay volume on top of it.
+// Kernel output is read from the domain serial console pty, so the manager needs access
+// to the host devices of the domain (i.e. the hypervisor should be local), and ssh needs
+// the guest address on the libvirt network to be reachable.
+//
+// If snapshot is enabled, a snapshot is taken after the first successful boot of each VM
+// and the following VMs are started by reverting to it instead of booting from scratch.
+// This requires a libvirt driver with internal snapshots support (e.g. qemu).
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("libvirt", ctor, true)
+}
+
+type Config struct {
+	Count    int    `json:"count"`    // number of VMs to run in parallel
+	URI      string `json:"uri"`      // libvirt connection URI ("qemu:///system" by default)
+	Type     string `json:"type"`     // domain type ("kvm" by default)
+	Network  string `json:"network"`  // libvirt network to attach VMs to ("default" by default)
+	Pool     string `json:"pool"`     // storage pool for VM volumes ("default" by default)
+	Snapshot bool   `json:"snapshot"` // revert VMs to a snapshot taken after the first boot
+	Template string `json:"template"` // custom domain XML template (optional, see domainTemplate)
+	Kernel   string `json:"kernel"`   // kernel for direct boot (optional, otherwise the image must be bootable)
+	Cmdline  string `json:"cmdline"`  // additional kernel command line options, can only be specified with kernel
+	CPU      int    `json:"cpu"`      // number of VM CPUs
+	Mem      int    `json:"mem"`      // amount of VM memory in MiB
+}
+
+type Pool struct {
+	env      *vmimpl.Env
+	cfg      *Config
+	arch     *archConfig
+	template *template.Template
+	image    string // base image volume
+	hostIP   string
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	debug   bool
+	name    string
+	workdir string
+	ip      string
+	console *os.File
+	merger  *vmimpl.OutputMerger
+}
+
+type archConfig struct {
+	Arch    string
+	Machine string
+	CmdLine []string
+}
+
+var archConfigs = map[string]*archConfig{
+	"linux/amd64": {
+		Arch:    "x86_64",
+		Machine: "pc",
+		CmdLine: append(linuxCmdline, "console=ttyS0", "root=/dev/vda"),
+	},
+	"linux/arm64": {
+		Arch:    "aarch64",
+		Machine: "virt",
+		CmdLine: append(linuxCmdline, "console=ttyAMA0", "root=/dev/vda"),
+	},
+}
+
+var linuxCmdline = []string{
+	"earlyprintk=serial",
+	"oops=panic",
+	"nmi_watchdog=panic",
+	"panic_on_warn=1",
+	"panic=1",
+	"ftrace_dump_on_oops=orig_cpu",
+	"rodata=n",
+	"vsyscall=native",
+	"net.ifnames=0",
+	"biosdevname=0",
+}
+
+// domainTemplate is the default domain definition. Custom templates get the same data.
+// Reboot and crash destroy the domain, so that kernel panics terminate the console stream.
+const domainTemplate = `<domain type='{{.Type}}'>
+<name>{{.Name}}</name>
+<memory unit='MiB'>{{.Mem}}</memory>
+<vcpu>{{.CPU}}</vcpu>
+<os>
+<type arch='{{.Arch}}' machine='{{.Machine}}'>hvm</type>
+{{if .Kernel}}<kernel>{{.Kernel}}</kernel>
+<cmdline>{{.Cmdline}}</cmdline>
+{{end}}</os>
+<features><acpi/></features>
+<cpu mode='host-passthrough'/>
+<on_poweroff>destroy</on_poweroff>
+<on_reboot>destroy</on_reboot>
+<on_crash>destroy</on_crash>
+<devices>
+<disk type='volume' device='disk'>
+<driver name='qemu' type='qcow2'/>
+<source pool='{{.Pool}}' volume='{{.Volume}}'/>
+<target dev='vda' bus='virtio'/>
+</disk>
+<interface type='network'>
+<source network='{{.Network}}'/>
+<model type='virtio'/>
+</interface>
+<serial type='pty'><target port='0'/></serial>
+<console type='pty'><target type='serial' port='0'/></console>
+</devices>
+</domain>
+`
+
+type domainData struct {
+	Type    string
+	Name    string
+	Arch    string
+	Machine string
+	CPU     int
+	Mem     int
+	Kernel  string
+	Cmdline string
+	Pool    string
+	Volume  string
+	Network string
+}
+
+// snapshotName is the name of the snapshot taken after the first boot.
+const snapshotName = "syzkaller"
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	if env.Name == "" {
+		return nil, fmt.Errorf("config param name is empty (required for libvirt)")
+	}
+	cfg := &Config{
+		Count:   1,
+		URI:     "qemu:///system",
+		Type:    "kvm",
+		Network: "default",
+		Pool:    "default",
+		CPU:     1,
+		Mem:     1024,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse libvirt vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 128 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 128]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	arch := archConfigs[env.OS+"/"+env.Arch]
+	if arch == nil {
+		return nil, fmt.Errorf("libvirt does not support %v/%v", env.OS, env.Arch)
+	}
+	if cfg.CPU <= 0 || cfg.CPU > 1024 {
+		return nil, fmt.Errorf("bad libvirt cpu: %v, want [1-1024]", cfg.CPU)
+	}
+	if cfg.Mem < 128 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad libvirt mem: %v, want [128-1048576]", cfg.Mem)
+	}
+	if env.Image == "" || !osutil.IsExist(env.Image) {
+		return nil, fmt.Errorf("image file '%v' does not exist", env.Image)
+	}
+	if cfg.Kernel != "" {
+		cfg.Kernel = osutil.Abs(cfg.Kernel)
+		if !osutil.IsExist(cfg.Kernel) {
+			return nil, fmt.Errorf("kernel file '%v' does not exist", cfg.Kernel)
+		}
+	} else if cfg.Cmdline != "" {
+		return nil, fmt.Errorf("cmdline can only be specified with kernel")
+	}
+	text := domainTemplate
+	if cfg.Template != "" {
+		data, err := ioutil.ReadFile(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read libvirt template: %v", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("domain").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse libvirt template: %v", err)
+	}
+	pool := &Pool{
+		env:      env,
+		cfg:      cfg,
+		arch:     arch,
+		template: tmpl,
+		image:    env.Name + "-image",
+	}
+	if _, err := pool.virsh("version"); err != nil {
+		return nil, err
+	}
+	if pool.hostIP, err = pool.networkAddr(); err != nil {
+		return nil, err
+	}
+	// Domains and snapshots left from previous runs refer to the old image.
+	for i := 0; i < cfg.Count; i++ {
+		pool.deleteDomain(pool.domainName(i))
+	}
+	log.Logf(0, "uploading image %v to libvirt pool %v...", env.Image, cfg.Pool)
+	if err := pool.uploadImage(); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		name:    pool.domainName(index),
+		workdir: workdir,
+	}
+	if pool.cfg.Snapshot && pool.hasSnapshot(inst.name) {
+		err := inst.restore()
+		if err == nil {
+			return inst, nil
+		}
+		log.Logf(0, "%v: failed to revert to snapshot, booting from scratch: %v", inst.name, err)
+		inst.Close()
+	}
+	pool.deleteDomain(inst.name)
+	if err := inst.boot(); err != nil {
+		inst.Close()
+		pool.deleteDomain(inst.name)
+		return nil, err
+	}
+	if pool.cfg.Snapshot {
+		// Not fatal, the next VM will just boot from scratch again.
+		if _, err := pool.virsh("snapshot-create-as", inst.name, snapshotName); err != nil {
+			log.Logf(0, "%v: failed to create snapshot: %v", inst.name, err)
+		}
+	}
+	return inst, nil
+}
+
+func (pool *Pool) domainName(index int) string {
+	return fmt.Sprintf("%v-%v", pool.env.Name, index)
+}
+
+func (inst *instance) boot() error {
+	cmdline := ""
+	if inst.cfg.Kernel != "" {
+		cmdline = strings.Join(append(append([]string{}, inst.pool.arch.CmdLine...), inst.cfg.Cmdline), " ")
+	}
+	data := &domainData{
+		Type:    inst.cfg.Type,
+		Name:    xmlEscape(inst.name),
+		Arch:    inst.pool.arch.Arch,
+		Machine: inst.pool.arch.Machine,
+		CPU:     inst.cfg.CPU,
+		Mem:     inst.cfg.Mem,
+		Kernel:  xmlEscape(inst.cfg.Kernel),
+		Cmdline: xmlEscape(cmdline),
+		Pool:    xmlEscape(inst.cfg.Pool),
+		Volume:  xmlEscape(inst.name),
+		Network: xmlEscape(inst.cfg.Network),
+	}
+	domainXML := new(bytes.Buffer)
+	if err := inst.pool.template.Execute(domainXML, data); err != nil {
+		return fmt.Errorf("failed to execute libvirt template: %v", err)
+	}
+	domainFile := filepath.Join(inst.workdir, "domain.xml")
+	if err := osutil.WriteFile(domainFile, domainXML.Bytes()); err != nil {
+		return err
+	}
+	if _, err := inst.pool.virsh("vol-create-as", inst.cfg.Pool, inst.name, inst.pool.imageSize(),
+		"--format", "qcow2", "--backing-vol", inst.pool.image, "--backing-vol-format", "raw"); err != nil {
+		return err
+	}
+	if _, err := inst.pool.virsh("define", domainFile); err != nil {
+		return err
+	}
+	// Start paused so that we don't miss any console output.
+	if _, err := inst.pool.virsh("start", "--paused", inst.name); err != nil {
+		return err
+	}
+	if err := inst.openConsole(); err != nil {
+		return err
+	}
+	if _, err := inst.pool.virsh("resume", inst.name); err != nil {
+		return err
+	}
+	return inst.waitForBoot(10 * time.Minute)
+}
+
+func (inst *instance) restore() error {
+	if _, err := inst.pool.virsh("snapshot-revert", inst.name, snapshotName, "--paused", "--force"); err != nil {
+		return err
+	}
+	if err := inst.openConsole(); err != nil {
+		return err
+	}
+	if _, err := inst.pool.virsh("resume", inst.name); err != nil {
+		return err
+	}
+	return inst.waitForBoot(time.Minute)
+}
+
+func (inst *instance) openConsole() error {
+	out, err := inst.pool.virsh("ttyconsole", inst.name)
+	if err != nil {
+		return err
+	}
+	tty := strings.TrimSpace(string(out))
+	inst.console, err = os.OpenFile(tty, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open console %v: %v", tty, err)
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", inst.console)
+	return nil
+}
+
+func (inst *instance) waitForBoot(timeout time.Duration) error {
+	timeout = inst.pool.env.Timeout(timeout)
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	err := inst.waitForIP(timeout)
+	if err == nil {
+		err = vmimpl.WaitForSSH(inst.debug, timeout, inst.ip, inst.pool.env.SSHKey,
+			inst.pool.env.SSHUser, inst.pool.env.OS, 22, inst.merger.Err)
+	}
+	bootOutputStop <- true
+	<-bootOutputStop
+	if err != nil {
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	return nil
+}
+
+var domainAddrRe = regexp.MustCompile(`ipv4\s+([0-9.]+)/`)
+
+func (inst *instance) waitForIP(timeout time.Duration) error {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(5 * time.Second) {
+		out, err := inst.pool.virsh("domifaddr", inst.name, "--source", "lease")
+		if err != nil {
+			return err
+		}
+		if match := domainAddrRe.FindSubmatch(out); match != nil {
+			inst.ip = string(match[1])
+			return nil
+		}
+	}
+	return fmt.Errorf("no DHCP lease for %v", inst.name)
+}
+
+func (inst *instance) Close() {
+	if inst.cfg.Snapshot {
+		// Keep the domain and the overlay volume, the snapshot lives in it.
+		inst.pool.virsh("destroy", inst.name)
+	} else {
+		inst.pool.deleteDomain(inst.name)
+	}
+	if inst.console != nil {
+		inst.console.Close()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.pool.hostIP, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join("/", filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		hostSrc, inst.pool.env.SSHUser+"@"+inst.ip+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	args := append(vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		inst.pool.env.SSHUser+"@"+inst.ip, "cd / && "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+func (pool *Pool) uploadImage() error {
+	pool.virsh("vol-delete", "--pool", pool.cfg.Pool, pool.image)
+	if _, err := pool.virsh("vol-create-as", pool.cfg.Pool, pool.image, pool.imageSize(),
+		"--format", "raw"); err != nil {
+		return err
+	}
+	if _, err := pool.virsh("vol-upload", "--pool", pool.cfg.Pool, pool.image, pool.env.Image); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pool *Pool) imageSize() string {
+	stat, err := os.Stat(pool.env.Image)
+	if err != nil {
+		return "0"
+	}
+	return fmt.Sprintf("%vb", stat.Size())
+}
+
+func (pool *Pool) hasSnapshot(name string) bool {
+	out, err := pool.virsh("snapshot-list", "--name", name)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == snapshotName {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteDomain destroys and undefines the domain and deletes its volume, ignoring errors
+// (the domain may not exist).
+func (pool *Pool) deleteDomain(name string) {
+	pool.virsh("destroy", name)
+	pool.virsh("undefine", "--snapshots-metadata", name)
+	pool.virsh("vol-delete", "--pool", pool.cfg.Pool, name)
+}
+
+// networkAddr returns host address on the libvirt network.
+func (pool *Pool) networkAddr() (string, error) {
+	out, err := pool.virsh("net-dumpxml", pool.cfg.Network)
+	if err != nil {
+		return "", err
+	}
+	var network struct {
+		IP []struct {
+			Address string `xml:"address,attr"`
+			Family  string `xml:"family,attr"`
+		} `xml:"ip"`
+	}
+	if err := xml.Unmarshal(out, &network); err != nil {
+		return "", fmt.Errorf("failed to parse libvirt network %v: %v", pool.cfg.Network, err)
+	}
+	for _, ip := range network.IP {
+		if ip.Family == "" || ip.Family == "ipv4" {
+			return ip.Address, nil
+		}
+	}
+	return "", fmt.Errorf("libvirt network %v has no ipv4 address", pool.cfg.Network)
+}
+
+func (pool *Pool) virsh(args ...string) ([]byte, error) {
+	args = append([]string{"--connect", pool.cfg.URI, "--quiet"}, args...)
+	if pool.env.Debug {
+		log.Logf(0, "running command: virsh %#v", args)
+	}
+	// vol-upload of a large image can take a while.
+	out, err := osutil.RunCmd(30*time.Minute, "", "virsh", args...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func xmlEscape(s string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+// Virtio console (virtio_console config option).
+// The kernel writes its log to both the ISA serial and a virtio console (hvc0), output of both
+// is merged with de-duplication. The serial console is slow and may not flush the whole crash
+// report before the VM is stopped, or may stop working during a crash (e.g. with hanged interrupts),
+// in such cases the rest of the report comes from the other console.
+// If the kernel is not specified in the config, the image must have console=hvc0 in the command line.
+
+const virtConsoleSocket = "virtcon"
+
+func (inst *instance) virtConsoleArgs() []string {
+	if !inst.cfg.VirtioConsole {
+		return nil
+	}
+	return []string{
+		"-device", "virtio-serial",
+		"-chardev", fmt.Sprintf("socket,id=virtcon,path=%v,server,nowait",
+			filepath.Join(inst.workdir, virtConsoleSocket)),
+		"-device", "virtconsole,chardev=virtcon",
+	}
+}
+
+// openVirtConsole connects to the virtio console socket of a just started qemu.
+func (inst *instance) openVirtConsole() (io.ReadCloser, error) {
+	for i := 0; ; i++ {
+		conn, err := net.Dial("unix", filepath.Join(inst.workdir, virtConsoleSocket))
+		if err == nil {
+			return conn, nil
+		}
+		if i == 100 {
+			return nil, fmt.Errorf("failed to connect to virtio console: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
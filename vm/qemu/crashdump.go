@@ -0,0 +1,59 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// Crash dumps (crash_dump config option).
+// VMs run with -no-shutdown, so that qemu keeps the stopped VM around after a kernel panic
+// instead of exiting. On Diagnose (which is called once a crash is detected) the VM is paused,
+// registers of all CPUs are saved and guest memory is dumped with dump-guest-memory
+// in ELF format, which can be opened with crash or drgn together with vmlinux.
+// The manager stores the dump in the crash dir.
+
+const (
+	crashDumpDir  = "crashdump"
+	vmcoreFile    = "vmcore"
+	registersFile = "registers"
+)
+
+func (inst *instance) saveCrashDump() {
+	if inst.mon == nil || inst.dumped {
+		return
+	}
+	inst.dumped = true
+	dir := filepath.Join(osutil.Abs(inst.workdir), crashDumpDir)
+	if err := osutil.MkdirAll(dir); err != nil {
+		log.Logf(0, "failed to create crash dump dir: %v", err)
+		return
+	}
+	// The VM is already stopped after a panic, but it may also be hanged or still running.
+	if _, err := inst.mon.hmp("stop", time.Minute); err != nil {
+		log.Logf(0, "failed to stop VM for crash dump: %v", err)
+		return
+	}
+	defer inst.mon.hmp("cont", time.Minute)
+	if regs, err := inst.mon.hmp("info registers -a", time.Minute); err != nil {
+		log.Logf(0, "failed to dump VM registers: %v", err)
+	} else {
+		regs = strings.TrimSuffix(regs, "(qemu) ")
+		osutil.WriteFile(filepath.Join(dir, registersFile), []byte(regs))
+	}
+	if _, err := inst.mon.hmp("dump-guest-memory "+filepath.Join(dir, vmcoreFile), 30*time.Minute); err != nil {
+		log.Logf(0, "failed to dump VM memory: %v", err)
+		return
+	}
+	inst.dumpDir = dir
+}
+
+func (inst *instance) CrashDump() string {
+	return inst.dumpDir
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+// KVM fuzzing profile (profile: "kvm").
+// The L1 guest is used to fuzz KVM itself, so it needs nested virtualization:
+// the host (L0) KVM must be loaded with nested=1 and the guest CPU needs vmx/svm.
+// Events of the guest KVM are traced by ftrace, the trace buffer is dumped to console
+// on oops (ftrace_dump_on_oops) and is collected over ssh on Diagnose.
+// If the L1 guest is dead (e.g. L0 KVM killed it), Diagnose collects L0 host dmesg instead,
+// because the bug is most likely in the host KVM then.
+
+const profileKVM = "kvm"
+
+const (
+	kvmTraceDir      = "/sys/kernel/debug/tracing"
+	kvmTraceBufferKB = 256
+	kvmMaxDiagnosis  = 256 << 10
+)
+
+// setupKVMProfile checks that the host supports nested virtualization
+// and adjusts the config for fuzzing of KVM.
+func setupKVMProfile(env *vmimpl.Env, cfg *Config) error {
+	if env.OS != "linux" || env.Arch != "amd64" {
+		return fmt.Errorf("kvm profile is supported only for linux/amd64")
+	}
+	if env.Image == "9p" {
+		return fmt.Errorf("kvm profile is not supported for 9p image")
+	}
+	feature := ""
+	for _, vendor := range []struct {
+		module  string
+		feature string
+	}{
+		{"kvm_intel", "+vmx"},
+		{"kvm_amd", "+svm"},
+	} {
+		data, err := ioutil.ReadFile("/sys/module/" + vendor.module + "/parameters/nested")
+		if err != nil {
+			continue
+		}
+		if val := strings.TrimSpace(string(data)); val != "Y" && val != "1" {
+			return fmt.Errorf("kvm profile requires nested virtualization, load %v with nested=1",
+				vendor.module)
+		}
+		feature = vendor.feature
+		break
+	}
+	if feature == "" {
+		return fmt.Errorf("kvm profile requires kvm_intel or kvm_amd module on the host")
+	}
+	if !strings.Contains(" "+cfg.QemuArgs+" ", " -enable-kvm ") {
+		cfg.QemuArgs = strings.TrimSpace("-enable-kvm " + cfg.QemuArgs)
+	}
+	cfg.CPUFeatures = append([]string{feature}, cfg.CPUFeatures...)
+	return nil
+}
+
+// addCPUFeatures appends features to the -cpu qemu argument.
+func addCPUFeatures(args, features []string) []string {
+	if len(features) == 0 {
+		return args
+	}
+	for i, arg := range args {
+		if arg == "-cpu" && i+1 < len(args) {
+			args[i+1] += "," + strings.Join(features, ",")
+			return args
+		}
+	}
+	return append(args, "-cpu", "host,migratable=off,"+strings.Join(features, ","))
+}
+
+// setupKVMTracing enables tracing of KVM events in the freshly booted guest.
+func (inst *instance) setupKVMTracing() error {
+	cmd := fmt.Sprintf("mount -t debugfs none /sys/kernel/debug 2>/dev/null; "+
+		"echo %v > %v/buffer_size_kb && echo 1 > %v/events/kvm/enable && "+
+		"(echo 1 > %v/events/kvmmmu/enable 2>/dev/null; true) && "+
+		"echo 2 > /proc/sys/kernel/ftrace_dump_on_oops && echo 1 > %v/tracing_on",
+		kvmTraceBufferKB, kvmTraceDir, kvmTraceDir, kvmTraceDir, kvmTraceDir)
+	if _, err := inst.ssh(time.Minute, cmd); err != nil {
+		return fmt.Errorf("failed to enable kvm tracing: %v", err)
+	}
+	return nil
+}
+
+func (inst *instance) diagnoseKVM() []byte {
+	buf := new(bytes.Buffer)
+	trace, err := inst.ssh(30*time.Second, "cat "+kvmTraceDir+"/trace")
+	if err == nil {
+		fmt.Fprintf(buf, "KVM TRACE:\n%s\n", tail(trace, kvmMaxDiagnosis))
+		return buf.Bytes()
+	}
+	// The L1 guest does not respond, most likely it was killed by L0 KVM.
+	dmesg, err := osutil.RunCmd(time.Minute, "", "dmesg")
+	if err != nil {
+		fmt.Fprintf(buf, "failed to read host dmesg: %v\n", err)
+		return buf.Bytes()
+	}
+	fmt.Fprintf(buf, "HOST DMESG:\n%s\n", tail(dmesgSince(dmesg, inst.hostUptime), kvmMaxDiagnosis))
+	return buf.Bytes()
+}
+
+func (inst *instance) ssh(timeout time.Duration, command string) ([]byte, error) {
+	args := append(vmimpl.SSHArgs(inst.debug, inst.sshkey, inst.port),
+		inst.sshuser+"@localhost", command)
+	return osutil.RunCmd(timeout, "", "ssh", args...)
+}
+
+var dmesgTimeRe = regexp.MustCompile(`^\[ *([0-9]+\.[0-9]+)\]`)
+
+// dmesgSince returns dmesg lines printed after the uptime (in seconds).
+func dmesgSince(dmesg []byte, since float64) []byte {
+	res := new(bytes.Buffer)
+	include := false
+	for s := bufio.NewScanner(bytes.NewReader(dmesg)); s.Scan(); {
+		if match := dmesgTimeRe.FindSubmatch(s.Bytes()); match != nil {
+			ts, _ := strconv.ParseFloat(string(match[1]), 64)
+			include = ts >= since
+		}
+		if include {
+			res.Write(s.Bytes())
+			res.WriteByte('\n')
+		}
+	}
+	return res.Bytes()
+}
+
+// hostUptime returns the current host uptime in seconds (the dmesg timestamp).
+func hostUptime() float64 {
+	data, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	uptime, _ := strconv.ParseFloat(fields[0], 64)
+	return uptime
+}
+
+func tail(data []byte, size int) []byte {
+	if len(data) <= size {
+		return data
+	}
+	data = data[len(data)-size:]
+	if pos := bytes.IndexByte(data, '\n'); pos != -1 {
+		data = data[pos+1:]
+	}
+	return data
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// monitor is a connection to the qemu human monitor (HMP).
+type monitor struct {
+	conn net.Conn
+}
+
+// dialMonitor connects to the monitor unix socket of a just started qemu.
+func dialMonitor(path string) (*monitor, error) {
+	// Qemu creates the monitor socket shortly after start.
+	for i := 0; ; i++ {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			mon := &monitor{conn: conn}
+			// Read the monitor greeting.
+			if _, err := mon.hmp("", time.Minute); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return mon, nil
+		}
+		if i == 100 {
+			return nil, fmt.Errorf("failed to connect to qemu monitor: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// hmp executes a human monitor command and returns its output.
+func (mon *monitor) hmp(cmd string, timeout time.Duration) (string, error) {
+	mon.conn.SetDeadline(time.Now().Add(timeout))
+	if cmd != "" {
+		if _, err := mon.conn.Write([]byte(cmd + "\n")); err != nil {
+			return "", fmt.Errorf("failed to write to qemu monitor: %v", err)
+		}
+	}
+	prompt := []byte("(qemu) ")
+	var output []byte
+	buf := make([]byte, 4<<10)
+	for !bytes.HasSuffix(output, prompt) {
+		n, err := mon.conn.Read(buf)
+		output = append(output, buf[:n]...)
+		if err != nil {
+			return "", fmt.Errorf("failed to read from qemu monitor: %v\n%s", err, output)
+		}
+	}
+	res := string(output)
+	if strings.Contains(strings.ToLower(res), "error") {
+		return "", fmt.Errorf("qemu monitor command %q failed: %v", cmd, res)
+	}
+	return res, nil
+}
+
+func (mon *monitor) close() {
+	mon.conn.Close()
+}
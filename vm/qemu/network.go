@@ -0,0 +1,147 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+// Private networks (nics config option).
+// Each VM gets additional NICs besides the user-mode one used for ssh/RPC. VMs are split into
+// groups of net_group VMs (by index), NICs with the same net name of VMs in the same group
+// are connected to the same private L2 segment. The segments are implemented as qemu
+// socket multicast netdevs on the loopback interface, so they don't need root or host bridges.
+// After boot the NICs are brought up and configured inside of the guest (optional vlan/bond devices
+// and an address in 10.200.X.0/24, where X is the index of the configured device in the VM).
+
+type NIC struct {
+	Net   string `json:"net"`   // name of the private network
+	Model string `json:"model"` // qemu NIC device model (e1000 by default)
+	VLAN  int    `json:"vlan"`  // create 802.1q vlan device with this id on top of the NIC (optional)
+	Bond  string `json:"bond"`  // enslave the NIC into this bond device (optional)
+}
+
+const netMcastAddr = "230.0.0.1"
+
+var netDevRe = regexp.MustCompile(`^[a-zA-Z0-9_]{1,12}$`)
+
+func validateNICs(targetOS string, cfg *Config) error {
+	if len(cfg.NICs) == 0 {
+		return nil
+	}
+	if targetOS != "linux" {
+		return fmt.Errorf("nics are supported only for linux")
+	}
+	if cfg.NetGroup < 1 || cfg.NetGroup > cfg.Count {
+		return fmt.Errorf("bad qemu net_group: %v, want [1-%v]", cfg.NetGroup, cfg.Count)
+	}
+	for i := range cfg.NICs {
+		nic := &cfg.NICs[i]
+		if nic.Net == "" {
+			return fmt.Errorf("nic #%v: empty net", i)
+		}
+		if nic.Model == "" {
+			nic.Model = "e1000"
+		}
+		if nic.VLAN < 0 || nic.VLAN > 4094 {
+			return fmt.Errorf("nic #%v: bad vlan %v, want [0-4094]", i, nic.VLAN)
+		}
+		if nic.Bond != "" && !netDevRe.MatchString(nic.Bond) {
+			return fmt.Errorf("nic #%v: bad bond device name %q", i, nic.Bond)
+		}
+	}
+	return nil
+}
+
+// allocateNetPorts allocates multicast ports for private networks of all VM groups.
+func allocateNetPorts(cfg *Config) map[string]int {
+	ports := make(map[string]int)
+	for group := 0; group*cfg.NetGroup < cfg.Count; group++ {
+		for _, nic := range cfg.NICs {
+			key := netKey(group, nic.Net)
+			if ports[key] == 0 {
+				ports[key] = vmimpl.UnusedTCPPort()
+			}
+		}
+	}
+	return ports
+}
+
+func netKey(group int, net string) string {
+	return fmt.Sprintf("%v/%v", group, net)
+}
+
+func (inst *instance) nicArgs() []string {
+	var args []string
+	group := inst.index / inst.cfg.NetGroup
+	for i, nic := range inst.cfg.NICs {
+		id := fmt.Sprintf("priv%v", i)
+		port := inst.netPorts[netKey(group, nic.Net)]
+		args = append(args,
+			"-netdev", fmt.Sprintf("socket,id=%v,mcast=%v:%v,localaddr=127.0.0.1", id, netMcastAddr, port),
+			"-device", fmt.Sprintf("%v,netdev=%v,mac=%v", nic.Model, id, inst.nicMAC(i)),
+		)
+	}
+	return args
+}
+
+// nicMAC returns a MAC address unique among all VMs of the pool.
+func (inst *instance) nicMAC(nic int) string {
+	return fmt.Sprintf("52:54:%02x:%02x:%02x:%02x", 0x10+nic, (inst.index>>16)&0xff,
+		(inst.index>>8)&0xff, inst.index&0xff)
+}
+
+// setupNICs configures the private NICs inside of the guest.
+// Interfaces are named eth1, eth2, ... (net.ifnames=0 in the kernel command line).
+func (inst *instance) setupNICs() error {
+	if len(inst.cfg.NICs) == 0 {
+		return nil
+	}
+	member := inst.index%inst.cfg.NetGroup + 1
+	var script []string
+	var devices []string // devices that get an address
+	bonds := make(map[string]bool)
+	for i, nic := range inst.cfg.NICs {
+		dev := fmt.Sprintf("eth%v", i+1)
+		if nic.Bond != "" {
+			if !bonds[nic.Bond] {
+				bonds[nic.Bond] = true
+				script = append(script,
+					fmt.Sprintf("ip link add %v type bond mode active-backup", nic.Bond),
+					fmt.Sprintf("ip link set %v up", nic.Bond))
+				devices = append(devices, inst.vlanDevice(&script, nic.Bond, nic.VLAN))
+			}
+			script = append(script,
+				fmt.Sprintf("ip link set %v down", dev),
+				fmt.Sprintf("ip link set %v master %v", dev, nic.Bond),
+				fmt.Sprintf("ip link set %v up", dev))
+			continue
+		}
+		script = append(script, fmt.Sprintf("ip link set %v up", dev))
+		devices = append(devices, inst.vlanDevice(&script, dev, nic.VLAN))
+	}
+	for i, dev := range devices {
+		script = append(script, fmt.Sprintf("ip addr add 10.200.%v.%v/24 dev %v", i, member, dev))
+	}
+	if _, err := inst.ssh(time.Minute, strings.Join(script, " && ")); err != nil {
+		return fmt.Errorf("failed to configure private nics: %v", err)
+	}
+	return nil
+}
+
+func (inst *instance) vlanDevice(script *[]string, dev string, vlan int) string {
+	if vlan == 0 {
+		return dev
+	}
+	vdev := fmt.Sprintf("%v.%v", dev, vlan)
+	*script = append(*script,
+		fmt.Sprintf("ip link add link %v name %v type vlan id %v", dev, vdev, vlan),
+		fmt.Sprintf("ip link set %v up", vdev))
+	return vdev
+}
@@ -9,8 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/syzkaller/pkg/config"
@@ -42,15 +44,45 @@ type Config struct {
 	CPU         int    `json:"cpu"`          // number of VM CPUs
 	Mem         int    `json:"mem"`          // amount of VM memory in MiB
 	Snapshot    bool   `json:"snapshot"`     // For building kernels without -snapshot (for pkg/build)
+	// Boot VMs once, take a snapshot after boot and revert to it instead of rebooting
+	// when the instance is recreated (e.g. after a crash).
+	SnapshotRevert bool `json:"snapshot_revert"`
+	// Recreate (revert) instances after executing that many programs (requires snapshot_revert).
+	// Allows to fuzz from a clean state without reboot overhead, 0 means only revert after crashes.
+	RevertPrograms int `json:"revert_programs"`
+	// Configuration profile: "kvm" sets up nested virtualization for fuzzing of KVM
+	// and collects KVM traces and host dmesg on crashes (see kvm.go).
+	Profile string `json:"profile"`
+	// Additional CPU features appended to the -cpu argument (e.g. ["+invtsc", "-x2apic"]).
+	CPUFeatures []string `json:"cpu_features"`
+	// On crash pause the VM and save guest memory and registers into the crash dir
+	// for offline analysis with crash/drgn (see crashdump.go).
+	// Note: each dump takes as much disk space as the VM memory.
+	CrashDump bool `json:"crash_dump"`
+	// Capture kernel output from virtio console in addition to the serial console (see console.go).
+	VirtioConsole bool `json:"virtio_console"`
+	// Additional NICs connected to private networks shared by groups of net_group VMs
+	// (e.g. two guests on a private bridge), see network.go.
+	NICs     []NIC `json:"nics"`
+	NetGroup int   `json:"net_group"` // number of VMs sharing private networks (1 by default)
+	// Host PCI (VFIO) and USB devices passed through into VMs, the VM with index i gets passthrough[i].
+	// Devices are rebound to vfio-pci and reset before each boot.
+	Passthrough []vmimpl.Passthrough `json:"passthrough"`
+	// Allowlist of vendor:device IDs of devices that can be passed through (e.g. ["10de:*", "144d:a808"]).
+	PassthroughAllow []string `json:"passthrough_allow"`
 }
 
 type Pool struct {
 	env        *vmimpl.Env
 	cfg        *Config
 	archConfig *archConfig
+	revertMu   sync.Mutex
+	revert     map[int]*revertVM // VMs kept running for snapshot_revert mode by index
+	netPorts   map[string]int    // multicast ports of private networks
 }
 
 type instance struct {
+	env        *vmimpl.Env
 	cfg        *Config
 	archConfig *archConfig
 	image      string
@@ -66,6 +98,16 @@ type instance struct {
 	merger     *vmimpl.OutputMerger
 	files      map[string]string
 	diagnose   chan bool
+	closed     chan bool
+	rvm        *revertVM
+	outc       <-chan []byte
+	revertc    chan bool // closed after revert_programs programs were executed
+	hostUptime float64   // host uptime when the VM was booted (kvm profile)
+	mon        *monitor
+	dumped     bool
+	dumpDir    string // crash dump saved by Diagnose
+	index      int
+	netPorts   map[string]int
 }
 
 type archConfig struct {
@@ -191,6 +233,10 @@ func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
 		Qemu:        archConfig.Qemu,
 		QemuArgs:    archConfig.QemuArgs,
 		Snapshot:    true,
+		NetGroup:    1,
+	}
+	if runtime.GOOS == "netbsd" {
+		cfg.QemuArgs = nvmmArgs(cfg.QemuArgs)
 	}
 	if err := config.LoadData(env.Config, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse qemu vm config: %v", err)
@@ -223,16 +269,55 @@ func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
 	if cfg.Mem < 128 || cfg.Mem > 1048576 {
 		return nil, fmt.Errorf("bad qemu mem: %v, want [128-1048576]", cfg.Mem)
 	}
+	if cfg.SnapshotRevert && env.Image == "9p" {
+		return nil, fmt.Errorf("snapshot_revert is not supported for 9p image")
+	}
+	if cfg.VirtioConsole && (env.OS != "linux" || cfg.SnapshotRevert) {
+		return nil, fmt.Errorf("virtio_console is supported only for linux without snapshot_revert")
+	}
+	if cfg.RevertPrograms < 0 || cfg.RevertPrograms != 0 && !cfg.SnapshotRevert {
+		return nil, fmt.Errorf("bad qemu revert_programs: %v, requires snapshot_revert", cfg.RevertPrograms)
+	}
+	if err := validateNICs(env.OS, cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Passthrough) != 0 {
+		if len(cfg.Passthrough) < cfg.Count {
+			return nil, fmt.Errorf("passthrough has devices for %v VMs, but count is %v",
+				len(cfg.Passthrough), cfg.Count)
+		}
+		if err := vmimpl.CheckPassthrough(cfg.Passthrough, cfg.PassthroughAllow); err != nil {
+			return nil, err
+		}
+	}
+	switch cfg.Profile {
+	case "":
+	case profileKVM:
+		if err := setupKVMProfile(env, cfg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown qemu profile %q", cfg.Profile)
+	}
 	cfg.Kernel = osutil.Abs(cfg.Kernel)
 	cfg.Initrd = osutil.Abs(cfg.Initrd)
 	pool := &Pool{
 		cfg:        cfg,
 		env:        env,
 		archConfig: archConfig,
+		revert:     make(map[int]*revertVM),
+		netPorts:   allocateNetPorts(cfg),
 	}
 	return pool, nil
 }
 
+// nvmmArgs converts default qemu args to use nvmm acceleration available on NetBSD hosts instead of kvm.
+// nvmm does not support the host CPU model, max gives all features supported by the accelerator.
+func nvmmArgs(args string) string {
+	args = strings.Replace(args, "-enable-kvm", "-accel nvmm", -1)
+	return strings.Replace(args, "-cpu host,migratable=off", "-cpu max", -1)
+}
+
 func (pool *Pool) Count() int {
 	return pool.cfg.Count
 }
@@ -240,6 +325,11 @@ func (pool *Pool) Count() int {
 func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 	sshkey := pool.env.SSHKey
 	sshuser := pool.env.SSHUser
+	if pool.cfg.SnapshotRevert {
+		if inst := pool.revertInstance(workdir, sshkey, sshuser, index); inst != nil {
+			return inst, nil
+		}
+	}
 	if pool.env.Image == "9p" {
 		sshkey = filepath.Join(workdir, "key")
 		sshuser = "root"
@@ -268,6 +358,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 
 func (pool *Pool) ctor(workdir, sshkey, sshuser string, index int) (vmimpl.Instance, error) {
 	inst := &instance{
+		env:        pool.env,
 		cfg:        pool.cfg,
 		archConfig: pool.archConfig,
 		image:      pool.env.Image,
@@ -277,6 +368,9 @@ func (pool *Pool) ctor(workdir, sshkey, sshuser string, index int) (vmimpl.Insta
 		sshkey:     sshkey,
 		sshuser:    sshuser,
 		diagnose:   make(chan bool, 1),
+		closed:     make(chan bool),
+		index:      index,
+		netPorts:   pool.netPorts,
 	}
 	if st, err := os.Stat(inst.image); err != nil && st.Size() == 0 {
 		// Some kernels may not need an image, however caller may still
@@ -300,12 +394,33 @@ func (pool *Pool) ctor(workdir, sshkey, sshuser string, index int) (vmimpl.Insta
 	if err := inst.boot(); err != nil {
 		return nil, err
 	}
+	if err := inst.setupNICs(); err != nil {
+		return nil, err
+	}
+	if pool.cfg.Profile == profileKVM {
+		if err := inst.setupKVMTracing(); err != nil {
+			return nil, err
+		}
+	}
+	if pool.cfg.SnapshotRevert {
+		pool.saveSnapshot(inst, index)
+	}
+	inst.startOutput()
 
 	closeInst = nil
 	return inst, nil
 }
 
 func (inst *instance) Close() {
+	close(inst.closed)
+	if inst.rvm != nil && inst.rvm.detach() {
+		// The VM is kept running to be reverted to the snapshot.
+		inst.merger.Wait()
+		return
+	}
+	if inst.mon != nil && inst.rvm == nil {
+		inst.mon.close()
+	}
 	if inst.qemu != nil {
 		inst.qemu.Process.Kill()
 		inst.qemu.Wait()
@@ -332,9 +447,24 @@ func (inst *instance) boot() error {
 		"-serial", "stdio",
 		"-no-reboot",
 	}
+	if inst.cfg.SnapshotRevert || inst.cfg.CrashDump {
+		// Stop instead of exiting after kernel panics, so that we can revert or dump the VM.
+		args = append(args,
+			"-no-shutdown",
+			"-monitor", fmt.Sprintf("unix:%v,server,nowait", filepath.Join(inst.workdir, "monitor")),
+		)
+	}
 	if inst.cfg.QemuArgs != "" {
 		args = append(args, strings.Split(inst.cfg.QemuArgs, " ")...)
 	}
+	args = addCPUFeatures(args, inst.cfg.CPUFeatures)
+	args = append(args, inst.nicArgs()...)
+	args = append(args, inst.virtConsoleArgs()...)
+	passthroughArgs, err := inst.passthroughArgs()
+	if err != nil {
+		return err
+	}
+	args = append(args, passthroughArgs...)
 	if inst.image == "9p" {
 		args = append(args,
 			"-fsdev", "local,id=fsdev0,path=/,security_model=none,readonly",
@@ -354,7 +484,12 @@ func (inst *instance) boot() error {
 		)
 	}
 	if inst.cfg.Kernel != "" {
-		cmdline := append([]string{}, inst.archConfig.CmdLine...)
+		var cmdline []string
+		if inst.cfg.VirtioConsole {
+			// The last console= is used for /dev/console, so serial stays the main console.
+			cmdline = append(cmdline, "console=hvc0")
+		}
+		cmdline = append(cmdline, inst.archConfig.CmdLine...)
 		if inst.image == "9p" {
 			cmdline = append(cmdline,
 				"root=/dev/root",
@@ -373,15 +508,33 @@ func (inst *instance) boot() error {
 		log.Logf(0, "running command: %v %#v", inst.cfg.Qemu, args)
 	}
 	qemu := osutil.Command(inst.cfg.Qemu, args...)
-	qemu.Stdout = inst.wpipe
-	qemu.Stderr = inst.wpipe
-	if err := qemu.Start(); err != nil {
-		return fmt.Errorf("failed to start %v %+v: %v", inst.cfg.Qemu, args, err)
+	if inst.cfg.SnapshotRevert {
+		// Qemu outlives the instance, so its output is copied to the current instance pipe.
+		rvm, err := startRevertVM(qemu, filepath.Join(inst.workdir, "monitor"), inst.port)
+		if err != nil {
+			return fmt.Errorf("failed to start %v %+v: %v", inst.cfg.Qemu, args, err)
+		}
+		rvm.attach(inst.wpipe)
+		inst.rvm = rvm
+		inst.mon = rvm.monitor
+	} else {
+		qemu.Stdout = inst.wpipe
+		qemu.Stderr = inst.wpipe
+		if err := qemu.Start(); err != nil {
+			return fmt.Errorf("failed to start %v %+v: %v", inst.cfg.Qemu, args, err)
+		}
+		inst.wpipe.Close()
 	}
-	inst.wpipe.Close()
 	inst.wpipe = nil
 	inst.qemu = qemu
 	// Qemu has started.
+	if inst.cfg.CrashDump && inst.mon == nil {
+		mon, err := dialMonitor(filepath.Join(inst.workdir, "monitor"))
+		if err != nil {
+			return err
+		}
+		inst.mon = mon
+	}
 
 	// Start output merger.
 	var tee io.Writer
@@ -389,9 +542,52 @@ func (inst *instance) boot() error {
 		tee = os.Stdout
 	}
 	inst.merger = vmimpl.NewOutputMerger(tee)
-	inst.merger.Add("qemu", inst.rpipe)
+	if inst.cfg.VirtioConsole {
+		virtcon, err := inst.openVirtConsole()
+		if err != nil {
+			return err
+		}
+		inst.merger.AddConsole("qemu", inst.rpipe)
+		inst.merger.AddConsole("virtio", virtcon)
+	} else {
+		inst.merger.Add("qemu", inst.rpipe)
+	}
 	inst.rpipe = nil
+	return inst.waitForBoot(10 * time.Minute)
+}
 
+// passthroughArgs prepares host devices for the VM and returns qemu arguments for them.
+func (inst *instance) passthroughArgs() ([]string, error) {
+	if len(inst.cfg.Passthrough) == 0 {
+		return nil, nil
+	}
+	if inst.index >= len(inst.cfg.Passthrough) {
+		return nil, fmt.Errorf("no passthrough devices for VM %v", inst.index)
+	}
+	var args []string
+	devs := inst.cfg.Passthrough[inst.index]
+	for _, addr := range devs.PCI {
+		if err := vmimpl.PreparePCIDevice(addr); err != nil {
+			return nil, err
+		}
+		args = append(args, "-device", "vfio-pci,host="+addr)
+	}
+	if len(devs.USB) != 0 {
+		args = append(args, "-device", "qemu-xhci,id=passthrough-xhci")
+	}
+	for _, port := range devs.USB {
+		if err := vmimpl.PrepareUSBDevice(port); err != nil {
+			return nil, err
+		}
+		bus, hostPort, _ := vmimpl.ParseUSBPort(port)
+		args = append(args, "-device", fmt.Sprintf("usb-host,bus=passthrough-xhci.0,hostbus=%v,hostport=%v",
+			bus, hostPort))
+	}
+	return args, nil
+}
+
+func (inst *instance) waitForBoot(timeout time.Duration) error {
+	inst.hostUptime = hostUptime()
 	var bootOutput []byte
 	bootOutputStop := make(chan bool)
 	go func() {
@@ -405,7 +601,7 @@ func (inst *instance) boot() error {
 			}
 		}
 	}()
-	if err := vmimpl.WaitForSSH(inst.debug, 10*time.Minute, "localhost",
+	if err := vmimpl.WaitForSSH(inst.debug, inst.env.Timeout(timeout), "localhost",
 		inst.sshkey, inst.sshuser, inst.os, inst.port, inst.merger.Err); err != nil {
 		bootOutputStop <- true
 		<-bootOutputStop
@@ -514,6 +710,10 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		case <-inst.diagnose:
 			cmd.Process.Kill()
 			goto retry
+		case <-inst.revertc:
+			signal(vmimpl.ErrTimeout)
+		case <-inst.closed:
+			signal(fmt.Errorf("instance closed"))
 		case err := <-inst.merger.Err:
 			cmd.Process.Kill()
 			if cmdErr := cmd.Wait(); cmdErr == nil {
@@ -527,15 +727,22 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		cmd.Process.Kill()
 		cmd.Wait()
 	}()
-	return inst.merger.Output, errc, nil
+	return inst.outc, errc, nil
 }
 
 func (inst *instance) Diagnose() ([]byte, bool) {
+	var diag []byte
+	if inst.cfg.Profile == profileKVM {
+		diag = inst.diagnoseKVM()
+	}
+	if inst.cfg.CrashDump {
+		inst.saveCrashDump()
+	}
 	select {
 	case inst.diagnose <- true:
 	default:
 	}
-	return nil, false
+	return diag, false
 }
 
 // nolint: lll
@@ -0,0 +1,228 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package qemu
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+// Snapshot revert mode (snapshot_revert config option).
+// The qemu process is not killed when the instance is closed. Instead the next instance
+// with the same index reverts the VM to the snapshot taken after the first boot
+// (savevm/loadvm human monitor commands). Kernel panics stop the VM (-no-shutdown),
+// so the same works after crashes. The snapshot is taken once the VM accepts ssh connections,
+// binaries are copied and the fuzzer is started anew for every instance as usual.
+
+const snapshotName = "syzkaller"
+
+type revertVM struct {
+	qemu    *exec.Cmd
+	port    int
+	monitor *monitor
+	mu      sync.Mutex
+	console io.WriteCloser // output pipe of the current instance
+	ready   bool           // the snapshot is taken, so the VM can be reverted
+	dead    bool           // qemu has exited
+}
+
+func startRevertVM(qemu *exec.Cmd, monitor string, port int) (*revertVM, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	qemu.Stdout = wpipe
+	qemu.Stderr = wpipe
+	if err := qemu.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, err
+	}
+	wpipe.Close()
+	rvm := &revertVM{
+		qemu: qemu,
+		port: port,
+	}
+	go rvm.copyOutput(rpipe)
+	if rvm.monitor, err = dialMonitor(monitor); err != nil {
+		rvm.kill()
+		return nil, err
+	}
+	return rvm, nil
+}
+
+func (rvm *revertVM) copyOutput(r io.ReadCloser) {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := r.Read(buf)
+		rvm.mu.Lock()
+		if n != 0 && rvm.console != nil {
+			rvm.console.Write(buf[:n])
+		}
+		if err != nil {
+			rvm.dead = true
+			if rvm.console != nil {
+				rvm.console.Close()
+				rvm.console = nil
+			}
+			rvm.mu.Unlock()
+			r.Close()
+			return
+		}
+		rvm.mu.Unlock()
+	}
+}
+
+// attach redirects qemu output to w, w is closed on detach or when qemu exits.
+func (rvm *revertVM) attach(w io.WriteCloser) {
+	rvm.mu.Lock()
+	defer rvm.mu.Unlock()
+	if rvm.dead {
+		w.Close()
+		return
+	}
+	rvm.console = w
+}
+
+// detach stops redirection of qemu output and returns true if the VM can be reverted later.
+func (rvm *revertVM) detach() bool {
+	rvm.mu.Lock()
+	defer rvm.mu.Unlock()
+	if rvm.console != nil {
+		rvm.console.Close()
+		rvm.console = nil
+	}
+	return rvm.ready && !rvm.dead
+}
+
+func (rvm *revertVM) kill() {
+	rvm.mu.Lock()
+	rvm.ready = false
+	rvm.mu.Unlock()
+	if rvm.monitor != nil {
+		rvm.monitor.close()
+	}
+	rvm.qemu.Process.Kill()
+	rvm.qemu.Wait()
+}
+
+// saveSnapshot takes the snapshot of the freshly booted instance and keeps the VM for reverts.
+// If this fails, the instance works as usual.
+func (pool *Pool) saveSnapshot(inst *instance, index int) {
+	if _, err := inst.rvm.monitor.hmp("savevm "+snapshotName, 10*time.Minute); err != nil {
+		log.Logf(0, "VM-%v: failed to save qemu snapshot: %v", index, err)
+		return
+	}
+	inst.rvm.mu.Lock()
+	inst.rvm.ready = true
+	inst.rvm.mu.Unlock()
+	pool.revertMu.Lock()
+	pool.revert[index] = inst.rvm
+	pool.revertMu.Unlock()
+}
+
+// revertInstance creates the instance by reverting the kept VM to the snapshot.
+// Returns nil if there is no VM to revert or revert fails, then the VM needs to be booted.
+func (pool *Pool) revertInstance(workdir, sshkey, sshuser string, index int) *instance {
+	pool.revertMu.Lock()
+	rvm := pool.revert[index]
+	delete(pool.revert, index)
+	pool.revertMu.Unlock()
+	if rvm == nil {
+		return nil
+	}
+	inst := &instance{
+		env:        pool.env,
+		cfg:        pool.cfg,
+		archConfig: pool.archConfig,
+		image:      pool.env.Image,
+		debug:      pool.env.Debug,
+		os:         pool.env.OS,
+		workdir:    workdir,
+		sshkey:     sshkey,
+		sshuser:    sshuser,
+		port:       rvm.port,
+		qemu:       rvm.qemu,
+		rvm:        rvm,
+		mon:        rvm.monitor,
+		index:      index,
+		netPorts:   pool.netPorts,
+		diagnose:   make(chan bool, 1),
+		closed:     make(chan bool),
+	}
+	if err := inst.revert(); err != nil {
+		log.Logf(0, "VM-%v: failed to revert to qemu snapshot: %v", index, err)
+		rvm.detach()
+		if inst.merger != nil {
+			inst.merger.Wait()
+		}
+		rvm.kill()
+		return nil
+	}
+	pool.revertMu.Lock()
+	pool.revert[index] = rvm
+	pool.revertMu.Unlock()
+	inst.startOutput()
+	return inst
+}
+
+func (inst *instance) revert() error {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return err
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("qemu", rpipe)
+	inst.rvm.attach(wpipe)
+	if _, err := inst.rvm.monitor.hmp("loadvm "+snapshotName, 5*time.Minute); err != nil {
+		return err
+	}
+	// The VM is stopped if the kernel has panicked.
+	if _, err := inst.rvm.monitor.hmp("cont", time.Minute); err != nil {
+		return err
+	}
+	return inst.waitForBoot(time.Minute)
+}
+
+const executingProgram = "executing program"
+
+// startOutput sets up the output channel returned from Run. For revert_programs mode
+// it counts executed programs and requests recreation of the instance after the limit.
+func (inst *instance) startOutput() {
+	inst.outc = inst.merger.Output
+	if inst.cfg.RevertPrograms == 0 {
+		return
+	}
+	outc := make(chan []byte, cap(inst.merger.Output))
+	inst.outc = outc
+	inst.revertc = make(chan bool)
+	go func() {
+		programs := 0
+		for out := range inst.merger.Output {
+			if programs < inst.cfg.RevertPrograms {
+				programs += bytes.Count(out, []byte(executingProgram))
+				if programs >= inst.cfg.RevertPrograms {
+					close(inst.revertc)
+				}
+			}
+			select {
+			case outc <- out:
+			default:
+			}
+		}
+		close(outc)
+	}()
+}
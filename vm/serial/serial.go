@@ -0,0 +1,303 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package serial implements VMs that are isolated devices (microcontroller boards or emulators)
+// running an RTOS with the executor on it (e.g. executor/zephyr). The device has no network,
+// so syz-fuzzer and syz-execprog run on the host and talk to the executor over a serial line
+// via tools/syz-serialexec; kernel console is read from another serial line.
+//
+// Commands can use the following placeholders: {{NAME}} (device name), {{IMAGE}} (image from
+// the manager config, i.e. the firmware).
+package serial
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("serial", ctor, false)
+}
+
+type Config struct {
+	Devices []*Device `json:"devices"` // devices to use
+	// Command that resets the device (e.g. "pyocd reset -u {{NAME}}"),
+	// for emulators it can start the emulator and keep running.
+	Reset string `json:"reset"`
+	// Command that flashes the firmware onto the device (optional), runs once per device
+	// before the first reset (e.g. "west flash --hex-file {{IMAGE}} -i {{NAME}}").
+	Flash       string `json:"flash"`
+	Baud        int    `json:"baud"`         // baud rate of the executor channel (115200 by default)
+	BootTimeout int    `json:"boot_timeout"` // timeout for device boot in seconds (60 by default)
+}
+
+type Device struct {
+	Name string `json:"name"` // passed to the commands as {{NAME}}
+	// Kernel console: a tty (e.g. "/dev/ttyACM0", 115200 baud),
+	// or a shell command that prints console output.
+	Console string `json:"console"`
+	Exec    string `json:"exec"` // tty of the executor channel (e.g. "/dev/ttyACM1")
+}
+
+type Pool struct {
+	env     *vmimpl.Env
+	cfg     *Config
+	flashMu sync.Mutex
+	flashed map[int]bool
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	dev     *Device
+	debug   bool
+	workdir string
+	reset   *exec.Cmd
+	console io.ReadCloser
+	merger  *vmimpl.OutputMerger
+	closed  chan bool
+}
+
+// The executor prints this on the console when it's ready to serve requests.
+var readyMarker = []byte("syz-executor: serving on")
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Baud:        115200,
+		BootTimeout: 60,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse serial vm config: %v", err)
+	}
+	if len(cfg.Devices) == 0 {
+		return nil, fmt.Errorf("config param devices is empty")
+	}
+	if cfg.Reset == "" {
+		return nil, fmt.Errorf("config param reset is empty")
+	}
+	if cfg.BootTimeout <= 0 {
+		return nil, fmt.Errorf("bad serial boot_timeout: %v", cfg.BootTimeout)
+	}
+	for _, dev := range cfg.Devices {
+		if dev.Name == "" {
+			return nil, fmt.Errorf("device name is empty")
+		}
+		if dev.Console == "" {
+			return nil, fmt.Errorf("device %v console is empty", dev.Name)
+		}
+		if dev.Exec == "" {
+			return nil, fmt.Errorf("device %v exec is empty", dev.Name)
+		}
+	}
+	if env.Debug && len(cfg.Devices) > 1 {
+		log.Logf(0, "limiting number of devices from %v to 1 in debug mode", len(cfg.Devices))
+		cfg.Devices = cfg.Devices[:1]
+	}
+	pool := &Pool{
+		env:     env,
+		cfg:     cfg,
+		flashed: make(map[int]bool),
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return len(pool.cfg.Devices)
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		dev:     pool.cfg.Devices[index],
+		debug:   pool.env.Debug,
+		workdir: workdir,
+		closed:  make(chan bool),
+	}
+	if err := pool.flash(index); err != nil {
+		return nil, err
+	}
+	if err := inst.boot(); err != nil {
+		inst.Close()
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (pool *Pool) flash(index int) error {
+	if pool.cfg.Flash == "" {
+		return nil
+	}
+	pool.flashMu.Lock()
+	defer pool.flashMu.Unlock()
+	if pool.flashed[index] {
+		return nil
+	}
+	dev := pool.cfg.Devices[index]
+	log.Logf(0, "device %v: flashing %v", dev.Name, pool.env.Image)
+	command := expand(pool.cfg.Flash, dev, pool.env.Image)
+	if _, err := osutil.RunCmd(10*time.Minute, "", "sh", "-c", command); err != nil {
+		// Not cached, the next attempt may succeed.
+		return fmt.Errorf("failed to flash device %v: %v", dev.Name, err)
+	}
+	pool.flashed[index] = true
+	return nil
+}
+
+func (inst *instance) boot() error {
+	var err error
+	if inst.console, err = openConsole(inst.dev.Console); err != nil {
+		return err
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", inst.console)
+
+	// The reset command may be long-running (e.g. an emulator),
+	// so we don't wait for it and kill it in Close.
+	command := expand(inst.cfg.Reset, inst.dev, inst.pool.env.Image)
+	if inst.debug {
+		log.Logf(0, "running command: %v", command)
+	}
+	inst.reset = osutil.Command("sh", "-c", command)
+	if err := inst.reset.Start(); err != nil {
+		return fmt.Errorf("failed to reset device %v: %v", inst.dev.Name, err)
+	}
+	var bootOutput []byte
+	timeout := time.NewTimer(time.Duration(inst.cfg.BootTimeout) * time.Second)
+	defer timeout.Stop()
+	for {
+		select {
+		case out := <-inst.merger.Output:
+			bootOutput = append(bootOutput, out...)
+			if bytes.Contains(bootOutput, readyMarker) {
+				return nil
+			}
+		case err := <-inst.merger.Err:
+			return vmimpl.MakeBootError(fmt.Errorf("console failed: %v", err), bootOutput)
+		case <-timeout.C:
+			return vmimpl.MakeBootError(fmt.Errorf("executor did not start"), bootOutput)
+		}
+	}
+}
+
+func (inst *instance) Close() {
+	close(inst.closed)
+	if inst.reset != nil {
+		inst.reset.Process.Kill()
+		inst.reset.Wait()
+	}
+	if inst.console != nil {
+		inst.console.Close()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+		inst.merger = nil
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	// syz-fuzzer runs on the host.
+	return fmt.Sprintf("127.0.0.1:%v", port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	// All binaries run on the host.
+	return hostSrc, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	args := rewriteCommand(command, inst.dev, inst.cfg.Baud)
+	if inst.debug {
+		log.Logf(0, "running command: %#v", args)
+	}
+	cmd := osutil.Command(args[0], args[1:]...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	inst.merger.Add("fuzzer", rpipe)
+	merger := inst.merger
+	// Multiplex waits for the merger.
+	inst.merger = nil
+	return vmimpl.Multiplex(cmd, merger, inst.console, timeout, stop, inst.closed, inst.debug)
+}
+
+// rewriteCommand makes the executor (syz-serialexec) talk to the device.
+// Executor arguments are passed in the same argument, pkg/ipc splits it by spaces.
+func rewriteCommand(command string, dev *Device, baud int) []string {
+	args := strings.Split(command, " ")
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-executor=") {
+			args[i] = fmt.Sprintf("%v -dev=%v -baud=%v", arg, dev.Exec, baud)
+		}
+	}
+	return args
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+func expand(command string, dev *Device, image string) string {
+	return strings.NewReplacer(
+		"{{NAME}}", dev.Name,
+		"{{IMAGE}}", image,
+	).Replace(command)
+}
+
+func openConsole(console string) (io.ReadCloser, error) {
+	if strings.HasPrefix(console, "/dev/") {
+		return vmimpl.OpenConsole(console)
+	}
+	cmd := osutil.Command("sh", "-c", console)
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return nil, fmt.Errorf("failed to start console: %v", err)
+	}
+	wpipe.Close()
+	return &cmdConsole{rpipe, cmd}, nil
+}
+
+// cmdConsole is console output of a command, closing it kills the command.
+type cmdConsole struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (con *cmdConsole) Close() error {
+	con.cmd.Process.Kill()
+	con.cmd.Wait()
+	return con.ReadCloser.Close()
+}
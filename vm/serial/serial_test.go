@@ -0,0 +1,23 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package serial
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteCommand(t *testing.T) {
+	dev := &Device{Name: "frdm-0", Console: "/dev/ttyACM0", Exec: "/dev/ttyACM1"}
+	got := rewriteCommand("/bin/syz-fuzzer -executor=/bin/syz-executor -name=vm-0 -os=zephyr", dev, 921600)
+	want := []string{
+		"/bin/syz-fuzzer",
+		"-executor=/bin/syz-executor -dev=/dev/ttyACM1 -baud=921600",
+		"-name=vm-0",
+		"-os=zephyr",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
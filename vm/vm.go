@@ -21,25 +21,39 @@ import (
 
 	// Import all VM implementations, so that users only need to import vm.
 	_ "github.com/google/syzkaller/vm/adb"
+	_ "github.com/google/syzkaller/vm/aws"
 	_ "github.com/google/syzkaller/vm/bhyve"
+	_ "github.com/google/syzkaller/vm/board"
+	_ "github.com/google/syzkaller/vm/cloudhypervisor"
+	_ "github.com/google/syzkaller/vm/cuttlefish"
+	_ "github.com/google/syzkaller/vm/external"
+	_ "github.com/google/syzkaller/vm/firecracker"
 	_ "github.com/google/syzkaller/vm/gce"
-	_ "github.com/google/syzkaller/vm/gvisor"
+	_ "github.com/google/syzkaller/vm/hyperv"
 	_ "github.com/google/syzkaller/vm/isolated"
 	_ "github.com/google/syzkaller/vm/kvm"
+	_ "github.com/google/syzkaller/vm/libvirt"
 	_ "github.com/google/syzkaller/vm/odroid"
 	_ "github.com/google/syzkaller/vm/qemu"
+	_ "github.com/google/syzkaller/vm/serial"
 	_ "github.com/google/syzkaller/vm/vmm"
+	_ "github.com/google/syzkaller/vm/vmware"
+	_ "github.com/google/syzkaller/vm/vz"
 )
 
 type Pool struct {
-	impl    vmimpl.Pool
-	workdir string
+	impl     vmimpl.Pool
+	workdir  string
+	slowdown int
 }
 
 type Instance struct {
-	impl    vmimpl.Instance
-	workdir string
-	index   int
+	impl      vmimpl.Instance
+	workdir   string
+	index     int
+	slowdown  int
+	anomalies *report.AnomalyDetector
+	health    *healthMonitor
 }
 
 var (
@@ -71,23 +85,28 @@ func Create(cfg *mgrconfig.Config, debug bool) (*Pool, error) {
 		return nil, fmt.Errorf("unknown instance type '%v'", cfg.Type)
 	}
 	env := &vmimpl.Env{
-		Name:    cfg.Name,
-		OS:      cfg.TargetOS,
-		Arch:    cfg.TargetVMArch,
-		Workdir: cfg.Workdir,
-		Image:   cfg.Image,
-		SSHKey:  cfg.SSHKey,
-		SSHUser: cfg.SSHUser,
-		Debug:   debug,
-		Config:  cfg.VM,
+		Name:     cfg.Name,
+		OS:       cfg.TargetOS,
+		Arch:     cfg.TargetVMArch,
+		Workdir:  cfg.Workdir,
+		Image:    cfg.Image,
+		SSHKey:   cfg.SSHKey,
+		SSHUser:  cfg.SSHUser,
+		Debug:    debug,
+		Config:   cfg.VM,
+		Slowdown: cfg.Slowdown,
+	}
+	if env.Slowdown < 1 {
+		env.Slowdown = 1
 	}
 	impl, err := typ.Ctor(env)
 	if err != nil {
 		return nil, err
 	}
 	return &Pool{
-		impl:    impl,
-		workdir: env.Workdir,
+		impl:     impl,
+		workdir:  env.Workdir,
+		slowdown: env.Slowdown,
 	}, nil
 }
 
@@ -109,14 +128,20 @@ func (pool *Pool) Create(index int) (*Instance, error) {
 		return nil, err
 	}
 	return &Instance{
-		impl:    impl,
-		workdir: workdir,
-		index:   index,
+		impl:     impl,
+		workdir:  workdir,
+		index:    index,
+		slowdown: pool.slowdown,
 	}, nil
 }
 
 func (inst *Instance) Copy(hostSrc string) (string, error) {
-	return inst.impl.Copy(hostSrc)
+	start := time.Now()
+	res, err := inst.impl.Copy(hostSrc)
+	if inst.health != nil && err == nil {
+		inst.health.sshOperation(time.Since(start))
+	}
+	return res, err
 }
 
 func (inst *Instance) Forward(port int) (string, error) {
@@ -132,6 +157,53 @@ func (inst *Instance) Diagnose() ([]byte, bool) {
 	return inst.impl.Diagnose()
 }
 
+// SaveCrashDump moves the crash dump saved by the last Diagnose (if the VM type supports it)
+// to the dst directory. Returns false if there is no dump.
+func (inst *Instance) SaveCrashDump(dst string) (bool, error) {
+	dumper, ok := inst.impl.(vmimpl.CrashDumper)
+	if !ok {
+		return false, nil
+	}
+	dir := dumper.CrashDump()
+	if dir == "" {
+		return false, nil
+	}
+	if err := os.Rename(dir, dst); err != nil {
+		return false, fmt.Errorf("failed to save crash dump: %v", err)
+	}
+	return true, nil
+}
+
+// PstoreLog returns kernel log of the previous boot of the machine recovered from pstore
+// (if the VM type supports it), or nil.
+func (inst *Instance) PstoreLog() []byte {
+	if reader, ok := inst.impl.(vmimpl.PstoreReader); ok {
+		return reader.PstoreLog()
+	}
+	return nil
+}
+
+// DetectAnomalies makes MonitorExecution feed all console output to the anomaly detector.
+func (inst *Instance) DetectAnomalies(detector *report.AnomalyDetector) {
+	inst.anomalies = detector
+}
+
+// MonitorHealth makes Copy and MonitorExecution track health of the VM.
+// If the VM is detected to be degraded, MonitorExecution stops execution
+// as if it finished successfully and RecycleReason returns the reason.
+func (inst *Instance) MonitorHealth(cfg *HealthConfig) {
+	inst.health = newHealthMonitor(cfg, time.Now())
+}
+
+// RecycleReason returns why the VM was detected to be degraded (one of Recycle* constants),
+// or an empty string if the VM is healthy or health is not monitored.
+func (inst *Instance) RecycleReason() string {
+	if inst.health == nil {
+		return ""
+	}
+	return inst.health.reason
+}
+
 func (inst *Instance) Close() {
 	inst.impl.Close()
 	os.RemoveAll(inst.workdir)
@@ -155,6 +227,15 @@ const (
 // Returns a non-symbolized crash report, or nil if no error happens.
 func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 	reporter report.Reporter, exit ExitCondition) (rep *report.Report) {
+	return inst.MonitorExecutionDelayed(outc, errc, reporter, exit, 0)
+}
+
+// MonitorExecutionDelayed is the same as MonitorExecution, but if the program exits
+// or times out normally, it keeps monitoring kernel output for the delay.
+// This allows to catch reports that kernel prints with a significant delay,
+// e.g. by hung task and RCU stall detectors.
+func (inst *Instance) MonitorExecutionDelayed(outc <-chan []byte, errc <-chan error,
+	reporter report.Reporter, exit ExitCondition, delay time.Duration) (rep *report.Report) {
 	mon := &monitor{
 		inst:     inst,
 		outc:     outc,
@@ -162,6 +243,9 @@ func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 		reporter: reporter,
 		exit:     exit,
 	}
+	if inst.RecycleReason() != "" {
+		return nil
+	}
 	lastExecuteTime := time.Now()
 	ticker := time.NewTicker(tickerPeriod)
 	defer ticker.Stop()
@@ -175,12 +259,17 @@ func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 				crash := ""
 				if mon.exit&ExitNormal == 0 {
 					crash = lostConnectionCrash
+				} else {
+					mon.waitForCrash(delay)
 				}
 				return mon.extractError(crash)
 			case ErrTimeout:
 				if mon.exit&ExitTimeout == 0 {
 					return mon.extractError(timeoutCrash)
 				}
+				if mon.waitForCrash(delay) {
+					return mon.extractError("")
+				}
 				return nil
 			default:
 				// Note: connection lost can race with a kernel oops message.
@@ -196,6 +285,12 @@ func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 				outc = nil
 				continue
 			}
+			if inst.anomalies != nil {
+				inst.anomalies.Feed(out)
+			}
+			if inst.health != nil {
+				inst.health.feed(out)
+			}
 			lastPos := len(mon.output)
 			mon.output = append(mon.output, out...)
 			if bytes.Contains(mon.output[lastPos:], executingProgram1) ||
@@ -205,6 +300,10 @@ func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 			if reporter.ContainsCrash(mon.output[mon.matchPos:]) {
 				return mon.extractError("unknown error")
 			}
+			if inst.RecycleReason() != "" {
+				// Still return a crash if the kernel oopses right after the disk error.
+				return mon.extractError("")
+			}
 			if len(mon.output) > 2*beforeContext {
 				copy(mon.output, mon.output[len(mon.output)-beforeContext:])
 				mon.output = mon.output[:beforeContext]
@@ -225,7 +324,13 @@ func (inst *Instance) MonitorExecution(outc <-chan []byte, errc <-chan error,
 			// in 140-280s detection delay.
 			// So the current timeout is 5 mins (300s).
 			// We don't want it to be too long too because it will waste time on real hangs.
-			if time.Since(lastExecuteTime) < NoOutputTimeout {
+			if time.Since(lastExecuteTime) < NoOutputTimeout*time.Duration(inst.slowdown) {
+				if inst.health != nil {
+					inst.health.check(time.Now())
+					if inst.RecycleReason() != "" {
+						return nil
+					}
+				}
 				break
 			}
 			diag, wait := inst.Diagnose()
@@ -269,7 +374,8 @@ func (mon *monitor) extractError(defaultError string) *report.Report {
 	}
 	// Give it some time to finish writing the error message.
 	mon.waitForOutput()
-	if bytes.Contains(mon.output, []byte(fuzzerPreemptedStr)) {
+	if bytes.Contains(mon.output, []byte(fuzzerPreemptedStr)) ||
+		bytes.Contains(mon.output, []byte(fuzzerRebootStr)) {
 		return nil
 	}
 	if !mon.reporter.ContainsCrash(mon.output[mon.matchPos:]) {
@@ -311,6 +417,32 @@ func (mon *monitor) extractError(defaultError string) *report.Report {
 	return rep
 }
 
+// waitForCrash collects kernel output for up to delay and returns true
+// as soon as the output contains a kernel crash.
+func (mon *monitor) waitForCrash(delay time.Duration) bool {
+	if delay == 0 {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case out, ok := <-mon.outc:
+			if !ok {
+				return false
+			}
+			mon.output = append(mon.output, out...)
+			if mon.reporter.ContainsCrash(mon.output[mon.matchPos:]) {
+				return true
+			}
+		case <-timer.C:
+			return false
+		case <-Shutdown:
+			return false
+		}
+	}
+}
+
 func (mon *monitor) waitForOutput() {
 	timer := time.NewTimer(waitForOutputTimeout)
 	defer timer.Stop()
@@ -329,6 +461,12 @@ func (mon *monitor) waitForOutput() {
 	}
 }
 
+// NoReport returns true for crashes detected without a kernel crash report in the output
+// (the machine stopped responding), the report may be recovered on the next boot (see PstoreLog).
+func NoReport(title string) bool {
+	return title == lostConnectionCrash || title == noOutputCrash
+}
+
 const (
 	maxErrorLength = 512
 
@@ -338,6 +476,7 @@ const (
 	executingProgramStr1 = "executing program"  // syz-fuzzer output
 	executingProgramStr2 = "executed programs:" // syz-execprog output
 	fuzzerPreemptedStr   = "SYZ-FUZZER: PREEMPTED"
+	fuzzerRebootStr      = "SYZ-FUZZER: REBOOT" // the fuzzer wants a fresh machine
 )
 
 var (
@@ -350,4 +489,7 @@ var (
 	NoOutputTimeout      = 5 * time.Minute
 	tickerPeriod         = 10 * time.Second
 	waitForOutputTimeout = 10 * time.Second
+	// Linux hung task and workqueue detectors can report a hang up to 280s
+	// after it happened (see the comment in MonitorExecution).
+	StallDetectionDelay = 5 * time.Minute
 )
@@ -0,0 +1,9 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// +build !windows
+
+package vm
+
+// gvisor relies on unix sockets and device files, so it's not available on windows hosts.
+import _ "github.com/google/syzkaller/vm/gvisor"
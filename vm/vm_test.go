@@ -74,6 +74,7 @@ func init() {
 	tickerPeriod = 1 * time.Second
 	NoOutputTimeout = 5 * time.Second
 	waitForOutputTimeout = 3 * time.Second
+	StallDetectionDelay = 3 * time.Second
 
 	ctor := func(env *vmimpl.Env) (vmimpl.Pool, error) {
 		return &testPool{}, nil
@@ -86,6 +87,7 @@ type Test struct {
 	Exit           ExitCondition
 	DiagnoseBug    bool // Diagnose produces output that is detected as kernel crash
 	DiagnoseNoWait bool // Diagnose returns output directly rather than to console
+	Delayed        bool // use MonitorExecutionDelayed
 	Body           func(outc chan []byte, errc chan error)
 	Report         *report.Report
 }
@@ -165,6 +167,13 @@ var tests = []*Test{
 			outc <- []byte(fuzzerPreemptedStr + "\n")
 		},
 	},
+	{
+		Name: "fuzzer-requests-reboot",
+		Body: func(outc chan []byte, errc chan error) {
+			outc <- []byte(fuzzerRebootStr + "\n")
+			errc <- fmt.Errorf("fuzzer exited")
+		},
+	},
 	{
 		Name: "program-exits-but-kernel-crashes-afterwards",
 		Exit: ExitNormal,
@@ -188,6 +197,57 @@ var tests = []*Test{
 			errc <- vmimpl.ErrTimeout
 		},
 	},
+	{
+		Name: "timeout-but-kernel-crashes-afterwards",
+		Exit: ExitTimeout,
+		Body: func(outc chan []byte, errc chan error) {
+			errc <- vmimpl.ErrTimeout
+			time.Sleep(time.Second)
+			outc <- []byte("BUG: bad\n")
+		},
+	},
+	{
+		Name:    "timeout-but-kernel-crashes-afterwards-delayed",
+		Exit:    ExitTimeout,
+		Delayed: true,
+		Body: func(outc chan []byte, errc chan error) {
+			errc <- vmimpl.ErrTimeout
+			time.Sleep(time.Second)
+			outc <- []byte("BUG: bad\n")
+		},
+		Report: &report.Report{
+			Title: "BUG: bad",
+			Report: []byte(
+				"BUG: bad\n" +
+					"DIAGNOSE\n",
+			),
+		},
+	},
+	{
+		Name:    "program-exits-but-kernel-crashes-much-later-delayed",
+		Exit:    ExitNormal,
+		Delayed: true,
+		Body: func(outc chan []byte, errc chan error) {
+			errc <- nil
+			time.Sleep(2 * time.Second)
+			outc <- []byte("BUG: bad\n")
+		},
+		Report: &report.Report{
+			Title: "BUG: bad",
+			Report: []byte(
+				"BUG: bad\n" +
+					"DIAGNOSE\n",
+			),
+		},
+	},
+	{
+		Name:    "timeout-delayed",
+		Exit:    ExitTimeout,
+		Delayed: true,
+		Body: func(outc chan []byte, errc chan error) {
+			errc <- vmimpl.ErrTimeout
+		},
+	},
 	{
 		Name: "bad-timeout",
 		Body: func(outc chan []byte, errc chan error) {
@@ -325,7 +385,12 @@ func testMonitorExecution(t *testing.T, test *Test) {
 		test.Body(testInst.outc, testInst.errc)
 		done <- true
 	}()
-	rep := inst.MonitorExecution(outc, errc, reporter, test.Exit)
+	var rep *report.Report
+	if test.Delayed {
+		rep = inst.MonitorExecutionDelayed(outc, errc, reporter, test.Exit, StallDetectionDelay)
+	} else {
+		rep = inst.MonitorExecution(outc, errc, reporter, test.Exit)
+	}
 	<-done
 	if test.Report != nil && rep == nil {
 		t.Fatalf("got no report")
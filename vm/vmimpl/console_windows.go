@@ -0,0 +1,24 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"fmt"
+	"io"
+)
+
+// Serial consoles are not supported on Windows hosts, VMs need to provide console in other ways
+// (e.g. hyperv uses named pipes).
+
+func OpenConsole(con string) (rc io.ReadCloser, err error) {
+	return nil, fmt.Errorf("serial consoles are not supported on windows")
+}
+
+func OpenRemoteConsole(bin string, args ...string) (rc io.ReadCloser, err error) {
+	return nil, fmt.Errorf("remote consoles are not supported on windows")
+}
+
+func OpenAdbConsole(bin, dev string) (rc io.ReadCloser, err error) {
+	return nil, fmt.Errorf("adb consoles are not supported on windows")
+}
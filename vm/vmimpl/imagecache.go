@@ -0,0 +1,258 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// ImageCache keeps pristine decompressed copies of VM images in the manager workdir
+// and creates per-VM writable images from them as cheaply as possible
+// (reflink copies on CoW filesystems, qcow2 overlays), so that creation of many VMs
+// does not result in full copies of a multi-GB image.
+// Cached images are identified by the hash of the source image and are verified
+// against the hash of contents when first used.
+type ImageCache struct {
+	dir    string
+	mu     sync.Mutex
+	images map[string]*cachedImage
+}
+
+type cachedImage struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// Number of cached images to keep in the cache dir (the most recently used).
+const maxCachedImages = 3
+
+func NewImageCache(workdir string) *ImageCache {
+	return &ImageCache{
+		dir:    filepath.Join(workdir, "image-cache"),
+		images: make(map[string]*cachedImage),
+	}
+}
+
+// Get returns path to the cached read-only decompressed copy of the image.
+// The image is prepared once, concurrent callers wait for the preparation.
+func (cache *ImageCache) Get(image string) (string, error) {
+	cache.mu.Lock()
+	img := cache.images[image]
+	if img == nil {
+		img = new(cachedImage)
+		cache.images[image] = img
+	}
+	cache.mu.Unlock()
+	img.once.Do(func() {
+		img.path, img.err = cache.prepare(image)
+	})
+	return img.path, img.err
+}
+
+// Clone creates a writable copy of the image at dst.
+func (cache *ImageCache) Clone(image, dst string) error {
+	base, err := cache.Get(image)
+	if err != nil {
+		return err
+	}
+	if err := CopyImage(base, dst); err != nil {
+		return err
+	}
+	return os.Chmod(dst, 0644)
+}
+
+// Overlay creates a qcow2 image at dst that uses the cached image as the backing file.
+func (cache *ImageCache) Overlay(image, dst string) error {
+	base, err := cache.Get(image)
+	if err != nil {
+		return err
+	}
+	format, err := imageFormat(base)
+	if err != nil {
+		return err
+	}
+	os.Remove(dst)
+	_, err = osutil.RunCmd(time.Minute, "", "qemu-img", "create", "-f", "qcow2",
+		"-F", format, "-b", base, dst)
+	return err
+}
+
+// CopyImage copies src to dst using reflink if the filesystem supports it.
+func CopyImage(src, dst string) error {
+	os.Remove(dst)
+	if _, err := exec.LookPath("cp"); err == nil {
+		if _, err := osutil.RunCmd(time.Hour, "", "cp", "--reflink=always", src, dst); err == nil {
+			return nil
+		}
+		os.Remove(dst)
+	}
+	return osutil.CopyFile(src, dst)
+}
+
+// IsCompressedImage returns true if the image needs to be decompressed before use.
+func IsCompressedImage(image string) bool {
+	return strings.HasSuffix(image, ".gz") || strings.HasSuffix(image, ".xz")
+}
+
+func (cache *ImageCache) prepare(image string) (string, error) {
+	hash, err := hashFile(image)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash image: %v", err)
+	}
+	cached := filepath.Join(cache.dir, hash+".img")
+	sumFile := cached + ".sha256"
+	if osutil.IsExist(cached) {
+		want, _ := ioutil.ReadFile(sumFile)
+		got, err := hashFile(cached)
+		if err == nil && string(want) == got {
+			now := time.Now()
+			os.Chtimes(cached, now, now)
+			return cached, nil
+		}
+		log.Logf(0, "cached image %v is corrupted, recreating", cached)
+	}
+	if err := osutil.MkdirAll(cache.dir); err != nil {
+		return "", err
+	}
+	log.Logf(1, "caching image %v as %v", image, cached)
+	tmp := cached + ".tmp"
+	if err := unpackImage(image, tmp); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to cache image: %v", err)
+	}
+	sum, err := hashFile(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := osutil.WriteFile(sumFile, []byte(sum)); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	// Cached images are shared by all VMs, so protect them from accidental writes.
+	if err := os.Chmod(tmp, 0444); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, cached); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	cache.cleanup()
+	return cached, nil
+}
+
+func unpackImage(image, dst string) error {
+	switch {
+	case strings.HasSuffix(image, ".gz"):
+		src, err := os.Open(image)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return writeImage(dst, gz)
+	case strings.HasSuffix(image, ".xz"):
+		cmd := osutil.Command("xz", "--decompress", "--stdout", image)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to run xz: %v", err)
+		}
+		err = writeImage(dst, stdout)
+		if waitErr := cmd.Wait(); err == nil && waitErr != nil {
+			err = fmt.Errorf("xz failed: %v", waitErr)
+		}
+		return err
+	default:
+		return CopyImage(image, dst)
+	}
+}
+
+func writeImage(dst string, r io.Reader) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// cleanup removes all but the most recently used cached images.
+func (cache *ImageCache) cleanup() {
+	files, err := filepath.Glob(filepath.Join(cache.dir, "*.img"))
+	if err != nil || len(files) <= maxCachedImages {
+		return
+	}
+	mtime := make(map[string]time.Time)
+	for _, file := range files {
+		if st, err := os.Stat(file); err == nil {
+			mtime[file] = st.ModTime()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return mtime[files[i]].After(mtime[files[j]])
+	})
+	for _, file := range files[maxCachedImages:] {
+		os.Remove(file)
+		os.Remove(file + ".sha256")
+	}
+}
+
+func hashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+var qcow2Magic = []byte("QFI\xfb")
+
+func imageFormat(image string) (string, error) {
+	f, err := os.Open(image)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	magic := make([]byte, len(qcow2Magic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return "raw", nil
+	}
+	if bytes.Equal(magic, qcow2Magic) {
+		return "qcow2", nil
+	}
+	return "raw", nil
+}
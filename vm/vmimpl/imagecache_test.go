@@ -0,0 +1,105 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestImageCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-image-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := bytes.Repeat([]byte("image data "), 1000)
+	image := filepath.Join(dir, "image")
+	if err := osutil.WriteFile(image, data); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	gz.Write(data)
+	gz.Close()
+	gzImage := filepath.Join(dir, "image.gz")
+	if err := osutil.WriteFile(gzImage, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewImageCache(dir)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src := image
+			if i%2 == 1 {
+				src = gzImage
+			}
+			dst := filepath.Join(dir, "vm", string('0'+rune(i)))
+			if err := osutil.MkdirAll(filepath.Dir(dst)); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := cache.Clone(src, dst); err != nil {
+				t.Error(err)
+				return
+			}
+			got, err := ioutil.ReadFile(dst)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("vm %v: bad image contents", i)
+			}
+			// The clone must be writable and independent from the cached image.
+			if err := osutil.WriteFile(dst, []byte("modified")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cached, err := cache.Get(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("cached image is modified")
+	}
+
+	// A new cache must detect and fix a corrupted cached image.
+	os.Chmod(cached, 0644)
+	if err := osutil.WriteFile(cached, []byte("corrupted")); err != nil {
+		t.Fatal(err)
+	}
+	cached1, err := NewImageCache(dir).Get(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached1 != cached {
+		t.Fatalf("cached image path changed: %v -> %v", cached, cached1)
+	}
+	got, err = ioutil.ReadFile(cached1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("corrupted cached image is not recreated")
+	}
+}
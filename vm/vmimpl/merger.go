@@ -16,6 +16,7 @@ type OutputMerger struct {
 	teeMu  sync.Mutex
 	tee    io.Writer
 	wg     sync.WaitGroup
+	dedup  consoleDedup
 }
 
 type MergerError struct {
@@ -36,6 +37,59 @@ func NewOutputMerger(tee io.Writer) *OutputMerger {
 	}
 }
 
+// consoleDedup matches lines received from different console sources.
+type consoleDedup struct {
+	mu     sync.Mutex
+	recent []*dedupLine
+}
+
+type dedupLine struct {
+	line    string
+	sources []string // sources that delivered the line
+}
+
+// Consoles may come with different delays, but they are not expected to diverge by more than this many lines.
+const dedupWindow = 1000
+
+// filter returns lines (a set of complete lines) that were not yet delivered by other consoles.
+func (dedup *consoleDedup) filter(source string, lines []byte) []byte {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	var res []byte
+	for len(lines) != 0 {
+		pos := bytes.IndexByte(lines, '\n')
+		line := lines[:pos+1]
+		lines = lines[pos+1:]
+		// Serial consoles use \r\n line endings.
+		key := string(bytes.TrimRight(line, "\r\n"))
+		if key == "" || !dedup.seen(source, key) {
+			res = append(res, line...)
+		}
+	}
+	return res
+}
+
+func (dedup *consoleDedup) seen(source, key string) bool {
+	for _, recent := range dedup.recent {
+		if recent.line != key {
+			continue
+		}
+		delivered := false
+		for _, src := range recent.sources {
+			delivered = delivered || src == source
+		}
+		if !delivered {
+			recent.sources = append(recent.sources, source)
+			return true
+		}
+	}
+	dedup.recent = append(dedup.recent, &dedupLine{line: key, sources: []string{source}})
+	if len(dedup.recent) > dedupWindow {
+		dedup.recent = dedup.recent[1:]
+	}
+	return false
+}
+
 func (merger *OutputMerger) Wait() {
 	merger.wg.Wait()
 	close(merger.Output)
@@ -45,13 +99,38 @@ func (merger *OutputMerger) Add(name string, r io.ReadCloser) {
 	merger.AddDecoder(name, r, nil)
 }
 
+// AddConsole adds a kernel console source. Several consoles that carry the same kernel output
+// (e.g. ISA serial and virtio console) can be added, then lines already received from another console
+// are dropped. This way output is not lost when one of the consoles dies during a crash,
+// but it's not duplicated while all consoles work.
+func (merger *OutputMerger) AddConsole(name string, r io.ReadCloser) {
+	merger.add(name, r, nil, true)
+}
+
 func (merger *OutputMerger) AddDecoder(name string, r io.ReadCloser,
 	decoder func(data []byte) (start, size int, decoded []byte)) {
+	merger.add(name, r, decoder, false)
+}
+
+func (merger *OutputMerger) add(name string, r io.ReadCloser,
+	decoder func(data []byte) (start, size int, decoded []byte), console bool) {
 	merger.wg.Add(1)
 	go func() {
 		var pending []byte
+		var ready []byte // complete lines that are not yet sent to Output
 		var proto []byte
 		var buf [4 << 10]byte
+		takeLines := func(lines []byte) {
+			if console {
+				lines = merger.dedup.filter(name, lines)
+			}
+			if merger.tee != nil {
+				merger.teeMu.Lock()
+				merger.tee.Write(lines)
+				merger.teeMu.Unlock()
+			}
+			ready = append(ready, lines...)
+		}
 		for {
 			n, err := r.Read(buf[:])
 			if n != 0 {
@@ -65,30 +144,25 @@ func (merger *OutputMerger) AddDecoder(name string, r io.ReadCloser,
 				}
 				pending = append(pending, buf[:n]...)
 				if pos := bytes.LastIndexByte(pending, '\n'); pos != -1 {
-					out := pending[:pos+1]
-					if merger.tee != nil {
-						merger.teeMu.Lock()
-						merger.tee.Write(out)
-						merger.teeMu.Unlock()
-					}
+					takeLines(pending[:pos+1])
+					r := copy(pending, pending[pos+1:])
+					pending = pending[:r]
+				}
+				if len(ready) != 0 {
 					select {
-					case merger.Output <- append([]byte{}, out...):
-						r := copy(pending, pending[pos+1:])
-						pending = pending[:r]
+					case merger.Output <- ready:
+						ready = nil
 					default:
 					}
 				}
 			}
 			if err != nil {
 				if len(pending) != 0 {
-					pending = append(pending, '\n')
-					if merger.tee != nil {
-						merger.teeMu.Lock()
-						merger.tee.Write(pending)
-						merger.teeMu.Unlock()
-					}
+					takeLines(append(pending, '\n'))
+				}
+				if len(ready) != 0 {
 					select {
-					case merger.Output <- pending:
+					case merger.Output <- ready:
 					default:
 					}
 				}
@@ -80,3 +80,43 @@ func TestMerger(t *testing.T) {
 		t.Fatalf("bad tee: '%s', want '%s'", got, want)
 	}
 }
+
+func TestMergerConsoleDedup(t *testing.T) {
+	tee := new(bytes.Buffer)
+	merger := NewOutputMerger(tee)
+
+	rp1, wp1, err := osutil.LongPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merger.AddConsole("serial", rp1)
+
+	rp2, wp2, err := osutil.LongPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merger.AddConsole("virtio", rp2)
+
+	wp1.Write([]byte("aaa\r\nbbb\r\n"))
+	if got, want := string(<-merger.Output), "aaa\r\nbbb\r\n"; got != want {
+		t.Fatalf("bad line: '%s', want '%s'", got, want)
+	}
+	// The same lines from the other console are dropped, the repeated line is not.
+	wp2.Write([]byte("aaa\nbbb\nbbb\nccc\n"))
+	if got, want := string(<-merger.Output), "bbb\nccc\n"; got != want {
+		t.Fatalf("bad line: '%s', want '%s'", got, want)
+	}
+	// The first console died, the rest of output comes from the second one.
+	wp1.Close()
+	<-merger.Err
+	wp2.Write([]byte("ddd\n"))
+	if got, want := string(<-merger.Output), "ddd\n"; got != want {
+		t.Fatalf("bad line: '%s', want '%s'", got, want)
+	}
+	wp2.Close()
+	merger.Wait()
+	want := "aaa\r\nbbb\r\nbbb\nccc\nddd\n"
+	if got := tee.String(); got != want {
+		t.Fatalf("bad tee: '%s', want '%s'", got, want)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// Passthrough describes host devices passed through into a single VM.
+// Devices are identified by their host location (PCI address, USB bus-port),
+// but they are additionally checked against an allowlist of vendor:device IDs,
+// so that a config typo or device renumbering never passes e.g. the host disk or NIC into a VM.
+type Passthrough struct {
+	// PCI addresses of devices bound to vfio-pci (e.g. "0000:03:00.0").
+	PCI []string `json:"pci"`
+	// USB devices as bus-port as in /sys/bus/usb/devices (e.g. "1-2.1").
+	USB []string `json:"usb"`
+}
+
+var sysfs = "/sys"
+
+// CheckPassthrough checks that the devices exist, are unique and their IDs match
+// the allowlist (glob patterns, e.g. "10de:*", "144d:a808").
+// PCI devices also must not share IOMMU groups with devices that are not passed through into the same VM.
+func CheckPassthrough(devs []Passthrough, allow []string) error {
+	used := make(map[string]bool)
+	for i, dev := range devs {
+		for _, addr := range dev.PCI {
+			if used[addr] {
+				return fmt.Errorf("PCI device %v is passed through twice", addr)
+			}
+			used[addr] = true
+			if err := checkPCIDevice(addr, dev.PCI, allow); err != nil {
+				return fmt.Errorf("VM %v: %v", i, err)
+			}
+		}
+		for _, port := range dev.USB {
+			if used["usb:"+port] {
+				return fmt.Errorf("USB device %v is passed through twice", port)
+			}
+			used["usb:"+port] = true
+			if _, _, err := ParseUSBPort(port); err != nil {
+				return fmt.Errorf("VM %v: %v", i, err)
+			}
+			id, err := readDeviceID(usbDir(port), "idVendor", "idProduct")
+			if err != nil {
+				return fmt.Errorf("VM %v: USB device %v: %v", i, port, err)
+			}
+			if !deviceAllowed(id, allow) {
+				return fmt.Errorf("VM %v: USB device %v (%v) is not in the allowlist", i, port, id)
+			}
+		}
+	}
+	return nil
+}
+
+func checkPCIDevice(addr string, vmDevices, allow []string) error {
+	id, err := readDeviceID(pciDir(addr), "vendor", "device")
+	if err != nil {
+		return fmt.Errorf("PCI device %v: %v", addr, err)
+	}
+	if !deviceAllowed(id, allow) {
+		return fmt.Errorf("PCI device %v (%v) is not in the allowlist", addr, id)
+	}
+	group, err := ioutil.ReadDir(filepath.Join(pciDir(addr), "iommu_group", "devices"))
+	if err != nil {
+		return fmt.Errorf("PCI device %v has no IOMMU group (is IOMMU enabled?): %v", addr, err)
+	}
+	for _, member := range group {
+		other := member.Name()
+		if other == addr || contains(vmDevices, other) {
+			continue
+		}
+		// Bridges stay with the host.
+		class, _ := ioutil.ReadFile(filepath.Join(pciDir(other), "class"))
+		if strings.HasPrefix(strings.TrimSpace(string(class)), "0x0604") {
+			continue
+		}
+		return fmt.Errorf("PCI device %v shares IOMMU group with %v which is not passed through", addr, other)
+	}
+	return nil
+}
+
+// PreparePCIDevice binds the device to vfio-pci (if it's not bound yet) and resets it.
+// Called before each VM boot, so that a device left in a bad state by the previous VM
+// (or taken by a host driver meanwhile) is recovered.
+func PreparePCIDevice(addr string) error {
+	dir := pciDir(addr)
+	if driver := pciDriver(addr); driver != "vfio-pci" {
+		if driver != "" {
+			if err := writeSysfs(filepath.Join(dir, "driver", "unbind"), addr); err != nil {
+				return fmt.Errorf("failed to unbind PCI device %v from %v: %v", addr, driver, err)
+			}
+		}
+		if err := writeSysfs(filepath.Join(dir, "driver_override"), "vfio-pci"); err != nil {
+			return fmt.Errorf("failed to set driver override for PCI device %v: %v", addr, err)
+		}
+		writeSysfs(filepath.Join(sysfs, "bus", "pci", "drivers_probe"), addr)
+		if driver := pciDriver(addr); driver != "vfio-pci" {
+			return fmt.Errorf("failed to bind PCI device %v to vfio-pci (is vfio-pci module loaded?)", addr)
+		}
+	}
+	// Not all devices support reset.
+	if reset := filepath.Join(dir, "reset"); osutil.IsExist(reset) {
+		if err := writeSysfs(reset, "1"); err != nil {
+			return fmt.Errorf("failed to reset PCI device %v: %v", addr, err)
+		}
+	}
+	return nil
+}
+
+// PrepareUSBDevice re-enumerates the USB device, so that it's in a clean state
+// (and rebound to host drivers, which qemu detaches) after the previous VM.
+func PrepareUSBDevice(port string) error {
+	authorized := filepath.Join(usbDir(port), "authorized")
+	if err := writeSysfs(authorized, "0"); err != nil {
+		return fmt.Errorf("failed to deauthorize USB device %v: %v", port, err)
+	}
+	if err := writeSysfs(authorized, "1"); err != nil {
+		return fmt.Errorf("failed to authorize USB device %v: %v", port, err)
+	}
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(100 * time.Millisecond) {
+		if osutil.IsExist(filepath.Join(usbDir(port), "idVendor")) {
+			return nil
+		}
+	}
+	return fmt.Errorf("USB device %v did not reappear after reset", port)
+}
+
+// ParseUSBPort splits USB bus-port (e.g. "1-2.1") into bus ("1") and port ("2.1").
+func ParseUSBPort(port string) (string, string, error) {
+	pos := strings.IndexByte(port, '-')
+	if pos <= 0 || pos == len(port)-1 || strings.ContainsAny(port, ":/") {
+		return "", "", fmt.Errorf("bad USB device %q, want bus-port (e.g. 1-2.1)", port)
+	}
+	return port[:pos], port[pos+1:], nil
+}
+
+func pciDir(addr string) string {
+	return filepath.Join(sysfs, "bus", "pci", "devices", addr)
+}
+
+func usbDir(port string) string {
+	return filepath.Join(sysfs, "bus", "usb", "devices", port)
+}
+
+func pciDriver(addr string) string {
+	link, err := os.Readlink(filepath.Join(pciDir(addr), "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(link)
+}
+
+func readDeviceID(dir, vendorFile, deviceFile string) (string, error) {
+	var id []string
+	for _, file := range []string{vendorFile, deviceFile} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return "", fmt.Errorf("failed to read device ID: %v", err)
+		}
+		id = append(id, strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	}
+	return strings.Join(id, ":"), nil
+}
+
+func deviceAllowed(id string, allow []string) bool {
+	for _, pattern := range allow {
+		if ok, _ := filepath.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeSysfs(file, data string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestCheckPassthrough(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(old string) { sysfs = old }(sysfs)
+	sysfs = dir
+	files := map[string]string{
+		// GPU with its audio function and a bridge in the same IOMMU group.
+		"bus/pci/devices/0000:03:00.0/vendor": "0x10de",
+		"bus/pci/devices/0000:03:00.0/device": "0x1eb8",
+		"bus/pci/devices/0000:03:00.1/vendor": "0x10de",
+		"bus/pci/devices/0000:03:00.1/device": "0x10f8",
+		"bus/pci/devices/0000:00:01.0/vendor": "0x8086",
+		"bus/pci/devices/0000:00:01.0/device": "0x1901",
+		"bus/pci/devices/0000:00:01.0/class":  "0x060400",
+		// NVMe in its own group.
+		"bus/pci/devices/0000:04:00.0/vendor": "0x144d",
+		"bus/pci/devices/0000:04:00.0/device": "0xa808",
+		"bus/usb/devices/1-2.1/idVendor":      "0781",
+		"bus/usb/devices/1-2.1/idProduct":     "5567",
+	}
+	for file, data := range files {
+		file = filepath.Join(dir, file)
+		if err := osutil.MkdirAll(filepath.Dir(file)); err != nil {
+			t.Fatal(err)
+		}
+		if err := osutil.WriteFile(file, []byte(data+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	groups := map[string][]string{
+		"1": {"0000:03:00.0", "0000:03:00.1", "0000:00:01.0"},
+		"2": {"0000:04:00.0"},
+	}
+	for group, devs := range groups {
+		groupDir := filepath.Join(dir, "kernel", "iommu_groups", group, "devices")
+		if err := osutil.MkdirAll(groupDir); err != nil {
+			t.Fatal(err)
+		}
+		for _, dev := range devs {
+			os.Symlink(filepath.Join(dir, "bus/pci/devices", dev), filepath.Join(groupDir, dev))
+			os.Symlink(filepath.Dir(groupDir), filepath.Join(dir, "bus/pci/devices", dev, "iommu_group"))
+		}
+	}
+	allow := []string{"10de:*", "144d:a808", "0781:5567"}
+	tests := []struct {
+		devs []Passthrough
+		err  string
+	}{
+		{
+			devs: []Passthrough{
+				{PCI: []string{"0000:03:00.0", "0000:03:00.1"}},
+				{PCI: []string{"0000:04:00.0"}, USB: []string{"1-2.1"}},
+			},
+		},
+		{
+			devs: []Passthrough{{PCI: []string{"0000:03:00.0"}}},
+			err:  "shares IOMMU group with 0000:03:00.1",
+		},
+		{
+			devs: []Passthrough{{PCI: []string{"0000:00:01.0"}}},
+			err:  "not in the allowlist",
+		},
+		{
+			devs: []Passthrough{{PCI: []string{"0000:04:00.0"}}, {PCI: []string{"0000:04:00.0"}}},
+			err:  "passed through twice",
+		},
+		{
+			devs: []Passthrough{{PCI: []string{"0000:05:00.0"}}},
+			err:  "failed to read device ID",
+		},
+		{
+			devs: []Passthrough{{USB: []string{"1-"}}},
+			err:  "bad USB device",
+		},
+	}
+	for i, test := range tests {
+		err := CheckPassthrough(test.devs, allow)
+		if test.err == "" && err != nil {
+			t.Errorf("#%v: unexpected error: %v", i, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("#%v: got error %v, want %q", i, err, test.err)
+		}
+	}
+}
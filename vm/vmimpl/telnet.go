@@ -0,0 +1,84 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"bufio"
+	"io"
+	"net"
+)
+
+// NewTelnetConn returns a reader of the console stream of a telnet connection
+// (e.g. a console server or a network serial port of a VM) with telnet protocol commands stripped.
+// We don't negotiate any options, the other side is supposed to send raw console output by default.
+func NewTelnetConn(conn net.Conn) io.ReadCloser {
+	return &telnetConn{conn, bufio.NewReader(conn)}
+}
+
+type telnetConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+const (
+	telnetIAC = 255
+	telnetSB  = 250
+	telnetSE  = 240
+	telnetMin = 251 // WILL, WONT, DO, DONT take an option argument
+)
+
+func (tc *telnetConn) Read(buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		if n != 0 && tc.r.Buffered() == 0 {
+			break
+		}
+		c, err := tc.r.ReadByte()
+		if err != nil {
+			if n != 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if c != telnetIAC {
+			buf[n] = c
+			n++
+			continue
+		}
+		if err := tc.skipCommand(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (tc *telnetConn) skipCommand() error {
+	cmd, err := tc.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cmd == telnetIAC:
+		// Escaped 0xff data byte, not interesting for console output.
+	case cmd >= telnetMin:
+		_, err = tc.r.ReadByte()
+	case cmd == telnetSB:
+		// Skip subnegotiation till IAC SE.
+		for prev := byte(0); ; {
+			c, err := tc.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if prev == telnetIAC && c == telnetSE {
+				break
+			}
+			prev = c
+		}
+	}
+	return err
+}
+
+func (tc *telnetConn) Close() error {
+	return tc.conn.Close()
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmimpl
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestTelnetConn(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"hello\n", "hello\n"},
+		// IAC DO ECHO, IAC WILL SGA.
+		{"\xff\xfd\x01a\xff\xfb\x03b\n", "ab\n"},
+		// Subnegotiation and escaped IAC.
+		{"x\xff\xfa\x18\x01\xff\xf0y\xff\xffz", "xyz"},
+		{"\xff\xfe", ""},
+	}
+	for i, test := range tests {
+		server, client := net.Pipe()
+		go func() {
+			server.Write([]byte(test.in))
+			server.Close()
+		}()
+		tc := NewTelnetConn(client)
+		out, err := ioutil.ReadAll(tc)
+		tc.Close()
+		if err != nil {
+			t.Fatalf("#%v: read failed: %v", i, err)
+		}
+		if string(out) != test.out {
+			t.Errorf("#%v: got %q, want %q", i, out, test.out)
+		}
+	}
+}
@@ -56,6 +56,27 @@ type Instance interface {
 	Close()
 }
 
+// CrashDumper is an optional interface for instances that save state of the crashed VM
+// (memory dump, registers, etc) during Diagnose for offline analysis.
+type CrashDumper interface {
+	// CrashDump returns the directory with files saved during Diagnose,
+	// or an empty string if nothing was saved. The directory is removed on Close.
+	CrashDump() string
+}
+
+// PstoreReader is an optional interface for instances that recover kernel log of the previous boot
+// of the same machine saved in pstore (e.g. by ramoops). If the machine crashed with all consoles dead
+// (e.g. a physical machine without serial console), this is the only way to get the crash report.
+type PstoreReader interface {
+	// PstoreLog returns the log recovered during Create, or nil.
+	PstoreLog() []byte
+}
+
+// PstoreCommand prints and removes pstore crash records, so that they are recovered only once.
+// pstore is not mounted by default on some distros, so mount it first.
+const PstoreCommand = "mount -t pstore pstore /sys/fs/pstore 2>/dev/null; " +
+	"cat /sys/fs/pstore/dmesg-* 2>/dev/null; rm -f /sys/fs/pstore/dmesg-*"
+
 // Env contains global constant parameters for a pool of VMs.
 type Env struct {
 	// Unique name
@@ -69,6 +90,13 @@ type Env struct {
 	SSHUser string
 	Debug   bool
 	Config  []byte // json-serialized VM-type-specific config
+	// Slowdown is the factor by which boot and ssh timeouts are scaled for slow kernels (>= 1).
+	Slowdown int
+}
+
+// Timeout scales the timeout according to Slowdown.
+func (env *Env) Timeout(timeout time.Duration) time.Duration {
+	return timeout * time.Duration(env.Slowdown)
 }
 
 // BootError is returned by Pool.Create when VM does not boot.
@@ -0,0 +1,455 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package vmware implements VMs running on VMware ESXi/vCenter.
+// VMs are managed with govc (the govmomi command line client), which needs to be installed.
+// Each VM is cloned from a template VM (optionally as a linked clone of a template snapshot),
+// the manager image is not used: the template must be bootable with sshd and VMware tools
+// (to report the VM IP) set up, and must provide the target kernel.
+//
+// Kernel output is collected through a network backed virtual serial port: the ESXi host
+// connects to a telnet listener of the manager (the manager acts as the serial port concentrator),
+// so host_addr must be reachable from ESXi and the "VM serial port connected over network"
+// firewall rule must be enabled on ESXi.
+//
+// If snapshot is enabled, a memory snapshot is taken after the first successful boot of each VM
+// and the following VMs are created by reverting to it instead of booting from scratch.
+package vmware
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("vmware", ctor, true)
+}
+
+type Config struct {
+	Count            int    `json:"count"`             // number of VMs to run in parallel
+	URL              string `json:"url"`               // ESXi/vCenter SDK URL (GOVC_URL), can contain credentials
+	Insecure         bool   `json:"insecure"`          // skip verification of the server certificate
+	Datacenter       string `json:"datacenter"`        // datacenter (optional)
+	Datastore        string `json:"datastore"`         // datastore for clones (optional, defaults to the template one)
+	ResourcePool     string `json:"resource_pool"`     // resource pool for clones (optional)
+	Folder           string `json:"folder"`            // inventory folder for clones (optional)
+	Network          string `json:"network"`           // network to attach clones to (optional)
+	Template         string `json:"template"`          // template VM name or inventory path
+	TemplateSnapshot string `json:"template_snapshot"` // create linked clones of this template snapshot (optional)
+	HostAddr         string `json:"host_addr"`         // manager address reachable from ESXi and VMs
+	Snapshot         bool   `json:"snapshot"`          // revert VMs to a snapshot taken after the first boot
+	CPU              int    `json:"cpu"`               // number of VM CPUs (the template value by default)
+	Mem              int    `json:"mem"`               // amount of VM memory in MiB (the template value by default)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+
+	mu        sync.Mutex
+	serial    map[int]net.Listener // per-index serial port listeners (fixed ports survive reverts)
+	snapshots map[int]bool
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	debug   bool
+	index   int
+	name    string
+	workdir string
+	ip      string
+	console io.ReadCloser
+	merger  *vmimpl.OutputMerger
+}
+
+// snapshotName is the name of the snapshot taken after the first boot.
+const snapshotName = "syzkaller"
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	if env.Name == "" {
+		return nil, fmt.Errorf("config param name is empty (required for vmware)")
+	}
+	cfg := &Config{
+		Count: 1,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse vmware vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 1000 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 1000]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("config param url is empty")
+	}
+	if cfg.Template == "" {
+		return nil, fmt.Errorf("config param template is empty")
+	}
+	if cfg.HostAddr == "" {
+		return nil, fmt.Errorf("config param host_addr is empty")
+	}
+	if cfg.CPU < 0 || cfg.CPU > 1024 {
+		return nil, fmt.Errorf("bad vmware cpu: %v, want [1-1024]", cfg.CPU)
+	}
+	if cfg.Mem != 0 && (cfg.Mem < 128 || cfg.Mem > 1048576) {
+		return nil, fmt.Errorf("bad vmware mem: %v, want [128-1048576]", cfg.Mem)
+	}
+	if env.SSHKey == "" {
+		return nil, fmt.Errorf("vmware requires ssh key")
+	}
+	pool := &Pool{
+		env:       env,
+		cfg:       cfg,
+		serial:    make(map[int]net.Listener),
+		snapshots: make(map[int]bool),
+	}
+	if _, err := pool.govc("about"); err != nil {
+		return nil, err
+	}
+	// VMs left from previous runs may be based on an old template.
+	for i := 0; i < cfg.Count; i++ {
+		pool.govc("vm.destroy", pool.vmName(i))
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		index:   index,
+		name:    pool.vmName(index),
+		workdir: workdir,
+	}
+	if pool.hasSnapshot(index) {
+		err := inst.restore()
+		if err == nil {
+			return inst, nil
+		}
+		log.Logf(0, "%v: failed to revert to snapshot, booting from scratch: %v", inst.name, err)
+		inst.Close()
+		pool.setSnapshot(index, false)
+	}
+	pool.govc("vm.destroy", inst.name)
+	if err := inst.boot(); err != nil {
+		inst.Close()
+		pool.govc("vm.destroy", inst.name)
+		return nil, err
+	}
+	if pool.cfg.Snapshot {
+		// Not fatal, the next VM will just boot from scratch again.
+		if _, err := pool.govc("snapshot.create", "-vm", inst.name, "-m=true", snapshotName); err != nil {
+			log.Logf(0, "%v: failed to create snapshot: %v", inst.name, err)
+		} else {
+			pool.setSnapshot(index, true)
+		}
+	}
+	return inst, nil
+}
+
+func (pool *Pool) vmName(index int) string {
+	return fmt.Sprintf("%v-%v", pool.env.Name, index)
+}
+
+func (inst *instance) boot() error {
+	args := []string{"vm.clone", "-vm", inst.cfg.Template, "-on=false"}
+	if inst.cfg.TemplateSnapshot != "" {
+		args = append(args, "-link", "-snapshot", inst.cfg.TemplateSnapshot)
+	}
+	if inst.cfg.Datastore != "" {
+		args = append(args, "-ds", inst.cfg.Datastore)
+	}
+	if inst.cfg.ResourcePool != "" {
+		args = append(args, "-pool", inst.cfg.ResourcePool)
+	}
+	if inst.cfg.Folder != "" {
+		args = append(args, "-folder", inst.cfg.Folder)
+	}
+	if inst.cfg.Network != "" {
+		args = append(args, "-net", inst.cfg.Network)
+	}
+	if inst.cfg.CPU != 0 {
+		args = append(args, "-c", strconv.Itoa(inst.cfg.CPU))
+	}
+	if inst.cfg.Mem != 0 {
+		args = append(args, "-m", strconv.Itoa(inst.cfg.Mem))
+	}
+	if _, err := inst.pool.govc(append(args, inst.name)...); err != nil {
+		return err
+	}
+	listener, err := inst.pool.serialListener(inst.index)
+	if err != nil {
+		return err
+	}
+	out, err := inst.pool.govc("device.serial.add", "-vm", inst.name)
+	if err != nil {
+		return err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if _, err := inst.pool.govc("device.serial.connect", "-vm", inst.name,
+		"-device", strings.TrimSpace(string(out)),
+		"-client", fmt.Sprintf("telnet://%v:%v", inst.cfg.HostAddr, port)); err != nil {
+		return err
+	}
+	if _, err := inst.pool.govc("vm.power", "-on", inst.name); err != nil {
+		return err
+	}
+	if err := inst.connectConsole(listener); err != nil {
+		return err
+	}
+	return inst.waitForBoot(10 * time.Minute)
+}
+
+func (inst *instance) restore() error {
+	listener, err := inst.pool.serialListener(inst.index)
+	if err != nil {
+		return err
+	}
+	// The snapshot contains the memory, so the VM is powered on after revert.
+	if _, err := inst.pool.govc("snapshot.revert", "-vm", inst.name, snapshotName); err != nil {
+		return err
+	}
+	if err := inst.connectConsole(listener); err != nil {
+		return err
+	}
+	return inst.waitForBoot(time.Minute)
+}
+
+// connectConsole waits for ESXi to connect to the serial port listener.
+func (inst *instance) connectConsole(listener net.Listener) error {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		conn, err := listener.Accept()
+		res <- result{conn, err}
+	}()
+	select {
+	case r := <-res:
+		if r.err != nil {
+			return fmt.Errorf("failed to accept serial port connection: %v", r.err)
+		}
+		inst.console = vmimpl.NewTelnetConn(r.conn)
+	case <-time.After(3 * time.Minute):
+		// Unblock Accept, the listener is recreated on the next boot.
+		inst.pool.closeSerialListener(inst.index)
+		<-res
+		return fmt.Errorf("ESXi did not connect to the serial port at %v", listener.Addr())
+	}
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", inst.console)
+	return nil
+}
+
+func (inst *instance) waitForBoot(timeout time.Duration) error {
+	timeout = inst.pool.env.Timeout(timeout)
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	err := inst.waitForIP(timeout)
+	if err == nil {
+		err = vmimpl.WaitForSSH(inst.debug, timeout, inst.ip, inst.pool.env.SSHKey,
+			inst.pool.env.SSHUser, inst.pool.env.OS, 22, inst.merger.Err)
+	}
+	bootOutputStop <- true
+	<-bootOutputStop
+	if err != nil {
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	return nil
+}
+
+func (inst *instance) waitForIP(timeout time.Duration) error {
+	out, err := inst.pool.govc("vm.ip", "-v4", "-wait", timeout.String(), inst.name)
+	if err != nil {
+		return fmt.Errorf("failed to get VM IP: %v", err)
+	}
+	ip := strings.TrimSpace(string(out))
+	if pos := strings.IndexByte(ip, '\n'); pos != -1 {
+		ip = ip[:pos]
+	}
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("bad VM IP %q", ip)
+	}
+	inst.ip = ip
+	return nil
+}
+
+func (inst *instance) Close() {
+	if inst.pool.hasSnapshot(inst.index) {
+		// Keep the VM, the snapshot lives in it.
+		inst.pool.govc("vm.power", "-off", "-force", inst.name)
+	} else {
+		inst.pool.govc("vm.destroy", inst.name)
+	}
+	if inst.console != nil {
+		inst.console.Close()
+	}
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.cfg.HostAddr, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	vmDst := filepath.Join("/", filepath.Base(hostSrc))
+	args := append(vmimpl.SCPArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		hostSrc, inst.pool.env.SSHUser+"@"+inst.ip+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	args := append(vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		inst.pool.env.SSHUser+"@"+inst.ip, "cd / && "+command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+func (pool *Pool) serialListener(index int) (net.Listener, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if listener := pool.serial[index]; listener != nil {
+		return listener, nil
+	}
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for serial port connections: %v", err)
+	}
+	pool.serial[index] = listener
+	return listener, nil
+}
+
+func (pool *Pool) closeSerialListener(index int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if listener := pool.serial[index]; listener != nil {
+		listener.Close()
+		delete(pool.serial, index)
+		// The snapshot refers to the old port.
+		delete(pool.snapshots, index)
+	}
+}
+
+func (pool *Pool) hasSnapshot(index int) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.snapshots[index]
+}
+
+func (pool *Pool) setSnapshot(index int, ok bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.snapshots[index] = ok
+}
+
+func (pool *Pool) govc(args ...string) ([]byte, error) {
+	if pool.env.Debug {
+		log.Logf(0, "running command: govc %#v", args)
+	}
+	cmd := osutil.Command("govc", args...)
+	cmd.Env = append(os.Environ(), "GOVC_URL="+pool.cfg.URL)
+	if pool.cfg.Insecure {
+		cmd.Env = append(cmd.Env, "GOVC_INSECURE=1")
+	}
+	if pool.cfg.Datacenter != "" {
+		cmd.Env = append(cmd.Env, "GOVC_DATACENTER="+pool.cfg.Datacenter)
+	}
+	// Cloning of a full VM can take a while.
+	return osutil.Run(30*time.Minute, cmd)
+}
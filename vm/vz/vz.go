@@ -0,0 +1,297 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package vz implements macOS VMs running on Apple Virtualization.framework.
+// syz-manager needs to run on the macOS host itself, VMs are managed with
+// the tart command line tool (https://github.com/cirruslabs/tart) which wraps Virtualization.framework.
+// Each VM is an APFS clone of the image VM (tart clone is copy-on-write),
+// so the image is never modified. The image VM must boot a development kernel
+// with serial console enabled (boot-args "serial=3") and have sshd set up.
+// macOS license allows at most 2 macOS guests per host, so count is limited to 2.
+package vz
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("vz", ctor, true)
+}
+
+type Config struct {
+	Count    int    `json:"count"`     // number of VMs to run in parallel (1 by default, at most 2)
+	Tart     string `json:"tart"`      // tart binary name ("tart" by default)
+	HostAddr string `json:"host_addr"` // manager address reachable from VMs (192.168.64.1 by default)
+	CPU      int    `json:"cpu"`       // number of VM CPUs (2 by default)
+	Mem      int    `json:"mem"`       // amount of VM memory in MiB (4096 by default)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+}
+
+type instance struct {
+	pool    *Pool
+	cfg     *Config
+	debug   bool
+	name    string
+	workdir string
+	ip      string
+	vm      *exec.Cmd
+	merger  *vmimpl.OutputMerger
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	if runtime.GOOS != "darwin" {
+		return nil, fmt.Errorf("vz VMs can be used only on macOS hosts")
+	}
+	if env.Name == "" {
+		return nil, fmt.Errorf("config param name is empty (required for vz)")
+	}
+	cfg := &Config{
+		Count:    1,
+		Tart:     "tart",
+		HostAddr: "192.168.64.1",
+		CPU:      2,
+		Mem:      4096,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse vz vm config: %v", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 2 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 2]", cfg.Count)
+	}
+	if env.Debug && cfg.Count > 1 {
+		log.Logf(0, "limiting number of VMs from %v to 1 in debug mode", cfg.Count)
+		cfg.Count = 1
+	}
+	if cfg.CPU < 1 || cfg.CPU > 64 {
+		return nil, fmt.Errorf("bad vz cpu: %v, want [1-64]", cfg.CPU)
+	}
+	if cfg.Mem < 2048 || cfg.Mem > 1048576 {
+		return nil, fmt.Errorf("bad vz mem: %v, want [2048-1048576]", cfg.Mem)
+	}
+	if env.Image == "" {
+		return nil, fmt.Errorf("config param image is empty (required for vz)")
+	}
+	if env.SSHKey == "" {
+		return nil, fmt.Errorf("vz requires ssh key")
+	}
+	pool := &Pool{
+		env: env,
+		cfg: cfg,
+	}
+	if _, err := pool.tart("get", env.Image); err != nil {
+		return nil, fmt.Errorf("failed to find image VM %v: %v", env.Image, err)
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		pool:    pool,
+		cfg:     pool.cfg,
+		debug:   pool.env.Debug,
+		name:    fmt.Sprintf("%v-%v", pool.env.Name, index),
+		workdir: workdir,
+	}
+	// VMs left from previous runs may be based on an old image.
+	inst.destroy()
+	if err := inst.boot(); err != nil {
+		inst.Close()
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (inst *instance) boot() error {
+	if _, err := inst.pool.tart("clone", inst.pool.env.Image, inst.name); err != nil {
+		return err
+	}
+	if _, err := inst.pool.tart("set", inst.name, "--cpu", fmt.Sprint(inst.cfg.CPU),
+		"--memory", fmt.Sprint(inst.cfg.Mem)); err != nil {
+		return err
+	}
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return err
+	}
+	// tart run does not exit while the VM is running, with --serial it proxies
+	// the VM serial port to its stdout.
+	inst.vm = osutil.Command(inst.cfg.Tart, "run", inst.name, "--no-graphics", "--serial")
+	inst.vm.Dir = inst.workdir
+	inst.vm.Stdout = wpipe
+	inst.vm.Stderr = wpipe
+	if err := inst.vm.Start(); err != nil {
+		rpipe.Close()
+		wpipe.Close()
+		return fmt.Errorf("failed to start %v run: %v", inst.cfg.Tart, err)
+	}
+	wpipe.Close()
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.Add("console", rpipe)
+	return inst.waitForBoot(10 * time.Minute)
+}
+
+func (inst *instance) waitForBoot(timeout time.Duration) error {
+	timeout = inst.pool.env.Timeout(timeout)
+	var bootOutput []byte
+	bootOutputStop := make(chan bool)
+	go func() {
+		for {
+			select {
+			case out := <-inst.merger.Output:
+				bootOutput = append(bootOutput, out...)
+			case <-bootOutputStop:
+				close(bootOutputStop)
+				return
+			}
+		}
+	}()
+	err := inst.waitForIP(timeout)
+	if err == nil {
+		err = vmimpl.WaitForSSH(inst.debug, timeout, inst.ip, inst.pool.env.SSHKey,
+			inst.pool.env.SSHUser, inst.pool.env.OS, 22, inst.merger.Err)
+	}
+	bootOutputStop <- true
+	<-bootOutputStop
+	if err != nil {
+		return vmimpl.MakeBootError(err, bootOutput)
+	}
+	return nil
+}
+
+// waitForIP waits for the VM to get a DHCP lease from the Virtualization.framework NAT.
+func (inst *instance) waitForIP(timeout time.Duration) error {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(5 * time.Second) {
+		out, err := inst.pool.tart("ip", inst.name)
+		if err != nil {
+			continue
+		}
+		if ip := strings.TrimSpace(string(out)); net.ParseIP(ip) != nil {
+			inst.ip = ip
+			return nil
+		}
+	}
+	return fmt.Errorf("VM did not get an IP address in %v", timeout)
+}
+
+func (inst *instance) destroy() {
+	inst.pool.tart("stop", inst.name)
+	inst.pool.tart("delete", inst.name)
+}
+
+func (inst *instance) Close() {
+	if inst.vm != nil {
+		inst.vm.Process.Kill()
+		inst.vm.Wait()
+	}
+	inst.destroy()
+	if inst.merger != nil {
+		inst.merger.Wait()
+	}
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	return fmt.Sprintf("%v:%v", inst.cfg.HostAddr, port), nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	// The root file system is read-only on macOS, so copy into the user home dir.
+	vmDst := "./" + filepath.Base(hostSrc)
+	args := append(vmimpl.SCPArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		hostSrc, inst.pool.env.SSHUser+"@"+inst.ip+":"+vmDst)
+	if inst.debug {
+		log.Logf(0, "running command: scp %#v", args)
+	}
+	_, err := osutil.RunCmd(3*time.Minute, "", "scp", args...)
+	if err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.merger.Add("ssh", rpipe)
+
+	args := append(vmimpl.SSHArgs(inst.debug, inst.pool.env.SSHKey, 22),
+		inst.pool.env.SSHUser+"@"+inst.ip, command)
+	if inst.debug {
+		log.Logf(0, "running command: ssh %#v", args)
+	}
+	cmd := osutil.Command("ssh", args...)
+	cmd.Dir = inst.workdir
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	if err := cmd.Start(); err != nil {
+		wpipe.Close()
+		return nil, nil, err
+	}
+	wpipe.Close()
+	errc := make(chan error, 1)
+	signal := func(err error) {
+		select {
+		case errc <- err:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-time.After(timeout):
+			signal(vmimpl.ErrTimeout)
+		case <-stop:
+			signal(vmimpl.ErrTimeout)
+		case err := <-inst.merger.Err:
+			cmd.Process.Kill()
+			if cmdErr := cmd.Wait(); cmdErr == nil {
+				// If the command exited successfully, we got EOF error from merger.
+				// But in this case no error has happened and the EOF is expected.
+				err = nil
+			}
+			signal(err)
+			return
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return inst.merger.Output, errc, nil
+}
+
+func (inst *instance) Diagnose() ([]byte, bool) {
+	return nil, false
+}
+
+func (pool *Pool) tart(args ...string) ([]byte, error) {
+	if pool.env.Debug {
+		log.Logf(0, "running command: %v %#v", pool.cfg.Tart, args)
+	}
+	return osutil.RunCmd(10*time.Minute, "", pool.cfg.Tart, args...)
+}